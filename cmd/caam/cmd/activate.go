@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/refresh"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
@@ -30,7 +33,10 @@ type activateOutput struct {
 	AutoBackup      string                  `json:"auto_backup,omitempty"`
 	Refreshed       bool                    `json:"refreshed,omitempty"`
 	Rotation        *activateRotationResult `json:"rotation,omitempty"`
+	LeaseExpiresAt  string                  `json:"lease_expires_at,omitempty"`
+	BrowserSession  string                  `json:"browser_session,omitempty"`
 	Error           string                  `json:"error,omitempty"`
+	ErrorCode       caamerr.Code            `json:"error_code,omitempty"`
 }
 
 type activateRotationResult struct {
@@ -58,7 +64,9 @@ Examples:
   caam activate codex
   caam activate claude personal-max
   caam activate gemini team-ultra
+  caam activate gemini-cli team-ultra     # Only the standalone CLI sign-in
   caam activate claude --auto
+  caam activate --from-env                # In CI: use CAAM_AUTH_* variables
 
 The --auto flag enables smart profile rotation, which selects the best profile
 based on health status, cooldown state, and usage patterns. Three algorithms
@@ -68,8 +76,17 @@ are available (configured in config.yaml):
   round_robin - Sequential rotation through profiles
   random      - Random selection
 
+--from-env writes the live auth files from the CAAM_AUTH_<TOOL> variables
+made by 'caam export secret', for CI containers and pods without a vault.
+With a tool, only that tool's variable is used.
+
 After activating, just run the tool normally - it will use the new account.`,
-	Args: cobra.RangeArgs(1, 2),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if fromEnv, _ := cmd.Flags().GetBool("from-env"); fromEnv {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.RangeArgs(1, 2)(cmd, args)
+	},
 	RunE: runActivate,
 }
 
@@ -78,9 +95,13 @@ func init() {
 	activateCmd.Flags().Bool("force", false, "activate even if the profile is in cooldown")
 	activateCmd.Flags().Bool("auto", false, "auto-select profile using rotation algorithm")
 	activateCmd.Flags().Bool("json", false, "output as JSON")
+	activateCmd.Flags().Bool("from-env", false, "write live auth from CAAM_AUTH_* variables (see 'caam export secret')")
 }
 
 func runActivate(cmd *cobra.Command, args []string) error {
+	if fromEnv, _ := cmd.Flags().GetBool("from-env"); fromEnv {
+		return runActivateFromEnv(cmd, args)
+	}
 	tool := strings.ToLower(args[0])
 	autoSelect, _ := cmd.Flags().GetBool("auto")
 	jsonOutput, _ := cmd.Flags().GetBool("json")
@@ -95,6 +116,7 @@ func runActivate(cmd *cobra.Command, args []string) error {
 		if jsonOutput {
 			output.Success = false
 			output.Error = err.Error()
+			output.ErrorCode = caamerr.CodeOf(err)
 			enc := json.NewEncoder(cmd.OutOrStdout())
 			enc.SetIndent("", "  ")
 			_ = enc.Encode(output)
@@ -107,10 +129,14 @@ func runActivate(cmd *cobra.Command, args []string) error {
 		return emitJSONError(fmt.Errorf("--auto cannot be used when a profile name is provided"))
 	}
 
-	getFileSet, ok := tools[tool]
+	getFileSet, ok := lookupToolFileSet(tool)
 	if !ok {
-		return emitJSONError(fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool))
+		return emitJSONError(caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini, gemini-cli, gemini-code-assist, gemini-adc)", tool))
 	}
+	// Sub-providers restore only their own files but share the parent's
+	// profiles, cooldowns, and history.
+	tool = authfile.ParentProvider(tool)
+	output.Tool = tool
 
 	// Ensure vault is initialized before using it
 	if vault == nil {
@@ -134,7 +160,7 @@ func runActivate(cmd *cobra.Command, args []string) error {
 		spmCfg = config.DefaultSPMConfig()
 	}
 
-	needDB := spmCfg.Analytics.Enabled || spmCfg.Stealth.Cooldown.Enabled || spmCfg.Stealth.Rotation.Enabled || autoSelect
+	needDB := logsActivations(spmCfg) || spmCfg.Stealth.Cooldown.Enabled || spmCfg.Stealth.Rotation.Enabled || autoSelect
 	var db *caamdb.DB
 	if needDB {
 		db, err = getDB()
@@ -181,7 +207,7 @@ func runActivate(cmd *cobra.Command, args []string) error {
 		if autoSelect {
 			profiles, err := vault.List(tool)
 			if err != nil {
-				return emitJSONError(fmt.Errorf("list profiles: %w", err))
+				return emitJSONError(caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err))
 			}
 
 			selection, err = selectProfileWithRotation(tool, profiles, previousProfile, spmCfg, db)
@@ -270,59 +296,33 @@ func runActivate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Step 1: Refresh if needed
-	refreshed := refreshIfNeeded(cmd.Context(), tool, profileName, jsonOutput)
-	output.Refreshed = refreshed
-
-	// Smart auto-backup before switch (based on safety config)
-	backupMode := strings.TrimSpace(spmCfg.Safety.AutoBackupBeforeSwitch)
-	if backupMode == "" {
-		backupMode = "smart" // Default
-	}
-
-	// Check if --backup-current flag overrides config
+	// Run the pre-activate hook, take the profile's lease in a shared
+	// namespace, and save the live auth before it is replaced (based on
+	// safety config). The lease is given back if the switch doesn't happen.
 	backupFirst, _ := cmd.Flags().GetBool("backup-current")
-	if backupFirst {
-		backupMode = "always"
+	act, err := beginActivation(fileSet, profileName, "activate", activationOptions{db: db, spmCfg: spmCfg, forceBackup: backupFirst})
+	if err != nil {
+		return emitJSONError(err)
 	}
-
-	if backupMode != "never" {
-		shouldBackup := false
-		currentProfile, _ := vault.ActiveProfile(fileSet)
-
-		switch backupMode {
-		case "always":
-			// Always backup if there are auth files and we're switching to a different profile
-			shouldBackup = currentProfile != profileName
-		case "smart":
-			// Backup only if current state doesn't match any vault profile (would be lost)
-			shouldBackup = currentProfile == "" && authfile.HasAuthFiles(fileSet)
-		}
-
-		if shouldBackup {
-			backupName, err := vault.BackupCurrent(fileSet)
-			if err != nil {
-				if !jsonOutput {
-					fmt.Printf("Warning: could not auto-backup current state: %v\n", err)
-				}
-			} else if backupName != "" {
-				output.AutoBackup = backupName
-				if !jsonOutput {
-					fmt.Printf("Auto-backed up current state to %s\n", backupName)
-				}
-
-				// Rotate old backups if limit is set
-				if spmCfg.Safety.MaxAutoBackups > 0 {
-					if err := vault.RotateAutoBackups(tool, spmCfg.Safety.MaxAutoBackups); err != nil {
-						if !jsonOutput {
-							fmt.Printf("Warning: could not rotate old backups: %v\n", err)
-						}
-					}
-				}
-			}
+	activated := false
+	defer func() { act.finish(activated) }()
+	if act.Lease != nil {
+		output.LeaseExpiresAt = act.Lease.ExpiresAt.Format(time.RFC3339)
+	}
+	if act.BackupErr != nil && !jsonOutput {
+		fmt.Printf("Warning: could not auto-backup current state: %v\n", act.BackupErr)
+	}
+	if act.AutoBackup != "" {
+		output.AutoBackup = act.AutoBackup
+		if !jsonOutput {
+			fmt.Printf("Auto-backed up current state to %s\n", act.AutoBackup)
 		}
 	}
 
+	// Refresh if needed
+	refreshed := refreshIfNeeded(cmd.Context(), tool, profileName, jsonOutput)
+	output.Refreshed = refreshed
+
 	// Stealth: optional delay before the actual switch happens.
 	// Skip stealth delay in JSON mode as it's for interactive use
 	if spmCfg.Stealth.SwitchDelay.Enabled && !jsonOutput {
@@ -366,10 +366,12 @@ func runActivate(cmd *cobra.Command, args []string) error {
 
 	// Restore from vault
 	if err := vault.Restore(fileSet, profileName); err != nil {
-		return emitJSONError(fmt.Errorf("activate failed: %w", err))
+		return emitJSONError(caamerr.Errorf(caamerr.ActivateFailed, "activate failed: %w", err))
 	}
+	activated = true
+	events.PublishActivated(tool, profileName, "activate")
 
-	if spmCfg.Analytics.Enabled && db != nil {
+	if logsActivations(spmCfg) && db != nil {
 		_ = db.LogEvent(caamdb.Event{
 			Type:        caamdb.EventActivate,
 			Provider:    tool,
@@ -381,6 +383,7 @@ func runActivate(cmd *cobra.Command, args []string) error {
 		})
 	}
 
+	output.BrowserSession = activateBrowserSession(tool, profileName)
 	output.Profile = profileName
 	output.Success = true
 
@@ -391,6 +394,12 @@ func runActivate(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("Activated %s profile '%s'\n", tool, profileName)
+	if act.Lease != nil {
+		fmt.Printf("  Leased to %s until %s\n", act.Lease.Holder, act.Lease.ExpiresAt.Local().Format("15:04 Jan 2"))
+	}
+	if output.BrowserSession != "" {
+		fmt.Printf("  Browser: %s\n", output.BrowserSession)
+	}
 	fmt.Printf("  Run '%s' to start using this account\n", tool)
 	return nil
 }
@@ -476,7 +485,7 @@ func refreshIfNeeded(ctx context.Context, provider, profile string, quiet bool)
 
 func selectProfileWithRotation(tool string, profiles []string, currentProfile string, spmCfg *config.SPMConfig, db *caamdb.DB) (*rotation.Result, error) {
 	if len(profiles) == 0 {
-		return nil, fmt.Errorf("no profiles found for %s; create one with 'caam backup %s <name>'", tool, tool)
+		return nil, caamerr.Errorf(caamerr.NoProfiles, "no profiles found for %s; create one with 'caam backup %s <name>'", tool, tool)
 	}
 
 	primePlanTypes(tool, profiles)
@@ -531,3 +540,72 @@ func resolveProfileName(tool, input string, profiles []string, quiet bool) strin
 	// No match found, return original (will fail later with proper error)
 	return input
 }
+
+// runActivateFromEnv writes live auth from the secrets in CAAM_AUTH_*
+// variables, or only the given tool's. It needs no vault, so it works in a
+// fresh container.
+func runActivateFromEnv(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	var outputs []activateOutput
+
+	fail := func(err error) error {
+		if jsonOutput {
+			outputs = append(outputs, activateOutput{Error: err.Error(), ErrorCode: caamerr.CodeOf(err)})
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(outputs)
+			return nil // Error already in JSON
+		}
+		return err
+	}
+
+	var names []string
+	if len(args) > 0 {
+		names = []string{authfile.EnvSecretName(strings.ToLower(args[0]))}
+	} else {
+		for _, kv := range os.Environ() {
+			if name, _, _ := strings.Cut(kv, "="); strings.HasPrefix(name, authfile.EnvSecretPrefix) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return fail(caamerr.Errorf(caamerr.NoAuth, "no %s* variables are set; create one with 'caam export secret'", authfile.EnvSecretPrefix))
+	}
+
+	for _, name := range names {
+		value := os.Getenv(name)
+		if value == "" {
+			return fail(caamerr.Errorf(caamerr.NoAuth, "%s is not set; create it with 'caam export secret'", name))
+		}
+		secret, err := authfile.DecodeEnvSecret(value)
+		if err != nil {
+			return fail(caamerr.Errorf(caamerr.InvalidArgs, "%s: %w", name, err))
+		}
+		if len(args) > 0 && !strings.EqualFold(secret.Provider, args[0]) {
+			return fail(caamerr.Errorf(caamerr.InvalidArgs, "%s holds a %s profile, not %s", name, secret.Provider, args[0]))
+		}
+		getFileSet, ok := lookupToolFileSet(secret.Provider)
+		if !ok {
+			return fail(caamerr.Errorf(caamerr.InvalidProvider, "%s: unknown tool %s", name, secret.Provider))
+		}
+		if err := secret.Restore(getFileSet()); err != nil {
+			return fail(caamerr.Errorf(caamerr.ActivateFailed, "%s: activate failed: %w", name, err))
+		}
+
+		tool := authfile.ParentProvider(secret.Provider)
+		events.PublishActivated(tool, secret.Profile, "env")
+		outputs = append(outputs, activateOutput{Success: true, Tool: tool, Profile: secret.Profile, Source: name})
+		if !jsonOutput {
+			fmt.Printf("Activated %s profile '%s' from %s\n", tool, secret.Profile, name)
+		}
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(outputs)
+	}
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 )
 
 func TestActivate_AutoBackupsOriginalOnFirstSwitch(t *testing.T) {
@@ -191,3 +193,105 @@ func TestActivate_AutoBackupsUnsavedStateBeforeSwitch(t *testing.T) {
 		t.Fatalf("auto-backup auth mismatch: got %q want %q", gotBackup, unsaved)
 	}
 }
+
+func TestAutoBackupSavesRefreshedAuthToLastProfile(t *testing.T) {
+	_, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for name, content := range map[string]string{
+		"a": `{"email":"a@example.com","access_token":"a-stale"}`,
+		"b": `{"email":"b@example.com","access_token":"b"}`,
+	} {
+		if err := os.MkdirAll(vault.ProfilePath("codex", name), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(vault.BackupPath("codex", name, "auth.json"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.AppendEvent(events.Event{Type: events.ProfileActivated, Provider: "codex", Profile: "a", Source: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The CLI refreshed a's token, so the live auth matches no profile.
+	authPath := filepath.Join(os.Getenv("CODEX_HOME"), "auth.json")
+	refreshed := `{"email":"a@example.com","access_token":"a-fresh"}`
+	if err := os.WriteFile(authPath, []byte(refreshed), 0600); err != nil {
+		t.Fatal(err)
+	}
+	result, failure := performRobotAct("activate", "codex", []string{"activate", "codex", "b"}, true)
+	if failure != nil {
+		t.Fatalf("activate failure = %+v", failure)
+	}
+	if result.AutoBackup != "a" {
+		t.Errorf("AutoBackup = %q, want a", result.AutoBackup)
+	}
+	if data, _ := os.ReadFile(vault.BackupPath("codex", "a", "auth.json")); string(data) != refreshed {
+		t.Errorf("vault a = %s, want the refreshed auth", data)
+	}
+
+	// A login to another account is never written over a's copy.
+	other := `{"email":"c@example.com","access_token":"c"}`
+	if err := os.WriteFile(authPath, []byte(other), 0600); err != nil {
+		t.Fatal(err)
+	}
+	result, failure = performRobotAct("activate", "codex", []string{"activate", "codex", "a"}, true)
+	if failure != nil {
+		t.Fatalf("activate failure = %+v", failure)
+	}
+	if !strings.HasPrefix(result.AutoBackup, "_backup_") {
+		t.Fatalf("AutoBackup = %q, want a _backup_ profile", result.AutoBackup)
+	}
+	if data, _ := os.ReadFile(vault.BackupPath("codex", result.AutoBackup, "auth.json")); string(data) != other {
+		t.Errorf("%s = %s, want the unsaved login", result.AutoBackup, data)
+	}
+	if data, _ := os.ReadFile(authPath); string(data) != refreshed {
+		t.Errorf("live auth = %s, want a's refreshed copy", data)
+	}
+}
+
+func TestSameAccount(t *testing.T) {
+	origVault := vault
+	t.Cleanup(func() { vault = origVault })
+	vault = authfile.NewVault(t.TempDir())
+	home := t.TempDir()
+
+	fileSet := authfile.AuthFileSet{
+		Tool:  "claude",
+		Files: []authfile.AuthFileSpec{{Path: filepath.Join(home, ".credentials.json")}},
+	}
+	tests := []struct {
+		name        string
+		saved, live string
+		want        bool
+	}{
+		{"same refresh token", `{"claudeAiOauth":{"accessToken":"old","refreshToken":"r1"}}`, `{"claudeAiOauth":{"accessToken":"new","refreshToken":"r1"}}`, true},
+		{"same account id", `{"tokens":{"account_id":"acct-1","refresh_token":"r1"}}`, `{"tokens":{"account_id":"acct-1","refresh_token":"r2"}}`, true},
+		{"email case differs", `{"email":"A@example.com"}`, `{"email":"a@example.com"}`, true},
+		{"nothing to compare", `{"claudeAiOauth":{"accessToken":"old","refreshToken":"r1"}}`, `{"claudeAiOauth":{"accessToken":"new","refreshToken":"r2"}}`, false},
+		{"different account", `{"email":"a@example.com","refresh_token":"r1"}`, `{"email":"b@example.com","refresh_token":"r1"}`, false},
+		{"no identity fields", `{"access_token":"a"}`, `{"access_token":"a"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.MkdirAll(vault.ProfilePath("claude", "a"), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(vault.BackupPath("claude", "a", ".credentials.json"), []byte(tt.saved), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(fileSet.Files[0].Path, []byte(tt.live), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if got := sameAccount(fileSet, "a"); got != tt.want {
+				t.Errorf("sameAccount = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/hooks"
+)
+
+// activationOptions tune beginActivation for one caller.
+type activationOptions struct {
+	// db is the open database, if the caller has one.
+	db *caamdb.DB
+	// spmCfg is the loaded config; nil loads it.
+	spmCfg *config.SPMConfig
+	// forceBackup saves the live auth even if safety config wouldn't.
+	forceBackup bool
+	// liveSaved skips the auto-backup because the caller has just saved
+	// the live auth itself, e.g. before editing the active profile.
+	liveSaved bool
+}
+
+// activation is a profile switch under way. Every path that replaces the
+// live auth with a vault profile goes through beginActivation before
+// vault.Restore and finish after it, directly or through vault.Activate.
+type activation struct {
+	provider   string
+	profile    string
+	previous   string
+	leaseStart time.Time
+
+	// Lease is the caller's lease on the profile in a shared namespace in
+	// lease mode, or nil.
+	Lease *caamdb.Lease
+
+	// AutoBackup is the profile the live auth was saved to, if any, and
+	// BackupErr why saving it failed. A failed backup doesn't stop the
+	// switch.
+	AutoBackup string
+	BackupErr  error
+}
+
+// beginActivation prepares to switch fileSet's tool to profile: the
+// pre-activate hook may refuse the switch, a profile someone else has
+// leased is refused, the caller's lease is taken if the namespace needs
+// one, and the live auth is saved before it is replaced.
+func beginActivation(fileSet authfile.AuthFileSet, profile, source string, opts activationOptions) (*activation, error) {
+	if err := runPreHook(hooks.PreActivate, fileSet.Tool, profile, source); err != nil {
+		return nil, err
+	}
+
+	a := &activation{provider: fileSet.Tool, profile: profile, leaseStart: time.Now().UTC()}
+	a.previous, _ = vault.ActiveProfile(fileSet)
+	lease, err := acquireActivationLease(opts.db, fileSet.Tool, profile)
+	if err != nil {
+		return nil, err
+	}
+	a.Lease = lease
+
+	spmCfg := opts.spmCfg
+	if spmCfg == nil {
+		spmCfg, _ = config.LoadSPMConfig()
+	}
+
+	if !opts.liveSaved {
+		a.AutoBackup, a.BackupErr = autoBackupBeforeActivate(fileSet, profile, spmCfg, opts.forceBackup)
+		if a.BackupErr != nil {
+			slog.Warn("auto-backup before activate failed", "provider", a.provider, "source", source, "error", a.BackupErr)
+		}
+	}
+	return a, nil
+}
+
+// finish completes the activation once vault.Restore has run. A failed
+// switch gives back the lease it took; a successful one releases the lease
+// on the profile switched away from.
+func (a *activation) finish(activated bool) {
+	if a.Lease == nil {
+		return
+	}
+	if !activated {
+		releaseNewLease(a.Lease, a.leaseStart)
+	} else if a.previous != "" && a.previous != a.profile {
+		releaseActivationLease(a.provider, a.previous)
+	}
+}
+
+// activateProfile switches fileSet's tool to profile through the shared
+// activation path.
+func activateProfile(fileSet authfile.AuthFileSet, profile, source string, opts activationOptions) error {
+	a, err := beginActivation(fileSet, profile, source, opts)
+	if err != nil {
+		return err
+	}
+	err = vault.Restore(fileSet, profile)
+	a.finish(err == nil)
+	return err
+}
+
+// installActivationHook routes vault.Activate in every package through
+// beginActivation for the rest of this process.
+func installActivationHook() {
+	authfile.SetActivationHook(func(fileSet authfile.AuthFileSet, profile, source string) (func(bool), error) {
+		a, err := beginActivation(fileSet, profile, source, activationOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return a.finish, nil
+	})
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/hooks"
+)
+
+// unsavedBackups lists the _backup_ profiles of codex in the vault.
+func unsavedBackups(t *testing.T) []string {
+	t.Helper()
+	profiles, err := vault.List("codex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backups []string
+	for _, p := range profiles {
+		if strings.HasPrefix(p, "_backup_") {
+			backups = append(backups, p)
+		}
+	}
+	return backups
+}
+
+func TestActivationHookSavesLiveAuth(t *testing.T) {
+	_, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	installActivationHook()
+	defer authfile.SetActivationHook(nil)
+
+	for _, name := range []string{"_original", "b"} {
+		if err := os.MkdirAll(vault.ProfilePath("codex", name), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(vault.BackupPath("codex", "b", "auth.json"), []byte(`{"access_token":"b"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	authPath := filepath.Join(os.Getenv("CODEX_HOME"), "auth.json")
+	unsaved := `{"access_token":"unsaved"}`
+	if err := os.WriteFile(authPath, []byte(unsaved), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Packages like the TUI open their own vault and call Activate.
+	other := authfile.NewVault(vault.BasePath())
+	if err := other.Activate(authfile.CodexAuthFiles(), "b", "tui"); err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+	backups := unsavedBackups(t)
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want one", backups)
+	}
+	if data, _ := os.ReadFile(vault.BackupPath("codex", backups[0], "auth.json")); string(data) != unsaved {
+		t.Errorf("backup = %s, want the unsaved auth", data)
+	}
+
+	// A caller that saved the live auth itself gets no second copy.
+	if err := os.WriteFile(authPath, []byte(`{"access_token":"edited"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := activateProfile(authfile.CodexAuthFiles(), "b", "org", activationOptions{liveSaved: true}); err != nil {
+		t.Fatalf("activateProfile() error = %v", err)
+	}
+	if backups := unsavedBackups(t); len(backups) != 1 {
+		t.Errorf("backups after liveSaved = %v, want one", backups)
+	}
+}
+
+func TestActivationHookRunsPreActivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test hooks are shell scripts")
+	}
+	_, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(hooks.EnvVar, "")
+	t.Setenv(hooks.DisableEnvVar, "")
+	installActivationHook()
+	defer authfile.SetActivationHook(nil)
+
+	if err := os.MkdirAll(vault.ProfilePath("codex", "b"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(vault.BackupPath("codex", "b", "auth.json"), []byte(`{"access_token":"b"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	authPath := filepath.Join(os.Getenv("CODEX_HOME"), "auth.json")
+	if err := os.WriteFile(authPath, []byte(`{"access_token":"a"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	installTestHook(t, hooks.PreActivate, `grep -q '"source":"wrap"' && { echo "no switching under wrap" >&2; exit 1; }; exit 0`)
+	err := authfile.NewVault(vault.BasePath()).Activate(authfile.CodexAuthFiles(), "b", "wrap")
+	if caamerr.CodeOf(err) != caamerr.HookRejected || !strings.Contains(err.Error(), "no switching under wrap") {
+		t.Fatalf("Activate() error = %v, want %s", err, caamerr.HookRejected)
+	}
+	if data, _ := os.ReadFile(authPath); string(data) != `{"access_token":"a"}` {
+		t.Errorf("auth after refused activation = %s", data)
+	}
+
+	if err := activateProfile(authfile.CodexAuthFiles(), "b", "policy", activationOptions{}); err != nil {
+		t.Fatalf("activateProfile() error = %v", err)
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 	codexprovider "github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/codex"
 )
 
@@ -38,7 +40,14 @@ Examples:
   caam add claude work-2       # Pre-specify profile name
   caam add codex --device-code # Device code flow (headless)
   caam add codex --no-activate # Don't activate after adding
-  caam add gemini --timeout 5m # Custom timeout for login flow`,
+  caam add gemini --timeout 5m # Custom timeout for login flow
+  caam add claude api-1 --api-key            # API-key profile ($ANTHROPIC_API_KEY or stdin)
+  caam add claude api-2 --api-key --keychain # Keep the key in the OS keychain
+
+With --api-key no login runs: the profile authenticates with an Anthropic API
+key instead of a claude.ai subscription. The key is written to the profile's
+~/.claude/settings.json, or with --keychain stored in the OS keychain and
+read back through Claude Code's apiKeyHelper.`,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: runAdd,
 }
@@ -49,6 +58,8 @@ func init() {
 	addCmd.Flags().Duration("timeout", 5*time.Minute, "timeout for login flow completion")
 	addCmd.Flags().Bool("force", false, "skip confirmation prompts")
 	addCmd.Flags().Bool("device-code", false, "use device code flow for codex (headless)")
+	addCmd.Flags().Bool("api-key", false, "add a claude API-key profile instead of logging in")
+	addCmd.Flags().Bool("keychain", false, "with --api-key, store the key in the OS keychain")
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
@@ -57,10 +68,12 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	timeout, _ := cmd.Flags().GetDuration("timeout")
 	force, _ := cmd.Flags().GetBool("force")
 	deviceCode, _ := cmd.Flags().GetBool("device-code")
+	apiKey, _ := cmd.Flags().GetBool("api-key")
+	useKeychain, _ := cmd.Flags().GetBool("keychain")
 
 	getFileSet, ok := tools[tool]
 	if !ok {
-		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 	}
 
 	// Initialize vault
@@ -82,12 +95,19 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		if err == nil {
 			for _, p := range profiles {
 				if p == profileName {
-					return fmt.Errorf("profile %s/%s already exists (use a different name or delete it first)", tool, profileName)
+					return caamerr.Errorf(caamerr.AlreadyExists, "profile %s/%s already exists (use a different name or delete it first)", tool, profileName)
 				}
 			}
 		}
 	}
 
+	if apiKey {
+		return runAddAPIKey(cmd, tool, profileName, fileSet, useKeychain, noActivate)
+	}
+	if useKeychain {
+		return caamerr.Errorf(caamerr.InvalidArgs, "--keychain requires --api-key")
+	}
+
 	// Check if auth files currently exist
 	hasExistingAuth := authfile.HasAuthFiles(fileSet)
 
@@ -184,7 +204,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 
 	// Validate profile name
 	if strings.HasPrefix(profileName, "_") {
-		return fmt.Errorf("profile names starting with '_' are reserved for system use")
+		return caamerr.Errorf(caamerr.InvalidArgs, "profile names starting with '_' are reserved for system use")
 	}
 
 	// Check again if profile exists (in case user entered same name interactively)
@@ -211,6 +231,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		if err := vault.Restore(fileSet, profileName); err != nil {
 			return fmt.Errorf("activate profile: %w", err)
 		}
+		events.PublishActivated(tool, profileName, "add")
 		fmt.Printf("  Activated %s/%s\n", tool, profileName)
 	}
 
@@ -32,6 +32,8 @@ func TestAddCommandFlags(t *testing.T) {
 		{"timeout", "5m0s"},
 		{"force", "false"},
 		{"device-code", "false"},
+		{"api-key", "false"},
+		{"keychain", "false"},
 	}
 
 	for _, tt := range flags {
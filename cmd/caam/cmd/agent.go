@@ -16,8 +16,9 @@ import (
 )
 
 var agentCmd = &cobra.Command{
-	Use:   "auth-agent",
-	Short: "Run the local auth agent for automated OAuth completion",
+	Use:     "auth-agent",
+	Aliases: []string{"agent"},
+	Short:   "Run the local auth agent for automated OAuth completion",
 	Long: `Receive OAuth URLs from the remote coordinator and complete authentication
 using browser automation.
 
@@ -26,10 +27,16 @@ existing Google account sessions. It:
 1. Receives auth request URLs from the remote coordinator
 2. Opens Chrome and navigates to the OAuth URL
 3. Selects the appropriate Google account (using LRU strategy by default)
-4. Extracts the challenge code
+4. Extracts the challenge code (or, for Codex and Gemini, captures the
+   redirect back to the CLI)
 5. Sends the code back to the coordinator
 
-The coordinator then injects this code into the waiting Claude Code session.
+The coordinator then injects this code into the waiting Claude Code, Codex,
+or Gemini session.
+
+Accounts come from --accounts, or with --from-vault from the emails recorded
+for your vault profiles (see 'caam identity list'). If login fails with the
+selected account, --fallback-account is tried once before giving up.
 
 SSH Tunnel Setup (run on local Mac):
   ssh -R 7890:localhost:7891 user@remote-server -N
@@ -44,6 +51,9 @@ Examples:
   caam auth-agent --coordinator http://localhost:7890 \
     --accounts alice@gmail.com,bob@gmail.com
 
+  # Rotate through vault accounts, falling back to a spare
+  caam agent --from-vault --fallback-account spare@gmail.com
+
   # Use specific Chrome profile
   caam auth-agent --chrome-profile ~/Library/Application\ Support/Google/Chrome/Default
 
@@ -57,6 +67,8 @@ var (
 	agentCoordinator      string
 	agentCoordinatorToken string
 	agentAccounts         []string
+	agentFallbackAccount  string
+	agentFromVault        bool
 	agentStrategy         string
 	agentChromeProfile    string
 	agentHeadless         bool
@@ -74,6 +86,10 @@ func init() {
 		"Coordinator auth token (shared secret)")
 	agentCmd.Flags().StringSliceVar(&agentAccounts, "accounts", nil,
 		"Google account emails for rotation (comma-separated)")
+	agentCmd.Flags().StringVar(&agentFallbackAccount, "fallback-account", "",
+		"Account to retry with when login fails with the selected one")
+	agentCmd.Flags().BoolVar(&agentFromVault, "from-vault", false,
+		"Add the emails recorded for vault profiles to the account rotation")
 	agentCmd.Flags().StringVar(&agentStrategy, "strategy", "lru",
 		"Account selection strategy: lru, round_robin, random")
 	agentCmd.Flags().StringVar(&agentChromeProfile, "chrome-profile", "",
@@ -114,9 +130,14 @@ func runAgent(cmd *cobra.Command, args []string) error {
 	config.Headless = agentHeadless
 	config.AccountStrategy = strategy
 	config.Accounts = agentAccounts
+	config.FallbackAccount = agentFallbackAccount
 	config.Logger = logger
 
-	return runSingleAgent(cmd, logger, config, agentStrategy, agentAccounts, agentChromeProfile)
+	if agentFromVault {
+		config.Accounts = appendVaultAccounts(config.Accounts, logger)
+	}
+
+	return runSingleAgent(cmd, logger, config, agentStrategy, config.Accounts, agentChromeProfile)
 }
 
 func truncateCode(code string) string {
@@ -168,6 +189,13 @@ func runAgentFromConfig(cmd *cobra.Command, logger *slog.Logger, path string) er
 }
 
 func runSingleAgent(cmd *cobra.Command, logger *slog.Logger, config agent.Config, strategy string, accounts []string, chromeProfile string) error {
+	accounts, err := excludeHighRiskAccounts(accounts, logger)
+	if err != nil {
+		return err
+	}
+	config.Accounts = accounts
+	config.FallbackAccount = safeFallbackAccount(config.FallbackAccount, logger)
+
 	// Create agent
 	ag := agent.New(config)
 
@@ -214,6 +242,9 @@ func runSingleAgent(cmd *cobra.Command, logger *slog.Logger, config agent.Config
 	if len(accounts) > 0 {
 		fmt.Printf("  Accounts: %v\n", accounts)
 	}
+	if config.FallbackAccount != "" {
+		fmt.Printf("  Fallback account: %s\n", config.FallbackAccount)
+	}
 	if chromeProfile != "" {
 		fmt.Printf("  Chrome profile: %s\n", chromeProfile)
 	}
@@ -240,6 +271,13 @@ func runSingleAgent(cmd *cobra.Command, logger *slog.Logger, config agent.Config
 }
 
 func runMultiAgent(cmd *cobra.Command, logger *slog.Logger, config agent.MultiConfig) error {
+	accounts, err := excludeHighRiskAccounts(config.Accounts, logger)
+	if err != nil {
+		return err
+	}
+	config.Accounts = accounts
+	config.FallbackAccount = safeFallbackAccount(config.FallbackAccount, logger)
+
 	ma := agent.NewMulti(config)
 
 	ma.OnAuthStart = func(coord, url, account string) {
@@ -278,6 +316,9 @@ func runMultiAgent(cmd *cobra.Command, logger *slog.Logger, config agent.MultiCo
 	if len(config.Accounts) > 0 {
 		fmt.Printf("  Accounts: %v\n", config.Accounts)
 	}
+	if config.FallbackAccount != "" {
+		fmt.Printf("  Fallback account: %s\n", config.FallbackAccount)
+	}
 	if config.ChromeUserDataDir != "" {
 		fmt.Printf("  Chrome profile: %s\n", config.ChromeUserDataDir)
 	}
@@ -311,6 +352,7 @@ type agentFileConfig struct {
 	Headless         bool                         `json:"headless"`
 	Strategy         string                       `json:"strategy"`
 	Accounts         []string                     `json:"accounts"`
+	FallbackAccount  string                       `json:"fallback_account"`
 	Coordinators     []*agent.CoordinatorEndpoint `json:"coordinators"`
 	ChromeUserData   string                       `json:"chrome_user_data_dir"`
 	ChromeProfileDir string                       `json:"chrome_profile_dir"`
@@ -350,6 +392,7 @@ func loadAgentConfig(path string) (bool, agent.Config, agent.MultiConfig, error)
 			cfg.AccountStrategy = strategy
 		}
 		cfg.Accounts = raw.Accounts
+		cfg.FallbackAccount = raw.FallbackAccount
 		cfg.Coordinators = raw.Coordinators
 		return true, agent.Config{}, cfg, nil
 	}
@@ -371,12 +414,54 @@ func loadAgentConfig(path string) (bool, agent.Config, agent.MultiConfig, error)
 		cfg.AccountStrategy = strategy
 	}
 	cfg.Accounts = raw.Accounts
+	cfg.FallbackAccount = raw.FallbackAccount
 	cfg.CoordinatorURL = firstNonEmpty(raw.CoordinatorURL, raw.Coordinator)
 	cfg.CoordinatorToken = raw.CoordinatorToken
 
 	return false, cfg, agent.MultiConfig{}, nil
 }
 
+// appendVaultAccounts adds the emails recorded for vault profiles to
+// accounts, skipping duplicates.
+func appendVaultAccounts(accounts []string, logger *slog.Logger) []string {
+	db, err := getDB()
+	if err != nil {
+		logger.Warn("cannot read vault identities", "error", err)
+		return accounts
+	}
+	ids, err := db.ListProfileIdentities("")
+	if err != nil {
+		logger.Warn("cannot read vault identities", "error", err)
+		return accounts
+	}
+
+	seen := make(map[string]bool, len(accounts))
+	for _, a := range accounts {
+		seen[strings.ToLower(a)] = true
+	}
+	for _, pi := range ids {
+		email := strings.TrimSpace(pi.Email)
+		if email == "" || seen[strings.ToLower(email)] {
+			continue
+		}
+		seen[strings.ToLower(email)] = true
+		accounts = append(accounts, email)
+	}
+	return accounts
+}
+
+// safeFallbackAccount drops a fallback account that belongs to a high-risk
+// profile, since the fallback is used unattended like any other account.
+func safeFallbackAccount(account string, logger *slog.Logger) string {
+	if account == "" {
+		return ""
+	}
+	if _, err := excludeHighRiskAccounts([]string{account}, logger); err != nil {
+		return ""
+	}
+	return account
+}
+
 func parseStrategy(value string) (agent.AccountStrategy, error) {
 	switch value {
 	case "lru":
@@ -64,6 +64,7 @@ func TestLoadAgentConfigSingle(t *testing.T) {
   "strategy": "round_robin",
   "chrome_profile": "/tmp/profile",
   "accounts": ["a@example.com", "b@example.com"],
+  "fallback_account": "spare@example.com",
   "coordinator_url": "http://localhost:7890",
   "coordinator_token": "shhh"
 }`)
@@ -96,4 +97,7 @@ func TestLoadAgentConfigSingle(t *testing.T) {
 	if cfg.CoordinatorToken != "shhh" {
 		t.Fatalf("CoordinatorToken = %q, want %q", cfg.CoordinatorToken, "shhh")
 	}
+	if cfg.FallbackAccount != "spare@example.com" {
+		t.Fatalf("FallbackAccount = %q, want %q", cfg.FallbackAccount, "spare@example.com")
+	}
 }
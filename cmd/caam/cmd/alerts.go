@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usagealert"
+)
+
+var alertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "Feed provider usage alerts into rotation",
+	Long: `Turn provider usage alerts into caam events so rotation reacts before a
+hard limit hits rather than after.
+
+An alert at or above alerts.critical_threshold (default 85%) puts the
+matching profile into cooldown until the provider's reset time (or
+stealth.cooldown.default_minutes when the alert has none). Every alert is
+logged to the activity database. The alert's account (usually an email) is
+matched to a profile by name or by the identity stored in the vault.
+
+Alerts can arrive three ways:
+  - Email: pipe a provider's usage email into 'caam alerts email', e.g. from
+    a procmail/sieve rule or a mail client filter.
+  - Webhook: set daemon.usage_webhook.listen and .secret; the daemon then
+    accepts POST /usage/<provider> (JSON) and POST /usage/email (raw email).
+  - Manually: 'caam alerts ingest --provider claude payload.json'.
+
+Webhook JSON fields: account (or email), percent (or utilization 0-1),
+window, reset_at (RFC 3339 or Unix seconds), and optionally profile.
+
+Examples:
+  caam alerts email < alert.eml
+  caam alerts ingest --provider codex - <<< '{"email":"me@x.com","percent":92}'
+  caam config set daemon.usage_webhook.listen 127.0.0.1:7895
+  caam config set daemon.usage_webhook.secret "$(openssl rand -hex 16)"
+  caam alerts list`,
+}
+
+var alertsEmailCmd = &cobra.Command{
+	Use:   "email [file]",
+	Short: "Parse a usage-alert email (stdin or file)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runAlertsEmail,
+}
+
+var alertsIngestCmd = &cobra.Command{
+	Use:   "ingest [file]",
+	Short: "Apply a webhook-style JSON alert (stdin or file)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runAlertsIngest,
+}
+
+var alertsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show recent usage alerts",
+	Args:  cobra.NoArgs,
+	RunE:  runAlertsList,
+}
+
+func init() {
+	rootCmd.AddCommand(alertsCmd)
+	alertsCmd.AddCommand(alertsEmailCmd)
+	alertsCmd.AddCommand(alertsIngestCmd)
+	alertsCmd.AddCommand(alertsListCmd)
+
+	alertsEmailCmd.Flags().Bool("dry-run", false, "parse and show the alert without applying it")
+	alertsEmailCmd.Flags().Bool("json", false, "output as JSON")
+	alertsIngestCmd.Flags().String("provider", "", "provider the alert is for (claude, codex, gemini)")
+	alertsIngestCmd.Flags().Bool("dry-run", false, "parse and show the alert without applying it")
+	alertsIngestCmd.Flags().Bool("json", false, "output as JSON")
+	alertsListCmd.Flags().Int("limit", 20, "number of alerts to show")
+	alertsListCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// newUsageAlertApplier builds the applier used by both the CLI and the
+// daemon's webhook receiver.
+func newUsageAlertApplier() *usagealert.Applier {
+	a := &usagealert.Applier{
+		Resolve:          resolveAlertProfile,
+		CriticalPercent:  85,
+		FallbackCooldown: usagealert.DefaultCooldown,
+	}
+	if spmCfg, err := config.LoadSPMConfig(); err == nil {
+		a.CriticalPercent = float64(spmCfg.Alerts.CriticalThreshold)
+		if !spmCfg.Alerts.Enabled {
+			a.CriticalPercent = 0
+		}
+		if m := spmCfg.Stealth.Cooldown.DefaultMinutes; m > 0 {
+			a.FallbackCooldown = time.Duration(m) * time.Minute
+		}
+	}
+	return a
+}
+
+// resolveAlertProfile finds the vault profile for a provider account, first
+// by profile name and then by the email in the stored credentials.
+func resolveAlertProfile(provider, account string) string {
+	if vault == nil || account == "" {
+		return ""
+	}
+	profiles, err := vault.List(provider)
+	if err != nil {
+		return ""
+	}
+	for _, p := range profiles {
+		if strings.EqualFold(p, account) {
+			return p
+		}
+	}
+	for _, p := range profiles {
+		if id := getVaultIdentity(provider, p); id != nil && strings.EqualFold(id.Email, account) {
+			return p
+		}
+	}
+	return ""
+}
+
+func runAlertsEmail(cmd *cobra.Command, args []string) error {
+	r, closeFn, err := openInputArg(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	alert, err := usagealert.ParseEmail(r)
+	if err != nil {
+		return err
+	}
+	return applyUsageAlert(cmd, alert)
+}
+
+func runAlertsIngest(cmd *cobra.Command, args []string) error {
+	provider, _ := cmd.Flags().GetString("provider")
+	r, closeFn, err := openInputArg(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read alert: %w", err)
+	}
+	alert, err := usagealert.ParseWebhook(provider, data)
+	if err != nil {
+		return err
+	}
+	return applyUsageAlert(cmd, alert)
+}
+
+// openInputArg opens the optional file argument, or stdin for none or "-".
+func openInputArg(cmd *cobra.Command, args []string) (io.Reader, func(), error) {
+	if len(args) == 0 || args[0] == "-" {
+		return cmd.InOrStdin(), func() {}, nil
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { f.Close() }, nil
+}
+
+func applyUsageAlert(cmd *cobra.Command, alert *usagealert.Alert) error {
+	if _, ok := tools[alert.Provider]; !ok {
+		return fmt.Errorf("unknown provider %q (supported: codex, claude, gemini)", alert.Provider)
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	out := cmd.OutOrStdout()
+
+	var result *usagealert.Result
+	if dryRun {
+		if alert.Profile == "" {
+			alert.Profile = resolveAlertProfile(alert.Provider, alert.Account)
+		}
+		result = &usagealert.Result{Alert: alert, Profile: alert.Profile, Action: "none (dry run)"}
+	} else {
+		var err error
+		result, err = newUsageAlertApplier().Apply(alert)
+		if err != nil {
+			return err
+		}
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	target := alert.Provider + "/" + result.Profile
+	if result.Profile == "" {
+		target = alert.Provider + " " + alert.Account + " (no matching profile)"
+	}
+	fmt.Fprintf(out, "Usage alert: %s at %.0f%%", target, alert.Percent)
+	if alert.Window != "" {
+		fmt.Fprintf(out, " of %s limit", alert.Window)
+	}
+	fmt.Fprintln(out)
+	if !alert.ResetAt.IsZero() {
+		fmt.Fprintf(out, "  Resets: %s\n", alert.ResetAt.Local().Format("2006-01-02 15:04"))
+	}
+	switch result.Action {
+	case "cooldown":
+		fmt.Fprintf(out, "  Cooldown scheduled until %s\n", result.Cooldown.CooldownUntil.Local().Format("2006-01-02 15:04"))
+	case "cooldown_kept":
+		fmt.Fprintf(out, "  Already in cooldown until %s\n", result.Cooldown.CooldownUntil.Local().Format("2006-01-02 15:04"))
+	default:
+		fmt.Fprintf(out, "  Action: %s\n", result.Action)
+	}
+	return nil
+}
+
+func runAlertsList(cmd *cobra.Command, args []string) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	db, err := caamdb.Open()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	defer db.Close()
+
+	events, err := db.ListRecentEvents(limit * 10)
+	if err != nil {
+		return err
+	}
+	var alerts []caamdb.Event
+	for _, ev := range events {
+		if ev.Type == usagealert.EventUsageAlert {
+			alerts = append(alerts, ev)
+			if len(alerts) == limit {
+				break
+			}
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOut {
+		if alerts == nil {
+			alerts = []caamdb.Event{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(alerts)
+	}
+	if len(alerts) == 0 {
+		fmt.Fprintln(out, "No usage alerts recorded.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TIME\tPROFILE\tUSAGE\tWINDOW\tSOURCE")
+	for _, ev := range alerts {
+		_, _ = fmt.Fprintf(tw, "%s\t%s/%s\t%v%%\t%v\t%v\n",
+			ev.Timestamp.Local().Format("2006-01-02 15:04"),
+			ev.Provider,
+			ev.ProfileName,
+			ev.Details["percent"],
+			detailOrDash(ev.Details["window"]),
+			ev.Details["source"],
+		)
+	}
+	return tw.Flush()
+}
+
+func detailOrDash(v any) any {
+	if v == nil {
+		return "-"
+	}
+	return v
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 )
 
@@ -42,7 +43,7 @@ func runAlias(cmd *cobra.Command, args []string) error {
 
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
 	}
 
 	// List all aliases
@@ -65,7 +66,7 @@ func runAlias(cmd *cobra.Command, args []string) error {
 
 	// Validate tool
 	if _, ok := tools[tool]; !ok {
-		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 	}
 
 	// Validate profile exists
@@ -74,7 +75,7 @@ func runAlias(cmd *cobra.Command, args []string) error {
 	}
 	profiles, err := vault.List(tool)
 	if err != nil {
-		return fmt.Errorf("list profiles: %w", err)
+		return caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
 	}
 	profileExists := false
 	for _, p := range profiles {
@@ -84,7 +85,7 @@ func runAlias(cmd *cobra.Command, args []string) error {
 		}
 	}
 	if !profileExists {
-		return fmt.Errorf("profile %s/%s not found; run 'caam ls %s' to see available profiles", tool, profile, tool)
+		return caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s not found; run 'caam ls %s' to see available profiles", tool, profile, tool)
 	}
 
 	// If no alias provided, show current aliases
@@ -129,7 +130,7 @@ func removeAlias(cfg *config.Config, alias string, jsonOutput bool) error {
 	}
 
 	if err := cfg.Save(); err != nil {
-		return fmt.Errorf("save config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "save config: %w", err)
 	}
 
 	if jsonOutput {
@@ -196,7 +197,7 @@ func addAlias(cfg *config.Config, tool, profile, alias string, jsonOutput bool)
 
 	cfg.AddAlias(tool, profile, alias)
 	if err := cfg.Save(); err != nil {
-		return fmt.Errorf("save config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "save config: %w", err)
 	}
 
 	if jsonOutput {
@@ -246,7 +247,7 @@ func runFavorite(cmd *cobra.Command, args []string) error {
 
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
 	}
 
 	// List all favorites
@@ -260,14 +261,14 @@ func runFavorite(cmd *cobra.Command, args []string) error {
 
 	tool := args[0]
 	if _, ok := tools[tool]; !ok {
-		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 	}
 
 	// Clear favorites
 	if clearFlag {
 		cfg.SetFavorites(tool, nil)
 		if err := cfg.Save(); err != nil {
-			return fmt.Errorf("save config: %w", err)
+			return caamerr.Errorf(caamerr.ConfigError, "save config: %w", err)
 		}
 		if jsonFlag {
 			result := map[string]any{
@@ -350,7 +351,7 @@ func setFavorites(cfg *config.Config, tool string, profiles []string, jsonOutput
 	}
 	existingProfiles, err := vault.List(tool)
 	if err != nil {
-		return fmt.Errorf("list profiles: %w", err)
+		return caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
 	}
 
 	profileSet := make(map[string]bool)
@@ -371,7 +372,7 @@ func setFavorites(cfg *config.Config, tool string, profiles []string, jsonOutput
 
 	cfg.SetFavorites(tool, profiles)
 	if err := cfg.Save(); err != nil {
-		return fmt.Errorf("save config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "save config: %w", err)
 	}
 
 	if jsonOutput {
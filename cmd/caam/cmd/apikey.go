@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// apiKeyHelperCmd prints a keychain-stored API key. API-key profiles saved
+// with --keychain point Claude Code's apiKeyHelper setting at it.
+var apiKeyHelperCmd = &cobra.Command{
+	Use:    "api-key-helper <tool> <profile>",
+	Short:  "Print a profile's API key from the OS keychain (used by apiKeyHelper)",
+	Hidden: true,
+	Args:   cobra.ExactArgs(2),
+	RunE:   runAPIKeyHelper,
+}
+
+func init() {
+	rootCmd.AddCommand(apiKeyHelperCmd)
+}
+
+// apiKeyKeychainID is the keychain account an API-key profile's key is
+// stored under.
+func apiKeyKeychainID(tool, profile string) string {
+	return "api-key:" + tool + "/" + profile
+}
+
+// readAPIKey returns the API key to store: $ANTHROPIC_API_KEY, or a line
+// read from stdin.
+func readAPIKey(cmd *cobra.Command) (string, error) {
+	if key := strings.TrimSpace(os.Getenv(authfile.ClaudeAPIKeyEnv)); key != "" {
+		return key, nil
+	}
+	fmt.Fprint(cmd.OutOrStdout(), "Anthropic API key: ")
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	key := strings.TrimSpace(line)
+	if key == "" {
+		if err != nil {
+			return "", caamerr.Errorf(caamerr.InvalidArgs, "no API key given: set %s or pass it on stdin", authfile.ClaudeAPIKeyEnv)
+		}
+		return "", caamerr.Errorf(caamerr.InvalidArgs, "no API key given")
+	}
+	return key, nil
+}
+
+// runAddAPIKey saves an API-key profile instead of running a login flow.
+func runAddAPIKey(cmd *cobra.Command, tool, profileName string, fileSet authfile.AuthFileSet, useKeychain, noActivate bool) error {
+	if tool != "claude" {
+		return caamerr.Errorf(caamerr.InvalidArgs, "--api-key is supported for claude only")
+	}
+	if profileName == "" {
+		return caamerr.Errorf(caamerr.InvalidArgs, "--api-key needs a profile name: caam add claude <profile> --api-key")
+	}
+	if authfile.IsSystemProfile(profileName) {
+		return caamerr.Errorf(caamerr.InvalidArgs, "profile names starting with '_' are reserved for system use")
+	}
+	key, err := readAPIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Start from the live settings so hooks and permissions carry over.
+	var base []byte
+	for _, spec := range fileSet.Files {
+		if spec.VaultFileName() == "settings.json" {
+			base, _ = os.ReadFile(spec.Path)
+		}
+	}
+
+	stored := authfile.ClaudeAPIKey{Store: authfile.APIKeyStoreSettings}
+	helper := ""
+	if useKeychain {
+		id := apiKeyKeychainID(tool, profileName)
+		ref, err := vaultcrypt.StoreSecret(id, []byte(key))
+		if err != nil {
+			return fmt.Errorf("store API key in keychain: %w", err)
+		}
+		exe, err := findCaamPath()
+		if err != nil {
+			return fmt.Errorf("locate caam for apiKeyHelper: %w", err)
+		}
+		helper = shellQuote(exe) + " api-key-helper " + tool + " " + shellQuote(profileName)
+		stored = authfile.ClaudeAPIKey{Store: authfile.APIKeyStoreKeychain, KeychainID: id, KeychainRef: ref}
+	}
+	settings, err := authfile.WithClaudeAPIKey(base, key, helper)
+	if err != nil {
+		return err
+	}
+	if err := vault.SaveClaudeAPIKeyProfile(profileName, settings, stored); err != nil {
+		return caamerr.Wrap(caamerr.SaveError, err)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out)
+	if useKeychain {
+		fmt.Fprintf(out, "Saved API-key profile %s/%s (key in the OS keychain)\n", tool, profileName)
+	} else {
+		fmt.Fprintf(out, "Saved API-key profile %s/%s\n", tool, profileName)
+	}
+	if !noActivate {
+		if _, err := vault.BackupOriginal(fileSet); err != nil {
+			fmt.Fprintf(out, "  Warning: could not back up original %s auth: %v\n", tool, err)
+		}
+		if err := vault.Restore(fileSet, profileName); err != nil {
+			return fmt.Errorf("activate profile: %w", err)
+		}
+		events.PublishActivated(tool, profileName, "add")
+		fmt.Fprintf(out, "  Activated %s/%s\n", tool, profileName)
+	}
+	return nil
+}
+
+func runAPIKeyHelper(cmd *cobra.Command, args []string) error {
+	tool, profile := strings.ToLower(args[0]), args[1]
+	if vault == nil {
+		vault = authfile.NewVault(authfile.DefaultVaultPath())
+	}
+	stored := vault.ClaudeAPIKey(profile)
+	if tool != "claude" || stored == nil {
+		return caamerr.Errorf(caamerr.ProfileNotFound, "%s/%s is not an API-key profile", tool, profile)
+	}
+	if stored.Store != authfile.APIKeyStoreKeychain {
+		return caamerr.Errorf(caamerr.InvalidArgs, "%s/%s keeps its key in settings, not the keychain", tool, profile)
+	}
+	key, err := vaultcrypt.LoadSecret(stored.KeychainID, stored.KeychainRef)
+	if err != nil {
+		return fmt.Errorf("load API key from keychain: %w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(key))
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+)
+
+func TestAddAPIKeyProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	home := filepath.Join(tmpDir, "home")
+	t.Setenv("HOME", home)
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	t.Setenv(authfile.ClaudeAPIKeyEnv, "sk-ant-test")
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	defer func() { vault = oldVault }()
+
+	// A subscription profile to compare against.
+	subDir := vault.ProfilePath("claude", "max")
+	if err := os.MkdirAll(subDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(subDir, ".credentials.json"), []byte(`{"claudeAiOauth":{"accessToken":"a","refreshToken":"r"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	addCmd.SetOut(&buf)
+	defer addCmd.SetOut(nil)
+	for name, value := range map[string]string{"api-key": "true", "keychain": "false", "no-activate": "false", "force": "false"} {
+		if err := addCmd.Flags().Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer addCmd.Flags().Set("api-key", "false")
+
+	if err := runAdd(addCmd, []string{"claude", "api"}); err != nil {
+		t.Fatalf("caam add claude api --api-key: %v", err)
+	}
+	settings, err := os.ReadFile(filepath.Join(home, ".claude", "settings.json"))
+	if err != nil || !strings.Contains(string(settings), `"ANTHROPIC_API_KEY": "sk-ant-test"`) {
+		t.Fatalf("live settings = %s, %v", settings, err)
+	}
+	if active, _ := vault.ActiveProfile(authfile.ClaudeAuthFiles()); active != "api" {
+		t.Errorf("active profile = %q, want api", active)
+	}
+
+	info := buildProfileInfo("claude", "api", "api", nil, false)
+	if info.PlanType != identity.PlanAPI {
+		t.Errorf("plan_type = %q, want %q", info.PlanType, identity.PlanAPI)
+	}
+
+	scored := scoreRobotNextProfiles("claude", []string{"api", "max"}, "smart", false, nil)
+	if len(scored) != 2 || scored[0].name != "max" {
+		t.Fatalf("robot next order = %+v, want max first", scored)
+	}
+	if !strings.Contains(strings.Join(scored[1].reasons, "; "), "api key: billed per token") {
+		t.Errorf("api profile reasons = %v", scored[1].reasons)
+	}
+}
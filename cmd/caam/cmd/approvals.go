@@ -0,0 +1,475 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/api"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+// agentEnvVar marks a process as an agent. Coding agents (or their wrappers)
+// set it, optionally to the agent's name, so their robot actions go through
+// the approval workflow.
+const agentEnvVar = "CAAM_AGENT"
+
+// approvalActions are the robot act actions the approval workflow gates.
+var approvalActions = map[string]bool{
+	"activate":   true,
+	"cooldown":   true,
+	"uncooldown": true,
+	"backup":     true,
+}
+
+var approvalsCmd = &cobra.Command{
+	Use:     "approvals",
+	Aliases: []string{"approval"},
+	Short:   "Review robot actions proposed by agents",
+	Long: `When the approval workflow is enabled, 'caam robot act' commands issued by
+agents are not run straight away. Depending on the configured rules they run,
+are denied, or are queued as proposals that a human approves or denies here.
+
+A robot action counts as agent-initiated when CAAM_AGENT is set in its
+environment (to "1" or to the agent's name) or when it arrives through
+'caam serve'. Commands you type yourself are never gated.
+
+Configure it in config.json:
+
+  "approvals": {
+    "enabled": true,
+    "default": "require",
+    "rules": [
+      {"action": "activate", "risk": "expendable", "decision": "approve"},
+      {"action": "cooldown", "decision": "approve"},
+      {"risk": "high", "decision": "require"}
+    ]
+  }
+
+Rules match on action, provider, and the target profile's risk tier (see
+'caam risk'); empty fields match anything, and the first matching rule
+decides: approve, require, or deny. Without a match, "default" applies
+(require unless set).
+
+Examples:
+  caam approvals list
+  caam approvals approve 12
+  caam approvals deny 13 --reason "keep personal account out of rotation"
+  caam approvals rules`,
+}
+
+var approvalsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List proposals (pending by default)",
+	Args:  cobra.NoArgs,
+	RunE:  runApprovalsList,
+}
+
+var approvalsApproveCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "Approve a proposal and run its action",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runApprovalsApprove,
+}
+
+var approvalsDenyCmd = &cobra.Command{
+	Use:   "deny <id>",
+	Short: "Deny a proposal",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runApprovalsDeny,
+}
+
+var approvalsRulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Show the approval configuration",
+	Args:  cobra.NoArgs,
+	RunE:  runApprovalsRules,
+}
+
+func init() {
+	rootCmd.AddCommand(approvalsCmd)
+	approvalsCmd.AddCommand(approvalsListCmd)
+	approvalsCmd.AddCommand(approvalsApproveCmd)
+	approvalsCmd.AddCommand(approvalsDenyCmd)
+	approvalsCmd.AddCommand(approvalsRulesCmd)
+
+	approvalsListCmd.Flags().Bool("all", false, "include decided proposals")
+	approvalsListCmd.Flags().Int("limit", 50, "maximum proposals to show")
+	approvalsListCmd.Flags().Bool("json", false, "output as JSON")
+	approvalsApproveCmd.Flags().Bool("json", false, "output the action's robot result as JSON")
+	approvalsDenyCmd.Flags().String("reason", "", "why the proposal was denied")
+	approvalsRulesCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+type approvalCallerKey struct{}
+
+// approvalCaller says who issued a robot command. Approved is set when the
+// command runs a proposal a human has approved.
+type approvalCaller struct {
+	Agent    string
+	Approved int64
+}
+
+func withApprovalCaller(ctx context.Context, caller approvalCaller) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, approvalCallerKey{}, caller)
+}
+
+// approvalRequester returns the agent behind a robot command, or "" if a
+// human issued it (or approved it).
+func approvalRequester(ctx context.Context) string {
+	if ctx != nil {
+		if caller, ok := ctx.Value(approvalCallerKey{}).(approvalCaller); ok {
+			if caller.Approved != 0 {
+				return ""
+			}
+			if caller.Agent != "" {
+				return caller.Agent
+			}
+		}
+	}
+	switch v := strings.TrimSpace(os.Getenv(agentEnvVar)); strings.ToLower(v) {
+	case "", "0", "false", "no":
+		return ""
+	case "1", "true", "yes":
+		return "agent"
+	default:
+		return v
+	}
+}
+
+// RobotApprovalData describes a proposal created for a gated robot action.
+type RobotApprovalData struct {
+	ProposalID int64    `json:"proposal_id"`
+	Status     string   `json:"status"`
+	Action     string   `json:"action"`
+	Provider   string   `json:"provider"`
+	Profile    string   `json:"profile,omitempty"`
+	RiskTier   string   `json:"risk_tier"`
+	Requester  string   `json:"requester"`
+	Args       []string `json:"args"`
+}
+
+// gateRobotAct applies the approval workflow to a robot act invocation. It
+// returns handled=true when the action must not run now, having already
+// written the robot output (a denial or a pending proposal).
+func gateRobotAct(cmd *cobra.Command, action, provider string, args []string) (handled bool, err error) {
+	if !approvalActions[action] {
+		return false, nil
+	}
+	requester := approvalRequester(cmd.Context())
+	if requester == "" {
+		return false, nil
+	}
+	cfg := loadRiskConfig()
+	if !cfg.Approvals.Enabled {
+		return false, nil
+	}
+
+	profile := ""
+	if len(args) >= 3 {
+		profile = args[2]
+	}
+	tier := cfg.GetRiskTier(provider, profile)
+
+	switch cfg.Approvals.Decide(action, provider, tier) {
+	case config.ApprovalAllow:
+		return false, nil
+	case config.ApprovalDeny:
+		return true, robotError(cmd, "act", caamerr.ApprovalDenied,
+			fmt.Sprintf("%s on %s/%s is not allowed for agents", action, provider, profile),
+			"denied by the approval rules in config.json",
+			[]string{"caam approvals rules"})
+	}
+
+	// Require, or a decision we don't recognise: queue it for a human.
+	db, err := getDB()
+	if err != nil {
+		return true, robotError(cmd, "act", caamerr.DBError,
+			"failed to open database",
+			err.Error(),
+			nil)
+	}
+	proposal, err := db.ProposeApproval(caamdb.Approval{
+		Requester:   requester,
+		Action:      action,
+		Provider:    provider,
+		ProfileName: profile,
+		Args:        append([]string(nil), args...),
+		RiskTier:    tier.String(),
+	})
+	if err != nil {
+		return true, robotError(cmd, "act", caamerr.ApprovalFailed,
+			"failed to queue action for approval",
+			err.Error(),
+			nil)
+	}
+
+	robotOutput(cmd, RobotOutput{
+		Success: false,
+		Command: "act",
+		Data: RobotApprovalData{
+			ProposalID: proposal.ID,
+			Status:     proposal.Status,
+			Action:     proposal.Action,
+			Provider:   proposal.Provider,
+			Profile:    proposal.ProfileName,
+			RiskTier:   proposal.RiskTier,
+			Requester:  proposal.Requester,
+			Args:       proposal.Args,
+		},
+		Error: &RobotError{
+			Code:    string(caamerr.ApprovalRequired),
+			Message: fmt.Sprintf("%s on %s/%s is waiting for human approval (proposal #%d)", action, provider, profile, proposal.ID),
+			Details: "a human must run 'caam approvals approve' before the action runs",
+		},
+		Suggestions: []string{
+			fmt.Sprintf("caam approvals approve %d", proposal.ID),
+			fmt.Sprintf("caam approvals deny %d", proposal.ID),
+		},
+	})
+	return true, caamerr.Errorf(caamerr.ApprovalRequired, "APPROVAL_REQUIRED: proposal #%d", proposal.ID)
+}
+
+func runApprovalsList(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	limit, _ := cmd.Flags().GetInt("limit")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	db, err := getDB()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	status := caamdb.ApprovalPending
+	if all {
+		status = ""
+	}
+	approvals, err := db.ListApprovals(status, limit)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		type approvalJSON struct {
+			ID        int64     `json:"id"`
+			CreatedAt time.Time `json:"created_at"`
+			Requester string    `json:"requester"`
+			Action    string    `json:"action"`
+			Provider  string    `json:"provider"`
+			Profile   string    `json:"profile,omitempty"`
+			Args      []string  `json:"args"`
+			RiskTier  string    `json:"risk_tier"`
+			Status    string    `json:"status"`
+			DecidedAt string    `json:"decided_at,omitempty"`
+			DecidedBy string    `json:"decided_by,omitempty"`
+			Reason    string    `json:"reason,omitempty"`
+		}
+		list := make([]approvalJSON, 0, len(approvals))
+		for _, a := range approvals {
+			item := approvalJSON{
+				ID:        a.ID,
+				CreatedAt: a.CreatedAt,
+				Requester: a.Requester,
+				Action:    a.Action,
+				Provider:  a.Provider,
+				Profile:   a.ProfileName,
+				Args:      a.Args,
+				RiskTier:  a.RiskTier,
+				Status:    a.Status,
+				DecidedBy: a.DecidedBy,
+				Reason:    a.Reason,
+			}
+			if !a.DecidedAt.IsZero() {
+				item.DecidedAt = a.DecidedAt.Format(time.RFC3339)
+			}
+			list = append(list, item)
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+
+	if len(approvals) == 0 {
+		if all {
+			fmt.Fprintln(out, "No proposals.")
+		} else {
+			fmt.Fprintln(out, "No pending proposals.")
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tREQUESTED\tBY\tACTION\tRISK")
+	for _, a := range approvals {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			a.ID, a.Status, formatTimeAgo(a.CreatedAt), a.Requester,
+			"caam robot act "+strings.Join(a.Args, " "), a.RiskTier)
+	}
+	return w.Flush()
+}
+
+func runApprovalsApprove(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	db, proposal, err := loadApproval(args[0])
+	if err != nil {
+		return err
+	}
+	if proposal.Status != caamdb.ApprovalPending {
+		return caamerr.Errorf(caamerr.Conflict, "proposal #%d is already %s", proposal.ID, proposal.Status)
+	}
+	ok, err := db.DecideApproval(proposal.ID, caamdb.ApprovalApproved, approvalOperator(), "", time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return caamerr.Errorf(caamerr.Conflict, "proposal #%d was decided by someone else", proposal.ID)
+	}
+
+	ctx := withApprovalCaller(cmd.Context(), approvalCaller{Approved: proposal.ID})
+	out, runErr := runRobotRequest(ctx, api.RobotRequest{Command: "act", Args: proposal.Args})
+
+	status := caamdb.ApprovalExecuted
+	if runErr != nil {
+		status = caamdb.ApprovalFailed
+	}
+	if err := db.FinishApproval(proposal.ID, status, strings.TrimSpace(string(out))); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record outcome of proposal #%d: %v\n", proposal.ID, err)
+	}
+
+	if jsonOutput {
+		if _, err := cmd.OutOrStdout().Write(out); err != nil {
+			return err
+		}
+		return runErr
+	}
+
+	var result struct {
+		Data  RobotActResult `json:"data"`
+		Error *RobotError    `json:"error"`
+	}
+	_ = json.Unmarshal(out, &result)
+	if runErr != nil {
+		msg := runErr.Error()
+		if result.Error != nil {
+			msg = result.Error.Message
+		}
+		return fmt.Errorf("approved proposal #%d but the action failed: %s", proposal.ID, msg)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Approved #%d: %s\n", proposal.ID, result.Data.Message)
+	return nil
+}
+
+func runApprovalsDeny(cmd *cobra.Command, args []string) error {
+	reason, _ := cmd.Flags().GetString("reason")
+
+	db, proposal, err := loadApproval(args[0])
+	if err != nil {
+		return err
+	}
+	ok, err := db.DecideApproval(proposal.ID, caamdb.ApprovalDenied, approvalOperator(), reason, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return caamerr.Errorf(caamerr.Conflict, "proposal #%d is already %s", proposal.ID, proposal.Status)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Denied #%d: %s %s/%s\n", proposal.ID, proposal.Action, proposal.Provider, proposal.ProfileName)
+	return nil
+}
+
+func runApprovalsRules(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := config.Load()
+	if err != nil {
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
+	}
+	approvals := cfg.Approvals
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(approvals)
+	}
+
+	if approvals.Enabled {
+		fmt.Fprintln(out, "Approval workflow: enabled")
+	} else {
+		fmt.Fprintln(out, "Approval workflow: disabled (agent actions run without approval)")
+	}
+	def := approvals.Default
+	if def == "" {
+		def = config.ApprovalRequire
+	}
+	fmt.Fprintf(out, "Default decision:  %s\n", def)
+
+	if len(approvals.Rules) > 0 {
+		fmt.Fprintln(out)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "#\tACTION\tPROVIDER\tRISK\tDECISION")
+		for i, r := range approvals.Rules {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1,
+				anyIfEmpty(r.Action), anyIfEmpty(r.Provider), anyIfEmpty(string(r.Risk)), r.Decision)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if err := approvals.Validate(); err != nil {
+		fmt.Fprintf(out, "\nWarning: %v (unrecognised decisions queue for approval)\n", err)
+	}
+	return nil
+}
+
+func anyIfEmpty(s string) string {
+	if s == "" {
+		return "*"
+	}
+	return s
+}
+
+func loadApproval(idArg string) (*caamdb.DB, *caamdb.Approval, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(idArg, "#"), 10, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid proposal id %q", idArg)
+	}
+	db, err := getDB()
+	if err != nil {
+		return nil, nil, caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	proposal, err := db.GetApproval(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if proposal == nil {
+		return nil, nil, fmt.Errorf("no proposal #%d", id)
+	}
+	return db, proposal, nil
+}
+
+// approvalOperator names the human deciding a proposal.
+func approvalOperator() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "operator"
+}
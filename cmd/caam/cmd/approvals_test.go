@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
+)
+
+func runRobotActForTest(t *testing.T, args ...string) (RobotOutput, json.RawMessage, error) {
+	t.Helper()
+	var out strings.Builder
+	robotActCmd.SetOut(&out)
+	t.Cleanup(func() { robotActCmd.SetOut(nil) })
+
+	err := runRobotAct(robotActCmd, args)
+
+	var output struct {
+		RobotOutput
+		Data json.RawMessage `json:"data"`
+	}
+	if decodeErr := json.Unmarshal([]byte(out.String()), &output); decodeErr != nil {
+		t.Fatalf("decode robot output: %v\n%s", decodeErr, out.String())
+	}
+	return output.RobotOutput, output.Data, err
+}
+
+func TestApprovalWorkflow(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	t.Setenv(agentEnvVar, "claude-agent")
+
+	cfg := config.DefaultConfig()
+	cfg.RiskTiers = map[string]risk.Tier{"claude/personal": risk.High, "claude/pool": risk.Expendable}
+	cfg.Approvals = config.ApprovalConfig{
+		Enabled: true,
+		Rules: []config.ApprovalRule{
+			{Action: "cooldown", Risk: risk.Expendable, Decision: config.ApprovalAllow},
+			{Action: "backup", Risk: risk.High, Decision: config.ApprovalDeny},
+		},
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Auto-approved by rule: runs straight away.
+	output, _, err := runRobotActForTest(t, "cooldown", "claude", "pool", "1h")
+	if err != nil || !output.Success {
+		t.Fatalf("auto-approved cooldown: success=%v err=%v", output.Success, err)
+	}
+
+	// Denied by rule.
+	output, _, err = runRobotActForTest(t, "backup", "claude", "personal")
+	if err == nil || output.Error == nil || output.Error.Code != "APPROVAL_DENIED" {
+		t.Fatalf("backup of high-risk profile: err=%v output=%+v, want APPROVAL_DENIED", err, output.Error)
+	}
+
+	// No rule matches: queued for approval, and retries reuse the proposal.
+	output, data, err := runRobotActForTest(t, "cooldown", "claude", "personal", "2h")
+	if err == nil || output.Error == nil || output.Error.Code != "APPROVAL_REQUIRED" {
+		t.Fatalf("cooldown of high-risk profile: err=%v output=%+v, want APPROVAL_REQUIRED", err, output.Error)
+	}
+	var pending RobotApprovalData
+	if err := json.Unmarshal(data, &pending); err != nil {
+		t.Fatal(err)
+	}
+	if pending.ProposalID == 0 || pending.Requester != "claude-agent" || pending.RiskTier != "high" {
+		t.Fatalf("proposal = %+v", pending)
+	}
+	_, data, _ = runRobotActForTest(t, "cooldown", "claude", "personal", "2h")
+	var retry RobotApprovalData
+	_ = json.Unmarshal(data, &retry)
+	if retry.ProposalID != pending.ProposalID {
+		t.Fatalf("retry proposal = %d, want %d", retry.ProposalID, pending.ProposalID)
+	}
+
+	db, err := getDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := db.ActiveCooldown("claude", "personal", time.Now()); active != nil {
+		t.Fatal("cooldown set before approval")
+	}
+
+	// Approving runs the queued action even though CAAM_AGENT is set.
+	var out strings.Builder
+	approvalsApproveCmd.SetOut(&out)
+	t.Cleanup(func() { approvalsApproveCmd.SetOut(nil) })
+	if err := runApprovalsApprove(approvalsApproveCmd, []string{strconv.FormatInt(pending.ProposalID, 10)}); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if !strings.Contains(out.String(), "Approved #") {
+		t.Errorf("approve output = %q", out.String())
+	}
+	if active, _ := db.ActiveCooldown("claude", "personal", time.Now()); active == nil {
+		t.Fatal("cooldown not set after approval")
+	}
+	proposal, err := db.GetApproval(pending.ProposalID)
+	if err != nil || proposal == nil || proposal.Status != caamdb.ApprovalExecuted {
+		t.Fatalf("proposal after approve = %+v, %v; want executed", proposal, err)
+	}
+	if err := runApprovalsApprove(approvalsApproveCmd, []string{strconv.FormatInt(pending.ProposalID, 10)}); err == nil {
+		t.Fatal("approving an executed proposal succeeded")
+	}
+
+	// Deny leaves the action unrun.
+	_, data, _ = runRobotActForTest(t, "uncooldown", "claude", "personal")
+	var second RobotApprovalData
+	_ = json.Unmarshal(data, &second)
+	if err := runApprovalsDeny(approvalsDenyCmd, []string{strconv.FormatInt(second.ProposalID, 10)}); err != nil {
+		t.Fatalf("deny: %v", err)
+	}
+	if active, _ := db.ActiveCooldown("claude", "personal", time.Now()); active == nil {
+		t.Fatal("denied uncooldown ran")
+	}
+
+	// Humans are never gated.
+	t.Setenv(agentEnvVar, "")
+	output, _, err = runRobotActForTest(t, "uncooldown", "claude", "personal")
+	if err != nil || !output.Success {
+		t.Fatalf("human uncooldown: success=%v err=%v", output.Success, err)
+	}
+}
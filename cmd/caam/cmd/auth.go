@@ -12,6 +12,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 )
 
@@ -90,7 +91,7 @@ This is useful for first-run experience to discover and import existing credenti
 			tool := strings.ToLower(args[0])
 			p, ok := registry.Get(tool)
 			if !ok {
-				return fmt.Errorf("unknown tool: %s (supported: claude, codex, gemini)", tool)
+				return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: claude, codex, gemini)", tool)
 			}
 			providersToCheck = append(providersToCheck, p)
 		} else {
@@ -126,7 +127,7 @@ type AuthImportResult struct {
 }
 
 var authImportCmd = &cobra.Command{
-	Use:   "import <tool>",
+	Use:   "import [tool]",
 	Short: "Import detected auth into a profile",
 	Long: `Import existing authentication files into a new caam profile.
 
@@ -145,8 +146,19 @@ Examples:
   caam auth import claude --force            # Overwrite existing profile
   caam auth import claude --json             # Output as JSON
 
-Use 'caam auth detect' first to see what auth files are available.`,
-	Args: cobra.ExactArgs(1),
+Use 'caam auth detect' first to see what auth files are available.
+
+With --from-ssh, profiles are pulled from another machine over SSH instead,
+without adding it to the sync pool: every profile in its vault that is
+missing locally or holds a fresher token, or, for providers it has no vault
+profiles for, the auth files its CLIs are logged in with (imported as the
+machine's name, or --name). The tool argument is optional and limits the
+import to one provider. The host may be an alias from ~/.ssh/config.
+
+  caam auth import --from-ssh build-1                 # Pull everything
+  caam auth import codex --from-ssh me@build-1:2222   # Only Codex
+  caam auth import --from-ssh build-1 --dry-run       # Show what would be pulled`,
+	Args: cobra.RangeArgs(0, 1),
 	RunE: runAuthImport,
 }
 
@@ -161,6 +173,10 @@ func init() {
 	authImportCmd.Flags().Bool("force", false, "overwrite existing profile")
 	authImportCmd.Flags().String("source", "", "path to auth file (overrides detection)")
 	authImportCmd.Flags().Bool("json", false, "output in JSON format")
+	authImportCmd.Flags().String("from-ssh", "", "pull profiles from [user@]host[:port] over SSH")
+	authImportCmd.Flags().String("ssh-key", "", "SSH private key for --from-ssh")
+	authImportCmd.Flags().String("remote-vault", "", "vault path on the remote machine (default ~/.local/share/caam/vault)")
+	authImportCmd.Flags().Bool("dry-run", false, "with --from-ssh, show what would be imported")
 }
 
 func runAuthDetection(providers []provider.Provider) *AuthDetectReport {
@@ -369,6 +385,12 @@ func formatFileSize(bytes int64) string {
 
 // runAuthImport implements the auth import command.
 func runAuthImport(cmd *cobra.Command, args []string) error {
+	if host, _ := cmd.Flags().GetString("from-ssh"); host != "" {
+		return runAuthImportFromSSH(cmd, host, args)
+	}
+	if len(args) != 1 {
+		return caamerr.Errorf(caamerr.InvalidArgs, "auth import needs a tool, or --from-ssh <host>")
+	}
 	tool := strings.ToLower(args[0])
 	name, _ := cmd.Flags().GetString("name")
 	description, _ := cmd.Flags().GetString("description")
@@ -379,12 +401,12 @@ func runAuthImport(cmd *cobra.Command, args []string) error {
 	// Validate provider
 	prov, ok := registry.Get(tool)
 	if !ok {
-		return fmt.Errorf("unknown tool: %s (supported: claude, codex, gemini)", tool)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: claude, codex, gemini)", tool)
 	}
 
 	// Check if profile exists
 	if profileStore.Exists(tool, name) && !force {
-		return fmt.Errorf("profile %s/%s already exists (use --force to overwrite)", tool, name)
+		return caamerr.Errorf(caamerr.AlreadyExists, "profile %s/%s already exists (use --force to overwrite)", tool, name)
 	}
 
 	// Determine source file
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+// AuthImportSSHResult is the JSON output of auth import --from-ssh.
+type AuthImportSSHResult struct {
+	Host     string                   `json:"host"`
+	DryRun   bool                     `json:"dry_run"`
+	Profiles []syncstate.ImportResult `json:"profiles"`
+	Pulled   int                      `json:"pulled"`
+	Skipped  int                      `json:"skipped"`
+	Failed   int                      `json:"failed"`
+}
+
+func runAuthImportFromSSH(cmd *cobra.Command, host string, args []string) error {
+	keyPath, _ := cmd.Flags().GetString("ssh-key")
+	remoteVault, _ := cmd.Flags().GetString("remote-vault")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	var providers []string
+	if len(args) == 1 {
+		tool := strings.ToLower(args[0])
+		if _, ok := tools[tool]; !ok {
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: %s)", tool, strings.Join(toolNames(), ", "))
+		}
+		providers = []string{tool}
+	}
+
+	m := importMachine(host, keyPath)
+	liveProfile := m.Name
+	if cmd.Flags().Changed("name") {
+		liveProfile, _ = cmd.Flags().GetString("name")
+	}
+
+	syncConfig := syncstate.DefaultSyncerConfig()
+	syncConfig.VaultPath = vault.BasePath()
+	if remoteVault != "" {
+		syncConfig.RemoteVaultPath = remoteVault
+	}
+	syncer, err := syncstate.NewSyncer(syncConfig)
+	if err != nil {
+		return fmt.Errorf("create syncer: %w", err)
+	}
+	defer syncer.Close()
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	results, err := syncer.ImportFromMachine(ctx, m, syncstate.ImportOptions{
+		Providers:   providers,
+		LiveProfile: liveProfile,
+		DryRun:      dryRun,
+	})
+	if err != nil {
+		return fmt.Errorf("import from %s: %w", host, err)
+	}
+
+	out := AuthImportSSHResult{Host: host, DryRun: dryRun, Profiles: results}
+	if out.Profiles == nil {
+		out.Profiles = []syncstate.ImportResult{}
+	}
+	for _, r := range results {
+		switch r.Action {
+		case syncstate.ImportPulled:
+			out.Pulled++
+		case syncstate.ImportSkipped:
+			out.Skipped++
+		case syncstate.ImportFailed:
+			out.Failed++
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	} else {
+		verb := "Imported"
+		if dryRun {
+			verb = "Would import"
+		}
+		fmt.Fprintf(w, "%s from %s:\n", verb, host)
+		if len(results) == 0 {
+			fmt.Fprintln(w, "  nothing found: no vault profiles or logged-in CLIs")
+		}
+		for _, r := range results {
+			mark := "✓"
+			switch r.Action {
+			case syncstate.ImportSkipped:
+				mark = "-"
+			case syncstate.ImportFailed:
+				mark = "✗"
+			}
+			fmt.Fprintf(w, "  %s %s/%s (%s): %s, %s\n", mark, r.Provider, r.Profile, r.Source, r.Action, r.Reason)
+		}
+		fmt.Fprintf(w, "\n%d pulled, %d up to date, %d failed\n", out.Pulled, out.Skipped, out.Failed)
+	}
+
+	if out.Failed > 0 {
+		return caamerr.Errorf(caamerr.PartialSuccess, "%d of %d profiles from %s failed to import", out.Failed, len(results), host)
+	}
+	return nil
+}
+
+// importMachine builds the machine auth import --from-ssh connects to. A
+// host matching a Host alias in ~/.ssh/config takes its settings from
+// there; otherwise host is parsed as [user@]host[:port].
+func importMachine(host, keyPath string) *syncstate.Machine {
+	var m *syncstate.Machine
+	if discovered, err := syncstate.DiscoverFromSSHConfig(); err == nil {
+		for _, d := range discovered {
+			if strings.EqualFold(d.Name, host) {
+				m = d
+				break
+			}
+		}
+	}
+	if m == nil {
+		addr, port, user := syncstate.ParseAddress(host)
+		m = syncstate.NewMachine(addr, addr)
+		if port != 0 {
+			m.Port = port
+		}
+		m.SSHUser = user
+	}
+	if keyPath != "" {
+		m.SSHKeyPath = keyPath
+	}
+	return m
+}
@@ -3,6 +3,8 @@ package cmd
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -508,3 +510,25 @@ func TestAuthDetectSummaryJSON(t *testing.T) {
 		t.Error("JSON should contain not_found_count field")
 	}
 }
+
+func TestImportMachine(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	m := importMachine("deploy@build-1.internal:2222", "")
+	if m.Name != "build-1.internal" || m.Address != "build-1.internal" || m.Port != 2222 || m.SSHUser != "deploy" {
+		t.Errorf("parsed machine = %+v", m)
+	}
+
+	sshConfig := "Host box\n  HostName box.example.com\n  User ci\n  Port 2200\n"
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "config"), []byte(sshConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m = importMachine("box", "/keys/caam")
+	if m.Name != "box" || m.Address != "box.example.com" || m.Port != 2200 || m.SSHUser != "ci" || m.SSHKeyPath != "/keys/caam" {
+		t.Errorf("ssh config machine = %+v", m)
+	}
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// autoBackupBeforeActivate saves the live auth before target replaces it,
+// following safety.auto_backup_before_switch ("always" when force is set).
+//
+// Live auth that matches no vault profile is usually the last activated
+// profile after its CLI refreshed the token, so it is saved back to that
+// profile when the files show the same account. Otherwise it goes to a
+// timestamped _backup_ profile, rotated to safety.max_auto_backups. It
+// returns the profile written, or "" if nothing needed saving.
+func autoBackupBeforeActivate(fileSet authfile.AuthFileSet, target string, spmCfg *config.SPMConfig, force bool) (string, error) {
+	mode, maxBackups := "smart", 0
+	if spmCfg != nil {
+		if m := strings.TrimSpace(spmCfg.Safety.AutoBackupBeforeSwitch); m != "" {
+			mode = m
+		}
+		maxBackups = spmCfg.Safety.MaxAutoBackups
+	}
+	if force {
+		mode = "always"
+	}
+	if mode == "never" || !authfile.HasAuthFiles(fileSet) {
+		return "", nil
+	}
+
+	current, _ := vault.ActiveProfile(fileSet)
+	if current == "" {
+		if profile := lastActivatedProfile(fileSet); profile != "" {
+			if err := vault.Backup(fileSet, profile); err != nil {
+				return "", fmt.Errorf("save refreshed auth to %s: %w", profile, err)
+			}
+			return profile, nil
+		}
+	} else if mode != "always" || current == target {
+		return "", nil
+	}
+
+	backupName, err := vault.BackupCurrent(fileSet)
+	if err != nil || backupName == "" {
+		return "", err
+	}
+	if maxBackups > 0 {
+		if err := vault.RotateAutoBackups(fileSet.Tool, maxBackups); err != nil {
+			return backupName, fmt.Errorf("rotate old backups: %w", err)
+		}
+	}
+	return backupName, nil
+}
+
+// lastActivatedProfile returns the profile most recently activated for the
+// file set's tool, if it is still in the vault and its files belong to the
+// same account as the live ones.
+func lastActivatedProfile(fileSet authfile.AuthFileSet) string {
+	db, err := caamdb.Open()
+	if err != nil {
+		return ""
+	}
+	defer db.Close()
+
+	evs, err := db.LatestEvents(events.Filter{Types: []events.Type{events.ProfileActivated}, Provider: fileSet.Tool}, 1)
+	if err != nil || len(evs) == 0 {
+		return ""
+	}
+	profile := evs[0].Profile
+	if profile == "" || authfile.IsSystemProfile(profile) {
+		return ""
+	}
+	if profiles, err := vault.List(fileSet.Tool); err != nil || !slices.Contains(profiles, profile) {
+		return ""
+	}
+
+	if !sameAccount(fileSet, profile) {
+		return ""
+	}
+	return profile
+}
+
+// accountFields are the auth file fields that name an account, and
+// refreshFields the ones that hold a refresh token.
+var (
+	accountFields = []string{"email", "emailAddress", "account_id", "accountId", "accountUuid", "user"}
+	refreshFields = []string{"refresh_token", "refreshToken"}
+)
+
+// sameAccount reports whether the live auth files positively belong to the
+// vault profile: some account field or refresh token has the same value in
+// both, and no account field differs. Files that carry neither, like
+// current Claude credentials after a fresh login, never match.
+func sameAccount(fileSet authfile.AuthFileSet, profile string) bool {
+	matched := false
+	for _, spec := range fileSet.Files {
+		live := authFields(spec.Path)
+		saved := authFields(vault.BackupPath(fileSet.Tool, profile, spec.VaultFileName()))
+		for path, liveValue := range live {
+			savedValue, ok := saved[path]
+			if !ok {
+				continue
+			}
+			key := path[strings.LastIndex(path, ".")+1:]
+			switch {
+			case slices.Contains(accountFields, key) && strings.EqualFold(liveValue, savedValue):
+				matched = true
+			case slices.Contains(accountFields, key):
+				return false
+			case liveValue == savedValue:
+				matched = true
+			}
+		}
+	}
+	return matched
+}
+
+// authFields returns the account and refresh token fields of a JSON auth
+// file, keyed by their dotted path.
+func authFields(path string) map[string]string {
+	data, err := vaultcrypt.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil
+	}
+	fields := make(map[string]string)
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for key, value := range obj {
+			path := prefix + key
+			if s, ok := value.(string); ok {
+				if s != "" && (slices.Contains(accountFields, key) || slices.Contains(refreshFields, key)) {
+					fields[path] = s
+				}
+				continue
+			}
+			walk(path+".", value)
+		}
+	}
+	walk("", root)
+	return fields
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -60,10 +61,29 @@ Encryption:
   The password can be provided via --password or will be prompted interactively.
   Encrypted bundles have .enc.zip extension and require the password to import.
 
+Recipients:
+  Use --recipient (or --recipient-key) to encrypt to teammates' public keys
+  instead of (or in addition to) a shared password. Accepts age keys
+  (age1...), SSH public keys (ssh-ed25519, ssh-rsa), or a file of keys, one
+  per line (authorized_keys or age recipients format). --gpg-recipient
+  encrypts to a key in your GnuPG keyring, by fingerprint, key ID, or email.
+  Any single recipient can open the bundle with their private key: "caam
+  bundle import --identity <key>" for age and SSH keys, or just "caam bundle
+  import" when the GPG secret key is in the local keyring. Repeat --password
+  to add more than one passphrase. Recipients are listed in the bundle
+  manifest.
+
 Filtering:
   --provider: Only include specific providers (claude, codex, gemini)
   --profiles: Only include profiles matching patterns (e.g., "work", "alice")
 
+Delta bundles:
+  --since-last: Only include profiles whose files changed since the last
+                export (each export records its manifest for this)
+  --since DATE: Only include profiles with a file modified since YYYY-MM-DD
+  Importing a delta merges it like any bundle; profiles it leaves out are
+  untouched.
+
 Optional content (included by default, can be excluded):
   --no-config: Exclude configuration file
   --no-projects: Exclude project associations
@@ -76,10 +96,16 @@ Examples:
   caam bundle export -o /backup               # Export to specific directory
   caam bundle export -e                       # Export with encryption (prompted)
   caam bundle export -e -p "secret123"        # Export with password
+  caam bundle export --recipient ~/.ssh/id_ed25519.pub \
+                     --recipient age1ql3z7hjy54pw3hyww5ay...  # Share with two keys
+  caam bundle export --gpg-recipient alice@example.com  # Share with a GPG key
   caam bundle export --provider claude,codex  # Only Claude and Codex
   caam bundle export --profiles "work,team"   # Only matching profiles
-  caam bundle export --dry-run                # Preview without creating`,
-	RunE: runBundleExport,
+  caam bundle export --dry-run                # Preview without creating
+  caam bundle export --since-last             # Only profiles changed since last export
+  caam bundle export --since 2024-06-01       # Only profiles modified since a date
+  caam bundle export --recipient-key ~/.ssh/id_ed25519.pub --async  # Run as a daemon job`,
+	RunE: jobRunE("bundle-export", runBundleExport, nil),
 }
 
 func init() {
@@ -90,14 +116,20 @@ func init() {
 	bundleExportCmd.Flags().StringP("output", "o", "", "output directory (default: current directory)")
 	bundleExportCmd.Flags().Bool("verbose-filename", false, "use descriptive filename with timestamp")
 	bundleExportCmd.Flags().Bool("dry-run", false, "preview export without creating files")
+	addAsyncFlag(bundleExportCmd)
 
 	// Encryption options
 	bundleExportCmd.Flags().BoolP("encrypt", "e", false, "encrypt the bundle with AES-256-GCM")
-	bundleExportCmd.Flags().StringP("password", "p", "", "encryption password (prompted if not provided)")
+	bundleExportCmd.Flags().StringArrayP("password", "p", nil, "encryption password (prompted if not provided; repeatable)")
+	bundleExportCmd.Flags().StringArray("recipient", nil, "encrypt to an age or SSH public key, or a file of keys (repeatable)")
+	bundleExportCmd.Flags().StringArray("recipient-key", nil, "same as --recipient")
+	bundleExportCmd.Flags().StringArray("gpg-recipient", nil, "encrypt to a GnuPG key by fingerprint, key ID, or email (repeatable)")
 
 	// Filtering options
 	bundleExportCmd.Flags().StringSlice("provider", nil, "only include specific providers (claude,codex,gemini)")
 	bundleExportCmd.Flags().StringSlice("profiles", nil, "only include profiles matching patterns")
+	bundleExportCmd.Flags().Bool("since-last", false, "only include profiles changed since the last export (delta bundle)")
+	bundleExportCmd.Flags().String("since", "", "only include profiles modified since a date (YYYY-MM-DD; delta bundle)")
 
 	// Content inclusion options (defaults match bundle.DefaultExportOptions)
 	bundleExportCmd.Flags().Bool("no-config", false, "exclude configuration file")
@@ -127,9 +159,41 @@ func runBundleExport(cmd *cobra.Command, args []string) error {
 
 	// Encryption options
 	opts.Encrypt, _ = cmd.Flags().GetBool("encrypt")
-	password, _ := cmd.Flags().GetString("password")
+	passwords, _ := cmd.Flags().GetStringArray("password")
+	recipientKeys, _ := cmd.Flags().GetStringArray("recipient")
+	legacyKeys, _ := cmd.Flags().GetStringArray("recipient-key")
+	recipientKeys = append(recipientKeys, legacyKeys...)
+	gpgRecipients, _ := cmd.Flags().GetStringArray("gpg-recipient")
+
+	for _, key := range recipientKeys {
+		recipients, err := loadRecipientKeys(key)
+		if err != nil {
+			return fmt.Errorf("--recipient %s: %w", key, err)
+		}
+		opts.Recipients = append(opts.Recipients, recipients...)
+	}
+	for _, id := range gpgRecipients {
+		r, err := bundle.NewGPGRecipient(id)
+		if err != nil {
+			return fmt.Errorf("--gpg-recipient %s: %w", id, err)
+		}
+		opts.Recipients = append(opts.Recipients, r)
+	}
+	if len(passwords) > 1 {
+		for _, extra := range passwords[1:] {
+			if extra == "" {
+				return fmt.Errorf("password cannot be empty")
+			}
+			opts.Recipients = append(opts.Recipients, bundle.NewPassphraseRecipient(extra))
+		}
+	}
+	password := ""
+	if len(passwords) > 0 {
+		password = passwords[0]
+	}
 
-	if opts.Encrypt {
+	// Recipient keys alone are enough; only prompt when a password is wanted
+	if opts.Encrypt && !(password == "" && len(recipientKeys)+len(gpgRecipients) > 0) {
 		if password == "" {
 			// Prompt for password
 			var err error
@@ -156,6 +220,13 @@ func runBundleExport(cmd *cobra.Command, args []string) error {
 	// Filtering options
 	opts.ProviderFilter, _ = cmd.Flags().GetStringSlice("provider")
 	opts.ProfileFilter, _ = cmd.Flags().GetStringSlice("profiles")
+	if since, _ := cmd.Flags().GetString("since"); since != "" {
+		t, err := parseSince(0, since)
+		if err != nil {
+			return err
+		}
+		opts.Since = t
+	}
 
 	// Content inclusion options
 	noConfig, _ := cmd.Flags().GetBool("no-config")
@@ -184,6 +255,17 @@ func runBundleExport(cmd *cobra.Command, args []string) error {
 		SyncPath:     syncstate.SyncDataDir(),
 	}
 
+	if sinceLast, _ := cmd.Flags().GetBool("since-last"); sinceLast {
+		base, err := bundle.LoadLastExport(dataPath)
+		if err != nil {
+			return fmt.Errorf("load last export: %w", err)
+		}
+		if base == nil {
+			return fmt.Errorf("no previous export recorded; run a full 'caam bundle export' first")
+		}
+		opts.Base = base
+	}
+
 	// Preview mode
 	if opts.DryRun {
 		fmt.Fprintln(cmd.OutOrStdout(), "Dry run - previewing export:")
@@ -192,10 +274,21 @@ func runBundleExport(cmd *cobra.Command, args []string) error {
 
 	// Perform export
 	result, err := exporter.Export(opts)
+	if errors.Is(err, bundle.ErrNoChanges) {
+		fmt.Fprintln(cmd.OutOrStdout(), "No profiles changed; nothing to export.")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("export failed: %w", err)
 	}
 
+	// Record what was exported for the next --since-last
+	if !opts.DryRun {
+		if err := bundle.SaveLastExport(dataPath, result.State); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: could not record export for --since-last: %v\n", err)
+		}
+	}
+
 	// Print results
 	printExportResult(cmd, result, opts.DryRun)
 
@@ -219,6 +312,10 @@ func printExportResult(cmd *cobra.Command, result *bundle.ExportResult, dryRun b
 	for provider, profiles := range manifest.Contents.Vault.Profiles {
 		fmt.Fprintf(out, "  %s: %s\n", provider, strings.Join(profiles, ", "))
 	}
+	if manifest.Delta != nil {
+		fmt.Fprintf(out, "Delta: %s (%d unchanged profiles left out)\n",
+			describeDelta(manifest.Delta), manifest.Delta.UnchangedProfiles)
+	}
 
 	// Optional content
 	fmt.Fprintln(out)
@@ -239,10 +336,17 @@ func printExportResult(cmd *cobra.Command, result *bundle.ExportResult, dryRun b
 	}
 
 	if result.Encrypted {
-		fmt.Fprintln(out, "Encryption: AES-256-GCM with Argon2id")
+		if len(manifest.Recipients) > 1 || (len(manifest.Recipients) == 1 && manifest.Recipients[0].Type != bundle.RecipientPassphrase) {
+			fmt.Fprintf(out, "Encryption: AES-256-GCM, key wrapped for %d recipients:\n", len(manifest.Recipients))
+			for _, r := range manifest.Recipients {
+				fmt.Fprintf(out, "  %-12s %s\n", r.Type, r.Label)
+			}
+		} else {
+			fmt.Fprintln(out, "Encryption: AES-256-GCM with Argon2id")
+		}
 		if !dryRun {
 			fmt.Fprintln(out)
-			fmt.Fprintln(out, "⚠️  Store your password securely - it cannot be recovered!")
+			fmt.Fprintln(out, "⚠️  Store your password or private keys securely - they cannot be recovered!")
 		}
 	} else {
 		fmt.Fprintln(out)
@@ -251,6 +355,18 @@ func printExportResult(cmd *cobra.Command, result *bundle.ExportResult, dryRun b
 	}
 }
 
+// describeDelta says what a delta bundle's profiles changed relative to.
+func describeDelta(d *bundle.DeltaInfo) string {
+	var parts []string
+	if d.BaseExportTimestamp != nil {
+		parts = append(parts, "changed since the export of "+d.BaseExportTimestamp.Local().Format("2006-01-02 15:04"))
+	}
+	if d.Since != nil {
+		parts = append(parts, "modified since "+d.Since.Local().Format("2006-01-02"))
+	}
+	return strings.Join(parts, ", ")
+}
+
 func printContentStatus(out io.Writer, name string, content bundle.OptionalContent) {
 	if content.Included {
 		note := ""
@@ -270,6 +386,19 @@ func printContentStatus(out io.Writer, name string, content bundle.OptionalConte
 	}
 }
 
+// loadRecipientKeys parses a --recipient-key value: a key string, or a file
+// containing one key per line.
+func loadRecipientKeys(value string) ([]bundle.Recipient, error) {
+	if data, err := os.ReadFile(value); err == nil {
+		return bundle.ParseRecipients(data)
+	}
+	r, err := bundle.ParseRecipient(value)
+	if err != nil {
+		return nil, err
+	}
+	return []bundle.Recipient{r}, nil
+}
+
 // promptPassword reads a password from the terminal without echo.
 func promptPassword(prompt string) (string, error) {
 	fmt.Print(prompt)
@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
@@ -14,6 +16,7 @@ import (
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/project"
 	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
 	"golang.org/x/term"
 )
 
@@ -37,18 +40,24 @@ Import Modes:
     - Does NOT delete local profiles not in bundle
 
 Encrypted Bundles:
-  Bundles with .enc.zip extension require a password.
-  Provide via --password or you will be prompted.
+  Bundles with .enc.zip extension require a password or a private key.
+  Provide via --password or you will be prompted. Bundles exported with
+  --recipient open with any matching --identity (an age identity file or
+  SSH private key); ~/.ssh/id_ed25519, ~/.ssh/id_rsa, and
+  ~/.config/age/keys.txt are tried by default. Bundles exported with
+  --gpg-recipient open with the secret key in your GnuPG keyring.
 
 Examples:
   caam bundle import ~/backup.zip                    # Smart import
   caam bundle import ~/backup.zip --dry-run          # Preview changes
   caam bundle import ~/backup.enc.zip                # Encrypted (prompts)
+  caam bundle import team.enc.zip --identity ~/.ssh/id_ed25519  # Open with a key
   caam bundle import ~/backup.zip --mode merge       # Add new only
   caam bundle import ~/backup.zip --mode replace     # Overwrite all
-  caam bundle import ~/backup.zip --provider claude  # Only Claude`,
+  caam bundle import ~/backup.zip --provider claude  # Only Claude
+  caam bundle import ~/backup.zip --force --async    # Run as a daemon job`,
 	Args: cobra.ExactArgs(1),
-	RunE: runBundleImport,
+	RunE: jobRunE("bundle-import", runBundleImport, nil),
 }
 
 func init() {
@@ -59,10 +68,12 @@ func init() {
 
 	// Encryption
 	bundleImportCmd.Flags().StringP("password", "p", "", "Password for encrypted bundles")
+	bundleImportCmd.Flags().StringArray("identity", nil, "Age identity file or SSH private key for bundles encrypted to recipients (repeatable)")
 
 	// Preview/control
 	bundleImportCmd.Flags().Bool("dry-run", false, "Preview import without making changes")
 	bundleImportCmd.Flags().Bool("force", false, "Skip confirmation prompts")
+	addAsyncFlag(bundleImportCmd)
 
 	// Optional content exclusion
 	bundleImportCmd.Flags().Bool("skip-config", false, "Don't import configuration")
@@ -107,7 +118,15 @@ func runBundleImport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("check encryption: %w", err)
 	}
 
-	if encrypted && password == "" {
+	if encrypted {
+		identityPaths, _ := cmd.Flags().GetStringArray("identity")
+		opts.Identities, err = loadBundleIdentities(bundlePath, identityPaths)
+		if err != nil {
+			return err
+		}
+	}
+
+	if encrypted && password == "" && needsBundlePassword(bundlePath, opts.Identities) {
 		var err error
 		password, err = promptPassword("Enter decryption password: ")
 		if err != nil {
@@ -187,6 +206,12 @@ func printImportPreview(cmd *cobra.Command, result *bundle.ImportResult) {
 		if result.Encrypted {
 			fmt.Fprintln(out, "  Encrypted: yes")
 		}
+		for _, r := range result.Manifest.Recipients {
+			fmt.Fprintf(out, "  Recipient: %s %s\n", r.Type, r.Label)
+		}
+		if d := result.Manifest.Delta; d != nil {
+			fmt.Fprintf(out, "  Delta: %s; profiles not in the bundle are left as they are\n", describeDelta(d))
+		}
 	}
 
 	// Verification
@@ -318,6 +343,105 @@ func printImportResult(cmd *cobra.Command, result *bundle.ImportResult) {
 	fmt.Fprintln(out, "Import complete. Use 'caam status' to verify.")
 }
 
+// defaultSSHIdentities are tried for bundles encrypted to SSH keys when no
+// --identity is given.
+var defaultSSHIdentities = []string{"id_ed25519", "id_rsa"}
+
+// defaultAgeIdentity is the age identity file, relative to the home
+// directory, tried when no --identity is given.
+var defaultAgeIdentity = filepath.Join(".config", "age", "keys.txt")
+
+// loadBundleIdentities loads the private keys used to open a bundle encrypted
+// to recipients. Passphrase-protected SSH keys are only unlocked (prompting
+// for their passphrase) when they match one of the bundle's recipients.
+func loadBundleIdentities(bundlePath string, paths []string) ([]bundle.Identity, error) {
+	meta, err := bundle.LoadBundleEncryptionMetadata(bundlePath)
+	if err != nil || meta.KDF != bundle.KDFRecipients {
+		// Password-only bundle, or the import will report the bad metadata
+		if len(paths) > 0 && err == nil {
+			return nil, fmt.Errorf("bundle is password-encrypted; --identity does not apply")
+		}
+		return nil, nil
+	}
+
+	explicit := len(paths) > 0
+	if !explicit {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		for _, name := range defaultSSHIdentities {
+			paths = append(paths, filepath.Join(home, ".ssh", name))
+		}
+		paths = append(paths, filepath.Join(home, defaultAgeIdentity))
+	}
+
+	keyIDs := make(map[string]bool)
+	for _, r := range meta.Recipients {
+		if r.KeyID != "" {
+			keyIDs[r.KeyID] = true
+		}
+	}
+
+	var identities []bundle.Identity
+	// GPG stanzas open with the local keyring whether or not --identity is
+	// given; gpg-agent asks for the key passphrase itself.
+	if bundle.HasGPGRecipients(meta) {
+		identities = append(identities, bundle.GPGIdentity{})
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if explicit {
+				return nil, fmt.Errorf("read identity: %w", err)
+			}
+			continue
+		}
+
+		ids, err := bundle.ParseIdentities(data)
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			if missing.PublicKey == nil || !keyIDs[ssh.FingerprintSHA256(missing.PublicKey)] {
+				continue
+			}
+			passphrase, perr := promptPassword(fmt.Sprintf("Enter passphrase for %s: ", path))
+			if perr != nil {
+				return nil, fmt.Errorf("read key passphrase: %w", perr)
+			}
+			key, perr := ssh.ParseRawPrivateKeyWithPassphrase(data, []byte(passphrase))
+			if perr != nil {
+				return nil, fmt.Errorf("decrypt %s: %w", path, perr)
+			}
+			id, perr := bundle.NewSSHIdentity(key)
+			if perr != nil {
+				return nil, fmt.Errorf("%s: %w", path, perr)
+			}
+			ids, err = []bundle.Identity{id}, nil
+		}
+		if err != nil {
+			if explicit {
+				return nil, fmt.Errorf("parse identity %s: %w", path, err)
+			}
+			continue
+		}
+		identities = append(identities, ids...)
+	}
+	return identities, nil
+}
+
+// needsBundlePassword reports whether an encrypted bundle still needs a
+// password after trying identities.
+func needsBundlePassword(bundlePath string, identities []bundle.Identity) bool {
+	meta, err := bundle.LoadBundleEncryptionMetadata(bundlePath)
+	if err != nil {
+		return true
+	}
+	if bundle.CanUnwrap(meta, identities) {
+		return false
+	}
+	return meta.NeedsPassphrase()
+}
+
 // promptPasswordImport reads a password from the terminal for import.
 // This is separate to avoid confusion with the export password prompt.
 func promptPasswordImport(prompt string) (string, error) {
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/browser"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+)
+
+// captureResult is the JSON output of capture.
+type captureResult struct {
+	Tool       string   `json:"tool"`
+	Profile    string   `json:"profile"`
+	Browser    string   `json:"browser"`
+	ProfileDir string   `json:"profile_dir"`
+	Domains    []string `json:"domains"`
+	Cookies    int      `json:"cookies"`
+	Restored   bool     `json:"restored,omitempty"`
+}
+
+var captureCmd = &cobra.Command{
+	Use:   "capture <tool> [profile-name]",
+	Short: "Capture a browser login session into a vault profile",
+	Long: `Copies the cookies a provider's web login lives in from a browser profile
+into a vault profile, next to its auth files. When the profile is activated,
+the cookies are written back into the same browser profile, so the web app
+switches accounts along with the CLI.
+
+The profile defaults to the tool's active profile. Cookies are read from a
+copy of the browser's cookie database, so the browser may stay open while
+capturing; restoring needs it closed.
+
+Chrome, Chromium, Brave and Edge encrypt cookies with a per-machine key, so
+a captured session only restores into the browser profile it came from.
+Local storage is not captured.
+
+Examples:
+  caam capture claude --browser chrome --profile "Default"
+  caam capture codex work --browser firefox
+  caam capture claude work --domain claude.ai
+  caam capture claude work --restore        # Write the captured cookies back now`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runCapture,
+}
+
+func init() {
+	rootCmd.AddCommand(captureCmd)
+	captureCmd.Flags().String("browser", "chrome", "browser to capture from: "+strings.Join(browser.SessionBrowsers, ", "))
+	captureCmd.Flags().String("profile", "", "browser profile name or path (default: the browser's default profile)")
+	captureCmd.Flags().String("user-data-dir", "", "browser user data directory (default: the browser's standard location)")
+	captureCmd.Flags().StringArray("domain", nil, "cookie domain to capture (repeatable; default: the provider's domains)")
+	captureCmd.Flags().Bool("restore", false, "write the profile's captured session back into the browser instead of capturing")
+	captureCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+func runCapture(cmd *cobra.Command, args []string) error {
+	browserName, _ := cmd.Flags().GetString("browser")
+	browserProfile, _ := cmd.Flags().GetString("profile")
+	userDataDir, _ := cmd.Flags().GetString("user-data-dir")
+	domains, _ := cmd.Flags().GetStringArray("domain")
+	restore, _ := cmd.Flags().GetBool("restore")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	tool := strings.ToLower(args[0])
+	getFileSet, ok := tools[tool]
+	if !ok {
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: %s)", tool, strings.Join(toolNames(), ", "))
+	}
+
+	var profile string
+	if len(args) == 2 {
+		profile = args[1]
+	} else {
+		active, err := vault.ActiveProfile(getFileSet())
+		if err != nil || active == "" {
+			return caamerr.Errorf(caamerr.InvalidArgs, "no active %s profile; name the vault profile to capture into", tool)
+		}
+		profile = active
+	}
+	if _, err := os.Stat(vault.ProfilePath(tool, profile)); err != nil {
+		return caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s not found in vault; run 'caam backup %s %s' first", tool, profile, tool, profile)
+	}
+
+	var out captureResult
+	if restore {
+		session, n, err := restoreBrowserSession(tool, profile)
+		if err != nil {
+			return err
+		}
+		if session == nil {
+			return caamerr.Errorf(caamerr.NotFound, "profile %s/%s has no captured browser session", tool, profile)
+		}
+		out = captureResult{Browser: session.Browser, ProfileDir: session.ProfileDir, Domains: session.Domains, Cookies: n, Restored: true}
+	} else {
+		if len(domains) == 0 {
+			domains = browser.CookieDomains(tool)
+		}
+		domains = browser.SortedDomains(domains)
+		if len(domains) == 0 {
+			return caamerr.Errorf(caamerr.InvalidArgs, "no known cookie domains for %s; pass them with --domain", tool)
+		}
+		if userDataDir == "" {
+			dir, err := browser.UserDataDir(browserName)
+			if err != nil {
+				return caamerr.Errorf(caamerr.InvalidArgs, "%w", err)
+			}
+			userDataDir = dir
+		}
+		profileDir, err := browser.ProfileDir(browserName, userDataDir, browserProfile)
+		if err != nil {
+			return fmt.Errorf("find browser profile: %w", err)
+		}
+
+		session, err := browser.CaptureSession(browserName, profileDir, domains)
+		if err != nil {
+			return fmt.Errorf("capture %s session: %w", browserName, err)
+		}
+		if len(session.Cookies) == 0 {
+			return caamerr.Errorf(caamerr.NotFound, "no cookies for %s in %s; log in to the web app there first", strings.Join(domains, ", "), profileDir)
+		}
+		data, err := json.MarshalIndent(session, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := vault.WriteProfileFile(tool, profile, browser.SessionFile, data); err != nil {
+			return caamerr.Errorf(caamerr.SaveError, "save browser session: %w", err)
+		}
+		out = captureResult{Browser: session.Browser, ProfileDir: profileDir, Domains: domains, Cookies: len(session.Cookies)}
+	}
+	out.Tool, out.Profile = tool, profile
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	w := cmd.OutOrStdout()
+	if out.Restored {
+		fmt.Fprintf(w, "Restored %d cookies for %s/%s into %s (%s)\n", out.Cookies, tool, profile, out.Browser, out.ProfileDir)
+		return nil
+	}
+	fmt.Fprintf(w, "Captured %d cookies for %s from %s (%s) into %s/%s\n", out.Cookies, strings.Join(out.Domains, ", "), out.Browser, out.ProfileDir, tool, profile)
+	fmt.Fprintf(w, "  They are restored into the browser whenever '%s/%s' is activated\n", tool, profile)
+	return nil
+}
+
+// restoreBrowserSession writes a profile's captured browser session back
+// into the browser it came from. It returns a nil session if the profile
+// has none.
+func restoreBrowserSession(tool, profile string) (*browser.Session, int, error) {
+	data, err := vault.ReadProfileFile(tool, profile, browser.SessionFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("read browser session: %w", err)
+	}
+	var session browser.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, 0, fmt.Errorf("parse browser session: %w", err)
+	}
+	n, err := browser.RestoreSession(&session)
+	if err != nil {
+		return &session, 0, fmt.Errorf("restore %s session: %w", session.Browser, err)
+	}
+	return &session, n, nil
+}
+
+// activateBrowserSession restores a profile's browser session after
+// activation and describes the outcome. Activation never fails on it: the
+// CLI login is already in place.
+func activateBrowserSession(tool, profile string) string {
+	session, n, err := restoreBrowserSession(tool, profile)
+	switch {
+	case err != nil:
+		return "browser session not restored: " + err.Error()
+	case session == nil:
+		return ""
+	default:
+		return fmt.Sprintf("restored %d %s cookies captured %s", n, session.Browser, session.CapturedAt.Local().Format(time.DateTime))
+	}
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/chaos"
+	"github.com/spf13/cobra"
+)
+
+var chaosCmd = &cobra.Command{
+	Use:    "chaos",
+	Short:  "Inject failures to test automation against caam failure modes",
+	Hidden: true,
+	Long: `Simulate caam failure modes on demand so agents and CI can verify they
+handle them gracefully.
+
+Faults:
+  expired_tokens     every profile's token reports as expired
+  corrupt_auth       activating a profile fails as if the vault files were corrupt
+  db_locked          opening the activity database fails with SQLITE_BUSY
+  sync_unreachable   every sync machine fails to connect
+
+Faults persist until disabled (or until --for elapses). They can also be set
+per-process with CAAM_CHAOS=db_locked,expired_tokens (or CAAM_CHAOS=all).
+
+Examples:
+  caam chaos enable db_locked
+  caam chaos enable expired_tokens corrupt_auth --for 10m
+  caam chaos status --json
+  caam chaos disable`,
+}
+
+var chaosEnableCmd = &cobra.Command{
+	Use:   "enable <fault>...",
+	Short: "Enable one or more faults",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runChaosEnable,
+}
+
+var chaosDisableCmd = &cobra.Command{
+	Use:   "disable [fault]...",
+	Short: "Disable faults (all if none given)",
+	RunE:  runChaosDisable,
+}
+
+var chaosStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show injected faults",
+	Args:  cobra.NoArgs,
+	RunE:  runChaosStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(chaosCmd)
+	chaosCmd.AddCommand(chaosEnableCmd)
+	chaosCmd.AddCommand(chaosDisableCmd)
+	chaosCmd.AddCommand(chaosStatusCmd)
+
+	chaosEnableCmd.Flags().Duration("for", 0, "automatically disable after this long (0 = until disabled)")
+	chaosStatusCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+func runChaosEnable(cmd *cobra.Command, args []string) error {
+	forDur, _ := cmd.Flags().GetDuration("for")
+
+	state, err := chaos.LoadState()
+	if err != nil {
+		return err
+	}
+	if state.Expired() {
+		state = &chaos.State{}
+	}
+
+	enabled := make(map[chaos.Fault]bool, len(state.Faults))
+	for _, f := range state.Faults {
+		enabled[f] = true
+	}
+	for _, arg := range args {
+		if arg == "all" {
+			for _, f := range chaos.AllFaults {
+				enabled[f] = true
+			}
+			continue
+		}
+		f, err := chaos.ParseFault(arg)
+		if err != nil {
+			return err
+		}
+		enabled[f] = true
+	}
+
+	state.Faults = state.Faults[:0]
+	for f := range enabled {
+		state.Faults = append(state.Faults, f)
+	}
+	state.EnabledAt = time.Now()
+	state.ExpiresAt = time.Time{}
+	if forDur > 0 {
+		state.ExpiresAt = state.EnabledAt.Add(forDur)
+	}
+
+	if err := chaos.SaveState(state); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Chaos enabled: %v\n", state.Faults)
+	if !state.ExpiresAt.IsZero() {
+		fmt.Fprintf(cmd.OutOrStdout(), "Expires: %s\n", state.ExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func runChaosDisable(cmd *cobra.Command, args []string) error {
+	state, err := chaos.LoadState()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		state.Faults = nil
+	} else {
+		remove := make(map[chaos.Fault]bool, len(args))
+		for _, arg := range args {
+			f, err := chaos.ParseFault(arg)
+			if err != nil {
+				return err
+			}
+			remove[f] = true
+		}
+		kept := state.Faults[:0]
+		for _, f := range state.Faults {
+			if !remove[f] {
+				kept = append(kept, f)
+			}
+		}
+		state.Faults = kept
+	}
+
+	if err := chaos.SaveState(state); err != nil {
+		return err
+	}
+
+	if len(state.Faults) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "Chaos disabled")
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "Chaos still enabled: %v\n", state.Faults)
+	}
+	return nil
+}
+
+type chaosStatusOutput struct {
+	Active    []chaos.Fault `json:"active"`
+	StatePath string        `json:"state_path"`
+	ExpiresAt string        `json:"expires_at,omitempty"`
+}
+
+func runChaosStatus(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	out := chaosStatusOutput{
+		Active:    chaos.ActiveFaults(),
+		StatePath: chaos.StatePath(),
+	}
+	if out.Active == nil {
+		out.Active = []chaos.Fault{}
+	}
+	if state, err := chaos.LoadState(); err == nil && !state.ExpiresAt.IsZero() && !state.Expired() {
+		out.ExpiresAt = state.ExpiresAt.Format(time.RFC3339)
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(out.Active) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No faults injected")
+		return nil
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "Injected faults:")
+	for _, f := range out.Active {
+		fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", f)
+	}
+	if out.ExpiresAt != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "Expires: %s\n", out.ExpiresAt)
+	}
+	return nil
+}
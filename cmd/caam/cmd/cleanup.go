@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/spf13/cobra"
@@ -40,7 +41,7 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 	// Load config for retention settings
 	spmCfg, err := config.LoadSPMConfig()
 	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
 	}
 
 	cfg := caamdb.CleanupConfig{
@@ -56,7 +57,7 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 	// Open database
 	db, err := caamdb.Open()
 	if err != nil {
-		return fmt.Errorf("open database: %w", err)
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
 	}
 	defer db.Close()
 
@@ -69,6 +70,7 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 		if !quiet {
 			fmt.Println("Dry run - would delete:")
 			fmt.Printf("  Activity logs older than %d days: %d entries\n", cfg.RetentionDays, result.ActivityLogsDeleted)
+			fmt.Printf("  Sync history older than %d days: %d entries\n", cfg.RetentionDays, result.SyncHistoryDeleted)
 			fmt.Printf("  Stale profile stats (>%d days inactive): %d entries\n", cfg.AggregateRetentionDays, result.StatsEntriesDeleted)
 			if result.VacuumRan {
 				fmt.Println("  Would run VACUUM to reclaim space")
@@ -86,6 +88,7 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 	if !quiet {
 		fmt.Println("Cleanup complete:")
 		fmt.Printf("  Activity logs deleted: %d\n", result.ActivityLogsDeleted)
+		fmt.Printf("  Sync history deleted: %d\n", result.SyncHistoryDeleted)
 		fmt.Printf("  Profile stats deleted: %d\n", result.StatsEntriesDeleted)
 		if result.VacuumRan {
 			fmt.Println("  VACUUM ran to reclaim space")
@@ -122,7 +125,7 @@ func init() {
 func runDBStats(cmd *cobra.Command, args []string) error {
 	db, err := caamdb.Open()
 	if err != nil {
-		return fmt.Errorf("open database: %w", err)
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
 	}
 	defer db.Close()
 
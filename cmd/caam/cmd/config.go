@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	"gopkg.in/yaml.v3"
 )
@@ -19,7 +20,8 @@ var configCmd = &cobra.Command{
 	Short: "Manage Smart Profile Management configuration",
 	Long: `View and modify Smart Profile Management settings.
 
-Configuration is stored at ~/.caam/config.yaml
+Configuration is stored at ~/.caam/config.yaml, or in the active config
+environment (see 'caam config env').
 
 Examples:
   caam config show                              # Show current config
@@ -27,6 +29,13 @@ Examples:
   caam config set health.refresh_threshold 5m   # Set value
   caam config reset                             # Reset to defaults`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := enterNamespace(cmd); err != nil {
+			return err
+		}
+		if err := enterConfigEnv(cmd); err != nil {
+			return err
+		}
+
 		// Load SPM config
 		var err error
 		spmConfig, err = config.LoadSPMConfig()
@@ -133,7 +142,7 @@ Examples:
 		}
 
 		if err := spmConfig.Save(); err != nil {
-			return fmt.Errorf("save config: %w", err)
+			return caamerr.Errorf(caamerr.ConfigError, "save config: %w", err)
 		}
 
 		// Show updated value
@@ -167,7 +176,7 @@ Examples:
 
 		spmConfig = config.DefaultSPMConfig()
 		if err := spmConfig.Save(); err != nil {
-			return fmt.Errorf("save config: %w", err)
+			return caamerr.Errorf(caamerr.ConfigError, "save config: %w", err)
 		}
 
 		fmt.Println("Configuration reset to defaults")
@@ -221,6 +230,17 @@ func getConfigValue(cfg *config.SPMConfig, key string) (string, error) {
 			if field == "auth_pool" {
 				return getAuthPoolValue(&cfg.Daemon.AuthPool, subfield)
 			}
+			if field == "cooldown_probe" {
+				return getCooldownProbeValue(&cfg.Daemon.CooldownProbe, subfield)
+			}
+			if field == "usage_webhook" {
+				return getUsageWebhookValue(&cfg.Daemon.UsageWebhook, subfield)
+			}
+		case "automation":
+			if err := config.ValidateAutomationKey(field, subfield); err != nil {
+				return "", err
+			}
+			return strconv.FormatBool(cfg.AutomationEnabled(field, subfield)), nil
 		}
 		return "", fmt.Errorf("unknown nested key: %s", key)
 	}
@@ -370,6 +390,30 @@ func getAuthPoolValue(a *config.AuthPoolConfig, field string) (string, error) {
 	}
 }
 
+func getCooldownProbeValue(c *config.CooldownProbeConfig, field string) (string, error) {
+	switch field {
+	case "enabled":
+		return strconv.FormatBool(c.Enabled), nil
+	case "usage_ping":
+		return strconv.FormatBool(c.UsagePing), nil
+	case "extension":
+		return c.Extension.String(), nil
+	default:
+		return "", fmt.Errorf("unknown cooldown_probe field: %s", field)
+	}
+}
+
+func getUsageWebhookValue(u *config.UsageWebhookConfig, field string) (string, error) {
+	switch field {
+	case "listen":
+		return u.Listen, nil
+	case "secret":
+		return u.Secret, nil
+	default:
+		return "", fmt.Errorf("unknown usage_webhook field: %s", field)
+	}
+}
+
 // setConfigValue sets a value in the config by key path.
 func setConfigValue(cfg *config.SPMConfig, key, value string) error {
 	parts := strings.Split(key, ".")
@@ -407,6 +451,18 @@ func setConfigValue(cfg *config.SPMConfig, key, value string) error {
 			if field == "auth_pool" {
 				return setAuthPoolValue(&cfg.Daemon.AuthPool, subfield, value)
 			}
+			if field == "cooldown_probe" {
+				return setCooldownProbeValue(&cfg.Daemon.CooldownProbe, subfield, value)
+			}
+			if field == "usage_webhook" {
+				return setUsageWebhookValue(&cfg.Daemon.UsageWebhook, subfield, value)
+			}
+		case "automation":
+			b, err := parseBool(value)
+			if err != nil {
+				return err
+			}
+			return cfg.SetAutomation(field, subfield, b)
 		}
 		return fmt.Errorf("unknown nested key: %s", key)
 	}
@@ -438,13 +494,13 @@ func setHealthValue(h *config.HealthConfig, field, value string) error {
 	case "refresh_threshold":
 		d, err := time.ParseDuration(value)
 		if err != nil {
-			return fmt.Errorf("invalid duration: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid duration: %w", err)
 		}
 		h.RefreshThreshold = config.Duration(d)
 	case "warning_threshold":
 		d, err := time.ParseDuration(value)
 		if err != nil {
-			return fmt.Errorf("invalid duration: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid duration: %w", err)
 		}
 		h.WarningThreshold = config.Duration(d)
 	case "penalty_decay_rate":
@@ -456,7 +512,7 @@ func setHealthValue(h *config.HealthConfig, field, value string) error {
 	case "penalty_decay_interval":
 		d, err := time.ParseDuration(value)
 		if err != nil {
-			return fmt.Errorf("invalid duration: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid duration: %w", err)
 		}
 		h.PenaltyDecayInterval = config.Duration(d)
 	default:
@@ -476,13 +532,13 @@ func setAnalyticsValue(a *config.AnalyticsConfig, field, value string) error {
 	case "retention_days":
 		i, err := strconv.Atoi(value)
 		if err != nil {
-			return fmt.Errorf("invalid integer: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid integer: %w", err)
 		}
 		a.RetentionDays = i
 	case "aggregate_retention_days":
 		i, err := strconv.Atoi(value)
 		if err != nil {
-			return fmt.Errorf("invalid integer: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid integer: %w", err)
 		}
 		a.AggregateRetentionDays = i
 	case "cleanup_on_startup":
@@ -554,13 +610,13 @@ func setAlertsValue(a *config.AlertConfig, field, value string) error {
 	case "warning_threshold":
 		i, err := strconv.Atoi(value)
 		if err != nil {
-			return fmt.Errorf("invalid integer: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid integer: %w", err)
 		}
 		a.WarningThreshold = i
 	case "critical_threshold":
 		i, err := strconv.Atoi(value)
 		if err != nil {
-			return fmt.Errorf("invalid integer: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid integer: %w", err)
 		}
 		a.CriticalThreshold = i
 	default:
@@ -602,13 +658,13 @@ func setHandoffValue(h *config.HandoffConfig, field, value string) error {
 	case "debounce_delay":
 		d, err := time.ParseDuration(value)
 		if err != nil {
-			return fmt.Errorf("invalid duration: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid duration: %w", err)
 		}
 		h.DebounceDelay = config.Duration(d)
 	case "max_retries":
 		i, err := strconv.Atoi(value)
 		if err != nil {
-			return fmt.Errorf("invalid integer: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid integer: %w", err)
 		}
 		h.MaxRetries = i
 	case "fallback_to_manual":
@@ -628,13 +684,13 @@ func setDaemonValue(d *config.DaemonConfig, field, value string) error {
 	case "check_interval":
 		dur, err := time.ParseDuration(value)
 		if err != nil {
-			return fmt.Errorf("invalid duration: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid duration: %w", err)
 		}
 		d.CheckInterval = config.Duration(dur)
 	case "refresh_threshold":
 		dur, err := time.ParseDuration(value)
 		if err != nil {
-			return fmt.Errorf("invalid duration: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid duration: %w", err)
 		}
 		d.RefreshThreshold = config.Duration(dur)
 	case "verbose":
@@ -660,19 +716,19 @@ func setAuthPoolValue(a *config.AuthPoolConfig, field, value string) error {
 	case "max_concurrent_refresh":
 		i, err := strconv.Atoi(value)
 		if err != nil {
-			return fmt.Errorf("invalid integer: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid integer: %w", err)
 		}
 		a.MaxConcurrentRefresh = i
 	case "refresh_retry_delay":
 		d, err := time.ParseDuration(value)
 		if err != nil {
-			return fmt.Errorf("invalid duration: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid duration: %w", err)
 		}
 		a.RefreshRetryDelay = config.Duration(d)
 	case "max_refresh_retries":
 		i, err := strconv.Atoi(value)
 		if err != nil {
-			return fmt.Errorf("invalid integer: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid integer: %w", err)
 		}
 		a.MaxRefreshRetries = i
 	default:
@@ -681,6 +737,44 @@ func setAuthPoolValue(a *config.AuthPoolConfig, field, value string) error {
 	return nil
 }
 
+func setCooldownProbeValue(c *config.CooldownProbeConfig, field, value string) error {
+	switch field {
+	case "enabled":
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		c.Enabled = b
+	case "usage_ping":
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		c.UsagePing = b
+	case "extension":
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid duration: %w", err)
+		}
+		c.Extension = config.Duration(d)
+	default:
+		return fmt.Errorf("unknown cooldown_probe field: %s", field)
+	}
+	return nil
+}
+
+func setUsageWebhookValue(u *config.UsageWebhookConfig, field, value string) error {
+	switch field {
+	case "listen":
+		u.Listen = value
+	case "secret":
+		u.Secret = value
+	default:
+		return fmt.Errorf("unknown usage_webhook field: %s", field)
+	}
+	return nil
+}
+
 // parseBool parses various boolean representations.
 func parseBool(s string) (bool, error) {
 	switch strings.ToLower(s) {
@@ -849,7 +943,7 @@ Examples:
 		}
 
 		if err := spmConfig.Save(); err != nil {
-			return fmt.Errorf("save config: %w", err)
+			return caamerr.Errorf(caamerr.ConfigError, "save config: %w", err)
 		}
 
 		// Show updated value
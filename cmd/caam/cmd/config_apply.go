@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/apply"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+var configApplyCmd = &cobra.Command{
+	Use:   "apply <file>",
+	Short: "Apply a declarative configuration file",
+	Long: `Brings this machine's configuration to the state described in a YAML file:
+sync machines and settings, rotation policies, per-provider settings, and
+notification targets. Applying the same file again changes nothing.
+
+Each section present in the file is the desired state of that part of the
+configuration; sections left out are not touched. Machines are matched by
+name and only added or updated unless sync.prune_machines is true.
+Policies are replaced as a whole.
+
+  machines:
+    - name: build-1
+      address: deploy@build-1.internal:22
+      ssh_key: ~/.ssh/caam_sync
+    - name: laptop
+      transport: https
+      address: https://dav.example.com/caam
+  sync:
+    enabled: true
+    auto: true
+    prune_machines: false
+  policies:
+    - provider: claude
+      every: 4h
+  providers:
+    claude:
+      default_profile: work
+      favorites: [work, spare]
+      automation: {auto_rotate: true}
+      risk_tiers: {personal: high}
+      weights: {work: 2}
+  notifications:
+    enabled: true
+    slack: https://hooks.slack.com/services/...
+    events: {token_expiring: false}
+
+Use --dry-run to see the changes as a diff without saving anything.
+
+Examples:
+  caam config apply fleet.yaml --dry-run
+  caam config apply fleet.yaml
+  caam config apply fleet.yaml --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigApply,
+}
+
+func init() {
+	configCmd.AddCommand(configApplyCmd)
+	configApplyCmd.Flags().Bool("dry-run", false, "show what would change without saving")
+	configApplyCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+type configApplyOutput struct {
+	File    string         `json:"file"`
+	DryRun  bool           `json:"dry_run"`
+	Applied bool           `json:"applied"`
+	Changes []apply.Change `json:"changes"`
+}
+
+func runConfigApply(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	spec, err := apply.Load(args[0])
+	if err != nil {
+		return caamerr.Wrap(caamerr.InvalidArgs, err)
+	}
+	var unknown []string
+	for provider := range spec.Providers {
+		if _, ok := tools[provider]; !ok {
+			unknown = append(unknown, provider)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider(s) in %s: %s (valid: %s)",
+			args[0], strings.Join(unknown, ", "), strings.Join(toolNames(), ", "))
+	}
+
+	globalCfg, err := config.Load()
+	if err != nil {
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
+	}
+	target := apply.Target{Config: globalCfg, SPM: spmConfig}
+	var state *syncstate.SyncState
+	if len(spec.Machines) > 0 || spec.Sync != nil {
+		if state, err = loadSyncState(); err != nil {
+			return err
+		}
+		target.Pool = state.Pool
+	}
+
+	result, err := spec.Apply(target)
+	if err != nil {
+		return caamerr.Wrap(caamerr.ConfigError, err)
+	}
+
+	if !dryRun {
+		if result.ConfigChanged {
+			if err := globalCfg.Save(); err != nil {
+				return caamerr.Errorf(caamerr.SaveError, "save config: %w", err)
+			}
+		}
+		if result.SPMChanged {
+			if err := spmConfig.Save(); err != nil {
+				return caamerr.Errorf(caamerr.SaveError, "save SPM config: %w", err)
+			}
+		}
+		if result.PoolChanged {
+			if err := state.Save(); err != nil {
+				return caamerr.Errorf(caamerr.SaveError, "save sync state: %w", err)
+			}
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		changes := result.Changes
+		if changes == nil {
+			changes = []apply.Change{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(configApplyOutput{
+			File:    args[0],
+			DryRun:  dryRun,
+			Applied: !dryRun && len(changes) > 0,
+			Changes: changes,
+		})
+	}
+
+	if len(result.Changes) == 0 {
+		fmt.Fprintln(out, "Configuration already matches; nothing to change.")
+		return nil
+	}
+	for _, c := range result.Changes {
+		fmt.Fprintln(out, c.String())
+	}
+	fmt.Fprintln(out)
+	if dryRun {
+		fmt.Fprintf(out, "%d change(s) would be applied (dry run).\n", len(result.Changes))
+	} else {
+		fmt.Fprintf(out, "Applied %d change(s).\n", len(result.Changes))
+	}
+	return nil
+}
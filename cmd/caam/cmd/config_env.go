@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+var configEnvCmd = &cobra.Command{
+	Use:     "env",
+	Aliases: []string{"environment"},
+	Short:   "Switch between named sets of caam configuration",
+	Long: `Config environments keep separate caam configuration side by side, such as
+a work setup with its coordinator, sync pool, and policies and a personal
+one, and switch between them.
+
+Each environment has its own config.json, config.yaml (policies, budgets,
+notifications, coordinators), and sync state, stored in
+~/.config/caam/envs/<name>. Profiles and the vault are not affected.
+
+The environment in effect is, in order: --config-env, CAAM_CONFIG_ENV, then
+the one chosen with 'caam config env use'. "default" means the usual
+configuration outside any environment.
+
+Examples:
+  caam config env create work --copy   # Start from the current config
+  caam config env use work
+  caam config env list
+  caam --config-env personal sync status
+  caam config env use default`,
+}
+
+var configEnvListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List config environments",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigEnvList,
+}
+
+var configEnvCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a config environment",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigEnvCreate,
+}
+
+var configEnvUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Switch to a config environment (\"default\" to leave)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigEnvUse,
+}
+
+var configEnvCurrentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Show the config environment in effect and its files",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigEnvCurrent,
+}
+
+func init() {
+	configCmd.AddCommand(configEnvCmd)
+	configEnvCmd.AddCommand(configEnvListCmd)
+	configEnvCmd.AddCommand(configEnvCreateCmd)
+	configEnvCmd.AddCommand(configEnvUseCmd)
+	configEnvCmd.AddCommand(configEnvCurrentCmd)
+
+	rootCmd.PersistentFlags().String("config-env", "", "config environment to use (env: CAAM_CONFIG_ENV)")
+
+	configEnvListCmd.Flags().Bool("json", false, "output as JSON")
+	configEnvCreateCmd.Flags().Bool("copy", false, "start from a copy of the configuration in effect")
+	configEnvCreateCmd.Flags().Bool("use", false, "switch to the new environment")
+	configEnvCurrentCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// enterConfigEnv switches this process into the config environment named
+// by --config-env, if given. CAAM_CONFIG_ENV and 'caam config env use' are
+// resolved by the config package itself.
+func enterConfigEnv(cmd *cobra.Command) error {
+	name, _ := cmd.Flags().GetString("config-env")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	return configEnvError(config.EnterEnv(name))
+}
+
+func configEnvError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, config.ErrInvalidEnvName):
+		return caamerr.Wrap(caamerr.InvalidArgs, err)
+	case errors.Is(err, config.ErrEnvNotFound):
+		return caamerr.Errorf(caamerr.ConfigError, "%w (create it with 'caam config env create')", err)
+	default:
+		return caamerr.Wrap(caamerr.ConfigError, err)
+	}
+}
+
+type configEnvInfo struct {
+	Name       string `json:"name"`
+	Current    bool   `json:"current"`
+	Config     string `json:"config"`
+	SPMConfig  string `json:"spm_config"`
+	SyncDir    string `json:"sync_dir"`
+	SelectedBy string `json:"selected_by,omitempty"`
+}
+
+// configEnvPaths describes environment name ("" for the default) by
+// resolving the paths with it selected.
+func configEnvPaths(name string) configEnvInfo {
+	prev, had := os.LookupEnv(config.ConfigEnvVar)
+	value := name
+	if value == "" {
+		value = config.DefaultEnv
+	}
+	os.Setenv(config.ConfigEnvVar, value)
+	defer func() {
+		if had {
+			os.Setenv(config.ConfigEnvVar, prev)
+		} else {
+			os.Unsetenv(config.ConfigEnvVar)
+		}
+	}()
+
+	info := configEnvInfo{
+		Name:      value,
+		Config:    config.ConfigPath(),
+		SPMConfig: config.SPMConfigPath(),
+		SyncDir:   syncstate.SyncDataDir(),
+	}
+	return info
+}
+
+func runConfigEnvList(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	names, err := config.ListEnvs()
+	if err != nil {
+		return caamerr.Wrap(caamerr.ConfigError, err)
+	}
+
+	active := config.ActiveEnv()
+	infos := []configEnvInfo{configEnvPaths("")}
+	for _, name := range names {
+		infos = append(infos, configEnvPaths(name))
+	}
+	for i := range infos {
+		infos[i].Current = infos[i].Name == active || (active == "" && infos[i].Name == config.DefaultEnv)
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  ENVIRONMENT\tCONFIG DIR")
+	for _, info := range infos {
+		marker := " "
+		if info.Current {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\n", marker, info.Name, filepath.Dir(info.Config))
+	}
+	return w.Flush()
+}
+
+func runConfigEnvCreate(cmd *cobra.Command, args []string) error {
+	name := strings.TrimSpace(args[0])
+	copyCurrent, _ := cmd.Flags().GetBool("copy")
+	use, _ := cmd.Flags().GetBool("use")
+
+	dir, err := config.CreateEnv(name, copyCurrent)
+	if err != nil {
+		return configEnvError(err)
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Created config environment %s in %s\n", name, dir)
+	if !use {
+		fmt.Fprintf(out, "Switch to it with: caam config env use %s\n", name)
+		return nil
+	}
+	if err := config.UseEnv(name); err != nil {
+		return configEnvError(err)
+	}
+	fmt.Fprintf(out, "Now using config environment %s\n", name)
+	return nil
+}
+
+func runConfigEnvUse(cmd *cobra.Command, args []string) error {
+	name := strings.TrimSpace(args[0])
+	if err := config.UseEnv(name); err != nil {
+		return configEnvError(err)
+	}
+	out := cmd.OutOrStdout()
+	if name == config.DefaultEnv {
+		fmt.Fprintln(out, "Now using the default configuration")
+	} else {
+		fmt.Fprintf(out, "Now using config environment %s\n", name)
+	}
+	if override := os.Getenv(config.ConfigEnvVar); override != "" && override != name && !cmd.Flags().Changed("config-env") {
+		fmt.Fprintf(out, "Note: %s=%s overrides this in the current shell.\n", config.ConfigEnvVar, override)
+	}
+	return nil
+}
+
+func runConfigEnvCurrent(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	info := configEnvPaths(config.ActiveEnv())
+	info.Current = true
+	switch {
+	case cmd.Flags().Changed("config-env"):
+		info.SelectedBy = "flag"
+	case os.Getenv(config.ConfigEnvVar) != "":
+		info.SelectedBy = "env"
+	case config.SelectedEnv() != "":
+		info.SelectedBy = "config env use"
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	fmt.Fprintf(out, "Environment: %s\n", info.Name)
+	if info.SelectedBy != "" {
+		fmt.Fprintf(out, "Selected by: %s\n", info.SelectedBy)
+	}
+	fmt.Fprintf(out, "Config:      %s\n", info.Config)
+	fmt.Fprintf(out, "SPM config:  %s\n", info.SPMConfig)
+	fmt.Fprintf(out, "Sync state:  %s\n", info.SyncDir)
+	return nil
+}
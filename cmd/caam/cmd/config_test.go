@@ -371,6 +371,55 @@ func TestSetConfigValue_Project(t *testing.T) {
 	}
 }
 
+func TestSetConfigValue_Automation(t *testing.T) {
+	cfg := config.DefaultSPMConfig()
+
+	if got, err := getConfigValue(cfg, "automation.codex.auto_refresh"); err != nil || got != "true" {
+		t.Errorf("getConfigValue(automation.codex.auto_refresh) = %q, %v; want true", got, err)
+	}
+
+	if err := setConfigValue(cfg, "automation.codex.auto_refresh", "false"); err != nil {
+		t.Fatalf("setConfigValue(automation.codex.auto_refresh) error: %v", err)
+	}
+	if got, _ := getConfigValue(cfg, "automation.codex.auto_refresh"); got != "false" {
+		t.Errorf("Expected false, got %q", got)
+	}
+	if !cfg.AutomationEnabled("claude", config.AutomationRefresh) {
+		t.Error("claude auto_refresh should be unaffected")
+	}
+
+	if err := setConfigValue(cfg, "automation.codex.auto_refresh", "maybe"); err == nil {
+		t.Error("Expected error for invalid boolean")
+	}
+	if _, err := getConfigValue(cfg, "automation.codex.auto_teleport"); err == nil {
+		t.Error("Expected error for unknown automation feature")
+	}
+}
+
+func TestSetConfigValue_CooldownProbe(t *testing.T) {
+	cfg := config.DefaultSPMConfig()
+
+	if got, err := getConfigValue(cfg, "daemon.cooldown_probe.enabled"); err != nil || got != "true" {
+		t.Errorf("getConfigValue(daemon.cooldown_probe.enabled) = %q, %v; want true", got, err)
+	}
+
+	if err := setConfigValue(cfg, "daemon.cooldown_probe.usage_ping", "yes"); err != nil {
+		t.Fatalf("setConfigValue(usage_ping) error: %v", err)
+	}
+	if err := setConfigValue(cfg, "daemon.cooldown_probe.extension", "45m"); err != nil {
+		t.Fatalf("setConfigValue(extension) error: %v", err)
+	}
+	if !cfg.Daemon.CooldownProbe.UsagePing {
+		t.Error("Expected usage_ping to be true")
+	}
+	if cfg.Daemon.CooldownProbe.Extension.Duration() != 45*time.Minute {
+		t.Errorf("Expected extension 45m, got %v", cfg.Daemon.CooldownProbe.Extension)
+	}
+	if err := setConfigValue(cfg, "daemon.cooldown_probe.interval", "1m"); err == nil {
+		t.Error("Expected error for unknown cooldown_probe field")
+	}
+}
+
 func TestSetConfigValue_InvalidKeys(t *testing.T) {
 	cfg := config.DefaultSPMConfig()
 
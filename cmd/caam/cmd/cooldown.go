@@ -9,8 +9,11 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/hooks"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
 	"github.com/spf13/cobra"
 )
 
@@ -41,7 +44,7 @@ var cooldownSetCmd = &cobra.Command{
 }
 
 func init() {
-	cooldownSetCmd.Flags().Int("minutes", 0, "cooldown duration in minutes (default: stealth.cooldown.default_minutes)")
+	cooldownSetCmd.Flags().Int("minutes", 0, "cooldown duration in minutes (default: the profile's stealth.cooldown template, else default_minutes)")
 	cooldownSetCmd.Flags().String("notes", "", "optional notes to store with the cooldown event")
 }
 
@@ -52,19 +55,34 @@ func runCooldownSet(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	notes, _ := cmd.Flags().GetString("notes")
+	hitAt := time.Now().UTC()
+
 	minutes, _ := cmd.Flags().GetInt("minutes")
+	duration := time.Duration(minutes) * time.Minute
+	var tpl *config.CooldownTemplate
 	if minutes <= 0 {
 		spmCfg, err := config.LoadSPMConfig()
 		if err != nil {
 			spmCfg = config.DefaultSPMConfig()
 		}
-		minutes = spmCfg.Stealth.Cooldown.DefaultMinutes
-	}
-	if minutes <= 0 {
-		minutes = 60
+		if tpl = cooldownTemplate(spmCfg, provider, profile); tpl != nil {
+			duration = tpl.Until(hitAt).Sub(hitAt)
+			if notes == "" {
+				notes = "template " + tpl.Name()
+			}
+		} else {
+			minutes = spmCfg.Stealth.Cooldown.DefaultMinutes
+			if minutes <= 0 {
+				minutes = 60
+			}
+			duration = time.Duration(minutes) * time.Minute
+		}
 	}
 
-	notes, _ := cmd.Flags().GetString("notes")
+	if err := runPreHook(hooks.PreCooldown, provider, profile, "cooldown"); err != nil {
+		return err
+	}
 
 	db, err := caamdb.Open()
 	if err != nil {
@@ -72,20 +90,44 @@ func runCooldownSet(cmd *cobra.Command, args []string) error {
 	}
 	defer db.Close()
 
-	ev, err := db.SetCooldown(provider, profile, time.Now().UTC(), time.Duration(minutes)*time.Minute, notes)
+	ev, err := db.SetCooldown(provider, profile, hitAt, jitterCooldown(duration), notes)
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprintf(cmd.OutOrStdout(), "Recorded cooldown for %s/%s until %s (%s remaining)\n",
+	source := ""
+	if tpl != nil {
+		source = fmt.Sprintf(", template %s", tpl.Name())
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Recorded cooldown for %s/%s until %s (%s remaining%s)\n",
 		ev.Provider,
 		ev.ProfileName,
 		ev.CooldownUntil.Local().Format("2006-01-02 15:04"),
 		formatDurationShort(time.Until(ev.CooldownUntil)),
+		source,
 	)
 	return nil
 }
 
+// cooldownTemplate returns the stealth.cooldown template for a profile,
+// matched on the plan type in its vault identity, or nil if none applies.
+func cooldownTemplate(spmCfg *config.SPMConfig, provider, profile string) *config.CooldownTemplate {
+	if spmCfg == nil {
+		return nil
+	}
+	plan := ""
+	if id := getVaultIdentity(provider, profile); id != nil {
+		plan = id.PlanType
+	}
+	tpl := spmCfg.Stealth.Cooldown.CooldownTemplateFor(provider, plan)
+	// API keys are metered per token, not by usage windows, so only a
+	// template written for the api plan applies to them.
+	if plan == identity.PlanAPI && tpl != nil && !strings.EqualFold(tpl.Plan, plan) {
+		return nil
+	}
+	return tpl
+}
+
 var cooldownClearCmd = &cobra.Command{
 	Use:   "clear [provider/profile|provider] [--all]",
 	Short: "Clear a cooldown (or all cooldowns)",
@@ -234,19 +276,19 @@ func renderCooldownList(w io.Writer, now time.Time, events []caamdb.CooldownEven
 func resolveProviderProfile(input string) (provider string, profile string, err error) {
 	input = strings.TrimSpace(input)
 	if input == "" {
-		return "", "", fmt.Errorf("profile is required")
+		return "", "", caamerr.Errorf(caamerr.InvalidArgs, "profile is required")
 	}
 
 	// Prefer explicit provider/profile.
 	if strings.Contains(input, "/") {
 		parts := strings.SplitN(input, "/", 2)
 		if len(parts) != 2 {
-			return "", "", fmt.Errorf("profile must be in provider/name format")
+			return "", "", caamerr.Errorf(caamerr.InvalidArgs, "profile must be in provider/name format")
 		}
 		provider = strings.TrimSpace(parts[0])
 		profile = strings.TrimSpace(parts[1])
 		if provider == "" || profile == "" {
-			return "", "", fmt.Errorf("profile must be in provider/name format")
+			return "", "", caamerr.Errorf(caamerr.InvalidArgs, "profile must be in provider/name format")
 		}
 		return provider, profile, nil
 	}
@@ -255,7 +297,7 @@ func resolveProviderProfile(input string) (provider string, profile string, err
 	tool := strings.ToLower(input)
 	getFileSet, ok := tools[tool]
 	if !ok {
-		return "", "", fmt.Errorf("unknown provider: %s (expected provider/name or supported provider)", input)
+		return "", "", caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s (expected provider/name or supported provider)", input)
 	}
 
 	fileSet := getFileSet()
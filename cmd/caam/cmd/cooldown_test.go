@@ -417,3 +417,58 @@ func TestConfirmProceed(t *testing.T) {
 		})
 	}
 }
+
+func TestCooldownSet_Template(t *testing.T) {
+	_, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+
+	cfgYAML := "stealth:\n  cooldown:\n    templates:\n      - provider: codex\n        duration: 3h\n"
+	if err := os.WriteFile(filepath.Join(os.Getenv("CAAM_HOME"), "config.yaml"), []byte(cfgYAML), 0600); err != nil {
+		t.Fatalf("WriteFile(config.yaml) error = %v", err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Int("minutes", 0, "")
+	cmd.Flags().String("notes", "", "")
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	if err := runCooldownSet(cmd, []string{"codex/work"}); err != nil {
+		t.Fatalf("runCooldownSet() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "template codex") {
+		t.Errorf("output should name the template, got: %s", buf.String())
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatalf("db.Open() error = %v", err)
+	}
+	defer db.Close()
+	ev, err := db.ActiveCooldown("codex", "work", time.Now().UTC())
+	if err != nil || ev == nil {
+		t.Fatalf("ActiveCooldown() = %v, %v", ev, err)
+	}
+	if got := ev.CooldownUntil.Sub(ev.HitAt); got != 3*time.Hour {
+		t.Errorf("cooldown length = %v, want 3h", got)
+	}
+	if ev.Notes != "template codex" {
+		t.Errorf("Notes = %q, want %q", ev.Notes, "template codex")
+	}
+
+	// With --include-cooldown, a templated cooldown near its window reset
+	// ranks ahead of one that just began.
+	if _, err := db.SetCooldown("codex", "nearly", time.Now().Add(-150*time.Minute), 3*time.Hour, ""); err != nil {
+		t.Fatalf("SetCooldown() error = %v", err)
+	}
+	scored := scoreRobotNextProfiles("codex", []string{"work", "nearly"}, "smart", true, db)
+	if len(scored) != 2 {
+		t.Fatalf("scored %d profiles, want 2", len(scored))
+	}
+	if scored[0].name != "nearly" {
+		t.Errorf("best = %s, want nearly (reasons %v)", scored[0].name, scored[0].reasons)
+	}
+	if c := scored[0].info.Cooldown; c == nil || c.Template != "codex" || c.WindowResetsAt == "" {
+		t.Errorf("cooldown = %+v, want codex template", c)
+	}
+}
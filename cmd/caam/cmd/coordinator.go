@@ -5,29 +5,43 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/coordinator"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/federation"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/signals"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
 	"github.com/spf13/cobra"
 )
 
 var coordinatorCmd = &cobra.Command{
-	Use:   "auth-coordinator",
-	Short: "Run the distributed auth recovery coordinator daemon",
-	Long: `Monitor terminal panes for Claude Code rate limits and coordinate authentication.
+	Use:     "auth-coordinator",
+	Aliases: []string{"coordinator"},
+	Short:   "Run the distributed auth recovery coordinator daemon",
+	Long: `Monitor terminal panes for Claude Code, Codex, and Gemini rate limits and coordinate authentication.
 
 The coordinator watches terminal panes for rate limit messages. When detected, it:
-1. Auto-injects /login command
-2. Selects Claude subscription login method
+1. Auto-injects the CLI's login command (/login, or /auth for Gemini)
+2. Selects the subscription login method
 3. Extracts the OAuth URL
 4. Exposes the URL via HTTP API for the local auth-agent
 5. Receives auth codes from the agent and injects them
 6. Resumes the session automatically
 
+Each pane's CLI is detected from its foreground process, title, or output.
+Codex receives its code on a localhost redirect, so for Codex panes the agent
+returns the redirect URL and the coordinator requests it on this machine.
+
 TERMINAL BACKENDS:
   WezTerm (PREFERRED) - Use WezTerm's native mux-server for best integration.
     Benefits: integrated multiplexing, domain awareness, rich metadata.
@@ -36,9 +50,35 @@ TERMINAL BACKENDS:
     Requires: tmux server running (tmux new-session -d)
     Limitations: no domain awareness, extra process layer, less metadata.
 
-This daemon should run on the remote machine where Claude Code sessions are running.
+  Kitty - Uses Kitty's remote control protocol (kitty @).
+    Requires: allow_remote_control yes in kitty.conf (and listen_on plus
+    KITTY_LISTEN_ON when the coordinator runs outside Kitty).
+
+  Zellij - Uses the zellij CLI against every running session.
+    Limitations: zellij actions target focused panes, so only the pane each
+    attached client has focused is monitored.
+
+With --backend auto, WezTerm is tried first, then tmux, Kitty, and Zellij.
+
+This daemon should run on the remote machine where the agent sessions are running.
 The local auth-agent connects to this coordinator to complete OAuth flows.
 
+To take manual control of panes for a while, pause injections with SIGUSR1 (or
+POST /pause) and resume with SIGUSR2 (or POST /resume). The coordinator keeps
+running while paused. To turn injection off permanently, set
+automation.claude.auto_login_inject to false (caam config set ...).
+
+HTTP API (also served by 'caam coordinator serve'):
+  GET  /                web page listing pending logins with a code form
+  GET  /status          coordinator and pane status
+  GET  /auth/pending    pending auth requests (id, pane, provider, url)
+  POST /auth/response   submit {"request_id", "code", "account"}; also
+                        accepts the web page's form post
+
+To finish logins by hand from a phone, bind a reachable address and set a
+token, then open http://<host>:7890/?token=<token>:
+  caam coordinator serve --host 100.64.0.5 --auth-token "$(openssl rand -hex 16)"
+
 Examples:
   # Start coordinator (auto-detects best backend)
   caam auth-coordinator
@@ -49,6 +89,10 @@ Examples:
   # Force tmux backend (for Ghostty/Alacritty/iTerm2)
   caam auth-coordinator --backend tmux
 
+  # Use Kitty or Zellij
+  caam auth-coordinator --backend kitty
+  caam auth-coordinator --backend zellij
+
   # Custom port and verbose logging
   caam auth-coordinator --port 7891 --verbose
 
@@ -66,22 +110,27 @@ var (
 	coordinatorBackend      string
 	coordinatorConfigPath   string
 	coordinatorAuthToken    string
+	coordinatorHost         string
 )
 
 func init() {
 	rootCmd.AddCommand(coordinatorCmd)
 
-	coordinatorCmd.Flags().IntVar(&coordinatorPort, "port", 7890, "API server port")
-	coordinatorCmd.Flags().IntVar(&coordinatorPollMs, "poll-interval", 500, "Pane poll interval in milliseconds")
-	coordinatorCmd.Flags().StringVar(&coordinatorResumePrompt, "resume-prompt",
+	// Persistent so 'serve' and 'status' share them.
+	flags := coordinatorCmd.PersistentFlags()
+	flags.IntVar(&coordinatorPort, "port", 7890, "API server port")
+	flags.IntVar(&coordinatorPollMs, "poll-interval", 500, "Pane poll interval in milliseconds")
+	flags.StringVar(&coordinatorResumePrompt, "resume-prompt",
 		"proceed. Reread AGENTS.md so it's still fresh in your mind. Use ultrathink.\n",
 		"Text to inject after successful auth")
-	coordinatorCmd.Flags().BoolVar(&coordinatorVerbose, "verbose", false, "Verbose output (debug level)")
-	coordinatorCmd.Flags().BoolVar(&coordinatorJSONLogs, "json", false, "Output logs in JSON format")
-	coordinatorCmd.Flags().StringVar(&coordinatorBackend, "backend", "auto",
-		"Terminal multiplexer backend: wezterm (preferred), tmux, or auto")
-	coordinatorCmd.Flags().StringVar(&coordinatorConfigPath, "config", "", "Path to JSON config file")
-	coordinatorCmd.Flags().StringVar(&coordinatorAuthToken, "auth-token", "", "Auth token for coordinator API (shared secret)")
+	flags.BoolVar(&coordinatorVerbose, "verbose", false, "Verbose output (debug level)")
+	flags.BoolVar(&coordinatorJSONLogs, "json", false, "Output logs in JSON format")
+	flags.StringVar(&coordinatorBackend, "backend", "auto",
+		"Terminal multiplexer backend: wezterm (preferred), tmux, kitty, zellij, or auto")
+	flags.StringVar(&coordinatorConfigPath, "config", "", "Path to JSON config file")
+	flags.StringVar(&coordinatorAuthToken, "auth-token", "", "Auth token for coordinator API (shared secret)")
+	flags.StringVar(&coordinatorHost, "host", "127.0.0.1",
+		"Address the API binds to (non-loopback addresses require --auth-token)")
 }
 
 func runCoordinator(cmd *cobra.Command, args []string) error {
@@ -99,7 +148,18 @@ func runCoordinator(cmd *cobra.Command, args []string) error {
 	} else {
 		logHandler = slog.NewTextHandler(os.Stderr, logOpts)
 	}
-	logger := slog.New(logHandler)
+	logger := teeCommandLog(logHandler)
+
+	// Per-provider automation switches live in the main config; each pane
+	// is checked against the switch of the provider running in it.
+	var disableLoginInject map[string]bool
+	var guard *coordinator.InjectionGuard
+	if spmCfg, err := config.LoadSPMConfig(); err == nil {
+		disableLoginInject = spmCfg.DisabledProviders(config.AutomationLoginInject)
+		if guard, err = newInjectionGuard(spmCfg.Injections); err != nil {
+			return err
+		}
+	}
 
 	config := coordinator.DefaultConfig()
 	apiPort := coordinatorPort
@@ -134,11 +194,24 @@ func runCoordinator(cmd *cobra.Command, args []string) error {
 	} else if envToken := strings.TrimSpace(os.Getenv("CAAM_COORDINATOR_TOKEN")); envToken != "" {
 		config.AuthToken = envToken
 	}
+	if cmd.Flags().Changed("host") || config.APIHost == "" {
+		config.APIHost = coordinatorHost
+	}
+	if !isLoopbackHost(config.APIHost) && strings.TrimSpace(config.AuthToken) == "" {
+		return fmt.Errorf("refusing to serve the coordinator API on %s without an auth token; set --auth-token or CAAM_COORDINATOR_TOKEN", config.APIHost)
+	}
 
 	config.Logger = logger
+	config.RunID = commandRunID()
+	config.DisableLoginInject = disableLoginInject
+	config.Guard = guard
+	for _, provider := range slices.Sorted(maps.Keys(disableLoginInject)) {
+		logger.Info("login injection disabled by automation."+provider+".auto_login_inject", "provider", provider)
+	}
 
 	// Create coordinator
 	coord := coordinator.New(config)
+	logger = logger.With("run_id", coord.RunID())
 
 	// Set up callbacks
 	coord.OnAuthRequest = func(req *coordinator.AuthRequest) {
@@ -162,6 +235,21 @@ func runCoordinator(cmd *cobra.Command, args []string) error {
 			err)
 	}
 
+	coord.Leases = coordinatorLeases
+	coord.OnRateLimit = func(paneID int, provider, resetText string) {
+		profile, err := recordRateLimit(provider, resetText, paneID)
+		if err != nil {
+			logger.Debug("rate limit not recorded", "pane_id", paneID, "provider", provider, "error", err)
+			return
+		}
+		fmt.Printf("[%s] RATE LIMITED pane=%d profile=%s/%s resets=%q\n",
+			time.Now().Format("15:04:05"),
+			paneID,
+			provider,
+			profile,
+			resetText)
+	}
+
 	// Create API server
 	api := coordinator.NewAPIServer(coord, apiPort, logger)
 
@@ -175,7 +263,7 @@ func runCoordinator(cmd *cobra.Command, args []string) error {
 
 	// Handle signals
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigCh, append([]os.Signal{syscall.SIGINT, syscall.SIGTERM}, signals.PauseResumeSignals()...)...)
 
 	// Start API server in background
 	errCh := make(chan error, 1)
@@ -185,26 +273,45 @@ func runCoordinator(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("Auth coordinator started\n")
 	fmt.Printf("  Backend: %s\n", coord.Backend())
-	fmt.Printf("  API: http://localhost:%d\n", apiPort)
+	fmt.Printf("  API: http://%s\n", net.JoinHostPort(config.APIHost, fmt.Sprint(apiPort)))
 	fmt.Printf("  Poll interval: %dms\n", int(config.PollInterval.Milliseconds()))
 	if config.AuthToken != "" {
 		fmt.Println("  Auth: token required")
 	}
-	if coord.Backend() == "tmux" {
+	switch coord.Backend() {
+	case "tmux":
 		fmt.Println("\nNote: Using tmux fallback. WezTerm is recommended for better integration.")
+	case "zellij":
+		fmt.Println("\nNote: Zellij only exposes focused panes; rate limits in background panes are picked up once they are focused.")
 	}
 	fmt.Println("\nWaiting for rate limits...")
 	fmt.Println("Press Ctrl+C to stop.")
 
-	// Wait for signal or error
-	select {
-	case <-sigCh:
-		fmt.Println("\nShutting down...")
-	case err := <-errCh:
-		if err != nil {
-			return fmt.Errorf("API server error: %w", err)
+	// Wait for shutdown signal or error; pause/resume signals keep running
+waitLoop:
+	for {
+		select {
+		case sig := <-sigCh:
+			switch sig {
+			case signals.PauseSignal:
+				coord.Pause()
+				fmt.Printf("[%s] PAUSED (send SIGUSR2 or POST /resume to resume)\n", time.Now().Format("15:04:05"))
+				continue
+			case signals.ResumeSignal:
+				coord.Resume()
+				fmt.Printf("[%s] RESUMED\n", time.Now().Format("15:04:05"))
+				continue
+			}
+			fmt.Println("\nShutting down...")
+			break waitLoop
+		case err := <-errCh:
+			if err != nil {
+				return fmt.Errorf("API server error: %w", err)
+			}
+			break waitLoop
+		case <-ctx.Done():
+			break waitLoop
 		}
-	case <-ctx.Done():
 	}
 
 	// Graceful shutdown
@@ -240,6 +347,7 @@ type coordinatorFileConfig struct {
 	OutputLines    int    `json:"output_lines"`
 	Backend        string `json:"backend"`
 	AuthToken      string `json:"auth_token"`
+	Host           string `json:"host"`
 }
 
 func loadCoordinatorConfig(path string) (coordinator.Config, int, error) {
@@ -302,6 +410,9 @@ func loadCoordinatorConfig(path string) (coordinator.Config, int, error) {
 	if raw.AuthToken != "" {
 		cfg.AuthToken = raw.AuthToken
 	}
+	if raw.Host != "" {
+		cfg.APIHost = raw.Host
+	}
 
 	return cfg, apiPort, nil
 }
@@ -312,30 +423,225 @@ func parseBackend(value string) (coordinator.Backend, error) {
 		return coordinator.BackendWezTerm, nil
 	case "tmux":
 		return coordinator.BackendTmux, nil
+	case "kitty":
+		return coordinator.BackendKitty, nil
+	case "zellij":
+		return coordinator.BackendZellij, nil
 	case "auto", "":
 		return coordinator.BackendAuto, nil
 	default:
-		return "", fmt.Errorf("invalid backend %q: use wezterm, tmux, or auto", value)
+		return "", fmt.Errorf("invalid backend %q: use wezterm, tmux, kitty, zellij, or auto", value)
 	}
 }
 
-// statusCmd shows coordinator status
+// coordinatorServeCmd runs the coordinator; it is the same as running
+// auth-coordinator directly.
+var coordinatorServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the coordinator and its HTTP API",
+	Args:  cobra.NoArgs,
+	RunE:  runCoordinator,
+}
+
+// coordinatorStatusCmd shows the status of a running coordinator.
 var coordinatorStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show auth-coordinator status",
+	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// This would make an HTTP request to the coordinator
-		// For now, just show how to check
-		fmt.Println("To check coordinator status:")
-		fmt.Printf("  curl http://localhost:%d/status\n", coordinatorPort)
-		fmt.Println("\nTo see pending auth requests:")
-		fmt.Printf("  curl http://localhost:%d/auth/pending\n", coordinatorPort)
+		baseURL, token := localCoordinatorEndpoint()
+
+		client := &http.Client{Timeout: 5 * time.Second}
+		status, err := fetchCoordinatorStatus(cmd.Context(), client, baseURL, token)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if coordinatorJSONLogs {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(status)
+		}
+
+		state := "running"
+		if status.Paused {
+			state = "paused"
+		}
+		fmt.Fprintf(out, "Coordinator: %s (%s backend) at %s\n", state, status.Backend, baseURL)
+		fmt.Fprintf(out, "Panes: %d  Pending logins: %d\n", status.PaneCount, status.PendingAuths)
+		for _, p := range status.Panes {
+			line := fmt.Sprintf("  pane %d: %s", p.PaneID, p.State)
+			if p.Provider != "" {
+				line += " (" + p.Provider + ")"
+			}
+			if p.Error != "" {
+				line += " - " + p.Error
+			}
+			fmt.Fprintln(out, line)
+		}
+		for _, req := range status.PendingDetails {
+			fmt.Fprintf(out, "  pending %s pane %d: %s\n", req.ID, req.PaneID, truncateURL(req.URL))
+		}
 		return nil
 	},
 }
 
+// coordinatorSubmitCmd routes an auth code to the coordinator holding the
+// request, which may be on another machine in the federation.
+var coordinatorSubmitCmd = &cobra.Command{
+	Use:   "submit <request-id> <code>",
+	Short: "Send an auth code to the coordinator waiting for it",
+	Long: `Sends the code for a pending login to the coordinator whose pane asked
+for it. The machine is looked up in the daemon's federation snapshot (see
+'caam robot status --include-coordinators'); requests it does not know
+about go to the local coordinator. Use --machine to pick one explicitly.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCoordinatorSubmit,
+}
+
 func init() {
+	coordinatorCmd.AddCommand(coordinatorServeCmd)
 	coordinatorCmd.AddCommand(coordinatorStatusCmd)
+	coordinatorCmd.AddCommand(coordinatorSubmitCmd)
+
+	coordinatorSubmitCmd.Flags().String("account", "manual", "Account the code was obtained with")
+	coordinatorSubmitCmd.Flags().String("machine", "", "Federated machine to send the code to (default: the one holding the request)")
+}
+
+// localCoordinatorEndpoint returns the base URL and token for the
+// coordinator on this machine, from the shared coordinator flags.
+func localCoordinatorEndpoint() (string, string) {
+	host := coordinatorHost
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	token := coordinatorAuthToken
+	if token == "" {
+		token = strings.TrimSpace(os.Getenv("CAAM_COORDINATOR_TOKEN"))
+	}
+	return "http://" + net.JoinHostPort(host, fmt.Sprint(coordinatorPort)), token
+}
+
+// recordRateLimit logs a rate_limit event for the provider's active profile
+// with the reset text seen in the pane, so 'caam robot act cooldown --auto'
+// can set the cooldown to the reset time. It returns the profile.
+func recordRateLimit(provider, resetText string, paneID int) (string, error) {
+	getFileSet, ok := tools[provider]
+	if !ok {
+		return "", fmt.Errorf("unknown provider: %s", provider)
+	}
+	profile, err := vault.ActiveProfile(getFileSet())
+	if err != nil {
+		return "", err
+	}
+	if profile == "" {
+		return "", fmt.Errorf("no active %s profile", provider)
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	return profile, db.LogEvent(caamdb.Event{
+		Type:        caamdb.EventRateLimit,
+		Provider:    provider,
+		ProfileName: profile,
+		Details: map[string]any{
+			"reset_text": resetText,
+			"source":     "coordinator",
+			"pane_id":    paneID,
+		},
+	})
+}
+
+func runCoordinatorSubmit(cmd *cobra.Command, args []string) error {
+	requestID, code := args[0], strings.TrimSpace(args[1])
+	if code == "" {
+		return fmt.Errorf("auth code is empty")
+	}
+	account, _ := cmd.Flags().GetString("account")
+	machine, _ := cmd.Flags().GetString("machine")
+
+	if machine == "" {
+		snap, err := federation.LoadSnapshot(federation.SnapshotPath())
+		if err != nil {
+			return err
+		}
+		machine, _ = snap.FindRequest(requestID)
+	}
+
+	peer, err := coordinatorPeer(machine)
+	if err != nil {
+		return err
+	}
+
+	resp := coordinator.AuthResponse{RequestID: requestID, Code: code, Account: account}
+	if err := peer.Submit(cmd.Context(), 30*time.Second, resp); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Sent code for %s to %s\n", requestID, peer.Name)
+	return nil
+}
+
+// coordinatorPeer returns the federated machine's coordinator, or the
+// local one when machine is empty.
+func coordinatorPeer(machine string) (federation.Peer, error) {
+	if machine == "" {
+		baseURL, token := localCoordinatorEndpoint()
+		return federation.Peer{Name: "local", Transport: federation.TransportHTTP, URL: baseURL, Token: token}, nil
+	}
+
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return federation.Peer{}, err
+	}
+	pool, err := syncstate.LoadSyncPool()
+	if err != nil {
+		pool = nil
+	}
+	for _, p := range federation.Peers(spmCfg.Daemon.Federation, pool) {
+		if p.Name == machine {
+			return p, nil
+		}
+	}
+	return federation.Peer{}, fmt.Errorf("unknown federated machine %q (see 'caam sync status' and daemon.federation.peers)", machine)
+}
+
+// fetchCoordinatorStatus reads GET /status from a coordinator.
+func fetchCoordinatorStatus(ctx context.Context, client *http.Client, baseURL, token string) (*coordinator.StatusResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reach coordinator: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coordinator returned %s", resp.Status)
+	}
+
+	var status coordinator.StatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("decode status: %w", err)
+	}
+	return &status, nil
+}
+
+// isLoopbackHost reports whether host only accepts local connections.
+func isLoopbackHost(host string) bool {
+	if host == "" || strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 // filterClaudePanes returns true for panes likely running Claude Code.
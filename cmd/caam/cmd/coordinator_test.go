@@ -20,7 +20,8 @@ func TestLoadCoordinatorConfig(t *testing.T) {
   "state_timeout": "15s",
   "resume_prompt": "resume now",
   "output_lines": 55,
-  "backend": "tmux"
+  "backend": "tmux",
+  "host": "100.64.0.5"
 }`)
 	if err := os.WriteFile(path, data, 0600); err != nil {
 		t.Fatalf("write config: %v", err)
@@ -51,4 +52,23 @@ func TestLoadCoordinatorConfig(t *testing.T) {
 	if cfg.Backend != coordinator.BackendTmux {
 		t.Fatalf("Backend = %s, want %s", cfg.Backend, coordinator.BackendTmux)
 	}
+	if cfg.APIHost != "100.64.0.5" {
+		t.Fatalf("APIHost = %q, want %q", cfg.APIHost, "100.64.0.5")
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"":           true,
+		"localhost":  true,
+		"127.0.0.1":  true,
+		"::1":        true,
+		"0.0.0.0":    false,
+		"100.64.0.5": false,
+		"myhost":     false,
+	} {
+		if got := isLoopbackHost(host); got != want {
+			t.Errorf("isLoopbackHost(%q) = %v, want %v", host, got, want)
+		}
+	}
 }
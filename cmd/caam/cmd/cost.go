@@ -11,9 +11,12 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/pricing"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 	"github.com/spf13/cobra"
 )
 
@@ -40,7 +43,8 @@ Examples:
 
 Subcommands:
   caam cost sessions               # List recent wrap sessions
-  caam cost rates                  # Show/set cost rate configuration`,
+  caam cost rates                  # Show/set cost rate configuration
+  caam cost report                 # Priced token usage and budgets for a month`,
 	Args: cobra.NoArgs,
 	RunE: runCostSummary,
 }
@@ -102,11 +106,42 @@ The cost comparison shows:
 	RunE: runCostTokens,
 }
 
+var costReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report priced token usage and budgets for a month",
+	Long: `Prices the token usage recorded for each profile (see 'caam usage record')
+with the cost model and checks it against the configured monthly budgets.
+The projection extrapolates the month's spend so far to the whole month.
+
+Prices come from the built-in per-model tables; costs.prices in config.yaml
+overrides them in dollars per million tokens, and costs.budgets sets monthly
+limits per profile, per provider, or overall:
+
+  costs:
+    prices:
+      - provider: claude
+        model: claude-sonnet-4
+        input: 3
+        output: 15
+    budgets:
+      - provider: claude
+        profile: work
+        monthly_usd: 200
+
+Examples:
+  caam cost report                     # This month
+  caam cost report --month 2024-07     # A past month
+  caam cost report --provider claude --json`,
+	Args: cobra.NoArgs,
+	RunE: runCostReport,
+}
+
 func init() {
 	rootCmd.AddCommand(costCmd)
 	costCmd.AddCommand(costSessionsCmd)
 	costCmd.AddCommand(costRatesCmd)
 	costCmd.AddCommand(costTokensCmd)
+	costCmd.AddCommand(costReportCmd)
 
 	// Cost summary flags
 	costCmd.Flags().String("provider", "", "filter by provider (claude, codex, gemini)")
@@ -128,6 +163,11 @@ func init() {
 	// Tokens flags
 	costTokensCmd.Flags().StringP("last", "l", "30d", "time period to analyze (e.g., 7d, 30d, 24h)")
 	costTokensCmd.Flags().StringP("format", "f", "table", "output format: table, json, csv")
+
+	// Report flags
+	costReportCmd.Flags().String("month", "", "month to report, YYYY-MM (default: this month)")
+	costReportCmd.Flags().String("provider", "", "filter by provider (claude, codex, gemini)")
+	costReportCmd.Flags().Bool("json", false, "output as JSON")
 }
 
 func runCostSummary(cmd *cobra.Command, args []string) error {
@@ -139,7 +179,7 @@ func runCostSummary(cmd *cobra.Command, args []string) error {
 	if sinceStr != "" {
 		duration, err := parseDuration(sinceStr)
 		if err != nil {
-			return fmt.Errorf("invalid --since duration: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid --since duration: %w", err)
 		}
 		sinceTime = time.Now().Add(-duration)
 	}
@@ -171,7 +211,7 @@ func runCostSessions(cmd *cobra.Command, args []string) error {
 	if sinceStr != "" {
 		duration, err := parseDuration(sinceStr)
 		if err != nil {
-			return fmt.Errorf("invalid --since duration: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid --since duration: %w", err)
 		}
 		sinceTime = time.Now().Add(-duration)
 	}
@@ -669,7 +709,7 @@ func renderTokenCostAnalysis(w io.Writer, format string, analyses []TokenCostAna
 		return renderTokenCostTable(w, analyses)
 
 	default:
-		return fmt.Errorf("unsupported format: %s", format)
+		return caamerr.Errorf(caamerr.InvalidArgs, "unsupported format: %s", format)
 	}
 }
 
@@ -805,6 +845,26 @@ func renderTokenCostCSV(w io.Writer, analyses []TokenCostAnalysis) error {
 	return nil
 }
 
+// apiKeySpendSince prices a profile's metered usage since since, in
+// dollars. Usage of models without a known price is skipped.
+func apiKeySpendSince(db *caamdb.DB, provider, profile string, since time.Time) float64 {
+	if db == nil {
+		return 0
+	}
+	records, err := db.UsageSince(provider, profile, since)
+	if err != nil {
+		return 0
+	}
+	prices := costsConfig().PriceTable()
+	var total float64
+	for _, rec := range records {
+		if cost, ok := prices.TokenCost(provider, rec.Model, rec.InputTokens, rec.OutputTokens, rec.CacheReadTokens, rec.CacheCreateTokens); ok {
+			total += cost
+		}
+	}
+	return total
+}
+
 func formatTokenCount(tokens int64) string {
 	if tokens >= 1_000_000 {
 		return fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
@@ -838,3 +898,135 @@ func truncateTokenModel(s string, maxLen int) string {
 	}
 	return s[:maxLen-3] + "..."
 }
+
+// costsConfig returns the configured cost model and budgets, or an empty
+// config (built-in prices, no budgets) when config.yaml can't be loaded.
+func costsConfig() config.CostsConfig {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil || spmCfg == nil {
+		return config.CostsConfig{}
+	}
+	return spmCfg.Costs
+}
+
+func runCostReport(cmd *cobra.Command, args []string) error {
+	monthStr, _ := cmd.Flags().GetString("month")
+	provider, _ := cmd.Flags().GetString("provider")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	provider = strings.ToLower(strings.TrimSpace(provider))
+
+	now := time.Now()
+	month := now
+	if monthStr != "" {
+		m, err := time.ParseInLocation("2006-01", monthStr, time.Local)
+		if err != nil {
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid --month %q: use YYYY-MM", monthStr)
+		}
+		month = m
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, err := buildCostReport(db, costsConfig(), provider, month, now)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return renderCostReport(out, report, now)
+}
+
+// buildCostReport prices a month of metered usage. With a provider, only
+// that provider's usage and budgets are included.
+func buildCostReport(db *caamdb.DB, costs config.CostsConfig, provider string, month, now time.Time) (*usage.MonthReport, error) {
+	start, end := usage.MonthBounds(month)
+	records, err := db.UsageBetween(provider, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("get usage: %w", err)
+	}
+	var budgets []config.Budget
+	for _, b := range costs.Budgets {
+		if provider == "" || strings.EqualFold(b.Provider, provider) {
+			budgets = append(budgets, b)
+		}
+	}
+	return usage.BuildMonthReport(records, costs.PriceTable(), budgets, start, now), nil
+}
+
+// daemonBudgetReport builds the month's budget report for the daemon's
+// budget_threshold notifications, reloading costs from config.yaml.
+func daemonBudgetReport(now time.Time) (*usage.MonthReport, error) {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(spmCfg.Costs.Budgets) == 0 {
+		return &usage.MonthReport{Month: now.Format("2006-01")}, nil
+	}
+	db, err := caamdb.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return buildCostReport(db, spmCfg.Costs, "", now, now)
+}
+
+func renderCostReport(w io.Writer, report *usage.MonthReport, now time.Time) error {
+	fmt.Fprintf(w, "Cost report for %s\n\n", report.Month)
+	if len(report.Profiles) == 0 {
+		fmt.Fprintln(w, "No usage recorded for this month.")
+		fmt.Fprintln(w, "\nUsage is recorded with: caam usage record")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROFILE\tREQUESTS\tTOKENS\tCOST\tPROJECTED")
+	for _, ps := range report.Profiles {
+		cost := fmt.Sprintf("$%.2f", ps.CostUSD)
+		if ps.UnpricedTokens > 0 {
+			cost += "*"
+		}
+		fmt.Fprintf(tw, "%s/%s\t%d\t%s\t%s\t$%.2f\n",
+			ps.Provider, ps.ProfileName, ps.Requests, formatTokenCount(ps.TotalTokens), cost, ps.ProjectedUSD)
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t\t$%.2f\t$%.2f\n", report.TotalUSD, report.ProjectedUSD)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, ps := range report.Profiles {
+		if ps.UnpricedTokens > 0 {
+			fmt.Fprintln(w, "\n* Some models have no known price; set one under costs.prices in config.yaml.")
+			break
+		}
+	}
+
+	if len(report.Budgets) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "BUDGET\tSPENT\tLIMIT\tUSED\tPROJECTED")
+		for _, b := range report.Budgets {
+			used := fmt.Sprintf("%.0f%%", b.UsedPercent)
+			if b.Crossed > 0 {
+				used += " !"
+			}
+			fmt.Fprintf(tw, "%s\t$%.2f\t$%.2f\t%s\t$%.2f\n", b.Name, b.SpentUSD, b.BudgetUSD, used, b.ProjectedUSD)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if now.After(report.Start) && now.Before(report.End) {
+		fmt.Fprintln(w, "\nProjected figures extrapolate the month so far at the same rate.")
+	}
+	return nil
+}
@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 )
 
 func TestCostCommand(t *testing.T) {
@@ -30,6 +33,11 @@ func TestCostSubcommands(t *testing.T) {
 	if costRatesCmd.Use != "rates" {
 		t.Errorf("Expected Use 'rates', got %q", costRatesCmd.Use)
 	}
+
+	// Check report subcommand
+	if costReportCmd.Use != "report" {
+		t.Errorf("Expected Use 'report', got %q", costReportCmd.Use)
+	}
 }
 
 func TestCostFlags(t *testing.T) {
@@ -61,6 +69,75 @@ func TestCostFlags(t *testing.T) {
 	}
 }
 
+func TestCostReport(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CAAM_HOME", home)
+	cfg := `costs:
+  prices:
+    - provider: claude
+      model: m1
+      input: 10
+      output: 20
+  budgets:
+    - provider: claude
+      profile: work
+      monthly_usd: 10
+`
+	if err := os.WriteFile(filepath.Join(home, "config.yaml"), []byte(cfg), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatalf("db.Open() error = %v", err)
+	}
+	defer db.Close()
+	july := time.Date(2024, 7, 15, 12, 0, 0, 0, time.Local)
+	now := time.Now()
+	for _, rec := range []caamdb.UsageRecord{
+		{Timestamp: july, Provider: "claude", ProfileName: "work", Model: "m1", InputTokens: 1_000_000, OutputTokens: 500_000},
+		{Timestamp: july, Provider: "codex", ProfileName: "main", Model: "m1", InputTokens: 10},
+		{Timestamp: now, Provider: "claude", ProfileName: "work", Model: "m1", InputTokens: 100_000},
+	} {
+		if _, err := db.RecordUsage(rec); err != nil {
+			t.Fatalf("RecordUsage() error = %v", err)
+		}
+	}
+
+	// executeCommand leaves its buffer on rootCmd; tests that follow print
+	// to stdout.
+	defer func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+	}()
+	out, err := executeCommand("cost", "report", "--month", "2024-07", "--provider", "claude", "--json")
+	if err != nil {
+		t.Fatalf("cost report: %v", err)
+	}
+	var report usage.MonthReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("unmarshal output: %v\n%s", err, out)
+	}
+	if report.Month != "2024-07" || len(report.Profiles) != 1 || report.Profiles[0].CostUSD != 20 {
+		t.Errorf("report = %+v, want $20 for claude/work only", report)
+	}
+	if len(report.Budgets) != 1 || report.Budgets[0].Crossed != 100 || report.Budgets[0].ProjectedUSD != 20 {
+		t.Errorf("budgets = %+v, want the work budget over its limit", report.Budgets)
+	}
+
+	if _, err := executeCommand("cost", "report", "--month", "July", "--provider", "", "--json"); caamerr.CodeOf(err) != caamerr.InvalidArgs {
+		t.Errorf("bad --month error = %v, want InvalidArgs", err)
+	}
+
+	spend := profileSpendThisMonth(db, costsConfig(), "claude", "work", now)
+	if spend == nil || spend.MonthToDateUSD != 1 || spend.Budget != "claude/work" || spend.BudgetUSD != 10 || spend.ProjectedMonthUSD < spend.MonthToDateUSD {
+		t.Errorf("robot spend = %+v", spend)
+	}
+	if spend := profileSpendThisMonth(db, costsConfig(), "gemini", "none", now); spend != nil {
+		t.Errorf("robot spend without usage or budget = %+v, want nil", spend)
+	}
+}
+
 func TestFormatDollars(t *testing.T) {
 	tests := []struct {
 		cents    int
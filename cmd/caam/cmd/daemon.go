@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -12,7 +15,14 @@ import (
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/daemon"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/federation"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/signals"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usagealert"
 )
 
 var daemonCmd = &cobra.Command{
@@ -28,6 +38,8 @@ Examples:
   caam daemon start --fg     # Start the daemon in the foreground
   caam daemon stop           # Stop the running daemon
   caam daemon status         # Check if daemon is running
+  caam daemon pause          # Suspend automatic refreshes and backups
+  caam daemon resume         # Resume automatic actions
   caam daemon logs           # View daemon logs`,
 }
 
@@ -37,7 +49,11 @@ var daemonStartCmd = &cobra.Command{
 	Long: `Start the background token refresh daemon.
 
 The daemon will periodically check all profiles and refresh tokens before they expire.
-By default, it runs in the background. Use --fg to run in the foreground (useful for debugging).`,
+By default, it runs in the background. Use --fg to run in the foreground (useful for debugging).
+
+With --auto-discover, the daemon also watches live auth files. When you log into an
+account that isn't in the vault yet, "suggest" logs the command to save it and "auto"
+backs it up to a new profile right away, so manual logins aren't lost on the next switch.`,
 	RunE: runDaemonStart,
 }
 
@@ -53,6 +69,27 @@ var daemonStatusCmd = &cobra.Command{
 	RunE:  runDaemonStatus,
 }
 
+var daemonPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Suspend automatic actions without stopping the daemon",
+	Long: `Temporarily suspend the daemon's automatic refreshes, pool refreshes,
+scheduled backups, login discovery, and sync watch saves and pushes while
+you take manual control of tokens. Logins and token changes made while
+paused are not saved. The daemon keeps running; use 'caam daemon resume'
+to pick up where it left off.
+
+Equivalent to sending SIGUSR1 to the daemon process (SIGUSR2 resumes).`,
+	Args: cobra.NoArgs,
+	RunE: runDaemonPause,
+}
+
+var daemonResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume automatic actions after a pause",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonResume,
+}
+
 var daemonLogsCmd = &cobra.Command{
 	Use:   "logs",
 	Short: "View daemon logs",
@@ -64,6 +101,8 @@ func init() {
 	daemonCmd.AddCommand(daemonStartCmd)
 	daemonCmd.AddCommand(daemonStopCmd)
 	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonPauseCmd)
+	daemonCmd.AddCommand(daemonResumeCmd)
 	daemonCmd.AddCommand(daemonLogsCmd)
 
 	// Start flags
@@ -72,6 +111,7 @@ func init() {
 	daemonStartCmd.Flags().Duration("threshold", daemon.DefaultRefreshThreshold, "refresh threshold (how long before expiry to refresh)")
 	daemonStartCmd.Flags().BoolP("verbose", "v", false, "verbose logging")
 	daemonStartCmd.Flags().Bool("pool", false, "enable auth pool for proactive token monitoring")
+	daemonStartCmd.Flags().String("auto-discover", "", "handle logins to accounts not in the vault: off, suggest, auto (default from config)")
 
 	// Logs flags
 	daemonLogsCmd.Flags().IntP("lines", "n", 50, "number of lines to show")
@@ -84,12 +124,23 @@ func runDaemonStart(cmd *cobra.Command, args []string) error {
 	threshold, _ := cmd.Flags().GetDuration("threshold")
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	usePool, _ := cmd.Flags().GetBool("pool")
+	autoDiscover, _ := cmd.Flags().GetString("auto-discover")
 
 	// Load global config to check for PID file setting
 	if spmCfg, err := config.LoadSPMConfig(); err == nil {
 		if spmCfg.Runtime.PIDFilePath != "" {
 			daemon.SetPIDFilePath(spmCfg.Runtime.PIDFilePath)
 		}
+		if autoDiscover == "" {
+			autoDiscover = spmCfg.Daemon.AutoDiscover
+		}
+	}
+
+	autoDiscover = strings.ToLower(strings.TrimSpace(autoDiscover))
+	switch autoDiscover {
+	case "", "off", "suggest", "auto":
+	default:
+		return fmt.Errorf("invalid --auto-discover value %q (use off, suggest, or auto)", autoDiscover)
 	}
 
 	// Check if daemon is already running
@@ -102,17 +153,20 @@ func runDaemonStart(cmd *cobra.Command, args []string) error {
 	}
 
 	if foreground {
-		return runDaemonForeground(interval, threshold, verbose, usePool)
+		return runDaemonForeground(interval, threshold, verbose, usePool, autoDiscover)
 	}
 
-	return runDaemonBackground(interval, threshold, verbose, usePool)
+	return runDaemonBackground(interval, threshold, verbose, usePool, autoDiscover)
 }
 
-func runDaemonForeground(interval, threshold time.Duration, verbose, usePool bool) error {
+func runDaemonForeground(interval, threshold time.Duration, verbose, usePool bool, autoDiscover string) error {
 	fmt.Println("Starting daemon in foreground mode...")
 	if usePool {
 		fmt.Println("Auth pool enabled")
 	}
+	if autoDiscover == "suggest" || autoDiscover == "auto" {
+		fmt.Printf("Login discovery enabled (%s)\n", autoDiscover)
+	}
 	fmt.Println("Press Ctrl+C to stop")
 
 	// Initialize vault and health store
@@ -124,6 +178,75 @@ func runDaemonForeground(interval, threshold time.Duration, verbose, usePool boo
 		RefreshThreshold: threshold,
 		Verbose:          verbose,
 		UseAuthPool:      usePool,
+		AutoDiscover:     autoDiscover,
+		WatchSnapshot:    robotWatchSnapshot,
+		EventBus:         events.Default(),
+		WipePaths:        []string{profile.DefaultStorePath()},
+		ApplyPolicies:    daemonApplyPolicies,
+		BudgetReport:     daemonBudgetReport,
+	}
+	if exe, err := os.Executable(); err == nil {
+		cfg.JobCommand = exe
+	}
+	if spmCfg, err := config.LoadSPMConfig(); err == nil {
+		cfg.NoAutoRefresh = spmCfg.DisabledProviders(config.AutomationRefresh)
+		cfg.CooldownProbe = spmCfg.Daemon.CooldownProbe.Enabled
+		cfg.CooldownProbeUsage = spmCfg.Daemon.CooldownProbe.UsagePing
+		cfg.CooldownExtension = spmCfg.Daemon.CooldownProbe.Extension.Duration()
+		if spmCfg.Daemon.SyncWatch {
+			cfg.SyncWatch = true
+			fmt.Println("Sync watch enabled")
+		}
+		if wh := spmCfg.Daemon.UsageWebhook; wh.Listen != "" {
+			cfg.UsageWebhookListen = wh.Listen
+			cfg.UsageWebhookHandler = &usagealert.Handler{
+				Secret:  wh.Secret,
+				Applier: newUsageAlertApplier(),
+				Logf:    log.New(os.Stdout, "[caam-daemon] ", log.LstdFlags).Printf,
+			}
+			cfg.UsageRecordHandler = &usage.RecordHandler{
+				Secret:        wh.Secret,
+				ActiveProfile: activeProfileName,
+			}
+			fmt.Printf("Usage alert webhook enabled on %s\n", wh.Listen)
+		}
+		if listen := spmCfg.Daemon.Announce.Listen; listen != "" {
+			cfg.AnnounceListen = listen
+			fmt.Printf("Announcing to sync discovery on %s\n", listen)
+		}
+		if fed := spmCfg.Daemon.Federation; fed.Enabled {
+			cfg.FederationInterval = fed.Interval.Duration()
+			cfg.FederationPeers = func() []federation.Peer {
+				// Reload the pool each poll so added machines are picked up.
+				pool, err := syncstate.LoadSyncPool()
+				if err != nil {
+					pool = nil
+				}
+				return federation.Peers(fed, pool)
+			}
+			fmt.Println("Coordinator federation enabled")
+		}
+	}
+	if globalCfg, err := config.Load(); err == nil {
+		if n := newNotificationDispatcher(globalCfg.Notifications); n != nil {
+			cfg.Notifier = n
+			cfg.ExpiryWarning = globalCfg.Notifications.GetExpiryWarning()
+			fmt.Println("Notifications enabled")
+		}
+	}
+	if cfg.CooldownProbe {
+		fmt.Println("End-of-cooldown probe enabled")
+	}
+	if spmCfg, err := config.LoadSPMConfig(); err == nil && len(spmCfg.Policies) > 0 {
+		fmt.Printf("Rotation policies enabled (%d configured)\n", len(spmCfg.Policies))
+	}
+	if len(cfg.NoAutoRefresh) > 0 {
+		var names []string
+		for provider := range cfg.NoAutoRefresh {
+			names = append(names, provider)
+		}
+		sort.Strings(names)
+		fmt.Printf("Automatic refresh disabled for: %s\n", strings.Join(names, ", "))
 	}
 
 	d := daemon.New(v, hs, cfg)
@@ -131,7 +254,7 @@ func runDaemonForeground(interval, threshold time.Duration, verbose, usePool boo
 	return d.Start()
 }
 
-func runDaemonBackground(interval, threshold time.Duration, verbose, usePool bool) error {
+func runDaemonBackground(interval, threshold time.Duration, verbose, usePool bool, autoDiscover string) error {
 	// Build the command to run in background
 	args := []string{"daemon", "start", "--fg",
 		"--interval", interval.String(),
@@ -143,6 +266,9 @@ func runDaemonBackground(interval, threshold time.Duration, verbose, usePool boo
 	if usePool {
 		args = append(args, "--pool")
 	}
+	if autoDiscover != "" {
+		args = append(args, "--auto-discover", autoDiscover)
+	}
 
 	executable, err := os.Executable()
 	if err != nil {
@@ -245,6 +371,9 @@ func runDaemonStatus(cmd *cobra.Command, args []string) error {
 
 	if running {
 		fmt.Printf("Daemon is running (pid %d)\n", pid)
+		if pausedAt := daemon.PausedSince(); !pausedAt.IsZero() {
+			fmt.Printf("Paused since %s (resume with: caam daemon resume)\n", pausedAt.Format(time.RFC3339))
+		}
 		fmt.Printf("Log file: %s\n", daemon.LogFilePath())
 	} else {
 		fmt.Println("Daemon is not running")
@@ -253,6 +382,38 @@ func runDaemonStatus(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runDaemonPause(cmd *cobra.Command, args []string) error {
+	return signalDaemon(signals.SendPause, "Daemon paused")
+}
+
+func runDaemonResume(cmd *cobra.Command, args []string) error {
+	return signalDaemon(signals.SendResume, "Daemon resumed")
+}
+
+// signalDaemon sends a signal to the running daemon and reports the result.
+func signalDaemon(send func(pid int) error, done string) error {
+	// Load global config to check for PID file setting
+	if spmCfg, err := config.LoadSPMConfig(); err == nil {
+		if spmCfg.Runtime.PIDFilePath != "" {
+			daemon.SetPIDFilePath(spmCfg.Runtime.PIDFilePath)
+		}
+	}
+
+	running, pid, err := daemon.GetDaemonStatus()
+	if err != nil {
+		return fmt.Errorf("check daemon status: %w", err)
+	}
+	if !running {
+		return fmt.Errorf("daemon is not running")
+	}
+
+	if err := send(pid); err != nil {
+		return fmt.Errorf("signal daemon: %w", err)
+	}
+	fmt.Printf("%s (pid %d)\n", done, pid)
+	return nil
+}
+
 func runDaemonLogs(cmd *cobra.Command, args []string) error {
 	lines, _ := cmd.Flags().GetInt("lines")
 	follow, _ := cmd.Flags().GetBool("follow")
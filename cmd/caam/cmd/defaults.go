@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 )
 
 // useCmd sets the default profile for a provider.
@@ -29,13 +31,13 @@ Use 'caam which' to see current defaults.`,
 
 		// Validate provider
 		if _, ok := tools[provider]; !ok {
-			return fmt.Errorf("unknown provider: %s (supported: codex, claude, gemini)", provider)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s (supported: codex, claude, gemini)", provider)
 		}
 
 		// Check if vault profile exists
 		profiles, err := vault.List(provider)
 		if err != nil {
-			return fmt.Errorf("list profiles: %w", err)
+			return caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
 		}
 
 		found := false
@@ -62,14 +64,14 @@ Use 'caam which' to see current defaults.`,
 		}
 
 		if !found {
-			return fmt.Errorf("profile '%s' not found for %s\nHint: Use 'caam ls %s' or 'caam profile ls %s' to see available profiles",
+			return caamerr.Errorf(caamerr.ProfileNotFound, "profile '%s' not found for %s\nHint: Use 'caam ls %s' or 'caam profile ls %s' to see available profiles",
 				profileName, provider, provider, provider)
 		}
 
 		// Update config
 		cfg.SetDefault(provider, profileName)
 		if err := cfg.Save(); err != nil {
-			return fmt.Errorf("save config: %w", err)
+			return caamerr.Errorf(caamerr.ConfigError, "save config: %w", err)
 		}
 
 		fmt.Printf("Set default %s profile to '%s'\n", provider, profileName)
@@ -97,7 +99,7 @@ Use 'caam use <provider> <profile>' to set defaults.`,
 		if len(args) > 0 {
 			provider := strings.ToLower(args[0])
 			if _, ok := tools[provider]; !ok {
-				return fmt.Errorf("unknown provider: %s", provider)
+				return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", provider)
 			}
 			providers = []string{provider}
 		}
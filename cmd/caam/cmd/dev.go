@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/seed"
+	"github.com/spf13/cobra"
+)
+
+var devCmd = &cobra.Command{
+	Use:    "dev",
+	Short:  "Developer and test tooling",
+	Hidden: true,
+}
+
+var devSeedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Generate fake profiles, history, and cooldowns for benchmarking",
+	Long: `Fill the vault and activity database with realistic fake profiles so
+performance work on status, next, and sync can be benchmarked reproducibly.
+
+Profiles are named seed-NNNN and spread evenly across providers. Token
+expiries, activity history, and cooldowns are randomized from --seed, so the
+same flags always produce the same data set.
+
+Because this writes into the vault, it refuses to run unless CAAM_HOME points
+at a scratch directory (or --force is given).
+
+Examples:
+  CAAM_HOME=/tmp/caam-bench caam dev seed --profiles 200 --providers 3
+  CAAM_HOME=/tmp/caam-bench caam robot status --compact`,
+	Args: cobra.NoArgs,
+	RunE: runDevSeed,
+}
+
+func init() {
+	rootCmd.AddCommand(devCmd)
+	devCmd.AddCommand(devSeedCmd)
+
+	devSeedCmd.Flags().Int("profiles", 200, "total number of profiles to generate")
+	devSeedCmd.Flags().Int("providers", 3, "number of providers to spread profiles across (1-3)")
+	devSeedCmd.Flags().Int64("seed", 1, "random seed for reproducible data")
+	devSeedCmd.Flags().Bool("force", false, "allow seeding when CAAM_HOME is not set")
+	devSeedCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+func runDevSeed(cmd *cobra.Command, args []string) error {
+	profiles, _ := cmd.Flags().GetInt("profiles")
+	providers, _ := cmd.Flags().GetInt("providers")
+	seedValue, _ := cmd.Flags().GetInt64("seed")
+	force, _ := cmd.Flags().GetBool("force")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	if os.Getenv("CAAM_HOME") == "" && !force {
+		return fmt.Errorf("refusing to seed the default vault; set CAAM_HOME to a scratch directory or pass --force")
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	defer db.Close()
+
+	result, err := seed.Generate(seed.Options{
+		VaultDir:  vault.BasePath(),
+		DB:        db,
+		Profiles:  profiles,
+		Providers: providers,
+		Seed:      seedValue,
+	})
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Seeded %d profiles into %s\n", result.Profiles, vault.BasePath())
+	names := make([]string, 0, len(result.ByProvider))
+	for name := range result.ByProvider {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-8s %d\n", name, result.ByProvider[name])
+	}
+	fmt.Fprintf(out, "Events: %d, cooldowns: %d, expired tokens: %d\n", result.Events, result.Cooldowns, result.Expired)
+	return nil
+}
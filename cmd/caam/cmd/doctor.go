@@ -2,33 +2,81 @@
 package cmd
 
 import (
+	"archive/zip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	osexec "os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/passthrough"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/claude"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/codex"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/copilot"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/cursor"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/gemini"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultfs"
 )
 
 // CheckResult represents the result of a single diagnostic check.
 type CheckResult struct {
-	Name    string `json:"name"`
-	Status  string `json:"status"` // "pass", "warn", "fail", "fixed"
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+	Name    string  `json:"name"`
+	Status  string  `json:"status"` // "pass", "warn", "fail", "fixed"
+	Message string  `json:"message"`
+	Details string  `json:"details,omitempty"`
+	Remedy  *Remedy `json:"remedy,omitempty"`
+}
+
+// Fix risk levels, from safe to unattended to needing a human decision.
+const (
+	FixRiskLow    = "low"    // Local, reversible housekeeping (mkdir, stale locks)
+	FixRiskMedium = "medium" // Changes credentials or installs software
+	FixRiskHigh   = "high"   // Can lose data or needs the setup moved
+)
+
+// Remedy describes how to resolve a failing or warning check.
+type Remedy struct {
+	// Command is the exact command to run ("" if the fix is manual).
+	Command string `json:"command,omitempty"`
+
+	// Preview is a read-only command showing what Command would do.
+	Preview string `json:"preview,omitempty"`
+
+	// Description says what the fix does, or what to do by hand.
+	Description string `json:"description"`
+
+	// Risk is FixRiskLow, FixRiskMedium, or FixRiskHigh.
+	Risk string `json:"risk"`
+
+	// AutoFix is true when `caam doctor --fix` applies this fix itself.
+	AutoFix bool `json:"auto_fix"`
+
+	// Interactive is true when the fix needs a human at the terminal or
+	// browser (e.g. an OAuth login).
+	Interactive bool `json:"interactive,omitempty"`
+}
+
+// FixStep is one ordered step of a doctor fix plan.
+type FixStep struct {
+	Step int `json:"step"`
+	Remedy
+
+	// Checks are the "category: name" of each check this step resolves.
+	Checks []string `json:"checks"`
 }
 
 // DoctorReport contains all diagnostic check results.
@@ -42,11 +90,13 @@ type DoctorReport struct {
 	CLITools        []CheckResult `json:"cli_tools"`
 	Dependencies    []CheckResult `json:"dependencies"`
 	Directories     []CheckResult `json:"directories"`
+	System          []CheckResult `json:"system"`
 	Config          []CheckResult `json:"config"`
 	Profiles        []CheckResult `json:"profiles"`
 	Locks           []CheckResult `json:"locks"`
 	AuthFiles       []CheckResult `json:"auth_files"`
 	TokenValidation []CheckResult `json:"token_validation,omitempty"`
+	FixPlan         []FixStep     `json:"fix_plan,omitempty"`
 }
 
 // DependencySpec defines an optional external dependency with install hints.
@@ -88,6 +138,8 @@ Checks performed:
   - CLI tools: Are codex, claude, gemini installed and in PATH?
   - Dependencies: Are optional tools (gum, wezterm, tailscale, playwright, etc.) available?
   - Data directories: Do vault/profiles directories exist with correct permissions?
+  - System: Is the clock in sync, is there free disk space under the data
+    directory, and does the caam database pass an integrity check?
   - Config: Is the configuration valid?
   - Profiles: Are all isolated profiles valid? Any broken symlinks?
   - Locks: Are there any stale lock files from crashed processes?
@@ -95,7 +147,11 @@ Checks performed:
   - Token validation (with --validate): Are auth tokens actually valid?
 
 Flags:
-  --fix       Attempt to fix issues (create directories, clean stale locks)
+  --fix       Attempt to fix issues (create directories, clean stale locks,
+              re-create broken passthrough symlinks, restrict vault
+              permissions to 0700 directories and 0600 files, rebuild a
+              damaged database with VACUUM or restore it from the newest
+              good backup)
   --json      Output results in JSON format for scripting
   --validate  Validate that auth tokens actually work (passive check, no API calls)
   --auto      Automatically install missing optional dependencies (prompts for confirmation unless --yes)
@@ -121,7 +177,7 @@ Flags:
 		printDoctorReport(report, validate)
 
 		if !report.OverallOK {
-			return fmt.Errorf("found %d issues (%d warnings, %d failures)",
+			return caamerr.Errorf(caamerr.CheckFailed, "found %d issues (%d warnings, %d failures)",
 				report.WarnCount+report.FailCount, report.WarnCount, report.FailCount)
 		}
 		return nil
@@ -142,8 +198,8 @@ func runDoctorChecks(fix bool, validate bool, autoInstall bool, skipConfirm bool
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
-	// Check CLI tools
-	report.CLITools = checkCLITools()
+	// Check CLI tools and any shims wrapping them
+	report.CLITools = append(checkCLITools(), checkShims(fix)...)
 
 	// Check external dependencies
 	report.Dependencies = checkDependencies(autoInstall, skipConfirm)
@@ -151,6 +207,9 @@ func runDoctorChecks(fix bool, validate bool, autoInstall bool, skipConfirm bool
 	// Check directories
 	report.Directories = checkDirectories(fix)
 
+	// Check clock, disk space, and database
+	report.System = checkSystem(fix)
+
 	// Check config
 	report.Config = checkConfig()
 
@@ -171,6 +230,7 @@ func runDoctorChecks(fix bool, validate bool, autoInstall bool, skipConfirm bool
 	// Calculate totals
 	allChecks := append(report.CLITools, report.Dependencies...)
 	allChecks = append(allChecks, report.Directories...)
+	allChecks = append(allChecks, report.System...)
 	allChecks = append(allChecks, report.Config...)
 	allChecks = append(allChecks, report.Profiles...)
 	allChecks = append(allChecks, report.Locks...)
@@ -192,17 +252,75 @@ func runDoctorChecks(fix bool, validate bool, autoInstall bool, skipConfirm bool
 	}
 
 	report.OverallOK = report.FailCount == 0
+	report.FixPlan = buildFixPlan(report)
 
 	return report
 }
 
+// buildFixPlan turns the remedies of failing and warning checks into ordered
+// steps. Steps follow dependency order (directories before locks before
+// credentials, tools last) and checks sharing a command collapse into one
+// step, so running the plan top to bottom runs each command once.
+func buildFixPlan(report *DoctorReport) []FixStep {
+	categories := []struct {
+		name   string
+		checks []CheckResult
+	}{
+		{"directories", report.Directories},
+		{"system", report.System},
+		{"locks", report.Locks},
+		{"config", report.Config},
+		{"profiles", report.Profiles},
+		{"auth_files", report.AuthFiles},
+		{"token_validation", report.TokenValidation},
+		{"cli_tools", report.CLITools},
+		{"dependencies", report.Dependencies},
+	}
+
+	var plan []FixStep
+	byCommand := make(map[string]int)
+	for _, cat := range categories {
+		for _, check := range cat.checks {
+			if check.Remedy == nil || (check.Status != "warn" && check.Status != "fail") {
+				continue
+			}
+			label := cat.name + ": " + check.Name
+			if check.Remedy.Command != "" {
+				if i, ok := byCommand[check.Remedy.Command]; ok {
+					plan[i].Checks = append(plan[i].Checks, label)
+					continue
+				}
+				byCommand[check.Remedy.Command] = len(plan)
+			}
+			plan = append(plan, FixStep{
+				Step:   len(plan) + 1,
+				Remedy: *check.Remedy,
+				Checks: []string{label},
+			})
+		}
+	}
+	return plan
+}
+
+// autoFixRemedy is the remedy for issues `caam doctor --fix` handles.
+func autoFixRemedy(description string) *Remedy {
+	return &Remedy{
+		Command:     "caam doctor --fix",
+		Description: description,
+		Risk:        FixRiskLow,
+		AutoFix:     true,
+	}
+}
+
 func checkCLITools() []CheckResult {
 	var results []CheckResult
 
 	toolBinaries := map[string][]string{
-		"codex":  {"codex"},
-		"claude": {"claude"},
-		"gemini": {"gemini"},
+		"codex":   {"codex"},
+		"claude":  {"claude"},
+		"gemini":  {"gemini"},
+		"cursor":  {"cursor-agent"},
+		"copilot": {"copilot"},
 	}
 
 	for tool, binaries := range toolBinaries {
@@ -230,6 +348,11 @@ func checkCLITools() []CheckResult {
 				Status:  "warn",
 				Message: "not found in PATH",
 				Details: fmt.Sprintf("Install %s to use caam with this tool", tool),
+				Remedy: &Remedy{
+					Description: fmt.Sprintf("Install the %s CLI and make sure it is on PATH", tool),
+					Risk:        FixRiskMedium,
+					Interactive: true,
+				},
 			})
 		}
 	}
@@ -446,12 +569,26 @@ func checkSingleDependency(spec DependencySpec, autoInstall bool, skipConfirm bo
 		}
 	}
 
-	return CheckResult{
+	result := CheckResult{
 		Name:    spec.Name,
 		Status:  status,
 		Message: "not found in PATH",
 		Details: details,
 	}
+	if installCmd != "" && !strings.HasPrefix(installCmd, "#") {
+		result.Remedy = &Remedy{
+			Command:     "caam doctor --auto --yes",
+			Description: fmt.Sprintf("Install missing dependencies (%s: %s)", spec.Name, installCmd),
+			Risk:        FixRiskMedium,
+		}
+	} else {
+		result.Remedy = &Remedy{
+			Description: fmt.Sprintf("Install %s manually: %s", spec.Name, strings.TrimSpace(strings.TrimPrefix(installCmd, "#"))),
+			Risk:        FixRiskMedium,
+			Interactive: true,
+		}
+	}
+	return result
 }
 
 // getInstallCommand returns the OS-appropriate install command for a dependency.
@@ -613,6 +750,7 @@ func checkDirectories(fix bool) []CheckResult {
 					Status:  "warn",
 					Message: fmt.Sprintf("missing: %s", dir.path),
 					Details: "Run with --fix to create",
+					Remedy:  autoFixRemedy("Create missing caam directories"),
 				})
 			}
 		} else if err != nil {
@@ -631,12 +769,19 @@ func checkDirectories(fix bool) []CheckResult {
 		} else {
 			// Check permissions
 			mode := info.Mode().Perm()
-			if mode&0077 != 0 {
+			if mode&0077 != 0 && fix && os.Chmod(dir.path, 0700) == nil {
+				results = append(results, CheckResult{
+					Name:    dir.name,
+					Status:  "fixed",
+					Message: fmt.Sprintf("restricted %s to mode 0700 (was %04o)", dir.path, mode),
+				})
+			} else if mode&0077 != 0 {
 				results = append(results, CheckResult{
 					Name:    dir.name,
 					Status:  "warn",
 					Message: fmt.Sprintf("permissions too open: %s (mode %04o)", dir.path, mode),
-					Details: "Consider running: chmod 700 " + dir.path,
+					Details: "Run with --fix, or: chmod 700 " + dir.path,
+					Remedy:  autoFixRemedy("Restrict caam directories to their owner (chmod 700)"),
 				})
 			} else {
 				results = append(results, CheckResult{
@@ -648,9 +793,412 @@ func checkDirectories(fix bool) []CheckResult {
 		}
 	}
 
+	results = append(results, checkVaultFilesystem(filepath.Join(dataDir, "vault"), fix))
+	results = append(results, checkVaultPermissions(filepath.Join(dataDir, "vault"), fix))
+	results = append(results, checkVaultConflicts(filepath.Join(dataDir, "vault")))
+
 	return results
 }
 
+// checkVaultPermissions looks for vault directories other users can open and
+// auth files other users can read. With fix, directories become 0700 and
+// files 0600.
+func checkVaultPermissions(vaultPath string, fix bool) CheckResult {
+	result := CheckResult{Name: "vault permissions"}
+	if runtime.GOOS == "windows" {
+		result.Status = "pass"
+		result.Message = "not checked on Windows"
+		return result
+	}
+
+	var openDirs, openFiles []string
+	err := filepath.WalkDir(vaultPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Mode().Perm()&0077 == 0 {
+			return nil
+		}
+		if d.IsDir() {
+			openDirs = append(openDirs, path)
+		} else {
+			openFiles = append(openFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			result.Status = "pass"
+			result.Message = "no vault yet"
+			return result
+		}
+		result.Status = "fail"
+		result.Message = "error scanning vault"
+		result.Details = err.Error()
+		return result
+	}
+	if len(openDirs)+len(openFiles) == 0 {
+		result.Status = "pass"
+		result.Message = "owner-only"
+		return result
+	}
+
+	if fix {
+		var failed []string
+		for _, dir := range openDirs {
+			if err := os.Chmod(dir, 0700); err != nil {
+				failed = append(failed, dir)
+			}
+		}
+		for _, file := range openFiles {
+			if err := os.Chmod(file, 0600); err != nil {
+				failed = append(failed, file)
+			}
+		}
+		if len(failed) == 0 {
+			result.Status = "fixed"
+			result.Message = fmt.Sprintf("restricted %d director(ies) to 0700 and %d file(s) to 0600", len(openDirs), len(openFiles))
+			return result
+		}
+		result.Status = "fail"
+		result.Message = fmt.Sprintf("could not restrict %d path(s)", len(failed))
+		result.Details = strings.Join(failed, ", ")
+		return result
+	}
+
+	result.Status = "warn"
+	result.Message = fmt.Sprintf("%d director(ies) and %d file(s) readable by other users", len(openDirs), len(openFiles))
+	result.Details = strings.Join(append(openDirs, openFiles...), ", ")
+	result.Remedy = autoFixRemedy("Restrict vault directories to 0700 and auth files to 0600")
+	return result
+}
+
+// checkVaultConflicts looks for conflict copies a sync tool left in the
+// vault. Healing picks between token versions, so doctor only reports them.
+func checkVaultConflicts(vaultPath string) CheckResult {
+	result := CheckResult{Name: "vault sync conflicts"}
+	conflicts, err := vaultfs.FindConflicts(vaultPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			result.Status = "pass"
+			result.Message = "none"
+			return result
+		}
+		result.Status = "warn"
+		result.Message = "could not scan vault"
+		result.Details = err.Error()
+		return result
+	}
+	if len(conflicts) == 0 {
+		result.Status = "pass"
+		result.Message = "none"
+		return result
+	}
+
+	var names []string
+	for _, c := range conflicts {
+		name := c.Path
+		if rel, err := filepath.Rel(vaultPath, c.Path); err == nil {
+			name = rel
+		}
+		names = append(names, name)
+	}
+	result.Status = "warn"
+	result.Message = fmt.Sprintf("%d %s conflict cop%s found", len(conflicts), conflicts[0].SyncTool, pluralY(len(conflicts)))
+	result.Details = strings.Join(names, ", ") + "; run 'caam vault heal-conflicts' to reconcile"
+	result.Remedy = &Remedy{
+		Command:     "caam vault heal-conflicts",
+		Preview:     "caam vault heal-conflicts --dry-run",
+		Description: "Keep the freshest copy of each conflicted file and remove the others",
+		Risk:        FixRiskMedium,
+	}
+	return result
+}
+
+// checkVaultFilesystem reports the filesystem the vault lives on, warning
+// about network or synced locations where concurrent writers are risky, and
+// about stale vault locks left behind by crashed writers.
+func checkVaultFilesystem(vaultPath string, fix bool) CheckResult {
+	fsInfo := vaultfs.Detect(vaultPath)
+	result := CheckResult{Name: "vault filesystem"}
+
+	lockPath := filepath.Join(vaultPath, vaultfs.LockFileName)
+	if fi, err := os.Stat(lockPath); err == nil && fsInfo.Locking() == vaultfs.LockExclusiveFile &&
+		time.Since(fi.ModTime()) > vaultfs.StaleLockAge {
+		if fix {
+			if err := os.Remove(lockPath); err == nil {
+				result.Status = "fixed"
+				result.Message = "removed stale vault lock"
+				return result
+			}
+		}
+		result.Status = "warn"
+		result.Message = fmt.Sprintf("stale vault lock (%s old)", time.Since(fi.ModTime()).Round(time.Second))
+		result.Details = "Remove " + lockPath + " if no caam process is running, or run with --fix"
+		result.Remedy = autoFixRemedy("Remove the stale vault lock")
+		return result
+	}
+
+	switch {
+	case !fsInfo.Supported():
+		result.Status = "warn"
+		result.Message = fmt.Sprintf("unsupported for concurrent writers: %s", fsInfo.Kind)
+		result.Details = fsInfo.Warning()
+		result.Remedy = &Remedy{
+			Description: "Move the caam data directory to a local disk (set CAAM_HOME) and use 'caam sync' to share profiles",
+			Risk:        FixRiskHigh,
+			Interactive: true,
+		}
+	case fsInfo.Kind == vaultfs.KindNetwork:
+		result.Status = "pass"
+		result.Message = fmt.Sprintf("network filesystem (%s); using %s locking", fsInfo.FSType, fsInfo.Locking())
+	default:
+		result.Status = "pass"
+		result.Message = fmt.Sprintf("%s (%s)", fsInfo.Kind, fsInfo.FSType)
+	}
+	return result
+}
+
+// clockCheckURL is requested for its Date header to measure clock skew.
+var clockCheckURL = "https://api.anthropic.com"
+
+// Clock skew beyond these makes token expiry checks unreliable.
+const (
+	clockSkewWarn = time.Minute
+	clockSkewFail = 5 * time.Minute
+)
+
+// Free space under the data directory below these risks a full disk
+// corrupting the database mid-write.
+const (
+	diskSpaceWarn = 500 << 20
+	diskSpaceFail = 50 << 20
+)
+
+func checkSystem(fix bool) []CheckResult {
+	backup := config.DefaultBackupConfig()
+	if cfg, err := config.Load(); err == nil {
+		backup = cfg.Backup
+	}
+	return []CheckResult{
+		checkClockSkew(clockCheckURL),
+		checkDiskSpace(config.DefaultDataPath()),
+		checkDatabase(caamdb.DefaultPath(), backup.GetLocation(), fix),
+	}
+}
+
+// checkClockSkew compares the local clock with the Date header of url.
+// Being offline is not an issue, so a failed request passes unchecked.
+func checkClockSkew(url string) CheckResult {
+	result := CheckResult{Name: "clock skew"}
+	skew, err := measureClockSkew(url)
+	if err != nil {
+		result.Status = "pass"
+		result.Message = "not checked (could not reach time source)"
+		result.Details = err.Error()
+		return result
+	}
+
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+	direction := "ahead"
+	if skew < 0 {
+		direction = "behind"
+	}
+	switch {
+	case abs >= clockSkewFail:
+		result.Status = "fail"
+	case abs >= clockSkewWarn:
+		result.Status = "warn"
+	default:
+		result.Status = "pass"
+		result.Message = "in sync"
+		return result
+	}
+	result.Message = fmt.Sprintf("system clock is %s %s", formatDurationShort(abs), direction)
+	result.Details = "Token expiry checks are off by the same amount"
+	result.Remedy = &Remedy{
+		Description: "Enable network time sync (e.g. 'sudo timedatectl set-ntp true' on Linux, or automatic time in system settings)",
+		Risk:        FixRiskMedium,
+		Interactive: true,
+	}
+	return result
+}
+
+// measureClockSkew returns how far the local clock is ahead of the server
+// at url, rounded to the second the Date header resolves.
+func measureClockSkew(url string) (time.Duration, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	sent := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	received := time.Now()
+
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("no usable Date header: %w", err)
+	}
+	local := sent.Add(received.Sub(sent) / 2)
+	return local.Sub(serverTime).Round(time.Second), nil
+}
+
+// checkDiskSpace reports the free space on the filesystem holding dataDir.
+func checkDiskSpace(dataDir string) CheckResult {
+	result := CheckResult{Name: "disk space"}
+	free, err := vaultfs.FreeSpace(dataDir)
+	if err != nil {
+		result.Status = "pass"
+		result.Message = "not checked on this platform"
+		result.Details = err.Error()
+		return result
+	}
+
+	result.Message = fmt.Sprintf("%s free under %s", formatBytes(int64(free)), dataDir)
+	switch {
+	case free < diskSpaceFail:
+		result.Status = "fail"
+	case free < diskSpaceWarn:
+		result.Status = "warn"
+	default:
+		result.Status = "pass"
+		return result
+	}
+	result.Details = "A full disk can corrupt the caam database mid-write"
+	result.Remedy = &Remedy{
+		Description: "Free up disk space on the filesystem holding " + dataDir,
+		Risk:        FixRiskLow,
+		Interactive: true,
+	}
+	return result
+}
+
+// checkDatabase runs an integrity check on the caam database. With fix, a
+// damaged database is rebuilt with VACUUM, and if that is not enough it is
+// replaced by the copy in the newest backup under backupDir that passes an
+// integrity check.
+func checkDatabase(dbPath, backupDir string, fix bool) CheckResult {
+	result := CheckResult{Name: "database integrity"}
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		result.Status = "pass"
+		result.Message = "not created yet"
+		return result
+	}
+
+	problems, err := caamdb.IntegrityCheck(dbPath)
+	if err != nil {
+		result.Status = "fail"
+		result.Message = "could not check"
+		result.Details = err.Error()
+		return result
+	}
+	if len(problems) == 0 {
+		result.Status = "pass"
+		result.Message = "ok"
+		return result
+	}
+
+	if !fix {
+		result.Status = "fail"
+		result.Message = fmt.Sprintf("%d integrity problem(s) in %s", len(problems), dbPath)
+		result.Details = problems[0]
+		result.Remedy = &Remedy{
+			Command:     "caam doctor --fix",
+			Description: "Rebuild the database with VACUUM, or restore it from the newest backup that passes an integrity check",
+			Risk:        FixRiskMedium,
+			AutoFix:     true,
+		}
+		return result
+	}
+
+	// Nothing may hold the database open while it is rebuilt or replaced.
+	if globalDB != nil {
+		globalDB.Close()
+		globalDB = nil
+	}
+
+	if caamdb.Vacuum(dbPath) == nil {
+		if after, err := caamdb.IntegrityCheck(dbPath); err == nil && len(after) == 0 {
+			result.Status = "fixed"
+			result.Message = "rebuilt with VACUUM"
+			return result
+		}
+	}
+
+	backupPath, kept, err := restoreDatabaseFromBackup(dbPath, backupDir)
+	if err != nil {
+		result.Status = "fail"
+		result.Message = "damaged and could not be repaired"
+		result.Details = fmt.Sprintf("%s; restore from backup: %v", problems[0], err)
+		result.Remedy = &Remedy{
+			Description: "Move " + dbPath + " aside; caam recreates an empty database on its next run (usage history is lost)",
+			Risk:        FixRiskHigh,
+			Interactive: true,
+		}
+		return result
+	}
+	result.Status = "fixed"
+	result.Message = "restored from backup " + filepath.Base(backupPath)
+	result.Details = "Damaged database kept at " + kept
+	return result
+}
+
+// restoreDatabaseFromBackup replaces the database at dbPath with the copy in
+// the newest backup bundle that passes an integrity check. It returns the
+// backup used and where the damaged database was kept.
+func restoreDatabaseFromBackup(dbPath, backupDir string) (string, string, error) {
+	// Backup bundles are named caam_export_<timestamp>.zip, so names sort by age.
+	backups, err := filepath.Glob(filepath.Join(backupDir, "caam_export_*.zip"))
+	if err != nil {
+		return "", "", err
+	}
+	slices.Sort(backups)
+	slices.Reverse(backups)
+
+	lastErr := fmt.Errorf("no backups in %s", backupDir)
+	for _, backupPath := range backups {
+		kept, err := restoreDatabaseFromZip(dbPath, backupPath)
+		if err == nil {
+			return backupPath, kept, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", filepath.Base(backupPath), err)
+	}
+	return "", "", lastErr
+}
+
+func restoreDatabaseFromZip(dbPath, zipPath string) (string, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name != "caam.db" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+		return caamdb.RestoreFrom(dbPath, rc)
+	}
+	return "", fmt.Errorf("no database in backup")
+}
+
 func checkConfig() []CheckResult {
 	var results []CheckResult
 
@@ -685,6 +1233,11 @@ func checkConfig() []CheckResult {
 				Status:  "fail",
 				Message: "invalid configuration",
 				Details: err.Error(),
+				Remedy: &Remedy{
+					Description: "Fix or remove " + configPath,
+					Risk:        FixRiskLow,
+					Interactive: true,
+				},
 			})
 		} else {
 			results = append(results, CheckResult{
@@ -741,6 +1294,12 @@ func checkProfiles(fix bool) []CheckResult {
 					Status:  "warn",
 					Message: "missing home directory",
 					Details: homePath,
+					Remedy: &Remedy{
+						Command:     fmt.Sprintf("caam login %s %s", provider, prof.Name),
+						Description: "Log in again to recreate the profile's home directory",
+						Risk:        FixRiskMedium,
+						Interactive: true,
+					},
 				})
 			} else if err != nil {
 				results = append(results, CheckResult{
@@ -752,14 +1311,26 @@ func checkProfiles(fix bool) []CheckResult {
 			} else {
 				// Check for broken symlinks in home
 				brokenLinks := checkBrokenSymlinks(homePath)
+				var repaired []string
+				if fix && len(brokenLinks) > 0 {
+					repaired, brokenLinks = repairBrokenSymlinks(homePath, brokenLinks)
+				}
+				for _, repair := range repaired {
+					results = append(results, CheckResult{
+						Name:    fmt.Sprintf("%s/%s", provider, prof.Name),
+						Status:  "fixed",
+						Message: "repaired broken symlink: " + repair,
+					})
+				}
 				if len(brokenLinks) > 0 {
 					results = append(results, CheckResult{
 						Name:    fmt.Sprintf("%s/%s", provider, prof.Name),
 						Status:  "warn",
 						Message: fmt.Sprintf("%d broken symlink(s)", len(brokenLinks)),
 						Details: strings.Join(brokenLinks, ", "),
+						Remedy:  brokenSymlinkRemedy(homePath, brokenLinks),
 					})
-				} else {
+				} else if len(repaired) == 0 {
 					results = append(results, CheckResult{
 						Name:    fmt.Sprintf("%s/%s", provider, prof.Name),
 						Status:  "pass",
@@ -773,6 +1344,59 @@ func checkProfiles(fix bool) []CheckResult {
 	return results
 }
 
+// repairBrokenSymlinks re-creates broken passthrough symlinks in an isolated
+// profile home from their source in the real HOME, and removes those whose
+// source is gone. Other broken links are returned as remaining.
+func repairBrokenSymlinks(homePath string, broken []string) (repaired, remaining []string) {
+	mgr, err := passthrough.NewManager()
+	if err != nil {
+		return nil, broken
+	}
+	known := make(map[string]bool)
+	for _, p := range mgr.Passthroughs() {
+		known[p] = true
+	}
+
+	for _, name := range broken {
+		if !known[name] {
+			remaining = append(remaining, name)
+			continue
+		}
+		linkPath := filepath.Join(homePath, name)
+		source := filepath.Join(mgr.RealHome(), name)
+		if _, err := os.Stat(source); err != nil {
+			if os.Remove(linkPath) != nil {
+				remaining = append(remaining, name)
+				continue
+			}
+			repaired = append(repaired, name+" removed (source is gone)")
+			continue
+		}
+		single, err := passthrough.NewManagerWithPaths([]string{name})
+		if err != nil || single.SetupPassthroughs(homePath) != nil {
+			remaining = append(remaining, name)
+			continue
+		}
+		repaired = append(repaired, name+" re-linked to "+source)
+	}
+	return repaired, remaining
+}
+
+// brokenSymlinkRemedy is --fix for broken passthrough links, and a manual
+// step for links caam did not create.
+func brokenSymlinkRemedy(homePath string, broken []string) *Remedy {
+	for _, name := range broken {
+		if !slices.Contains(passthrough.DefaultPassthroughs, name) {
+			return &Remedy{
+				Description: fmt.Sprintf("Remove or repoint the broken symlinks in %s", homePath),
+				Risk:        FixRiskLow,
+				Interactive: true,
+			}
+		}
+	}
+	return autoFixRemedy("Re-create broken passthrough symlinks")
+}
+
 func checkBrokenSymlinks(dir string) []string {
 	var broken []string
 
@@ -847,6 +1471,7 @@ func checkLocks(fix bool) []CheckResult {
 					Status:  "warn",
 					Message: "lock file exists but is empty or corrupt",
 					Details: "Run with --fix to remove",
+					Remedy:  autoFixRemedy("Remove corrupt profile lock files"),
 				})
 				if fix {
 					if err := prof.Unlock(); err == nil {
@@ -877,6 +1502,7 @@ func checkLocks(fix bool) []CheckResult {
 						Status:  "warn",
 						Message: fmt.Sprintf("stale lock (PID %d not running)", info.PID),
 						Details: "Run with --fix to remove",
+						Remedy:  autoFixRemedy("Remove stale profile lock files"),
 					})
 				}
 			} else {
@@ -935,6 +1561,11 @@ func checkAuthFiles() []CheckResult {
 				Status:  "warn",
 				Message: "no auth files",
 				Details: "Login with the tool first, then use 'caam backup' to save",
+				Remedy: &Remedy{
+					Description: fmt.Sprintf("Log in with %s, then run 'caam backup %s <profile>'", tool, tool),
+					Risk:        FixRiskMedium,
+					Interactive: true,
+				},
 			})
 		}
 	}
@@ -963,6 +1594,8 @@ func checkTokenValidation() []CheckResult {
 	reg.Register(claude.New())
 	reg.Register(codex.New())
 	reg.Register(gemini.New())
+	reg.Register(cursor.New())
+	reg.Register(copilot.New())
 
 	// Get all profiles and validate tokens
 	allProfiles, err := profileStore.ListAll()
@@ -1022,6 +1655,12 @@ func checkTokenValidation() []CheckResult {
 					Status:  "fail",
 					Message: "invalid token",
 					Details: result.Error,
+					Remedy: &Remedy{
+						Command:     fmt.Sprintf("caam login %s %s", providerID, prof.Name),
+						Description: "Re-authenticate the profile",
+						Risk:        FixRiskMedium,
+						Interactive: true,
+					},
 				})
 			}
 		}
@@ -1075,6 +1714,13 @@ func printDoctorReport(report *DoctorReport, validate bool) {
 	}
 	fmt.Println()
 
+	// System
+	fmt.Println("Checking system health...")
+	for _, check := range report.System {
+		printCheck(check)
+	}
+	fmt.Println()
+
 	// Config
 	fmt.Println("Checking configuration...")
 	for _, check := range report.Config {
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 )
 
 var envCmd = &cobra.Command{
@@ -42,7 +44,7 @@ Use --export-prefix to change the export syntax (default: "export").`,
 
 		prov, ok := registry.Get(tool)
 		if !ok {
-			return fmt.Errorf("unknown provider: %s (supported: codex, claude, gemini)", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s (supported: codex, claude, gemini)", tool)
 		}
 
 		prof, err := profileStore.Load(tool, name)
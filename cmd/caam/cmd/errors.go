@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+)
+
+var errorsCmd = &cobra.Command{
+	Use:   "errors",
+	Short: "Machine-readable error codes and exit statuses",
+	Long: `Every caam failure carries a stable error code. Robot commands report it
+as error.code in their JSON output, --json output of commands like activate
+and backup reports it as error_code, and every command exits with the
+code's exit status:
+
+  0    success
+  1    unexpected error (INTERNAL)
+  2    partial success
+  3    usage: bad command line, argument, or provider
+  4    not found: profile, provider profiles, or other named item
+  5    unavailable: every profile is blocked; retry later
+  6    approval: waiting for, or denied, human approval
+  7    storage: vault, database, or config could not be read or written
+  8    failed: the operation itself failed
+  9    permission denied
+  10   timeout
+  11   conflict: already exists or changed state
+  130  canceled
+
+Codes and exit statuses are stable; new ones may be added.
+
+Examples:
+  caam errors list
+  caam errors list --json`,
+}
+
+var errorsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every error code",
+	Args:  cobra.NoArgs,
+	RunE:  runErrorsList,
+}
+
+func init() {
+	rootCmd.AddCommand(errorsCmd)
+	errorsCmd.AddCommand(errorsListCmd)
+
+	errorsListCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+func runErrorsList(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	specs := caamerr.Registry()
+	out := cmd.OutOrStdout()
+
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(specs)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CODE\tEXIT\tCATEGORY\tRETRY\tDESCRIPTION")
+	for _, spec := range specs {
+		retry := "no"
+		if spec.Retryable {
+			retry = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", spec.Code, spec.ExitCode, spec.Category, retry, spec.Description)
+	}
+	return w.Flush()
+}
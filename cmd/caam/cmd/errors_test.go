@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+)
+
+func TestRunErrorsListJSON(t *testing.T) {
+	var buf bytes.Buffer
+	errorsListCmd.SetOut(&buf)
+	defer errorsListCmd.SetOut(nil)
+	if err := errorsListCmd.Flags().Set("json", "true"); err != nil {
+		t.Fatal(err)
+	}
+	defer errorsListCmd.Flags().Set("json", "false")
+
+	if err := runErrorsList(errorsListCmd, nil); err != nil {
+		t.Fatalf("runErrorsList() error = %v", err)
+	}
+	var specs []caamerr.Spec
+	if err := json.Unmarshal(buf.Bytes(), &specs); err != nil {
+		t.Fatalf("output is not a JSON spec list: %v\n%s", err, buf.String())
+	}
+	codes := make(map[caamerr.Code]int)
+	for _, spec := range specs {
+		codes[spec.Code] = spec.ExitCode
+	}
+	if codes[caamerr.VaultError] != 7 || codes[caamerr.PartialSuccess] != 2 {
+		t.Errorf("exit codes = %v", codes)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"robot code", caamerr.Errorf(caamerr.NoProfiles, "NO_PROFILES: none"), 4},
+		{"exit error", &ExitError{Code: 42, Err: errors.New("custom")}, 42},
+		{"plain", errors.New("boom"), 1},
+		{"cobra args", fmt.Errorf("accepts 1 arg(s), received 0"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if !isUsageError(errors.New(`unknown command "nope" for "caam"`)) {
+		t.Error("unknown command should be a usage error")
+	}
+	if isUsageError(caamerr.New(caamerr.InvalidArgs, "accepts only numbers")) {
+		t.Error("coded errors keep their own code")
+	}
+}
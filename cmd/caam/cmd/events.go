@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/daemon"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Follow caam's event stream",
+	Long: `Every caam process publishes what it does to one append-only event stream
+stored in the database:
+
+  profile_activated  a vault profile became the live auth
+  cooldown_set       a profile was put into cooldown
+  token_refreshed    a profile's OAuth token was refreshed
+  sync_completed     a sync with another machine finished
+  health_changed     a profile's health status changed
+
+'caam events tail' prints recent events and, with -f, follows new ones:
+through the daemon's event socket when it is running, otherwise by polling
+the database. 'caam serve' streams the same events over /api/v1/events.
+
+Examples:
+  caam events tail
+  caam events tail -f --type cooldown_set --type token_refreshed
+  caam events tail -f --provider claude --json`,
+}
+
+var eventsTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Print recent events and optionally follow new ones",
+	Args:  cobra.NoArgs,
+	RunE:  runEventsTail,
+}
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+	eventsCmd.AddCommand(eventsTailCmd)
+
+	eventsTailCmd.Flags().IntP("lines", "n", 20, "number of recent events to print first")
+	eventsTailCmd.Flags().BoolP("follow", "f", false, "keep printing new events as they happen")
+	eventsTailCmd.Flags().StringSlice("type", nil, "only these event types (repeatable)")
+	eventsTailCmd.Flags().String("provider", "", "only events for this provider")
+	eventsTailCmd.Flags().Bool("json", false, "print one JSON event per line")
+	eventsTailCmd.Flags().Bool("no-daemon", false, "poll the database even if the daemon is running")
+}
+
+// dbEventStore is the event store for this process. It opens its own
+// database handle, since events are published from daemon goroutines that
+// must not share getDB's unsynchronized cache, and reopens it if the data
+// directory changes.
+type dbEventStore struct {
+	mu sync.Mutex
+	db *caamdb.DB
+}
+
+func (s *dbEventStore) open() (*caamdb.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := filepath.Clean(caamdb.DefaultPath())
+	if s.db != nil {
+		if s.db.Path() == path {
+			return s.db, nil
+		}
+		s.db.Close()
+		s.db = nil
+	}
+	db, err := caamdb.OpenAt(path)
+	if err != nil {
+		return nil, err
+	}
+	s.db = db
+	return db, nil
+}
+
+// AppendEvent implements events.Store.
+func (s *dbEventStore) AppendEvent(ev events.Event) (events.Event, error) {
+	db, err := s.open()
+	if err != nil {
+		return ev, err
+	}
+	return db.AppendEvent(ev)
+}
+
+// EventsAfter implements events.Store.
+func (s *dbEventStore) EventsAfter(afterID int64, limit int) ([]events.Event, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	return db.EventsAfter(afterID, limit)
+}
+
+// installEventBus makes events.Publish persist to the database for the rest
+// of this process, and run the matching hooks.
+func installEventBus() {
+	if events.Default() == nil {
+		bus := events.NewBus(&dbEventStore{})
+		bus.OnPublish(runEventHooks)
+		events.SetDefault(bus)
+	}
+}
+
+func runEventsTail(cmd *cobra.Command, args []string) error {
+	lines, _ := cmd.Flags().GetInt("lines")
+	follow, _ := cmd.Flags().GetBool("follow")
+	types, _ := cmd.Flags().GetStringSlice("type")
+	provider, _ := cmd.Flags().GetString("provider")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	noDaemon, _ := cmd.Flags().GetBool("no-daemon")
+
+	filter, err := parseEventFilter(types, provider)
+	if err != nil {
+		return err
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	var lastID int64
+	if lines > 0 {
+		recent, err := db.LatestEvents(filter, lines)
+		if err != nil {
+			return err
+		}
+		for _, ev := range recent {
+			if err := printBusEvent(out, ev, jsonOut); err != nil {
+				return nil
+			}
+		}
+	}
+	if !follow {
+		return nil
+	}
+
+	// Start after the newest event of any type, so following never replays
+	// what was skipped by -n.
+	if newest, err := db.LatestEvents(events.Filter{}, 1); err == nil && len(newest) > 0 {
+		lastID = newest[0].ID
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if !noDaemon {
+		if attached, err := tailViaDaemon(ctx, out, lastID, filter, jsonOut); attached {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		batch, err := db.EventsAfter(lastID, 500)
+		if err != nil {
+			continue
+		}
+		for _, ev := range batch {
+			lastID = ev.ID
+			if !filter.Match(ev) {
+				continue
+			}
+			if err := printBusEvent(out, ev, jsonOut); err != nil {
+				return nil // Exit gracefully if stdout is closed
+			}
+		}
+	}
+}
+
+// tailViaDaemon streams events from the daemon's event socket. attached is
+// false if no daemon is serving events, so the caller polls instead.
+func tailViaDaemon(ctx context.Context, out io.Writer, afterID int64, filter events.Filter, jsonOut bool) (attached bool, err error) {
+	if running, _, _ := daemon.GetDaemonStatus(); !running {
+		return false, nil
+	}
+	conn, err := daemon.DialEvents(daemon.EventsRequest{AfterID: afterID, Filter: filter})
+	if err != nil {
+		return false, nil
+	}
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		attached = true
+		var ev events.Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			continue
+		}
+		if err := printBusEvent(out, ev, jsonOut); err != nil {
+			return true, nil // Exit gracefully if stdout is closed
+		}
+	}
+	if ctx.Err() != nil {
+		return true, nil
+	}
+	// Daemon went away mid-stream; let the caller carry on locally.
+	return false, nil
+}
+
+// parseEventFilter validates --type and --provider.
+func parseEventFilter(types []string, provider string) (events.Filter, error) {
+	known := make(map[events.Type]bool)
+	for _, t := range events.Types() {
+		known[t] = true
+	}
+
+	var f events.Filter
+	for _, raw := range types {
+		t := events.Type(strings.ToLower(strings.TrimSpace(raw)))
+		if !known[t] {
+			names := make([]string, 0, len(known))
+			for k := range known {
+				names = append(names, string(k))
+			}
+			sort.Strings(names)
+			return f, fmt.Errorf("unknown event type %q (valid: %s)", raw, strings.Join(names, ", "))
+		}
+		f.Types = append(f.Types, t)
+	}
+	if provider != "" {
+		provider = strings.ToLower(provider)
+		if _, ok := tools[provider]; !ok {
+			return f, caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", provider)
+		}
+		f.Provider = provider
+	}
+	return f, nil
+}
+
+// printBusEvent writes one event as a JSON line or a human-readable line.
+func printBusEvent(w io.Writer, ev events.Event, jsonOut bool) error {
+	if jsonOut {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return nil
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %-17s", ev.Time.Local().Format("2006-01-02 15:04:05"), ev.Type)
+	if ev.Provider != "" {
+		b.WriteString("  " + ev.Provider)
+		if ev.Profile != "" {
+			b.WriteString("/" + ev.Profile)
+		}
+	}
+	if ev.Source != "" {
+		b.WriteString("  source=" + ev.Source)
+	}
+	keys := make([]string, 0, len(ev.Data))
+	for k := range ev.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, ev.Data[k])
+	}
+	b.WriteByte('\n')
+	_, err := io.WriteString(w, b.String())
+	return err
+}
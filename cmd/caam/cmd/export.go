@@ -21,7 +21,9 @@ This is the recommended way to set up profiles on headless servers:
 4. Import on server: caam import profile.tar.gz
 5. Activate: caam activate codex work
 
-The exported file contains only the auth credentials, not session state.`,
+The exported file contains only the auth credentials, not session state.
+
+Use --progress json to emit NDJSON progress events on stderr.`,
 	Args: cobra.RangeArgs(0, 2),
 	RunE: runExport,
 }
@@ -29,11 +31,16 @@ The exported file contains only the auth credentials, not session state.`,
 func init() {
 	exportCmd.Flags().Bool("all", false, "export all profiles (use with optional <tool>)")
 	exportCmd.Flags().StringP("output", "o", "", "write archive to file instead of stdout")
+	addProgressFlag(exportCmd)
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
 	all, _ := cmd.Flags().GetBool("all")
 	outPath, _ := cmd.Flags().GetString("output")
+	prog, err := progressReporter(cmd, "export")
+	if err != nil {
+		return err
+	}
 
 	var req exportRequest
 	switch {
@@ -55,12 +62,15 @@ func runExport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("usage: caam export <tool/profile> or caam export <tool> <profile> or caam export --all [tool]")
 	}
 
+	prog.Phase("collect", 0)
 	targets, err := resolveExportTargets(vault, req)
 	if err != nil {
+		prog.Done(err)
 		return err
 	}
 	manifest, files, err := buildExportManifest(targets)
 	if err != nil {
+		prog.Done(err)
 		return err
 	}
 
@@ -71,7 +81,9 @@ func runExport(cmd *cobra.Command, args []string) error {
 	if outPath != "" {
 		f, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 		if err != nil {
-			return fmt.Errorf("open output file: %w", err)
+			err = fmt.Errorf("open output file: %w", err)
+			prog.Done(err)
+			return err
 		}
 		w = f
 		close = f.Close
@@ -80,13 +92,17 @@ func runExport(cmd *cobra.Command, args []string) error {
 		close = func() error { return nil }
 	}
 
-	if err := writeExportArchive(w, manifest, files); err != nil {
+	if err := writeExportArchive(w, manifest, files, prog); err != nil {
 		_ = close()
+		prog.Done(err)
 		return err
 	}
 	if err := close(); err != nil {
-		return fmt.Errorf("close output: %w", err)
+		err = fmt.Errorf("close output: %w", err)
+		prog.Done(err)
+		return err
 	}
+	prog.Done(nil)
 
 	fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d profile(s)\n", len(targets))
 	if outPath != "" {
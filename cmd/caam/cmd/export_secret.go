@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+)
+
+var exportSecretCmd = &cobra.Command{
+	Use:   "secret <tool/profile> | <tool> <profile>",
+	Short: "Export a profile as a CI or Kubernetes secret",
+	Long: `Packs a profile's auth files into one environment variable,
+CAAM_AUTH_<TOOL> (e.g. CAAM_AUTH_CLAUDE), for CI containers and pods that
+can't log in interactively. Inside the container, 'caam activate --from-env'
+writes the live auth files back from it; no vault is needed there.
+
+Formats:
+  env             export CAAM_AUTH_<TOOL>='...' (for eval) [default]
+  dotenv          CAAM_AUTH_<TOOL>=...
+  k8s             a Secret manifest for kubectl apply (--name, --k8s-namespace)
+  github-actions  a 'gh secret set' command, with the workflow lines that use it
+
+The value is a token: treat the output like a password. When printing to a
+terminal the value is masked; pipe or redirect the output, or pass --reveal.
+
+Examples:
+  caam export secret claude work --format k8s | kubectl apply -f -
+  caam export secret codex/ci --format github-actions | sh
+  caam export secret claude work --format dotenv > .env.ci
+
+  # In the container, with CAAM_AUTH_CLAUDE set:
+  caam activate --from-env`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runExportSecret,
+}
+
+func init() {
+	exportCmd.AddCommand(exportSecretCmd)
+	exportSecretCmd.Flags().String("format", "env", "output format: env, dotenv, k8s, github-actions")
+	exportSecretCmd.Flags().String("name", "", "k8s: Secret name (default caam-<tool>-<profile>)")
+	exportSecretCmd.Flags().String("k8s-namespace", "", "k8s: namespace of the Secret")
+	exportSecretCmd.Flags().Bool("reveal", false, "print the value even to a terminal")
+}
+
+// githubSecretLimit is the largest value GitHub accepts for a secret.
+const githubSecretLimit = 48 << 10
+
+func runExportSecret(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	name, _ := cmd.Flags().GetString("name")
+	k8sNamespace, _ := cmd.Flags().GetString("k8s-namespace")
+	reveal, _ := cmd.Flags().GetBool("reveal")
+
+	provider, profile := "", ""
+	if len(args) == 1 {
+		var err error
+		if provider, profile, err = parseToolProfileArg(args[0]); err != nil {
+			return caamerr.Wrap(caamerr.InvalidArgs, err)
+		}
+	} else {
+		provider, profile = strings.ToLower(args[0]), args[1]
+	}
+	switch format {
+	case "env", "dotenv", "k8s", "github-actions":
+	default:
+		return caamerr.Errorf(caamerr.InvalidArgs, "unknown format %q (use env, dotenv, k8s, or github-actions)", format)
+	}
+
+	getFileSet, ok := lookupToolFileSet(provider)
+	if !ok {
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s", provider)
+	}
+	if vault == nil {
+		vault = authfile.NewVault(authfile.DefaultVaultPath())
+	}
+	secret, err := vault.EnvSecret(getFileSet(), profile)
+	if err != nil {
+		return caamerr.Wrap(caamerr.ProfileNotFound, err)
+	}
+	secret.Provider = provider
+	value, err := secret.Encode()
+	if err != nil {
+		return caamerr.Errorf(caamerr.Internal, "encode secret: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	stderr := cmd.ErrOrStderr()
+	shown := value
+	if !reveal && writerIsTerminal(out) {
+		shown = "********"
+		defer fmt.Fprintln(stderr, "The value is masked on a terminal; pipe the output or pass --reveal.")
+	}
+	if format == "github-actions" && len(value) > githubSecretLimit {
+		fmt.Fprintf(stderr, "Warning: the secret is %d KB; GitHub accepts at most 48 KB.\n", len(value)>>10)
+	}
+
+	env := authfile.EnvSecretName(provider)
+	switch format {
+	case "env":
+		fmt.Fprintf(out, "export %s=%s\n", env, shellQuote(shown))
+	case "dotenv":
+		fmt.Fprintf(out, "%s=%s\n", env, shown)
+	case "k8s":
+		if name == "" {
+			name = k8sSecretName("caam-" + provider + "-" + profile)
+		}
+		writeK8sSecret(out, name, k8sNamespace, secret, env, shown)
+	case "github-actions":
+		fmt.Fprintf(out, "# Stores %s/%s as the repository secret %s. Use it in a workflow with:\n", provider, profile, env)
+		fmt.Fprintf(out, "#   env:\n#     %s: ${{ secrets.%s }}\n", env, env)
+		fmt.Fprintln(out, "#   steps:\n#     - run: caam activate --from-env")
+		fmt.Fprintf(out, "gh secret set %s --body %s\n", env, shellQuote(shown))
+	}
+	fmt.Fprintf(stderr, "Exported %s/%s (%s) as %s\n", provider, profile, strings.Join(secret.FileNames(), ", "), env)
+	return nil
+}
+
+// writeK8sSecret writes an Opaque Secret holding the env var.
+func writeK8sSecret(w io.Writer, name, namespace string, secret *authfile.EnvSecret, env, value string) {
+	fmt.Fprintln(w, "apiVersion: v1")
+	fmt.Fprintln(w, "kind: Secret")
+	fmt.Fprintln(w, "metadata:")
+	fmt.Fprintf(w, "  name: %s\n", name)
+	if namespace != "" {
+		fmt.Fprintf(w, "  namespace: %s\n", namespace)
+	}
+	fmt.Fprintln(w, "  labels:")
+	fmt.Fprintln(w, "    app.kubernetes.io/managed-by: caam")
+	fmt.Fprintln(w, "  annotations:")
+	fmt.Fprintf(w, "    caam/provider: %q\n", secret.Provider)
+	fmt.Fprintf(w, "    caam/profile: %q\n", secret.Profile)
+	fmt.Fprintln(w, "type: Opaque")
+	fmt.Fprintln(w, "stringData:")
+	fmt.Fprintf(w, "  %s: %q\n", env, value)
+}
+
+// k8sSecretName makes s a valid Kubernetes object name: lowercase
+// alphanumerics, '-' and '.', at most 253 characters.
+func k8sSecretName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, s)
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	return strings.Trim(name, "-.")
+}
+
+// writerIsTerminal reports whether w is a terminal.
+func writerIsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
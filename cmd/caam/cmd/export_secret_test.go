@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+func TestExportSecretAndActivateFromEnv(t *testing.T) {
+	_, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	auth := `{"access_token":"ci-token"}`
+	if err := os.MkdirAll(vault.ProfilePath("codex", "CI@example.com"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(vault.BackupPath("codex", "CI@example.com", "auth.json"), []byte(auth), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	export := func(format string) string {
+		t.Helper()
+		var out bytes.Buffer
+		exportSecretCmd.SetOut(&out)
+		exportSecretCmd.SetErr(&bytes.Buffer{})
+		defer exportSecretCmd.SetOut(nil)
+		_ = exportSecretCmd.Flags().Set("format", format)
+		defer exportSecretCmd.Flags().Set("format", "env")
+		if err := runExportSecret(exportSecretCmd, []string{"codex/CI@example.com"}); err != nil {
+			t.Fatalf("export secret --format %s: %v", format, err)
+		}
+		return out.String()
+	}
+
+	manifest := export("k8s")
+	for _, want := range []string{"kind: Secret", "name: caam-codex-ci-example.com", `CAAM_AUTH_CODEX: "caam1:`} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("k8s manifest missing %q:\n%s", want, manifest)
+		}
+	}
+
+	name, value, ok := strings.Cut(strings.TrimSpace(export("dotenv")), "=")
+	if !ok || name != authfile.EnvSecretName("codex") {
+		t.Fatalf("dotenv output = %s=%s", name, value)
+	}
+
+	// A fresh container: no vault, no live auth, just the variable.
+	vault = nil
+	t.Setenv(name, value)
+	_ = activateCmd.Flags().Set("from-env", "true")
+	defer activateCmd.Flags().Set("from-env", "false")
+	if err := runActivate(activateCmd, nil); err != nil {
+		t.Fatalf("activate --from-env: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(os.Getenv("CODEX_HOME"), "auth.json")); string(data) != auth {
+		t.Errorf("live auth = %q, want %q", data, auth)
+	}
+
+	if err := runActivate(activateCmd, []string{"claude"}); err == nil {
+		t.Error("activate --from-env claude succeeded without CAAM_AUTH_CLAUDE")
+	}
+}
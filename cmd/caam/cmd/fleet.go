@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet",
+	Short: "Manage the machines in the sync pool",
+	Long: `Manage the machines in the sync pool.
+
+status, next, and act run the matching robot command on pool machines over
+SSH, using the same keys and known_hosts as sync, and print one JSON
+envelope with every machine's reply under data.machines. Each machine only
+needs caam installed; no agent or daemon is required. Machines run in
+parallel (--parallel) and each gets --timeout to connect and finish.
+
+--machine picks machines by name (repeatable); without it, status and next
+run on every machine in the pool. Arguments after -- are passed to the
+robot command unchanged. The result is successful only if every machine
+succeeded: otherwise error.code is PARTIAL_SUCCESS, or, if every machine
+failed, the machines' shared error code (SYNC_FAILED when they differ or
+could not be reached).
+
+Examples:
+  caam fleet status
+  caam fleet status claude --machine build-1 --machine build-2
+  caam fleet next claude -- --strategy lru
+  caam fleet act activate claude work --machine build-3`,
+}
+
+var fleetStatusCmd = &cobra.Command{
+	Use:   "status [provider] [-- robot flags...]",
+	Short: "Run robot status on pool machines",
+	RunE:  runFleetRobot("status", 0),
+}
+
+var fleetNextCmd = &cobra.Command{
+	Use:   "next <provider> [-- robot flags...]",
+	Short: "Run robot next on pool machines",
+	RunE:  runFleetRobot("next", 1),
+}
+
+var fleetActCmd = &cobra.Command{
+	Use:   "act <action> <provider> [profile] [args...] --machine <name>",
+	Short: "Run robot act on chosen pool machines",
+	RunE:  runFleetRobot("act", 2),
+}
+
+var fleetWipeCmd = &cobra.Command{
+	Use:   "wipe <machine>",
+	Short: "Order a lost machine to delete its vault",
+	Long: `Order a lost or retired machine in the sync pool to securely delete its
+vault, backups, and isolated profiles.
+
+The wipe order is signed with your SSH key (--key, default ~/.ssh/id_ed25519
+or id_rsa) and copied to every machine in the pool. From then on the pool
+refuses to sync with the target. The first pool member to reach the target
+drops the order into its sync directory; the target's daemon verifies the
+signature against its trusted keys and wipes itself on its next check.
+
+The order names the target's sync identity, so caam must have synced with
+the target at least once. A target refuses orders for another identity,
+orders older than 30 days, and orders already executed or cancelled.
+
+Trusted keys are read from the sync directory's trusted_keys file
+(authorized_keys format), falling back to ~/.ssh/authorized_keys.
+
+Use --cancel to withdraw a pending order and resume syncing. The
+cancellation reaches each machine on its next sync; a target that checks
+a delivered order before then still wipes itself.
+
+Examples:
+  caam fleet wipe old-laptop
+  caam fleet wipe old-laptop --key ~/.ssh/fleet_admin --yes
+  caam fleet wipe old-laptop --cancel`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFleetWipe,
+}
+
+func init() {
+	rootCmd.AddCommand(fleetCmd)
+	fleetCmd.AddCommand(fleetWipeCmd)
+	fleetCmd.AddCommand(fleetStatusCmd)
+	fleetCmd.AddCommand(fleetNextCmd)
+	fleetCmd.AddCommand(fleetActCmd)
+
+	for _, c := range []*cobra.Command{fleetStatusCmd, fleetNextCmd, fleetActCmd} {
+		c.Flags().StringArray("machine", nil, "pool machine to run on (repeatable; default: all)")
+		c.Flags().String("caam-path", "caam", "caam command on the remote machines")
+		c.Flags().Duration("timeout", sync.DefaultFleetTimeout, "time each machine gets to connect and finish")
+		c.Flags().Int("parallel", 8, "machines to run at once")
+	}
+
+	fleetWipeCmd.Flags().String("key", "", "SSH private key used to sign the wipe order")
+	fleetWipeCmd.Flags().BoolP("yes", "y", false, "skip the confirmation prompt")
+	fleetWipeCmd.Flags().Bool("cancel", false, "withdraw a pending wipe order")
+	fleetWipeCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+func runFleetWipe(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	keyPath, _ := cmd.Flags().GetString("key")
+	yes, _ := cmd.Flags().GetBool("yes")
+	cancel, _ := cmd.Flags().GetBool("cancel")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	out := cmd.OutOrStdout()
+
+	syncer, err := sync.NewSyncer(sync.DefaultSyncerConfig())
+	if err != nil {
+		return fmt.Errorf("create syncer: %w", err)
+	}
+	defer syncer.Close()
+
+	if cancel {
+		found, err := syncer.CancelWipe(name)
+		if err != nil {
+			return err
+		}
+		if jsonOut {
+			return json.NewEncoder(out).Encode(map[string]interface{}{"machine": name, "cancelled": found})
+		}
+		if !found {
+			fmt.Fprintf(out, "No pending wipe for %q\n", name)
+			return nil
+		}
+		fmt.Fprintf(out, "Wipe order for %q withdrawn; sync with it will resume\n", name)
+		return nil
+	}
+
+	signer, err := sync.LoadSigningKey(keyPath)
+	if err != nil {
+		return err
+	}
+
+	if !yes {
+		fmt.Fprintf(out, "Order %q to delete its vault? This cannot be undone once delivered. (y/N): ", name)
+		var response string
+		fmt.Scanln(&response)
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Fprintln(out, "Cancelled")
+			return nil
+		}
+	}
+
+	order, deliveries, err := syncer.IssueWipe(name, signer)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Order      *sync.WipeOrder     `json:"order"`
+			Deliveries []sync.WipeDelivery `json:"deliveries"`
+		}{order, deliveries})
+	}
+
+	fmt.Fprintf(out, "Wipe order for %q signed with %s\n", name, strings.SplitN(order.SigningKey, " ", 3)[0])
+	for _, d := range deliveries {
+		role := "pool member"
+		if d.Target {
+			role = "target"
+		}
+		if d.Error != "" {
+			fmt.Fprintf(out, "  %-20s %-12s pending (%s)\n", d.Machine, role, d.Error)
+			continue
+		}
+		fmt.Fprintf(out, "  %-20s %-12s delivered\n", d.Machine, role)
+	}
+	fmt.Fprintln(out, "Sync with this machine is now refused. Undelivered copies are retried on the next sync.")
+	return nil
+}
+
+// fleetReport is the data of a fleet status, next, or act envelope.
+type fleetReport struct {
+	Command   string             `json:"command"`
+	Total     int                `json:"total"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Machines  []sync.FleetResult `json:"machines"`
+}
+
+// runFleetRobot returns the RunE of a fleet command that runs
+// 'caam robot <robotCmd> args...' on pool machines, requiring minArgs
+// arguments. act also requires --machine, so an action is never sent to
+// the whole pool by accident.
+func runFleetRobot(robotCmd string, minArgs int) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		command := "fleet " + robotCmd
+		start := time.Now()
+		names, _ := cmd.Flags().GetStringArray("machine")
+		binary, _ := cmd.Flags().GetString("caam-path")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+
+		if len(args) < minArgs {
+			return robotError(cmd, command, caamerr.InvalidArgs,
+				fmt.Sprintf("%s needs %d argument(s)", command, minArgs), "", []string{"caam fleet " + robotCmd + " --help"})
+		}
+		if robotCmd == "act" && len(names) == 0 {
+			return robotError(cmd, command, caamerr.InvalidArgs,
+				"fleet act needs --machine", "name each machine the action should run on", nil)
+		}
+
+		machines, err := fleetMachines(names)
+		if err != nil {
+			return robotError(cmd, command, caamerr.CodeOf(err), err.Error(), "", []string{"caam sync status"})
+		}
+
+		remote := fleetCommand(binary, append([]string{"robot", robotCmd}, args...))
+		results := sync.RunFleet(cmd.Context(), machines, remote, sync.FleetOptions{
+			Connect:  sync.DefaultConnectOptions(),
+			Timeout:  timeout,
+			Parallel: parallel,
+		})
+
+		report := summarizeFleet(remote, results)
+		output := RobotOutput{
+			Success: report.Failed == 0,
+			Command: command,
+			Data:    report,
+			Timing: &RobotTiming{
+				StartedAt:  start.UTC().Format(time.RFC3339),
+				DurationMs: time.Since(start).Milliseconds(),
+			},
+		}
+		if output.Success {
+			return robotOutput(cmd, output)
+		}
+		code := fleetErrorCode(report)
+		output.Error = &RobotError{
+			Code:    string(code),
+			Message: fmt.Sprintf("%d of %d machine(s) failed", report.Failed, report.Total),
+		}
+		robotOutput(cmd, output)
+		return caamerr.Errorf(code, "%s: %s", code, output.Error.Message)
+	}
+}
+
+// fleetMachines returns the named pool machines, or all of them.
+func fleetMachines(names []string) ([]*sync.Machine, error) {
+	pool, err := sync.LoadSyncPool()
+	if err != nil {
+		return nil, caamerr.Errorf(caamerr.ConfigError, "load sync pool: %w", err)
+	}
+	if pool.IsEmpty() {
+		return nil, caamerr.Errorf(caamerr.NotFound, "the sync pool has no machines; add one with 'caam sync add'")
+	}
+	if len(names) == 0 {
+		return pool.ListMachines(), nil
+	}
+	var machines []*sync.Machine
+	for _, name := range names {
+		m := pool.GetMachineByName(name)
+		if m == nil {
+			return nil, caamerr.Errorf(caamerr.NotFound, "machine %q not found in pool", name)
+		}
+		machines = append(machines, m)
+	}
+	return machines, nil
+}
+
+// fleetCommand returns the shell command that runs caam with args on a
+// remote machine, quoting each argument for a POSIX shell.
+func fleetCommand(binary string, args []string) string {
+	if binary == "" {
+		binary = "caam"
+	}
+	parts := []string{shellQuote(binary)}
+	for _, a := range args {
+		parts = append(parts, shellQuote(a))
+	}
+	return strings.Join(parts, " ")
+}
+
+func summarizeFleet(command string, results []sync.FleetResult) fleetReport {
+	report := fleetReport{Command: command, Total: len(results), Machines: results}
+	for _, r := range results {
+		if r.Success {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+	}
+	return report
+}
+
+// fleetErrorCode is the error code of a fleet run with failures: partial
+// success if any machine succeeded, else the code every machine failed
+// with, else SYNC_FAILED.
+func fleetErrorCode(report fleetReport) caamerr.Code {
+	if report.Succeeded > 0 {
+		return caamerr.PartialSuccess
+	}
+	code := ""
+	for _, r := range report.Machines {
+		if code != "" && r.Code != code {
+			return caamerr.SyncFailed
+		}
+		code = r.Code
+	}
+	if code == "" {
+		return caamerr.SyncFailed
+	}
+	return caamerr.Code(code)
+}
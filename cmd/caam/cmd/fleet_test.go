@@ -0,0 +1,14 @@
+package cmd
+
+import "testing"
+
+func TestFleetCommand(t *testing.T) {
+	got := fleetCommand("", []string{"robot", "act", "note", "claude", "it's done; rm -rf /", "#2", ""})
+	want := `caam robot act note claude 'it'"'"'s done; rm -rf /' '#2' ''`
+	if got != want {
+		t.Errorf("fleetCommand() = %s, want %s", got, want)
+	}
+	if got := fleetCommand("/opt/caam bin/caam", nil); got != `'/opt/caam bin/caam'` {
+		t.Errorf("fleetCommand() = %s", got)
+	}
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+)
+
+var gcpCmd = &cobra.Command{
+	Use:   "gcp",
+	Short: "Manage the Google Cloud project of gemini profiles",
+	Long: `Gemini CLI bills Code Assist and Vertex AI requests to the project in
+GOOGLE_CLOUD_PROJECT (read from ~/.gemini/.env), and gcloud Application
+Default Credentials bill API quota to their quota project. Both are saved
+per gemini profile, so activating a profile also switches projects.
+
+ADC credentials are backed up with the rest of a gemini profile; to add them
+to an existing profile, run 'gcloud auth application-default login' and then
+'caam backup gemini-adc <profile>'.
+
+Examples:
+  caam gcp show work
+  caam gcp set work my-project
+  caam gcp set work my-project --quota-project billing-project`,
+}
+
+var gcpShowCmd = &cobra.Command{
+	Use:   "show <profile>",
+	Short: "Show a gemini profile's Google account and project",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGCPShow,
+}
+
+var gcpSetCmd = &cobra.Command{
+	Use:   "set <profile> <project>",
+	Short: "Set a gemini profile's project and quota project",
+	Long: `Sets GOOGLE_CLOUD_PROJECT in the profile's .env. The ADC quota project is
+set to --quota-project, or to the project when the profile holds ADC
+credentials and no quota project is given. If the profile is active, its
+refreshed tokens are saved first and the live auth files are updated.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runGCPSet,
+}
+
+func init() {
+	rootCmd.AddCommand(gcpCmd)
+	gcpCmd.AddCommand(gcpShowCmd)
+	gcpCmd.AddCommand(gcpSetCmd)
+
+	gcpShowCmd.Flags().Bool("json", false, "output as JSON")
+	gcpSetCmd.Flags().String("quota-project", "", "ADC quota project (default: the project, if the profile has ADC credentials)")
+	gcpSetCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// gcpOutput is the JSON output for gcp show and gcp set.
+type gcpOutput struct {
+	Profile      string `json:"profile"`
+	Email        string `json:"email,omitempty"`
+	Project      string `json:"project,omitempty"`
+	QuotaProject string `json:"quota_project,omitempty"`
+	HasADC       bool   `json:"has_adc"`
+	Active       bool   `json:"active"`
+}
+
+// gcpCommandSetup checks that a gemini profile exists.
+func gcpCommandSetup(profile string) error {
+	if vault == nil {
+		vault = authfile.NewVault(authfile.DefaultVaultPath())
+	}
+	profiles, err := vault.List("gemini")
+	if err != nil {
+		return err
+	}
+	for _, p := range profiles {
+		if p == profile {
+			return nil
+		}
+	}
+	return caamerr.Errorf(caamerr.ProfileNotFound, "profile gemini/%s not found", profile)
+}
+
+// gcpProfileStatus collects a gemini profile's account and project.
+func gcpProfileStatus(profile string) (gcpOutput, error) {
+	p, err := vault.GCPProject(profile)
+	if err != nil {
+		return gcpOutput{}, err
+	}
+	out := gcpOutput{
+		Profile:      profile,
+		Project:      p.Project,
+		QuotaProject: p.QuotaProject,
+	}
+	if id := getVaultIdentity("gemini", profile); id != nil {
+		out.Email = id.Email
+		if out.Project == "" {
+			out.Project = id.Organization
+		}
+	}
+	for _, spec := range authfile.GeminiADCAuthFiles().Files {
+		if _, err := os.Stat(vault.BackupPath("gemini", profile, spec.VaultFileName())); err == nil {
+			out.HasADC = true
+		}
+	}
+	active, _ := vault.ActiveProfile(authfile.GeminiAuthFiles())
+	out.Active = active == profile
+	return out, nil
+}
+
+func runGCPShow(cmd *cobra.Command, args []string) error {
+	profile := args[0]
+	if err := gcpCommandSetup(profile); err != nil {
+		return err
+	}
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	status, err := gcpProfileStatus(profile)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+	fmt.Fprintf(out, "gemini/%s:\n", profile)
+	printGCPStatus(cmd, status)
+	return nil
+}
+
+func runGCPSet(cmd *cobra.Command, args []string) error {
+	profile, project := args[0], args[1]
+	if err := gcpCommandSetup(profile); err != nil {
+		return err
+	}
+	quota, _ := cmd.Flags().GetString("quota-project")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	before, err := gcpProfileStatus(profile)
+	if err != nil {
+		return err
+	}
+	if quota == "" && before.HasADC {
+		quota = project
+	}
+
+	fileSet := authfile.GeminiAuthFiles()
+	if before.Active {
+		// Keep tokens the tools refreshed while the profile was live.
+		if err := vault.Backup(fileSet, profile); err != nil {
+			return fmt.Errorf("save current auth: %w", err)
+		}
+	}
+	if err := vault.SetGCPProject(profile, authfile.GCPProject{Project: project, QuotaProject: quota}); err != nil {
+		return err
+	}
+	if before.Active {
+		if err := activateProfile(fileSet, profile, "gcp", activationOptions{liveSaved: true}); err != nil {
+			return fmt.Errorf("activate gemini/%s: %w", profile, err)
+		}
+	}
+
+	status, err := gcpProfileStatus(profile)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+	fmt.Fprintf(out, "Switched gemini/%s to project %s\n", profile, project)
+	printGCPStatus(cmd, status)
+	if status.Active {
+		fmt.Fprintln(out, "  Live auth files updated.")
+	}
+	return nil
+}
+
+func printGCPStatus(cmd *cobra.Command, s gcpOutput) {
+	out := cmd.OutOrStdout()
+	orNone := func(v string) string {
+		if v == "" {
+			return "(none)"
+		}
+		return v
+	}
+	fmt.Fprintf(out, "  Account:       %s\n", orNone(s.Email))
+	fmt.Fprintf(out, "  Project:       %s\n", orNone(s.Project))
+	if s.HasADC {
+		fmt.Fprintf(out, "  Quota project: %s\n", orNone(s.QuotaProject))
+	} else {
+		fmt.Fprintln(out, "  Quota project: (no ADC credentials)")
+	}
+}
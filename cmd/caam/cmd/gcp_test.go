@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+func TestGCPSetSwitchesActiveProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam"))
+	t.Setenv("GEMINI_HOME", filepath.Join(tmpDir, "gemini"))
+	t.Setenv("GEMINI_CODE_ASSIST_HOME", filepath.Join(tmpDir, "code-assist"))
+	t.Setenv("CLOUDSDK_CONFIG", filepath.Join(tmpDir, "gcloud"))
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	defer func() { vault = oldVault }()
+
+	adcPath := filepath.Join(tmpDir, "gcloud", "application_default_credentials.json")
+	if err := os.MkdirAll(filepath.Dir(adcPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	adc := `{"type":"authorized_user","account":"dev@example.com","refresh_token":"1//r","quota_project_id":"old-proj"}`
+	if err := os.WriteFile(adcPath, []byte(adc), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := vault.Backup(authfile.GeminiADCAuthFiles(), "vertex"); err != nil {
+		t.Fatalf("backup gemini-adc: %v", err)
+	}
+
+	var buf bytes.Buffer
+	gcpSetCmd.SetOut(&buf)
+	defer gcpSetCmd.SetOut(nil)
+	for name, value := range map[string]string{"quota-project": "", "json": "true"} {
+		if err := gcpSetCmd.Flags().Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer gcpSetCmd.Flags().Set("json", "false")
+
+	if err := runGCPSet(gcpSetCmd, []string{"missing", "p"}); err == nil {
+		t.Fatal("gcp set on a missing profile: want error")
+	}
+	if err := runGCPSet(gcpSetCmd, []string{"vertex", "new-proj"}); err != nil {
+		t.Fatalf("gcp set: %v", err)
+	}
+	var got gcpOutput
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("parse output %q: %v", buf.String(), err)
+	}
+	if !got.Active || !got.HasADC || got.Email != "dev@example.com" || got.Project != "new-proj" || got.QuotaProject != "new-proj" {
+		t.Errorf("gcp set output = %+v", got)
+	}
+
+	// The profile was active, so the live credentials follow the switch.
+	data, err := os.ReadFile(adcPath)
+	if err != nil || !strings.Contains(string(data), `"quota_project_id": "new-proj"`) {
+		t.Errorf("live ADC = %s, %v", data, err)
+	}
+	env, err := os.ReadFile(filepath.Join(tmpDir, "gemini", ".env"))
+	if err != nil || string(env) != "GOOGLE_CLOUD_PROJECT=new-proj\n" {
+		t.Errorf("live .env = %q, %v", env, err)
+	}
+
+	info := buildProfileInfo("gemini", "vertex", "vertex", nil, false)
+	if info.Email != "dev@example.com" || info.GCPProject != "new-proj" || info.GCPQuota != "new-proj" {
+		t.Errorf("robot profile info = email %q, project %q, quota %q", info.Email, info.GCPProject, info.GCPQuota)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/spf13/cobra"
 )
@@ -53,7 +54,7 @@ func runHistory(cmd *cobra.Command, args []string) error {
 	if sinceStr != "" {
 		duration, err := parseDuration(sinceStr)
 		if err != nil {
-			return fmt.Errorf("invalid --since duration: %w", err)
+			return caamerr.Errorf(caamerr.InvalidArgs, "invalid --since duration: %w", err)
 		}
 		sinceTime = time.Now().Add(-duration)
 	}
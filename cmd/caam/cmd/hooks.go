@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/hooks"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "List and test lifecycle hooks",
+	Long: `Hooks are executables in ~/.config/caam/hooks/ named after the event they
+handle. Each reads the event as JSON on stdin: hook, time, provider,
+profile, source (what caused it), and data.
+
+  pre-activate    before a profile is activated, by any command
+  post-activate   after a profile is activated, by any command
+  pre-backup      before backup or robot act backup saves the current auth
+  post-backup     after backup or robot act backup
+  pre-cooldown    before cooldown set or robot act cooldown records a cooldown
+  post-cooldown   after a cooldown is recorded, by any command
+  post-refresh    after a token is refreshed
+  post-sync       after each sync with a machine
+  all-blocked     a cooldown left every profile of a provider blocked
+
+A pre- hook that exits non-zero refuses the action; its output is reported
+as the reason, with the error code HOOK_REJECTED. A failed post- hook is logged and otherwise ignored. Hooks
+are killed after 30s. CAAM_HOOK holds the hook's name while it runs, and
+caam commands it starts run no hooks of their own. Set CAAM_NO_HOOKS=1 to
+turn hooks off.
+
+Examples:
+  caam hooks list
+  caam hooks run post-activate claude work`,
+}
+
+var hooksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show which hooks are installed",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksList,
+}
+
+var hooksRunCmd = &cobra.Command{
+	Use:   "run <hook> [provider] [profile]",
+	Short: "Run a hook with a test event",
+	Args:  cobra.RangeArgs(1, 3),
+	RunE:  runHooksRun,
+}
+
+func init() {
+	rootCmd.AddCommand(hooksCmd)
+	hooksCmd.AddCommand(hooksListCmd)
+	hooksCmd.AddCommand(hooksRunCmd)
+
+	hooksListCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// hookStatus is one hook in caam hooks list.
+type hookStatus struct {
+	Hook      hooks.Name `json:"hook"`
+	Installed bool       `json:"installed"`
+	Path      string     `json:"path,omitempty"`
+}
+
+func runHooksList(cmd *cobra.Command, args []string) error {
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	out := cmd.OutOrStdout()
+
+	var list []hookStatus
+	for _, name := range hooks.Names() {
+		path := hooks.Path(name)
+		list = append(list, hookStatus{Hook: name, Installed: path != "", Path: path})
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Dir      string       `json:"dir"`
+			Disabled bool         `json:"disabled"`
+			Hooks    []hookStatus `json:"hooks"`
+		}{hooks.Dir(), hooks.Disabled(), list})
+	}
+
+	fmt.Fprintf(out, "Hooks directory: %s\n", hooks.Dir())
+	if hooks.Disabled() {
+		fmt.Fprintln(out, "Hooks are disabled in this environment.")
+	}
+	for _, h := range list {
+		state := "-"
+		if h.Installed {
+			state = "installed"
+		}
+		fmt.Fprintf(out, "  %-14s %s\n", h.Hook, state)
+	}
+	return nil
+}
+
+func runHooksRun(cmd *cobra.Command, args []string) error {
+	name := hooks.Name(args[0])
+	known := false
+	for _, n := range hooks.Names() {
+		known = known || n == name
+	}
+	if !known {
+		return caamerr.Errorf(caamerr.InvalidArgs, "unknown hook %q (see caam hooks list)", args[0])
+	}
+	if hooks.Disabled() {
+		return caamerr.Errorf(caamerr.InvalidArgs, "hooks are disabled (%s or %s is set)", hooks.DisableEnvVar, hooks.EnvVar)
+	}
+	if hooks.Path(name) == "" {
+		return caamerr.Errorf(caamerr.NotFound, "%s is not installed in %s", name, hooks.Dir())
+	}
+
+	p := hooks.Payload{Source: "test"}
+	if len(args) > 1 {
+		p.Provider = args[1]
+	}
+	if len(args) > 2 {
+		p.Profile = args[2]
+	}
+	if err := hooks.Run(cmd.Context(), name, p, hooks.DefaultTimeout); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%s ran successfully\n", name)
+	return nil
+}
+
+// runPreHook runs a pre- hook before an action on provider/profile. It
+// returns a HOOK_REJECTED error if the hook refuses the action.
+func runPreHook(name hooks.Name, provider, profile, source string) error {
+	err := hooks.Run(context.Background(), name, hooks.Payload{
+		Provider: provider,
+		Profile:  profile,
+		Source:   source,
+	}, hooks.DefaultTimeout)
+	if err != nil {
+		return caamerr.Errorf(caamerr.HookRejected, "%w", err)
+	}
+	return nil
+}
+
+// runPostHook runs a post- hook, logging its failure.
+func runPostHook(name hooks.Name, p hooks.Payload) {
+	if err := hooks.Run(context.Background(), name, p, hooks.DefaultTimeout); err != nil {
+		slog.Warn("hook failed", "hook", string(name), "error", err)
+	}
+}
+
+// runEventHooks runs the post- hook for an event this process published,
+// and the all-blocked hook when a cooldown blocks a provider's last
+// profile.
+func runEventHooks(ev events.Event) {
+	if hooks.Disabled() {
+		return
+	}
+	if name, ok := hooks.ForEvent(ev.Type); ok {
+		runPostHook(name, hooks.PayloadFor(name, ev))
+	}
+	if ev.Type == events.CooldownSet && hooks.Path(hooks.AllBlocked) != "" {
+		if blocked := providerAllBlocked(ev.Provider, time.Now()); len(blocked) > 0 {
+			runPostHook(hooks.AllBlocked, hooks.Payload{
+				Provider: ev.Provider,
+				Profile:  ev.Profile,
+				Source:   ev.Source,
+				Data:     map[string]any{"profiles": blocked},
+			})
+		}
+	}
+}
+
+// providerAllBlocked returns the provider's profiles if every one is in
+// cooldown or revoked, and nil otherwise.
+func providerAllBlocked(provider string, now time.Time) []string {
+	if vault == nil {
+		return nil
+	}
+	all, err := vault.List(provider)
+	if err != nil {
+		return nil
+	}
+	db, err := caamdb.Open()
+	if err != nil {
+		return nil
+	}
+	defer db.Close()
+
+	var profiles []string
+	for _, profile := range all {
+		if authfile.IsSystemProfile(profile) {
+			continue
+		}
+		cooldown, _ := db.ActiveCooldown(provider, profile, now)
+		revoked, _ := db.ActiveRevocation(provider, profile)
+		if cooldown == nil && revoked == nil {
+			return nil
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/hooks"
+)
+
+func installTestHook(t *testing.T, name hooks.Name, body string) {
+	t.Helper()
+	if err := os.MkdirAll(hooks.Dir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hooks.Dir(), string(name)), []byte("#!/bin/sh\n"+body+"\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHooksVetoAndAllBlocked(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test hooks are shell scripts")
+	}
+	tmpDir, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(hooks.EnvVar, "")
+	t.Setenv(hooks.DisableEnvVar, "")
+
+	for _, name := range []string{"a", "b"} {
+		if err := os.MkdirAll(vault.ProfilePath("codex", name), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(vault.ProfilePath("codex", name), "auth.json"), []byte(`{"access_token":"`+name+`"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	authPath := filepath.Join(os.Getenv("CODEX_HOME"), "auth.json")
+	if err := os.WriteFile(authPath, []byte(`{"access_token":"a"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A refusing pre-activate hook leaves the active profile alone.
+	installTestHook(t, hooks.PreActivate, `grep -q '"profile":"b"' && { echo "b is reserved for CI" >&2; exit 1; }; exit 0`)
+	_, failure := performRobotAct("activate", "codex", []string{"activate", "codex", "b"}, true)
+	if failure == nil || failure.Code != string(caamerr.HookRejected) || !strings.Contains(failure.Details, "b is reserved for CI") {
+		t.Fatalf("activate failure = %+v, want %s", failure, caamerr.HookRejected)
+	}
+	if data, _ := os.ReadFile(authPath); !strings.Contains(string(data), `"a"`) {
+		t.Errorf("auth after refused activation = %s", data)
+	}
+
+	// all-blocked runs once the last unblocked profile goes into cooldown.
+	out := filepath.Join(tmpDir, "all-blocked.json")
+	installTestHook(t, hooks.AllBlocked, "cat > "+out)
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	now := time.Now()
+	for _, profile := range []string{"a", "b"} {
+		if _, err := db.SetCooldown("codex", profile, now, time.Hour, "test"); err != nil {
+			t.Fatal(err)
+		}
+		runEventHooks(events.Event{Type: events.CooldownSet, Provider: "codex", Profile: profile, Source: "cooldown"})
+		if _, err := os.Stat(out); (err == nil) != (profile == "b") {
+			t.Fatalf("after cooldown on %s: all-blocked ran = %v", profile, err == nil)
+		}
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p hooks.Payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatalf("payload %q: %v", data, err)
+	}
+	if p.Hook != hooks.AllBlocked || p.Provider != "codex" || p.Profile != "b" || len(p.Data["profiles"].([]any)) != 2 {
+		t.Errorf("all-blocked payload = %+v", p)
+	}
+}
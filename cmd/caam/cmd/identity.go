@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+var identityCmd = &cobra.Command{
+	Use:   "identity",
+	Short: "Inspect and backfill account identity for vault profiles",
+	Long: `caam reads each profile's email and plan from its auth files. Profiles
+saved before identity extraction existed, or whose files don't carry an
+email, show up blank in status and can't be matched by email.
+
+'caam identity refresh' re-parses stored profiles (and, with --online, asks
+the provider's profile API) and records what it finds, so those profiles
+show their email and plan everywhere.
+
+Examples:
+  caam identity refresh --all
+  caam identity refresh claude work --online
+  caam identity list`,
+}
+
+var identityRefreshCmd = &cobra.Command{
+	Use:   "refresh [provider] [profile]",
+	Short: "Re-parse vault profiles and record their email and plan",
+	Args:  cobra.MaximumNArgs(2),
+	RunE:  runIdentityRefresh,
+}
+
+var identityListCmd = &cobra.Command{
+	Use:     "list [provider]",
+	Aliases: []string{"ls"},
+	Short:   "List recorded profile identities",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    runIdentityList,
+}
+
+func init() {
+	rootCmd.AddCommand(identityCmd)
+	identityCmd.AddCommand(identityRefreshCmd)
+	identityCmd.AddCommand(identityListCmd)
+
+	identityRefreshCmd.Flags().Bool("all", false, "refresh every profile of every provider")
+	identityRefreshCmd.Flags().Bool("online", false, "query provider profile APIs for fields the auth files lack")
+	identityRefreshCmd.Flags().Bool("json", false, "output as JSON")
+	identityListCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// identityRefreshResult is one profile in identity refresh output.
+type identityRefreshResult struct {
+	Provider string `json:"provider"`
+	Profile  string `json:"profile"`
+	Email    string `json:"email,omitempty"`
+	Plan     string `json:"plan,omitempty"`
+	Source   string `json:"source,omitempty"`
+	// Status is "updated", "unchanged", or "missing".
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// identityListItem is one recorded identity in identity list output.
+type identityListItem struct {
+	Provider     string    `json:"provider"`
+	Profile      string    `json:"profile"`
+	Email        string    `json:"email,omitempty"`
+	Plan         string    `json:"plan,omitempty"`
+	AccountID    string    `json:"account_id,omitempty"`
+	Organization string    `json:"organization,omitempty"`
+	Source       string    `json:"source"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func runIdentityRefresh(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	online, _ := cmd.Flags().GetBool("online")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	if !all && len(args) == 0 {
+		return fmt.Errorf("specify a provider (and optionally a profile), or use --all")
+	}
+	if all && len(args) > 0 {
+		return fmt.Errorf("--all cannot be combined with a provider or profile")
+	}
+
+	targets, err := identityRefreshTargets(args)
+	if err != nil {
+		return err
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var results []identityRefreshResult
+	for _, t := range targets {
+		results = append(results, refreshProfileIdentity(ctx, db, t[0], t[1], online))
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOut {
+		if results == nil {
+			results = []identityRefreshResult{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintln(out, "No profiles found.")
+		return nil
+	}
+
+	updated, missing := 0, 0
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PROFILE\tEMAIL\tPLAN\tSOURCE\tSTATUS")
+	for _, r := range results {
+		status := r.Status
+		if r.Error != "" {
+			status += " (" + r.Error + ")"
+		}
+		_, _ = fmt.Fprintf(tw, "%s/%s\t%s\t%s\t%s\t%s\n", r.Provider, r.Profile, valueOrDash(r.Email), valueOrDash(r.Plan), valueOrDash(r.Source), status)
+		switch r.Status {
+		case "updated":
+			updated++
+		case "missing":
+			missing++
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\n%d profile(s): %d updated, %d without identity.\n", len(results), updated, missing)
+	if missing > 0 && !online {
+		fmt.Fprintln(out, "Try --online to ask the provider for missing fields.")
+	}
+	return nil
+}
+
+// identityRefreshTargets expands refresh arguments into provider/profile
+// pairs, sorted for stable output.
+func identityRefreshTargets(args []string) ([][2]string, error) {
+	if vault == nil {
+		vault = authfile.NewVault(authfile.DefaultVaultPath())
+	}
+
+	var providers []string
+	if len(args) > 0 {
+		provider := strings.ToLower(args[0])
+		if _, ok := tools[provider]; !ok {
+			return nil, caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", provider)
+		}
+		providers = []string{provider}
+	} else {
+		for name := range tools {
+			providers = append(providers, name)
+		}
+		sort.Strings(providers)
+	}
+
+	var targets [][2]string
+	for _, provider := range providers {
+		if len(args) == 2 {
+			if _, err := os.Stat(vault.ProfilePath(provider, args[1])); err != nil {
+				return nil, caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s not found", provider, args[1])
+			}
+			targets = append(targets, [2]string{provider, args[1]})
+			continue
+		}
+		profiles, err := vault.List(provider)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(profiles)
+		for _, profile := range profiles {
+			if authfile.IsSystemProfile(profile) {
+				continue
+			}
+			targets = append(targets, [2]string{provider, profile})
+		}
+	}
+	return targets, nil
+}
+
+// refreshProfileIdentity re-parses one profile, optionally fills gaps from
+// the provider API, and records the result.
+func refreshProfileIdentity(ctx context.Context, db *caamdb.DB, provider, profile string, online bool) identityRefreshResult {
+	result := identityRefreshResult{Provider: provider, Profile: profile, Status: "missing"}
+
+	id := extractVaultIdentity(provider, profile)
+	source := "vault"
+	if id == nil {
+		id = &identity.Identity{Provider: provider}
+	}
+	if online && (id.Email == "" || id.PlanType == "") {
+		if err := fillIdentityOnline(ctx, provider, vault.ProfilePath(provider, profile), id); err != nil {
+			result.Error = err.Error()
+		} else {
+			source = "api"
+		}
+	}
+	normalizeIdentityPlan(id)
+
+	result.Email = id.Email
+	result.Plan = id.PlanType
+	if id.Email == "" && id.PlanType == "" && id.AccountID == "" {
+		return result
+	}
+	result.Source = source
+
+	before, _ := db.ProfileIdentityFor(provider, profile)
+	if err := db.UpsertProfileIdentity(caamdb.ProfileIdentity{
+		Provider:     provider,
+		ProfileName:  profile,
+		Email:        id.Email,
+		PlanType:     id.PlanType,
+		AccountID:    id.AccountID,
+		Organization: id.Organization,
+		Source:       source,
+	}); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if id.PlanType != "" && healthStore != nil {
+		_ = healthStore.SetPlanType(provider, profile, normalizePlanType(id.PlanType))
+	}
+
+	result.Status = "updated"
+	if before != nil && before.Email == id.Email && before.PlanType == id.PlanType {
+		result.Status = "unchanged"
+	}
+	return result
+}
+
+// fillIdentityOnline completes id from the provider's profile or usage API
+// using the token stored in the vault profile.
+func fillIdentityOnline(ctx context.Context, provider, profileDir string, id *identity.Identity) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	switch provider {
+	case "claude":
+		token, _, err := usage.ReadClaudeCredentials(filepath.Join(profileDir, ".credentials.json"))
+		if err != nil {
+			return fmt.Errorf("read credentials: %w", err)
+		}
+		profile, err := usage.NewClaudeFetcher().FetchProfile(ctx, token)
+		if err != nil {
+			return err
+		}
+		if id.Email == "" {
+			id.Email = profile.Email
+		}
+		if id.PlanType == "" {
+			id.PlanType = profile.PlanType
+		}
+		if id.AccountID == "" {
+			id.AccountID = profile.AccountUUID
+		}
+		if id.Organization == "" {
+			id.Organization = profile.OrganizationName
+		}
+		return nil
+	case "codex":
+		token, accountID, err := usage.ReadCodexCredentials(filepath.Join(profileDir, "auth.json"))
+		if err != nil {
+			return fmt.Errorf("read credentials: %w", err)
+		}
+		info, err := usage.NewCodexFetcher().FetchWithOptions(ctx, token, &usage.CodexFetchOptions{AccountID: accountID})
+		if err != nil {
+			return err
+		}
+		if id.PlanType == "" {
+			id.PlanType = info.PlanType
+		}
+		if id.AccountID == "" {
+			id.AccountID = accountID
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s has no profile API", provider)
+	}
+}
+
+func runIdentityList(cmd *cobra.Command, args []string) error {
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	provider := ""
+	if len(args) > 0 {
+		provider = strings.ToLower(args[0])
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	ids, err := db.ListProfileIdentities(provider)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOut {
+		items := make([]identityListItem, 0, len(ids))
+		for _, pi := range ids {
+			items = append(items, identityListItem{
+				Provider:     pi.Provider,
+				Profile:      pi.ProfileName,
+				Email:        pi.Email,
+				Plan:         pi.PlanType,
+				AccountID:    pi.AccountID,
+				Organization: pi.Organization,
+				Source:       pi.Source,
+				UpdatedAt:    pi.UpdatedAt,
+			})
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	}
+
+	if len(ids) == 0 {
+		fmt.Fprintln(out, "No identities recorded. Run 'caam identity refresh --all'.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PROFILE\tEMAIL\tPLAN\tSOURCE\tUPDATED")
+	for _, pi := range ids {
+		_, _ = fmt.Fprintf(tw, "%s/%s\t%s\t%s\t%s\t%s\n", pi.Provider, pi.ProfileName, valueOrDash(pi.Email), valueOrDash(pi.PlanType), pi.Source, formatTimeAgo(pi.UpdatedAt))
+	}
+	return tw.Flush()
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
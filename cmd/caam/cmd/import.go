@@ -18,6 +18,7 @@ Examples:
   caam import codex-work.tar.gz
   cat codex-work.tar.gz | caam import -
   caam import codex-work.tar.gz --as codex/server-work
+  caam import all.tar.gz --progress json 2>progress.ndjson
 `,
 	Args: cobra.ExactArgs(1),
 	RunE: runImport,
@@ -26,12 +27,17 @@ Examples:
 func init() {
 	importCmd.Flags().String("as", "", "import single-profile archive under a new tool/profile (e.g. codex/server-work)")
 	importCmd.Flags().Bool("force", false, "overwrite existing profile(s) if they already exist")
+	addProgressFlag(importCmd)
 }
 
 func runImport(cmd *cobra.Command, args []string) error {
 	inPath := strings.TrimSpace(args[0])
 	as, _ := cmd.Flags().GetString("as")
 	force, _ := cmd.Flags().GetBool("force")
+	prog, err := progressReporter(cmd, "import")
+	if err != nil {
+		return err
+	}
 
 	var r io.Reader
 	var close func() error
@@ -50,6 +56,7 @@ func runImport(cmd *cobra.Command, args []string) error {
 
 	var opt importOptions
 	opt.Force = force
+	opt.Progress = prog
 	if as != "" {
 		tool, profile, err := parseToolProfileArg(as)
 		if err != nil {
@@ -60,6 +67,7 @@ func runImport(cmd *cobra.Command, args []string) error {
 	}
 
 	manifest, err := importArchive(r, vault, opt)
+	prog.Done(err)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/daemon"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/jobs"
+)
+
+// activeJob is the job the current command is running as, if any.
+// progressReporter forwards progress events to it.
+var activeJob *jobs.Job
+
+// jobSecretFlags are never written to a job record, so commands using them
+// cannot be queued with --async.
+var jobSecretFlags = map[string]bool{"password": true}
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "List and manage long-running jobs",
+	Long: `Long-running operations - sync runs, bulk refreshes (refresh --all),
+bundle export/import, and distributed setup - are recorded as jobs with an
+ID, status, progress, and a log, so they can be inspected after the fact and
+are reported as interrupted rather than vanishing if the process dies.
+
+Add --async to any of those commands to queue it for the daemon instead of
+blocking the terminal. Queued jobs run one at a time with the same flags, so
+include non-interactive flags (--force, --yes) where the command would
+otherwise prompt.
+
+Examples:
+  caam sync --async
+  caam jobs list
+  caam jobs show 20260101-120000-a1b2c3
+  caam jobs cancel 20260101-120000-a1b2c3`,
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List recent jobs",
+	Args:    cobra.NoArgs,
+	RunE:    runJobsList,
+}
+
+var jobsShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a job's status, progress, and log",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobsShow,
+}
+
+var jobsCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel a queued or running job",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobsCancel,
+}
+
+func init() {
+	rootCmd.AddCommand(jobsCmd)
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsCmd.AddCommand(jobsShowCmd)
+	jobsCmd.AddCommand(jobsCancelCmd)
+
+	jobsListCmd.Flags().Int("limit", 20, "number of jobs to show (0 for all)")
+	jobsListCmd.Flags().Bool("json", false, "output as JSON")
+	jobsShowCmd.Flags().Bool("json", false, "output as JSON")
+	jobsShowCmd.Flags().Bool("no-log", false, "omit the job log")
+}
+
+// addAsyncFlag adds --async to a command wrapped with jobRunE.
+func addAsyncFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("async", false, "queue as a background job for the daemon (see 'caam jobs')")
+}
+
+// jobRunE wraps a long-running command so each run is recorded as a job, or
+// queued for the daemon with --async. Dry runs are never recorded; track,
+// when set, further limits which invocations count as long-running.
+func jobRunE(kind string, run func(*cobra.Command, []string) error, track func(*cobra.Command, []string) bool) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		async, _ := cmd.Flags().GetBool("async")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if dryRun || (track != nil && !track(cmd, args)) {
+			if async {
+				return fmt.Errorf("--async only applies to %s runs that do real work", kind)
+			}
+			return run(cmd, args)
+		}
+
+		if async {
+			return queueJob(cmd, kind, args)
+		}
+
+		var job *jobs.Job
+		var err error
+		if id := os.Getenv(jobs.EnvJobID); id != "" {
+			job, err = jobs.Load(id)
+			if err == nil && job.Status != jobs.StatusQueued {
+				err = fmt.Errorf("job %s is %s", id, job.Status)
+			}
+		} else {
+			job, err = jobs.New(kind, jobArgs(cmd, args))
+		}
+		if err != nil {
+			return err
+		}
+
+		ctx, stop, err := job.Start(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer stop()
+		cmd.SetContext(ctx)
+
+		activeJob = job
+		defer func() { activeJob = nil }()
+
+		runErr := run(cmd, args)
+		if err := job.Finish(runErr); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: could not record job %s: %v\n", job.ID, err)
+		}
+		return runErr
+	}
+}
+
+// queueJob records the command as a queued job for the daemon to run.
+func queueJob(cmd *cobra.Command, kind string, args []string) error {
+	var secret string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if jobSecretFlags[f.Name] {
+			secret = f.Name
+		}
+	})
+	if secret != "" {
+		return fmt.Errorf("--%s is not stored in job records, so it cannot be combined with --async", secret)
+	}
+
+	job, err := jobs.New(kind, jobArgs(cmd, args))
+	if err != nil {
+		return err
+	}
+	job.Async = true
+	job.Dir, _ = os.Getwd()
+	if err := job.Save(); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Queued job %s\n", job.ID)
+	fmt.Fprintf(out, "  caam jobs show %s\n", job.ID)
+	if running, _, _ := daemon.GetDaemonStatus(); !running {
+		fmt.Fprintln(out, "Note: the daemon is not running; start it with 'caam daemon start' to run queued jobs.")
+	}
+	return nil
+}
+
+// jobArgs rebuilds the command line for a job from the parsed command,
+// leaving out --async so the daemon runs it in the foreground.
+func jobArgs(cmd *cobra.Command, args []string) []string {
+	argv := strings.Fields(cmd.CommandPath())[1:]
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Name == "async" || jobSecretFlags[f.Name] {
+			return
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			for _, v := range sv.GetSlice() {
+				argv = append(argv, "--"+f.Name+"="+v)
+			}
+			return
+		}
+		argv = append(argv, "--"+f.Name+"="+f.Value.String())
+	})
+	if len(args) > 0 {
+		argv = append(argv, "--")
+		argv = append(argv, args...)
+	}
+	return argv
+}
+
+func runJobsList(cmd *cobra.Command, args []string) error {
+	list, err := jobs.List()
+	if err != nil {
+		return err
+	}
+	limit, _ := cmd.Flags().GetInt("limit")
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	if jsonOut {
+		if list == nil {
+			list = []*jobs.Job{}
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+
+	out := cmd.OutOrStdout()
+	if len(list) == 0 {
+		fmt.Fprintln(out, "No jobs recorded.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tKIND\tSTATUS\tPROGRESS\tCREATED\tDURATION")
+	for _, j := range list {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			j.ID,
+			j.Kind,
+			j.Status,
+			jobProgressSummary(j),
+			formatTimeAgo(j.CreatedAt),
+			jobDuration(j),
+		)
+	}
+	return tw.Flush()
+}
+
+func runJobsShow(cmd *cobra.Command, args []string) error {
+	job, err := jobs.Load(args[0])
+	if err != nil {
+		return err
+	}
+	noLog, _ := cmd.Flags().GetBool("no-log")
+	var logText string
+	if !noLog {
+		if data, err := os.ReadFile(jobs.LogPath(job.ID)); err == nil {
+			logText = string(data)
+		}
+	}
+
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	if jsonOut {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			*jobs.Job
+			Log string `json:"log,omitempty"`
+		}{job, logText})
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Job:      %s\n", job.ID)
+	fmt.Fprintf(out, "Kind:     %s\n", job.Kind)
+	fmt.Fprintf(out, "Command:  caam %s\n", strings.Join(job.Args, " "))
+	fmt.Fprintf(out, "Status:   %s\n", job.Status)
+	fmt.Fprintf(out, "Created:  %s\n", job.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	if !job.StartedAt.IsZero() {
+		fmt.Fprintf(out, "Started:  %s\n", job.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if !job.FinishedAt.IsZero() {
+		fmt.Fprintf(out, "Finished: %s (%s)\n", job.FinishedAt.Local().Format("2006-01-02 15:04:05"), jobDuration(job))
+	}
+	if p := jobProgressSummary(job); p != "-" {
+		fmt.Fprintf(out, "Progress: %s\n", p)
+	}
+	if job.Error != "" {
+		fmt.Fprintf(out, "Error:    %s\n", job.Error)
+	}
+	if logText != "" {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Log:")
+		writeIndented(out, logText)
+	}
+	return nil
+}
+
+func runJobsCancel(cmd *cobra.Command, args []string) error {
+	job, err := jobs.Cancel(args[0])
+	if err != nil {
+		return err
+	}
+	if job.Status == jobs.StatusCancelled {
+		fmt.Fprintf(cmd.OutOrStdout(), "Cancelled queued job %s\n", job.ID)
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Requested cancellation of job %s; it stops at the next safe point.\n", job.ID)
+	return nil
+}
+
+// jobProgressSummary renders a job's latest progress event.
+func jobProgressSummary(j *jobs.Job) string {
+	p := j.Progress
+	if p == nil {
+		return "-"
+	}
+	var parts []string
+	if p.Total > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d", p.Current, p.Total))
+	}
+	if p.Item != "" {
+		parts = append(parts, p.Item)
+	} else if p.Phase != "" {
+		parts = append(parts, p.Phase)
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+// jobDuration is how long a job ran, or has been running.
+func jobDuration(j *jobs.Job) string {
+	if j.StartedAt.IsZero() {
+		return "-"
+	}
+	end := j.FinishedAt
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return formatDurationShort(end.Sub(j.StartedAt))
+}
+
+func writeIndented(w io.Writer, text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/jobs"
+)
+
+func newJobTestCmd(run func(*cobra.Command, []string) error) *cobra.Command {
+	parent := &cobra.Command{Use: "caam"}
+	c := &cobra.Command{Use: "refresh", RunE: jobRunE("refresh", run, refreshIsBulk)}
+	c.Flags().Bool("all", false, "")
+	c.Flags().Bool("dry-run", false, "")
+	c.Flags().StringSlice("provider", nil, "")
+	c.Flags().String("password", "", "")
+	addAsyncFlag(c)
+	parent.AddCommand(c)
+	return parent
+}
+
+func TestJobArgs(t *testing.T) {
+	root := newJobTestCmd(func(*cobra.Command, []string) error { return nil })
+	c, _, _ := root.Find([]string{"refresh"})
+	if err := c.ParseFlags([]string{"--all", "--async", "--provider", "claude,codex", "--password", "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := jobArgs(c, []string{"claude"})
+	want := []string{"refresh", "--all=true", "--provider=claude", "--provider=codex", "--", "claude"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("jobArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestJobRunE(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	t.Setenv(jobs.EnvJobID, "")
+
+	var sawJob bool
+	runErr := errors.New("2 profiles failed")
+	root := newJobTestCmd(func(*cobra.Command, []string) error {
+		sawJob = activeJob != nil
+		return runErr
+	})
+
+	// Not bulk: runs directly without a job.
+	root.SetArgs([]string{"refresh"})
+	if err := root.Execute(); err != runErr || sawJob {
+		t.Fatalf("plain refresh: err = %v, job = %v", err, sawJob)
+	}
+	if list, _ := jobs.List(); len(list) != 0 {
+		t.Fatalf("plain refresh recorded %d jobs", len(list))
+	}
+
+	// Bulk: recorded as a failed job.
+	root.SetArgs([]string{"refresh", "--all"})
+	if err := root.Execute(); err != runErr || !sawJob {
+		t.Fatalf("refresh --all: err = %v, job = %v", err, sawJob)
+	}
+	list, _ := jobs.List()
+	if len(list) != 1 || list[0].Status != jobs.StatusFailed || list[0].Error != runErr.Error() {
+		t.Fatalf("jobs = %+v", list)
+	}
+
+	// --async queues without running; secrets are refused.
+	sawJob = false
+	root.SetArgs([]string{"refresh", "--all", "--async"})
+	if err := root.Execute(); err != nil || sawJob {
+		t.Fatalf("refresh --all --async: err = %v, ran = %v", err, sawJob)
+	}
+	next, _ := jobs.NextQueued()
+	if next == nil || !next.Async || next.Dir == "" {
+		t.Fatalf("queued job = %+v", next)
+	}
+	root.SetArgs([]string{"refresh", "--all", "--async", "--password", "x"})
+	if err := root.Execute(); err == nil {
+		t.Error("--async with --password should be refused")
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/exec"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+// launchCmd runs any command inside an isolated profile's sandbox.
+var launchCmd = &cobra.Command{
+	Use:   "launch <provider> <profile> [-- command args...]",
+	Short: "Run a command in an isolated profile's sandbox",
+	Long: `Runs a command with an isolated profile's environment: the profile's own
+HOME and XDG_CONFIG_HOME, XDG_DATA_HOME, XDG_CACHE_HOME and XDG_STATE_HOME,
+the provider's environment variables, the proxy and user agent from
+stealth.network in config.yaml, and the profile's own network settings
+(see 'caam profile network'). With no command, the provider's CLI is run.
+
+The profile is locked while the command runs and unlocked when it exits. A
+lock left behind by a process that no longer exists is recovered
+automatically; a lock held by a running process makes launch fail.
+
+Proxy settings apply to every profile, or to one profile under
+stealth.network.profiles.<provider>/<profile>:
+
+  caam robot config set stealth.network.profiles.claude/work.proxy http://127.0.0.1:8080
+
+Examples:
+  caam launch claude work                      # Claude Code in the sandbox
+  caam launch codex work -- codex exec "fix the tests"
+  caam launch claude work -- bash              # A shell inside the sandbox
+  caam launch claude work --env FOO=bar -- env`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runLaunch,
+}
+
+func init() {
+	rootCmd.AddCommand(launchCmd)
+	launchCmd.Flags().StringArray("env", nil, "extra environment variable KEY=VALUE (repeatable)")
+}
+
+func runLaunch(cmd *cobra.Command, args []string) error {
+	tool := strings.ToLower(args[0])
+	name := args[1]
+	extraEnv, _ := cmd.Flags().GetStringArray("env")
+
+	prov, ok := registry.Get(tool)
+	if !ok {
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", tool)
+	}
+	if profileStore == nil {
+		profileStore = profile.NewStore(profile.DefaultStorePath())
+	}
+	prof, err := profileStore.Load(tool, name)
+	if err != nil {
+		return caamerr.Errorf(caamerr.ProfileNotFound, "isolated profile %s/%s: %w; create it with 'caam profile add %s %s'", tool, name, err, tool, name)
+	}
+
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
+	}
+	env, stopNetwork, err := profileNetworkEnv(prof, spmCfg)
+	if err != nil {
+		return err
+	}
+	defer stopNetwork()
+	for _, kv := range extraEnv {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return caamerr.Errorf(caamerr.InvalidArgs, "--env %q must be KEY=VALUE", kv)
+		}
+		env[k] = v
+	}
+
+	if err := recoverLaunchLock(cmd, prof); err != nil {
+		return err
+	}
+
+	if runner == nil {
+		runner = exec.NewRunner(registry)
+	}
+	err = runner.Run(context.Background(), exec.RunOptions{
+		Profile:  prof,
+		Provider: prov,
+		Command:  args[2:],
+		Env:      env,
+		Sandbox:  true,
+	})
+	var exitErr *exec.ExitCodeError
+	if errors.As(err, &exitErr) {
+		stopNetwork()
+		os.Exit(exitErr.Code)
+	}
+	return err
+}
+
+// recoverLaunchLock clears a stale lock on prof and fails if a running
+// process holds it.
+func recoverLaunchLock(cmd *cobra.Command, prof *profile.Profile) error {
+	info, err := prof.GetLockInfo()
+	if err != nil || info == nil {
+		return err
+	}
+	if profile.IsProcessAlive(info.PID) {
+		return caamerr.Errorf(caamerr.Conflict, "profile %s/%s is in use by pid %d since %s", prof.Provider, prof.Name, info.PID, info.LockedAt.Local().Format("15:04 Jan 2"))
+	}
+	if cleaned, err := prof.CleanStaleLock(); err != nil {
+		return err
+	} else if cleaned {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Recovered stale lock on %s/%s left by pid %d\n", prof.Provider, prof.Name, info.PID)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+func TestRecoverLaunchLock(t *testing.T) {
+	prof := &profile.Profile{Name: "work", Provider: "claude", BasePath: t.TempDir()}
+	writeLock := func(pid int) {
+		t.Helper()
+		content := fmt.Sprintf(`{"pid": %d, "locked_at": %q}`, pid, time.Now().Format(time.RFC3339))
+		if err := os.WriteFile(prof.LockPath(), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stderr bytes.Buffer
+	launchCmd.SetErr(&stderr)
+	defer launchCmd.SetErr(nil)
+
+	if err := recoverLaunchLock(launchCmd, prof); err != nil {
+		t.Fatalf("unlocked profile: %v", err)
+	}
+
+	// A lock held by a running process is refused.
+	writeLock(os.Getpid())
+	err := recoverLaunchLock(launchCmd, prof)
+	if caamerr.CodeOf(err) != caamerr.Conflict {
+		t.Fatalf("live lock: err = %v, want %s", err, caamerr.Conflict)
+	}
+	if !prof.IsLocked() {
+		t.Fatal("live lock was removed")
+	}
+
+	// A lock left by a dead process is recovered.
+	writeLock(999999999)
+	if err := recoverLaunchLock(launchCmd, prof); err != nil {
+		t.Fatalf("stale lock: %v", err)
+	}
+	if prof.IsLocked() {
+		t.Error("stale lock not removed")
+	}
+	if !strings.Contains(stderr.String(), "Recovered stale lock") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
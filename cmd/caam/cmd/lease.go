@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/coordinator"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/federation"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/namespace"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+// defaultLeaseTTL is how long 'caam lease acquire' holds a profile without
+// --ttl.
+const defaultLeaseTTL = 2 * time.Hour
+
+var leaseCmd = &cobra.Command{
+	Use:   "lease",
+	Short: "Reserve profiles so two agents don't share an account",
+	Long: `A lease reserves a profile for one holder until it expires or is released.
+While it is held, 'caam activate', 'caam robot act activate', and
+'caam robot next' on other machines and for other holders leave the profile
+alone.
+
+Leases are kept in the local database and published to the sync pool; each
+'caam sync' exchanges them with peers, and coordinators report them in
+/status so federated machines see them too. Leases lapse on their own when
+the TTL runs out; acquiring your own lease again renews it.
+
+The holder is CAAM_USER (or the OS user) at this machine's hostname unless
+--holder is given.
+
+Examples:
+  caam lease acquire claude work1 --ttl 2h --note "nightly refactor"
+  caam lease list
+  caam lease release claude work1`,
+}
+
+var leaseAcquireCmd = &cobra.Command{
+	Use:   "acquire <tool> <profile>",
+	Short: "Take or renew the lease on a profile",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runLeaseAcquire,
+}
+
+var leaseReleaseCmd = &cobra.Command{
+	Use:   "release <tool> <profile>",
+	Short: "Give up a lease",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runLeaseRelease,
+}
+
+var leaseListCmd = &cobra.Command{
+	Use:   "list [tool]",
+	Short: "List active leases, local and from other machines",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runLeaseList,
+}
+
+func init() {
+	rootCmd.AddCommand(leaseCmd)
+	leaseCmd.AddCommand(leaseAcquireCmd)
+	leaseCmd.AddCommand(leaseReleaseCmd)
+	leaseCmd.AddCommand(leaseListCmd)
+
+	leaseAcquireCmd.Flags().Duration("ttl", defaultLeaseTTL, "how long the lease lasts")
+	leaseAcquireCmd.Flags().String("note", "", "what the profile is being used for")
+	leaseAcquireCmd.Flags().String("holder", "", "lease holder (default: user@hostname)")
+	leaseAcquireCmd.Flags().Bool("json", false, "output as JSON")
+
+	leaseReleaseCmd.Flags().String("holder", "", "lease holder (default: user@hostname)")
+	leaseReleaseCmd.Flags().Bool("force", false, "release the lease whoever holds it")
+
+	leaseListCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// profileLease is a lease on a profile as seen from this machine.
+type profileLease struct {
+	Provider  string    `json:"provider"`
+	Profile   string    `json:"profile"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Note      string    `json:"note,omitempty"`
+
+	// Source is "local", "sync", or "coordinator"; Machine names the
+	// machine a remote lease came from.
+	Source  string `json:"source"`
+	Machine string `json:"machine,omitempty"`
+}
+
+// leaseHolder names the caller on this machine, e.g. "alice@buildbox".
+func leaseHolder() string {
+	holder := namespace.Caller()
+	if host, err := os.Hostname(); err == nil && host != "" {
+		holder += "@" + host
+	}
+	return holder
+}
+
+// remoteLeases returns the unexpired leases other machines hold: those
+// exchanged through the sync pool and those in the daemon's federation
+// snapshot of peer coordinators.
+func remoteLeases(now time.Time) []profileLease {
+	var out []profileLease
+	if peers, err := syncstate.PeerLeases(now); err == nil {
+		for _, l := range peers {
+			out = append(out, profileLease{
+				Provider: l.Provider, Profile: l.Profile, Holder: l.Holder,
+				ExpiresAt: l.ExpiresAt, Note: l.Note, Source: "sync", Machine: l.Machine,
+			})
+		}
+	}
+	if snap, err := federation.LoadSnapshot(federation.SnapshotPath()); err == nil && snap != nil {
+		for _, m := range snap.Machines {
+			if m.Status == nil {
+				continue
+			}
+			for _, l := range m.Status.Leases {
+				if l.ExpiresAt.After(now) {
+					out = append(out, profileLease{
+						Provider: l.Provider, Profile: l.Profile, Holder: l.Holder,
+						ExpiresAt: l.ExpiresAt, Source: "coordinator", Machine: m.Machine,
+					})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// blockingLease returns a lease someone other than holder has on
+// provider/profile, here or on another machine, or nil.
+func blockingLease(db *caamdb.DB, provider, profile, holder string, now time.Time) *profileLease {
+	if db != nil {
+		if l, err := db.ActiveLease(provider, profile, now); err == nil && l != nil && l.Holder != holder {
+			return &profileLease{
+				Provider: l.Provider, Profile: l.ProfileName, Holder: l.Holder,
+				ExpiresAt: l.ExpiresAt, Note: l.Note, Source: "local",
+			}
+		}
+	}
+	for _, l := range remoteLeases(now) {
+		if l.Provider == provider && l.Profile == profile && l.Holder != holder {
+			return &l
+		}
+	}
+	return nil
+}
+
+// unleasedProfiles returns the profiles no one else has leased, here or on
+// another machine, as robot next does when it picks a candidate.
+func unleasedProfiles(db *caamdb.DB, provider string, profiles []string) []string {
+	holder, now := leaseHolder(), time.Now().UTC()
+	var out []string
+	for _, profile := range profiles {
+		if blockingLease(db, provider, profile, holder, now) == nil {
+			out = append(out, profile)
+		}
+	}
+	return out
+}
+
+// leasedError reports that l keeps the caller off a profile.
+func leasedError(l *profileLease) error {
+	where := ""
+	if l.Machine != "" {
+		where = " on " + l.Machine
+	}
+	return caamerr.Errorf(caamerr.ProfileLeased, "%s/%s is leased by %s%s until %s",
+		l.Provider, l.Profile, l.Holder, where, l.ExpiresAt.Local().Format(time.RFC3339))
+}
+
+// publishLeases records this machine's active leases for the sync pool to
+// pick up.
+func publishLeases(db *caamdb.DB) {
+	leases, err := db.ActiveLeases("", time.Now())
+	if err != nil {
+		return
+	}
+	records := make([]syncstate.LeaseRecord, 0, len(leases))
+	for _, l := range leases {
+		records = append(records, syncstate.LeaseRecord{
+			Provider:   l.Provider,
+			Profile:    l.ProfileName,
+			Holder:     l.Holder,
+			AcquiredAt: l.AcquiredAt,
+			ExpiresAt:  l.ExpiresAt,
+			Note:       l.Note,
+		})
+	}
+	_ = syncstate.PublishLeases(records)
+}
+
+// coordinatorLeases lists this machine's active leases for the
+// coordinator's /status.
+func coordinatorLeases() []coordinator.LeaseStatus {
+	db, err := caamdb.Open()
+	if err != nil {
+		return nil
+	}
+	defer db.Close()
+	leases, err := db.ActiveLeases("", time.Now())
+	if err != nil {
+		return nil
+	}
+	out := make([]coordinator.LeaseStatus, 0, len(leases))
+	for _, l := range leases {
+		out = append(out, coordinator.LeaseStatus{
+			Provider:  l.Provider,
+			Profile:   l.ProfileName,
+			Holder:    l.Holder,
+			ExpiresAt: l.ExpiresAt,
+		})
+	}
+	return out
+}
+
+func runLeaseAcquire(cmd *cobra.Command, args []string) error {
+	provider := strings.ToLower(args[0])
+	profileName := args[1]
+	ttl, _ := cmd.Flags().GetDuration("ttl")
+	note, _ := cmd.Flags().GetString("note")
+	holder, _ := cmd.Flags().GetString("holder")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	if _, ok := tools[provider]; !ok {
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s", provider)
+	}
+	if ttl <= 0 {
+		return caamerr.Errorf(caamerr.InvalidArgs, "--ttl must be positive")
+	}
+	if holder = strings.TrimSpace(holder); holder == "" {
+		holder = leaseHolder()
+	}
+	if vault != nil {
+		if profiles, err := vault.List(provider); err == nil && !slices.Contains(profiles, profileName) {
+			return caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s not found", provider, profileName)
+		}
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	now := time.Now().UTC()
+	if l := blockingLease(db, provider, profileName, holder, now); l != nil {
+		return leasedError(l)
+	}
+	lease, err := db.AcquireLease(provider, profileName, holder, ttl, note, now)
+	if err != nil {
+		if errors.Is(err, caamdb.ErrLeaseHeld) {
+			return caamerr.Wrap(caamerr.ProfileLeased, err)
+		}
+		return caamerr.Errorf(caamerr.DBError, "acquire lease: %w", err)
+	}
+	publishLeases(db)
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(profileLease{
+			Provider: lease.Provider, Profile: lease.ProfileName, Holder: lease.Holder,
+			ExpiresAt: lease.ExpiresAt, Note: lease.Note, Source: "local",
+		})
+	}
+	fmt.Fprintf(out, "Leased %s/%s to %s until %s (%s)\n",
+		provider, profileName, lease.Holder, lease.ExpiresAt.Local().Format("15:04 Jan 2"), formatDurationShort(lease.ExpiresAt.Sub(now)))
+	return nil
+}
+
+func runLeaseRelease(cmd *cobra.Command, args []string) error {
+	provider := strings.ToLower(args[0])
+	profileName := args[1]
+	holder, _ := cmd.Flags().GetString("holder")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if holder = strings.TrimSpace(holder); holder == "" {
+		holder = leaseHolder()
+	}
+	if force {
+		holder = ""
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	released, err := db.ReleaseLease(provider, profileName, holder)
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "release lease: %w", err)
+	}
+	if !released {
+		if l, _ := db.ActiveLease(provider, profileName, time.Now()); l != nil {
+			return caamerr.Errorf(caamerr.ProfileLeased, "%s/%s is leased by %s, not %s (use --force to release it anyway)",
+				provider, profileName, l.Holder, holder)
+		}
+		return caamerr.Errorf(caamerr.NotFound, "no lease on %s/%s", provider, profileName)
+	}
+	publishLeases(db)
+	fmt.Fprintf(cmd.OutOrStdout(), "Released %s/%s\n", provider, profileName)
+	return nil
+}
+
+func runLeaseList(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	provider := ""
+	if len(args) > 0 {
+		provider = strings.ToLower(args[0])
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	now := time.Now().UTC()
+	local, err := db.ActiveLeases(provider, now)
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "list leases: %w", err)
+	}
+
+	leases := make([]profileLease, 0, len(local))
+	for _, l := range local {
+		leases = append(leases, profileLease{
+			Provider: l.Provider, Profile: l.ProfileName, Holder: l.Holder,
+			ExpiresAt: l.ExpiresAt, Note: l.Note, Source: "local",
+		})
+	}
+	for _, l := range remoteLeases(now) {
+		if provider == "" || l.Provider == provider {
+			leases = append(leases, l)
+		}
+	}
+	sort.SliceStable(leases, func(i, j int) bool { return leases[i].ExpiresAt.Before(leases[j].ExpiresAt) })
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(leases)
+	}
+	if len(leases) == 0 {
+		fmt.Fprintln(out, "No active leases.")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tHOLDER\tEXPIRES IN\tSOURCE\tNOTE")
+	for _, l := range leases {
+		source := l.Source
+		if l.Machine != "" {
+			source += " (" + l.Machine + ")"
+		}
+		fmt.Fprintf(w, "%s/%s\t%s\t%s\t%s\t%s\n", l.Provider, l.Profile, l.Holder, formatDurationShort(l.ExpiresAt.Sub(now)), source, l.Note)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/namespace"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+func setLeaseFlags(t *testing.T, ttl, holder string, force bool) {
+	t.Helper()
+	for name, value := range map[string]string{"ttl": ttl, "note": "", "holder": holder, "json": "false"} {
+		if err := leaseAcquireCmd.Flags().Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := leaseReleaseCmd.Flags().Set("holder", holder); err != nil {
+		t.Fatal(err)
+	}
+	if err := leaseReleaseCmd.Flags().Set("force", strconv.FormatBool(force)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLeaseAcquireBlocksOthers(t *testing.T) {
+	_, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+	t.Setenv(namespace.Env, "")
+	vaultDir := t.TempDir()
+	vault = authfile.NewVault(vaultDir)
+	for _, p := range []string{"work", "spare"} {
+		dir := filepath.Join(vaultDir, "codex", p)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "auth.json"), []byte(`{}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	leaseAcquireCmd.SetOut(&buf)
+	defer leaseAcquireCmd.SetOut(nil)
+	leaseReleaseCmd.SetOut(&buf)
+	defer leaseReleaseCmd.SetOut(nil)
+	defer setLeaseFlags(t, "2h", "", false)
+
+	t.Setenv(namespace.UserEnv, "alice")
+	setLeaseFlags(t, "1h", "", false)
+	if err := runLeaseAcquire(leaseAcquireCmd, []string{"codex", "work"}); err != nil {
+		t.Fatalf("alice acquire: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Leased codex/work to alice") {
+		t.Errorf("acquire output: %s", buf.String())
+	}
+	if err := runLeaseAcquire(leaseAcquireCmd, []string{"codex", "missing"}); caamerr.CodeOf(err) != caamerr.ProfileNotFound {
+		t.Errorf("acquire missing profile: error = %v", err)
+	}
+
+	t.Setenv(namespace.UserEnv, "bob")
+	if err := runLeaseAcquire(leaseAcquireCmd, []string{"codex", "work"}); caamerr.CodeOf(err) != caamerr.ProfileLeased {
+		t.Fatalf("bob acquire: error = %v, want %s", err, caamerr.ProfileLeased)
+	}
+	if _, err := acquireActivationLease(nil, "codex", "work"); caamerr.CodeOf(err) != caamerr.ProfileLeased {
+		t.Errorf("bob activate: error = %v, want %s", err, caamerr.ProfileLeased)
+	}
+
+	db, err := getDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scored := scoreRobotNextProfiles("codex", []string{"work", "spare"}, "smart", false, db)
+	if len(scored) != 1 || scored[0].name != "spare" {
+		t.Errorf("robot next for bob = %+v, want only spare", scored)
+	}
+
+	if err := runLeaseRelease(leaseReleaseCmd, []string{"codex", "work"}); caamerr.CodeOf(err) != caamerr.ProfileLeased {
+		t.Errorf("bob release: error = %v, want %s", err, caamerr.ProfileLeased)
+	}
+	t.Setenv(namespace.UserEnv, "alice")
+	if err := runLeaseRelease(leaseReleaseCmd, []string{"codex", "work"}); err != nil {
+		t.Fatalf("alice release: %v", err)
+	}
+	t.Setenv(namespace.UserEnv, "bob")
+	if err := runLeaseAcquire(leaseAcquireCmd, []string{"codex", "work"}); err != nil {
+		t.Fatalf("bob acquire after release: %v", err)
+	}
+}
+
+func TestLeaseFromPeerBlocksProfile(t *testing.T) {
+	_, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+	t.Setenv(namespace.UserEnv, "alice")
+
+	now := time.Now().UTC()
+	set := syncstate.LeaseSet{
+		MachineID: "peer-1",
+		Hostname:  "buildbox",
+		UpdatedAt: now,
+		Leases: []syncstate.LeaseRecord{{
+			Provider: "codex", Profile: "spare", Holder: "ci@buildbox",
+			AcquiredAt: now, ExpiresAt: now.Add(time.Hour),
+		}},
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(syncstate.SyncDataDir(), "leases")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "peer-1.json"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	l := blockingLease(nil, "codex", "spare", leaseHolder(), now)
+	if l == nil || l.Source != "sync" || l.Machine != "buildbox" {
+		t.Fatalf("blockingLease() = %+v, want ci@buildbox via sync", l)
+	}
+	if l := blockingLease(nil, "codex", "spare", "ci@buildbox", now); l != nil {
+		t.Errorf("holder's own lease blocks it: %+v", l)
+	}
+	if err := leasedError(l); err == nil || !strings.Contains(err.Error(), "on buildbox") {
+		t.Errorf("leasedError() = %v", err)
+	}
+}
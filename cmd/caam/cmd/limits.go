@@ -13,6 +13,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 )
@@ -143,6 +144,12 @@ func getProfileToken(vaultDir, provider, profileName string) (string, error) {
 		authPath := filepath.Join(profileDir, "auth.json")
 		token, _, err := usage.ReadCodexCredentials(authPath)
 		return token, err
+	case "gemini":
+		token, err := usage.ReadGeminiCredentials(filepath.Join(profileDir, "oauth_creds.json"))
+		if err != nil {
+			token, err = usage.ReadGeminiCredentials(filepath.Join(profileDir, "oauth_credentials.json"))
+		}
+		return token, err
 	default:
 		return "", fmt.Errorf("unsupported provider: %s", provider)
 	}
@@ -228,7 +235,7 @@ func renderLimits(w io.Writer, format string, results []usage.ProfileUsage) erro
 		return nil
 
 	default:
-		return fmt.Errorf("unsupported format: %s", format)
+		return caamerr.Errorf(caamerr.InvalidArgs, "unsupported format: %s", format)
 	}
 }
 
@@ -298,7 +305,7 @@ func renderBestProfile(w io.Writer, format string, results []usage.ProfileUsage,
 		return nil
 
 	default:
-		return fmt.Errorf("unsupported format: %s", format)
+		return caamerr.Errorf(caamerr.InvalidArgs, "unsupported format: %s", format)
 	}
 }
 
@@ -395,7 +402,7 @@ func renderRecommendations(w io.Writer, format string, results []usage.ProfileUs
 		return nil
 
 	default:
-		return fmt.Errorf("unsupported format: %s", format)
+		return caamerr.Errorf(caamerr.InvalidArgs, "unsupported format: %s", format)
 	}
 }
 
@@ -525,7 +532,7 @@ func renderForecast(w io.Writer, format string, results []usage.ProfileUsage) er
 		return nil
 
 	default:
-		return fmt.Errorf("unsupported format: %s", format)
+		return caamerr.Errorf(caamerr.InvalidArgs, "unsupported format: %s", format)
 	}
 }
 
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/applog"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Read caam's own command logs",
+	Long: `Every caam command writes JSON log lines to logs/<command>.log in the data
+directory (~/.caam/data/logs, or $CAAM_HOME/data/logs), rotating files once
+they reach logging.max_size_mb. Each line carries the command, a run_id shared
+by everything one invocation does (including a coordinator it starts and caam
+commands run by its children), and fields such as provider and profile.
+
+'caam logs tail' prints recent records from all commands merged by time and,
+with -f, follows new ones. --filter matches any field exactly.
+
+Levels are set in config.yaml, per command if needed:
+
+  logging:
+    level: info
+    commands:
+      run: debug
+
+CAAM_LOG_LEVEL overrides the level for one invocation, and CAAM_DEBUG also
+mirrors every record to stderr. For the daemon's own log file use
+'caam daemon logs'.
+
+Examples:
+  caam logs tail
+  caam logs tail -f --filter provider=claude
+  caam logs tail --command run --level warn -n 100
+  caam logs tail --filter run_id=1a2b3c4d --json`,
+}
+
+var logsTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Print recent log records and optionally follow new ones",
+	Args:  cobra.NoArgs,
+	RunE:  runLogsTail,
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.AddCommand(logsTailCmd)
+
+	logsTailCmd.Flags().IntP("lines", "n", 20, "number of recent records to print first")
+	logsTailCmd.Flags().BoolP("follow", "f", false, "keep printing new records as they are written")
+	logsTailCmd.Flags().StringArray("filter", nil, "only records whose field equals a value, as key=value (repeatable)")
+	logsTailCmd.Flags().StringSlice("command", nil, "only these commands' logs (repeatable)")
+	logsTailCmd.Flags().String("level", "debug", "minimum level: debug, info, warn, or error")
+	logsTailCmd.Flags().Bool("json", false, "print records as written, one JSON object per line")
+}
+
+func runLogsTail(cmd *cobra.Command, args []string) error {
+	lines, _ := cmd.Flags().GetInt("lines")
+	follow, _ := cmd.Flags().GetBool("follow")
+	filters, _ := cmd.Flags().GetStringArray("filter")
+	commands, _ := cmd.Flags().GetStringSlice("command")
+	levelName, _ := cmd.Flags().GetString("level")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	level, err := applog.ParseLevel(levelName)
+	if err != nil {
+		return caamerr.Wrap(caamerr.Usage, err)
+	}
+	filter, err := applog.ParseFilter(filters, level)
+	if err != nil {
+		return caamerr.Wrap(caamerr.Usage, err)
+	}
+
+	dir := applog.Dir()
+	out := cmd.OutOrStdout()
+	if lines > 0 {
+		records, err := applog.Tail(dir, commands, lines, filter)
+		if err != nil {
+			return fmt.Errorf("read logs: %w", err)
+		}
+		for _, r := range records {
+			if err := printLogRecord(out, r, jsonOut); err != nil {
+				return nil
+			}
+		}
+	}
+	if !follow {
+		return nil
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	return applog.Follow(ctx, dir, commands, filter, 250*time.Millisecond, func(r applog.Record) {
+		if err := printLogRecord(out, r, jsonOut); err != nil {
+			cancel() // Exit gracefully if stdout is closed
+		}
+	})
+}
+
+// logRecordFixed are the fields printLogRecord shows before the others.
+var logRecordFixed = map[string]bool{"time": true, "level": true, "msg": true, "command": true, "run_id": true}
+
+// printLogRecord writes one record as its JSON line or a human-readable line.
+func printLogRecord(out io.Writer, r applog.Record, jsonOut bool) error {
+	if jsonOut {
+		_, err := fmt.Fprintln(out, r.Raw)
+		return err
+	}
+	keys := make([]string, 0, len(r.Attrs))
+	for k := range r.Attrs {
+		if !logRecordFixed[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %-5s  %-8s  %s  %s",
+		r.Time.Local().Format("2006-01-02 15:04:05"), r.Level, r.Command, r.RunID, r.Msg)
+	for _, k := range keys {
+		v, _ := r.Field(k)
+		if strings.ContainsAny(v, " \t\"") {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, " %s=%s", k, v)
+	}
+	_, err := fmt.Fprintln(out, b.String())
+	return err
+}
+
+// commandLogging is set by Execute, so only the caam binary writes command
+// logs, not tests that drive rootCmd directly.
+var commandLogging bool
+
+// commandLog is this invocation's log, opened by startCommandLog.
+var commandLog struct {
+	base   *slog.Logger // tagged with command only
+	closer io.Closer
+	runID  string
+	start  time.Time
+	target []any // provider and profile, repeated on the finish record
+}
+
+// startCommandLog opens the log for the command about to run and makes it
+// the default slog logger. Failing to open it only costs the log.
+func startCommandLog(cmd *cobra.Command, args []string, spmCfg *config.SPMConfig) {
+	if !commandLogging || commandLog.base != nil {
+		return
+	}
+	commandLog.start = time.Now()
+
+	// Share the run_id with the processes this command starts.
+	commandLog.runID = os.Getenv(applog.RunIDEnvVar)
+	if commandLog.runID == "" {
+		commandLog.runID = applog.NewRunID()
+		_ = os.Setenv(applog.RunIDEnvVar, commandLog.runID)
+	}
+
+	logCfg := config.DefaultLoggingConfig()
+	if spmCfg != nil {
+		logCfg = spmCfg.Logging
+	}
+	if !logCfg.Enabled {
+		return
+	}
+
+	path := strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+	name := cmd.Root().Name()
+	if fields := strings.Fields(path); len(fields) > 0 {
+		name = fields[0]
+	}
+	if name == logsCmd.Name() {
+		return // Reading the logs should not add to them.
+	}
+	opts := applog.Options{
+		Command:   name,
+		Level:     logCfg.LevelFor(path),
+		MaxSizeMB: logCfg.MaxSizeMB,
+		MaxFiles:  logCfg.MaxFiles,
+	}
+	if os.Getenv("CAAM_DEBUG") != "" {
+		opts.Mirror = os.Stderr
+	}
+	logger, closer, err := applog.Open(applog.Dir(), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: command log disabled: %v\n", err)
+		return
+	}
+	commandLog.base, commandLog.closer = logger, closer
+
+	// Packages log through slog.Default; the standard log package keeps
+	// printing to stderr as before.
+	slog.SetDefault(logger.With("run_id", commandLog.runID))
+	log.SetOutput(os.Stderr)
+	log.SetFlags(log.LstdFlags)
+
+	commandLog.target = commandLogTarget(cmd, args)
+	slog.Info("command started", append([]any{"path", path}, commandLog.target...)...)
+}
+
+// commandLogTarget guesses the provider and profile a command acts on from
+// its --provider flag or its first two arguments. Other arguments are not
+// logged, since they may hold secrets.
+func commandLogTarget(cmd *cobra.Command, args []string) []any {
+	var attrs []any
+	provider := ""
+	if f := cmd.Flags().Lookup("provider"); f != nil && f.Value.Type() == "string" {
+		provider = strings.ToLower(f.Value.String())
+	}
+	if provider == "" && len(args) > 0 {
+		if _, ok := tools[strings.ToLower(args[0])]; ok {
+			provider = strings.ToLower(args[0])
+			if len(args) > 1 {
+				attrs = append(attrs, "profile", args[1])
+			}
+		}
+	}
+	if provider != "" {
+		attrs = append([]any{"provider", provider}, attrs...)
+	}
+	return attrs
+}
+
+// finishCommandLog records how the command ended and closes its log.
+func finishCommandLog(err error) {
+	if commandLog.base == nil {
+		return
+	}
+	logger := commandLog.base.With("run_id", commandLog.runID).With(commandLog.target...)
+	durationMs := time.Since(commandLog.start).Milliseconds()
+	if err != nil {
+		logger.Error("command failed", "duration_ms", durationMs, "exit_code", ExitCode(err), "error", err.Error())
+	} else {
+		logger.Info("command finished", "duration_ms", durationMs, "exit_code", 0)
+	}
+	commandLog.closer.Close()
+	commandLog.base, commandLog.closer = nil, nil
+}
+
+// teeCommandLog returns a logger writing to h and to this invocation's
+// log, if one is open. Records are not tagged with run_id; see
+// commandRunID.
+func teeCommandLog(h slog.Handler) *slog.Logger {
+	if commandLog.base == nil {
+		return slog.New(h)
+	}
+	return slog.New(applog.Fanout(h, commandLog.base.Handler()))
+}
+
+// commandRunID returns this invocation's run_id, or "" outside the caam
+// binary.
+func commandRunID() string {
+	return commandLog.runID
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/applog"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+)
+
+func TestCommandLogAndTail(t *testing.T) {
+	t.Setenv("CAAM_HOME", filepath.Join(t.TempDir(), "caam_home"))
+	t.Setenv(applog.RunIDEnvVar, "testrun1")
+	t.Setenv("CAAM_DEBUG", "")
+	prevDefault := slog.Default()
+	commandLogging = true
+	t.Cleanup(func() {
+		commandLogging = false
+		slog.SetDefault(prevDefault)
+	})
+
+	spmCfg := config.DefaultSPMConfig()
+	startCommandLog(activateCmd, []string{"claude", "work"}, spmCfg)
+	slog.Debug("below the default level")
+	slog.Info("switching", "provider", "claude")
+	finishCommandLog(caamerr.Errorf(caamerr.ProfileNotFound, "profile not found"))
+
+	// A second command in the same run, with a different provider.
+	startCommandLog(activateCmd, []string{"codex", "home"}, spmCfg)
+	finishCommandLog(nil)
+
+	records, err := applog.Tail(applog.Dir(), []string{"activate"}, 0, applog.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []string
+	for _, r := range records {
+		if r.RunID != "testrun1" || r.Command != "activate" {
+			t.Errorf("record %q: run_id=%q command=%q", r.Msg, r.RunID, r.Command)
+		}
+		msgs = append(msgs, r.Msg)
+	}
+	if got := strings.Join(msgs, ","); got != "command started,switching,command failed,command started,command finished" {
+		t.Fatalf("messages = %s", got)
+	}
+	if profile, _ := records[0].Field("profile"); profile != "work" {
+		t.Errorf("started profile = %q, want work", profile)
+	}
+	if code, _ := records[2].Field("exit_code"); code == "0" {
+		t.Errorf("failed command exit_code = %s, want non-zero", code)
+	}
+
+	var out strings.Builder
+	logsTailCmd.SetOut(&out)
+	t.Cleanup(func() {
+		logsTailCmd.SetOut(nil)
+		logsTailCmd.Flags().Set("json", "false")
+		_ = logsTailCmd.Flags().Lookup("filter").Value.(pflag.SliceValue).Replace(nil)
+	})
+	if err := logsTailCmd.Flags().Set("filter", "provider=claude"); err != nil {
+		t.Fatal(err)
+	}
+	if err := logsTailCmd.Flags().Set("json", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runLogsTail(logsTailCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("claude records = %d, want 3:\n%s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[2], `"msg":"command failed"`) {
+		t.Errorf("last claude record = %s, want the failure", lines[2])
+	}
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first["msg"] != "command started" {
+		t.Errorf("first line = %s (%v)", lines[0], err)
+	}
+
+	if err := logsTailCmd.Flags().Set("level", "loud"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logsTailCmd.Flags().Set("level", "debug") })
+	if err := runLogsTail(logsTailCmd, nil); caamerr.CodeOf(err) != caamerr.Usage {
+		t.Errorf("bad --level error = %v, want a usage error", err)
+	}
+}
+
+func TestCommandLogDisabledOutsideBinary(t *testing.T) {
+	t.Setenv("CAAM_HOME", filepath.Join(t.TempDir(), "caam_home"))
+	startCommandLog(activateCmd, []string{"claude"}, config.DefaultSPMConfig())
+	finishCommandLog(nil)
+	if commands, _ := applog.Commands(applog.Dir()); len(commands) != 0 {
+		t.Errorf("logs written without Execute: %v", commands)
+	}
+}
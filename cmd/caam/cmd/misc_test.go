@@ -2,15 +2,22 @@
 package cmd
 
 import (
+	"archive/zip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultfs"
 )
 
 // TestDoctorCommand tests the doctor command structure.
@@ -123,6 +130,60 @@ func TestDoctorReportOverallOK(t *testing.T) {
 	}
 }
 
+// TestBuildFixPlan tests ordering and merging of doctor remedies.
+func TestBuildFixPlan(t *testing.T) {
+	report := &DoctorReport{
+		AuthFiles: []CheckResult{
+			{Name: "codex", Status: "warn", Remedy: &Remedy{Description: "log in", Risk: FixRiskMedium, Interactive: true}},
+		},
+		Directories: []CheckResult{
+			{Name: "vault directory", Status: "warn", Remedy: autoFixRemedy("Create missing caam directories")},
+			{Name: "profiles directory", Status: "pass"},
+		},
+		Locks: []CheckResult{
+			{Name: "codex/work lock", Status: "warn", Remedy: autoFixRemedy("Remove stale profile lock files")},
+			{Name: "claude/home lock", Status: "fixed", Remedy: autoFixRemedy("Remove stale profile lock files")},
+		},
+		TokenValidation: []CheckResult{
+			{Name: "claude/work", Status: "fail", Remedy: &Remedy{Command: "caam login claude work", Risk: FixRiskMedium, Interactive: true}},
+		},
+	}
+
+	plan := buildFixPlan(report)
+	if len(plan) != 3 {
+		t.Fatalf("buildFixPlan() = %+v, want 3 steps", plan)
+	}
+
+	// Directory and lock fixes share `caam doctor --fix` and come first.
+	if plan[0].Command != "caam doctor --fix" || !plan[0].AutoFix || plan[0].Risk != FixRiskLow {
+		t.Errorf("step 1 = %+v, want auto-fix", plan[0])
+	}
+	if want := []string{"directories: vault directory", "locks: codex/work lock"}; strings.Join(plan[0].Checks, "|") != strings.Join(want, "|") {
+		t.Errorf("step 1 checks = %v, want %v", plan[0].Checks, want)
+	}
+	if plan[1].Checks[0] != "auth_files: codex" || plan[1].Command != "" {
+		t.Errorf("step 2 = %+v, want manual auth step", plan[1])
+	}
+	if plan[2].Command != "caam login claude work" || !plan[2].Interactive {
+		t.Errorf("step 3 = %+v, want interactive login", plan[2])
+	}
+	for i, step := range plan {
+		if step.Step != i+1 {
+			t.Errorf("plan[%d].Step = %d, want %d", i, step.Step, i+1)
+		}
+	}
+
+	data, err := json.Marshal(plan[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{`"step":1`, `"command":"caam doctor --fix"`, `"risk":"low"`, `"auto_fix":true`, `"checks":`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("fix step JSON %s missing %s", data, key)
+		}
+	}
+}
+
 // TestSessionsCommand tests the sessions command structure.
 func TestSessionsCommand(t *testing.T) {
 	if sessionsCmd.Use != "sessions" {
@@ -204,11 +265,13 @@ func TestFormatDuration(t *testing.T) {
 func TestCheckCLITools(t *testing.T) {
 	results := checkCLITools()
 
-	// Should check all three tools
+	// Should check all built-in tools
 	expectedTools := map[string]bool{
 		"codex":  false,
 		"claude": false,
 		"gemini": false,
+		"cursor":  false,
+		"copilot": false,
 	}
 
 	for _, result := range results {
@@ -295,6 +358,42 @@ func TestCheckDirectoriesWithFix(t *testing.T) {
 	}
 }
 
+// TestCheckVaultFilesystem tests synced-folder detection and stale lock cleanup.
+func TestCheckVaultFilesystem(t *testing.T) {
+	root := t.TempDir()
+	vaultPath := filepath.Join(root, "caam", "vault")
+	if err := os.MkdirAll(vaultPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := checkVaultFilesystem(vaultPath, false); result.Status != "pass" {
+		t.Errorf("local vault status = %q (%s), want pass", result.Status, result.Message)
+	}
+
+	if err := os.Mkdir(filepath.Join(root, ".stfolder"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	result := checkVaultFilesystem(vaultPath, false)
+	if result.Status != "warn" || !strings.Contains(result.Details, "Syncthing") {
+		t.Errorf("synced vault result = %+v, want Syncthing warning", result)
+	}
+
+	lockPath := filepath.Join(vaultPath, vaultfs.LockFileName)
+	if err := os.WriteFile(lockPath, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * vaultfs.StaleLockAge)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if result := checkVaultFilesystem(vaultPath, true); result.Status != "fixed" {
+		t.Errorf("stale lock result = %+v, want fixed", result)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("stale lock not removed: %v", err)
+	}
+}
+
 // TestCheckConfig tests config checking function.
 func TestCheckConfig(t *testing.T) {
 	results := checkConfig()
@@ -393,15 +492,191 @@ func TestCheckBrokenSymlinks(t *testing.T) {
 	}
 }
 
+// TestRepairBrokenSymlinks tests that --fix re-creates passthrough links and
+// leaves links caam did not create alone.
+func TestRepairBrokenSymlinks(t *testing.T) {
+	realHome := t.TempDir()
+	t.Setenv("HOME", realHome)
+	profileHome := t.TempDir()
+
+	// .ssh exists in the real HOME but the link points at an old location.
+	if err := os.Mkdir(filepath.Join(realHome, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(realHome, "moved", ".ssh"), filepath.Join(profileHome, ".ssh")); err != nil {
+		t.Fatal(err)
+	}
+	// .aws no longer exists anywhere.
+	if err := os.Symlink(filepath.Join(realHome, ".aws"), filepath.Join(profileHome, ".aws")); err != nil {
+		t.Fatal(err)
+	}
+	// Not a passthrough.
+	if err := os.Symlink(filepath.Join(realHome, "gone"), filepath.Join(profileHome, "custom")); err != nil {
+		t.Fatal(err)
+	}
+
+	broken := checkBrokenSymlinks(profileHome)
+	if len(broken) != 3 {
+		t.Fatalf("broken = %v, want 3 links", broken)
+	}
+	repaired, remaining := repairBrokenSymlinks(profileHome, broken)
+	if len(repaired) != 2 || len(remaining) != 1 || remaining[0] != "custom" {
+		t.Errorf("repaired = %v, remaining = %v", repaired, remaining)
+	}
+
+	if target, err := os.Readlink(filepath.Join(profileHome, ".ssh")); err != nil || target != filepath.Join(realHome, ".ssh") {
+		t.Errorf(".ssh link = %q, %v; want link to real HOME", target, err)
+	}
+	if _, err := os.Lstat(filepath.Join(profileHome, ".aws")); !os.IsNotExist(err) {
+		t.Errorf(".aws link should be removed, got %v", err)
+	}
+	if remaining := checkBrokenSymlinks(profileHome); len(remaining) != 1 {
+		t.Errorf("broken after repair = %v, want only custom", remaining)
+	}
+}
+
+// TestCheckVaultPermissions tests detection and --fix of open vault modes.
+func TestCheckVaultPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not checked on Windows")
+	}
+	vaultPath := filepath.Join(t.TempDir(), "vault")
+	profileDir := filepath.Join(vaultPath, "claude", "work")
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	authPath := filepath.Join(profileDir, ".claude.json")
+	if err := os.WriteFile(authPath, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := checkVaultPermissions(vaultPath, false); result.Status != "pass" {
+		t.Fatalf("owner-only vault = %+v, want pass", result)
+	}
+
+	if err := os.Chmod(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(authPath, 0644); err != nil {
+		t.Fatal(err)
+	}
+	result := checkVaultPermissions(vaultPath, false)
+	if result.Status != "warn" || result.Remedy == nil || !result.Remedy.AutoFix {
+		t.Fatalf("open vault = %+v, want warn with auto fix", result)
+	}
+
+	if result := checkVaultPermissions(vaultPath, true); result.Status != "fixed" {
+		t.Fatalf("fix = %+v, want fixed", result)
+	}
+	if info, _ := os.Stat(profileDir); info.Mode().Perm() != 0700 {
+		t.Errorf("profile dir mode = %04o, want 0700", info.Mode().Perm())
+	}
+	if info, _ := os.Stat(authPath); info.Mode().Perm() != 0600 {
+		t.Errorf("auth file mode = %04o, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	offset := 0 * time.Second
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(offset).UTC().Format(http.TimeFormat))
+	}))
+	defer srv.Close()
+
+	if result := checkClockSkew(srv.URL); result.Status != "pass" || result.Message != "in sync" {
+		t.Errorf("in-sync clock = %+v, want pass", result)
+	}
+
+	offset = -10 * time.Minute
+	result := checkClockSkew(srv.URL)
+	if result.Status != "fail" || !strings.Contains(result.Message, "ahead") || result.Remedy == nil {
+		t.Errorf("clock 10m ahead = %+v, want fail with remedy", result)
+	}
+
+	offset = 2 * time.Minute
+	if result := checkClockSkew(srv.URL); result.Status != "warn" || !strings.Contains(result.Message, "behind") {
+		t.Errorf("clock 2m behind = %+v, want warn", result)
+	}
+
+	srv.Close()
+	if result := checkClockSkew(srv.URL); result.Status != "pass" || result.Details == "" {
+		t.Errorf("unreachable time source = %+v, want pass with details", result)
+	}
+}
+
+func TestCheckDatabase(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "data", "caam.db")
+	backupDir := filepath.Join(tmpDir, "backups")
+
+	if result := checkDatabase(dbPath, backupDir, false); result.Status != "pass" {
+		t.Fatalf("missing db = %+v, want pass", result)
+	}
+
+	d, err := caamdb.OpenAt(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+	if result := checkDatabase(dbPath, backupDir, false); result.Status != "pass" {
+		t.Fatalf("healthy db = %+v, want pass", result)
+	}
+
+	// Back the healthy database up the way the backup scheduler does.
+	good, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	zf, err := os.Create(filepath.Join(backupDir, "caam_export_2026-01-01_0000.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zf)
+	w, err := zw.Create("caam.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(good); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zf.Close()
+
+	if err := os.WriteFile(dbPath, []byte(strings.Repeat("not a database ", 512)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	result := checkDatabase(dbPath, backupDir, false)
+	if result.Status != "fail" || result.Remedy == nil || !result.Remedy.AutoFix {
+		t.Fatalf("damaged db = %+v, want fail with auto fix", result)
+	}
+
+	result = checkDatabase(dbPath, backupDir, true)
+	if result.Status != "fixed" || !strings.Contains(result.Message, "caam_export_2026-01-01_0000.zip") {
+		t.Fatalf("fix = %+v, want restored from backup", result)
+	}
+	if result := checkDatabase(dbPath, backupDir, false); result.Status != "pass" {
+		t.Errorf("restored db = %+v, want pass", result)
+	}
+	if kept, _ := filepath.Glob(dbPath + ".corrupt.*"); len(kept) != 1 {
+		t.Errorf("damaged db copies = %v, want 1", kept)
+	}
+}
+
 // TestCheckAuthFiles tests auth file checking function.
 func TestCheckAuthFiles(t *testing.T) {
 	results := checkAuthFiles()
 
-	// Should check all three tools
+	// Should check all built-in tools
 	expectedTools := map[string]bool{
 		"codex":  false,
 		"claude": false,
 		"gemini": false,
+		"cursor": false,
 	}
 
 	for _, result := range results {
@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/namespace"
+)
+
+var namespaceCmd = &cobra.Command{
+	Use:     "namespace",
+	Aliases: []string{"ns"},
+	Short:   "Keep separate vaults for the people sharing a machine",
+	Long: `Namespaces give each person on a shared machine their own vault, profiles,
+database, and config, plus a shared pool everyone can use.
+
+Pick a namespace with --namespace or CAAM_NAMESPACE. Each one lives in
+<caam home>/namespaces/<name> and is created the first time it is used.
+Without either, caam uses the default (un-namespaced) home as before.
+
+The namespace named "shared" holds the common pool. Its policy decides who
+may change it:
+  open       anyone may add, remove, and log in to profiles (default)
+  read_only  only the listed writers may change profiles; anyone may use them
+  lease      like read_only, and activating a profile takes a lease on it so
+             two people are not on the same account at once
+
+The caller is CAAM_USER if set, otherwise the OS user name. For several OS
+users to share a pool, point CAAM_HOME (or CAAM_BASE_HOME) at a directory
+their common group can write.
+
+Examples:
+  caam --namespace alice activate claude work
+  export CAAM_NAMESPACE=alice
+  caam namespace list
+  caam namespace policy --mode lease --writers alice,bob --lease-ttl 2h
+  caam --namespace shared activate claude pool1`,
+}
+
+var namespaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List namespaces",
+	Args:  cobra.NoArgs,
+	RunE:  runNamespaceList,
+}
+
+var namespaceCurrentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Show the namespace in effect and its home",
+	Args:  cobra.NoArgs,
+	RunE:  runNamespaceCurrent,
+}
+
+var namespacePolicyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Show or change the shared namespace's access policy",
+	Args:  cobra.NoArgs,
+	RunE:  runNamespacePolicy,
+}
+
+func init() {
+	rootCmd.AddCommand(namespaceCmd)
+	namespaceCmd.AddCommand(namespaceListCmd)
+	namespaceCmd.AddCommand(namespaceCurrentCmd)
+	namespaceCmd.AddCommand(namespacePolicyCmd)
+
+	rootCmd.PersistentFlags().String("namespace", "", "vault namespace to use (env: CAAM_NAMESPACE)")
+
+	namespaceListCmd.Flags().Bool("json", false, "output as JSON")
+	namespaceCurrentCmd.Flags().Bool("json", false, "output as JSON")
+
+	namespacePolicyCmd.Flags().String("mode", "", "access mode: open, read_only, or lease")
+	namespacePolicyCmd.Flags().StringSlice("writers", nil, "users allowed to change shared profiles (comma-separated)")
+	namespacePolicyCmd.Flags().String("lease-ttl", "", "how long an activation lease lasts in lease mode (e.g. 2h)")
+	namespacePolicyCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// sharedWriteCommands change the profiles in a namespace. In the shared
+// namespace they are limited to the policy's writers.
+var sharedWriteCommands = map[string]bool{
+	"caam add":                    true,
+	"caam alias":                  true,
+	"caam auth import":            true,
+	"caam backup":                 true,
+	"caam bundle import":          true,
+	"caam config reset":           true,
+	"caam config set":             true,
+	"caam delete":                 true,
+	"caam gcp set":                true,
+	"caam import":                 true,
+	"caam login":                  true,
+	"caam profile add":            true,
+	"caam profile clone":          true,
+	"caam profile delete":         true,
+	"caam rename":                 true,
+	"caam tag add":                true,
+	"caam vault decrypt":          true,
+	"caam vault encrypt":          true,
+	"caam vault enroll-key":       true,
+	"caam vault prune":            true,
+	"caam vault snapshot restore": true,
+}
+
+// enterNamespace switches this process into the namespace named by
+// --namespace or CAAM_NAMESPACE, if any, and refuses profile changes the
+// shared namespace's policy does not allow the caller.
+func enterNamespace(cmd *cobra.Command) error {
+	name, _ := cmd.Flags().GetString("namespace")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = strings.TrimSpace(namespace.Current())
+	}
+	if name == "" {
+		return nil
+	}
+	if _, err := namespace.Enter(name); err != nil {
+		if errors.Is(err, namespace.ErrInvalidName) {
+			return caamerr.Wrap(caamerr.InvalidArgs, err)
+		}
+		return caamerr.Wrap(caamerr.PermissionDenied, err)
+	}
+
+	if name != namespace.Shared || !sharedWriteCommands[cmd.CommandPath()] {
+		return nil
+	}
+	policy, err := namespace.LoadPolicy(namespace.BaseHome())
+	if err != nil {
+		return caamerr.Wrap(caamerr.ConfigError, err)
+	}
+	if caller := namespace.Caller(); !policy.CanWrite(caller) {
+		return caamerr.Errorf(caamerr.NamespaceReadOnly,
+			"%s may not run '%s' in the shared namespace (%s mode; writers: %s)",
+			caller, cmd.CommandPath(), policy.Mode, strings.Join(policy.Writers, ", "))
+	}
+	return nil
+}
+
+// acquireActivationLease refuses a profile someone else has leased, here
+// or on another machine, and takes the caller's lease on it when the shared
+// namespace is in lease mode. It returns the lease taken, or nil.
+func acquireActivationLease(db *caamdb.DB, provider, profile string) (*caamdb.Lease, error) {
+	if db == nil {
+		if opened, err := getDB(); err == nil {
+			db = opened
+		}
+	}
+	holder := leaseHolder()
+	now := time.Now().UTC()
+	if l := blockingLease(db, provider, profile, holder, now); l != nil {
+		return nil, leasedError(l)
+	}
+
+	if namespace.Current() != namespace.Shared {
+		return nil, nil
+	}
+	policy, err := namespace.LoadPolicy(namespace.BaseHome())
+	if err != nil {
+		return nil, caamerr.Wrap(caamerr.ConfigError, err)
+	}
+	if !policy.NeedsLease() {
+		return nil, nil
+	}
+	if db == nil {
+		return nil, caamerr.Errorf(caamerr.DBError, "database unavailable; cannot lease %s/%s", provider, profile)
+	}
+	lease, err := db.AcquireLease(provider, profile, holder, policy.TTL(), "activate", now)
+	if err != nil {
+		if errors.Is(err, caamdb.ErrLeaseHeld) {
+			return nil, caamerr.Wrap(caamerr.ProfileLeased, err)
+		}
+		return nil, caamerr.Errorf(caamerr.DBError, "acquire lease: %w", err)
+	}
+	publishLeases(db)
+	return lease, nil
+}
+
+// releaseActivationLease drops the caller's lease on a profile they have
+// switched away from. Failures only leave the lease to expire.
+func releaseActivationLease(provider, profile string) {
+	db, err := getDB()
+	if err != nil {
+		return
+	}
+	if released, _ := db.ReleaseLease(provider, profile, leaseHolder()); released {
+		publishLeases(db)
+	}
+}
+
+// releaseNewLease drops a lease acquireActivationLease took at or after
+// since for an activation that then failed. A lease the caller already
+// held keeps its earlier acquisition time through the renewal and is left
+// in place.
+func releaseNewLease(lease *caamdb.Lease, since time.Time) {
+	if lease != nil && !lease.AcquiredAt.Before(since) {
+		releaseActivationLease(lease.Provider, lease.ProfileName)
+	}
+}
+
+type namespaceInfo struct {
+	Name    string `json:"name"`
+	Home    string `json:"home"`
+	Current bool   `json:"current"`
+}
+
+func runNamespaceList(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	base := namespace.BaseHome()
+	names, err := namespace.List(base)
+	if err != nil {
+		return caamerr.Wrap(caamerr.ConfigError, err)
+	}
+
+	current := namespace.Current()
+	infos := make([]namespaceInfo, 0, len(names))
+	for _, name := range names {
+		infos = append(infos, namespaceInfo{Name: name, Home: namespace.Dir(base, name), Current: name == current})
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	}
+	if len(infos) == 0 {
+		fmt.Fprintln(out, "No namespaces yet. Use --namespace <name> or CAAM_NAMESPACE to create one.")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAMESPACE\tHOME")
+	for _, info := range infos {
+		marker := " "
+		if info.Current {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\n", marker, info.Name, info.Home)
+	}
+	return w.Flush()
+}
+
+func runNamespaceCurrent(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	base := namespace.BaseHome()
+	info := namespaceInfo{Name: namespace.Current(), Home: base, Current: true}
+	if info.Name != "" {
+		info.Home = namespace.Dir(base, info.Name)
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	name := info.Name
+	if name == "" {
+		name = "(default)"
+	}
+	fmt.Fprintf(out, "%s\t%s\n", name, info.Home)
+	return nil
+}
+
+func runNamespacePolicy(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	base := namespace.BaseHome()
+	policy, err := namespace.LoadPolicy(base)
+	if err != nil {
+		return caamerr.Wrap(caamerr.ConfigError, err)
+	}
+
+	changed := cmd.Flags().Changed("mode") || cmd.Flags().Changed("writers") || cmd.Flags().Changed("lease-ttl")
+	if changed {
+		caller := namespace.Caller()
+		if len(policy.Writers) > 0 && !policy.IsWriter(caller) {
+			return caamerr.Errorf(caamerr.NamespaceReadOnly,
+				"%s may not change the shared namespace policy (writers: %s)", caller, strings.Join(policy.Writers, ", "))
+		}
+		if cmd.Flags().Changed("mode") {
+			policy.Mode, _ = cmd.Flags().GetString("mode")
+		}
+		if cmd.Flags().Changed("writers") {
+			writers, _ := cmd.Flags().GetStringSlice("writers")
+			policy.Writers = nil
+			for _, w := range writers {
+				if w = strings.TrimSpace(w); w != "" {
+					policy.Writers = append(policy.Writers, w)
+				}
+			}
+		}
+		if cmd.Flags().Changed("lease-ttl") {
+			policy.LeaseTTL, _ = cmd.Flags().GetString("lease-ttl")
+		}
+		if err := policy.Validate(); err != nil {
+			return caamerr.Wrap(caamerr.InvalidArgs, err)
+		}
+		policy.UpdatedAt = time.Now().UTC()
+		policy.UpdatedBy = caller
+		if err := namespace.SavePolicy(base, policy); err != nil {
+			return caamerr.Wrap(caamerr.SaveError, err)
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(policy)
+	}
+	if changed {
+		fmt.Fprintln(out, "Updated shared namespace policy.")
+	}
+	writers := strings.Join(policy.Writers, ", ")
+	if writers == "" {
+		writers = "(everyone)"
+	}
+	fmt.Fprintf(out, "Mode:      %s\n", policy.Mode)
+	fmt.Fprintf(out, "Writers:   %s\n", writers)
+	if policy.NeedsLease() {
+		fmt.Fprintf(out, "Lease TTL: %s\n", policy.TTL())
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/namespace"
+)
+
+func TestSharedWriteCommandsExist(t *testing.T) {
+	for path := range sharedWriteCommands {
+		found, _, err := rootCmd.Find(strings.Fields(path)[1:])
+		if err != nil || found.CommandPath() != path {
+			t.Errorf("%q does not name a command", path)
+		}
+	}
+}
+
+// setupNamespaceTestEnv gives the test a fresh caam home with no namespace
+// selected; the env vars namespace.Enter sets are restored afterwards.
+func setupNamespaceTestEnv(t *testing.T) string {
+	t.Helper()
+	base := t.TempDir()
+	t.Setenv("CAAM_HOME", base)
+	t.Setenv(namespace.BaseHomeEnv, "")
+	t.Setenv(namespace.Env, "")
+	t.Setenv(namespace.UserEnv, "")
+	return base
+}
+
+func TestEnterNamespace_SharedReadOnly(t *testing.T) {
+	base := setupNamespaceTestEnv(t)
+	if err := namespace.SavePolicy(base, namespace.Policy{Mode: namespace.ModeReadOnly, Writers: []string{"alice"}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(namespace.Env, namespace.Shared)
+
+	t.Setenv(namespace.UserEnv, "bob")
+	if err := enterNamespace(deleteCmd); caamerr.CodeOf(err) != caamerr.NamespaceReadOnly {
+		t.Fatalf("bob delete: error = %v, want %s", err, caamerr.NamespaceReadOnly)
+	}
+	if err := enterNamespace(lsCmd); err != nil {
+		t.Fatalf("bob ls: error = %v", err)
+	}
+	if got := os.Getenv("CAAM_HOME"); got != filepath.Join(base, "namespaces", "shared") {
+		t.Errorf("CAAM_HOME = %q", got)
+	}
+
+	t.Setenv(namespace.UserEnv, "alice")
+	if err := enterNamespace(deleteCmd); err != nil {
+		t.Fatalf("alice delete: error = %v", err)
+	}
+}
+
+func TestActivationLease(t *testing.T) {
+	base := setupNamespaceTestEnv(t)
+	if lease, err := acquireActivationLease(nil, "claude", "pool1"); err != nil || lease != nil {
+		t.Fatalf("outside the shared namespace: lease = %+v, err = %v", lease, err)
+	}
+
+	if err := namespace.SavePolicy(base, namespace.Policy{Mode: namespace.ModeLease, LeaseTTL: "1h"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := namespace.Enter(namespace.Shared); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(namespace.UserEnv, "alice")
+	lease, err := acquireActivationLease(nil, "claude", "pool1")
+	if err != nil || lease == nil || !strings.HasPrefix(lease.Holder, "alice") {
+		t.Fatalf("alice lease = %+v, err = %v", lease, err)
+	}
+
+	t.Setenv(namespace.UserEnv, "bob")
+	if _, err := acquireActivationLease(nil, "claude", "pool1"); caamerr.CodeOf(err) != caamerr.ProfileLeased {
+		t.Fatalf("bob: error = %v, want %s", err, caamerr.ProfileLeased)
+	}
+
+	t.Setenv(namespace.UserEnv, "alice")
+	releaseActivationLease("claude", "pool1")
+	t.Setenv(namespace.UserEnv, "bob")
+	if lease, err := acquireActivationLease(nil, "claude", "pool1"); err != nil || !strings.HasPrefix(lease.Holder, "bob") {
+		t.Fatalf("bob after release: lease = %+v, err = %v", lease, err)
+	}
+}
+
+func TestActivationLeaseReleasedOnFailure(t *testing.T) {
+	base := setupNamespaceTestEnv(t)
+	t.Setenv("CODEX_HOME", t.TempDir())
+	oldVault := vault
+	vault = authfile.NewVault(t.TempDir())
+	defer func() { vault = oldVault }()
+	if err := namespace.SavePolicy(base, namespace.Policy{Mode: namespace.ModeLease, LeaseTTL: "1h"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := namespace.Enter(namespace.Shared); err != nil {
+		t.Fatal(err)
+	}
+
+	// The profile isn't in the vault, so the restore fails after the lease
+	// is taken.
+	t.Setenv(namespace.UserEnv, "alice")
+	if _, failure := performRobotAct("activate", "codex", []string{"activate", "codex", "ghost"}, true); failure == nil {
+		t.Fatal("activating a missing profile succeeded")
+	}
+
+	t.Setenv(namespace.UserEnv, "bob")
+	if lease, err := acquireActivationLease(nil, "codex", "ghost"); err != nil || lease == nil {
+		t.Fatalf("bob after alice's failed activation: lease = %+v, err = %v", lease, err)
+	}
+}
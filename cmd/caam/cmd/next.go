@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 	"github.com/spf13/cobra"
@@ -16,7 +18,7 @@ import (
 
 // nextCmd rotates to the next available profile for a tool.
 var nextCmd = &cobra.Command{
-	Use:     "next <tool>",
+	Use:     "next [tool]",
 	Aliases: []string{"rotate"},
 	Short:   "Rotate to next available profile",
 	Long: `Instantly rotate to the next best profile for a tool.
@@ -31,8 +33,29 @@ Examples:
   caam next codex       # Switch to next healthy Codex profile
   caam next gemini      # Switch to next healthy Gemini profile
   caam next claude --dry-run   # Show what would be selected
-  caam next claude -q   # Quiet mode, minimal output`,
-	Args: cobra.ExactArgs(1),
+  caam next claude -q   # Quiet mode, minimal output
+
+With --apply-policies, evaluates the rotation policies in config.yaml
+instead and rotates every provider whose policy is due:
+
+  policies:
+    - provider: claude
+      every: 4h
+      algorithm: round_robin
+    - provider: codex
+      when: error_count_1h >= 3
+
+Conditions compare error_count_1h, penalty, token_expires_min, or usage_pct
+with >=, <=, >, <, or ==. The daemon applies policies on every check.
+
+  caam rotate --apply-policies            # Apply due policies
+  caam rotate --apply-policies --dry-run  # Show what each policy would do`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if apply, _ := cmd.Flags().GetBool("apply-policies"); apply {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: runNext,
 }
 
@@ -42,13 +65,18 @@ func init() {
 	nextCmd.Flags().Bool("force", false, "activate even if profile is in cooldown")
 	nextCmd.Flags().String("algorithm", "", "override rotation algorithm (smart, round_robin, random)")
 	nextCmd.Flags().Bool("usage-aware", false, "fetch real-time rate limits to inform selection")
+	nextCmd.Flags().Bool("apply-policies", false, "apply the rotation policies in config.yaml")
 	rootCmd.AddCommand(nextCmd)
 }
 
 func runNext(cmd *cobra.Command, args []string) error {
-	tool := strings.ToLower(args[0])
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	quiet, _ := cmd.Flags().GetBool("quiet")
+	if apply, _ := cmd.Flags().GetBool("apply-policies"); apply {
+		return runApplyPolicies(dryRun, quiet)
+	}
+
+	tool := strings.ToLower(args[0])
 	force, _ := cmd.Flags().GetBool("force")
 	algoOverride, _ := cmd.Flags().GetString("algorithm")
 	usageAware, _ := cmd.Flags().GetBool("usage-aware")
@@ -56,7 +84,7 @@ func runNext(cmd *cobra.Command, args []string) error {
 	// Validate tool
 	getFileSet, ok := tools[tool]
 	if !ok {
-		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 	}
 
 	// Ensure vault is initialized
@@ -70,11 +98,11 @@ func runNext(cmd *cobra.Command, args []string) error {
 	// List available profiles
 	profiles, err := vault.List(tool)
 	if err != nil {
-		return fmt.Errorf("list profiles: %w", err)
+		return caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
 	}
 
 	if len(profiles) == 0 {
-		return fmt.Errorf("no profiles found for %s; create one with 'caam backup %s <name>'", tool, tool)
+		return caamerr.Errorf(caamerr.NoProfiles, "no profiles found for %s; create one with 'caam backup %s <name>'", tool, tool)
 	}
 
 	if len(profiles) == 1 {
@@ -84,8 +112,9 @@ func runNext(cmd *cobra.Command, args []string) error {
 		}
 		// Single profile case: just activate it
 		if !dryRun {
-			if err := vault.Restore(fileSet, profiles[0]); err != nil {
-				return fmt.Errorf("activate failed: %w", err)
+			spmCfg, _ := config.LoadSPMConfig()
+			if err := activateForNext(fileSet, profiles[0], spmCfg, nil, quiet); err != nil {
+				return err
 			}
 		}
 		if !quiet {
@@ -129,17 +158,23 @@ func runNext(cmd *cobra.Command, args []string) error {
 		usageData = fetchUsageDataForProfiles(tool, profiles)
 	}
 
+	// Profiles someone else has leased are not candidates.
+	candidates := unleasedProfiles(db, tool, profiles)
+	if len(candidates) == 0 || (len(candidates) == 1 && candidates[0] == currentProfile) {
+		return caamerr.Errorf(caamerr.ProfileLeased, "every other %s profile is leased; see 'caam lease list'", tool)
+	}
+
 	// Select next profile using rotation
-	selection, err := selectProfileWithRotationAndUsage(tool, profiles, currentProfile, spmCfg, db, usageData)
+	selection, err := selectProfileWithRotationAndUsage(tool, candidates, currentProfile, spmCfg, db, usageData)
 	if err != nil {
 		return err
 	}
 
 	// If rotation selected the same profile (can happen with smart algorithm),
 	// use round_robin to force rotation to a different profile.
-	if selection.Selected == currentProfile && len(profiles) > 1 {
+	if selection.Selected == currentProfile && len(candidates) > 1 {
 		spmCfg.Stealth.Rotation.Algorithm = "round_robin"
-		selection, err = selectProfileWithRotationAndUsage(tool, profiles, currentProfile, spmCfg, db, usageData)
+		selection, err = selectProfileWithRotationAndUsage(tool, candidates, currentProfile, spmCfg, db, usageData)
 		if err != nil {
 			return err
 		}
@@ -178,8 +213,8 @@ func runNext(cmd *cobra.Command, args []string) error {
 	}
 
 	// Activate selected profile
-	if err := vault.Restore(fileSet, selection.Selected); err != nil {
-		return fmt.Errorf("activate failed: %w", err)
+	if err := activateForNext(fileSet, selection.Selected, spmCfg, db, quiet); err != nil {
+		return err
 	}
 
 	// Log event
@@ -256,7 +291,7 @@ func fetchUsageDataForProfiles(tool string, profiles []string) map[string]*rotat
 // selectProfileWithRotationAndUsage selects a profile using rotation with optional usage data.
 func selectProfileWithRotationAndUsage(tool string, profiles []string, currentProfile string, spmCfg *config.SPMConfig, db *caamdb.DB, usageData map[string]*rotation.UsageInfo) (*rotation.Result, error) {
 	if len(profiles) == 0 {
-		return nil, fmt.Errorf("no profiles found for %s; create one with 'caam backup %s <name>'", tool, tool)
+		return nil, caamerr.Errorf(caamerr.NoProfiles, "no profiles found for %s; create one with 'caam backup %s <name>'", tool, tool)
 	}
 
 	primePlanTypes(tool, profiles)
@@ -282,3 +317,26 @@ func selectProfileWithRotationAndUsage(tool string, profiles []string, currentPr
 
 	return result, nil
 }
+
+// activateForNext switches to target for next. A failed backup of the
+// live auth is reported but doesn't stop the switch, as with activate.
+func activateForNext(fileSet authfile.AuthFileSet, target string, spmCfg *config.SPMConfig, db *caamdb.DB, quiet bool) error {
+	act, err := beginActivation(fileSet, target, "next", activationOptions{db: db, spmCfg: spmCfg})
+	if err != nil {
+		return err
+	}
+	if !quiet && act.BackupErr != nil {
+		fmt.Printf("Warning: could not auto-backup current state: %v\n", act.BackupErr)
+	}
+	if !quiet && act.AutoBackup != "" {
+		fmt.Printf("Auto-backed up current state to %s\n", act.AutoBackup)
+	}
+
+	err = vault.Restore(fileSet, target)
+	act.finish(err == nil)
+	if err != nil {
+		return caamerr.Errorf(caamerr.ActivateFailed, "activate failed: %w", err)
+	}
+	events.PublishActivated(fileSet.Tool, target, "next")
+	return nil
+}
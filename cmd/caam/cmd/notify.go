@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
+)
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Manage event notifications",
+	Long: `The daemon sends notifications on key events to the channels configured
+under "notifications" in config.json:
+
+  all_blocked       every profile of a provider is in cooldown or revoked
+  token_expiring    a token expires within expiry_warning (default 2h)
+  cooldown_started  a profile was put into cooldown
+  sync_failed       a sync with another machine failed
+
+Channels are a generic webhook (JSON POST), a Slack incoming webhook, and
+desktop notifications (notify-send or osascript). Each event can be turned
+off under "events", and repeats of the same event for the same profile are
+suppressed for rate_limit (default 1h).
+
+Examples:
+  caam notify status
+  caam notify test`,
+}
+
+var notifyStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show notification channels and events",
+	Args:  cobra.NoArgs,
+	RunE:  runNotifyStatus,
+}
+
+var notifyTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Send a test notification to every configured channel",
+	Args:  cobra.NoArgs,
+	RunE:  runNotifyTest,
+}
+
+func init() {
+	rootCmd.AddCommand(notifyCmd)
+	notifyCmd.AddCommand(notifyStatusCmd)
+	notifyCmd.AddCommand(notifyTestCmd)
+}
+
+// notificationChannels returns the notifiers configured in cfg.
+func notificationChannels(cfg config.NotificationsConfig) []notify.Notifier {
+	var channels []notify.Notifier
+	if cfg.Webhook != "" {
+		wh := notify.NewWebhookNotifier(cfg.Webhook)
+		for k, v := range cfg.WebhookHeaders {
+			wh.Headers[k] = v
+		}
+		channels = append(channels, wh)
+	}
+	if cfg.Slack != "" {
+		channels = append(channels, notify.NewSlackNotifier(cfg.Slack))
+	}
+	if cfg.Desktop {
+		channels = append(channels, notify.NewDesktopNotifier())
+	}
+	return channels
+}
+
+// newNotificationDispatcher returns the daemon's notification dispatcher,
+// or nil if notifications are off or no channel is configured.
+func newNotificationDispatcher(cfg config.NotificationsConfig) *notify.Dispatcher {
+	if !cfg.Enabled {
+		return nil
+	}
+	channels := notificationChannels(cfg)
+	if len(channels) == 0 {
+		return nil
+	}
+	return notify.NewDispatcher(notify.NewMultiNotifier(channels...), cfg.EventEnabled, cfg.GetRateLimit())
+}
+
+func runNotifyStatus(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
+	}
+	n := cfg.Notifications
+	out := cmd.OutOrStdout()
+
+	state := "disabled"
+	if n.Enabled {
+		state = "enabled"
+	}
+	fmt.Fprintf(out, "Notifications: %s\n", state)
+
+	channels := notificationChannels(n)
+	if len(channels) == 0 {
+		fmt.Fprintln(out, "Channels:      none configured")
+	} else {
+		fmt.Fprintln(out, "Channels:")
+		for _, c := range channels {
+			avail := ""
+			if !c.Available() {
+				avail = " (unavailable)"
+			}
+			fmt.Fprintf(out, "  %s%s\n", c.Name(), avail)
+		}
+	}
+
+	fmt.Fprintln(out, "Events:")
+	for _, event := range config.NotifyEvents() {
+		on := "on"
+		if !n.EventEnabled(event) {
+			on = "off"
+		}
+		fmt.Fprintf(out, "  %-17s %s\n", event, on)
+	}
+	fmt.Fprintf(out, "Expiry warning: %v\n", n.GetExpiryWarning())
+	fmt.Fprintf(out, "Rate limit:     %v\n", n.GetRateLimit())
+	return nil
+}
+
+func runNotifyTest(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
+	}
+	channels := notificationChannels(cfg.Notifications)
+	if len(channels) == 0 {
+		return fmt.Errorf("no notification channels configured (set notifications.webhook, notifications.slack, or notifications.desktop in %s)", config.ConfigPath())
+	}
+
+	alert := &notify.Alert{
+		Level:     notify.Info,
+		Title:     "caam test notification",
+		Message:   "Notifications from caam are working.",
+		Timestamp: time.Now(),
+		Event:     "test",
+	}
+
+	var failed []string
+	for _, c := range channels {
+		if err := c.Notify(alert); err != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %s: failed: %v\n", c.Name(), err)
+			failed = append(failed, c.Name())
+			continue
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "  %s: sent\n", c.Name())
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("test notification failed for: %s", strings.Join(failed, ", "))
+	}
+	if !cfg.Notifications.Enabled {
+		fmt.Fprintln(cmd.OutOrStdout(), "Note: notifications.enabled is false; the daemon won't send notifications until it is set.")
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/browser"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 )
 
@@ -38,7 +39,7 @@ Examples:
 		// Validate provider using centralized metadata
 		meta, ok := provider.GetProviderMeta(tool)
 		if !ok {
-			return fmt.Errorf("unknown provider: %s (supported: codex, claude, gemini)", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s (supported: codex, claude, gemini)", tool)
 		}
 
 		// Allow custom URL override
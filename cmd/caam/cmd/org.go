@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+)
+
+// orgProviders maps providers whose accounts can belong to several
+// organizations to a reader for the organization selected in a vault
+// profile.
+var orgProviders = map[string]func(profileDir string) (*identity.Identity, error){
+	"claude": func(profileDir string) (*identity.Identity, error) {
+		return identity.ExtractFromClaudeConfig(filepath.Join(profileDir, ".claude.json"))
+	},
+}
+
+var orgCmd = &cobra.Command{
+	Use:   "org",
+	Short: "Manage the organization selected in a profile",
+	Long: `Claude Team and Enterprise accounts choose an organization at login, and
+that choice is stored alongside the OAuth tokens. caam snapshots each
+organization a profile has been backed up with, so a profile can be switched
+between organizations without logging in again.
+
+A snapshot is taken automatically by 'caam backup'. To add another
+organization to a profile, log in to it, then back up to the same profile.
+
+Examples:
+  caam org show claude work
+  caam org switch claude work "Acme Corp"
+  caam org capture claude work`,
+}
+
+var orgShowCmd = &cobra.Command{
+	Use:     "show <provider> <profile>",
+	Aliases: []string{"list", "ls"},
+	Short:   "Show the selected and saved organizations",
+	Args:    cobra.ExactArgs(2),
+	RunE:    runOrgShow,
+}
+
+var orgCaptureCmd = &cobra.Command{
+	Use:   "capture <provider> <profile>",
+	Short: "Snapshot the profile's current organization",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runOrgCapture,
+}
+
+var orgSwitchCmd = &cobra.Command{
+	Use:   "switch <provider> <profile> <org>",
+	Short: "Switch a profile to a saved organization (by name or ID)",
+	Long: `Switches a profile to an organization it was previously backed up with.
+If the profile is active, the current organization's refreshed tokens are
+saved first and the live auth files are updated.`,
+	Args: cobra.ExactArgs(3),
+	RunE: runOrgSwitch,
+}
+
+func init() {
+	rootCmd.AddCommand(orgCmd)
+	orgCmd.AddCommand(orgShowCmd)
+	orgCmd.AddCommand(orgCaptureCmd)
+	orgCmd.AddCommand(orgSwitchCmd)
+
+	orgShowCmd.Flags().Bool("json", false, "output as JSON")
+	orgSwitchCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// orgShowOutput is the JSON output for org show.
+type orgShowOutput struct {
+	Provider string                 `json:"provider"`
+	Profile  string                 `json:"profile"`
+	Current  *authfile.OrgSnapshot  `json:"current,omitempty"`
+	Saved    []authfile.OrgSnapshot `json:"saved"`
+}
+
+// orgCommandSetup validates the provider and profile shared by org commands.
+func orgCommandSetup(args []string) (string, string, authfile.AuthFileSet, error) {
+	provider := strings.ToLower(args[0])
+	profile := args[1]
+	getFileSet, ok := tools[provider]
+	if !ok {
+		return "", "", authfile.AuthFileSet{}, caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", provider)
+	}
+	if _, ok := orgProviders[provider]; !ok {
+		return "", "", authfile.AuthFileSet{}, fmt.Errorf("%s does not support non-interactive organization selection", provider)
+	}
+	if vault == nil {
+		vault = authfile.NewVault(authfile.DefaultVaultPath())
+	}
+	profiles, err := vault.List(provider)
+	if err != nil {
+		return "", "", authfile.AuthFileSet{}, err
+	}
+	found := false
+	for _, p := range profiles {
+		if p == profile {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", "", authfile.AuthFileSet{}, caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s not found", provider, profile)
+	}
+	return provider, profile, getFileSet(), nil
+}
+
+// currentProfileOrg returns the organization selected in a vault profile, or
+// nil if the provider has none or the profile isn't tied to one.
+func currentProfileOrg(provider, profile string) *authfile.OrgSnapshot {
+	read, ok := orgProviders[provider]
+	if !ok || vault == nil {
+		return nil
+	}
+	id, err := read(vault.ProfilePath(provider, profile))
+	if err != nil || id.OrganizationID == "" {
+		return nil
+	}
+	return &authfile.OrgSnapshot{ID: id.OrganizationID, Name: id.Organization}
+}
+
+// captureProfileOrg snapshots the profile's current organization. It returns
+// nil without error when the profile isn't tied to an organization.
+func captureProfileOrg(provider, profile string) (*authfile.OrgSnapshot, error) {
+	org := currentProfileOrg(provider, profile)
+	if org == nil {
+		return nil, nil
+	}
+	getFileSet, ok := tools[provider]
+	if !ok {
+		return nil, nil
+	}
+	if err := vault.SaveOrgSnapshot(getFileSet(), profile, *org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func runOrgShow(cmd *cobra.Command, args []string) error {
+	provider, profile, _, err := orgCommandSetup(args)
+	if err != nil {
+		return err
+	}
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	saved, err := vault.ListOrgSnapshots(provider, profile)
+	if err != nil {
+		return err
+	}
+	output := orgShowOutput{
+		Provider: provider,
+		Profile:  profile,
+		Current:  currentProfileOrg(provider, profile),
+		Saved:    saved,
+	}
+	if output.Saved == nil {
+		output.Saved = []authfile.OrgSnapshot{}
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(output)
+	}
+
+	if output.Current == nil {
+		fmt.Fprintf(out, "%s/%s is not tied to an organization.\n", provider, profile)
+	} else {
+		fmt.Fprintf(out, "%s/%s organization: %s (%s)\n", provider, profile, orgDisplayName(*output.Current), output.Current.ID)
+	}
+	if len(saved) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "  \tORGANIZATION\tID\tSAVED")
+	for _, org := range saved {
+		marker := " "
+		if output.Current != nil && org.ID == output.Current.ID {
+			marker = "*"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", marker, orgDisplayName(org), org.ID, formatTimeAgo(org.SavedAt))
+	}
+	return tw.Flush()
+}
+
+func runOrgCapture(cmd *cobra.Command, args []string) error {
+	provider, profile, _, err := orgCommandSetup(args)
+	if err != nil {
+		return err
+	}
+	org, err := captureProfileOrg(provider, profile)
+	if err != nil {
+		return err
+	}
+	if org == nil {
+		return fmt.Errorf("%s/%s is not tied to an organization", provider, profile)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Saved organization %s for %s/%s\n", orgDisplayName(*org), provider, profile)
+	return nil
+}
+
+func runOrgSwitch(cmd *cobra.Command, args []string) error {
+	provider, profile, fileSet, err := orgCommandSetup(args)
+	if err != nil {
+		return err
+	}
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	target, err := vault.FindOrgSnapshot(provider, profile, args[2])
+	if err != nil {
+		return fmt.Errorf("%w\nLog in to that organization, then run: caam backup %s %s", err, provider, profile)
+	}
+
+	active, _ := vault.ActiveProfile(fileSet)
+	isActive := active == profile
+	if isActive {
+		// Keep tokens the tool refreshed while this organization was live.
+		if err := vault.Backup(fileSet, profile); err != nil {
+			return fmt.Errorf("save current auth: %w", err)
+		}
+	}
+	if _, err := captureProfileOrg(provider, profile); err != nil {
+		return fmt.Errorf("save current organization: %w", err)
+	}
+
+	if err := vault.RestoreOrgSnapshot(fileSet, profile, target.ID); err != nil {
+		return err
+	}
+	if isActive {
+		if err := activateProfile(fileSet, profile, "org", activationOptions{liveSaved: true}); err != nil {
+			return fmt.Errorf("activate %s/%s: %w", provider, profile, err)
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"provider":     provider,
+			"profile":      profile,
+			"organization": target,
+			"active":       isActive,
+		})
+	}
+	fmt.Fprintf(out, "Switched %s/%s to organization %s\n", provider, profile, orgDisplayName(*target))
+	if isActive {
+		fmt.Fprintln(out, "  Live auth files updated.")
+	}
+	return nil
+}
+
+func orgDisplayName(org authfile.OrgSnapshot) string {
+	if org.Name != "" {
+		return org.Name
+	}
+	return org.ID
+}
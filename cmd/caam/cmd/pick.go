@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -63,7 +64,7 @@ func runPick(cmd *cobra.Command, args []string) error {
 	}
 
 	if _, ok := tools[tool]; !ok {
-		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 	}
 
 	if vault == nil {
@@ -72,7 +73,7 @@ func runPick(cmd *cobra.Command, args []string) error {
 
 	profiles, err := vault.List(tool)
 	if err != nil {
-		return fmt.Errorf("list profiles: %w", err)
+		return caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
 	}
 
 	var filtered []string
@@ -84,7 +85,7 @@ func runPick(cmd *cobra.Command, args []string) error {
 	}
 
 	if len(filtered) == 0 {
-		return fmt.Errorf("no profiles found for %s; create one with 'caam backup %s <name>'", tool, tool)
+		return caamerr.Errorf(caamerr.NoProfiles, "no profiles found for %s; create one with 'caam backup %s <name>'", tool, tool)
 	}
 
 	sort.Strings(filtered)
@@ -233,5 +234,5 @@ func pickWithPrompt(cmd *cobra.Command, tool string, profiles []string, cfg *con
 		}
 	}
 
-	return "", fmt.Errorf("profile not found: %s; run 'caam ls %s' to see available profiles", name, tool)
+	return "", caamerr.Errorf(caamerr.ProfileNotFound, "profile not found: %s; run 'caam ls %s' to see available profiles", name, tool)
 }
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+// policyOutcome is the result of evaluating one rotation policy.
+type policyOutcome struct {
+	Policy   string
+	Provider string
+	From     string
+	To       string
+	// Action is "rotated", "would_rotate", "not_due", "skipped", or "error".
+	Action string
+	Reason string
+}
+
+func (o policyOutcome) String() string {
+	switch o.Action {
+	case "rotated":
+		return fmt.Sprintf("%s: rotated %s from %s to %s (%s)", o.Policy, o.Provider, o.From, o.To, o.Reason)
+	case "would_rotate":
+		return fmt.Sprintf("%s: would rotate %s from %s to %s (%s)", o.Policy, o.Provider, o.From, o.To, o.Reason)
+	case "error":
+		return fmt.Sprintf("%s: %s: %s", o.Policy, o.Provider, o.Reason)
+	default:
+		return fmt.Sprintf("%s: %s not rotated (%s)", o.Policy, o.Provider, o.Reason)
+	}
+}
+
+func runApplyPolicies(dryRun, quiet bool) error {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
+	}
+	if len(spmCfg.Policies) == 0 {
+		if !quiet {
+			fmt.Println("No rotation policies configured (add a policies: list to config.yaml)")
+		}
+		return nil
+	}
+
+	failed := 0
+	for _, o := range applyRotationPolicies(spmCfg, dryRun) {
+		if o.Action == "error" {
+			failed++
+		}
+		if !quiet || o.Action == "rotated" || o.Action == "error" {
+			fmt.Println(o)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d policies failed", failed, len(spmCfg.Policies))
+	}
+	return nil
+}
+
+// applyRotationPolicies evaluates every configured rotation policy and, unless
+// dryRun is set, activates the next profile for each one that is due. A
+// provider is rotated at most once per call, by its first due policy.
+func applyRotationPolicies(spmCfg *config.SPMConfig, dryRun bool) []policyOutcome {
+	if spmCfg == nil || len(spmCfg.Policies) == 0 {
+		return nil
+	}
+
+	db, err := getDB()
+	if err != nil {
+		db = nil
+	}
+
+	now := time.Now()
+	rotated := make(map[string]bool)
+	var outcomes []policyOutcome
+	for _, p := range spmCfg.Policies {
+		provider := strings.ToLower(strings.TrimSpace(p.Provider))
+		o := policyOutcome{Policy: p.PolicyName(), Provider: provider}
+
+		getFileSet, ok := tools[provider]
+		if !ok {
+			o.Action, o.Reason = "error", "unknown provider"
+			outcomes = append(outcomes, o)
+			continue
+		}
+		if rotated[provider] {
+			o.Action, o.Reason = "skipped", "already rotated by an earlier policy"
+			outcomes = append(outcomes, o)
+			continue
+		}
+		if !spmCfg.AutomationEnabled(provider, config.AutomationRotate) {
+			o.Action, o.Reason = "skipped", fmt.Sprintf("automatic rotation is disabled for %s (automation.%s.auto_rotate)", provider, provider)
+			outcomes = append(outcomes, o)
+			continue
+		}
+
+		fileSet := getFileSet()
+		active, _ := vault.ActiveProfile(fileSet)
+		o.From = active
+
+		due, reason := rotation.PolicyDue(p, policyState(db, provider, active), now)
+		o.Reason = reason
+		if !due {
+			o.Action = "not_due"
+			outcomes = append(outcomes, o)
+			continue
+		}
+		shaping := newRotationShaping(spmCfg.Stealth.Shaping, provider, active, db, now)
+		if remaining := shaping.dwell(); remaining > 0 {
+			o.Action, o.Reason = "not_due", reason+"; "+rotation.DwellReason(remaining)
+			outcomes = append(outcomes, o)
+			continue
+		}
+
+		profiles, err := vault.List(provider)
+		if err != nil {
+			o.Action, o.Reason = "error", fmt.Sprintf("list profiles: %v", err)
+			outcomes = append(outcomes, o)
+			continue
+		}
+		var others []string
+		for _, name := range profiles {
+			if name != active {
+				others = append(others, name)
+			}
+		}
+		if len(others) == 0 {
+			o.Action, o.Reason = "skipped", "no other profile to rotate to"
+			outcomes = append(outcomes, o)
+			continue
+		}
+		if others = shaping.allowed(others); len(others) == 0 {
+			o.Action, o.Reason = "skipped", "rotation shaping blocks every other profile now"
+			outcomes = append(outcomes, o)
+			continue
+		}
+		if others = unleasedProfiles(db, provider, others); len(others) == 0 {
+			o.Action, o.Reason = "skipped", "every other profile is leased"
+			outcomes = append(outcomes, o)
+			continue
+		}
+
+		algorithm := rotation.Algorithm(p.Algorithm)
+		if algorithm == "" {
+			algorithm = rotation.Algorithm(spmCfg.Stealth.Rotation.Algorithm)
+		}
+		if algorithm == "" {
+			algorithm = rotation.AlgorithmSmart
+		}
+		primePlanTypes(provider, profiles)
+		selector := rotation.NewSelector(algorithm, healthStore, db)
+		selector.SetRiskTiers(loadRiskConfig().RiskTiersForProvider(provider))
+		selector.SetUnattended(true)
+		// Round robin needs the active profile in the list to know where
+		// the sequence continues; smart and random must not pick it.
+		candidates := others
+		if algorithm == rotation.AlgorithmRoundRobin {
+			candidates = unleasedProfiles(db, provider, shaping.allowed(profiles))
+		}
+		selection, err := selector.Select(provider, candidates, active)
+		if err != nil {
+			o.Action, o.Reason = "skipped", err.Error()
+			outcomes = append(outcomes, o)
+			continue
+		}
+		if selection.Selected == active {
+			o.Action, o.Reason = "skipped", "no other profile is available"
+			outcomes = append(outcomes, o)
+			continue
+		}
+		o.To = selection.Selected
+
+		if dryRun {
+			o.Action = "would_rotate"
+			outcomes = append(outcomes, o)
+			continue
+		}
+
+		if err := activateProfile(fileSet, o.To, "policy", activationOptions{spmCfg: spmCfg}); err != nil {
+			o.Action, o.Reason = "error", fmt.Sprintf("activate %s: %v", o.To, err)
+			outcomes = append(outcomes, o)
+			continue
+		}
+		events.PublishActivated(provider, o.To, "policy")
+		// Always recorded: scheduled policies measure from the last activation.
+		if db != nil {
+			_ = db.LogEvent(caamdb.Event{
+				Type:        caamdb.EventActivate,
+				Provider:    provider,
+				ProfileName: o.To,
+				Details: map[string]any{
+					"previous_profile": active,
+					"selection_source": "policy",
+					"policy":           o.Policy,
+					"reason":           reason,
+					"algorithm":        selection.Algorithm,
+				},
+			})
+		}
+		rotated[provider] = true
+		o.Action = "rotated"
+		outcomes = append(outcomes, o)
+	}
+	return outcomes
+}
+
+// policyState gathers what policies are evaluated against for the active
+// profile. db may be nil.
+func policyState(db *caamdb.DB, provider, active string) rotation.PolicyState {
+	state := rotation.PolicyState{Active: active, Metrics: make(map[string]float64)}
+	if active == "" {
+		return state
+	}
+
+	if db != nil {
+		if ts, err := db.LastActivation(provider, active); err == nil {
+			state.ActiveSince = ts
+		}
+		if info, err := usage.LoadFromCache(db, provider, active); err == nil && info != nil && info.PrimaryWindow != nil {
+			state.Metrics["usage_pct"] = float64(info.PrimaryWindow.UsedPercent)
+		}
+	}
+
+	if healthStore != nil {
+		if h, err := healthStore.GetProfile(provider, active); err == nil && h != nil {
+			state.Metrics["error_count_1h"] = float64(h.ErrorCount1h)
+			state.Metrics["penalty"] = h.Penalty
+			if !h.TokenExpiresAt.IsZero() {
+				state.Metrics["token_expires_min"] = time.Until(h.TokenExpiresAt).Minutes()
+			}
+		}
+	}
+	return state
+}
+
+// daemonApplyPolicies is the daemon's hook for rotation policies. It reloads
+// the config so edited policies take effect without a restart.
+func daemonApplyPolicies() ([]string, error) {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, o := range applyRotationPolicies(spmCfg, false) {
+		if o.Action == "rotated" || o.Action == "error" {
+			lines = append(lines, o.String())
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+)
+
+func TestApplyRotationPolicies_ScheduledRoundRobin(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "codex_home"))
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	if err := os.MkdirAll(os.Getenv("CODEX_HOME"), 0700); err != nil {
+		t.Fatalf("MkdirAll(CODEX_HOME) error = %v", err)
+	}
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	for _, name := range []string{"a", "b"} {
+		if err := os.MkdirAll(vault.ProfilePath("codex", name), 0700); err != nil {
+			t.Fatalf("MkdirAll(profile %s) error = %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(vault.ProfilePath("codex", name), "auth.json"), []byte(`{"access_token":"`+name+`"}`), 0600); err != nil {
+			t.Fatalf("WriteFile(profile %s) error = %v", name, err)
+		}
+	}
+	authPath := filepath.Join(os.Getenv("CODEX_HOME"), "auth.json")
+	if err := os.WriteFile(authPath, []byte(`{"access_token":"a"}`), 0600); err != nil {
+		t.Fatalf("WriteFile(active auth) error = %v", err)
+	}
+
+	spmCfg := config.DefaultSPMConfig()
+	spmCfg.Policies = []config.RotationPolicy{
+		{Provider: "codex", Every: config.Duration(4 * time.Hour), Algorithm: "round_robin"},
+		{Provider: "codex", When: "error_count_1h >= 0"},
+	}
+
+	// Dry run reports without switching.
+	outcomes := applyRotationPolicies(spmCfg, true)
+	if len(outcomes) != 2 || outcomes[0].Action != "would_rotate" || outcomes[0].To != "b" {
+		t.Fatalf("dry-run outcomes = %+v", outcomes)
+	}
+	if got, _ := os.ReadFile(authPath); string(got) != `{"access_token":"a"}` {
+		t.Fatalf("dry run changed the active auth: %s", got)
+	}
+
+	outcomes = applyRotationPolicies(spmCfg, false)
+	if outcomes[0].Action != "rotated" || outcomes[0].From != "a" || outcomes[0].To != "b" {
+		t.Fatalf("outcomes[0] = %+v, want a -> b", outcomes[0])
+	}
+	if outcomes[1].Action != "skipped" {
+		t.Errorf("outcomes[1] = %+v, want skipped after the provider rotated", outcomes[1])
+	}
+	if got, _ := os.ReadFile(authPath); string(got) != `{"access_token":"b"}` {
+		t.Fatalf("active auth = %s, want profile b", got)
+	}
+
+	// The activation was recorded, so the schedule is not due again.
+	outcomes = applyRotationPolicies(spmCfg, false)
+	if outcomes[0].Action != "not_due" {
+		t.Errorf("second pass = %+v, want not_due", outcomes[0])
+	}
+}
+
+func TestApplyRotationPolicies_SkipsLeasedProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "codex_home"))
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	if err := os.MkdirAll(os.Getenv("CODEX_HOME"), 0700); err != nil {
+		t.Fatalf("MkdirAll(CODEX_HOME) error = %v", err)
+	}
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := os.MkdirAll(vault.ProfilePath("codex", name), 0700); err != nil {
+			t.Fatalf("MkdirAll(profile %s) error = %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(vault.ProfilePath("codex", name), "auth.json"), []byte(`{"access_token":"`+name+`"}`), 0600); err != nil {
+			t.Fatalf("WriteFile(profile %s) error = %v", name, err)
+		}
+	}
+	authPath := filepath.Join(os.Getenv("CODEX_HOME"), "auth.json")
+	if err := os.WriteFile(authPath, []byte(`{"access_token":"a"}`), 0600); err != nil {
+		t.Fatalf("WriteFile(active auth) error = %v", err)
+	}
+
+	db, err := getDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AcquireLease("codex", "b", "bob@elsewhere", time.Hour, "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	spmCfg := config.DefaultSPMConfig()
+	spmCfg.Policies = []config.RotationPolicy{
+		{Provider: "codex", Every: config.Duration(4 * time.Hour), Algorithm: "round_robin"},
+	}
+	outcomes := applyRotationPolicies(spmCfg, false)
+	if len(outcomes) != 1 || outcomes[0].Action != "rotated" || outcomes[0].To != "c" {
+		t.Fatalf("outcomes = %+v, want a -> c past bob's lease on b", outcomes)
+	}
+	if got, _ := os.ReadFile(authPath); string(got) != `{"access_token":"c"}` {
+		t.Fatalf("active auth = %s, want profile c", got)
+	}
+}
+
+func TestApplyRotationPolicies_AutomationOff(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "codex_home"))
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	off := false
+	spmCfg := config.DefaultSPMConfig()
+	spmCfg.Automation = map[string]config.ProviderAutomation{"codex": {AutoRotate: &off}}
+	spmCfg.Policies = []config.RotationPolicy{{Provider: "codex", When: "error_count_1h >= 0"}}
+
+	outcomes := applyRotationPolicies(spmCfg, false)
+	if len(outcomes) != 1 || outcomes[0].Action != "skipped" || !strings.Contains(outcomes[0].Reason, "auto_rotate") {
+		t.Fatalf("outcomes = %+v, want skipped with auto_rotate off", outcomes)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authpool"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
@@ -128,7 +129,7 @@ func runPrecheckCmd(cmd *cobra.Command, args []string) error {
 
 	// Validate provider
 	if _, ok := tools[provider]; !ok {
-		return fmt.Errorf("unknown provider: %s (supported: claude, codex, gemini)", provider)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s (supported: claude, codex, gemini)", provider)
 	}
 
 	// Initialize dependencies
@@ -138,7 +139,7 @@ func runPrecheckCmd(cmd *cobra.Command, args []string) error {
 	// List profiles
 	profiles, err := vaultInst.List(provider)
 	if err != nil {
-		return fmt.Errorf("list profiles: %w", err)
+		return caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
 	}
 
 	// Filter out system profiles
@@ -150,7 +151,7 @@ func runPrecheckCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	if len(userProfiles) == 0 {
-		return fmt.Errorf("no profiles found for %s; create one with 'caam backup %s <name>'", provider, provider)
+		return caamerr.Errorf(caamerr.NoProfiles, "no profiles found for %s; create one with 'caam backup %s <name>'", provider, provider)
 	}
 
 	// Load SPM config
@@ -184,7 +185,7 @@ func runPrecheckCmd(cmd *cobra.Command, args []string) error {
 	var usageResults []usage.ProfileUsage
 	var usageMap map[string]*usage.UsageInfo
 
-	if !noFetch && (provider == "claude" || provider == "codex") {
+	if !noFetch && (provider == "claude" || provider == "codex" || provider == "gemini") {
 		credentials, err := usage.LoadProfileCredentials(authfile.DefaultVaultPath(), provider)
 		if err == nil && len(credentials) > 0 {
 			fetchCtx, cancel := context.WithTimeout(ctx, timeout)
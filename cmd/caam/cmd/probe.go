@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+var probeCmd = &cobra.Command{
+	Use:   "probe <tool/profile> | <tool> <profile>",
+	Short: "Send a real minimal request to check that an account still works",
+	Long: `Sends the smallest real request the provider serves with a profile's
+credentials, measures it, and records the result:
+
+  claude  a one-token completion (--model, default claude-haiku-4-5)
+  codex   the Codex model list
+  gemini  a one-token completion through Code Assist (default gemini-2.5-flash)
+
+'caam validate --active' only asks whether the token is accepted. A banned
+or flagged account often still has a token that passes that check while
+every real request is refused; a probe catches it.
+
+Probes are opt-in: each one is a real request and may use a sliver of the
+account's quota. Results are stored in the caam database. A request
+refused with 401 or 403, or with a ban or suspension message, counts as
+an error in the profile's health, which lowers its health status and its
+rank in rotation; a successful one clears the profile's error count.
+Other failures, such as an unknown --model, leave health alone.
+
+Examples:
+  caam probe claude work
+  caam probe codex/main --json
+  caam probe gemini personal --model gemini-2.5-pro`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runProbe,
+}
+
+func init() {
+	rootCmd.AddCommand(probeCmd)
+	probeCmd.Flags().String("model", "", "model to request (claude and gemini)")
+	probeCmd.Flags().Duration("timeout", 30*time.Second, "time limit for the request")
+	probeCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// probeAccount sends a probe; tests replace it.
+var probeAccount = usage.NewProber().Probe
+
+// probeOutput is the JSON output for caam probe.
+type probeOutput struct {
+	Provider     string    `json:"provider"`
+	Profile      string    `json:"profile"`
+	Success      bool      `json:"success"`
+	Endpoint     string    `json:"endpoint,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	HTTPStatus   int       `json:"http_status,omitempty"`
+	LatencyMs    int64     `json:"latency_ms"`
+	AccountError bool      `json:"account_error,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ProbedAt     time.Time `json:"probed_at"`
+}
+
+func runProbe(cmd *cobra.Command, args []string) error {
+	model, _ := cmd.Flags().GetString("model")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	provider, profile := "", ""
+	if len(args) == 1 {
+		var err error
+		if provider, profile, err = parseToolProfileArg(args[0]); err != nil {
+			return caamerr.Wrap(caamerr.InvalidArgs, err)
+		}
+	} else {
+		provider, profile = strings.ToLower(args[0]), args[1]
+	}
+	if !usage.SupportsProbe(provider) {
+		return caamerr.Errorf(caamerr.InvalidProvider, "no probe for %s (supported: claude, codex, gemini)", provider)
+	}
+
+	if vault == nil {
+		vault = authfile.NewVault(authfile.DefaultVaultPath())
+	}
+	profileDir := vault.ProfilePath(provider, profile)
+	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
+		return caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s not found in vault", provider, profile)
+	}
+	token, accountID, err := usage.ReadProfileCredentials(profileDir, provider)
+	if err != nil {
+		return caamerr.Errorf(caamerr.NoAuth, "read %s/%s credentials: %w", provider, profile, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	probedAt := time.Now()
+	result := probeAccount(ctx, provider, token, accountID, model)
+
+	if db, err := getDB(); err == nil {
+		if _, err := db.RecordProbe(caamdb.Probe{
+			Provider:    provider,
+			ProfileName: profile,
+			ProbedAt:    probedAt,
+			Endpoint:    result.Endpoint,
+			Model:       result.Model,
+			Success:     result.OK,
+			HTTPStatus:  result.HTTPStatus,
+			Latency:     result.Latency,
+			Error:       result.Error,
+		}); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: record probe: %v\n", err)
+		}
+	}
+
+	// Only answers about the account move its health: a rate limit, a
+	// server error or a network failure says nothing about a ban.
+	if healthStore != nil {
+		switch {
+		case result.OK:
+			_ = healthStore.ClearErrors(provider, profile)
+		case result.AccountError:
+			_ = healthStore.RecordError(provider, profile, errors.New(result.Error))
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(probeOutput{
+			Provider:     provider,
+			Profile:      profile,
+			Success:      result.OK,
+			Endpoint:     result.Endpoint,
+			Model:        result.Model,
+			HTTPStatus:   result.HTTPStatus,
+			LatencyMs:    result.Latency.Milliseconds(),
+			AccountError: result.AccountError,
+			Error:        result.Error,
+			ProbedAt:     probedAt.UTC(),
+		}); err != nil {
+			return err
+		}
+	} else {
+		detail := fmt.Sprintf("%dms", result.Latency.Milliseconds())
+		if result.HTTPStatus != 0 {
+			detail = fmt.Sprintf("HTTP %d, %s", result.HTTPStatus, detail)
+		}
+		if result.Model != "" {
+			detail += ", " + result.Model
+		}
+		if result.OK {
+			fmt.Fprintf(out, "%s/%s: ok (%s)\n", provider, profile, detail)
+		} else {
+			fmt.Fprintf(out, "%s/%s: failed (%s): %s\n", provider, profile, detail, result.Error)
+			if result.AccountError {
+				fmt.Fprintln(out, "The provider refused a real request for this account. If 'caam validate --active'")
+				fmt.Fprintln(out, "still passes, the account may be flagged or suspended; check it in the provider's console.")
+			}
+		}
+	}
+
+	if !result.OK {
+		return caamerr.Errorf(caamerr.CheckFailed, "probe of %s/%s failed: %s", provider, profile, result.Error)
+	}
+	return nil
+}
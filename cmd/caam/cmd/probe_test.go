@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+func TestRunProbeRecordsResultAndHealth(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "home", ".codex"))
+
+	oldVault, oldHealth, oldProbe := vault, healthStore, probeAccount
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	healthStore = health.NewStorage(filepath.Join(tmpDir, "health.json"))
+	t.Cleanup(func() { vault, healthStore, probeAccount = oldVault, oldHealth, oldProbe })
+
+	dir := vault.ProfilePath("codex", "work")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	auth := `{"tokens":{"access_token":"tok-work","account_id":"acct-work"}}`
+	if err := os.WriteFile(filepath.Join(dir, "auth.json"), []byte(auth), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	banned := true
+	probeAccount = func(_ context.Context, provider, token, accountID, model string) usage.ProbeResult {
+		if token != "tok-work" || accountID != "acct-work" {
+			t.Errorf("probe got token %q, account %q", token, accountID)
+		}
+		if banned {
+			return usage.ProbeResult{Provider: provider, HTTPStatus: 403, AccountError: true, Latency: 80 * time.Millisecond,
+				Error: "unauthorized: status 403: account deactivated"}
+		}
+		return usage.ProbeResult{Provider: provider, HTTPStatus: 200, OK: true, Latency: 300 * time.Millisecond}
+	}
+
+	var buf bytes.Buffer
+	probeCmd.SetOut(&buf)
+	defer probeCmd.SetOut(nil)
+	if err := probeCmd.Flags().Set("json", "true"); err != nil {
+		t.Fatal(err)
+	}
+	defer probeCmd.Flags().Set("json", "false")
+
+	err := runProbe(probeCmd, []string{"codex/work"})
+	if caamerr.CodeOf(err) != caamerr.CheckFailed {
+		t.Fatalf("banned probe error = %v, want %s", err, caamerr.CheckFailed)
+	}
+	var out probeOutput
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("parse output: %v\n%s", err, buf.String())
+	}
+	if out.Success || !out.AccountError || out.HTTPStatus != 403 || out.LatencyMs != 80 {
+		t.Errorf("banned output = %+v", out)
+	}
+	ph, err := healthStore.GetProfile("codex", "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ph == nil || ph.ErrorCount1h != 1 {
+		t.Fatalf("health after refused probe = %+v, want one error", ph)
+	}
+
+	banned = false
+	buf.Reset()
+	if err := runProbe(probeCmd, []string{"codex", "work"}); err != nil {
+		t.Fatalf("healthy probe: %v", err)
+	}
+	if ph, _ = healthStore.GetProfile("codex", "work"); ph == nil || ph.ErrorCount1h != 0 {
+		t.Errorf("health after successful probe = %+v, want errors cleared", ph)
+	}
+
+	db, err := getDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	probes, err := db.RecentProbes("codex", "work", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(probes) != 2 || !probes[0].Success || probes[1].Success || probes[1].HTTPStatus != 403 {
+		t.Fatalf("recorded probes = %+v, want the success after the 403", probes)
+	}
+
+	if err := runProbe(probeCmd, []string{"codex", "missing"}); caamerr.CodeOf(err) != caamerr.ProfileNotFound {
+		t.Errorf("missing profile error = %v, want %s", err, caamerr.ProfileNotFound)
+	}
+	if err := runProbe(probeCmd, []string{"cursor", "work"}); caamerr.CodeOf(err) != caamerr.InvalidProvider {
+		t.Errorf("cursor error = %v, want %s", err, caamerr.InvalidProvider)
+	}
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/egress"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+var profileNetworkCmd = &cobra.Command{
+	Use:   "network <tool> <name>",
+	Short: "Set or show a profile's proxy, egress interface, timezone and locale",
+	Long: `Sets the network fingerprint a profile runs with under 'caam run' and
+'caam launch', so rotated accounts don't all present the same address,
+timezone and locale.
+
+  --proxy      http, https, socks5 or socks5h proxy URL (HTTP_PROXY, HTTPS_PROXY)
+  --interface  network interface traffic leaves through, e.g. wg1
+  --timezone   IANA timezone (TZ)
+  --locale     locale (LANG, LC_ALL)
+
+A profile's proxy overrides stealth.network in config.yaml. With an
+interface, caam starts a local relay for the run that dials out from the
+interface's address, chaining to the proxy if one is set. Routing must send
+that address out through the interface; for a VPN interface it usually does.
+
+The profile may be an isolated profile or a vault profile. Without flags,
+shows the current settings. Pass an empty value to unset one, or --clear to
+unset all.
+
+Examples:
+  caam profile network claude work --proxy socks5://10.0.0.2:1080 --timezone Europe/Berlin --locale de_DE.UTF-8
+  caam profile network codex alt --interface wg1
+  caam profile network claude work --proxy ""
+  caam profile network claude work --clear`,
+	Args: cobra.ExactArgs(2),
+	RunE: runProfileNetwork,
+}
+
+func init() {
+	profileNetworkCmd.Flags().String("proxy", "", "proxy URL (empty to unset)")
+	profileNetworkCmd.Flags().String("interface", "", "egress network interface (empty to unset)")
+	profileNetworkCmd.Flags().String("timezone", "", "IANA timezone, exported as TZ (empty to unset)")
+	profileNetworkCmd.Flags().String("locale", "", "locale, exported as LANG and LC_ALL (empty to unset)")
+	profileNetworkCmd.Flags().Bool("clear", false, "remove all network settings")
+	profileNetworkCmd.Flags().Bool("json", false, "output as JSON")
+	profileCmd.AddCommand(profileNetworkCmd)
+}
+
+func runProfileNetwork(cmd *cobra.Command, args []string) error {
+	tool := strings.ToLower(args[0])
+	name := args[1]
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	clearFlag, _ := cmd.Flags().GetBool("clear")
+
+	if _, ok := tools[tool]; !ok {
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: %s)", tool, strings.Join(toolNames(), ", "))
+	}
+	prof, err := loadNetworkProfile(tool, name)
+	if err != nil {
+		return err
+	}
+
+	n := profile.Network{}
+	if prof.Network != nil {
+		n = *prof.Network
+	}
+	changed := clearFlag
+	if clearFlag {
+		n = profile.Network{}
+	}
+	for flag, field := range map[string]*string{
+		"proxy":     &n.Proxy,
+		"interface": &n.Interface,
+		"timezone":  &n.Timezone,
+		"locale":    &n.Locale,
+	} {
+		if cmd.Flags().Changed(flag) {
+			*field, _ = cmd.Flags().GetString(flag)
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := n.Validate(); err != nil {
+			return caamerr.Errorf(caamerr.InvalidArgs, "%s/%s: %w", tool, name, err)
+		}
+		prof.Network = &n
+		if n.IsZero() {
+			prof.Network = nil
+		}
+		if err := prof.Save(); err != nil {
+			return caamerr.Errorf(caamerr.SaveError, "save profile: %w", err)
+		}
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Tool    string          `json:"tool"`
+			Profile string          `json:"profile"`
+			Network profile.Network `json:"network"`
+		}{tool, name, n})
+	}
+
+	w := cmd.OutOrStdout()
+	if n.IsZero() {
+		fmt.Fprintf(w, "%s/%s has no network settings\n", tool, name)
+		return nil
+	}
+	if changed {
+		fmt.Fprintf(w, "Updated network settings for %s/%s:\n", tool, name)
+	} else {
+		fmt.Fprintf(w, "%s/%s:\n", tool, name)
+	}
+	for _, row := range [][2]string{
+		{"proxy", n.Proxy},
+		{"interface", n.Interface},
+		{"timezone", n.Timezone},
+		{"locale", n.Locale},
+	} {
+		if row[1] != "" {
+			fmt.Fprintf(w, "  %-10s %s\n", row[0], row[1])
+		}
+	}
+	return nil
+}
+
+// loadNetworkProfile loads the profile network settings are stored on: the
+// isolated profile if there is one, otherwise a record for the vault
+// profile, which caam run keeps in the same place.
+func loadNetworkProfile(tool, name string) (*profile.Profile, error) {
+	if profileStore == nil {
+		profileStore = profile.NewStore(profile.DefaultStorePath())
+	}
+	if prof, err := profileStore.Load(tool, name); err == nil {
+		return prof, nil
+	}
+	if vault == nil || !vaultProfileExists(tool, name) {
+		return nil, caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s not found", tool, name)
+	}
+	return loadRunProfile(tool, name), nil
+}
+
+func vaultProfileExists(tool, name string) bool {
+	profiles, err := vault.List(tool)
+	if err != nil {
+		return false
+	}
+	for _, p := range profiles {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// profileNetworkEnv returns the environment variables that give prof its
+// network fingerprint: stealth.network from config.yaml, overridden by the
+// profile's own settings. A profile bound to an egress interface gets a
+// relay for the run; stop closes it and must be called when the run ends.
+func profileNetworkEnv(prof *profile.Profile, spmCfg *config.SPMConfig) (env map[string]string, stop func(), err error) {
+	stop = func() {}
+	var settings config.NetworkSettings
+	if spmCfg != nil {
+		settings = spmCfg.Stealth.Network.For(prof.Provider, prof.Name)
+	}
+	n := prof.Network
+	if n == nil {
+		n = &profile.Network{}
+	}
+	if n.Proxy != "" {
+		settings.Proxy = n.Proxy
+	}
+
+	if n.Interface != "" {
+		addr, err := egress.InterfaceAddr(n.Interface)
+		if err != nil {
+			return nil, stop, fmt.Errorf("%s/%s egress: %w", prof.Provider, prof.Name, err)
+		}
+		relay, err := egress.Start(addr, settings.Proxy)
+		if err != nil {
+			return nil, stop, fmt.Errorf("%s/%s egress: %w", prof.Provider, prof.Name, err)
+		}
+		settings.Proxy = relay.ProxyURL()
+		stop = func() { _ = relay.Close() }
+	}
+
+	env = settings.Env()
+	if n.Timezone != "" {
+		env["TZ"] = n.Timezone
+	}
+	if n.Locale != "" {
+		env["LANG"] = n.Locale
+		env["LC_ALL"] = n.Locale
+	}
+	return env, stop, nil
+}
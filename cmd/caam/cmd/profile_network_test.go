@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+func newProfileNetworkTestCmd(t *testing.T, flags ...string) (*cobra.Command, *bytes.Buffer) {
+	t.Helper()
+	c := &cobra.Command{}
+	for _, f := range []string{"proxy", "interface", "timezone", "locale"} {
+		c.Flags().String(f, "", "")
+	}
+	c.Flags().Bool("clear", false, "")
+	c.Flags().Bool("json", false, "")
+	if err := c.ParseFlags(flags); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	c.SetOut(&out)
+	return c, &out
+}
+
+func TestProfileNetwork(t *testing.T) {
+	oldStore, oldVault := profileStore, vault
+	defer func() { profileStore, vault = oldStore, oldVault }()
+	profileStore = profile.NewStore(t.TempDir())
+	vault = authfile.NewVault(t.TempDir())
+
+	// A vault-only profile gets a record in the profile store.
+	if err := os.MkdirAll(vault.ProfilePath("codex", "alt"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(vault.ProfilePath("codex", "alt"), "auth.json"), []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := newProfileNetworkTestCmd(t, "--proxy", "socks5://10.0.0.2:1080", "--timezone", "Europe/Berlin", "--locale", "de_DE.UTF-8")
+	if err := runProfileNetwork(c, []string{"codex", "alt"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	prof, err := profileStore.Load("codex", "alt")
+	if err != nil {
+		t.Fatalf("load saved profile: %v", err)
+	}
+	want := profile.Network{Proxy: "socks5://10.0.0.2:1080", Timezone: "Europe/Berlin", Locale: "de_DE.UTF-8"}
+	if prof.Network == nil || *prof.Network != want {
+		t.Fatalf("saved network = %+v, want %+v", prof.Network, want)
+	}
+
+	// Flags not given are kept; an empty value unsets one.
+	c, out := newProfileNetworkTestCmd(t, "--proxy", "")
+	if err := runProfileNetwork(c, []string{"codex", "alt"}); err != nil {
+		t.Fatalf("unset proxy: %v", err)
+	}
+	prof, _ = profileStore.Load("codex", "alt")
+	if prof.Network.Proxy != "" || prof.Network.Timezone != "Europe/Berlin" {
+		t.Errorf("after unsetting proxy: %+v", prof.Network)
+	}
+	if !strings.Contains(out.String(), "Europe/Berlin") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	c, _ = newProfileNetworkTestCmd(t, "--timezone", "Mars/Olympus")
+	if err := runProfileNetwork(c, []string{"codex", "alt"}); caamerr.CodeOf(err) != caamerr.InvalidArgs {
+		t.Errorf("bad timezone: err = %v, want %s", err, caamerr.InvalidArgs)
+	}
+
+	c, _ = newProfileNetworkTestCmd(t, "--clear")
+	if err := runProfileNetwork(c, []string{"codex", "alt"}); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if prof, _ = profileStore.Load("codex", "alt"); prof.Network != nil {
+		t.Errorf("after clear: %+v", prof.Network)
+	}
+
+	c, _ = newProfileNetworkTestCmd(t)
+	if err := runProfileNetwork(c, []string{"codex", "missing"}); caamerr.CodeOf(err) != caamerr.ProfileNotFound {
+		t.Errorf("missing profile: err = %v, want %s", err, caamerr.ProfileNotFound)
+	}
+}
+
+func TestProfileNetworkEnv(t *testing.T) {
+	spmCfg := config.DefaultSPMConfig()
+	spmCfg.Stealth.Network = config.NetworkConfig{Proxy: "http://shared:8080", UserAgent: "ua"}
+	prof := &profile.Profile{Provider: "claude", Name: "work"}
+
+	env, stop, err := profileNetworkEnv(prof, spmCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	if env["HTTPS_PROXY"] != "http://shared:8080" || env["CAAM_USER_AGENT"] != "ua" || env["TZ"] != "" {
+		t.Errorf("config-only env = %v", env)
+	}
+
+	prof.Network = &profile.Network{Proxy: "socks5://own:1080", Timezone: "Asia/Tokyo", Locale: "ja_JP.UTF-8"}
+	env, stop, err = profileNetworkEnv(prof, spmCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	if env["HTTPS_PROXY"] != "socks5://own:1080" || env["TZ"] != "Asia/Tokyo" || env["LC_ALL"] != "ja_JP.UTF-8" {
+		t.Errorf("profile env = %v", env)
+	}
+
+	prof.Network = &profile.Network{Interface: "caam-no-such-if0"}
+	if _, _, err := profileNetworkEnv(prof, spmCfg); err == nil {
+		t.Error("missing egress interface did not fail")
+	}
+}
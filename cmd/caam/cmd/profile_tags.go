@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+var profileTagCmd = &cobra.Command{
+	Use:   "tag <tool> <profile> [key=value|key...]",
+	Short: "Set key=value tags and notes on a vault profile",
+	Long: `Attach key=value tags and a free-form note to a vault profile.
+
+Tags are stored in the caam database, travel with export bundles, and can be
+used to filter 'caam robot status' and 'caam robot next' with --tag.
+Setting an existing key replaces its value. A bare key is a flag-style tag.
+With no tags or flags, the profile's current tags are shown.
+
+Examples:
+  caam profile tag claude work-acct client=acme tier=max
+  caam profile tag claude work-acct --note "billing contact: ops@acme.io"
+  caam profile tag claude work-acct --remove tier
+  caam profile tag claude work-acct --clear
+  caam robot next claude --tag client=acme`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runProfileTag,
+}
+
+var profileTagsCmd = &cobra.Command{
+	Use:   "tags [tool]",
+	Short: "List vault profile tags",
+	Long: `List the key=value tags set with 'caam profile tag'.
+
+Examples:
+  caam profile tags
+  caam profile tags claude --tag client=acme
+  caam profile tags --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runProfileTags,
+}
+
+func init() {
+	profileTagCmd.Flags().String("note", "", "set the profile note (stored as the note tag)")
+	profileTagCmd.Flags().StringArray("remove", nil, "remove a tag key (repeatable)")
+	profileTagCmd.Flags().Bool("clear", false, "remove all tags from the profile")
+	profileTagCmd.Flags().Bool("json", false, "output in JSON format")
+	profileCmd.AddCommand(profileTagCmd)
+
+	profileTagsCmd.Flags().StringArray("tag", nil, "only show profiles with this tag (key or key=value, repeatable)")
+	profileTagsCmd.Flags().Bool("json", false, "output in JSON format")
+	profileCmd.AddCommand(profileTagsCmd)
+}
+
+func runProfileTag(cmd *cobra.Command, args []string) error {
+	tool := strings.ToLower(args[0])
+	profileName := args[1]
+	note, _ := cmd.Flags().GetString("note")
+	remove, _ := cmd.Flags().GetStringArray("remove")
+	clearAll, _ := cmd.Flags().GetBool("clear")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	if _, ok := tools[tool]; !ok {
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
+	}
+	profiles, err := vault.List(tool)
+	if err != nil {
+		return caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
+	}
+	if !slices.Contains(profiles, profileName) {
+		return caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s not found in vault; run 'caam ls %s' to see available profiles", tool, profileName, tool)
+	}
+
+	set := make(map[string]string)
+	for _, arg := range args[2:] {
+		key, value, err := caamdb.ParseProfileTag(arg)
+		if err != nil {
+			return err
+		}
+		set[key] = value
+	}
+	if cmd.Flags().Changed("note") {
+		set["note"] = strings.TrimSpace(note)
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+
+	if clearAll {
+		if _, err := db.RemoveProfileTags(tool, profileName); err != nil {
+			return err
+		}
+	} else if len(remove) > 0 {
+		if _, err := db.RemoveProfileTags(tool, profileName, remove...); err != nil {
+			return err
+		}
+	}
+	if len(set) > 0 {
+		if err := db.SetProfileTags(tool, profileName, set); err != nil {
+			return err
+		}
+	}
+
+	tags, err := db.ProfileTags(tool, profileName)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		output := struct {
+			Tool    string            `json:"tool"`
+			Profile string            `json:"profile"`
+			Tags    map[string]string `json:"tags"`
+		}{
+			Tool:    tool,
+			Profile: profileName,
+			Tags:    make(map[string]string),
+		}
+		for _, t := range tags {
+			output.Tags[t.Key] = t.Value
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(output)
+	}
+
+	if len(tags) == 0 {
+		fmt.Fprintf(out, "No tags for %s/%s\n", tool, profileName)
+		return nil
+	}
+	fmt.Fprintf(out, "Tags for %s/%s:\n", tool, profileName)
+	for _, t := range tags {
+		fmt.Fprintf(out, "  %s\n", t)
+	}
+	return nil
+}
+
+func runProfileTags(cmd *cobra.Command, args []string) error {
+	var tool string
+	if len(args) > 0 {
+		tool = strings.ToLower(args[0])
+	}
+	filters, _ := cmd.Flags().GetStringArray("tag")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	db, err := getDB()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	all, err := db.ListProfileTags(tool)
+	if err != nil {
+		return err
+	}
+
+	// Group by profile, keeping the sorted order from the database.
+	type taggedProfile struct {
+		Tool    string            `json:"tool"`
+		Profile string            `json:"profile"`
+		Tags    map[string]string `json:"tags"`
+		sorted  []caamdb.ProfileTag
+	}
+	var profiles []*taggedProfile
+	for _, t := range all {
+		n := len(profiles)
+		if n == 0 || profiles[n-1].Tool != t.Provider || profiles[n-1].Profile != t.ProfileName {
+			profiles = append(profiles, &taggedProfile{Tool: t.Provider, Profile: t.ProfileName, Tags: make(map[string]string)})
+			n++
+		}
+		profiles[n-1].Tags[t.Key] = t.Value
+		profiles[n-1].sorted = append(profiles[n-1].sorted, t)
+	}
+	matched := make([]*taggedProfile, 0, len(profiles))
+	for _, p := range profiles {
+		if caamdb.TagsMatch(p.Tags, filters) {
+			matched = append(matched, p)
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(matched)
+	}
+
+	if len(matched) == 0 {
+		fmt.Fprintln(out, "No tagged profiles")
+		return nil
+	}
+	for _, p := range matched {
+		fmt.Fprintf(out, "%s/%s:", p.Tool, p.Profile)
+		for _, t := range p.sorted {
+			fmt.Fprintf(out, " %s", t)
+		}
+		fmt.Fprintln(out)
+	}
+	return nil
+}
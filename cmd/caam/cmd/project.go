@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 )
 
 // projectCmd is the parent command for project association management.
@@ -46,7 +48,7 @@ var projectSetCmd = &cobra.Command{
 		profileName := args[1]
 
 		if _, ok := tools[tool]; !ok {
-			return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 		}
 		if projectStore == nil {
 			return fmt.Errorf("project store not initialized")
@@ -205,7 +207,7 @@ var projectRemoveCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tool := strings.ToLower(args[0])
 		if _, ok := tools[tool]; !ok {
-			return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 		}
 		if projectStore == nil {
 			return fmt.Errorf("project store not initialized")
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/plugin"
+	"github.com/spf13/cobra"
+)
+
+// providersCmd lists built-in and plugin providers.
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "List providers, including plugins from providers.d",
+	Long: `List the providers caam manages: the built-in codex, claude, gemini,
+cursor, and copilot, plus any plugin providers described in
+~/.config/caam/providers.d/.
+
+A plugin is a JSON or YAML descriptor naming the tool's auth files and where
+its token expiry lives. Plugin providers work with backup, activate, health,
+sync, and robot commands like the built-in ones.
+
+Example ~/.config/caam/providers.d/amp.yaml:
+
+  id: amp
+  display_name: Amp
+  binary: amp
+  login_command: amp login
+  auth_files:
+    - path: ~/.local/share/amp/secrets.json
+      description: Amp CLI session
+      required: true
+  expiry:
+    file: secrets.json
+    path: $.expiresAt
+    format: unix_ms          # unix, unix_ms, rfc3339, or auto
+
+Use 'caam providers validate <file>' to check a descriptor before installing it.`,
+	Args: cobra.NoArgs,
+	RunE: runProviders,
+}
+
+var providersValidateCmd = &cobra.Command{
+	Use:   "validate <file>",
+	Short: "Check a provider descriptor",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProvidersValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(providersCmd)
+	providersCmd.AddCommand(providersValidateCmd)
+	providersCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// providerListEntry is one row of 'caam providers'.
+type providerListEntry struct {
+	ID          string   `json:"id"`
+	DisplayName string   `json:"display_name"`
+	Source      string   `json:"source"`
+	AuthFiles   []string `json:"auth_files"`
+	Expiry      string   `json:"expiry,omitempty"`
+}
+
+func runProviders(cmd *cobra.Command, args []string) error {
+	var entries []providerListEntry
+	for _, id := range toolNames() {
+		entry := providerListEntry{ID: id, DisplayName: getProviderDisplayName(id), Source: "built-in"}
+		if p, ok := registry.Get(id); ok {
+			if pp, ok := p.(*plugin.Provider); ok {
+				d := pp.Descriptor()
+				entry.Source = d.Source
+				if d.Expiry != nil {
+					entry.Expiry = d.Expiry.File + " " + d.Expiry.Path
+				}
+			}
+		}
+		for _, spec := range tools[id]().Files {
+			entry.AuthFiles = append(entry.AuthFiles, spec.Path)
+		}
+		entries = append(entries, entry)
+	}
+
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	if jsonOut {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSOURCE\tAUTH FILES")
+	for _, e := range entries {
+		source := e.Source
+		if source != "built-in" {
+			source = filepath.Base(source)
+		}
+		for i, path := range e.AuthFiles {
+			if i == 0 {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.ID, e.DisplayName, source, path)
+			} else {
+				fmt.Fprintf(w, "\t\t\t%s\n", path)
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "\nPlugin directory: %s\n", plugin.Dir())
+	return nil
+}
+
+func runProvidersValidate(cmd *cobra.Command, args []string) error {
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	d, err := plugin.Parse(path, data)
+	if err != nil {
+		return err
+	}
+	if src := registryDescriptorSource(d.ID); src != "" && src != d.Source {
+		return fmt.Errorf("provider id %q is already defined in %s", d.ID, src)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "✓ %s (%s) is valid\n", d.ID, d.DisplayName)
+	p := plugin.NewProvider(d)
+	for _, spec := range p.AuthFileSet().Files {
+		state := "missing"
+		if _, err := os.Stat(spec.Path); err == nil {
+			state = "found"
+		}
+		fmt.Fprintf(out, "  %s (%s)\n", spec.Path, state)
+	}
+	if d.Expiry == nil {
+		fmt.Fprintln(out, "  No expiry configured; health will show unknown expiry.")
+		return nil
+	}
+	for _, f := range p.AuthFileSet().Files {
+		if filepath.Base(f.Path) != d.Expiry.File {
+			continue
+		}
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			break
+		}
+		info, err := d.ParseExpiry(data)
+		if err != nil {
+			fmt.Fprintf(out, "  ✗ expiry: %v\n", err)
+			return nil
+		}
+		fmt.Fprintf(out, "  Token expires %s\n", info.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+// registryDescriptorSource returns the descriptor file a plugin provider was
+// loaded from, or "" for built-in and unknown providers.
+func registryDescriptorSource(id string) string {
+	if p, ok := registry.Get(id); ok {
+		if pp, ok := p.(*plugin.Provider); ok {
+			return pp.Descriptor().Source
+		}
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+)
+
+func TestLoadProviderPlugins(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	dir := filepath.Join(configHome, "caam", "providers.d")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	descriptor := "id: cmdplug\ndisplay_name: Cmd Plug\nauth_files:\n  - path: ~/.cmdplug/auth.json\n    required: true\n"
+	if err := os.WriteFile(filepath.Join(dir, "cmdplug.yaml"), []byte(descriptor), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	oldRegistry := registry
+	registry = provider.NewRegistry()
+	t.Cleanup(func() {
+		registry = oldRegistry
+		delete(tools, "cmdplug")
+	})
+
+	loadProviderPlugins()
+
+	if _, ok := registry.Get("cmdplug"); !ok {
+		t.Error("plugin not in provider registry")
+	}
+	getFileSet, ok := tools["cmdplug"]
+	if !ok {
+		t.Fatal("plugin not in tools")
+	}
+	if set := getFileSet(); len(set.Files) != 1 || filepath.Base(set.Files[0].Path) != "auth.json" {
+		t.Errorf("file set = %+v", set)
+	}
+	names := toolNames()
+	if !slices.Equal(names[:len(builtinTools)], builtinTools) || !slices.Contains(names, "cmdplug") {
+		t.Errorf("toolNames = %v", names)
+	}
+	if got := getProviderDisplayName("cmdplug"); got != "Cmd Plug" {
+		t.Errorf("display name = %q", got)
+	}
+}
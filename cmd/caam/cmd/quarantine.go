@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/ratelimit"
+)
+
+var quarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "Manage profiles whose tokens were revoked",
+	Long: `Profiles whose tokens were revoked are quarantined: rotation, robot next,
+and the daemon's refresher skip them until someone logs in again. Unlike a
+cooldown, a quarantine never expires on its own.
+
+caam run, caam wrap, and token refresh quarantine a profile automatically
+when the provider's output matches a revoked-token fingerprint (for example
+invalid_grant or a 401 Unauthorized). Backing the profile up again after a
+fresh login lifts the quarantine.
+
+Examples:
+  caam quarantine                          # List quarantined profiles
+  caam quarantine ingest claude/work err.log
+  caam quarantine clear claude/work`,
+	Args: cobra.NoArgs,
+	RunE: runQuarantineList,
+}
+
+var quarantineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List quarantined profiles",
+	Args:  cobra.NoArgs,
+	RunE:  runQuarantineList,
+}
+
+var quarantineClearCmd = &cobra.Command{
+	Use:   "clear <provider/profile>",
+	Short: "Lift a quarantine without re-backing up the profile",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runQuarantineClear,
+}
+
+var quarantineIngestCmd = &cobra.Command{
+	Use:   "ingest <provider/profile> [file]",
+	Short: "Classify error output and set the profile's state",
+	Long: `Reads error output (stdin or file) from a provider CLI or API and matches
+it against known fingerprints:
+
+  revoked       quarantine the profile and prompt for re-login
+  rate_limited  put the profile in cooldown
+  network       no change (the account is fine)
+
+Use this from scripts and agents that run the tools themselves.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runQuarantineIngest,
+}
+
+func init() {
+	rootCmd.AddCommand(quarantineCmd)
+	quarantineCmd.AddCommand(quarantineListCmd)
+	quarantineCmd.AddCommand(quarantineClearCmd)
+	quarantineCmd.AddCommand(quarantineIngestCmd)
+
+	quarantineCmd.Flags().Bool("json", false, "output as JSON")
+	quarantineListCmd.Flags().Bool("json", false, "output as JSON")
+	quarantineIngestCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// quarantineListItem is one quarantined profile in JSON output.
+type quarantineListItem struct {
+	Provider   string    `json:"provider"`
+	Profile    string    `json:"profile"`
+	DetectedAt time.Time `json:"detected_at"`
+	Reason     string    `json:"reason,omitempty"`
+	Source     string    `json:"source,omitempty"`
+}
+
+// quarantineListOutput is the JSON output for quarantine list.
+type quarantineListOutput struct {
+	Profiles []quarantineListItem `json:"profiles"`
+	Count    int                  `json:"count"`
+}
+
+// ingestResult is the JSON output for quarantine ingest.
+type ingestResult struct {
+	Provider string `json:"provider"`
+	Profile  string `json:"profile"`
+	Kind     string `json:"kind"`
+	Match    string `json:"match,omitempty"`
+	Action   string `json:"action"`
+	Until    string `json:"until,omitempty"`
+}
+
+// quarantineProfile marks a profile's token as revoked.
+func quarantineProfile(provider, profile, reason, source string) (*caamdb.Revocation, error) {
+	db, err := getDB()
+	if err != nil {
+		return nil, err
+	}
+	return db.MarkRevoked(provider, profile, time.Now(), reason, source)
+}
+
+// liftQuarantine clears a revocation after the profile has been backed up
+// from a fresh login. Errors are ignored; the database is optional here.
+func liftQuarantine(provider, profile string) bool {
+	db, err := getDB()
+	if err != nil {
+		return false
+	}
+	n, err := db.ClearRevocation(provider, profile, time.Now())
+	return err == nil && n > 0
+}
+
+func runQuarantineList(cmd *cobra.Command, args []string) error {
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	db, err := getDB()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	revs, err := db.ListActiveRevocations()
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOut {
+		output := quarantineListOutput{Profiles: []quarantineListItem{}, Count: len(revs)}
+		for _, rev := range revs {
+			output.Profiles = append(output.Profiles, quarantineListItem{
+				Provider:   rev.Provider,
+				Profile:    rev.ProfileName,
+				DetectedAt: rev.DetectedAt,
+				Reason:     rev.Reason,
+				Source:     rev.Source,
+			})
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(output)
+	}
+	if len(revs) == 0 {
+		fmt.Fprintln(out, "No quarantined profiles.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PROFILE\tDETECTED\tSOURCE\tREASON")
+	for _, rev := range revs {
+		_, _ = fmt.Fprintf(tw, "%s/%s\t%s\t%s\t%s\n", rev.Provider, rev.ProfileName, formatTimeAgo(rev.DetectedAt), rev.Source, rev.Reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "\nLog in again and run 'caam backup <provider> <profile>' to lift a quarantine.")
+	return nil
+}
+
+func runQuarantineClear(cmd *cobra.Command, args []string) error {
+	provider, profile, err := resolveProviderProfile(strings.TrimSpace(args[0]))
+	if err != nil {
+		return err
+	}
+	db, err := getDB()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	n, err := db.ClearRevocation(provider, profile, time.Now())
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s/%s is not quarantined\n", provider, profile)
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Lifted quarantine on %s/%s\n", provider, profile)
+	return nil
+}
+
+func runQuarantineIngest(cmd *cobra.Command, args []string) error {
+	provider, profile, err := resolveProviderProfile(strings.TrimSpace(args[0]))
+	if err != nil {
+		return err
+	}
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	r, closeFn, err := openInputArg(cmd, args[1:])
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	data, err := io.ReadAll(io.LimitReader(r, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read error output: %w", err)
+	}
+
+	kind, match := ratelimit.Classify(ratelimit.ProviderFromString(provider), string(data))
+	result := ingestResult{Provider: provider, Profile: profile, Kind: string(kind), Match: match, Action: "none"}
+
+	switch kind {
+	case ratelimit.KindRevoked:
+		if _, err := quarantineProfile(provider, profile, match, "ingest"); err != nil {
+			return err
+		}
+		result.Action = "quarantined"
+	case ratelimit.KindRateLimited:
+		db, err := getDB()
+		if err != nil {
+			return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+		}
+		ev, err := db.SetCooldown(provider, profile, time.Now().UTC(), defaultCooldownDuration(), "ingested: "+match)
+		if err != nil {
+			return err
+		}
+		result.Action = "cooldown"
+		result.Until = ev.CooldownUntil.Format(time.RFC3339)
+	case ratelimit.KindNone:
+		result.Kind = "unknown"
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	switch result.Action {
+	case "quarantined":
+		fmt.Fprintf(out, "%s/%s: token revoked (%s); quarantined.\n", provider, profile, match)
+		fmt.Fprintf(out, "  Log in again, then run: caam backup %s %s\n", provider, profile)
+	case "cooldown":
+		fmt.Fprintf(out, "%s/%s: rate limited (%s); cooldown until %s\n", provider, profile, match, result.Until)
+	default:
+		if kind == ratelimit.KindNetwork {
+			fmt.Fprintf(out, "%s/%s: network error (%s); profile left unchanged\n", provider, profile, match)
+		} else {
+			fmt.Fprintf(out, "%s/%s: no known error fingerprint matched; profile left unchanged\n", provider, profile)
+		}
+	}
+	return nil
+}
+
+// defaultCooldownDuration returns stealth.cooldown.default_minutes, or an
+// hour when unset.
+func defaultCooldownDuration() time.Duration {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		spmCfg = config.DefaultSPMConfig()
+	}
+	if m := spmCfg.Stealth.Cooldown.DefaultMinutes; m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return time.Hour
+}
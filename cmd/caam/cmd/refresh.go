@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/refresh"
@@ -26,9 +27,10 @@ Examples:
   caam refresh codex main --force
   caam refresh --all
   caam refresh --all --dry-run
+  caam refresh --all --async      # Queue as a daemon job (see 'caam jobs')
 `,
 	Args: cobra.RangeArgs(0, 2),
-	RunE: runRefresh,
+	RunE: jobRunE("refresh", runRefresh, refreshIsBulk),
 }
 
 func init() {
@@ -36,6 +38,7 @@ func init() {
 	refreshCmd.Flags().Bool("dry-run", false, "show what would be refreshed")
 	refreshCmd.Flags().Bool("force", false, "force refresh even if not expiring")
 	refreshCmd.Flags().Bool("quiet", false, "suppress output")
+	addAsyncFlag(refreshCmd)
 	rootCmd.AddCommand(refreshCmd)
 }
 
@@ -65,7 +68,7 @@ func runRefresh(cmd *cobra.Command, args []string) error {
 
 	tool := strings.ToLower(args[0])
 	if _, ok := tools[tool]; !ok {
-		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 	}
 
 	if len(args) == 1 {
@@ -77,6 +80,13 @@ func runRefresh(cmd *cobra.Command, args []string) error {
 	return refreshSingle(ctx, tool, profile, threshold, dryRun, force, quiet)
 }
 
+// refreshIsBulk reports whether a refresh run covers every profile and so
+// is tracked as a job.
+func refreshIsBulk(cmd *cobra.Command, args []string) bool {
+	all, _ := cmd.Flags().GetBool("all")
+	return all
+}
+
 func refreshAll(ctx context.Context, threshold time.Duration, dryRun, force, quiet bool) error {
 	toolsToCheck := []string{"codex", "claude", "gemini"}
 
@@ -186,6 +196,7 @@ func refreshTool(ctx context.Context, tool string, threshold time.Duration, dryR
 			if !quiet {
 				fmt.Printf("failed (%v)\n", err)
 			}
+			quarantineRevoked(err, tool, profile, quiet)
 			continue
 		}
 
@@ -245,6 +256,7 @@ func refreshSingle(ctx context.Context, tool, profile string, threshold time.Dur
 		if !quiet {
 			fmt.Printf("failed (%v)\n", err)
 		}
+		quarantineRevoked(err, tool, profile, quiet)
 		return err
 	}
 
@@ -260,9 +272,24 @@ func refreshSingle(ctx context.Context, tool, profile string, threshold time.Dur
 	return nil
 }
 
+// quarantineRevoked takes a profile out of rotation when its refresh failed
+// because the token was revoked, so it isn't retried until the next login.
+func quarantineRevoked(err error, tool, profile string, quiet bool) {
+	var revoked *refresh.RevokedError
+	if !errors.As(err, &revoked) {
+		return
+	}
+	if _, qErr := quarantineProfile(tool, profile, revoked.Reason, "refresh"); qErr != nil {
+		return
+	}
+	if !quiet {
+		fmt.Printf("    %s/%s quarantined; log in again, then run: caam backup %s %s\n", tool, profile, tool, profile)
+	}
+}
+
 func shouldRefreshProfile(tool, profile string, threshold time.Duration, force bool) (bool, string, error) {
 	if _, ok := tools[tool]; !ok {
-		return false, "", fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+		return false, "", caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 	}
 
 	// Ensure profile exists.
@@ -340,7 +367,7 @@ func ensureVaultProfileDir(tool, profile string) error {
 	st, err := os.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("profile %s/%s not found in vault; run 'caam ls %s' to see available profiles", tool, profile, tool)
+			return caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s not found in vault; run 'caam ls %s' to see available profiles", tool, profile, tool)
 		}
 		return fmt.Errorf("stat profile: %w", err)
 	}
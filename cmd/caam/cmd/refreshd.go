@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/deploy"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/refresh"
+)
+
+var refreshdCmd = &cobra.Command{
+	Use:   "refreshd",
+	Short: "Keep vault tokens fresh in the background",
+	Long: `Runs in the foreground and, every --interval, refreshes each vault
+profile whose token expires within --window using its stored refresh token.
+Refreshed auth is written back to the vault, and every refresh and failure
+is logged to the activity log. Revoked tokens are quarantined.
+
+Unlike 'caam daemon' it does nothing else, never forks, and logs to stdout,
+so it suits a systemd user service or a launchd agent. --unit prints one:
+
+  caam refreshd --unit systemd > ~/.config/systemd/user/caam-refreshd.service
+  systemctl --user enable --now caam-refreshd
+
+  caam refreshd --unit launchd > ~/Library/LaunchAgents/com.caam.refreshd.plist
+  launchctl load ~/Library/LaunchAgents/com.caam.refreshd.plist
+
+--once runs a single pass and exits, for cron or systemd timers.`,
+	Args: cobra.NoArgs,
+	RunE: runRefreshd,
+}
+
+func init() {
+	rootCmd.AddCommand(refreshdCmd)
+	refreshdCmd.Flags().Duration("interval", 15*time.Minute, "time between passes")
+	refreshdCmd.Flags().Duration("window", 0, "refresh tokens expiring within this window (default: health.refresh_threshold)")
+	refreshdCmd.Flags().Bool("once", false, "run one pass and exit")
+	refreshdCmd.Flags().String("unit", "", "print a service definition and exit: systemd, launchd")
+}
+
+// refreshdOutcome is the result of one profile in a refreshd pass.
+type refreshdOutcome struct {
+	Provider string
+	Profile  string
+	// Action is "refreshed", "skipped", "unsupported", "revoked", or "failed".
+	Action string
+	Reason string
+}
+
+func runRefreshd(cmd *cobra.Command, args []string) error {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	window, _ := cmd.Flags().GetDuration("window")
+	once, _ := cmd.Flags().GetBool("once")
+	unit, _ := cmd.Flags().GetString("unit")
+
+	if unit != "" {
+		return printRefreshdUnit(cmd.OutOrStdout(), unit, interval, window)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	var disabled map[string]bool
+	if spmCfg, err := config.LoadSPMConfig(); err == nil {
+		disabled = spmCfg.DisabledProviders(config.AutomationRefresh)
+		if window <= 0 {
+			window = spmCfg.Health.RefreshThreshold.Duration()
+		}
+	}
+	if window <= 0 {
+		window = refresh.DefaultRefreshThreshold
+	}
+
+	logger := log.New(cmd.OutOrStdout(), "[caam-refreshd] ", log.LstdFlags)
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if !once {
+		logger.Printf("Refreshing tokens expiring within %s every %s", window, interval)
+	}
+	for {
+		outcomes := refreshdPass(ctx, window, disabled)
+		logRefreshdOutcomes(logger, outcomes)
+		if once {
+			for _, o := range outcomes {
+				if o.Action == "failed" {
+					return fmt.Errorf("one or more refreshes failed")
+				}
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Println("Stopping")
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// refreshdPass refreshes every profile expiring within window and records
+// the results in the activity log.
+func refreshdPass(ctx context.Context, window time.Duration, disabled map[string]bool) []refreshdOutcome {
+	db, dbErr := getDB()
+
+	var outcomes []refreshdOutcome
+	for _, tool := range toolNames() {
+		if disabled[tool] {
+			continue
+		}
+		profiles, err := vault.List(tool)
+		if err != nil {
+			continue
+		}
+		sort.Strings(profiles)
+
+		for _, profile := range profiles {
+			if ctx.Err() != nil {
+				return outcomes
+			}
+			o := refreshdOutcome{Provider: tool, Profile: profile}
+
+			if dbErr == nil {
+				if rev, err := db.ActiveRevocation(tool, profile); err == nil && rev != nil {
+					o.Action, o.Reason = "skipped", "token revoked, awaiting re-login"
+					outcomes = append(outcomes, o)
+					continue
+				}
+			}
+
+			should, reason, err := shouldRefreshProfile(tool, profile, window, false)
+			switch {
+			case err != nil:
+				o.Action, o.Reason = "failed", err.Error()
+			case !should:
+				o.Action, o.Reason = "skipped", reason
+			default:
+				o.Action, o.Reason = refreshdRefresh(ctx, tool, profile, reason)
+			}
+			outcomes = append(outcomes, o)
+
+			if dbErr == nil && o.Action != "skipped" && o.Action != "unsupported" {
+				_ = db.LogEvent(caamdb.Event{
+					Type:        caamdb.EventRefresh,
+					Provider:    tool,
+					ProfileName: profile,
+					Details: map[string]any{
+						"result": o.Action,
+						"reason": o.Reason,
+						"source": "refreshd",
+					},
+				})
+			}
+		}
+	}
+	return outcomes
+}
+
+// refreshdRefresh refreshes one profile, quarantining it if the provider
+// reports the token revoked.
+func refreshdRefresh(ctx context.Context, tool, profile, reason string) (action, detail string) {
+	refreshCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	err := refresh.RefreshProfile(refreshCtx, tool, profile, vault, healthStore)
+	if err == nil {
+		if ttl := refreshedTTL(tool, profile); ttl != "" {
+			return "refreshed", ttl
+		}
+		return "refreshed", reason
+	}
+
+	var revoked *refresh.RevokedError
+	switch {
+	case errors.Is(err, refresh.ErrUnsupported):
+		return "unsupported", err.Error()
+	case errors.As(err, &revoked):
+		if _, qErr := quarantineProfile(tool, profile, revoked.Reason, "refreshd"); qErr != nil {
+			return "revoked", revoked.Reason + " (quarantine failed: " + qErr.Error() + ")"
+		}
+		return "revoked", revoked.Reason
+	default:
+		return "failed", err.Error()
+	}
+}
+
+func logRefreshdOutcomes(logger *log.Logger, outcomes []refreshdOutcome) {
+	var refreshed, failed int
+	for _, o := range outcomes {
+		switch o.Action {
+		case "refreshed":
+			refreshed++
+			logger.Printf("%s/%s: refreshed (%s)", o.Provider, o.Profile, o.Reason)
+		case "failed":
+			failed++
+			logger.Printf("%s/%s: refresh failed: %s", o.Provider, o.Profile, o.Reason)
+		case "revoked":
+			failed++
+			logger.Printf("%s/%s: token revoked (%s); quarantined until re-login (caam backup %s %s)", o.Provider, o.Profile, o.Reason, o.Provider, o.Profile)
+		}
+	}
+	logger.Printf("Checked %d profiles: %d refreshed, %d failed", len(outcomes), refreshed, failed)
+}
+
+const refreshdLaunchdPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>Label</key>
+  <string>com.caam.refreshd</string>
+  <key>ProgramArguments</key>
+  <array>
+%s  </array>
+  <key>RunAtLoad</key>
+  <true/>
+  <key>KeepAlive</key>
+  <true/>
+  <key>StandardOutPath</key>
+  <string>/tmp/caam-refreshd.log</string>
+  <key>StandardErrorPath</key>
+  <string>/tmp/caam-refreshd.log</string>
+</dict>
+</plist>
+`
+
+// printRefreshdUnit writes a systemd user unit or launchd agent that runs
+// refreshd with the given settings.
+func printRefreshdUnit(w io.Writer, kind string, interval, window time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "caam"
+	}
+	argv := []string{exe, "refreshd", "--interval", interval.String()}
+	if window > 0 {
+		argv = append(argv, "--window", window.String())
+	}
+
+	switch strings.ToLower(kind) {
+	case "systemd":
+		unit, err := deploy.GenerateSystemdUnit(deploy.SystemdUnitConfig{
+			Type:      "Token Refresh",
+			ExecStart: strings.Join(argv, " "),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, unit)
+		return err
+	case "launchd":
+		var args strings.Builder
+		for _, a := range argv {
+			fmt.Fprintf(&args, "    <string>%s</string>\n", a)
+		}
+		_, err := fmt.Fprintf(w, refreshdLaunchdPlist, args.String())
+		return err
+	default:
+		return fmt.Errorf("unknown --unit %q (expected systemd or launchd)", kind)
+	}
+}
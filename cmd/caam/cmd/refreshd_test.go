@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/refresh"
+)
+
+func TestRefreshdPass_RefreshesExpiringAndLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", tmpDir)
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	writeAuth := func(profile string, expiresIn time.Duration) string {
+		dir := vault.ProfilePath("codex", profile)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		raw, _ := json.Marshal(map[string]any{
+			"access_token":  "old-access",
+			"refresh_token": "old-refresh",
+			"expires_at":    time.Now().Add(expiresIn).Unix(),
+		})
+		path := filepath.Join(dir, "auth.json")
+		if err := os.WriteFile(path, raw, 0600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		return path
+	}
+	expiringPath := writeAuth("expiring", 2*time.Minute)
+	writeAuth("fresh", 5*time.Hour)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`))
+	}))
+	defer ts.Close()
+	oldTokenURL := refresh.CodexTokenURL
+	refresh.CodexTokenURL = ts.URL
+	t.Cleanup(func() { refresh.CodexTokenURL = oldTokenURL })
+
+	outcomes := refreshdPass(context.Background(), time.Hour, map[string]bool{"claude": true, "gemini": true})
+
+	got := map[string]string{}
+	for _, o := range outcomes {
+		got[o.Provider+"/"+o.Profile] = o.Action
+	}
+	if got["codex/expiring"] != "refreshed" || got["codex/fresh"] != "skipped" {
+		t.Fatalf("outcomes = %+v", outcomes)
+	}
+
+	raw, err := os.ReadFile(expiringPath)
+	if err != nil || !strings.Contains(string(raw), "new-access") {
+		t.Errorf("vault auth not updated: %s (%v)", raw, err)
+	}
+
+	db, err := getDB()
+	if err != nil {
+		t.Fatalf("getDB() error = %v", err)
+	}
+	events, err := db.GetEvents("codex", "expiring", time.Time{}, 10)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Type != caamdb.EventRefresh {
+		t.Errorf("events = %+v, want one refresh event for codex/expiring", events)
+	}
+}
+
+func TestPrintRefreshdUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printRefreshdUnit(&buf, "systemd", 10*time.Minute, time.Hour); err != nil {
+		t.Fatalf("printRefreshdUnit(systemd) error = %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "refreshd --interval 10m0s --window 1h0m0s") || !strings.Contains(out, "[Service]") {
+		t.Errorf("systemd unit = %s", out)
+	}
+
+	buf.Reset()
+	if err := printRefreshdUnit(&buf, "launchd", 10*time.Minute, 0); err != nil {
+		t.Fatalf("printRefreshdUnit(launchd) error = %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "<string>refreshd</string>") || strings.Contains(out, "--window") {
+		t.Errorf("launchd plist = %s", out)
+	}
+
+	if err := printRefreshdUnit(&buf, "upstart", time.Minute, 0); err == nil {
+		t.Error("expected error for unknown unit type")
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
 )
 
 var renameCmd = &cobra.Command{
@@ -50,7 +52,7 @@ func runRename(cmd *cobra.Command, args []string) error {
 
 	// Validate tool
 	if _, ok := tools[tool]; !ok {
-		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 	}
 
 	// Initialize vault if needed
@@ -61,7 +63,7 @@ func runRename(cmd *cobra.Command, args []string) error {
 	// Validate old profile exists
 	profiles, err := vault.List(tool)
 	if err != nil {
-		return fmt.Errorf("list profiles: %w", err)
+		return caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
 	}
 	oldExists := false
 	newExists := false
@@ -93,6 +95,16 @@ func runRename(cmd *cobra.Command, args []string) error {
 		"deleted":  false,
 	}
 
+	// The copy holds the same account, so it keeps the same risk tier.
+	if cfg, err := config.Load(); err == nil {
+		if tier := cfg.GetRiskTier(tool, oldName); tier != risk.Normal {
+			cfg.SetRiskTier(tool, newName, tier)
+			if err := cfg.Save(); err != nil && !jsonOutput {
+				fmt.Printf("Warning: failed to copy risk tier: %v\n", err)
+			}
+		}
+	}
+
 	// Migrate aliases if requested
 	if migrateAliases {
 		cfg, err := config.Load()
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/exec"
 	"github.com/spf13/cobra"
 )
@@ -32,7 +33,7 @@ Examples:
 
 		prov, ok := registry.Get(tool)
 		if !ok {
-			return fmt.Errorf("unknown provider: %s", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", tool)
 		}
 
 		prof, err := profileStore.Load(tool, name)
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
+)
+
+var riskCmd = &cobra.Command{
+	Use:   "risk [tool] [profile] [tier]",
+	Short: "Classify profiles by risk tier",
+	Long: `Mark profiles as high-value or expendable so automation knows how careful
+to be with them.
+
+Tiers:
+  high        Primary/personal account. Never chosen by automatic rotation
+              (caam run, --precheck) and never used for unattended login
+              injection by the auth agent.
+  normal      Default for unclassified profiles.
+  expendable  Disposable pool account. Preferred for unattended agent work.
+
+"primary" and "personal" are accepted for high; "pool" and "disposable" for
+expendable. Setting a profile back to normal removes its entry.
+
+The tier is shown in 'caam status' and in robot output.
+
+Examples:
+  caam risk                              # List classified profiles
+  caam risk claude                       # Show tiers for all Claude profiles
+  caam risk claude personal high         # Protect a primary account
+  caam risk claude pool-1 expendable     # Mark a pool account as disposable
+  caam risk claude pool-1 normal         # Clear the classification`,
+	Args: cobra.MaximumNArgs(3),
+	RunE: runRisk,
+}
+
+func init() {
+	rootCmd.AddCommand(riskCmd)
+	riskCmd.Flags().Bool("json", false, "output in JSON format")
+}
+
+// riskEntry is the JSON form of one profile's risk tier.
+type riskEntry struct {
+	Tool    string    `json:"tool"`
+	Profile string    `json:"profile"`
+	Tier    risk.Tier `json:"tier"`
+}
+
+func runRisk(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := config.Load()
+	if err != nil {
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
+	}
+
+	var tool string
+	if len(args) > 0 {
+		tool = strings.ToLower(args[0])
+		if _, ok := tools[tool]; !ok {
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
+		}
+	}
+
+	switch len(args) {
+	case 0:
+		return printRiskEntries(cmd, classifiedRiskEntries(cfg), jsonOutput)
+	case 1:
+		profiles, err := vault.List(tool)
+		if err != nil {
+			return caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
+		}
+		entries := make([]riskEntry, 0, len(profiles))
+		for _, p := range profiles {
+			if strings.HasPrefix(p, "_") {
+				continue
+			}
+			entries = append(entries, riskEntry{Tool: tool, Profile: p, Tier: cfg.GetRiskTier(tool, p)})
+		}
+		return printRiskEntries(cmd, entries, jsonOutput)
+	case 2:
+		profile := args[1]
+		return printRiskEntries(cmd, []riskEntry{{Tool: tool, Profile: profile, Tier: cfg.GetRiskTier(tool, profile)}}, jsonOutput)
+	}
+
+	profile := args[1]
+	tier, err := risk.Parse(args[2])
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(vault.ProfilePath(tool, profile)); err != nil {
+		return caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s not found; run 'caam ls %s' to see available profiles", tool, profile, tool)
+	}
+
+	cfg.SetRiskTier(tool, profile, tier)
+	if err := cfg.Save(); err != nil {
+		return caamerr.Errorf(caamerr.ConfigError, "save config: %w", err)
+	}
+
+	if jsonOutput {
+		return printRiskEntries(cmd, []riskEntry{{Tool: tool, Profile: profile, Tier: tier}}, true)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Set risk tier for %s/%s: %s\n", tool, profile, tier)
+	return nil
+}
+
+// classifiedRiskEntries returns every profile with a non-default tier,
+// sorted by tool and profile.
+func classifiedRiskEntries(cfg *config.Config) []riskEntry {
+	entries := make([]riskEntry, 0, len(cfg.RiskTiers))
+	for key, tier := range cfg.RiskTiers {
+		tool, profile, ok := strings.Cut(key, "/")
+		if !ok {
+			continue
+		}
+		entries = append(entries, riskEntry{Tool: tool, Profile: profile, Tier: tier})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Tool != entries[j].Tool {
+			return entries[i].Tool < entries[j].Tool
+		}
+		return entries[i].Profile < entries[j].Profile
+	})
+	return entries
+}
+
+func printRiskEntries(cmd *cobra.Command, entries []riskEntry, jsonOutput bool) error {
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "No profiles classified; all profiles are normal risk.")
+		return nil
+	}
+	fmt.Fprintf(out, "%-10s  %-24s  %s\n", "TOOL", "PROFILE", "RISK")
+	for _, e := range entries {
+		fmt.Fprintf(out, "%-10s  %-24s  %s\n", e.Tool, e.Profile, e.Tier)
+	}
+	return nil
+}
+
+// riskTierCache holds the configured risk tiers so status and robot output
+// read config.json once, while still noticing edits made since.
+var riskTierCache struct {
+	sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	cfg     *config.Config
+}
+
+// loadRiskConfig returns the global config for risk lookups. Load errors
+// yield an empty config, so every profile is treated as normal risk.
+func loadRiskConfig() *config.Config {
+	path := config.ConfigPath()
+	var modTime time.Time
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		modTime, size = info.ModTime(), info.Size()
+	}
+
+	riskTierCache.Lock()
+	defer riskTierCache.Unlock()
+	if riskTierCache.cfg == nil || riskTierCache.path != path || !riskTierCache.modTime.Equal(modTime) || riskTierCache.size != size {
+		cfg, err := config.Load()
+		if err != nil {
+			cfg = config.DefaultConfig()
+		}
+		riskTierCache.path = path
+		riskTierCache.modTime = modTime
+		riskTierCache.size = size
+		riskTierCache.cfg = cfg
+	}
+	return riskTierCache.cfg
+}
+
+// profileRiskTier returns the configured risk tier for a profile.
+func profileRiskTier(tool, profile string) risk.Tier {
+	return loadRiskConfig().GetRiskTier(tool, profile)
+}
+
+// excludeHighRiskAccounts drops accounts belonging to high-risk profiles from
+// the auth agent's rotation list. If every listed account is high-risk it
+// returns an error rather than an empty list, since an empty list makes the
+// agent fall back to whatever account the browser is signed into.
+func excludeHighRiskAccounts(accounts []string, logger *slog.Logger) ([]string, error) {
+	if len(accounts) == 0 {
+		return accounts, nil
+	}
+	highRisk := highRiskAccountEmails()
+	if len(highRisk) == 0 {
+		return accounts, nil
+	}
+
+	kept := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		if profile, ok := highRisk[strings.ToLower(strings.TrimSpace(account))]; ok {
+			logger.Warn("skipping high-risk account for unattended login", "account", account, "profile", profile)
+			continue
+		}
+		kept = append(kept, account)
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("all configured accounts belong to high-risk profiles; unattended login is disabled for them (see 'caam risk')")
+	}
+	return kept, nil
+}
+
+// highRiskAccountEmails returns the emails of high-risk profiles, so the
+// auth agent can refuse to use them for unattended logins.
+func highRiskAccountEmails() map[string]string {
+	emails := make(map[string]string)
+	for _, e := range classifiedRiskEntries(loadRiskConfig()) {
+		if e.Tier.AllowsUnattended() {
+			continue
+		}
+		id := getVaultIdentity(e.Tool, e.Profile)
+		if id == nil || strings.TrimSpace(id.Email) == "" {
+			continue
+		}
+		emails[strings.ToLower(id.Email)] = e.Tool + "/" + e.Profile
+	}
+	return emails
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
+)
+
+func TestRiskCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	vaultPath := filepath.Join(tmpDir, "vault")
+	for _, name := range []string{"personal", "pool-1"} {
+		dir := filepath.Join(vaultPath, "claude", name)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".claude.json"), []byte(`{}`), 0600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	oldVault := vault
+	vault = authfile.NewVault(vaultPath)
+	defer func() { vault = oldVault }()
+
+	run := func(jsonOutput bool, args ...string) (string, error) {
+		var buf bytes.Buffer
+		riskCmd.SetOut(&buf)
+		defer riskCmd.SetOut(nil)
+		if err := riskCmd.Flags().Set("json", strconv.FormatBool(jsonOutput)); err != nil {
+			t.Fatalf("set json flag: %v", err)
+		}
+		err := runRisk(riskCmd, args)
+		return buf.String(), err
+	}
+
+	if _, err := run(false, "claude", "personal", "primary"); err != nil {
+		t.Fatalf("set high: %v", err)
+	}
+	if _, err := run(false, "claude", "pool-1", "pool"); err != nil {
+		t.Fatalf("set expendable: %v", err)
+	}
+	if _, err := run(false, "claude", "missing", "high"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+	if _, err := run(false, "claude", "personal", "medium"); err == nil {
+		t.Error("expected error for invalid tier")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if got := cfg.GetRiskTier("claude", "personal"); got != risk.High {
+		t.Errorf("personal tier = %q, want high", got)
+	}
+	if got := profileRiskTier("claude", "pool-1"); got != risk.Expendable {
+		t.Errorf("profileRiskTier(pool-1) = %q, want expendable", got)
+	}
+
+	out, err := run(true)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var entries []riskEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("decode list: %v\n%s", err, out)
+	}
+	if len(entries) != 2 || entries[0].Profile != "personal" || entries[1].Tier != risk.Expendable {
+		t.Errorf("list = %+v", entries)
+	}
+
+	// Back to normal clears the entry and the cached lookup notices.
+	if _, err := run(false, "claude", "personal", "normal"); err != nil {
+		t.Fatalf("set normal: %v", err)
+	}
+	out, err = run(false, "claude")
+	if err != nil {
+		t.Fatalf("show tool: %v", err)
+	}
+	if !strings.Contains(out, "personal") || !strings.Contains(out, "normal") {
+		t.Errorf("tool listing missing reset profile:\n%s", out)
+	}
+	if got := profileRiskTier("claude", "personal"); got != risk.Normal {
+		t.Errorf("profileRiskTier(personal) after reset = %q, want normal", got)
+	}
+}
@@ -2,19 +2,41 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/anomaly"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/coordinator"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/daemon"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/federation"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/hooks"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/namespace"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/refresh"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/statusengine"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/tracing"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/version"
 	"github.com/spf13/cobra"
 )
@@ -30,13 +52,15 @@ import (
 // - JSON output by default (no --json flag needed)
 // - Structured errors with error_code field
 // - Actionable suggestions in output
-// - Exit codes: 0=success, 1=error, 2=partial success
+// - Exit codes: 0=success, else the error code's exit status from the
+//   internal/caamerr registry (1=unexpected, 2=partial success, ...)
 // - Compact but complete information
 
 // RobotOutput is the standard response wrapper for all robot commands.
 type RobotOutput struct {
 	Success     bool        `json:"success"`
 	Command     string      `json:"command"`
+	Namespace   string      `json:"namespace,omitempty"`
 	Timestamp   string      `json:"timestamp"`
 	Data        interface{} `json:"data,omitempty"`
 	Error       *RobotError `json:"error,omitempty"`
@@ -55,6 +79,18 @@ type RobotError struct {
 type RobotTiming struct {
 	StartedAt  string `json:"started_at"`
 	DurationMs int64  `json:"duration_ms"`
+
+	// Providers is how long each provider's profiles took to scan, for
+	// commands that scan them concurrently.
+	Providers []RobotProviderTiming `json:"providers,omitempty"`
+}
+
+// RobotProviderTiming is how long one provider took to scan.
+type RobotProviderTiming struct {
+	Provider   string `json:"provider"`
+	DurationMs int64  `json:"duration_ms"`
+	Profiles   int    `json:"profiles"`
+	TimedOut   bool   `json:"timed_out,omitempty"`
 }
 
 // RobotStatusData contains the full status overview.
@@ -87,6 +123,10 @@ type RobotProviderInfo struct {
 	ActiveProfile string               `json:"active_profile,omitempty"`
 	Profiles      []RobotProfileInfo   `json:"profiles"`
 	AuthPaths     []RobotAuthPath      `json:"auth_paths"`
+
+	// Error says why the profile list is incomplete, such as a scan
+	// that ran past --timeout.
+	Error string `json:"error,omitempty"`
 }
 
 // RobotProfileInfo contains profile details optimized for agents.
@@ -96,9 +136,61 @@ type RobotProfileInfo struct {
 	System         bool              `json:"system"`
 	Email          string            `json:"email,omitempty"`
 	PlanType       string            `json:"plan_type,omitempty"`
+	RiskTier       string            `json:"risk_tier,omitempty"`
+	Workspaces     []string          `json:"workspaces,omitempty"`
+	GCPProject     string            `json:"gcp_project,omitempty"`
+	GCPQuota       string            `json:"gcp_quota_project,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	HoursThisWeek  float64           `json:"hours_this_week,omitempty"`
+	Spend          *RobotSpend       `json:"spend,omitempty"`
 	Health         RobotHealthInfo   `json:"health"`
 	Cooldown       *RobotCooldown    `json:"cooldown,omitempty"`
+	Revoked        *RobotRevoked     `json:"revoked,omitempty"`
+	Suspect        *RobotSuspect     `json:"suspect,omitempty"`
+	Lease          *RobotLease       `json:"lease,omitempty"`
 	Recommendation string            `json:"recommendation,omitempty"`
+	HumanAction    *RobotHumanAction `json:"human_action,omitempty"`
+}
+
+// RobotRevoked describes a profile quarantined because its token was revoked.
+type RobotRevoked struct {
+	DetectedAt string `json:"detected_at"`
+	Reason     string `json:"reason,omitempty"`
+	Source     string `json:"source,omitempty"`
+}
+
+// RobotSuspect marks a profile whose history suggests the provider has
+// flagged or banned the account, with the patterns found.
+type RobotSuspect struct {
+	Evidence []anomaly.Evidence `json:"evidence"`
+}
+
+// RobotSpend is a profile's priced usage this month and the budget that
+// covers it.
+type RobotSpend struct {
+	MonthToDateUSD    float64 `json:"month_to_date_usd"`
+	ProjectedMonthUSD float64 `json:"projected_month_usd"`
+	Budget            string  `json:"budget,omitempty"`
+	BudgetUSD         float64 `json:"budget_usd,omitempty"`
+	BudgetSpentUSD    float64 `json:"budget_spent_usd,omitempty"`
+	BudgetUsedPercent float64 `json:"budget_used_percent,omitempty"`
+}
+
+// RobotLease is a lease someone else holds on a profile.
+type RobotLease struct {
+	Holder    string `json:"holder"`
+	Machine   string `json:"machine,omitempty"`
+	ExpiresAt string `json:"expires_at"`
+	Source    string `json:"source"`
+}
+
+// RobotHumanAction tells a human operator exactly how to fix a profile that
+// an agent cannot fix on its own (e.g., an expired login).
+type RobotHumanAction struct {
+	Summary         string   `json:"summary"`
+	Commands        []string `json:"commands"`
+	ExpectedPrompts []string `json:"expected_prompts,omitempty"`
+	LoginURL        string   `json:"login_url,omitempty"`
 }
 
 // RobotHealthInfo contains health status.
@@ -108,6 +200,8 @@ type RobotHealthInfo struct {
 	ExpiresAt    string `json:"expires_at,omitempty"`
 	ExpiresIn    string `json:"expires_in,omitempty"` // human-readable
 	ErrorCount1h int    `json:"error_count_1h"`
+	StaleVsPool  bool   `json:"stale_vs_pool,omitempty"`
+	LastPoolSync string `json:"last_pool_sync,omitempty"`
 }
 
 // RobotCooldown contains cooldown information.
@@ -117,6 +211,18 @@ type RobotCooldown struct {
 	RemainingMs  int64  `json:"remaining_ms,omitempty"`
 	RemainingStr string `json:"remaining_str,omitempty"`
 	Reason       string `json:"reason,omitempty"`
+
+	// PendingProbe is set when the cooldown has ended but the daemon has
+	// not yet probed the profile to confirm the limit really reset.
+	PendingProbe bool `json:"pending_probe,omitempty"`
+
+	// Template is the stealth.cooldown template for the profile's plan, and
+	// WindowResetsAt when it says the limit hit clears.
+	Template       string `json:"template,omitempty"`
+	WindowResetsAt string `json:"window_resets_at,omitempty"`
+
+	// window is the template's window for this hit, for scoring.
+	window time.Duration
 }
 
 // RobotAuthPath shows auth file locations.
@@ -135,6 +241,16 @@ type RobotCoordinator struct {
 	Latency  int64  `json:"latency_ms,omitempty"`
 	Error    string `json:"error,omitempty"`
 	Pending  int    `json:"pending_auth_requests"`
+
+	// Set for coordinators on other machines, read from the daemon's
+	// federation snapshot rather than queried directly.
+	Machine   string     `json:"machine,omitempty"`
+	Transport string     `json:"transport,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	Stale     bool       `json:"stale,omitempty"`
+
+	Panes    []coordinator.PaneStatusResponse `json:"panes,omitempty"`
+	Requests []*coordinator.AuthRequest       `json:"requests,omitempty"`
 }
 
 // RobotNextData contains recommended next action.
@@ -142,6 +258,7 @@ type RobotNextData struct {
 	Provider        string            `json:"provider"`
 	Profile         string            `json:"profile"`
 	Score           float64           `json:"score"`
+	Strategy        string            `json:"strategy"`
 	Reasons         []string          `json:"reasons"`
 	Command         string            `json:"command"`
 	AlternateChoice *RobotNextProfile `json:"alternate,omitempty"`
@@ -155,6 +272,35 @@ type RobotNextProfile struct {
 	Reason   string  `json:"reason"`
 }
 
+// RobotNextAllData is the cross-provider robot next result.
+type RobotNextAllData struct {
+	Strategy    string                  `json:"strategy"`
+	Recommended RobotNextRanked         `json:"recommended"`
+	Ranked      []RobotNextRanked       `json:"ranked"`
+	Providers   []RobotNextProviderPick `json:"providers"`
+}
+
+// RobotNextRanked is a profile in the cross-provider ranking.
+type RobotNextRanked struct {
+	Rank     int     `json:"rank"`
+	Provider string  `json:"provider"`
+	Profile  string  `json:"profile"`
+	Score    float64 `json:"score"`
+	// NormalizedScore is Score scaled to 0-100 against robotNextMaxScore.
+	NormalizedScore float64  `json:"normalized_score"`
+	Reasons         []string `json:"reasons"`
+	Command         string   `json:"command"`
+}
+
+// RobotNextProviderPick is one provider's best profile and alternates.
+type RobotNextProviderPick struct {
+	Provider   string            `json:"provider"`
+	Best       *RobotNextRanked  `json:"best,omitempty"`
+	Alternates []RobotNextRanked `json:"alternates"`
+	// Blocked explains why a provider has no candidate.
+	Blocked string `json:"blocked,omitempty"`
+}
+
 // RobotActResult is the result of an action.
 type RobotActResult struct {
 	Action      string `json:"action"`
@@ -163,6 +309,20 @@ type RobotActResult struct {
 	OldProfile  string `json:"old_profile,omitempty"`
 	Success     bool   `json:"success"`
 	Message     string `json:"message"`
+
+	// ResetSource is where a cooldown's end came from when caam worked it
+	// out: reset_text, retry_after, usage_window, template, or
+	// window_fallback.
+	ResetSource string `json:"reset_source,omitempty"`
+
+	// AutoBackup is the vault profile activate saved the live auth to
+	// before switching.
+	AutoBackup string `json:"auto_backup,omitempty"`
+
+	// DryRun is set when activate --dry-run only previewed the switch.
+	// Changes lists what it would do to each live auth file.
+	DryRun  bool                     `json:"dry_run,omitempty"`
+	Changes []authfile.RestoreChange `json:"changes,omitempty"`
 }
 
 var robotCmd = &cobra.Command{
@@ -191,13 +351,15 @@ Includes:
 - Actionable suggestions
 
 Use --provider to filter to a specific provider.
+Use --tag key=value (or a bare key) to keep only profiles with matching tags
+set by 'caam profile tag'; repeat it to require several.
 Use --compact for minimal output (IDs and status only).`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runRobotStatus,
 }
 
 var robotNextCmd = &cobra.Command{
-	Use:   "next <provider>",
+	Use:   "next [provider]",
 	Short: "Suggest best profile to use",
 	Long: `Analyzes all profiles for a provider and suggests the best one to use.
 
@@ -206,10 +368,43 @@ Scoring factors:
 - Cooldown status (not in cooldown preferred)
 - Token expiry (longer expiry preferred)
 - Recent error count (fewer errors preferred)
-- Last used time (LRU by default)
 
-Returns the recommended profile with activation command.`,
-	Args: cobra.ExactArgs(1),
+Use 'caam robot explain <provider> <profile>' for one profile's full
+breakdown.
+
+--strategy then adjusts the scores, and says how in each profile's reasons:
+  smart             Slightly favor switching away from the active profile [default]
+  lru               Favor profiles activated longest ago
+  round-robin       Take profiles in name order after the last pick (the
+                    cursor is stored in the database)
+  weighted          Multiply by profile_weights or plan_weights in config.json
+                    (e.g. {"plan_weights": {"max": 2, "pro": 1}})
+  least-used-today  Favor profiles with the fewest metered tokens today
+                    (see 'caam usage record')
+  sticky            Keep the active profile unless it is in cooldown or critical
+  random            Add random jitter
+
+Use --workspace to consider only profiles whose credentials are authorized
+for a workspace (matched by ID or name). For Codex these are the ChatGPT
+workspace chosen at login and any API organizations in the token, so an agent
+never activates an account that then fails Codex's workspace check mid-run.
+
+Use --tag key=value (or a bare key, repeatable) to consider only profiles
+tagged with 'caam profile tag', e.g. --tag client=acme.
+
+Profiles marked suspect in robot status (their probes, rate limits or
+revocations look like a flagged account) are skipped unless
+--include-suspect is given.
+
+Returns the recommended profile with activation command.
+
+With --all-providers (or no provider), profiles of every provider are scored
+and ranked together. Scores are normalized to 0-100 against the best score
+any profile can reach, so they compare across providers. data.recommended is
+the overall pick, data.ranked the full order, and data.providers the best
+profile and alternates for each provider, for orchestrators that can run any
+of the agents.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runRobotNext,
 }
 
@@ -220,13 +415,55 @@ var robotActCmd = &cobra.Command{
 
 Supported actions:
   activate <provider> <profile>  - Activate a profile
-  cooldown <provider> <profile> [duration]  - Start cooldown
+  cooldown <provider> <profile> [duration|auto]  - Start cooldown
   uncooldown <provider> <profile>  - Clear cooldown
-  refresh <provider> <profile>  - Refresh token
   backup <provider> <profile>   - Backup current auth
-
-All actions return structured results with success/failure status.`,
-	Args: cobra.MinimumNArgs(2),
+  note <provider> <profile> <text>  - Record a note in the activity log
+
+All actions return structured results with success/failure status.
+
+With --auto (or a duration of "auto"), cooldown lasts until the profile's
+limit actually resets. The reset time comes from the latest rate limit
+message the coordinator saw ("resets 3pm", "try again in 2 hours"), else
+the provider's usage API (Retry-After or the exhausted window's reset),
+else the provider's window: five hours for Claude and Codex, midnight
+Pacific for Gemini.
+
+When rotation shaping is on (stealth.shaping), activate fails with
+SHAPING_BLOCKED if the target is outside its active hours or at its daily
+activation cap, or the active profile's minimum dwell has not passed.
+--ignore-shaping activates anyway.
+
+Activate replaces all of a provider's auth files together: each is staged
+beside the live file, then renamed into place, and if any fails the files
+already replaced are restored, so the provider is never half-switched.
+--dry-run runs activate's checks and reports what would happen to each live
+auth file (create, replace, unchanged, keep, or clear_api_key) without
+changing anything.
+
+With --plan, reads a JSON array of actions from a file (or "-" for stdin)
+and runs them in order, reporting a result for each:
+
+  [{"action": "cooldown", "provider": "claude", "profile": "work", "duration": "2h"},
+   {"action": "activate", "provider": "claude", "profile": "backup"},
+   {"action": "note", "provider": "claude", "profile": "work", "note": "hit 429"}]
+
+The whole plan is validated before anything runs. Without --atomic every
+action runs even if an earlier one failed; with --atomic the first failure
+skips the rest and reactivates the profiles that earlier activate actions
+replaced. A plan where some actions failed and others took effect exits with
+code 2.
+
+When the approval workflow is enabled (see 'caam approvals'), actions from
+agents (CAAM_AGENT set, or requests through 'caam serve') may instead fail
+with APPROVAL_REQUIRED and a proposal_id for a human to approve, or with
+APPROVAL_DENIED.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if plan, _ := cmd.Flags().GetString("plan"); plan != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(2)(cmd, args)
+	},
 	RunE: runRobotAct,
 }
 
@@ -258,7 +495,23 @@ Each line is a complete JSON object with the current status.
 Updates are emitted on changes or at the poll interval.
 
 Use --interval to set poll interval (default 5s).
-Use --provider to filter to a specific provider.`,
+Use --provider to filter to a specific provider.
+
+Use --changes-only to diff successive snapshots instead. The first line is
+a "snapshot" event with the full status; after that each line is one typed
+event carrying only the delta:
+
+  profile_health_changed  health status changed (from, to, reason)
+  cooldown_started        a profile entered cooldown (until, reason)
+  cooldown_expired        a profile's cooldown ended
+  active_profile_changed  the active profile changed (from, to)
+  token_expiring          a token expires within 2h (expires_at, expires_in)
+  heartbeat               nothing changed for --heartbeat seconds (default 30)
+
+When the daemon is running, watch attaches to its shared poller instead of
+scanning the vault itself, so any number of watchers cost one scan per
+interval. Falls back to polling locally if the daemon can't be reached.
+Use --no-daemon to always poll locally.`,
 	RunE: runRobotWatch,
 }
 
@@ -266,24 +519,25 @@ Use --provider to filter to a specific provider.`,
 // robotOutput writes a RobotOutput to stdout.
 func robotOutput(cmd *cobra.Command, output RobotOutput) error {
 	output.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	output.Namespace = namespace.Current()
 	enc := json.NewEncoder(cmd.OutOrStdout())
 	return enc.Encode(output)
 }
 
 // robotError creates an error output.
-func robotError(cmd *cobra.Command, command string, code, message string, details string, suggestions []string) error {
+func robotError(cmd *cobra.Command, command string, code caamerr.Code, message string, details string, suggestions []string) error {
 	output := RobotOutput{
 		Success: false,
 		Command: command,
 		Error: &RobotError{
-			Code:    code,
+			Code:    string(code),
 			Message: message,
 			Details: details,
 		},
 		Suggestions: suggestions,
 	}
 	robotOutput(cmd, output)
-	return fmt.Errorf("%s: %s", code, message)
+	return caamerr.Errorf(code, "%s: %s", code, message)
 }
 
 func runRobotStatus(cmd *cobra.Command, args []string) error {
@@ -291,18 +545,19 @@ func runRobotStatus(cmd *cobra.Command, args []string) error {
 	providerFilter, _ := cmd.Flags().GetString("provider")
 	compact, _ := cmd.Flags().GetBool("compact")
 	includeCoords, _ := cmd.Flags().GetBool("include-coordinators")
+	tagFilters, _ := cmd.Flags().GetStringArray("tag")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
 
 	// Determine which providers to check
-	providersToCheck := []string{"codex", "claude", "gemini"}
+	providersToCheck := toolNames()
 	if len(args) > 0 {
 		providerFilter = strings.ToLower(args[0])
 	}
 	if providerFilter != "" {
-		validProviders := map[string]bool{"codex": true, "claude": true, "gemini": true}
-		if !validProviders[providerFilter] {
-			return robotError(cmd, "status", "INVALID_PROVIDER",
+		if _, ok := tools[providerFilter]; !ok {
+			return robotError(cmd, "status", caamerr.InvalidProvider,
 				fmt.Sprintf("unknown provider: %s", providerFilter),
-				"valid providers: codex, claude, gemini",
+				"valid providers: "+strings.Join(toolNames(), ", "),
 				[]string{"caam robot status claude", "caam robot status codex", "caam robot status gemini"})
 		}
 		providersToCheck = []string{providerFilter}
@@ -322,9 +577,25 @@ func runRobotStatus(cmd *cobra.Command, args []string) error {
 
 	var suggestions []string
 
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	st, closeState := openRobotState(ctx, providersToCheck...)
+	defer closeState()
+	provInfos, provTimings := st.providerInfos(ctx, providersToCheck, compact, timeout)
+
 	var usableProfiles int
-	for _, tool := range providersToCheck {
-		provInfo := buildProviderInfo(tool, compact)
+	for _, provInfo := range provInfos {
+		if len(tagFilters) > 0 {
+			matching := make([]RobotProfileInfo, 0, len(provInfo.Profiles))
+			for _, p := range provInfo.Profiles {
+				if caamdb.TagsMatch(p.Tags, tagFilters) {
+					matching = append(matching, p)
+				}
+			}
+			provInfo.Profiles = matching
+		}
 		data.Providers = append(data.Providers, provInfo)
 
 		// Update summary
@@ -363,6 +634,11 @@ func runRobotStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Add suggestions based on status
+	for _, p := range provInfos {
+		if p.Error != "" {
+			suggestions = append(suggestions, fmt.Sprintf("%s: %s. Retry with a longer --timeout.", p.ID, p.Error))
+		}
+	}
 	if data.Summary.ExpiringSoon > 0 {
 		suggestions = append(suggestions, fmt.Sprintf("%d profile(s) expiring within 24h. Consider refreshing tokens.", data.Summary.ExpiringSoon))
 	}
@@ -381,6 +657,7 @@ func runRobotStatus(cmd *cobra.Command, args []string) error {
 		Timing: &RobotTiming{
 			StartedAt:  start.UTC().Format(time.RFC3339),
 			DurationMs: duration.Milliseconds(),
+			Providers:  provTimings,
 		},
 	}
 
@@ -388,6 +665,17 @@ func runRobotStatus(cmd *cobra.Command, args []string) error {
 }
 
 func buildProviderInfo(tool string, compact bool) RobotProviderInfo {
+	st, closeState := openRobotState(context.Background(), tool)
+	defer closeState()
+	return st.providerInfo(context.Background(), tool, compact)
+}
+
+// providerInfo reports on a provider and each of its profiles from the
+// loaded state.
+func (st *robotState) providerInfo(ctx context.Context, tool string, compact bool) RobotProviderInfo {
+	ctx, span := tracing.Start(ctx, "robot.provider_info", tracing.Provider(tool))
+	defer span.End()
+
 	info := RobotProviderInfo{
 		ID:          tool,
 		DisplayName: getProviderDisplayName(tool),
@@ -419,41 +707,79 @@ func buildProviderInfo(tool string, compact bool) RobotProviderInfo {
 		}
 	}
 
-	// List profiles
-	profiles, err := vault.List(tool)
+	// Profiles are scanned concurrently; each reads its own files and rows.
+	profiles := st.Profiles(tool)
+	scanned := make([]*RobotProfileInfo, len(profiles))
+	err := statusengine.ForEach(ctx, len(profiles), robotScanWorkers, func(ctx context.Context, i int) {
+		_, span := tracing.Start(ctx, "health.profile", tracing.Provider(tool), tracing.Profile(profiles[i]))
+		pInfo := st.profileInfo(tool, profiles[i], info.ActiveProfile, compact)
+		span.End()
+		scanned[i] = &pInfo
+	})
+	for _, pInfo := range scanned {
+		if pInfo != nil {
+			info.Profiles = append(info.Profiles, *pInfo)
+		}
+	}
 	if err != nil {
-		return info
+		info.Error = fmt.Sprintf("scan stopped (%v) after %d of %d profiles", err, len(info.Profiles), len(profiles))
 	}
 
-	db, _ := caamdb.Open()
-	defer func() {
-		if db != nil {
-			db.Close()
-		}
-	}()
+	return info
+}
 
-	for _, profileName := range profiles {
-		pInfo := buildProfileInfo(tool, profileName, info.ActiveProfile, db, compact)
-		info.Profiles = append(info.Profiles, pInfo)
+// profilesForWorkspace keeps the profiles whose stored credentials are
+// authorized for workspace.
+func profilesForWorkspace(provider string, profiles []string, workspace string) []string {
+	var matching []string
+	for _, p := range profiles {
+		if getVaultIdentity(provider, p).HasWorkspace(workspace) {
+			matching = append(matching, p)
+		}
 	}
+	return matching
+}
 
-	return info
+// profilesWithTags keeps the profiles whose tags match every filter (see
+// caamdb.TagsMatch).
+func (st *robotState) profilesWithTags(provider string, profiles []string, filters []string) []string {
+	var matching []string
+	for _, p := range profiles {
+		if caamdb.TagsMatch(st.Tags(provider, p), filters) {
+			matching = append(matching, p)
+		}
+	}
+	return matching
 }
 
+// buildProfileInfo reports on one profile. Commands reporting on many
+// profiles load a robotState once and use its profileInfo instead.
 func buildProfileInfo(tool, profileName, activeProfile string, db *caamdb.DB, compact bool) RobotProfileInfo {
+	return loadRobotState(context.Background(), db, tool).profileInfo(tool, profileName, activeProfile, compact)
+}
+
+// profileInfo reports on one profile from the loaded state.
+func (st *robotState) profileInfo(tool, profileName, activeProfile string, compact bool) RobotProfileInfo {
 	pInfo := RobotProfileInfo{
 		Name:   profileName,
 		Active: profileName == activeProfile,
 		System: authfile.IsSystemProfile(profileName),
 	}
+	if !pInfo.System {
+		pInfo.RiskTier = profileRiskTier(tool, profileName).String()
+	}
 
 	// Get health info
-	ph, id := getProfileHealthWithIdentity(tool, profileName)
+	ph, id := st.profileHealth(tool, profileName)
 	status := health.CalculateStatus(ph)
 
 	pInfo.Health = RobotHealthInfo{
 		Status:       status.String(),
 		ErrorCount1h: ph.ErrorCount1h,
+		StaleVsPool:  ph.StaleVsPool,
+	}
+	if ph.StaleVsPool {
+		pInfo.Health.LastPoolSync = ph.LastPoolSync.Format(time.RFC3339)
 	}
 
 	if !compact {
@@ -481,34 +807,157 @@ func buildProfileInfo(tool, profileName, activeProfile string, db *caamdb.DB, co
 		} else {
 			pInfo.Email = id.Email
 			pInfo.PlanType = id.PlanType
+			for _, ws := range id.Workspaces {
+				pInfo.Workspaces = append(pInfo.Workspaces, ws.ID)
+			}
+		}
+	}
+	if tool == "gemini" && !compact && vault != nil {
+		if p, err := vault.GCPProject(profileName); err == nil {
+			pInfo.GCPProject = p.Project
+			pInfo.GCPQuota = p.QuotaProject
+		}
+		if pInfo.GCPProject == "" && id != nil {
+			pInfo.GCPProject = id.Organization
 		}
 	}
 
 	// Check cooldown
-	if db != nil {
-		now := time.Now()
-		if cooldown, err := db.ActiveCooldown(tool, profileName, now); err == nil && cooldown != nil {
-			remaining := cooldown.CooldownUntil.Sub(now)
-			if remaining > 0 {
-				pInfo.Cooldown = &RobotCooldown{
-					Active:       true,
-					Until:        cooldown.CooldownUntil.Format(time.RFC3339),
-					RemainingMs:  remaining.Milliseconds(),
-					RemainingStr: robotFormatDuration(remaining),
-					Reason:       cooldown.Notes,
-				}
+	if cooldown := st.Cooldown(tool, profileName); cooldown != nil {
+		remaining := cooldown.CooldownUntil.Sub(st.Now)
+		if remaining > 0 {
+			pInfo.Cooldown = &RobotCooldown{
+				Active:       true,
+				Until:        cooldown.CooldownUntil.Format(time.RFC3339),
+				RemainingMs:  remaining.Milliseconds(),
+				RemainingStr: robotFormatDuration(remaining),
+				Reason:       cooldown.Notes,
+			}
+			if tpl := cooldownTemplate(st.SPM, tool, profileName); tpl != nil {
+				resetsAt := tpl.Until(cooldown.HitAt)
+				pInfo.Cooldown.Template = tpl.Name()
+				pInfo.Cooldown.WindowResetsAt = resetsAt.Format(time.RFC3339)
+				pInfo.Cooldown.window = resetsAt.Sub(cooldown.HitAt)
 			}
 		}
+	} else if ended := st.AwaitingProbe(tool, profileName); ended != nil {
+		pInfo.Cooldown = &RobotCooldown{
+			Active:       true,
+			Until:        ended.CooldownUntil.Format(time.RFC3339),
+			Reason:       "cooldown ended; waiting for the daemon's probe to confirm the limit reset",
+			PendingProbe: true,
+		}
+	}
+
+	// Time spent serving sessions this week, for rotating toward idle accounts
+	if st.db != nil && !compact {
+		pInfo.HoursThisWeek = profileHoursThisWeek(st.db, tool, profileName, st.Now)
+		pInfo.Spend = profileSpendThisMonth(st.db, st.SPM.Costs, tool, profileName, st.Now)
+	}
+
+	if tags := st.Tags(tool, profileName); len(tags) > 0 {
+		pInfo.Tags = make(map[string]string, len(tags))
+		for k, v := range tags {
+			pInfo.Tags[k] = v
+		}
+	}
+
+	// Another holder's lease, here or on another machine.
+	if l := st.blockingLease(tool, profileName, leaseHolder()); l != nil {
+		pInfo.Lease = &RobotLease{
+			Holder:    l.Holder,
+			Machine:   l.Machine,
+			ExpiresAt: l.ExpiresAt.Format(time.RFC3339),
+			Source:    l.Source,
+		}
+	}
+
+	// A revoked token overrides everything else: only a fresh login helps.
+	if rev := st.Revocation(tool, profileName); rev != nil {
+		pInfo.Revoked = &RobotRevoked{
+			DetectedAt: rev.DetectedAt.Format(time.RFC3339),
+			Reason:     rev.Reason,
+			Source:     rev.Source,
+		}
+		pInfo.Health.Status = health.StatusCritical.String()
+		pInfo.Health.Reason = "token revoked"
+	}
+
+	if evidence := st.Suspect(tool, profileName); len(evidence) > 0 {
+		pInfo.Suspect = &RobotSuspect{Evidence: evidence}
 	}
 
 	// Generate recommendation (unless compact)
 	if !compact {
 		pInfo.Recommendation = generateRecommendation(pInfo)
+		if pInfo.Recommendation == "refresh token required" || pInfo.Revoked != nil {
+			pInfo.HumanAction = buildHumanLoginAction(tool, profileName)
+		}
 	}
 
 	return pInfo
 }
 
+// profileSpendThisMonth prices a profile's metered usage this month and
+// checks the budget covering it. It returns nil when the profile has no
+// usage and no budget.
+func profileSpendThisMonth(db *caamdb.DB, costs config.CostsConfig, tool, profileName string, now time.Time) *RobotSpend {
+	budget := costs.BudgetFor(tool, profileName)
+	provider := tool
+	if budget != nil {
+		// Provider and overall budgets need the other profiles' spend too.
+		provider = budget.Provider
+	}
+	start, end := usage.MonthBounds(now)
+	records, err := db.UsageBetween(provider, start, end)
+	if err != nil {
+		return nil
+	}
+	var budgets []config.Budget
+	if budget != nil {
+		budgets = append(budgets, *budget)
+	}
+	report := usage.BuildMonthReport(records, costs.PriceTable(), budgets, start, now)
+
+	spend := &RobotSpend{}
+	found := false
+	for _, ps := range report.Profiles {
+		if ps.Provider == tool && ps.ProfileName == profileName {
+			spend.MonthToDateUSD = ps.CostUSD
+			spend.ProjectedMonthUSD = ps.ProjectedUSD
+			found = true
+		}
+	}
+	if len(report.Budgets) == 1 {
+		b := report.Budgets[0]
+		spend.Budget = b.Name
+		spend.BudgetUSD = b.BudgetUSD
+		spend.BudgetSpentUSD = b.SpentUSD
+		spend.BudgetUsedPercent = b.UsedPercent
+	} else if !found {
+		return nil
+	}
+	return spend
+}
+
+// buildHumanLoginAction returns the steps a human needs to re-login a profile.
+func buildHumanLoginAction(tool, profileName string) *RobotHumanAction {
+	meta, ok := provider.GetProviderMeta(tool)
+	if !ok {
+		return nil
+	}
+	return &RobotHumanAction{
+		Summary: fmt.Sprintf("Log in to %s again as %s and save it back to the vault", meta.DisplayName, profileName),
+		Commands: []string{
+			fmt.Sprintf("caam activate %s %s", tool, profileName),
+			meta.LoginCommand,
+			fmt.Sprintf("caam backup %s %s", tool, profileName),
+		},
+		ExpectedPrompts: meta.LoginPrompts,
+		LoginURL:        meta.LoginURL,
+	}
+}
+
 func getHealthReason(ph *health.ProfileHealth, status health.HealthStatus) string {
 	// Use thresholds from health.DefaultHealthConfig()
 	cfg := health.DefaultHealthConfig()
@@ -528,11 +977,20 @@ func getHealthReason(ph *health.ProfileHealth, status health.HealthStatus) strin
 		if ph.ErrorCount1h >= cfg.ErrorCountWarning {
 			return fmt.Sprintf("elevated error rate (%d errors in 1h)", ph.ErrorCount1h)
 		}
+		if ph.StaleVsPool {
+			return fmt.Sprintf("stale_vs_pool: last synced with pool %s ago", robotFormatDuration(time.Since(ph.LastPoolSync)))
+		}
 	}
 	return ""
 }
 
 func generateRecommendation(p RobotProfileInfo) string {
+	if p.Revoked != nil {
+		return "log in again (token revoked)"
+	}
+	if p.Suspect != nil {
+		return "check the account with the provider before use (" + p.Suspect.Evidence[0].Detail + ")"
+	}
 	if p.Cooldown != nil && p.Cooldown.Active {
 		return fmt.Sprintf("wait for cooldown (%s remaining)", p.Cooldown.RemainingStr)
 	}
@@ -546,6 +1004,9 @@ func generateRecommendation(p RobotProfileInfo) string {
 		if strings.Contains(p.Health.Reason, "expiring") {
 			return "consider refreshing token soon"
 		}
+		if strings.HasPrefix(p.Health.Reason, "stale_vs_pool") {
+			return "run caam sync before use"
+		}
 	}
 	if p.Health.Status == "healthy" && !p.Active {
 		return "ready to activate"
@@ -577,14 +1038,21 @@ func robotFormatDuration(d time.Duration) string {
 }
 
 func checkCoordinators() []RobotCoordinator {
-	// Check known coordinator endpoints
-	// This is a simplified version - in production, this would read from config
+	// The local coordinator, or the one named by CAAM_COORDINATOR_URL, using
+	// the same token the coordinator reads from CAAM_COORDINATOR_TOKEN.
 	endpoints := []struct {
 		name string
 		url  string
 	}{
 		{"local", "http://localhost:7890"},
 	}
+	if u := strings.TrimSpace(os.Getenv("CAAM_COORDINATOR_URL")); u != "" {
+		endpoints[0] = struct {
+			name string
+			url  string
+		}{"remote", strings.TrimRight(u, "/")}
+	}
+	token := strings.TrimSpace(os.Getenv("CAAM_COORDINATOR_TOKEN"))
 
 	var coords []RobotCoordinator
 	client := &http.Client{Timeout: 2 * time.Second}
@@ -596,56 +1064,141 @@ func checkCoordinators() []RobotCoordinator {
 		}
 
 		start := time.Now()
-		resp, err := client.Get(ep.url + "/status")
+		status, err := fetchCoordinatorStatus(context.Background(), client, ep.url, token)
 		coord.Latency = time.Since(start).Milliseconds()
 
 		if err != nil {
 			coord.Error = err.Error()
 			coord.Healthy = false
 		} else {
-			resp.Body.Close()
-			coord.Healthy = resp.StatusCode == http.StatusOK
-
-			// Try to get pending count
-			if pendResp, err := client.Get(ep.url + "/auth/pending"); err == nil {
-				var pending []interface{}
-				json.NewDecoder(pendResp.Body).Decode(&pending)
-				pendResp.Body.Close()
-				coord.Pending = len(pending)
-			}
+			coord.Healthy = true
+			coord.Pending = status.PendingAuths
+			coord.Panes = status.Panes
+			coord.Requests = status.PendingDetails
 		}
 
 		coords = append(coords, coord)
 	}
 
+	return append(coords, federatedCoordinators()...)
+}
+
+// federatedCoordinators reports the other machines' coordinators from the
+// daemon's last federation poll. A snapshot older than three poll
+// intervals is marked stale (the daemon has stopped or cannot reach them).
+func federatedCoordinators() []RobotCoordinator {
+	snap, err := federation.LoadSnapshot(federation.SnapshotPath())
+	if err != nil || snap == nil {
+		return nil
+	}
+
+	interval := federation.DefaultInterval
+	if spmCfg, err := config.LoadSPMConfig(); err == nil && spmCfg.Daemon.Federation.Interval.Duration() > 0 {
+		interval = spmCfg.Daemon.Federation.Interval.Duration()
+	}
+	stale := time.Since(snap.UpdatedAt) > 3*interval
+
+	coords := make([]RobotCoordinator, 0, len(snap.Machines))
+	for _, m := range snap.Machines {
+		checkedAt := m.CheckedAt
+		coord := RobotCoordinator{
+			Name:      m.Machine,
+			URL:       m.URL,
+			Healthy:   m.Healthy,
+			Latency:   m.LatencyMs,
+			Error:     m.Error,
+			Machine:   m.Machine,
+			Transport: m.Transport,
+			CheckedAt: &checkedAt,
+			Stale:     stale,
+		}
+		if m.Status != nil {
+			coord.Pending = m.Status.PendingAuths
+			coord.Panes = m.Status.Panes
+			coord.Requests = m.Status.PendingDetails
+		}
+		coords = append(coords, coord)
+	}
 	return coords
 }
 
 func runRobotNext(cmd *cobra.Command, args []string) error {
 	start := time.Now()
+	allProviders, _ := cmd.Flags().GetBool("all-providers")
+	if allProviders && len(args) > 0 {
+		return robotError(cmd, "next", caamerr.InvalidArgs,
+			"--all-providers cannot be combined with a provider",
+			"",
+			[]string{"caam robot next --all-providers", "caam robot next " + args[0]})
+	}
+	if len(args) == 0 {
+		return runRobotNextAll(cmd, start)
+	}
 	provider := strings.ToLower(args[0])
 
 	if _, ok := tools[provider]; !ok {
-		return robotError(cmd, "next", "INVALID_PROVIDER",
+		return robotError(cmd, "next", caamerr.InvalidProvider,
 			fmt.Sprintf("unknown provider: %s", provider),
-			"valid providers: codex, claude, gemini",
+			"valid providers: "+strings.Join(toolNames(), ", "),
 			nil)
 	}
 
-	strategy, _ := cmd.Flags().GetString("strategy")
+	strategy, err := robotNextStrategy(cmd)
+	if err != nil {
+		return err
+	}
 	includeCooldown, _ := cmd.Flags().GetBool("include-cooldown")
+	includeSuspect, _ := cmd.Flags().GetBool("include-suspect")
+
+	db, _ := caamdb.Open()
+	defer func() {
+		if db != nil {
+			db.Close()
+		}
+	}()
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	st := loadRobotState(ctx, db, provider)
 
 	// Get all profiles for this provider
 	profiles, err := vault.List(provider)
 	if err != nil {
-		return robotError(cmd, "next", "VAULT_ERROR",
+		return robotError(cmd, "next", caamerr.VaultError,
 			"failed to list profiles",
 			err.Error(),
 			[]string{"caam robot status " + provider})
 	}
 
+	if workspace, _ := cmd.Flags().GetString("workspace"); workspace != "" {
+		profiles = profilesForWorkspace(provider, profiles, workspace)
+		if len(profiles) == 0 {
+			return robotError(cmd, "next", caamerr.NoWorkspaceMatch,
+				fmt.Sprintf("no %s profile is authorized for workspace %s", provider, workspace),
+				"workspaces are read from each profile's stored credentials",
+				[]string{
+					"caam robot status " + provider,
+					"caam backup " + provider + " <profile-name>  # after logging in to the workspace",
+				})
+		}
+	}
+
+	if tagFilters, _ := cmd.Flags().GetStringArray("tag"); len(tagFilters) > 0 {
+		profiles = st.profilesWithTags(provider, profiles, tagFilters)
+		if len(profiles) == 0 {
+			return robotError(cmd, "next", caamerr.NoTagMatch,
+				fmt.Sprintf("no %s profile has tags %s", provider, strings.Join(tagFilters, ", ")),
+				"tags are set with 'caam profile tag'",
+				[]string{
+					"caam profile tags " + provider,
+					"caam profile tag " + provider + " <profile-name> key=value",
+				})
+		}
+	}
+
 	if len(profiles) == 0 {
-		return robotError(cmd, "next", "NO_PROFILES",
+		return robotError(cmd, "next", caamerr.NoProfiles,
 			fmt.Sprintf("no profiles found for %s", provider),
 			"",
 			[]string{
@@ -654,138 +1207,549 @@ func runRobotNext(cmd *cobra.Command, args []string) error {
 			})
 	}
 
-	db, _ := caamdb.Open()
-	defer func() {
-		if db != nil {
-			db.Close()
+	scored := st.scoreNext(provider, profiles, strategy, includeCooldown, includeSuspect)
+
+	if len(scored) == 0 {
+		suggestions := []string{
+			fmt.Sprintf("caam robot status %s", provider),
 		}
-	}()
+		if !includeCooldown {
+			suggestions = append(suggestions, "caam robot next "+provider+" --include-cooldown")
+		}
+		if !includeSuspect && st.anySuspect(provider, profiles) {
+			suggestions = append(suggestions, "caam robot next "+provider+" --include-suspect")
+		}
+		return robotError(cmd, "next", caamerr.AllBlocked,
+			"all profiles are blocked or in cooldown",
+			nextCooldownExpiry(provider, profiles, db),
+			suggestions)
+	}
+
+	best := scored[0]
+	if strategy == "round-robin" && db != nil {
+		_ = db.SetRotationCursor(provider, best.name)
+	}
+	data := RobotNextData{
+		Provider: provider,
+		Profile:  best.name,
+		Score:    best.score,
+		Strategy: strategy,
+		Reasons:  best.reasons,
+		Command:  fmt.Sprintf("caam activate %s %s", provider, best.name),
+	}
 
-	// Score each profile
-	type scoredProfile struct {
-		name    string
-		score   float64
-		reasons []string
-		info    RobotProfileInfo
+	// Include alternate if available
+	if len(scored) > 1 {
+		alt := scored[1]
+		data.AlternateChoice = &RobotNextProfile{
+			Provider: provider,
+			Profile:  alt.name,
+			Score:    alt.score,
+			Reason:   strings.Join(alt.reasons, "; "),
+		}
 	}
 
-	var scored []scoredProfile
+	duration := time.Since(start)
+	output := RobotOutput{
+		Success: true,
+		Command: "next",
+		Data:    data,
+		Timing: &RobotTiming{
+			StartedAt:  start.UTC().Format(time.RFC3339),
+			DurationMs: duration.Milliseconds(),
+		},
+	}
+
+	return robotOutput(cmd, output)
+}
+
+// robotScoredProfile is a profile scored for robot next.
+type robotScoredProfile struct {
+	name    string
+	score   float64
+	reasons []string
+	factors []RobotScoreFactor
+	info    RobotProfileInfo
+}
+
+// add applies a factor's points to the score and gives reason for it.
+func (sp *robotScoredProfile) add(factor string, points float64, reason string) {
+	sp.score += points
+	sp.reasons = append(sp.reasons, reason)
+	sp.factors = append(sp.factors, RobotScoreFactor{Factor: factor, Points: points, Detail: reason})
+}
+
+// note applies a factor's points without listing it among the reasons.
+func (sp *robotScoredProfile) note(factor string, points float64, detail string) {
+	sp.score += points
+	sp.factors = append(sp.factors, RobotScoreFactor{Factor: factor, Points: points, Detail: detail})
+}
+
+// nextCooldownExpiry says which of profiles leaves cooldown first, for when
+// every profile is blocked. It returns "" if none is in cooldown.
+func nextCooldownExpiry(provider string, profiles []string, db *caamdb.DB) string {
+	if db == nil {
+		return ""
+	}
 	now := time.Now()
+	var first *caamdb.CooldownEvent
+	for _, profileName := range profiles {
+		ev, err := db.ActiveCooldown(provider, profileName, now)
+		if err == nil && ev != nil && (first == nil || ev.CooldownUntil.Before(first.CooldownUntil)) {
+			first = ev
+		}
+	}
+	if first == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s leaves cooldown first, at %s (in %s)",
+		first.ProfileName, first.CooldownUntil.Format(time.RFC3339), robotFormatDuration(first.CooldownUntil.Sub(now)))
+}
+
+// scoreRobotNextProfiles scores a provider's profiles for robot next, best
+// first. Revoked profiles, profiles leased by someone else, profiles rotation
+// shaping blocks, and cooldowns unless includeCooldown, are left out. While
+// the active profile's minimum dwell has not passed, it comes first.
+func scoreRobotNextProfiles(provider string, profiles []string, strategy string, includeCooldown bool, db *caamdb.DB) []robotScoredProfile {
+	return loadRobotState(context.Background(), db, provider).scoreNext(provider, profiles, strategy, includeCooldown, false)
+}
+
+// scoreNext is scoreRobotNextProfiles from the loaded state.
+func (st *robotState) scoreNext(provider string, profiles []string, strategy string, includeCooldown, includeSuspect bool) []robotScoredProfile {
+	var scored []robotScoredProfile
+	scoring := st.SPM.Scoring
+	shaping := rotationShapingFor(st.SPM, provider, st.db)
 
 	for _, profileName := range profiles {
-		pInfo := buildProfileInfo(provider, profileName, "", db, false)
+		pInfo := st.profileInfo(provider, profileName, "", false)
+		if robotNextExclusion(pInfo, includeCooldown, includeSuspect) != "" || shaping.block(profileName) != "" {
+			continue
+		}
+		scored = append(scored, scoreRobotProfile(provider, profileName, pInfo, scoring, st.db, st.Now))
+	}
+
+	applyRobotNextStrategy(provider, strategy, scored, st.db)
+	applyRobotScoringScript(scoring, provider, scored)
+
+	statusengine.Rank(scored, func(sp robotScoredProfile) float64 { return sp.score })
+	shaping.holdActive(scored)
+	return scored
+}
 
+// robotNextExclusion says why robot next skips a profile, or returns "" if
+// the profile is a candidate.
+func robotNextExclusion(pInfo RobotProfileInfo, includeCooldown, includeSuspect bool) string {
+	switch {
+	case !includeCooldown && pInfo.Cooldown != nil && pInfo.Cooldown.Active:
 		// Skip profiles in cooldown unless requested
-		if !includeCooldown && pInfo.Cooldown != nil && pInfo.Cooldown.Active {
-			continue
+		return fmt.Sprintf("in cooldown until %s", pInfo.Cooldown.Until)
+	case pInfo.Revoked != nil:
+		// Revoked tokens need a human to log in again.
+		return "token revoked; a human must log in again"
+	case pInfo.Lease != nil:
+		// Someone else has the profile leased.
+		return fmt.Sprintf("leased by %s until %s", pInfo.Lease.Holder, pInfo.Lease.ExpiresAt)
+	case !includeSuspect && pInfo.Suspect != nil:
+		// The account looks flagged; using it risks a ban.
+		return "suspect: " + pInfo.Suspect.Evidence[0].Detail
+	}
+	return ""
+}
+
+// anySuspect reports whether any of a provider's profiles is suspect.
+func (st *robotState) anySuspect(provider string, profiles []string) bool {
+	for _, name := range profiles {
+		if len(st.Suspect(provider, name)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreRobotProfile scores one profile before the strategy is applied,
+// with the points set by the scoring config.
+func scoreRobotProfile(provider, profileName string, pInfo RobotProfileInfo, scoring config.ScoringConfig, db *caamdb.DB, now time.Time) robotScoredProfile {
+	sp := robotScoredProfile{
+		name:    profileName,
+		info:    pInfo,
+		reasons: []string{},
+	}
+	w := scoring.Weights
+
+	// Calculate score (higher is better)
+	switch pInfo.Health.Status {
+	case "healthy":
+		sp.add("health", w.Healthy, "healthy status")
+	case "warning":
+		sp.add("health", w.Warning, "warning status")
+	case "critical":
+		sp.add("health", w.Critical, "critical status (not recommended)")
+	default:
+		sp.note("health", w.Unknown, "unknown status")
+	}
+
+	// Cooldown penalty
+	if c := pInfo.Cooldown; c != nil && c.Active {
+		if c.window > 0 {
+			// A templated cooldown ends when the plan's window resets, so
+			// one near its reset costs less than one that just began.
+			frac := float64(c.RemainingMs) / float64(c.window.Milliseconds())
+			frac = math.Max(0.25, math.Min(1, frac))
+			sp.add("cooldown", w.Cooldown*frac, fmt.Sprintf("in cooldown (%s remaining, %s window resets %s)", c.RemainingStr, c.Template, c.WindowResetsAt))
+		} else {
+			sp.add("cooldown", w.Cooldown, fmt.Sprintf("in cooldown (%s remaining)", c.RemainingStr))
 		}
+	}
 
-		sp := scoredProfile{
-			name:    profileName,
-			info:    pInfo,
-			reasons: []string{},
+	// Error penalty
+	if pInfo.Health.ErrorCount1h > 0 {
+		sp.add("errors", float64(pInfo.Health.ErrorCount1h)*w.Error, fmt.Sprintf("%d recent errors", pInfo.Health.ErrorCount1h))
+	}
+
+	// Token expiry consideration
+	if pInfo.Health.ExpiresAt != "" {
+		if exp, err := time.Parse(time.RFC3339, pInfo.Health.ExpiresAt); err == nil {
+			remaining := exp.Sub(now)
+			if remaining > 7*24*time.Hour {
+				sp.add("token_expiry", w.TokenLongLived, "token valid for >7d")
+			} else if remaining > 24*time.Hour {
+				sp.add("token_expiry", w.TokenValid, fmt.Sprintf("token expires in %s", robotFormatDuration(remaining)))
+			} else if remaining > 0 {
+				sp.add("token_expiry", w.TokenExpiring, fmt.Sprintf("token expiring soon (%s)", robotFormatDuration(remaining)))
+			} else {
+				sp.add("token_expiry", w.TokenExpired, "token expired")
+			}
 		}
+	}
 
-		// Calculate score (higher is better)
-		switch pInfo.Health.Status {
-		case "healthy":
-			sp.score += 100
-			sp.reasons = append(sp.reasons, "healthy status")
-		case "warning":
-			sp.score += 50
-			sp.reasons = append(sp.reasons, "warning status")
-		case "critical":
-			sp.score += 10
-			sp.reasons = append(sp.reasons, "critical status (not recommended)")
-		default:
-			sp.score += 30
-		}
-
-		// Cooldown penalty
-		if pInfo.Cooldown != nil && pInfo.Cooldown.Active {
-			sp.score -= 200
-			sp.reasons = append(sp.reasons, fmt.Sprintf("in cooldown (%s remaining)", pInfo.Cooldown.RemainingStr))
-		}
-
-		// Error penalty
-		if pInfo.Health.ErrorCount1h > 0 {
-			sp.score -= float64(pInfo.Health.ErrorCount1h * 10)
-			sp.reasons = append(sp.reasons, fmt.Sprintf("%d recent errors", pInfo.Health.ErrorCount1h))
-		}
-
-		// Token expiry consideration
-		if pInfo.Health.ExpiresAt != "" {
-			if exp, err := time.Parse(time.RFC3339, pInfo.Health.ExpiresAt); err == nil {
-				remaining := exp.Sub(now)
-				if remaining > 7*24*time.Hour {
-					sp.score += 20
-					sp.reasons = append(sp.reasons, "token valid for >7d")
-				} else if remaining > 24*time.Hour {
-					sp.score += 10
-					sp.reasons = append(sp.reasons, fmt.Sprintf("token expires in %s", robotFormatDuration(remaining)))
-				} else if remaining > 0 {
-					sp.score -= 20
-					sp.reasons = append(sp.reasons, fmt.Sprintf("token expiring soon (%s)", robotFormatDuration(remaining)))
-				} else {
-					sp.score -= 100
-					sp.reasons = append(sp.reasons, "token expired")
+	// Sync pool staleness penalty
+	if pInfo.Health.StaleVsPool {
+		if last, err := time.Parse(time.RFC3339, pInfo.Health.LastPoolSync); err == nil {
+			sp.add("stale_vs_pool", w.StaleVsPool, fmt.Sprintf("stale_vs_pool (last synced %s ago)", robotFormatDuration(now.Sub(last))))
+		} else {
+			sp.add("stale_vs_pool", w.StaleVsPool, "stale_vs_pool")
+		}
+	}
+
+	// Risk tier: agents doing unattended work should land on expendable
+	// accounts and stay off high-value ones.
+	switch risk.Tier(pInfo.RiskTier) {
+	case risk.High:
+		sp.add("risk_tier", w.RiskHigh, "high-value account (reserved for manual use)")
+	case risk.Expendable:
+		sp.add("risk_tier", w.RiskExpendable, "expendable account (preferred for unattended work)")
+	}
+
+	// Switching back to an account soon after using it is a pattern some
+	// teams want to avoid.
+	if w.RecentUse != 0 && db != nil {
+		window := scoring.RecentUseWindow.Duration()
+		if last, err := db.LastActivation(provider, profileName); err == nil && !last.IsZero() && now.Sub(last) < window {
+			sp.add("recent_use", w.RecentUse, fmt.Sprintf("activated %s ago (within %s)", robotFormatDuration(now.Sub(last)), robotFormatDuration(window)))
+		}
+	}
+
+	// API keys have no usage windows to run out of but bill every
+	// token, so subscription profiles go first and today's spend pushes
+	// a key further down.
+	if pInfo.PlanType == identity.PlanAPI {
+		y, m, d := now.Date()
+		spend := apiKeySpendSince(db, provider, profileName, time.Date(y, m, d, 0, 0, 0, 0, now.Location()))
+		penalty := 40 + math.Min(40, spend*4)
+		sp.add("api_key_spend", -penalty, fmt.Sprintf("api key: billed per token ($%.2f today, -%.0f)", spend, penalty))
+	}
+	return sp
+}
+
+// robotNextStrategies lists the robot next selection strategies.
+var robotNextStrategies = []string{"smart", "lru", "round-robin", "weighted", "least-used-today", "sticky", "random"}
+
+// robotNextStrategy reads --strategy, reporting an unknown one as a robot
+// error.
+func robotNextStrategy(cmd *cobra.Command) (string, error) {
+	raw, _ := cmd.Flags().GetString("strategy")
+	strategy, ok := parseRobotNextStrategy(raw)
+	if !ok {
+		return "", robotError(cmd, "next", caamerr.InvalidArgs,
+			fmt.Sprintf("unknown strategy: %s", raw),
+			"valid strategies: "+strings.Join(robotNextStrategies, ", "),
+			[]string{"caam robot next --strategy smart"})
+	}
+	return strategy, nil
+}
+
+// parseRobotNextStrategy normalizes a --strategy value ("round_robin" is
+// accepted for "round-robin").
+func parseRobotNextStrategy(s string) (string, bool) {
+	s = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "_", "-")
+	if s == "" {
+		return "smart", true
+	}
+	for _, name := range robotNextStrategies {
+		if s == name {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// applyRobotNextStrategy adjusts the health-based scores for the selection
+// strategy and explains each adjustment in the profile's reasons.
+func applyRobotNextStrategy(provider, strategy string, scored []robotScoredProfile, db *caamdb.DB) {
+	now := time.Now()
+
+	switch strategy {
+	case "smart":
+		// Slightly favor switching away from the active profile.
+		for i := range scored {
+			if !scored[i].info.Active {
+				scored[i].note("strategy", 5, "smart: not the active profile")
+			}
+		}
+
+	case "lru":
+		// Up to 30 points, one per hour since the last activation.
+		for i := range scored {
+			sp := &scored[i]
+			var last time.Time
+			if db != nil {
+				last, _ = db.LastActivation(provider, sp.name)
+			}
+			if last.IsZero() {
+				sp.add("strategy", 30, "lru: never activated")
+				continue
+			}
+			bonus := math.Min(30, math.Floor(now.Sub(last).Hours()))
+			sp.add("strategy", bonus, fmt.Sprintf("lru: last activated %s ago (+%.0f)", robotFormatDuration(now.Sub(last)), bonus))
+		}
+
+	case "round-robin":
+		// Profiles after the persisted cursor, in name order, score highest.
+		var cursor string
+		if db != nil {
+			cursor, _ = db.RotationCursor(provider)
+		}
+		names := make([]string, len(scored))
+		for i := range scored {
+			names[i] = scored[i].name
+		}
+		sort.Strings(names)
+		start := sort.SearchStrings(names, cursor)
+		if start < len(names) && names[start] == cursor {
+			start++
+		}
+		position := make(map[string]int, len(names))
+		for i, name := range names {
+			position[name] = (i - start + len(names)) % len(names)
+		}
+		for i := range scored {
+			sp := &scored[i]
+			pos := position[sp.name]
+			bonus := 60 * float64(len(names)-pos) / float64(len(names))
+			if cursor == "" {
+				sp.add("strategy", bonus, fmt.Sprintf("round-robin: position %d (+%.0f)", pos+1, bonus))
+			} else {
+				sp.add("strategy", bonus, fmt.Sprintf("round-robin: position %d after %s (+%.0f)", pos+1, cursor, bonus))
+			}
+		}
+
+	case "weighted":
+		// Positive scores are multiplied by the configured weight.
+		cfg := loadRiskConfig()
+		for i := range scored {
+			sp := &scored[i]
+			weight, source := cfg.SelectionWeight(provider, sp.name, sp.info.PlanType)
+			var delta float64
+			if sp.score > 0 {
+				delta = sp.score*weight - sp.score
+			}
+			sp.add("strategy", delta, fmt.Sprintf("weighted: weight %g (%s)", weight, source))
+		}
+
+	case "least-used-today":
+		// Up to 50 points for the profile with the fewest metered tokens
+		// since local midnight.
+		used := make(map[string]int64)
+		var most int64
+		if db != nil {
+			y, m, d := now.Date()
+			totals, _ := db.UsageTotalsSince(provider, time.Date(y, m, d, 0, 0, 0, 0, now.Location()))
+			for _, t := range totals {
+				used[t.ProfileName] = t.TotalTokens
+				if t.TotalTokens > most {
+					most = t.TotalTokens
 				}
 			}
 		}
+		for i := range scored {
+			sp := &scored[i]
+			if most == 0 {
+				sp.add("strategy", 0, "least-used-today: no usage recorded today")
+				continue
+			}
+			bonus := 50 * (1 - float64(used[sp.name])/float64(most))
+			sp.add("strategy", bonus, fmt.Sprintf("least-used-today: %s tokens today (+%.0f)", formatTokenCount(used[sp.name]), bonus))
+		}
 
-		// LRU bonus (strategy-dependent)
-		if strategy == "lru" || strategy == "smart" {
-			// Could check last used time here
-			// For now, just slightly favor non-active profiles
-			if !pInfo.Active {
-				sp.score += 5
+	case "sticky":
+		// Keep the active profile unless it is cooling down or critical.
+		for i := range scored {
+			sp := &scored[i]
+			if !sp.info.Active {
+				continue
+			}
+			switch {
+			case sp.info.Cooldown != nil && sp.info.Cooldown.Active:
+				sp.add("strategy", 0, "sticky: active profile is in cooldown, switching")
+			case sp.info.Health.Status == "critical":
+				sp.add("strategy", 0, "sticky: active profile is critical, switching")
+			default:
+				sp.add("strategy", 100, "sticky: keeping the active profile (+100)")
 			}
 		}
 
-		scored = append(scored, sp)
+	case "random":
+		for i := range scored {
+			jitter := float64(rand.Intn(26))
+			scored[i].add("strategy", jitter, fmt.Sprintf("random: +%.0f", jitter))
+		}
 	}
+}
 
-	if len(scored) == 0 {
-		suggestions := []string{
-			fmt.Sprintf("caam robot status %s", provider),
-		}
-		if !includeCooldown {
-			suggestions = append(suggestions, "caam robot next "+provider+" --include-cooldown")
+// robotNextMaxScore is the highest score scoreRobotNextProfiles can give: a
+// healthy, expendable, inactive profile with a long-lived token.
+var robotNextMaxScore = 100 + 20 + 5 + risk.Expendable.SelectionBonus()
+
+// normalizeRobotNextScore maps a raw robot next score onto 0-100.
+func normalizeRobotNextScore(score float64) float64 {
+	n := score / robotNextMaxScore * 100
+	if n < 0 {
+		n = 0
+	}
+	if n > 100 {
+		n = 100
+	}
+	return math.Round(n*10) / 10
+}
+
+func runRobotNextAll(cmd *cobra.Command, start time.Time) error {
+	strategy, err := robotNextStrategy(cmd)
+	if err != nil {
+		return err
+	}
+	includeCooldown, _ := cmd.Flags().GetBool("include-cooldown")
+	includeSuspect, _ := cmd.Flags().GetBool("include-suspect")
+	workspace, _ := cmd.Flags().GetString("workspace")
+	tagFilters, _ := cmd.Flags().GetStringArray("tag")
+
+	db, _ := caamdb.Open()
+	defer func() {
+		if db != nil {
+			db.Close()
 		}
-		return robotError(cmd, "next", "ALL_BLOCKED",
-			"all profiles are blocked or in cooldown",
-			"",
-			suggestions)
+	}()
+
+	providers := make([]string, 0, len(tools))
+	for name := range tools {
+		providers = append(providers, name)
 	}
+	sort.Strings(providers)
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	st := loadRobotState(ctx, db, providers...)
+
+	data := RobotNextAllData{
+		Strategy:  strategy,
+		Ranked:    make([]RobotNextRanked, 0),
+		Providers: make([]RobotNextProviderPick, 0, len(providers)),
+	}
+	skippedSuspect := false
+	for _, provider := range providers {
+		pick := RobotNextProviderPick{Provider: provider, Alternates: make([]RobotNextRanked, 0)}
+
+		profiles, err := vault.List(provider)
+		if err != nil {
+			pick.Blocked = "failed to list profiles: " + err.Error()
+			data.Providers = append(data.Providers, pick)
+			continue
+		}
+		if workspace != "" {
+			profiles = profilesForWorkspace(provider, profiles, workspace)
+		}
+		if len(tagFilters) > 0 {
+			profiles = st.profilesWithTags(provider, profiles, tagFilters)
+		}
+
+		scored := st.scoreNext(provider, profiles, strategy, includeCooldown, includeSuspect)
+		if !includeSuspect && st.anySuspect(provider, profiles) {
+			skippedSuspect = true
+		}
+		switch {
+		case len(profiles) == 0 && workspace != "":
+			pick.Blocked = "no profile authorized for workspace " + workspace
+		case len(profiles) == 0 && len(tagFilters) > 0:
+			pick.Blocked = "no profile has tags " + strings.Join(tagFilters, ", ")
+		case len(profiles) == 0:
+			pick.Blocked = "no profiles"
+		case len(scored) == 0:
+			pick.Blocked = "all profiles are blocked or in cooldown"
+		}
 
-	// Sort by score (descending)
-	for i := 0; i < len(scored)-1; i++ {
-		for j := i + 1; j < len(scored); j++ {
-			if scored[j].score > scored[i].score {
-				scored[i], scored[j] = scored[j], scored[i]
+		for i, sp := range scored {
+			ranked := RobotNextRanked{
+				Provider:        provider,
+				Profile:         sp.name,
+				Score:           sp.score,
+				NormalizedScore: normalizeRobotNextScore(sp.score),
+				Reasons:         sp.reasons,
+				Command:         fmt.Sprintf("caam activate %s %s", provider, sp.name),
+			}
+			data.Ranked = append(data.Ranked, ranked)
+			if i == 0 {
+				best := ranked
+				pick.Best = &best
+			} else {
+				pick.Alternates = append(pick.Alternates, ranked)
 			}
 		}
+		data.Providers = append(data.Providers, pick)
 	}
 
-	best := scored[0]
-	data := RobotNextData{
-		Provider: provider,
-		Profile:  best.name,
-		Score:    best.score,
-		Reasons:  best.reasons,
-		Command:  fmt.Sprintf("caam activate %s %s", provider, best.name),
+	if len(data.Ranked) == 0 {
+		suggestions := []string{"caam robot status"}
+		if !includeCooldown {
+			suggestions = append(suggestions, "caam robot next --all-providers --include-cooldown")
+		}
+		if skippedSuspect {
+			suggestions = append(suggestions, "caam robot next --all-providers --include-suspect")
+		}
+		return robotError(cmd, "next", caamerr.AllBlocked,
+			"no profile of any provider is available",
+			"",
+			suggestions)
 	}
 
-	// Include alternate if available
-	if len(scored) > 1 {
-		alt := scored[1]
-		data.AlternateChoice = &RobotNextProfile{
-			Provider: provider,
-			Profile:  alt.name,
-			Score:    alt.score,
-			Reason:   strings.Join(alt.reasons, "; "),
+	statusengine.Rank(data.Ranked, func(r RobotNextRanked) float64 { return r.Score })
+	rankOf := make(map[string]int, len(data.Ranked))
+	for i := range data.Ranked {
+		data.Ranked[i].Rank = i + 1
+		rankOf[data.Ranked[i].Provider+"/"+data.Ranked[i].Profile] = i + 1
+	}
+	for i := range data.Providers {
+		pick := &data.Providers[i]
+		if pick.Best != nil {
+			pick.Best.Rank = rankOf[pick.Provider+"/"+pick.Best.Profile]
+		}
+		for j := range pick.Alternates {
+			pick.Alternates[j].Rank = rankOf[pick.Provider+"/"+pick.Alternates[j].Profile]
 		}
 	}
+	data.Recommended = data.Ranked[0]
+	if strategy == "round-robin" && db != nil {
+		_ = db.SetRotationCursor(data.Recommended.Provider, data.Recommended.Profile)
+	}
 
 	duration := time.Since(start)
 	output := RobotOutput{
@@ -803,16 +1767,179 @@ func runRobotNext(cmd *cobra.Command, args []string) error {
 
 func runRobotAct(cmd *cobra.Command, args []string) error {
 	start := time.Now()
+	if plan, _ := cmd.Flags().GetString("plan"); plan != "" {
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			return robotError(cmd, "act", caamerr.InvalidArgs,
+				"--dry-run only applies to a single activate",
+				"usage: caam robot act activate <provider> <profile> --dry-run",
+				nil)
+		}
+		atomic, _ := cmd.Flags().GetBool("atomic")
+		return runRobotActPlan(cmd, plan, atomic, start)
+	}
+
 	action := strings.ToLower(args[0])
 	provider := strings.ToLower(args[1])
 
 	if _, ok := tools[provider]; !ok {
-		return robotError(cmd, "act", "INVALID_PROVIDER",
+		return robotError(cmd, "act", caamerr.InvalidProvider,
 			fmt.Sprintf("unknown provider: %s", provider),
-			"valid providers: codex, claude, gemini",
+			"valid providers: "+strings.Join(toolNames(), ", "),
+			nil)
+	}
+
+	if auto, _ := cmd.Flags().GetBool("auto"); auto {
+		if action != "cooldown" {
+			return robotError(cmd, "act", caamerr.InvalidArgs,
+				"--auto only applies to cooldown",
+				"usage: caam robot act cooldown <provider> <profile> --auto",
+				nil)
+		}
+		if len(args) >= 4 {
+			return robotError(cmd, "act", caamerr.InvalidArgs,
+				"give a duration or --auto, not both",
+				"usage: caam robot act cooldown <provider> <profile> --auto",
+				nil)
+		}
+		if len(args) == 3 {
+			args = append(args, autoCooldownArg)
+		}
+	}
+
+	ignoreShaping, _ := cmd.Flags().GetBool("ignore-shaping")
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		if action != "activate" {
+			return robotError(cmd, "act", caamerr.InvalidArgs,
+				"--dry-run only applies to activate",
+				"usage: caam robot act activate <provider> <profile> --dry-run",
+				nil)
+		}
+		result, failure := previewRobotActivate(provider, args, ignoreShaping)
+		if failure != nil {
+			return robotError(cmd, "act", caamerr.Code(failure.Code), failure.Message, failure.Details, failure.suggestions)
+		}
+		return robotOutput(cmd, RobotOutput{
+			Success: true,
+			Command: "act",
+			Data:    result,
+			Timing: &RobotTiming{
+				StartedAt:  start.UTC().Format(time.RFC3339),
+				DurationMs: time.Since(start).Milliseconds(),
+			},
+		})
+	}
+
+	// Agents may need a human to approve this first.
+	if handled, err := gateRobotAct(cmd, action, provider, args); handled {
+		return err
+	}
+
+	result, failure := performRobotAct(action, provider, args, ignoreShaping)
+	if failure != nil {
+		return robotError(cmd, "act", caamerr.Code(failure.Code), failure.Message, failure.Details, failure.suggestions)
+	}
+
+	duration := time.Since(start)
+	output := RobotOutput{
+		Success: result.Success,
+		Command: "act",
+		Data:    result,
+		Timing: &RobotTiming{
+			StartedAt:  start.UTC().Format(time.RFC3339),
+			DurationMs: duration.Milliseconds(),
+		},
+	}
+
+	return robotOutput(cmd, output)
+}
+
+// robotActFailure is a failed robot act action, before it is written out.
+type robotActFailure struct {
+	RobotError
+	suggestions []string
+}
+
+func newRobotActFailure(code caamerr.Code, message, details string, suggestions []string) *robotActFailure {
+	return &robotActFailure{
+		RobotError:  RobotError{Code: string(code), Message: message, Details: details},
+		suggestions: suggestions,
+	}
+}
+
+// robotActivateShapingFailure returns the SHAPING_BLOCKED failure for
+// activating provider/profile over oldProfile, or nil if shaping allows it.
+func robotActivateShapingFailure(spmCfg *config.SPMConfig, db *caamdb.DB, provider, oldProfile, profile string) *robotActFailure {
+	if spmCfg == nil {
+		return nil
+	}
+	shaping := newRotationShaping(spmCfg.Stealth.Shaping, provider, oldProfile, db, time.Now())
+	reason := shaping.activationBlock(profile)
+	if reason == "" {
+		return nil
+	}
+	return newRobotActFailure(caamerr.ShapingBlocked,
+		fmt.Sprintf("rotation shaping blocks activating %s/%s", provider, profile),
+		reason,
+		[]string{
+			fmt.Sprintf("caam robot next %s", provider),
+			fmt.Sprintf("caam robot act activate %s %s --ignore-shaping", provider, profile),
+		})
+}
+
+// previewRobotActivate runs activate's checks and reports what activating
+// would do to each live auth file, without changing anything. Hooks, leases
+// and approvals, which have effects of their own, are left out.
+func previewRobotActivate(provider string, args []string, ignoreShaping bool) (RobotActResult, *robotActFailure) {
+	result := RobotActResult{Action: "activate", Provider: provider, DryRun: true}
+	if len(args) < 3 {
+		return result, newRobotActFailure(caamerr.MissingProfile,
+			"profile name required for activate",
+			"usage: caam robot act activate <provider> <profile> --dry-run",
 			nil)
 	}
+	profile := args[2]
+	result.Profile = profile
+
+	fileSet := tools[provider]()
+	if oldProfile, err := vault.ActiveProfile(fileSet); err == nil {
+		result.OldProfile = oldProfile
+	}
+
+	if !ignoreShaping {
+		spmCfg, _ := config.LoadSPMConfig()
+		db, _ := caamdb.Open()
+		if db != nil {
+			defer db.Close()
+		}
+		if failure := robotActivateShapingFailure(spmCfg, db, provider, result.OldProfile, profile); failure != nil {
+			return result, failure
+		}
+	}
+
+	changes, err := vault.PlanRestore(fileSet, profile)
+	if err != nil {
+		return result, newRobotActFailure(caamerr.ActivateFailed,
+			fmt.Sprintf("cannot activate %s/%s", provider, profile),
+			err.Error(),
+			[]string{fmt.Sprintf("caam robot status %s", provider)})
+	}
+	result.Changes = changes
 
+	changed := 0
+	for _, c := range changes {
+		if c.Action != authfile.RestoreUnchanged && c.Action != authfile.RestoreKeep {
+			changed++
+		}
+	}
+	result.Success = true
+	result.Message = fmt.Sprintf("would activate %s/%s: %d of %d auth file(s) change", provider, profile, changed, len(changes))
+	return result, nil
+}
+
+// performRobotAct runs one robot act action. args are the command-line
+// arguments: action, provider, then any profile and extra arguments.
+// ignoreShaping lets activate skip the rotation shaping rules.
+func performRobotAct(action, provider string, args []string, ignoreShaping bool) (RobotActResult, *robotActFailure) {
 	var result RobotActResult
 	result.Action = action
 	result.Provider = provider
@@ -820,7 +1947,7 @@ func runRobotAct(cmd *cobra.Command, args []string) error {
 	switch action {
 	case "activate":
 		if len(args) < 3 {
-			return robotError(cmd, "act", "MISSING_PROFILE",
+			return result, newRobotActFailure(caamerr.MissingProfile,
 				"profile name required for activate",
 				"usage: caam robot act activate <provider> <profile>",
 				nil)
@@ -834,20 +1961,60 @@ func runRobotAct(cmd *cobra.Command, args []string) error {
 			result.OldProfile = oldProfile
 		}
 
+		spmCfg, _ := config.LoadSPMConfig()
+		db, _ := caamdb.Open()
+		if db != nil {
+			defer db.Close()
+		}
+		if !ignoreShaping {
+			if failure := robotActivateShapingFailure(spmCfg, db, provider, result.OldProfile, profile); failure != nil {
+				return result, failure
+			}
+		}
+
+		act, err := beginActivation(fileSet, profile, "robot", activationOptions{db: db, spmCfg: spmCfg})
+		if err != nil {
+			return result, newRobotActFailure(caamerr.CodeOf(err),
+				fmt.Sprintf("cannot activate %s/%s", provider, profile),
+				err.Error(),
+				[]string{fmt.Sprintf("caam robot next %s", provider)})
+		}
+		defer func() { act.finish(result.Success) }()
+		result.AutoBackup = act.AutoBackup
+
 		// Activate the profile
 		if err := vault.Restore(fileSet, profile); err != nil {
-			return robotError(cmd, "act", "ACTIVATE_FAILED",
+			return result, newRobotActFailure(caamerr.ActivateFailed,
 				fmt.Sprintf("failed to activate %s/%s", provider, profile),
 				err.Error(),
 				[]string{fmt.Sprintf("caam robot status %s", provider)})
 		}
 
+		events.PublishActivated(provider, profile, "robot")
+		if logsActivations(spmCfg) && db != nil {
+			_ = db.LogEvent(caamdb.Event{
+				Type:        caamdb.EventActivate,
+				Provider:    provider,
+				ProfileName: profile,
+				Details: map[string]any{
+					"previous_profile": result.OldProfile,
+					"selection_source": "robot",
+					"ignore_shaping":   ignoreShaping,
+				},
+			})
+		}
 		result.Success = true
 		result.Message = fmt.Sprintf("activated %s/%s", provider, profile)
+		if msg := activateBrowserSession(provider, profile); msg != "" {
+			result.Message += ", " + msg
+		}
+		if act.Lease != nil {
+			result.Message += fmt.Sprintf(", leased until %s", act.Lease.ExpiresAt.Format(time.RFC3339))
+		}
 
 	case "cooldown":
 		if len(args) < 3 {
-			return robotError(cmd, "act", "MISSING_PROFILE",
+			return result, newRobotActFailure(caamerr.MissingProfile,
 				"profile name required for cooldown",
 				"usage: caam robot act cooldown <provider> <profile> [duration]",
 				nil)
@@ -856,7 +2023,8 @@ func runRobotAct(cmd *cobra.Command, args []string) error {
 		result.Profile = profile
 
 		duration := 4 * time.Hour // default
-		if len(args) >= 4 {
+		auto := len(args) >= 4 && strings.EqualFold(args[3], autoCooldownArg)
+		if len(args) >= 4 && !auto {
 			if d, err := time.ParseDuration(args[3]); err == nil {
 				duration = d
 			}
@@ -864,7 +2032,7 @@ func runRobotAct(cmd *cobra.Command, args []string) error {
 
 		db, err := caamdb.Open()
 		if err != nil {
-			return robotError(cmd, "act", "DB_ERROR",
+			return result, newRobotActFailure(caamerr.DBError,
 				"failed to open database",
 				err.Error(),
 				nil)
@@ -872,9 +2040,35 @@ func runRobotAct(cmd *cobra.Command, args []string) error {
 		defer db.Close()
 
 		hitAt := time.Now()
-		cooldownEvent, err := db.SetCooldown(provider, profile, hitAt, duration, "manual via robot act")
+		notes := "manual via robot act"
+		if auto {
+			until, source, ok := inferCooldownUntil(db, provider, profile, hitAt)
+			if !ok {
+				return result, newRobotActFailure(caamerr.CooldownFailed,
+					"could not infer when the limit resets",
+					fmt.Sprintf("no reset information for %s/%s", provider, profile),
+					[]string{fmt.Sprintf("caam robot act cooldown %s %s 4h", provider, profile)})
+			}
+			duration = until.Sub(hitAt)
+			notes = "auto via robot act (" + source + ")"
+			result.ResetSource = source
+		} else if len(args) < 4 {
+			spmCfg, _ := config.LoadSPMConfig()
+			if tpl := cooldownTemplate(spmCfg, provider, profile); tpl != nil {
+				duration = tpl.Until(hitAt).Sub(hitAt)
+				notes = "template " + tpl.Name() + " via robot act"
+				result.ResetSource = resetSourceTemplate
+			}
+		}
+		if err := runPreHook(hooks.PreCooldown, provider, profile, "robot"); err != nil {
+			return result, newRobotActFailure(caamerr.HookRejected,
+				fmt.Sprintf("pre-cooldown hook refused %s/%s", provider, profile),
+				err.Error(),
+				nil)
+		}
+		cooldownEvent, err := db.SetCooldown(provider, profile, hitAt, jitterCooldown(duration), notes)
 		if err != nil {
-			return robotError(cmd, "act", "COOLDOWN_FAILED",
+			return result, newRobotActFailure(caamerr.CooldownFailed,
 				"failed to set cooldown",
 				err.Error(),
 				nil)
@@ -882,10 +2076,13 @@ func runRobotAct(cmd *cobra.Command, args []string) error {
 
 		result.Success = true
 		result.Message = fmt.Sprintf("cooldown set until %s (%s)", cooldownEvent.CooldownUntil.Format(time.RFC3339), robotFormatDuration(duration))
+		if result.ResetSource != "" {
+			result.Message += ", from " + result.ResetSource
+		}
 
 	case "uncooldown":
 		if len(args) < 3 {
-			return robotError(cmd, "act", "MISSING_PROFILE",
+			return result, newRobotActFailure(caamerr.MissingProfile,
 				"profile name required for uncooldown",
 				"usage: caam robot act uncooldown <provider> <profile>",
 				nil)
@@ -895,7 +2092,7 @@ func runRobotAct(cmd *cobra.Command, args []string) error {
 
 		db, err := caamdb.Open()
 		if err != nil {
-			return robotError(cmd, "act", "DB_ERROR",
+			return result, newRobotActFailure(caamerr.DBError,
 				"failed to open database",
 				err.Error(),
 				nil)
@@ -903,7 +2100,7 @@ func runRobotAct(cmd *cobra.Command, args []string) error {
 		defer db.Close()
 
 		if _, err := db.ClearCooldown(provider, profile); err != nil {
-			return robotError(cmd, "act", "UNCOOLDOWN_FAILED",
+			return result, newRobotActFailure(caamerr.UncooldownFailed,
 				"failed to clear cooldown",
 				err.Error(),
 				nil)
@@ -915,7 +2112,7 @@ func runRobotAct(cmd *cobra.Command, args []string) error {
 	case "backup":
 		fileSet := tools[provider]()
 		if !authfile.HasAuthFiles(fileSet) {
-			return robotError(cmd, "act", "NO_AUTH",
+			return result, newRobotActFailure(caamerr.NoAuth,
 				fmt.Sprintf("no auth files found for %s", provider),
 				"login first using the tool's login command",
 				nil)
@@ -927,40 +2124,71 @@ func runRobotAct(cmd *cobra.Command, args []string) error {
 		}
 		result.Profile = profile
 
+		if err := runPreHook(hooks.PreBackup, provider, profile, "robot"); err != nil {
+			return result, newRobotActFailure(caamerr.HookRejected,
+				fmt.Sprintf("pre-backup hook refused %s/%s", provider, profile),
+				err.Error(),
+				nil)
+		}
 		if err := vault.Backup(fileSet, profile); err != nil {
-			return robotError(cmd, "act", "BACKUP_FAILED",
+			return result, newRobotActFailure(caamerr.BackupFailed,
 				"backup failed",
 				err.Error(),
 				nil)
 		}
+		runPostHook(hooks.PostBackup, hooks.Payload{Provider: provider, Profile: profile, Source: "robot"})
 
 		result.Success = true
 		result.Message = fmt.Sprintf("backed up to %s/%s", provider, profile)
 
+	case "note":
+		if len(args) < 4 {
+			return result, newRobotActFailure(caamerr.MissingNote,
+				"profile and note text required for note",
+				"usage: caam robot act note <provider> <profile> <text>",
+				nil)
+		}
+		profile := args[2]
+		result.Profile = profile
+
+		db, err := caamdb.Open()
+		if err != nil {
+			return result, newRobotActFailure(caamerr.DBError,
+				"failed to open database",
+				err.Error(),
+				nil)
+		}
+		defer db.Close()
+
+		if err := db.LogEvent(caamdb.Event{
+			Type:        caamdb.EventNote,
+			Provider:    provider,
+			ProfileName: profile,
+			Details:     map[string]any{"note": strings.Join(args[3:], " "), "source": "robot"},
+		}); err != nil {
+			return result, newRobotActFailure(caamerr.NoteFailed,
+				"failed to record note",
+				err.Error(),
+				nil)
+		}
+
+		result.Success = true
+		result.Message = fmt.Sprintf("recorded note for %s/%s", provider, profile)
+
 	default:
-		return robotError(cmd, "act", "INVALID_ACTION",
+		return result, newRobotActFailure(caamerr.InvalidAction,
 			fmt.Sprintf("unknown action: %s", action),
-			"valid actions: activate, cooldown, uncooldown, backup",
+			"valid actions: activate, cooldown, uncooldown, backup, note",
 			[]string{
 				"caam robot act activate <provider> <profile>",
 				"caam robot act cooldown <provider> <profile> [duration]",
 				"caam robot act uncooldown <provider> <profile>",
 				"caam robot act backup <provider> [profile]",
+				"caam robot act note <provider> <profile> <text>",
 			})
 	}
 
-	duration := time.Since(start)
-	output := RobotOutput{
-		Success: result.Success,
-		Command: "act",
-		Data:    result,
-		Timing: &RobotTiming{
-			StartedAt:  start.UTC().Format(time.RFC3339),
-			DurationMs: duration.Milliseconds(),
-		},
-	}
-
-	return robotOutput(cmd, output)
+	return result, nil
 }
 
 func runRobotHealth(cmd *cobra.Command, args []string) error {
@@ -1002,8 +2230,9 @@ func runRobotHealth(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check database
-	if db, err := caamdb.Open(); err == nil {
-		db.Close()
+	db, err := caamdb.Open()
+	if err == nil {
+		defer db.Close()
 		result.Checks = append(result.Checks, HealthCheck{
 			Name:   "database",
 			Status: "ok",
@@ -1021,7 +2250,8 @@ func runRobotHealth(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check each provider
-	for _, tool := range []string{"codex", "claude", "gemini"} {
+	st := loadRobotState(cmd.Context(), db, toolNames()...)
+	for _, tool := range toolNames() {
 		profiles, err := vault.List(tool)
 		if err != nil {
 			continue
@@ -1031,6 +2261,10 @@ func runRobotHealth(cmd *cobra.Command, args []string) error {
 		totalCount := len(profiles)
 
 		for _, profileName := range profiles {
+			// Not healthy again until the end-of-cooldown probe passes.
+			if st.AwaitingProbe(tool, profileName) != nil {
+				continue
+			}
 			ph := buildProfileHealth(tool, profileName)
 			status := health.CalculateStatus(ph)
 			if status == health.StatusHealthy {
@@ -1087,14 +2321,16 @@ func runRobotHealth(cmd *cobra.Command, args []string) error {
 func runRobotWatch(cmd *cobra.Command, args []string) error {
 	interval, _ := cmd.Flags().GetInt("interval")
 	providerFilter, _ := cmd.Flags().GetString("provider")
+	changesOnly, _ := cmd.Flags().GetBool("changes-only")
+	heartbeat, _ := cmd.Flags().GetInt("heartbeat")
 
 	// Validate provider filter if specified
 	if providerFilter != "" {
 		providerFilter = strings.ToLower(providerFilter)
 		if _, ok := tools[providerFilter]; !ok {
-			return robotError(cmd, "watch", "INVALID_PROVIDER",
+			return robotError(cmd, "watch", caamerr.InvalidProvider,
 				fmt.Sprintf("unknown provider: %s", providerFilter),
-				"valid providers: codex, claude, gemini",
+				"valid providers: "+strings.Join(toolNames(), ", "),
 				nil)
 		}
 	}
@@ -1103,13 +2339,29 @@ func runRobotWatch(cmd *cobra.Command, args []string) error {
 		interval = 1
 	}
 
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	emitter := &watchEmitter{
+		out:         cmd.OutOrStdout(),
+		changesOnly: changesOnly,
+		heartbeat:   time.Duration(heartbeat) * time.Second,
+	}
+
+	noDaemon, _ := cmd.Flags().GetBool("no-daemon")
+	if !noDaemon {
+		if attached, err := watchViaDaemon(ctx, emitter, interval, providerFilter); attached {
+			return err
+		}
+	}
+
 	ticker := time.NewTicker(time.Duration(interval) * time.Second)
 	defer ticker.Stop()
 
-	ctx := cmd.Context()
-
 	// Emit initial status
-	if err := emitWatchStatus(cmd, providerFilter); err != nil {
+	if err := emitter.emit(collectWatchStatus(providerFilter)); err != nil {
 		return nil // Exit gracefully if we can't write output
 	}
 
@@ -1118,35 +2370,89 @@ func runRobotWatch(cmd *cobra.Command, args []string) error {
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			if err := emitWatchStatus(cmd, providerFilter); err != nil {
+			if err := emitter.emit(collectWatchStatus(providerFilter)); err != nil {
 				return nil // Exit gracefully if stdout is closed
 			}
 		}
 	}
 }
 
-func emitWatchStatus(cmd *cobra.Command, providerFilter string) error {
-	providersToCheck := []string{"codex", "claude", "gemini"}
+func collectWatchStatus(providerFilter string) RobotWatchSnapshot {
+	providersToCheck := toolNames()
 	if providerFilter != "" {
 		providersToCheck = []string{providerFilter}
 	}
 
-	type WatchEvent struct {
-		Timestamp string              `json:"timestamp"`
-		Providers []RobotProviderInfo `json:"providers"`
-	}
-
-	event := WatchEvent{
+	snap := RobotWatchSnapshot{
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Providers: make([]RobotProviderInfo, 0),
 	}
 
-	for _, tool := range providersToCheck {
-		event.Providers = append(event.Providers, buildProviderInfo(tool, true))
+	st, closeState := openRobotState(context.Background(), providersToCheck...)
+	defer closeState()
+	snap.Providers, _ = st.providerInfos(context.Background(), providersToCheck, true, defaultRobotScanTimeout)
+	return snap
+}
+
+// watchViaDaemon streams status from the daemon's shared watch poller. It
+// reports attached=false if the daemon isn't running or drops the connection
+// before sending anything, in which case the caller polls locally.
+func watchViaDaemon(ctx context.Context, emitter *watchEmitter, interval int, providerFilter string) (attached bool, err error) {
+	if running, _, _ := daemon.GetDaemonStatus(); !running {
+		return false, nil
+	}
+	conn, err := daemon.DialWatch(daemon.WatchRequest{Interval: interval, Provider: providerFilter})
+	if err != nil {
+		return false, nil
 	}
+	defer conn.Close()
 
-	enc := json.NewEncoder(cmd.OutOrStdout())
-	return enc.Encode(event)
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		attached = true
+		if !emitter.changesOnly {
+			line := append(scanner.Bytes(), '\n')
+			if _, err := emitter.out.Write(line); err != nil {
+				return true, nil // Exit gracefully if stdout is closed
+			}
+			continue
+		}
+		var snap RobotWatchSnapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snap); err != nil {
+			continue
+		}
+		if err := emitter.emit(snap); err != nil {
+			return true, nil // Exit gracefully if stdout is closed
+		}
+	}
+	if ctx.Err() != nil {
+		return true, nil
+	}
+	// Daemon went away mid-stream; let the caller carry on locally.
+	return false, nil
+}
+
+// robotWatchSnapshot is the daemon's watch hub snapshot: the same per-provider
+// status a standalone `robot watch` emits.
+func robotWatchSnapshot() ([]daemon.ProviderSnapshot, error) {
+	var out []daemon.ProviderSnapshot
+	st, closeState := openRobotState(context.Background(), toolNames()...)
+	defer closeState()
+	infos, _ := st.providerInfos(context.Background(), toolNames(), true, defaultRobotScanTimeout)
+	for _, info := range infos {
+		data, err := json.Marshal(info)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s status: %w", info.ID, err)
+		}
+		out = append(out, daemon.ProviderSnapshot{Provider: info.ID, Data: data})
+	}
+	return out, nil
 }
 
 // ============================================================================
@@ -1161,6 +2467,8 @@ func runRobotQuickStart(cmd *cobra.Command, args []string) error {
 caam robot status              # Full system overview
 caam robot status claude       # Single provider
 caam robot next claude         # Best profile recommendation
+caam robot next --all-providers # Best profile across claude, codex, gemini
+caam robot explain claude work # Why robot next scores a profile as it does
 caam robot limits claude       # Rate limits + burn rate
 caam robot precheck claude     # Session planner
 ` + "```" + `
@@ -1169,6 +2477,7 @@ caam robot precheck claude     # Session planner
 ` + "```" + `
 caam robot act activate claude <profile>   # Switch profile
 caam robot act cooldown claude <profile> 1h  # Set cooldown
+caam robot act cooldown claude <profile> --auto  # Cooldown until the limit resets
 caam robot act uncooldown claude <profile>   # Clear cooldown
 caam robot act backup claude [name]          # Backup current auth
 caam robot act delete claude <profile>       # Delete profile
@@ -1182,6 +2491,7 @@ caam robot doctor              # Full diagnostics
 caam robot validate claude     # Token validation
 caam robot paths               # Auth file locations
 caam robot history             # Recent activity
+caam robot history --sessions  # Sessions and hours per profile this week
 ` + "```" + `
 
 ## Configuration
@@ -1207,7 +2517,9 @@ All commands return:
 ## Error Codes
 - INVALID_PROVIDER: Unknown provider (use: claude, codex, gemini)
 - NO_PROFILES: No profiles exist for provider
-- ALL_BLOCKED: All profiles in cooldown/unhealthy
+- ALL_BLOCKED: All profiles in cooldown/unhealthy/leased
+- SHAPING_BLOCKED: Rotation shaping forbids the activation now
+- PROFILE_LEASED: Someone else holds the profile's lease (caam lease list)
 - MISSING_PROFILE: Profile name required
 - VAULT_ERROR: Cannot access profile storage
 
@@ -1230,9 +2542,17 @@ All commands return:
 var robotLimitsCmd = &cobra.Command{
 	Use:   "limits <provider>",
 	Short: "Fetch rate limits and burn rate",
-	Long: `Fetches real-time rate limit data from provider APIs.
-
-Returns usage percentages, reset times, burn rates, and depletion forecasts.
+	Long: `Fetches each profile's rate-limit usage from its provider's API
+(Claude OAuth usage, Codex usage and rate-limit headers, Gemini Code Assist
+quotas) and reports usage percentages, reset times, and an availability
+score. Readings are cached per profile; --no-fetch reports the cached ones,
+and a failed fetch falls back to them. Profiles without a reading are scored
+from health data.
+
+With --forecast, adds burn rates, usage percentages, and depletion forecasts
+computed from token usage recorded with "caam usage record" (or the daemon's
+/usage/record endpoint). Percentages and depletion times need the plan's
+allowance in subscriptions.<provider>.token_limit and limit_window.
 Useful for deciding when to switch profiles.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runRobotLimits,
@@ -1245,8 +2565,25 @@ var robotPrecheckCmd = &cobra.Command{
 - Recommended profile with score breakdown
 - Backup profiles in priority order
 - Profiles in cooldown
-- Usage forecasts and alerts
-- Quick action commands`,
+- Usage forecasts: utilization, burn rate, and when each profile hits its
+  limit, from the provider's rate-limit API (cached readings with
+  --no-fetch) and usage recorded with 'caam usage record'
+- A session budget for the recommended profile: the tokens and requests it
+  can spend over --session without hitting its limit (tokens need
+  subscriptions.<provider>.token_limit in config.yaml)
+- Alternates: the best ready profile of each other provider, plan B if
+  this provider runs dry
+- Alerts and quick action commands
+
+Profiles near their limit or expected to hit it during the session score
+lower (scoring.weights.near_limit and depletes_in_session).
+
+With --prepare, the recommended profile's token is refreshed if it would
+expire before --session is over, then re-read to confirm the new expiry.
+If it can't be made to last the session (refresh unsupported, revoked, or
+failed), the next backup is tried and promoted instead, so the agent starts
+with a credential that outlives the planned session. data.prepare reports
+what was done; success is false when no profile could be prepared.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runRobotPrecheck,
 }
@@ -1258,7 +2595,13 @@ var robotValidateCmd = &cobra.Command{
 
 Without arguments, validates all profiles.
 With provider, validates all profiles for that provider.
-With provider and profile, validates that specific profile.`,
+With provider and profile, validates that specific profile.
+
+By default only token expiry is checked. With --active, each profile's
+token is sent on one cheap authenticated call (Claude's OAuth profile, the
+Codex usage endpoint, Gemini's loadCodeAssist), which reports the HTTP
+status and the plan and organization the provider sees. Errors the provider
+returns are counted in the profile's health.`,
 	Args: cobra.MaximumNArgs(2),
 	RunE: runRobotValidate,
 }
@@ -1272,7 +2615,13 @@ var robotDoctorCmd = &cobra.Command{
 - Config validation
 - Profile integrity
 - Lock file status
-- Auth file status`,
+- Auth file status
+
+When checks fail or warn, data.fix_plan lists ordered remediation steps. Each
+step has the exact command (if any), a risk level (low, medium, high),
+auto_fix (whether --fix applies it), and interactive (whether a human must
+take part, e.g. an OAuth login). Agents can run low-risk steps and ask for
+approval on the rest.`,
 	RunE: runRobotDoctor,
 }
 
@@ -1289,17 +2638,34 @@ var robotHistoryCmd = &cobra.Command{
 	Long: `Returns recent activity events from the database.
 
 Use --days to specify how many days of history to fetch.
-Use --limit to limit the number of events.`,
+Use --limit to limit the number of events.
+Use --sessions to return working sessions (which profile served which
+directory, for how long, and how it exited) and each profile's hours this
+week instead of activity events.`,
 	RunE: runRobotHistory,
 }
 
 var robotConfigCmd = &cobra.Command{
-	Use:   "config [set <key> <value>]",
+	Use:   "config [set <key> <value> | keys]",
 	Short: "View or modify configuration",
 	Long: `View or modify caam configuration.
 
-Without arguments, returns full config as JSON.
-With 'set <key> <value>', updates a config value.`,
+Without arguments, returns the full config as JSON: config.json, config.yaml
+(spm_config), and the sync pool's switches.
+
+'set <key> <value>' changes any setting by its dotted path, e.g.
+stealth.rotation.algorithm, health.refresh_threshold, notifications.enabled,
+plan_weights.max, automation.claude.auto_rotate, or sync.auto. The value is
+checked against the setting's type, an unknown key returns the closest
+known ones, and the output is the full resulting config.
+
+'keys' lists every settable key with its type and file.
+
+Examples:
+  caam robot config set stealth.rotation.algorithm round_robin
+  caam robot config set health.refresh_threshold 15m
+  caam robot config set sync.auto true
+  caam robot config keys`,
 	RunE: runRobotConfig,
 }
 
@@ -1322,24 +2688,45 @@ func init() {
 	robotStatusCmd.Flags().String("provider", "", "filter to specific provider")
 	robotStatusCmd.Flags().Bool("compact", false, "minimal output")
 	robotStatusCmd.Flags().Bool("include-coordinators", false, "check coordinator status")
+	robotStatusCmd.Flags().StringArray("tag", nil, "only profiles with this tag (key or key=value, repeatable)")
+	robotStatusCmd.Flags().Duration("timeout", defaultRobotScanTimeout, "maximum time to scan each provider's profiles (0 = no limit)")
 
 	// Next flags
-	robotNextCmd.Flags().String("strategy", "smart", "selection strategy: smart, lru, random")
+	robotNextCmd.Flags().String("strategy", "smart", "selection strategy: smart, lru, round-robin, weighted, least-used-today, sticky, random")
 	robotNextCmd.Flags().Bool("include-cooldown", false, "include profiles in cooldown")
+	robotNextCmd.Flags().Bool("include-suspect", false, "include profiles whose history looks like a flagged account")
+	robotNextCmd.Flags().String("workspace", "", "only profiles authorized for this workspace ID or name")
+	robotNextCmd.Flags().StringArray("tag", nil, "only profiles with this tag (key or key=value, repeatable)")
+	robotNextCmd.Flags().Bool("all-providers", false, "rank profiles across all providers")
+
+	// Act flags
+	robotActCmd.Flags().String("plan", "", "run a JSON array of actions from a file (- for stdin)")
+	robotActCmd.Flags().Bool("atomic", false, "with --plan, stop at the first failure and roll back activations")
+	robotActCmd.Flags().Bool("auto", false, "cooldown: last until the profile's limit resets")
+	robotActCmd.Flags().Bool("ignore-shaping", false, "activate: skip the rotation shaping rules (stealth.shaping)")
+	robotActCmd.Flags().Bool("dry-run", false, "activate: report which auth files would change without activating")
 
 	// Watch flags
 	robotWatchCmd.Flags().Int("interval", 5, "poll interval in seconds")
 	robotWatchCmd.Flags().String("provider", "", "filter to specific provider")
+	robotWatchCmd.Flags().Bool("no-daemon", false, "poll locally even if the daemon is running")
+	robotWatchCmd.Flags().Bool("changes-only", false, "emit typed change events instead of full snapshots")
+	robotWatchCmd.Flags().Int("heartbeat", 30, "with --changes-only, seconds without changes before a heartbeat event (0 disables)")
 
 	// Limits flags
-	robotLimitsCmd.Flags().Bool("forecast", false, "include depletion forecasts")
+	robotLimitsCmd.Flags().Bool("forecast", false, "include burn rates and depletion forecasts from recorded usage")
+	robotLimitsCmd.Flags().Bool("no-fetch", false, "skip API calls (use cached readings)")
+	robotLimitsCmd.Flags().Duration("timeout", 30*time.Second, "API fetch timeout")
 
 	// Precheck flags
 	robotPrecheckCmd.Flags().Duration("timeout", 30*time.Second, "API fetch timeout")
 	robotPrecheckCmd.Flags().Bool("no-fetch", false, "skip API calls (use cached data)")
+	robotPrecheckCmd.Flags().Bool("prepare", false, "refresh the recommended profile's token if it would expire during the session")
+	robotPrecheckCmd.Flags().Duration("session", 2*time.Hour, "planned session length, for the budget and --prepare")
 
 	// Validate flags
-	robotValidateCmd.Flags().Bool("active", false, "perform active validation (API calls)")
+	robotValidateCmd.Flags().Bool("active", false, "check each token with an authenticated API call")
+	robotValidateCmd.Flags().Duration("timeout", defaultRobotScanTimeout, "maximum time to validate each provider's profiles (0 = no limit)")
 
 	// Doctor flags
 	robotDoctorCmd.Flags().Bool("fix", false, "attempt to fix issues")
@@ -1349,6 +2736,7 @@ func init() {
 	robotHistoryCmd.Flags().Int("days", 7, "number of days of history")
 	robotHistoryCmd.Flags().Int("limit", 50, "max events to return")
 	robotHistoryCmd.Flags().String("provider", "", "filter to specific provider")
+	robotHistoryCmd.Flags().Bool("sessions", false, "return working sessions and hours per profile this week")
 }
 
 // RobotLimitsData contains rate limit information.
@@ -1368,6 +2756,15 @@ type RobotProfileLimits struct {
 	DepletesIn     string `json:"depletes_in,omitempty"`
 	Error          string `json:"error,omitempty"`
 	Recommendation string `json:"recommendation,omitempty"`
+
+	// FetchedAt is when the provider's rate-limit reading was taken; Cached
+	// marks a reading reused from the database instead of fetched now.
+	FetchedAt string `json:"fetched_at,omitempty"`
+	Cached    bool   `json:"cached,omitempty"`
+
+	// Forecast is set with --forecast, from usage recorded by
+	// "caam usage record" or the daemon's /usage/record endpoint.
+	Forecast *usage.Forecast `json:"forecast,omitempty"`
 }
 
 func runRobotLimits(cmd *cobra.Command, args []string) error {
@@ -1375,23 +2772,25 @@ func runRobotLimits(cmd *cobra.Command, args []string) error {
 	provider := strings.ToLower(args[0])
 
 	if _, ok := tools[provider]; !ok {
-		return robotError(cmd, "limits", "INVALID_PROVIDER",
+		return robotError(cmd, "limits", caamerr.InvalidProvider,
 			fmt.Sprintf("unknown provider: %s", provider),
-			"valid providers: codex, claude, gemini",
+			"valid providers: "+strings.Join(toolNames(), ", "),
 			nil)
 	}
 
-	// For now, use health data as a proxy. Full implementation would call usage APIs.
+	// Availability comes from the provider's rate-limit API where one exists
+	// (cached per profile in the DB), else from health data. --forecast adds
+	// burn rates and depletion times computed from metered usage.
 	profiles, err := vault.List(provider)
 	if err != nil {
-		return robotError(cmd, "limits", "VAULT_ERROR",
+		return robotError(cmd, "limits", caamerr.VaultError,
 			"failed to list profiles",
 			err.Error(),
 			nil)
 	}
 
 	if len(profiles) == 0 {
-		return robotError(cmd, "limits", "NO_PROFILES",
+		return robotError(cmd, "limits", caamerr.NoProfiles,
 			fmt.Sprintf("no profiles found for %s", provider),
 			"",
 			[]string{fmt.Sprintf("caam backup %s <name>", provider)})
@@ -1402,6 +2801,36 @@ func runRobotLimits(cmd *cobra.Command, args []string) error {
 		Profiles: make([]RobotProfileLimits, 0, len(profiles)),
 	}
 
+	forecast, _ := cmd.Flags().GetBool("forecast")
+	noFetch, _ := cmd.Flags().GetBool("no-fetch")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	usageDB, err := caamdb.Open()
+	if err != nil {
+		return robotError(cmd, "limits", caamerr.DBError,
+			"failed to open usage database",
+			err.Error(),
+			nil)
+	}
+	defer usageDB.Close()
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	st := loadRobotState(ctx, usageDB, provider)
+
+	var tokenLimit int64
+	var limitWindow time.Duration
+	if forecast {
+		sub := st.SPM.Subscriptions[provider]
+		tokenLimit, limitWindow = sub.TokenLimit, sub.LimitWindow.Duration()
+	}
+
+	readings := make(map[string]*usage.UsageInfo)
+	if !noFetch {
+		readings = fetchUsageReadings(ctx, usageDB, provider, timeout)
+	}
+
 	for _, profileName := range profiles {
 		if strings.HasPrefix(profileName, "_") {
 			continue
@@ -1412,7 +2841,7 @@ func runRobotLimits(cmd *cobra.Command, args []string) error {
 		}
 
 		// Get health info for estimates
-		ph, _ := getProfileHealthWithIdentity(provider, profileName)
+		ph, _ := st.profileHealth(provider, profileName)
 		status := health.CalculateStatus(ph)
 
 		switch status {
@@ -1430,6 +2859,21 @@ func runRobotLimits(cmd *cobra.Command, args []string) error {
 			limits.Recommendation = "status unknown"
 		}
 
+		reading, cached, fetchErr := usageReading(usageDB, provider, profileName, readings[profileName])
+		if cached {
+			limits.Cached = true
+			if fetchErr != "" {
+				limits.Error = "fetch failed, using cached reading: " + fetchErr
+			}
+		}
+		if reading != nil {
+			applyUsageReading(&limits, reading, status)
+		}
+
+		if forecast {
+			applyUsageForecast(&limits, usageDB, provider, reading, tokenLimit, limitWindow)
+		}
+
 		data.Profiles = append(data.Profiles, limits)
 	}
 
@@ -1447,15 +2891,132 @@ func runRobotLimits(cmd *cobra.Command, args []string) error {
 	return robotOutput(cmd, output)
 }
 
+// applyUsageReading replaces health-based estimates with a provider's
+// rate-limit reading. A profile whose health is critical stays capped.
+func applyUsageReading(limits *RobotProfileLimits, reading *usage.UsageInfo, status health.HealthStatus) {
+	if reading.Error != "" {
+		limits.Error = reading.Error
+		return
+	}
+
+	limits.FetchedAt = reading.FetchedAt.UTC().Format(time.RFC3339)
+	if reading.PrimaryWindow != nil {
+		limits.PrimaryPct = reading.PrimaryWindow.UsedPercent
+	}
+	if reading.SecondaryWindow != nil {
+		limits.SecondaryPct = reading.SecondaryWindow.UsedPercent
+	}
+	if ttl := reading.TimeUntilReset(); ttl > 0 {
+		limits.ResetsIn = formatDurationShort(ttl)
+	}
+	if reading.BurnRate != nil {
+		limits.BurnRate = reading.BurnRate.String()
+	}
+	if ttd := reading.TimeToDepletion(); ttd > 0 {
+		limits.DepletesIn = formatDurationShort(ttd)
+	}
+
+	limits.AvailScore = reading.AvailabilityScore()
+	switch {
+	case reading.IsNearLimit(0.95):
+		limits.Recommendation = "avoid - at rate limit"
+	case reading.IsNearLimit(0.8):
+		limits.Recommendation = "use with caution - near rate limit"
+	default:
+		limits.Recommendation = "ready to use"
+	}
+	if status == health.StatusCritical && limits.AvailScore > 10 {
+		limits.AvailScore = 10
+		limits.Recommendation = "avoid - issues detected"
+	}
+}
+
+// applyUsageForecast fills a profile's burn rate and depletion forecast from
+// metered usage, lowering its availability when the limit is near. With a
+// provider reading, depletion is projected from its utilization instead of
+// the metered total.
+func applyUsageForecast(limits *RobotProfileLimits, db *caamdb.DB, provider string, reading *usage.UsageInfo, tokenLimit int64, limitWindow time.Duration) {
+	window := limitWindow
+	if window <= 0 {
+		window = usage.DefaultForecastWindow
+	}
+	records, err := db.UsageSince(provider, limits.Name, time.Now().Add(-window))
+	if err != nil {
+		limits.Error = err.Error()
+		return
+	}
+
+	f := usage.ForecastFromRecords(records, tokenLimit, limitWindow)
+	limits.Forecast = f
+	if f.BurnRate != nil {
+		limits.BurnRate = f.BurnRate.String()
+	}
+
+	live := reading != nil && reading.Error == "" && reading.PrimaryWindow != nil
+	if live {
+		if f.BurnRate != nil && f.BurnRate.PercentPerHour > 0 {
+			reading.BurnRate = f.BurnRate
+			reading.UpdateDepletion()
+			if ttd := reading.TimeToDepletion(); ttd > 0 {
+				f.DepletesIn = ttd
+				f.DepletesAt = reading.EstimatedDepletion
+			}
+		}
+	} else if f.TokenLimit > 0 {
+		limits.PrimaryPct = int(math.Round(f.UsedPercent))
+	}
+
+	switch {
+	case !live && f.Depleted():
+		limits.AvailScore = 0
+		limits.Recommendation = "token limit reached - switch profiles"
+	case f.DepletesIn > 0:
+		limits.DepletesIn = formatDurationShort(f.DepletesIn)
+		if f.DepletesIn < 30*time.Minute && limits.AvailScore > 10 {
+			limits.AvailScore = 10
+			limits.Recommendation = "depletes soon at current burn rate"
+		} else if f.DepletesIn < 2*time.Hour && limits.AvailScore > 50 {
+			limits.AvailScore = 50
+			limits.Recommendation = "use with caution - limit within reach"
+		}
+	}
+}
+
 // RobotPrecheckData contains session planning data.
 type RobotPrecheckData struct {
-	Provider    string                  `json:"provider"`
-	Recommended *RobotPrecheckProfile   `json:"recommended,omitempty"`
-	Backups     []RobotPrecheckProfile  `json:"backups"`
-	InCooldown  []RobotCooldownProfile  `json:"in_cooldown"`
-	Alerts      []RobotPrecheckAlert    `json:"alerts,omitempty"`
-	Summary     RobotPrecheckSummary    `json:"summary"`
-	Commands    RobotPrecheckCommands   `json:"commands"`
+	Provider    string                   `json:"provider"`
+	Recommended *RobotPrecheckProfile    `json:"recommended,omitempty"`
+	Backups     []RobotPrecheckProfile   `json:"backups"`
+	InCooldown  []RobotCooldownProfile   `json:"in_cooldown"`
+	Alerts      []RobotPrecheckAlert     `json:"alerts,omitempty"`
+	Summary     RobotPrecheckSummary     `json:"summary"`
+	Commands    RobotPrecheckCommands    `json:"commands"`
+	Prepare     *RobotPrecheckPrepare    `json:"prepare,omitempty"`
+	Budget      *RobotSessionBudget      `json:"budget,omitempty"`
+	Alternates  []RobotPrecheckAlternate `json:"alternates"`
+}
+
+// RobotPrecheckPrepare reports the outcome of precheck --prepare.
+type RobotPrecheckPrepare struct {
+	Profile     string                `json:"profile,omitempty"`
+	Ready       bool                  `json:"ready"`
+	SessionEnds string                `json:"session_ends"`
+	Attempts    []RobotPrepareAttempt `json:"attempts"`
+}
+
+// RobotPrepareAttempt is one profile tried by precheck --prepare.
+type RobotPrepareAttempt struct {
+	Profile string `json:"profile"`
+	// Action is "none" (already fresh), "refreshed", "unsupported",
+	// "revoked", or "failed".
+	Action    string `json:"action"`
+	Ready     bool   `json:"ready"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	ExpiresIn string `json:"expires_in,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	HumanAction *RobotHumanAction `json:"human_action,omitempty"`
 }
 
 // RobotPrecheckProfile is a profile with recommendation data.
@@ -1465,6 +3026,8 @@ type RobotPrecheckProfile struct {
 	Health     string   `json:"health"`
 	Reasons    []string `json:"reasons"`
 	PoolStatus string   `json:"pool_status,omitempty"`
+
+	Forecast *RobotPrecheckForecast `json:"forecast,omitempty"`
 }
 
 // RobotCooldownProfile is a profile in cooldown.
@@ -1490,6 +3053,9 @@ type RobotPrecheckSummary struct {
 	Healthy    int `json:"healthy"`
 	Warning    int `json:"warning"`
 	Critical   int `json:"critical"`
+	// NearLimit counts ready profiles with 80% or more of their limit used
+	// or expected to hit it during the session.
+	NearLimit int `json:"near_limit"`
 }
 
 // RobotPrecheckCommands contains suggested commands.
@@ -1497,6 +3063,8 @@ type RobotPrecheckCommands struct {
 	Activate string `json:"activate,omitempty"`
 	Next     string `json:"next"`
 	Run      string `json:"run"`
+	// PlanB activates the first alternate provider's profile.
+	PlanB string `json:"plan_b,omitempty"`
 }
 
 func runRobotPrecheck(cmd *cobra.Command, args []string) error {
@@ -1504,20 +3072,26 @@ func runRobotPrecheck(cmd *cobra.Command, args []string) error {
 	provider := strings.ToLower(args[0])
 
 	if _, ok := tools[provider]; !ok {
-		return robotError(cmd, "precheck", "INVALID_PROVIDER",
+		return robotError(cmd, "precheck", caamerr.InvalidProvider,
 			fmt.Sprintf("unknown provider: %s", provider),
-			"valid providers: codex, claude, gemini",
+			"valid providers: "+strings.Join(toolNames(), ", "),
 			nil)
 	}
 
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, listSpan := tracing.Start(ctx, "vault.list", tracing.Provider(provider))
 	profiles, err := vault.List(provider)
+	tracing.End(listSpan, err)
 	if err != nil {
-		return robotError(cmd, "precheck", "VAULT_ERROR",
+		return robotError(cmd, "precheck", caamerr.VaultError,
 			"failed to list profiles", err.Error(), nil)
 	}
 
 	if len(profiles) == 0 {
-		return robotError(cmd, "precheck", "NO_PROFILES",
+		return robotError(cmd, "precheck", caamerr.NoProfiles,
 			fmt.Sprintf("no profiles found for %s", provider),
 			"",
 			[]string{fmt.Sprintf("caam backup %s <name>", provider)})
@@ -1529,20 +3103,33 @@ func runRobotPrecheck(cmd *cobra.Command, args []string) error {
 			db.Close()
 		}
 	}()
+	st := loadRobotState(ctx, db, provider)
 
 	data := RobotPrecheckData{
 		Provider:   provider,
 		Backups:    make([]RobotPrecheckProfile, 0),
 		InCooldown: make([]RobotCooldownProfile, 0),
+		Alternates: make([]RobotPrecheckAlternate, 0),
 		Commands: RobotPrecheckCommands{
 			Next: fmt.Sprintf("caam robot next %s", provider),
 			Run:  fmt.Sprintf("caam run %s -- <command>", provider),
 		},
 	}
 
-	now := time.Now()
-	var bestScore float64 = -9999
-	var bestProfile string
+	now := st.Now
+	scoring := st.SPM.Scoring
+	w := scoring.Weights
+	sub := st.SPM.Subscriptions[provider]
+	session, _ := cmd.Flags().GetDuration("session")
+	var ready []RobotPrecheckProfile
+
+	readings := map[string]*usage.UsageInfo{}
+	if noFetch, _ := cmd.Flags().GetBool("no-fetch"); !noFetch {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		fetchCtx, fetchSpan := tracing.Start(ctx, "usage.fetch", tracing.Provider(provider))
+		readings = fetchUsageReadings(fetchCtx, db, provider, timeout)
+		fetchSpan.End()
+	}
 
 	for _, profileName := range profiles {
 		if strings.HasPrefix(profileName, "_") {
@@ -1552,24 +3139,33 @@ func runRobotPrecheck(cmd *cobra.Command, args []string) error {
 		data.Summary.Total++
 
 		// Check cooldown
-		if db != nil {
-			if ev, err := db.ActiveCooldown(provider, profileName, now); err == nil && ev != nil {
-				remaining := time.Until(ev.CooldownUntil)
-				if remaining > 0 {
-					data.InCooldown = append(data.InCooldown, RobotCooldownProfile{
-						Name:      profileName,
-						Remaining: robotFormatDuration(remaining),
-						Until:     ev.CooldownUntil.Format(time.RFC3339),
-					})
-					data.Summary.InCooldown++
-					continue
-				}
+		if ev := st.Cooldown(provider, profileName); ev != nil {
+			remaining := time.Until(ev.CooldownUntil)
+			if remaining > 0 {
+				data.InCooldown = append(data.InCooldown, RobotCooldownProfile{
+					Name:      profileName,
+					Remaining: robotFormatDuration(remaining),
+					Until:     ev.CooldownUntil.Format(time.RFC3339),
+				})
+				data.Summary.InCooldown++
+				continue
 			}
 		}
+		if ev := st.AwaitingProbe(provider, profileName); ev != nil {
+			data.InCooldown = append(data.InCooldown, RobotCooldownProfile{
+				Name:      profileName,
+				Remaining: "awaiting probe",
+				Until:     ev.CooldownUntil.Format(time.RFC3339),
+			})
+			data.Summary.InCooldown++
+			continue
+		}
 
 		// Get health
-		ph, _ := getProfileHealthWithIdentity(provider, profileName)
+		_, healthSpan := tracing.Start(ctx, "health.profile", tracing.Provider(provider), tracing.Profile(profileName))
+		ph, _ := st.profileHealth(provider, profileName)
 		status := health.CalculateStatus(ph)
+		healthSpan.End()
 
 		rec := RobotPrecheckProfile{
 			Name:    profileName,
@@ -1580,41 +3176,60 @@ func runRobotPrecheck(cmd *cobra.Command, args []string) error {
 		// Calculate score
 		switch status {
 		case health.StatusHealthy:
-			rec.Score = 100
+			rec.Score = w.Healthy
 			rec.Reasons = append(rec.Reasons, "+healthy status")
 			data.Summary.Healthy++
 		case health.StatusWarning:
-			rec.Score = 50
+			rec.Score = w.Warning
 			rec.Reasons = append(rec.Reasons, "-warning status")
 			data.Summary.Warning++
 		case health.StatusCritical:
-			rec.Score = 10
+			rec.Score = w.Critical
 			rec.Reasons = append(rec.Reasons, "-critical status")
 			data.Summary.Critical++
 		default:
-			rec.Score = 30
+			rec.Score = w.Unknown
+		}
+		if w.RecentUse != 0 && db != nil {
+			if last, err := db.LastActivation(provider, profileName); err == nil && !last.IsZero() && now.Sub(last) < scoring.RecentUseWindow.Duration() {
+				rec.Score += w.RecentUse
+				rec.Reasons = append(rec.Reasons, fmt.Sprintf("%+.0f activated %s ago", w.RecentUse, robotFormatDuration(now.Sub(last))))
+			}
 		}
 
-		data.Summary.Ready++
-
-		if rec.Score > bestScore {
-			bestScore = rec.Score
-			bestProfile = profileName
-			data.Recommended = &RobotPrecheckProfile{
-				Name:    rec.Name,
-				Score:   rec.Score,
-				Health:  rec.Health,
-				Reasons: rec.Reasons,
+		reading, cached, fetchErr := usageReading(db, provider, profileName, readings[profileName])
+		if rec.Forecast = precheckForecast(db, provider, profileName, reading, cached, sub); rec.Forecast != nil {
+			if fetchErr != "" {
+				rec.Forecast.Error = "fetch failed, using cached reading: " + fetchErr
+			}
+			scorePrecheckForecast(&rec, w, session)
+			if (rec.Forecast.usedKnown && rec.Forecast.UsedPercent >= nearLimitPercent) || rec.Forecast.hitsLimitWithin(session) {
+				data.Summary.NearLimit++
 			}
-		} else {
-			data.Backups = append(data.Backups, rec)
 		}
+
+		data.Summary.Ready++
+		ready = append(ready, rec)
 	}
 
-	// Update recommended from backups if needed
-	if data.Recommended == nil && len(data.Backups) > 0 {
-		data.Recommended = &data.Backups[0]
-		data.Backups = data.Backups[1:]
+	_, scriptSpan := tracing.Start(ctx, "scoring.script", tracing.Provider(provider))
+	applyPrecheckScoringScript(scoring, provider, ready)
+	scriptSpan.End()
+
+	// The highest score is recommended; the rest are backups, in order.
+	best := -1
+	for i := range ready {
+		if best < 0 || ready[i].Score > ready[best].Score {
+			best = i
+		}
+	}
+	for i := range ready {
+		if i == best {
+			rec := ready[i]
+			data.Recommended = &rec
+		} else {
+			data.Backups = append(data.Backups, ready[i])
+		}
 	}
 
 	if data.Recommended != nil {
@@ -1639,11 +3254,32 @@ func runRobotPrecheck(cmd *cobra.Command, args []string) error {
 		})
 	}
 
-	_ = bestProfile // Used above
+	data.Alternates = precheckAlternates(ctx, db, provider)
+	if len(data.Alternates) > 0 {
+		data.Commands.PlanB = data.Alternates[0].Activate
+	}
+	data.Alerts = append(data.Alerts, precheckForecastAlerts(&data, ready, session)...)
+
+	success := true
+	if prepare, _ := cmd.Flags().GetBool("prepare"); prepare && data.Recommended != nil {
+		data.Prepare = prepareRecommended(ctx, provider, &data, session)
+		if !data.Prepare.Ready {
+			success = false
+			data.Alerts = append(data.Alerts, RobotPrecheckAlert{
+				Type:    "no_fresh_credential",
+				Message: fmt.Sprintf("no profile has a token valid for the next %s", robotFormatDuration(session)),
+				Urgency: "high",
+				Action:  "log in again (see data.prepare.attempts[].human_action) or shorten --session",
+			})
+		}
+	}
+	if data.Recommended != nil {
+		data.Budget = precheckSessionBudget(data.Recommended, sub, session)
+	}
 
 	duration := time.Since(start)
 	output := RobotOutput{
-		Success: true,
+		Success: success,
 		Command: "precheck",
 		Data:    data,
 		Timing: &RobotTiming{
@@ -1655,6 +3291,159 @@ func runRobotPrecheck(cmd *cobra.Command, args []string) error {
 	return robotOutput(cmd, output)
 }
 
+// precheckRefreshProfile refreshes a vault profile's token; tests replace it.
+var precheckRefreshProfile = refresh.RefreshProfile
+
+// robotCheckToken makes an active token check for validate --active; tests
+// replace it.
+var robotCheckToken = usage.NewTokenChecker().CheckToken
+
+// prepareRecommended makes sure the recommended profile's token outlives the
+// session, refreshing it if needed. When it can't, backups are tried in order
+// and the first one that works is promoted to recommended.
+func prepareRecommended(ctx context.Context, provider string, data *RobotPrecheckData, session time.Duration) *RobotPrecheckPrepare {
+	sessionEnd := time.Now().Add(session)
+	result := &RobotPrecheckPrepare{
+		SessionEnds: sessionEnd.UTC().Format(time.RFC3339),
+		Attempts:    make([]RobotPrepareAttempt, 0),
+	}
+
+	candidates := []string{data.Recommended.Name}
+	for _, b := range data.Backups {
+		candidates = append(candidates, b.Name)
+	}
+
+	for i, name := range candidates {
+		attempt := prepareProfileForSession(ctx, provider, name, sessionEnd)
+		result.Attempts = append(result.Attempts, attempt)
+		if !attempt.Ready {
+			continue
+		}
+		result.Ready = true
+		result.Profile = name
+
+		if i > 0 {
+			demoted := *data.Recommended
+			promoted := data.Backups[i-1]
+			backups := make([]RobotPrecheckProfile, 0, len(data.Backups))
+			backups = append(backups, demoted)
+			backups = append(backups, data.Backups[:i-1]...)
+			backups = append(backups, data.Backups[i:]...)
+			data.Backups = backups
+			promoted.Reasons = append(promoted.Reasons, "+token valid for session")
+			data.Recommended = &promoted
+			data.Commands.Activate = fmt.Sprintf("caam robot act activate %s %s", provider, name)
+		}
+		break
+	}
+	return result
+}
+
+// prepareProfileForSession refreshes a profile's token if it expires before
+// sessionEnd, then re-reads the expiry to confirm the refresh took.
+func prepareProfileForSession(ctx context.Context, provider, profile string, sessionEnd time.Time) RobotPrepareAttempt {
+	attempt := RobotPrepareAttempt{Profile: profile, Action: "none"}
+	setExpiry := func(expiresAt time.Time) {
+		if expiresAt.IsZero() {
+			return
+		}
+		attempt.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+		if remaining := time.Until(expiresAt); remaining > 0 {
+			attempt.ExpiresIn = robotFormatDuration(remaining)
+		} else {
+			attempt.ExpiresIn = "expired"
+		}
+	}
+
+	expiresAt, hasRefresh, err := sessionTokenExpiry(provider, profile)
+	if err != nil {
+		attempt.Action = "failed"
+		attempt.Error = err.Error()
+		attempt.HumanAction = buildHumanLoginAction(provider, profile)
+		return attempt
+	}
+	setExpiry(expiresAt)
+
+	if expiresAt.IsZero() {
+		attempt.Ready = true
+		attempt.Reason = "token expiry unknown"
+		return attempt
+	}
+	if expiresAt.After(sessionEnd) {
+		attempt.Ready = true
+		attempt.Reason = "token valid past session end"
+		return attempt
+	}
+	if !hasRefresh {
+		attempt.Action = "unsupported"
+		attempt.Reason = "token expires during session and has no refresh token"
+		attempt.HumanAction = buildHumanLoginAction(provider, profile)
+		return attempt
+	}
+
+	if err := precheckRefreshProfile(ctx, provider, profile, vault, healthStore); err != nil {
+		var revoked *refresh.RevokedError
+		switch {
+		case errors.Is(err, refresh.ErrUnsupported):
+			attempt.Action = "unsupported"
+		case errors.As(err, &revoked):
+			attempt.Action = "revoked"
+			quarantineRevoked(err, provider, profile, true)
+		default:
+			attempt.Action = "failed"
+		}
+		attempt.Reason = "token expires during session"
+		attempt.Error = err.Error()
+		attempt.HumanAction = buildHumanLoginAction(provider, profile)
+		return attempt
+	}
+	attempt.Action = "refreshed"
+
+	// Re-validate from disk rather than trusting the refresh call.
+	expiresAt, _, err = sessionTokenExpiry(provider, profile)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	attempt.ExpiresAt, attempt.ExpiresIn = "", ""
+	setExpiry(expiresAt)
+	switch {
+	case expiresAt.IsZero():
+		attempt.Ready = true
+		attempt.Reason = "refreshed; token expiry unknown"
+	case expiresAt.After(sessionEnd):
+		attempt.Ready = true
+		attempt.Reason = "refreshed; token valid past session end"
+	default:
+		attempt.Reason = "refreshed token still expires during session"
+	}
+	return attempt
+}
+
+// sessionTokenExpiry returns a vault profile's token expiry and whether it
+// can be refreshed. Gemini refreshes record the new expiry in health
+// metadata rather than the auth files, so the later of the two is used.
+func sessionTokenExpiry(provider, profile string) (time.Time, bool, error) {
+	var expiresAt time.Time
+	hasRefresh := false
+
+	info, err := loadExpiryInfo(provider, profile)
+	switch {
+	case err == nil && info != nil:
+		expiresAt = info.ExpiresAt
+		hasRefresh = info.HasRefreshToken
+	case err != nil && !errors.Is(err, health.ErrNoExpiry):
+		return time.Time{}, false, err
+	}
+
+	if provider == "gemini" && healthStore != nil {
+		if h, err := healthStore.GetProfile(provider, profile); err == nil && h != nil && h.TokenExpiresAt.After(expiresAt) {
+			expiresAt = h.TokenExpiresAt
+		}
+	}
+	return expiresAt, hasRefresh, nil
+}
+
 // RobotValidateData contains validation results.
 type RobotValidateData struct {
 	Method   string                `json:"method"`
@@ -1670,6 +3459,26 @@ type RobotValidateResult struct {
 	ExpiresAt string `json:"expires_at,omitempty"`
 	ExpiresIn string `json:"expires_in,omitempty"`
 	Error     string `json:"error,omitempty"`
+
+	// Method is "active" when the provider was asked about the token, and
+	// "passive" when only local data was used.
+	Method string `json:"method"`
+	// Confidence is how sure the verdict is: "high" when the provider
+	// accepted or rejected the token (or it has plainly expired), "medium"
+	// for an answer that didn't settle it, "low" for a guess from local data.
+	Confidence string `json:"confidence"`
+	// HTTPStatus, Email, Plan, Organization and LatencyMs come from an
+	// active check's response.
+	HTTPStatus   int    `json:"http_status,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Plan         string `json:"plan,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	LatencyMs    int64  `json:"latency_ms,omitempty"`
+	// Note explains a verdict that isn't an error, such as why an active
+	// check fell back to local data.
+	Note string `json:"note,omitempty"`
+
+	HumanAction *RobotHumanAction `json:"human_action,omitempty"`
 }
 
 // RobotValidateSummary contains validation summary.
@@ -1681,6 +3490,8 @@ type RobotValidateSummary struct {
 
 func runRobotValidate(cmd *cobra.Command, args []string) error {
 	start := time.Now()
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	active, _ := cmd.Flags().GetBool("active")
 
 	var providersToCheck []string
 	var profileFilter string
@@ -1688,9 +3499,9 @@ func runRobotValidate(cmd *cobra.Command, args []string) error {
 	if len(args) >= 1 {
 		provider := strings.ToLower(args[0])
 		if _, ok := tools[provider]; !ok {
-			return robotError(cmd, "validate", "INVALID_PROVIDER",
+			return robotError(cmd, "validate", caamerr.InvalidProvider,
 				fmt.Sprintf("unknown provider: %s", provider),
-				"valid providers: codex, claude, gemini",
+				"valid providers: "+strings.Join(toolNames(), ", "),
 				nil)
 		}
 		providersToCheck = []string{provider}
@@ -1698,55 +3509,84 @@ func runRobotValidate(cmd *cobra.Command, args []string) error {
 			profileFilter = args[1]
 		}
 	} else {
-		providersToCheck = []string{"codex", "claude", "gemini"}
+		providersToCheck = toolNames()
 	}
 
 	data := RobotValidateData{
 		Method:   "passive",
 		Profiles: make([]RobotValidateResult, 0),
 	}
+	if active {
+		data.Method = "active"
+	}
 
-	for _, provider := range providersToCheck {
-		profiles, err := vault.List(provider)
-		if err != nil {
-			continue
-		}
-
-		for _, profileName := range profiles {
-			if strings.HasPrefix(profileName, "_") {
-				continue
-			}
-			if profileFilter != "" && profileName != profileFilter {
-				continue
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Providers, and each provider's profiles, are validated concurrently;
+	// results are reported in vault order.
+	results := make([][]RobotValidateResult, len(providersToCheck))
+	timings := make([]RobotProviderTiming, len(providersToCheck))
+	_ = statusengine.ForEach(ctx, len(providersToCheck), len(providersToCheck), func(ctx context.Context, i int) {
+		provider := providersToCheck[i]
+		provStart := time.Now()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		var profiles []string
+		if all, err := vault.List(provider); err == nil {
+			for _, profileName := range all {
+				if strings.HasPrefix(profileName, "_") {
+					continue
+				}
+				if profileFilter != "" && profileName != profileFilter {
+					continue
+				}
+				profiles = append(profiles, profileName)
 			}
+		}
 
-			result := RobotValidateResult{
-				Provider: provider,
-				Profile:  profileName,
+		checked := make([]*RobotValidateResult, len(profiles))
+		err := statusengine.ForEach(ctx, len(profiles), robotScanWorkers, func(ctx context.Context, j int) {
+			result := validateProfilePassive(provider, profiles[j])
+			if active {
+				validateProfileActive(ctx, &result)
 			}
-
-			// Get health info for token expiry
-			ph, _ := getProfileHealthWithIdentity(provider, profileName)
-			if !ph.TokenExpiresAt.IsZero() {
-				result.ExpiresAt = ph.TokenExpiresAt.Format(time.RFC3339)
-				remaining := time.Until(ph.TokenExpiresAt)
-				if remaining > 0 {
-					result.ExpiresIn = robotFormatDuration(remaining)
-					result.Valid = true
-					data.Summary.Valid++
-				} else {
-					result.ExpiresIn = "expired"
-					result.Valid = false
-					result.Error = "token expired"
-					data.Summary.Invalid++
+			checked[j] = &result
+		})
+		for j, result := range checked {
+			if result == nil {
+				result = &RobotValidateResult{
+					Provider:   provider,
+					Profile:    profiles[j],
+					Error:      fmt.Sprintf("not validated: %v", err),
+					Method:     data.Method,
+					Confidence: usage.ConfidenceLow,
 				}
-			} else {
-				// No expiry info - assume valid
-				result.Valid = true
-				data.Summary.Valid++
 			}
+			results[i] = append(results[i], *result)
+		}
+		timings[i] = RobotProviderTiming{
+			Provider:   provider,
+			DurationMs: time.Since(provStart).Milliseconds(),
+			Profiles:   len(profiles),
+			TimedOut:   err != nil,
+		}
+	})
 
+	for _, provResults := range results {
+		for _, result := range provResults {
 			data.Summary.Total++
+			if result.Valid {
+				data.Summary.Valid++
+			} else {
+				data.Summary.Invalid++
+			}
 			data.Profiles = append(data.Profiles, result)
 		}
 	}
@@ -1759,12 +3599,91 @@ func runRobotValidate(cmd *cobra.Command, args []string) error {
 		Timing: &RobotTiming{
 			StartedAt:  start.UTC().Format(time.RFC3339),
 			DurationMs: duration.Milliseconds(),
+			Providers:  timings,
 		},
 	}
 
 	return robotOutput(cmd, output)
 }
 
+// validateProfilePassive checks a profile's token expiry without calling
+// the provider.
+func validateProfilePassive(provider, profileName string) RobotValidateResult {
+	result := RobotValidateResult{
+		Provider: provider,
+		Profile:  profileName,
+		Method:   "passive",
+	}
+
+	// Get health info for token expiry
+	ph, _ := getProfileHealthWithIdentity(provider, profileName)
+	if !ph.TokenExpiresAt.IsZero() {
+		result.ExpiresAt = ph.TokenExpiresAt.Format(time.RFC3339)
+		remaining := time.Until(ph.TokenExpiresAt)
+		if remaining > 0 {
+			result.ExpiresIn = robotFormatDuration(remaining)
+			result.Valid = true
+			result.Confidence = usage.ConfidenceMedium
+		} else {
+			result.ExpiresIn = "expired"
+			result.Valid = false
+			result.Error = "token expired"
+			result.Confidence = usage.ConfidenceHigh
+			result.HumanAction = buildHumanLoginAction(provider, profileName)
+		}
+	} else {
+		// No expiry info - assume valid
+		result.Valid = true
+		result.Confidence = usage.ConfidenceLow
+	}
+	return result
+}
+
+// validateProfileActive asks the provider whether a profile's token works
+// and updates the passive result with the answer. When the provider can't
+// be asked, or its answer says nothing about the token, the passive verdict
+// stands. Errors the provider returns are recorded in the profile's health.
+func validateProfileActive(ctx context.Context, result *RobotValidateResult) {
+	if !usage.SupportsTokenCheck(result.Provider) {
+		result.Note = "no active check for " + result.Provider + "; checked expiry only"
+		return
+	}
+	token, accountID, err := usage.ReadProfileCredentials(vault.ProfilePath(result.Provider, result.Profile), result.Provider)
+	if err != nil {
+		result.Note = fmt.Sprintf("no access token to check (%v); checked expiry only", err)
+		return
+	}
+
+	check := robotCheckToken(ctx, result.Provider, token, accountID)
+	result.Method = "active"
+	result.HTTPStatus = check.HTTPStatus
+	result.Email = check.Email
+	result.Plan = check.Plan
+	result.Organization = check.Organization
+	result.LatencyMs = check.Latency.Milliseconds()
+
+	if check.HTTPStatus != 0 && check.Error != "" && healthStore != nil {
+		_ = healthStore.RecordError(result.Provider, result.Profile, errors.New(check.Error))
+	}
+
+	if check.Confidence == usage.ConfidenceLow {
+		// The passive verdict stands, and so does its confidence.
+		result.Note = check.Error + "; verdict from expiry only"
+		return
+	}
+	result.Confidence = check.Confidence
+	result.Valid = check.Valid
+	if check.Valid {
+		// A local expiry that says otherwise is stale.
+		result.Error = ""
+		result.HumanAction = nil
+		result.Note = check.Error
+	} else {
+		result.Error = check.Error
+		result.HumanAction = buildHumanLoginAction(result.Provider, result.Profile)
+	}
+}
+
 func runRobotDoctor(cmd *cobra.Command, args []string) error {
 	start := time.Now()
 	fix, _ := cmd.Flags().GetBool("fix")
@@ -1866,6 +3785,10 @@ type RobotHistoryData struct {
 	Since  string              `json:"since"`
 	Events []RobotHistoryEvent `json:"events"`
 	Count  int                 `json:"count"`
+
+	// Set with --sessions, which leaves Events empty.
+	Sessions  []sessionJSON      `json:"sessions,omitempty"`
+	WeekHours []profileHoursJSON `json:"week_hours,omitempty"`
 }
 
 // RobotHistoryEvent is a single activity event.
@@ -1893,13 +3816,31 @@ func runRobotHistory(cmd *cobra.Command, args []string) error {
 
 	db, err := caamdb.Open()
 	if err != nil {
-		return robotError(cmd, "history", "DB_ERROR",
+		return robotError(cmd, "history", caamerr.DBError,
 			"failed to open database", err.Error(), nil)
 	}
 	defer db.Close()
 
-	// Query activity log
-	if db.Conn() != nil {
+	if sessions, _ := cmd.Flags().GetBool("sessions"); sessions {
+		now := time.Now()
+		list, err := db.ListSessions(providerFilter, since, limit)
+		if err != nil {
+			return robotError(cmd, "history", caamerr.DBError,
+				"failed to list sessions", err.Error(), nil)
+		}
+		hours, err := sessionHoursThisWeek(db, providerFilter, now)
+		if err != nil {
+			return robotError(cmd, "history", caamerr.DBError,
+				"failed to total session hours", err.Error(), nil)
+		}
+		data.Sessions = make([]sessionJSON, 0, len(list))
+		for _, sess := range list {
+			data.Sessions = append(data.Sessions, toSessionJSON(sess, now))
+		}
+		data.WeekHours = hours
+		data.Count = len(data.Sessions)
+	} else if db.Conn() != nil {
+		// Query activity log
 		query := `SELECT timestamp, provider, profile_name, event_type, COALESCE(duration_seconds, 0), COALESCE(notes, '')
 			FROM activity_log
 			WHERE datetime(timestamp) >= datetime(?)
@@ -1934,7 +3875,9 @@ func runRobotHistory(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	data.Count = len(data.Events)
+	if data.Sessions == nil {
+		data.Count = len(data.Events)
+	}
 
 	duration := time.Since(start)
 	output := RobotOutput{
@@ -1953,40 +3896,18 @@ func runRobotHistory(cmd *cobra.Command, args []string) error {
 func runRobotConfig(cmd *cobra.Command, args []string) error {
 	start := time.Now()
 
-	// Handle set subcommand
-	if len(args) >= 3 && args[0] == "set" {
-		key := args[1]
-		value := args[2]
-
-		// Simple key-value setting for common config options
-		switch key {
-		case "rotation_algorithm", "algorithm":
-			spmCfg, _ := config.LoadSPMConfig()
-			if spmCfg == nil {
-				spmCfg = config.DefaultSPMConfig()
-			}
-			spmCfg.Stealth.Rotation.Algorithm = value
-			if err := spmCfg.Save(); err != nil {
-				return robotError(cmd, "config", "SAVE_ERROR",
-					"failed to save config", err.Error(), nil)
-			}
+	if len(args) > 0 {
+		switch {
+		case args[0] == "set" && len(args) == 3:
+			return runRobotConfigSet(cmd, args[1], args[2], start)
+		case args[0] == "keys" && len(args) == 1:
+			return runRobotConfigKeys(cmd, start)
 		default:
-			return robotError(cmd, "config", "UNKNOWN_KEY",
-				fmt.Sprintf("unknown config key: %s", key),
-				"valid keys: rotation_algorithm",
-				nil)
-		}
-
-		output := RobotOutput{
-			Success: true,
-			Command: "config",
-			Data: map[string]string{
-				"action": "set",
-				"key":    key,
-				"value":  value,
-			},
+			return robotError(cmd, "config", caamerr.InvalidArgs,
+				fmt.Sprintf("unknown config action: %s", strings.Join(args, " ")),
+				"usage: caam robot config [set <key> <value> | keys]",
+				[]string{"caam robot config keys"})
 		}
-		return robotOutput(cmd, output)
 	}
 
 	// Default: show config
@@ -2004,6 +3925,9 @@ func runRobotConfig(cmd *cobra.Command, args []string) error {
 		"config":     cfg,
 		"spm_config": spmCfg,
 	}
+	if pool, err := syncstate.LoadSyncPool(); err == nil {
+		data["sync"] = robotSyncView(pool)
+	}
 
 	duration := time.Since(start)
 	output := RobotOutput{
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+// robotSyncFile is what robot config reports as the file sync.* keys are
+// stored in.
+const robotSyncFile = "pool.json"
+
+// robotSyncSettings are the sync pool switches robot config set accepts.
+// They live in the sync state, not config.json or config.yaml.
+var robotSyncSettings = []config.Setting{
+	{Key: "sync.auto", Type: "bool", File: robotSyncFile},
+	{Key: "sync.enabled", Type: "bool", File: robotSyncFile},
+}
+
+// RobotConfigSetData is the result of robot config set.
+type RobotConfigSetData struct {
+	Action string      `json:"action"`
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	File   string      `json:"file"`
+
+	// The configuration after the change.
+	Config    *config.Config    `json:"config"`
+	SPMConfig *config.SPMConfig `json:"spm_config"`
+	Sync      *RobotSyncConfig  `json:"sync,omitempty"`
+}
+
+// RobotSyncConfig is the sync pool's switches.
+type RobotSyncConfig struct {
+	Enabled  bool `json:"enabled"`
+	AutoSync bool `json:"auto"`
+	Machines int  `json:"machines"`
+}
+
+func robotSettings() []config.Setting {
+	settings := append(config.Settings(), robotSyncSettings...)
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+func runRobotConfigKeys(cmd *cobra.Command, start time.Time) error {
+	return robotOutput(cmd, RobotOutput{
+		Success: true,
+		Command: "config",
+		Data: map[string]interface{}{
+			"action": "keys",
+			"keys":   robotSettings(),
+		},
+		Timing: &RobotTiming{
+			StartedAt:  start.UTC().Format(time.RFC3339),
+			DurationMs: time.Since(start).Milliseconds(),
+		},
+	})
+}
+
+func runRobotConfigSet(cmd *cobra.Command, key, value string, start time.Time) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return robotError(cmd, "config", caamerr.ConfigError,
+			"failed to load config", err.Error(), nil)
+	}
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return robotError(cmd, "config", caamerr.ConfigError,
+			"failed to load SPM config", err.Error(), nil)
+	}
+	pool, err := syncstate.LoadSyncPool()
+	if err != nil {
+		return robotError(cmd, "config", caamerr.ConfigError,
+			"failed to load sync pool", err.Error(), nil)
+	}
+
+	data := RobotConfigSetData{Action: "set", Config: cfg, SPMConfig: spmCfg}
+	if strings.HasPrefix(key, "sync.") {
+		data.Key, data.File = key, robotSyncFile
+		b, err := parseBool(value)
+		if err != nil {
+			return robotError(cmd, "config", caamerr.InvalidArgs,
+				fmt.Sprintf("%s expects true or false; got %q", key, value), "", nil)
+		}
+		switch key {
+		case "sync.auto":
+			pool.AutoSync = b
+		case "sync.enabled":
+			pool.Enabled = b
+		default:
+			return robotUnknownConfigKey(cmd, &config.UnknownKeyError{Key: key})
+		}
+		if err := pool.Save(); err != nil {
+			return robotError(cmd, "config", caamerr.SaveError,
+				"failed to save sync pool", err.Error(), nil)
+		}
+		data.Value = b
+	} else {
+		data.Key, data.File, data.Value, err = config.SetKey(cfg, spmCfg, key, value)
+		var unknown *config.UnknownKeyError
+		switch {
+		case errors.As(err, &unknown):
+			return robotUnknownConfigKey(cmd, unknown)
+		case err != nil:
+			return robotError(cmd, "config", caamerr.InvalidArgs,
+				err.Error(), "", []string{"caam robot config keys"})
+		}
+		save := cfg.Save
+		if data.File == config.FileSPMYAML {
+			save = spmCfg.Save
+		}
+		if err := save(); err != nil {
+			return robotError(cmd, "config", caamerr.SaveError,
+				"failed to save config", err.Error(), nil)
+		}
+	}
+	data.Sync = robotSyncView(pool)
+
+	return robotOutput(cmd, RobotOutput{
+		Success: true,
+		Command: "config",
+		Data:    data,
+		Timing: &RobotTiming{
+			StartedAt:  start.UTC().Format(time.RFC3339),
+			DurationMs: time.Since(start).Milliseconds(),
+		},
+	})
+}
+
+// robotUnknownConfigKey reports an unknown key with the closest known
+// ones, including the sync keys config.SetKey doesn't know about.
+func robotUnknownConfigKey(cmd *cobra.Command, unknown *config.UnknownKeyError) error {
+	var keys []string
+	for _, s := range robotSettings() {
+		keys = append(keys, s.Key)
+	}
+	var suggestions []string
+	for _, k := range config.SuggestKeys(unknown.Key, keys) {
+		suggestions = append(suggestions, "caam robot config set "+k+" <value>")
+	}
+	suggestions = append(suggestions, "caam robot config keys")
+	return robotError(cmd, "config", caamerr.UnknownKey,
+		fmt.Sprintf("unknown config key: %s", unknown.Key),
+		"list the settable keys and their types with 'caam robot config keys'",
+		suggestions)
+}
+
+func robotSyncView(pool *syncstate.SyncPool) *RobotSyncConfig {
+	if pool == nil {
+		return nil
+	}
+	return &RobotSyncConfig{
+		Enabled:  pool.Enabled,
+		AutoSync: pool.AutoSync,
+		Machines: pool.MachineCount(),
+	}
+}
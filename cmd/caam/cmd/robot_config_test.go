@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+func TestRobotConfigSet(t *testing.T) {
+	_, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(config.ConfigEnvVar, config.DefaultEnv)
+
+	var buf bytes.Buffer
+	robotConfigCmd.SetOut(&buf)
+	defer robotConfigCmd.SetOut(nil)
+
+	set := func(key, value string) (map[string]interface{}, error) {
+		buf.Reset()
+		err := runRobotConfig(robotConfigCmd, []string{"set", key, value})
+		var out struct {
+			Data        map[string]interface{} `json:"data"`
+			Suggestions []string               `json:"suggestions"`
+		}
+		if jsonErr := json.Unmarshal(buf.Bytes(), &out); jsonErr != nil {
+			t.Fatalf("output is not JSON: %v\n%s", jsonErr, buf.String())
+		}
+		if err != nil {
+			return map[string]interface{}{"suggestions": out.Suggestions}, err
+		}
+		return out.Data, nil
+	}
+
+	data, err := set("stealth.rotation.algorithm", "round_robin")
+	if err != nil {
+		t.Fatalf("set algorithm: %v", err)
+	}
+	if data["file"] != config.FileSPMYAML || data["spm_config"] == nil || data["config"] == nil {
+		t.Errorf("set algorithm output = %s", buf.String())
+	}
+	if saved, _ := config.LoadSPMConfig(); saved.Stealth.Rotation.Algorithm != "round_robin" {
+		t.Errorf("saved algorithm = %q", saved.Stealth.Rotation.Algorithm)
+	}
+
+	if _, err := set("notifications.expiry_warning", "30m"); err != nil {
+		t.Fatalf("set expiry_warning: %v", err)
+	}
+	if saved, _ := config.Load(); saved.Notifications.GetExpiryWarning() != 30*time.Minute {
+		t.Errorf("saved expiry warning = %v", saved.Notifications.GetExpiryWarning())
+	}
+
+	data, err = set("sync.auto", "true")
+	if err != nil {
+		t.Fatalf("set sync.auto: %v", err)
+	}
+	if pool, _ := syncstate.LoadSyncPool(); !pool.AutoSync {
+		t.Error("sync.auto not saved")
+	}
+	if s, _ := data["sync"].(map[string]interface{}); s["auto"] != true {
+		t.Errorf("sync in output = %v", data["sync"])
+	}
+
+	data, err = set("sync.atuo", "true")
+	if caamerr.CodeOf(err) != caamerr.UnknownKey {
+		t.Fatalf("misspelled key error = %v", err)
+	}
+	if s, _ := data["suggestions"].([]string); len(s) == 0 || !strings.Contains(s[0], "sync.auto") {
+		t.Errorf("suggestions = %v", data["suggestions"])
+	}
+
+	if _, err := set("health.refresh_threshold", "soon"); caamerr.CodeOf(err) != caamerr.InvalidArgs {
+		t.Errorf("bad duration error = %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+const (
+	// autoCooldownArg is the cooldown duration that asks caam to work out
+	// when the profile's limit resets.
+	autoCooldownArg = "auto"
+
+	// rateLimitEventLookback is how far back rate limit messages are used;
+	// weekly limits can name a reset days away.
+	rateLimitEventLookback = 7 * 24 * time.Hour
+
+	autoCooldownFetchTimeout = 10 * time.Second
+
+	// resetSourceTemplate marks a cooldown end taken from a configured
+	// stealth.cooldown template.
+	resetSourceTemplate = "template"
+)
+
+// inferCooldownUntil works out when provider/profile's rate limit resets.
+// It tries, in order: the reset time in the latest rate limit message the
+// coordinator saw for the profile, a Retry-After or window reset from the
+// provider's usage API (live, else the cached reading), the profile's
+// cooldown template, and the provider's window semantics. It returns the
+// time and where it came from.
+func inferCooldownUntil(db *caamdb.DB, provider, profile string, now time.Time) (time.Time, string, bool) {
+	if events, err := db.GetEvents(provider, profile, now.Add(-rateLimitEventLookback), 100); err == nil {
+		for _, e := range events {
+			if e.Type != caamdb.EventRateLimit {
+				continue
+			}
+			text, _ := e.Details["reset_text"].(string)
+			if t, ok := usage.ParseResetText(text, e.Timestamp); ok && t.After(now) {
+				return t, usage.ResetSourceResetText, true
+			}
+			// Only the latest message describes the current limit.
+			break
+		}
+	}
+
+	credentials, err := usage.LoadProfileCredentials(authfile.DefaultVaultPath(), provider)
+	if token := credentials[profile]; err == nil && token != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), autoCooldownFetchTimeout)
+		results := usage.NewMultiProfileFetcher().FetchAllProfiles(ctx, provider, map[string]string{profile: token})
+		cancel()
+		_ = usage.SaveToCache(db, results)
+		for _, r := range results {
+			if t, source, ok := usage.InferReset(r.Usage, now); ok {
+				return t, source, true
+			}
+		}
+	}
+	if cached, err := usage.LoadFromCache(db, provider, profile); err == nil {
+		if t, source, ok := usage.InferReset(cached, now); ok {
+			return t, source, true
+		}
+	}
+
+	spmCfg, _ := config.LoadSPMConfig()
+	if tpl := cooldownTemplate(spmCfg, provider, profile); tpl != nil {
+		return tpl.Until(now), resetSourceTemplate, true
+	}
+	if t, ok := usage.WindowFallback(provider, now); ok {
+		return t, usage.ResetSourceWindow, true
+	}
+	return time.Time{}, "", false
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+func TestRobotActCooldownAuto(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatalf("caamdb.Open() error = %v", err)
+	}
+	if err := db.LogEvent(caamdb.Event{
+		Type:        caamdb.EventRateLimit,
+		Provider:    "codex",
+		ProfileName: "limited",
+		Details:     map[string]any{"reset_text": "2 hours 30 minutes", "source": "coordinator"},
+	}); err != nil {
+		t.Fatalf("LogEvent() error = %v", err)
+	}
+	db.Close()
+
+	check := func(profile, wantSource string, want time.Duration) {
+		t.Helper()
+		start := time.Now()
+		result, failure := performRobotAct("cooldown", "codex", []string{"cooldown", "codex", profile, autoCooldownArg}, false)
+		if failure != nil {
+			t.Fatalf("performRobotAct(%s) failure = %+v", profile, failure)
+		}
+		if result.ResetSource != wantSource {
+			t.Errorf("%s: ResetSource = %q, want %q", profile, result.ResetSource, wantSource)
+		}
+
+		db, err := caamdb.Open()
+		if err != nil {
+			t.Fatalf("caamdb.Open() error = %v", err)
+		}
+		defer db.Close()
+		ev, err := db.ActiveCooldown("codex", profile, time.Now())
+		if err != nil || ev == nil {
+			t.Fatalf("%s: ActiveCooldown() = %v, %v", profile, ev, err)
+		}
+		if got := ev.CooldownUntil.Sub(start); got < want-time.Minute || got > want+time.Minute {
+			t.Errorf("%s: cooldown lasts %v, want about %v", profile, got, want)
+		}
+	}
+
+	check("limited", usage.ResetSourceResetText, 150*time.Minute)
+	check("unknown", usage.ResetSourceWindow, 5*time.Hour)
+}
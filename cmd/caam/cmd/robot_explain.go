@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+// robotExplainCooldownHistory is how many past cooldowns explain lists.
+const robotExplainCooldownHistory = 10
+
+var robotExplainCmd = &cobra.Command{
+	Use:   "explain <provider> <profile>",
+	Short: "Explain how robot next scores a profile",
+	Long: `Returns the full scoring breakdown robot next uses for one profile, so an
+agent's choice of profile (or refusal to use one) can be audited.
+
+The output lists each factor's contribution to the score (health, cooldown,
+recent errors, token expiry, pool staleness, risk tier, API-key spend, and
+the --strategy adjustment), whether robot next would skip the profile
+outright (cooldown, revoked token, another holder's lease) and its rank
+among the candidates. The inputs behind the factors are included: health
+data, cooldown history, usage today and this month, the lease, and the
+rotation policies configured for the provider.
+
+Examples:
+  caam robot explain claude work
+  caam robot explain codex main --strategy lru`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRobotExplain,
+}
+
+func init() {
+	robotCmd.AddCommand(robotExplainCmd)
+	robotExplainCmd.Flags().String("strategy", "smart", "selection strategy to explain: smart, lru, round-robin, weighted, least-used-today, sticky, random")
+}
+
+// RobotScoreFactor is one input's contribution to a robot next score.
+type RobotScoreFactor struct {
+	Factor string  `json:"factor"`
+	Points float64 `json:"points"`
+	Detail string  `json:"detail,omitempty"`
+}
+
+// RobotExplainData is the scoring breakdown of one profile.
+type RobotExplainData struct {
+	Provider        string             `json:"provider"`
+	Profile         string             `json:"profile"`
+	Strategy        string             `json:"strategy"`
+	Eligible        bool               `json:"eligible"`
+	Excluded        string             `json:"excluded,omitempty"`
+	Score           float64            `json:"score"`
+	NormalizedScore float64            `json:"normalized_score"`
+	Rank            int                `json:"rank,omitempty"`
+	Candidates      int                `json:"candidates"`
+	Best            string             `json:"best,omitempty"`
+	Factors         []RobotScoreFactor `json:"factors"`
+	Inputs          RobotExplainInputs `json:"inputs"`
+}
+
+// RobotExplainInputs are the data the score was computed from.
+type RobotExplainInputs struct {
+	Status          RobotProfileInfo       `json:"status"`
+	Health          RobotExplainHealth     `json:"health"`
+	CooldownHistory []RobotExplainCooldown `json:"cooldown_history"`
+	Usage           RobotExplainUsage      `json:"usage"`
+	Policies        []RobotExplainPolicy   `json:"policies,omitempty"`
+}
+
+// RobotExplainHealth is the stored health data of a profile.
+type RobotExplainHealth struct {
+	TokenExpiresAt string  `json:"token_expires_at,omitempty"`
+	LastError      string  `json:"last_error,omitempty"`
+	ErrorCount1h   int     `json:"error_count_1h"`
+	Penalty        float64 `json:"penalty"`
+	LastChecked    string  `json:"last_checked,omitempty"`
+}
+
+// RobotExplainCooldown is one past or current cooldown.
+type RobotExplainCooldown struct {
+	HitAt  string `json:"hit_at"`
+	Until  string `json:"until"`
+	Active bool   `json:"active"`
+	Notes  string `json:"notes,omitempty"`
+}
+
+// RobotExplainUsage is a profile's recorded usage.
+type RobotExplainUsage struct {
+	LastActivated    string `json:"last_activated,omitempty"`
+	TokensToday      int64  `json:"tokens_today"`
+	RequestsToday    int    `json:"requests_today"`
+	LimitUsedPercent *int   `json:"limit_used_percent,omitempty"`
+}
+
+// RobotExplainPolicy is how a rotation policy treats the profile.
+type RobotExplainPolicy struct {
+	Policy string `json:"policy"`
+	// Effect is "would_rotate" when the policy is due to rotate away from
+	// the profile, "not_due" when it isn't yet, or "inactive" when the
+	// profile isn't active (policies only rotate the active profile).
+	Effect string `json:"effect"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func runRobotExplain(cmd *cobra.Command, args []string) error {
+	start := time.Now()
+	provider, profileName := strings.ToLower(args[0]), args[1]
+
+	getFileSet, ok := tools[provider]
+	if !ok {
+		return robotError(cmd, "explain", caamerr.InvalidProvider,
+			fmt.Sprintf("unknown provider: %s", provider),
+			"valid providers: "+strings.Join(toolNames(), ", "),
+			nil)
+	}
+	raw, _ := cmd.Flags().GetString("strategy")
+	strategy, ok := parseRobotNextStrategy(raw)
+	if !ok {
+		return robotError(cmd, "explain", caamerr.InvalidArgs,
+			fmt.Sprintf("unknown strategy: %s", raw),
+			"valid strategies: "+strings.Join(robotNextStrategies, ", "),
+			[]string{"caam robot explain " + provider + " " + profileName + " --strategy smart"})
+	}
+
+	profiles, err := vault.List(provider)
+	if err != nil {
+		return robotError(cmd, "explain", caamerr.VaultError,
+			"failed to list profiles",
+			err.Error(),
+			[]string{"caam robot status " + provider})
+	}
+	found := false
+	for _, p := range profiles {
+		found = found || p == profileName
+	}
+	if !found {
+		return robotError(cmd, "explain", caamerr.ProfileNotFound,
+			fmt.Sprintf("profile %s/%s not found", provider, profileName),
+			"",
+			[]string{"caam robot status " + provider})
+	}
+
+	db, _ := caamdb.Open()
+	defer func() {
+		if db != nil {
+			db.Close()
+		}
+	}()
+
+	active, _ := vault.ActiveProfile(getFileSet())
+	data := explainRobotProfile(provider, profileName, active, profiles, strategy, db, time.Now())
+
+	return robotOutput(cmd, RobotOutput{
+		Success: true,
+		Command: "explain",
+		Data:    data,
+		Timing: &RobotTiming{
+			StartedAt:  start.UTC().Format(time.RFC3339),
+			DurationMs: time.Since(start).Milliseconds(),
+		},
+	})
+}
+
+// explainRobotProfile scores profiles the way robot next does and breaks
+// down profileName's score.
+func explainRobotProfile(provider, profileName, active string, profiles []string, strategy string, db *caamdb.DB, now time.Time) RobotExplainData {
+	data := RobotExplainData{
+		Provider: provider,
+		Profile:  profileName,
+		Strategy: strategy,
+		Factors:  []RobotScoreFactor{},
+	}
+
+	// Rank among the profiles robot next would consider.
+	scored := scoreRobotNextProfiles(provider, profiles, strategy, false, db)
+	data.Candidates = len(scored)
+	if len(scored) > 0 {
+		data.Best = scored[0].name
+	}
+	var target *robotScoredProfile
+	for i := range scored {
+		if scored[i].name == profileName {
+			target = &scored[i]
+			data.Rank = i + 1
+		}
+	}
+
+	pInfo := buildProfileInfo(provider, profileName, active, db, false)
+	data.Excluded = robotNextExclusion(pInfo, false, false)
+	if data.Excluded == "" {
+		data.Excluded = loadRotationShaping(provider, db).block(profileName)
+	}
+	data.Eligible = data.Excluded == ""
+	if target == nil {
+		// An excluded profile is scored on its own, so strategies that
+		// compare profiles (round-robin, least-used-today) score it as the
+		// only candidate.
+		sp := scoreRobotProfile(provider, profileName, pInfo, robotScoringConfig(), db, now)
+		one := []robotScoredProfile{sp}
+		applyRobotNextStrategy(provider, strategy, one, db)
+		target = &one[0]
+	}
+	data.Score = target.score
+	data.NormalizedScore = normalizeRobotNextScore(target.score)
+	data.Factors = append(data.Factors, target.factors...)
+
+	data.Inputs = RobotExplainInputs{
+		Status:          pInfo,
+		Health:          explainHealth(provider, profileName),
+		CooldownHistory: explainCooldowns(db, provider, profileName, now),
+		Usage:           explainUsage(db, provider, profileName, now),
+		Policies:        explainPolicies(db, provider, profileName, active, now),
+	}
+	return data
+}
+
+func explainHealth(provider, profileName string) RobotExplainHealth {
+	ph := buildProfileHealth(provider, profileName)
+	out := RobotExplainHealth{
+		ErrorCount1h: ph.ErrorCount1h,
+		Penalty:      ph.Penalty,
+	}
+	if !ph.TokenExpiresAt.IsZero() {
+		out.TokenExpiresAt = ph.TokenExpiresAt.Format(time.RFC3339)
+	}
+	if !ph.LastError.IsZero() {
+		out.LastError = ph.LastError.Format(time.RFC3339)
+	}
+	if !ph.LastChecked.IsZero() {
+		out.LastChecked = ph.LastChecked.Format(time.RFC3339)
+	}
+	return out
+}
+
+func explainCooldowns(db *caamdb.DB, provider, profileName string, now time.Time) []RobotExplainCooldown {
+	out := []RobotExplainCooldown{}
+	if db == nil {
+		return out
+	}
+	history, err := db.CooldownHistory(provider, profileName, robotExplainCooldownHistory)
+	if err != nil {
+		return out
+	}
+	for _, ev := range history {
+		out = append(out, RobotExplainCooldown{
+			HitAt:  ev.HitAt.Format(time.RFC3339),
+			Until:  ev.CooldownUntil.Format(time.RFC3339),
+			Active: ev.CooldownUntil.After(now),
+			Notes:  ev.Notes,
+		})
+	}
+	return out
+}
+
+func explainUsage(db *caamdb.DB, provider, profileName string, now time.Time) RobotExplainUsage {
+	var out RobotExplainUsage
+	if db == nil {
+		return out
+	}
+	if last, err := db.LastActivation(provider, profileName); err == nil && !last.IsZero() {
+		out.LastActivated = last.Format(time.RFC3339)
+	}
+	y, m, d := now.Date()
+	if totals, err := db.UsageTotalsSince(provider, time.Date(y, m, d, 0, 0, 0, 0, now.Location())); err == nil {
+		for _, t := range totals {
+			if t.ProfileName == profileName {
+				out.TokensToday = t.TotalTokens
+				out.RequestsToday = t.Requests
+			}
+		}
+	}
+	if info, err := usage.LoadFromCache(db, provider, profileName); err == nil && info != nil && info.PrimaryWindow != nil {
+		pct := info.PrimaryWindow.UsedPercent
+		out.LimitUsedPercent = &pct
+	}
+	return out
+}
+
+// explainPolicies evaluates the provider's rotation policies. They only
+// rotate away from the active profile, so for others they have no effect.
+func explainPolicies(db *caamdb.DB, provider, profileName, active string, now time.Time) []RobotExplainPolicy {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return nil
+	}
+	var out []RobotExplainPolicy
+	for _, p := range spmCfg.Policies {
+		if !strings.EqualFold(strings.TrimSpace(p.Provider), provider) {
+			continue
+		}
+		ep := RobotExplainPolicy{Policy: p.PolicyName()}
+		if profileName != active || authfile.IsSystemProfile(profileName) {
+			ep.Effect = "inactive"
+			ep.Reason = "policies only rotate the active profile"
+			out = append(out, ep)
+			continue
+		}
+		due, reason := rotation.PolicyDue(p, policyState(db, provider, active), now)
+		ep.Effect, ep.Reason = "not_due", reason
+		if due {
+			ep.Effect = "would_rotate"
+		}
+		out = append(out, ep)
+	}
+	return out
+}
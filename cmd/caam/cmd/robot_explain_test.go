@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func TestExplainRobotProfile(t *testing.T) {
+	tmpDir, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+
+	for _, name := range []string{"a", "b"} {
+		if err := os.MkdirAll(vault.ProfilePath("codex", name), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	policies := "policies:\n  - name: hourly\n    provider: codex\n    every: 1h\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "caam_home", "config.yaml"), []byte(policies), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatalf("db.Open() error = %v", err)
+	}
+	defer db.Close()
+	now := time.Now()
+	if _, err := db.SetCooldown("codex", "b", now.Add(-3*time.Hour), time.Hour, "old limit"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.SetCooldown("codex", "b", now.Add(-time.Minute), time.Hour, "rate limited"); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles := []string{"a", "b"}
+	got := explainRobotProfile("codex", "a", "a", profiles, "smart", db, now)
+	if !got.Eligible || got.Rank != 1 || got.Candidates != 1 || got.Best != "a" {
+		t.Errorf("explain a = eligible %v rank %d of %d best %q", got.Eligible, got.Rank, got.Candidates, got.Best)
+	}
+	var sum float64
+	for _, f := range got.Factors {
+		sum += f.Points
+	}
+	if len(got.Factors) == 0 || math.Abs(sum-got.Score) > 1e-9 {
+		t.Errorf("factors %+v sum to %v, want score %v", got.Factors, sum, got.Score)
+	}
+	if len(got.Inputs.Policies) != 1 || got.Inputs.Policies[0].Policy != "hourly" || got.Inputs.Policies[0].Effect != "would_rotate" {
+		t.Errorf("policies for the active profile = %+v, want hourly would_rotate", got.Inputs.Policies)
+	}
+
+	got = explainRobotProfile("codex", "b", "a", profiles, "smart", db, now)
+	if got.Eligible || got.Excluded == "" || got.Rank != 0 {
+		t.Errorf("explain b = eligible %v excluded %q rank %d, want excluded by cooldown", got.Eligible, got.Excluded, got.Rank)
+	}
+	hasCooldown := false
+	for _, f := range got.Factors {
+		hasCooldown = hasCooldown || (f.Factor == "cooldown" && f.Points < 0)
+	}
+	if !hasCooldown {
+		t.Errorf("factors = %+v, want a cooldown penalty", got.Factors)
+	}
+	if h := got.Inputs.CooldownHistory; len(h) != 2 || !h[0].Active || h[0].Notes != "rate limited" || h[1].Active {
+		t.Errorf("cooldown history = %+v, want the active one first", h)
+	}
+	if p := got.Inputs.Policies; len(p) != 1 || p[0].Effect != "inactive" {
+		t.Errorf("policies for an inactive profile = %+v", p)
+	}
+}
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+)
+
+// RobotPlanAction is one step of a robot act plan.
+type RobotPlanAction struct {
+	Action   string `json:"action"`
+	Provider string `json:"provider"`
+	Profile  string `json:"profile,omitempty"`
+	// Duration is the cooldown length, e.g. "2h", or "auto" to last until
+	// the limit resets (cooldown only).
+	Duration string `json:"duration,omitempty"`
+	// Note is the text to record (note only).
+	Note string `json:"note,omitempty"`
+	// IgnoreShaping skips the rotation shaping rules (activate only).
+	IgnoreShaping bool `json:"ignore_shaping,omitempty"`
+}
+
+// args returns the action as robot act command-line arguments.
+func (a RobotPlanAction) args() []string {
+	args := []string{a.Action, a.Provider}
+	if a.Profile != "" {
+		args = append(args, a.Profile)
+	}
+	switch {
+	case a.Action == "cooldown" && a.Duration != "":
+		args = append(args, a.Duration)
+	case a.Action == "note" && a.Note != "":
+		args = append(args, a.Note)
+	}
+	return args
+}
+
+// RobotPlanStep is the outcome of one plan action.
+type RobotPlanStep struct {
+	Index  int             `json:"index"`
+	Action RobotPlanAction `json:"action"`
+	// Status is "ok", "failed", "skipped", or "rolled_back".
+	Status string          `json:"status"`
+	Result *RobotActResult `json:"result,omitempty"`
+	Error  *RobotError     `json:"error,omitempty"`
+}
+
+// RobotPlanData summarizes a robot act plan.
+type RobotPlanData struct {
+	Atomic     bool            `json:"atomic"`
+	Total      int             `json:"total"`
+	Succeeded  int             `json:"succeeded"`
+	Failed     int             `json:"failed"`
+	Skipped    int             `json:"skipped"`
+	RolledBack bool            `json:"rolled_back,omitempty"`
+	Steps      []RobotPlanStep `json:"steps"`
+}
+
+// runRobotActPlan runs the JSON action plan read from source ("-" for
+// stdin). Every action runs in order; with atomic, the first failure stops
+// the plan and activations already made are rolled back. Partial success
+// exits with code 2.
+func runRobotActPlan(cmd *cobra.Command, source string, atomic bool, start time.Time) error {
+	actions, err := readRobotPlan(cmd, source)
+	if err != nil {
+		return robotError(cmd, "act", caamerr.InvalidPlan,
+			"could not read action plan",
+			err.Error(),
+			[]string{`echo '[{"action":"activate","provider":"claude","profile":"work"}]' | caam robot act --plan -`})
+	}
+	for i, a := range actions {
+		if msg := validateRobotPlanAction(a); msg != "" {
+			return robotError(cmd, "act", caamerr.InvalidPlan,
+				fmt.Sprintf("action %d: %s", i, msg),
+				"no actions were run",
+				nil)
+		}
+	}
+	if i, blocked := robotPlanNeedsApproval(cmd, actions); blocked {
+		a := actions[i]
+		return robotError(cmd, "act", caamerr.ApprovalRequired,
+			fmt.Sprintf("action %d (%s on %s/%s) needs human approval", i, a.Action, a.Provider, a.Profile),
+			"plans cannot be queued for approval; submit gated actions one at a time",
+			[]string{fmt.Sprintf("caam robot act %s", strings.Join(a.args(), " "))})
+	}
+
+	ignoreShaping, _ := cmd.Flags().GetBool("ignore-shaping")
+	data := RobotPlanData{Atomic: atomic, Total: len(actions), Steps: make([]RobotPlanStep, 0, len(actions))}
+	for i, a := range actions {
+		step := RobotPlanStep{Index: i, Action: a}
+		if atomic && data.Failed > 0 {
+			step.Status = "skipped"
+			data.Skipped++
+			data.Steps = append(data.Steps, step)
+			continue
+		}
+
+		result, failure := performRobotAct(a.Action, a.Provider, a.args(), ignoreShaping || a.IgnoreShaping)
+		if failure != nil {
+			step.Status = "failed"
+			step.Error = &failure.RobotError
+			data.Failed++
+		} else {
+			step.Status = "ok"
+			step.Result = &result
+			data.Succeeded++
+		}
+		data.Steps = append(data.Steps, step)
+	}
+
+	if atomic && data.Failed > 0 && data.Succeeded > 0 {
+		data.RolledBack = rollbackRobotPlan(data.Steps)
+	}
+
+	output := RobotOutput{
+		Success: data.Failed == 0,
+		Command: "act",
+		Data:    data,
+		Timing: &RobotTiming{
+			StartedAt:  start.UTC().Format(time.RFC3339),
+			DurationMs: time.Since(start).Milliseconds(),
+		},
+	}
+	switch {
+	case data.Failed == 0:
+		return robotOutput(cmd, output)
+	case atomic || data.Succeeded == 0:
+		output.Error = &RobotError{
+			Code:    string(caamerr.PlanFailed),
+			Message: fmt.Sprintf("%d of %d actions failed", data.Failed, data.Total),
+		}
+		if atomic && data.Succeeded > 0 {
+			output.Error.Details = "activations made by earlier actions were rolled back"
+		}
+		robotOutput(cmd, output)
+		return caamerr.Errorf(caamerr.PlanFailed, "PLAN_FAILED: %s", output.Error.Message)
+	default:
+		output.Error = &RobotError{
+			Code:    string(caamerr.PartialSuccess),
+			Message: fmt.Sprintf("%d of %d actions failed", data.Failed, data.Total),
+		}
+		robotOutput(cmd, output)
+		return caamerr.Errorf(caamerr.PartialSuccess, "PARTIAL_SUCCESS: %s", output.Error.Message)
+	}
+}
+
+// readRobotPlan reads a JSON array of actions from a file or stdin.
+func readRobotPlan(cmd *cobra.Command, source string) ([]RobotPlanAction, error) {
+	var raw []byte
+	var err error
+	if source == "-" {
+		raw, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		raw, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []RobotPlanAction
+	if err := json.Unmarshal(raw, &actions); err != nil {
+		return nil, fmt.Errorf("plan must be a JSON array of actions: %w", err)
+	}
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("plan has no actions")
+	}
+	for i := range actions {
+		actions[i].Action = strings.ToLower(strings.TrimSpace(actions[i].Action))
+		actions[i].Provider = strings.ToLower(strings.TrimSpace(actions[i].Provider))
+		actions[i].Profile = strings.TrimSpace(actions[i].Profile)
+	}
+	return actions, nil
+}
+
+// validateRobotPlanAction checks an action before any action runs, so a
+// malformed plan changes nothing. It returns a message, or "" if valid.
+func validateRobotPlanAction(a RobotPlanAction) string {
+	if _, ok := tools[a.Provider]; !ok {
+		return fmt.Sprintf("unknown provider %q (valid: %s)", a.Provider, strings.Join(toolNames(), ", "))
+	}
+	switch a.Action {
+	case "activate", "uncooldown":
+		if a.Profile == "" {
+			return a.Action + " needs a profile"
+		}
+	case "cooldown":
+		if a.Profile == "" {
+			return "cooldown needs a profile"
+		}
+		if a.Duration != "" && !strings.EqualFold(a.Duration, autoCooldownArg) {
+			if _, err := time.ParseDuration(a.Duration); err != nil {
+				return fmt.Sprintf("invalid duration %q", a.Duration)
+			}
+		}
+	case "note":
+		if a.Profile == "" || strings.TrimSpace(a.Note) == "" {
+			return "note needs a profile and note text"
+		}
+	case "backup":
+	default:
+		return fmt.Sprintf("unknown action %q (valid: activate, cooldown, uncooldown, backup, note)", a.Action)
+	}
+	return ""
+}
+
+// robotPlanNeedsApproval reports the first action the approval workflow
+// would not allow outright.
+func robotPlanNeedsApproval(cmd *cobra.Command, actions []RobotPlanAction) (int, bool) {
+	if approvalRequester(cmd.Context()) == "" {
+		return 0, false
+	}
+	cfg := loadRiskConfig()
+	if !cfg.Approvals.Enabled {
+		return 0, false
+	}
+	for i, a := range actions {
+		if !approvalActions[a.Action] {
+			continue
+		}
+		tier := cfg.GetRiskTier(a.Provider, a.Profile)
+		if cfg.Approvals.Decide(a.Action, a.Provider, tier) != config.ApprovalAllow {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// rollbackRobotPlan reactivates, newest first, the profiles that were active
+// before each successful activation. It reports whether anything was
+// rolled back.
+func rollbackRobotPlan(steps []RobotPlanStep) bool {
+	rolledBack := false
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := &steps[i]
+		if step.Status != "ok" || step.Action.Action != "activate" || step.Result == nil {
+			continue
+		}
+		previous := step.Result.OldProfile
+		if previous == "" || previous == step.Result.Profile {
+			continue
+		}
+		if err := vault.Restore(tools[step.Action.Provider](), previous); err != nil {
+			step.Error = &RobotError{
+				Code:    string(caamerr.RollbackFailed),
+				Message: fmt.Sprintf("could not reactivate %s/%s", step.Action.Provider, previous),
+				Details: err.Error(),
+			}
+			continue
+		}
+		events.PublishActivated(step.Action.Provider, previous, "robot")
+		step.Status = "rolled_back"
+		rolledBack = true
+	}
+	return rolledBack
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+func runRobotPlanForTest(t *testing.T, plan string, atomic bool) (RobotOutput, RobotPlanData, error) {
+	t.Helper()
+	var out strings.Builder
+	robotActCmd.SetOut(&out)
+	robotActCmd.SetIn(strings.NewReader(plan))
+	_ = robotActCmd.Flags().Set("plan", "-")
+	if atomic {
+		_ = robotActCmd.Flags().Set("atomic", "true")
+	}
+	t.Cleanup(func() {
+		robotActCmd.SetOut(nil)
+		robotActCmd.SetIn(nil)
+		_ = robotActCmd.Flags().Set("plan", "")
+		_ = robotActCmd.Flags().Set("atomic", "false")
+	})
+
+	err := runRobotAct(robotActCmd, nil)
+
+	var output struct {
+		RobotOutput
+		Data RobotPlanData `json:"data"`
+	}
+	if decodeErr := json.Unmarshal([]byte(out.String()), &output); decodeErr != nil {
+		t.Fatalf("decode robot output: %v\n%s", decodeErr, out.String())
+	}
+	return output.RobotOutput, output.Data, err
+}
+
+func TestRobotActPlan(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "codex_home"))
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	t.Setenv("CAAM_AGENT", "")
+	if err := os.MkdirAll(os.Getenv("CODEX_HOME"), 0700); err != nil {
+		t.Fatalf("MkdirAll(CODEX_HOME) error = %v", err)
+	}
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	for _, name := range []string{"a", "b"} {
+		if err := os.MkdirAll(vault.ProfilePath("codex", name), 0700); err != nil {
+			t.Fatalf("MkdirAll(profile %s) error = %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(vault.ProfilePath("codex", name), "auth.json"), []byte(`{"access_token":"`+name+`"}`), 0600); err != nil {
+			t.Fatalf("WriteFile(profile %s) error = %v", name, err)
+		}
+	}
+	authPath := filepath.Join(os.Getenv("CODEX_HOME"), "auth.json")
+	if err := os.WriteFile(authPath, []byte(`{"access_token":"a"}`), 0600); err != nil {
+		t.Fatalf("WriteFile(active auth) error = %v", err)
+	}
+	active := func() string {
+		data, _ := os.ReadFile(authPath)
+		return string(data)
+	}
+
+	t.Run("invalid plan runs nothing", func(t *testing.T) {
+		out, _, err := runRobotPlanForTest(t, `[{"action":"activate","provider":"codex","profile":"b"},{"action":"explode","provider":"codex"}]`, false)
+		if err == nil || out.Error == nil || out.Error.Code != "INVALID_PLAN" {
+			t.Fatalf("output = %+v, err = %v; want INVALID_PLAN", out, err)
+		}
+		if active() != `{"access_token":"a"}` {
+			t.Errorf("invalid plan changed the active profile")
+		}
+	})
+
+	t.Run("partial success exits 2", func(t *testing.T) {
+		plan := `[
+			{"action":"cooldown","provider":"codex","profile":"a","duration":"1h"},
+			{"action":"activate","provider":"codex","profile":"missing"},
+			{"action":"activate","provider":"codex","profile":"b"},
+			{"action":"note","provider":"codex","profile":"a","note":"hit 429"}
+		]`
+		out, data, err := runRobotPlanForTest(t, plan, false)
+		if got := ExitCode(err); got != 2 {
+			t.Fatalf("err = %v, exit code %d, want 2", err, got)
+		}
+		if out.Success || out.Error == nil || out.Error.Code != "PARTIAL_SUCCESS" {
+			t.Errorf("output = %+v, want PARTIAL_SUCCESS", out)
+		}
+		if data.Succeeded != 3 || data.Failed != 1 || data.Steps[1].Status != "failed" {
+			t.Errorf("data = %+v", data)
+		}
+		if active() != `{"access_token":"b"}` {
+			t.Errorf("active auth = %s, want b", active())
+		}
+	})
+
+	t.Run("atomic rolls back activations", func(t *testing.T) {
+		plan := `[
+			{"action":"activate","provider":"codex","profile":"a"},
+			{"action":"activate","provider":"codex","profile":"missing"},
+			{"action":"uncooldown","provider":"codex","profile":"a"}
+		]`
+		out, data, err := runRobotPlanForTest(t, plan, true)
+		if err == nil || out.Error == nil || out.Error.Code != "PLAN_FAILED" {
+			t.Fatalf("output = %+v, err = %v; want PLAN_FAILED", out, err)
+		}
+		if !data.RolledBack || data.Steps[0].Status != "rolled_back" || data.Steps[2].Status != "skipped" {
+			t.Errorf("data = %+v", data)
+		}
+		if active() != `{"access_token":"b"}` {
+			t.Errorf("active auth = %s, want b restored", active())
+		}
+	})
+}
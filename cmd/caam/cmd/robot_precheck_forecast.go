@@ -0,0 +1,470 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+// nearLimitPercent is the share of a limit used at which a profile counts
+// as near its limit.
+const nearLimitPercent = 80
+
+// RobotPrecheckForecast is how long a profile lasts at its current burn
+// rate, from the provider's rate-limit reading and usage recorded with
+// "caam usage record".
+type RobotPrecheckForecast struct {
+	// UsedPercent is the most constrained window's utilization.
+	UsedPercent int    `json:"used_percent"`
+	ResetsAt    string `json:"resets_at,omitempty"`
+	ResetsIn    string `json:"resets_in,omitempty"`
+
+	BurnRate      string  `json:"burn_rate,omitempty"`
+	TokensPerHour float64 `json:"tokens_per_hour,omitempty"`
+
+	// DepletesAt is when the limit is hit at the current burn rate. It is
+	// empty when the window resets first or there is no burn rate.
+	DepletesAt string `json:"depletes_at,omitempty"`
+	DepletesIn string `json:"depletes_in,omitempty"`
+
+	// Source is where the utilization came from: "api", "cached" (an
+	// earlier API reading), or "metered" (recorded usage against
+	// subscriptions.<provider>.token_limit).
+	Source    string `json:"source"`
+	FetchedAt string `json:"fetched_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	usedKnown  bool
+	depletesIn time.Duration
+	depleted   bool
+	resetsIn   time.Duration
+	burn       *usage.BurnRateInfo
+}
+
+// RobotSessionBudget is how much the recommended profile can spend in the
+// planned session without hitting its limit.
+type RobotSessionBudget struct {
+	Profile string `json:"profile"`
+	Session string `json:"session"`
+
+	// RemainingPercent is the share of the limit window left now.
+	RemainingPercent *int `json:"remaining_percent,omitempty"`
+
+	// Tokens is the allowance left for the session, including a full
+	// window if the limit resets before the session ends. TokensPerHour
+	// spreads it evenly; Requests divides it by the recorded average
+	// request size.
+	Tokens        int64 `json:"tokens,omitempty"`
+	TokensPerHour int64 `json:"tokens_per_hour,omitempty"`
+	Requests      int64 `json:"requests,omitempty"`
+
+	// ProjectedTokens is what the session uses at the current burn rate.
+	ProjectedTokens int64 `json:"projected_tokens,omitempty"`
+
+	ResetsDuringSession bool `json:"resets_during_session"`
+
+	// Sufficient is false when the profile is expected to hit its limit
+	// before the session ends.
+	Sufficient bool `json:"sufficient"`
+
+	// Basis is "token_limit" when Tokens is known, "utilization" when only
+	// the percentage is, and "none" without usage data.
+	Basis string `json:"basis"`
+	Note  string `json:"note,omitempty"`
+}
+
+// RobotPrecheckAlternate is the best ready profile of another provider,
+// the plan B if this provider runs dry.
+type RobotPrecheckAlternate struct {
+	Provider      string `json:"provider"`
+	Profile       string `json:"profile"`
+	Health        string `json:"health"`
+	UsedPercent   *int   `json:"used_percent,omitempty"`
+	ReadyProfiles int    `json:"ready_profiles"`
+	Activate      string `json:"activate"`
+}
+
+// fetchUsageReadings fetches the provider's rate-limit reading for every
+// profile with stored credentials, caching successful readings in db.
+func fetchUsageReadings(ctx context.Context, db *caamdb.DB, provider string, timeout time.Duration) map[string]*usage.UsageInfo {
+	readings := make(map[string]*usage.UsageInfo)
+	credentials, err := usage.LoadProfileCredentials(vault.BasePath(), provider)
+	if err != nil || len(credentials) == 0 {
+		return readings
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	results := usage.NewMultiProfileFetcher().FetchAllProfiles(ctx, provider, credentials)
+	for _, r := range results {
+		readings[r.ProfileName] = r.Usage
+	}
+	if db != nil {
+		_ = usage.SaveToCache(db, results)
+	}
+	return readings
+}
+
+// usageReading returns the profile's fetched reading, or its cached one
+// when the fetch failed or was skipped. fetchErr is the failed fetch's
+// error when the cached reading replaced it.
+func usageReading(db *caamdb.DB, provider, profile string, fetched *usage.UsageInfo) (reading *usage.UsageInfo, cached bool, fetchErr string) {
+	if fetched != nil && fetched.Error == "" {
+		return fetched, false, ""
+	}
+	if db != nil {
+		if c, err := usage.LoadFromCache(db, provider, profile); err == nil && c != nil {
+			if fetched != nil {
+				fetchErr = fetched.Error
+			}
+			return c, true, fetchErr
+		}
+	}
+	return fetched, false, ""
+}
+
+// precheckForecast combines a profile's rate-limit reading with its
+// recorded usage. Without a reading, utilization is taken from recorded
+// usage when the subscription's token limit is configured.
+func precheckForecast(db *caamdb.DB, provider, profile string, reading *usage.UsageInfo, cached bool, sub config.SubscriptionConfig) *RobotPrecheckForecast {
+	fc := &RobotPrecheckForecast{}
+	tokenLimit, limitWindow := sub.TokenLimit, sub.LimitWindow.Duration()
+
+	var metered *usage.Forecast
+	if db != nil {
+		window := limitWindow
+		if window <= 0 {
+			window = usage.DefaultForecastWindow
+		}
+		if records, err := db.UsageSince(provider, profile, time.Now().Add(-window)); err == nil && len(records) > 0 {
+			metered = usage.ForecastFromRecords(records, tokenLimit, limitWindow)
+			fc.burn = metered.BurnRate
+		}
+	}
+
+	if w := reading.MostConstrainedWindow(); reading != nil && reading.Error == "" && w != nil {
+		fc.Source = "api"
+		if cached {
+			fc.Source = "cached"
+		}
+		fc.FetchedAt = reading.FetchedAt.UTC().Format(time.RFC3339)
+		util := w.Utilization
+		if util == 0 && w.UsedPercent > 0 {
+			util = float64(w.UsedPercent) / 100
+		}
+		fc.UsedPercent = int(math.Round(util * 100))
+		fc.usedKnown = true
+		fc.depleted = fc.UsedPercent >= 100
+		if !w.ResetsAt.IsZero() && w.ResetsAt.After(time.Now()) {
+			fc.resetsIn = time.Until(w.ResetsAt)
+			fc.ResetsAt = w.ResetsAt.UTC().Format(time.RFC3339)
+			fc.ResetsIn = robotFormatDuration(fc.resetsIn)
+		}
+
+		if fc.burn != nil && fc.burn.PercentPerHour > 0 {
+			reading.BurnRate = fc.burn
+		}
+		if reading.BurnRate != nil {
+			fc.burn = reading.BurnRate
+			reading.UpdateDepletion()
+			// UpdateDepletion caps the estimate at the window reset, which
+			// means the limit isn't hit.
+			if ttd := reading.TimeToDepletion(); ttd > 0 && (w.ResetsAt.IsZero() || reading.EstimatedDepletion.Before(w.ResetsAt)) {
+				fc.depletesIn = ttd
+			}
+		}
+	} else if metered != nil && metered.TokenLimit > 0 {
+		fc.Source = "metered"
+		fc.UsedPercent = int(math.Round(metered.UsedPercent))
+		fc.usedKnown = true
+		fc.depleted = metered.Depleted()
+		fc.depletesIn = metered.DepletesIn
+	} else {
+		if reading != nil && reading.Error != "" {
+			fc.Error = reading.Error
+		}
+		if fc.burn == nil {
+			return nil
+		}
+		fc.Source = "metered"
+	}
+
+	if fc.burn != nil {
+		fc.BurnRate = fc.burn.String()
+		fc.TokensPerHour = math.Round(fc.burn.TokensPerHour)
+	}
+	if fc.depleted {
+		fc.DepletesAt = time.Now().UTC().Format(time.RFC3339)
+		fc.DepletesIn = "now"
+	} else if fc.depletesIn > 0 {
+		fc.DepletesAt = time.Now().Add(fc.depletesIn).UTC().Format(time.RFC3339)
+		fc.DepletesIn = robotFormatDuration(fc.depletesIn)
+	}
+	return fc
+}
+
+// hitsLimitWithin reports whether the profile is expected to hit its limit
+// within d.
+func (fc *RobotPrecheckForecast) hitsLimitWithin(d time.Duration) bool {
+	if fc == nil {
+		return false
+	}
+	return fc.depleted || (fc.depletesIn > 0 && fc.depletesIn < d)
+}
+
+// scorePrecheckForecast applies the usage weights to a precheck profile.
+func scorePrecheckForecast(rec *RobotPrecheckProfile, w config.ScoringWeights, session time.Duration) {
+	fc := rec.Forecast
+	if fc == nil {
+		return
+	}
+	if fc.usedKnown && fc.UsedPercent >= nearLimitPercent && w.NearLimit != 0 {
+		rec.Score += w.NearLimit
+		rec.Reasons = append(rec.Reasons, fmt.Sprintf("%+.0f %d%% of limit used", w.NearLimit, fc.UsedPercent))
+	}
+	if fc.hitsLimitWithin(session) && w.DepletesInSession != 0 {
+		rec.Score += w.DepletesInSession
+		when := "now"
+		if !fc.depleted {
+			when = "in " + fc.DepletesIn
+		}
+		rec.Reasons = append(rec.Reasons, fmt.Sprintf("%+.0f hits limit %s, before the %s session ends", w.DepletesInSession, when, robotFormatDuration(session)))
+	}
+}
+
+// precheckSessionBudget works out how much of the profile's limit the
+// session can use.
+func precheckSessionBudget(rec *RobotPrecheckProfile, sub config.SubscriptionConfig, session time.Duration) *RobotSessionBudget {
+	b := &RobotSessionBudget{
+		Profile:    rec.Name,
+		Session:    robotFormatDuration(session),
+		Sufficient: true,
+		Basis:      "none",
+	}
+	fc := rec.Forecast
+	if fc == nil || !fc.usedKnown {
+		b.Note = "no usage data; record usage with 'caam usage record' or fetch rate limits to get a budget"
+		return b
+	}
+
+	remaining := 100 - fc.UsedPercent
+	if remaining < 0 {
+		remaining = 0
+	}
+	b.RemainingPercent = &remaining
+	b.Basis = "utilization"
+	b.ResetsDuringSession = fc.resetsIn > 0 && fc.resetsIn < session
+	b.Sufficient = !fc.hitsLimitWithin(session)
+
+	hours := session.Hours()
+	if fc.burn != nil && fc.burn.TokensPerHour > 0 {
+		b.ProjectedTokens = int64(fc.burn.TokensPerHour * hours)
+	}
+
+	if sub.TokenLimit <= 0 {
+		b.Note = "set subscriptions.<provider>.token_limit in config.yaml for a token budget"
+		return b
+	}
+	b.Basis = "token_limit"
+	b.Tokens = sub.TokenLimit * int64(remaining) / 100
+	if b.ResetsDuringSession {
+		b.Tokens += sub.TokenLimit
+	}
+	if hours > 0 {
+		b.TokensPerHour = int64(float64(b.Tokens) / hours)
+	}
+	if fc.burn != nil && fc.burn.SampleSize > 0 && fc.burn.TotalTokens > 0 {
+		perRequest := fc.burn.TotalTokens / int64(fc.burn.SampleSize)
+		if perRequest > 0 {
+			b.Requests = b.Tokens / perRequest
+		}
+	}
+	if b.ProjectedTokens > 0 && b.ProjectedTokens > b.Tokens {
+		b.Sufficient = false
+	}
+	return b
+}
+
+// precheckAlternates finds the best ready profile of every other provider,
+// using stored health, cooldowns, and cached rate-limit readings. Nothing
+// is fetched, so plan B costs no API calls.
+func precheckAlternates(ctx context.Context, db *caamdb.DB, provider string) []RobotPrecheckAlternate {
+	var others []string
+	for _, name := range toolNames() {
+		if name != provider {
+			others = append(others, name)
+		}
+	}
+	alternates := make([]RobotPrecheckAlternate, 0)
+	if len(others) == 0 {
+		return alternates
+	}
+	st := loadRobotState(ctx, db, others...)
+
+	rank := func(status string) int {
+		switch status {
+		case health.StatusHealthy.String():
+			return 0
+		case health.StatusWarning.String():
+			return 1
+		case health.StatusUnknown.String():
+			return 2
+		}
+		return 3
+	}
+	used := func(a RobotPrecheckAlternate) int {
+		if a.UsedPercent == nil {
+			return 50
+		}
+		return *a.UsedPercent
+	}
+
+	for _, other := range others {
+		profiles, err := vault.List(other)
+		if err != nil {
+			continue
+		}
+		var best *RobotPrecheckAlternate
+		ready := 0
+		for _, name := range profiles {
+			if strings.HasPrefix(name, "_") {
+				continue
+			}
+			if ev := st.Cooldown(other, name); ev != nil && time.Until(ev.CooldownUntil) > 0 {
+				continue
+			}
+			ph, _ := st.profileHealth(other, name)
+			status := health.CalculateStatus(ph)
+			if status == health.StatusCritical {
+				continue
+			}
+			alt := RobotPrecheckAlternate{
+				Provider: other,
+				Profile:  name,
+				Health:   status.String(),
+				Activate: fmt.Sprintf("caam robot act activate %s %s", other, name),
+			}
+			if db != nil {
+				if r, err := usage.LoadFromCache(db, other, name); err == nil && r != nil {
+					if w := r.MostConstrainedWindow(); w != nil {
+						pct := w.UsedPercent
+						if w.Utilization > 0 {
+							pct = int(math.Round(w.Utilization * 100))
+						}
+						if pct >= 100 {
+							continue
+						}
+						alt.UsedPercent = &pct
+					}
+				}
+			}
+			ready++
+			if best == nil || rank(alt.Health) < rank(best.Health) ||
+				(rank(alt.Health) == rank(best.Health) && used(alt) < used(*best)) {
+				a := alt
+				best = &a
+			}
+		}
+		if best != nil {
+			best.ReadyProfiles = ready
+			alternates = append(alternates, *best)
+		}
+	}
+
+	sort.SliceStable(alternates, func(i, j int) bool {
+		if rank(alternates[i].Health) != rank(alternates[j].Health) {
+			return rank(alternates[i].Health) < rank(alternates[j].Health)
+		}
+		return used(alternates[i]) < used(alternates[j])
+	})
+	return alternates
+}
+
+// precheckForecastAlerts warns about profiles near their limit and about a
+// recommended profile that won't last the session, pointing at plan B.
+func precheckForecastAlerts(data *RobotPrecheckData, ready []RobotPrecheckProfile, session time.Duration) []RobotPrecheckAlert {
+	var alerts []RobotPrecheckAlert
+	for _, rec := range ready {
+		fc := rec.Forecast
+		if fc == nil {
+			continue
+		}
+		switch {
+		case fc.depleted:
+			alerts = append(alerts, RobotPrecheckAlert{
+				Type:    "limit_reached",
+				Message: fmt.Sprintf("%s has reached its limit", rec.Name),
+				Urgency: "high",
+				Action:  "avoid until it resets" + resetSuffix(fc),
+			})
+		case fc.depletesIn > 0 && fc.depletesIn < 10*time.Minute:
+			alerts = append(alerts, RobotPrecheckAlert{
+				Type:    "imminent_limit",
+				Message: fmt.Sprintf("%s hits its limit in %s at the current burn rate", rec.Name, fc.DepletesIn),
+				Urgency: "high",
+				Action:  "switch profiles now",
+			})
+		case fc.depletesIn > 0 && fc.depletesIn < 30*time.Minute:
+			alerts = append(alerts, RobotPrecheckAlert{
+				Type:    "approaching_limit",
+				Message: fmt.Sprintf("%s hits its limit in %s at the current burn rate", rec.Name, fc.DepletesIn),
+				Urgency: "medium",
+				Action:  "plan to switch profiles soon",
+			})
+		}
+	}
+
+	if data.Recommended != nil && data.Recommended.Forecast.hitsLimitWithin(session) {
+		action := "plan to switch to a backup profile mid-session"
+		if len(data.Backups) == 0 || allHitLimit(data.Backups, session) {
+			if len(data.Alternates) > 0 {
+				action = "switch providers: " + data.Alternates[0].Activate
+			} else {
+				action = "shorten the session or add another profile"
+			}
+		}
+		alerts = append(alerts, RobotPrecheckAlert{
+			Type:    "session_exceeds_budget",
+			Message: fmt.Sprintf("%s is expected to hit its limit before the %s session ends", data.Recommended.Name, robotFormatDuration(session)),
+			Urgency: "medium",
+			Action:  action,
+		})
+	}
+
+	if data.Summary.Ready > 0 && data.Summary.NearLimit == data.Summary.Ready {
+		action := "wait for limits to reset or add a profile"
+		if len(data.Alternates) > 0 {
+			action = "switch providers: " + data.Alternates[0].Activate
+		}
+		alerts = append(alerts, RobotPrecheckAlert{
+			Type:    "all_near_limit",
+			Message: fmt.Sprintf("every ready %s profile is at %d%% or more of its limit", data.Provider, nearLimitPercent),
+			Urgency: "high",
+			Action:  action,
+		})
+	}
+	return alerts
+}
+
+func allHitLimit(profiles []RobotPrecheckProfile, session time.Duration) bool {
+	for _, p := range profiles {
+		if !p.Forecast.hitsLimitWithin(session) {
+			return false
+		}
+	}
+	return true
+}
+
+func resetSuffix(fc *RobotPrecheckForecast) string {
+	if fc.ResetsIn == "" {
+		return ""
+	}
+	return " (resets in " + fc.ResetsIn + ")"
+}
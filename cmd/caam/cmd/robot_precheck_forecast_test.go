@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+// recordSteadyUsage records five 20k-token requests over the last hour.
+func recordSteadyUsage(t *testing.T, db *caamdb.DB, provider, profile string) {
+	t.Helper()
+	now := time.Now()
+	for i := 4; i >= 0; i-- {
+		if _, err := db.RecordUsage(caamdb.UsageRecord{
+			Timestamp:   now.Add(-time.Duration(i) * 15 * time.Minute),
+			Provider:    provider,
+			ProfileName: profile,
+			TotalTokens: 20_000,
+		}); err != nil {
+			t.Fatalf("RecordUsage() error = %v", err)
+		}
+	}
+}
+
+func TestPrecheckForecastAndBudget(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer db.Close()
+	recordSteadyUsage(t, db, "claude", "work")
+	sub := config.SubscriptionConfig{TokenLimit: 5_000_000, LimitWindow: config.Duration(5 * time.Hour)}
+
+	// 60% used, burning 10%/h from recorded usage: the limit is 4h away,
+	// after the window resets in 3h.
+	reading := &usage.UsageInfo{
+		Provider:      "claude",
+		ProfileName:   "work",
+		FetchedAt:     time.Now(),
+		PrimaryWindow: &usage.UsageWindow{UsedPercent: 60, ResetsAt: time.Now().Add(3 * time.Hour)},
+	}
+	fc := precheckForecast(db, "claude", "work", reading, false, sub)
+	if fc == nil || fc.Source != "api" || fc.UsedPercent != 60 || fc.BurnRate == "" || fc.ResetsIn == "" {
+		t.Fatalf("forecast = %+v", fc)
+	}
+	if fc.DepletesIn != "" || fc.hitsLimitWithin(2*time.Hour) {
+		t.Errorf("forecast reports a depletion after the reset: %+v", fc)
+	}
+
+	rec := RobotPrecheckProfile{Name: "work", Score: 100, Forecast: fc}
+	b := precheckSessionBudget(&rec, sub, 2*time.Hour)
+	if b.Basis != "token_limit" || *b.RemainingPercent != 40 || b.Tokens != 2_000_000 || b.TokensPerHour != 1_000_000 {
+		t.Errorf("budget = %+v", b)
+	}
+	if b.Requests != 100 || !b.Sufficient || b.ResetsDuringSession {
+		t.Errorf("budget requests/sufficient = %+v", b)
+	}
+
+	// Without a reading, recorded usage against the token limit is used.
+	metered := precheckForecast(db, "claude", "work", nil, false, config.SubscriptionConfig{TokenLimit: 110_000, LimitWindow: config.Duration(5 * time.Hour)})
+	if metered == nil || metered.Source != "metered" || metered.UsedPercent != 91 || !metered.hitsLimitWithin(2*time.Hour) {
+		t.Fatalf("metered forecast = %+v", metered)
+	}
+	rec = RobotPrecheckProfile{Name: "work", Score: 100, Forecast: metered}
+	scorePrecheckForecast(&rec, config.DefaultScoringConfig().Weights, 2*time.Hour)
+	if rec.Score != 100-40-80 || len(rec.Reasons) != 2 {
+		t.Errorf("score = %v, reasons = %v", rec.Score, rec.Reasons)
+	}
+	if b := precheckSessionBudget(&rec, config.SubscriptionConfig{}, 2*time.Hour); b.Sufficient || b.Basis != "utilization" || b.Tokens != 0 {
+		t.Errorf("budget without token limit = %+v", b)
+	}
+
+	if got := precheckForecast(db, "claude", "idle", nil, false, config.SubscriptionConfig{}); got != nil {
+		t.Errorf("forecast without data = %+v, want nil", got)
+	}
+}
+
+func TestPrecheckForecastAlertsPointToPlanB(t *testing.T) {
+	depleting := &RobotPrecheckForecast{UsedPercent: 90, usedKnown: true, depletesIn: 20 * time.Minute, DepletesIn: "20m"}
+	data := &RobotPrecheckData{
+		Provider:    "claude",
+		Recommended: &RobotPrecheckProfile{Name: "work", Forecast: depleting},
+		Summary:     RobotPrecheckSummary{Ready: 1, NearLimit: 1},
+		Alternates:  []RobotPrecheckAlternate{{Provider: "codex", Profile: "main", Activate: "caam robot act activate codex main"}},
+	}
+
+	alerts := precheckForecastAlerts(data, []RobotPrecheckProfile{*data.Recommended}, 2*time.Hour)
+	types := map[string]RobotPrecheckAlert{}
+	for _, a := range alerts {
+		types[a.Type] = a
+	}
+	if _, ok := types["approaching_limit"]; !ok {
+		t.Errorf("alerts = %+v, want approaching_limit", alerts)
+	}
+	for _, typ := range []string{"session_exceeds_budget", "all_near_limit"} {
+		if a, ok := types[typ]; !ok || !strings.Contains(a.Action, "activate codex main") {
+			t.Errorf("%s alert = %+v, want plan B action", typ, a)
+		}
+	}
+}
+
+func TestPrecheckAlternates(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(t.TempDir(), "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	for _, p := range []struct{ provider, profile string }{
+		{"claude", "work"}, {"codex", "busy"}, {"codex", "fresh"}, {"gemini", "_original"},
+	} {
+		if err := os.MkdirAll(vault.ProfilePath(p.provider, p.profile), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := usage.SaveToCache(db, []usage.ProfileUsage{
+		{Provider: "codex", ProfileName: "busy", Usage: &usage.UsageInfo{Provider: "codex", ProfileName: "busy", FetchedAt: time.Now(), PrimaryWindow: &usage.UsageWindow{UsedPercent: 85}}},
+		{Provider: "codex", ProfileName: "fresh", Usage: &usage.UsageInfo{Provider: "codex", ProfileName: "fresh", FetchedAt: time.Now(), PrimaryWindow: &usage.UsageWindow{UsedPercent: 10}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	alts := precheckAlternates(context.Background(), db, "claude")
+	if len(alts) != 1 {
+		t.Fatalf("alternates = %+v, want codex only", alts)
+	}
+	a := alts[0]
+	if a.Provider != "codex" || a.Profile != "fresh" || a.ReadyProfiles != 2 || a.UsedPercent == nil || *a.UsedPercent != 10 {
+		t.Errorf("alternate = %+v", a)
+	}
+}
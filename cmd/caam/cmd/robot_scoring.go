@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
+)
+
+// robotScoringConfig returns the scoring section of config.yaml, or the
+// built-in scoring if it can't be loaded.
+func robotScoringConfig() config.ScoringConfig {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return config.DefaultScoringConfig()
+	}
+	return spmCfg.Scoring
+}
+
+// runRobotScoringScript runs the configured scoring script over candidates
+// and returns its adjustments by profile. It returns nil, nil if no script
+// is configured.
+func runRobotScoringScript(scoring config.ScoringConfig, command, provider string, candidates []rotation.ScriptCandidate) (map[string][]rotation.ScriptAdjustment, error) {
+	if scoring.Script == "" || len(candidates) == 0 {
+		return nil, nil
+	}
+	adjustments, err := rotation.RunScoringScript(context.Background(), scoring.Script, scoring.ScriptTimeout.Duration(), rotation.ScriptInput{
+		Command:    command,
+		Provider:   provider,
+		Now:        time.Now().UTC().Format(time.RFC3339),
+		Candidates: candidates,
+	})
+	if err != nil {
+		return nil, err
+	}
+	byProfile := make(map[string][]rotation.ScriptAdjustment)
+	for _, a := range adjustments {
+		byProfile[a.Profile] = append(byProfile[a.Profile], a)
+	}
+	return byProfile, nil
+}
+
+// applyRobotScoringScript lets the scoring script adjust robot next's
+// scores. If the script fails, the built-in scores stand and each profile's
+// reasons say why.
+func applyRobotScoringScript(scoring config.ScoringConfig, provider string, scored []robotScoredProfile) {
+	candidates := make([]rotation.ScriptCandidate, len(scored))
+	for i, sp := range scored {
+		factors := make([]rotation.ScriptFactor, len(sp.factors))
+		for j, f := range sp.factors {
+			factors[j] = rotation.ScriptFactor(f)
+		}
+		candidates[i] = rotation.ScriptCandidate{Profile: sp.name, Score: sp.score, Factors: factors, Info: sp.info}
+	}
+	adjustments, err := runRobotScoringScript(scoring, "next", provider, candidates)
+	for i := range scored {
+		sp := &scored[i]
+		if err != nil {
+			sp.add("script", 0, err.Error())
+			continue
+		}
+		for _, a := range adjustments[sp.name] {
+			sp.add("script", a.Points, scriptReason(a))
+		}
+	}
+}
+
+// scriptReason describes a scoring script adjustment.
+func scriptReason(a rotation.ScriptAdjustment) string {
+	if a.Reason == "" {
+		return fmt.Sprintf("scoring script: %+.0f", a.Points)
+	}
+	return fmt.Sprintf("scoring script: %s (%+.0f)", a.Reason, a.Points)
+}
+
+// applyPrecheckScoringScript lets the scoring script adjust robot
+// precheck's scores. If the script fails, the built-in scores stand and each
+// profile's reasons say why.
+func applyPrecheckScoringScript(scoring config.ScoringConfig, provider string, ready []RobotPrecheckProfile) {
+	candidates := make([]rotation.ScriptCandidate, len(ready))
+	for i, rec := range ready {
+		candidates[i] = rotation.ScriptCandidate{Profile: rec.Name, Score: rec.Score, Info: rec}
+	}
+	adjustments, err := runRobotScoringScript(scoring, "precheck", provider, candidates)
+	for i := range ready {
+		rec := &ready[i]
+		if err != nil {
+			rec.Reasons = append(rec.Reasons, err.Error())
+			continue
+		}
+		for _, a := range adjustments[rec.Name] {
+			rec.Score += a.Points
+			rec.Reasons = append(rec.Reasons, scriptReason(a))
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func TestRobotNextScoringConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scoring scripts are shell scripts here")
+	}
+	tmpDir, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+	t.Setenv(config.ConfigEnvVar, config.DefaultEnv)
+	vaultDir := t.TempDir()
+	vault = authfile.NewVault(vaultDir)
+	for _, p := range []string{"work", "spare"} {
+		dir := filepath.Join(vaultDir, "codex", p)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "auth.json"), []byte(`{}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := getDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.LogEvent(caamdb.Event{Type: caamdb.EventActivate, Provider: "codex", ProfileName: "work"}); err != nil {
+		t.Fatal(err)
+	}
+
+	writeScoring := func(script string) {
+		t.Helper()
+		spmCfg := config.DefaultSPMConfig()
+		spmCfg.Scoring.Weights.RecentUse = -80
+		spmCfg.Scoring.Script = script
+		if err := spmCfg.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	factor := func(sp robotScoredProfile, name string) *RobotScoreFactor {
+		for i := range sp.factors {
+			if sp.factors[i].Factor == name {
+				return &sp.factors[i]
+			}
+		}
+		return nil
+	}
+	byName := func(scored []robotScoredProfile) map[string]robotScoredProfile {
+		m := make(map[string]robotScoredProfile)
+		for _, sp := range scored {
+			m[sp.name] = sp
+		}
+		return m
+	}
+
+	writeScoring("")
+	scored := byName(scoreRobotNextProfiles("codex", []string{"work", "spare"}, "smart", false, db))
+	if f := factor(scored["work"], "recent_use"); f == nil || f.Points != -80 {
+		t.Errorf("work recent_use factor = %+v", f)
+	}
+	if f := factor(scored["spare"], "recent_use"); f != nil {
+		t.Errorf("spare recent_use factor = %+v, want none", f)
+	}
+
+	script := filepath.Join(tmpDir, "score.sh")
+	body := "#!/bin/sh\ngrep -q '\"command\":\"next\"' || exit 3\n" +
+		`echo '{"adjustments":[{"profile":"spare","points":-500,"reason":"team pick"},{"profile":"ghost","points":1}]}'` + "\n"
+	if err := os.WriteFile(script, []byte(body), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeScoring(script)
+	ranked := scoreRobotNextProfiles("codex", []string{"work", "spare"}, "smart", false, db)
+	if len(ranked) != 2 || ranked[0].name != "work" {
+		t.Fatalf("ranking with script = %+v, want work first", ranked)
+	}
+	if !strings.Contains(strings.Join(ranked[1].reasons, "; "), "scoring script: team pick (-500)") {
+		t.Errorf("spare reasons = %v", ranked[1].reasons)
+	}
+
+	writeScoring(filepath.Join(tmpDir, "missing.sh"))
+	for _, sp := range scoreRobotNextProfiles("codex", []string{"work", "spare"}, "smart", false, db) {
+		if f := factor(sp, "script"); f == nil || f.Points != 0 || !strings.Contains(f.Detail, "scoring script") {
+			t.Errorf("%s script factor with a failing script = %+v", sp.name, f)
+		}
+	}
+}
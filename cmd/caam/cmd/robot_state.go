@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/daemon"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/statusengine"
+)
+
+// robotStatusCache keeps status snapshots between robot commands run in one
+// process. It is off for one-shot commands; caam serve turns it on.
+var robotStatusCache = &statusengine.Cache{}
+
+// robotState is what a robot command knows about profiles for one
+// invocation: the status snapshot, leases held on other machines, and the
+// open database for the queries the snapshot does not cover.
+type robotState struct {
+	*statusengine.Snapshot
+	db     *caamdb.DB
+	remote []profileLease
+}
+
+// loadRobotState loads the state of providers' profiles. db may be nil.
+func loadRobotState(ctx context.Context, db *caamdb.DB, providers ...string) *robotState {
+	now := time.Now()
+	snap := robotStatusCache.Get(strings.Join(providers, ","), now, func() *statusengine.Snapshot {
+		src := statusengine.Sources{Vault: vault, DB: db, Health: healthStore}
+		if spmCfg, err := config.LoadSPMConfig(); err == nil {
+			src.SPM = spmCfg
+			// A cooldown the daemon will probe isn't over until the probe
+			// passes; a failed one extends it.
+			if running, _, _ := daemon.GetDaemonStatus(); running && spmCfg.Daemon.CooldownProbe.Enabled {
+				src.UnprobedSince = now.Add(-daemon.CooldownProbeLookback)
+			}
+		}
+		return statusengine.Load(ctx, src, providers, now)
+	})
+	return &robotState{Snapshot: snap, db: db, remote: remoteLeases(snap.Now)}
+}
+
+// profileHealth is getProfileHealthWithIdentity, starting from the stored
+// health in the snapshot.
+func (st *robotState) profileHealth(tool, profileName string) (*health.ProfileHealth, *identity.Identity) {
+	ph := completeProfileHealth(st.Health(tool, profileName), tool, profileName)
+	id := getVaultIdentity(tool, profileName)
+	applyIdentityToHealth(tool, profileName, ph, id)
+	return ph, id
+}
+
+// blockingLease is blockingLease, from the snapshot's leases.
+func (st *robotState) blockingLease(provider, profile, holder string) *profileLease {
+	if l := st.Lease(provider, profile); l != nil && l.Holder != holder {
+		return &profileLease{
+			Provider: l.Provider, Profile: l.ProfileName, Holder: l.Holder,
+			ExpiresAt: l.ExpiresAt, Note: l.Note, Source: "local",
+		}
+	}
+	for _, l := range st.remote {
+		if l.Provider == provider && l.Profile == profile && l.Holder != holder {
+			return &l
+		}
+	}
+	return nil
+}
+
+// openRobotState opens the database and loads the state of providers'
+// profiles. The returned func closes the database.
+func openRobotState(ctx context.Context, providers ...string) (*robotState, func()) {
+	db, _ := caamdb.Open()
+	return loadRobotState(ctx, db, providers...), func() {
+		if db != nil {
+			db.Close()
+		}
+	}
+}
+
+// robotScanWorkers bounds how many of one provider's profiles are scanned at
+// once.
+const robotScanWorkers = 8
+
+// defaultRobotScanTimeout is how long robot commands give each provider's
+// profile scan by default.
+const defaultRobotScanTimeout = 10 * time.Second
+
+// providerInfos reports on providers concurrently, giving each at most
+// timeout (no limit if zero), and returns how long each one took.
+func (st *robotState) providerInfos(ctx context.Context, providers []string, compact bool, timeout time.Duration) ([]RobotProviderInfo, []RobotProviderTiming) {
+	infos := make([]RobotProviderInfo, len(providers))
+	timings := make([]RobotProviderTiming, len(providers))
+	err := statusengine.ForEach(ctx, len(providers), len(providers), func(ctx context.Context, i int) {
+		start := time.Now()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		infos[i] = st.providerInfo(ctx, providers[i], compact)
+		timings[i] = RobotProviderTiming{
+			Provider:   providers[i],
+			DurationMs: time.Since(start).Milliseconds(),
+			Profiles:   len(infos[i].Profiles),
+			TimedOut:   infos[i].Error != "",
+		}
+	})
+	if err != nil {
+		// The caller gave up before some providers started.
+		for i, tool := range providers {
+			if infos[i].ID == "" {
+				infos[i] = RobotProviderInfo{
+					ID: tool, DisplayName: getProviderDisplayName(tool),
+					Profiles: []RobotProfileInfo{}, AuthPaths: []RobotAuthPath{},
+					Error: fmt.Sprintf("not scanned: %v", err),
+				}
+				timings[i] = RobotProviderTiming{Provider: tool, TimedOut: true}
+			}
+		}
+	}
+	return infos, timings
+}
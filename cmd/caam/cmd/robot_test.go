@@ -0,0 +1,828 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/anomaly"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/seed"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/statusengine"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+	"github.com/spf13/pflag"
+)
+
+func TestBuildHumanLoginAction(t *testing.T) {
+	action := buildHumanLoginAction("codex", "work")
+	if action == nil {
+		t.Fatal("expected human action for codex")
+	}
+
+	wantCommands := []string{
+		"caam activate codex work",
+		"codex login",
+		"caam backup codex work",
+	}
+	if len(action.Commands) != len(wantCommands) {
+		t.Fatalf("Commands = %v, want %v", action.Commands, wantCommands)
+	}
+	for i, want := range wantCommands {
+		if action.Commands[i] != want {
+			t.Errorf("Commands[%d] = %q, want %q", i, action.Commands[i], want)
+		}
+	}
+	if action.LoginURL == "" {
+		t.Error("expected LoginURL to be set")
+	}
+	if len(action.ExpectedPrompts) == 0 {
+		t.Error("expected ExpectedPrompts to be set")
+	}
+
+	if buildHumanLoginAction("unknown", "work") != nil {
+		t.Error("expected nil human action for unknown provider")
+	}
+}
+
+func TestGetHealthReason_StaleVsPool(t *testing.T) {
+	ph := &health.ProfileHealth{
+		TokenExpiresAt: time.Now().Add(48 * time.Hour),
+		StaleVsPool:    true,
+		LastPoolSync:   time.Now().Add(-5 * 24 * time.Hour),
+	}
+	status := health.CalculateStatus(ph)
+	if status != health.StatusWarning {
+		t.Fatalf("status = %v, want warning", status)
+	}
+
+	reason := getHealthReason(ph, status)
+	if !strings.HasPrefix(reason, "stale_vs_pool") {
+		t.Errorf("reason = %q, want stale_vs_pool prefix", reason)
+	}
+	if !strings.Contains(reason, "5d") {
+		t.Errorf("reason = %q, want last sync age", reason)
+	}
+
+	rec := generateRecommendation(RobotProfileInfo{Health: RobotHealthInfo{Status: "warning", Reason: reason}})
+	if rec != "run caam sync before use" {
+		t.Errorf("recommendation = %q", rec)
+	}
+}
+
+// setupSeededVault fills a temp vault and DB with seeded profiles for benchmarks.
+func TestProfilesForWorkspace(t *testing.T) {
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(t.TempDir(), "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	writeCodexProfile := func(name, workspace string) {
+		t.Helper()
+		claims, _ := json.Marshal(map[string]interface{}{
+			"email":                       name + "@example.com",
+			"https://api.openai.com/auth": map[string]interface{}{"chatgpt_account_id": workspace},
+		})
+		token := "e30." + base64.RawURLEncoding.EncodeToString(claims) + ".sig"
+		auth, _ := json.Marshal(map[string]interface{}{"tokens": map[string]string{"id_token": token}})
+		dir := vault.ProfilePath("codex", name)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "auth.json"), auth, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCodexProfile("alpha", "ws-a")
+	writeCodexProfile("beta", "ws-b")
+	writeCodexProfile("gamma", "ws-a")
+
+	got := profilesForWorkspace("codex", []string{"alpha", "beta", "gamma", "missing"}, "ws-a")
+	if strings.Join(got, ",") != "alpha,gamma" {
+		t.Errorf("profilesForWorkspace(ws-a) = %v, want [alpha gamma]", got)
+	}
+	if got := profilesForWorkspace("codex", []string{"alpha", "beta"}, "ws-z"); len(got) != 0 {
+		t.Errorf("profilesForWorkspace(ws-z) = %v, want none", got)
+	}
+}
+
+func TestPrepareRecommended(t *testing.T) {
+	oldVault, oldRefresh := vault, precheckRefreshProfile
+	vault = authfile.NewVault(filepath.Join(t.TempDir(), "vault"))
+	t.Cleanup(func() { vault, precheckRefreshProfile = oldVault, oldRefresh })
+
+	writeCodexAuth := func(name string, expiresIn time.Duration) {
+		t.Helper()
+		auth, _ := json.Marshal(map[string]interface{}{
+			"access_token":  "at-" + name,
+			"refresh_token": "rt-" + name,
+			"expires_at":    time.Now().Add(expiresIn).UTC().Format(time.RFC3339),
+		})
+		dir := vault.ProfilePath("codex", name)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "auth.json"), auth, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newData := func() *RobotPrecheckData {
+		return &RobotPrecheckData{
+			Provider:    "codex",
+			Recommended: &RobotPrecheckProfile{Name: "alpha", Score: 100},
+			Backups:     []RobotPrecheckProfile{{Name: "beta", Score: 50}},
+		}
+	}
+
+	t.Run("refreshes expiring token", func(t *testing.T) {
+		writeCodexAuth("alpha", 30*time.Minute)
+		writeCodexAuth("beta", 10*time.Hour)
+		precheckRefreshProfile = func(_ context.Context, provider, profile string, _ *authfile.Vault, _ *health.Storage) error {
+			writeCodexAuth(profile, 8*time.Hour)
+			return nil
+		}
+
+		data := newData()
+		got := prepareRecommended(context.Background(), "codex", data, 2*time.Hour)
+		if !got.Ready || got.Profile != "alpha" {
+			t.Fatalf("prepare = %+v, want alpha ready", got)
+		}
+		if len(got.Attempts) != 1 || got.Attempts[0].Action != "refreshed" {
+			t.Errorf("attempts = %+v, want one refresh", got.Attempts)
+		}
+		if data.Recommended.Name != "alpha" {
+			t.Errorf("recommended = %s, want alpha", data.Recommended.Name)
+		}
+	})
+
+	t.Run("skips refresh when token outlives session", func(t *testing.T) {
+		writeCodexAuth("alpha", 5*time.Hour)
+		precheckRefreshProfile = func(context.Context, string, string, *authfile.Vault, *health.Storage) error {
+			t.Error("refresh should not be called")
+			return nil
+		}
+
+		got := prepareRecommended(context.Background(), "codex", newData(), 2*time.Hour)
+		if !got.Ready || got.Attempts[0].Action != "none" {
+			t.Errorf("prepare = %+v, want ready without refresh", got)
+		}
+	})
+
+	t.Run("promotes backup when refresh fails", func(t *testing.T) {
+		writeCodexAuth("alpha", 30*time.Minute)
+		writeCodexAuth("beta", 10*time.Hour)
+		precheckRefreshProfile = func(context.Context, string, string, *authfile.Vault, *health.Storage) error {
+			return errors.New("token endpoint returned 500")
+		}
+
+		data := newData()
+		got := prepareRecommended(context.Background(), "codex", data, 2*time.Hour)
+		if !got.Ready || got.Profile != "beta" {
+			t.Fatalf("prepare = %+v, want beta ready", got)
+		}
+		if got.Attempts[0].Action != "failed" || got.Attempts[0].HumanAction == nil {
+			t.Errorf("first attempt = %+v, want failed with human action", got.Attempts[0])
+		}
+		if data.Recommended.Name != "beta" || len(data.Backups) != 1 || data.Backups[0].Name != "alpha" {
+			t.Errorf("recommended = %s, backups = %+v; want beta then alpha", data.Recommended.Name, data.Backups)
+		}
+		if data.Commands.Activate != "caam robot act activate codex beta" {
+			t.Errorf("activate = %q", data.Commands.Activate)
+		}
+	})
+
+	t.Run("not ready when nothing outlives session", func(t *testing.T) {
+		writeCodexAuth("alpha", 30*time.Minute)
+		writeCodexAuth("beta", 45*time.Minute)
+		precheckRefreshProfile = func(_ context.Context, _ string, profile string, _ *authfile.Vault, _ *health.Storage) error {
+			writeCodexAuth(profile, time.Hour)
+			return nil
+		}
+
+		got := prepareRecommended(context.Background(), "codex", newData(), 2*time.Hour)
+		if got.Ready {
+			t.Fatalf("prepare = %+v, want not ready", got)
+		}
+		for _, a := range got.Attempts {
+			if a.Action != "refreshed" || a.Ready {
+				t.Errorf("attempt = %+v, want refreshed but not ready", a)
+			}
+		}
+	})
+}
+
+func TestRunRobotStatusScansConcurrently(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "home", ".codex"))
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	var want []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("p%02d", i)
+		want = append(want, name)
+		if err := os.MkdirAll(vault.ProfilePath("claude", name), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(timeout string) (RobotStatusData, RobotTiming) {
+		t.Helper()
+		var out strings.Builder
+		robotStatusCmd.SetOut(&out)
+		t.Cleanup(func() { robotStatusCmd.SetOut(nil) })
+		if err := robotStatusCmd.Flags().Set("timeout", timeout); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = robotStatusCmd.Flags().Set("timeout", defaultRobotScanTimeout.String()) })
+		if err := runRobotStatus(robotStatusCmd, []string{"claude"}); err != nil {
+			t.Fatalf("runRobotStatus: %v", err)
+		}
+		var output struct {
+			Data   RobotStatusData `json:"data"`
+			Timing RobotTiming     `json:"timing"`
+		}
+		if err := json.Unmarshal([]byte(out.String()), &output); err != nil {
+			t.Fatalf("decode output: %v\n%s", err, out.String())
+		}
+		return output.Data, output.Timing
+	}
+
+	data, timing := run("10s")
+	if len(data.Providers) != 1 || data.Providers[0].Error != "" {
+		t.Fatalf("providers = %+v", data.Providers)
+	}
+	var got []string
+	for _, p := range data.Providers[0].Profiles {
+		got = append(got, p.Name)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("profiles = %v, want vault order %v", got, want)
+	}
+	if len(timing.Providers) != 1 || timing.Providers[0].Provider != "claude" || timing.Providers[0].Profiles != 20 || timing.Providers[0].TimedOut {
+		t.Errorf("timing.providers = %+v", timing.Providers)
+	}
+
+	data, timing = run("1ns")
+	if data.Providers[0].Error == "" || len(timing.Providers) != 1 || !timing.Providers[0].TimedOut {
+		t.Errorf("after timeout: provider = %+v, timing = %+v", data.Providers[0], timing.Providers)
+	}
+}
+
+func TestRunRobotValidateActive(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "home", ".codex"))
+
+	oldVault, oldHealth, oldCheck := vault, healthStore, robotCheckToken
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	healthStore = health.NewStorage(filepath.Join(tmpDir, "health.json"))
+	t.Cleanup(func() { vault, healthStore, robotCheckToken = oldVault, oldHealth, oldCheck })
+
+	for _, name := range []string{"good", "revoked", "flaky"} {
+		dir := vault.ProfilePath("codex", name)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		auth := `{"tokens":{"access_token":"tok-` + name + `","account_id":"acct-` + name + `"}}`
+		if err := os.WriteFile(filepath.Join(dir, "auth.json"), []byte(auth), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	robotCheckToken = func(_ context.Context, provider, token, accountID string) usage.TokenCheck {
+		check := usage.TokenCheck{Provider: provider, Latency: time.Millisecond}
+		switch token {
+		case "tok-good":
+			check.HTTPStatus, check.Valid, check.Confidence, check.Plan = 200, true, usage.ConfidenceHigh, "pro"
+		case "tok-revoked":
+			check.HTTPStatus, check.Confidence, check.Error = 401, usage.ConfidenceHigh, "unauthorized: status 401"
+		default:
+			check.Confidence, check.Error = usage.ConfidenceLow, "request failed: connection refused"
+		}
+		if accountID != "acct-"+strings.TrimPrefix(token, "tok-") {
+			t.Errorf("account ID for %s = %q", token, accountID)
+		}
+		return check
+	}
+
+	var out strings.Builder
+	robotValidateCmd.SetOut(&out)
+	t.Cleanup(func() { robotValidateCmd.SetOut(nil) })
+	if err := robotValidateCmd.Flags().Set("active", "true"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = robotValidateCmd.Flags().Set("active", "false") })
+	if err := runRobotValidate(robotValidateCmd, []string{"codex"}); err != nil {
+		t.Fatalf("runRobotValidate: %v", err)
+	}
+
+	var output struct {
+		Success bool              `json:"success"`
+		Data    RobotValidateData `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &output); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if output.Success || output.Data.Method != "active" || output.Data.Summary.Invalid != 1 || output.Data.Summary.Valid != 2 {
+		t.Fatalf("output = %+v", output)
+	}
+	results := make(map[string]RobotValidateResult)
+	for _, r := range output.Data.Profiles {
+		results[r.Profile] = r
+	}
+	if r := results["good"]; !r.Valid || r.Method != "active" || r.HTTPStatus != 200 || r.Plan != "pro" || r.Confidence != usage.ConfidenceHigh {
+		t.Errorf("good = %+v", r)
+	}
+	if r := results["revoked"]; r.Valid || r.HTTPStatus != 401 || r.HumanAction == nil || r.Confidence != usage.ConfidenceHigh {
+		t.Errorf("revoked = %+v", r)
+	}
+	if r := results["flaky"]; !r.Valid || r.Confidence != usage.ConfidenceLow || r.Note == "" {
+		t.Errorf("flaky = %+v, want the passive verdict with a note", r)
+	}
+
+	// Only the provider's own answers count against health.
+	if ph, _ := healthStore.GetProfile("codex", "revoked"); ph == nil || ph.ErrorCount1h != 1 {
+		t.Errorf("revoked health = %+v, want one error", ph)
+	}
+	if ph, _ := healthStore.GetProfile("codex", "flaky"); ph != nil && ph.ErrorCount1h != 0 {
+		t.Errorf("flaky health = %+v, want no errors", ph)
+	}
+}
+
+func TestRunRobotNextAllProviders(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "home", ".codex"))
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	for _, p := range []string{"claude/work", "claude/personal", "codex/main"} {
+		provider, profile, _ := strings.Cut(p, "/")
+		if err := os.MkdirAll(vault.ProfilePath(provider, profile), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out strings.Builder
+	robotNextCmd.SetOut(&out)
+	t.Cleanup(func() { robotNextCmd.SetOut(nil) })
+	if err := robotNextCmd.Flags().Set("all-providers", "true"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = robotNextCmd.Flags().Set("all-providers", "false") })
+
+	if err := runRobotNext(robotNextCmd, nil); err != nil {
+		t.Fatalf("runRobotNext: %v", err)
+	}
+
+	var output struct {
+		Success bool             `json:"success"`
+		Data    RobotNextAllData `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &output); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if !output.Success {
+		t.Fatalf("success = false: %s", out.String())
+	}
+
+	data := output.Data
+	if len(data.Ranked) != 3 {
+		t.Fatalf("ranked = %d profiles, want 3", len(data.Ranked))
+	}
+	for i, r := range data.Ranked {
+		if r.Rank != i+1 {
+			t.Errorf("ranked[%d].Rank = %d", i, r.Rank)
+		}
+		if i > 0 && r.Score > data.Ranked[i-1].Score {
+			t.Errorf("ranked not sorted by score: %+v", data.Ranked)
+		}
+		if r.NormalizedScore < 0 || r.NormalizedScore > 100 {
+			t.Errorf("normalized score %v out of range", r.NormalizedScore)
+		}
+	}
+	if data.Recommended.Rank != 1 {
+		t.Errorf("recommended rank = %d, want 1", data.Recommended.Rank)
+	}
+
+	picks := make(map[string]RobotNextProviderPick)
+	for _, pick := range data.Providers {
+		picks[pick.Provider] = pick
+	}
+	if pick := picks["claude"]; pick.Best == nil || len(pick.Alternates) != 1 {
+		t.Errorf("claude pick = %+v, want best plus one alternate", pick)
+	}
+	if pick := picks["codex"]; pick.Best == nil || pick.Best.Profile != "main" || len(pick.Alternates) != 0 {
+		t.Errorf("codex pick = %+v, want main only", pick)
+	}
+	if pick := picks["gemini"]; pick.Best != nil || pick.Blocked != "no profiles" {
+		t.Errorf("gemini pick = %+v, want blocked", pick)
+	}
+}
+
+func TestRunRobotNextTagFilter(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	for _, profile := range []string{"work", "personal"} {
+		if err := os.MkdirAll(vault.ProfilePath("claude", profile), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetProfileTags("claude", "work", map[string]string{"client": "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetProfileTags("claude", "personal", map[string]string{"client": "other"}); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	runWithTag := func(tag string) map[string]interface{} {
+		t.Helper()
+		var out strings.Builder
+		robotNextCmd.SetOut(&out)
+		t.Cleanup(func() { robotNextCmd.SetOut(nil) })
+		tagFlag := robotNextCmd.Flags().Lookup("tag")
+		if err := tagFlag.Value.(pflag.SliceValue).Replace([]string{tag}); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = tagFlag.Value.(pflag.SliceValue).Replace(nil) })
+
+		_ = runRobotNext(robotNextCmd, []string{"claude"})
+		var output map[string]interface{}
+		if err := json.Unmarshal([]byte(out.String()), &output); err != nil {
+			t.Fatalf("decode output: %v\n%s", err, out.String())
+		}
+		return output
+	}
+
+	output := runWithTag("client=acme")
+	data, _ := output["data"].(map[string]interface{})
+	if output["success"] != true || data["profile"] != "work" {
+		t.Errorf("next --tag client=acme = %v, want work", output)
+	}
+
+	output = runWithTag("client=nobody")
+	errInfo, _ := output["error"].(map[string]interface{})
+	if output["success"] != false || errInfo["code"] != "NO_TAG_MATCH" {
+		t.Errorf("next --tag client=nobody = %v, want NO_TAG_MATCH", output)
+	}
+}
+
+func TestRunRobotNextSkipsSuspect(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	if err := os.MkdirAll(vault.ProfilePath("claude", "flagged"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, ago := range []time.Duration{time.Hour, 3 * time.Hour} {
+		if _, err := db.RecordProbe(caamdb.Probe{Provider: "claude", ProfileName: "flagged", ProbedAt: time.Now().Add(-ago), HTTPStatus: 403}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pInfo := loadRobotState(context.Background(), db, "claude").profileInfo("claude", "flagged", "", false)
+	if pInfo.Suspect == nil || len(pInfo.Suspect.Evidence) != 1 || pInfo.Suspect.Evidence[0].Kind != anomaly.ForbiddenSpike {
+		t.Fatalf("status suspect = %+v, want forbidden spike evidence", pInfo.Suspect)
+	}
+
+	run := func(includeSuspect bool) map[string]interface{} {
+		t.Helper()
+		var out strings.Builder
+		robotNextCmd.SetOut(&out)
+		t.Cleanup(func() { robotNextCmd.SetOut(nil) })
+		if err := robotNextCmd.Flags().Set("include-suspect", strconv.FormatBool(includeSuspect)); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = robotNextCmd.Flags().Set("include-suspect", "false") })
+
+		_ = runRobotNext(robotNextCmd, []string{"claude"})
+		var output map[string]interface{}
+		if err := json.Unmarshal([]byte(out.String()), &output); err != nil {
+			t.Fatalf("decode output: %v\n%s", err, out.String())
+		}
+		return output
+	}
+
+	output := run(false)
+	errInfo, _ := output["error"].(map[string]interface{})
+	if output["success"] != false || errInfo["code"] != "ALL_BLOCKED" || !strings.Contains(fmt.Sprint(output["suggestions"]), "--include-suspect") {
+		t.Errorf("next without --include-suspect = %v, want ALL_BLOCKED suggesting --include-suspect", output)
+	}
+
+	output = run(true)
+	data, _ := output["data"].(map[string]interface{})
+	if output["success"] != true || data["profile"] != "flagged" {
+		t.Errorf("next --include-suspect = %v, want flagged", output)
+	}
+}
+
+func TestRobotProfileAwaitingCooldownProbe(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	if err := os.MkdirAll(vault.ProfilePath("claude", "work"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	now := time.Now()
+	if _, err := db.SetCooldown("claude", "work", now.Add(-2*time.Hour), time.Hour, "limit"); err != nil {
+		t.Fatal(err)
+	}
+
+	src := statusengine.Sources{Vault: vault, DB: db, SPM: config.DefaultSPMConfig()}
+	load := func() *robotState {
+		return &robotState{Snapshot: statusengine.Load(context.Background(), src, []string{"claude"}, now), db: db}
+	}
+
+	// Without the daemon probing, an ended cooldown is over.
+	if pInfo := load().profileInfo("claude", "work", "", false); pInfo.Cooldown != nil {
+		t.Errorf("cooldown without probing = %+v, want none", pInfo.Cooldown)
+	}
+
+	src.UnprobedSince = now.Add(-24 * time.Hour)
+	st := load()
+	pInfo := st.profileInfo("claude", "work", "", false)
+	if pInfo.Cooldown == nil || !pInfo.Cooldown.Active || !pInfo.Cooldown.PendingProbe {
+		t.Fatalf("cooldown awaiting probe = %+v, want active and pending", pInfo.Cooldown)
+	}
+	if reason := robotNextExclusion(pInfo, false, false); reason == "" {
+		t.Error("robot next would pick a profile still awaiting its probe")
+	}
+	if scored := st.scoreNext("claude", []string{"work"}, "smart", false, false); len(scored) != 0 {
+		t.Errorf("scoreNext = %d profiles, want none", len(scored))
+	}
+}
+
+func TestRunRobotNextAllProvidersRejectsProvider(t *testing.T) {
+	var out strings.Builder
+	robotNextCmd.SetOut(&out)
+	t.Cleanup(func() { robotNextCmd.SetOut(nil) })
+	if err := robotNextCmd.Flags().Set("all-providers", "true"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = robotNextCmd.Flags().Set("all-providers", "false") })
+
+	_ = runRobotNext(robotNextCmd, []string{"claude"})
+	if !strings.Contains(out.String(), "INVALID_ARGS") {
+		t.Errorf("output = %s, want INVALID_ARGS", out.String())
+	}
+}
+
+func TestNormalizeRobotNextScore(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  float64
+	}{
+		{robotNextMaxScore, 100},
+		{robotNextMaxScore * 2, 100},
+		{0, 0},
+		{-200, 0},
+		{robotNextMaxScore / 2, 50},
+	}
+	for _, tt := range tests {
+		if got := normalizeRobotNextScore(tt.score); got != tt.want {
+			t.Errorf("normalizeRobotNextScore(%v) = %v, want %v", tt.score, got, tt.want)
+		}
+	}
+}
+
+func setupSeededVault(b *testing.B, profiles int) {
+	b.Helper()
+	tmpDir := b.TempDir()
+	b.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	b.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	b.Setenv("CODEX_HOME", filepath.Join(tmpDir, "home", ".codex"))
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	b.Cleanup(func() { vault = oldVault })
+
+	db, err := caamdb.Open()
+	if err != nil {
+		b.Fatalf("Open db: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := seed.Generate(seed.Options{
+		VaultDir:  vault.BasePath(),
+		DB:        db,
+		Profiles:  profiles,
+		Providers: 3,
+	}); err != nil {
+		b.Fatalf("seed.Generate: %v", err)
+	}
+}
+
+func BenchmarkBuildProviderInfo(b *testing.B) {
+	setupSeededVault(b, 200)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, tool := range []string{"claude", "codex", "gemini"} {
+			buildProviderInfo(tool, false)
+		}
+	}
+}
+
+func BenchmarkRobotNext(b *testing.B) {
+	setupSeededVault(b, 200)
+	robotNextCmd.SetOut(io.Discard)
+	defer robotNextCmd.SetOut(nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := runRobotNext(robotNextCmd, []string{"claude"}); err != nil {
+			b.Fatalf("runRobotNext: %v", err)
+		}
+	}
+}
+
+func TestApplyUsageReading(t *testing.T) {
+	reading := &usage.UsageInfo{
+		Provider:        "claude",
+		FetchedAt:       time.Now(),
+		PrimaryWindow:   &usage.UsageWindow{UsedPercent: 85, Utilization: 0.85, ResetsAt: time.Now().Add(90 * time.Minute)},
+		SecondaryWindow: &usage.UsageWindow{UsedPercent: 40, Utilization: 0.4},
+	}
+
+	limits := RobotProfileLimits{Name: "work", AvailScore: 100}
+	applyUsageReading(&limits, reading, health.StatusHealthy)
+	if limits.PrimaryPct != 85 || limits.SecondaryPct != 40 || limits.ResetsIn == "" || limits.FetchedAt == "" {
+		t.Errorf("limits = %+v", limits)
+	}
+	if limits.AvailScore != reading.AvailabilityScore() || !strings.Contains(limits.Recommendation, "near rate limit") {
+		t.Errorf("score/recommendation = %d/%q", limits.AvailScore, limits.Recommendation)
+	}
+
+	critical := RobotProfileLimits{Name: "work"}
+	applyUsageReading(&critical, reading, health.StatusCritical)
+	if critical.AvailScore != 10 {
+		t.Errorf("critical AvailScore = %d, want capped at 10", critical.AvailScore)
+	}
+
+	failed := RobotProfileLimits{Name: "work", AvailScore: 50}
+	applyUsageReading(&failed, &usage.UsageInfo{Error: "unauthorized"}, health.StatusWarning)
+	if failed.Error != "unauthorized" || failed.AvailScore != 50 {
+		t.Errorf("failed = %+v, want health score kept", failed)
+	}
+}
+
+func TestApplyRobotNextStrategy(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	profiles := func() []robotScoredProfile {
+		return []robotScoredProfile{
+			{name: "a", score: 100, info: RobotProfileInfo{Active: true, PlanType: "pro"}},
+			{name: "b", score: 100, info: RobotProfileInfo{PlanType: "max"}},
+			{name: "c", score: 100, info: RobotProfileInfo{PlanType: "pro"}},
+		}
+	}
+	best := func(strategy string) robotScoredProfile {
+		scored := profiles()
+		applyRobotNextStrategy("claude", strategy, scored, db)
+		top := scored[0]
+		for _, sp := range scored[1:] {
+			if sp.score > top.score {
+				top = sp
+			}
+		}
+		if strategy != "smart" && len(top.reasons) == 0 {
+			t.Errorf("%s: no reason recorded", strategy)
+		}
+		return top
+	}
+
+	if got := best("sticky"); got.name != "a" {
+		t.Errorf("sticky picked %s, want the active profile", got.name)
+	}
+
+	// Round robin continues after the stored cursor and wraps around.
+	if got := best("round-robin"); got.name != "a" {
+		t.Errorf("round-robin without cursor picked %s, want a", got.name)
+	}
+	if err := db.SetRotationCursor("claude", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if got := best("round-robin"); got.name != "a" {
+		t.Errorf("round-robin after c picked %s, want a", got.name)
+	}
+	if err := db.SetRotationCursor("claude", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := best("round-robin"); got.name != "b" {
+		t.Errorf("round-robin after a picked %s, want b", got.name)
+	}
+
+	cfgPath := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "caam", "config.json")
+	if err := os.MkdirAll(filepath.Dir(cfgPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfgPath, []byte(`{"plan_weights":{"max":2}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := best("weighted"); got.name != "b" || got.score != 200 {
+		t.Errorf("weighted picked %s (%v), want the max plan at 200", got.name, got.score)
+	}
+
+	for name, tokens := range map[string]int64{"a": 5000, "b": 1000} {
+		if _, err := db.RecordUsage(caamdb.UsageRecord{Provider: "claude", ProfileName: name, TotalTokens: tokens}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := best("least-used-today"); got.name != "c" {
+		t.Errorf("least-used-today picked %s, want c", got.name)
+	}
+
+	if s, ok := parseRobotNextStrategy("Round_Robin"); !ok || s != "round-robin" {
+		t.Errorf("parseRobotNextStrategy(Round_Robin) = %q, %v", s, ok)
+	}
+	if _, ok := parseRobotNextStrategy("fastest"); ok {
+		t.Error("parseRobotNextStrategy accepted an unknown strategy")
+	}
+}
+
+func TestPreviewRobotActivate(t *testing.T) {
+	_, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+
+	dir := vault.ProfilePath("codex", "b")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "auth.json"), []byte(`{"access_token":"b"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	authPath := filepath.Join(os.Getenv("CODEX_HOME"), "auth.json")
+	if err := os.WriteFile(authPath, []byte(`{"access_token":"a"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, failure := previewRobotActivate("codex", []string{"activate", "codex", "b"}, true)
+	if failure != nil {
+		t.Fatalf("previewRobotActivate() failure = %+v", failure)
+	}
+	if !result.DryRun || len(result.Changes) != 1 || result.Changes[0].Path != authPath || result.Changes[0].Action != authfile.RestoreReplace {
+		t.Errorf("result = %+v", result)
+	}
+	if data, _ := os.ReadFile(authPath); string(data) != `{"access_token":"a"}` {
+		t.Errorf("dry run changed live auth to %s", data)
+	}
+
+	if _, failure := previewRobotActivate("codex", []string{"activate", "codex", "ghost"}, true); failure == nil || failure.Code != string(caamerr.ActivateFailed) {
+		t.Errorf("preview of a missing profile = %+v, want %s", failure, caamerr.ActivateFailed)
+	}
+}
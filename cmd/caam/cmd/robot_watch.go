@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/daemon"
+)
+
+// Change-only watch event types.
+const (
+	watchEventSnapshot       = "snapshot"
+	watchEventHeartbeat      = "heartbeat"
+	watchEventHealthChanged  = "profile_health_changed"
+	watchEventCooldownStart  = "cooldown_started"
+	watchEventCooldownExpiry = "cooldown_expired"
+	watchEventActiveChanged  = "active_profile_changed"
+	watchEventTokenExpiring  = "token_expiring"
+)
+
+// RobotWatchSnapshot is one full-status line of `caam robot watch`.
+type RobotWatchSnapshot struct {
+	Timestamp string              `json:"timestamp"`
+	Providers []RobotProviderInfo `json:"providers"`
+}
+
+// RobotWatchChange is one line of `caam robot watch --changes-only`. The
+// first line is a snapshot with the full status; after that only the fields
+// relevant to each event type are set.
+type RobotWatchChange struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	Provider  string `json:"provider,omitempty"`
+	Profile   string `json:"profile,omitempty"`
+
+	// From and To are the old and new health status
+	// (profile_health_changed) or active profile (active_profile_changed).
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+
+	Reason    string `json:"reason,omitempty"`
+	Until     string `json:"until,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	ExpiresIn string `json:"expires_in,omitempty"`
+
+	// Providers is the full status (snapshot only).
+	Providers []RobotProviderInfo `json:"providers,omitempty"`
+}
+
+// watchEmitter writes watch output: every snapshot in full, or with
+// changesOnly the first snapshot followed by typed deltas and heartbeats.
+type watchEmitter struct {
+	out         io.Writer
+	changesOnly bool
+	heartbeat   time.Duration
+
+	prev     *RobotWatchSnapshot
+	lastEmit time.Time
+	now      func() time.Time
+}
+
+func (e *watchEmitter) emit(snap RobotWatchSnapshot) error {
+	enc := json.NewEncoder(e.out)
+	if !e.changesOnly {
+		return enc.Encode(snap)
+	}
+
+	now := time.Now()
+	if e.now != nil {
+		now = e.now()
+	}
+
+	var changes []RobotWatchChange
+	if e.prev == nil {
+		changes = []RobotWatchChange{{Type: watchEventSnapshot, Timestamp: snap.Timestamp, Providers: snap.Providers}}
+	} else {
+		changes = diffWatchSnapshots(*e.prev, snap)
+	}
+	e.prev = &snap
+
+	if len(changes) == 0 {
+		if e.heartbeat <= 0 || now.Sub(e.lastEmit) < e.heartbeat {
+			return nil
+		}
+		changes = []RobotWatchChange{{Type: watchEventHeartbeat, Timestamp: snap.Timestamp}}
+	}
+	for _, c := range changes {
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+	}
+	e.lastEmit = now
+	return nil
+}
+
+// diffWatchSnapshots returns the events that turn prev into next, in
+// provider and profile order. Profiles that appear or disappear between
+// snapshots produce no events.
+func diffWatchSnapshots(prev, next RobotWatchSnapshot) []RobotWatchChange {
+	prevAt, nextAt := watchSnapshotTime(prev), watchSnapshotTime(next)
+
+	oldProviders := make(map[string]RobotProviderInfo, len(prev.Providers))
+	for _, p := range prev.Providers {
+		oldProviders[p.ID] = p
+	}
+
+	var changes []RobotWatchChange
+	for _, p := range next.Providers {
+		old, ok := oldProviders[p.ID]
+		if !ok {
+			continue
+		}
+		change := func(typ, profile string) RobotWatchChange {
+			return RobotWatchChange{Type: typ, Timestamp: next.Timestamp, Provider: p.ID, Profile: profile}
+		}
+
+		if old.ActiveProfile != p.ActiveProfile {
+			c := change(watchEventActiveChanged, p.ActiveProfile)
+			c.From, c.To = old.ActiveProfile, p.ActiveProfile
+			changes = append(changes, c)
+		}
+
+		oldProfiles := make(map[string]RobotProfileInfo, len(old.Profiles))
+		for _, prof := range old.Profiles {
+			oldProfiles[prof.Name] = prof
+		}
+		for _, prof := range p.Profiles {
+			before, ok := oldProfiles[prof.Name]
+			if !ok {
+				continue
+			}
+
+			if before.Health.Status != prof.Health.Status {
+				c := change(watchEventHealthChanged, prof.Name)
+				c.From, c.To = before.Health.Status, prof.Health.Status
+				c.Reason = prof.Health.Reason
+				changes = append(changes, c)
+			}
+
+			wasCooling := before.Cooldown != nil && before.Cooldown.Active
+			isCooling := prof.Cooldown != nil && prof.Cooldown.Active
+			switch {
+			case isCooling && !wasCooling:
+				c := change(watchEventCooldownStart, prof.Name)
+				c.Until, c.Reason = prof.Cooldown.Until, prof.Cooldown.Reason
+				changes = append(changes, c)
+			case wasCooling && !isCooling:
+				changes = append(changes, change(watchEventCooldownExpiry, prof.Name))
+			}
+
+			if watchTokenExpiring(prof, nextAt) && (!watchTokenExpiring(before, prevAt) || before.Health.ExpiresAt != prof.Health.ExpiresAt) {
+				c := change(watchEventTokenExpiring, prof.Name)
+				c.ExpiresAt, c.ExpiresIn = prof.Health.ExpiresAt, prof.Health.ExpiresIn
+				changes = append(changes, c)
+			}
+		}
+	}
+	return changes
+}
+
+// watchSnapshotTime returns when a snapshot was taken.
+func watchSnapshotTime(snap RobotWatchSnapshot) time.Time {
+	if t, err := time.Parse(time.RFC3339, snap.Timestamp); err == nil {
+		return t
+	}
+	return time.Now()
+}
+
+// watchTokenExpiring reports whether a profile's token expires within the
+// same warning window the daemon notifies on.
+func watchTokenExpiring(p RobotProfileInfo, now time.Time) bool {
+	if p.Health.ExpiresAt == "" {
+		return false
+	}
+	exp, err := time.Parse(time.RFC3339, p.Health.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	ttl := exp.Sub(now)
+	return ttl > 0 && ttl <= daemon.DefaultExpiryWarning
+}
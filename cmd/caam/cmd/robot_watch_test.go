@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchEmitterChangesOnly(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	var buf bytes.Buffer
+	e := &watchEmitter{out: &buf, changesOnly: true, heartbeat: 30 * time.Second, now: func() time.Time { return now }}
+
+	snap := func(at time.Time, active string, profiles ...RobotProfileInfo) RobotWatchSnapshot {
+		return RobotWatchSnapshot{
+			Timestamp: at.Format(time.RFC3339),
+			Providers: []RobotProviderInfo{{ID: "claude", ActiveProfile: active, Profiles: profiles}},
+		}
+	}
+	profile := func(name, status string, cooling bool, expiresAt time.Time) RobotProfileInfo {
+		p := RobotProfileInfo{Name: name, Health: RobotHealthInfo{Status: status, ExpiresAt: expiresAt.Format(time.RFC3339)}}
+		if cooling {
+			p.Cooldown = &RobotCooldown{Active: true, Until: start.Add(time.Hour).Format(time.RFC3339), Reason: "rate limit"}
+		}
+		return p
+	}
+	farExpiry := start.Add(24 * time.Hour)
+	nearExpiry := start.Add(3 * time.Hour)
+
+	read := func() []RobotWatchChange {
+		t.Helper()
+		var out []RobotWatchChange
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var c RobotWatchChange
+			if err := json.Unmarshal([]byte(line), &c); err != nil {
+				t.Fatalf("bad line %q: %v", line, err)
+			}
+			out = append(out, c)
+		}
+		buf.Reset()
+		return out
+	}
+
+	// First poll: full snapshot.
+	e.emit(snap(now, "a", profile("a", "healthy", false, farExpiry), profile("b", "healthy", false, nearExpiry)))
+	if got := read(); len(got) != 1 || got[0].Type != watchEventSnapshot || len(got[0].Providers) != 1 {
+		t.Fatalf("first poll = %+v, want one snapshot", got)
+	}
+
+	// Nothing changed, heartbeat not due.
+	now = now.Add(10 * time.Second)
+	e.emit(snap(now, "a", profile("a", "healthy", false, farExpiry), profile("b", "healthy", false, nearExpiry)))
+	if got := read(); len(got) != 0 {
+		t.Fatalf("unchanged poll = %+v, want nothing", got)
+	}
+
+	// Active profile switches, a goes into cooldown and turns critical.
+	now = now.Add(10 * time.Second)
+	e.emit(snap(now, "b", profile("a", "critical", true, farExpiry), profile("b", "healthy", false, nearExpiry)))
+	got := read()
+	var types []string
+	for _, c := range got {
+		types = append(types, c.Type)
+	}
+	if want := "active_profile_changed profile_health_changed cooldown_started"; strings.Join(types, " ") != want {
+		t.Fatalf("types = %v, want %s", types, want)
+	}
+	if got[0].From != "a" || got[0].To != "b" || got[1].To != "critical" || got[2].Reason != "rate limit" {
+		t.Errorf("changes = %+v", got)
+	}
+
+	// An hour later b's token is inside the warning window and a's cooldown is over.
+	now = now.Add(time.Hour + 10*time.Minute)
+	e.emit(snap(now, "b", profile("a", "critical", false, farExpiry), profile("b", "healthy", false, nearExpiry)))
+	got = read()
+	if len(got) != 2 || got[0].Type != watchEventCooldownExpiry || got[1].Type != watchEventTokenExpiring || got[1].Profile != "b" {
+		t.Fatalf("changes = %+v, want cooldown_expired for a then token_expiring for b", got)
+	}
+
+	// Quiet for longer than the heartbeat: one heartbeat, not repeated early.
+	now = now.Add(31 * time.Second)
+	e.emit(snap(now, "b", profile("a", "critical", false, farExpiry), profile("b", "healthy", false, nearExpiry)))
+	if got := read(); len(got) != 1 || got[0].Type != watchEventHeartbeat {
+		t.Fatalf("quiet poll = %+v, want heartbeat", got)
+	}
+	now = now.Add(5 * time.Second)
+	e.emit(snap(now, "b", profile("a", "critical", false, farExpiry), profile("b", "healthy", false, nearExpiry)))
+	if got := read(); len(got) != 0 {
+		t.Fatalf("poll after heartbeat = %+v, want nothing", got)
+	}
+}
@@ -11,25 +11,41 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/chaos"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/exec"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/hooks"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/i18n"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/namespace"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/passthrough"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/progress"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/project"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/claude"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/codex"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/copilot"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/cursor"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/gemini"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/plugin"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/telemetry"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/tui"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/version"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/warnings"
@@ -48,15 +64,72 @@ var (
 	globalDB     *caamdb.DB
 )
 
+// globalDBMu guards opening globalDB, which robot commands may do from
+// several goroutines while scanning profiles.
+var globalDBMu sync.Mutex
+
 // Tools supported for auth file swapping
 var tools = map[string]func() authfile.AuthFileSet{
-	"codex":  authfile.CodexAuthFiles,
-	"claude": authfile.ClaudeAuthFiles,
-	"gemini": authfile.GeminiAuthFiles,
+	"codex":   authfile.CodexAuthFiles,
+	"claude":  authfile.ClaudeAuthFiles,
+	"gemini":  authfile.GeminiAuthFiles,
+	"cursor":  authfile.CursorAuthFiles,
+	"copilot": authfile.CopilotAuthFiles,
+}
+
+// builtinTools lists the compiled-in tools in display order.
+var builtinTools = []string{"codex", "claude", "gemini", "cursor", "copilot"}
+
+// toolNames returns the built-in tools followed by plugin providers, sorted.
+func toolNames() []string {
+	names := append([]string(nil), builtinTools...)
+	var extra []string
+	for name := range tools {
+		if !slices.Contains(builtinTools, name) {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
+}
+
+// loadProviderPlugins registers the providers described in providers.d.
+// Broken descriptors are reported and skipped.
+func loadProviderPlugins() {
+	descriptors, errs := plugin.LoadDir(plugin.Dir())
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: provider plugin skipped: %v\n", err)
+	}
+	for _, d := range descriptors {
+		plugin.Register(d)
+		p := plugin.NewProvider(d)
+		registry.Register(p)
+		tools[d.ID] = p.AuthFileSet
+	}
+}
+
+// subTools are provider variants that share a parent tool's vault namespace
+// and identity but only read and write their own auth files. They are
+// accepted by backup, activate, and paths; everything else uses the parent.
+var subTools = map[string]func() authfile.AuthFileSet{
+	authfile.GeminiVariantCLI:        authfile.GeminiCLIAuthFiles,
+	authfile.GeminiVariantCodeAssist: authfile.GeminiCodeAssistAuthFiles,
+	authfile.GeminiVariantADC:        authfile.GeminiADCAuthFiles,
+}
+
+// lookupToolFileSet resolves a tool or sub-provider name to its file set.
+func lookupToolFileSet(name string) (func() authfile.AuthFileSet, bool) {
+	if getFileSet, ok := tools[name]; ok {
+		return getFileSet, true
+	}
+	getFileSet, ok := subTools[name]
+	return getFileSet, ok
 }
 
 // getDB returns the global database connection, initializing it if necessary.
 func getDB() (*caamdb.DB, error) {
+	globalDBMu.Lock()
+	defer globalDBMu.Unlock()
 	targetPath := filepath.Clean(caamdb.DefaultPath())
 	if globalDB != nil {
 		if globalDB.Path() == targetPath {
@@ -91,6 +164,11 @@ Supported tools:
   - codex   (OpenAI Codex CLI / GPT Pro)
   - claude  (Anthropic Claude Code / Claude Max)
   - gemini  (Google Gemini CLI / Gemini Ultra)
+  - cursor  (Cursor CLI / cursor-agent)
+  - copilot (GitHub Copilot CLI)
+
+More tools can be added without recompiling by dropping a provider
+descriptor into ~/.config/caam/providers.d/ (see 'caam providers').
 
 Advanced: Profile isolation for simultaneous sessions:
   caam profile add codex work
@@ -103,8 +181,18 @@ Run 'caam' without arguments to launch the interactive TUI.`,
 		return tui.Run()
 	},
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		if _, err := config.MigrateDataToCAAMHome(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: data migration skipped: %v\n", err)
+		// Point CAAM_HOME at the selected namespace before anything
+		// resolves a path.
+		if err := enterNamespace(cmd); err != nil {
+			return err
+		}
+		if err := enterConfigEnv(cmd); err != nil {
+			return err
+		}
+		if namespace.Current() == "" {
+			if _, err := config.MigrateDataToCAAMHome(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: data migration skipped: %v\n", err)
+			}
 		}
 
 		// Initialize vault
@@ -124,6 +212,9 @@ Run 'caam' without arguments to launch the interactive TUI.`,
 		registry.Register(codex.New())
 		registry.Register(claude.New())
 		registry.Register(gemini.New())
+		registry.Register(cursor.New())
+		registry.Register(copilot.New())
+		loadProviderPlugins()
 
 		// Initialize runner
 		runner = exec.NewRunner(registry)
@@ -132,8 +223,30 @@ Run 'caam' without arguments to launch the interactive TUI.`,
 		var err error
 		cfg, err = config.Load()
 		if err != nil {
-			return fmt.Errorf("load config: %w", err)
+			return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
+		}
+
+		// Pick the language for human-readable output.
+		var language string
+		spmCfg, err := config.LoadSPMConfig()
+		if err == nil {
+			language = spmCfg.Language
 		}
+		i18n.SetLocale(i18n.Detect(language))
+
+		// Log this command to its file under the data directory, and
+		// trace it if tracing is enabled.
+		startCommandLog(cmd, args, spmCfg)
+		startCommandTrace(cmd, spmCfg)
+
+		// Count the command if the user opted in to telemetry (no-op otherwise).
+		telemetry.Record(cmd.CommandPath())
+
+		// Persist what this command does to the event stream.
+		installEventBus()
+
+		// Switch profiles the same way whichever package does it.
+		installActivationHook()
 
 		// Show token expiry warnings (skip for certain commands)
 		if shouldShowWarnings(cmd) {
@@ -149,9 +262,65 @@ Run 'caam' without arguments to launch the interactive TUI.`,
 	},
 }
 
-// Execute runs the root command.
+// Execute runs the root command. Command-line mistakes cobra reports
+// (unknown commands, wrong argument counts) come back tagged
+// caamerr.Usage.
 func Execute() error {
-	return rootCmd.Execute()
+	commandLogging = true
+	err := rootCmd.Execute()
+	if err != nil && isUsageError(err) {
+		err = caamerr.Wrap(caamerr.Usage, err)
+	}
+	finishCommandTrace(err)
+	finishCommandLog(err)
+	return err
+}
+
+// ExitError makes the process exit with Code instead of the exit status of
+// the error's caamerr code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode returns the process exit status for an error from Execute.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return caamerr.ExitCodeOf(err)
+}
+
+// cobraUsageErrors prefix the errors cobra returns for a bad command line
+// before any command runs. Flag parse errors are tagged by the root
+// command's flag error func instead.
+var cobraUsageErrors = []string{
+	"unknown command ",
+	"accepts ",
+	"requires at least ",
+	"requires at most ",
+	"invalid argument ",
+	"required flag(s) ",
+	"if any flags in the group ",
+}
+
+func isUsageError(err error) bool {
+	var coded *caamerr.Error
+	if errors.As(err, &coded) {
+		return false
+	}
+	msg := err.Error()
+	for _, prefix := range cobraUsageErrors {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 // shouldShowWarnings returns true if the current command should display token warnings.
@@ -227,31 +396,87 @@ func buildProfileHealth(tool, profileName string) *health.ProfileHealth {
 			ph = stored
 		}
 	}
+	return completeProfileHealth(ph, tool, profileName)
+}
 
-	// Get auth files from vault profile
-	vaultPath := vault.ProfilePath(tool, profileName)
+// completeProfileHealth fills in stored health data with the token expiry
+// from the vault and the sync pool's staleness.
+func completeProfileHealth(ph *health.ProfileHealth, tool, profileName string) *health.ProfileHealth {
+	expInfo, err := parseVaultExpiry(tool, profileName)
 
-	// Try to parse expiry based on tool type
-	var expInfo *health.ExpiryInfo
-	var err error
+	// If file parsing succeeds and provides an expiry, treat it as authoritative
+	if err == nil && expInfo != nil && !expInfo.ExpiresAt.IsZero() {
+		ph.TokenExpiresAt = expInfo.ExpiresAt
+	}
+
+	if chaos.Active(chaos.ExpiredTokens) {
+		ph.TokenExpiresAt = time.Now().Add(-time.Hour)
+	}
+
+	if state := loadPoolSyncState(); state != nil {
+		ph.StaleVsPool, ph.LastPoolSync = state.StaleVsPool(tool, profileName, syncstate.DefaultPoolStaleThreshold, time.Now())
+	}
+
+	return ph
+}
+
+// parseVaultExpiry reads token expiry from a profile's vault auth files.
+func parseVaultExpiry(tool, profileName string) (*health.ExpiryInfo, error) {
+	vaultPath := vault.ProfilePath(tool, profileName)
 
 	switch tool {
 	case "claude":
-		expInfo, err = health.ParseClaudeExpiry(vaultPath)
+		return health.ParseClaudeExpiry(vaultPath)
 	case "codex":
 		// Codex auth is in auth.json at vaultPath
-		authPath := filepath.Join(vaultPath, "auth.json")
-		expInfo, err = health.ParseCodexExpiry(authPath)
+		return health.ParseCodexExpiry(filepath.Join(vaultPath, "auth.json"))
 	case "gemini":
-		expInfo, err = health.ParseGeminiExpiry(vaultPath)
+		return health.ParseGeminiExpiry(vaultPath)
+	case "cursor":
+		return health.ParseCursorExpiry(vaultPath)
+	case "copilot":
+		return health.ParseCopilotExpiry(vaultPath)
+	default:
+		return health.ParseRegisteredExpiry(tool, vaultPath)
 	}
+}
 
-	// If file parsing succeeds and provides an expiry, treat it as authoritative
-	if err == nil && expInfo != nil && !expInfo.ExpiresAt.IsZero() {
-		ph.TokenExpiresAt = expInfo.ExpiresAt
+// poolSyncStateCache holds the sync state for the current data dir so health
+// checks across many profiles read it from disk once. It is keyed by the
+// modification time and size of pool.json as well, so long-running
+// processes like caam serve and the TUI see the pool change under them.
+var poolSyncStateCache struct {
+	sync.Mutex
+	dir     string
+	modTime time.Time
+	size    int64
+	state   *syncstate.SyncState
+}
+
+// loadPoolSyncState returns the sync state if a sync pool is enabled, or nil.
+func loadPoolSyncState() *syncstate.SyncState {
+	dir := syncstate.SyncDataDir()
+	var modTime time.Time
+	size := int64(-1)
+	info, err := os.Stat(filepath.Join(dir, "pool.json"))
+	if err == nil {
+		modTime, size = info.ModTime(), info.Size()
 	}
 
-	return ph
+	c := &poolSyncStateCache
+	c.Lock()
+	defer c.Unlock()
+	if c.dir != dir || !c.modTime.Equal(modTime) || c.size != size {
+		c.dir, c.modTime, c.size = dir, modTime, size
+		c.state = nil
+		if err == nil {
+			state := syncstate.NewSyncState(dir)
+			if err := state.Load(); err == nil && state.Pool.Enabled {
+				c.state = state
+			}
+		}
+	}
+	return c.state
 }
 
 func getProfileHealthWithIdentity(tool, profileName string) (*health.ProfileHealth, *identity.Identity) {
@@ -261,7 +486,46 @@ func getProfileHealthWithIdentity(tool, profileName string) (*health.ProfileHeal
 	return ph, id
 }
 
+// getVaultIdentity returns the identity for a vault profile, parsed from its
+// auth files and completed from the identity recorded by caam identity
+// refresh when the files lack an email or plan.
 func getVaultIdentity(tool, profileName string) *identity.Identity {
+	if vault == nil {
+		return nil
+	}
+	id := extractVaultIdentity(tool, profileName)
+	if id != nil && id.Email != "" && id.PlanType != "" {
+		return id
+	}
+	db, err := getDB()
+	if err != nil {
+		return id
+	}
+	stored, err := db.ProfileIdentityFor(tool, profileName)
+	if err != nil || stored == nil {
+		return id
+	}
+	if id == nil {
+		id = &identity.Identity{Provider: tool}
+	}
+	if id.Email == "" {
+		id.Email = stored.Email
+	}
+	if id.PlanType == "" {
+		id.PlanType = stored.PlanType
+	}
+	if id.AccountID == "" {
+		id.AccountID = stored.AccountID
+	}
+	if id.Organization == "" {
+		id.Organization = stored.Organization
+	}
+	normalizeIdentityPlan(id)
+	return id
+}
+
+// extractVaultIdentity parses identity from a vault profile's auth files.
+func extractVaultIdentity(tool, profileName string) *identity.Identity {
 	if vault == nil {
 		return nil
 	}
@@ -276,24 +540,74 @@ func getVaultIdentity(tool, profileName string) *identity.Identity {
 		normalizeIdentityPlan(id)
 		return id
 	case "claude":
+		// Claude Code uses an API key over any OAuth credentials.
+		if vault.ClaudeAPIKey(profileName) != nil {
+			return &identity.Identity{Provider: "claude", PlanType: identity.PlanAPI}
+		}
 		id, err := identity.ExtractFromClaudeCredentials(filepath.Join(vaultPath, ".credentials.json"))
 		if err != nil {
 			return nil
 		}
+		// The account and selected organization live in .claude.json.
+		if acct, err := identity.ExtractFromClaudeConfig(filepath.Join(vaultPath, ".claude.json")); err == nil {
+			if id.Email == "" {
+				id.Email = acct.Email
+			}
+			if id.AccountID == "" {
+				id.AccountID = acct.AccountID
+			}
+			id.Organization = acct.Organization
+			id.OrganizationID = acct.OrganizationID
+		}
 		normalizeIdentityPlan(id)
 		return id
 	case "gemini":
 		candidates := []string{
 			filepath.Join(vaultPath, "settings.json"),
+			filepath.Join(vaultPath, "oauth_creds.json"),
 			filepath.Join(vaultPath, "oauth_credentials.json"),
-		}
+			// Gemini Code Assist shares the identity when only it is logged in.
+			filepath.Join(vaultPath, "code-assist-credentials.json"),
+			filepath.Join(vaultPath, "code-assist-settings.json"),
+			// gcloud ADC names the Google account and quota project.
+			filepath.Join(vaultPath, "application_default_credentials.json"),
+		}
+		// Prefer the first file naming the account; Gemini CLI settings
+		// often carry none when the sign-in lives in ADC.
+		var found *identity.Identity
 		for _, path := range candidates {
 			id, err := identity.ExtractFromGeminiConfig(path)
 			if err != nil {
 				continue
 			}
-			normalizeIdentityPlan(id)
-			return id
+			if found == nil {
+				found = id
+			} else if found.Email == "" {
+				found.Email = id.Email
+			}
+			if found.Organization == "" {
+				found.Organization = id.Organization
+			}
+			if found.Email != "" {
+				break
+			}
+		}
+		if found != nil {
+			normalizeIdentityPlan(found)
+			return found
+		}
+	case "cursor":
+		id, err := identity.ExtractFromCursorAuth(filepath.Join(vaultPath, "auth.json"))
+		if err != nil {
+			return nil
+		}
+		normalizeIdentityPlan(id)
+		return id
+	case "copilot":
+		for _, name := range []string{"hosts.json", "apps.json"} {
+			if id, err := identity.ExtractFromCopilotHosts(filepath.Join(vaultPath, name)); err == nil {
+				return id
+			}
 		}
 	}
 
@@ -374,6 +688,9 @@ func formatIdentityDisplay(id *identity.Identity) (string, string) {
 			plan = formatted
 		}
 	}
+	if id.Provider == "claude" && strings.TrimSpace(id.Organization) != "" {
+		plan += " (" + id.Organization + ")"
+	}
 	return email, plan
 }
 
@@ -473,25 +790,12 @@ func checkAllProfilesCooldown(tool string) (bool, time.Duration, string) {
 
 // formatAllCooldownWarning formats the "all profiles in cooldown" warning.
 func formatAllCooldownWarning(tool string, remaining time.Duration, nextProfile string, opts health.FormatOptions) string {
-	var timeStr string
-	if remaining >= time.Hour {
-		hours := int(remaining.Hours())
-		mins := int(remaining.Minutes()) % 60
-		timeStr = fmt.Sprintf("%dh %dm", hours, mins)
-	} else {
-		mins := int(remaining.Minutes())
-		if mins < 1 {
-			timeStr = "<1m"
-		} else {
-			timeStr = fmt.Sprintf("%dm", mins)
-		}
-	}
-
+	msg := i18n.T("warning.all_cooldown", tool, nextProfile, i18n.HoursMinutes(remaining))
 	if opts.NoColor {
-		return fmt.Sprintf("%s: ⚠️  ALL profiles in cooldown (next available: %s in %s)", tool, nextProfile, timeStr)
+		return msg
 	}
 	// Yellow warning
-	return fmt.Sprintf("\033[33m%s: ⚠️  ALL profiles in cooldown (next available: %s in %s)\033[0m", tool, nextProfile, timeStr)
+	return "\033[33m" + msg + "\033[0m"
 }
 
 // truncateDescription truncates a description to maxLen characters, adding "..." if truncated.
@@ -508,7 +812,32 @@ func truncateDescription(desc string, maxLen int) string {
 	return desc[:maxLen-3] + "..."
 }
 
+// addProgressFlag registers --progress on a long-running command.
+func addProgressFlag(cmd *cobra.Command) {
+	cmd.Flags().String("progress", "", "emit machine-readable progress to stderr (json: NDJSON events)")
+}
+
+// progressReporter returns the reporter selected by --progress, or nil when
+// progress reporting is off.
+func progressReporter(cmd *cobra.Command, operation string) (*progress.Reporter, error) {
+	mode, _ := cmd.Flags().GetString("progress")
+	r, err := progress.FromMode(mode, cmd.ErrOrStderr(), operation)
+	if err != nil || activeJob == nil {
+		return r, err
+	}
+	// Jobs record progress even when --progress is off.
+	if r == nil {
+		r = progress.New(io.Discard, operation)
+	}
+	r.Observe(activeJob.Track)
+	return r, nil
+}
+
 func init() {
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return caamerr.Wrap(caamerr.Usage, err)
+	})
+
 	// Core commands (auth file swapping - PRIMARY)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(backupCmd)
@@ -542,11 +871,15 @@ var versionCmd = &cobra.Command{
 
 // backupOutput is the JSON output structure for backup command.
 type backupOutput struct {
-	Success bool   `json:"success"`
-	Tool    string `json:"tool"`
-	Profile string `json:"profile"`
-	Path    string `json:"path"`
-	Error   string `json:"error,omitempty"`
+	Success   bool         `json:"success"`
+	Tool      string       `json:"tool"`
+	Profile   string       `json:"profile"`
+	Path      string       `json:"path"`
+	Error     string       `json:"error,omitempty"`
+	ErrorCode caamerr.Code `json:"error_code,omitempty"`
+
+	// Unquarantined is set when the backup lifted a revoked-token quarantine.
+	Unquarantined bool `json:"unquarantined,omitempty"`
 }
 
 // backupCmd saves current auth files to the vault.
@@ -566,6 +899,8 @@ Examples:
   caam backup codex work-account
   caam backup claude personal-max
   caam backup gemini team-ultra
+  caam backup gemini-code-assist team-ultra   # Only the Code Assist sign-in
+  caam backup gemini-adc vertex-prod          # Only the gcloud ADC credentials
   caam backup codex work --json`,
 	Args: cobra.ExactArgs(2),
 	RunE: runBackup,
@@ -589,6 +924,7 @@ func runBackup(cmd *cobra.Command, args []string) error {
 		if jsonOutput {
 			output.Success = false
 			output.Error = err.Error()
+			output.ErrorCode = caamerr.CodeOf(err)
 			enc := json.NewEncoder(cmd.OutOrStdout())
 			enc.SetIndent("", "  ")
 			_ = enc.Encode(output)
@@ -597,9 +933,9 @@ func runBackup(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	getFileSet, ok := tools[tool]
+	getFileSet, ok := lookupToolFileSet(tool)
 	if !ok {
-		return emitJSONError(fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool))
+		return emitJSONError(caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini, gemini-cli, gemini-code-assist, gemini-adc)", tool))
 	}
 
 	fileSet := getFileSet()
@@ -609,13 +945,28 @@ func runBackup(cmd *cobra.Command, args []string) error {
 		return emitJSONError(fmt.Errorf("no auth files found for %s - login first using the tool's login command", tool))
 	}
 
+	if err := runPreHook(hooks.PreBackup, fileSet.Tool, profileName, "backup"); err != nil {
+		return emitJSONError(err)
+	}
+
 	// Backup to vault
 	if err := vault.Backup(fileSet, profileName); err != nil {
 		return emitJSONError(fmt.Errorf("backup failed: %w", err))
 	}
+	runPostHook(hooks.PostBackup, hooks.Payload{Provider: fileSet.Tool, Profile: profileName, Source: "backup"})
 
 	output.Success = true
-	output.Path = vault.ProfilePath(tool, profileName)
+	output.Path = vault.ProfilePath(fileSet.Tool, profileName)
+
+	// Remember which organization this login selected so the profile can
+	// switch back to it later (caam org switch).
+	if _, err := captureProfileOrg(fileSet.Tool, profileName); err != nil && !jsonOutput {
+		fmt.Fprintf(os.Stderr, "Warning: could not save organization snapshot: %v\n", err)
+	}
+
+	// A fresh backup means the user logged in again, so a revoked token
+	// quarantine no longer applies.
+	output.Unquarantined = liftQuarantine(fileSet.Tool, profileName)
 
 	if jsonOutput {
 		enc := json.NewEncoder(cmd.OutOrStdout())
@@ -625,6 +976,9 @@ func runBackup(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("Backed up %s auth to profile '%s'\n", tool, profileName)
 	fmt.Printf("  Vault: %s\n", output.Path)
+	if output.Unquarantined {
+		fmt.Println("  Lifted revoked-token quarantine")
+	}
 	return nil
 }
 
@@ -640,6 +994,7 @@ type statusTool struct {
 	LoggedIn      bool               `json:"logged_in"`
 	ActiveProfile string             `json:"active_profile,omitempty"`
 	Error         string             `json:"error,omitempty"`
+	RiskTier      string             `json:"risk_tier,omitempty"`
 	Health        *statusHealth      `json:"health,omitempty"`
 	Identity      *identity.Identity `json:"identity,omitempty"`
 }
@@ -678,11 +1033,11 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	jsonOutput, _ := cmd.Flags().GetBool("json")
 	formatOpts := health.FormatOptions{NoColor: noColor || !isTerminal()}
 
-	toolsToCheck := []string{"codex", "claude", "gemini"}
+	toolsToCheck := toolNames()
 	if len(args) > 0 {
 		tool := strings.ToLower(args[0])
 		if _, ok := tools[tool]; !ok {
-			return fmt.Errorf("unknown tool: %s", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s", tool)
 		}
 		toolsToCheck = []string{tool}
 	}
@@ -694,7 +1049,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	if !jsonOutput {
 		fmt.Println("Active Profiles")
 		fmt.Println("───────────────────────────────────────────────────")
-		fmt.Printf("%-10s  %-20s  %-24s  %-10s  %s\n", "TOOL", "PROFILE", "EMAIL", "PLAN", "STATUS")
+		fmt.Printf("%-10s  %-20s  %-24s  %-10s  %-10s  %s\n", "TOOL", "PROFILE", "EMAIL", "PLAN", "RISK", "STATUS")
 	}
 
 	for _, tool := range toolsToCheck {
@@ -742,12 +1097,14 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		// Get health and identity info
 		ph, id := getProfileHealthWithIdentity(tool, activeProfile)
 		status := health.CalculateStatus(ph)
+		riskTier := profileRiskTier(tool, activeProfile)
 
 		if jsonOutput {
 			st := statusTool{
 				Tool:          tool,
 				LoggedIn:      true,
 				ActiveProfile: activeProfile,
+				RiskTier:      riskTier.String(),
 				Identity:      id,
 				Health: &statusHealth{
 					Status:     status.String(),
@@ -773,7 +1130,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 				healthStr = healthStr + " " + cooldownStr
 			}
 
-			fmt.Printf("%-10s  %-20s  %-24s  %-10s  %s\n", tool, activeProfile, email, plan, healthStr)
+			fmt.Printf("%-10s  %-20s  %-24s  %-10s  %-10s  %s\n", tool, activeProfile, email, plan, riskTier, healthStr)
 		}
 
 		// Collect warnings
@@ -900,7 +1257,7 @@ func runLs(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		tool := strings.ToLower(args[0])
 		if _, ok := tools[tool]; !ok {
-			return fmt.Errorf("unknown tool: %s", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s", tool)
 		}
 
 		profiles, err := vault.List(tool)
@@ -1097,7 +1454,7 @@ Examples:
 		profileName := args[1]
 
 		if _, ok := tools[tool]; !ok {
-			return fmt.Errorf("unknown tool: %s", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s", tool)
 		}
 
 		force, _ := cmd.Flags().GetBool("force")
@@ -1124,6 +1481,11 @@ Examples:
 			return fmt.Errorf("delete failed: %w", err)
 		}
 
+		// Forget recorded identity so a new profile reusing the name starts clean.
+		if db, err := getDB(); err == nil {
+			_ = db.DeleteProfileIdentity(tool, profileName)
+		}
+
 		fmt.Printf("Deleted %s/%s\n", tool, profileName)
 		return nil
 	},
@@ -1143,20 +1505,22 @@ Useful for understanding what caam is backing up and for manual troubleshooting.
 
 Examples:
   caam paths           # Show all tools
-  caam paths claude    # Show just Claude`,
+  caam paths claude    # Show just Claude
+  caam paths gemini-code-assist`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		toolsToShow := []string{"codex", "claude", "gemini"}
+		toolsToShow := toolNames()
 		if len(args) > 0 {
 			tool := strings.ToLower(args[0])
-			if _, ok := tools[tool]; !ok {
-				return fmt.Errorf("unknown tool: %s", tool)
+			if _, ok := lookupToolFileSet(tool); !ok {
+				return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s", tool)
 			}
 			toolsToShow = []string{tool}
 		}
 
 		for _, tool := range toolsToShow {
-			fileSet := tools[tool]()
+			getFileSet, _ := lookupToolFileSet(tool)
+			fileSet := getFileSet()
 			fmt.Printf("%s:\n", tool)
 			for _, spec := range fileSet.Files {
 				exists := "missing"
@@ -1168,7 +1532,11 @@ Examples:
 					required = " (required)"
 				}
 				fmt.Printf("  [%s] %s%s\n", exists, spec.Path, required)
-				fmt.Printf("         %s\n", spec.Description)
+				if spec.Variant != "" {
+					fmt.Printf("         %s [%s]\n", spec.Description, spec.Variant)
+				} else {
+					fmt.Printf("         %s\n", spec.Description)
+				}
 			}
 			fmt.Println()
 		}
@@ -1194,7 +1562,7 @@ Examples:
 
 		getFileSet, ok := tools[tool]
 		if !ok {
-			return fmt.Errorf("unknown tool: %s", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s", tool)
 		}
 
 		fileSet := getFileSet()
@@ -1273,7 +1641,7 @@ Examples:
 
 		prov, ok := registry.Get(tool)
 		if !ok {
-			return fmt.Errorf("unknown provider: %s", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", tool)
 		}
 
 		authMode, _ := cmd.Flags().GetString("auth-mode")
@@ -1452,7 +1820,7 @@ var profileStatusCmd = &cobra.Command{
 
 		prov, ok := registry.Get(tool)
 		if !ok {
-			return fmt.Errorf("unknown provider: %s", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", tool)
 		}
 
 		prof, err := profileStore.Load(tool, name)
@@ -1724,7 +2092,7 @@ Examples:
 
 		prov, ok := registry.Get(tool)
 		if !ok {
-			return fmt.Errorf("unknown provider: %s", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", tool)
 		}
 
 		prof, err := profileStore.Load(tool, name)
@@ -1783,7 +2151,7 @@ Examples:
 
 		prov, ok := registry.Get(tool)
 		if !ok {
-			return fmt.Errorf("unknown provider: %s", tool)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", tool)
 		}
 
 		prof, err := profileStore.Load(tool, name)
@@ -11,6 +11,7 @@ import (
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
 	"github.com/spf13/cobra"
 )
 
@@ -368,7 +369,7 @@ func TestExecCommandFlags(t *testing.T) {
 
 // TestToolsMap verifies the tools map contains expected providers.
 func TestToolsMap(t *testing.T) {
-	expectedTools := []string{"codex", "claude", "gemini"}
+	expectedTools := []string{"codex", "claude", "gemini", "cursor", "copilot"}
 
 	for _, tool := range expectedTools {
 		if _, ok := tools[tool]; !ok {
@@ -606,3 +607,28 @@ func TestFormatIdentityDisplay_ClaudeEmptyEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadPoolSyncStateSeesPoolChanges(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	if loadPoolSyncState() != nil {
+		t.Fatal("sync state without a pool")
+	}
+
+	pool := syncstate.NewSyncPool()
+	pool.SetBasePath(syncstate.SyncDataDir())
+	pool.Enable()
+	if err := pool.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if loadPoolSyncState() == nil {
+		t.Fatal("pool enabled after the first load was not seen")
+	}
+
+	pool.Disable()
+	if err := pool.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if loadPoolSyncState() != nil {
+		t.Fatal("pool disabled after the last load was not seen")
+	}
+}
@@ -13,12 +13,15 @@ import (
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authpool"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/exec"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 	"github.com/spf13/cobra"
@@ -35,10 +38,14 @@ var runCmd = &cobra.Command{
 profile switching. This is the "zero friction" mode - just use caam run instead
 of calling the CLI directly.
 
-When a rate limit is detected:
-1. The current profile is put into cooldown
-2. The next best profile is automatically selected
-3. The command is re-executed seamlessly
+When a rate limit is detected (429, "usage limit", RESOURCE_EXHAUSTED, ...):
+1. caam first tries to switch accounts inside the running session
+2. If that is not possible, the current profile is put into cooldown
+3. The next best profile is activated, scored the same way as robot next
+4. The command is restarted (codex sessions are resumed)
+
+Use --max-failovers to cap restarts per invocation, or --no-failover to
+disable switching entirely.
 
 Use --precheck for proactive switching:
   When enabled, caam checks real-time usage levels BEFORE running and
@@ -53,9 +60,16 @@ Examples:
   # Proactive switching (checks usage before running)
   caam run claude --precheck -- "explain this code"
 
-  # Interactive mode (no auto-retry on rate limit)
+  # Interactive mode
   caam run claude
 
+  # Report rate limits but never switch accounts
+  caam run claude --no-failover -- "explain this code"
+
+To route every call of a tool through caam, including from scripts and
+agents, install a shim:
+  caam shim install claude
+
 For shell integration, add an alias:
   alias claude='caam run claude --precheck --'
 
@@ -70,7 +84,10 @@ And rate limits will be handled automatically!`,
 
 func init() {
 	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().Int("max-failovers", 3, "maximum profile failovers per run (0 = never restart)")
+	runCmd.Flags().Bool("no-failover", false, "do not switch profiles on rate limit")
 	runCmd.Flags().Int("max-retries", 1, "maximum retry attempts on rate limit (0 = no retries)")
+	_ = runCmd.Flags().MarkDeprecated("max-retries", "use --max-failovers")
 	runCmd.Flags().Duration("cooldown", 60*time.Minute, "cooldown duration after rate limit")
 	runCmd.Flags().Bool("quiet", false, "suppress profile switch notifications")
 	runCmd.Flags().String("algorithm", "smart", "rotation algorithm (smart, round_robin, random)")
@@ -87,9 +104,12 @@ func runWrap(cmd *cobra.Command, args []string) error {
 
 	// Validate tool
 	if _, ok := tools[tool]; !ok {
-		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 	}
 
+	// A shim may have started us; make sure the tool below is the real one.
+	dropShimDirsFromPath()
+
 	// Parse CLI args (everything after the tool name)
 	var cliArgs []string
 	if len(args) > 1 {
@@ -100,6 +120,12 @@ func runWrap(cmd *cobra.Command, args []string) error {
 	quiet, _ := cmd.Flags().GetBool("quiet")
 	algorithmStr, _ := cmd.Flags().GetString("algorithm")
 	cooldownDur, _ := cmd.Flags().GetDuration("cooldown")
+	cooldownDur = jitterCooldown(cooldownDur)
+	noFailover, _ := cmd.Flags().GetBool("no-failover")
+	maxFailovers, _ := cmd.Flags().GetInt("max-failovers")
+	if !cmd.Flags().Changed("max-failovers") && cmd.Flags().Changed("max-retries") {
+		maxFailovers, _ = cmd.Flags().GetInt("max-retries")
+	}
 
 	// Parse algorithm
 	var algorithm rotation.Algorithm
@@ -146,7 +172,11 @@ func runWrap(cmd *cobra.Command, args []string) error {
 	// Precheck: switch profile if near limit before running
 	precheck, _ := cmd.Flags().GetBool("precheck")
 	precheckThreshold, _ := cmd.Flags().GetFloat64("precheck-threshold")
-	if precheck && (tool == "claude" || tool == "codex") {
+	autoRotate := spmCfg.AutomationEnabled(tool, config.AutomationRotate) && !noFailover
+	if precheck && !autoRotate && !quiet {
+		fmt.Fprintf(os.Stderr, "caam: --precheck ignored (automatic rotation disabled for %s)\n", tool)
+	}
+	if precheck && autoRotate && (tool == "claude" || tool == "codex") {
 		if switched := runPrecheck(tool, precheckThreshold, quiet, db, algorithm); switched && !quiet {
 			fmt.Fprintf(os.Stderr, "caam: switched profile before running (usage was near limit)\n")
 		}
@@ -160,8 +190,11 @@ func runWrap(cmd *cobra.Command, args []string) error {
 		_ = pool.Load(authpool.PersistOptions{})
 	}
 
-	// Initialize Rotation Selector
+	// Initialize Rotation Selector. Rotation here happens unattended, so it
+	// stays off high-risk profiles and prefers expendable ones.
 	selector := rotation.NewSelector(algorithm, healthStore, db)
+	selector.SetRiskTiers(loadRiskConfig().RiskTiersForProvider(tool))
+	selector.SetUnattended(true)
 
 	// Initialize Runner
 	if runner == nil {
@@ -184,8 +217,8 @@ func runWrap(cmd *cobra.Command, args []string) error {
 		AuthPool:         pool,
 		Rotation:         selector,
 		CooldownDuration: cooldownDur,
+		DisableRotation:  !autoRotate,
 	}
-	smartRunner := exec.NewSmartRunner(runner, opts)
 
 	// Get provider
 	prov, ok := registry.Get(tool)
@@ -200,7 +233,7 @@ func runWrap(cmd *cobra.Command, args []string) error {
 		// If no active profile, try to select one
 		profiles, err := vault.List(tool)
 		if err != nil || len(profiles) == 0 {
-			return fmt.Errorf("no profiles found for %s; create one with 'caam backup %s <name>'", tool, tool)
+			return caamerr.Errorf(caamerr.NoProfiles, "no profiles found for %s; create one with 'caam backup %s <name>'", tool, tool)
 		}
 		res, err := selector.Select(tool, profiles, "")
 		if err != nil {
@@ -216,41 +249,32 @@ func runWrap(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Load profile object
-	prof, err := profileStore.Load(tool, activeProfileName)
-	if err != nil {
-		// If profile object doesn't exist (only in vault), create a transient one.
-		// We need a proper BasePath for locking to work correctly - otherwise
-		// the lock file ends up in the current directory which causes issues
-		// when multiple runs use the same profile.
-		var basePath string
-		if profileStore != nil {
-			basePath = profileStore.ProfilePath(tool, activeProfileName)
-		} else {
-			// Fallback: use default store path
-			basePath = filepath.Join(profile.DefaultStorePath(), tool, activeProfileName)
-		}
-		prof = &profile.Profile{
-			Name:     activeProfileName,
-			Provider: tool,
-			AuthMode: "oauth", // Assumption
-			BasePath: basePath,
-		}
-	}
-
-	// Set CLI overrides
-	// Cooldown duration is now passed directly to SmartRunner via opts.CooldownDuration
-
 	// Run
 	runOptions := exec.RunOptions{
-		Profile:      prof,
+		Profile:      loadRunProfile(tool, activeProfileName),
 		Provider:     prov,
 		Args:         cliArgs,
 		WorkDir:      cwd,
-		Env:          nil,  // Inherit
 		UseGlobalEnv: true, // Force global environment for vault-based switching
 	}
 
+	// The profile's network fingerprint is the only environment added to
+	// the inherited one.
+	stopNetwork := func() {}
+	defer func() { stopNetwork() }()
+	applyNetwork := func() error {
+		stopNetwork()
+		env, stop, err := profileNetworkEnv(runOptions.Profile, spmCfg)
+		if err != nil {
+			return err
+		}
+		runOptions.Env, stopNetwork = env, stop
+		return nil
+	}
+	if err := applyNetwork(); err != nil {
+		return err
+	}
+
 	// Handle signals - use cmd.Context() for proper context propagation
 	ctx, cancel := context.WithCancel(cmd.Context())
 	defer cancel()
@@ -266,7 +290,67 @@ func runWrap(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	err = smartRunner.Run(ctx, runOptions)
+	// Record the run as a working session. A failover ends the session and
+	// starts another, so time is charged to the profile that served it.
+	var sessionID int64
+	startSession := func(profileName string) {
+		if db != nil {
+			sessionID, _ = db.StartSession(caamdb.Session{
+				Provider:    tool,
+				ProfileName: profileName,
+				WorkDir:     cwd,
+				GitRepo:     gitRepoRoot(cwd),
+				Source:      caamdb.SessionSourceRun,
+			})
+		}
+	}
+	startSession(activeProfileName)
+
+	// Each pass runs the command on one profile. A rate limit the SmartRunner
+	// could not hand off in-session fails over to the next profile and
+	// restarts the command, up to maxFailovers times.
+	for failovers := 0; ; failovers++ {
+		smartRunner := exec.NewSmartRunner(runner, opts)
+		err = smartRunner.Run(ctx, runOptions)
+		if !autoRotate || ctx.Err() != nil || !smartRunner.RateLimitUnresolved() {
+			break
+		}
+
+		current := smartRunner.CurrentProfile()
+		if failovers >= maxFailovers {
+			fmt.Fprintf(os.Stderr, "caam: rate limit on %s/%s; not failing over (--max-failovers %d reached)\n", tool, current, maxFailovers)
+			break
+		}
+		next, reasons, ferr := failoverProfile(tool, current, cooldownDur, db)
+		if ferr != nil {
+			fmt.Fprintf(os.Stderr, "caam: rate limit on %s/%s; cannot fail over: %v\n", tool, current, ferr)
+			break
+		}
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "caam: rate limit on %s/%s; failing over to %s (%d/%d)\n", tool, current, next, failovers+1, maxFailovers)
+			if len(reasons) > 0 {
+				fmt.Fprintf(os.Stderr, "caam:   %s\n", strings.Join(reasons, ", "))
+			}
+		}
+
+		if sessionID != 0 {
+			_ = db.AnnotateSession(sessionID, "rate limited; failed over to "+next)
+			_, _ = db.EndSession(sessionID, time.Now(), nil)
+		}
+		startSession(next)
+
+		// Codex can pick the conversation back up; other tools start over.
+		resumeID := runOptions.Profile.LastSessionID
+		runOptions.Profile = loadRunProfile(tool, next)
+		runOptions.Args = cliArgs
+		if err := applyNetwork(); err != nil {
+			fmt.Fprintf(os.Stderr, "caam: %v; stopping\n", err)
+			break
+		}
+		if tool == "codex" && resumeID != "" {
+			runOptions.Args = []string{"resume", resumeID}
+		}
+	}
 
 	// Stop signal handling and allow the signal goroutine to exit
 	signal.Stop(sigChan)
@@ -274,17 +358,108 @@ func runWrap(cmd *cobra.Command, args []string) error {
 
 	// Handle exit code
 	var exitErr *exec.ExitCodeError
-	if errors.As(err, &exitErr) {
+	isExitErr := errors.As(err, &exitErr)
+	if sessionID != 0 {
+		code := 0
+		switch {
+		case isExitErr:
+			code = exitErr.Code
+		case err != nil:
+			code = 1
+		}
+		_, _ = db.EndSession(sessionID, time.Now(), &code)
+	}
+	if isExitErr {
 		// Clean up before exiting - os.Exit() bypasses defers
 		if db != nil {
 			db.Close()
 		}
+		stopNetwork()
 		os.Exit(exitErr.Code)
 	}
 
 	return err
 }
 
+// loadRunProfile loads a profile for caam run. Profiles that exist only in
+// the vault get a transient profile so locking still works.
+func loadRunProfile(tool, name string) *profile.Profile {
+	if profileStore != nil {
+		if prof, err := profileStore.Load(tool, name); err == nil {
+			return prof
+		}
+	}
+	// We need a proper BasePath for locking to work correctly - otherwise
+	// the lock file ends up in the current directory which causes issues
+	// when multiple runs use the same profile.
+	var basePath string
+	if profileStore != nil {
+		basePath = profileStore.ProfilePath(tool, name)
+	} else {
+		// Fallback: use default store path
+		basePath = filepath.Join(profile.DefaultStorePath(), tool, name)
+	}
+	return &profile.Profile{
+		Name:     name,
+		Provider: tool,
+		AuthMode: "oauth", // Assumption
+		BasePath: basePath,
+	}
+}
+
+// failoverProfile puts the rate-limited profile into cooldown (unless it
+// already has one or was quarantined) and activates the best remaining
+// profile, scored the same way as robot next. High-risk profiles are never
+// picked because nobody is watching. It returns the new profile and the
+// reasons it was chosen. db may be nil.
+func failoverProfile(tool, current string, cooldown time.Duration, db *caamdb.DB) (string, []string, error) {
+	now := time.Now()
+	if db != nil && current != "" {
+		revoked, _ := db.ActiveRevocation(tool, current)
+		active, _ := db.ActiveCooldown(tool, current, now)
+		if revoked == nil && active == nil && cooldown > 0 {
+			if _, err := db.SetCooldown(tool, current, now, cooldown, "auto-detected via caam run"); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to record cooldown for %s/%s: %v\n", tool, current, err)
+			}
+		}
+	}
+
+	profiles, err := vault.List(tool)
+	if err != nil {
+		return "", nil, caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
+	}
+	var others []string
+	for _, name := range profiles {
+		if name != current && !authfile.IsSystemProfile(name) {
+			others = append(others, name)
+		}
+	}
+
+	primePlanTypes(tool, others)
+	for _, sp := range scoreRobotNextProfiles(tool, others, "smart", false, db) {
+		if !risk.Tier(sp.info.RiskTier).AllowsUnattended() {
+			continue
+		}
+		if err := activateProfile(tools[tool](), sp.name, "run", activationOptions{}); err != nil {
+			return "", nil, fmt.Errorf("activate %s: %w", sp.name, err)
+		}
+		events.PublishActivated(tool, sp.name, "run")
+		if db != nil {
+			_ = db.LogEvent(caamdb.Event{
+				Type:        caamdb.EventActivate,
+				Provider:    tool,
+				ProfileName: sp.name,
+				Details: map[string]any{
+					"previous_profile": current,
+					"selection_source": "run_failover",
+				},
+			})
+		}
+		return sp.name, sp.reasons, nil
+	}
+	return "", nil, fmt.Errorf("no other %s profile is available (all in cooldown, revoked, or high-risk)", tool)
+}
+
 // runPrecheck checks current usage levels and switches profile if near limit.
 // Returns true if a switch was performed.
 func runPrecheck(tool string, threshold float64, quiet bool, db *caamdb.DB, algorithm rotation.Algorithm) bool {
@@ -365,6 +540,8 @@ func runPrecheck(tool string, threshold float64, quiet bool, db *caamdb.DB, algo
 	// Use rotation selector with usage data
 	selector := rotation.NewSelector(algorithm, nil, db)
 	selector.SetUsageData(usageData)
+	selector.SetRiskTiers(loadRiskConfig().RiskTiersForProvider(tool))
+	selector.SetUnattended(true)
 
 	result, err := selector.Select(tool, allProfiles, currentProfile)
 	if err != nil || result.Selected == currentProfile {
@@ -372,9 +549,10 @@ func runPrecheck(tool string, threshold float64, quiet bool, db *caamdb.DB, algo
 	}
 
 	// Switch to the better profile
-	if err := vault.Restore(fileSet, result.Selected); err != nil {
+	if err := activateProfile(fileSet, result.Selected, "run", activationOptions{}); err != nil {
 		return false
 	}
+	events.PublishActivated(tool, result.Selected, "run")
 
 	if !quiet {
 		fmt.Fprintf(os.Stderr, "caam: precheck switched %s/%s -> %s/%s\n",
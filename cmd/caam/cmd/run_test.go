@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+func TestFailoverProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "codex_home"))
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	if err := os.MkdirAll(os.Getenv("CODEX_HOME"), 0700); err != nil {
+		t.Fatalf("MkdirAll(CODEX_HOME) error = %v", err)
+	}
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := os.MkdirAll(vault.ProfilePath("codex", name), 0700); err != nil {
+			t.Fatalf("MkdirAll(profile %s) error = %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(vault.ProfilePath("codex", name), "auth.json"), []byte(`{"access_token":"`+name+`"}`), 0600); err != nil {
+			t.Fatalf("WriteFile(profile %s) error = %v", name, err)
+		}
+	}
+	authPath := filepath.Join(os.Getenv("CODEX_HOME"), "auth.json")
+	if err := os.WriteFile(authPath, []byte(`{"access_token":"a"}`), 0600); err != nil {
+		t.Fatalf("WriteFile(active auth) error = %v", err)
+	}
+
+	db, err := getDB()
+	if err != nil {
+		t.Fatalf("getDB() error = %v", err)
+	}
+	if _, err := db.SetCooldown("codex", "b", time.Now(), time.Hour, "test"); err != nil {
+		t.Fatalf("SetCooldown(b) error = %v", err)
+	}
+
+	next, _, err := failoverProfile("codex", "a", 30*time.Minute, db)
+	if err != nil {
+		t.Fatalf("failoverProfile() error = %v", err)
+	}
+	if next != "c" {
+		t.Fatalf("failoverProfile() = %q, want c (b is cooling down)", next)
+	}
+	if got, _ := os.ReadFile(authPath); string(got) != `{"access_token":"c"}` {
+		t.Errorf("active auth = %s, want profile c", got)
+	}
+	cooldown, err := db.ActiveCooldown("codex", "a", time.Now())
+	if err != nil || cooldown == nil {
+		t.Fatalf("ActiveCooldown(a) = %v, %v; want a cooldown", cooldown, err)
+	}
+	if cooldown.Notes != "auto-detected via caam run" {
+		t.Errorf("cooldown notes = %q", cooldown.Notes)
+	}
+
+	// With a and b cooling down, c has nowhere to go.
+	if _, _, err := failoverProfile("codex", "c", 30*time.Minute, db); err == nil || !strings.Contains(err.Error(), "no other codex profile") {
+		t.Errorf("failoverProfile(c) error = %v, want no profile available", err)
+	}
+}
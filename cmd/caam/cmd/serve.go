@@ -1,16 +1,22 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"os/signal"
+	"sort"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/api"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var serveCmd = &cobra.Command{
@@ -31,7 +37,17 @@ ENDPOINTS:
   GET  /api/v1/coordinators     Coordinator status
   POST /api/v1/actions/activate Activate a profile
   POST /api/v1/actions/backup   Backup current auth to a profile
-  GET  /api/v1/events           SSE stream for live updates
+  GET  /api/v1/events           SSE stream for live updates and bus events
+  GET  /api/v1/robot/<command>  Run a robot command (status, next, limits,
+                                precheck, health, history); ?arg= for
+                                arguments, other parameters are flags
+  POST /api/v1/robot/<command>  Same, with {"args": [...], "flags": {...}};
+                                required for act
+
+ROBOT COMMANDS:
+  /api/v1/robot/* returns the same JSON as 'caam robot <command>', from a
+  warm process instead of a fresh fork per call. Failed commands return
+  HTTP 422 with the robot error envelope. Commands run one at a time.
 
 AUTHENTICATION:
   All endpoints except /health require Bearer token authentication.
@@ -40,6 +56,16 @@ AUTHENTICATION:
 
   Include the header: Authorization: Bearer <token>
 
+  With --socket the API is also served on a unix socket (mode 0600), which
+  needs no token.
+
+GRPC:
+  --grpc-port also serves the caam.v1.Caam gRPC service on localhost:
+  ListProfiles, Activate, SetCooldown, ClearCooldown, ListCooldowns, and the
+  streaming WatchStatus and StreamEvents calls. The service definition is
+  internal/api/caampb/caam.proto. Send the token as "authorization: Bearer
+  <token>" metadata.
+
 SECURITY:
   - Server binds to 127.0.0.1 only (localhost)
   - CORS allows only localhost origins
@@ -51,10 +77,14 @@ Examples:
   caam serve --port 8080            # Use custom port
   caam serve --verbose              # Debug logging
   caam serve --show-token           # Print the API token
+  caam serve --socket               # Also listen on ~/.config/caam/api.sock
+  caam serve --grpc-port 7892       # Also serve gRPC
 
 Querying the API:
   TOKEN=$(cat ~/.config/caam/.api_token)
-  curl -H "Authorization: Bearer $TOKEN" http://localhost:7891/api/v1/status`,
+  curl -H "Authorization: Bearer $TOKEN" http://localhost:7891/api/v1/status
+  curl -H "Authorization: Bearer $TOKEN" "http://localhost:7891/api/v1/robot/next?arg=claude&strategy=lru"
+  curl --unix-socket ~/.config/caam/api.sock -d '{"args":["activate","claude","work"]}' http://caam/api/v1/robot/act`,
 	RunE: runServe,
 }
 
@@ -63,6 +93,8 @@ var (
 	serveVerbose   bool
 	serveShowToken bool
 	serveJSONLogs  bool
+	serveSocket    string
+	serveGRPCPort  int
 )
 
 func init() {
@@ -72,6 +104,9 @@ func init() {
 	serveCmd.Flags().BoolVar(&serveVerbose, "verbose", false, "Enable debug logging")
 	serveCmd.Flags().BoolVar(&serveShowToken, "show-token", false, "Print API token and exit")
 	serveCmd.Flags().BoolVar(&serveJSONLogs, "json", false, "Output logs in JSON format")
+	serveCmd.Flags().StringVar(&serveSocket, "socket", "", "Also serve on this unix socket (no token; default path if given without a value)")
+	serveCmd.Flags().Lookup("socket").NoOptDefVal = api.DefaultSocketPath()
+	serveCmd.Flags().IntVar(&serveGRPCPort, "grpc-port", 0, "Also serve the gRPC API on this localhost port (0 = off)")
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
@@ -88,7 +123,10 @@ func runServe(cmd *cobra.Command, args []string) error {
 	} else {
 		logHandler = slog.NewTextHandler(os.Stderr, logOpts)
 	}
-	logger := slog.New(logHandler)
+	logger := teeCommandLog(logHandler)
+	if runID := commandRunID(); runID != "" {
+		logger = logger.With("run_id", runID)
+	}
 
 	// Create handlers with dependencies from root command
 	db, err := getDB()
@@ -102,6 +140,9 @@ func runServe(cmd *cobra.Command, args []string) error {
 	serverCfg := api.DefaultConfig()
 	serverCfg.Port = servePort
 	serverCfg.Logger = logger
+	serverCfg.Robot = runRobotRequest
+	serverCfg.SocketPath = serveSocket
+	serverCfg.EventBus = events.Default()
 
 	// Create server
 	server, err := api.NewServer(serverCfg, handlers)
@@ -115,6 +156,14 @@ func runServe(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Robot requests share status snapshots for a moment, so a dashboard
+	// polling several commands doesn't reload every profile for each one.
+	robotStatusCache.TTL = serveStatusCacheTTL
+	defer func() {
+		robotStatusCache.TTL = 0
+		robotStatusCache.Invalidate()
+	}()
+
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(cmd.Context())
 	defer cancel()
@@ -122,16 +171,52 @@ func runServe(cmd *cobra.Command, args []string) error {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
+	// Forward the event bus, including events from other caam processes,
+	// to SSE clients.
+	if bus := events.Default(); bus != nil {
+		go func() {
+			if err := bus.Follow(ctx, time.Second); err != nil {
+				logger.Warn("event stream unavailable", "error", err)
+			}
+		}()
+		busCh, unsubscribe := bus.Subscribe(events.Filter{}, 256)
+		defer unsubscribe()
+		go func() {
+			for ev := range busCh {
+				// Activations and cooldowns, here or elsewhere, change status.
+				robotStatusCache.Invalidate()
+				server.Emit(api.Event{Type: string(ev.Type), Timestamp: ev.Time, Data: ev})
+			}
+		}()
+	}
+
 	// Start server in background
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 2)
 	go func() {
 		errCh <- server.Start()
 	}()
 
+	if serveGRPCPort > 0 {
+		addr := fmt.Sprintf("127.0.0.1:%d", serveGRPCPort)
+		grpcListener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("listen %s: %w", addr, err)
+		}
+		go func() {
+			errCh <- server.ServeGRPC(grpcListener)
+		}()
+	}
+
 	// Print startup info
 	fmt.Printf("caam API server started\n")
 	fmt.Printf("  Address: http://127.0.0.1:%d\n", server.Port())
 	fmt.Printf("  Token:   %s\n", server.Token()[:8]+"...")
+	if server.SocketPath() != "" {
+		fmt.Printf("  Socket:  %s\n", server.SocketPath())
+	}
+	if serveGRPCPort > 0 {
+		fmt.Printf("  gRPC:    127.0.0.1:%d (service caam.v1.Caam)\n", serveGRPCPort)
+	}
 	fmt.Println()
 	fmt.Println("Endpoints:")
 	fmt.Println("  GET  /health              - Health check")
@@ -140,6 +225,7 @@ func runServe(cmd *cobra.Command, args []string) error {
 	fmt.Println("  GET  /api/v1/usage        - Usage statistics")
 	fmt.Println("  GET  /api/v1/events       - SSE live updates")
 	fmt.Println("  POST /api/v1/actions/*    - Actions (activate, backup)")
+	fmt.Println("  *    /api/v1/robot/*      - Robot commands (status, next, act, ...)")
 	fmt.Println()
 	fmt.Println("Press Ctrl+C to stop.")
 
@@ -165,3 +251,91 @@ func runServe(cmd *cobra.Command, args []string) error {
 	fmt.Println("Server stopped.")
 	return nil
 }
+
+// serveStatusCacheTTL is how long caam serve reuses a status snapshot
+// between robot requests.
+const serveStatusCacheTTL = 2 * time.Second
+
+// robotStatusReaders are the robot commands that only read status. Any other
+// command may change it, so the cached snapshot is dropped after it runs.
+var robotStatusReaders = map[string]bool{
+	"status": true, "next": true, "health": true, "watch": true, "limits": true,
+	"precheck": true, "validate": true, "doctor": true, "paths": true,
+	"history": true, "explain": true,
+}
+
+// robotServeMu serializes robot commands run for the API: they share cobra
+// flag state and the process-wide vault, DB and health handles.
+var robotServeMu sync.Mutex
+
+// runRobotRequest runs a robot command in-process for `caam serve` and
+// returns the JSON it printed. Flags are reset to their defaults first so
+// one request never sees another's flags.
+func runRobotRequest(ctx context.Context, req api.RobotRequest) ([]byte, error) {
+	robotServeMu.Lock()
+	defer robotServeMu.Unlock()
+
+	var sub *cobra.Command
+	for _, c := range robotCmd.Commands() {
+		if c.Name() == req.Command {
+			sub = c
+			break
+		}
+	}
+	if sub == nil || sub.RunE == nil {
+		return nil, fmt.Errorf("%w: unknown robot command %q", api.ErrRobotRequest, req.Command)
+	}
+
+	flags := sub.Flags()
+	flags.VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	names := make([]string, 0, len(req.Flags))
+	for name := range req.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if flags.Lookup(name) == nil {
+			return nil, fmt.Errorf("%w: unknown flag %q for robot %s", api.ErrRobotRequest, name, req.Command)
+		}
+		if err := flags.Set(name, req.Flags[name]); err != nil {
+			return nil, fmt.Errorf("%w: flag %q: %v", api.ErrRobotRequest, name, err)
+		}
+	}
+	if err := sub.ValidateArgs(req.Args); err != nil {
+		return nil, fmt.Errorf("%w: %v", api.ErrRobotRequest, err)
+	}
+
+	// Requests through the API come from tooling, not a person at the
+	// terminal, so they are subject to the approval workflow.
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Value(approvalCallerKey{}).(approvalCaller); !ok {
+		ctx = withApprovalCaller(ctx, approvalCaller{Agent: "api"})
+	}
+
+	var out bytes.Buffer
+	prevCtx := sub.Context()
+	sub.SetOut(&out)
+	// The server's stdin is not the caller's; "--plan -" sees an empty plan.
+	sub.SetIn(bytes.NewReader(nil))
+	sub.SetContext(ctx)
+	defer func() {
+		sub.SetOut(nil)
+		sub.SetIn(nil)
+		sub.SetContext(prevCtx)
+	}()
+
+	err := sub.RunE(sub, req.Args)
+	if !robotStatusReaders[req.Command] {
+		robotStatusCache.Invalidate()
+	}
+	return out.Bytes(), err
+}
@@ -2,9 +2,16 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/api"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 )
 
 func TestServeCommand(t *testing.T) {
@@ -118,3 +125,63 @@ func TestServeShowTokenCreatesToken(t *testing.T) {
 	// Reset show token flag
 	serveShowToken = false
 }
+
+func TestRunRobotRequest(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "home", ".codex"))
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	for _, p := range []string{"claude/work", "codex/main"} {
+		provider, profile, _ := strings.Cut(p, "/")
+		if err := os.MkdirAll(vault.ProfilePath(provider, profile), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := runRobotRequest(context.Background(), api.RobotRequest{
+		Command: "next",
+		Flags:   map[string]string{"all-providers": "true"},
+	})
+	if err != nil {
+		t.Fatalf("runRobotRequest(all-providers) error = %v\n%s", err, out)
+	}
+	var all struct {
+		Success bool             `json:"success"`
+		Data    RobotNextAllData `json:"data"`
+	}
+	if err := json.Unmarshal(out, &all); err != nil || !all.Success || len(all.Data.Ranked) != 2 {
+		t.Fatalf("all-providers output = %s (err %v), want 2 ranked profiles", out, err)
+	}
+
+	// The previous request's flag must not leak into this one.
+	out, err = runRobotRequest(context.Background(), api.RobotRequest{
+		Command: "next",
+		Args:    []string{"claude"},
+	})
+	if err != nil {
+		t.Fatalf("runRobotRequest(claude) error = %v\n%s", err, out)
+	}
+	var next struct {
+		Success bool          `json:"success"`
+		Data    RobotNextData `json:"data"`
+	}
+	if err := json.Unmarshal(out, &next); err != nil || !next.Success || next.Data.Profile != "work" {
+		t.Fatalf("next claude output = %s (err %v), want claude/work", out, err)
+	}
+
+	for name, req := range map[string]api.RobotRequest{
+		"unknown command": {Command: "watch-everything"},
+		"unknown flag":    {Command: "next", Flags: map[string]string{"bogus": "1"}},
+		"bad flag value":  {Command: "history", Flags: map[string]string{"days": "many"}},
+		"too many args":   {Command: "status", Args: []string{"claude", "codex"}},
+	} {
+		if _, err := runRobotRequest(context.Background(), req); !errors.Is(err, api.ErrRobotRequest) {
+			t.Errorf("%s: error = %v, want ErrRobotRequest", name, err)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Record which profile served which working session",
+	Long: `Sessions record which profile served a stretch of work: provider,
+profile, working directory, git repository, duration, and exit status.
+
+'caam run' records a session for every run automatically. Use start and
+stop to record work done by calling a tool directly, and annotate to note
+what a session was for. 'caam robot history --sessions' and the
+hours_this_week field of 'caam robot status' report the totals, so agents
+can rotate toward the least-used account. (To see which profiles are
+locked by running processes right now, use 'caam sessions'.)
+
+Examples:
+  caam session start claude            # active claude profile, this directory
+  caam session annotate "refactor auth"
+  caam session stop --exit-code 0
+  caam session list --days 7`,
+}
+
+var sessionStartCmd = &cobra.Command{
+	Use:   "start <provider/profile|provider>",
+	Short: "Start a session in the current directory",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSessionStart,
+}
+
+var sessionStopCmd = &cobra.Command{
+	Use:   "stop [id]",
+	Short: "Stop a session (default: the newest open one here)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runSessionStop,
+}
+
+var sessionAnnotateCmd = &cobra.Command{
+	Use:   "annotate <note>",
+	Short: "Add a note to a session (default: the newest open one here)",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runSessionAnnotate,
+}
+
+var sessionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recent sessions and hours per profile this week",
+	Args:  cobra.NoArgs,
+	RunE:  runSessionList,
+}
+
+func init() {
+	rootCmd.AddCommand(sessionCmd)
+	sessionCmd.AddCommand(sessionStartCmd)
+	sessionCmd.AddCommand(sessionStopCmd)
+	sessionCmd.AddCommand(sessionAnnotateCmd)
+	sessionCmd.AddCommand(sessionListCmd)
+
+	sessionStartCmd.Flags().String("note", "", "note to record with the session")
+
+	sessionStopCmd.Flags().String("provider", "", "only consider sessions of this provider")
+	sessionStopCmd.Flags().Int("exit-code", -1, "exit status to record (default: unknown)")
+
+	sessionAnnotateCmd.Flags().Int64("id", 0, "session to annotate")
+
+	sessionListCmd.Flags().String("provider", "", "filter to a provider")
+	sessionListCmd.Flags().Int("days", 7, "days of sessions to list")
+	sessionListCmd.Flags().Int("limit", 50, "maximum sessions to list")
+	sessionListCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+func runSessionStart(cmd *cobra.Command, args []string) error {
+	provider, profile, err := resolveProviderProfile(args[0])
+	if err != nil {
+		return err
+	}
+	note, _ := cmd.Flags().GetString("note")
+
+	db, err := caamdb.Open()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	defer db.Close()
+
+	wd, _ := os.Getwd()
+	id, err := db.StartSession(caamdb.Session{
+		Provider:    provider,
+		ProfileName: profile,
+		WorkDir:     wd,
+		GitRepo:     gitRepoRoot(wd),
+		Source:      caamdb.SessionSourceManual,
+		Notes:       note,
+	})
+	if err != nil {
+		return caamerr.Errorf(caamerr.SaveError, "start session: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Started session %d: %s/%s in %s\n", id, provider, profile, wd)
+	return nil
+}
+
+func runSessionStop(cmd *cobra.Command, args []string) error {
+	providerFilter, _ := cmd.Flags().GetString("provider")
+	exitCode, _ := cmd.Flags().GetInt("exit-code")
+
+	db, err := caamdb.Open()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	defer db.Close()
+
+	id, err := sessionTarget(db, args, providerFilter)
+	if err != nil {
+		return err
+	}
+	var code *int
+	if exitCode >= 0 {
+		code = &exitCode
+	}
+	stopped, err := db.EndSession(id, time.Now(), code)
+	if errors.Is(err, caamdb.ErrSessionNotFound) {
+		return caamerr.Wrap(caamerr.NotFound, err)
+	}
+	if err != nil {
+		return caamerr.Errorf(caamerr.SaveError, "stop session: %w", err)
+	}
+	if !stopped {
+		return caamerr.Errorf(caamerr.Conflict, "session %d is already stopped", id)
+	}
+
+	s, err := db.GetSession(id)
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "read session: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Stopped session %d: %s/%s after %s\n", id, s.Provider, s.ProfileName, formatDurationShort(s.Duration))
+	return nil
+}
+
+func runSessionAnnotate(cmd *cobra.Command, args []string) error {
+	id, _ := cmd.Flags().GetInt64("id")
+	note := strings.TrimSpace(strings.Join(args, " "))
+	if note == "" {
+		return caamerr.New(caamerr.InvalidArgs, "note is required")
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	defer db.Close()
+
+	if id == 0 {
+		if id, err = sessionTarget(db, nil, ""); err != nil {
+			return err
+		}
+	}
+	if err := db.AnnotateSession(id, note); err != nil {
+		if errors.Is(err, caamdb.ErrSessionNotFound) {
+			return caamerr.Wrap(caamerr.NotFound, err)
+		}
+		return caamerr.Errorf(caamerr.SaveError, "annotate session: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Annotated session %d\n", id)
+	return nil
+}
+
+// sessionTarget returns the session id given in args, or else the newest
+// open session started in the current directory.
+func sessionTarget(db *caamdb.DB, args []string, provider string) (int64, error) {
+	if len(args) > 0 {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || id <= 0 {
+			return 0, caamerr.Errorf(caamerr.InvalidArgs, "invalid session id: %s", args[0])
+		}
+		return id, nil
+	}
+
+	open, err := db.OpenSessions(provider)
+	if err != nil {
+		return 0, caamerr.Errorf(caamerr.DBError, "list open sessions: %w", err)
+	}
+	wd, _ := os.Getwd()
+	for _, s := range open {
+		if s.WorkDir == wd {
+			return s.ID, nil
+		}
+	}
+	return 0, caamerr.Errorf(caamerr.NotFound, "no open session in %s; give a session id (see 'caam session list')", wd)
+}
+
+// sessionJSON is the JSON form of a session in CLI and robot output.
+type sessionJSON struct {
+	ID              int64  `json:"id"`
+	Provider        string `json:"provider"`
+	Profile         string `json:"profile"`
+	WorkDir         string `json:"work_dir,omitempty"`
+	GitRepo         string `json:"git_repo,omitempty"`
+	Source          string `json:"source,omitempty"`
+	StartedAt       string `json:"started_at"`
+	EndedAt         string `json:"ended_at,omitempty"`
+	Open            bool   `json:"open"`
+	DurationSeconds int64  `json:"duration_seconds"`
+	ExitCode        *int   `json:"exit_code,omitempty"`
+	Notes           string `json:"notes,omitempty"`
+}
+
+func toSessionJSON(s caamdb.Session, now time.Time) sessionJSON {
+	out := sessionJSON{
+		ID:              s.ID,
+		Provider:        s.Provider,
+		Profile:         s.ProfileName,
+		WorkDir:         s.WorkDir,
+		GitRepo:         s.GitRepo,
+		Source:          s.Source,
+		StartedAt:       s.StartedAt.Format(time.RFC3339),
+		Open:            s.Open(),
+		DurationSeconds: int64(s.Elapsed(now).Seconds()),
+		ExitCode:        s.ExitCode,
+		Notes:           s.Notes,
+	}
+	if !s.Open() {
+		out.EndedAt = s.EndedAt.Format(time.RFC3339)
+	}
+	return out
+}
+
+// profileHoursJSON is one profile's session time this week.
+type profileHoursJSON struct {
+	Provider      string  `json:"provider"`
+	Profile       string  `json:"profile"`
+	Sessions      int     `json:"sessions"`
+	HoursThisWeek float64 `json:"hours_this_week"`
+}
+
+func sessionHoursThisWeek(db *caamdb.DB, provider string, now time.Time) ([]profileHoursJSON, error) {
+	usage, err := db.SessionUsageSince(provider, weekStart(now), now)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]profileHoursJSON, 0, len(usage))
+	for _, u := range usage {
+		out = append(out, profileHoursJSON{
+			Provider:      u.Provider,
+			Profile:       u.ProfileName,
+			Sessions:      u.Sessions,
+			HoursThisWeek: roundHours(u.Duration),
+		})
+	}
+	return out, nil
+}
+
+// profileHoursThisWeek returns the hours provider/profile served sessions
+// since the start of the week. Errors count as zero.
+func profileHoursThisWeek(db *caamdb.DB, provider, profile string, now time.Time) float64 {
+	usage, err := db.SessionUsageSince(provider, weekStart(now), now)
+	if err != nil {
+		return 0
+	}
+	for _, u := range usage {
+		if u.ProfileName == profile {
+			return roundHours(u.Duration)
+		}
+	}
+	return 0
+}
+
+// weekStart returns midnight local time on the Monday of now's week.
+func weekStart(now time.Time) time.Time {
+	days := (int(now.Weekday()) + 6) % 7
+	y, m, d := now.AddDate(0, 0, -days).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+}
+
+func roundHours(d time.Duration) float64 {
+	return float64(int64(d.Hours()*100+0.5)) / 100
+}
+
+func runSessionList(cmd *cobra.Command, args []string) error {
+	provider, _ := cmd.Flags().GetString("provider")
+	days, _ := cmd.Flags().GetInt("days")
+	limit, _ := cmd.Flags().GetInt("limit")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	db, err := caamdb.Open()
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "open database: %w", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	sessions, err := db.ListSessions(provider, now.AddDate(0, 0, -days), limit)
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "list sessions: %w", err)
+	}
+	hours, err := sessionHoursThisWeek(db, provider, now)
+	if err != nil {
+		return caamerr.Errorf(caamerr.DBError, "total session hours: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		list := make([]sessionJSON, 0, len(sessions))
+		for _, s := range sessions {
+			list = append(list, toSessionJSON(s, now))
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Sessions  []sessionJSON      `json:"sessions"`
+			WeekHours []profileHoursJSON `json:"week_hours"`
+		}{list, hours})
+	}
+
+	if len(sessions) == 0 {
+		fmt.Fprintln(out, "No sessions recorded.")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPROFILE\tSTARTED\tDURATION\tEXIT\tDIRECTORY")
+	for _, s := range sessions {
+		duration := formatDurationShort(s.Elapsed(now))
+		if s.Open() {
+			duration += " (open)"
+		}
+		exit := "-"
+		if s.ExitCode != nil {
+			exit = strconv.Itoa(*s.ExitCode)
+		}
+		fmt.Fprintf(w, "%d\t%s/%s\t%s\t%s\t%s\t%s\n", s.ID, s.Provider, s.ProfileName,
+			s.StartedAt.Local().Format("2006-01-02 15:04"), duration, exit, s.WorkDir)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(hours) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "This week:")
+		for _, h := range hours {
+			fmt.Fprintf(out, "  %s/%s: %.1fh over %d sessions\n", h.Provider, h.Profile, h.HoursThisWeek, h.Sessions)
+		}
+	}
+	return nil
+}
+
+// gitRepoRoot returns the top of the git work tree containing dir, or "".
+func gitRepoRoot(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
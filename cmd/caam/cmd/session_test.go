@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func TestSessionStartAnnotateStop(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	work := filepath.Join(repo, "pkg")
+	if err := os.Mkdir(work, 0755); err != nil {
+		t.Fatal(err)
+	}
+	oldWd, _ := os.Getwd()
+	if err := os.Chdir(work); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldWd) })
+	wd, _ := os.Getwd()
+
+	var buf bytes.Buffer
+	sessionStartCmd.SetOut(&buf)
+	sessionAnnotateCmd.SetOut(&buf)
+	sessionStopCmd.SetOut(&buf)
+	defer sessionStartCmd.SetOut(nil)
+	defer sessionAnnotateCmd.SetOut(nil)
+	defer sessionStopCmd.SetOut(nil)
+
+	if err := runSessionStart(sessionStartCmd, []string{"claude/work"}); err != nil {
+		t.Fatalf("runSessionStart() error = %v", err)
+	}
+	if err := runSessionAnnotate(sessionAnnotateCmd, []string{"fix", "login"}); err != nil {
+		t.Fatalf("runSessionAnnotate() error = %v", err)
+	}
+	if err := sessionStopCmd.Flags().Set("exit-code", "0"); err != nil {
+		t.Fatal(err)
+	}
+	defer sessionStopCmd.Flags().Set("exit-code", "-1")
+	if err := runSessionStop(sessionStopCmd, nil); err != nil {
+		t.Fatalf("runSessionStop() error = %v", err)
+	}
+	if err := runSessionStop(sessionStopCmd, nil); err == nil {
+		t.Error("stopping with no open session succeeded")
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sessions, err := db.ListSessions("claude", time.Time{}, 10)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("ListSessions() = %+v, %v", sessions, err)
+	}
+	s := sessions[0]
+	if s.ProfileName != "work" || s.WorkDir != wd || s.GitRepo != filepath.Dir(wd) {
+		t.Errorf("session = %+v, want work in %s (repo %s)", s, wd, filepath.Dir(wd))
+	}
+	if s.Open() || s.ExitCode == nil || *s.ExitCode != 0 || s.Notes != "fix login" {
+		t.Errorf("session = %+v, want stopped with exit 0 and note", s)
+	}
+}
+
+func TestRobotHistorySessions(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	id, err := db.StartSession(caamdb.Session{Provider: "codex", ProfileName: "alpha", StartedAt: now.Add(-30 * time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.EndSession(id, now, nil); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	var buf bytes.Buffer
+	robotHistoryCmd.SetOut(&buf)
+	defer robotHistoryCmd.SetOut(nil)
+	for flag, value := range map[string]string{"sessions": "true", "days": "7", "limit": "50", "provider": ""} {
+		if err := robotHistoryCmd.Flags().Set(flag, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer robotHistoryCmd.Flags().Set("sessions", "false")
+
+	if err := runRobotHistory(robotHistoryCmd, nil); err != nil {
+		t.Fatalf("runRobotHistory() error = %v", err)
+	}
+	var out struct {
+		Data RobotHistoryData `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	if out.Data.Count != 1 || len(out.Data.Sessions) != 1 || out.Data.Sessions[0].Profile != "alpha" {
+		t.Fatalf("sessions = %+v", out.Data.Sessions)
+	}
+	if out.Data.Sessions[0].DurationSeconds != 1800 {
+		t.Errorf("duration = %d, want 1800", out.Data.Sessions[0].DurationSeconds)
+	}
+	if !strings.Contains(buf.String(), `"week_hours"`) && weekStart(now).Before(now.Add(-30*time.Minute)) {
+		t.Errorf("output lacks week_hours:\n%s", buf.String())
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	loc := time.UTC
+	for _, tc := range []struct{ now, want time.Time }{
+		{time.Date(2026, 10, 15, 13, 0, 0, 0, loc), time.Date(2026, 10, 12, 0, 0, 0, 0, loc)}, // Thursday
+		{time.Date(2026, 10, 12, 0, 30, 0, 0, loc), time.Date(2026, 10, 12, 0, 0, 0, 0, loc)}, // Monday
+		{time.Date(2026, 10, 18, 23, 0, 0, 0, loc), time.Date(2026, 10, 12, 0, 0, 0, 0, loc)}, // Sunday
+	} {
+		if got := weekStart(tc.now); !got.Equal(tc.want) {
+			t.Errorf("weekStart(%s) = %s, want %s", tc.now, got, tc.want)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
 )
 
@@ -86,7 +87,7 @@ func collectSessions(providerFilter string) (*SessionsReport, error) {
 
 	allProfiles, err := profileStore.ListAll()
 	if err != nil {
-		return nil, fmt.Errorf("list profiles: %w", err)
+		return nil, caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
 	}
 
 	for provider, profiles := range allProfiles {
@@ -46,8 +46,13 @@ Examples:
   caam setup distributed                     # Auto-detect everything
   caam setup distributed --dry-run           # Preview what would be done
   caam setup distributed --remotes css,csd   # Only setup specific domains
-  caam setup distributed --no-tailscale      # Use public IPs only`,
-	RunE: runSetupDistributed,
+  caam setup distributed --no-tailscale      # Use public IPs only
+  caam setup distributed --yes --progress json  # NDJSON progress on stderr
+  caam setup distributed --yes --async       # Run as a daemon job (see 'caam jobs')`,
+	RunE: jobRunE("setup", runSetupDistributed, func(cmd *cobra.Command, args []string) bool {
+		printScript, _ := cmd.Flags().GetBool("print-script")
+		return !printScript
+	}),
 }
 
 func init() {
@@ -61,6 +66,8 @@ func init() {
 	setupDistributedCmd.Flags().Int("remote-port", 7890, "port for remote coordinators")
 	setupDistributedCmd.Flags().StringSlice("remotes", nil, "limit setup to these domain names")
 	setupDistributedCmd.Flags().Bool("no-tailscale", false, "disable Tailscale (use public IPs)")
+	addProgressFlag(setupDistributedCmd)
+	addAsyncFlag(setupDistributedCmd)
 }
 
 func runSetupDistributed(cmd *cobra.Command, args []string) error {
@@ -76,6 +83,10 @@ func runSetupDistributed(cmd *cobra.Command, args []string) error {
 	localPort, _ := cmd.Flags().GetInt("local-port")
 	remotePort, _ := cmd.Flags().GetInt("remote-port")
 	remotes, _ := cmd.Flags().GetStringSlice("remotes")
+	prog, err := progressReporter(cmd, "setup")
+	if err != nil {
+		return err
+	}
 
 	if noTailscale {
 		useTailscale = false
@@ -140,7 +151,14 @@ func runSetupDistributed(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	// Setup phase
+	prog.Phase("deploy", len(remoteMachines))
 	result, err := orch.Setup(ctx, func(p *setup.SetupProgress) {
+		if p.Status == "running" {
+			prog.Update(p.Machine, p.Status, p.Step)
+		} else {
+			prog.Advance(p.Machine, p.Status, p.Message)
+		}
+
 		var status string
 		switch p.Status {
 		case "running":
@@ -156,8 +174,10 @@ func runSetupDistributed(cmd *cobra.Command, args []string) error {
 	})
 
 	if err != nil && result == nil {
+		prog.Done(err)
 		return err
 	}
+	prog.Done(nil)
 
 	// Print summary
 	fmt.Println()
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
+)
+
+// rotationShaping applies stealth.shaping to one provider's profiles. A nil
+// *rotationShaping means shaping is off and allows everything.
+type rotationShaping struct {
+	cfg      config.ShapingConfig
+	provider string
+	active   string
+	db       *caamdb.DB
+	now      time.Time
+}
+
+// newRotationShaping returns the shaping rules for provider, or nil if
+// shaping is off. Without a db, dwell and daily caps cannot be checked and
+// only active hours apply.
+func newRotationShaping(cfg config.ShapingConfig, provider, active string, db *caamdb.DB, now time.Time) *rotationShaping {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &rotationShaping{cfg: cfg, provider: provider, active: active, db: db, now: now}
+}
+
+// loadRotationShaping loads the shaping config and the provider's active
+// profile. It returns nil if shaping is off.
+func loadRotationShaping(provider string, db *caamdb.DB) *rotationShaping {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return nil
+	}
+	return rotationShapingFor(spmCfg, provider, db)
+}
+
+// rotationShapingFor is loadRotationShaping with the config already loaded.
+func rotationShapingFor(spmCfg *config.SPMConfig, provider string, db *caamdb.DB) *rotationShaping {
+	if spmCfg == nil || !spmCfg.Stealth.Shaping.Enabled {
+		return nil
+	}
+	var active string
+	if getFileSet, ok := tools[provider]; ok && vault != nil {
+		active, _ = vault.ActiveProfile(getFileSet())
+	}
+	return newRotationShaping(spmCfg.Stealth.Shaping, provider, active, db, time.Now())
+}
+
+func (s *rotationShaping) state(profile string) rotation.ShapingState {
+	state := rotation.ShapingState{Active: profile != "" && profile == s.active}
+	if s.db == nil {
+		return state
+	}
+	if state.Active {
+		state.ActiveSince, _ = s.db.LastActivation(s.provider, profile)
+	}
+	state.ActivationsToday, _ = s.db.CountActivations(s.provider, profile, rotation.StartOfDay(s.now))
+	return state
+}
+
+// block says why profile may not be activated now, or returns "" if it may.
+func (s *rotationShaping) block(profile string) string {
+	if s == nil {
+		return ""
+	}
+	return rotation.ShapingBlock(s.cfg.For(s.provider, profile), s.state(profile), s.now)
+}
+
+// dwell returns how much longer the active profile must stay active.
+func (s *rotationShaping) dwell() time.Duration {
+	if s == nil || s.active == "" {
+		return 0
+	}
+	return rotation.DwellRemaining(s.cfg.For(s.provider, s.active), s.state(s.active), s.now)
+}
+
+// activationBlock says why shaping forbids switching to profile now: it is
+// blocked, or the active profile's minimum dwell has not passed. It returns
+// "" if the switch is allowed.
+func (s *rotationShaping) activationBlock(profile string) string {
+	if s == nil || profile == s.active {
+		return ""
+	}
+	if reason := s.block(profile); reason != "" {
+		return reason
+	}
+	if remaining := s.dwell(); remaining > 0 {
+		return fmt.Sprintf("%s is still active: %s", s.active, rotation.DwellReason(remaining))
+	}
+	return ""
+}
+
+// allowed returns the profiles shaping allows activating now. The active
+// profile is always kept, since staying on it activates nothing.
+func (s *rotationShaping) allowed(profiles []string) []string {
+	if s == nil {
+		return profiles
+	}
+	var out []string
+	for _, name := range profiles {
+		if name == s.active || s.block(name) == "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// holdActive moves the active profile to the front of scored while its
+// minimum dwell has not passed, so robot next keeps recommending it.
+func (s *rotationShaping) holdActive(scored []robotScoredProfile) {
+	remaining := s.dwell()
+	if remaining <= 0 {
+		return
+	}
+	for i := range scored {
+		if scored[i].name != s.active {
+			continue
+		}
+		sp := scored[i]
+		sp.reasons = append([]string{"shaping: " + rotation.DwellReason(remaining)}, sp.reasons...)
+		copy(scored[1:i+1], scored[:i])
+		scored[0] = sp
+		return
+	}
+}
+
+// jitterCooldown lengthens a cooldown by up to stealth.shaping.cooldown_jitter
+// when shaping is on, so cooldowns don't all end on the hour.
+func jitterCooldown(d time.Duration) time.Duration {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil || !spmCfg.Stealth.Shaping.Enabled {
+		return d
+	}
+	return rotation.JitterDuration(d, spmCfg.Stealth.Shaping.CooldownJitter, nil)
+}
+
+// logsActivations reports whether activations are written to the activity
+// log: for analytics, and for shaping, whose dwell and daily caps count them.
+func logsActivations(spmCfg *config.SPMConfig) bool {
+	return spmCfg != nil && (spmCfg.Analytics.Enabled || spmCfg.Stealth.Shaping.Enabled)
+}
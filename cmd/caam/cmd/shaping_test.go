@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func TestRotationShaping(t *testing.T) {
+	_, cleanup := setupCooldownTestEnv(t)
+	defer cleanup()
+	t.Setenv(config.ConfigEnvVar, config.DefaultEnv)
+	vault = authfile.NewVault(t.TempDir())
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := os.MkdirAll(vault.ProfilePath("codex", name), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(vault.ProfilePath("codex", name), "auth.json"), []byte(`{"access_token":"`+name+`"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	authPath := filepath.Join(os.Getenv("CODEX_HOME"), "auth.json")
+	if err := os.WriteFile(authPath, []byte(`{"access_token":"a"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := getDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, ev := range []caamdb.Event{
+		{Type: caamdb.EventActivate, Provider: "codex", ProfileName: "b", Timestamp: now.Add(-2 * time.Minute)},
+		{Type: caamdb.EventActivate, Provider: "codex", ProfileName: "a", Timestamp: now.Add(-time.Minute)},
+	} {
+		if err := db.LogEvent(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spmCfg := config.DefaultSPMConfig()
+	spmCfg.Stealth.Shaping.Enabled = true
+	spmCfg.Stealth.Shaping.MinDwell = config.Duration(time.Hour)
+	spmCfg.Stealth.Shaping.MaxActivationsPerDay = 1
+	spmCfg.Policies = []config.RotationPolicy{{Provider: "codex", Every: config.Duration(30 * time.Second)}}
+	if err := spmCfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// robot next keeps the active profile during its dwell and leaves out
+	// b, which has used its activation for the day.
+	scored := scoreRobotNextProfiles("codex", []string{"a", "b", "c"}, "smart", false, db)
+	if len(scored) != 2 || scored[0].name != "a" || !strings.HasPrefix(scored[0].reasons[0], "shaping: minimum dwell") {
+		t.Fatalf("robot next = %+v, want a held for its dwell, then c", scored)
+	}
+
+	outcomes := applyRotationPolicies(spmCfg, true)
+	if outcomes[0].Action != "not_due" || !strings.Contains(outcomes[0].Reason, "minimum dwell") {
+		t.Errorf("policy during dwell = %+v, want not_due", outcomes[0])
+	}
+
+	_, failure := performRobotAct("activate", "codex", []string{"activate", "codex", "c"}, false)
+	if failure == nil || failure.Code != string(caamerr.ShapingBlocked) {
+		t.Fatalf("activate during dwell failure = %+v, want %s", failure, caamerr.ShapingBlocked)
+	}
+
+	spmCfg.Stealth.Shaping.MinDwell = 0
+	outcomes = applyRotationPolicies(spmCfg, true)
+	if outcomes[0].Action != "would_rotate" || outcomes[0].To != "c" {
+		t.Errorf("policy after dwell = %+v, want would_rotate to c", outcomes[0])
+	}
+
+	if err := db.LogEvent(caamdb.Event{Type: caamdb.EventActivate, Provider: "codex", ProfileName: "c", Timestamp: now.Add(-3 * time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	outcomes = applyRotationPolicies(spmCfg, true)
+	if outcomes[0].Action != "skipped" {
+		t.Errorf("policy with every other profile capped = %+v, want skipped", outcomes[0])
+	}
+
+	// The override activates anyway, and the activation counts toward c's cap.
+	if _, failure := performRobotAct("activate", "codex", []string{"activate", "codex", "c"}, true); failure != nil {
+		t.Fatalf("activate --ignore-shaping failure = %+v", failure)
+	}
+	if n, _ := db.CountActivations("codex", "c", now.Add(-time.Hour)); n != 2 {
+		t.Errorf("activations of c = %d, want 2", n)
+	}
+	if got, _ := os.ReadFile(authPath); string(got) != `{"access_token":"c"}` {
+		t.Errorf("active auth = %s, want profile c", got)
+	}
+}
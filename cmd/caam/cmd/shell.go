@@ -152,13 +152,13 @@ func findCaamPath() (string, error) {
 // This prevents command injection when paths contain spaces or special characters.
 func shellQuote(s string) string {
 	// If the string contains no special characters, return as-is
-	needsQuote := false
+	needsQuote := s == ""
 	for _, c := range s {
 		if c == ' ' || c == '\'' || c == '"' || c == '\\' || c == '$' ||
 			c == '`' || c == '!' || c == '*' || c == '?' || c == '[' ||
 			c == ']' || c == '(' || c == ')' || c == '{' || c == '}' ||
 			c == '|' || c == '&' || c == ';' || c == '<' || c == '>' ||
-			c == '\n' || c == '\t' {
+			c == '#' || c == '~' || c == '\n' || c == '\t' {
 			needsQuote = true
 			break
 		}
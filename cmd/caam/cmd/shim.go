@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/shim"
+)
+
+var shimCmd = &cobra.Command{
+	Use:   "shim",
+	Short: "Install wrapper scripts that route a tool through caam run",
+	Long: `Shims are small scripts named after a tool (claude, codex, gemini, ...)
+that run it through 'caam run'. Put the shim directory ahead of the real tool
+on PATH and every script, agent, or editor that calls the tool by name gets
+the best profile, rate limit failover, and usage recording without changes.
+
+Unlike the functions from 'caam shell init', shims work in non-interactive
+shells, cron jobs, and programs that run the tool directly.
+
+Shims go in the shims directory under caam's data directory unless --dir
+is given. 'caam run' drops shim directories from PATH before starting the
+tool, so the real binary runs, not the shim.
+
+Examples:
+  caam shim install claude codex
+  export PATH="$HOME/.local/share/caam/shims:$PATH"
+  caam shim list
+  caam shim uninstall --all`,
+}
+
+var shimInstallCmd = &cobra.Command{
+	Use:   "install <tool>...",
+	Short: "Install shims for tools",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runShimInstall,
+}
+
+var shimUninstallCmd = &cobra.Command{
+	Use:   "uninstall [tool]...",
+	Short: "Remove shims",
+	RunE:  runShimUninstall,
+}
+
+var shimListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed shims and whether they are active",
+	Args:  cobra.NoArgs,
+	RunE:  runShimList,
+}
+
+func init() {
+	rootCmd.AddCommand(shimCmd)
+	shimCmd.AddCommand(shimInstallCmd)
+	shimCmd.AddCommand(shimUninstallCmd)
+	shimCmd.AddCommand(shimListCmd)
+
+	shimInstallCmd.Flags().String("dir", "", "directory for the shims (default: caam data dir/shims)")
+	shimInstallCmd.Flags().Bool("force", false, "overwrite existing files that are not caam shims")
+	shimInstallCmd.Flags().Bool("precheck", false, "have the shims run 'caam run --precheck'")
+
+	shimUninstallCmd.Flags().Bool("all", false, "remove every installed shim")
+
+	shimListCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+func shimManager() *shim.Manager {
+	return shim.NewManager(config.DefaultDataPath())
+}
+
+// dropShimDirsFromPath removes shim directories from this process's PATH so
+// the tool 'caam run' starts is the real binary, not a shim calling caam.
+func dropShimDirsFromPath() {
+	dirs := append(shimManager().Dirs(), shim.DefaultDir(config.DefaultDataPath()))
+	_ = os.Setenv("PATH", shim.StripPath(os.Getenv("PATH"), dirs))
+}
+
+func runShimInstall(cmd *cobra.Command, args []string) error {
+	dir, _ := cmd.Flags().GetString("dir")
+	force, _ := cmd.Flags().GetBool("force")
+	precheck, _ := cmd.Flags().GetBool("precheck")
+
+	for _, tool := range args {
+		if _, ok := tools[strings.ToLower(tool)]; !ok {
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
+		}
+	}
+
+	if dir == "" {
+		dir = shim.DefaultDir(config.DefaultDataPath())
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return caamerr.Errorf(caamerr.InvalidArgs, "shim dir: %w", err)
+	}
+	caamPath, err := findCaamPath()
+	if err != nil {
+		return fmt.Errorf("find caam executable: %w", err)
+	}
+	var runArgs []string
+	if precheck {
+		runArgs = append(runArgs, "--precheck")
+	}
+
+	out := cmd.OutOrStdout()
+	mgr := shimManager()
+	for _, tool := range args {
+		tool = strings.ToLower(tool)
+		s := shim.Shim{
+			Provider: tool,
+			Path:     filepath.Join(dir, shim.ScriptName(tool)),
+			CaamPath: caamPath,
+			RunArgs:  runArgs,
+		}
+		if err := mgr.Install(s, force); err != nil {
+			if errors.Is(err, shim.ErrNotShim) {
+				return caamerr.Errorf(caamerr.AlreadyExists, "%w (use --force to replace it)", err)
+			}
+			return caamerr.Errorf(caamerr.SaveError, "install %s shim: %w", tool, err)
+		}
+		fmt.Fprintf(out, "Installed %s shim: %s\n", tool, s.Path)
+	}
+
+	if !pathContains(os.Getenv("PATH"), dir) {
+		fmt.Fprintf(out, "\n%s is not on PATH. Add it ahead of the real tools, e.g. in your shell profile:\n", dir)
+		fmt.Fprintf(out, "  export PATH=%s:\"$PATH\"\n", shellQuote(dir))
+	}
+	return nil
+}
+
+func runShimUninstall(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	if all == (len(args) > 0) {
+		return caamerr.New(caamerr.InvalidArgs, "give tool names or --all")
+	}
+
+	mgr := shimManager()
+	if all {
+		shims, err := mgr.List()
+		if err != nil {
+			return caamerr.Wrap(caamerr.ConfigError, err)
+		}
+		for _, s := range shims {
+			args = append(args, s.Provider)
+		}
+		if len(args) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No shims installed.")
+			return nil
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	for _, tool := range args {
+		tool = strings.ToLower(tool)
+		removed, err := mgr.Uninstall(tool)
+		switch {
+		case errors.Is(err, shim.ErrNotInstalled):
+			return caamerr.Errorf(caamerr.NotFound, "no %s shim installed", tool)
+		case errors.Is(err, shim.ErrNotShim):
+			fmt.Fprintf(out, "Forgot %s shim; left %s in place (no longer a caam shim)\n", tool, removed.Path)
+		case err != nil:
+			return caamerr.Errorf(caamerr.SaveError, "uninstall %s shim: %w", tool, err)
+		default:
+			fmt.Fprintf(out, "Removed %s shim: %s\n", tool, removed.Path)
+		}
+	}
+	return nil
+}
+
+func runShimList(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	mgr := shimManager()
+	shims, err := mgr.List()
+	if err != nil {
+		return caamerr.Wrap(caamerr.ConfigError, err)
+	}
+	statuses := make([]shim.Status, 0, len(shims))
+	for _, s := range shims {
+		statuses = append(statuses, mgr.Check(s, os.Getenv("PATH")))
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statuses)
+	}
+
+	if len(statuses) == 0 {
+		fmt.Fprintln(out, "No shims installed. Install one with: caam shim install <tool>")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOOL\tSTATUS\tPATH")
+	for _, st := range statuses {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", st.Provider, shimStatusText(st), st.Path)
+	}
+	return w.Flush()
+}
+
+// shimStatusText summarizes a shim's health in a few words.
+func shimStatusText(st shim.Status) string {
+	switch {
+	case !st.Present:
+		return "missing"
+	case !st.CaamFound:
+		return "stale (caam moved)"
+	case !st.OnPath:
+		return "inactive (not on PATH)"
+	case !st.Active:
+		return "shadowed by " + st.Resolved
+	case st.RealBinary == "":
+		return "active (" + st.Provider + " not installed)"
+	default:
+		return "active"
+	}
+}
+
+// pathContains reports whether dir is one of the directories in pathList.
+func pathContains(pathList, dir string) bool {
+	dir = filepath.Clean(dir)
+	for _, p := range filepath.SplitList(pathList) {
+		if p != "" && filepath.Clean(p) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// checkShims reports the health of installed shims. With fix, shims whose
+// file is gone or whose caam binary moved are rewritten.
+func checkShims(fix bool) []CheckResult {
+	mgr := shimManager()
+	shims, err := mgr.List()
+	if err != nil {
+		return []CheckResult{{
+			Name:    "shims",
+			Status:  "warn",
+			Message: "could not read shim state",
+			Details: err.Error(),
+		}}
+	}
+
+	caamPath, _ := findCaamPath()
+	var results []CheckResult
+	for _, s := range shims {
+		name := s.Provider + " shim"
+		st := mgr.Check(s, os.Getenv("PATH"))
+
+		if !st.Present || !st.CaamFound {
+			problem := "shim file is missing or was replaced"
+			if st.Present {
+				problem = fmt.Sprintf("shim runs %s, which no longer exists", s.CaamPath)
+			}
+			if fix && caamPath != "" {
+				s.CaamPath = caamPath
+				if err := mgr.Install(s, false); err == nil {
+					results = append(results, CheckResult{
+						Name:    name,
+						Status:  "fixed",
+						Message: "rewrote " + s.Path,
+						Details: problem,
+					})
+					continue
+				}
+			}
+			results = append(results, CheckResult{
+				Name:    name,
+				Status:  "fail",
+				Message: problem,
+				Details: s.Path,
+				Remedy:  autoFixRemedy("Rewrite the shim"),
+			})
+			continue
+		}
+
+		switch {
+		case !st.OnPath:
+			results = append(results, CheckResult{
+				Name:    name,
+				Status:  "warn",
+				Message: "shim directory is not on PATH",
+				Details: s.Dir(),
+				Remedy: &Remedy{
+					Description: fmt.Sprintf("Add %s to the front of PATH in your shell profile", s.Dir()),
+					Risk:        FixRiskLow,
+					Interactive: true,
+				},
+			})
+		case !st.Active:
+			results = append(results, CheckResult{
+				Name:    name,
+				Status:  "warn",
+				Message: "shadowed by " + st.Resolved,
+				Details: fmt.Sprintf("Running %s by name skips caam", s.Provider),
+				Remedy: &Remedy{
+					Description: fmt.Sprintf("Move %s ahead of %s in PATH", s.Dir(), filepath.Dir(st.Resolved)),
+					Risk:        FixRiskLow,
+					Interactive: true,
+				},
+			})
+		case st.RealBinary == "":
+			results = append(results, CheckResult{
+				Name:    name,
+				Status:  "warn",
+				Message: fmt.Sprintf("active, but no real %s found on PATH", s.Provider),
+				Details: "The shim has no tool to run",
+				Remedy: &Remedy{
+					Description: fmt.Sprintf("Install the %s CLI and make sure it is on PATH", s.Provider),
+					Risk:        FixRiskMedium,
+					Interactive: true,
+				},
+			})
+		default:
+			results = append(results, CheckResult{
+				Name:    name,
+				Status:  "pass",
+				Message: fmt.Sprintf("active, wraps %s", st.RealBinary),
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestShimInstallListUninstall(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shims")
+	}
+	t.Setenv("CAAM_HOME", t.TempDir())
+	shimDir := t.TempDir()
+
+	var buf bytes.Buffer
+	shimInstallCmd.SetOut(&buf)
+	defer shimInstallCmd.SetOut(nil)
+	if err := shimInstallCmd.Flags().Set("dir", shimDir); err != nil {
+		t.Fatal(err)
+	}
+	defer shimInstallCmd.Flags().Set("dir", "")
+
+	if err := runShimInstall(shimInstallCmd, []string{"claude"}); err != nil {
+		t.Fatalf("runShimInstall() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "is not on PATH") {
+		t.Errorf("install output lacks PATH hint:\n%s", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(shimDir, "claude")); err != nil {
+		t.Fatalf("shim not written: %v", err)
+	}
+	if err := runShimInstall(shimInstallCmd, []string{"nope"}); err == nil {
+		t.Error("runShimInstall(unknown tool) succeeded")
+	}
+
+	// caam run must not find the shim when it looks up the tool.
+	t.Setenv("PATH", shimDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	dropShimDirsFromPath()
+	if strings.Contains(os.Getenv("PATH"), shimDir) {
+		t.Errorf("PATH still has shim dir: %s", os.Getenv("PATH"))
+	}
+
+	buf.Reset()
+	shimListCmd.SetOut(&buf)
+	defer shimListCmd.SetOut(nil)
+	if err := runShimList(shimListCmd, nil); err != nil {
+		t.Fatalf("runShimList() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "inactive (not on PATH)") {
+		t.Errorf("list output:\n%s", buf.String())
+	}
+
+	if results := checkShims(false); len(results) != 1 || results[0].Status != "warn" {
+		t.Errorf("checkShims() = %+v, want one warning", results)
+	}
+
+	shimUninstallCmd.SetOut(&buf)
+	defer shimUninstallCmd.SetOut(nil)
+	if err := runShimUninstall(shimUninstallCmd, []string{"claude"}); err != nil {
+		t.Fatalf("runShimUninstall() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(shimDir, "claude")); !os.IsNotExist(err) {
+		t.Error("shim still present after uninstall")
+	}
+	if err := runShimUninstall(shimUninstallCmd, []string{"claude"}); err == nil {
+		t.Error("second uninstall succeeded")
+	}
+}
@@ -9,11 +9,16 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/hub"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 // syncCmd is the parent command for sync operations.
@@ -36,14 +41,27 @@ Machine management:
   caam sync remove <name>          # Remove machine from pool
   caam sync test [name]            # Test connectivity
 
+Cloud storage hub:
+  caam sync hub init s3://bucket/prefix   # Sync through S3, GCS, or B2
+  caam sync hub status                    # Show profiles in the hub
+
 Auto-sync:
   caam sync enable      # Enable auto-sync after backup/refresh
   caam sync disable     # Disable auto-sync
 
 Troubleshooting:
   caam sync log         # View sync history
-  caam sync queue       # View/manage retry queue`,
-	RunE: runSync,
+  caam sync queue       # View/manage retry queue
+
+'caam sync' never prompts, so it is safe to run from cron. --json prints one
+JSON object with each machine's per-profile push, pull, skip, or error and
+timings. The exit status is 2 (PARTIAL_SUCCESS) when some machines or
+profiles failed and others synced, and 8 (SYNC_FAILED) when nothing synced.
+
+Use --progress json to emit NDJSON progress events (one phase per machine)
+on stderr for wrapping UIs. Use --async to queue the sync as a background job
+for the daemon (see 'caam jobs').`,
+	RunE: jobRunE("sync", runSync, nil),
 }
 
 // syncStatusCmd shows the sync pool status.
@@ -67,13 +85,22 @@ var syncAddCmd = &cobra.Command{
 
 Arguments:
   name      Friendly name for the machine (e.g., "work-laptop")
-  address   IP address or hostname, optionally with user/port (e.g., "jeff@192.168.1.100:22")
+  address   IP address or hostname, optionally with user/port (e.g., "jeff@192.168.1.100:22"),
+            or with --transport https, a WebDAV endpoint URL
+
+Machines that can't accept SSH can sync through an HTTPS/WebDAV endpoint
+instead. Every machine pointed at the same endpoint shares its profiles
+through it; files are encrypted with the sync passphrase (CAAM_SYNC_PASSPHRASE,
+or the passphrase file in the sync data directory) before upload. Server
+credentials come from CAAM_SYNC_HTTP_PASSWORD (with --user) or
+CAAM_SYNC_HTTP_TOKEN.
 
 Examples:
   caam sync add work-laptop 192.168.1.100
   caam sync add home-desktop jeff@10.0.0.50
   caam sync add dev-server admin@dev.example.com:2222
-  caam sync add cloud-vm 34.123.45.67 --key ~/.ssh/cloud_key`,
+  caam sync add cloud-vm 34.123.45.67 --key ~/.ssh/cloud_key
+  caam sync add hub https://dav.example.com/caam --transport https --user jeff`,
 	Args: cobra.ExactArgs(2),
 	RunE: runSyncAdd,
 }
@@ -91,7 +118,7 @@ var syncRemoveCmd = &cobra.Command{
 var syncTestCmd = &cobra.Command{
 	Use:   "test [name]",
 	Short: "Test connectivity to machines",
-	Long: `Test SSH connectivity to one or all machines in the sync pool.
+	Long: `Test SSH (or HTTPS) connectivity to one or all machines in the sync pool.
 
 Without arguments, tests all machines. With a machine name, tests only that machine.
 
@@ -143,7 +170,18 @@ var syncDiscoverCmd = &cobra.Command{
 This filters out:
   - Known code hosting services (github.com, gitlab.com, etc.)
   - Wildcard hosts (Host *)
-  - Hosts with ProxyJump (complex setups)`,
+  - Hosts with ProxyJump (complex setups)
+
+With --network, find other caam instances instead: on the LAN over mDNS,
+and on your tailnet through the Tailscale local API. Only machines whose
+daemon announces itself (daemon.announce.listen in config.yaml) are found.
+Each is listed with its hostname, caam version, and vault profile counts,
+and you're offered to add the new ones to the pool.
+
+Examples:
+  caam sync discover
+  caam sync discover --network
+  caam sync discover --network --add`,
 	RunE: runSyncDiscover,
 }
 
@@ -180,9 +218,13 @@ This wizard helps you:
   3. Test connectivity to machines
   4. Enable or disable auto-sync
 
+With --encrypt, it also creates a pool encryption key and sends it to the
+new machines (see 'caam sync encrypt').
+
 Examples:
   caam sync init              # Interactive setup
   caam sync init --discover   # Auto-discover from SSH config
+  caam sync init --encrypt    # Encrypt profiles sent between machines
   caam sync init --csv        # Create CSV template only`,
 	RunE: runSyncInit,
 }
@@ -190,6 +232,16 @@ Examples:
 func init() {
 	rootCmd.AddCommand(syncCmd)
 
+	// Sync history and the retry queue live in the caam database; legacy
+	// history.json/queue.json files are imported on first use.
+	sync.SetDefaultStoreOpener(func() (sync.Store, error) {
+		db, err := caamdb.Open()
+		if err != nil {
+			return nil, err
+		}
+		return sync.NewDBStore(db), nil
+	})
+
 	// Add subcommands
 	syncCmd.AddCommand(syncInitCmd)
 	syncCmd.AddCommand(syncStatusCmd)
@@ -210,10 +262,14 @@ func init() {
 	syncCmd.Flags().Bool("dry-run", false, "show what would sync without doing it")
 	syncCmd.Flags().Bool("force", false, "force sync even if recently synced")
 	syncCmd.Flags().Bool("json", false, "output results as JSON")
+	syncCmd.Flags().BoolP("yes", "y", false, "assume yes to any question (sync never waits for input, so cron jobs are safe)")
+	addAsyncFlag(syncCmd)
+	addProgressFlag(syncCmd)
 
 	// Add command flags
 	syncAddCmd.Flags().String("key", "", "path to SSH private key")
-	syncAddCmd.Flags().String("user", "", "SSH username")
+	syncAddCmd.Flags().String("user", "", "SSH username (HTTPS: basic auth username)")
+	syncAddCmd.Flags().String("transport", sync.TransportSSH, "how to reach the machine: ssh or https")
 	syncAddCmd.Flags().String("remote-path", "", "path to caam data on remote")
 	syncAddCmd.Flags().Bool("test", true, "test connectivity after adding")
 
@@ -233,10 +289,13 @@ func init() {
 	// Init command flags
 	syncInitCmd.Flags().Bool("discover", false, "auto-discover from SSH config")
 	syncInitCmd.Flags().Bool("csv", false, "create CSV template only")
+	syncInitCmd.Flags().Bool("encrypt", false, "encrypt profiles sent to other machines with a pool key")
 
 	// Discover command flags
 	syncDiscoverCmd.Flags().Bool("add", false, "add discovered machines to pool")
 	syncDiscoverCmd.Flags().Bool("test", false, "test connectivity to discovered")
+	syncDiscoverCmd.Flags().Bool("network", false, "find caam instances on the LAN (mDNS) and tailnet instead of SSH config")
+	syncDiscoverCmd.Flags().Duration("timeout", 3*time.Second, "how long to wait for answers with --network")
 
 	// Status command flags
 	syncStatusCmd.Flags().Bool("json", false, "output as JSON")
@@ -256,100 +315,347 @@ func loadSyncState() (*sync.SyncState, error) {
 	return state, nil
 }
 
-// runSync performs a sync with all or specific machines.
+// SyncRunResult is the --json output of 'caam sync'.
+type SyncRunResult struct {
+	// Status is ok, partial (some profiles or machines failed), or failed
+	// (nothing succeeded).
+	Status     string              `json:"status"`
+	DryRun     bool                `json:"dry_run,omitempty"`
+	Machines   []SyncMachineResult `json:"machines"`
+	Pushed     int                 `json:"pushed"`
+	Pulled     int                 `json:"pulled"`
+	Skipped    int                 `json:"skipped"`
+	Failed     int                 `json:"failed"`
+	DurationMs int64               `json:"duration_ms"`
+}
+
+// SyncMachineResult is one machine's part of a sync run.
+type SyncMachineResult struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	// Status is ok, partial, failed, or, in a dry run, pending.
+	Status     string              `json:"status"`
+	Error      string              `json:"error,omitempty"`
+	DurationMs int64               `json:"duration_ms"`
+	Profiles   []SyncProfileResult `json:"profiles"`
+}
+
+// SyncProfileResult is what a sync run did with one profile on one machine.
+type SyncProfileResult struct {
+	Provider string `json:"provider"`
+	Profile  string `json:"profile"`
+	// Action is push, pull, skip (already in sync), conflict, or error.
+	Action string `json:"action"`
+	// Direction is the push or pull that failed, for an error.
+	Direction     string `json:"direction,omitempty"`
+	Error         string `json:"error,omitempty"`
+	BytesSent     int64  `json:"bytes_sent,omitempty"`
+	BytesReceived int64  `json:"bytes_received,omitempty"`
+	DurationMs    int64  `json:"duration_ms"`
+}
+
+// syncProfileResult converts a per-profile sync result; nil means the
+// profile was already in sync.
+func syncProfileResult(p sync.ProfileRef, r *sync.SyncResult) SyncProfileResult {
+	out := SyncProfileResult{Provider: p.Provider, Profile: p.Profile, Action: string(sync.SyncSkip)}
+	if r == nil {
+		return out
+	}
+	out.BytesSent, out.BytesReceived = r.BytesSent, r.BytesReceived
+	out.DurationMs = r.Duration.Milliseconds()
+	if r.Operation != nil {
+		out.Action = string(r.Operation.Direction)
+	}
+	if !r.Success {
+		if out.Action != string(sync.SyncConflict) {
+			out.Direction, out.Action = out.Action, "error"
+			if out.Direction == string(sync.SyncSkip) {
+				out.Direction = ""
+			}
+		}
+		if r.Error != nil {
+			out.Error = r.Error.Error()
+		}
+	}
+	return out
+}
+
+// hubProfileResults converts hub results for a sync run.
+func hubProfileResults(results []hub.Result) []SyncProfileResult {
+	out := make([]SyncProfileResult, 0, len(results))
+	for _, r := range results {
+		p := SyncProfileResult{Provider: r.Provider, Profile: r.Profile, Action: r.Action, Error: r.Error}
+		if r.Error != "" {
+			p.Direction, p.Action = r.Action, "error"
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// finish sets the machine's status from its profiles.
+func (m *SyncMachineResult) finish(start time.Time) {
+	m.DurationMs = time.Since(start).Milliseconds()
+	if m.Profiles == nil {
+		m.Profiles = []SyncProfileResult{}
+	}
+	failed := 0
+	for _, p := range m.Profiles {
+		if p.Action == "error" || p.Action == string(sync.SyncConflict) {
+			failed++
+		}
+	}
+	switch {
+	case m.Error != "":
+		m.Status = "failed"
+	case failed == 0:
+		m.Status = "ok"
+	case failed == len(m.Profiles):
+		m.Status = "failed"
+	default:
+		m.Status = "partial"
+	}
+}
+
+// runSync performs a sync with all or specific machines. It never prompts,
+// so it can run from cron; with --json it prints a SyncRunResult. It exits
+// 2 (PARTIAL_SUCCESS) if some profiles or machines failed and others
+// synced, and 8 (SYNC_FAILED) if nothing synced.
 func runSync(cmd *cobra.Command, args []string) error {
+	start := time.Now()
 	state, err := loadSyncState()
 	if err != nil {
 		return err
 	}
 
-	if len(state.Pool.ListMachines()) == 0 {
-		fmt.Fprintln(cmd.OutOrStdout(), "No machines in sync pool.")
-		fmt.Fprintln(cmd.OutOrStdout(), "")
-		fmt.Fprintln(cmd.OutOrStdout(), "Get started:")
-		fmt.Fprintln(cmd.OutOrStdout(), "  caam sync add <name> <address>   # Add a machine")
-		fmt.Fprintln(cmd.OutOrStdout(), "  caam sync discover               # Find from SSH config")
-		fmt.Fprintln(cmd.OutOrStdout(), "")
-		fmt.Fprintln(cmd.OutOrStdout(), "Example:")
-		fmt.Fprintln(cmd.OutOrStdout(), "  caam sync add work-laptop 192.168.1.100")
-		return nil
-	}
-
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	machineName, _ := cmd.Flags().GetString("machine")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	// Human-readable lines go nowhere when the output is JSON.
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		out = io.Discard
+	}
+	run := SyncRunResult{DryRun: dryRun, Machines: []SyncMachineResult{}}
+
+	// The hub syncs alongside the pool unless a single machine was named.
+	var hubCfg *hub.Config
+	if machineName == "" {
+		if hubCfg, err = hub.LoadConfig(); err != nil {
+			return fmt.Errorf("load hub config: %w", err)
+		}
+	}
+
+	if len(state.Pool.ListMachines()) == 0 && hubCfg == nil {
+		fmt.Fprintln(out, "No machines in sync pool.")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Get started:")
+		fmt.Fprintln(out, "  caam sync add <name> <address>   # Add a machine")
+		fmt.Fprintln(out, "  caam sync discover               # Find from SSH config")
+		fmt.Fprintln(out, "  caam sync hub init s3://bucket   # Sync through a bucket")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Example:")
+		fmt.Fprintln(out, "  caam sync add work-laptop 192.168.1.100")
+		return finishSyncRun(cmd, &run, start, jsonOutput)
+	}
+
+	prog, err := progressReporter(cmd, "sync")
+	if err != nil {
+		return err
+	}
 
 	machines := state.Pool.ListMachines()
 	if machineName != "" {
 		// Filter to specific machine
 		m := state.Pool.GetMachineByName(machineName)
 		if m == nil {
-			return fmt.Errorf("machine %q not found in pool; run 'caam sync ls' to see available machines", machineName)
+			return caamerr.Errorf(caamerr.NotFound, "machine %q not found in pool; run 'caam sync ls' to see available machines", machineName)
 		}
 		machines = []*sync.Machine{m}
 	}
 
 	if dryRun {
-		fmt.Fprintln(cmd.OutOrStdout(), "Dry run - would sync with:")
+		fmt.Fprintln(out, "Dry run - would sync with:")
 		for _, m := range machines {
-			fmt.Fprintf(cmd.OutOrStdout(), "  %s (%s)\n", m.Name, m.Address)
+			fmt.Fprintf(out, "  %s (%s)\n", m.Name, m.Address)
+			run.Machines = append(run.Machines, SyncMachineResult{Name: m.Name, Address: m.Address, Status: "pending", Profiles: []SyncProfileResult{}})
 		}
-		return nil
+		if hubCfg != nil {
+			fmt.Fprintf(out, "  %s (%s)\n", hubMachineName, hubCfg.URL)
+			run.Machines = append(run.Machines, SyncMachineResult{Name: hubMachineName, Address: hubCfg.URL, Status: "pending", Profiles: []SyncProfileResult{}})
+		}
+		return finishSyncRun(cmd, &run, start, jsonOutput)
 	}
 
-	fmt.Fprintf(cmd.OutOrStdout(), "Syncing with %d machine(s)...\n\n", len(machines))
+	if len(machines) > 0 {
+		fmt.Fprintf(out, "Syncing with %d machine(s)...\n\n", len(machines))
+	}
 
-	// Create syncer with configuration
-	syncer, err := sync.NewSyncer(sync.DefaultSyncerConfig())
+	// Create syncer with configuration. Every profile compared, including
+	// those already in sync, is recorded against the current machine.
+	var current *SyncMachineResult
+	syncConfig := sync.DefaultSyncerConfig()
+	syncConfig.OnProfile = func(m *sync.Machine, p sync.ProfileRef, done, total int, result *sync.SyncResult) {
+		if current != nil {
+			current.Profiles = append(current.Profiles, syncProfileResult(p, result))
+		}
+		if prog != nil {
+			prog.SetTotal(total)
+			status, message := syncProgressStatus(result)
+			prog.Advance(p.Provider+"/"+p.Profile, status, message)
+		}
+	}
+	syncer, err := sync.NewSyncer(syncConfig)
 	if err != nil {
-		return fmt.Errorf("create syncer: %w", err)
+		err = fmt.Errorf("create syncer: %w", err)
+		prog.Done(err)
+		return err
 	}
 	defer syncer.Close()
 
 	// Build context
 	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
 	var allResults []*sync.SyncResult
 	for _, m := range machines {
-		fmt.Fprintf(cmd.OutOrStdout(), "  %s (%s):\n", m.Name, m.Address)
+		fmt.Fprintf(out, "  %s (%s):\n", m.Name, m.Address)
+		prog.Phase("machine:"+m.Name, 0)
+		machineStart := time.Now()
+		current = &SyncMachineResult{Name: m.Name, Address: m.Address}
 
 		results, err := syncer.SyncWithMachine(ctx, m)
 		if err != nil {
-			fmt.Fprintf(cmd.OutOrStdout(), "    ✗ Error: %v\n\n", err)
-			continue
-		}
-
-		if len(results) == 0 {
-			fmt.Fprintln(cmd.OutOrStdout(), "    ✓ All profiles up to date")
-			fmt.Fprintln(cmd.OutOrStdout())
-			continue
-		}
-
-		for _, r := range results {
-			profile := fmt.Sprintf("%s/%s", r.Operation.Provider, r.Operation.Profile)
-			if r.Success {
-				switch r.Operation.Direction {
-				case sync.SyncPush:
-					fmt.Fprintf(cmd.OutOrStdout(), "    ✓ %s: pushed (local fresher)\n", profile)
-				case sync.SyncPull:
-					fmt.Fprintf(cmd.OutOrStdout(), "    ✓ %s: pulled (remote fresher)\n", profile)
-				case sync.SyncSkip:
-					fmt.Fprintf(cmd.OutOrStdout(), "    ✓ %s: up to date\n", profile)
+			fmt.Fprintf(out, "    ✗ Error: %v\n\n", err)
+			prog.Update(m.Name, "failed", err.Error())
+			current.Error = err.Error()
+		} else if len(results) == 0 {
+			fmt.Fprintln(out, "    ✓ All profiles up to date")
+			fmt.Fprintln(out)
+		} else {
+			for _, r := range results {
+				profile := fmt.Sprintf("%s/%s", r.Operation.Provider, r.Operation.Profile)
+				if r.Success {
+					switch r.Operation.Direction {
+					case sync.SyncPush:
+						fmt.Fprintf(out, "    ✓ %s: pushed (local fresher)\n", profile)
+					case sync.SyncPull:
+						fmt.Fprintf(out, "    ✓ %s: pulled (remote fresher)\n", profile)
+					case sync.SyncSkip:
+						fmt.Fprintf(out, "    ✓ %s: up to date\n", profile)
+					}
+				} else {
+					fmt.Fprintf(out, "    ✗ %s: %v\n", profile, r.Error)
 				}
-			} else {
-				fmt.Fprintf(cmd.OutOrStdout(), "    ✗ %s: %v\n", profile, r.Error)
 			}
+			fmt.Fprintln(out)
+			allResults = append(allResults, results...)
 		}
-		fmt.Fprintln(cmd.OutOrStdout())
 
-		allResults = append(allResults, results...)
+		current.finish(machineStart)
+		run.Machines = append(run.Machines, *current)
+		current = nil
+	}
+
+	if hubCfg != nil {
+		fmt.Fprintf(out, "  %s (%s):\n", hubMachineName, hubCfg.URL)
+		prog.Phase("machine:"+hubMachineName, 0)
+		hubStart := time.Now()
+		hubRun := SyncMachineResult{Name: hubMachineName, Address: hubCfg.URL}
+		results, err := runHubFromSync(cmd, hubCfg)
+		if err != nil {
+			fmt.Fprintf(out, "    ✗ Error: %v\n\n", err)
+			prog.Update(hubMachineName, "failed", err.Error())
+			hubRun.Error = err.Error()
+		} else {
+			printHubResults(out, results)
+			fmt.Fprintln(out)
+			allResults = append(allResults, hubSyncResults(results)...)
+			hubRun.Profiles = hubProfileResults(results)
+		}
+		hubRun.finish(hubStart)
+		run.Machines = append(run.Machines, hubRun)
 	}
 
 	// Print summary
 	stats := sync.AggregateResults(allResults)
-	fmt.Fprintf(cmd.OutOrStdout(), "Sync complete: %d pushed, %d pulled, %d up to date, %d errors\n",
+	fmt.Fprintf(out, "Sync complete: %d pushed, %d pulled, %d up to date, %d errors\n",
 		stats.Pushed, stats.Pulled, stats.Skipped, stats.Failed)
+	prog.Done(nil)
 
-	return nil
+	return finishSyncRun(cmd, &run, start, jsonOutput)
+}
+
+// finishSyncRun totals the run, prints it as JSON if asked, and returns the
+// error that sets the exit status.
+func finishSyncRun(cmd *cobra.Command, run *SyncRunResult, start time.Time, jsonOutput bool) error {
+	run.DurationMs = time.Since(start).Milliseconds()
+	failedMachines, synced := 0, 0
+	for _, m := range run.Machines {
+		if m.Error != "" {
+			failedMachines++
+		}
+		for _, p := range m.Profiles {
+			switch p.Action {
+			case string(sync.SyncPush):
+				run.Pushed++
+			case string(sync.SyncPull):
+				run.Pulled++
+			case string(sync.SyncSkip):
+				run.Skipped++
+			default:
+				run.Failed++
+			}
+		}
+		if m.Status == "ok" || m.Status == "partial" {
+			synced++
+		}
+	}
+
+	var err error
+	switch {
+	case run.DryRun || (failedMachines == 0 && run.Failed == 0):
+		run.Status = "ok"
+	case synced == 0:
+		run.Status = "failed"
+		err = caamerr.Errorf(caamerr.SyncFailed, "sync failed with all %d machine(s)", len(run.Machines))
+	default:
+		run.Status = "partial"
+		err = caamerr.Errorf(caamerr.PartialSuccess, "sync partly failed: %d profile error(s), %d of %d machine(s) failed",
+			run.Failed, failedMachines, len(run.Machines))
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(run); encErr != nil {
+			return encErr
+		}
+	}
+	return err
+}
+
+// syncProgressStatus maps a per-profile sync result to a progress status.
+// A nil result means the profile was already in sync.
+func syncProgressStatus(r *sync.SyncResult) (status, message string) {
+	switch {
+	case r == nil:
+		return "up_to_date", ""
+	case !r.Success:
+		if r.Error != nil {
+			return "failed", r.Error.Error()
+		}
+		return "failed", ""
+	case r.Operation != nil && r.Operation.Direction == sync.SyncPush:
+		return "pushed", ""
+	case r.Operation != nil && r.Operation.Direction == sync.SyncPull:
+		return "pulled", ""
+	default:
+		return "up_to_date", ""
+	}
 }
 
 // runSyncStatus shows the sync pool status.
@@ -383,6 +689,12 @@ func runSyncStatus(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Fprintf(out, "Auto-sync: %s\n", autoSyncStatus)
 
+	if kr, err := sync.LoadPoolKeyring(); err == nil && kr.Enabled() {
+		fmt.Fprintf(out, "Encryption: pool key %s\n", kr.CurrentKey().ID)
+	} else {
+		fmt.Fprintln(out, "Encryption: off (enable with 'caam sync encrypt')")
+	}
+
 	// Last full sync
 	if !state.Pool.LastFullSync.IsZero() {
 		fmt.Fprintf(out, "Last full sync: %s\n", formatTimeAgo(state.Pool.LastFullSync))
@@ -409,6 +721,9 @@ func runSyncStatus(cmd *cobra.Command, args []string) error {
 			if !m.LastSync.IsZero() {
 				lastSync = formatTimeAgo(m.LastSync)
 			}
+			if sync.PendingWipeOrder(m) != nil || !m.WipeRequestedAt.IsZero() {
+				lastSync += " (wipe pending, sync refused)"
+			}
 			fmt.Fprintf(out, "  %-15s %-20s %-10s %s\n", m.Name, m.Address, status, lastSync)
 		}
 	}
@@ -417,13 +732,10 @@ func runSyncStatus(cmd *cobra.Command, args []string) error {
 
 	// Queue and history stats
 	queueCount := 0
-	historyCount := 0
 	if state.Queue != nil {
 		queueCount = len(state.Queue.Entries)
 	}
-	if state.History != nil {
-		historyCount = len(state.History.Entries)
-	}
+	historyCount := state.HistoryCount()
 	fmt.Fprintf(out, "Queue: %d pending | History: %d entries\n", queueCount, historyCount)
 
 	return nil
@@ -444,9 +756,17 @@ func runSyncAdd(cmd *cobra.Command, args []string) error {
 	sshKeyPath, _ := cmd.Flags().GetString("key")
 	remotePath, _ := cmd.Flags().GetString("remote-path")
 	testAfter, _ := cmd.Flags().GetBool("test")
+	transport, _ := cmd.Flags().GetString("transport")
+	if !sync.ValidTransport(transport) {
+		return fmt.Errorf("invalid transport %q (valid: ssh, https)", transport)
+	}
 
-	// Parse user from address if present
-	if strings.Contains(address, "@") {
+	// HTTPS machines are addressed by URL, not user@host:port
+	if transport == sync.TransportHTTPS {
+		if _, err := sync.ParseEndpoint(address); err != nil {
+			return err
+		}
+	} else if strings.Contains(address, "@") {
 		parts := strings.SplitN(address, "@", 2)
 		if sshUser == "" {
 			sshUser = parts[0]
@@ -456,7 +776,7 @@ func runSyncAdd(cmd *cobra.Command, args []string) error {
 
 	// Parse port from address if present
 	port := sync.DefaultSSHPort
-	if strings.Contains(address, ":") {
+	if transport == sync.TransportSSH && strings.Contains(address, ":") {
 		parts := strings.Split(address, ":")
 		address = parts[0]
 		if len(parts) > 1 {
@@ -477,6 +797,10 @@ func runSyncAdd(cmd *cobra.Command, args []string) error {
 	machine.SSHKeyPath = sshKeyPath
 	machine.RemotePath = remotePath
 	machine.Source = sync.SourceManual
+	if transport == sync.TransportHTTPS {
+		machine.Transport = transport
+		machine.Port = 0
+	}
 
 	if err := state.Pool.AddMachine(machine); err != nil {
 		return fmt.Errorf("add machine: %w", err)
@@ -510,7 +834,7 @@ func runSyncRemove(cmd *cobra.Command, args []string) error {
 
 	machine := state.Pool.GetMachineByName(name)
 	if machine == nil {
-		return fmt.Errorf("machine %q not found in pool; run 'caam sync ls' to see available machines", name)
+		return caamerr.Errorf(caamerr.NotFound, "machine %q not found in pool; run 'caam sync ls' to see available machines", name)
 	}
 
 	force, _ := cmd.Flags().GetBool("force")
@@ -554,7 +878,7 @@ func runSyncTest(cmd *cobra.Command, args []string) error {
 		name := args[0]
 		m := state.Pool.GetMachineByName(name)
 		if m == nil {
-			return fmt.Errorf("machine %q not found in pool; run 'caam sync ls' to see available machines", name)
+			return caamerr.Errorf(caamerr.NotFound, "machine %q not found in pool; run 'caam sync ls' to see available machines", name)
 		}
 		machines = []*sync.Machine{m}
 	}
@@ -585,9 +909,10 @@ func runSyncTest(cmd *cobra.Command, args []string) error {
 }
 
 func testSyncMachine(out io.Writer, pool *sync.ConnectionPool, m *sync.Machine) bool {
+	label := strings.ToUpper(m.TransportName()) + " connection"
 	client, err := pool.Get(m)
 	if err != nil {
-		fmt.Fprintf(out, "  SSH connection: ✗ %v\n", err)
+		fmt.Fprintf(out, "  %s: ✗ %v\n", label, err)
 		return false
 	}
 
@@ -595,15 +920,15 @@ func testSyncMachine(out io.Writer, pool *sync.ConnectionPool, m *sync.Machine)
 	vaultPath := remoteVaultPath(m)
 	exists, err := client.FileExists(vaultPath)
 	if err != nil {
-		fmt.Fprintf(out, "  SSH connection: ✓ connected\n")
+		fmt.Fprintf(out, "  %s: ✓ connected\n", label)
 		fmt.Fprintf(out, "  CAAM vault: ⚠️  could not check (%v)\n", err)
 		return true
 	}
 	if exists {
-		fmt.Fprintln(out, "  SSH connection: ✓ connected")
+		fmt.Fprintf(out, "  %s: ✓ connected\n", label)
 		fmt.Fprintln(out, "  CAAM vault: ✓ found")
 	} else {
-		fmt.Fprintln(out, "  SSH connection: ✓ connected")
+		fmt.Fprintf(out, "  %s: ✓ connected\n", label)
 		fmt.Fprintln(out, "  CAAM vault: ⚠️  not found (will be created on first sync)")
 	}
 	return true
@@ -613,6 +938,9 @@ func remoteVaultPath(m *sync.Machine) string {
 	if m == nil {
 		return sync.DefaultSyncerConfig().RemoteVaultPath
 	}
+	if m.TransportName() == sync.TransportHTTPS {
+		return "vault"
+	}
 	if strings.TrimSpace(m.RemotePath) == "" {
 		return sync.DefaultSyncerConfig().RemoteVaultPath
 	}
@@ -679,31 +1007,24 @@ func runSyncLog(cmd *cobra.Command, args []string) error {
 	providerFilter, _ := cmd.Flags().GetString("provider")
 	errorsOnly, _ := cmd.Flags().GetBool("errors")
 
-	if state.History == nil || len(state.History.Entries) == 0 {
-		fmt.Fprintln(cmd.OutOrStdout(), "No sync history yet.")
-		return nil
+	filtered, err := state.QueryHistory(sync.HistoryFilter{
+		Machine:    machineFilter,
+		Provider:   providerFilter,
+		ErrorsOnly: errorsOnly,
+		Limit:      limit,
+	})
+	if err != nil {
+		return fmt.Errorf("query sync history: %w", err)
 	}
 
-	entries := state.History.Entries
-
-	// Apply filters
-	var filtered []sync.HistoryEntry
-	for _, e := range entries {
-		if machineFilter != "" && e.Machine != machineFilter {
-			continue
-		}
-		if providerFilter != "" && e.Provider != providerFilter {
-			continue
-		}
-		if errorsOnly && e.Success {
-			continue
-		}
-		filtered = append(filtered, e)
+	if len(filtered) == 0 && state.HistoryCount() == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No sync history yet.")
+		return nil
 	}
 
-	// Apply limit
-	if len(filtered) > limit {
-		filtered = filtered[len(filtered)-limit:]
+	// Show oldest first, ending with the most recent entry.
+	for i, j := 0, len(filtered)-1; i < j; i, j = i+1, j-1 {
+		filtered[i], filtered[j] = filtered[j], filtered[i]
 	}
 
 	if len(filtered) == 0 {
@@ -734,6 +1055,10 @@ func runSyncLog(cmd *cobra.Command, args []string) error {
 
 // runSyncDiscover discovers machines from SSH config.
 func runSyncDiscover(cmd *cobra.Command, args []string) error {
+	if network, _ := cmd.Flags().GetBool("network"); network {
+		return runSyncDiscoverNetwork(cmd)
+	}
+
 	machines, err := sync.DiscoverFromSSHConfig()
 	if err != nil {
 		return fmt.Errorf("discover from SSH config: %w", err)
@@ -794,6 +1119,116 @@ func runSyncDiscover(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runSyncDiscoverNetwork lists caam instances found over mDNS and Tailscale
+// and adds them to the pool with --add or after confirmation.
+func runSyncDiscoverNetwork(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	addToPool, _ := cmd.Flags().GetBool("add")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	state, err := loadSyncState()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "Looking for caam instances on the LAN and tailnet...")
+	result := sync.DiscoverNetwork(cmd.Context(), sync.NetworkDiscoveryOptions{Timeout: timeout})
+	for _, w := range result.Warnings {
+		fmt.Fprintf(out, "  Warning: %s\n", w)
+	}
+
+	if len(result.Peers) == 0 {
+		fmt.Fprintln(out, "No caam instances found.")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Other machines are only found while their daemon announces itself:")
+		fmt.Fprintln(out, "  set daemon.announce.listen: \":7899\" in config.yaml, then run 'caam daemon start'")
+		return nil
+	}
+
+	fmt.Fprintf(out, "\nDiscovered %d caam instance(s):\n\n", len(result.Peers))
+	fmt.Fprintf(out, "  %-20s %-16s %-10s %-10s %-30s %s\n", "HOSTNAME", "ADDRESS", "VIA", "VERSION", "PROFILES", "STATUS")
+
+	var newMachines []*sync.Machine
+	for _, p := range result.Peers {
+		m := p.Machine()
+		status := "not in pool"
+		if poolHasMachine(state.Pool, m) {
+			status = "already in pool"
+		} else {
+			newMachines = append(newMachines, m)
+		}
+		fmt.Fprintf(out, "  %-20s %-16s %-10s %-10s %-30s %s\n",
+			p.Hostname, p.Address, p.Source, p.Version, formatProfileCounts(p.Profiles), status)
+	}
+
+	if len(newMachines) == 0 {
+		return nil
+	}
+	if !addToPool {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Fprintln(out, "")
+			fmt.Fprintln(out, "Add them to the pool with:")
+			fmt.Fprintln(out, "  caam sync discover --network --add")
+			return nil
+		}
+		fmt.Fprintf(out, "\nAdd %d machine(s) to the sync pool? [y/N]: ", len(newMachines))
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer := strings.ToLower(strings.TrimSpace(line))
+		if answer != "y" && answer != "yes" {
+			return nil
+		}
+	}
+
+	added := 0
+	for _, m := range newMachines {
+		if err := state.Pool.AddMachine(m); err != nil {
+			fmt.Fprintf(out, "  Could not add %s: %v\n", m.Name, err)
+			continue
+		}
+		added++
+	}
+	if added > 0 {
+		if err := state.Save(); err != nil {
+			return fmt.Errorf("save state: %w", err)
+		}
+		fmt.Fprintf(out, "\nAdded %d machine(s) to sync pool.\n", added)
+		fmt.Fprintln(out, "Check SSH access with: caam sync test")
+	}
+	return nil
+}
+
+// poolHasMachine reports whether the pool already has a machine with m's
+// name or address.
+func poolHasMachine(pool *sync.SyncPool, m *sync.Machine) bool {
+	if pool.GetMachineByName(m.Name) != nil {
+		return true
+	}
+	for _, existing := range pool.ListMachines() {
+		if strings.EqualFold(existing.Address, m.Address) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatProfileCounts renders per-provider profile counts, e.g.
+// "claude:2 codex:1".
+func formatProfileCounts(counts map[string]int) string {
+	if len(counts) == 0 {
+		return "-"
+	}
+	providers := make([]string, 0, len(counts))
+	for provider := range counts {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	parts := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		parts = append(parts, fmt.Sprintf("%s:%d", provider, counts[provider]))
+	}
+	return strings.Join(parts, " ")
+}
+
 // runSyncQueue manages the retry queue.
 func runSyncQueue(cmd *cobra.Command, args []string) error {
 	state, err := loadSyncState()
@@ -891,6 +1326,7 @@ func runSyncInit(cmd *cobra.Command, args []string) error {
 
 	csvOnly, _ := cmd.Flags().GetBool("csv")
 	autoDiscover, _ := cmd.Flags().GetBool("discover")
+	encrypt, _ := cmd.Flags().GetBool("encrypt")
 
 	// CSV-only mode
 	if csvOnly {
@@ -1061,6 +1497,22 @@ func runSyncInit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if encrypt {
+		syncer, err := sync.NewSyncer(sync.DefaultSyncerConfig())
+		if err != nil {
+			return fmt.Errorf("create syncer: %w", err)
+		}
+		deliveries, err := syncer.EnablePoolEncryption()
+		syncer.Close()
+		if err != nil {
+			return fmt.Errorf("enable pool encryption: %w", err)
+		}
+		fmt.Fprintln(out, "")
+		if err := printPoolKeyDeliveries(out, false, "Pool encryption enabled", deliveries); err != nil {
+			return err
+		}
+	}
+
 	fmt.Fprintln(out, "")
 	fmt.Fprintln(out, "Setup complete!")
 
@@ -1140,11 +1592,14 @@ func runSyncStatusJSON(state *sync.SyncState, out io.Writer) error {
 		Address  string     `json:"address"`
 		Status   string     `json:"status"`
 		LastSync *time.Time `json:"last_sync,omitempty"`
+
+		WipePending bool `json:"wipe_pending,omitempty"`
 	}
 
 	type statusJSON struct {
 		LocalMachine string        `json:"local_machine,omitempty"`
 		AutoSync     bool          `json:"auto_sync"`
+		PoolKeyID    string        `json:"pool_key_id,omitempty"`
 		LastFullSync *time.Time    `json:"last_full_sync,omitempty"`
 		Machines     []machineJSON `json:"machines"`
 		QueuePending int           `json:"queue_pending"`
@@ -1160,6 +1615,10 @@ func runSyncStatusJSON(state *sync.SyncState, out io.Writer) error {
 		output.LocalMachine = state.Identity.Hostname
 	}
 
+	if kr, err := sync.LoadPoolKeyring(); err == nil && kr.Enabled() {
+		output.PoolKeyID = kr.CurrentKey().ID
+	}
+
 	if !state.Pool.LastFullSync.IsZero() {
 		t := state.Pool.LastFullSync
 		output.LastFullSync = &t
@@ -1170,6 +1629,8 @@ func runSyncStatusJSON(state *sync.SyncState, out io.Writer) error {
 			Name:    m.Name,
 			Address: m.Address,
 			Status:  m.Status,
+
+			WipePending: sync.PendingWipeOrder(m) != nil || !m.WipeRequestedAt.IsZero(),
 		}
 		if !m.LastSync.IsZero() {
 			t := m.LastSync
@@ -1181,9 +1642,7 @@ func runSyncStatusJSON(state *sync.SyncState, out io.Writer) error {
 	if state.Queue != nil {
 		output.QueuePending = len(state.Queue.Entries)
 	}
-	if state.History != nil {
-		output.HistoryCount = len(state.History.Entries)
-	}
+	output.HistoryCount = state.HistoryCount()
 
 	enc := json.NewEncoder(out)
 	enc.SetIndent("", "  ")
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// syncConflictsCmd lists profiles that changed on both sides of a sync.
+var syncConflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "List and resolve sync conflicts",
+	Long: `List profiles that were refreshed on both this machine and a peer since
+they were last in sync.
+
+Sync normally copies the fresher token over the staler one. When both copies
+changed since the last sync, caam can't tell whether either refresh
+invalidated the other, so it leaves both alone and records a conflict
+instead. Conflicted profiles are skipped by 'caam sync' until resolved.
+
+Examples:
+  caam sync conflicts                                # List conflicts
+  caam sync conflicts resolve                        # Choose interactively
+  caam sync conflicts resolve --keep freshest        # Resolve all by expiry
+  caam sync conflicts resolve claude/work --keep local --machine laptop`,
+	Args: cobra.NoArgs,
+	RunE: runSyncConflicts,
+}
+
+var syncConflictsResolveCmd = &cobra.Command{
+	Use:   "resolve [provider/profile]",
+	Short: "Resolve sync conflicts",
+	Long: `Resolve sync conflicts by keeping one side and syncing it to the other.
+
+  local     push this machine's copy to the peer
+  remote    pull the peer's copy to this machine
+  freshest  keep whichever copy has the later token expiry (re-checked now)
+
+Without --keep, you are asked for each conflict.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSyncConflictsResolve,
+}
+
+func init() {
+	syncCmd.AddCommand(syncConflictsCmd)
+	syncConflictsCmd.AddCommand(syncConflictsResolveCmd)
+
+	syncConflictsCmd.Flags().Bool("json", false, "output as JSON")
+
+	syncConflictsResolveCmd.Flags().String("keep", "", "resolution for every matching conflict: local, remote, or freshest")
+	syncConflictsResolveCmd.Flags().String("machine", "", "only resolve conflicts with this machine")
+}
+
+func runSyncConflicts(cmd *cobra.Command, args []string) error {
+	state, err := loadSyncState()
+	if err != nil {
+		return err
+	}
+	conflicts := state.ListConflicts()
+	out := cmd.OutOrStdout()
+
+	if jsonOut, _ := cmd.Flags().GetBool("json"); jsonOut {
+		if conflicts == nil {
+			conflicts = []sync.Conflict{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(conflicts)
+	}
+
+	if len(conflicts) == 0 {
+		fmt.Fprintln(out, "No sync conflicts.")
+		return nil
+	}
+
+	fmt.Fprintf(out, "Sync Conflicts: %d\n\n", len(conflicts))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  PROFILE\tMACHINE\tLOCAL EXPIRES\tREMOTE EXPIRES\tFRESHEST\tDETECTED")
+	for _, c := range conflicts {
+		fmt.Fprintf(w, "  %s/%s\t%s\t%s\t%s\t%s\t%s\n",
+			c.Provider, c.Profile, c.MachineName,
+			formatConflictExpiry(c.Local.ExpiresAt), formatConflictExpiry(c.Remote.ExpiresAt),
+			strings.TrimPrefix(string(c.Freshest()), "keep-"), formatTimeAgo(c.DetectedAt))
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Use 'caam sync conflicts resolve' to choose which copy to keep.")
+	return nil
+}
+
+func runSyncConflictsResolve(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+
+	keep, _ := cmd.Flags().GetString("keep")
+	var resolution sync.ConflictResolution
+	if keep != "" {
+		var err error
+		if resolution, err = sync.ParseConflictResolution(keep); err != nil {
+			return err
+		}
+	}
+	machineName, _ := cmd.Flags().GetString("machine")
+
+	var provider, profile string
+	if len(args) == 1 {
+		var ok bool
+		provider, profile, ok = strings.Cut(args[0], "/")
+		if !ok || provider == "" || profile == "" {
+			return fmt.Errorf("invalid profile %q: expected provider/profile", args[0])
+		}
+	}
+
+	syncer, err := sync.NewSyncer(sync.DefaultSyncerConfig())
+	if err != nil {
+		return fmt.Errorf("create syncer: %w", err)
+	}
+	defer syncer.Close()
+
+	var matched []sync.Conflict
+	for _, c := range syncer.Conflicts() {
+		if provider != "" && (c.Provider != provider || c.Profile != profile) {
+			continue
+		}
+		if machineName != "" && c.MachineName != machineName {
+			continue
+		}
+		matched = append(matched, c)
+	}
+	if len(matched) == 0 {
+		fmt.Fprintln(out, "No matching sync conflicts.")
+		return nil
+	}
+
+	reader := bufio.NewReader(cmd.InOrStdin())
+	resolved, failed := 0, 0
+	for _, c := range matched {
+		label := fmt.Sprintf("%s/%s on %s", c.Provider, c.Profile, c.MachineName)
+
+		choice := resolution
+		if choice == "" {
+			var err error
+			choice, err = promptConflictResolution(reader, out, c)
+			if err != nil {
+				return err
+			}
+			if choice == "" {
+				fmt.Fprintf(out, "  - %s: skipped\n", label)
+				continue
+			}
+		}
+
+		result, err := syncer.ResolveConflict(cmd.Context(), c, choice)
+		if err == nil && !result.Success {
+			err = result.Error
+		}
+		if err != nil {
+			fmt.Fprintf(out, "  ✗ %s: %v\n", label, err)
+			failed++
+			continue
+		}
+		switch result.Operation.Direction {
+		case sync.SyncPush:
+			fmt.Fprintf(out, "  ✓ %s: kept local (pushed)\n", label)
+		case sync.SyncPull:
+			fmt.Fprintf(out, "  ✓ %s: kept remote (pulled)\n", label)
+		default:
+			fmt.Fprintf(out, "  ✓ %s: copies already match\n", label)
+		}
+		resolved++
+	}
+
+	fmt.Fprintf(out, "\nResolved %d of %d conflict(s)", resolved, len(matched))
+	if failed > 0 {
+		fmt.Fprintf(out, ", %d failed", failed)
+	}
+	fmt.Fprintln(out)
+	if failed > 0 {
+		return fmt.Errorf("%d conflict(s) could not be resolved", failed)
+	}
+	return nil
+}
+
+// promptConflictResolution shows both copies of a conflicted profile and
+// asks which to keep. It returns "" when the user skips the conflict or
+// input ends.
+func promptConflictResolution(reader *bufio.Reader, out io.Writer, c sync.Conflict) (sync.ConflictResolution, error) {
+	fmt.Fprintf(out, "\n%s/%s changed on both this machine and %s:\n", c.Provider, c.Profile, c.MachineName)
+	fmt.Fprintf(out, "  last synced  %s\n", formatTimeAgo(c.Base.SyncedAt))
+	fmt.Fprintf(out, "  local        expires %s, modified %s\n", formatConflictExpiry(c.Local.ExpiresAt), formatConflictModified(c.Local.ModifiedAt))
+	fmt.Fprintf(out, "  remote       expires %s, modified %s\n", formatConflictExpiry(c.Remote.ExpiresAt), formatConflictModified(c.Remote.ModifiedAt))
+
+	for {
+		fmt.Fprintf(out, "Keep [l]ocal, [r]emote, [f]reshest (%s), or [s]kip? ", strings.TrimPrefix(string(c.Freshest()), "keep-"))
+		line, err := reader.ReadString('\n')
+		answer := strings.ToLower(strings.TrimSpace(line))
+		switch answer {
+		case "l", "local":
+			return sync.KeepLocal, nil
+		case "r", "remote":
+			return sync.KeepRemote, nil
+		case "f", "freshest":
+			return sync.KeepFreshest, nil
+		case "s", "skip":
+			return "", nil
+		}
+		if err != nil {
+			if err == io.EOF {
+				fmt.Fprintln(out)
+				return "", nil
+			}
+			return "", err
+		}
+	}
+}
+
+func formatConflictExpiry(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatConflictModified(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return formatTimeAgo(t)
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+var syncEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt profiles sent to other machines in the pool",
+	Long: `Seal profiles with a pool-shared key before they're written to another
+machine over SSH, so hubs and backup hosts that don't hold the key only ever
+store ciphertext.
+
+The first run creates the pool key and sends it to every SSH machine in the
+pool, wrapped for that machine's X25519 exchange key; nothing secret crosses
+the wire in the clear. Machines added later receive it on their first sync.
+The key is signed with your SSH key (~/.ssh/id_ed25519 or id_rsa), and a
+machine only accepts keys signed by a key in its trusted_keys file, falling
+back to ~/.ssh/authorized_keys, the same trust list 'caam fleet wipe' uses.
+A machine that pulls a profile sealed with a key it doesn't have keeps the
+ciphertext and passes it on unchanged.
+
+HTTPS endpoints and the sync hub are already encrypted with the sync
+passphrase and are unaffected.
+
+--disable stops this machine sealing what it pushes. Keys are kept, so
+profiles sealed earlier stay readable. Keys delivered later are stored but
+don't turn sealing back on; run 'caam sync encrypt' again for that.
+
+Examples:
+  caam sync encrypt
+  caam sync encrypt --disable
+  caam sync rekey`,
+	Args: cobra.NoArgs,
+	RunE: runSyncEncrypt,
+}
+
+var syncRekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "Rotate the pool encryption key",
+	Long: `Replace the pool encryption key, send the new key to every SSH machine in
+the pool, and push every local profile again so each machine's copy is sealed
+with the new key.
+
+Rekey after removing a machine from the pool: the removed machine keeps the
+old key, but never receives the new one. Old keys are kept to read profiles
+that haven't been rewritten yet.
+
+Examples:
+  caam sync rekey
+  caam sync rekey --json`,
+	Args: cobra.NoArgs,
+	RunE: runSyncRekey,
+}
+
+func init() {
+	syncCmd.AddCommand(syncEncryptCmd)
+	syncCmd.AddCommand(syncRekeyCmd)
+
+	syncEncryptCmd.Flags().Bool("disable", false, "stop encrypting profiles this machine pushes")
+	syncEncryptCmd.Flags().Bool("json", false, "output as JSON")
+	syncRekeyCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+func runSyncEncrypt(cmd *cobra.Command, args []string) error {
+	disable, _ := cmd.Flags().GetBool("disable")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	out := cmd.OutOrStdout()
+
+	if disable {
+		kr, err := sync.LoadPoolKeyring()
+		if err != nil {
+			return err
+		}
+		kr.Disable()
+		if err := kr.Save(); err != nil {
+			return err
+		}
+		if jsonOut {
+			return json.NewEncoder(out).Encode(map[string]interface{}{"encryption": false})
+		}
+		fmt.Fprintln(out, "Pool encryption disabled on this machine; profiles it pushes are no longer sealed.")
+		return nil
+	}
+
+	syncer, err := sync.NewSyncer(sync.DefaultSyncerConfig())
+	if err != nil {
+		return fmt.Errorf("create syncer: %w", err)
+	}
+	defer syncer.Close()
+
+	deliveries, err := syncer.EnablePoolEncryption()
+	if err != nil {
+		return err
+	}
+	return printPoolKeyDeliveries(out, jsonOut, "Pool encryption enabled", deliveries)
+}
+
+func runSyncRekey(cmd *cobra.Command, args []string) error {
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	out := cmd.OutOrStdout()
+
+	syncer, err := sync.NewSyncer(sync.DefaultSyncerConfig())
+	if err != nil {
+		return fmt.Errorf("create syncer: %w", err)
+	}
+	defer syncer.Close()
+
+	deliveries, err := syncer.Rekey()
+	if err != nil {
+		return err
+	}
+	return printPoolKeyDeliveries(out, jsonOut, "Pool key rotated", deliveries)
+}
+
+// printPoolKeyDeliveries reports where a pool key was sent.
+func printPoolKeyDeliveries(out io.Writer, jsonOut bool, headline string, deliveries []sync.PoolKeyDelivery) error {
+	kr, err := sync.LoadPoolKeyring()
+	if err != nil {
+		return err
+	}
+	keyID := ""
+	if k := kr.CurrentKey(); k != nil {
+		keyID = k.ID
+	}
+
+	if jsonOut {
+		if deliveries == nil {
+			deliveries = []sync.PoolKeyDelivery{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			KeyID      string                 `json:"key_id"`
+			Deliveries []sync.PoolKeyDelivery `json:"deliveries"`
+		}{keyID, deliveries})
+	}
+
+	fmt.Fprintf(out, "%s (key %s)\n", headline, keyID)
+	failed := 0
+	for _, d := range deliveries {
+		if d.Error != "" {
+			fmt.Fprintf(out, "  %-20s pending (%s)\n", d.Machine, d.Error)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "  %-20s delivered\n", d.Machine)
+	}
+	if failed > 0 {
+		fmt.Fprintln(out, "Undelivered keys are sent on the next sync with each machine.")
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/hub"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// hubMachineName labels hub syncs in sync history.
+const hubMachineName = "hub"
+
+// syncHubCmd is the parent command for object storage hub sync.
+var syncHubCmd = &cobra.Command{
+	Use:   "hub",
+	Short: "Sync through a cloud storage bucket",
+	Long: `Sync profiles through an S3, GCS, or Backblaze B2 bucket instead of over SSH.
+
+Every machine pushes to and pulls from the bucket, so machines behind NAT or
+on different networks never need to reach each other. Profiles are encrypted
+on this machine before upload; the bucket only ever holds ciphertext. Each
+push writes a new version and old versions are pruned (--keep).
+
+Credentials come from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY (HMAC keys
+for GCS and B2). The encryption passphrase comes from CAAM_SYNC_PASSPHRASE or
+the passphrase file in the sync data directory; use the same passphrase on
+every machine.
+
+Once a hub is configured, 'caam sync' syncs with it too.
+
+Examples:
+  caam sync hub init s3://my-bucket/caam
+  caam sync hub init gs://my-bucket/caam
+  caam sync hub init b2://my-bucket/caam --endpoint https://s3.us-west-004.backblazeb2.com
+  caam sync hub status
+  caam sync hub sync`,
+}
+
+var syncHubInitCmd = &cobra.Command{
+	Use:   "init <url>",
+	Short: "Configure the sync hub bucket",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSyncHubInit,
+}
+
+var syncHubStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show profiles stored in the hub",
+	Args:  cobra.NoArgs,
+	RunE:  runSyncHubStatus,
+}
+
+var syncHubSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync with the hub now",
+	Args:  cobra.NoArgs,
+	RunE:  runSyncHubSync,
+}
+
+func init() {
+	syncCmd.AddCommand(syncHubCmd)
+	syncHubCmd.AddCommand(syncHubInitCmd)
+	syncHubCmd.AddCommand(syncHubStatusCmd)
+	syncHubCmd.AddCommand(syncHubSyncCmd)
+
+	syncHubInitCmd.Flags().String("endpoint", "", "storage API URL (required for B2; MinIO and other S3-compatible services)")
+	syncHubInitCmd.Flags().String("region", "", "bucket region (default: AWS_REGION or us-east-1)")
+	syncHubInitCmd.Flags().Int("keep", hub.DefaultKeepVersions, "versions to keep per profile")
+
+	syncHubStatusCmd.Flags().Bool("json", false, "output as JSON")
+	syncHubSyncCmd.Flags().Bool("json", false, "output results as JSON")
+}
+
+func runSyncHubInit(cmd *cobra.Command, args []string) error {
+	endpoint, _ := cmd.Flags().GetString("endpoint")
+	region, _ := cmd.Flags().GetString("region")
+	keep, _ := cmd.Flags().GetInt("keep")
+	if keep < 1 {
+		return fmt.Errorf("--keep must be at least 1")
+	}
+
+	cfg := &hub.Config{URL: args[0], Endpoint: endpoint, Region: region, KeepVersions: keep}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	h, err := hub.Open(cfg, authfile.DefaultVaultPath())
+	if err != nil {
+		return err
+	}
+	remote, err := h.Remote(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("check bucket access: %w", err)
+	}
+
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("save hub config: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Sync hub set to %s (%d profile(s) stored).\n", cfg.URL, len(remote))
+	fmt.Fprintln(cmd.OutOrStdout(), "Run 'caam sync hub sync' or 'caam sync' to sync now.")
+	return nil
+}
+
+// loadHub opens the configured hub. It returns (nil, nil, nil) when no hub
+// is configured.
+func loadHub() (*hub.Config, *hub.Hub, error) {
+	cfg, err := hub.LoadConfig()
+	if err != nil || cfg == nil {
+		return nil, nil, err
+	}
+	h, err := hub.Open(cfg, authfile.DefaultVaultPath())
+	if err != nil {
+		return cfg, nil, err
+	}
+	return cfg, h, nil
+}
+
+func requireHub() (*hub.Config, *hub.Hub, error) {
+	cfg, h, err := loadHub()
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg == nil {
+		return nil, nil, fmt.Errorf("no sync hub configured; run 'caam sync hub init <url>'")
+	}
+	return cfg, h, nil
+}
+
+func runSyncHubStatus(cmd *cobra.Command, args []string) error {
+	cfg, h, err := requireHub()
+	if err != nil {
+		return err
+	}
+	remote, err := h.Remote(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("list hub: %w", err)
+	}
+
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	if jsonOut {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			URL      string                       `json:"url"`
+			Keep     int                          `json:"keep_versions"`
+			Profiles map[string]hub.RemoteProfile `json:"profiles"`
+		}{cfg.URL, cfg.Keep(), remote})
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Hub: %s (keeping %d versions)\n\n", cfg.URL, cfg.Keep())
+	if len(remote) == 0 {
+		fmt.Fprintln(out, "No profiles stored yet. Run 'caam sync hub sync' to push this machine's profiles.")
+		return nil
+	}
+
+	names := make([]string, 0, len(remote))
+	for name := range remote {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tUPLOADED\tFROM\tEXPIRES\tVERSIONS")
+	for _, name := range names {
+		rp := remote[name]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n",
+			name,
+			formatTimeAgo(rp.Latest.UploadedAt),
+			rp.Latest.MachineName,
+			formatConflictExpiry(rp.Latest.ExpiresAt),
+			rp.Versions)
+	}
+	return w.Flush()
+}
+
+func runSyncHubSync(cmd *cobra.Command, args []string) error {
+	_, h, err := requireHub()
+	if err != nil {
+		return err
+	}
+
+	results, err := syncWithHub(cmd, h)
+	if err != nil {
+		return err
+	}
+
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	if jsonOut {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	printHubResults(cmd.OutOrStdout(), results)
+	return nil
+}
+
+// runHubFromSync opens the configured hub and syncs with it.
+func runHubFromSync(cmd *cobra.Command, cfg *hub.Config) ([]hub.Result, error) {
+	h, err := hub.Open(cfg, authfile.DefaultVaultPath())
+	if err != nil {
+		return nil, err
+	}
+	return syncWithHub(cmd, h)
+}
+
+// syncWithHub syncs with the hub and records the results in sync history.
+func syncWithHub(cmd *cobra.Command, h *hub.Hub) ([]hub.Result, error) {
+	start := time.Now()
+	results, err := h.Sync(cmd.Context())
+	if err != nil {
+		return results, fmt.Errorf("hub sync: %w", err)
+	}
+
+	state, err := loadSyncState()
+	if err != nil {
+		return results, nil
+	}
+	elapsed := time.Since(start)
+	for _, r := range results {
+		if r.Action == hub.ActionSkip {
+			continue
+		}
+		state.AddToHistory(sync.HistoryEntry{
+			Timestamp: time.Now(),
+			Trigger:   "manual",
+			Provider:  r.Provider,
+			Profile:   r.Profile,
+			Machine:   hubMachineName,
+			Action:    r.Action,
+			Success:   r.Error == "",
+			Error:     r.Error,
+			Duration:  elapsed,
+		})
+	}
+	return results, nil
+}
+
+// printHubResults prints one line per profile, in the style of 'caam sync'.
+func printHubResults(out io.Writer, results []hub.Result) {
+	if len(results) == 0 {
+		fmt.Fprintln(out, "    ✓ No profiles to sync")
+		return
+	}
+	for _, r := range results {
+		profile := r.Provider + "/" + r.Profile
+		switch {
+		case r.Error != "":
+			fmt.Fprintf(out, "    ✗ %s: %s\n", profile, r.Error)
+		case r.Action == hub.ActionPush:
+			fmt.Fprintf(out, "    ✓ %s: pushed (local fresher)\n", profile)
+		case r.Action == hub.ActionPull:
+			fmt.Fprintf(out, "    ✓ %s: pulled (hub fresher)\n", profile)
+		default:
+			fmt.Fprintf(out, "    ✓ %s: up to date\n", profile)
+		}
+	}
+}
+
+// hubSyncResults converts hub results for the 'caam sync' summary.
+func hubSyncResults(results []hub.Result) []*sync.SyncResult {
+	out := make([]*sync.SyncResult, 0, len(results))
+	for _, r := range results {
+		dir := sync.SyncSkip
+		switch r.Action {
+		case hub.ActionPush:
+			dir = sync.SyncPush
+		case hub.ActionPull:
+			dir = sync.SyncPull
+		}
+		res := &sync.SyncResult{
+			Operation: &sync.SyncOperation{Provider: r.Provider, Profile: r.Profile, Direction: dir},
+			Success:   r.Error == "",
+		}
+		if r.Error != "" {
+			res.Error = fmt.Errorf("%s", r.Error)
+		}
+		out = append(out, res)
+	}
+	return out
+}
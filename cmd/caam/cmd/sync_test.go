@@ -1,11 +1,21 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"golang.org/x/net/webdav"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
 )
 
@@ -75,6 +85,8 @@ func TestSyncCommands(t *testing.T) {
 		"discover",
 		"queue",
 		"edit",
+		"conflicts",
+		"hub",
 	}
 
 	for _, name := range subcommands {
@@ -102,6 +114,7 @@ func TestSyncCmdFlags(t *testing.T) {
 		"dry-run",
 		"force",
 		"json",
+		"yes",
 	}
 
 	for _, flag := range flags {
@@ -218,3 +231,154 @@ func TestRemoteVaultPath(t *testing.T) {
 		t.Fatalf("remoteVaultPath(custom) = %q, want %q", got, "/data/caam/vault")
 	}
 }
+
+func TestPromptConflictResolution(t *testing.T) {
+	c := sync.Conflict{
+		Provider:    "claude",
+		Profile:     "work",
+		MachineName: "laptop",
+		Local:       sync.TokenFreshness{ExpiresAt: time.Now().Add(time.Hour)},
+		Remote:      sync.TokenFreshness{ExpiresAt: time.Now().Add(2 * time.Hour)},
+	}
+
+	tests := []struct {
+		input string
+		want  sync.ConflictResolution
+	}{
+		{"l\n", sync.KeepLocal},
+		{"remote\n", sync.KeepRemote},
+		{"what\nf\n", sync.KeepFreshest},
+		{"s\n", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		got, err := promptConflictResolution(bufio.NewReader(strings.NewReader(tt.input)), &out, c)
+		if err != nil {
+			t.Fatalf("input %q: %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Errorf("input %q: got %q, want %q", tt.input, got, tt.want)
+		}
+		if !strings.Contains(out.String(), "[f]reshest (remote)") {
+			t.Errorf("prompt missing freshest hint: %q", out.String())
+		}
+	}
+}
+
+func TestSyncJSONPartialFailure(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	t.Setenv("CAAM_SYNC_PASSPHRASE", "shared")
+
+	dav := httptest.NewServer(&webdav.Handler{FileSystem: webdav.NewMemFS(), LockSystem: webdav.NewMemLS()})
+	defer dav.Close()
+	down := httptest.NewServer(nil)
+	down.Close()
+
+	profileDir := filepath.Join(authfile.DefaultVaultPath(), "claude", "work")
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	creds := fmt.Sprintf(`{"claudeAiOauth":{"accessToken":"tok","expiresAt":%d}}`, time.Now().Add(time.Hour).UnixMilli())
+	if err := os.WriteFile(filepath.Join(profileDir, ".credentials.json"), []byte(creds), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := loadSyncState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, url := range map[string]string{"a-dav": dav.URL + "/caam", "b-down": down.URL} {
+		m := sync.NewMachine(name, url)
+		m.Transport = sync.TransportHTTPS
+		if err := state.Pool.AddMachine(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := state.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	syncCmd.SetOut(&out)
+	t.Cleanup(func() {
+		syncCmd.SetOut(nil)
+		syncCmd.Flags().Set("json", "false")
+		syncCmd.Flags().Set("machine", "")
+	})
+	if err := syncCmd.Flags().Set("json", "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = runSync(syncCmd, nil)
+	if caamerr.CodeOf(err) != caamerr.PartialSuccess || ExitCode(err) != 2 {
+		t.Fatalf("err = %v (exit %d), want PARTIAL_SUCCESS", err, ExitCode(err))
+	}
+	var run SyncRunResult
+	if err := json.Unmarshal(out.Bytes(), &run); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if run.Status != "partial" || run.Pushed != 1 || len(run.Machines) != 2 {
+		t.Fatalf("run = %+v", run)
+	}
+	ok, failed := run.Machines[0], run.Machines[1]
+	if ok.Status != "ok" || len(ok.Profiles) != 1 || ok.Profiles[0].Action != "push" || ok.Profiles[0].Profile != "work" || ok.Profiles[0].BytesSent != int64(len(creds)) {
+		t.Errorf("a-dav = %+v", ok)
+	}
+	if failed.Status != "failed" || failed.Error == "" || len(failed.Profiles) != 0 {
+		t.Errorf("b-down = %+v", failed)
+	}
+
+	// The dead machine alone is a total failure; the live one alone is
+	// success.
+	out.Reset()
+	if err := syncCmd.Flags().Set("machine", "b-down"); err != nil {
+		t.Fatal(err)
+	}
+	err = runSync(syncCmd, nil)
+	if caamerr.CodeOf(err) != caamerr.SyncFailed {
+		t.Fatalf("err = %v, want SYNC_FAILED", err)
+	}
+	run = SyncRunResult{}
+	if err := json.Unmarshal(out.Bytes(), &run); err != nil || run.Status != "failed" {
+		t.Fatalf("run = %+v (%v)", run, err)
+	}
+
+	out.Reset()
+	if err := syncCmd.Flags().Set("machine", "a-dav"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runSync(syncCmd, nil); err != nil {
+		t.Fatalf("sync with a-dav: %v", err)
+	}
+	run = SyncRunResult{}
+	if err := json.Unmarshal(out.Bytes(), &run); err != nil || run.Status != "ok" || run.Failed != 0 {
+		t.Fatalf("run = %+v (%v)", run, err)
+	}
+}
+
+func TestFormatProfileCounts(t *testing.T) {
+	if got := formatProfileCounts(nil); got != "-" {
+		t.Errorf("formatProfileCounts(nil) = %q", got)
+	}
+	got := formatProfileCounts(map[string]int{"codex": 1, "claude": 3})
+	if got != "claude:3 codex:1" {
+		t.Errorf("formatProfileCounts = %q", got)
+	}
+}
+
+func TestPoolHasMachine(t *testing.T) {
+	pool := sync.NewSyncPool()
+	if err := pool.AddMachine(sync.NewMachine("desk", "192.168.1.5")); err != nil {
+		t.Fatal(err)
+	}
+	if !poolHasMachine(pool, sync.NewMachine("other", "192.168.1.5")) {
+		t.Error("same address not detected")
+	}
+	if !poolHasMachine(pool, sync.NewMachine("desk", "100.64.0.1")) {
+		t.Error("same name not detected")
+	}
+	if poolHasMachine(pool, sync.NewMachine("cloud", "100.64.0.2")) {
+		t.Error("new machine reported as in pool")
+	}
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+// syncWatchCmd pushes token refreshes to the pool as they happen.
+var syncWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Push refreshed tokens to the sync pool as they happen",
+	Long: `Watch the CLIs' live auth files and the vault, and sync changes to every
+machine in the pool within seconds.
+
+When a CLI refreshes its own token, the live auth files stop matching the
+vault profile they were activated from. 'caam sync watch' saves the fresh
+token back to that profile, then pushes it. It never saves a login to a
+profile that belongs to a different account. Any other change to a profile's
+auth files in the vault, such as 'caam backup' or 'caam refresh', is pushed
+too.
+
+Runs until interrupted. To run it in the background instead, set
+daemon.sync_watch: true in config.yaml and start 'caam daemon'.
+
+Examples:
+  caam sync watch
+  caam sync watch --provider claude --debounce 5s
+  caam sync watch --json`,
+	Args: cobra.NoArgs,
+	RunE: runSyncWatch,
+}
+
+func init() {
+	syncCmd.AddCommand(syncWatchCmd)
+
+	syncWatchCmd.Flags().StringSlice("provider", nil, "only watch these providers (default: claude, codex, gemini)")
+	syncWatchCmd.Flags().Duration("debounce", sync.DefaultWatchDebounce, "wait this long after the last change before acting")
+	syncWatchCmd.Flags().Bool("json", false, "print one JSON object per event")
+}
+
+// syncWatchEvent is one line of 'caam sync watch --json'.
+type syncWatchEvent struct {
+	Time     time.Time          `json:"time"`
+	Provider string             `json:"provider,omitempty"`
+	Profile  string             `json:"profile,omitempty"`
+	Action   string             `json:"action"`
+	Message  string             `json:"message,omitempty"`
+	Error    string             `json:"error,omitempty"`
+	Machines []syncWatchMachine `json:"machines,omitempty"`
+}
+
+// syncWatchMachine is what a push did on one machine.
+type syncWatchMachine struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+func runSyncWatch(cmd *cobra.Command, args []string) error {
+	providers, _ := cmd.Flags().GetStringSlice("provider")
+	debounce, _ := cmd.Flags().GetDuration("debounce")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	for i, p := range providers {
+		providers[i] = strings.ToLower(strings.TrimSpace(p))
+		if _, ok := authfile.GetAuthFileSet(providers[i]); !ok {
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider %q", p)
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if !jsonOutput {
+		if state, err := loadSyncState(); err == nil && len(state.Pool.ListMachines()) == 0 {
+			fmt.Fprintln(out, "No machines in sync pool; refreshed tokens are saved to the vault only.")
+		}
+		fmt.Fprintln(out, "Watching for token refreshes. Press Ctrl+C to stop.")
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return sync.Watch(ctx, sync.WatchConfig{
+		Vault:     authfile.NewVault(authfile.DefaultVaultPath()),
+		Providers: providers,
+		Debounce:  debounce,
+		OnEvent: func(e sync.WatchEvent) {
+			printSyncWatchEvent(out, e, jsonOutput)
+		},
+	})
+}
+
+// printSyncWatchEvent writes one watch event as a JSON line or a
+// human-readable line.
+func printSyncWatchEvent(out io.Writer, e sync.WatchEvent, jsonOutput bool) {
+	line := syncWatchEvent{
+		Time:     e.Time,
+		Provider: e.Provider,
+		Profile:  e.Profile,
+		Action:   e.Action,
+		Message:  e.Message,
+	}
+	if e.Err != nil {
+		line.Error = e.Err.Error()
+	}
+	for _, r := range e.Results {
+		if r.Operation == nil || r.Operation.Machine == nil {
+			continue
+		}
+		p := syncProfileResult(sync.ProfileRef{Provider: e.Provider, Profile: e.Profile}, r)
+		line.Machines = append(line.Machines, syncWatchMachine{Name: r.Operation.Machine.Name, Action: p.Action, Error: p.Error})
+	}
+
+	if jsonOutput {
+		_ = json.NewEncoder(out).Encode(line)
+		return
+	}
+	target := "-"
+	if e.Provider != "" {
+		target = e.Provider + "/" + e.Profile
+		if e.Profile == "" {
+			target = e.Provider
+		}
+	}
+	msg := e.Message
+	if line.Error != "" {
+		msg += ": " + line.Error
+	}
+	fmt.Fprintf(out, "%s  %-9s  %s  %s\n", e.Time.Local().Format("15:04:05"), e.Action, target, msg)
+	for _, m := range line.Machines {
+		if m.Error != "" {
+			fmt.Fprintf(out, "           ✗ %s: %s\n", m.Name, m.Error)
+		}
+	}
+}
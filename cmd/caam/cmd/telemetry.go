@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/telemetry"
+)
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Manage opt-in anonymous usage counters",
+	Long: `Telemetry is OFF by default. When you opt in, caam counts which commands
+you use (for example "caam activate": 12). Only command names and counts are
+recorded - never arguments, profile names, emails, paths, or tokens.
+
+Counters are aggregated locally and nothing is uploaded until you run
+"caam telemetry send". Run "caam telemetry preview" first to see the exact
+payload. Disabling telemetry deletes the counters and the anonymous install ID.
+
+CAAM_TELEMETRY=off or DO_NOT_TRACK=1 turn telemetry off regardless of the
+stored setting.
+
+Examples:
+  caam telemetry status
+  caam telemetry enable --endpoint https://telemetry.example.com/caam
+  caam telemetry preview
+  caam telemetry send
+  caam telemetry disable`,
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether telemetry is enabled",
+	Args:  cobra.NoArgs,
+	RunE:  runTelemetryStatus,
+}
+
+var telemetryEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Opt in to anonymous usage counters",
+	Args:  cobra.NoArgs,
+	RunE:  runTelemetryEnable,
+}
+
+var telemetryDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Opt out and delete collected counters",
+	Args:  cobra.NoArgs,
+	RunE:  runTelemetryDisable,
+}
+
+var telemetryPreviewCmd = &cobra.Command{
+	Use:   "preview",
+	Short: "Print the exact payload that would be uploaded",
+	Args:  cobra.NoArgs,
+	RunE:  runTelemetryPreview,
+}
+
+var telemetrySendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Upload the aggregated counters",
+	Args:  cobra.NoArgs,
+	RunE:  runTelemetrySend,
+}
+
+func init() {
+	rootCmd.AddCommand(telemetryCmd)
+	telemetryCmd.AddCommand(telemetryStatusCmd)
+	telemetryCmd.AddCommand(telemetryEnableCmd)
+	telemetryCmd.AddCommand(telemetryDisableCmd)
+	telemetryCmd.AddCommand(telemetryPreviewCmd)
+	telemetryCmd.AddCommand(telemetrySendCmd)
+
+	telemetryStatusCmd.Flags().Bool("json", false, "output as JSON")
+	telemetryEnableCmd.Flags().String("endpoint", "", "HTTPS URL that 'caam telemetry send' uploads to")
+}
+
+func runTelemetryStatus(cmd *cobra.Command, args []string) error {
+	state, err := telemetry.Load()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, n := range state.Counters {
+		total += n
+	}
+
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	if jsonOut {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"enabled":    state.Enabled,
+			"active":     state.Active(),
+			"forced_off": telemetry.ForcedOff(),
+			"endpoint":   state.UploadEndpoint(),
+			"events":     total,
+			"last_sent":  state.LastSent,
+			"state_path": telemetry.Path(),
+		})
+	}
+
+	out := cmd.OutOrStdout()
+	switch {
+	case state.Enabled && telemetry.ForcedOff():
+		fmt.Fprintf(out, "Telemetry: enabled, but forced off by %s or DO_NOT_TRACK\n", telemetry.EnvVar)
+	case state.Enabled:
+		fmt.Fprintln(out, "Telemetry: enabled")
+	default:
+		fmt.Fprintln(out, "Telemetry: disabled")
+		return nil
+	}
+	if state.UploadEndpoint() != "" {
+		fmt.Fprintf(out, "Endpoint: %s\n", state.UploadEndpoint())
+	} else {
+		fmt.Fprintln(out, "Endpoint: not configured (nothing can be sent)")
+	}
+	fmt.Fprintf(out, "Events since %s: %d\n", state.PeriodStart.Local().Format(time.RFC3339), total)
+	if !state.LastSent.IsZero() {
+		fmt.Fprintf(out, "Last sent: %s\n", formatTimeAgo(state.LastSent))
+	}
+	return nil
+}
+
+func runTelemetryEnable(cmd *cobra.Command, args []string) error {
+	endpoint, _ := cmd.Flags().GetString("endpoint")
+	state, err := telemetry.Load()
+	if err != nil {
+		return err
+	}
+	if err := state.Enable(endpoint); err != nil {
+		return err
+	}
+	if err := state.Save(); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, "Telemetry enabled. Only command names and counts are recorded.")
+	fmt.Fprintln(out, "Nothing is uploaded until you run 'caam telemetry send'; review it with 'caam telemetry preview'.")
+	if telemetry.ForcedOff() {
+		fmt.Fprintf(out, "Note: %s or DO_NOT_TRACK is set, so nothing will be collected.\n", telemetry.EnvVar)
+	}
+	return nil
+}
+
+func runTelemetryDisable(cmd *cobra.Command, args []string) error {
+	state, err := telemetry.Load()
+	if err != nil {
+		return err
+	}
+	state.Disable()
+	if err := state.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "Telemetry disabled. Collected counters and the install ID were deleted.")
+	return nil
+}
+
+func runTelemetryPreview(cmd *cobra.Command, args []string) error {
+	state, err := telemetry.Load()
+	if err != nil {
+		return err
+	}
+	if !state.Enabled {
+		fmt.Fprintln(cmd.OutOrStdout(), "Telemetry is disabled; there is nothing to send.")
+		return nil
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(state.Payload(time.Now()))
+}
+
+func runTelemetrySend(cmd *cobra.Command, args []string) error {
+	state, err := telemetry.Load()
+	if err != nil {
+		return err
+	}
+	payload, err := state.Send(cmd.Context(), nil)
+	if err != nil {
+		return err
+	}
+	if err := state.Save(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Sent %d feature counters to %s\n", len(payload.Counters), state.UploadEndpoint())
+	return nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/tracing"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/version"
+)
+
+// commandTrace is this invocation's root span, started by startCommandTrace.
+var commandTrace struct {
+	span     trace.Span
+	shutdown func(context.Context) error
+}
+
+// startCommandTrace starts exporting spans if tracing is enabled and opens
+// the command's root span in cmd's context, so the spans commands start
+// from cmd.Context() nest under it. Like command logs, it only runs in the
+// caam binary.
+func startCommandTrace(cmd *cobra.Command, spmCfg *config.SPMConfig) {
+	if !commandLogging || commandTrace.span != nil || spmCfg == nil || !spmCfg.Tracing.Enabled {
+		return
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	shutdown, err := tracing.Setup(ctx, tracing.Options{
+		Endpoint:       spmCfg.Tracing.Endpoint,
+		Insecure:       spmCfg.Tracing.Insecure,
+		Headers:        spmCfg.Tracing.Headers,
+		SampleRatio:    spmCfg.Tracing.SampleRatio,
+		ServiceVersion: version.Version,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: tracing disabled: %v\n", err)
+		return
+	}
+	ctx, span := tracing.Start(ctx, cmd.CommandPath(), attribute.String("caam.run_id", commandRunID()))
+	cmd.SetContext(ctx)
+	commandTrace.span, commandTrace.shutdown = span, shutdown
+}
+
+// finishCommandTrace ends the root span and flushes spans to the collector.
+func finishCommandTrace(err error) {
+	if commandTrace.span == nil {
+		return
+	}
+	tracing.End(commandTrace.span, err)
+	ctx, cancel := context.WithTimeout(context.Background(), tracing.ShutdownTimeout)
+	defer cancel()
+	if shutdownErr := commandTrace.shutdown(ctx); shutdownErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: export traces: %v\n", shutdownErr)
+	}
+	commandTrace.span, commandTrace.shutdown = nil, nil
+}
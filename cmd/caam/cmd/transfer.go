@@ -18,6 +18,8 @@ import (
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/progress"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/version"
 )
 
@@ -78,6 +80,9 @@ type importOptions struct {
 	Force     bool
 	AsTool    string
 	AsProfile string
+
+	// Progress, if non-nil, receives per-file and per-profile events.
+	Progress *progress.Reporter
 }
 
 type importTarget struct {
@@ -90,7 +95,7 @@ type importTarget struct {
 
 func resolveExportTargets(v *authfile.Vault, req exportRequest) ([]exportTarget, error) {
 	if v == nil {
-		return nil, fmt.Errorf("vault not initialized")
+		return nil, caamerr.Errorf(caamerr.VaultError, "vault not initialized")
 	}
 
 	switch {
@@ -139,7 +144,7 @@ func resolveExportTargets(v *authfile.Vault, req exportRequest) ([]exportTarget,
 			return nil, fmt.Errorf("tool cannot be empty")
 		}
 		if _, ok := tools[tool]; !ok {
-			return nil, fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+			return nil, caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 		}
 
 		profiles, err := v.List(tool)
@@ -165,13 +170,13 @@ func resolveExportTargets(v *authfile.Vault, req exportRequest) ([]exportTarget,
 			return nil, fmt.Errorf("tool and profile are required")
 		}
 		if _, ok := tools[tool]; !ok {
-			return nil, fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+			return nil, caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", tool)
 		}
 
 		dirPath := v.ProfilePath(tool, profile)
 		if st, err := os.Stat(dirPath); err != nil || !st.IsDir() {
 			if os.IsNotExist(err) {
-				return nil, fmt.Errorf("profile %s/%s not found in vault; run 'caam ls %s' to see available profiles", tool, profile, tool)
+				return nil, caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s not found in vault; run 'caam ls %s' to see available profiles", tool, profile, tool)
 			}
 			if err != nil {
 				return nil, fmt.Errorf("stat profile: %w", err)
@@ -262,7 +267,7 @@ func buildExportManifest(targets []exportTarget) (*vaultExportManifest, []export
 	return manifest, allFiles, nil
 }
 
-func writeExportArchive(w io.Writer, manifest *vaultExportManifest, files []exportFileSpec) error {
+func writeExportArchive(w io.Writer, manifest *vaultExportManifest, files []exportFileSpec, prog *progress.Reporter) error {
 	if w == nil {
 		return fmt.Errorf("writer is nil")
 	}
@@ -288,6 +293,7 @@ func writeExportArchive(w io.Writer, manifest *vaultExportManifest, files []expo
 		return err
 	}
 
+	prog.Phase("write", len(files))
 	for _, f := range files {
 		if f.Size > maxFileBytes {
 			return fmt.Errorf("refusing to export unusually large file (%d bytes): %s", f.Size, f.SrcPath)
@@ -315,6 +321,7 @@ func writeExportArchive(w io.Writer, manifest *vaultExportManifest, files []expo
 		if err := src.Close(); err != nil {
 			return fmt.Errorf("close %s: %w", f.SrcPath, err)
 		}
+		prog.Advance(f.TarPath, "ok", "")
 	}
 
 	if err := tw.Close(); err != nil {
@@ -402,7 +409,7 @@ func importArchive(r io.Reader, v *authfile.Vault, opt importOptions) (*vaultExp
 		return nil, fmt.Errorf("reader is nil")
 	}
 	if v == nil {
-		return nil, fmt.Errorf("vault not initialized")
+		return nil, caamerr.Errorf(caamerr.VaultError, "vault not initialized")
 	}
 
 	gr, err := gzip.NewReader(r)
@@ -431,7 +438,7 @@ func importArchive(r io.Reader, v *authfile.Vault, opt importOptions) (*vaultExp
 			return nil, err
 		}
 		if _, ok := tools[opt.AsTool]; !ok {
-			return nil, fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", opt.AsTool)
+			return nil, caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", opt.AsTool)
 		}
 		if err := validateVaultSegment("profile", opt.AsProfile); err != nil {
 			return nil, err
@@ -455,7 +462,7 @@ func importArchive(r io.Reader, v *authfile.Vault, opt importOptions) (*vaultExp
 		finalDir := v.ProfilePath(targetTool, targetProfile)
 		if st, err := os.Stat(finalDir); err == nil && st.IsDir() {
 			if !opt.Force {
-				return nil, fmt.Errorf("profile already exists: %s/%s (use --force or --as)", targetTool, targetProfile)
+				return nil, caamerr.Errorf(caamerr.AlreadyExists, "profile already exists: %s/%s (use --force or --as)", targetTool, targetProfile)
 			}
 		} else if err != nil && !os.IsNotExist(err) {
 			return nil, fmt.Errorf("stat %s/%s: %w", targetTool, targetProfile, err)
@@ -485,6 +492,7 @@ func importArchive(r io.Reader, v *authfile.Vault, opt importOptions) (*vaultExp
 		}
 	}
 
+	opt.Progress.Phase("extract", len(expected))
 	extracted := make(map[string]struct{}, len(expected))
 	for {
 		hdr, err := tr.Next()
@@ -586,6 +594,7 @@ func importArchive(r io.Reader, v *authfile.Vault, opt importOptions) (*vaultExp
 
 		extracted[name] = struct{}{}
 		tgt.SeenFiles[name] = struct{}{}
+		opt.Progress.Advance(name, "ok", "")
 	}
 
 	if len(extracted) != len(expected) {
@@ -594,6 +603,7 @@ func importArchive(r io.Reader, v *authfile.Vault, opt importOptions) (*vaultExp
 	}
 
 	// Promote temp dirs to final location atomically per profile.
+	opt.Progress.Phase("finalize", len(targets))
 	for _, tgt := range targets {
 		if tgt.TempDir == "" {
 			continue
@@ -611,6 +621,7 @@ func importArchive(r io.Reader, v *authfile.Vault, opt importOptions) (*vaultExp
 			return nil, fmt.Errorf("finalize %s/%s: %w", tgt.Tool, tgt.Profile, err)
 		}
 		tgt.TempDir = ""
+		opt.Progress.Advance(tgt.Tool+"/"+tgt.Profile, "ok", "")
 	}
 
 	cleanup()
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/progress"
 )
 
 func TestExportImport_RoundTripSingleProfile(t *testing.T) {
@@ -35,7 +38,7 @@ func TestExportImport_RoundTripSingleProfile(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	if err := writeExportArchive(&buf, manifest, files); err != nil {
+	if err := writeExportArchive(&buf, manifest, files, nil); err != nil {
 		t.Fatalf("writeExportArchive() error = %v", err)
 	}
 
@@ -75,7 +78,7 @@ func TestImport_WithAsRenamesProfile(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	if err := writeExportArchive(&buf, manifest, files); err != nil {
+	if err := writeExportArchive(&buf, manifest, files, nil); err != nil {
 		t.Fatalf("writeExportArchive() error = %v", err)
 	}
 
@@ -114,7 +117,7 @@ func TestImport_ConflictRequiresForce(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	if err := writeExportArchive(&buf, manifest, files); err != nil {
+	if err := writeExportArchive(&buf, manifest, files, nil); err != nil {
 		t.Fatalf("writeExportArchive() error = %v", err)
 	}
 
@@ -170,7 +173,7 @@ func TestExportAllForTool_ImportsAllProfiles(t *testing.T) {
 		t.Fatalf("buildExportManifest() error = %v", err)
 	}
 	var buf bytes.Buffer
-	if err := writeExportArchive(&buf, manifest, files); err != nil {
+	if err := writeExportArchive(&buf, manifest, files, nil); err != nil {
 		t.Fatalf("writeExportArchive() error = %v", err)
 	}
 
@@ -189,3 +192,82 @@ func TestExportAllForTool_ImportsAllProfiles(t *testing.T) {
 		}
 	}
 }
+
+func TestExportImport_ProgressEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	srcVault := authfile.NewVault(filepath.Join(tmpDir, "src-vault"))
+	for _, name := range []string{"work", "home"} {
+		profileDir := srcVault.ProfilePath("codex", name)
+		if err := os.MkdirAll(profileDir, 0700); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, "auth.json"), []byte(`{"access_token":"`+name+`"}`), 0600); err != nil {
+			t.Fatalf("WriteFile(auth.json) error = %v", err)
+		}
+	}
+
+	targets, err := resolveExportTargets(srcVault, exportRequest{ToolAll: true, Tool: "codex"})
+	if err != nil {
+		t.Fatalf("resolveExportTargets() error = %v", err)
+	}
+	manifest, files, err := buildExportManifest(targets)
+	if err != nil {
+		t.Fatalf("buildExportManifest() error = %v", err)
+	}
+
+	var archive, exportEvents bytes.Buffer
+	exportProg := progress.New(&exportEvents, "export")
+	if err := writeExportArchive(&archive, manifest, files, exportProg); err != nil {
+		t.Fatalf("writeExportArchive() error = %v", err)
+	}
+	exportProg.Done(nil)
+
+	var importEvents bytes.Buffer
+	importProg := progress.New(&importEvents, "import")
+	dstVault := authfile.NewVault(filepath.Join(tmpDir, "dst-vault"))
+	if _, err := importArchive(bytes.NewReader(archive.Bytes()), dstVault, importOptions{Progress: importProg}); err != nil {
+		t.Fatalf("importArchive() error = %v", err)
+	}
+	importProg.Done(nil)
+
+	exported := decodeProgressEvents(t, &exportEvents)
+	last := exported[len(exported)-2]
+	if last.Phase != "write" || last.Current != len(files) || last.Total != len(files) || last.Percent != 100 {
+		t.Fatalf("last export progress = %+v, want write %d/%d at 100%%", last, len(files), len(files))
+	}
+
+	imported := decodeProgressEvents(t, &importEvents)
+	phases := map[string]int{}
+	for _, ev := range imported {
+		if ev.Type == progress.EventProgress {
+			phases[ev.Phase]++
+		}
+	}
+	if phases["extract"] != len(files) {
+		t.Fatalf("extract events = %d, want %d", phases["extract"], len(files))
+	}
+	if phases["finalize"] != 2 {
+		t.Fatalf("finalize events = %d, want 2", phases["finalize"])
+	}
+	if done := imported[len(imported)-1]; done.Type != progress.EventDone || done.Status != "ok" {
+		t.Fatalf("final import event = %+v, want done/ok", done)
+	}
+}
+
+func decodeProgressEvents(t *testing.T, buf *bytes.Buffer) []progress.Event {
+	t.Helper()
+	var events []progress.Event
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var ev progress.Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, ev)
+	}
+	if len(events) == 0 {
+		t.Fatal("no progress events")
+	}
+	return events
+}
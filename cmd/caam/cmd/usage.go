@@ -11,6 +11,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 )
 
@@ -230,7 +231,7 @@ func renderUsageSummary(w io.Writer, format string, since time.Time, rows []usag
 		_ = tw.Flush()
 		return nil
 	default:
-		return fmt.Errorf("unsupported format: %s", format)
+		return caamerr.Errorf(caamerr.InvalidArgs, "unsupported format: %s", format)
 	}
 }
 
@@ -277,7 +278,7 @@ func renderUsageDetailed(w io.Writer, format, provider, profile string, since ti
 		_, _ = fmt.Fprintf(w, "\nTotal: %d sessions, %.1f hours\n", len(rows), totalHours)
 		return nil
 	default:
-		return fmt.Errorf("unsupported format: %s", format)
+		return caamerr.Errorf(caamerr.InvalidArgs, "unsupported format: %s", format)
 	}
 }
 
@@ -299,12 +300,12 @@ func parseSince(days int, since string) (time.Time, error) {
 func splitProviderProfile(input string) (string, string, error) {
 	parts := strings.SplitN(strings.TrimSpace(input), "/", 2)
 	if len(parts) != 2 {
-		return "", "", fmt.Errorf("profile must be in provider/name format")
+		return "", "", caamerr.Errorf(caamerr.InvalidArgs, "profile must be in provider/name format")
 	}
 	provider := strings.TrimSpace(parts[0])
 	profile := strings.TrimSpace(parts[1])
 	if provider == "" || profile == "" {
-		return "", "", fmt.Errorf("profile must be in provider/name format")
+		return "", "", caamerr.Errorf(caamerr.InvalidArgs, "profile must be in provider/name format")
 	}
 	return provider, profile, nil
 }
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+var usageRecordCmd = &cobra.Command{
+	Use:   "record <provider>[/<profile>]",
+	Short: "Record tokens used by a request",
+	Long: `Records the tokens one request consumed so burn rates and depletion
+forecasts (caam robot limits --forecast) are based on real usage.
+
+Agents and wrappers call this after each request. Without a profile the
+provider's active profile is charged. Long-running agents can POST the same
+record to the daemon at /usage/record instead (see daemon.usage_webhook).
+
+Examples:
+  caam usage record claude --input 1200 --output 300
+  caam usage record codex/work --total 5000 --model gpt-5 --source my-agent`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUsageRecord,
+}
+
+func init() {
+	usageCmd.AddCommand(usageRecordCmd)
+	usageRecordCmd.Flags().Int64("input", 0, "input (prompt) tokens")
+	usageRecordCmd.Flags().Int64("output", 0, "output (completion) tokens")
+	usageRecordCmd.Flags().Int64("cache-read", 0, "cache read tokens")
+	usageRecordCmd.Flags().Int64("cache-create", 0, "cache creation tokens")
+	usageRecordCmd.Flags().Int64("total", 0, "total tokens (default: sum of the other counts)")
+	usageRecordCmd.Flags().String("model", "", "model that served the request")
+	usageRecordCmd.Flags().String("source", "cli", "who reported the usage")
+	usageRecordCmd.Flags().String("at", "", "when the request completed (RFC 3339, default now)")
+	usageRecordCmd.Flags().Bool("json", false, "output the stored record as JSON")
+}
+
+func runUsageRecord(cmd *cobra.Command, args []string) error {
+	provider, profileName, err := resolveProviderProfile(args[0])
+	if err != nil {
+		return err
+	}
+
+	rec := caamdb.UsageRecord{
+		Provider:    strings.ToLower(provider),
+		ProfileName: profileName,
+	}
+	rec.InputTokens, _ = cmd.Flags().GetInt64("input")
+	rec.OutputTokens, _ = cmd.Flags().GetInt64("output")
+	rec.CacheReadTokens, _ = cmd.Flags().GetInt64("cache-read")
+	rec.CacheCreateTokens, _ = cmd.Flags().GetInt64("cache-create")
+	rec.TotalTokens, _ = cmd.Flags().GetInt64("total")
+	rec.Model, _ = cmd.Flags().GetString("model")
+	rec.Source, _ = cmd.Flags().GetString("source")
+	if at, _ := cmd.Flags().GetString("at"); at != "" {
+		rec.Timestamp, err = time.Parse(time.RFC3339, at)
+		if err != nil {
+			return fmt.Errorf("parse --at: %w", err)
+		}
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stored, err := db.RecordUsage(rec)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut, _ := cmd.Flags().GetBool("json"); jsonOut {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(stored)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Recorded %d tokens for %s/%s\n", stored.TotalTokens, stored.Provider, stored.ProfileName)
+	return nil
+}
+
+// activeProfileName returns the provider's active profile, or "" if it
+// cannot be determined.
+func activeProfileName(provider string) string {
+	getFileSet, ok := tools[provider]
+	if !ok || vault == nil {
+		return ""
+	}
+	name, _ := vault.ActiveProfile(getFileSet())
+	return name
+}
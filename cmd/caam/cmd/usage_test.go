@@ -115,3 +115,63 @@ func TestUsage_Detailed_JSON(t *testing.T) {
 		t.Fatalf("DurationSeconds = %d, want %d", rows[0].DurationSeconds, int64((2 * time.Hour).Seconds()))
 	}
 }
+
+func TestUsageRecord_JSON(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+
+	out, err := executeCommand("usage", "record", "claude/work", "--input", "1200", "--output", "300", "--model", "sonnet", "--json")
+	if err != nil {
+		t.Fatalf("executeCommand() error = %v", err)
+	}
+	var rec caamdb.UsageRecord
+	if err := json.Unmarshal([]byte(out), &rec); err != nil {
+		t.Fatalf("unmarshal output: %v\n%s", err, out)
+	}
+	if rec.Provider != "claude" || rec.ProfileName != "work" || rec.TotalTokens != 1500 || rec.Source != "cli" {
+		t.Errorf("recorded = %+v", rec)
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		t.Fatalf("db.Open() error = %v", err)
+	}
+	defer db.Close()
+	if records, _ := db.UsageSince("claude", "work", time.Time{}); len(records) != 1 {
+		t.Errorf("stored %d records, want 1", len(records))
+	}
+}
+
+func TestApplyUsageForecast(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	for i := 4; i >= 0; i-- {
+		if _, err := db.RecordUsage(caamdb.UsageRecord{
+			Timestamp:   now.Add(-time.Duration(i) * 15 * time.Minute),
+			Provider:    "claude",
+			ProfileName: "work",
+			TotalTokens: 20_000,
+		}); err != nil {
+			t.Fatalf("RecordUsage() error = %v", err)
+		}
+	}
+
+	limits := RobotProfileLimits{Name: "work", AvailScore: 100}
+	applyUsageForecast(&limits, db, "claude", nil, 110_000, 5*time.Hour)
+	if limits.Forecast == nil || limits.BurnRate == "" || limits.PrimaryPct != 91 {
+		t.Fatalf("limits = %+v", limits)
+	}
+	if limits.DepletesIn == "" || limits.AvailScore != 10 {
+		t.Errorf("DepletesIn/AvailScore = %q/%d, want a near depletion", limits.DepletesIn, limits.AvailScore)
+	}
+
+	idle := RobotProfileLimits{Name: "idle", AvailScore: 100}
+	applyUsageForecast(&idle, db, "claude", nil, 110_000, 5*time.Hour)
+	if idle.AvailScore != 100 || idle.BurnRate != "" || idle.DepletesIn != "" {
+		t.Errorf("idle limits = %+v", idle)
+	}
+}
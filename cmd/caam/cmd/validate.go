@@ -9,10 +9,13 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/claude"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/codex"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/copilot"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/cursor"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/gemini"
 )
 
@@ -75,6 +78,8 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	registry.Register(claude.New())
 	registry.Register(codex.New())
 	registry.Register(gemini.New())
+	registry.Register(cursor.New())
+	registry.Register(copilot.New())
 
 	var results []ValidationOutput
 	var err error
@@ -134,12 +139,12 @@ func validateAllProfiles(ctx context.Context, store *profile.Store, registry *pr
 func validateProviderProfiles(ctx context.Context, store *profile.Store, registry *provider.Registry, providerID string, passive bool) ([]ValidationOutput, error) {
 	prov, ok := registry.Get(providerID)
 	if !ok {
-		return nil, fmt.Errorf("unknown provider: %s", providerID)
+		return nil, caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", providerID)
 	}
 
 	profiles, err := store.List(providerID)
 	if err != nil {
-		return nil, fmt.Errorf("list profiles: %w", err)
+		return nil, caamerr.Errorf(caamerr.VaultError, "list profiles: %w", err)
 	}
 
 	var results []ValidationOutput
@@ -164,7 +169,7 @@ func validateProviderProfiles(ctx context.Context, store *profile.Store, registr
 func validateSingleProfile(ctx context.Context, store *profile.Store, registry *provider.Registry, providerID, profileName string, passive bool) ([]ValidationOutput, error) {
 	prov, ok := registry.Get(providerID)
 	if !ok {
-		return nil, fmt.Errorf("unknown provider: %s", providerID)
+		return nil, caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", providerID)
 	}
 
 	prof, err := store.Load(providerID, profileName)
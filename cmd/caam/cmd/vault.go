@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultfs"
+)
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Maintain the profile vault",
+}
+
+var vaultHealConflictsCmd = &cobra.Command{
+	Use:   "heal-conflicts",
+	Short: "Reconcile sync-tool conflict copies in the vault",
+	Long: `Find conflict copies left by Syncthing, Dropbox, or Nextcloud inside the
+vault (for example "auth (conflicted copy).json" or
+"auth.sync-conflict-20240102-150405-ABCDEF1.json") and reconcile them.
+
+For each file, the copy holding the freshest token wins: later token expiry
+first, then later modification time. The winner replaces the original and
+every other copy is removed.
+
+Examples:
+  caam vault heal-conflicts --dry-run
+  caam vault heal-conflicts --json`,
+	Args: cobra.NoArgs,
+	RunE: runVaultHealConflicts,
+}
+
+var vaultEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt vault auth files at rest",
+	Long: `Create a vault key and encrypt every profile's auth files with it
+(AES-256-GCM). Activation, backup, and token refresh keep working as before:
+files are decrypted on restore and encrypted on backup.
+
+The key is kept in the OS keychain: the macOS Keychain, the Linux secret
+service (via secret-tool), or Windows DPAPI. When no keychain is available,
+the key is protected with a passphrase instead; caam prompts for it when
+needed, or reads it from CAAM_VAULT_PASSPHRASE.
+
+Running encrypt again on an encrypted vault encrypts any files added in
+plaintext since (for example by a bundle import).
+
+Examples:
+  caam vault encrypt
+  caam vault encrypt --passphrase
+  caam vault status`,
+	Args: cobra.NoArgs,
+	RunE: runVaultEncrypt,
+}
+
+var vaultDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt vault auth files and remove the vault key",
+	Args:  cobra.NoArgs,
+	RunE:  runVaultDecrypt,
+}
+
+var vaultStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the vault is encrypted",
+	Args:  cobra.NoArgs,
+	RunE:  runVaultStatus,
+}
+
+var vaultEnrollKeyCmd = &cobra.Command{
+	Use:   "enroll-key",
+	Short: "Let a FIDO2 security key unlock the encrypted vault",
+	Long: `Enrolls the FIDO2 security key that is plugged in to unlock the vault key,
+using the key's hmac-secret extension. caam drives the key with the libfido2
+tools (fido2-token, fido2-cred, fido2-assert), which must be installed.
+
+The keychain or passphrase keeps working unless --only is given; then it is
+removed, and the vault can only be unlocked by touching an enrolled security
+key or with a recovery code.
+
+The first enrollment prints single-use recovery codes for when no enrolled
+key is at hand. They are shown once; store them somewhere safe. A code is
+entered at the prompt or through CAAM_VAULT_RECOVERY_CODE.
+
+Examples:
+  caam vault enroll-key
+  caam vault enroll-key --name backup-key --only
+  caam vault enroll-key --new-recovery-codes`,
+	Args: cobra.NoArgs,
+	RunE: runVaultEnrollKey,
+}
+
+func init() {
+	rootCmd.AddCommand(vaultCmd)
+	vaultCmd.AddCommand(vaultHealConflictsCmd)
+	vaultCmd.AddCommand(vaultEncryptCmd)
+	vaultCmd.AddCommand(vaultDecryptCmd)
+	vaultCmd.AddCommand(vaultStatusCmd)
+	vaultCmd.AddCommand(vaultEnrollKeyCmd)
+
+	vaultHealConflictsCmd.Flags().Bool("dry-run", false, "show what would change without modifying the vault")
+	vaultHealConflictsCmd.Flags().Bool("json", false, "output as JSON")
+
+	vaultEncryptCmd.Flags().Bool("passphrase", false, "protect the key with a passphrase instead of the OS keychain")
+	vaultEncryptCmd.Flags().Bool("keychain", false, "fail instead of falling back to a passphrase when no keychain is available")
+
+	vaultEnrollKeyCmd.Flags().String("name", "", "name for the security key (default: the device name)")
+	vaultEnrollKeyCmd.Flags().Bool("only", false, "remove the keychain entry or passphrase so only security keys and recovery codes unlock the vault")
+	vaultEnrollKeyCmd.Flags().Bool("new-recovery-codes", false, "replace the recovery codes with a new set")
+
+	vaultcrypt.Prompt = promptVaultPassphrase
+	vaultcrypt.Notice = func(msg string) { fmt.Fprintln(os.Stderr, msg) }
+}
+
+// promptVaultPassphrase asks for the vault passphrase on the terminal. It
+// writes to stderr so robot output on stdout stays clean.
+func promptVaultPassphrase(prompt string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("stdin is not a terminal; set %s", vaultcrypt.PassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(passphrase), nil
+}
+
+func runVaultEncrypt(cmd *cobra.Command, args []string) error {
+	usePassphrase, _ := cmd.Flags().GetBool("passphrase")
+	useKeychain, _ := cmd.Flags().GetBool("keychain")
+	if usePassphrase && useKeychain {
+		return fmt.Errorf("--passphrase and --keychain are mutually exclusive")
+	}
+	out := cmd.OutOrStdout()
+
+	if !vault.Encrypted() {
+		var mode vaultcrypt.Mode
+		switch {
+		case usePassphrase:
+			mode = vaultcrypt.ModePassphrase
+		case useKeychain:
+			mode = vaultcrypt.ModeKeychain
+		}
+		where, err := vaultcrypt.Init(vault.BasePath(), mode)
+		if err != nil {
+			return fmt.Errorf("create vault key: %w", err)
+		}
+		fmt.Fprintf(out, "Created vault key (stored in %s)\n", where)
+	} else if usePassphrase || useKeychain {
+		return fmt.Errorf("vault is already encrypted; run 'caam vault decrypt' first to change where the key is kept")
+	}
+
+	n, err := vault.EncryptFiles()
+	if err != nil {
+		return fmt.Errorf("encrypt vault: %w", err)
+	}
+	fmt.Fprintf(out, "Encrypted %d auth file(s) in %s\n", n, vault.BasePath())
+	return nil
+}
+
+func runVaultDecrypt(cmd *cobra.Command, args []string) error {
+	if !vault.Encrypted() {
+		fmt.Fprintln(cmd.OutOrStdout(), "Vault is not encrypted.")
+		return nil
+	}
+	n, err := vault.DecryptFiles()
+	if err != nil {
+		return fmt.Errorf("decrypt vault: %w", err)
+	}
+	if err := vaultcrypt.Remove(vault.BasePath()); err != nil {
+		return fmt.Errorf("remove vault key: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Decrypted %d auth file(s); vault key removed\n", n)
+	return nil
+}
+
+func runVaultStatus(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	if !vault.Encrypted() {
+		fmt.Fprintf(out, "Vault %s is not encrypted (run 'caam vault encrypt')\n", vault.BasePath())
+		return nil
+	}
+	where, err := vaultcrypt.Describe(vault.BasePath())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Vault %s is encrypted (unlocked by %s)\n", vault.BasePath(), where)
+	return nil
+}
+
+func runVaultEnrollKey(cmd *cobra.Command, args []string) error {
+	name, _ := cmd.Flags().GetString("name")
+	only, _ := cmd.Flags().GetBool("only")
+	newCodes, _ := cmd.Flags().GetBool("new-recovery-codes")
+	if !vault.Encrypted() {
+		return fmt.Errorf("vault is not encrypted; run 'caam vault encrypt' first")
+	}
+
+	codes, err := vaultcrypt.EnrollSecurityKey(vault.BasePath(), name, only, newCodes)
+	if codes == nil && err != nil {
+		return fmt.Errorf("enroll security key: %w", err)
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, "Security key enrolled.")
+	if only {
+		fmt.Fprintln(out, "The vault now unlocks only with an enrolled security key or a recovery code.")
+	}
+	if len(codes) > 0 {
+		fmt.Fprintln(out, "\nRecovery codes (each works once; they will not be shown again):")
+		for _, code := range codes {
+			fmt.Fprintf(out, "  %s\n", code)
+		}
+	}
+	return err
+}
+
+// conflictResolution records how one set of conflicting copies was resolved.
+type conflictResolution struct {
+	Provider string   `json:"provider,omitempty"`
+	Profile  string   `json:"profile,omitempty"`
+	File     string   `json:"file"`
+	Kept     string   `json:"kept"`
+	Removed  []string `json:"removed"`
+	SyncTool string   `json:"sync_tool"`
+}
+
+func runVaultHealConflicts(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	resolutions, err := healVaultConflicts(vault.BasePath(), vault.Filesystem(), dryRun)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			DryRun      bool                 `json:"dry_run"`
+			Resolutions []conflictResolution `json:"resolutions"`
+		}{dryRun, resolutions})
+	}
+
+	out := cmd.OutOrStdout()
+	if len(resolutions) == 0 {
+		fmt.Fprintln(out, "No sync conflicts found in the vault.")
+		return nil
+	}
+	verb := "Kept"
+	if dryRun {
+		verb = "Would keep"
+	}
+	for _, r := range resolutions {
+		label := r.File
+		if rel, err := filepath.Rel(vault.BasePath(), r.File); err == nil {
+			label = rel
+		}
+		fmt.Fprintf(out, "%s: %s %s, removing %d conflict cop%s\n",
+			label, verb, filepath.Base(r.Kept), len(r.Removed), pluralY(len(r.Removed)))
+	}
+	return nil
+}
+
+func pluralY(n int) string {
+	if n == 1 {
+		return "y"
+	}
+	return "ies"
+}
+
+// healVaultConflicts resolves every conflict copy under vaultPath, keeping
+// the freshest copy of each file in place of the original.
+func healVaultConflicts(vaultPath string, fsInfo vaultfs.Info, dryRun bool) ([]conflictResolution, error) {
+	if _, err := os.Stat(vaultPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if !dryRun {
+		lock, err := vaultfs.Acquire(vaultPath, fsInfo.Locking(), vaultfs.DefaultLockTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("lock vault: %w", err)
+		}
+		defer lock.Unlock()
+	}
+
+	conflicts, err := vaultfs.FindConflicts(vaultPath)
+	if err != nil {
+		return nil, fmt.Errorf("scan vault: %w", err)
+	}
+
+	// Group copies by the file they conflict with, preserving scan order.
+	var originals []string
+	groups := make(map[string][]vaultfs.Conflict)
+	for _, c := range conflicts {
+		if _, ok := groups[c.Original]; !ok {
+			originals = append(originals, c.Original)
+		}
+		groups[c.Original] = append(groups[c.Original], c)
+	}
+
+	resolutions := make([]conflictResolution, 0, len(originals))
+	for _, original := range originals {
+		copies := groups[original]
+		provider, profileName := vaultProfileOf(vaultPath, original)
+
+		candidates := make([]string, 0, len(copies)+1)
+		if _, err := os.Stat(original); err == nil {
+			candidates = append(candidates, original)
+		}
+		for _, c := range copies {
+			candidates = append(candidates, c.Path)
+		}
+
+		kept := candidates[0]
+		keptFresh := conflictCandidateFreshness(provider, profileName, original, kept)
+		for _, candidate := range candidates[1:] {
+			fresh := conflictCandidateFreshness(provider, profileName, original, candidate)
+			if syncstate.CompareFreshness(fresh, keptFresh) {
+				kept, keptFresh = candidate, fresh
+			}
+		}
+
+		res := conflictResolution{
+			Provider: provider,
+			Profile:  profileName,
+			File:     original,
+			Kept:     kept,
+			SyncTool: copies[0].SyncTool,
+		}
+		for _, c := range copies {
+			if c.Path != kept {
+				res.Removed = append(res.Removed, c.Path)
+			}
+		}
+
+		if !dryRun {
+			if kept != original {
+				if err := os.Rename(kept, original); err != nil {
+					return resolutions, fmt.Errorf("replace %s: %w", original, err)
+				}
+				_ = os.Chmod(original, 0600)
+			}
+			for _, path := range res.Removed {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return resolutions, fmt.Errorf("remove %s: %w", path, err)
+				}
+			}
+			if fsInfo.NeedsDirSync() {
+				_ = vaultfs.SyncDir(filepath.Dir(original))
+			}
+		}
+		resolutions = append(resolutions, res)
+	}
+
+	return resolutions, nil
+}
+
+// vaultProfileOf maps vault/<provider>/<profile>/<file> to its provider and
+// profile, or empty strings for files elsewhere in the vault.
+func vaultProfileOf(vaultPath, path string) (string, string) {
+	rel, err := filepath.Rel(vaultPath, path)
+	if err != nil {
+		return "", ""
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if len(parts) < 3 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// conflictCandidateFreshness reads candidate as if it were the original auth
+// file so the provider's freshness extractor recognizes it. Files without a
+// parseable token are compared by modification time alone.
+func conflictCandidateFreshness(provider, profileName, original, candidate string) *syncstate.TokenFreshness {
+	fresh := &syncstate.TokenFreshness{Provider: provider, Profile: profileName}
+	if data, err := vaultcrypt.ReadFile(candidate); err == nil && provider != "" {
+		if extracted, err := syncstate.ExtractFreshnessFromBytes(provider, profileName, map[string][]byte{original: data}); err == nil && extracted != nil {
+			fresh = extracted
+		}
+	}
+	if info, err := os.Stat(candidate); err == nil {
+		fresh.ModifiedAt = info.ModTime()
+	}
+	return fresh
+}
@@ -0,0 +1,436 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+)
+
+var vaultPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete dead or unused profiles from the vault",
+	Long: `Find profiles matching every given rule and delete them. At least one rule
+is required:
+
+  --expired-only     token expired with no refresh token, or quarantined
+  --older-than 90d   not backed up for 90 days
+  --unused-since 30d not activated (per the activity log) for 30 days
+  --match 'backup-*' profile name matches the glob
+
+System profiles (names starting with '_') and active profiles are never
+pruned. Before deleting, the vault is snapshotted ('caam vault snapshot')
+so the prune can be undone.
+
+Examples:
+  caam vault prune --expired-only --dry-run
+  caam vault prune --unused-since 30d --older-than 90d
+  caam vault prune --match 'backup-2024*' --provider claude --json`,
+	Args: cobra.NoArgs,
+	RunE: runVaultPrune,
+}
+
+var robotPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete dead or unused profiles",
+	Long: `Same rules as 'caam vault prune', without confirmation. Use --dry-run to
+list what would be deleted.`,
+	Args: cobra.NoArgs,
+	RunE: runRobotPrune,
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultPruneCmd)
+	robotCmd.AddCommand(robotPruneCmd)
+
+	for _, c := range []*cobra.Command{vaultPruneCmd, robotPruneCmd} {
+		c.Flags().Bool("expired-only", false, "only profiles whose token is expired without a refresh token, or quarantined")
+		c.Flags().String("older-than", "", "only profiles not backed up within this long (e.g. 90d)")
+		c.Flags().String("unused-since", "", "only profiles not activated within this long (e.g. 30d)")
+		c.Flags().String("match", "", "only profiles whose name matches this glob")
+		c.Flags().String("provider", "", "only this provider's profiles")
+		c.Flags().Bool("dry-run", false, "list matching profiles without deleting them")
+		c.Flags().Bool("no-snapshot", false, "do not snapshot the vault before deleting")
+	}
+	vaultPruneCmd.Flags().Bool("force", false, "skip confirmation")
+	vaultPruneCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+// pruneRules selects profiles for pruning; a profile must match every
+// rule that is set.
+type pruneRules struct {
+	ExpiredOnly bool          `json:"expired_only,omitempty"`
+	OlderThan   time.Duration `json:"-"`
+	UnusedSince time.Duration `json:"-"`
+	Match       string        `json:"match,omitempty"`
+	Provider    string        `json:"provider,omitempty"`
+
+	// The --older-than and --unused-since values as given, for output.
+	OlderThanFlag   string `json:"older_than,omitempty"`
+	UnusedSinceFlag string `json:"unused_since,omitempty"`
+}
+
+func (r pruneRules) empty() bool {
+	return !r.ExpiredOnly && r.OlderThan == 0 && r.UnusedSince == 0 && r.Match == ""
+}
+
+// pruneCandidate is a profile the rules selected.
+type pruneCandidate struct {
+	Provider   string     `json:"provider"`
+	Profile    string     `json:"profile"`
+	Reasons    []string   `json:"reasons"`
+	BackedUpAt *time.Time `json:"backed_up_at,omitempty"`
+	LastUsed   *time.Time `json:"last_used,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Skipped    string     `json:"skipped,omitempty"`
+}
+
+// pruneResult is the outcome of a prune, or of a dry run.
+type pruneResult struct {
+	DryRun     bool             `json:"dry_run"`
+	Rules      pruneRules       `json:"rules"`
+	Candidates []pruneCandidate `json:"candidates"`
+	Deleted    []string         `json:"deleted,omitempty"`
+	Snapshot   string           `json:"snapshot,omitempty"`
+}
+
+func pruneRulesFromFlags(cmd *cobra.Command) (pruneRules, error) {
+	var rules pruneRules
+	rules.ExpiredOnly, _ = cmd.Flags().GetBool("expired-only")
+	rules.Match, _ = cmd.Flags().GetString("match")
+	rules.Provider, _ = cmd.Flags().GetString("provider")
+	rules.Provider = strings.ToLower(strings.TrimSpace(rules.Provider))
+
+	rules.OlderThanFlag, _ = cmd.Flags().GetString("older-than")
+	rules.UnusedSinceFlag, _ = cmd.Flags().GetString("unused-since")
+	for flag, value := range map[string]string{"older-than": rules.OlderThanFlag, "unused-since": rules.UnusedSinceFlag} {
+		if value == "" {
+			continue
+		}
+		d, err := parseDuration(value)
+		if err != nil || d <= 0 {
+			return rules, fmt.Errorf("invalid --%s %q: use a duration like 30d or 720h", flag, value)
+		}
+		if flag == "older-than" {
+			rules.OlderThan = d
+		} else {
+			rules.UnusedSince = d
+		}
+	}
+	if rules.Match != "" {
+		if _, err := path.Match(rules.Match, ""); err != nil {
+			return rules, fmt.Errorf("invalid --match pattern: %w", err)
+		}
+	}
+	if rules.Provider != "" {
+		if _, ok := lookupToolFileSet(rules.Provider); !ok {
+			return rules, caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", rules.Provider)
+		}
+	}
+	if rules.empty() {
+		return rules, fmt.Errorf("no prune rule given: use --expired-only, --older-than, --unused-since, or --match")
+	}
+	return rules, nil
+}
+
+// findPruneCandidates returns the profiles matching rules, with active
+// profiles marked Skipped.
+func findPruneCandidates(rules pruneRules, now time.Time) ([]pruneCandidate, error) {
+	all, err := vault.ListAll()
+	if err != nil {
+		return nil, fmt.Errorf("list vault: %w", err)
+	}
+	providers := make([]string, 0, len(all))
+	for provider := range all {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	var activity pruneActivityDB
+	if db, err := getDB(); err == nil {
+		activity = db
+	}
+	var candidates []pruneCandidate
+	for _, provider := range providers {
+		if rules.Provider != "" && provider != rules.Provider {
+			continue
+		}
+		getFileSet, ok := lookupToolFileSet(provider)
+		if !ok {
+			continue
+		}
+		active, _ := vault.ActiveProfile(getFileSet())
+
+		profiles := append([]string(nil), all[provider]...)
+		sort.Strings(profiles)
+		for _, profile := range profiles {
+			if authfile.IsSystemProfile(profile) {
+				continue
+			}
+			c, ok := matchPruneRules(rules, provider, profile, activity, now)
+			if !ok {
+				continue
+			}
+			if profile == active {
+				c.Skipped = "active"
+			}
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates, nil
+}
+
+// pruneActivityDB is the part of the activity database prune reads.
+type pruneActivityDB interface {
+	LastActivation(provider, profile string) (time.Time, error)
+}
+
+func matchPruneRules(rules pruneRules, provider, profile string, db pruneActivityDB, now time.Time) (pruneCandidate, bool) {
+	c := pruneCandidate{Provider: provider, Profile: profile}
+	if rules.Match != "" {
+		if ok, _ := path.Match(rules.Match, profile); !ok {
+			return c, false
+		}
+		c.Reasons = append(c.Reasons, "name matches "+rules.Match)
+	}
+
+	backedUp := profileBackedUpAt(provider, profile)
+	if !backedUp.IsZero() {
+		c.BackedUpAt = &backedUp
+	}
+
+	if rules.ExpiredOnly {
+		reason := profileExpiredReason(provider, profile, now, &c)
+		if reason == "" {
+			return c, false
+		}
+		c.Reasons = append(c.Reasons, reason)
+	}
+
+	if rules.OlderThan > 0 {
+		if backedUp.IsZero() || now.Sub(backedUp) < rules.OlderThan {
+			return c, false
+		}
+		c.Reasons = append(c.Reasons, "backed up "+formatDurationAgo(now.Sub(backedUp)))
+	}
+
+	if rules.UnusedSince > 0 {
+		var lastUsed time.Time
+		if db != nil {
+			lastUsed, _ = db.LastActivation(provider, profile)
+		}
+		if !lastUsed.IsZero() {
+			c.LastUsed = &lastUsed
+		}
+		// A profile that was never activated counts from when it was saved.
+		since := lastUsed
+		if since.IsZero() {
+			since = backedUp
+		}
+		if since.IsZero() || now.Sub(since) < rules.UnusedSince {
+			return c, false
+		}
+		if lastUsed.IsZero() {
+			c.Reasons = append(c.Reasons, "never activated")
+		} else {
+			c.Reasons = append(c.Reasons, "last activated "+formatDurationAgo(now.Sub(lastUsed)))
+		}
+	}
+	return c, true
+}
+
+// profileExpiredReason explains why a profile's login is dead, or returns
+// "" if it is not.
+func profileExpiredReason(provider, profile string, now time.Time, c *pruneCandidate) string {
+	if db, err := getDB(); err == nil {
+		if rev, err := db.ActiveRevocation(provider, profile); err == nil && rev != nil {
+			return "quarantined: " + rev.Reason
+		}
+	}
+	info, err := parseVaultExpiry(provider, profile)
+	if err != nil || info == nil || info.ExpiresAt.IsZero() {
+		return ""
+	}
+	c.ExpiresAt = &info.ExpiresAt
+	if info.ExpiresAt.After(now) || info.HasRefreshToken {
+		return ""
+	}
+	return "token expired " + formatDurationAgo(now.Sub(info.ExpiresAt)) + " with no refresh token"
+}
+
+// profileBackedUpAt returns when the profile was last saved to the vault,
+// from meta.json or, failing that, the profile directory's mtime.
+func profileBackedUpAt(provider, profile string) time.Time {
+	dir := vault.ProfilePath(provider, profile)
+	if data, err := os.ReadFile(filepath.Join(dir, "meta.json")); err == nil {
+		var meta struct {
+			BackedUpAt string `json:"backed_up_at"`
+		}
+		if json.Unmarshal(data, &meta) == nil {
+			if t, err := time.Parse(time.RFC3339, meta.BackedUpAt); err == nil {
+				return t
+			}
+		}
+	}
+	if info, err := os.Stat(dir); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+func formatDurationAgo(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+	return d.Round(time.Minute).String() + " ago"
+}
+
+// pruneProfiles runs the prune: it finds candidates and, unless dryRun,
+// snapshots the vault and deletes them.
+func pruneProfiles(rules pruneRules, dryRun, snapshotFirst bool, confirm func([]pruneCandidate) (bool, error)) (*pruneResult, error) {
+	candidates, err := findPruneCandidates(rules, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	result := &pruneResult{DryRun: dryRun, Rules: rules, Candidates: candidates}
+	if result.Candidates == nil {
+		result.Candidates = []pruneCandidate{}
+	}
+
+	var doomed []pruneCandidate
+	for _, c := range candidates {
+		if c.Skipped == "" {
+			doomed = append(doomed, c)
+		}
+	}
+	if dryRun || len(doomed) == 0 {
+		return result, nil
+	}
+	if confirm != nil {
+		ok, err := confirm(doomed)
+		if err != nil || !ok {
+			return nil, err
+		}
+	}
+
+	if snapshotFirst {
+		snap, err := snapshotStore().Create(fmt.Sprintf("before pruning %d profile(s)", len(doomed)), snapshotKeepLast())
+		if err != nil {
+			return nil, fmt.Errorf("snapshot before prune: %w", err)
+		}
+		result.Snapshot = snap.ID
+	}
+
+	db, _ := getDB()
+	for _, c := range doomed {
+		if err := vault.Delete(c.Provider, c.Profile); err != nil {
+			return result, fmt.Errorf("delete %s/%s: %w", c.Provider, c.Profile, err)
+		}
+		if db != nil {
+			_ = db.DeleteProfileIdentity(c.Provider, c.Profile)
+		}
+		result.Deleted = append(result.Deleted, c.Provider+"/"+c.Profile)
+	}
+	return result, nil
+}
+
+func runVaultPrune(cmd *cobra.Command, args []string) error {
+	rules, err := pruneRulesFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	noSnapshot, _ := cmd.Flags().GetBool("no-snapshot")
+	force, _ := cmd.Flags().GetBool("force")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	out := cmd.OutOrStdout()
+
+	var confirm func([]pruneCandidate) (bool, error)
+	if !force && !jsonOut {
+		confirm = func(doomed []pruneCandidate) (bool, error) {
+			printPruneCandidates(out, doomed)
+			fmt.Fprintf(out, "Delete %d profile(s)?\n", len(doomed))
+			return confirmProceed(cmd.InOrStdin(), out)
+		}
+	}
+
+	result, err := pruneProfiles(rules, dryRun, !noSnapshot, confirm)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		fmt.Fprintln(out, "Cancelled.")
+		return nil
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	if len(result.Candidates) == 0 {
+		fmt.Fprintln(out, "No profiles match.")
+		return nil
+	}
+	if dryRun {
+		printPruneCandidates(out, result.Candidates)
+		fmt.Fprintln(out, "Dry run: nothing deleted.")
+		return nil
+	}
+	for _, c := range result.Candidates {
+		if c.Skipped != "" {
+			fmt.Fprintf(out, "Kept %s/%s (%s)\n", c.Provider, c.Profile, c.Skipped)
+		}
+	}
+	if result.Snapshot != "" {
+		fmt.Fprintf(out, "Saved snapshot %s (undo with 'caam vault snapshot restore %s')\n", result.Snapshot, result.Snapshot)
+	}
+	fmt.Fprintf(out, "Deleted %d profile(s)\n", len(result.Deleted))
+	return nil
+}
+
+func printPruneCandidates(out io.Writer, candidates []pruneCandidate) {
+	for _, c := range candidates {
+		line := fmt.Sprintf("  %s/%s: %s", c.Provider, c.Profile, strings.Join(c.Reasons, ", "))
+		if c.Skipped != "" {
+			line += " [kept: " + c.Skipped + "]"
+		}
+		fmt.Fprintln(out, line)
+	}
+}
+
+func runRobotPrune(cmd *cobra.Command, args []string) error {
+	start := time.Now()
+	rules, err := pruneRulesFromFlags(cmd)
+	if err != nil {
+		return robotError(cmd, "prune", caamerr.InvalidArgs, err.Error(), "",
+			[]string{"caam robot prune --expired-only --dry-run", "caam robot prune --unused-since 30d --dry-run"})
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	noSnapshot, _ := cmd.Flags().GetBool("no-snapshot")
+
+	result, err := pruneProfiles(rules, dryRun, !noSnapshot, nil)
+	if err != nil {
+		return robotError(cmd, "prune", caamerr.PruneFailed, err.Error(), "", nil)
+	}
+	return robotOutput(cmd, RobotOutput{
+		Success: true,
+		Command: "prune",
+		Data:    result,
+		Timing: &RobotTiming{
+			StartedAt:  start.UTC().Format(time.RFC3339),
+			DurationMs: time.Since(start).Milliseconds(),
+		},
+	})
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/snapshot"
+)
+
+var vaultSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save and restore point-in-time copies of the vault",
+	Long: `Snapshots copy every profile in the vault, with a checksum for each file,
+so a profile or the whole vault can be rolled back later. Take one before
+experimenting with new rotation policies.
+
+Snapshots are kept next to the vault in a "snapshots" directory. Creating a
+snapshot removes the oldest ones beyond snapshots.keep_last (default 10).
+
+Examples:
+  caam vault snapshot create --note "before weighted policy"
+  caam vault snapshot list
+  caam vault snapshot restore latest claude/work
+  caam vault snapshot restore 20260115-093000`,
+}
+
+var vaultSnapshotCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Snapshot every profile in the vault",
+	Args:  cobra.NoArgs,
+	RunE:  runVaultSnapshotCreate,
+}
+
+var vaultSnapshotListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List vault snapshots, newest first",
+	Args:    cobra.NoArgs,
+	RunE:    runVaultSnapshotList,
+}
+
+var vaultSnapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <id|latest> [provider/profile]",
+	Short: "Restore one profile or the whole vault from a snapshot",
+	Long: `Restore a profile, or with no profile the whole vault, to its state in a
+snapshot. The snapshot's checksums are verified first, and a new snapshot of
+the current vault is taken so the restore can itself be undone.
+
+Restoring the whole vault removes profiles created since the snapshot.
+Restoring only changes the vault: activate a restored profile again to update
+the live auth files.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runVaultSnapshotRestore,
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultSnapshotCmd)
+	vaultSnapshotCmd.AddCommand(vaultSnapshotCreateCmd)
+	vaultSnapshotCmd.AddCommand(vaultSnapshotListCmd)
+	vaultSnapshotCmd.AddCommand(vaultSnapshotRestoreCmd)
+
+	vaultSnapshotCreateCmd.Flags().String("note", "", "describe why the snapshot was taken")
+	vaultSnapshotCreateCmd.Flags().Int("keep", 0, "snapshots to keep (default: snapshots.keep_last)")
+	vaultSnapshotCreateCmd.Flags().Bool("json", false, "output as JSON")
+
+	vaultSnapshotListCmd.Flags().Bool("json", false, "output as JSON")
+
+	vaultSnapshotRestoreCmd.Flags().Bool("force", false, "skip confirmation when restoring the whole vault")
+	vaultSnapshotRestoreCmd.Flags().Bool("no-safety-snapshot", false, "do not snapshot the current vault first")
+	vaultSnapshotRestoreCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+func snapshotStore() *snapshot.Store {
+	return snapshot.NewStore(snapshot.DefaultDir(vault.BasePath()), vault)
+}
+
+// snapshotKeepLast returns the configured snapshot retention.
+func snapshotKeepLast() int {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	return cfg.Snapshots.GetKeepLast()
+}
+
+func runVaultSnapshotCreate(cmd *cobra.Command, args []string) error {
+	note, _ := cmd.Flags().GetString("note")
+	keep, _ := cmd.Flags().GetInt("keep")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	if keep < 0 {
+		return fmt.Errorf("--keep cannot be negative")
+	}
+	if keep == 0 {
+		keep = snapshotKeepLast()
+	}
+
+	snap, err := snapshotStore().Create(note, keep)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(snap)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Created snapshot %s (%d profile(s), %d file(s))\n",
+		snap.ID, snap.ProfileCount(), snap.Files)
+	return nil
+}
+
+func runVaultSnapshotList(cmd *cobra.Command, args []string) error {
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	snaps, err := snapshotStore().List()
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOut {
+		if snaps == nil {
+			snaps = []*snapshot.Snapshot{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snaps)
+	}
+	if len(snaps) == 0 {
+		fmt.Fprintln(out, "No vault snapshots. Create one with 'caam vault snapshot create'.")
+		return nil
+	}
+	fmt.Fprintf(out, "%-20s  %-16s  %8s  %s\n", "ID", "CREATED", "PROFILES", "NOTE")
+	for _, snap := range snaps {
+		fmt.Fprintf(out, "%-20s  %-16s  %8d  %s\n",
+			snap.ID, snap.CreatedAt.Local().Format("2006-01-02 15:04"), snap.ProfileCount(), snap.Note)
+	}
+	return nil
+}
+
+func runVaultSnapshotRestore(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+	noSafety, _ := cmd.Flags().GetBool("no-safety-snapshot")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	out := cmd.OutOrStdout()
+
+	var provider, profile string
+	if len(args) == 2 {
+		parts := strings.SplitN(args[1], "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return caamerr.Errorf(caamerr.InvalidArgs, "profile must be provider/profile, got %q", args[1])
+		}
+		provider, profile = strings.ToLower(parts[0]), parts[1]
+	}
+
+	store := snapshotStore()
+	snap, err := store.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	if provider == "" && !force && !jsonOut {
+		fmt.Fprintf(out, "Restore all %d profile(s) from snapshot %s, removing profiles created since?\n",
+			snap.ProfileCount(), snap.ID)
+		ok, err := confirmProceed(cmd.InOrStdin(), out)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(out, "Cancelled.")
+			return nil
+		}
+	}
+
+	var safety *snapshot.Snapshot
+	if !noSafety {
+		target := "vault"
+		if provider != "" {
+			target = provider + "/" + profile
+		}
+		// Not pruned here, so the snapshot being restored cannot be removed.
+		safety, err = store.Create(fmt.Sprintf("before restoring %s from %s", target, snap.ID), 0)
+		if err != nil {
+			return fmt.Errorf("safety snapshot: %w", err)
+		}
+	}
+
+	result, err := store.Restore(snap, provider, profile)
+	if err != nil {
+		return err
+	}
+	if safety != nil {
+		_, _ = store.Prune(snapshotKeepLast())
+	}
+
+	if jsonOut {
+		payload := struct {
+			Snapshot       string   `json:"snapshot"`
+			SafetySnapshot string   `json:"safety_snapshot,omitempty"`
+			Restored       []string `json:"restored"`
+			Removed        []string `json:"removed,omitempty"`
+		}{Snapshot: snap.ID, Restored: result.Restored, Removed: result.Removed}
+		if safety != nil {
+			payload.SafetySnapshot = safety.ID
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(payload)
+	}
+
+	if safety != nil {
+		fmt.Fprintf(out, "Saved current vault as snapshot %s\n", safety.ID)
+	}
+	fmt.Fprintf(out, "Restored %d profile(s) from snapshot %s\n", len(result.Restored), snap.ID)
+	for _, key := range result.Removed {
+		fmt.Fprintf(out, "  removed %s (not in snapshot)\n", key)
+	}
+	fmt.Fprintln(out, "Activate restored profiles again to update the live auth files.")
+	return nil
+}
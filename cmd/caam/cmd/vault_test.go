@@ -3,12 +3,15 @@ package cmd
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultfs"
 )
 
 // TestBackupCommand_UnknownTool tests backup command rejects unknown tools.
@@ -569,3 +572,124 @@ func TestVaultDelete(t *testing.T) {
 		t.Errorf("Expected 0 profiles after delete, got %d", len(profiles))
 	}
 }
+
+func TestHealVaultConflicts(t *testing.T) {
+	vaultPath := t.TempDir()
+	profileDir := filepath.Join(vaultPath, "codex", "work")
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	writeToken := func(name string, expires time.Time) string {
+		path := filepath.Join(profileDir, name)
+		data := fmt.Sprintf(`{"access_token":%q,"expires_at":%d}`, name, expires.Unix())
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	now := time.Now()
+	original := writeToken("auth.json", now.Add(time.Hour))
+	fresher := writeToken("auth.sync-conflict-20240102-150405-ABCDEF1.json", now.Add(3*time.Hour))
+	stale := writeToken("auth (conflicted copy).json", now.Add(-time.Hour))
+
+	fsInfo := vaultfs.Detect(vaultPath)
+
+	resolutions, err := healVaultConflicts(vaultPath, fsInfo, true)
+	if err != nil {
+		t.Fatalf("dry run error = %v", err)
+	}
+	if len(resolutions) != 1 || resolutions[0].Kept != fresher {
+		t.Fatalf("dry run resolutions = %+v, want fresher copy kept", resolutions)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatal("dry run removed a conflict copy")
+	}
+
+	resolutions, err = healVaultConflicts(vaultPath, fsInfo, false)
+	if err != nil {
+		t.Fatalf("heal error = %v", err)
+	}
+	if len(resolutions) != 1 || len(resolutions[0].Removed) != 1 || resolutions[0].Removed[0] != stale {
+		t.Fatalf("resolutions = %+v", resolutions)
+	}
+	if resolutions[0].Provider != "codex" || resolutions[0].Profile != "work" {
+		t.Errorf("provider/profile = %s/%s", resolutions[0].Provider, resolutions[0].Profile)
+	}
+
+	data, err := os.ReadFile(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Base(fresher); !contains(string(data), want) {
+		t.Errorf("auth.json = %s, want contents of %s", data, want)
+	}
+	for _, path := range []string{fresher, stale} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", filepath.Base(path))
+		}
+	}
+
+	conflicts, _ := vaultfs.FindConflicts(vaultPath)
+	if len(conflicts) != 0 {
+		t.Errorf("conflicts remain after heal: %+v", conflicts)
+	}
+}
+
+type fakeActivityDB map[string]time.Time
+
+func (f fakeActivityDB) LastActivation(provider, profile string) (time.Time, error) {
+	return f[provider+"/"+profile], nil
+}
+
+func TestMatchPruneRules(t *testing.T) {
+	origVault := vault
+	t.Cleanup(func() { vault = origVault })
+	vault = authfile.NewVault(t.TempDir())
+
+	now := time.Now()
+	writeMeta := func(profile string, backedUp time.Time) {
+		dir := vault.ProfilePath("claude", profile)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		meta := fmt.Sprintf(`{"backed_up_at":%q}`, backedUp.Format(time.RFC3339))
+		if err := os.WriteFile(filepath.Join(dir, "meta.json"), []byte(meta), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeMeta("old", now.Add(-100*24*time.Hour))
+	writeMeta("fresh", now.Add(-time.Hour))
+	writeMeta("backup-20240312", now.Add(-200*24*time.Hour))
+
+	db := fakeActivityDB{
+		"claude/old": now.Add(-2 * time.Hour),
+	}
+
+	tests := []struct {
+		name    string
+		rules   pruneRules
+		profile string
+		want    bool
+	}{
+		{"older than matches", pruneRules{OlderThan: 90 * 24 * time.Hour}, "old", true},
+		{"older than skips fresh", pruneRules{OlderThan: 90 * 24 * time.Hour}, "fresh", false},
+		{"recently used is kept", pruneRules{UnusedSince: 30 * 24 * time.Hour}, "old", false},
+		{"never used counts from backup", pruneRules{UnusedSince: 30 * 24 * time.Hour}, "backup-20240312", true},
+		{"never used but new is kept", pruneRules{UnusedSince: 30 * 24 * time.Hour}, "fresh", false},
+		{"glob", pruneRules{Match: "backup-*"}, "backup-20240312", true},
+		{"glob miss", pruneRules{Match: "backup-*"}, "old", false},
+		{"all rules must match", pruneRules{Match: "backup-*", OlderThan: 300 * 24 * time.Hour}, "backup-20240312", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := matchPruneRules(tt.rules, "claude", tt.profile, db, now)
+			if ok != tt.want {
+				t.Fatalf("matchPruneRules(%s) = %v, want %v", tt.profile, ok, tt.want)
+			}
+			if ok && len(c.Reasons) == 0 {
+				t.Error("match has no reasons")
+			}
+		})
+	}
+}
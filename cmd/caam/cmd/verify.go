@@ -9,6 +9,7 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
 	"github.com/spf13/cobra"
 )
@@ -79,7 +80,7 @@ func runVerify(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		toolFilter = strings.ToLower(args[0])
 		if _, ok := tools[toolFilter]; !ok {
-			return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", toolFilter)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: codex, claude, gemini)", toolFilter)
 		}
 	}
 
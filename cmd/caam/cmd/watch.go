@@ -10,6 +10,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/discovery"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
 	"github.com/spf13/cobra"
@@ -74,7 +75,7 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	validProviders := map[string]bool{"claude": true, "codex": true, "gemini": true}
 	for _, p := range providers {
 		if !validProviders[strings.ToLower(p)] {
-			return fmt.Errorf("unknown provider: %s", p)
+			return caamerr.Errorf(caamerr.InvalidProvider, "unknown provider: %s", p)
 		}
 	}
 
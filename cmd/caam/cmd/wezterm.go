@@ -12,10 +12,15 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/coordinator"
 )
 
 var weztermCmd = &cobra.Command{
@@ -105,6 +110,8 @@ func init() {
 	weztermLoginAllCmd.Flags().Bool("dry-run", false, "show target panes without sending")
 	weztermLoginAllCmd.Flags().Bool("subscription", false, "also send '1' to choose subscription login")
 	weztermLoginAllCmd.Flags().String("match", "", "regex pattern to match panes (overrides default)")
+	weztermLoginAllCmd.Flags().Duration("verify-timeout", defaultWeztermVerifyTimeout, "how long to wait for each pane to echo the injection (0 disables verification)")
+	weztermLoginAllCmd.Flags().Bool("json", false, "output per-pane results as JSON")
 
 	weztermOAuthReportCmd.Flags().Bool("all", false, "scan all panes (skip matching)")
 	weztermOAuthReportCmd.Flags().String("match", "", "regex pattern to match panes (overrides default)")
@@ -115,12 +122,15 @@ func init() {
 	weztermRecoverCmd.Flags().Bool("yes", false, "skip confirmation (alias for --force)")
 	weztermRecoverCmd.Flags().Bool("watch", false, "continuously watch and refresh (with --status)")
 	weztermRecoverCmd.Flags().Duration("interval", 2*time.Second, "refresh interval for watch mode")
+	weztermRecoverCmd.Flags().Duration("verify-timeout", defaultWeztermVerifyTimeout, "how long to wait for each pane to echo the injection (0 disables verification)")
 	weztermRecoverCmd.Flags().String("resume-prompt", "proceed. Reread AGENTS.md so it's still fresh in your mind. Use ultrathink.\n", "prompt to inject after successful auth")
 }
 
 type weztermPane struct {
-	ID    int
-	Title string
+	ID       int
+	Title    string
+	IsActive bool
+	TTYName  string
 }
 
 type weztermTarget struct {
@@ -136,6 +146,7 @@ var (
 	weztermIsTerminal              = term.IsTerminal
 	weztermNow                     = time.Now
 	weztermDebugWriter   io.Writer = os.Stderr
+	weztermVerifyPoll              = 150 * time.Millisecond
 )
 
 func runWeztermLoginAll(cmd *cobra.Command, args []string) error {
@@ -144,7 +155,7 @@ func runWeztermLoginAll(cmd *cobra.Command, args []string) error {
 	case "claude", "codex", "gemini":
 		// ok
 	default:
-		return fmt.Errorf("unknown tool: %s (supported: claude, codex, gemini)", tool)
+		return caamerr.Errorf(caamerr.InvalidProvider, "unknown tool: %s (supported: claude, codex, gemini)", tool)
 	}
 
 	if _, err := weztermLookupFunc("wezterm"); err != nil {
@@ -203,9 +214,19 @@ func runWeztermLoginAll(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no panes matched (use --all to force)")
 	}
 
+	guard, err := weztermInjectionGuard(tool)
+	if err != nil {
+		return err
+	}
+
 	if dryRun {
 		fmt.Fprintf(cmd.OutOrStdout(), "Would send /login to %d pane(s):\n", len(targets))
 		for _, target := range targets {
+			text, _ := weztermGetTextFunc(target.Pane.ID)
+			if ok, reason := guard.Check(target.Pane.coordinatorPane(), text, weztermNow()); !ok {
+				fmt.Fprintf(cmd.OutOrStdout(), "  pane %d %s (%s) - would skip: %s\n", target.Pane.ID, target.Pane.Title, target.Reason, reason)
+				continue
+			}
 			fmt.Fprintf(cmd.OutOrStdout(), "  pane %d %s (%s)\n", target.Pane.ID, target.Pane.Title, target.Reason)
 		}
 		return nil
@@ -230,22 +251,49 @@ func runWeztermLoginAll(cmd *cobra.Command, args []string) error {
 		payload += "1\n"
 	}
 
-	successCount := 0
-	failCount := 0
-	for _, target := range targets {
-		if err := weztermSendTextFunc(target.Pane.ID, payload); err != nil {
+	verifyTimeout, _ := cmd.Flags().GetDuration("verify-timeout")
+	jsonOut, _ := cmd.Flags().GetBool("json")
+
+	targetPanes := make([]weztermPane, len(targets))
+	for i, target := range targets {
+		targetPanes[i] = target.Pane
+	}
+	results := weztermInjectAll(targetPanes, func(weztermPane) string { return payload }, verifyTimeout, guard, logger)
+
+	if jsonOut {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	successCount, unverifiedCount, skippedCount, failCount := 0, 0, 0, 0
+	for _, res := range results {
+		switch {
+		case res.Skipped != "":
+			skippedCount++
+			fmt.Fprintf(cmd.ErrOrStderr(), "pane %d: skipped (%s)\n", res.PaneID, res.Skipped)
+		case res.Error != "":
 			failCount++
-			fmt.Fprintf(cmd.ErrOrStderr(), "pane %d: %v\n", target.Pane.ID, err)
-			continue
+			fmt.Fprintf(cmd.ErrOrStderr(), "pane %d: %s\n", res.PaneID, res.Error)
+		case res.Checked && !res.Verified:
+			unverifiedCount++
+			fmt.Fprintf(cmd.ErrOrStderr(), "pane %d: sent, but the pane did not echo /login (is it in an editor?)\n", res.PaneID)
+		default:
+			successCount++
 		}
-		successCount++
 	}
 
+	summary := fmt.Sprintf("Targeted %d pane(s): %d succeeded", len(targets), successCount)
+	if unverifiedCount > 0 {
+		summary += fmt.Sprintf(", %d unverified", unverifiedCount)
+	}
+	if skippedCount > 0 {
+		summary += fmt.Sprintf(", %d skipped by injection guards", skippedCount)
+	}
 	if failCount > 0 {
-		fmt.Fprintf(cmd.OutOrStdout(), "Targeted %d pane(s): %d succeeded, %d failed.\n", len(targets), successCount, failCount)
-	} else {
-		fmt.Fprintf(cmd.OutOrStdout(), "Targeted %d pane(s): %d succeeded.\n", len(targets), successCount)
+		summary += fmt.Sprintf(", %d failed", failCount)
 	}
+	fmt.Fprintln(cmd.OutOrStdout(), summary+".")
 	return nil
 }
 
@@ -537,7 +585,8 @@ func weztermListPanes() ([]weztermPane, error) {
 			continue
 		}
 		title := getStringField(item, "title", "tab_title", "domain_name", "workspace")
-		panes = append(panes, weztermPane{ID: id, Title: title})
+		active, _ := item["is_active"].(bool)
+		panes = append(panes, weztermPane{ID: id, Title: title, IsActive: active, TTYName: getStringField(item, "tty_name")})
 	}
 	return panes, nil
 }
@@ -572,6 +621,165 @@ func weztermSendText(paneID int, text string) error {
 	return nil
 }
 
+// defaultWeztermVerifyTimeout is how long an injection waits for the pane to
+// echo it before retrying.
+const defaultWeztermVerifyTimeout = 2 * time.Second
+
+// weztermSendMu serializes send-text calls so concurrent injections never
+// interleave keystrokes.
+var weztermSendMu sync.Mutex
+
+// weztermInjectResult reports one pane injection.
+type weztermInjectResult struct {
+	PaneID int    `json:"pane_id"`
+	Title  string `json:"title,omitempty"`
+	Sent   bool   `json:"sent"`
+	// Checked is false when verification was disabled.
+	Checked  bool   `json:"checked"`
+	Verified bool   `json:"verified"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+	// Skipped names the injection guard rule that blocked the pane.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// OK reports whether the injection can be counted as a success.
+func (r weztermInjectResult) OK() bool {
+	return r.Sent && (!r.Checked || r.Verified)
+}
+
+// weztermInjectAll injects text into each pane concurrently and verifies the
+// echo, returning results in pane order.
+func weztermInjectAll(panes []weztermPane, textFor func(weztermPane) string, verifyTimeout time.Duration, guard *coordinator.InjectionGuard, logger *slog.Logger) []weztermInjectResult {
+	results := make([]weztermInjectResult, len(panes))
+	var wg sync.WaitGroup
+	for i, pane := range panes {
+		wg.Add(1)
+		go func(i int, pane weztermPane) {
+			defer wg.Done()
+			results[i] = weztermInjectVerified(pane, textFor(pane), verifyTimeout, guard, logger)
+		}(i, pane)
+	}
+	wg.Wait()
+	return results
+}
+
+// weztermInjectVerified sends text to a pane and waits up to verifyTimeout
+// for the pane to echo it or for its recovery state to advance. A pane that
+// shows neither (say, vim swallowed the keystrokes) gets one retry. The guard
+// is consulted before every attempt.
+func weztermInjectVerified(pane weztermPane, text string, verifyTimeout time.Duration, guard *coordinator.InjectionGuard, logger *slog.Logger) weztermInjectResult {
+	res := weztermInjectResult{PaneID: pane.ID, Title: pane.Title, Checked: verifyTimeout > 0}
+
+	for res.Attempts < 2 {
+		var before string
+		if res.Checked || guard != nil {
+			before, _ = weztermGetTextFunc(pane.ID)
+		}
+		if ok, reason := guard.Check(pane.coordinatorPane(), before, weztermNow()); !ok {
+			res.Skipped = reason
+			if logger != nil {
+				logger.Info("wezterm injection blocked by guard", "pane_id", pane.ID, "reason", reason)
+			}
+			return res
+		}
+
+		res.Attempts++
+		weztermSendMu.Lock()
+		err := weztermSendTextFunc(pane.ID, text)
+		weztermSendMu.Unlock()
+		if err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		res.Sent = true
+		res.Error = ""
+		if !res.Checked {
+			return res
+		}
+
+		if weztermAwaitEcho(pane.ID, before, text, verifyTimeout) {
+			res.Verified = true
+			return res
+		}
+		if logger != nil {
+			logger.Warn("wezterm injection not echoed", "pane_id", pane.ID, "attempt", res.Attempts)
+		}
+	}
+	return res
+}
+
+// weztermAwaitEcho polls a pane until its output shows the injected text or
+// its recovery state changes, or timeout elapses.
+func weztermAwaitEcho(paneID int, before, text string, timeout time.Duration) bool {
+	echo := weztermEchoToken(text)
+	beforeNorm := normalizeWeztermText(before)
+	beforeState, _, _ := detectRecoverState(before)
+
+	deadline := weztermNow().Add(timeout)
+	for {
+		time.Sleep(weztermVerifyPoll)
+		after, err := weztermGetTextFunc(paneID)
+		if err == nil && after != before {
+			afterState, _, _ := detectRecoverState(after)
+			if afterState != beforeState {
+				return true
+			}
+			afterNorm := normalizeWeztermText(after)
+			if echo != "" && strings.Count(afterNorm, echo) > strings.Count(beforeNorm, echo) {
+				return true
+			}
+		}
+		if !weztermNow().Before(deadline) {
+			return false
+		}
+	}
+}
+
+// coordinatorPane converts a pane for the injection guard.
+func (p weztermPane) coordinatorPane() coordinator.Pane {
+	return coordinator.Pane{PaneID: p.ID, Title: p.Title, IsActive: p.IsActive, TTYName: p.TTYName}
+}
+
+// weztermInjectionGuard builds the injection guard from the injections
+// config. require_claude_prompt only applies to Claude injections.
+func weztermInjectionGuard(tool string) (*coordinator.InjectionGuard, error) {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		spmCfg = config.DefaultSPMConfig()
+	}
+	cfg := spmCfg.Injections
+	if tool != "claude" {
+		cfg.RequireClaudePrompt = false
+	}
+	return newInjectionGuard(cfg)
+}
+
+func newInjectionGuard(cfg config.InjectionsConfig) (*coordinator.InjectionGuard, error) {
+	return coordinator.NewInjectionGuard(coordinator.GuardConfig{
+		ExcludeTitles:       cfg.ExcludeTitles,
+		ExcludeProcesses:    cfg.ExcludeProcesses,
+		HumanActiveWindow:   cfg.HumanActiveWindow.Duration(),
+		RequireClaudePrompt: cfg.RequireClaudePrompt,
+	})
+}
+
+// weztermEchoToken returns the part of an injection expected to appear in
+// the pane: its first non-empty line, capped so wrapped prompts still match.
+func weztermEchoToken(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if len(line) > 40 {
+			line = line[:40]
+		}
+		return line
+	}
+	return ""
+}
+
 func getIntField(m map[string]any, keys ...string) int {
 	for _, key := range keys {
 		if v, ok := m[key]; ok {
@@ -945,42 +1153,79 @@ func runAutoRecover(cmd *cobra.Command, states []*RecoverPaneState, yes bool, re
 		}
 	}
 
-	// Execute actions
-	success, fail := 0, 0
+	// Let prompts settle before answering them.
+	settle := time.Duration(0)
 	for _, s := range actionable {
-		var err error
+		switch s.State {
+		case RecoverAwaitingSelect:
+			settle = max(settle, 200*time.Millisecond)
+		case RecoverResuming:
+			settle = max(settle, 500*time.Millisecond)
+		}
+	}
+	time.Sleep(settle)
+
+	// Execute actions
+	verifyTimeout, _ := cmd.Flags().GetDuration("verify-timeout")
+	panes := make([]weztermPane, len(actionable))
+	payloads := make(map[int]string, len(actionable))
+	for i, s := range actionable {
+		panes[i] = s.Pane
 		switch s.State {
 		case RecoverRateLimited:
-			err = weztermSendTextFunc(s.Pane.ID, "/login\n")
-			if err == nil && logger != nil {
-				logger.Debug("injected /login", "pane_id", s.Pane.ID)
-			}
+			payloads[s.Pane.ID] = "/login\n"
 		case RecoverAwaitingSelect:
-			time.Sleep(200 * time.Millisecond)
-			err = weztermSendTextFunc(s.Pane.ID, "1\n")
-			if err == nil && logger != nil {
-				logger.Debug("injected subscription select", "pane_id", s.Pane.ID)
-			}
+			payloads[s.Pane.ID] = "1\n"
 		case RecoverResuming:
-			time.Sleep(500 * time.Millisecond)
-			err = weztermSendTextFunc(s.Pane.ID, resumePrompt)
-			if err == nil && logger != nil {
-				logger.Debug("injected resume prompt", "pane_id", s.Pane.ID)
-			}
+			payloads[s.Pane.ID] = resumePrompt
 		}
-		if err != nil {
-			fail++
-			fmt.Fprintf(cmd.ErrOrStderr(), "  pane %d: FAILED - %v\n", s.Pane.ID, err)
-		} else {
+	}
+	guard, err := weztermInjectionGuard("claude")
+	if err != nil {
+		return err
+	}
+	results := weztermInjectAll(panes, func(p weztermPane) string { return payloads[p.ID] }, verifyTimeout, guard, logger)
+
+	success, skipped, fail := 0, 0, 0
+	for i, res := range results {
+		switch {
+		case res.Skipped != "":
+			skipped++
+		case res.OK():
 			success++
-			fmt.Fprintf(cmd.OutOrStdout(), "  pane %d: OK\n", s.Pane.ID)
+		default:
+			fail++
 		}
+		if logger != nil && res.Sent {
+			logger.Debug("injected", "pane_id", res.PaneID, "state", actionable[i].State.String(), "verified", res.Verified)
+		}
+		printInjectResult(cmd, res)
 	}
 
-	fmt.Fprintf(cmd.OutOrStdout(), "\nDone: %d succeeded, %d failed.\n", success, fail)
+	if skipped > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "\nDone: %d succeeded, %d skipped, %d failed.\n", success, skipped, fail)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "\nDone: %d succeeded, %d failed.\n", success, fail)
+	}
 	return nil
 }
 
+// printInjectResult prints one pane's injection outcome for recover.
+func printInjectResult(cmd *cobra.Command, res weztermInjectResult) {
+	switch {
+	case res.Skipped != "":
+		fmt.Fprintf(cmd.OutOrStdout(), "  pane %d: SKIPPED - %s\n", res.PaneID, res.Skipped)
+	case res.Error != "":
+		fmt.Fprintf(cmd.ErrOrStderr(), "  pane %d: FAILED - %s\n", res.PaneID, res.Error)
+	case !res.Checked:
+		fmt.Fprintf(cmd.OutOrStdout(), "  pane %d: OK\n", res.PaneID)
+	case res.Verified:
+		fmt.Fprintf(cmd.OutOrStdout(), "  pane %d: OK (verified)\n", res.PaneID)
+	default:
+		fmt.Fprintf(cmd.ErrOrStderr(), "  pane %d: UNVERIFIED - no echo after %d attempts\n", res.PaneID, res.Attempts)
+	}
+}
+
 func runInteractiveRecover(cmd *cobra.Command, states []*RecoverPaneState, resumePrompt string, logger *slog.Logger) error {
 	if !weztermIsTerminal(int(os.Stdin.Fd())) {
 		return fmt.Errorf("interactive mode requires a terminal (use --status or --auto)")
@@ -1061,19 +1306,24 @@ func runInteractiveRecover(cmd *cobra.Command, states []*RecoverPaneState, resum
 }
 
 func injectToState(cmd *cobra.Command, states []*RecoverPaneState, targetState RecoverState, text string, logger *slog.Logger) {
-	count := 0
+	var panes []weztermPane
 	for _, s := range states {
-		if s.State != targetState {
-			continue
-		}
-		if err := weztermSendTextFunc(s.Pane.ID, text); err != nil {
-			fmt.Fprintf(cmd.ErrOrStderr(), "  pane %d: FAILED - %v\n", s.Pane.ID, err)
-		} else {
-			fmt.Fprintf(cmd.OutOrStdout(), "  pane %d: OK\n", s.Pane.ID)
-			count++
+		if s.State == targetState {
+			panes = append(panes, s.Pane)
 		}
 	}
-	if count == 0 {
+	if len(panes) == 0 {
 		fmt.Fprintln(cmd.OutOrStdout(), "  (no panes in target state)")
+		return
+	}
+
+	guard, err := weztermInjectionGuard("claude")
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "  %v\n", err)
+		return
+	}
+	verifyTimeout, _ := cmd.Flags().GetDuration("verify-timeout")
+	for _, res := range weztermInjectAll(panes, func(weztermPane) string { return text }, verifyTimeout, guard, logger) {
+		printInjectResult(cmd, res)
 	}
 }
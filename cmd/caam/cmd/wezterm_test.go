@@ -6,6 +6,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -326,3 +327,58 @@ func TestWeztermOAuthReportRedactsLogs(t *testing.T) {
 		t.Fatalf("expected logs to redact urls, got: %s", logs)
 	}
 }
+
+func TestWeztermInjectVerified(t *testing.T) {
+	savedGet := weztermGetTextFunc
+	savedSend := weztermSendTextFunc
+	savedPoll := weztermVerifyPoll
+	defer func() {
+		weztermGetTextFunc = savedGet
+		weztermSendTextFunc = savedSend
+		weztermVerifyPoll = savedPoll
+	}()
+	weztermVerifyPoll = time.Millisecond
+
+	// Pane 1 echoes what it is sent; pane 2 is in vim and swallows it.
+	screens := map[int]string{1: "You've hit your limit", 2: "~\n~\n-- NORMAL --"}
+	sends := map[int]int{}
+	var mu sync.Mutex
+	weztermGetTextFunc = func(paneID int) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return screens[paneID], nil
+	}
+	weztermSendTextFunc = func(paneID int, text string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sends[paneID]++
+		if paneID == 1 {
+			screens[paneID] += "\n> " + text
+		}
+		return nil
+	}
+
+	panes := []weztermPane{{ID: 1}, {ID: 2}}
+	results := weztermInjectAll(panes, func(weztermPane) string { return "/login\n" }, 50*time.Millisecond, nil, nil)
+
+	if !results[0].Verified || results[0].Attempts != 1 || !results[0].OK() {
+		t.Fatalf("pane 1 result = %+v, want verified on first attempt", results[0])
+	}
+	if results[1].Verified || results[1].Attempts != 2 || results[1].OK() {
+		t.Fatalf("pane 2 result = %+v, want unverified after retry", results[1])
+	}
+	if sends[2] != 2 {
+		t.Fatalf("pane 2 sends = %d, want 2", sends[2])
+	}
+}
+
+func TestWeztermInjectVerifiedDisabled(t *testing.T) {
+	savedSend := weztermSendTextFunc
+	defer func() { weztermSendTextFunc = savedSend }()
+	weztermSendTextFunc = func(int, string) error { return nil }
+
+	res := weztermInjectVerified(weztermPane{ID: 3}, "/login\n", 0, nil, nil)
+	if res.Checked || !res.Sent || !res.OK() || res.Attempts != 1 {
+		t.Fatalf("result = %+v, want unchecked success", res)
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 )
 
 var workspaceCmd = &cobra.Command{
@@ -86,7 +88,7 @@ func runWorkspace(cmd *cobra.Command, args []string) error {
 	// Load config
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
 	}
 
 	// If no args, list workspaces
@@ -104,13 +106,13 @@ func runWorkspaceCreate(cmd *cobra.Command, args []string) error {
 
 	// Validate workspace name
 	if strings.HasPrefix(workspaceName, "_") {
-		return fmt.Errorf("workspace names starting with '_' are reserved")
+		return caamerr.Errorf(caamerr.InvalidArgs, "workspace names starting with '_' are reserved")
 	}
 
 	// Load config
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
 	}
 
 	// Collect profile mappings from flags
@@ -153,7 +155,7 @@ func runWorkspaceCreate(cmd *cobra.Command, args []string) error {
 			}
 		}
 		if !found {
-			return fmt.Errorf("profile %s/%s does not exist", tool, profile)
+			return caamerr.Errorf(caamerr.ProfileNotFound, "profile %s/%s does not exist", tool, profile)
 		}
 	}
 
@@ -167,7 +169,7 @@ func runWorkspaceCreate(cmd *cobra.Command, args []string) error {
 	cfg.CreateWorkspace(workspaceName, profiles)
 
 	if err := cfg.Save(); err != nil {
-		return fmt.Errorf("save config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "save config: %w", err)
 	}
 
 	fmt.Printf("Created workspace '%s':\n", workspaceName)
@@ -190,15 +192,15 @@ func runWorkspaceDelete(cmd *cobra.Command, args []string) error {
 	// Load config
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
 	}
 
 	if !cfg.DeleteWorkspace(workspaceName) {
-		return fmt.Errorf("workspace '%s' not found; run 'caam workspace ls' to see available workspaces", workspaceName)
+		return caamerr.Errorf(caamerr.NotFound, "workspace '%s' not found; run 'caam workspace ls' to see available workspaces", workspaceName)
 	}
 
 	if err := cfg.Save(); err != nil {
-		return fmt.Errorf("save config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "save config: %w", err)
 	}
 
 	fmt.Printf("Deleted workspace '%s'\n", workspaceName)
@@ -211,7 +213,7 @@ func runWorkspaceList(cmd *cobra.Command, args []string) error {
 	// Load config
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+		return caamerr.Errorf(caamerr.ConfigError, "load config: %w", err)
 	}
 
 	workspaces := cfg.ListWorkspaces()
@@ -277,7 +279,7 @@ func runWorkspaceList(cmd *cobra.Command, args []string) error {
 func switchWorkspace(cfg *config.Config, workspaceName string) error {
 	profiles := cfg.GetWorkspace(workspaceName)
 	if profiles == nil {
-		return fmt.Errorf("workspace '%s' not found; run 'caam workspace ls' to see available workspaces", workspaceName)
+		return caamerr.Errorf(caamerr.NotFound, "workspace '%s' not found; run 'caam workspace ls' to see available workspaces", workspaceName)
 	}
 
 	// Initialize vault if needed
@@ -314,10 +316,11 @@ func switchWorkspace(cfg *config.Config, workspaceName string) error {
 		}
 
 		// Restore profile
-		if err := vault.Restore(fileSet, profile); err != nil {
+		if err := activateProfile(fileSet, profile, "workspace", activationOptions{}); err != nil {
 			fmt.Printf("  Error activating %s/%s: %v\n", tool, profile, err)
 			continue
 		}
+		events.PublishActivated(tool, profile, "workspace")
 
 		activated = append(activated, fmt.Sprintf("%s: %s", tool, profile))
 	}
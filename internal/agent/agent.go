@@ -43,6 +43,10 @@ type Config struct {
 	// Accounts is the list of account emails to cycle through.
 	Accounts []string
 
+	// FallbackAccount is tried once when OAuth fails with the selected
+	// account (e.g., a designated account kept for recovery).
+	FallbackAccount string
+
 	// Logger for structured logging.
 	Logger *slog.Logger
 }
@@ -307,6 +311,16 @@ func (a *Agent) processAuthRequest(ctx context.Context, requestID, authURL strin
 
 	// Complete OAuth
 	code, usedAccount, err := a.browser.CompleteOAuth(ctx, authURL, account)
+	if fallback := a.config.FallbackAccount; err != nil && fallback != "" && fallback != account {
+		a.logger.Warn("OAuth failed, retrying with fallback account",
+			"request_id", requestID,
+			"account", account,
+			"fallback", fallback,
+			"error", err)
+		a.recordUsage(account, "failed")
+		account = fallback
+		code, usedAccount, err = a.browser.CompleteOAuth(ctx, authURL, account)
+	}
 	if err != nil {
 		a.logger.Error("OAuth failed",
 			"request_id", requestID,
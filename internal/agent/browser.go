@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 )
 
@@ -58,6 +61,10 @@ func (b *Browser) Close() {
 // CompleteOAuth navigates to the OAuth URL and extracts the challenge code.
 // If preferredAccount is set, it will try to select that Google account.
 // Returns the code, the account actually used, and any error.
+//
+// Flows that redirect back to a CLI (Codex's localhost server, Gemini's
+// code page) are finished by watching for the redirect; see callbackResult
+// for what is returned in that case.
 func (b *Browser) CompleteOAuth(ctx context.Context, oauthURL, preferredAccount string) (string, string, error) {
 	// Only log URL details at debug level to avoid exposing tokens
 	b.logger.Debug("starting OAuth flow",
@@ -110,7 +117,24 @@ func (b *Browser) CompleteOAuth(ctx context.Context, oauthURL, preferredAccount
 	var code string
 	var usedAccount string
 
+	// The CLI's localhost server is on the remote machine, so a loopback
+	// redirect fails to load here. Catch it as the request goes out.
+	var (
+		callbackMu sync.Mutex
+		callback   string
+	)
+	chromedp.ListenTarget(taskCtx, func(ev interface{}) {
+		if e, ok := ev.(*network.EventRequestWillBeSent); ok {
+			if result, ok := callbackResult(oauthURL, e.Request.URL); ok {
+				callbackMu.Lock()
+				callback = result
+				callbackMu.Unlock()
+			}
+		}
+	})
+
 	err := chromedp.Run(taskCtx,
+		network.Enable(),
 		// Navigate to OAuth URL
 		chromedp.Navigate(oauthURL),
 		chromedp.WaitReady("body"),
@@ -139,6 +163,18 @@ func (b *Browser) CompleteOAuth(ctx context.Context, oauthURL, preferredAccount
 			"attempt", attempt,
 			"url", truncateURL(currentURL, 80))
 
+		// Check if the flow redirected back to the CLI
+		callbackMu.Lock()
+		result := callback
+		callbackMu.Unlock()
+		if result == "" {
+			result, _ = callbackResult(oauthURL, currentURL)
+		}
+		if result != "" {
+			b.logger.Info("captured OAuth redirect")
+			return result, usedAccount, nil
+		}
+
 		// Check if we have a challenge code
 		if code = extractChallengeCode(pageHTML); code != "" {
 			b.logger.Info("extracted challenge code")
@@ -281,6 +317,43 @@ func (b *Browser) CompleteOAuth(ctx context.Context, oauthURL, preferredAccount
 	return "", "", fmt.Errorf("could not complete OAuth flow - no challenge code found")
 }
 
+// callbackResult reports whether target is the OAuth flow's redirect back to
+// the CLI, and what to hand the coordinator if so. Loopback redirects (Codex)
+// return the whole URL, which the coordinator requests on the remote machine.
+// Other redirects (Gemini's code page) return the code query parameter.
+// Claude's redirect page shows a code#state value that is scraped from the
+// page instead, so it is never matched here.
+func callbackResult(oauthURL, target string) (string, bool) {
+	auth, err := url.Parse(oauthURL)
+	if err != nil {
+		return "", false
+	}
+	redirect, err := url.Parse(auth.Query().Get("redirect_uri"))
+	if err != nil || redirect.Host == "" {
+		return "", false
+	}
+	host := redirect.Hostname()
+	if strings.HasSuffix(host, "claude.ai") || strings.HasSuffix(host, "anthropic.com") {
+		return "", false
+	}
+
+	got, err := url.Parse(target)
+	if err != nil || got.Host != redirect.Host || got.Path != redirect.Path {
+		return "", false
+	}
+	code := got.Query().Get("code")
+	if code == "" {
+		return "", false
+	}
+
+	switch host {
+	case "localhost", "127.0.0.1", "::1":
+		return target, true
+	default:
+		return code, true
+	}
+}
+
 // extractChallengeCode finds the challenge code in HTML content.
 func extractChallengeCode(html string) string {
 	// Look for common patterns:
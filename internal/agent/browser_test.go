@@ -544,3 +544,34 @@ func TestFormatSelector(t *testing.T) {
 		t.Errorf("formatSelector() = %q, want %q", selector, expected)
 	}
 }
+
+func TestCallbackResult(t *testing.T) {
+	codexAuth := "https://auth.openai.com/oauth/authorize?response_type=code&client_id=app_x&redirect_uri=http%3A%2F%2Flocalhost%3A1455%2Fauth%2Fcallback&state=s1"
+	geminiAuth := "https://accounts.google.com/o/oauth2/v2/auth?redirect_uri=https%3A%2F%2Fcodeassist.google.com%2Fauthcode&client_id=x"
+	claudeAuth := "https://claude.ai/oauth/authorize?code=true&redirect_uri=https%3A%2F%2Fconsole.anthropic.com%2Foauth%2Fcode%2Fcallback"
+
+	tests := []struct {
+		name   string
+		auth   string
+		target string
+		want   string
+		ok     bool
+	}{
+		{"codex loopback returns full URL", codexAuth, "http://localhost:1455/auth/callback?code=abc&state=s1", "http://localhost:1455/auth/callback?code=abc&state=s1", true},
+		{"codex other page", codexAuth, "https://auth.openai.com/log-in", "", false},
+		{"codex callback without code", codexAuth, "http://localhost:1455/auth/callback?error=access_denied", "", false},
+		{"gemini returns code", geminiAuth, "https://codeassist.google.com/authcode?code=4%2F0Axyz&scope=email", "4/0Axyz", true},
+		{"gemini wrong path", geminiAuth, "https://codeassist.google.com/other?code=abc", "", false},
+		{"claude is scraped instead", claudeAuth, "https://console.anthropic.com/oauth/code/callback?code=abc&state=s", "", false},
+		{"no redirect_uri", "https://example.com/oauth?x=1", "https://example.com/cb?code=abc", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := callbackResult(tt.auth, tt.target)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("callbackResult() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
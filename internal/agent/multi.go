@@ -64,6 +64,10 @@ type MultiConfig struct {
 	// Accounts is the list of account emails to cycle through.
 	Accounts []string `json:"accounts"`
 
+	// FallbackAccount is tried once when OAuth fails with the selected
+	// account.
+	FallbackAccount string `json:"fallback_account,omitempty"`
+
 	// Logger for structured logging.
 	Logger *slog.Logger `json:"-"`
 }
@@ -352,6 +356,17 @@ func (a *MultiAgent) processAuthRequest(ctx context.Context, coord *CoordinatorE
 
 	// Complete OAuth
 	code, usedAccount, err := a.browser.CompleteOAuth(ctx, authURL, account)
+	if fallback := a.config.FallbackAccount; err != nil && fallback != "" && fallback != account {
+		a.logger.Warn("OAuth failed, retrying with fallback account",
+			"coordinator", coord.Name,
+			"request_id", requestID,
+			"account", account,
+			"fallback", fallback,
+			"error", err)
+		a.recordUsage(account, "failed")
+		account = fallback
+		code, usedAccount, err = a.browser.CompleteOAuth(ctx, authURL, account)
+	}
 	if err != nil {
 		a.logger.Error("OAuth failed",
 			"coordinator", coord.Name,
@@ -0,0 +1,259 @@
+// Package anomaly looks for signs that a provider has flagged or banned an
+// account in the history caam already keeps: probe results, rate-limit
+// hits and token revocations. A raw error count can't tell a ban from a
+// bad network day; these patterns can:
+//
+//   - requests refused with 403 while rate limits (429) stay rare
+//   - a token revoked again soon after each fresh login
+//   - rate limits hit much sooner after each reset than they used to be
+//
+// Analyze only reads history. Callers decide what a suspect profile means;
+// robot status reports it and robot next skips it.
+package anomaly
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+// Kinds of evidence.
+const (
+	// ForbiddenSpike means probes were refused with 403 more often than
+	// the profile was rate-limited.
+	ForbiddenSpike = "forbidden_spike"
+	// LoginLoop means the token was revoked again after a fresh login.
+	LoginLoop = "login_loop"
+	// ShortenedLimits means the profile hits its rate limit much sooner
+	// after each reset than it used to.
+	ShortenedLimits = "shortened_rate_limits"
+)
+
+// Lookback is how much history Analyze uses. The shortened-limits check
+// compares recent limits against the rest of it.
+const Lookback = 30 * 24 * time.Hour
+
+// continuedBlock is how soon after a cooldown ends a new limit hit counts
+// as the same block going on, such as a cooldown extended because the
+// provider still refused requests at the advertised reset.
+const continuedBlock = 5 * time.Minute
+
+// Evidence is one pattern found in a profile's history.
+type Evidence struct {
+	Kind   string    `json:"kind"`
+	Detail string    `json:"detail"`
+	Count  int       `json:"count"`
+	LastAt time.Time `json:"last_at"`
+}
+
+// Config sets how much of each pattern makes a profile suspect. Zero
+// fields take the DefaultConfig values.
+type Config struct {
+	// Window is how recent forbidden requests and revocations must be,
+	// and how recent the last rate limit must be to judge its timing.
+	Window time.Duration
+	// MinForbidden is how many 403s in Window make a spike.
+	MinForbidden int
+	// MinRevocations is how many revocations in Window make a login loop.
+	MinRevocations int
+	// ShrinkRatio is how short, as a fraction of the usual time, the time
+	// from a reset to the next limit must be for the last two limits.
+	ShrinkRatio float64
+	// MinBaseline is how many earlier reset-to-limit times are needed to
+	// know what is usual.
+	MinBaseline int
+}
+
+// DefaultConfig returns the thresholds caam uses.
+func DefaultConfig() Config {
+	return Config{
+		Window:         24 * time.Hour,
+		MinForbidden:   2,
+		MinRevocations: 2,
+		ShrinkRatio:    0.5,
+		MinBaseline:    3,
+	}
+}
+
+func (c Config) withDefaults() Config {
+	d := DefaultConfig()
+	if c.Window <= 0 {
+		c.Window = d.Window
+	}
+	if c.MinForbidden <= 0 {
+		c.MinForbidden = d.MinForbidden
+	}
+	if c.MinRevocations <= 0 {
+		c.MinRevocations = d.MinRevocations
+	}
+	if c.ShrinkRatio <= 0 {
+		c.ShrinkRatio = d.ShrinkRatio
+	}
+	if c.MinBaseline <= 0 {
+		c.MinBaseline = d.MinBaseline
+	}
+	return c
+}
+
+// History is one profile's records, in any order.
+type History struct {
+	Probes      []caamdb.Probe
+	Cooldowns   []caamdb.CooldownEvent
+	Revocations []caamdb.Revocation
+}
+
+// Analyze returns the evidence in h that the profile's account is flagged,
+// as of now. No evidence means the profile is not suspect.
+func Analyze(h History, now time.Time, cfg Config) []Evidence {
+	cfg = cfg.withDefaults()
+	var found []Evidence
+	for _, check := range []func(History, time.Time, Config) *Evidence{
+		forbiddenSpike,
+		loginLoop,
+		shortenedLimits,
+	} {
+		if ev := check(h, now, cfg); ev != nil {
+			found = append(found, *ev)
+		}
+	}
+	return found
+}
+
+// forbiddenSpike counts 403s in the window since the last probe that got
+// through, against the rate limits in the same window. A banned account
+// is refused; a busy one is throttled.
+func forbiddenSpike(h History, now time.Time, cfg Config) *Evidence {
+	since := now.Add(-cfg.Window)
+	probes := append([]caamdb.Probe(nil), h.Probes...)
+	sort.Slice(probes, func(i, j int) bool { return probes[i].ProbedAt.After(probes[j].ProbedAt) })
+
+	forbidden, limited := 0, 0
+	var last time.Time
+	for _, p := range probes {
+		if p.ProbedAt.Before(since) || p.Success {
+			break
+		}
+		if p.HTTPStatus == 403 {
+			forbidden++
+			if last.IsZero() {
+				last = p.ProbedAt
+			}
+		}
+	}
+	for _, p := range probes {
+		if !p.ProbedAt.Before(since) && p.HTTPStatus == 429 {
+			limited++
+		}
+	}
+	for _, c := range h.Cooldowns {
+		if !c.HitAt.Before(since) {
+			limited++
+		}
+	}
+	if forbidden < cfg.MinForbidden || forbidden <= limited {
+		return nil
+	}
+	return &Evidence{
+		Kind:   ForbiddenSpike,
+		Detail: fmt.Sprintf("%d requests refused with 403 in the last %s, against %d rate limits", forbidden, formatDuration(cfg.Window), limited),
+		Count:  forbidden,
+		LastAt: last,
+	}
+}
+
+// loginLoop counts revocations in the window. A revocation stays open
+// until the next fresh login, so more than one means the account was
+// revoked again after logging back in.
+func loginLoop(h History, now time.Time, cfg Config) *Evidence {
+	since := now.Add(-cfg.Window)
+	count := 0
+	var last time.Time
+	for _, r := range h.Revocations {
+		if r.DetectedAt.Before(since) {
+			continue
+		}
+		count++
+		if r.DetectedAt.After(last) {
+			last = r.DetectedAt
+		}
+	}
+	if count < cfg.MinRevocations {
+		return nil
+	}
+	return &Evidence{
+		Kind:   LoginLoop,
+		Detail: fmt.Sprintf("token revoked %d times in the last %s despite fresh logins", count, formatDuration(cfg.Window)),
+		Count:  count,
+		LastAt: last,
+	}
+}
+
+// shortenedLimits compares how long the profile lasted after each of its
+// last two resets with the median of the times before them. A provider
+// that quietly lowers an account's limits shows up here first.
+func shortenedLimits(h History, now time.Time, cfg Config) *Evidence {
+	blocks := append([]caamdb.CooldownEvent(nil), h.Cooldowns...)
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].HitAt.Before(blocks[j].HitAt) })
+
+	// Merge continued blocks, then measure each reset-to-limit time.
+	var merged []caamdb.CooldownEvent
+	for _, b := range blocks {
+		if n := len(merged); n > 0 && b.HitAt.Sub(merged[n-1].CooldownUntil) < continuedBlock {
+			if b.CooldownUntil.After(merged[n-1].CooldownUntil) {
+				merged[n-1].CooldownUntil = b.CooldownUntil
+			}
+			continue
+		}
+		merged = append(merged, b)
+	}
+	if len(merged) == 0 || merged[len(merged)-1].HitAt.Before(now.Add(-cfg.Window)) {
+		return nil
+	}
+	var lasted []time.Duration
+	for i := 1; i < len(merged); i++ {
+		lasted = append(lasted, merged[i].HitAt.Sub(merged[i-1].CooldownUntil))
+	}
+	if len(lasted) < cfg.MinBaseline+2 {
+		return nil
+	}
+
+	recent := lasted[len(lasted)-2:]
+	usual := median(lasted[:len(lasted)-2])
+	limit := time.Duration(float64(usual) * cfg.ShrinkRatio)
+	if recent[0] >= limit || recent[1] >= limit {
+		return nil
+	}
+	return &Evidence{
+		Kind: ShortenedLimits,
+		Detail: fmt.Sprintf("rate-limited %s and %s after the last two resets, against a usual %s",
+			formatDuration(recent[0]), formatDuration(recent[1]), formatDuration(usual)),
+		Count:  2,
+		LastAt: merged[len(merged)-1].HitAt,
+	}
+}
+
+func median(ds []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// formatDuration formats d in hours and minutes, e.g. "24h" or "1h30m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h, m := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case h > 0 && m > 0:
+		return fmt.Sprintf("%dh%dm", h, m)
+	case h > 0:
+		return fmt.Sprintf("%dh", h)
+	default:
+		return fmt.Sprintf("%dm", m)
+	}
+}
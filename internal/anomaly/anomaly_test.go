@@ -0,0 +1,122 @@
+package anomaly
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func kinds(evs []Evidence) []string {
+	var out []string
+	for _, ev := range evs {
+		out = append(out, ev.Kind)
+	}
+	return out
+}
+
+func TestAnalyzeForbiddenSpike(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	probe := func(ago time.Duration, status int) caamdb.Probe {
+		return caamdb.Probe{ProbedAt: now.Add(-ago), HTTPStatus: status, Success: status == 200}
+	}
+
+	h := History{Probes: []caamdb.Probe{probe(time.Hour, 403), probe(3*time.Hour, 403), probe(5*time.Hour, 429)}}
+	got := Analyze(h, now, Config{})
+	if len(got) != 1 || got[0].Kind != ForbiddenSpike || got[0].Count != 2 || !got[0].LastAt.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("two 403s and one 429 = %+v, want a forbidden spike of 2", got)
+	}
+	if !strings.Contains(got[0].Detail, "2 requests refused with 403 in the last 24h, against 1 rate limits") {
+		t.Errorf("detail = %q", got[0].Detail)
+	}
+
+	// As many rate limits as 403s looks like a busy account, not a ban.
+	h.Cooldowns = []caamdb.CooldownEvent{{HitAt: now.Add(-2 * time.Hour), CooldownUntil: now.Add(time.Hour)}}
+	if got := Analyze(h, now, Config{}); len(got) != 0 {
+		t.Errorf("403s matched by rate limits = %v, want none", kinds(got))
+	}
+
+	// A probe that got through after the 403s clears them.
+	h = History{Probes: []caamdb.Probe{probe(10*time.Minute, 200), probe(time.Hour, 403), probe(2*time.Hour, 403)}}
+	if got := Analyze(h, now, Config{}); len(got) != 0 {
+		t.Errorf("403s before a success = %v, want none", kinds(got))
+	}
+
+	// 403s outside the window don't count.
+	h = History{Probes: []caamdb.Probe{probe(25*time.Hour, 403), probe(26*time.Hour, 403)}}
+	if got := Analyze(h, now, Config{}); len(got) != 0 {
+		t.Errorf("old 403s = %v, want none", kinds(got))
+	}
+}
+
+func TestAnalyzeLoginLoop(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	h := History{Revocations: []caamdb.Revocation{
+		{DetectedAt: now.Add(-20 * time.Hour)},
+		{DetectedAt: now.Add(-2 * time.Hour)},
+		{DetectedAt: now.Add(-48 * time.Hour)},
+	}}
+	got := Analyze(h, now, Config{})
+	if len(got) != 1 || got[0].Kind != LoginLoop || got[0].Count != 2 || !got[0].LastAt.Equal(now.Add(-2*time.Hour)) {
+		t.Fatalf("two revocations in a day = %+v, want a login loop of 2", got)
+	}
+
+	h.Revocations = h.Revocations[1:]
+	if got := Analyze(h, now, Config{}); len(got) != 0 {
+		t.Errorf("one recent revocation = %v, want none", kinds(got))
+	}
+}
+
+func TestAnalyzeShortenedLimits(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	// Each block: the account lasts `lasted` after the previous reset,
+	// then cools down for 5h.
+	build := func(lasted ...time.Duration) []caamdb.CooldownEvent {
+		var total time.Duration
+		for _, l := range lasted {
+			total += l + 5*time.Hour
+		}
+		at := now.Add(-total - 5*time.Hour + time.Hour)
+		var out []caamdb.CooldownEvent
+		for i, l := range append([]time.Duration{0}, lasted...) {
+			if i > 0 {
+				at = at.Add(l)
+			}
+			out = append(out, caamdb.CooldownEvent{HitAt: at, CooldownUntil: at.Add(5 * time.Hour)})
+			at = at.Add(5 * time.Hour)
+		}
+		return out
+	}
+
+	h := History{Cooldowns: build(4*time.Hour, 5*time.Hour, 4*time.Hour, 40*time.Minute, time.Hour)}
+	got := Analyze(h, now, Config{})
+	if len(got) != 1 || got[0].Kind != ShortenedLimits {
+		t.Fatalf("two short runs after a 4h baseline = %+v, want shortened limits", got)
+	}
+	if !strings.Contains(got[0].Detail, "40m and 1h after the last two resets, against a usual 4h") {
+		t.Errorf("detail = %q", got[0].Detail)
+	}
+
+	// One short run is ordinary heavy use.
+	h.Cooldowns = build(4*time.Hour, 5*time.Hour, 4*time.Hour, 4*time.Hour, 40*time.Minute)
+	if got := Analyze(h, now, Config{}); len(got) != 0 {
+		t.Errorf("one short run = %v, want none", kinds(got))
+	}
+
+	// Too little history to know what is usual.
+	h.Cooldowns = build(4*time.Hour, 40*time.Minute, time.Hour)
+	if got := Analyze(h, now, Config{}); len(got) != 0 {
+		t.Errorf("short baseline = %v, want none", kinds(got))
+	}
+
+	// An extension right at the reset continues the block instead of
+	// counting as a limit hit a minute after it.
+	h.Cooldowns = build(4*time.Hour, 5*time.Hour, 4*time.Hour, 4*time.Hour, 40*time.Minute)
+	last := h.Cooldowns[len(h.Cooldowns)-1]
+	h.Cooldowns = append(h.Cooldowns,
+		caamdb.CooldownEvent{HitAt: last.CooldownUntil.Add(time.Minute), CooldownUntil: last.CooldownUntil.Add(31 * time.Minute)})
+	if got := Analyze(h, now.Add(6*time.Hour), Config{}); len(got) != 0 {
+		t.Errorf("extended cooldown = %v, want none", kinds(got))
+	}
+}
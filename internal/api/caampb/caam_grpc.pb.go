@@ -0,0 +1,414 @@
+// gRPC interface to caam, served by `caam serve --grpc-port`.
+//
+// Regenerate the Go code after editing (run from the repository root):
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//     internal/api/caampb/caam.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: internal/api/caampb/caam.proto
+
+package caampb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Caam_ListProfiles_FullMethodName  = "/caam.v1.Caam/ListProfiles"
+	Caam_Activate_FullMethodName      = "/caam.v1.Caam/Activate"
+	Caam_SetCooldown_FullMethodName   = "/caam.v1.Caam/SetCooldown"
+	Caam_ClearCooldown_FullMethodName = "/caam.v1.Caam/ClearCooldown"
+	Caam_ListCooldowns_FullMethodName = "/caam.v1.Caam/ListCooldowns"
+	Caam_WatchStatus_FullMethodName   = "/caam.v1.Caam/WatchStatus"
+	Caam_StreamEvents_FullMethodName  = "/caam.v1.Caam/StreamEvents"
+)
+
+// CaamClient is the client API for Caam service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CaamClient interface {
+	// ListProfiles returns vault profiles, optionally for one provider.
+	ListProfiles(ctx context.Context, in *ListProfilesRequest, opts ...grpc.CallOption) (*ListProfilesResponse, error)
+	// Activate makes a vault profile the live auth for its provider. Like the
+	// other actions it runs `caam robot act`, so the approval workflow applies.
+	Activate(ctx context.Context, in *ActivateRequest, opts ...grpc.CallOption) (*ActionResponse, error)
+	// SetCooldown puts a profile into cooldown.
+	SetCooldown(ctx context.Context, in *SetCooldownRequest, opts ...grpc.CallOption) (*ActionResponse, error)
+	// ClearCooldown lifts a profile's cooldown.
+	ClearCooldown(ctx context.Context, in *ClearCooldownRequest, opts ...grpc.CallOption) (*ActionResponse, error)
+	// ListCooldowns returns the active cooldowns.
+	ListCooldowns(ctx context.Context, in *ListCooldownsRequest, opts ...grpc.CallOption) (*ListCooldownsResponse, error)
+	// WatchStatus streams each provider's profiles when they change, starting
+	// with the current state. It replaces polling `caam robot watch`.
+	WatchStatus(ctx context.Context, in *WatchStatusRequest, opts ...grpc.CallOption) (Caam_WatchStatusClient, error)
+	// StreamEvents streams the event bus (profile_activated, cooldown_set,
+	// token_refreshed, sync_completed, health_changed).
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (Caam_StreamEventsClient, error)
+}
+
+type caamClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCaamClient(cc grpc.ClientConnInterface) CaamClient {
+	return &caamClient{cc}
+}
+
+func (c *caamClient) ListProfiles(ctx context.Context, in *ListProfilesRequest, opts ...grpc.CallOption) (*ListProfilesResponse, error) {
+	out := new(ListProfilesResponse)
+	err := c.cc.Invoke(ctx, Caam_ListProfiles_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *caamClient) Activate(ctx context.Context, in *ActivateRequest, opts ...grpc.CallOption) (*ActionResponse, error) {
+	out := new(ActionResponse)
+	err := c.cc.Invoke(ctx, Caam_Activate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *caamClient) SetCooldown(ctx context.Context, in *SetCooldownRequest, opts ...grpc.CallOption) (*ActionResponse, error) {
+	out := new(ActionResponse)
+	err := c.cc.Invoke(ctx, Caam_SetCooldown_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *caamClient) ClearCooldown(ctx context.Context, in *ClearCooldownRequest, opts ...grpc.CallOption) (*ActionResponse, error) {
+	out := new(ActionResponse)
+	err := c.cc.Invoke(ctx, Caam_ClearCooldown_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *caamClient) ListCooldowns(ctx context.Context, in *ListCooldownsRequest, opts ...grpc.CallOption) (*ListCooldownsResponse, error) {
+	out := new(ListCooldownsResponse)
+	err := c.cc.Invoke(ctx, Caam_ListCooldowns_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *caamClient) WatchStatus(ctx context.Context, in *WatchStatusRequest, opts ...grpc.CallOption) (Caam_WatchStatusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Caam_ServiceDesc.Streams[0], Caam_WatchStatus_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &caamWatchStatusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Caam_WatchStatusClient interface {
+	Recv() (*StatusUpdate, error)
+	grpc.ClientStream
+}
+
+type caamWatchStatusClient struct {
+	grpc.ClientStream
+}
+
+func (x *caamWatchStatusClient) Recv() (*StatusUpdate, error) {
+	m := new(StatusUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *caamClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (Caam_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Caam_ServiceDesc.Streams[1], Caam_StreamEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &caamStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Caam_StreamEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type caamStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *caamStreamEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CaamServer is the server API for Caam service.
+// All implementations must embed UnimplementedCaamServer
+// for forward compatibility
+type CaamServer interface {
+	// ListProfiles returns vault profiles, optionally for one provider.
+	ListProfiles(context.Context, *ListProfilesRequest) (*ListProfilesResponse, error)
+	// Activate makes a vault profile the live auth for its provider. Like the
+	// other actions it runs `caam robot act`, so the approval workflow applies.
+	Activate(context.Context, *ActivateRequest) (*ActionResponse, error)
+	// SetCooldown puts a profile into cooldown.
+	SetCooldown(context.Context, *SetCooldownRequest) (*ActionResponse, error)
+	// ClearCooldown lifts a profile's cooldown.
+	ClearCooldown(context.Context, *ClearCooldownRequest) (*ActionResponse, error)
+	// ListCooldowns returns the active cooldowns.
+	ListCooldowns(context.Context, *ListCooldownsRequest) (*ListCooldownsResponse, error)
+	// WatchStatus streams each provider's profiles when they change, starting
+	// with the current state. It replaces polling `caam robot watch`.
+	WatchStatus(*WatchStatusRequest, Caam_WatchStatusServer) error
+	// StreamEvents streams the event bus (profile_activated, cooldown_set,
+	// token_refreshed, sync_completed, health_changed).
+	StreamEvents(*StreamEventsRequest, Caam_StreamEventsServer) error
+	mustEmbedUnimplementedCaamServer()
+}
+
+// UnimplementedCaamServer must be embedded to have forward compatible implementations.
+type UnimplementedCaamServer struct {
+}
+
+func (UnimplementedCaamServer) ListProfiles(context.Context, *ListProfilesRequest) (*ListProfilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProfiles not implemented")
+}
+func (UnimplementedCaamServer) Activate(context.Context, *ActivateRequest) (*ActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Activate not implemented")
+}
+func (UnimplementedCaamServer) SetCooldown(context.Context, *SetCooldownRequest) (*ActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetCooldown not implemented")
+}
+func (UnimplementedCaamServer) ClearCooldown(context.Context, *ClearCooldownRequest) (*ActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearCooldown not implemented")
+}
+func (UnimplementedCaamServer) ListCooldowns(context.Context, *ListCooldownsRequest) (*ListCooldownsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCooldowns not implemented")
+}
+func (UnimplementedCaamServer) WatchStatus(*WatchStatusRequest, Caam_WatchStatusServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchStatus not implemented")
+}
+func (UnimplementedCaamServer) StreamEvents(*StreamEventsRequest, Caam_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedCaamServer) mustEmbedUnimplementedCaamServer() {}
+
+// UnsafeCaamServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CaamServer will
+// result in compilation errors.
+type UnsafeCaamServer interface {
+	mustEmbedUnimplementedCaamServer()
+}
+
+func RegisterCaamServer(s grpc.ServiceRegistrar, srv CaamServer) {
+	s.RegisterService(&Caam_ServiceDesc, srv)
+}
+
+func _Caam_ListProfiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProfilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CaamServer).ListProfiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Caam_ListProfiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CaamServer).ListProfiles(ctx, req.(*ListProfilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Caam_Activate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActivateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CaamServer).Activate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Caam_Activate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CaamServer).Activate(ctx, req.(*ActivateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Caam_SetCooldown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetCooldownRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CaamServer).SetCooldown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Caam_SetCooldown_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CaamServer).SetCooldown(ctx, req.(*SetCooldownRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Caam_ClearCooldown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearCooldownRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CaamServer).ClearCooldown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Caam_ClearCooldown_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CaamServer).ClearCooldown(ctx, req.(*ClearCooldownRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Caam_ListCooldowns_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCooldownsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CaamServer).ListCooldowns(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Caam_ListCooldowns_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CaamServer).ListCooldowns(ctx, req.(*ListCooldownsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Caam_WatchStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CaamServer).WatchStatus(m, &caamWatchStatusServer{stream})
+}
+
+type Caam_WatchStatusServer interface {
+	Send(*StatusUpdate) error
+	grpc.ServerStream
+}
+
+type caamWatchStatusServer struct {
+	grpc.ServerStream
+}
+
+func (x *caamWatchStatusServer) Send(m *StatusUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Caam_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CaamServer).StreamEvents(m, &caamStreamEventsServer{stream})
+}
+
+type Caam_StreamEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type caamStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *caamStreamEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Caam_ServiceDesc is the grpc.ServiceDesc for Caam service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Caam_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "caam.v1.Caam",
+	HandlerType: (*CaamServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListProfiles",
+			Handler:    _Caam_ListProfiles_Handler,
+		},
+		{
+			MethodName: "Activate",
+			Handler:    _Caam_Activate_Handler,
+		},
+		{
+			MethodName: "SetCooldown",
+			Handler:    _Caam_SetCooldown_Handler,
+		},
+		{
+			MethodName: "ClearCooldown",
+			Handler:    _Caam_ClearCooldown_Handler,
+		},
+		{
+			MethodName: "ListCooldowns",
+			Handler:    _Caam_ListCooldowns_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchStatus",
+			Handler:       _Caam_WatchStatus_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _Caam_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "internal/api/caampb/caam.proto",
+}
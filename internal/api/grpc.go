@@ -0,0 +1,395 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/api/caampb"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+)
+
+// defaultWatchInterval is WatchStatus's poll interval when the client
+// doesn't pick one.
+const defaultWatchInterval = 5 * time.Second
+
+// grpcService implements caampb.CaamServer on top of the HTTP API's
+// handlers and robot runner.
+type grpcService struct {
+	caampb.UnimplementedCaamServer
+	server *Server
+}
+
+// ServeGRPC serves the gRPC API on ln until the server is stopped. It uses
+// the same token, handlers, robot runner, and event bus as the HTTP API.
+func (s *Server) ServeGRPC(ln net.Listener) error {
+	gs := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := s.checkGRPCToken(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.checkGRPCToken(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	caampb.RegisterCaamServer(gs, &grpcService{server: s})
+
+	go func() {
+		<-s.shutdownCh
+		gs.GracefulStop()
+	}()
+
+	s.logger.Info("gRPC server starting", "addr", ln.Addr().String())
+	if err := gs.Serve(ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// checkGRPCToken validates "authorization: Bearer <token>" metadata.
+func (s *Server) checkGRPCToken(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return nil
+}
+
+func (g *grpcService) ListProfiles(ctx context.Context, req *caampb.ListProfilesRequest) (*caampb.ListProfilesResponse, error) {
+	profiles, err := g.listProfiles(req.GetProvider())
+	if err != nil {
+		return nil, err
+	}
+	return &caampb.ListProfilesResponse{Profiles: profiles}, nil
+}
+
+func (g *grpcService) listProfiles(provider string) ([]*caampb.Profile, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider != "" {
+		if _, ok := tools[provider]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown provider: %s", provider)
+		}
+	}
+	resp, err := g.server.handlers.GetProfiles(provider)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	out := make([]*caampb.Profile, 0, len(resp.Profiles))
+	for _, p := range resp.Profiles {
+		out = append(out, profileToProto(p))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+func profileToProto(p ProfileInfo) *caampb.Profile {
+	pb := &caampb.Profile{
+		Provider: p.Tool,
+		Name:     p.Name,
+		Active:   p.Active,
+		System:   p.System,
+	}
+	if p.Health != nil {
+		pb.Health = &caampb.Health{
+			Status:            p.Health.Status,
+			ExpiresAt:         p.Health.ExpiresAt,
+			ErrorCount:        int32(p.Health.ErrorCount),
+			CooldownRemaining: p.Health.CooldownRemaining,
+		}
+	}
+	if p.Identity != nil {
+		pb.Email = p.Identity.Email
+		pb.PlanType = p.Identity.PlanType
+	}
+	return pb
+}
+
+func (g *grpcService) Activate(ctx context.Context, req *caampb.ActivateRequest) (*caampb.ActionResponse, error) {
+	if req.GetProvider() == "" || req.GetProfile() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider and profile are required")
+	}
+	return g.act(ctx, "activate", req.GetProvider(), req.GetProfile())
+}
+
+func (g *grpcService) SetCooldown(ctx context.Context, req *caampb.SetCooldownRequest) (*caampb.ActionResponse, error) {
+	if req.GetProvider() == "" || req.GetProfile() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider and profile are required")
+	}
+	args := []string{req.GetProvider(), req.GetProfile()}
+	if d := strings.TrimSpace(req.GetDuration()); d != "" {
+		if _, err := time.ParseDuration(d); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid duration %q", d)
+		}
+		args = append(args, d)
+	}
+	return g.act(ctx, "cooldown", args...)
+}
+
+func (g *grpcService) ClearCooldown(ctx context.Context, req *caampb.ClearCooldownRequest) (*caampb.ActionResponse, error) {
+	if req.GetProvider() == "" || req.GetProfile() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider and profile are required")
+	}
+	return g.act(ctx, "uncooldown", req.GetProvider(), req.GetProfile())
+}
+
+// act runs `caam robot act <action> <args...>` and maps its output. Robot
+// failures (including APPROVAL_REQUIRED) are returned in the response, not
+// as gRPC errors, so callers see the error code and proposal ID.
+func (g *grpcService) act(ctx context.Context, action string, args ...string) (*caampb.ActionResponse, error) {
+	if g.server.robot == nil {
+		return nil, status.Error(codes.Unimplemented, "robot commands are not available")
+	}
+	out, err := g.server.robot(ctx, RobotRequest{Command: "act", Args: append([]string{action}, args...)})
+	if len(out) == 0 {
+		switch {
+		case errors.Is(err, ErrRobotRequest):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case err != nil:
+			return nil, status.Error(codes.Internal, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, "robot command produced no output")
+		}
+	}
+
+	var envelope struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Message    string `json:"message"`
+			ProposalID int64  `json:"proposal_id"`
+		} `json:"data"`
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(out, &envelope); err != nil {
+		return nil, status.Errorf(codes.Internal, "decode robot output: %v", err)
+	}
+	resp := &caampb.ActionResponse{
+		Success:    envelope.Success,
+		Message:    envelope.Data.Message,
+		ProposalId: envelope.Data.ProposalID,
+		RobotJson:  strings.TrimSpace(string(out)),
+	}
+	if envelope.Error != nil {
+		resp.ErrorCode = envelope.Error.Code
+		resp.ErrorMessage = envelope.Error.Message
+	}
+	return resp, nil
+}
+
+func (g *grpcService) ListCooldowns(ctx context.Context, req *caampb.ListCooldownsRequest) (*caampb.ListCooldownsResponse, error) {
+	db := g.server.handlers.db
+	if db == nil {
+		return nil, status.Error(codes.Unavailable, "database not available")
+	}
+	cooldowns, err := db.ListActiveCooldowns(time.Now())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &caampb.ListCooldownsResponse{}
+	for _, c := range cooldowns {
+		resp.Cooldowns = append(resp.Cooldowns, &caampb.Cooldown{
+			Provider: c.Provider,
+			Profile:  c.ProfileName,
+			HitAt:    timestamppb.New(c.HitAt),
+			Until:    timestamppb.New(c.CooldownUntil),
+			Notes:    c.Notes,
+		})
+	}
+	return resp, nil
+}
+
+func (g *grpcService) WatchStatus(req *caampb.WatchStatusRequest, stream caampb.Caam_WatchStatusServer) error {
+	providers := []string{"claude", "codex", "gemini"}
+	if p := strings.ToLower(strings.TrimSpace(req.GetProvider())); p != "" {
+		if _, ok := tools[p]; !ok {
+			return status.Errorf(codes.InvalidArgument, "unknown provider: %s", p)
+		}
+		providers = []string{p}
+	}
+	interval := defaultWatchInterval
+	if req.GetIntervalSeconds() > 0 {
+		interval = time.Duration(req.GetIntervalSeconds()) * time.Second
+	}
+
+	last := make(map[string][]*caampb.Profile)
+	check := func() error {
+		for _, provider := range providers {
+			profiles, err := g.listProfiles(provider)
+			if err != nil {
+				return err
+			}
+			if prev, ok := last[provider]; ok && sameWatchStatus(prev, profiles) {
+				continue
+			}
+			last[provider] = profiles
+			if err := stream.Send(&caampb.StatusUpdate{
+				Time:     timestamppb.Now(),
+				Provider: provider,
+				Profiles: profiles,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := check(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-g.server.shutdownCh:
+			return nil
+		case <-ticker.C:
+			if err := check(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sameWatchStatus compares two profile lists, ignoring the countdown in
+// cooldown_remaining so a ticking cooldown isn't reported as a change.
+func sameWatchStatus(a, b []*caampb.Profile) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(watchKey(a[i]), watchKey(b[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func watchKey(p *caampb.Profile) *caampb.Profile {
+	key := proto.Clone(p).(*caampb.Profile)
+	if key.Health != nil && key.Health.CooldownRemaining != "" {
+		key.Health.CooldownRemaining = "active"
+	}
+	return key
+}
+
+func (g *grpcService) StreamEvents(req *caampb.StreamEventsRequest, stream caampb.Caam_StreamEventsServer) error {
+	bus := g.server.bus
+	if bus == nil {
+		return status.Error(codes.Unavailable, "event bus not available")
+	}
+	filter := events.Filter{Provider: strings.ToLower(req.GetProvider())}
+	for _, t := range req.GetTypes() {
+		filter.Types = append(filter.Types, events.Type(strings.ToLower(t)))
+	}
+
+	// Subscribe before replaying so nothing published in between is lost.
+	ch, cancel := bus.Subscribe(filter, 256)
+	defer cancel()
+
+	lastID := req.GetAfterId()
+	send := func(ev events.Event) error {
+		if ev.ID != 0 && ev.ID <= lastID {
+			return nil
+		}
+		pb, err := eventToProto(ev)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(pb); err != nil {
+			return err
+		}
+		if ev.ID > lastID {
+			lastID = ev.ID
+		}
+		return nil
+	}
+
+	if store := bus.Store(); store != nil && lastID > 0 {
+		for {
+			batch, err := store.EventsAfter(lastID, 500)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			for _, ev := range batch {
+				if filter.Match(ev) {
+					if err := send(ev); err != nil {
+						return err
+					}
+				} else if ev.ID > lastID {
+					lastID = ev.ID
+				}
+			}
+			if len(batch) < 500 {
+				break
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-g.server.shutdownCh:
+			return nil
+		case ev, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func eventToProto(ev events.Event) (*caampb.Event, error) {
+	pb := &caampb.Event{
+		Id:       ev.ID,
+		Type:     string(ev.Type),
+		Time:     timestamppb.New(ev.Time),
+		Provider: ev.Provider,
+		Profile:  ev.Profile,
+		Source:   ev.Source,
+	}
+	if len(ev.Data) > 0 {
+		data, err := json.Marshal(ev.Data)
+		if err != nil {
+			return nil, fmt.Errorf("encode event data: %w", err)
+		}
+		pb.DataJson = string(data)
+	}
+	return pb, nil
+}
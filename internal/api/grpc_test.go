@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/api/caampb"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+)
+
+func startGRPCTestServer(t *testing.T, robot RobotFunc, bus *events.Bus) (*Server, caampb.CaamClient) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, "caam_home"))
+
+	vault := authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	for _, p := range [][2]string{{"claude", "work"}, {"claude", "personal"}, {"codex", "main"}} {
+		if err := os.MkdirAll(vault.ProfilePath(p[0], p[1]), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.TokenPath = filepath.Join(tmpDir, ".api_token")
+	cfg.Robot = robot
+	cfg.EventBus = bus
+	server, err := NewServer(cfg, NewHandlers(vault, nil, nil))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	ln := bufconn.Listen(1 << 20)
+	go server.ServeGRPC(ln)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Stop(ctx)
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return server, caampb.NewCaamClient(conn)
+}
+
+func withToken(ctx context.Context, s *Server) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+s.Token())
+}
+
+func TestGRPCRequiresToken(t *testing.T) {
+	_, client := startGRPCTestServer(t, nil, nil)
+
+	_, err := client.ListProfiles(context.Background(), &caampb.ListProfilesRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("ListProfiles without token: %v, want Unauthenticated", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	if _, err := client.ListProfiles(ctx, &caampb.ListProfilesRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("ListProfiles with wrong token: %v, want Unauthenticated", err)
+	}
+}
+
+func TestGRPCListProfilesAndWatch(t *testing.T) {
+	server, client := startGRPCTestServer(t, nil, nil)
+	ctx := withToken(context.Background(), server)
+
+	resp, err := client.ListProfiles(ctx, &caampb.ListProfilesRequest{Provider: "claude"})
+	if err != nil {
+		t.Fatalf("ListProfiles() error = %v", err)
+	}
+	if len(resp.Profiles) != 2 || resp.Profiles[0].Name != "personal" || resp.Profiles[1].Name != "work" {
+		t.Fatalf("ListProfiles(claude) = %v, want personal and work", resp.Profiles)
+	}
+	if _, err := client.ListProfiles(ctx, &caampb.ListProfilesRequest{Provider: "nope"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("ListProfiles(nope): %v, want InvalidArgument", err)
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.WatchStatus(watchCtx, &caampb.WatchStatusRequest{Provider: "codex", IntervalSeconds: 1})
+	if err != nil {
+		t.Fatalf("WatchStatus() error = %v", err)
+	}
+	update, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("WatchStatus Recv() error = %v", err)
+	}
+	if update.Provider != "codex" || len(update.Profiles) != 1 || update.Profiles[0].Name != "main" {
+		t.Fatalf("first update = %v, want codex/main", update)
+	}
+}
+
+func TestGRPCActionsUseRobot(t *testing.T) {
+	var got RobotRequest
+	robot := func(ctx context.Context, req RobotRequest) ([]byte, error) {
+		got = req
+		if req.Args[0] == "activate" {
+			return []byte(`{"success":false,"command":"act","data":{"proposal_id":7},"error":{"code":"APPROVAL_REQUIRED","message":"waiting"}}`), nil
+		}
+		return []byte(`{"success":true,"command":"act","data":{"message":"cooldown set"}}`), nil
+	}
+	server, client := startGRPCTestServer(t, robot, nil)
+	ctx := withToken(context.Background(), server)
+
+	resp, err := client.SetCooldown(ctx, &caampb.SetCooldownRequest{Provider: "claude", Profile: "work", Duration: "90m"})
+	if err != nil {
+		t.Fatalf("SetCooldown() error = %v", err)
+	}
+	if !resp.Success || resp.Message != "cooldown set" {
+		t.Fatalf("SetCooldown() = %v", resp)
+	}
+	if got.Command != "act" || len(got.Args) != 4 || got.Args[0] != "cooldown" || got.Args[3] != "90m" {
+		t.Fatalf("robot request = %+v", got)
+	}
+
+	resp, err = client.Activate(ctx, &caampb.ActivateRequest{Provider: "claude", Profile: "personal"})
+	if err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+	if resp.Success || resp.ErrorCode != "APPROVAL_REQUIRED" || resp.ProposalId != 7 {
+		t.Fatalf("Activate() = %v, want pending approval 7", resp)
+	}
+
+	if _, err := client.SetCooldown(ctx, &caampb.SetCooldownRequest{Provider: "claude", Profile: "work", Duration: "soon"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("SetCooldown(bad duration): %v, want InvalidArgument", err)
+	}
+}
+
+func TestGRPCStreamEvents(t *testing.T) {
+	bus := events.NewBus(nil)
+	server, client := startGRPCTestServer(t, nil, bus)
+	ctx, cancel := context.WithCancel(withToken(context.Background(), server))
+	defer cancel()
+
+	stream, err := client.StreamEvents(ctx, &caampb.StreamEventsRequest{Types: []string{"cooldown_set"}})
+	if err != nil {
+		t.Fatalf("StreamEvents() error = %v", err)
+	}
+
+	// The subscription is registered asynchronously; publish until it lands.
+	received := make(chan *caampb.Event, 1)
+	go func() {
+		if ev, err := stream.Recv(); err == nil {
+			received <- ev
+		}
+	}()
+	deadline := time.After(2 * time.Second)
+	for {
+		bus.Publish(events.Event{Type: events.ProfileActivated, Provider: "claude", Profile: "work"})
+		bus.Publish(events.Event{Type: events.CooldownSet, Provider: "claude", Profile: "work", Data: map[string]any{"notes": "limit"}})
+		select {
+		case ev := <-received:
+			if ev.Type != "cooldown_set" || ev.DataJson != `{"notes":"limit"}` {
+				t.Fatalf("event = %v, want cooldown_set with data", ev)
+			}
+			return
+		case <-deadline:
+			t.Fatal("timed out waiting for event")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
 )
@@ -384,9 +385,10 @@ func (h *Handlers) Activate(req ActivateRequest) (*ActivateResponse, error) {
 	}
 
 	// Restore the profile (activating it)
-	if err := h.vault.Restore(fileSet, req.Profile); err != nil {
+	if err := h.vault.Activate(fileSet, req.Profile, "api"); err != nil {
 		return nil, fmt.Errorf("activate failed: %w", err)
 	}
+	events.PublishActivated(req.Tool, req.Profile, "api")
 
 	return &ActivateResponse{
 		Success: true,
@@ -489,6 +491,13 @@ func (h *Handlers) getProfileIdentity(tool, name string) *identity.Identity {
 		if err != nil {
 			id, err = identity.ExtractFromGeminiConfig(vaultPath + "/oauth_credentials.json")
 		}
+	case "cursor":
+		id, err = identity.ExtractFromCursorAuth(vaultPath + "/auth.json")
+	case "copilot":
+		id, err = identity.ExtractFromCopilotHosts(vaultPath + "/hosts.json")
+		if err != nil {
+			id, err = identity.ExtractFromCopilotHosts(vaultPath + "/apps.json")
+		}
 	}
 
 	if err != nil {
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RobotCommands are the `caam robot` commands served under /api/v1/robot/.
+var RobotCommands = []string{"status", "next", "act", "limits", "precheck", "health", "history"}
+
+// robotMutating lists robot commands that change state and so require POST.
+var robotMutating = map[string]bool{"act": true}
+
+// maxRobotBody bounds a robot request body.
+const maxRobotBody = 64 * 1024
+
+// ErrRobotRequest marks a robot request the command rejected before running
+// (unknown flag, wrong number of arguments). RobotFunc implementations wrap
+// it so the server answers 400 instead of 500.
+var ErrRobotRequest = errors.New("invalid robot request")
+
+// RobotRequest is one robot command invocation. Args are the command's
+// positional arguments; Flags are its flags by long name, as they would be
+// written on the command line.
+type RobotRequest struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Flags   map[string]string `json:"flags,omitempty"`
+}
+
+// RobotFunc runs a robot command in-process and returns its JSON output,
+// exactly as `caam robot <command>` would print it.
+type RobotFunc func(ctx context.Context, req RobotRequest) ([]byte, error)
+
+// handleRobot serves /api/v1/robot/{command}. GET takes positional
+// arguments as repeated ?arg= parameters and every other parameter as a
+// flag; POST takes a RobotRequest body.
+func (s *Server) handleRobot(w http.ResponseWriter, r *http.Request) {
+	if s.robot == nil {
+		s.jsonError(w, http.StatusNotImplemented, "robot commands are not available")
+		return
+	}
+
+	command := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/robot/"), "/")
+	if !isRobotCommand(command) {
+		s.jsonError(w, http.StatusNotFound, "unknown robot command: "+command)
+		return
+	}
+
+	var req RobotRequest
+	switch r.Method {
+	case http.MethodGet:
+		if robotMutating[command] {
+			s.jsonError(w, http.StatusMethodNotAllowed, command+" requires POST")
+			return
+		}
+		req = robotRequestFromQuery(r)
+	case http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRobotBody))
+		if err != nil {
+			s.jsonError(w, http.StatusBadRequest, "read body: "+err.Error())
+			return
+		}
+		if len(strings.TrimSpace(string(body))) > 0 {
+			if err := json.Unmarshal(body, &req); err != nil {
+				s.jsonError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+				return
+			}
+		}
+	default:
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req.Command = command
+
+	out, err := s.robot(r.Context(), req)
+	if len(out) == 0 {
+		switch {
+		case errors.Is(err, ErrRobotRequest):
+			s.jsonError(w, http.StatusBadRequest, err.Error())
+		case err != nil:
+			s.jsonError(w, http.StatusInternalServerError, err.Error())
+		default:
+			s.jsonError(w, http.StatusInternalServerError, "robot command produced no output")
+		}
+		return
+	}
+
+	// The robot envelope carries its own success flag and error code; map
+	// failures to 422 so plain HTTP clients can tell them apart.
+	var envelope struct {
+		Success bool `json:"success"`
+	}
+	status := http.StatusOK
+	if json.Unmarshal(out, &envelope) == nil && !envelope.Success {
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(out)
+}
+
+func isRobotCommand(name string) bool {
+	for _, c := range RobotCommands {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+func robotRequestFromQuery(r *http.Request) RobotRequest {
+	var req RobotRequest
+	for key, values := range r.URL.Query() {
+		if key == "arg" {
+			req.Args = append(req.Args, values...)
+			continue
+		}
+		if req.Flags == nil {
+			req.Flags = make(map[string]string)
+		}
+		req.Flags[key] = values[len(values)-1]
+	}
+	return req
+}
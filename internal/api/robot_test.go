@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newRobotTestServer(t *testing.T, fn RobotFunc) *Server {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.TokenPath = filepath.Join(t.TempDir(), ".api_token")
+	cfg.Robot = fn
+	server, err := NewServer(cfg, &Handlers{})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	return server
+}
+
+func TestHandleRobot(t *testing.T) {
+	var got RobotRequest
+	server := newRobotTestServer(t, func(ctx context.Context, req RobotRequest) ([]byte, error) {
+		got = req
+		switch {
+		case req.Flags["bogus"] != "":
+			return nil, fmt.Errorf("%w: unknown flag", ErrRobotRequest)
+		case req.Command == "limits":
+			return []byte(`{"success":false,"error":{"code":"INVALID_PROVIDER"}}` + "\n"), fmt.Errorf("INVALID_PROVIDER")
+		}
+		return []byte(`{"success":true,"command":"` + req.Command + `"}` + "\n"), nil
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantReq    *RobotRequest
+	}{
+		{
+			name:       "get with args and flags",
+			method:     http.MethodGet,
+			target:     "/api/v1/robot/next?arg=claude&strategy=lru",
+			wantStatus: http.StatusOK,
+			wantReq:    &RobotRequest{Command: "next", Args: []string{"claude"}, Flags: map[string]string{"strategy": "lru"}},
+		},
+		{
+			name:       "post body",
+			method:     http.MethodPost,
+			target:     "/api/v1/robot/act",
+			body:       `{"args":["activate","claude","work"]}`,
+			wantStatus: http.StatusOK,
+			wantReq:    &RobotRequest{Command: "act", Args: []string{"activate", "claude", "work"}},
+		},
+		{name: "act requires post", method: http.MethodGet, target: "/api/v1/robot/act?arg=activate", wantStatus: http.StatusMethodNotAllowed},
+		{name: "unknown command", method: http.MethodGet, target: "/api/v1/robot/watch", wantStatus: http.StatusNotFound},
+		{name: "bad json", method: http.MethodPost, target: "/api/v1/robot/status", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "rejected request", method: http.MethodGet, target: "/api/v1/robot/status?bogus=1", wantStatus: http.StatusBadRequest},
+		{name: "command failure", method: http.MethodGet, target: "/api/v1/robot/limits?arg=nope", wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = RobotRequest{}
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			server.handleRobot(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantReq == nil {
+				return
+			}
+			if got.Command != tt.wantReq.Command || fmt.Sprint(got.Args) != fmt.Sprint(tt.wantReq.Args) || fmt.Sprint(got.Flags) != fmt.Sprint(tt.wantReq.Flags) {
+				t.Errorf("robot request = %+v, want %+v", got, *tt.wantReq)
+			}
+		})
+	}
+}
+
+func TestHandleRobotDisabled(t *testing.T) {
+	server := newRobotTestServer(t, nil)
+	w := httptest.NewRecorder()
+	server.handleRobot(w, httptest.NewRequest(http.MethodGet, "/api/v1/robot/status", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotImplemented)
+	}
+}
+
+func TestServerSocketSkipsToken(t *testing.T) {
+	dir, err := os.MkdirTemp("", "caam-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	cfg := DefaultConfig()
+	cfg.Port = 0
+	cfg.TokenPath = filepath.Join(dir, ".api_token")
+	cfg.SocketPath = filepath.Join(dir, "api.sock")
+	cfg.Robot = func(ctx context.Context, req RobotRequest) ([]byte, error) {
+		return []byte(`{"success":true}`), nil
+	}
+	server, err := NewServer(cfg, &Handlers{})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	go server.Start()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Stop(ctx)
+	})
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", cfg.SocketPath)
+		},
+	}}
+
+	var resp *http.Response
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err = client.Get("http://caam/api/v1/robot/status")
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("GET over socket: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 without a token (body %s)", resp.StatusCode, body)
+	}
+
+	info, err := os.Stat(cfg.SocketPath)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket mode = %o, want 600", perm)
+	}
+	if leftover, _ := filepath.Glob(filepath.Join(dir, ".caam-sock-*")); len(leftover) != 0 {
+		t.Errorf("private bind dirs left behind: %v", leftover)
+	}
+}
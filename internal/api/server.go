@@ -17,6 +17,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 )
 
 // Server is the local HTTP API server.
@@ -27,6 +29,9 @@ type Server struct {
 	logger     *slog.Logger
 	httpServer *http.Server
 	handlers   *Handlers
+	robot      RobotFunc
+	socketPath string
+	bus        *events.Bus
 
 	// SSE clients for live updates
 	sseClients   map[chan Event]struct{}
@@ -42,6 +47,16 @@ type Config struct {
 	Port      int
 	TokenPath string
 	Logger    *slog.Logger
+
+	// Robot runs robot commands for /api/v1/robot/. Nil disables them.
+	Robot RobotFunc
+
+	// SocketPath, if set, also serves the API on a unix socket. The socket
+	// is mode 0600 and needs no token: only its owner can connect.
+	SocketPath string
+
+	// EventBus feeds the gRPC StreamEvents call. Nil disables it.
+	EventBus *events.Bus
 }
 
 // DefaultConfig returns sensible defaults.
@@ -62,6 +77,11 @@ func defaultTokenPath() string {
 	return filepath.Join(homeDir, ".config", "caam", ".api_token")
 }
 
+// DefaultSocketPath returns the default unix socket path for --socket.
+func DefaultSocketPath() string {
+	return filepath.Join(filepath.Dir(defaultTokenPath()), "api.sock")
+}
+
 // NewServer creates a new API server.
 func NewServer(cfg Config, handlers *Handlers) (*Server, error) {
 	if cfg.Logger == nil {
@@ -76,6 +96,9 @@ func NewServer(cfg Config, handlers *Handlers) (*Server, error) {
 		tokenPath:  cfg.TokenPath,
 		logger:     cfg.Logger,
 		handlers:   handlers,
+		robot:      cfg.Robot,
+		socketPath: cfg.SocketPath,
+		bus:        cfg.EventBus,
 		sseClients: make(map[chan Event]struct{}),
 		eventCh:    make(chan Event, 100),
 		shutdownCh: make(chan struct{}),
@@ -143,6 +166,7 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/v1/actions/activate", s.authMiddleware(s.handleActivate))
 	mux.HandleFunc("/api/v1/actions/backup", s.authMiddleware(s.handleBackup))
 	mux.HandleFunc("/api/v1/events", s.authMiddleware(s.handleSSE))
+	mux.HandleFunc("/api/v1/robot/", s.authMiddleware(s.handleRobot))
 
 	// CORS middleware for localhost only
 	handler := s.corsMiddleware(mux)
@@ -159,6 +183,26 @@ func (s *Server) Start() error {
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
+		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+			if c.LocalAddr().Network() == "unix" {
+				return context.WithValue(ctx, socketConnKey{}, true)
+			}
+			return ctx
+		},
+	}
+
+	if s.socketPath != "" {
+		socketListener, err := s.listenSocket()
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		go func() {
+			if err := s.httpServer.Serve(socketListener); err != nil && err != http.ErrServerClosed {
+				s.logger.Error("socket server failed", "error", err)
+			}
+		}()
+		s.logger.Info("API socket listening", "path", s.socketPath)
 	}
 
 	// Start SSE broadcaster
@@ -175,11 +219,56 @@ func (s *Server) Stop(ctx context.Context) error {
 		close(s.shutdownCh)
 	})
 	if s.httpServer != nil {
-		return s.httpServer.Shutdown(ctx)
+		err := s.httpServer.Shutdown(ctx)
+		if s.socketPath != "" {
+			os.Remove(s.socketPath)
+		}
+		return err
 	}
 	return nil
 }
 
+// socketConnKey marks requests that arrived on the unix socket.
+type socketConnKey struct{}
+
+// listenSocket listens on the configured unix socket, replacing a stale
+// socket file left by a server that didn't exit cleanly.
+//
+// The socket is bound inside a private 0700 directory and only moved into
+// place once it is 0600, so no other user can connect to it in between.
+func (s *Server) listenSocket() (net.Listener, error) {
+	dir := filepath.Dir(s.socketPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create socket dir: %w", err)
+	}
+	private, err := os.MkdirTemp(dir, ".caam-sock-")
+	if err != nil {
+		return nil, fmt.Errorf("create socket dir: %w", err)
+	}
+	defer os.RemoveAll(private)
+
+	bound := filepath.Join(private, "s")
+	ln, err := net.Listen("unix", bound)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", s.socketPath, err)
+	}
+	if err := os.Chmod(bound, 0600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("restrict socket: %w", err)
+	}
+	os.Remove(s.socketPath)
+	if err := os.Rename(bound, s.socketPath); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("listen %s: %w", s.socketPath, err)
+	}
+	return ln, nil
+}
+
+// SocketPath returns the unix socket path, or "" if none is configured.
+func (s *Server) SocketPath() string {
+	return s.socketPath
+}
+
 // Port returns the configured port.
 func (s *Server) Port() int {
 	return s.port
@@ -258,6 +347,11 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 // authMiddleware validates the bearer token.
 func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if onSocket, _ := r.Context().Value(socketConnKey{}).(bool); onSocket {
+			next(w, r)
+			return
+		}
+
 		auth := r.Header.Get("Authorization")
 		if auth == "" {
 			s.jsonError(w, http.StatusUnauthorized, "missing authorization header")
@@ -0,0 +1,222 @@
+// Package applog writes caam's own logs: JSON lines in size-rotated files
+// under the data directory, one file per top-level command. Callers tag
+// records with the run_id of the invocation that wrote them, so one run can
+// be picked out of a shared file.
+package applog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// RunIDEnvVar carries an invocation's run_id to the processes it starts, so
+// a caam run and the caam commands its child calls share one run_id.
+const RunIDEnvVar = "CAAM_RUN_ID"
+
+// Defaults for Options left zero.
+const (
+	DefaultMaxSizeMB = 10
+	DefaultMaxFiles  = 3
+)
+
+// Dir returns the directory log files are written to: logs/ in the data
+// directory.
+func Dir() string {
+	if caamHome := os.Getenv("CAAM_HOME"); caamHome != "" {
+		return filepath.Join(caamHome, "data", "logs")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".caam", "data", "logs")
+	}
+	return filepath.Join(homeDir, ".caam", "data", "logs")
+}
+
+// NewRunID returns a short random correlation ID, in the same form as a
+// coordinator's run_id.
+func NewRunID() string {
+	return uuid.New().String()[:8]
+}
+
+// ParseLevel parses "debug", "info", "warn" or "error".
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q (use debug, info, warn, or error)", s)
+	}
+	return level, nil
+}
+
+// Options configure a command's log.
+type Options struct {
+	// Command names the log file: <dir>/<command>.log.
+	Command string
+	Level   slog.Level
+	// MaxSizeMB is the size a file may reach before it is rotated, and
+	// MaxFiles how many rotated files are kept.
+	MaxSizeMB int
+	MaxFiles  int
+	// Mirror, if set, also receives every record as text, at debug level.
+	Mirror io.Writer
+}
+
+// Open opens the log for a command in dir. Records go to the returned
+// logger, tagged with command; close the returned Closer when the command
+// ends.
+func Open(dir string, opts Options) (*slog.Logger, io.Closer, error) {
+	if opts.Command == "" {
+		return nil, nil, errors.New("log command name is empty")
+	}
+	if opts.MaxSizeMB <= 0 {
+		opts.MaxSizeMB = DefaultMaxSizeMB
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = DefaultMaxFiles
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, fmt.Errorf("create log dir: %w", err)
+	}
+
+	file, err := OpenRotating(filepath.Join(dir, opts.Command+".log"), int64(opts.MaxSizeMB)<<20, opts.MaxFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	var handler slog.Handler = slog.NewJSONHandler(file, &slog.HandlerOptions{Level: opts.Level})
+	if opts.Mirror != nil {
+		handler = Fanout(handler, slog.NewTextHandler(opts.Mirror, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	return slog.New(handler).With("command", opts.Command), file, nil
+}
+
+// RotatingFile is an append-only file that is renamed to path.1 once it
+// would grow past its maximum size, shifting older files up to path.N.
+// Several processes may append to the same file; each checks the size it
+// has seen, so a file can run over by what the others wrote meanwhile.
+type RotatingFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenRotating opens path for appending.
+func OpenRotating(path string, maxSize int64, maxFiles int) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat log: %w", err)
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if p would take the file past its
+// maximum size.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) rotate() error {
+	r.f.Close()
+	r.f = nil
+	for i := r.maxFiles - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if r.maxFiles > 0 {
+		_ = os.Rename(r.path, r.path+".1")
+	} else {
+		_ = os.Truncate(r.path, 0)
+	}
+	return r.open()
+}
+
+// Close closes the file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// Fanout returns a handler that passes each record to every handler that
+// is enabled for its level.
+func Fanout(handlers ...slog.Handler) slog.Handler {
+	return fanout(handlers)
+}
+
+type fanout []slog.Handler
+
+func (h fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, sub := range h {
+		if sub.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanout) Handle(ctx context.Context, rec slog.Record) error {
+	var errs []error
+	for _, sub := range h {
+		if sub.Enabled(ctx, rec.Level) {
+			errs = append(errs, sub.Handle(ctx, rec.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanout, len(h))
+	for i, sub := range h {
+		out[i] = sub.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (h fanout) WithGroup(name string) slog.Handler {
+	out := make(fanout, len(h))
+	for i, sub := range h {
+		out[i] = sub.WithGroup(name)
+	}
+	return out
+}
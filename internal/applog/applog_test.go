@@ -0,0 +1,158 @@
+package applog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOpenWritesJSON(t *testing.T) {
+	dir := t.TempDir()
+	var mirror bytes.Buffer
+	logger, closer, err := Open(dir, Options{Command: "run", Level: slog.LevelInfo, Mirror: &mirror})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger = logger.With("run_id", "abc123")
+	logger.Debug("hidden")
+	logger.Info("started", "provider", "claude")
+	closer.Close()
+
+	records, err := Tail(dir, nil, 0, Filter{MinLevel: slog.LevelDebug})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("records = %d, want 1 (debug is below the file level)", len(records))
+	}
+	r := records[0]
+	if r.Msg != "started" || r.RunID != "abc123" || r.Command != "run" || r.Level != slog.LevelInfo {
+		t.Errorf("record = %+v", r)
+	}
+	if v, _ := r.Field("provider"); v != "claude" {
+		t.Errorf("provider = %q", v)
+	}
+	if !strings.Contains(mirror.String(), "hidden") || !strings.Contains(mirror.String(), "started") {
+		t.Errorf("mirror = %q, want both records", mirror.String())
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.log")
+	r, err := OpenRotating(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		if _, err := r.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.Close()
+
+	for name, want := range map[string]string{"x.log": "dddddd\n", "x.log.1": "cccccc\n", "x.log.2": "bbbbbb\n"} {
+		got, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("x.log.3 exists; want at most 2 rotated files")
+	}
+}
+
+func TestTailMergesAndFilters(t *testing.T) {
+	dir := t.TempDir()
+	lines := map[string]string{
+		"run.log.1": `{"time":"2026-01-01T00:00:01Z","level":"INFO","msg":"one","provider":"claude"}` + "\n",
+		"run.log":   `{"time":"2026-01-01T00:00:03Z","level":"ERROR","msg":"three","provider":"claude"}` + "\nnot json\n",
+		"robot.log": `{"time":"2026-01-01T00:00:02Z","level":"INFO","msg":"two","provider":"codex"}` + "\n",
+	}
+	for name, content := range lines {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := Tail(dir, nil, 0, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msgs(all); got != "one,two,three" {
+		t.Errorf("all = %s, want one,two,three", got)
+	}
+
+	f, err := ParseFilter([]string{"provider=claude"}, slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, _ := Tail(dir, nil, 1, f)
+	if got := msgs(last); got != "three" {
+		t.Errorf("last claude = %s, want three", got)
+	}
+	errs, _ := Tail(dir, []string{"run"}, 0, Filter{MinLevel: slog.LevelError})
+	if got := msgs(errs); got != "three" {
+		t.Errorf("errors = %s, want three", got)
+	}
+	if _, err := ParseFilter([]string{"provider"}, slog.LevelInfo); err == nil {
+		t.Error("ParseFilter accepted a spec without =")
+	}
+}
+
+func TestFollow(t *testing.T) {
+	dir := t.TempDir()
+	logger, closer, err := Open(dir, Options{Command: "run", MaxSizeMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	logger.Info("before")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	var got []string
+	done := make(chan error)
+	go func() {
+		done <- Follow(ctx, dir, nil, Filter{Fields: map[string]string{"provider": "codex"}}, 5*time.Millisecond, func(r Record) {
+			mu.Lock()
+			got = append(got, r.Msg)
+			mu.Unlock()
+		})
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	logger.Info("skipped", "provider", "claude")
+	logger.Info("after", "provider", "codex")
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(got)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "after" {
+		t.Errorf("followed = %v, want [after]", got)
+	}
+}
+
+func msgs(records []Record) string {
+	var out []string
+	for _, r := range records {
+		out = append(out, r.Msg)
+	}
+	return strings.Join(out, ",")
+}
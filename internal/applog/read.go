@@ -0,0 +1,246 @@
+package applog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Record is one parsed log line.
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Msg     string
+	RunID   string
+	Command string
+	// Attrs holds every field of the line, including the ones above.
+	Attrs map[string]any
+	// Raw is the line as written.
+	Raw string
+}
+
+// ParseRecord parses one JSON log line.
+func ParseRecord(line string) (Record, error) {
+	var attrs map[string]any
+	if err := json.Unmarshal([]byte(line), &attrs); err != nil {
+		return Record{}, err
+	}
+	rec := Record{Attrs: attrs, Raw: line}
+	if s, ok := attrs["time"].(string); ok {
+		rec.Time, _ = time.Parse(time.RFC3339Nano, s)
+	}
+	if s, ok := attrs["level"].(string); ok {
+		rec.Level, _ = ParseLevel(s)
+	}
+	rec.Msg, _ = attrs["msg"].(string)
+	rec.RunID, _ = attrs["run_id"].(string)
+	rec.Command, _ = attrs["command"].(string)
+	return rec, nil
+}
+
+// Field returns the value of a field as text, and whether it is present.
+func (r Record) Field(key string) (string, bool) {
+	v, ok := r.Attrs[key]
+	if !ok {
+		return "", false
+	}
+	if s, ok := v.(string); ok {
+		return s, true
+	}
+	b, _ := json.Marshal(v)
+	return string(b), true
+}
+
+// Filter selects records by minimum level and exact field values.
+type Filter struct {
+	MinLevel slog.Level
+	Fields   map[string]string
+}
+
+// ParseFilter builds a Filter from key=value specs.
+func ParseFilter(specs []string, minLevel slog.Level) (Filter, error) {
+	f := Filter{MinLevel: minLevel, Fields: make(map[string]string)}
+	for _, spec := range specs {
+		key, value, ok := strings.Cut(spec, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return Filter{}, fmt.Errorf("invalid filter %q (use key=value)", spec)
+		}
+		f.Fields[key] = strings.TrimSpace(value)
+	}
+	return f, nil
+}
+
+// Match reports whether r passes the filter.
+func (f Filter) Match(r Record) bool {
+	if r.Level < f.MinLevel {
+		return false
+	}
+	for key, want := range f.Fields {
+		if got, ok := r.Field(key); !ok || got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Commands lists the commands with a log file in dir.
+func Commands(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var commands []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".log"); ok && !e.IsDir() {
+			commands = append(commands, name)
+		}
+	}
+	sort.Strings(commands)
+	return commands, nil
+}
+
+// Tail returns the last n records matching filter across the logs of
+// commands in dir (all commands if empty), rotated files included, in time
+// order. n <= 0 returns every match.
+func Tail(dir string, commands []string, n int, filter Filter) ([]Record, error) {
+	if len(commands) == 0 {
+		var err error
+		if commands, err = Commands(dir); err != nil {
+			return nil, err
+		}
+	}
+	var records []Record
+	for _, command := range commands {
+		base := filepath.Join(dir, command+".log")
+		matches, _ := filepath.Glob(base + ".*")
+		sort.Slice(matches, func(i, j int) bool { return rotationIndex(matches[i]) > rotationIndex(matches[j]) })
+		for _, path := range append(matches, base) {
+			f, err := os.Open(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			_, err = readRecords(f, filter, func(r Record) { records = append(records, r) })
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("read %s: %w", path, err)
+			}
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	if n > 0 && len(records) > n {
+		records = records[len(records)-n:]
+	}
+	return records, nil
+}
+
+// rotationIndex returns N for path.log.N, or 0.
+func rotationIndex(path string) int {
+	var n int
+	fmt.Sscanf(filepath.Ext(path), ".%d", &n)
+	return n
+}
+
+// readRecords passes each complete line of r that parses and matches filter
+// to fn, and returns how many bytes of complete lines it read. Lines that
+// are not JSON are skipped.
+func readRecords(r io.Reader, filter Filter, fn func(Record)) (int64, error) {
+	br := bufio.NewReader(r)
+	var read int64
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return read, nil
+			}
+			return read, err
+		}
+		read += int64(len(line))
+		rec, perr := ParseRecord(strings.TrimSpace(line))
+		if perr == nil && filter.Match(rec) {
+			fn(rec)
+		}
+	}
+}
+
+// Follow calls fn with each record matching filter that is appended to the
+// logs of commands in dir (all commands, including ones that start logging
+// later, if empty) until ctx is done. Records already in the files are not
+// passed. It polls every interval and picks up where it left off when a
+// file is rotated.
+func Follow(ctx context.Context, dir string, commands []string, filter Filter, interval time.Duration, fn func(Record)) error {
+	offsets := make(map[string]int64)
+	scan := func(initial bool) error {
+		names := commands
+		if len(names) == 0 {
+			var err error
+			if names, err = Commands(dir); err != nil {
+				return err
+			}
+		}
+		for _, command := range names {
+			path := filepath.Join(dir, command+".log")
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			offset, seen := offsets[path]
+			if initial {
+				offsets[path] = info.Size()
+				continue
+			}
+			if !seen {
+				offset = 0
+			}
+			if info.Size() < offset {
+				// Rotated: the rest of the old file is in path.1.
+				offset = 0
+			}
+			if info.Size() == offset {
+				offsets[path] = offset
+				continue
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			if _, err := f.Seek(offset, io.SeekStart); err == nil {
+				n, _ := readRecords(f, filter, fn)
+				offset += n
+			}
+			f.Close()
+			offsets[path] = offset
+		}
+		return nil
+	}
+
+	if err := scan(true); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := scan(false); err != nil {
+				return err
+			}
+		}
+	}
+}
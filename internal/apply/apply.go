@@ -0,0 +1,577 @@
+// Package apply reconciles caam's configuration with a declarative spec.
+//
+// A spec is one YAML file describing the sync machines, sync settings,
+// rotation policies, per-provider settings, and notification targets a
+// machine should have. Each section that is present is the desired state
+// of that part of the configuration; sections left out are not touched.
+// Applying the same spec twice changes nothing the second time.
+package apply
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+// Change actions.
+const (
+	ActionAdd    = "add"
+	ActionUpdate = "update"
+	ActionRemove = "remove"
+)
+
+// Spec is the desired configuration.
+type Spec struct {
+	// Machines are the sync pool's machines, matched by name.
+	Machines []MachineSpec `yaml:"machines,omitempty"`
+
+	// Sync sets the pool's switches.
+	Sync *SyncSpec `yaml:"sync,omitempty"`
+
+	// Policies replace the rotation policies in config.yaml, matched by
+	// name. An empty list removes them all.
+	Policies *[]config.RotationPolicy `yaml:"policies,omitempty"`
+
+	// Providers sets per-provider settings, keyed by provider.
+	Providers map[string]ProviderSpec `yaml:"providers,omitempty"`
+
+	// Notifications replaces the notification settings in config.json.
+	Notifications *NotificationsSpec `yaml:"notifications,omitempty"`
+}
+
+// MachineSpec is a machine in the sync pool.
+type MachineSpec struct {
+	Name string `yaml:"name"`
+
+	// Address is [user@]host[:port] for SSH, or the endpoint URL for HTTPS.
+	Address    string `yaml:"address"`
+	Transport  string `yaml:"transport,omitempty"`
+	User       string `yaml:"user,omitempty"`
+	SSHKey     string `yaml:"ssh_key,omitempty"`
+	RemotePath string `yaml:"remote_path,omitempty"`
+}
+
+// SyncSpec sets the sync pool's switches. Unset fields are left alone.
+type SyncSpec struct {
+	Enabled *bool `yaml:"enabled,omitempty"`
+	Auto    *bool `yaml:"auto,omitempty"`
+
+	// PruneMachines removes pool machines the spec does not list. Without
+	// it, machines are only added and updated.
+	PruneMachines bool `yaml:"prune_machines,omitempty"`
+}
+
+// ProviderSpec is one provider's settings. Unset fields are left alone.
+type ProviderSpec struct {
+	DefaultProfile *string                    `yaml:"default_profile,omitempty"`
+	Favorites      *[]string                  `yaml:"favorites,omitempty"`
+	Automation     *config.ProviderAutomation `yaml:"automation,omitempty"`
+
+	// RiskTiers and Weights are keyed by profile name.
+	RiskTiers map[string]string  `yaml:"risk_tiers,omitempty"`
+	Weights   map[string]float64 `yaml:"weights,omitempty"`
+}
+
+// NotificationsSpec mirrors config.NotificationsConfig in YAML.
+type NotificationsSpec struct {
+	Enabled        bool              `yaml:"enabled"`
+	Webhook        string            `yaml:"webhook,omitempty"`
+	WebhookHeaders map[string]string `yaml:"webhook_headers,omitempty"`
+	Slack          string            `yaml:"slack,omitempty"`
+	Desktop        bool              `yaml:"desktop,omitempty"`
+	Events         map[string]bool   `yaml:"events,omitempty"`
+	ExpiryWarning  config.Duration   `yaml:"expiry_warning,omitempty"`
+	RateLimit      config.Duration   `yaml:"rate_limit,omitempty"`
+}
+
+// Change is one difference between the current and desired configuration.
+type Change struct {
+	Section string `json:"section"`
+	Key     string `json:"key"`
+	Action  string `json:"action"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+}
+
+// String renders the change as a diff line.
+func (c Change) String() string {
+	key := c.Section
+	if c.Key != "" {
+		key += "." + c.Key
+	}
+	switch c.Action {
+	case ActionAdd:
+		return fmt.Sprintf("+ %s: %s", key, c.To)
+	case ActionRemove:
+		return fmt.Sprintf("- %s: %s", key, c.From)
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", key, c.From, c.To)
+	}
+}
+
+// Load reads and validates a spec. Unknown keys are errors, so a typo
+// doesn't silently leave a setting unapplied.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read spec: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a spec.
+func Parse(data []byte) (*Spec, error) {
+	var spec Spec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil {
+		if errors.Is(err, io.EOF) {
+			return &spec, nil
+		}
+		return nil, fmt.Errorf("parse spec: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Validate checks the parts of the spec that the configuration's own
+// validation doesn't.
+func (s *Spec) Validate() error {
+	seen := make(map[string]bool)
+	for i, m := range s.Machines {
+		name := strings.ToLower(strings.TrimSpace(m.Name))
+		if name == "" {
+			return fmt.Errorf("machines[%d]: name is required", i)
+		}
+		if seen[name] {
+			return fmt.Errorf("machines[%d]: duplicate machine %q", i, m.Name)
+		}
+		seen[name] = true
+		if _, err := m.machine(); err != nil {
+			return fmt.Errorf("machines[%d] (%s): %w", i, m.Name, err)
+		}
+	}
+	for provider, p := range s.Providers {
+		if p.Automation != nil {
+			if err := config.ValidateAutomationKey(provider, config.AutomationRefresh); err != nil {
+				return fmt.Errorf("providers.%s.automation: %w", provider, err)
+			}
+		}
+		for profile, tier := range p.RiskTiers {
+			if _, err := risk.Parse(tier); err != nil {
+				return fmt.Errorf("providers.%s.risk_tiers.%s: %w", provider, profile, err)
+			}
+		}
+		for profile, w := range p.Weights {
+			if w < 0 {
+				return fmt.Errorf("providers.%s.weights.%s: weight cannot be negative", provider, profile)
+			}
+		}
+	}
+	if n := s.Notifications; n != nil {
+		known := make(map[string]bool)
+		for _, e := range config.NotifyEvents() {
+			known[e] = true
+		}
+		for event := range n.Events {
+			if !known[event] {
+				return fmt.Errorf("notifications.events: unknown event %q (valid: %s)", event, strings.Join(config.NotifyEvents(), ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// machine builds the pool entry the spec describes.
+func (m MachineSpec) machine() (*sync.Machine, error) {
+	transport := m.Transport
+	if transport == "" {
+		transport = sync.TransportSSH
+	}
+	if !sync.ValidTransport(transport) {
+		return nil, fmt.Errorf("invalid transport %q (valid: ssh, https)", transport)
+	}
+	out := &sync.Machine{
+		Name:       strings.TrimSpace(m.Name),
+		SSHUser:    m.User,
+		SSHKeyPath: m.SSHKey,
+		RemotePath: m.RemotePath,
+		Source:     sync.SourceManual,
+	}
+	if transport == sync.TransportHTTPS {
+		out.Transport = transport
+		out.Address = m.Address
+	} else {
+		host, port, user := sync.ParseAddress(m.Address)
+		if port == 0 {
+			port = sync.DefaultSSHPort
+		}
+		if out.SSHUser == "" {
+			out.SSHUser = user
+		}
+		out.Address, out.Port = host, port
+	}
+	if err := out.Validate(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Target is the configuration a spec is applied to. Pool may be nil when
+// the spec has no machines or sync section.
+type Target struct {
+	Config *config.Config
+	SPM    *config.SPMConfig
+	Pool   *sync.SyncPool
+}
+
+// Result says which parts of the target changed and need saving.
+type Result struct {
+	Changes       []Change
+	ConfigChanged bool
+	SPMChanged    bool
+	PoolChanged   bool
+}
+
+// Apply brings t to the state s describes, in memory, and reports the
+// changes. Nothing is saved; the caller saves the changed parts unless it
+// is a dry run.
+func (s *Spec) Apply(t Target) (*Result, error) {
+	r := &Result{}
+	if (len(s.Machines) > 0 || s.Sync != nil) && t.Pool == nil {
+		return nil, fmt.Errorf("sync pool unavailable")
+	}
+
+	n := len(r.Changes)
+	s.applyMachines(t.Pool, r)
+	s.applySync(t.Pool, r)
+	r.PoolChanged = len(r.Changes) > n
+
+	n = len(r.Changes)
+	s.applyPolicies(t.SPM, r)
+	s.applyAutomation(t.SPM, r)
+	r.SPMChanged = len(r.Changes) > n
+
+	n = len(r.Changes)
+	s.applyProviders(t.Config, r)
+	s.applyNotifications(t.Config, r)
+	r.ConfigChanged = len(r.Changes) > n
+
+	if r.SPMChanged {
+		if err := t.SPM.Validate(); err != nil {
+			return nil, fmt.Errorf("resulting config.yaml is invalid: %w", err)
+		}
+	}
+	return r, nil
+}
+
+func (s *Spec) applyMachines(pool *sync.SyncPool, r *Result) {
+	if pool == nil {
+		return
+	}
+	wanted := make(map[string]bool)
+	for _, ms := range s.Machines {
+		want, _ := ms.machine() // validated by Parse
+		wanted[strings.ToLower(want.Name)] = true
+
+		existing := pool.GetMachineByName(want.Name)
+		if existing == nil {
+			m := sync.NewMachine(want.Name, want.Address)
+			copyMachine(m, want)
+			_ = pool.AddMachine(m)
+			r.Changes = append(r.Changes, Change{Section: "machines", Key: want.Name, Action: ActionAdd, To: describeMachine(m)})
+			continue
+		}
+		if before := describeMachine(existing); before != describeMachine(want) {
+			copyMachine(existing, want)
+			r.Changes = append(r.Changes, Change{Section: "machines", Key: want.Name, Action: ActionUpdate, From: before, To: describeMachine(existing)})
+		}
+	}
+
+	if s.Sync == nil || !s.Sync.PruneMachines {
+		return
+	}
+	for _, m := range pool.ListMachines() {
+		if !wanted[strings.ToLower(m.Name)] {
+			_ = pool.RemoveMachine(m.ID)
+			r.Changes = append(r.Changes, Change{Section: "machines", Key: m.Name, Action: ActionRemove, From: describeMachine(m)})
+		}
+	}
+}
+
+func copyMachine(dst, src *sync.Machine) {
+	dst.Address = src.Address
+	dst.Port = src.Port
+	dst.SSHUser = src.SSHUser
+	dst.Transport = src.Transport
+	dst.SSHKeyPath = src.SSHKeyPath
+	dst.RemotePath = src.RemotePath
+}
+
+func describeMachine(m *sync.Machine) string {
+	var b strings.Builder
+	if m.TransportName() == sync.TransportHTTPS {
+		b.WriteString(m.Address)
+	} else {
+		if m.SSHUser != "" {
+			b.WriteString(m.SSHUser + "@")
+		}
+		b.WriteString(m.HostPort())
+	}
+	if m.SSHKeyPath != "" {
+		b.WriteString(" key=" + m.SSHKeyPath)
+	}
+	if m.RemotePath != "" {
+		b.WriteString(" path=" + m.RemotePath)
+	}
+	return b.String()
+}
+
+func (s *Spec) applySync(pool *sync.SyncPool, r *Result) {
+	if s.Sync == nil || pool == nil {
+		return
+	}
+	if s.Sync.Enabled != nil && pool.Enabled != *s.Sync.Enabled {
+		r.Changes = append(r.Changes, boolChange("sync", "enabled", pool.Enabled, *s.Sync.Enabled))
+		pool.Enabled = *s.Sync.Enabled
+	}
+	if s.Sync.Auto != nil && pool.AutoSync != *s.Sync.Auto {
+		r.Changes = append(r.Changes, boolChange("sync", "auto", pool.AutoSync, *s.Sync.Auto))
+		pool.AutoSync = *s.Sync.Auto
+	}
+}
+
+func (s *Spec) applyPolicies(spm *config.SPMConfig, r *Result) {
+	if s.Policies == nil {
+		return
+	}
+	current := make(map[string]config.RotationPolicy)
+	for _, p := range spm.Policies {
+		current[p.PolicyName()] = p
+	}
+	wanted := make(map[string]bool)
+	for _, p := range *s.Policies {
+		name := p.PolicyName()
+		wanted[name] = true
+		old, ok := current[name]
+		switch {
+		case !ok:
+			r.Changes = append(r.Changes, Change{Section: "policies", Key: name, Action: ActionAdd, To: describePolicy(p)})
+		case !reflect.DeepEqual(old, p):
+			r.Changes = append(r.Changes, Change{Section: "policies", Key: name, Action: ActionUpdate, From: describePolicy(old), To: describePolicy(p)})
+		}
+	}
+	for _, p := range spm.Policies {
+		if !wanted[p.PolicyName()] {
+			r.Changes = append(r.Changes, Change{Section: "policies", Key: p.PolicyName(), Action: ActionRemove, From: describePolicy(p)})
+		}
+	}
+	spm.Policies = append([]config.RotationPolicy(nil), *s.Policies...)
+}
+
+func describePolicy(p config.RotationPolicy) string {
+	parts := []string{"provider=" + p.Provider}
+	if p.Every > 0 {
+		parts = append(parts, "every="+p.Every.String())
+	}
+	if p.When != "" {
+		parts = append(parts, "when="+strconv.Quote(p.When))
+	}
+	if p.Algorithm != "" {
+		parts = append(parts, "algorithm="+p.Algorithm)
+	}
+	return strings.Join(parts, " ")
+}
+
+// applyAutomation sets the providers' automation switches in config.yaml.
+func (s *Spec) applyAutomation(spm *config.SPMConfig, r *Result) {
+	for _, provider := range sortedKeys(s.Providers) {
+		a := s.Providers[provider].Automation
+		if a == nil {
+			continue
+		}
+		for _, f := range []struct {
+			name string
+			want *bool
+		}{
+			{config.AutomationLoginInject, a.AutoLoginInject},
+			{config.AutomationRefresh, a.AutoRefresh},
+			{config.AutomationRotate, a.AutoRotate},
+		} {
+			if f.want == nil {
+				continue
+			}
+			before := spm.AutomationEnabled(provider, f.name)
+			if before == *f.want {
+				continue
+			}
+			_ = spm.SetAutomation(provider, f.name, *f.want)
+			r.Changes = append(r.Changes, boolChange("providers", provider+".automation."+f.name, before, *f.want))
+		}
+	}
+}
+
+func (s *Spec) applyProviders(cfg *config.Config, r *Result) {
+	for _, provider := range sortedKeys(s.Providers) {
+		p := s.Providers[provider]
+		if p.DefaultProfile != nil {
+			if before := cfg.GetDefault(provider); before != *p.DefaultProfile {
+				r.Changes = append(r.Changes, stringChange("providers", provider+".default_profile", before, *p.DefaultProfile))
+				if *p.DefaultProfile == "" {
+					delete(cfg.DefaultProfiles, provider)
+				} else {
+					cfg.SetDefault(provider, *p.DefaultProfile)
+				}
+			}
+		}
+		if p.Favorites != nil {
+			before := cfg.GetFavorites(provider)
+			if strings.Join(before, ",") != strings.Join(*p.Favorites, ",") {
+				r.Changes = append(r.Changes, stringChange("providers", provider+".favorites", strings.Join(before, ","), strings.Join(*p.Favorites, ",")))
+				cfg.SetFavorites(provider, *p.Favorites)
+			}
+		}
+		for _, profile := range sortedKeys(p.RiskTiers) {
+			tier, _ := risk.Parse(p.RiskTiers[profile]) // validated by Parse
+			before := cfg.GetRiskTier(provider, profile)
+			if before != tier {
+				r.Changes = append(r.Changes, stringChange("providers", provider+".risk_tiers."+profile, string(before), string(tier)))
+				cfg.SetRiskTier(provider, profile, tier)
+			}
+		}
+		for _, profile := range sortedKeys(p.Weights) {
+			key := config.ProfileKey(provider, profile)
+			before, ok := cfg.ProfileWeights[key]
+			if want := p.Weights[profile]; !ok || before != want {
+				from := ""
+				if ok {
+					from = formatFloat(before)
+				}
+				r.Changes = append(r.Changes, stringChange("providers", provider+".weights."+profile, from, formatFloat(want)))
+				if cfg.ProfileWeights == nil {
+					cfg.ProfileWeights = make(map[string]float64)
+				}
+				cfg.ProfileWeights[key] = want
+			}
+		}
+	}
+}
+
+func (s *Spec) applyNotifications(cfg *config.Config, r *Result) {
+	n := s.Notifications
+	if n == nil {
+		return
+	}
+	want := config.NotificationsConfig{
+		Enabled:        n.Enabled,
+		Webhook:        n.Webhook,
+		WebhookHeaders: n.WebhookHeaders,
+		Slack:          n.Slack,
+		Desktop:        n.Desktop,
+		Events:         n.Events,
+		ExpiryWarning:  n.ExpiryWarning,
+		RateLimit:      n.RateLimit,
+	}
+	before, after := flatten(cfg.Notifications), flatten(want)
+	for _, key := range unionKeys(before, after) {
+		from, hadFrom := before[key]
+		to, hasTo := after[key]
+		switch {
+		case !hadFrom:
+			r.Changes = append(r.Changes, Change{Section: "notifications", Key: key, Action: ActionAdd, To: to})
+		case !hasTo:
+			r.Changes = append(r.Changes, Change{Section: "notifications", Key: key, Action: ActionRemove, From: from})
+		case from != to:
+			r.Changes = append(r.Changes, Change{Section: "notifications", Key: key, Action: ActionUpdate, From: from, To: to})
+		}
+	}
+	cfg.Notifications = want
+}
+
+// flatten turns a JSON-serializable value into dotted keys and their
+// values, omitting empty strings.
+func flatten(v interface{}) map[string]string {
+	data, _ := json.Marshal(v)
+	var tree map[string]interface{}
+	_ = json.Unmarshal(data, &tree)
+	out := make(map[string]string)
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, child := range val {
+				key := k
+				if prefix != "" {
+					key = prefix + "." + k
+				}
+				walk(key, child)
+			}
+		case nil:
+		case bool:
+			out[prefix] = strconv.FormatBool(val)
+		case string:
+			if val != "" {
+				out[prefix] = val
+			}
+		default:
+			b, _ := json.Marshal(val)
+			out[prefix] = string(b)
+		}
+	}
+	walk("", tree)
+	return out
+}
+
+func unionKeys(a, b map[string]string) []string {
+	keys := make(map[string]bool)
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return sortedKeys(keys)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func boolChange(section, key string, from, to bool) Change {
+	return Change{Section: section, Key: key, Action: ActionUpdate, From: strconv.FormatBool(from), To: strconv.FormatBool(to)}
+}
+
+func stringChange(section, key, from, to string) Change {
+	action := ActionUpdate
+	switch {
+	case from == "":
+		action = ActionAdd
+	case to == "":
+		action = ActionRemove
+	}
+	return Change{Section: section, Key: key, Action: action, From: from, To: to}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,143 @@
+package apply
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+const testSpec = `
+machines:
+  - name: build-1
+    address: deploy@build-1.internal:2222
+    ssh_key: ~/.ssh/caam
+  - name: laptop
+    transport: https
+    address: https://dav.example.com/caam
+sync:
+  enabled: true
+  auto: true
+  prune_machines: true
+policies:
+  - name: rotate
+    provider: claude
+    every: 4h
+providers:
+  claude:
+    default_profile: work
+    favorites: [work, spare]
+    automation: {auto_rotate: false}
+    risk_tiers: {personal: high}
+    weights: {work: 2}
+notifications:
+  enabled: true
+  slack: https://hooks.slack.com/services/x
+  events: {token_expiring: false}
+  rate_limit: 30m
+`
+
+func TestApply(t *testing.T) {
+	spec, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	pool := sync.NewSyncPool()
+	old := sync.NewMachine("old", "old.internal")
+	if err := pool.AddMachine(old); err != nil {
+		t.Fatal(err)
+	}
+	spm := config.DefaultSPMConfig()
+	spm.Policies = []config.RotationPolicy{{Name: "stale", Provider: "codex", When: "error_count_1h >= 3"}}
+	target := Target{Config: config.DefaultConfig(), SPM: spm, Pool: pool}
+
+	result, err := spec.Apply(target)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if !result.ConfigChanged || !result.SPMChanged || !result.PoolChanged {
+		t.Errorf("result = %+v, want every part changed", result)
+	}
+	var lines []string
+	for _, c := range result.Changes {
+		lines = append(lines, c.String())
+	}
+	diff := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"+ machines.build-1: deploy@build-1.internal:2222 key=~/.ssh/caam",
+		"+ machines.laptop: https://dav.example.com/caam",
+		"- machines.old: old.internal:22",
+		"~ sync.enabled: false -> true",
+		"+ policies.rotate: provider=claude every=4h0m0s",
+		"- policies.stale: provider=codex when=\"error_count_1h >= 3\"",
+		"~ providers.claude.automation.auto_rotate: true -> false",
+		"+ providers.claude.default_profile: work",
+		"~ providers.claude.risk_tiers.personal: normal -> high",
+		"+ notifications.events.token_expiring: false",
+		"+ notifications.slack: https://hooks.slack.com/services/x",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff is missing %q:\n%s", want, diff)
+		}
+	}
+
+	if m := pool.GetMachineByName("build-1"); m == nil || m.Port != 2222 || m.SSHUser != "deploy" {
+		t.Errorf("build-1 = %+v", m)
+	}
+	if pool.GetMachineByName("old") != nil || !pool.AutoSync {
+		t.Error("pool not pruned or auto sync not enabled")
+	}
+	if target.Config.GetRiskTier("claude", "personal") != risk.High || target.Config.GetDefault("claude") != "work" {
+		t.Errorf("config = %+v", target.Config)
+	}
+	if spm.AutomationEnabled("claude", config.AutomationRotate) || len(spm.Policies) != 1 {
+		t.Errorf("spm automation/policies not applied: %+v", spm.Policies)
+	}
+
+	// Applying again is a no-op.
+	again, err := spec.Apply(target)
+	if err != nil {
+		t.Fatalf("second Apply: %v", err)
+	}
+	if len(again.Changes) != 0 || again.ConfigChanged || again.SPMChanged || again.PoolChanged {
+		t.Errorf("second apply changed %v", again.Changes)
+	}
+
+	// Without prune_machines, machines outside the spec are kept.
+	spec.Sync.PruneMachines = false
+	if err := pool.AddMachine(sync.NewMachine("extra", "extra.internal")); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := spec.Apply(target); len(again.Changes) != 0 || pool.GetMachineByName("extra") == nil {
+		t.Errorf("apply without prune changed %v", again.Changes)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, spec, wantErr string
+	}{
+		{"unknown key", "polices: []\n", "polices"},
+		{"machine without address", "machines:\n  - name: a\n", "address"},
+		{"duplicate machine", "machines:\n  - {name: a, address: h}\n  - {name: A, address: h2}\n", "duplicate"},
+		{"bad transport", "machines:\n  - {name: a, address: h, transport: ftp}\n", "transport"},
+		{"bad risk tier", "providers:\n  claude:\n    risk_tiers: {x: extreme}\n", "risk_tiers"},
+		{"bad event", "notifications:\n  events: {nope: true}\n", "unknown event"},
+		{"automation provider", "providers:\n  bogus:\n    automation: {auto_refresh: true}\n", "automation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.spec))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if spec, err := Parse(nil); err != nil || spec == nil {
+		t.Errorf("empty spec = %v, %v", spec, err)
+	}
+}
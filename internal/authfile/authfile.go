@@ -18,9 +18,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultfs"
 )
 
 // AuthFileSpec defines where a tool stores its auth credentials.
@@ -36,6 +41,24 @@ type AuthFileSpec struct {
 
 	// Required indicates if this file must exist for auth to work.
 	Required bool
+
+	// Variant names the sub-provider the file belongs to when a tool has
+	// more than one install flavour (e.g. gemini-cli, gemini-code-assist,
+	// gemini-adc).
+	Variant string
+
+	// VaultName is the file name used inside a vault profile directory.
+	// Empty means the base name of Path; set it when two files in the same
+	// set share a base name.
+	VaultName string
+}
+
+// VaultFileName returns the name the file is stored under in the vault.
+func (s AuthFileSpec) VaultFileName() string {
+	if s.VaultName != "" {
+		return s.VaultName
+	}
+	return filepath.Base(s.Path)
 }
 
 // AuthFileSet is a collection of auth files that together represent
@@ -119,9 +142,33 @@ func ClaudeAuthFiles() AuthFileSet {
 	}
 }
 
-// GeminiAuthFiles returns the auth files for Gemini CLI.
-// Gemini CLI stores Google OAuth tokens in ~/.gemini/ directory.
+// Gemini sub-providers. All share the "gemini" vault namespace (one
+// Google identity per profile) but keep credentials in different places.
+const (
+	GeminiVariantCLI        = "gemini-cli"
+	GeminiVariantCodeAssist = "gemini-code-assist"
+	GeminiVariantADC        = "gemini-adc"
+)
+
+// GeminiAuthFiles returns the auth files for Gemini: the union of the
+// standalone Gemini CLI, the Gemini Code Assist IDE integration, and gcloud
+// Application Default Credentials, so a backup of a gemini profile captures
+// whichever variants are logged in.
 func GeminiAuthFiles() AuthFileSet {
+	var files []AuthFileSpec
+	files = append(files, GeminiCLIAuthFiles().Files...)
+	files = append(files, GeminiCodeAssistAuthFiles().Files...)
+	files = append(files, GeminiADCAuthFiles().Files...)
+	return AuthFileSet{
+		Tool:              "gemini",
+		Files:             files,
+		AllowOptionalOnly: true,
+	}
+}
+
+// GeminiCLIAuthFiles returns the auth files for the standalone Gemini CLI.
+// Gemini CLI stores Google OAuth tokens in ~/.gemini/ directory.
+func GeminiCLIAuthFiles() AuthFileSet {
 	homeDir, _ := os.UserHomeDir()
 
 	// Check for GEMINI_HOME override
@@ -138,25 +185,200 @@ func GeminiAuthFiles() AuthFileSet {
 				Path:        filepath.Join(geminiHome, "settings.json"),
 				Description: "Gemini CLI settings with Google OAuth state (Gemini Ultra subscription)",
 				Required:    true,
+				Variant:     GeminiVariantCLI,
 			},
 			// Additional auth files that may store tokens
+			{
+				Tool:        "gemini",
+				Path:        filepath.Join(geminiHome, "oauth_creds.json"),
+				Description: "Gemini CLI Google OAuth tokens",
+				Required:    false,
+				Variant:     GeminiVariantCLI,
+			},
 			{
 				Tool:        "gemini",
 				Path:        filepath.Join(geminiHome, "oauth_credentials.json"),
 				Description: "Gemini CLI OAuth credentials cache",
 				Required:    false,
+				Variant:     GeminiVariantCLI,
 			},
 			{
 				Tool:        "gemini",
 				Path:        filepath.Join(geminiHome, ".env"),
 				Description: "Gemini API key (.env file)",
 				Required:    false,
+				Variant:     GeminiVariantCLI,
+			},
+		},
+		AllowOptionalOnly: true,
+	}
+}
+
+// GeminiCodeAssistAuthFiles returns the auth files for Gemini Code Assist in
+// VS Code / JetBrains, which keeps its Google sign-in under the Cloud Code
+// extension cache rather than ~/.gemini. GEMINI_CODE_ASSIST_HOME overrides
+// the directory. Files are stored in the vault with a "code-assist-" prefix
+// so they never collide with the CLI's files of the same name.
+func GeminiCodeAssistAuthFiles() AuthFileSet {
+	dir := os.Getenv("GEMINI_CODE_ASSIST_HOME")
+	if dir == "" {
+		homeDir, _ := os.UserHomeDir()
+		dir = filepath.Join(homeDir, ".cache", "google-vscode-extension", "auth")
+	}
+
+	return AuthFileSet{
+		Tool: "gemini",
+		Files: []AuthFileSpec{
+			{
+				Tool:        "gemini",
+				Path:        filepath.Join(dir, "credentials.json"),
+				Description: "Gemini Code Assist Google OAuth credentials",
+				Required:    false,
+				Variant:     GeminiVariantCodeAssist,
+				VaultName:   "code-assist-credentials.json",
+			},
+			{
+				Tool:        "gemini",
+				Path:        filepath.Join(dir, "settings.json"),
+				Description: "Gemini Code Assist account and project settings",
+				Required:    false,
+				Variant:     GeminiVariantCodeAssist,
+				VaultName:   "code-assist-settings.json",
+			},
+		},
+		AllowOptionalOnly: true,
+	}
+}
+
+// GeminiADCAuthFiles returns the gcloud Application Default Credentials
+// that Gemini CLI falls back to for Vertex AI and Code Assist auth
+// (GOOGLE_GENAI_USE_VERTEXAI, "gcloud auth application-default login").
+// gcloud keeps them in $CLOUDSDK_CONFIG, ~/.config/gcloud, or
+// %APPDATA%\gcloud on Windows.
+func GeminiADCAuthFiles() AuthFileSet {
+	return AuthFileSet{
+		Tool: "gemini",
+		Files: []AuthFileSpec{
+			{
+				Tool:        "gemini",
+				Path:        filepath.Join(gcloudConfigDir(), "application_default_credentials.json"),
+				Description: "Google Cloud Application Default Credentials (account and quota project)",
+				Required:    false,
+				Variant:     GeminiVariantADC,
+			},
+		},
+		AllowOptionalOnly: true,
+	}
+}
+
+// gcloudConfigDir returns the directory gcloud stores its configuration in.
+func gcloudConfigDir() string {
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return dir
+	}
+	if runtime.GOOS == "windows" {
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, "gcloud")
+		}
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "gcloud")
+}
+
+// CursorAuthFiles returns the auth files for the Cursor CLI (cursor-agent).
+// Tokens live in $XDG_CONFIG_HOME/cursor/auth.json; the signed-in account
+// (email, plan) is recorded in ~/.cursor/cli-config.json, which
+// CURSOR_CONFIG_DIR relocates.
+func CursorAuthFiles() AuthFileSet {
+	homeDir, _ := os.UserHomeDir()
+	xdgConfig := os.Getenv("XDG_CONFIG_HOME")
+	if xdgConfig == "" {
+		xdgConfig = filepath.Join(homeDir, ".config")
+	}
+	configDir := os.Getenv("CURSOR_CONFIG_DIR")
+	if configDir == "" {
+		configDir = filepath.Join(homeDir, ".cursor")
+	}
+
+	return AuthFileSet{
+		Tool: "cursor",
+		Files: []AuthFileSpec{
+			{
+				Tool:        "cursor",
+				Path:        filepath.Join(xdgConfig, "cursor", "auth.json"),
+				Description: "Cursor CLI access and refresh tokens",
+				Required:    true,
+			},
+			{
+				Tool:        "cursor",
+				Path:        filepath.Join(configDir, "cli-config.json"),
+				Description: "Cursor CLI config with signed-in account info",
+				Required:    false,
+			},
+		},
+	}
+}
+
+// CopilotAuthFiles returns the auth files for GitHub Copilot. The Copilot
+// CLI and editor plugins keep their GitHub sign-in in
+// $XDG_CONFIG_HOME/github-copilot/: hosts.json from the device-code login,
+// apps.json from GitHub App sign-ins. Either one is enough.
+func CopilotAuthFiles() AuthFileSet {
+	xdgConfig := os.Getenv("XDG_CONFIG_HOME")
+	if xdgConfig == "" {
+		homeDir, _ := os.UserHomeDir()
+		xdgConfig = filepath.Join(homeDir, ".config")
+	}
+	dir := filepath.Join(xdgConfig, "github-copilot")
+
+	return AuthFileSet{
+		Tool: "copilot",
+		Files: []AuthFileSpec{
+			{
+				Tool:        "copilot",
+				Path:        filepath.Join(dir, "hosts.json"),
+				Description: "GitHub Copilot OAuth token per GitHub host",
+				Required:    false,
+			},
+			{
+				Tool:        "copilot",
+				Path:        filepath.Join(dir, "apps.json"),
+				Description: "GitHub Copilot app tokens per GitHub host",
+				Required:    false,
 			},
 		},
 		AllowOptionalOnly: true,
 	}
 }
 
+// ParentProvider maps a sub-provider name (gemini-cli, gemini-code-assist,
+// gemini-adc) to the provider whose vault namespace and identity it shares. Other names
+// are returned lower-cased and unchanged.
+func ParentProvider(provider string) string {
+	switch p := strings.ToLower(provider); p {
+	case GeminiVariantCLI, GeminiVariantCodeAssist, GeminiVariantADC:
+		return "gemini"
+	default:
+		return p
+	}
+}
+
+// IsVaultAuthFile reports whether name is one of provider's auth files as
+// stored in a vault profile directory. These are the files an encrypted
+// vault keeps encrypted; others, such as meta.json, stay plaintext.
+func IsVaultAuthFile(provider, name string) bool {
+	fileSet, ok := GetAuthFileSet(provider)
+	if !ok {
+		return false
+	}
+	for _, spec := range fileSet.Files {
+		if spec.VaultFileName() == name {
+			return true
+		}
+	}
+	return false
+}
+
 // GetAuthFileSet returns the AuthFileSet for the given provider name.
 func GetAuthFileSet(provider string) (AuthFileSet, bool) {
 	switch strings.ToLower(provider) {
@@ -166,6 +388,16 @@ func GetAuthFileSet(provider string) (AuthFileSet, bool) {
 		return CodexAuthFiles(), true
 	case "gemini":
 		return GeminiAuthFiles(), true
+	case GeminiVariantCLI:
+		return GeminiCLIAuthFiles(), true
+	case GeminiVariantCodeAssist:
+		return GeminiCodeAssistAuthFiles(), true
+	case GeminiVariantADC:
+		return GeminiADCAuthFiles(), true
+	case "cursor":
+		return CursorAuthFiles(), true
+	case "copilot":
+		return CopilotAuthFiles(), true
 	default:
 		return AuthFileSet{}, false
 	}
@@ -174,6 +406,9 @@ func GetAuthFileSet(provider string) (AuthFileSet, bool) {
 // Vault manages stored auth file backups.
 type Vault struct {
 	basePath string // ~/.local/share/caam/vault
+
+	fsOnce sync.Once
+	fs     vaultfs.Info
 }
 
 const originalProfileName = "_original"
@@ -198,6 +433,33 @@ func (v *Vault) BasePath() string {
 	return v.basePath
 }
 
+// Filesystem reports the filesystem the vault lives on. Detection runs once
+// per Vault.
+func (v *Vault) Filesystem() vaultfs.Info {
+	v.fsOnce.Do(func() {
+		v.fs = vaultfs.Detect(v.basePath)
+	})
+	return v.fs
+}
+
+// lockForWrite serializes vault writers using the lock strategy suited to
+// the vault's filesystem. Callers must Unlock the returned lock.
+func (v *Vault) lockForWrite() (*vaultfs.Lock, error) {
+	lock, err := vaultfs.Acquire(v.basePath, v.Filesystem().Locking(), vaultfs.DefaultLockTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("lock vault: %w", err)
+	}
+	return lock, nil
+}
+
+// syncDir makes renames in dir durable on filesystems that need it.
+func (v *Vault) syncDir(dir string) error {
+	if !v.Filesystem().NeedsDirSync() {
+		return nil
+	}
+	return vaultfs.SyncDir(dir)
+}
+
 // DefaultVaultPath returns the default vault location.
 // Falls back to current directory if home directory cannot be determined.
 func DefaultVaultPath() string {
@@ -227,6 +489,32 @@ func (v *Vault) BackupPath(tool, profile, filename string) string {
 	return filepath.Join(v.ProfilePath(tool, profile), filename)
 }
 
+// WriteProfileFile stores an extra file in a profile's vault directory,
+// encrypting it when the vault is encrypted.
+func (v *Vault) WriteProfileFile(tool, profile, name string, data []byte) error {
+	profileDir, err := v.safeProfileDir(tool, profile)
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(profileDir, filepath.Base(name))
+	if vaultcrypt.VaultFor(dst) != "" {
+		if data, err = vaultcrypt.SealFor(dst, data); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(dst, data)
+}
+
+// ReadProfileFile reads a file written by WriteProfileFile, decrypting it
+// if needed.
+func (v *Vault) ReadProfileFile(tool, profile, name string) ([]byte, error) {
+	profileDir, err := v.safeProfileDir(tool, profile)
+	if err != nil {
+		return nil, err
+	}
+	return vaultcrypt.ReadFile(filepath.Join(profileDir, filepath.Base(name)))
+}
+
 // Backup saves the current auth files to the vault.
 func (v *Vault) Backup(fileSet AuthFileSet, profile string) error {
 	profileDir, err := v.safeProfileDir(fileSet.Tool, profile)
@@ -237,6 +525,12 @@ func (v *Vault) Backup(fileSet AuthFileSet, profile string) error {
 	tool := strings.TrimSpace(fileSet.Tool)
 	profile = strings.TrimSpace(profile)
 
+	lock, err := v.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
 	// System profiles are immutable safety artifacts; never overwrite them.
 	if IsSystemProfile(profile) {
 		st, err := os.Stat(profileDir)
@@ -270,10 +564,10 @@ func (v *Vault) Backup(fileSet AuthFileSet, profile string) error {
 		}
 
 		// Copy file to vault
-		filename := filepath.Base(spec.Path)
+		filename := spec.VaultFileName()
 		destPath := filepath.Join(profileDir, filename)
 
-		if err := copyFile(spec.Path, destPath); err != nil {
+		if err := backupFile(spec.Path, destPath); err != nil {
 			return fmt.Errorf("backup %s: %w", spec.Path, err)
 		}
 		backedUp++
@@ -358,6 +652,10 @@ func (v *Vault) Backup(fileSet AuthFileSet, profile string) error {
 		return fmt.Errorf("rename metadata file: %w", err)
 	}
 
+	if err := v.syncDir(profileDir); err != nil {
+		return fmt.Errorf("sync profile dir: %w", err)
+	}
+
 	return nil
 }
 
@@ -480,60 +778,16 @@ func (v *Vault) BackupOriginal(fileSet AuthFileSet) (bool, error) {
 	return true, nil
 }
 
-// Restore copies backed-up auth files to their original locations.
+// Restore copies backed-up auth files to their original locations. Every
+// file is read and checked before any live file changes, and the live files
+// are replaced together: if one can't be, those already replaced are put
+// back, so a multi-file provider is never left half-switched.
 func (v *Vault) Restore(fileSet AuthFileSet, profile string) error {
-	profileDir, err := v.safeProfileDir(fileSet.Tool, profile)
+	writes, _, err := v.stageRestore(fileSet, profile)
 	if err != nil {
 		return err
 	}
-
-	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
-		return fmt.Errorf("profile %s/%s not found in vault; run 'caam ls %s' to see available profiles", fileSet.Tool, profile, fileSet.Tool)
-	}
-
-	restored := 0
-	requiredFound := false
-	optionalFound := false
-	var missingRequired []string
-	for _, spec := range fileSet.Files {
-		filename := filepath.Base(spec.Path)
-		srcPath := filepath.Join(profileDir, filename)
-
-		// Check if backup exists
-		if _, err := os.Stat(srcPath); os.IsNotExist(err) {
-			if spec.Required {
-				missingRequired = append(missingRequired, srcPath)
-			}
-			continue // Skip optional files
-		}
-
-		// Ensure parent directory exists
-		if err := os.MkdirAll(filepath.Dir(spec.Path), 0700); err != nil {
-			return fmt.Errorf("create parent dir for %s: %w", spec.Path, err)
-		}
-
-		// Copy from vault to original location
-		if err := copyFile(srcPath, spec.Path); err != nil {
-			return fmt.Errorf("restore %s: %w", spec.Path, err)
-		}
-		restored++
-		if spec.Required {
-			requiredFound = true
-		} else {
-			optionalFound = true
-		}
-	}
-
-	if restored == 0 {
-		return fmt.Errorf("no auth files restored for %s/%s", fileSet.Tool, profile)
-	}
-	if len(missingRequired) > 0 {
-		if !(fileSet.AllowOptionalOnly && !requiredFound && optionalFound) {
-			return fmt.Errorf("required backup not found: %s", missingRequired[0])
-		}
-	}
-
-	return nil
+	return applyRestore(writes)
 }
 
 // List returns all profiles stored for a tool.
@@ -600,7 +854,17 @@ func (v *Vault) DeleteForce(tool, profile string) error {
 	if err != nil {
 		return err
 	}
-	return os.RemoveAll(profileDir)
+
+	lock, err := v.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := os.RemoveAll(profileDir); err != nil {
+		return err
+	}
+	return v.syncDir(filepath.Dir(profileDir))
 }
 
 // CopyProfile creates a copy of a profile with a new name.
@@ -616,6 +880,12 @@ func (v *Vault) CopyProfile(tool, srcProfile, dstProfile string) error {
 		return fmt.Errorf("invalid destination profile: %w", err)
 	}
 
+	lock, err := v.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
 	// Verify source exists
 	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
 		return fmt.Errorf("source profile %s/%s not found", tool, srcProfile)
@@ -676,7 +946,7 @@ func (v *Vault) CopyProfile(tool, srcProfile, dstProfile string) error {
 		}
 	}
 
-	return nil
+	return v.syncDir(dstDir)
 }
 
 // ActiveProfile returns which profile is currently active (if any).
@@ -687,6 +957,11 @@ func (v *Vault) ActiveProfile(fileSet AuthFileSet) (string, error) {
 		return "", err
 	}
 
+	// An API key in the live Claude settings wins over OAuth credentials.
+	if profile := v.liveClaudeAPIKeyProfile(fileSet); profile != "" {
+		return profile, nil
+	}
+
 	// Hash the current auth files.
 	// Prefer required files for matching; optional files can change frequently
 	// (e.g., settings/session files) and should not break profile detection.
@@ -701,7 +976,7 @@ func (v *Vault) ActiveProfile(fileSet AuthFileSet) (string, error) {
 		if err != nil {
 			continue
 		}
-		base := filepath.Base(spec.Path)
+		base := spec.VaultFileName()
 		if spec.Required {
 			requiredFound = true
 			currentHashes[base] = hash
@@ -727,7 +1002,7 @@ func (v *Vault) ActiveProfile(fileSet AuthFileSet) (string, error) {
 
 		for filename, currentHash := range currentHashes {
 			backupPath := filepath.Join(profileDir, filename)
-			backupHash, err := hashFile(backupPath)
+			backupHash, err := hashVaultFile(backupPath)
 			if err != nil {
 				matches = false
 				break
@@ -824,6 +1099,78 @@ func copyFile(src, dst string) error {
 	return os.Rename(tmpPath, dst)
 }
 
+// backupFile copies a live auth file into the vault, encrypting it when the
+// vault is encrypted.
+func backupFile(src, dst string) error {
+	if vaultcrypt.VaultFor(dst) == "" {
+		return copyFile(src, dst)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	sealed, err := vaultcrypt.SealFor(dst, data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(dst, sealed)
+}
+
+// writeFileAtomic writes data to dst with 0600 permissions via a temp file
+// and rename.
+func writeFileAtomic(dst string, data []byte) error {
+	tmpPath, err := stageFile(dst, data)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	return renameFile(tmpPath, dst)
+}
+
+// stageFile writes data with 0600 permissions to a temp file beside dst and
+// returns its path, for the caller to rename over dst.
+func stageFile(dst string, data []byte) (string, error) {
+	dir := filepath.Dir(dst)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(dst)+".tmp.*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := f.Name()
+
+	err = func() error {
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+		if err := f.Chmod(0600); err != nil {
+			return err
+		}
+		return f.Sync()
+	}()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+// hashVaultFile hashes a vault file's plaintext, so encrypted copies still
+// match the live auth file.
+func hashVaultFile(path string) (string, error) {
+	data, err := vaultcrypt.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 func hashFile(path string) (string, error) {
 	f, err := os.Open(path)
 	if err != nil {
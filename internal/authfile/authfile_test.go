@@ -1627,3 +1627,291 @@ func TestVaultCopyProfile(t *testing.T) {
 		}
 	})
 }
+
+func TestGeminiVariants(t *testing.T) {
+	tmpDir := t.TempDir()
+	cliHome := filepath.Join(tmpDir, "gemini")
+	assistHome := filepath.Join(tmpDir, "code-assist")
+	t.Setenv("GEMINI_HOME", cliHome)
+	t.Setenv("GEMINI_CODE_ASSIST_HOME", assistHome)
+	t.Setenv("CLOUDSDK_CONFIG", filepath.Join(tmpDir, "gcloud"))
+
+	for name, want := range map[string]string{
+		"gemini":             "gemini",
+		"gemini-cli":         "gemini",
+		"Gemini-Code-Assist": "gemini",
+		"gemini-adc":         "gemini",
+		"claude":             "claude",
+	} {
+		if got := ParentProvider(name); got != want {
+			t.Errorf("ParentProvider(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	cli, ok := GetAuthFileSet(GeminiVariantCLI)
+	if !ok || cli.Tool != "gemini" {
+		t.Fatalf("GetAuthFileSet(gemini-cli) = %+v, %v", cli, ok)
+	}
+	assist, ok := GetAuthFileSet(GeminiVariantCodeAssist)
+	if !ok || assist.Tool != "gemini" {
+		t.Fatalf("GetAuthFileSet(gemini-code-assist) = %+v, %v", assist, ok)
+	}
+	adc, ok := GetAuthFileSet(GeminiVariantADC)
+	if !ok || adc.Tool != "gemini" || adc.Files[0].Path != filepath.Join(tmpDir, "gcloud", "application_default_credentials.json") {
+		t.Fatalf("GetAuthFileSet(gemini-adc) = %+v, %v", adc, ok)
+	}
+	all := GeminiAuthFiles()
+	if len(all.Files) != len(cli.Files)+len(assist.Files)+len(adc.Files) {
+		t.Errorf("GeminiAuthFiles has %d files, want union of %d+%d+%d", len(all.Files), len(cli.Files), len(assist.Files), len(adc.Files))
+	}
+
+	// Both variants keep a settings.json; the vault must hold both.
+	seen := make(map[string]bool)
+	for _, spec := range all.Files {
+		if seen[spec.VaultFileName()] {
+			t.Errorf("duplicate vault file name %q", spec.VaultFileName())
+		}
+		seen[spec.VaultFileName()] = true
+	}
+
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(cliHome, "settings.json"), `{"cli":true}`)
+	writeFile(filepath.Join(assistHome, "settings.json"), `{"assist":true}`)
+	writeFile(filepath.Join(assistHome, "credentials.json"), `{"email":"me@example.com"}`)
+
+	vault := NewVault(filepath.Join(tmpDir, "vault"))
+	if err := vault.Backup(all, "work"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	data, err := os.ReadFile(vault.BackupPath("gemini", "work", "settings.json"))
+	if err != nil || string(data) != `{"cli":true}` {
+		t.Errorf("vault settings.json = %q, %v", data, err)
+	}
+	data, err = os.ReadFile(vault.BackupPath("gemini", "work", "code-assist-settings.json"))
+	if err != nil || string(data) != `{"assist":true}` {
+		t.Errorf("vault code-assist-settings.json = %q, %v", data, err)
+	}
+
+	// Restoring only the Code Assist variant leaves the CLI untouched.
+	writeFile(filepath.Join(cliHome, "settings.json"), `{"cli":"other"}`)
+	writeFile(filepath.Join(assistHome, "settings.json"), `{"assist":"other"}`)
+	if err := vault.Restore(assist, "work"); err != nil {
+		t.Fatalf("Restore(code assist) error = %v", err)
+	}
+	data, _ = os.ReadFile(filepath.Join(assistHome, "settings.json"))
+	if string(data) != `{"assist":true}` {
+		t.Errorf("code assist settings.json = %q after restore", data)
+	}
+	data, _ = os.ReadFile(filepath.Join(cliHome, "settings.json"))
+	if string(data) != `{"cli":"other"}` {
+		t.Errorf("CLI settings.json = %q, want untouched", data)
+	}
+
+	if active, err := vault.ActiveProfile(assist); err != nil || active != "work" {
+		t.Errorf("ActiveProfile(code assist) = %q, %v; want work", active, err)
+	}
+}
+
+func TestVaultOrgSnapshots(t *testing.T) {
+	tmpDir := t.TempDir()
+	credPath := filepath.Join(tmpDir, "home", ".credentials.json")
+	configPath := filepath.Join(tmpDir, "home", ".claude.json")
+	fileSet := AuthFileSet{
+		Tool: "claude",
+		Files: []AuthFileSpec{
+			{Tool: "claude", Path: credPath, Required: true},
+			{Tool: "claude", Path: configPath},
+		},
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vault := NewVault(filepath.Join(tmpDir, "vault"))
+	login := func(token, org string) {
+		t.Helper()
+		write(credPath, token)
+		write(configPath, org)
+		if err := vault.Backup(fileSet, "work"); err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+	}
+
+	login("token-acme", "acme")
+	if err := vault.SaveOrgSnapshot(fileSet, "work", OrgSnapshot{ID: "org-1", Name: "Acme"}); err != nil {
+		t.Fatalf("SaveOrgSnapshot(acme) error = %v", err)
+	}
+	login("token-globex", "globex")
+	if err := vault.SaveOrgSnapshot(fileSet, "work", OrgSnapshot{ID: "org-2", Name: "Globex"}); err != nil {
+		t.Fatalf("SaveOrgSnapshot(globex) error = %v", err)
+	}
+
+	orgs, err := vault.ListOrgSnapshots("claude", "work")
+	if err != nil || len(orgs) != 2 || orgs[0].Name != "Acme" || orgs[1].Name != "Globex" {
+		t.Fatalf("ListOrgSnapshots() = %+v, %v", orgs, err)
+	}
+
+	org, err := vault.FindOrgSnapshot("claude", "work", "acme")
+	if err != nil || org.ID != "org-1" {
+		t.Fatalf("FindOrgSnapshot(acme) = %+v, %v", org, err)
+	}
+	if _, err := vault.FindOrgSnapshot("claude", "work", "initech"); err == nil {
+		t.Error("FindOrgSnapshot(initech) should fail")
+	}
+
+	if err := vault.RestoreOrgSnapshot(fileSet, "work", org.ID); err != nil {
+		t.Fatalf("RestoreOrgSnapshot() error = %v", err)
+	}
+	data, _ := os.ReadFile(vault.BackupPath("claude", "work", ".credentials.json"))
+	if string(data) != "token-acme" {
+		t.Errorf("vault credentials = %q, want token-acme", data)
+	}
+	data, _ = os.ReadFile(vault.BackupPath("claude", "work", ".claude.json"))
+	if string(data) != "acme" {
+		t.Errorf("vault config = %q, want acme", data)
+	}
+
+	// Snapshots survive a later backup of the profile.
+	login("token-globex-2", "globex")
+	if orgs, _ := vault.ListOrgSnapshots("claude", "work"); len(orgs) != 2 {
+		t.Errorf("snapshots after backup = %d, want 2", len(orgs))
+	}
+}
+
+func TestVaultGCPProject(t *testing.T) {
+	tmpDir := t.TempDir()
+	vault := NewVault(filepath.Join(tmpDir, "vault"))
+	profileDir := vault.ProfilePath("gemini", "work")
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	envPath := filepath.Join(profileDir, ".env")
+	if err := os.WriteFile(envPath, []byte("GEMINI_API_KEY=abc\nexport GOOGLE_CLOUD_PROJECT=\"old-proj\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := vault.GCPProject("work")
+	if err != nil || p.Project != "old-proj" || p.QuotaProject != "" {
+		t.Fatalf("GCPProject() = %+v, %v", p, err)
+	}
+
+	// Without ADC credentials there is no quota project to set.
+	if err := vault.SetGCPProject("work", GCPProject{QuotaProject: "billing"}); err == nil {
+		t.Error("SetGCPProject(quota) without ADC: want error")
+	}
+
+	adc := `{"type":"authorized_user","refresh_token":"1//r","quota_project_id":"old-quota"}`
+	if err := os.WriteFile(filepath.Join(profileDir, "application_default_credentials.json"), []byte(adc), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := vault.SetGCPProject("work", GCPProject{Project: "new-proj", QuotaProject: "billing"}); err != nil {
+		t.Fatalf("SetGCPProject() error = %v", err)
+	}
+	p, err = vault.GCPProject("work")
+	if err != nil || p.Project != "new-proj" || p.QuotaProject != "billing" {
+		t.Errorf("GCPProject() after set = %+v, %v", p, err)
+	}
+
+	env, _ := os.ReadFile(envPath)
+	if string(env) != "GEMINI_API_KEY=abc\nGOOGLE_CLOUD_PROJECT=new-proj\n" {
+		t.Errorf(".env = %q", env)
+	}
+	var creds map[string]string
+	data, _ := os.ReadFile(filepath.Join(profileDir, "application_default_credentials.json"))
+	if err := json.Unmarshal(data, &creds); err != nil || creds["refresh_token"] != "1//r" {
+		t.Errorf("ADC credentials not preserved: %s, %v", data, err)
+	}
+
+	if err := vault.SetGCPProject("_original", GCPProject{Project: "x"}); err == nil {
+		t.Error("SetGCPProject(_original): want error")
+	}
+}
+
+func TestClaudeAPIKeyProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	credPath := filepath.Join(tmpDir, "home", ".claude", ".credentials.json")
+	settingsPath := filepath.Join(tmpDir, "home", ".claude", "settings.json")
+	fileSet := AuthFileSet{
+		Tool: "claude",
+		Files: []AuthFileSpec{
+			{Tool: "claude", Path: credPath, Required: true},
+			{Tool: "claude", Path: settingsPath},
+		},
+		AllowOptionalOnly: true,
+	}
+	vault := NewVault(filepath.Join(tmpDir, "vault"))
+
+	if err := os.MkdirAll(filepath.Dir(credPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(credPath, []byte(`{"claudeAiOauth":{"accessToken":"a"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := vault.Backup(fileSet, "max"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	settings, err := WithClaudeAPIKey([]byte(`{"model":"opus"}`), "sk-ant-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vault.SaveClaudeAPIKeyProfile("api", settings, ClaudeAPIKey{Store: APIKeyStoreSettings}); err != nil {
+		t.Fatalf("SaveClaudeAPIKeyProfile() error = %v", err)
+	}
+	if err := vault.SaveClaudeAPIKeyProfile("max", settings, ClaudeAPIKey{Store: APIKeyStoreSettings}); err == nil {
+		t.Error("SaveClaudeAPIKeyProfile() over an OAuth profile: want error")
+	}
+	if k := vault.ClaudeAPIKey("api"); k == nil || k.Store != APIKeyStoreSettings {
+		t.Errorf("ClaudeAPIKey(api) = %+v", k)
+	}
+	if k := vault.ClaudeAPIKey("max"); k != nil {
+		t.Errorf("ClaudeAPIKey(max) = %+v, want nil", k)
+	}
+
+	// The key wins over the OAuth credentials still on disk.
+	if err := vault.Restore(fileSet, "api"); err != nil {
+		t.Fatalf("Restore(api) error = %v", err)
+	}
+	if active, _ := vault.ActiveProfile(fileSet); active != "api" {
+		t.Errorf("ActiveProfile() = %q, want api", active)
+	}
+
+	// Restoring an OAuth profile drops the key but keeps other settings.
+	if err := vault.Restore(fileSet, "max"); err != nil {
+		t.Fatalf("Restore(max) error = %v", err)
+	}
+	data, _ := os.ReadFile(settingsPath)
+	var live map[string]interface{}
+	if err := json.Unmarshal(data, &live); err != nil || live["env"] != nil || live["model"] != "opus" {
+		t.Errorf("live settings after OAuth restore = %s, %v", data, err)
+	}
+	if active, _ := vault.ActiveProfile(fileSet); active != "max" {
+		t.Errorf("ActiveProfile() = %q, want max", active)
+	}
+
+	// A key caam didn't put there is left alone.
+	if err := os.WriteFile(settingsPath, []byte(`{"apiKeyHelper":"my-helper"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := vault.Restore(fileSet, "max"); err != nil {
+		t.Fatalf("Restore(max) error = %v", err)
+	}
+	if data, _ := os.ReadFile(settingsPath); string(data) != `{"apiKeyHelper":"my-helper"}` {
+		t.Errorf("user apiKeyHelper changed: %s", data)
+	}
+}
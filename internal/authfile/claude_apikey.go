@@ -0,0 +1,234 @@
+package authfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// Claude API-key profiles.
+//
+// Besides claude.ai OAuth logins, Claude Code authenticates with an
+// Anthropic API key taken from ANTHROPIC_API_KEY or printed by an
+// apiKeyHelper command. An API-key profile keeps that setting in the
+// profile's copy of ~/.claude/settings.json: either the key itself under
+// "env", or an apiKeyHelper that reads it from the OS keychain. Claude Code
+// prefers the key over OAuth credentials, so activating an OAuth profile
+// removes a key an API-key profile left in the live settings.
+
+// ClaudeAPIKeyEnv is the variable Claude Code reads an API key from.
+const ClaudeAPIKeyEnv = "ANTHROPIC_API_KEY"
+
+const (
+	claudeSettingsFile   = "settings.json"
+	claudeAPIKeyMetaFile = "api-key.json"
+)
+
+// Where an API-key profile keeps its key.
+const (
+	APIKeyStoreSettings = "settings"
+	APIKeyStoreKeychain = "keychain"
+)
+
+// ClaudeAPIKey describes how an API-key profile stores its key.
+type ClaudeAPIKey struct {
+	Store string `json:"store"`
+	// KeychainID and KeychainRef locate a key kept in the OS keychain.
+	KeychainID  string    `json:"keychain_id,omitempty"`
+	KeychainRef string    `json:"keychain_ref,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// claudeSettingsAuth returns the API key and apiKeyHelper set in Claude
+// settings; both are empty for OAuth settings.
+func claudeSettingsAuth(data []byte) (key, helper string) {
+	var settings struct {
+		APIKeyHelper string            `json:"apiKeyHelper"`
+		Env          map[string]string `json:"env"`
+	}
+	if json.Unmarshal(data, &settings) != nil {
+		return "", ""
+	}
+	return settings.Env[ClaudeAPIKeyEnv], settings.APIKeyHelper
+}
+
+// WithClaudeAPIKey returns Claude settings that authenticate with key, or,
+// when helper is set, with the apiKeyHelper command. Other settings are kept.
+func WithClaudeAPIKey(settings []byte, key, helper string) ([]byte, error) {
+	root, err := WithoutClaudeAPIKey(settings)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(root, &m); err != nil {
+		return nil, err
+	}
+	if helper != "" {
+		m["apiKeyHelper"] = helper
+	} else {
+		env, _ := m["env"].(map[string]interface{})
+		if env == nil {
+			env = make(map[string]interface{})
+		}
+		env[ClaudeAPIKeyEnv] = key
+		m["env"] = env
+	}
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// WithoutClaudeAPIKey returns Claude settings with any API key and
+// apiKeyHelper removed. Empty input yields an empty object.
+func WithoutClaudeAPIKey(settings []byte) ([]byte, error) {
+	m := make(map[string]interface{})
+	if len(settings) > 0 {
+		if err := json.Unmarshal(settings, &m); err != nil {
+			return nil, fmt.Errorf("parse claude settings: %w", err)
+		}
+	}
+	delete(m, "apiKeyHelper")
+	if env, ok := m["env"].(map[string]interface{}); ok {
+		delete(env, ClaudeAPIKeyEnv)
+		if len(env) == 0 {
+			delete(m, "env")
+		}
+	}
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// SaveClaudeAPIKeyProfile stores an API-key profile: settings (as built by
+// WithClaudeAPIKey) become the profile's settings.json, and key records
+// where the key lives. An existing profile must already be an API-key
+// profile; OAuth profiles are never converted.
+func (v *Vault) SaveClaudeAPIKeyProfile(profile string, settings []byte, key ClaudeAPIKey) error {
+	profileDir, err := v.safeProfileDir("claude", profile)
+	if err != nil {
+		return err
+	}
+	if IsSystemProfile(profile) {
+		return fmt.Errorf("%w: refusing to modify claude/%s", errProtectedSystemProfile, profile)
+	}
+	if _, err := os.Stat(profileDir); err == nil && v.ClaudeAPIKey(profile) == nil {
+		return fmt.Errorf("profile claude/%s holds OAuth credentials; choose another name", profile)
+	}
+
+	lock, err := v.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		return fmt.Errorf("create profile dir: %w", err)
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now().UTC()
+	}
+	meta, err := json.MarshalIndent(key, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal api key: %w", err)
+	}
+	if err := writeVaultFile(filepath.Join(profileDir, claudeSettingsFile), settings); err != nil {
+		return fmt.Errorf("write settings: %w", err)
+	}
+	return writeVaultFile(filepath.Join(profileDir, claudeAPIKeyMetaFile), meta)
+}
+
+// ClaudeAPIKey reports how a claude profile stores its API key, or nil for
+// OAuth profiles. Profiles whose settings were backed up with an API key
+// but not made by SaveClaudeAPIKeyProfile report the settings store.
+func (v *Vault) ClaudeAPIKey(profile string) *ClaudeAPIKey {
+	profileDir, err := v.safeProfileDir("claude", profile)
+	if err != nil {
+		return nil
+	}
+	if data, err := vaultcrypt.ReadFile(filepath.Join(profileDir, claudeAPIKeyMetaFile)); err == nil {
+		var key ClaudeAPIKey
+		if json.Unmarshal(data, &key) == nil && key.Store != "" {
+			return &key
+		}
+	}
+	data, err := vaultcrypt.ReadFile(filepath.Join(profileDir, claudeSettingsFile))
+	if err != nil {
+		return nil
+	}
+	if key, helper := claudeSettingsAuth(data); key != "" || helper != "" {
+		return &ClaudeAPIKey{Store: APIKeyStoreSettings}
+	}
+	return nil
+}
+
+// claudeSettingsSpec returns the ~/.claude/settings.json entry of a claude
+// file set.
+func claudeSettingsSpec(fileSet AuthFileSet) (AuthFileSpec, bool) {
+	if fileSet.Tool != "claude" {
+		return AuthFileSpec{}, false
+	}
+	for _, spec := range fileSet.Files {
+		if spec.VaultFileName() == claudeSettingsFile {
+			return spec, true
+		}
+	}
+	return AuthFileSpec{}, false
+}
+
+// liveClaudeAPIKeyProfile returns the API-key profile whose key the live
+// Claude settings hold, or "".
+func (v *Vault) liveClaudeAPIKeyProfile(fileSet AuthFileSet) string {
+	spec, ok := claudeSettingsSpec(fileSet)
+	if !ok {
+		return ""
+	}
+	live, err := os.ReadFile(spec.Path)
+	if err != nil {
+		return ""
+	}
+	key, helper := claudeSettingsAuth(live)
+	if key == "" && helper == "" {
+		return ""
+	}
+	profiles, _ := v.List("claude")
+	for _, profile := range profiles {
+		if IsSystemProfile(profile) || v.ClaudeAPIKey(profile) == nil {
+			continue
+		}
+		data, err := vaultcrypt.ReadFile(filepath.Join(v.ProfilePath("claude", profile), claudeSettingsFile))
+		if err != nil {
+			continue
+		}
+		if k, h := claudeSettingsAuth(data); k == key && h == helper {
+			return profile
+		}
+	}
+	return ""
+}
+
+// claudeAPIKeyCleanup returns the live Claude settings without the key an
+// API-key profile left there, for Restore to write when the profile being
+// restored has no settings of its own, so Claude Code falls back to the
+// restored OAuth credentials. Keys caam didn't put there are left alone: ok
+// is false when there is nothing to write.
+func (v *Vault) claudeAPIKeyCleanup(fileSet AuthFileSet, profileDir string) (path string, cleaned []byte, ok bool, err error) {
+	spec, found := claudeSettingsSpec(fileSet)
+	if !found {
+		return "", nil, false, nil
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, claudeSettingsFile)); err == nil {
+		return "", nil, false, nil
+	}
+	if v.liveClaudeAPIKeyProfile(fileSet) == "" {
+		return "", nil, false, nil
+	}
+	live, err := os.ReadFile(spec.Path)
+	if err != nil {
+		return "", nil, false, err
+	}
+	cleaned, err = WithoutClaudeAPIKey(live)
+	if err != nil {
+		return "", nil, false, err
+	}
+	return spec.Path, cleaned, true, nil
+}
@@ -0,0 +1,109 @@
+package authfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// Encrypted reports whether the vault encrypts auth files at rest.
+func (v *Vault) Encrypted() bool {
+	return vaultcrypt.Enabled(v.basePath)
+}
+
+// EncryptFiles encrypts every plaintext auth file in the vault. The vault
+// key must already exist (see vaultcrypt.Init). It returns the number of
+// files encrypted; files that are already encrypted are left alone, so an
+// interrupted run can be repeated.
+func (v *Vault) EncryptFiles() (int, error) {
+	if !v.Encrypted() {
+		return 0, fmt.Errorf("vault has no encryption key")
+	}
+	key, err := vaultcrypt.Key(v.basePath)
+	if err != nil {
+		return 0, err
+	}
+	return v.convertFiles(func(data []byte) ([]byte, bool, error) {
+		if vaultcrypt.IsEncrypted(data) {
+			return nil, false, nil
+		}
+		sealed, err := vaultcrypt.Seal(key, data)
+		return sealed, true, err
+	})
+}
+
+// DecryptFiles decrypts every encrypted auth file in the vault and returns
+// the number of files decrypted. The vault key is left in place; remove it
+// with vaultcrypt.Remove once this succeeds.
+func (v *Vault) DecryptFiles() (int, error) {
+	if !v.Encrypted() {
+		return 0, fmt.Errorf("vault is not encrypted")
+	}
+	key, err := vaultcrypt.Key(v.basePath)
+	if err != nil {
+		return 0, err
+	}
+	return v.convertFiles(func(data []byte) ([]byte, bool, error) {
+		if !vaultcrypt.IsEncrypted(data) {
+			return nil, false, nil
+		}
+		plain, err := vaultcrypt.Open(key, data)
+		return plain, true, err
+	})
+}
+
+// convertFiles rewrites each profile auth file through convert, which
+// reports whether the file needs rewriting.
+func (v *Vault) convertFiles(convert func([]byte) ([]byte, bool, error)) (int, error) {
+	lock, err := v.lockForWrite()
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Unlock()
+
+	all, err := v.ListAll()
+	if err != nil {
+		return 0, fmt.Errorf("list profiles: %w", err)
+	}
+
+	converted := 0
+	for tool, profiles := range all {
+		fileSet, ok := GetAuthFileSet(tool)
+		if !ok {
+			continue
+		}
+		for _, profile := range profiles {
+			profileDir, err := v.safeProfileDir(tool, profile)
+			if err != nil {
+				continue
+			}
+			for _, spec := range fileSet.Files {
+				path := filepath.Join(profileDir, spec.VaultFileName())
+				data, err := os.ReadFile(path)
+				if os.IsNotExist(err) {
+					continue
+				}
+				if err != nil {
+					return converted, fmt.Errorf("read %s: %w", path, err)
+				}
+				out, rewrite, err := convert(data)
+				if err != nil {
+					return converted, fmt.Errorf("%s/%s %s: %w", tool, profile, spec.VaultFileName(), err)
+				}
+				if !rewrite {
+					continue
+				}
+				if err := writeFileAtomic(path, out); err != nil {
+					return converted, fmt.Errorf("write %s: %w", path, err)
+				}
+				converted++
+			}
+			if err := v.syncDir(profileDir); err != nil {
+				return converted, fmt.Errorf("sync profile dir: %w", err)
+			}
+		}
+	}
+	return converted, nil
+}
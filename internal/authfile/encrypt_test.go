@@ -0,0 +1,84 @@
+package authfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+func TestVaultEncryption_RoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv(vaultcrypt.PassphraseEnv, "correct horse")
+
+	authPath := filepath.Join(tmpDir, "codex_home", "auth.json")
+	fileSet := AuthFileSet{
+		Tool:  "codex",
+		Files: []AuthFileSpec{{Tool: "codex", Path: authPath, Required: true}},
+	}
+	writeAuth := func(content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(authPath), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(authPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v := NewVault(filepath.Join(tmpDir, "vault"))
+	writeAuth(`{"access_token":"plain"}`)
+	if err := v.Backup(fileSet, "plain"); err != nil {
+		t.Fatalf("Backup(plain) error = %v", err)
+	}
+
+	if _, err := vaultcrypt.Init(v.BasePath(), vaultcrypt.ModePassphrase); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if !v.Encrypted() {
+		t.Fatal("Encrypted() = false after Init")
+	}
+
+	// New backups are encrypted on disk.
+	writeAuth(`{"access_token":"secret"}`)
+	if err := v.Backup(fileSet, "secret"); err != nil {
+		t.Fatalf("Backup(secret) error = %v", err)
+	}
+	raw, _ := os.ReadFile(v.BackupPath("codex", "secret", "auth.json"))
+	if !vaultcrypt.IsEncrypted(raw) {
+		t.Fatalf("vault copy is plaintext: %s", raw)
+	}
+	if got, err := v.ActiveProfile(fileSet); err != nil || got != "secret" {
+		t.Errorf("ActiveProfile() = %q, %v; want secret", got, err)
+	}
+
+	// Existing plaintext profiles are migrated.
+	n, err := v.EncryptFiles()
+	if err != nil || n != 1 {
+		t.Fatalf("EncryptFiles() = %d, %v; want 1", n, err)
+	}
+	raw, _ = os.ReadFile(v.BackupPath("codex", "plain", "auth.json"))
+	if !vaultcrypt.IsEncrypted(raw) {
+		t.Fatalf("plain profile was not encrypted: %s", raw)
+	}
+
+	if err := v.Restore(fileSet, "plain"); err != nil {
+		t.Fatalf("Restore(plain) error = %v", err)
+	}
+	if got, _ := os.ReadFile(authPath); string(got) != `{"access_token":"plain"}` {
+		t.Errorf("restored auth = %s", got)
+	}
+
+	n, err = v.DecryptFiles()
+	if err != nil || n != 2 {
+		t.Fatalf("DecryptFiles() = %d, %v; want 2", n, err)
+	}
+	if err := vaultcrypt.Remove(v.BasePath()); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	raw, _ = os.ReadFile(v.BackupPath("codex", "secret", "auth.json"))
+	if string(raw) != `{"access_token":"secret"}` {
+		t.Errorf("decrypted vault copy = %s", raw)
+	}
+}
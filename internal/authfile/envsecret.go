@@ -0,0 +1,183 @@
+package authfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// EnvSecretPrefix starts the name of every environment variable that
+// carries a profile into a container: CAAM_AUTH_<PROVIDER>.
+const EnvSecretPrefix = "CAAM_AUTH_"
+
+// envSecretVersion prefixes an encoded EnvSecret.
+const envSecretVersion = "caam1:"
+
+// maxEnvSecretSize bounds a decoded EnvSecret, so a bad value can't
+// expand without limit.
+const maxEnvSecretSize = 16 << 20
+
+// EnvSecret is a profile's auth files packed into one environment variable
+// value, for CI containers and Kubernetes pods that can't log in
+// interactively. Files are keyed by vault file name.
+type EnvSecret struct {
+	Provider string            `json:"provider"`
+	Profile  string            `json:"profile"`
+	Files    map[string][]byte `json:"files"`
+}
+
+// EnvSecretName returns the environment variable that carries provider's
+// secret, e.g. CAAM_AUTH_GEMINI_CLI for gemini-cli.
+func EnvSecretName(provider string) string {
+	name := strings.ToUpper(provider)
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+	return EnvSecretPrefix + name
+}
+
+// EnvSecret reads the files of a vault profile that fileSet restores,
+// decrypting them if the vault is encrypted.
+func (v *Vault) EnvSecret(fileSet AuthFileSet, profile string) (*EnvSecret, error) {
+	profileDir, err := v.safeProfileDir(fileSet.Tool, profile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("profile %s/%s not found in vault; run 'caam ls %s' to see available profiles", fileSet.Tool, profile, fileSet.Tool)
+	}
+
+	s := &EnvSecret{Provider: fileSet.Tool, Profile: profile, Files: map[string][]byte{}}
+	for _, spec := range fileSet.Files {
+		name := spec.VaultFileName()
+		data, err := vaultcrypt.ReadFile(filepath.Join(profileDir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		s.Files[name] = data
+	}
+	if err := s.check(fileSet); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Encode packs s into a single-line value: gzipped JSON in base64.
+func (s *EnvSecret) Encode() (string, error) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return envSecretVersion + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeEnvSecret unpacks a value made by EnvSecret.Encode.
+func DecodeEnvSecret(value string) (*EnvSecret, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, envSecretVersion) {
+		return nil, fmt.Errorf("not a caam secret (expected a value starting with %q)", envSecretVersion)
+	}
+	packed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, envSecretVersion))
+	if err != nil {
+		return nil, fmt.Errorf("decode secret: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(packed))
+	if err != nil {
+		return nil, fmt.Errorf("decode secret: %w", err)
+	}
+	raw, err := io.ReadAll(io.LimitReader(zr, maxEnvSecretSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("decode secret: %w", err)
+	}
+	if len(raw) > maxEnvSecretSize {
+		return nil, fmt.Errorf("decode secret: larger than %d bytes", maxEnvSecretSize)
+	}
+	var s EnvSecret
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("decode secret: %w", err)
+	}
+	if s.Provider == "" || len(s.Files) == 0 {
+		return nil, fmt.Errorf("decode secret: no provider or auth files")
+	}
+	return &s, nil
+}
+
+// FileNames returns the names of the files in s, sorted.
+func (s *EnvSecret) FileNames() []string {
+	names := make([]string, 0, len(s.Files))
+	for name := range s.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Restore writes the files in s to their live paths in fileSet, replacing
+// all of them or none, as Vault.Restore does. No vault is needed.
+func (s *EnvSecret) Restore(fileSet AuthFileSet) error {
+	if err := s.check(fileSet); err != nil {
+		return err
+	}
+	var writes []restoreWrite
+	for _, spec := range fileSet.Files {
+		if data, ok := s.Files[spec.VaultFileName()]; ok {
+			writes = append(writes, restoreWrite{path: spec.Path, data: data, required: spec.Required})
+		}
+	}
+	return applyRestore(writes)
+}
+
+// check reports whether s holds a complete auth state for fileSet and
+// nothing else.
+func (s *EnvSecret) check(fileSet AuthFileSet) error {
+	known := make(map[string]bool, len(fileSet.Files))
+	requiredFound, optionalFound := false, false
+	var missingRequired []string
+	for _, spec := range fileSet.Files {
+		name := spec.VaultFileName()
+		known[name] = true
+		switch _, ok := s.Files[name]; {
+		case ok && spec.Required:
+			requiredFound = true
+		case ok:
+			optionalFound = true
+		case spec.Required:
+			missingRequired = append(missingRequired, name)
+		}
+	}
+	for _, name := range s.FileNames() {
+		if !known[name] {
+			return fmt.Errorf("%s has no auth file named %q", fileSet.Tool, name)
+		}
+	}
+	if !requiredFound && !optionalFound {
+		return fmt.Errorf("no auth files for %s/%s", fileSet.Tool, s.Profile)
+	}
+	if len(missingRequired) > 0 && !(fileSet.AllowOptionalOnly && !requiredFound && optionalFound) {
+		return fmt.Errorf("required auth file %s missing for %s/%s", missingRequired[0], fileSet.Tool, s.Profile)
+	}
+	return nil
+}
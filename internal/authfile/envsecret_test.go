@@ -0,0 +1,71 @@
+package authfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvSecretRoundTrip(t *testing.T) {
+	v, fileSet := multiFileSet(t)
+
+	secret, err := v.EnvSecret(fileSet, "next")
+	if err != nil {
+		t.Fatalf("EnvSecret() error = %v", err)
+	}
+	if got := strings.Join(secret.FileNames(), ","); got != "oauth_creds.json,settings.json" {
+		t.Errorf("FileNames() = %s", got)
+	}
+	value, err := secret.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(value, "\n ") {
+		t.Errorf("Encode() = %q, want a single word", value)
+	}
+
+	decoded, err := DecodeEnvSecret(value)
+	if err != nil {
+		t.Fatalf("DecodeEnvSecret() error = %v", err)
+	}
+	if decoded.Provider != "testtool" || decoded.Profile != "next" {
+		t.Errorf("decoded = %s/%s", decoded.Provider, decoded.Profile)
+	}
+	if err := decoded.Restore(fileSet); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if data, _ := os.ReadFile(fileSet.Files[1].Path); string(data) != "next-token" {
+		t.Errorf("oauth_creds.json = %q", data)
+	}
+	if data, _ := os.ReadFile(fileSet.Files[2].Path); string(data) != "KEY=1" {
+		t.Errorf(".env = %q, want it left alone", data)
+	}
+
+	if EnvSecretName("gemini-cli") != "CAAM_AUTH_GEMINI_CLI" {
+		t.Errorf("EnvSecretName(gemini-cli) = %s", EnvSecretName("gemini-cli"))
+	}
+}
+
+func TestEnvSecretRejectsIncompleteOrForeignFiles(t *testing.T) {
+	_, fileSet := multiFileSet(t)
+
+	for name, files := range map[string]map[string][]byte{
+		"missing required": {"oauth_creds.json": []byte("x")},
+		"unknown file":     {"settings.json": []byte("{}"), "../../etc/passwd": []byte("x")},
+	} {
+		s := &EnvSecret{Provider: "testtool", Profile: "p", Files: files}
+		if err := s.Restore(fileSet); err == nil {
+			t.Errorf("%s: Restore() succeeded", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(fileSet.Files[0].Path), "oauth_creds.json")); !os.IsNotExist(err) {
+		t.Error("a rejected secret wrote a file")
+	}
+
+	for _, value := range []string{"", "plain-token", "caam1:not base64!", "caam1:aGVsbG8="} {
+		if _, err := DecodeEnvSecret(value); err == nil {
+			t.Errorf("DecodeEnvSecret(%q) succeeded", value)
+		}
+	}
+}
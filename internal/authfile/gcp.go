@@ -0,0 +1,190 @@
+package authfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// Google Cloud project selection for gemini profiles.
+//
+// Gemini CLI bills Code Assist and Vertex AI requests to the project named by
+// GOOGLE_CLOUD_PROJECT, which it loads from ~/.gemini/.env; gcloud ADC bills
+// API quota to the credentials' quota_project_id. Both are stored per profile
+// in the vault, so switching profiles switches projects too.
+
+const (
+	// GCPProjectEnv is the variable Gemini CLI reads the project from.
+	GCPProjectEnv = "GOOGLE_CLOUD_PROJECT"
+
+	geminiEnvFile = ".env"
+	adcFile       = "application_default_credentials.json"
+)
+
+// GCPProject is the Google Cloud project a gemini profile uses.
+type GCPProject struct {
+	Project      string `json:"project,omitempty"`
+	QuotaProject string `json:"quota_project,omitempty"`
+}
+
+// GCPProject reads the project and quota project saved in a gemini profile.
+// Missing files yield empty fields.
+func (v *Vault) GCPProject(profile string) (GCPProject, error) {
+	profileDir, err := v.safeProfileDir("gemini", profile)
+	if err != nil {
+		return GCPProject{}, err
+	}
+
+	var p GCPProject
+	if data, err := vaultcrypt.ReadFile(filepath.Join(profileDir, geminiEnvFile)); err == nil {
+		p.Project = envFileValue(data, GCPProjectEnv)
+	} else if !os.IsNotExist(err) {
+		return GCPProject{}, err
+	}
+
+	data, err := vaultcrypt.ReadFile(filepath.Join(profileDir, adcFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return GCPProject{}, err
+	}
+	var adc struct {
+		QuotaProjectID string `json:"quota_project_id"`
+	}
+	if err := json.Unmarshal(data, &adc); err != nil {
+		return GCPProject{}, fmt.Errorf("parse %s: %w", adcFile, err)
+	}
+	p.QuotaProject = adc.QuotaProjectID
+	return p, nil
+}
+
+// SetGCPProject updates the project and quota project saved in a gemini
+// profile. Empty fields are left unchanged. Setting a quota project requires
+// the profile to hold ADC credentials. Like RestoreOrgSnapshot it only
+// changes the vault; restore the profile afterwards if it is active.
+func (v *Vault) SetGCPProject(profile string, p GCPProject) error {
+	profileDir, err := v.safeProfileDir("gemini", profile)
+	if err != nil {
+		return err
+	}
+	if IsSystemProfile(profile) {
+		return fmt.Errorf("%w: refusing to modify gemini/%s", errProtectedSystemProfile, profile)
+	}
+	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
+		return fmt.Errorf("profile gemini/%s not found in vault", profile)
+	}
+
+	lock, err := v.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if p.QuotaProject != "" {
+		path := filepath.Join(profileDir, adcFile)
+		data, err := vaultcrypt.ReadFile(path)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("gemini/%s has no Application Default Credentials; log in with 'gcloud auth application-default login', then run: caam backup gemini-adc %s", profile, profile)
+		}
+		if err != nil {
+			return err
+		}
+		var adc map[string]json.RawMessage
+		if err := json.Unmarshal(data, &adc); err != nil {
+			return fmt.Errorf("parse %s: %w", adcFile, err)
+		}
+		quota, _ := json.Marshal(p.QuotaProject)
+		adc["quota_project_id"] = quota
+		data, err = json.MarshalIndent(adc, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal %s: %w", adcFile, err)
+		}
+		if err := writeVaultFile(path, data); err != nil {
+			return fmt.Errorf("write %s: %w", adcFile, err)
+		}
+	}
+
+	if p.Project != "" {
+		path := filepath.Join(profileDir, geminiEnvFile)
+		data, err := vaultcrypt.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := writeVaultFile(path, setEnvFileValue(data, GCPProjectEnv, p.Project)); err != nil {
+			return fmt.Errorf("write %s: %w", geminiEnvFile, err)
+		}
+	}
+	return nil
+}
+
+// writeVaultFile writes data to a vault file, encrypting it when the vault
+// is encrypted.
+func writeVaultFile(path string, data []byte) error {
+	sealed, err := vaultcrypt.SealFor(path, data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, sealed)
+}
+
+// envFileValue returns the value of key in a dotenv file.
+func envFileValue(data []byte, key string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		k, v, ok := parseEnvLine(scanner.Text())
+		if ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// setEnvFileValue sets key in a dotenv file, replacing an existing
+// assignment or appending one, and keeps every other line as it was.
+func setEnvFileValue(data []byte, key, value string) []byte {
+	var out bytes.Buffer
+	replaced := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if k, _, ok := parseEnvLine(line); ok && k == key {
+			if replaced {
+				continue
+			}
+			line = key + "=" + value
+			replaced = true
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	if !replaced {
+		out.WriteString(key + "=" + value + "\n")
+	}
+	return out.Bytes()
+}
+
+// parseEnvLine splits a dotenv assignment, allowing an "export " prefix and
+// quoted values.
+func parseEnvLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	line = strings.TrimPrefix(line, "export ")
+	k, v, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+	v = strings.TrimSpace(v)
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		v = v[1 : len(v)-1]
+	}
+	return strings.TrimSpace(k), v, true
+}
@@ -0,0 +1,183 @@
+package authfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// orgDirName is the per-profile directory holding organization snapshots:
+// vault/<tool>/<profile>/orgs/<org-id>/.
+const orgDirName = "orgs"
+
+// orgMetaFile describes a snapshot inside its directory.
+const orgMetaFile = "org.json"
+
+// OrgSnapshot is a profile's saved auth state for one organization. Accounts
+// that belong to several organizations (Claude Team/Enterprise) pick one at
+// login; a snapshot per organization lets a profile switch between them
+// without logging in again.
+type OrgSnapshot struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name,omitempty"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// SaveOrgSnapshot copies the profile's current vault files for fileSet into
+// the snapshot for org, replacing any earlier snapshot of the same org.
+func (v *Vault) SaveOrgSnapshot(fileSet AuthFileSet, profile string, org OrgSnapshot) error {
+	profileDir, err := v.safeProfileDir(fileSet.Tool, profile)
+	if err != nil {
+		return err
+	}
+	orgID, err := validateVaultSegment("organization", org.ID)
+	if err != nil {
+		return err
+	}
+
+	lock, err := v.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	snapDir := filepath.Join(profileDir, orgDirName, orgID)
+	saved := 0
+	for _, spec := range fileSet.Files {
+		src := filepath.Join(profileDir, spec.VaultFileName())
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+		if err := copyFile(src, filepath.Join(snapDir, spec.VaultFileName())); err != nil {
+			return fmt.Errorf("snapshot %s: %w", spec.VaultFileName(), err)
+		}
+		saved++
+	}
+	if saved == 0 {
+		return fmt.Errorf("profile %s/%s has no auth files to snapshot", fileSet.Tool, profile)
+	}
+
+	org.ID = orgID
+	if org.SavedAt.IsZero() {
+		org.SavedAt = time.Now().UTC()
+	}
+	data, err := json.MarshalIndent(org, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal org snapshot: %w", err)
+	}
+	metaPath := filepath.Join(snapDir, orgMetaFile)
+	tmpPath := metaPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("write org snapshot: %w", err)
+	}
+	return os.Rename(tmpPath, metaPath)
+}
+
+// ListOrgSnapshots returns the organizations saved for a profile, sorted by
+// name.
+func (v *Vault) ListOrgSnapshots(tool, profile string) ([]OrgSnapshot, error) {
+	profileDir, err := v.safeProfileDir(tool, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(profileDir, orgDirName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read org snapshots: %w", err)
+	}
+
+	var orgs []OrgSnapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		org := OrgSnapshot{ID: entry.Name()}
+		if data, err := os.ReadFile(filepath.Join(profileDir, orgDirName, entry.Name(), orgMetaFile)); err == nil {
+			_ = json.Unmarshal(data, &org)
+			org.ID = entry.Name()
+		}
+		orgs = append(orgs, org)
+	}
+	sort.Slice(orgs, func(i, j int) bool {
+		return strings.ToLower(orgs[i].Name) < strings.ToLower(orgs[j].Name)
+	})
+	return orgs, nil
+}
+
+// FindOrgSnapshot resolves query against a profile's snapshots by ID or
+// case-insensitive name.
+func (v *Vault) FindOrgSnapshot(tool, profile, query string) (*OrgSnapshot, error) {
+	orgs, err := v.ListOrgSnapshots(tool, profile)
+	if err != nil {
+		return nil, err
+	}
+	query = strings.TrimSpace(query)
+	var byName []OrgSnapshot
+	for _, org := range orgs {
+		if org.ID == query {
+			return &org, nil
+		}
+		if strings.EqualFold(org.Name, query) {
+			byName = append(byName, org)
+		}
+	}
+	switch len(byName) {
+	case 0:
+		return nil, fmt.Errorf("no organization %q saved for %s/%s", query, tool, profile)
+	case 1:
+		return &byName[0], nil
+	default:
+		return nil, fmt.Errorf("organization name %q is ambiguous for %s/%s; use its ID", query, tool, profile)
+	}
+}
+
+// RestoreOrgSnapshot makes a saved organization the profile's current auth
+// state by copying the snapshot over the profile's vault files. It does not
+// touch the live auth files; restore the profile afterwards if it is active.
+func (v *Vault) RestoreOrgSnapshot(fileSet AuthFileSet, profile, orgID string) error {
+	profileDir, err := v.safeProfileDir(fileSet.Tool, profile)
+	if err != nil {
+		return err
+	}
+	orgID, err = validateVaultSegment("organization", orgID)
+	if err != nil {
+		return err
+	}
+	if IsSystemProfile(profile) {
+		return fmt.Errorf("%w: refusing to modify %s/%s", errProtectedSystemProfile, fileSet.Tool, profile)
+	}
+
+	lock, err := v.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	snapDir := filepath.Join(profileDir, orgDirName, orgID)
+	if _, err := os.Stat(snapDir); err != nil {
+		return fmt.Errorf("organization snapshot %s not found: %w", orgID, err)
+	}
+
+	restored := 0
+	for _, spec := range fileSet.Files {
+		src := filepath.Join(snapDir, spec.VaultFileName())
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+		if err := copyFile(src, filepath.Join(profileDir, spec.VaultFileName())); err != nil {
+			return fmt.Errorf("restore %s: %w", spec.VaultFileName(), err)
+		}
+		restored++
+	}
+	if restored == 0 {
+		return fmt.Errorf("organization snapshot %s has no auth files", orgID)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package authfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/chaos"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// What restoring a profile does to one live auth file.
+const (
+	// RestoreCreate writes a file that doesn't exist yet.
+	RestoreCreate = "create"
+	// RestoreReplace overwrites a file with different content.
+	RestoreReplace = "replace"
+	// RestoreUnchanged rewrites a file with the content it already has.
+	RestoreUnchanged = "unchanged"
+	// RestoreKeep leaves a file alone because the profile has no copy.
+	RestoreKeep = "keep"
+	// RestoreClearAPIKey removes the key an API-key profile left in the
+	// live Claude settings.
+	RestoreClearAPIKey = "clear_api_key"
+)
+
+// RestoreChange is what Restore would do to one live auth file.
+type RestoreChange struct {
+	Path     string `json:"path"`
+	Action   string `json:"action"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// restoreWrite is one live file Restore writes.
+type restoreWrite struct {
+	path     string
+	data     []byte
+	required bool
+	clearKey bool
+}
+
+// renameFile moves a staged file into place. Tests replace it to fail
+// partway through a restore.
+var renameFile = os.Rename
+
+// stageRestore reads every file restoring profile writes and checks the
+// profile is complete, without touching live auth. skipped lists the live
+// files the profile has no copy of.
+func (v *Vault) stageRestore(fileSet AuthFileSet, profile string) (writes []restoreWrite, skipped []AuthFileSpec, err error) {
+	profileDir, err := v.safeProfileDir(fileSet.Tool, profile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("profile %s/%s not found in vault; run 'caam ls %s' to see available profiles", fileSet.Tool, profile, fileSet.Tool)
+	}
+
+	if err := chaos.Err(chaos.CorruptAuth); err != nil {
+		return nil, nil, fmt.Errorf("restore %s/%s: %w", fileSet.Tool, profile, err)
+	}
+
+	requiredFound := false
+	optionalFound := false
+	var missingRequired []string
+	for _, spec := range fileSet.Files {
+		srcPath := filepath.Join(profileDir, spec.VaultFileName())
+
+		// Check if backup exists
+		if _, err := os.Stat(srcPath); os.IsNotExist(err) {
+			if spec.Required {
+				missingRequired = append(missingRequired, srcPath)
+			}
+			skipped = append(skipped, spec)
+			continue // Skip optional files
+		}
+
+		data, err := vaultcrypt.ReadFile(srcPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("restore %s: %w", spec.Path, err)
+		}
+		writes = append(writes, restoreWrite{path: spec.Path, data: data, required: spec.Required})
+		if spec.Required {
+			requiredFound = true
+		} else {
+			optionalFound = true
+		}
+	}
+
+	if len(writes) == 0 {
+		return nil, nil, fmt.Errorf("no auth files restored for %s/%s", fileSet.Tool, profile)
+	}
+	if len(missingRequired) > 0 {
+		if !(fileSet.AllowOptionalOnly && !requiredFound && optionalFound) {
+			return nil, nil, fmt.Errorf("required backup not found: %s", missingRequired[0])
+		}
+	}
+
+	path, cleaned, ok, err := v.claudeAPIKeyCleanup(fileSet, profileDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("clear claude api key: %w", err)
+	}
+	if ok {
+		writes = append(writes, restoreWrite{path: path, data: cleaned, clearKey: true})
+		for i, spec := range skipped {
+			if spec.Path == path {
+				skipped = append(skipped[:i], skipped[i+1:]...)
+				break
+			}
+		}
+	}
+	return writes, skipped, nil
+}
+
+// PlanRestore reports what Restore would do to each live auth file, without
+// changing any. It fails where Restore would fail before writing.
+func (v *Vault) PlanRestore(fileSet AuthFileSet, profile string) ([]RestoreChange, error) {
+	writes, skipped, err := v.stageRestore(fileSet, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]RestoreChange, 0, len(writes)+len(skipped))
+	for _, w := range writes {
+		change := RestoreChange{Path: w.path, Required: w.required}
+		live, err := os.ReadFile(w.path)
+		switch {
+		case w.clearKey:
+			change.Action = RestoreClearAPIKey
+		case os.IsNotExist(err):
+			change.Action = RestoreCreate
+		case err == nil && bytes.Equal(live, w.data):
+			change.Action = RestoreUnchanged
+		default:
+			change.Action = RestoreReplace
+		}
+		changes = append(changes, change)
+	}
+	for _, spec := range skipped {
+		if _, err := os.Stat(spec.Path); err == nil {
+			changes = append(changes, RestoreChange{Path: spec.Path, Action: RestoreKeep, Required: spec.Required})
+		}
+	}
+	return changes, nil
+}
+
+// applyRestore replaces every live file in writes or none of them. Each is
+// written to a temp file beside it first; the temp files are then renamed
+// into place, and if a rename fails the files already replaced are put back
+// as they were.
+func applyRestore(writes []restoreWrite) error {
+	type staged struct {
+		path    string
+		tmp     string
+		prev    []byte
+		existed bool
+	}
+	var all []staged
+	defer func() {
+		for _, s := range all {
+			os.Remove(s.tmp)
+		}
+	}()
+
+	for _, w := range writes {
+		s := staged{path: w.path}
+		prev, err := os.ReadFile(w.path)
+		switch {
+		case err == nil:
+			s.prev, s.existed = prev, true
+		case !os.IsNotExist(err):
+			return fmt.Errorf("restore %s: read current file: %w", w.path, err)
+		}
+		if s.tmp, err = stageFile(w.path, w.data); err != nil {
+			return fmt.Errorf("restore %s: %w", w.path, err)
+		}
+		all = append(all, s)
+	}
+
+	for i, s := range all {
+		if err := renameFile(s.tmp, s.path); err != nil {
+			err = fmt.Errorf("restore %s: %w", s.path, err)
+			var rollbackErrs []error
+			for j := i - 1; j >= 0; j-- {
+				done := all[j]
+				if done.existed {
+					rollbackErrs = append(rollbackErrs, writeFileAtomic(done.path, done.prev))
+				} else if rmErr := os.Remove(done.path); rmErr != nil && !os.IsNotExist(rmErr) {
+					rollbackErrs = append(rollbackErrs, rmErr)
+				}
+			}
+			if rbErr := errors.Join(rollbackErrs...); rbErr != nil {
+				return fmt.Errorf("%w; rolling back left auth files mixed: %v", err, rbErr)
+			}
+			if i > 0 {
+				return fmt.Errorf("%w (rolled back %d file(s) already replaced)", err, i)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// ActivationHook runs before Activate switches the live auth to profile,
+// and may refuse the switch by returning an error. Otherwise it returns a
+// function Activate calls once the restore is over, with whether it
+// succeeded, so the hook can finish or undo what it started. source names
+// the caller, e.g. "wrap" or "tui".
+type ActivationHook func(fileSet AuthFileSet, profile, source string) (done func(activated bool), err error)
+
+var (
+	activationHookMu sync.RWMutex
+	activationHook   ActivationHook
+)
+
+// SetActivationHook installs the hook every Activate in this process runs.
+// The caam CLI uses it to take leases, run the pre-activate hook and save
+// the live auth first, whichever package the switch comes from.
+func SetActivationHook(h ActivationHook) {
+	activationHookMu.Lock()
+	activationHook = h
+	activationHookMu.Unlock()
+}
+
+// Activate makes profile the live auth for fileSet: Restore, wrapped in
+// the process's activation hook, if one is installed.
+func (v *Vault) Activate(fileSet AuthFileSet, profile, source string) error {
+	activationHookMu.RLock()
+	hook := activationHook
+	activationHookMu.RUnlock()
+
+	done := func(bool) {}
+	if hook != nil {
+		d, err := hook(fileSet, profile, source)
+		if err != nil {
+			return err
+		}
+		if d != nil {
+			done = d
+		}
+	}
+	err := v.Restore(fileSet, profile)
+	done(err == nil)
+	return err
+}
@@ -0,0 +1,152 @@
+package authfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// multiFileSet is a gemini-like set with one required and two optional
+// files, with profile "next" saved in the vault.
+func multiFileSet(t *testing.T) (*Vault, AuthFileSet) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	authDir := filepath.Join(tmpDir, "auth")
+	fileSet := AuthFileSet{
+		Tool: "testtool",
+		Files: []AuthFileSpec{
+			{Tool: "testtool", Path: filepath.Join(authDir, "settings.json"), Required: true},
+			{Tool: "testtool", Path: filepath.Join(authDir, "oauth_creds.json")},
+			{Tool: "testtool", Path: filepath.Join(authDir, ".env")},
+		},
+	}
+
+	v := NewVault(filepath.Join(tmpDir, "vault"))
+	profileDir := v.ProfilePath("testtool", "next")
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"settings.json": `{"same":true}`, "oauth_creds.json": "next-token"} {
+		if err := os.WriteFile(filepath.Join(profileDir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.MkdirAll(authDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"settings.json": `{"same":true}`, ".env": "KEY=1"} {
+		if err := os.WriteFile(filepath.Join(authDir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return v, fileSet
+}
+
+func TestPlanRestore(t *testing.T) {
+	v, fileSet := multiFileSet(t)
+
+	changes, err := v.PlanRestore(fileSet, "next")
+	if err != nil {
+		t.Fatalf("PlanRestore() error = %v", err)
+	}
+	got := map[string]string{}
+	for _, c := range changes {
+		got[filepath.Base(c.Path)] = c.Action
+	}
+	want := map[string]string{"settings.json": RestoreUnchanged, "oauth_creds.json": RestoreCreate, ".env": RestoreKeep}
+	if len(got) != len(want) {
+		t.Fatalf("changes = %+v, want %v", changes, want)
+	}
+	for name, action := range want {
+		if got[name] != action {
+			t.Errorf("%s: action = %q, want %q", name, got[name], action)
+		}
+	}
+	if _, err := os.Stat(fileSet.Files[1].Path); !os.IsNotExist(err) {
+		t.Error("PlanRestore() wrote a live file")
+	}
+
+	if _, err := v.PlanRestore(fileSet, "missing"); err == nil {
+		t.Error("PlanRestore() of a missing profile succeeded")
+	}
+}
+
+func TestRestoreRollsBackOnPartialFailure(t *testing.T) {
+	v, fileSet := multiFileSet(t)
+	if err := os.WriteFile(fileSet.Files[0].Path, []byte(`{"old":true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first file is replaced, then the second rename fails.
+	renames := 0
+	renameFile = func(from, to string) error {
+		renames++
+		if renames == 2 {
+			return errors.New("disk full")
+		}
+		return os.Rename(from, to)
+	}
+	t.Cleanup(func() { renameFile = os.Rename })
+
+	err := v.Restore(fileSet, "next")
+	if err == nil || !strings.Contains(err.Error(), "disk full") || !strings.Contains(err.Error(), "rolled back 1 file") {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if data, _ := os.ReadFile(fileSet.Files[0].Path); string(data) != `{"old":true}` {
+		t.Errorf("settings.json after rollback = %q, want the previous content", data)
+	}
+	if _, err := os.Stat(fileSet.Files[1].Path); !os.IsNotExist(err) {
+		t.Errorf("oauth_creds.json exists after rollback: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(fileSet.Files[0].Path))
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp.") {
+			t.Errorf("temp file %s left behind", e.Name())
+		}
+	}
+
+	// Nothing is written when a required file is missing from the profile.
+	if err := os.Remove(filepath.Join(v.ProfilePath("testtool", "next"), "settings.json")); err != nil {
+		t.Fatal(err)
+	}
+	renameFile = os.Rename
+	if err := v.Restore(fileSet, "next"); err == nil {
+		t.Fatal("Restore() without the required file succeeded")
+	}
+	if _, err := os.Stat(fileSet.Files[1].Path); !os.IsNotExist(err) {
+		t.Error("Restore() wrote an optional file before failing on the required one")
+	}
+}
+
+func TestActivateRunsHook(t *testing.T) {
+	v, fileSet := multiFileSet(t)
+	creds := fileSet.Files[1].Path
+	defer SetActivationHook(nil)
+
+	SetActivationHook(func(fs AuthFileSet, profile, source string) (func(bool), error) {
+		return nil, errors.New(source + " may not activate " + profile)
+	})
+	if err := v.Activate(fileSet, "next", "test"); err == nil || !strings.Contains(err.Error(), "test may not activate next") {
+		t.Fatalf("refused Activate() error = %v", err)
+	}
+	if _, err := os.Stat(creds); !os.IsNotExist(err) {
+		t.Fatal("a refused activation restored the profile")
+	}
+
+	var results []bool
+	SetActivationHook(func(fs AuthFileSet, profile, source string) (func(bool), error) {
+		return func(activated bool) { results = append(results, activated) }, nil
+	})
+	if err := v.Activate(fileSet, "missing", "test"); err == nil {
+		t.Fatal("activating a missing profile succeeded")
+	}
+	if err := v.Activate(fileSet, "next", "test"); err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+	if len(results) != 2 || results[0] || !results[1] {
+		t.Errorf("done calls = %v, want [false true]", results)
+	}
+}
@@ -28,6 +28,11 @@ type MonitorConfig struct {
 	// Default: 3
 	MaxConcurrent int
 
+	// SkipRefresh, if set, excludes profiles from automatic refresh (for
+	// example when automation is disabled for their provider). ForceRefresh
+	// ignores it.
+	SkipRefresh func(provider, profile string) bool
+
 	// OnRefreshStart is called when a refresh starts.
 	OnRefreshStart func(provider, profile string)
 
@@ -54,6 +59,7 @@ type Monitor struct {
 	mu        sync.Mutex
 	running   bool
 	stopping  bool      // Set when Stop() is called, prevents new refreshes
+	paused    bool      // Set by Pause(), skips refresh checks until Resume()
 	stopCh    chan struct{}
 	stopOnce  sync.Once // Ensures stopCh is only closed once
 	refreshWg sync.WaitGroup
@@ -136,6 +142,28 @@ func (m *Monitor) IsRunning() bool {
 	return m.running
 }
 
+// Pause suspends automatic refreshes without stopping the loop.
+// In-flight refreshes are allowed to finish.
+func (m *Monitor) Pause() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paused = true
+}
+
+// Resume re-enables automatic refreshes after Pause.
+func (m *Monitor) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paused = false
+}
+
+// IsPaused returns whether automatic refreshes are paused.
+func (m *Monitor) IsPaused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paused
+}
+
 // runLoop is the main monitoring loop.
 func (m *Monitor) runLoop(ctx context.Context) {
 	ticker := time.NewTicker(m.config.CheckInterval)
@@ -158,6 +186,10 @@ func (m *Monitor) runLoop(ctx context.Context) {
 
 // checkAndRefresh checks all profiles and triggers refresh for those needing it.
 func (m *Monitor) checkAndRefresh(ctx context.Context) {
+	if m.IsPaused() {
+		return
+	}
+
 	// Clear expired cooldowns first
 	m.pool.CheckAndUpdateCooldowns()
 
@@ -169,6 +201,9 @@ func (m *Monitor) checkAndRefresh(ctx context.Context) {
 		if profile.Status == PoolStatusRefreshing {
 			continue
 		}
+		if m.config.SkipRefresh != nil && m.config.SkipRefresh(profile.Provider, profile.ProfileName) {
+			continue
+		}
 
 		// Check if expiring soon or already expired/error
 		needsRefresh := profile.IsExpiringSoon(m.config.RefreshThreshold) ||
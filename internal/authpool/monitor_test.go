@@ -176,6 +176,72 @@ func TestMonitor_RefreshesExpiringSoon(t *testing.T) {
 	}
 }
 
+func TestMonitor_PauseResume(t *testing.T) {
+	pool := NewAuthPool()
+	refresher := NewMockRefresher()
+	config := DefaultMonitorConfig()
+	config.CheckInterval = 10 * time.Millisecond
+	config.RefreshThreshold = 10 * time.Minute
+
+	monitor := NewMonitor(pool, refresher, config)
+
+	pool.AddProfile("claude", "expiring")
+	pool.SetStatus("claude", "expiring", PoolStatusReady)
+	pool.UpdateTokenExpiry("claude", "expiring", time.Now().Add(5*time.Minute))
+
+	monitor.Pause()
+	if !monitor.IsPaused() {
+		t.Fatal("IsPaused() = false after Pause()")
+	}
+
+	monitor.Start(context.Background())
+	defer monitor.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+	if refresher.CallCount() != 0 {
+		t.Errorf("paused monitor refreshed %d times", refresher.CallCount())
+	}
+
+	monitor.Resume()
+	time.Sleep(50 * time.Millisecond)
+	if refresher.CallCount() == 0 {
+		t.Error("resumed monitor should refresh expiring profile")
+	}
+}
+
+func TestMonitor_SkipRefresh(t *testing.T) {
+	pool := NewAuthPool()
+	refresher := NewMockRefresher()
+	config := DefaultMonitorConfig()
+	config.CheckInterval = 10 * time.Millisecond
+	config.RefreshThreshold = 10 * time.Minute
+	config.SkipRefresh = func(provider, profile string) bool {
+		return provider == "codex"
+	}
+
+	monitor := NewMonitor(pool, refresher, config)
+
+	pool.AddProfile("codex", "precious")
+	pool.SetStatus("codex", "precious", PoolStatusReady)
+	pool.UpdateTokenExpiry("codex", "precious", time.Now().Add(5*time.Minute))
+
+	monitor.Start(context.Background())
+	defer monitor.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+	if refresher.CallCount() != 0 {
+		t.Errorf("skipped provider refreshed %d times", refresher.CallCount())
+	}
+
+	// Manual refresh still works.
+	if err := monitor.ForceRefresh(context.Background(), "codex", "precious"); err != nil {
+		t.Fatalf("ForceRefresh() error = %v", err)
+	}
+	if refresher.CallCount() != 1 {
+		t.Errorf("ForceRefresh calls = %d, want 1", refresher.CallCount())
+	}
+}
+
 func TestMonitor_RefreshesExpired(t *testing.T) {
 	pool := NewAuthPool()
 	refresher := NewMockRefresher()
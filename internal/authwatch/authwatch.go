@@ -125,7 +125,7 @@ func (t *Tracker) Capture(provider string) (*AuthState, error) {
 		state.FileHashes[spec.Path] = hashStr
 
 		// Add to combined hash with filename + length delimiters to avoid ambiguity.
-		writeHashComponent(hasher, filepath.Base(spec.Path), content)
+		writeHashComponent(hasher, spec.VaultFileName(), content)
 		state.Exists = true
 	}
 
@@ -304,7 +304,7 @@ func (t *Tracker) getProfileHash(provider, profile string) (string, error) {
 
 	for _, spec := range fileSet.Files {
 		// Map source path to profile path
-		fileName := filepath.Base(spec.Path)
+		fileName := spec.VaultFileName()
 		profileFilePath := filepath.Join(profilePath, fileName)
 
 		content, err := os.ReadFile(profileFilePath)
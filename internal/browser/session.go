@@ -0,0 +1,403 @@
+package browser
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SessionFile is the vault file a captured browser session is stored in,
+// next to the profile's auth files.
+const SessionFile = "browser_session.json"
+
+// ErrBrowserRunning is returned when a browser's cookie database is locked,
+// which means the browser is open.
+var ErrBrowserRunning = errors.New("browser is running")
+
+// SessionBrowsers are the browsers sessions can be captured from.
+var SessionBrowsers = []string{"chrome", "chromium", "brave", "edge", "firefox"}
+
+// Session is a browser's cookies for a provider's domains.
+//
+// Chromium-family browsers encrypt cookie values with a key held by the OS
+// keychain, so a session is stored as the browser stored it and can only
+// be restored into the same browser profile on the same machine.
+type Session struct {
+	Browser    string    `json:"browser"`
+	ProfileDir string    `json:"profile_dir"`
+	CapturedAt time.Time `json:"captured_at"`
+	Domains    []string  `json:"domains"`
+	Cookies    []Row     `json:"cookies"`
+}
+
+// Row is a cookie database row by column name.
+type Row map[string]Value
+
+// Value is a SQLite value. All fields nil is NULL.
+type Value struct {
+	Int  *int64   `json:"i,omitempty"`
+	Real *float64 `json:"f,omitempty"`
+	Text *string  `json:"s,omitempty"`
+	Blob []byte   `json:"b,omitempty"`
+}
+
+func (v Value) sqlValue() interface{} {
+	switch {
+	case v.Int != nil:
+		return *v.Int
+	case v.Real != nil:
+		return *v.Real
+	case v.Text != nil:
+		return *v.Text
+	case v.Blob != nil:
+		return v.Blob
+	}
+	return nil
+}
+
+func toValue(x interface{}) Value {
+	switch x := x.(type) {
+	case int64:
+		return Value{Int: &x}
+	case float64:
+		return Value{Real: &x}
+	case string:
+		return Value{Text: &x}
+	case []byte:
+		return Value{Blob: append([]byte{}, x...)}
+	case bool:
+		var i int64
+		if x {
+			i = 1
+		}
+		return Value{Int: &i}
+	case time.Time:
+		s := x.Format(time.RFC3339Nano)
+		return Value{Text: &s}
+	}
+	return Value{}
+}
+
+// CookieDomains returns the domains whose cookies hold a provider's web
+// session.
+func CookieDomains(provider string) []string {
+	switch provider {
+	case "claude":
+		return []string{"claude.ai", "anthropic.com"}
+	case "codex":
+		return []string{"chatgpt.com", "openai.com"}
+	case "gemini":
+		return []string{"google.com"}
+	case "copilot":
+		return []string{"github.com"}
+	case "cursor":
+		return []string{"cursor.com", "cursor.sh"}
+	}
+	return nil
+}
+
+// isFirefox reports whether name is a Firefox browser rather than a
+// Chromium one.
+func isFirefox(name string) bool {
+	return strings.EqualFold(name, "firefox")
+}
+
+// UserDataDir returns where a browser keeps its profiles by default.
+func UserDataDir(name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dirs := map[string][3]string{
+		// linux, darwin, windows
+		"chrome":   {".config/google-chrome", "Library/Application Support/Google/Chrome", "Google/Chrome/User Data"},
+		"chromium": {".config/chromium", "Library/Application Support/Chromium", "Chromium/User Data"},
+		"brave":    {".config/BraveSoftware/Brave-Browser", "Library/Application Support/BraveSoftware/Brave-Browser", "BraveSoftware/Brave-Browser/User Data"},
+		"edge":     {".config/microsoft-edge", "Library/Application Support/Microsoft Edge", "Microsoft/Edge/User Data"},
+		"firefox":  {".mozilla/firefox", "Library/Application Support/Firefox/Profiles", "Mozilla/Firefox/Profiles"},
+	}
+	d, ok := dirs[strings.ToLower(name)]
+	if !ok {
+		return "", fmt.Errorf("unsupported browser %q (supported: %s)", name, strings.Join(SessionBrowsers, ", "))
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, filepath.FromSlash(d[1])), nil
+	case "windows":
+		base := os.Getenv("LOCALAPPDATA")
+		if isFirefox(name) {
+			base = os.Getenv("APPDATA")
+		}
+		if base == "" {
+			return "", fmt.Errorf("%s is not set", map[bool]string{true: "APPDATA", false: "LOCALAPPDATA"}[isFirefox(name)])
+		}
+		return filepath.Join(base, filepath.FromSlash(d[2])), nil
+	default:
+		return filepath.Join(home, filepath.FromSlash(d[0])), nil
+	}
+}
+
+// ProfileDir resolves a browser profile: an absolute path is used as is,
+// anything else is a directory in userDataDir. For Firefox an empty profile
+// picks the default-release profile; for Chromium browsers it is "Default".
+func ProfileDir(name, userDataDir, profile string) (string, error) {
+	if filepath.IsAbs(profile) {
+		return profile, nil
+	}
+	if profile != "" {
+		return filepath.Join(userDataDir, profile), nil
+	}
+	if !isFirefox(name) {
+		return filepath.Join(userDataDir, "Default"), nil
+	}
+	entries, err := os.ReadDir(userDataDir)
+	if err != nil {
+		return "", fmt.Errorf("find firefox profile: %w", err)
+	}
+	var fallback string
+	for _, e := range entries {
+		switch {
+		case !e.IsDir():
+		case strings.HasSuffix(e.Name(), ".default-release"):
+			return filepath.Join(userDataDir, e.Name()), nil
+		case strings.HasSuffix(e.Name(), ".default") && fallback == "":
+			fallback = filepath.Join(userDataDir, e.Name())
+		}
+	}
+	if fallback == "" {
+		return "", fmt.Errorf("no firefox profile in %s; pass one with --profile", userDataDir)
+	}
+	return fallback, nil
+}
+
+// cookieStore returns a browser profile's cookie database, its cookie
+// table, and the table's host column.
+func cookieStore(name, profileDir string) (path, table, hostCol string, err error) {
+	candidates := []string{filepath.Join(profileDir, "Network", "Cookies"), filepath.Join(profileDir, "Cookies")}
+	table, hostCol = "cookies", "host_key"
+	if isFirefox(name) {
+		candidates = []string{filepath.Join(profileDir, "cookies.sqlite")}
+		table, hostCol = "moz_cookies", "host"
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c, table, hostCol, nil
+		}
+	}
+	return "", "", "", fmt.Errorf("no cookie database in %s", profileDir)
+}
+
+// domainFilter returns a WHERE clause matching cookies set for domains or
+// their subdomains, with its arguments.
+func domainFilter(hostCol string, domains []string) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+	for _, d := range domains {
+		d = strings.TrimPrefix(strings.ToLower(d), ".")
+		clauses = append(clauses, fmt.Sprintf("(%[1]s = ? OR %[1]s = ? OR %[1]s LIKE ?)", hostCol))
+		args = append(args, d, "."+d, "%."+d)
+	}
+	return strings.Join(clauses, " OR "), args
+}
+
+// CaptureSession reads the cookies for domains from a browser profile. The
+// database is copied first, so the browser may be running.
+func CaptureSession(name, profileDir string, domains []string) (*Session, error) {
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("no cookie domains")
+	}
+	dbPath, table, hostCol, err := cookieStore(name, profileDir)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp("", "caam-cookies-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	copyPath := filepath.Join(tmp, "cookies.db")
+	for _, suffix := range []string{"", "-wal"} {
+		if err := copyIfExists(dbPath+suffix, copyPath+suffix); err != nil {
+			return nil, fmt.Errorf("copy cookie database: %w", err)
+		}
+	}
+
+	conn, err := sql.Open("sqlite", "file:"+filepath.ToSlash(copyPath))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	where, args := domainFilter(hostCol, domains)
+	rows, err := conn.Query(fmt.Sprintf("SELECT * FROM %s WHERE %s", table, where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("read cookies: %w", err)
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{
+		Browser:    strings.ToLower(name),
+		ProfileDir: profileDir,
+		CapturedAt: time.Now().UTC(),
+		Domains:    domains,
+		Cookies:    []Row{},
+	}
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("read cookies: %w", err)
+		}
+		row := make(Row, len(cols))
+		for i, c := range cols {
+			// Firefox's row id is reassigned on restore.
+			if c == "id" {
+				continue
+			}
+			row[c] = toValue(vals[i])
+		}
+		s.Cookies = append(s.Cookies, row)
+	}
+	return s, rows.Err()
+}
+
+// RestoreSession replaces the browser profile's cookies for the session's
+// domains with the captured ones and returns how many were written. The
+// browser must be closed; ErrBrowserRunning is returned if it is open.
+func RestoreSession(s *Session) (int, error) {
+	dbPath, table, hostCol, err := cookieStore(s.Browser, s.ProfileDir)
+	if err != nil {
+		return 0, err
+	}
+	conn, err := sql.Open("sqlite", "file:"+filepath.ToSlash(dbPath)+"?_pragma=busy_timeout(1000)")
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	columns, err := tableColumns(conn, table)
+	if err != nil {
+		return 0, lockedErr(s.Browser, err)
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return 0, lockedErr(s.Browser, err)
+	}
+	defer tx.Rollback()
+
+	where, args := domainFilter(hostCol, s.Domains)
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", table, where), args...); err != nil {
+		return 0, lockedErr(s.Browser, err)
+	}
+	for _, row := range s.Cookies {
+		var names, marks []string
+		var vals []interface{}
+		for c, v := range row {
+			// Columns the browser's schema no longer has are dropped, and
+			// NULLs are left to the column default.
+			if !columns[c] || v.sqlValue() == nil {
+				continue
+			}
+			names = append(names, c)
+			marks = append(marks, "?")
+			vals = append(vals, v.sqlValue())
+		}
+		if len(names) == 0 {
+			continue
+		}
+		query := fmt.Sprintf("INSERT OR REPLACE INTO %s (%s) VALUES (%s)", table, strings.Join(names, ", "), strings.Join(marks, ", "))
+		if _, err := tx.Exec(query, vals...); err != nil {
+			return 0, lockedErr(s.Browser, fmt.Errorf("write cookie: %w", err))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, lockedErr(s.Browser, err)
+	}
+	return len(s.Cookies), nil
+}
+
+func tableColumns(conn *sql.DB, table string) (map[string]bool, error) {
+	rows, err := conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no %s table in the cookie database", table)
+	}
+	return columns, rows.Err()
+}
+
+// lockedErr reports a locked database as ErrBrowserRunning.
+func lockedErr(name string, err error) error {
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "locked") || strings.Contains(msg, "busy") {
+		return fmt.Errorf("%w: close %s and try again", ErrBrowserRunning, name)
+	}
+	return err
+}
+
+// SortedDomains returns domains sorted and without duplicates.
+func SortedDomains(domains []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, d := range domains {
+		d = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), ".")
+		if d != "" && !seen[d] {
+			seen[d] = true
+			out = append(out, d)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func copyIfExists(src, dst string) error {
+	in, err := os.Open(src)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,140 @@
+package browser
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// chromeCookieDB creates a Chromium-style cookie database in profileDir.
+func chromeCookieDB(t *testing.T, profileDir string, hosts ...string) string {
+	t.Helper()
+	path := filepath.Join(profileDir, "Network", "Cookies")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Exec(`CREATE TABLE cookies (host_key TEXT NOT NULL, name TEXT NOT NULL, value TEXT NOT NULL, encrypted_value BLOB, expires_utc INTEGER NOT NULL, is_secure INTEGER, UNIQUE (host_key, name))`); err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range hosts {
+		if _, err := conn.Exec(`INSERT INTO cookies VALUES (?, 'sessionKey', 'v-'||?, x'763130aa', 13370000000000000, NULL)`, h, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func cookieHosts(t *testing.T, path string) map[string]string {
+	t.Helper()
+	conn, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rows, err := conn.Query(`SELECT host_key, value FROM cookies`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got := make(map[string]string)
+	for rows.Next() {
+		var host, value string
+		if err := rows.Scan(&host, &value); err != nil {
+			t.Fatal(err)
+		}
+		got[host] = value
+	}
+	return got
+}
+
+func TestCaptureRestoreSession(t *testing.T) {
+	profileDir := t.TempDir()
+	path := chromeCookieDB(t, profileDir, ".claude.ai", "claude.ai", "console.anthropic.com", "notclaude.ai", ".github.com")
+
+	s, err := CaptureSession("chrome", profileDir, CookieDomains("claude"))
+	if err != nil {
+		t.Fatalf("CaptureSession: %v", err)
+	}
+	var hosts []string
+	for _, row := range s.Cookies {
+		hosts = append(hosts, *row["host_key"].Text)
+		if string(row["encrypted_value"].Blob) != "v10\xaa" {
+			t.Errorf("encrypted_value = %q", row["encrypted_value"].Blob)
+		}
+	}
+	sort.Strings(hosts)
+	if want := []string{".claude.ai", "claude.ai", "console.anthropic.com"}; len(hosts) != len(want) || hosts[0] != want[0] || hosts[2] != want[2] {
+		t.Fatalf("captured hosts = %v, want %v", hosts, want)
+	}
+
+	// The session survives the vault's JSON round trip.
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored Session
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+
+	// Log in as someone else, then restore.
+	conn, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(`UPDATE cookies SET value = 'other'; INSERT INTO cookies VALUES ('api.claude.ai', 'x', 'other', NULL, 0, 1)`); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	n, err := RestoreSession(&stored)
+	if err != nil {
+		t.Fatalf("RestoreSession: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("restored %d cookies, want 3", n)
+	}
+	want := map[string]string{
+		".claude.ai":            "v-.claude.ai",
+		"claude.ai":             "v-claude.ai",
+		"console.anthropic.com": "v-console.anthropic.com",
+		"notclaude.ai":          "other",
+		".github.com":           "other",
+	}
+	got := cookieHosts(t, path)
+	if len(got) != len(want) {
+		t.Errorf("cookies after restore = %v", got)
+	}
+	for host, value := range want {
+		if got[host] != value {
+			t.Errorf("%s = %q, want %q", host, got[host], value)
+		}
+	}
+}
+
+func TestProfileDirFirefox(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"abc.default", "xyz.default-release"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := ProfileDir("firefox", dir, "")
+	if err != nil || got != filepath.Join(dir, "xyz.default-release") {
+		t.Errorf("ProfileDir = %q, %v", got, err)
+	}
+	if got, _ := ProfileDir("chrome", dir, ""); got != filepath.Join(dir, "Default") {
+		t.Errorf("chrome ProfileDir = %q", got)
+	}
+	if got, _ := ProfileDir("chrome", dir, "Profile 2"); got != filepath.Join(dir, "Profile 2") {
+		t.Errorf("named ProfileDir = %q", got)
+	}
+}
@@ -0,0 +1,123 @@
+package bundle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Minimal Bech32 (BIP 173) codec for age-style keys ("age1...",
+// "AGE-SECRET-KEY-1..."). Unlike BIP 173 there is no 90 character limit.
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var bech32Generator = []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func bech32Polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= bech32Generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// convertBits regroups a byte slice from one bit width to another.
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, error) {
+	var (
+		acc  uint32
+		bits uint
+		out  []byte
+	)
+	maxv := uint32(1)<<to - 1
+	for _, b := range data {
+		if uint32(b)>>from != 0 {
+			return nil, fmt.Errorf("invalid data range")
+		}
+		acc = acc<<from | uint32(b)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(to-bits)&maxv))
+		}
+	} else if bits >= from || acc<<(to-bits)&maxv != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return out, nil
+}
+
+// bech32Encode encodes data with the given human-readable part. The result
+// is lowercase unless hrp is uppercase.
+func bech32Encode(hrp string, data []byte) (string, error) {
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	lower := strings.ToLower(hrp)
+	polymod := bech32Polymod(append(append(bech32HRPExpand(lower), values...), 0, 0, 0, 0, 0, 0)) ^ 1
+
+	var sb strings.Builder
+	sb.WriteString(lower)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+	if hrp == strings.ToUpper(hrp) {
+		return strings.ToUpper(sb.String()), nil
+	}
+	return sb.String(), nil
+}
+
+// bech32Decode decodes a Bech32 string, returning its lowercase
+// human-readable part and data.
+func bech32Decode(s string) (string, []byte, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, fmt.Errorf("mixed case")
+	}
+	s = strings.ToLower(s)
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, fmt.Errorf("invalid separator position")
+	}
+	hrp := s[:pos]
+	var values []byte
+	for i := pos + 1; i < len(s); i++ {
+		idx := strings.IndexByte(bech32Charset, s[i])
+		if idx < 0 {
+			return "", nil, fmt.Errorf("invalid character %q", s[i])
+		}
+		values = append(values, byte(idx))
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), values...)) != 1 {
+		return "", nil, fmt.Errorf("invalid checksum")
+	}
+	data, err := convertBits(values[:len(values)-6], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, data, nil
+}
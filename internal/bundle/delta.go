@@ -0,0 +1,124 @@
+package bundle
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LastExportDirName is the directory, under the caam data path, holding the
+// manifest recorded by the last export for --since-last deltas.
+const LastExportDirName = "last_export"
+
+// LastExportDir returns where the last export's manifest is recorded.
+func LastExportDir(dataPath string) string {
+	return filepath.Join(dataPath, LastExportDirName)
+}
+
+// LoadLastExport reads the recorded last export manifest. It returns nil and
+// no error when no export has been recorded yet.
+func LoadLastExport(dataPath string) (*ManifestV1, error) {
+	m, err := LoadManifest(LastExportDir(dataPath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return m, err
+}
+
+// SaveLastExport records state (ExportResult.State) as the last export.
+func SaveLastExport(dataPath string, state *ManifestV1) error {
+	return SaveManifest(LastExportDir(dataPath), state)
+}
+
+// newExportState starts the record of an export from the vault checksums in
+// base, so profiles outside this export's filters keep their last state.
+func newExportState(manifest, base *ManifestV1) *ManifestV1 {
+	state := NewManifest()
+	state.ExportTimestamp = manifest.ExportTimestamp
+	state.ExportTimestampHuman = manifest.ExportTimestampHuman
+	state.Source = manifest.Source
+	if base == nil {
+		return state
+	}
+	for path, sum := range base.Checksums.Files {
+		if strings.HasPrefix(path, "vault/") {
+			state.AddChecksum(path, sum)
+		}
+	}
+	for provider, profiles := range base.Contents.Vault.Profiles {
+		for _, profile := range profiles {
+			state.AddProfile(provider, profile)
+		}
+	}
+	return state
+}
+
+// recordProfileState replaces a profile's checksums in state with those of
+// its current files, and returns them keyed by bundle path.
+func recordProfileState(state *ManifestV1, provider, profile string, files []fileEntry) (map[string]string, error) {
+	prefix := profileBundlePrefix(provider, profile)
+	for path := range state.Checksums.Files {
+		if strings.HasPrefix(path, prefix) {
+			delete(state.Checksums.Files, path)
+		}
+	}
+
+	sums := make(map[string]string, len(files))
+	for _, f := range files {
+		sum, err := ComputeFileChecksum(f.SrcPath, DefaultAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+		sums[f.RelPath] = sum
+		state.AddChecksum(f.RelPath, sum)
+	}
+	if !contains(state.Contents.Vault.Profiles[provider], profile) {
+		state.AddProfile(provider, profile)
+	}
+	return sums, nil
+}
+
+// profileChanged reports whether a profile belongs in a delta bundle: it
+// must differ from opts.Base and have a file modified after opts.Since,
+// for whichever of the two are set.
+func profileChanged(opts *ExportOptions, provider, profile string, files []fileEntry, sums map[string]string) bool {
+	if opts.Base != nil && !differsFromBase(opts.Base, provider, profile, sums) {
+		return false
+	}
+	if !opts.Since.IsZero() && !modifiedSince(files, opts.Since) {
+		return false
+	}
+	return true
+}
+
+func differsFromBase(base *ManifestV1, provider, profile string, sums map[string]string) bool {
+	prefix := profileBundlePrefix(provider, profile)
+	n := 0
+	for path, sum := range base.Checksums.Files {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		n++
+		if sums[path] != sum {
+			return true
+		}
+	}
+	return n != len(sums)
+}
+
+func modifiedSince(files []fileEntry, since time.Time) bool {
+	for _, f := range files {
+		info, err := os.Stat(f.SrcPath)
+		if err != nil || !info.ModTime().Before(since) {
+			return true
+		}
+	}
+	return false
+}
+
+func profileBundlePrefix(provider, profile string) string {
+	return NormalizePath(filepath.Join("vault", provider, profile)) + "/"
+}
@@ -0,0 +1,115 @@
+package bundle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeDeltaProfile(t *testing.T, vaultDir, provider, profile, content string) string {
+	t.Helper()
+	dir := filepath.Join(vaultDir, provider, profile)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "auth.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExportDeltaSinceLast(t *testing.T) {
+	tmpDir := t.TempDir()
+	vaultDir := filepath.Join(tmpDir, "vault")
+	writeDeltaProfile(t, vaultDir, "claude", "alice", `{"token":"a1"}`)
+	bobPath := writeDeltaProfile(t, vaultDir, "claude", "bob", `{"token":"b1"}`)
+
+	if base, err := LoadLastExport(tmpDir); err != nil || base != nil {
+		t.Fatalf("LoadLastExport() before any export = %v, %v; want nil, nil", base, err)
+	}
+
+	exporter := &VaultExporter{VaultPath: vaultDir, DataPath: tmpDir}
+	export := func(base *ManifestV1) (*ExportResult, error) {
+		opts := DefaultExportOptions()
+		opts.OutputDir = filepath.Join(tmpDir, "out")
+		opts.DryRun = true
+		opts.Base = base
+		return exporter.Export(opts)
+	}
+
+	full, err := export(nil)
+	if err != nil {
+		t.Fatalf("full export: %v", err)
+	}
+	if full.Manifest.Delta != nil || full.Manifest.Contents.Vault.TotalProfiles != 2 {
+		t.Fatalf("full export manifest = %+v", full.Manifest.Contents.Vault)
+	}
+	if err := SaveLastExport(tmpDir, full.State); err != nil {
+		t.Fatalf("SaveLastExport() error = %v", err)
+	}
+	base, err := LoadLastExport(tmpDir)
+	if err != nil || base == nil {
+		t.Fatalf("LoadLastExport() = %v, %v", base, err)
+	}
+
+	if _, err := export(base); !errors.Is(err, ErrNoChanges) {
+		t.Fatalf("delta with no changes error = %v, want ErrNoChanges", err)
+	}
+
+	if err := os.WriteFile(bobPath, []byte(`{"token":"b2"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	writeDeltaProfile(t, vaultDir, "codex", "carol", `{"token":"c1"}`)
+
+	delta, err := export(base)
+	if err != nil {
+		t.Fatalf("delta export: %v", err)
+	}
+	m := delta.Manifest
+	if m.Delta == nil || m.Delta.BaseExportTimestamp == nil || m.Delta.UnchangedProfiles != 1 {
+		t.Fatalf("delta info = %+v, want base timestamp and 1 unchanged profile", m.Delta)
+	}
+	if got := m.Contents.Vault.Profiles; len(got["claude"]) != 1 || got["claude"][0] != "bob" || len(got["codex"]) != 1 {
+		t.Errorf("delta profiles = %v, want claude/bob and codex/carol", got)
+	}
+
+	// The recorded state covers every profile, not only the delta's.
+	if got := delta.State.Contents.Vault.TotalProfiles; got != 3 {
+		t.Errorf("state profiles = %d, want 3", got)
+	}
+	if _, err := export(delta.State); !errors.Is(err, ErrNoChanges) {
+		t.Errorf("delta against new state error = %v, want ErrNoChanges", err)
+	}
+}
+
+func TestExportDeltaSince(t *testing.T) {
+	tmpDir := t.TempDir()
+	vaultDir := filepath.Join(tmpDir, "vault")
+	old := writeDeltaProfile(t, vaultDir, "claude", "old", `{}`)
+	writeDeltaProfile(t, vaultDir, "claude", "new", `{}`)
+
+	longAgo := time.Now().Add(-30 * 24 * time.Hour)
+	if err := os.Chtimes(old, longAgo, longAgo); err != nil {
+		t.Fatal(err)
+	}
+
+	exporter := &VaultExporter{VaultPath: vaultDir, DataPath: tmpDir}
+	opts := DefaultExportOptions()
+	opts.DryRun = true
+	opts.Since = time.Now().Add(-24 * time.Hour)
+	result, err := exporter.Export(opts)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	m := result.Manifest
+	if m.Delta == nil || m.Delta.Since == nil || m.Delta.UnchangedProfiles != 1 {
+		t.Fatalf("delta info = %+v", m.Delta)
+	}
+	if got := m.Contents.Vault.Profiles["claude"]; len(got) != 1 || got[0] != "new" {
+		t.Errorf("profiles = %v, want [new]", got)
+	}
+}
@@ -98,6 +98,15 @@ func DecryptBundle(ciphertext []byte, meta *EncryptionMetadata, password string)
 		return nil, fmt.Errorf("password is required")
 	}
 
+	// Multi-recipient bundles wrap their content key; try it as a passphrase
+	if meta.KDF == KDFRecipients {
+		plaintext, err := DecryptBundleWithIdentities(ciphertext, meta, []Identity{NewPassphraseRecipient(password)})
+		if err != nil {
+			return nil, fmt.Errorf("%w (wrong password?)", err)
+		}
+		return plaintext, nil
+	}
+
 	// Decode salt
 	salt, err := base64.StdEncoding.DecodeString(meta.Salt)
 	if err != nil {
@@ -262,6 +271,19 @@ func GenerateRandomBytes(n int) ([]byte, error) {
 	return b, nil
 }
 
+// newGCM creates an AES-256-GCM cipher for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create GCM: %w", err)
+	}
+	return gcm, nil
+}
+
 // SecureWipe attempts to overwrite sensitive data in memory.
 // Note: This is best-effort; Go's GC may have copied the data elsewhere.
 func SecureWipe(data []byte) {
@@ -3,6 +3,7 @@ package bundle
 import (
 	"archive/zip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -14,6 +15,8 @@ import (
 	"time"
 
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // ExportOptions configures the export operation.
@@ -27,9 +30,15 @@ type ExportOptions struct {
 	// Encrypt enables AES-256-GCM encryption with Argon2id key derivation.
 	Encrypt bool
 
-	// Password is the encryption password (required if Encrypt is true).
+	// Password is the encryption password. Required if Encrypt is true and
+	// Recipients is empty.
 	Password string
 
+	// Recipients are public keys or extra passphrases the content key is
+	// wrapped for, so any of them can open the bundle. Setting it implies
+	// Encrypt; Password, if set, becomes one more passphrase recipient.
+	Recipients []Recipient
+
 	// IncludeConfig includes config.yaml in the bundle.
 	IncludeConfig bool
 
@@ -53,8 +62,25 @@ type ExportOptions struct {
 
 	// DryRun shows what would be exported without creating a file.
 	DryRun bool
+
+	// Since, when set, makes a delta bundle of the profiles with a file
+	// modified after it.
+	Since time.Time
+
+	// Base, when set, makes a delta bundle of the profiles whose files
+	// differ from the checksums recorded in Base (see LoadLastExport).
+	// Profiles missing from Base count as changed.
+	Base *ManifestV1
+}
+
+// IsDelta reports whether the options select a delta bundle.
+func (o *ExportOptions) IsDelta() bool {
+	return !o.Since.IsZero() || o.Base != nil
 }
 
+// ErrNoChanges is returned by Export for a delta with no changed profiles.
+var ErrNoChanges = errors.New("no profiles changed")
+
 // DefaultExportOptions returns sensible defaults for export.
 func DefaultExportOptions() *ExportOptions {
 	return &ExportOptions{
@@ -85,6 +111,11 @@ type ExportResult struct {
 
 	// CompressedSize is the final bundle size in bytes.
 	CompressedSize int64
+
+	// State records the checksums of every profile this export scanned,
+	// merged over ExportOptions.Base. Save it with SaveLastExport so the
+	// next delta export can compare against it.
+	State *ManifestV1
 }
 
 // VaultExporter handles exporting vault contents to a bundle.
@@ -117,22 +148,43 @@ func (e *VaultExporter) Export(opts *ExportOptions) (*ExportResult, error) {
 		opts = DefaultExportOptions()
 	}
 
-	if opts.Encrypt && opts.Password == "" {
+	if len(opts.Recipients) > 0 {
+		opts.Encrypt = true
+	}
+	if opts.Encrypt && opts.Password == "" && len(opts.Recipients) == 0 {
 		return nil, fmt.Errorf("encryption enabled but no password provided")
 	}
 
 	// Create manifest
 	manifest := NewManifest()
+	if opts.Encrypt {
+		manifest.Recipients = exportRecipientInfos(opts)
+	}
 	if err := e.populateSourceInfo(manifest); err != nil {
 		return nil, fmt.Errorf("populate source info: %w", err)
 	}
+	state := newExportState(manifest, opts.Base)
+	if opts.IsDelta() {
+		manifest.Delta = &DeltaInfo{}
+		if !opts.Since.IsZero() {
+			since := opts.Since
+			manifest.Delta.Since = &since
+		}
+		if opts.Base != nil {
+			base := opts.Base.ExportTimestamp
+			manifest.Delta.BaseExportTimestamp = &base
+		}
+	}
 
 	// Collect files to include
-	files, err := e.collectFiles(opts, manifest)
+	files, err := e.collectFiles(opts, manifest, state)
 	if err != nil {
 		return nil, fmt.Errorf("collect files: %w", err)
 	}
 
+	if opts.IsDelta() && manifest.Contents.Vault.TotalProfiles == 0 {
+		return nil, ErrNoChanges
+	}
 	if len(files) == 0 {
 		return nil, fmt.Errorf("no files to export")
 	}
@@ -147,6 +199,7 @@ func (e *VaultExporter) Export(opts *ExportOptions) (*ExportResult, error) {
 			Manifest:   manifest,
 			Encrypted:  opts.Encrypt,
 			TotalFiles: len(files),
+			State:      state,
 		}, nil
 	}
 
@@ -165,7 +218,15 @@ func (e *VaultExporter) Export(opts *ExportOptions) (*ExportResult, error) {
 			return nil, fmt.Errorf("create dir for %s: %w", f.RelPath, err)
 		}
 
-		if err := copyFileForExport(f.SrcPath, destPath); err != nil {
+		if f.Data != nil {
+			if err := os.WriteFile(destPath, f.Data, 0600); err != nil {
+				return nil, fmt.Errorf("write %s: %w", f.RelPath, err)
+			}
+		} else if strings.HasPrefix(f.RelPath, "vault/") {
+			if err := copyVaultFileForExport(f.SrcPath, destPath); err != nil {
+				return nil, fmt.Errorf("copy %s: %w", f.RelPath, err)
+			}
+		} else if err := copyFileForExport(f.SrcPath, destPath); err != nil {
 			return nil, fmt.Errorf("copy %s: %w", f.RelPath, err)
 		}
 
@@ -196,7 +257,7 @@ func (e *VaultExporter) Export(opts *ExportOptions) (*ExportResult, error) {
 	// Handle encryption if requested
 	var finalPath string
 	if opts.Encrypt {
-		encPath, err := e.encryptBundle(zipPath, opts.Password, outputPath)
+		encPath, err := e.encryptBundle(zipPath, opts, outputPath)
 		if err != nil {
 			return nil, fmt.Errorf("encrypt bundle: %w", err)
 		}
@@ -225,6 +286,7 @@ func (e *VaultExporter) Export(opts *ExportOptions) (*ExportResult, error) {
 		TotalFiles:     len(files) + 1, // +1 for manifest
 		TotalSize:      totalSize,
 		CompressedSize: info.Size(),
+		State:          state,
 	}, nil
 }
 
@@ -232,19 +294,27 @@ func (e *VaultExporter) Export(opts *ExportOptions) (*ExportResult, error) {
 type fileEntry struct {
 	SrcPath string
 	RelPath string
+
+	// Data, when set, is written instead of copying SrcPath.
+	Data []byte
 }
 
 // collectFiles gathers all files to include in the bundle.
-func (e *VaultExporter) collectFiles(opts *ExportOptions, manifest *ManifestV1) ([]fileEntry, error) {
+func (e *VaultExporter) collectFiles(opts *ExportOptions, manifest, state *ManifestV1) ([]fileEntry, error) {
 	var files []fileEntry
 
 	// Collect vault profiles
-	vaultFiles, err := e.collectVaultFiles(opts, manifest)
+	vaultFiles, err := e.collectVaultFiles(opts, manifest, state)
 	if err != nil {
 		return nil, fmt.Errorf("collect vault files: %w", err)
 	}
 	files = append(files, vaultFiles...)
 
+	// Tags travel with the profiles they describe.
+	if tagFiles, err := e.collectTagFiles(manifest); err == nil {
+		files = append(files, tagFiles...)
+	}
+
 	// Collect optional files
 	if opts.IncludeConfig {
 		if configFiles, err := e.collectConfigFiles(manifest); err == nil {
@@ -290,7 +360,9 @@ func (e *VaultExporter) collectFiles(opts *ExportOptions, manifest *ManifestV1)
 }
 
 // collectVaultFiles collects profile files from the vault.
-func (e *VaultExporter) collectVaultFiles(opts *ExportOptions, manifest *ManifestV1) ([]fileEntry, error) {
+// Every scanned profile is recorded in state; delta options then leave out
+// the unchanged ones.
+func (e *VaultExporter) collectVaultFiles(opts *ExportOptions, manifest, state *ManifestV1) ([]fileEntry, error) {
 	var files []fileEntry
 
 	// Read vault directory
@@ -343,10 +415,21 @@ func (e *VaultExporter) collectVaultFiles(opts *ExportOptions, manifest *Manifes
 				continue
 			}
 
-			if len(profileFiles) > 0 {
-				files = append(files, profileFiles...)
-				manifest.AddProfile(provider, profile)
+			if len(profileFiles) == 0 {
+				continue
+			}
+
+			sums, err := recordProfileState(state, provider, profile, profileFiles)
+			if err != nil {
+				return nil, err
+			}
+			if opts.IsDelta() && !profileChanged(opts, provider, profile, profileFiles, sums) {
+				manifest.Delta.UnchangedProfiles++
+				continue
 			}
+
+			files = append(files, profileFiles...)
+			manifest.AddProfile(provider, profile)
 		}
 	}
 
@@ -515,6 +598,48 @@ func (e *VaultExporter) collectDatabaseFiles(manifest *ManifestV1) ([]fileEntry,
 	}}, nil
 }
 
+// TagsFileName is the bundle file holding profile tags.
+const TagsFileName = "tags.json"
+
+// collectTagFiles exports the tags of the profiles in the bundle from the
+// activity database as tags.json.
+func (e *VaultExporter) collectTagFiles(manifest *ManifestV1) ([]fileEntry, error) {
+	manifest.SetTags(false, "", 0)
+	if e.DatabasePath == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(e.DatabasePath); err != nil {
+		return nil, nil
+	}
+
+	db, err := caamdb.OpenAt(e.DatabasePath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	all, err := db.ListProfileTags("")
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []caamdb.ProfileTag
+	for _, t := range all {
+		if contains(manifest.Contents.Vault.Profiles[t.Provider], t.ProfileName) {
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.MarshalIndent(tags, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	manifest.SetTags(true, TagsFileName, len(tags))
+	return []fileEntry{{RelPath: TagsFileName, Data: data}}, nil
+}
+
 // collectSyncFiles collects sync configuration files.
 func (e *VaultExporter) collectSyncFiles(manifest *ManifestV1) ([]fileEntry, error) {
 	if e.SyncPath == "" {
@@ -598,15 +723,23 @@ func (e *VaultExporter) generateOutputPath(opts *ExportOptions) string {
 }
 
 // encryptBundle encrypts a zip file and saves it to the output path.
-func (e *VaultExporter) encryptBundle(zipPath, password, outputPath string) (string, error) {
+func (e *VaultExporter) encryptBundle(zipPath string, opts *ExportOptions, outputPath string) (string, error) {
 	// Read the zip file
 	plainData, err := os.ReadFile(zipPath)
 	if err != nil {
 		return "", fmt.Errorf("read zip: %w", err)
 	}
 
-	// Encrypt
-	ciphertext, meta, err := EncryptBundle(plainData, password)
+	// Encrypt: a lone password keeps the v1 format, recipients need v2
+	var (
+		ciphertext []byte
+		meta       *EncryptionMetadata
+	)
+	if len(opts.Recipients) == 0 {
+		ciphertext, meta, err = EncryptBundle(plainData, opts.Password)
+	} else {
+		ciphertext, meta, err = EncryptBundleForRecipients(plainData, exportRecipients(opts))
+	}
 	if err != nil {
 		return "", fmt.Errorf("encrypt: %w", err)
 	}
@@ -637,6 +770,27 @@ func (e *VaultExporter) encryptBundle(zipPath, password, outputPath string) (str
 	return outputPath, nil
 }
 
+// exportRecipients returns every recipient the bundle is encrypted to.
+func exportRecipients(opts *ExportOptions) []Recipient {
+	var recipients []Recipient
+	if opts.Password != "" {
+		recipients = append(recipients, NewPassphraseRecipient(opts.Password))
+	}
+	return append(recipients, opts.Recipients...)
+}
+
+// exportRecipientInfos describes the bundle's recipients for the manifest.
+func exportRecipientInfos(opts *ExportOptions) []RecipientInfo {
+	if len(opts.Recipients) == 0 {
+		return []RecipientInfo{{Type: RecipientPassphrase, Label: RecipientPassphrase}}
+	}
+	var infos []RecipientInfo
+	for _, r := range exportRecipients(opts) {
+		infos = append(infos, DescribeRecipient(r))
+	}
+	return infos
+}
+
 // atomicWriteBytes writes data to a file atomically using temp file + fsync + rename.
 func atomicWriteBytes(path string, data []byte, perm os.FileMode) error {
 	dir := filepath.Dir(path)
@@ -781,6 +935,32 @@ func copyFileForExport(src, dst string) error {
 	return os.Rename(tmpPath, dst)
 }
 
+// copyVaultFileForExport copies a vault file for export, decrypting it if
+// the vault is encrypted: the vault key never leaves this machine, so a
+// file sealed with it could not be imported anywhere else. Files sealed
+// with a sync pool key this machine doesn't hold are copied as they are.
+func copyVaultFileForExport(src, dst string) error {
+	data, err := vaultcrypt.ReadFile(src)
+	if err != nil {
+		var noKey *sync.ErrNoPoolKey
+		if !errors.As(err, &noKey) {
+			return err
+		}
+		if data, err = os.ReadFile(src); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	tmpPath := dst + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, dst)
+}
+
 // contains checks if a slice contains a string.
 func contains(slice []string, s string) bool {
 	for _, item := range slice {
@@ -0,0 +1,154 @@
+package bundle
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// GPGCommand is the gpg binary used for GPG recipients and identities.
+var GPGCommand = "gpg"
+
+// ============================================================================
+// GPG recipients
+// ============================================================================
+
+// GPGRecipient is a public key in the local GnuPG keyring. The content key is
+// encrypted with gpg itself, so any key type gpg supports works.
+type GPGRecipient struct {
+	fingerprint string
+	label       string
+}
+
+// GPGIdentity opens GPG stanzas with the secret keys in the local GnuPG
+// keyring; gpg-agent handles any key passphrase.
+type GPGIdentity struct{}
+
+// NewGPGRecipient looks up a key by fingerprint, key ID, or user ID (e.g. an
+// email address). The lookup must match exactly one key.
+func NewGPGRecipient(id string) (*GPGRecipient, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, fmt.Errorf("gpg recipient is empty")
+	}
+	out, err := runGPG(nil, "--batch", "--with-colons", "--list-keys", "--", id)
+	if err != nil {
+		return nil, fmt.Errorf("gpg key %q not found: %w", id, err)
+	}
+
+	var keys []*GPGRecipient
+	var cur *GPGRecipient
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, ":")
+		switch fields[0] {
+		case "pub":
+			cur = &GPGRecipient{}
+			keys = append(keys, cur)
+		case "fpr":
+			// The first fpr after pub is the primary key's.
+			if cur != nil && cur.fingerprint == "" && len(fields) > 9 {
+				cur.fingerprint = fields[9]
+			}
+		case "uid":
+			if cur != nil && cur.label == "" && len(fields) > 9 {
+				cur.label = fields[9]
+			}
+		}
+	}
+	switch {
+	case len(keys) == 0 || keys[0].fingerprint == "":
+		return nil, fmt.Errorf("gpg key %q not found", id)
+	case len(keys) > 1:
+		return nil, fmt.Errorf("gpg recipient %q matches %d keys; use a fingerprint", id, len(keys))
+	}
+
+	r := keys[0]
+	short := r.fingerprint
+	if len(short) > 16 {
+		short = short[len(short)-16:]
+	}
+	if r.label == "" {
+		r.label = short
+	} else {
+		r.label = fmt.Sprintf("%s (%s)", r.label, short)
+	}
+	return r, nil
+}
+
+// Fingerprint returns the primary key fingerprint.
+func (r *GPGRecipient) Fingerprint() string {
+	return r.fingerprint
+}
+
+// Wrap implements Recipient.
+func (r *GPGRecipient) Wrap(contentKey []byte) (*RecipientStanza, error) {
+	out, err := runGPG(contentKey,
+		"--batch", "--yes", "--quiet", "--trust-model", "always",
+		"--armor", "--encrypt", "--recipient", r.fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("gpg encrypt: %w", err)
+	}
+	return &RecipientStanza{
+		Type:       RecipientGPG,
+		Label:      r.label,
+		KeyID:      r.fingerprint,
+		WrappedKey: string(out),
+	}, nil
+}
+
+// Unwrap implements Identity. Stanzas for keys without a local secret key
+// are skipped without running gpg --decrypt, so no passphrase is asked for.
+func (GPGIdentity) Unwrap(s *RecipientStanza) ([]byte, error) {
+	if s.Type != RecipientGPG || s.KeyID == "" {
+		return nil, ErrIncorrectIdentity
+	}
+	if _, err := runGPG(nil, "--batch", "--with-colons", "--list-secret-keys", "--", s.KeyID); err != nil {
+		return nil, ErrIncorrectIdentity
+	}
+	key, err := runGPG([]byte(s.WrappedKey), "--quiet", "--decrypt")
+	if err != nil {
+		return nil, fmt.Errorf("gpg decrypt: %w", err)
+	}
+	if len(key) != contentKeySize {
+		SecureWipe(key)
+		return nil, fmt.Errorf("gpg decrypt: unexpected key size %d", len(key))
+	}
+	return key, nil
+}
+
+// HasGPGRecipients reports whether meta has a stanza for a GPG key.
+func HasGPGRecipients(meta *EncryptionMetadata) bool {
+	if meta == nil {
+		return false
+	}
+	for _, r := range meta.Recipients {
+		if r.Type == RecipientGPG {
+			return true
+		}
+	}
+	return false
+}
+
+// runGPG runs gpg with stdin and returns its stdout. Errors include gpg's
+// stderr, which names the missing key or bad passphrase.
+func runGPG(stdin []byte, args ...string) ([]byte, error) {
+	bin, err := exec.LookPath(GPGCommand)
+	if err != nil {
+		return nil, fmt.Errorf("gpg not found in PATH")
+	}
+	cmd := exec.Command(bin, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
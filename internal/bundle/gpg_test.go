@@ -0,0 +1,81 @@
+package bundle
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// withTestGPGKey points GnuPG at a fresh keyring holding one unprotected key
+// for uid. The test is skipped when gpg is not installed.
+func withTestGPGKey(t *testing.T, uid string) {
+	t.Helper()
+	if _, err := exec.LookPath(GPGCommand); err != nil {
+		t.Skip("gpg not installed")
+	}
+	home, err := os.MkdirTemp("", "caam-gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GNUPGHOME", home)
+	t.Cleanup(func() {
+		_ = exec.Command("gpgconf", "--kill", "gpg-agent").Run()
+		_ = os.RemoveAll(home)
+	})
+	if _, err := runGPG(nil, "--batch", "--passphrase", "", "--quick-gen-key", uid, "future-default", "default", "never"); err != nil {
+		t.Skipf("gpg key generation failed: %v", err)
+	}
+}
+
+func TestGPGRecipientRoundTrip(t *testing.T) {
+	withTestGPGKey(t, "Teammate <teammate@example.com>")
+
+	r, err := NewGPGRecipient("teammate@example.com")
+	if err != nil {
+		t.Fatalf("NewGPGRecipient() error = %v", err)
+	}
+	if len(r.Fingerprint()) != 40 {
+		t.Errorf("fingerprint = %q, want 40 hex chars", r.Fingerprint())
+	}
+	if info := DescribeRecipient(r); info.Type != RecipientGPG || info.Label == "" {
+		t.Errorf("DescribeRecipient() = %+v", info)
+	}
+
+	plain := []byte("vault bundle contents")
+	ciphertext, meta, err := EncryptBundleForRecipients(plain, []Recipient{r})
+	if err != nil {
+		t.Fatalf("EncryptBundleForRecipients() error = %v", err)
+	}
+	if !HasGPGRecipients(meta) || meta.NeedsPassphrase() {
+		t.Errorf("meta recipients = %+v, want one gpg stanza", meta.Recipients)
+	}
+	if !CanUnwrap(meta, []Identity{GPGIdentity{}}) {
+		t.Fatal("CanUnwrap() = false with the secret key in the keyring")
+	}
+
+	got, err := DecryptBundleWithIdentities(ciphertext, meta, []Identity{GPGIdentity{}})
+	if err != nil {
+		t.Fatalf("DecryptBundleWithIdentities() error = %v", err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("decrypted = %q, want %q", got, plain)
+	}
+}
+
+func TestGPGIdentitySkipsOtherKeys(t *testing.T) {
+	withTestGPGKey(t, "Someone <someone@example.com>")
+
+	stanza := &RecipientStanza{
+		Type:       RecipientGPG,
+		KeyID:      "0000000000000000000000000000000000000000",
+		WrappedKey: "not armored",
+	}
+	if _, err := (GPGIdentity{}).Unwrap(stanza); !errors.Is(err, ErrIncorrectIdentity) {
+		t.Errorf("Unwrap() error = %v, want ErrIncorrectIdentity", err)
+	}
+	if _, err := NewGPGRecipient("nobody@example.com"); err == nil {
+		t.Error("NewGPGRecipient() should fail for an unknown key")
+	}
+}
@@ -12,7 +12,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // ImportMode defines how to handle conflicts during import.
@@ -38,6 +41,10 @@ type ImportOptions struct {
 	// Password is the decryption password for encrypted bundles.
 	Password string
 
+	// Identities are private keys (age or SSH) that can open bundles
+	// encrypted to recipients. They are tried before Password.
+	Identities []Identity
+
 	// DryRun shows what would be imported without making changes.
 	DryRun bool
 
@@ -163,8 +170,8 @@ func (i *VaultImporter) Import(opts *ImportOptions) (*ImportResult, error) {
 	}
 	result.Encrypted = encrypted
 
-	if encrypted && opts.Password == "" {
-		return nil, fmt.Errorf("encrypted bundle requires password")
+	if encrypted && opts.Password == "" && len(opts.Identities) == 0 {
+		return nil, fmt.Errorf("encrypted bundle requires password or identity")
 	}
 
 	// Extract to temp directory
@@ -176,7 +183,7 @@ func (i *VaultImporter) Import(opts *ImportOptions) (*ImportResult, error) {
 
 	// Extract bundle
 	if encrypted {
-		if err := i.extractEncryptedBundle(tempDir, opts.Password); err != nil {
+		if err := i.extractEncryptedBundle(tempDir, opts); err != nil {
 			return nil, fmt.Errorf("extract encrypted bundle: %w", err)
 		}
 	} else {
@@ -243,7 +250,7 @@ func (i *VaultImporter) extractBundle(destDir string) error {
 }
 
 // extractEncryptedBundle decrypts and extracts an encrypted bundle.
-func (i *VaultImporter) extractEncryptedBundle(destDir, password string) error {
+func (i *VaultImporter) extractEncryptedBundle(destDir string, opts *ImportOptions) error {
 	// Read encrypted data
 	ciphertext, err := os.ReadFile(i.BundlePath)
 	if err != nil {
@@ -251,19 +258,22 @@ func (i *VaultImporter) extractEncryptedBundle(destDir, password string) error {
 	}
 
 	// Load encryption metadata
-	metaPath := i.BundlePath + ".meta"
-	metaData, err := os.ReadFile(metaPath)
+	meta, err := LoadBundleEncryptionMetadata(i.BundlePath)
 	if err != nil {
-		return fmt.Errorf("read encryption metadata: %w", err)
-	}
-
-	var meta EncryptionMetadata
-	if err := json.Unmarshal(metaData, &meta); err != nil {
-		return fmt.Errorf("parse encryption metadata: %w", err)
+		return err
 	}
 
 	// Decrypt
-	plainData, err := DecryptBundle(ciphertext, &meta, password)
+	var plainData []byte
+	if meta.KDF == KDFRecipients {
+		identities := append([]Identity{}, opts.Identities...)
+		if opts.Password != "" {
+			identities = append(identities, NewPassphraseRecipient(opts.Password))
+		}
+		plainData, err = DecryptBundleWithIdentities(ciphertext, meta, identities)
+	} else {
+		plainData, err = DecryptBundle(ciphertext, meta, opts.Password)
+	}
 	if err != nil {
 		return fmt.Errorf("decrypt: %w", err)
 	}
@@ -283,6 +293,21 @@ func (i *VaultImporter) extractEncryptedBundle(destDir, password string) error {
 	return nil
 }
 
+// LoadBundleEncryptionMetadata reads the ".meta" file written next to an
+// encrypted bundle file.
+func LoadBundleEncryptionMetadata(bundlePath string) (*EncryptionMetadata, error) {
+	metaData, err := os.ReadFile(bundlePath + ".meta")
+	if err != nil {
+		return nil, fmt.Errorf("read encryption metadata: %w", err)
+	}
+
+	var meta EncryptionMetadata
+	if err := json.Unmarshal(metaData, &meta); err != nil {
+		return nil, fmt.Errorf("parse encryption metadata: %w", err)
+	}
+	return &meta, nil
+}
+
 // extractZipFile extracts a single file from a zip archive.
 func extractZipFile(f *zip.File, destDir string) error {
 	// Sanitize path to prevent directory traversal (Zip Slip attack)
@@ -562,6 +587,16 @@ func (i *VaultImporter) previewOptionalFiles(manifest *ManifestV1, opts *ImportO
 		})
 	}
 
+	// Tags
+	if manifest.Contents.Tags.Included {
+		result.OptionalActions = append(result.OptionalActions, OptionalAction{
+			Name:    "tags",
+			Action:  "merge",
+			Reason:  "will be merged with local",
+			Details: fmt.Sprintf("%d tags", manifest.Contents.Tags.Count),
+		})
+	}
+
 	// Sync
 	if manifest.Contents.SyncConfig.Included && !opts.SkipSync {
 		result.OptionalActions = append(result.OptionalActions, OptionalAction{
@@ -629,6 +664,8 @@ func copyProfileDirectory(src, dst string) error {
 	if err := os.MkdirAll(parentDir, 0700); err != nil {
 		return fmt.Errorf("create parent dir: %w", err)
 	}
+	// Profiles live at <vault>/<provider>/<profile>.
+	provider := filepath.Base(parentDir)
 
 	// Create a temporary directory in the same parent to ensure atomic rename works
 	tmpDir, err := os.MkdirTemp(parentDir, ".caam_import_*")
@@ -660,6 +697,9 @@ func copyProfileDirectory(src, dst string) error {
 		if d.IsDir() {
 			return os.MkdirAll(dstPath, 0700)
 		}
+		if authfile.IsVaultAuthFile(provider, relPath) {
+			return copyAuthFileSealed(path, dstPath, filepath.Join(dst, relPath))
+		}
 
 		return copyFile(path, dstPath)
 	}); err != nil {
@@ -698,6 +738,33 @@ func copyProfileDirectory(src, dst string) error {
 	return nil
 }
 
+// copyAuthFileSealed copies a bundled auth file into a vault profile,
+// encrypting it when finalPath lies in an encrypted vault. Files that are
+// already encrypted or pool-sealed are copied as they are.
+func copyAuthFileSealed(src, dst, finalPath string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if !vaultcrypt.IsEncrypted(data) && !vaultcrypt.IsPoolSealed(data) {
+		if data, err = vaultcrypt.SealFor(finalPath, data); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
 // copyFile copies a single file.
 func copyFile(src, dst string) error {
 	srcFile, err := os.Open(src)
@@ -863,6 +930,24 @@ func (i *VaultImporter) importOptionalFiles(bundleDir string, manifest *Manifest
 		}
 	}
 
+	// Tags (merge into the database, after any database import above)
+	if manifest.Contents.Tags.Included && opts.DatabasePath != "" {
+		if n, err := importTags(bundleDir, manifest, opts); err != nil {
+			result.OptionalActions = append(result.OptionalActions, OptionalAction{
+				Name:   "tags",
+				Action: "error",
+				Reason: err.Error(),
+			})
+		} else {
+			result.OptionalActions = append(result.OptionalActions, OptionalAction{
+				Name:    "tags",
+				Action:  "merge",
+				Reason:  "merged successfully",
+				Details: fmt.Sprintf("%d tags", n),
+			})
+		}
+	}
+
 	// Sync config (merge)
 	if manifest.Contents.SyncConfig.Included && !opts.SkipSync && opts.SyncPath != "" {
 		srcPath, err := ValidateManifestPath(bundleDir, manifest.Contents.SyncConfig.Path)
@@ -888,6 +973,60 @@ func (i *VaultImporter) importOptionalFiles(bundleDir string, manifest *Manifest
 	}
 }
 
+// importTags applies the bundle's tags.json to the local database for the
+// profiles selected by the provider and profile filters. Tags already set
+// locally on other keys are kept.
+func importTags(bundleDir string, manifest *ManifestV1, opts *ImportOptions) (int, error) {
+	srcPath, err := ValidateManifestPath(bundleDir, manifest.Contents.Tags.Path)
+	if err != nil {
+		return 0, fmt.Errorf("invalid path: %w", err)
+	}
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	var tags []caamdb.ProfileTag
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return 0, fmt.Errorf("parse tags: %w", err)
+	}
+
+	byProfile := make(map[[2]string]map[string]string)
+	n := 0
+	for _, t := range tags {
+		if len(opts.ProviderFilter) > 0 && !containsIgnoreCase(opts.ProviderFilter, t.Provider) {
+			continue
+		}
+		if len(opts.ProfileFilter) > 0 && !matchesAnyPattern(t.ProfileName, opts.ProfileFilter) {
+			continue
+		}
+		key, _, err := caamdb.ParseProfileTag(t.Key)
+		if err != nil {
+			continue
+		}
+		k := [2]string{t.Provider, t.ProfileName}
+		if byProfile[k] == nil {
+			byProfile[k] = make(map[string]string)
+		}
+		byProfile[k][key] = t.Value
+		n++
+	}
+	if n == 0 {
+		return 0, nil
+	}
+
+	db, err := caamdb.OpenAt(opts.DatabasePath)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	for k, values := range byProfile {
+		if err := db.SetProfileTags(k[0], k[1], values); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
 // mergeJSONFile merges a JSON file from bundle into an existing local file.
 // Uses atomic write (temp + fsync + rename) to prevent corruption.
 func mergeJSONFile(srcPath, dstPath string) error {
@@ -6,6 +6,9 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 func TestDefaultImportOptions(t *testing.T) {
@@ -227,6 +230,90 @@ func TestVaultImporter_Import_ActualImport(t *testing.T) {
 	}
 }
 
+func TestVaultImporter_Import_Tags(t *testing.T) {
+	tempDir := t.TempDir()
+	vaultDir := filepath.Join(tempDir, "vault")
+	outputDir := filepath.Join(tempDir, "output")
+
+	for _, profile := range []string{"work", "personal"} {
+		profileDir := filepath.Join(vaultDir, "claude", profile)
+		if err := os.MkdirAll(profileDir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, ".claude.json"), []byte(`{}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srcDBPath := filepath.Join(tempDir, "src.db")
+	srcDB, err := caamdb.OpenAt(srcDBPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srcDB.SetProfileTags("claude", "work", map[string]string{"client": "acme", "tier": "max"}); err != nil {
+		t.Fatal(err)
+	}
+	// Tags of profiles outside the vault are not exported.
+	if err := srcDB.SetProfileTags("codex", "gone", map[string]string{"client": "old"}); err != nil {
+		t.Fatal(err)
+	}
+	srcDB.Close()
+
+	exporter := &VaultExporter{
+		VaultPath:    vaultDir,
+		DataPath:     tempDir,
+		DatabasePath: srcDBPath,
+	}
+	exportOpts := DefaultExportOptions()
+	exportOpts.OutputDir = outputDir
+	exportResult, err := exporter.Export(exportOpts)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	tags := exportResult.Manifest.Contents.Tags
+	if !tags.Included || tags.Path != TagsFileName || tags.Count != 2 {
+		t.Fatalf("manifest tags = %+v, want 2 tags in %s", tags, TagsFileName)
+	}
+
+	dstDBPath := filepath.Join(tempDir, "dst.db")
+	dstDB, err := caamdb.OpenAt(dstDBPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dstDB.SetProfileTags("claude", "work", map[string]string{"client": "local", "owner": "me"}); err != nil {
+		t.Fatal(err)
+	}
+	dstDB.Close()
+
+	importer := &VaultImporter{BundlePath: exportResult.OutputPath}
+	importOpts := DefaultImportOptions()
+	importOpts.VaultPath = filepath.Join(tempDir, "import_vault")
+	importOpts.DatabasePath = dstDBPath
+	if _, err := importer.Import(importOpts); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	dstDB, err = caamdb.OpenAt(dstDBPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dstDB.Close()
+	all, err := dstDB.ProfileTagMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := all["claude/work"]
+	want := map[string]string{"client": "acme", "tier": "max", "owner": "me"}
+	if len(got) != len(want) {
+		t.Fatalf("tags = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("tag %s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
 func TestVaultImporter_Import_MergeMode(t *testing.T) {
 	tempDir := t.TempDir()
 	vaultDir := filepath.Join(tempDir, "vault")
@@ -1007,3 +1094,65 @@ func TestVaultImporter_Import_WrongPassword(t *testing.T) {
 		t.Error("Import should fail with wrong password")
 	}
 }
+
+func TestExportImport_EncryptedVaults(t *testing.T) {
+	tempDir := t.TempDir()
+	srcVault := filepath.Join(tempDir, "vault")
+	dstVault := filepath.Join(tempDir, "import_vault")
+
+	// Two vaults, each encrypted with its own key.
+	t.Setenv(vaultcrypt.PassphraseEnv, "test-passphrase")
+	for _, dir := range []string{srcVault, dstVault} {
+		if _, err := vaultcrypt.Init(dir, vaultcrypt.ModePassphrase); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	token := []byte(`{"claudeAiOauth":{"accessToken":"secret-token"}}`)
+	srcFile := filepath.Join(srcVault, "claude", "work", ".credentials.json")
+	if err := os.MkdirAll(filepath.Dir(srcFile), 0700); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := vaultcrypt.SealFor(srcFile, token)
+	if err != nil || !vaultcrypt.IsEncrypted(sealed) {
+		t.Fatalf("SealFor() = %q, %v", sealed, err)
+	}
+	if err := os.WriteFile(srcFile, sealed, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(srcFile), "meta.json"), []byte(`{"provider":"claude"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	exporter := &VaultExporter{VaultPath: srcVault, DataPath: tempDir}
+	exportOpts := DefaultExportOptions()
+	exportOpts.OutputDir = filepath.Join(tempDir, "output")
+	exportResult, err := exporter.Export(exportOpts)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	importer := &VaultImporter{BundlePath: exportResult.OutputPath}
+	importOpts := DefaultImportOptions()
+	importOpts.VaultPath = dstVault
+	if _, err := importer.Import(importOpts); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	dstFile := filepath.Join(dstVault, "claude", "work", ".credentials.json")
+	raw, err := os.ReadFile(dstFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vaultcrypt.IsEncrypted(raw) {
+		t.Errorf("imported auth file is plaintext in an encrypted vault: %q", raw)
+	}
+	key, _ := vaultcrypt.Key(dstVault)
+	if got, err := vaultcrypt.Open(key, raw); err != nil || string(got) != string(token) {
+		t.Errorf("imported file under the destination key = %q, %v", got, err)
+	}
+	meta, err := os.ReadFile(filepath.Join(dstVault, "claude", "work", "meta.json"))
+	if err != nil || vaultcrypt.IsEncrypted(meta) {
+		t.Errorf("meta.json = %q, %v; want plaintext", meta, err)
+	}
+}
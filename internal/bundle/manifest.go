@@ -50,6 +50,27 @@ type ManifestV1 struct {
 
 	// Checksums contains integrity hashes for bundle files.
 	Checksums ChecksumInfo `json:"checksums"`
+
+	// Recipients lists who can open the bundle when it is encrypted.
+	Recipients []RecipientInfo `json:"recipients,omitempty"`
+
+	// Delta is set on incremental bundles that hold only changed profiles.
+	Delta *DeltaInfo `json:"delta,omitempty"`
+}
+
+// DeltaInfo describes what an incremental bundle is relative to. Importing
+// a delta leaves profiles it does not contain untouched.
+type DeltaInfo struct {
+	// Since is the modification time cutoff, for --since exports.
+	Since *time.Time `json:"since,omitempty"`
+
+	// BaseExportTimestamp is when the export this delta builds on ran, for
+	// --since-last exports.
+	BaseExportTimestamp *time.Time `json:"base_export_timestamp,omitempty"`
+
+	// UnchangedProfiles counts the profiles left out because they had not
+	// changed.
+	UnchangedProfiles int `json:"unchanged_profiles"`
 }
 
 // SourceInfo contains information about the machine that created the bundle.
@@ -89,6 +110,9 @@ type ContentsInfo struct {
 
 	// SyncConfig describes included sync pool configuration.
 	SyncConfig OptionalContent `json:"sync_config"`
+
+	// Tags describes the included profile tags.
+	Tags OptionalContent `json:"tags"`
 }
 
 // VaultContents describes the vault profiles in the bundle.
@@ -149,6 +173,10 @@ type EncryptionMetadata struct {
 
 	// Argon2Params contains Argon2 parameters (if KDF is argon2id).
 	Argon2Params *Argon2Params `json:"argon2_params,omitempty"`
+
+	// Recipients holds the content key wrapped for each recipient
+	// (if KDF is "recipients", version 2).
+	Recipients []RecipientStanza `json:"recipients,omitempty"`
 }
 
 // Argon2Params contains Argon2id parameters for key derivation.
@@ -284,6 +312,18 @@ func (m *ManifestV1) SetDatabase(included bool, path string) {
 	}
 }
 
+// SetTags configures the profile tags content entry.
+func (m *ManifestV1) SetTags(included bool, path string, count int) {
+	m.Contents.Tags = OptionalContent{
+		Included: included,
+		Path:     path,
+		Count:    count,
+	}
+	if !included {
+		m.Contents.Tags.Reason = "no tags on exported profiles"
+	}
+}
+
 // SetSyncConfig configures the sync config content entry.
 func (m *ManifestV1) SetSyncConfig(included bool, path string) {
 	m.Contents.SyncConfig = OptionalContent{
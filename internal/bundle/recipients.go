@@ -0,0 +1,768 @@
+package bundle
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/ssh"
+)
+
+// Recipient types. A multi-recipient bundle encrypts its payload with a random
+// content key and wraps that key once per recipient, so any one of them can
+// open it.
+const (
+	RecipientPassphrase = "passphrase"
+	RecipientX25519     = "x25519"
+	RecipientSSHEd25519 = "ssh-ed25519"
+	RecipientSSHRSA     = "ssh-rsa"
+	RecipientGPG        = "gpg"
+)
+
+// KDFRecipients is the EncryptionMetadata.KDF of multi-recipient bundles.
+const KDFRecipients = "recipients"
+
+// contentKeySize is the size of a bundle's random content key.
+const contentKeySize = 32
+
+const (
+	ageRecipientHRP = "age"
+	ageIdentityHRP  = "AGE-SECRET-KEY-"
+	wrapInfoPrefix  = "caam-bundle/v2/"
+)
+
+// ErrIncorrectIdentity is returned by Identity.Unwrap when a stanza was not
+// wrapped for that identity.
+var ErrIncorrectIdentity = errors.New("incorrect identity for recipient stanza")
+
+// RecipientStanza is the content key wrapped for one recipient.
+type RecipientStanza struct {
+	// Type is one of the Recipient* constants.
+	Type string `json:"type"`
+
+	// Label identifies the recipient in listings: the age public key, the
+	// SSH key comment and fingerprint, or "passphrase".
+	Label string `json:"label"`
+
+	// KeyID is the SSH SHA256 fingerprint or age public key, letting
+	// identities skip stanzas that aren't theirs.
+	KeyID string `json:"key_id,omitempty"`
+
+	// Salt and Argon2Params derive the wrapping key (passphrase only).
+	Salt         string        `json:"salt,omitempty"`
+	Argon2Params *Argon2Params `json:"argon2_params,omitempty"`
+
+	// EphemeralKey is the sender's X25519 public key (x25519, ssh-ed25519).
+	EphemeralKey string `json:"ephemeral_key,omitempty"`
+
+	// WrappedKey is the encrypted content key.
+	WrappedKey string `json:"wrapped_key"`
+}
+
+// RecipientInfo describes a recipient without key material, for manifests
+// and listings.
+type RecipientInfo struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+}
+
+// Recipient wraps a bundle content key.
+type Recipient interface {
+	Wrap(contentKey []byte) (*RecipientStanza, error)
+}
+
+// Identity unwraps a bundle content key. Unwrap returns ErrIncorrectIdentity
+// for stanzas meant for someone else.
+type Identity interface {
+	Unwrap(stanza *RecipientStanza) ([]byte, error)
+}
+
+// EncryptBundleForRecipients encrypts data with a random content key wrapped
+// for each recipient.
+func EncryptBundleForRecipients(plainData []byte, recipients []Recipient) ([]byte, *EncryptionMetadata, error) {
+	if len(recipients) == 0 {
+		return nil, nil, fmt.Errorf("at least one recipient is required")
+	}
+
+	contentKey, err := GenerateRandomBytes(contentKeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer SecureWipe(contentKey)
+
+	nonce, err := GenerateRandomBytes(NonceSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta := &EncryptionMetadata{
+		Version:   2,
+		Algorithm: "aes-256-gcm",
+		KDF:       KDFRecipients,
+		Nonce:     base64.StdEncoding.EncodeToString(nonce),
+	}
+	for _, r := range recipients {
+		stanza, err := r.Wrap(contentKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("wrap key: %w", err)
+		}
+		meta.Recipients = append(meta.Recipients, *stanza)
+	}
+
+	gcm, err := newGCM(contentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gcm.Seal(nil, nonce, plainData, nil), meta, nil
+}
+
+// DecryptBundleWithIdentities decrypts a multi-recipient bundle with the first
+// identity that can unwrap its content key.
+func DecryptBundleWithIdentities(ciphertext []byte, meta *EncryptionMetadata, identities []Identity) ([]byte, error) {
+	if err := ValidateEncryptionMetadata(meta); err != nil {
+		return nil, fmt.Errorf("invalid encryption metadata: %w", err)
+	}
+	if meta.KDF != KDFRecipients {
+		return nil, fmt.Errorf("bundle is not encrypted to recipients")
+	}
+
+	contentKey, err := unwrapContentKey(meta, identities)
+	if err != nil {
+		return nil, err
+	}
+	defer SecureWipe(contentKey)
+
+	nonce, err := base64.StdEncoding.DecodeString(meta.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("decode nonce: %w", err)
+	}
+	gcm, err := newGCM(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func unwrapContentKey(meta *EncryptionMetadata, identities []Identity) ([]byte, error) {
+	var lastErr error
+	for i := range meta.Recipients {
+		for _, id := range identities {
+			key, err := id.Unwrap(&meta.Recipients[i])
+			if err == nil {
+				return key, nil
+			}
+			if !errors.Is(err, ErrIncorrectIdentity) {
+				lastErr = err
+			}
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("no identity matches the bundle's recipients (%s)", strings.Join(recipientLabels(meta), ", "))
+}
+
+// CanUnwrap reports whether any non-passphrase identity matches one of the
+// bundle's recipients. It does not try passphrases, which are slow to check.
+func CanUnwrap(meta *EncryptionMetadata, identities []Identity) bool {
+	if meta == nil {
+		return false
+	}
+	for i := range meta.Recipients {
+		if meta.Recipients[i].Type == RecipientPassphrase {
+			continue
+		}
+		for _, id := range identities {
+			if key, err := id.Unwrap(&meta.Recipients[i]); err == nil {
+				SecureWipe(key)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NeedsPassphrase reports whether meta can be opened with a passphrase.
+func (e *EncryptionMetadata) NeedsPassphrase() bool {
+	if e.KDF != KDFRecipients {
+		return true
+	}
+	for _, r := range e.Recipients {
+		if r.Type == RecipientPassphrase {
+			return true
+		}
+	}
+	return false
+}
+
+// RecipientInfos lists who can open a bundle encrypted with meta.
+func (e *EncryptionMetadata) RecipientInfos() []RecipientInfo {
+	if e.KDF != KDFRecipients {
+		return []RecipientInfo{{Type: RecipientPassphrase, Label: RecipientPassphrase}}
+	}
+	out := make([]RecipientInfo, 0, len(e.Recipients))
+	for _, r := range e.Recipients {
+		out = append(out, RecipientInfo{Type: r.Type, Label: r.Label})
+	}
+	return out
+}
+
+// DescribeRecipient returns the listing entry for r.
+func DescribeRecipient(r Recipient) RecipientInfo {
+	switch r := r.(type) {
+	case *PassphraseRecipient:
+		return RecipientInfo{Type: RecipientPassphrase, Label: RecipientPassphrase}
+	case *X25519Recipient:
+		return RecipientInfo{Type: RecipientX25519, Label: r.String()}
+	case *SSHEd25519Recipient:
+		return RecipientInfo{Type: RecipientSSHEd25519, Label: r.label}
+	case *SSHRSARecipient:
+		return RecipientInfo{Type: RecipientSSHRSA, Label: r.label}
+	case *GPGRecipient:
+		return RecipientInfo{Type: RecipientGPG, Label: r.label}
+	default:
+		return RecipientInfo{Type: fmt.Sprintf("%T", r)}
+	}
+}
+
+func recipientLabels(meta *EncryptionMetadata) []string {
+	var labels []string
+	for _, r := range meta.RecipientInfos() {
+		labels = append(labels, r.Label)
+	}
+	return labels
+}
+
+// ============================================================================
+// Passphrase recipients
+// ============================================================================
+
+// PassphraseRecipient wraps the content key with an Argon2id-derived key. It
+// is also the Identity for the same passphrase.
+type PassphraseRecipient struct {
+	Passphrase string
+}
+
+// NewPassphraseRecipient returns a recipient (and identity) for passphrase.
+func NewPassphraseRecipient(passphrase string) *PassphraseRecipient {
+	return &PassphraseRecipient{Passphrase: passphrase}
+}
+
+// Wrap implements Recipient.
+func (p *PassphraseRecipient) Wrap(contentKey []byte) (*RecipientStanza, error) {
+	if p.Passphrase == "" {
+		return nil, fmt.Errorf("passphrase is required")
+	}
+	salt, err := GenerateRandomBytes(SaltSize)
+	if err != nil {
+		return nil, err
+	}
+	params := DefaultArgon2Params()
+	key := argon2.IDKey([]byte(p.Passphrase), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+	defer SecureWipe(key)
+
+	wrapped, err := sealKey(key, contentKey)
+	if err != nil {
+		return nil, err
+	}
+	return &RecipientStanza{
+		Type:         RecipientPassphrase,
+		Label:        RecipientPassphrase,
+		Salt:         base64.StdEncoding.EncodeToString(salt),
+		Argon2Params: params,
+		WrappedKey:   base64.StdEncoding.EncodeToString(wrapped),
+	}, nil
+}
+
+// Unwrap implements Identity.
+func (p *PassphraseRecipient) Unwrap(s *RecipientStanza) ([]byte, error) {
+	if s.Type != RecipientPassphrase || p.Passphrase == "" {
+		return nil, ErrIncorrectIdentity
+	}
+	salt, err := base64.StdEncoding.DecodeString(s.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("decode salt: %w", err)
+	}
+	params := s.Argon2Params
+	if params == nil {
+		params = DefaultArgon2Params()
+	}
+	key := argon2.IDKey([]byte(p.Passphrase), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+	defer SecureWipe(key)
+
+	contentKey, err := openKey(key, s.WrappedKey)
+	if err != nil {
+		// A wrong passphrase looks the same as a stanza for another one.
+		return nil, ErrIncorrectIdentity
+	}
+	return contentKey, nil
+}
+
+// ============================================================================
+// X25519 (age-style) recipients
+// ============================================================================
+
+// X25519Recipient is an age-style "age1..." public key.
+type X25519Recipient struct {
+	pub []byte
+}
+
+// X25519Identity is an age-style "AGE-SECRET-KEY-1..." private key.
+type X25519Identity struct {
+	priv []byte
+	pub  []byte
+}
+
+// GenerateX25519Identity creates a new random X25519 identity.
+func GenerateX25519Identity() (*X25519Identity, error) {
+	priv, err := GenerateRandomBytes(curve25519.ScalarSize)
+	if err != nil {
+		return nil, err
+	}
+	return newX25519Identity(priv)
+}
+
+func newX25519Identity(priv []byte) (*X25519Identity, error) {
+	pub, err := curve25519.X25519(priv, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	return &X25519Identity{priv: priv, pub: pub}, nil
+}
+
+// ParseX25519Recipient parses an "age1..." public key.
+func ParseX25519Recipient(s string) (*X25519Recipient, error) {
+	hrp, data, err := bech32Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed age recipient: %w", err)
+	}
+	if hrp != ageRecipientHRP || len(data) != curve25519.PointSize {
+		return nil, fmt.Errorf("malformed age recipient %q", s)
+	}
+	return &X25519Recipient{pub: data}, nil
+}
+
+// ParseX25519Identity parses an "AGE-SECRET-KEY-1..." private key.
+func ParseX25519Identity(s string) (*X25519Identity, error) {
+	hrp, data, err := bech32Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed age identity: %w", err)
+	}
+	if hrp != strings.ToLower(ageIdentityHRP) || len(data) != curve25519.ScalarSize {
+		return nil, fmt.Errorf("malformed age identity")
+	}
+	return newX25519Identity(data)
+}
+
+// String returns the "age1..." encoding of the key.
+func (r *X25519Recipient) String() string {
+	s, _ := bech32Encode(ageRecipientHRP, r.pub)
+	return s
+}
+
+// String returns the "AGE-SECRET-KEY-1..." encoding of the key.
+func (i *X25519Identity) String() string {
+	s, _ := bech32Encode(ageIdentityHRP, i.priv)
+	return s
+}
+
+// Recipient returns the public key for i.
+func (i *X25519Identity) Recipient() *X25519Recipient {
+	return &X25519Recipient{pub: i.pub}
+}
+
+// Wrap implements Recipient.
+func (r *X25519Recipient) Wrap(contentKey []byte) (*RecipientStanza, error) {
+	label := r.String()
+	s, err := wrapX25519(r.pub, contentKey, RecipientX25519)
+	if err != nil {
+		return nil, err
+	}
+	s.Label = label
+	s.KeyID = label
+	return s, nil
+}
+
+// Unwrap implements Identity.
+func (i *X25519Identity) Unwrap(s *RecipientStanza) ([]byte, error) {
+	if s.Type != RecipientX25519 {
+		return nil, ErrIncorrectIdentity
+	}
+	if s.KeyID != "" && s.KeyID != i.Recipient().String() {
+		return nil, ErrIncorrectIdentity
+	}
+	return unwrapX25519(i.priv, i.pub, s)
+}
+
+// wrapX25519 performs an ephemeral-static X25519 exchange with pub and seals
+// contentKey under the derived key.
+func wrapX25519(pub, contentKey []byte, stanzaType string) (*RecipientStanza, error) {
+	eph, err := GenerateRandomBytes(curve25519.ScalarSize)
+	if err != nil {
+		return nil, err
+	}
+	defer SecureWipe(eph)
+	ephPub, err := curve25519.X25519(eph, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := curve25519.X25519(eph, pub)
+	if err != nil {
+		return nil, fmt.Errorf("x25519: %w", err)
+	}
+	key, err := deriveWrapKey(shared, ephPub, pub, stanzaType)
+	if err != nil {
+		return nil, err
+	}
+	defer SecureWipe(key)
+
+	wrapped, err := sealKey(key, contentKey)
+	if err != nil {
+		return nil, err
+	}
+	return &RecipientStanza{
+		Type:         stanzaType,
+		EphemeralKey: base64.StdEncoding.EncodeToString(ephPub),
+		WrappedKey:   base64.StdEncoding.EncodeToString(wrapped),
+	}, nil
+}
+
+func unwrapX25519(priv, pub []byte, s *RecipientStanza) ([]byte, error) {
+	ephPub, err := base64.StdEncoding.DecodeString(s.EphemeralKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode ephemeral key: %w", err)
+	}
+	shared, err := curve25519.X25519(priv, ephPub)
+	if err != nil {
+		return nil, ErrIncorrectIdentity
+	}
+	key, err := deriveWrapKey(shared, ephPub, pub, s.Type)
+	if err != nil {
+		return nil, err
+	}
+	defer SecureWipe(key)
+
+	contentKey, err := openKey(key, s.WrappedKey)
+	if err != nil {
+		return nil, ErrIncorrectIdentity
+	}
+	return contentKey, nil
+}
+
+func deriveWrapKey(shared, ephPub, pub []byte, stanzaType string) ([]byte, error) {
+	salt := append(append([]byte{}, ephPub...), pub...)
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(wrapInfoPrefix+stanzaType)), key); err != nil {
+		return nil, fmt.Errorf("derive wrap key: %w", err)
+	}
+	return key, nil
+}
+
+// ============================================================================
+// SSH recipients
+// ============================================================================
+
+// SSHEd25519Recipient is an ssh-ed25519 public key, used via its X25519 form.
+type SSHEd25519Recipient struct {
+	sshKey ssh.PublicKey
+	pub    []byte // X25519 form
+	label  string
+}
+
+// SSHEd25519Identity is an ssh-ed25519 private key.
+type SSHEd25519Identity struct {
+	keyID string
+	priv  []byte // X25519 form
+	pub   []byte
+}
+
+// SSHRSARecipient is an ssh-rsa public key, wrapping with RSA-OAEP.
+type SSHRSARecipient struct {
+	sshKey ssh.PublicKey
+	pub    *rsa.PublicKey
+	label  string
+}
+
+// SSHRSAIdentity is an ssh-rsa private key.
+type SSHRSAIdentity struct {
+	keyID string
+	priv  *rsa.PrivateKey
+}
+
+// Wrap implements Recipient.
+func (r *SSHEd25519Recipient) Wrap(contentKey []byte) (*RecipientStanza, error) {
+	s, err := wrapX25519(r.pub, contentKey, RecipientSSHEd25519)
+	if err != nil {
+		return nil, err
+	}
+	s.Label = r.label
+	s.KeyID = ssh.FingerprintSHA256(r.sshKey)
+	return s, nil
+}
+
+// Unwrap implements Identity.
+func (i *SSHEd25519Identity) Unwrap(s *RecipientStanza) ([]byte, error) {
+	if s.Type != RecipientSSHEd25519 || s.KeyID != i.keyID {
+		return nil, ErrIncorrectIdentity
+	}
+	return unwrapX25519(i.priv, i.pub, s)
+}
+
+// Wrap implements Recipient.
+func (r *SSHRSARecipient) Wrap(contentKey []byte) (*RecipientStanza, error) {
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, r.pub, contentKey, []byte(wrapInfoPrefix+RecipientSSHRSA))
+	if err != nil {
+		return nil, fmt.Errorf("rsa wrap: %w", err)
+	}
+	return &RecipientStanza{
+		Type:       RecipientSSHRSA,
+		Label:      r.label,
+		KeyID:      ssh.FingerprintSHA256(r.sshKey),
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+	}, nil
+}
+
+// Unwrap implements Identity.
+func (i *SSHRSAIdentity) Unwrap(s *RecipientStanza) ([]byte, error) {
+	if s.Type != RecipientSSHRSA || s.KeyID != i.keyID {
+		return nil, ErrIncorrectIdentity
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(s.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode wrapped key: %w", err)
+	}
+	key, err := rsa.DecryptOAEP(sha256.New(), nil, i.priv, wrapped, []byte(wrapInfoPrefix+RecipientSSHRSA))
+	if err != nil {
+		return nil, ErrIncorrectIdentity
+	}
+	return key, nil
+}
+
+// parseSSHRecipient converts an authorized_keys line to a Recipient.
+func parseSSHRecipient(line string) (Recipient, error) {
+	pk, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return nil, fmt.Errorf("parse ssh public key: %w", err)
+	}
+	label := ssh.FingerprintSHA256(pk)
+	if comment != "" {
+		label = comment + " (" + label + ")"
+	}
+
+	cryptoKey, ok := pk.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported ssh key type %s", pk.Type())
+	}
+	switch key := cryptoKey.CryptoPublicKey().(type) {
+	case ed25519.PublicKey:
+		pub, err := ed25519PublicKeyToX25519(key)
+		if err != nil {
+			return nil, err
+		}
+		return &SSHEd25519Recipient{sshKey: pk, pub: pub, label: label}, nil
+	case *rsa.PublicKey:
+		if key.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("ssh-rsa key too small (%d bits)", key.N.BitLen())
+		}
+		return &SSHRSARecipient{sshKey: pk, pub: key, label: label}, nil
+	default:
+		return nil, fmt.Errorf("unsupported ssh key type %s (use ssh-ed25519 or ssh-rsa)", pk.Type())
+	}
+}
+
+// NewSSHIdentity wraps a private key returned by ssh.ParseRawPrivateKey.
+func NewSSHIdentity(key interface{}) (Identity, error) {
+	switch k := key.(type) {
+	case *ed25519.PrivateKey:
+		return NewSSHIdentity(*k)
+	case ed25519.PrivateKey:
+		pk, err := ssh.NewPublicKey(k.Public())
+		if err != nil {
+			return nil, err
+		}
+		pub, err := ed25519PublicKeyToX25519(k.Public().(ed25519.PublicKey))
+		if err != nil {
+			return nil, err
+		}
+		h := sha512.Sum512(k.Seed())
+		return &SSHEd25519Identity{
+			keyID: ssh.FingerprintSHA256(pk),
+			priv:  h[:curve25519.ScalarSize],
+			pub:   pub,
+		}, nil
+	case *rsa.PrivateKey:
+		pk, err := ssh.NewPublicKey(&k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		return &SSHRSAIdentity{keyID: ssh.FingerprintSHA256(pk), priv: k}, nil
+	default:
+		return nil, fmt.Errorf("unsupported ssh private key type %T (use ed25519 or rsa)", key)
+	}
+}
+
+// curve25519P is the field prime 2^255 - 19.
+var curve25519P, _ = new(big.Int).SetString("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed", 16)
+
+// ed25519PublicKeyToX25519 maps an Edwards point to its Montgomery
+// u-coordinate: u = (1 + y) / (1 - y) mod p.
+func ed25519PublicKeyToX25519(pk ed25519.PublicKey) ([]byte, error) {
+	if len(pk) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key")
+	}
+	le := make([]byte, len(pk))
+	copy(le, pk)
+	le[31] &= 0x7f // Drop the x sign bit
+	y := new(big.Int).SetBytes(reverseBytes(le))
+
+	one := big.NewInt(1)
+	num := new(big.Int).Add(one, y)
+	den := new(big.Int).Sub(one, y)
+	den.Mod(den, curve25519P)
+	if den.Sign() == 0 {
+		return nil, fmt.Errorf("invalid ed25519 public key")
+	}
+	den.ModInverse(den, curve25519P)
+	u := num.Mul(num, den)
+	u.Mod(u, curve25519P)
+
+	out := make([]byte, curve25519.PointSize)
+	u.FillBytes(out)
+	return reverseBytes(out), nil
+}
+
+func reverseBytes(b []byte) []byte {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
+
+// ============================================================================
+// Parsing
+// ============================================================================
+
+// ParseRecipient parses an age public key ("age1...") or an SSH public key
+// in authorized_keys format ("ssh-ed25519 AAAA... comment").
+func ParseRecipient(s string) (Recipient, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, ageRecipientHRP+"1"):
+		return ParseX25519Recipient(s)
+	case strings.HasPrefix(s, "ssh-"):
+		return parseSSHRecipient(s)
+	default:
+		return nil, fmt.Errorf("unrecognized recipient %q (want age1... or an SSH public key)", truncateKey(s))
+	}
+}
+
+// ParseRecipients parses one recipient per line, skipping blank lines and
+// # comments (the format of age recipients files and authorized_keys).
+func ParseRecipients(data []byte) ([]Recipient, error) {
+	var out []Recipient
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r, err := ParseRecipient(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		out = append(out, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no recipients found")
+	}
+	return out, nil
+}
+
+// ParseIdentities parses an age identity file (AGE-SECRET-KEY-1... lines) or
+// an unencrypted SSH private key. Passphrase-protected SSH keys return an
+// *ssh.PassphraseMissingError; decrypt them with
+// ssh.ParseRawPrivateKeyWithPassphrase and NewSSHIdentity.
+func ParseIdentities(data []byte) ([]Identity, error) {
+	if bytes.Contains(data, []byte(ageIdentityHRP+"1")) {
+		var out []Identity
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			id, err := ParseX25519Identity(line)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, id)
+		}
+		return out, sc.Err()
+	}
+
+	key, err := ssh.ParseRawPrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	id, err := NewSSHIdentity(key)
+	if err != nil {
+		return nil, err
+	}
+	return []Identity{id}, nil
+}
+
+func truncateKey(s string) string {
+	if len(s) > 24 {
+		return s[:24] + "..."
+	}
+	return s
+}
+
+// ============================================================================
+// Key wrapping
+// ============================================================================
+
+// Each wrapping key is used exactly once (fresh salt or ephemeral key), so a
+// zero nonce is safe.
+var zeroNonce = make([]byte, chacha20poly1305.NonceSize)
+
+func sealKey(key, contentKey []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, zeroNonce, contentKey, nil), nil
+}
+
+func openKey(key []byte, wrappedB64 string) ([]byte, error) {
+	wrapped, err := base64.StdEncoding.DecodeString(wrappedB64)
+	if err != nil {
+		return nil, fmt.Errorf("decode wrapped key: %w", err)
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, zeroNonce, wrapped, nil)
+}
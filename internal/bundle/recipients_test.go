@@ -0,0 +1,298 @@
+package bundle
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestBech32RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte{0xab, 0x01}, 16)
+	for _, hrp := range []string{"age", "AGE-SECRET-KEY-"} {
+		s, err := bech32Encode(hrp, data)
+		if err != nil {
+			t.Fatalf("bech32Encode(%q) error = %v", hrp, err)
+		}
+		gotHRP, got, err := bech32Decode(s)
+		if err != nil {
+			t.Fatalf("bech32Decode(%q) error = %v", s, err)
+		}
+		if gotHRP != strings.ToLower(hrp) || !bytes.Equal(got, data) {
+			t.Errorf("round trip = (%q, %x), want (%q, %x)", gotHRP, got, strings.ToLower(hrp), data)
+		}
+	}
+
+	// A flipped character breaks the checksum
+	s, _ := bech32Encode("age", data)
+	corrupt := s[:10] + string(bech32Charset[(strings.IndexByte(bech32Charset, s[10])+1)%32]) + s[11:]
+	if _, _, err := bech32Decode(corrupt); err == nil {
+		t.Error("bech32Decode() should reject a corrupted string")
+	}
+}
+
+func TestX25519IdentityEncoding(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(id.String(), "AGE-SECRET-KEY-1") {
+		t.Errorf("identity = %q, want AGE-SECRET-KEY-1 prefix", id.String())
+	}
+	if !strings.HasPrefix(id.Recipient().String(), "age1") {
+		t.Errorf("recipient = %q, want age1 prefix", id.Recipient().String())
+	}
+
+	parsed, err := ParseX25519Identity(id.String())
+	if err != nil {
+		t.Fatalf("ParseX25519Identity() error = %v", err)
+	}
+	if parsed.Recipient().String() != id.Recipient().String() {
+		t.Error("parsed identity has a different public key")
+	}
+}
+
+func TestEd25519ToX25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted, err := ed25519PublicKeyToX25519(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha512.Sum512(priv.Seed())
+	want, err := curve25519.X25519(h[:32], curve25519.Basepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(converted, want) {
+		t.Errorf("converted public key = %x, want %x", converted, want)
+	}
+}
+
+// testRecipients returns one recipient/identity pair per recipient type.
+func testRecipients(t *testing.T) ([]Recipient, []Identity) {
+	t.Helper()
+
+	age, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recipients := []Recipient{age.Recipient()}
+	identities := []Identity{age}
+	for _, key := range []interface{}{edPriv, rsaPriv} {
+		signer, err := ssh.NewSignerFromKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))) + " alice@laptop"
+		r, err := ParseRecipient(line)
+		if err != nil {
+			t.Fatalf("ParseRecipient(%q) error = %v", line, err)
+		}
+		id, err := NewSSHIdentity(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recipients = append(recipients, r)
+		identities = append(identities, id)
+	}
+
+	recipients = append(recipients, NewPassphraseRecipient("team-secret"))
+	identities = append(identities, NewPassphraseRecipient("team-secret"))
+	return recipients, identities
+}
+
+func TestEncryptBundleForRecipients(t *testing.T) {
+	recipients, identities := testRecipients(t)
+	plain := []byte("bundle zip contents")
+
+	ciphertext, meta, err := EncryptBundleForRecipients(plain, recipients)
+	if err != nil {
+		t.Fatalf("EncryptBundleForRecipients() error = %v", err)
+	}
+	if err := ValidateEncryptionMetadata(meta); err != nil {
+		t.Fatalf("metadata invalid: %v", err)
+	}
+	if len(meta.Recipients) != len(recipients) {
+		t.Fatalf("stanzas = %d, want %d", len(meta.Recipients), len(recipients))
+	}
+
+	wantTypes := []string{RecipientX25519, RecipientSSHEd25519, RecipientSSHRSA, RecipientPassphrase}
+	for i, info := range meta.RecipientInfos() {
+		if info.Type != wantTypes[i] {
+			t.Errorf("recipient %d type = %q, want %q", i, info.Type, wantTypes[i])
+		}
+	}
+	if !strings.HasPrefix(meta.Recipients[1].Label, "alice@laptop (SHA256:") {
+		t.Errorf("ssh label = %q, want comment and fingerprint", meta.Recipients[1].Label)
+	}
+
+	// Each identity alone opens the bundle
+	for i, id := range identities {
+		got, err := DecryptBundleWithIdentities(ciphertext, meta, []Identity{id})
+		if err != nil {
+			t.Fatalf("identity %d: DecryptBundleWithIdentities() error = %v", i, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Errorf("identity %d: decrypted = %q, want %q", i, got, plain)
+		}
+	}
+
+	// The passphrase also works through DecryptBundle
+	if got, err := DecryptBundle(ciphertext, meta, "team-secret"); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("DecryptBundle() = %q, %v", got, err)
+	}
+
+	// Keys that weren't recipients are rejected
+	other, _ := GenerateX25519Identity()
+	_, err = DecryptBundleWithIdentities(ciphertext, meta, []Identity{other, NewPassphraseRecipient("wrong")})
+	if err == nil {
+		t.Error("decrypt with non-recipient identities should fail")
+	}
+	if CanUnwrap(meta, []Identity{other}) {
+		t.Error("CanUnwrap() = true for a non-recipient")
+	}
+	if !CanUnwrap(meta, identities[1:2]) {
+		t.Error("CanUnwrap() = false for a recipient")
+	}
+}
+
+func TestIdentityRejectsForeignStanza(t *testing.T) {
+	a, _ := GenerateX25519Identity()
+	b, _ := GenerateX25519Identity()
+
+	stanza, err := a.Recipient().Wrap(bytes.Repeat([]byte{7}, contentKeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Unwrap(stanza); !errors.Is(err, ErrIncorrectIdentity) {
+		t.Errorf("Unwrap() error = %v, want ErrIncorrectIdentity", err)
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	a, _ := GenerateX25519Identity()
+	b, _ := GenerateX25519Identity()
+	data := "# team keys\n" + a.Recipient().String() + "\n\n" + b.Recipient().String() + "\n"
+
+	recipients, err := ParseRecipients([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseRecipients() error = %v", err)
+	}
+	if len(recipients) != 2 {
+		t.Errorf("len = %d, want 2", len(recipients))
+	}
+
+	if _, err := ParseRecipient("not-a-key"); err == nil {
+		t.Error("ParseRecipient() should reject garbage")
+	}
+	if _, err := ParseRecipients([]byte("# only comments\n")); err == nil {
+		t.Error("ParseRecipients() should reject an empty list")
+	}
+}
+
+func TestParseIdentities(t *testing.T) {
+	age, _ := GenerateX25519Identity()
+	ids, err := ParseIdentities([]byte("# created: today\n" + age.String() + "\n"))
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("ParseIdentities(age) = %d ids, %v", len(ids), err)
+	}
+
+	_, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(edPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pemData bytes.Buffer
+	if err := pem.Encode(&pemData, block); err != nil {
+		t.Fatal(err)
+	}
+	ids, err = ParseIdentities(pemData.Bytes())
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("ParseIdentities(ssh) = %d ids, %v", len(ids), err)
+	}
+	if _, ok := ids[0].(*SSHEd25519Identity); !ok {
+		t.Errorf("identity type = %T, want *SSHEd25519Identity", ids[0])
+	}
+}
+
+func TestVaultExporter_Export_WithRecipients(t *testing.T) {
+	tmpDir := t.TempDir()
+	vaultDir := filepath.Join(tmpDir, "vault")
+	claudeDir := filepath.Join(vaultDir, "claude", "test@gmail.com")
+	if err := os.MkdirAll(claudeDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(claudeDir, "auth.json"), []byte(`{"token":"secret"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	alice, _ := GenerateX25519Identity()
+	bob, _ := GenerateX25519Identity()
+
+	exporter := &VaultExporter{VaultPath: vaultDir, DataPath: tmpDir}
+	result, err := exporter.Export(&ExportOptions{
+		OutputDir:  filepath.Join(tmpDir, "output"),
+		Recipients: []Recipient{alice.Recipient(), bob.Recipient()},
+	})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if !result.Encrypted {
+		t.Error("recipients should imply encryption")
+	}
+	if len(result.Manifest.Recipients) != 2 || result.Manifest.Recipients[1].Label != bob.Recipient().String() {
+		t.Errorf("manifest recipients = %+v", result.Manifest.Recipients)
+	}
+
+	meta, err := LoadBundleEncryptionMetadata(result.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.KDF != KDFRecipients || meta.NeedsPassphrase() {
+		t.Errorf("meta KDF = %q, NeedsPassphrase = %v", meta.KDF, meta.NeedsPassphrase())
+	}
+
+	// Bob can import with only his key
+	importer := &VaultImporter{BundlePath: result.OutputPath}
+	opts := DefaultImportOptions()
+	opts.DryRun = true
+	opts.VaultPath = filepath.Join(tmpDir, "restore")
+	opts.Identities = []Identity{bob}
+	imported, err := importer.Import(opts)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(imported.Manifest.Recipients) != 2 {
+		t.Errorf("imported manifest recipients = %d, want 2", len(imported.Manifest.Recipients))
+	}
+
+	// A stranger cannot
+	stranger, _ := GenerateX25519Identity()
+	opts.Identities = []Identity{stranger}
+	if _, err := importer.Import(opts); err == nil {
+		t.Error("Import with a non-recipient identity should fail")
+	}
+}
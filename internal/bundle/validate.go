@@ -249,10 +249,14 @@ func ValidateEncryptionMetadata(e *EncryptionMetadata) error {
 		}
 	}
 
-	if e.KDF != "argon2id" {
+	switch e.KDF {
+	case "argon2id":
+	case KDFRecipients:
+		return validateRecipientMetadata(e)
+	default:
 		return &ValidationError{
 			Field:   "kdf",
-			Message: fmt.Sprintf("unsupported KDF %q; only argon2id is supported", e.KDF),
+			Message: fmt.Sprintf("unsupported KDF %q; only argon2id and recipients are supported", e.KDF),
 		}
 	}
 
@@ -279,6 +283,47 @@ func ValidateEncryptionMetadata(e *EncryptionMetadata) error {
 	return nil
 }
 
+// validateRecipientMetadata validates multi-recipient encryption metadata.
+func validateRecipientMetadata(e *EncryptionMetadata) error {
+	if e.Nonce == "" {
+		return &ValidationError{
+			Field:   "nonce",
+			Message: "is required",
+		}
+	}
+
+	if len(e.Recipients) == 0 {
+		return &ValidationError{
+			Field:   "recipients",
+			Message: "at least one recipient is required",
+		}
+	}
+
+	for i, r := range e.Recipients {
+		switch r.Type {
+		case RecipientPassphrase, RecipientX25519, RecipientSSHEd25519, RecipientSSHRSA, RecipientGPG:
+		default:
+			return &ValidationError{
+				Field:   fmt.Sprintf("recipients[%d].type", i),
+				Message: fmt.Sprintf("unsupported recipient type %q", r.Type),
+			}
+		}
+		if r.WrappedKey == "" {
+			return &ValidationError{
+				Field:   fmt.Sprintf("recipients[%d].wrapped_key", i),
+				Message: "is required",
+			}
+		}
+		if r.Argon2Params != nil {
+			if err := validateArgon2Params(r.Argon2Params); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // validateArgon2Params validates Argon2 parameters.
 func validateArgon2Params(p *Argon2Params) error {
 	if p.Time < 1 {
@@ -0,0 +1,281 @@
+// Package caamerr is the registry of caam's machine-readable error codes.
+//
+// Every failure caam reports carries a Code: robot commands put it in the
+// JSON envelope's error.code, and every command, robot or not, exits with
+// the code's stable exit status. Codes are grouped into categories, and all
+// codes in a category share an exit status, so scripts can branch on the
+// exit status alone and agents can read the exact code from robot output or
+// 'caam errors list --json'.
+//
+// Codes and exit statuses are part of caam's interface: add new ones, but
+// never rename, remove, or renumber existing ones.
+package caamerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Code is a stable machine-readable error code.
+type Code string
+
+// Category groups codes that share an exit status.
+type Category string
+
+const (
+	CategoryGeneral     Category = "general"
+	CategoryPartial     Category = "partial"
+	CategoryUsage       Category = "usage"
+	CategoryNotFound    Category = "not_found"
+	CategoryUnavailable Category = "unavailable"
+	CategoryApproval    Category = "approval"
+	CategoryStorage     Category = "storage"
+	CategoryFailed      Category = "failed"
+	CategoryPermission  Category = "permission"
+	CategoryTimeout     Category = "timeout"
+	CategoryConflict    Category = "conflict"
+	CategoryCanceled    Category = "canceled"
+)
+
+// categoryExitCodes are the process exit statuses of each category.
+var categoryExitCodes = map[Category]int{
+	CategoryGeneral:     1,
+	CategoryPartial:     2,
+	CategoryUsage:       3,
+	CategoryNotFound:    4,
+	CategoryUnavailable: 5,
+	CategoryApproval:    6,
+	CategoryStorage:     7,
+	CategoryFailed:      8,
+	CategoryPermission:  9,
+	CategoryTimeout:     10,
+	CategoryConflict:    11,
+	CategoryCanceled:    130, // as if interrupted by SIGINT
+}
+
+// Error codes.
+const (
+	Internal Code = "INTERNAL"
+
+	PartialSuccess Code = "PARTIAL_SUCCESS"
+
+	Usage           Code = "USAGE"
+	InvalidArgs     Code = "INVALID_ARGS"
+	InvalidProvider Code = "INVALID_PROVIDER"
+	InvalidAction   Code = "INVALID_ACTION"
+	InvalidPlan     Code = "INVALID_PLAN"
+	UnknownKey      Code = "UNKNOWN_KEY"
+	MissingNote     Code = "MISSING_NOTE"
+	MissingProfile  Code = "MISSING_PROFILE"
+
+	NotFound         Code = "NOT_FOUND"
+	ProfileNotFound  Code = "PROFILE_NOT_FOUND"
+	NoProfiles       Code = "NO_PROFILES"
+	NoAuth           Code = "NO_AUTH"
+	NoWorkspaceMatch Code = "NO_WORKSPACE_MATCH"
+	NoTagMatch       Code = "NO_TAG_MATCH"
+
+	AllBlocked     Code = "ALL_BLOCKED"
+	ShapingBlocked Code = "SHAPING_BLOCKED"
+
+	ApprovalRequired Code = "APPROVAL_REQUIRED"
+	ApprovalDenied   Code = "APPROVAL_DENIED"
+	ApprovalFailed   Code = "APPROVAL_FAILED"
+
+	VaultError   Code = "VAULT_ERROR"
+	DBError      Code = "DB_ERROR"
+	ConfigError  Code = "CONFIG_ERROR"
+	SaveError    Code = "SAVE_ERROR"
+	BackupFailed Code = "BACKUP_FAILED"
+
+	ActivateFailed   Code = "ACTIVATE_FAILED"
+	CooldownFailed   Code = "COOLDOWN_FAILED"
+	UncooldownFailed Code = "UNCOOLDOWN_FAILED"
+	NoteFailed       Code = "NOTE_FAILED"
+	PruneFailed      Code = "PRUNE_FAILED"
+	PlanFailed       Code = "PLAN_FAILED"
+	RollbackFailed   Code = "ROLLBACK_FAILED"
+	CheckFailed      Code = "CHECK_FAILED"
+	SyncFailed       Code = "SYNC_FAILED"
+	HookRejected     Code = "HOOK_REJECTED"
+
+	PermissionDenied  Code = "PERMISSION_DENIED"
+	NamespaceReadOnly Code = "NAMESPACE_READ_ONLY"
+
+	Timeout Code = "TIMEOUT"
+
+	Conflict      Code = "CONFLICT"
+	AlreadyExists Code = "ALREADY_EXISTS"
+	ProfileLeased Code = "PROFILE_LEASED"
+
+	Canceled Code = "CANCELED"
+)
+
+// Spec describes one registered code.
+type Spec struct {
+	Code        Code     `json:"code"`
+	Category    Category `json:"category"`
+	ExitCode    int      `json:"exit_code"`
+	Retryable   bool     `json:"retryable"`
+	Description string   `json:"description"`
+}
+
+var registry = map[Code]Spec{}
+
+func register(code Code, category Category, retryable bool, description string) {
+	registry[code] = Spec{
+		Code:        code,
+		Category:    category,
+		ExitCode:    categoryExitCodes[category],
+		Retryable:   retryable,
+		Description: description,
+	}
+}
+
+func init() {
+	register(Internal, CategoryGeneral, false, "Unexpected error; see the message for details")
+
+	register(PartialSuccess, CategoryPartial, false, "Some actions failed and others took effect")
+
+	register(Usage, CategoryUsage, false, "Unknown command or flag, or the wrong number of arguments")
+	register(InvalidArgs, CategoryUsage, false, "An argument or flag value is invalid")
+	register(InvalidProvider, CategoryUsage, false, "Unknown provider (tool) name")
+	register(InvalidAction, CategoryUsage, false, "Unknown robot act action")
+	register(InvalidPlan, CategoryUsage, false, "The robot act plan is malformed; no actions were run")
+	register(UnknownKey, CategoryUsage, false, "Unknown configuration key")
+	register(MissingNote, CategoryUsage, false, "A note action needs note text")
+	register(MissingProfile, CategoryUsage, false, "The action needs a profile name argument")
+
+	register(NotFound, CategoryNotFound, false, "The named item does not exist")
+	register(ProfileNotFound, CategoryNotFound, false, "The named profile is not in the vault")
+	register(NoProfiles, CategoryNotFound, false, "The provider has no saved profiles")
+	register(NoAuth, CategoryNotFound, false, "The provider is not logged in, so there is nothing to save")
+	register(NoWorkspaceMatch, CategoryNotFound, false, "No profile matches the current workspace")
+	register(NoTagMatch, CategoryNotFound, false, "No profile matches the requested tags")
+
+	register(AllBlocked, CategoryUnavailable, true, "Every profile is cooling down, rate limited, or unhealthy")
+	register(ShapingBlocked, CategoryUnavailable, true, "Rotation shaping does not allow activating the profile now")
+
+	register(ApprovalRequired, CategoryApproval, true, "The action is waiting for a human to approve it")
+	register(ApprovalDenied, CategoryApproval, false, "A human denied the action")
+	register(ApprovalFailed, CategoryApproval, true, "The approval request could not be recorded")
+
+	register(VaultError, CategoryStorage, true, "The profile vault could not be read or written")
+	register(DBError, CategoryStorage, true, "The activity database could not be opened or queried")
+	register(ConfigError, CategoryStorage, false, "The configuration could not be loaded or saved")
+	register(SaveError, CategoryStorage, true, "A change could not be saved")
+	register(BackupFailed, CategoryStorage, true, "The current auth files could not be saved to the vault")
+
+	register(ActivateFailed, CategoryFailed, true, "The profile could not be activated")
+	register(CooldownFailed, CategoryFailed, true, "The cooldown could not be recorded")
+	register(UncooldownFailed, CategoryFailed, true, "The cooldown could not be cleared")
+	register(NoteFailed, CategoryFailed, true, "The note could not be recorded")
+	register(PruneFailed, CategoryFailed, true, "Vault pruning failed")
+	register(PlanFailed, CategoryFailed, false, "The robot act plan failed; nothing took effect")
+	register(RollbackFailed, CategoryFailed, false, "A failed atomic plan could not be rolled back")
+	register(CheckFailed, CategoryFailed, false, "A diagnostic check found problems")
+	register(SyncFailed, CategoryFailed, true, "Sync failed with every machine it tried")
+	register(HookRejected, CategoryFailed, false, "A pre- hook exited non-zero, so the action was not taken")
+
+	register(PermissionDenied, CategoryPermission, false, "A file or directory is not accessible")
+	register(NamespaceReadOnly, CategoryPermission, false, "The shared namespace only lets its writers change profiles")
+
+	register(Timeout, CategoryTimeout, true, "The operation timed out")
+
+	register(Conflict, CategoryConflict, false, "The item changed state and the operation no longer applies")
+	register(AlreadyExists, CategoryConflict, false, "An item with that name already exists")
+	register(ProfileLeased, CategoryConflict, true, "Someone else holds the lease on the profile")
+
+	register(Canceled, CategoryCanceled, true, "The operation was canceled")
+}
+
+// Registry returns every registered code, ordered by exit status and code.
+func Registry() []Spec {
+	specs := make([]Spec, 0, len(registry))
+	for _, spec := range registry {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		if specs[i].ExitCode != specs[j].ExitCode {
+			return specs[i].ExitCode < specs[j].ExitCode
+		}
+		return specs[i].Code < specs[j].Code
+	})
+	return specs
+}
+
+// Lookup returns the spec of a registered code.
+func Lookup(code Code) (Spec, bool) {
+	spec, ok := registry[code]
+	return spec, ok
+}
+
+// ExitCode returns the exit status for code; unregistered codes exit 1.
+func ExitCode(code Code) int {
+	if spec, ok := registry[code]; ok {
+		return spec.ExitCode
+	}
+	return categoryExitCodes[CategoryGeneral]
+}
+
+// Error is an error tagged with a Code.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+// New returns an error with code and message.
+func New(code Code, message string) error {
+	return &Error{Code: code, Err: errors.New(message)}
+}
+
+// Errorf returns an error with code, formatted like fmt.Errorf (so %w wraps).
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap tags err with code. It returns nil if err is nil.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// CodeOf returns the code of err: the outermost Error in its chain, else a
+// code inferred from standard library errors, else Internal.
+func CodeOf(err error) Code {
+	var coded *Error
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return Timeout
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	case errors.Is(err, os.ErrPermission):
+		return PermissionDenied
+	case errors.Is(err, os.ErrNotExist):
+		return NotFound
+	case errors.Is(err, os.ErrExist):
+		return AlreadyExists
+	default:
+		return Internal
+	}
+}
+
+// ExitCodeOf returns the exit status for err: 0 for nil, else that of
+// CodeOf(err).
+func ExitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	return ExitCode(CodeOf(err))
+}
@@ -0,0 +1,77 @@
+package caamerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	specs := Registry()
+	if len(specs) == 0 {
+		t.Fatal("Registry() is empty")
+	}
+	seen := make(map[Code]bool)
+	for i, spec := range specs {
+		if seen[spec.Code] {
+			t.Errorf("code %s registered twice", spec.Code)
+		}
+		seen[spec.Code] = true
+		if spec.ExitCode == 0 || spec.ExitCode != categoryExitCodes[spec.Category] {
+			t.Errorf("%s exit code = %d, want %d for category %q", spec.Code, spec.ExitCode, categoryExitCodes[spec.Category], spec.Category)
+		}
+		if spec.Description == "" {
+			t.Errorf("%s has no description", spec.Code)
+		}
+		if i > 0 && specs[i-1].ExitCode > spec.ExitCode {
+			t.Errorf("Registry() not ordered by exit code at %s", spec.Code)
+		}
+	}
+
+	if got := ExitCode(PartialSuccess); got != 2 {
+		t.Errorf("ExitCode(PARTIAL_SUCCESS) = %d, want 2", got)
+	}
+	if got := ExitCode("NOT_A_CODE"); got != 1 {
+		t.Errorf("ExitCode(unregistered) = %d, want 1", got)
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	base := New(NoProfiles, "no profiles found for claude")
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"coded", base, NoProfiles},
+		{"wrapped coded", fmt.Errorf("next: %w", base), NoProfiles},
+		{"outermost code wins", Wrap(VaultError, base), VaultError},
+		{"not exist", fmt.Errorf("read: %w", os.ErrNotExist), NotFound},
+		{"permission", &os.PathError{Op: "open", Path: "x", Err: os.ErrPermission}, PermissionDenied},
+		{"deadline", context.DeadlineExceeded, Timeout},
+		{"canceled", context.Canceled, Canceled},
+		{"plain", errors.New("boom"), Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.want {
+				t.Errorf("CodeOf() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if ExitCodeOf(nil) != 0 {
+		t.Error("ExitCodeOf(nil) != 0")
+	}
+	if got := ExitCodeOf(base); got != 4 {
+		t.Errorf("ExitCodeOf(NO_PROFILES) = %d, want 4", got)
+	}
+	if Wrap(Internal, nil) != nil {
+		t.Error("Wrap(nil) != nil")
+	}
+	if err := Errorf(DBError, "open database: %w", os.ErrPermission); !errors.Is(err, os.ErrPermission) || err.Error() != "open database: permission denied" {
+		t.Errorf("Errorf() = %v, want wrapped permission error", err)
+	}
+}
@@ -0,0 +1,243 @@
+// Package chaos provides opt-in failure injection for caam.
+//
+// Agents driving caam through robot mode need to handle caam's own failure
+// modes: expired tokens, unreadable auth files, a locked database, or sync
+// peers that can't be reached. This package lets users and CI switch those
+// failures on deliberately instead of waiting for them to happen.
+//
+// Faults can be enabled three ways, checked in this order:
+//   - Inject, for Go tests in the current process
+//   - the CAAM_CHAOS environment variable (comma-separated fault names, or "all")
+//   - the state file written by 'caam chaos enable'
+package chaos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault identifies a failure mode that can be injected.
+type Fault string
+
+const (
+	// ExpiredTokens makes every profile's token look expired.
+	ExpiredTokens Fault = "expired_tokens"
+
+	// CorruptAuth makes restoring auth files from the vault fail as if the
+	// stored files were unparseable.
+	CorruptAuth Fault = "corrupt_auth"
+
+	// DBLocked makes opening the activity database fail with SQLITE_BUSY.
+	DBLocked Fault = "db_locked"
+
+	// SyncUnreachable makes every sync machine fail to connect.
+	SyncUnreachable Fault = "sync_unreachable"
+)
+
+// AllFaults lists every supported fault.
+var AllFaults = []Fault{ExpiredTokens, CorruptAuth, DBLocked, SyncUnreachable}
+
+// ErrInjected is wrapped by every error produced by Err.
+var ErrInjected = errors.New("chaos: injected failure")
+
+// faultMessages mimic the errors the real failure would produce.
+var faultMessages = map[Fault]string{
+	ExpiredTokens:   "token expired",
+	CorruptAuth:     "auth file is corrupt: unexpected end of JSON input",
+	DBLocked:        "database is locked (SQLITE_BUSY)",
+	SyncUnreachable: "dial tcp: connect: no route to host",
+}
+
+// State is the persisted chaos configuration.
+type State struct {
+	Faults    []Fault   `json:"faults"`
+	EnabledAt time.Time `json:"enabled_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired reports whether the state has passed its expiry time.
+func (s *State) Expired() bool {
+	return s != nil && !s.ExpiresAt.IsZero() && time.Now().After(s.ExpiresAt)
+}
+
+var (
+	mu        sync.Mutex
+	loaded    bool
+	active    map[Fault]bool
+	overrides map[Fault]bool
+)
+
+// ParseFault validates a fault name.
+func ParseFault(name string) (Fault, error) {
+	f := Fault(strings.ToLower(strings.TrimSpace(name)))
+	for _, known := range AllFaults {
+		if f == known {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown fault %q (valid: %s)", name, faultList())
+}
+
+func faultList() string {
+	names := make([]string, len(AllFaults))
+	for i, f := range AllFaults {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
+
+// StatePath returns the location of the chaos state file.
+func StatePath() string {
+	if caamHome := os.Getenv("CAAM_HOME"); caamHome != "" {
+		return filepath.Join(caamHome, "chaos.json")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".caam", "chaos.json")
+	}
+	return filepath.Join(homeDir, ".caam", "chaos.json")
+}
+
+// LoadState reads the chaos state file. A missing file yields an empty state.
+func LoadState() (*State, error) {
+	data, err := os.ReadFile(StatePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, fmt.Errorf("read chaos state: %w", err)
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse chaos state: %w", err)
+	}
+	return &s, nil
+}
+
+// SaveState writes the chaos state file. Saving a state with no faults
+// removes the file.
+func SaveState(s *State) error {
+	path := StatePath()
+	defer Reset()
+
+	if s == nil || len(s.Faults) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove chaos state: %w", err)
+		}
+		return nil
+	}
+
+	sort.Slice(s.Faults, func(i, j int) bool { return s.Faults[i] < s.Faults[j] })
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create chaos dir: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal chaos state: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("write chaos state: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("rename chaos state: %w", err)
+	}
+	return nil
+}
+
+// load populates the active fault set from the environment and state file.
+// Callers must hold mu.
+func load() {
+	if loaded {
+		return
+	}
+	loaded = true
+	active = make(map[Fault]bool)
+
+	if env := os.Getenv("CAAM_CHAOS"); env != "" {
+		for _, name := range strings.Split(env, ",") {
+			if strings.TrimSpace(name) == "all" {
+				for _, f := range AllFaults {
+					active[f] = true
+				}
+				continue
+			}
+			if f, err := ParseFault(name); err == nil {
+				active[f] = true
+			}
+		}
+	}
+
+	if s, err := LoadState(); err == nil && !s.Expired() {
+		for _, f := range s.Faults {
+			active[f] = true
+		}
+	}
+}
+
+// Active reports whether a fault is currently injected.
+func Active(f Fault) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if overrides != nil {
+		return overrides[f]
+	}
+	load()
+	return active[f]
+}
+
+// ActiveFaults returns every fault that is currently injected.
+func ActiveFaults() []Fault {
+	var faults []Fault
+	for _, f := range AllFaults {
+		if Active(f) {
+			faults = append(faults, f)
+		}
+	}
+	return faults
+}
+
+// Err returns an error wrapping ErrInjected if the fault is active, or nil.
+func Err(f Fault) error {
+	if !Active(f) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInjected, faultMessages[f])
+}
+
+// Inject enables exactly the given faults for the current process, ignoring
+// the environment and state file, and returns a function that undoes it.
+// It is intended for tests.
+func Inject(faults ...Fault) (restore func()) {
+	mu.Lock()
+	prev := overrides
+	overrides = make(map[Fault]bool, len(faults))
+	for _, f := range faults {
+		overrides[f] = true
+	}
+	mu.Unlock()
+
+	return func() {
+		mu.Lock()
+		overrides = prev
+		mu.Unlock()
+	}
+}
+
+// Reset drops the cached fault set so the next check re-reads the
+// environment and state file.
+func Reset() {
+	mu.Lock()
+	loaded = false
+	active = nil
+	mu.Unlock()
+}
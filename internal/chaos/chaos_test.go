@@ -0,0 +1,79 @@
+package chaos
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInject(t *testing.T) {
+	restore := Inject(DBLocked)
+	assert.True(t, Active(DBLocked))
+	assert.False(t, Active(ExpiredTokens))
+
+	err := Err(DBLocked)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInjected))
+	assert.Contains(t, err.Error(), "SQLITE_BUSY")
+	assert.NoError(t, Err(SyncUnreachable))
+
+	restore()
+	assert.False(t, Active(DBLocked))
+}
+
+func TestEnvOverride(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	t.Setenv("CAAM_CHAOS", "expired_tokens, bogus")
+	Reset()
+	defer Reset()
+
+	assert.True(t, Active(ExpiredTokens))
+	assert.False(t, Active(CorruptAuth))
+
+	t.Setenv("CAAM_CHAOS", "all")
+	Reset()
+	assert.ElementsMatch(t, AllFaults, ActiveFaults())
+}
+
+func TestStateFile(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	t.Setenv("CAAM_CHAOS", "")
+	Reset()
+	defer Reset()
+
+	require.NoError(t, SaveState(&State{
+		Faults:    []Fault{SyncUnreachable, CorruptAuth},
+		EnabledAt: time.Now(),
+	}))
+	assert.Equal(t, []Fault{CorruptAuth, SyncUnreachable}, ActiveFaults())
+
+	state, err := LoadState()
+	require.NoError(t, err)
+	assert.Equal(t, []Fault{CorruptAuth, SyncUnreachable}, state.Faults)
+
+	// Expired state injects nothing.
+	require.NoError(t, SaveState(&State{
+		Faults:    []Fault{DBLocked},
+		EnabledAt: time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}))
+	assert.Empty(t, ActiveFaults())
+
+	// Saving an empty state removes the file.
+	require.NoError(t, SaveState(&State{}))
+	state, err = LoadState()
+	require.NoError(t, err)
+	assert.Empty(t, state.Faults)
+}
+
+func TestParseFault(t *testing.T) {
+	f, err := ParseFault(" DB_LOCKED ")
+	require.NoError(t, err)
+	assert.Equal(t, DBLocked, f)
+
+	_, err = ParseFault("meteor_strike")
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
+)
+
+// ApprovalDecision is what happens to an agent-initiated action.
+type ApprovalDecision string
+
+const (
+	// ApprovalAllow runs the action immediately.
+	ApprovalAllow ApprovalDecision = "approve"
+
+	// ApprovalRequire queues the action as a proposal for a human to approve.
+	ApprovalRequire ApprovalDecision = "require"
+
+	// ApprovalDeny rejects the action outright.
+	ApprovalDeny ApprovalDecision = "deny"
+)
+
+// ApprovalConfig gates robot actions requested by agents behind human
+// approval. Actions run by a person at the terminal are never gated.
+type ApprovalConfig struct {
+	// Enabled turns the approval workflow on. Default: false
+	Enabled bool `json:"enabled"`
+
+	// Default is the decision when no rule matches. Default: "require"
+	Default ApprovalDecision `json:"default,omitempty"`
+
+	// Rules are checked in order; the first match decides.
+	// Example: [{"action": "activate", "risk": "expendable", "decision": "approve"}]
+	Rules []ApprovalRule `json:"rules,omitempty"`
+}
+
+// ApprovalRule matches an action by name, provider, and the target profile's
+// risk tier. Empty fields (or "*") match anything.
+type ApprovalRule struct {
+	Action   string           `json:"action,omitempty"`
+	Provider string           `json:"provider,omitempty"`
+	Risk     risk.Tier        `json:"risk,omitempty"`
+	Decision ApprovalDecision `json:"decision"`
+}
+
+// Matches reports whether the rule applies to an action.
+func (r ApprovalRule) Matches(action, provider string, tier risk.Tier) bool {
+	if r.Action != "" && r.Action != "*" && !strings.EqualFold(r.Action, action) {
+		return false
+	}
+	if r.Provider != "" && r.Provider != "*" && !strings.EqualFold(r.Provider, provider) {
+		return false
+	}
+	if r.Risk != "" && r.Risk != "*" && r.Risk != tier {
+		return false
+	}
+	return true
+}
+
+// Decide returns the decision for an agent-initiated action. It returns
+// ApprovalAllow when the workflow is disabled.
+func (c ApprovalConfig) Decide(action, provider string, tier risk.Tier) ApprovalDecision {
+	if !c.Enabled {
+		return ApprovalAllow
+	}
+	if tier == "" {
+		tier = risk.Normal
+	}
+	for _, rule := range c.Rules {
+		if rule.Matches(action, provider, tier) {
+			return rule.Decision
+		}
+	}
+	if c.Default != "" {
+		return c.Default
+	}
+	return ApprovalRequire
+}
+
+// Validate checks decisions and risk tiers.
+func (c ApprovalConfig) Validate() error {
+	if c.Default != "" && !validApprovalDecision(c.Default) {
+		return fmt.Errorf("approvals.default: invalid decision %q (valid: approve, require, deny)", c.Default)
+	}
+	for i, rule := range c.Rules {
+		if !validApprovalDecision(rule.Decision) {
+			return fmt.Errorf("approvals.rules[%d]: invalid decision %q (valid: approve, require, deny)", i, rule.Decision)
+		}
+		if rule.Risk != "" && rule.Risk != "*" {
+			if _, err := risk.Parse(string(rule.Risk)); err != nil {
+				return fmt.Errorf("approvals.rules[%d]: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validApprovalDecision(d ApprovalDecision) bool {
+	switch d {
+	case ApprovalAllow, ApprovalRequire, ApprovalDeny:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
+)
+
+func TestApprovalConfigDecide(t *testing.T) {
+	cfg := ApprovalConfig{
+		Enabled: true,
+		Rules: []ApprovalRule{
+			{Action: "activate", Risk: risk.High, Decision: ApprovalRequire},
+			{Action: "activate", Decision: ApprovalAllow},
+			{Action: "cooldown", Provider: "codex", Decision: ApprovalAllow},
+			{Action: "backup", Risk: risk.High, Decision: ApprovalDeny},
+		},
+	}
+
+	tests := []struct {
+		action, provider string
+		tier             risk.Tier
+		want             ApprovalDecision
+	}{
+		{"activate", "claude", risk.High, ApprovalRequire},
+		{"activate", "claude", risk.Normal, ApprovalAllow},
+		{"activate", "claude", "", ApprovalAllow},
+		{"cooldown", "codex", risk.High, ApprovalAllow},
+		{"cooldown", "claude", risk.Expendable, ApprovalRequire},
+		{"backup", "gemini", risk.High, ApprovalDeny},
+		{"uncooldown", "gemini", risk.Normal, ApprovalRequire},
+	}
+	for _, tt := range tests {
+		if got := cfg.Decide(tt.action, tt.provider, tt.tier); got != tt.want {
+			t.Errorf("Decide(%s, %s, %s) = %s, want %s", tt.action, tt.provider, tt.tier, got, tt.want)
+		}
+	}
+
+	cfg.Default = ApprovalDeny
+	if got := cfg.Decide("uncooldown", "gemini", risk.Normal); got != ApprovalDeny {
+		t.Errorf("Decide with default deny = %s, want deny", got)
+	}
+
+	cfg.Enabled = false
+	if got := cfg.Decide("backup", "gemini", risk.High); got != ApprovalAllow {
+		t.Errorf("Decide when disabled = %s, want approve", got)
+	}
+}
+
+func TestApprovalConfigValidate(t *testing.T) {
+	valid := ApprovalConfig{Rules: []ApprovalRule{{Action: "*", Risk: "*", Decision: ApprovalAllow}}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	for name, cfg := range map[string]ApprovalConfig{
+		"bad default":  {Default: "maybe"},
+		"bad decision": {Rules: []ApprovalRule{{Action: "activate", Decision: "allow"}}},
+		"bad risk":     {Rules: []ApprovalRule{{Risk: "extreme", Decision: ApprovalAllow}}},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: Validate() = nil, want error", name)
+		}
+	}
+}
@@ -74,3 +74,19 @@ func (c *BackupConfig) GetKeepLast() int {
 func (c *BackupConfig) IsEnabled() bool {
 	return c.Enabled
 }
+
+// SnapshotConfig holds settings for `caam vault snapshot`.
+type SnapshotConfig struct {
+	// KeepLast is the number of vault snapshots to retain. Older snapshots
+	// are deleted when a new one is created.
+	// Default: 10
+	KeepLast int `json:"keep_last,omitempty"`
+}
+
+// GetKeepLast returns the number of snapshots to keep.
+func (c *SnapshotConfig) GetKeepLast() int {
+	if c.KeepLast <= 0 {
+		return 10 // Default
+	}
+	return c.KeepLast
+}
@@ -9,7 +9,10 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
 )
 
 // Config holds the global caam configuration.
@@ -52,6 +55,21 @@ type Config struct {
 	// Example: {"claude": ["work", "personal"]}
 	Favorites map[string][]string `json:"favorites,omitempty"`
 
+	// RiskTiers maps profile keys (provider/profile) to their risk tier.
+	// Profiles without an entry are risk.Normal.
+	// Example: {"claude/personal": "high", "claude/pool-3": "expendable"}
+	RiskTiers map[string]risk.Tier `json:"risk_tiers,omitempty"`
+
+	// ProfileWeights maps profile keys (provider/profile) to selection
+	// weights for the weighted robot next strategy. The default is 1.
+	// Example: {"claude/work": 2, "claude/spare": 0.5}
+	ProfileWeights map[string]float64 `json:"profile_weights,omitempty"`
+
+	// PlanWeights maps plan types to selection weights, for profiles
+	// without a ProfileWeights entry.
+	// Example: {"max": 2, "pro": 1}
+	PlanWeights map[string]float64 `json:"plan_weights,omitempty"`
+
 	// Workspaces maps workspace names to provider-profile mappings.
 	// Example: {"work": {"claude": "work-claude", "codex": "work-codex"}}
 	Workspaces map[string]map[string]string `json:"workspaces,omitempty"`
@@ -64,6 +82,16 @@ type Config struct {
 
 	// Backup configures automatic backup scheduling.
 	Backup BackupConfig `json:"backup,omitempty"`
+
+	// Snapshots configures vault snapshot retention.
+	Snapshots SnapshotConfig `json:"snapshots,omitempty"`
+
+	// Approvals gates agent-initiated robot actions behind human approval.
+	Approvals ApprovalConfig `json:"approvals,omitempty"`
+
+	// Notifications sends webhook, Slack, and desktop notifications on key
+	// events such as cooldowns and expiring tokens.
+	Notifications NotificationsConfig `json:"notifications,omitempty"`
 }
 
 // DefaultConfig returns the default configuration.
@@ -77,18 +105,14 @@ func DefaultConfig() *Config {
 	}
 }
 
-// ConfigPath returns the path to the config file.
+// ConfigPath returns the path to the config file, inside the active config
+// environment if there is one.
 // Falls back to current directory if home directory cannot be determined.
 func ConfigPath() string {
-	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
-		return filepath.Join(xdgConfig, "caam", "config.json")
-	}
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		// Fallback to current directory - unusual but handles edge cases
-		return filepath.Join(".config", "caam", "config.json")
+	if env := ActiveEnv(); env != "" {
+		return filepath.Join(EnvDir(env), "config.json")
 	}
-	return filepath.Join(homeDir, ".config", "caam", "config.json")
+	return filepath.Join(baseConfigDir(), "config.json")
 }
 
 // DefaultDataPath returns the base caam data directory path.
@@ -509,6 +533,55 @@ func (c *Config) IsFavorite(provider, profile string) bool {
 	return false
 }
 
+// SetRiskTier sets the risk tier for a profile. Setting risk.Normal removes
+// the entry, since that is the default.
+func (c *Config) SetRiskTier(provider, profile string, tier risk.Tier) {
+	key := ProfileKey(provider, profile)
+	if tier == "" || tier == risk.Normal {
+		delete(c.RiskTiers, key)
+		return
+	}
+	if c.RiskTiers == nil {
+		c.RiskTiers = make(map[string]risk.Tier)
+	}
+	c.RiskTiers[key] = tier
+}
+
+// GetRiskTier returns the risk tier for a profile (risk.Normal if unset).
+func (c *Config) GetRiskTier(provider, profile string) risk.Tier {
+	if tier, ok := c.RiskTiers[ProfileKey(provider, profile)]; ok && tier != "" {
+		return tier
+	}
+	return risk.Normal
+}
+
+// SelectionWeight returns a profile's weight for weighted selection and
+// where it came from: its own entry, its plan's entry, or the default of 1.
+func (c *Config) SelectionWeight(provider, profile, planType string) (float64, string) {
+	if w, ok := c.ProfileWeights[ProfileKey(provider, profile)]; ok && w >= 0 {
+		return w, "profile"
+	}
+	if planType != "" {
+		if w, ok := c.PlanWeights[strings.ToLower(planType)]; ok && w >= 0 {
+			return w, "plan " + strings.ToLower(planType)
+		}
+	}
+	return 1, "default"
+}
+
+// RiskTiersForProvider returns the non-default risk tiers for a provider's
+// profiles, keyed by profile name.
+func (c *Config) RiskTiersForProvider(provider string) map[string]risk.Tier {
+	prefix := provider + "/"
+	tiers := make(map[string]risk.Tier)
+	for key, tier := range c.RiskTiers {
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			tiers[name] = tier
+		}
+	}
+	return tiers
+}
+
 // CreateWorkspace creates or updates a workspace with the given profile mappings.
 func (c *Config) CreateWorkspace(name string, profiles map[string]string) {
 	if c.Workspaces == nil {
@@ -551,13 +624,7 @@ func (c *Config) ListWorkspaces() []string {
 		names = append(names, name)
 	}
 	// Sort for consistent ordering
-	for i := 0; i < len(names)-1; i++ {
-		for j := i + 1; j < len(names); j++ {
-			if names[j] < names[i] {
-				names[i], names[j] = names[j], names[i]
-			}
-		}
-	}
+	sort.Strings(names)
 	return names
 }
 
@@ -639,14 +706,10 @@ func (c *Config) FuzzyMatch(provider, query string, profiles []string) []string
 		}
 	}
 
-	// Sort by score
-	for i := 0; i < len(matches)-1; i++ {
-		for j := i + 1; j < len(matches); j++ {
-			if matches[j].score < matches[i].score {
-				matches[i], matches[j] = matches[j], matches[i]
-			}
-		}
-	}
+	// Sort by score, keeping profiles with equal scores in list order
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score < matches[j].score
+	})
 
 	// Extract profile names
 	result := make([]string, len(matches))
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -796,6 +798,58 @@ func TestFavorites(t *testing.T) {
 	}
 }
 
+func TestRiskTiers(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if got := cfg.GetRiskTier("claude", "personal"); got != risk.Normal {
+		t.Errorf("GetRiskTier() unset = %q, want normal", got)
+	}
+
+	cfg.SetRiskTier("claude", "personal", risk.High)
+	cfg.SetRiskTier("claude", "pool-1", risk.Expendable)
+	cfg.SetRiskTier("codex", "personal", risk.Expendable)
+
+	if got := cfg.GetRiskTier("claude", "personal"); got != risk.High {
+		t.Errorf("GetRiskTier(claude, personal) = %q, want high", got)
+	}
+
+	tiers := cfg.RiskTiersForProvider("claude")
+	if len(tiers) != 2 || tiers["personal"] != risk.High || tiers["pool-1"] != risk.Expendable {
+		t.Errorf("RiskTiersForProvider(claude) = %v", tiers)
+	}
+
+	// Setting normal clears the entry.
+	cfg.SetRiskTier("claude", "personal", risk.Normal)
+	if _, ok := cfg.RiskTiers["claude/personal"]; ok {
+		t.Error("SetRiskTier(normal) should remove the entry")
+	}
+	if got := cfg.GetRiskTier("claude", "personal"); got != risk.Normal {
+		t.Errorf("GetRiskTier() after reset = %q, want normal", got)
+	}
+}
+
+func TestSelectionWeight(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ProfileWeights = map[string]float64{"claude/work": 3}
+	cfg.PlanWeights = map[string]float64{"max": 2}
+
+	tests := []struct {
+		profile, plan string
+		want          float64
+		wantSource    string
+	}{
+		{"work", "pro", 3, "profile"},
+		{"other", "Max", 2, "plan max"},
+		{"other", "pro", 1, "default"},
+	}
+	for _, tc := range tests {
+		w, source := cfg.SelectionWeight("claude", tc.profile, tc.plan)
+		if w != tc.want || source != tc.wantSource {
+			t.Errorf("SelectionWeight(%s, %s) = %v, %q; want %v, %q", tc.profile, tc.plan, w, source, tc.want, tc.wantSource)
+		}
+	}
+}
+
 func TestFuzzyMatch(t *testing.T) {
 	profiles := []string{
 		"work-account-1",
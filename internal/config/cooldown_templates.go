@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CooldownTemplate is the cooldown a provider's plan needs after hitting its
+// limit, used when a cooldown is set without a duration. Plans reset on
+// different schedules: a rolling window (Claude Pro's five hours, Max's
+// weekly cap) is a duration from the hit; a daily quota (Gemini) resets at a
+// fixed clock time.
+//
+//	stealth:
+//	  cooldown:
+//	    templates:
+//	      - provider: claude
+//	        plan: max
+//	        duration: 168h
+//	      - provider: claude
+//	        duration: 5h
+//	      - provider: gemini
+//	        reset_at: "00:00"
+//	        timezone: America/Los_Angeles
+type CooldownTemplate struct {
+	// Provider is the tool the template applies to.
+	Provider string `yaml:"provider"`
+
+	// Plan is the profile's plan type ("pro", "max", "plus", ...). Empty
+	// matches any plan the provider has no more specific template for.
+	Plan string `yaml:"plan,omitempty"`
+
+	// Duration is a rolling window: the cooldown lasts this long from the hit.
+	Duration Duration `yaml:"duration,omitempty"`
+
+	// ResetAt is a daily reset time, "HH:MM" on a 24-hour clock.
+	ResetAt string `yaml:"reset_at,omitempty"`
+
+	// Timezone is the IANA zone ResetAt is in. Defaults to local time.
+	Timezone string `yaml:"timezone,omitempty"`
+}
+
+// Name returns "provider" or "provider/plan".
+func (t CooldownTemplate) Name() string {
+	if t.Plan == "" {
+		return t.Provider
+	}
+	return t.Provider + "/" + t.Plan
+}
+
+// Until returns when a limit hit at hitAt clears under the template.
+func (t CooldownTemplate) Until(hitAt time.Time) time.Time {
+	if t.ResetAt == "" {
+		return hitAt.Add(t.Duration.Duration())
+	}
+	clock, err := time.Parse("15:04", t.ResetAt)
+	if err != nil {
+		return hitAt.Add(t.Duration.Duration())
+	}
+	loc := time.Local
+	if t.Timezone != "" {
+		if l, err := time.LoadLocation(t.Timezone); err == nil {
+			loc = l
+		}
+	}
+	local := hitAt.In(loc)
+	reset := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if !reset.After(hitAt) {
+		reset = reset.AddDate(0, 0, 1)
+	}
+	return reset
+}
+
+// CooldownTemplateFor returns the template for provider and plan: an exact
+// plan match first, then the provider's plan-less template. It returns nil
+// when neither is configured.
+func (c CooldownConfig) CooldownTemplateFor(provider, plan string) *CooldownTemplate {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	plan = strings.ToLower(strings.TrimSpace(plan))
+	var fallback *CooldownTemplate
+	for i := range c.Templates {
+		t := &c.Templates[i]
+		if strings.ToLower(t.Provider) != provider {
+			continue
+		}
+		tplPlan := strings.ToLower(t.Plan)
+		if plan != "" && tplPlan == plan {
+			return t
+		}
+		if tplPlan == "" && fallback == nil {
+			fallback = t
+		}
+	}
+	return fallback
+}
+
+func validateCooldownTemplates(templates []CooldownTemplate) error {
+	for i, t := range templates {
+		field := fmt.Sprintf("stealth.cooldown.templates[%d]", i)
+		if strings.TrimSpace(t.Provider) == "" {
+			return fmt.Errorf("%s.provider is required", field)
+		}
+		if (t.Duration.Duration() > 0) == (t.ResetAt != "") {
+			return fmt.Errorf("%s must set exactly one of duration or reset_at", field)
+		}
+		if t.ResetAt != "" {
+			if _, err := time.Parse("15:04", t.ResetAt); err != nil {
+				return fmt.Errorf("%s.reset_at must be HH:MM, got %q", field, t.ResetAt)
+			}
+		}
+		if t.Timezone != "" {
+			if t.ResetAt == "" {
+				return fmt.Errorf("%s.timezone only applies with reset_at", field)
+			}
+			if _, err := time.LoadLocation(t.Timezone); err != nil {
+				return fmt.Errorf("%s.timezone: %w", field, err)
+			}
+		}
+	}
+	return nil
+}
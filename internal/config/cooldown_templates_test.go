@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCooldownTemplateFor(t *testing.T) {
+	cfg := CooldownConfig{Templates: []CooldownTemplate{
+		{Provider: "claude", Duration: Duration(5 * time.Hour)},
+		{Provider: "claude", Plan: "Max", Duration: Duration(168 * time.Hour)},
+		{Provider: "gemini", ResetAt: "00:00", Timezone: "UTC"},
+	}}
+
+	tests := []struct {
+		provider, plan string
+		want           string
+	}{
+		{"claude", "max", "claude/Max"},
+		{"claude", "pro", "claude"},
+		{"claude", "", "claude"},
+		{"gemini", "free", "gemini"},
+		{"codex", "plus", ""},
+	}
+	for _, tt := range tests {
+		got := cfg.CooldownTemplateFor(tt.provider, tt.plan)
+		name := ""
+		if got != nil {
+			name = got.Name()
+		}
+		if name != tt.want {
+			t.Errorf("CooldownTemplateFor(%q, %q) = %q, want %q", tt.provider, tt.plan, name, tt.want)
+		}
+	}
+}
+
+func TestCooldownTemplateUntil(t *testing.T) {
+	hitAt := time.Date(2026, 3, 10, 18, 30, 0, 0, time.UTC)
+
+	rolling := CooldownTemplate{Provider: "claude", Duration: Duration(5 * time.Hour)}
+	if got := rolling.Until(hitAt); !got.Equal(hitAt.Add(5 * time.Hour)) {
+		t.Errorf("rolling Until = %v", got)
+	}
+
+	daily := CooldownTemplate{Provider: "gemini", ResetAt: "00:00", Timezone: "UTC"}
+	if got := daily.Until(hitAt); !got.Equal(time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily Until = %v", got)
+	}
+
+	later := CooldownTemplate{Provider: "gemini", ResetAt: "20:00", Timezone: "UTC"}
+	if got := later.Until(hitAt); !got.Equal(time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)) {
+		t.Errorf("same-day Until = %v", got)
+	}
+}
+
+func TestValidateCooldownTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		template CooldownTemplate
+		wantErr  string
+	}{
+		{name: "rolling", template: CooldownTemplate{Provider: "claude", Duration: Duration(time.Hour)}},
+		{name: "daily", template: CooldownTemplate{Provider: "gemini", ResetAt: "07:30", Timezone: "UTC"}},
+		{name: "no provider", template: CooldownTemplate{Duration: Duration(time.Hour)}, wantErr: "provider is required"},
+		{name: "neither", template: CooldownTemplate{Provider: "claude"}, wantErr: "exactly one"},
+		{name: "both", template: CooldownTemplate{Provider: "claude", Duration: Duration(time.Hour), ResetAt: "00:00"}, wantErr: "exactly one"},
+		{name: "bad clock", template: CooldownTemplate{Provider: "gemini", ResetAt: "25:00"}, wantErr: "HH:MM"},
+		{name: "bad zone", template: CooldownTemplate{Provider: "gemini", ResetAt: "00:00", Timezone: "Nowhere/City"}, wantErr: "timezone"},
+		{name: "zone without clock", template: CooldownTemplate{Provider: "claude", Duration: Duration(time.Hour), Timezone: "UTC"}, wantErr: "only applies"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCooldownTemplates([]CooldownTemplate{tt.template})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
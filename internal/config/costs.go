@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/pricing"
+)
+
+// DefaultBudgetThresholds are the percentages of a budget that notify when
+// a budget sets none.
+var DefaultBudgetThresholds = []int{80, 100}
+
+// CostsConfig holds the cost model used to price metered usage and the
+// monthly budgets spend is checked against.
+//
+//	costs:
+//	  prices:
+//	    - provider: claude
+//	      model: claude-sonnet-4
+//	      input: 3
+//	      output: 15
+//	    - provider: codex
+//	      model: "*"
+//	      input: 1.25
+//	      output: 10
+//	  budgets:
+//	    - provider: claude
+//	      profile: work
+//	      monthly_usd: 200
+//	    - provider: codex
+//	      monthly_usd: 50
+//	      thresholds: [50, 90, 100]
+type CostsConfig struct {
+	// Prices override the built-in per-model prices.
+	Prices []ModelPrice `yaml:"prices,omitempty"`
+
+	// Budgets cap monthly spend per profile, per provider, or overall.
+	Budgets []Budget `yaml:"budgets,omitempty"`
+}
+
+// ModelPrice is a model's price in dollars per million tokens.
+type ModelPrice struct {
+	Provider string `yaml:"provider"`
+
+	// Model is the model name; "*" prices every model of the provider that
+	// has no price of its own.
+	Model string `yaml:"model"`
+
+	Input       float64 `yaml:"input"`
+	Output      float64 `yaml:"output"`
+	CacheRead   float64 `yaml:"cache_read,omitempty"`
+	CacheCreate float64 `yaml:"cache_create,omitempty"`
+}
+
+// Budget is a monthly spend limit. Without a provider it covers all usage;
+// with a provider but no profile it covers the provider's profiles together.
+type Budget struct {
+	Provider   string  `yaml:"provider,omitempty"`
+	Profile    string  `yaml:"profile,omitempty"`
+	MonthlyUSD float64 `yaml:"monthly_usd"`
+
+	// Thresholds are the percentages of MonthlyUSD that notify when
+	// crossed. Default: DefaultBudgetThresholds
+	Thresholds []int `yaml:"thresholds,omitempty"`
+}
+
+// Name returns "all", "provider", or "provider/profile".
+func (b Budget) Name() string {
+	switch {
+	case b.Provider == "":
+		return "all"
+	case b.Profile == "":
+		return b.Provider
+	default:
+		return b.Provider + "/" + b.Profile
+	}
+}
+
+// Covers reports whether usage of provider/profile counts toward the budget.
+func (b Budget) Covers(provider, profile string) bool {
+	if b.Provider != "" && !strings.EqualFold(b.Provider, provider) {
+		return false
+	}
+	return b.Profile == "" || b.Profile == profile
+}
+
+// ThresholdList returns the budget's notification thresholds.
+func (b Budget) ThresholdList() []int {
+	if len(b.Thresholds) == 0 {
+		return DefaultBudgetThresholds
+	}
+	return b.Thresholds
+}
+
+// PriceTable returns the built-in prices with the configured ones applied.
+func (c CostsConfig) PriceTable() *pricing.Table {
+	t := pricing.NewTable()
+	for _, p := range c.Prices {
+		t.Set(p.Provider, p.Model, pricing.TokenPrice{
+			InputPer1M:       p.Input,
+			OutputPer1M:      p.Output,
+			CacheReadPer1M:   p.CacheRead,
+			CacheCreatePer1M: p.CacheCreate,
+		})
+	}
+	return t
+}
+
+// BudgetFor returns the most specific budget covering a profile: its own,
+// then its provider's, then the overall one. It returns nil when none does.
+func (c CostsConfig) BudgetFor(provider, profile string) *Budget {
+	var best *Budget
+	rank := func(b *Budget) int {
+		switch {
+		case b.Profile != "":
+			return 2
+		case b.Provider != "":
+			return 1
+		}
+		return 0
+	}
+	for i := range c.Budgets {
+		b := &c.Budgets[i]
+		if b.Covers(provider, profile) && (best == nil || rank(b) > rank(best)) {
+			best = b
+		}
+	}
+	return best
+}
+
+func validateCosts(c CostsConfig) error {
+	for i, p := range c.Prices {
+		field := fmt.Sprintf("costs.prices[%d]", i)
+		if strings.TrimSpace(p.Provider) == "" {
+			return fmt.Errorf("%s.provider is required", field)
+		}
+		if strings.TrimSpace(p.Model) == "" {
+			return fmt.Errorf("%s.model is required (use \"*\" for every model)", field)
+		}
+		if p.Input < 0 || p.Output < 0 || p.CacheRead < 0 || p.CacheCreate < 0 {
+			return fmt.Errorf("%s prices cannot be negative", field)
+		}
+	}
+	seen := make(map[string]bool)
+	for i, b := range c.Budgets {
+		field := fmt.Sprintf("costs.budgets[%d]", i)
+		if b.Profile != "" && b.Provider == "" {
+			return fmt.Errorf("%s.provider is required when profile is set", field)
+		}
+		if b.MonthlyUSD <= 0 {
+			return fmt.Errorf("%s.monthly_usd must be positive", field)
+		}
+		for _, pct := range b.Thresholds {
+			if pct <= 0 {
+				return fmt.Errorf("%s.thresholds must be positive percentages, got %d", field, pct)
+			}
+		}
+		name := strings.ToLower(b.Name())
+		if seen[name] {
+			return fmt.Errorf("%s duplicates the budget for %s", field, b.Name())
+		}
+		seen[name] = true
+	}
+	return nil
+}
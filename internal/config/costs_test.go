@@ -0,0 +1,89 @@
+package config
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBudgetFor(t *testing.T) {
+	cfg := CostsConfig{Budgets: []Budget{
+		{MonthlyUSD: 500},
+		{Provider: "claude", MonthlyUSD: 300},
+		{Provider: "claude", Profile: "work", MonthlyUSD: 200},
+	}}
+
+	tests := []struct {
+		provider, profile string
+		want              string
+	}{
+		{"claude", "work", "claude/work"},
+		{"Claude", "home", "claude"},
+		{"codex", "work", "all"},
+	}
+	for _, tt := range tests {
+		got := cfg.BudgetFor(tt.provider, tt.profile)
+		if got == nil || got.Name() != tt.want {
+			t.Errorf("BudgetFor(%q, %q) = %+v, want %s", tt.provider, tt.profile, got, tt.want)
+		}
+	}
+	if got := (CostsConfig{}).BudgetFor("claude", "work"); got != nil {
+		t.Errorf("BudgetFor without budgets = %+v, want nil", got)
+	}
+
+	if got := (Budget{MonthlyUSD: 1}).ThresholdList(); len(got) != 2 || got[0] != 80 || got[1] != 100 {
+		t.Errorf("default thresholds = %v", got)
+	}
+}
+
+func TestCostsPriceTable(t *testing.T) {
+	cfg := CostsConfig{Prices: []ModelPrice{
+		{Provider: "claude", Model: "claude-sonnet-4", Input: 1, Output: 2},
+		{Provider: "codex", Model: "*", Input: 10, Output: 10},
+	}}
+	table := cfg.PriceTable()
+
+	if cost, ok := table.TokenCost("claude", "claude-sonnet-4", 1_000_000, 1_000_000, 0, 0); !ok || math.Abs(cost-3) > 1e-9 {
+		t.Errorf("overridden model cost = %v, %v; want 3", cost, ok)
+	}
+	if cost, ok := table.TokenCost("codex", "some-internal-model", 500_000, 0, 0, 0); !ok || math.Abs(cost-5) > 1e-9 {
+		t.Errorf("provider fallback cost = %v, %v; want 5", cost, ok)
+	}
+	if _, ok := table.TokenCost("gemini", "some-internal-model", 1, 1, 0, 0); ok {
+		t.Error("unknown gemini model priced without a fallback")
+	}
+}
+
+func TestValidateCosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		costs   CostsConfig
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "valid", costs: CostsConfig{
+			Prices:  []ModelPrice{{Provider: "claude", Model: "*", Input: 3, Output: 15}},
+			Budgets: []Budget{{Provider: "claude", Profile: "work", MonthlyUSD: 100, Thresholds: []int{50, 100}}},
+		}},
+		{name: "price without model", costs: CostsConfig{Prices: []ModelPrice{{Provider: "claude"}}}, wantErr: "model is required"},
+		{name: "negative price", costs: CostsConfig{Prices: []ModelPrice{{Provider: "claude", Model: "x", Input: -1}}}, wantErr: "negative"},
+		{name: "profile without provider", costs: CostsConfig{Budgets: []Budget{{Profile: "work", MonthlyUSD: 1}}}, wantErr: "provider is required"},
+		{name: "zero budget", costs: CostsConfig{Budgets: []Budget{{Provider: "claude"}}}, wantErr: "must be positive"},
+		{name: "bad threshold", costs: CostsConfig{Budgets: []Budget{{Provider: "claude", MonthlyUSD: 1, Thresholds: []int{0}}}}, wantErr: "thresholds"},
+		{name: "duplicate", costs: CostsConfig{Budgets: []Budget{{Provider: "claude", MonthlyUSD: 1}, {Provider: "Claude", MonthlyUSD: 2}}}, wantErr: "duplicates"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCosts(tt.costs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
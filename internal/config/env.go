@@ -0,0 +1,196 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Config environments are named sets of caam configuration (config.json,
+// the SPM config.yaml, and sync state) kept side by side under
+// <config dir>/envs/<name>, so one machine can switch between, say, a work
+// setup with its coordinator and sync pool and a personal one.
+//
+// The environment in effect is CAAM_CONFIG_ENV if set (the --config-env
+// flag sets it, so child processes follow), otherwise the one recorded by
+// 'caam config env use'. With neither, caam uses its usual paths.
+
+const (
+	// ConfigEnvVar selects the config environment.
+	ConfigEnvVar = "CAAM_CONFIG_ENV"
+
+	// DefaultEnv names the usual, environment-less configuration.
+	DefaultEnv = "default"
+
+	envsDirName    = "envs"
+	currentEnvFile = "current"
+)
+
+var envNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ErrInvalidEnvName is returned for environment names that are not usable
+// as a directory name.
+var ErrInvalidEnvName = errors.New("environment names are 1-32 lowercase letters, digits, '-' or '_'")
+
+// ErrEnvNotFound is returned when selecting an environment that was never
+// created.
+var ErrEnvNotFound = errors.New("config environment not found")
+
+// ValidateEnvName checks that name is a usable environment name.
+func ValidateEnvName(name string) error {
+	if name == DefaultEnv || !envNameRe.MatchString(name) {
+		return fmt.Errorf("%q: %w", name, ErrInvalidEnvName)
+	}
+	return nil
+}
+
+// baseConfigDir returns caam's config directory, ignoring environments.
+func baseConfigDir() string {
+	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+		return filepath.Join(xdgConfig, "caam")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		// Fallback to current directory - unusual but handles edge cases
+		return filepath.Join(".config", "caam")
+	}
+	return filepath.Join(homeDir, ".config", "caam")
+}
+
+// EnvsDir returns the directory holding the config environments.
+func EnvsDir() string {
+	return filepath.Join(baseConfigDir(), envsDirName)
+}
+
+// EnvDir returns the directory of environment name.
+func EnvDir(name string) string {
+	return filepath.Join(EnvsDir(), name)
+}
+
+// ActiveEnv returns the config environment in effect, or "" for the
+// default configuration.
+func ActiveEnv() string {
+	name, ok := os.LookupEnv(ConfigEnvVar)
+	if !ok {
+		name = SelectedEnv()
+	}
+	name = strings.TrimSpace(name)
+	if name == DefaultEnv {
+		return ""
+	}
+	return name
+}
+
+// SelectedEnv returns the environment recorded by UseEnv, or "".
+func SelectedEnv() string {
+	data, err := os.ReadFile(filepath.Join(EnvsDir(), currentEnvFile))
+	if err != nil {
+		return ""
+	}
+	name := strings.TrimSpace(string(data))
+	if ValidateEnvName(name) != nil {
+		return ""
+	}
+	return name
+}
+
+// EnvExists reports whether environment name has been created.
+func EnvExists(name string) bool {
+	info, err := os.Stat(EnvDir(name))
+	return err == nil && info.IsDir()
+}
+
+// ListEnvs returns the created environments, sorted by name.
+func ListEnvs() ([]string, error) {
+	entries, err := os.ReadDir(EnvsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read config environments: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && ValidateEnvName(e.Name()) == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CreateEnv creates environment name. With copyCurrent, it starts from a
+// copy of the config.json and config.yaml in effect now; otherwise it
+// starts from defaults.
+func CreateEnv(name string, copyCurrent bool) (string, error) {
+	if err := ValidateEnvName(name); err != nil {
+		return "", err
+	}
+	dir := EnvDir(name)
+	if EnvExists(name) {
+		return "", fmt.Errorf("config environment %s already exists", name)
+	}
+
+	// Resolve the sources before the new directory exists.
+	sources := map[string]string{
+		"config.json": ConfigPath(),
+		"config.yaml": SPMConfigPath(),
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("create config environment %s: %w", name, err)
+	}
+	if !copyCurrent {
+		return dir, nil
+	}
+	for file, src := range sources {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", fmt.Errorf("copy %s: %w", src, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), data, 0600); err != nil {
+			return "", fmt.Errorf("copy %s: %w", src, err)
+		}
+	}
+	return dir, nil
+}
+
+// UseEnv records name as the environment to use when CAAM_CONFIG_ENV is
+// not set. "" or "default" goes back to the default configuration.
+func UseEnv(name string) error {
+	if name == "" {
+		name = DefaultEnv
+	}
+	if name != DefaultEnv {
+		if err := ValidateEnvName(name); err != nil {
+			return err
+		}
+		if !EnvExists(name) {
+			return fmt.Errorf("%s: %w", name, ErrEnvNotFound)
+		}
+	}
+	if err := os.MkdirAll(EnvsDir(), 0700); err != nil {
+		return fmt.Errorf("create environments dir: %w", err)
+	}
+	return os.WriteFile(filepath.Join(EnvsDir(), currentEnvFile), []byte(name+"\n"), 0600)
+}
+
+// EnterEnv makes name the config environment for this process and its
+// children. "default" selects the default configuration.
+func EnterEnv(name string) error {
+	if name != DefaultEnv {
+		if err := ValidateEnvName(name); err != nil {
+			return err
+		}
+		if !EnvExists(name) {
+			return fmt.Errorf("%s: %w", name, ErrEnvNotFound)
+		}
+	}
+	return os.Setenv(ConfigEnvVar, name)
+}
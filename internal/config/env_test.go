@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigEnvs(t *testing.T) {
+	xdg := t.TempDir()
+	caamHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("CAAM_HOME", caamHome)
+	t.Setenv(ConfigEnvVar, "")
+	os.Unsetenv(ConfigEnvVar)
+
+	if got := ConfigPath(); got != filepath.Join(xdg, "caam", "config.json") {
+		t.Fatalf("default ConfigPath = %s", got)
+	}
+	if err := os.WriteFile(SPMConfigPath(), []byte("language: de\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := CreateEnv("work", true)
+	if err != nil {
+		t.Fatalf("CreateEnv: %v", err)
+	}
+	if _, err := CreateEnv("work", false); err == nil {
+		t.Error("creating an existing environment succeeded")
+	}
+	if _, err := CreateEnv("Work!", false); !errors.Is(err, ErrInvalidEnvName) {
+		t.Errorf("CreateEnv with a bad name = %v", err)
+	}
+	if _, err := CreateEnv("personal", false); err != nil {
+		t.Fatalf("CreateEnv: %v", err)
+	}
+	if names, _ := ListEnvs(); len(names) != 2 || names[0] != "personal" || names[1] != "work" {
+		t.Errorf("ListEnvs = %v", names)
+	}
+
+	if err := UseEnv("missing"); !errors.Is(err, ErrEnvNotFound) {
+		t.Errorf("UseEnv(missing) = %v", err)
+	}
+	if err := UseEnv("work"); err != nil {
+		t.Fatalf("UseEnv: %v", err)
+	}
+	if ActiveEnv() != "work" || ConfigPath() != filepath.Join(dir, "config.json") || SPMConfigPath() != filepath.Join(dir, "config.yaml") {
+		t.Errorf("after use: env %q config %s spm %s", ActiveEnv(), ConfigPath(), SPMConfigPath())
+	}
+	spm, err := LoadSPMConfig()
+	if err != nil || spm.Language != "de" {
+		t.Errorf("copied SPM config language = %v, %v", spm, err)
+	}
+
+	// The environment variable overrides the recorded choice.
+	t.Setenv(ConfigEnvVar, "personal")
+	if ActiveEnv() != "personal" {
+		t.Errorf("ActiveEnv with %s=personal = %q", ConfigEnvVar, ActiveEnv())
+	}
+	if spm, _ := LoadSPMConfig(); spm.Language != "" {
+		t.Errorf("uncopied environment language = %q, want default", spm.Language)
+	}
+	t.Setenv(ConfigEnvVar, DefaultEnv)
+	if ActiveEnv() != "" || SPMConfigPath() != filepath.Join(caamHome, "config.yaml") {
+		t.Errorf("default override: env %q spm %s", ActiveEnv(), SPMConfigPath())
+	}
+
+	os.Unsetenv(ConfigEnvVar)
+	if err := UseEnv(DefaultEnv); err != nil || ActiveEnv() != "" {
+		t.Errorf("UseEnv(default) = %v, active %q", err, ActiveEnv())
+	}
+}
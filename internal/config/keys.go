@@ -0,0 +1,402 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
+)
+
+// Files a setting can live in.
+const (
+	FileConfigJSON = "config.json"
+	FileSPMYAML    = "config.yaml"
+)
+
+// Setting describes a key SetKey can change.
+type Setting struct {
+	// Key is the dotted path. Map entries use a <placeholder> segment,
+	// e.g. "plan_weights.<plan>".
+	Key string `json:"key"`
+
+	// Type is string, bool, int, float, duration, list, or risk_tier.
+	Type string `json:"type"`
+
+	// File is the file the setting is stored in.
+	File string `json:"file"`
+}
+
+// UnknownKeyError is returned for a key that names no setting.
+type UnknownKeyError struct {
+	Key         string
+	Suggestions []string
+}
+
+func (e *UnknownKeyError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("unknown config key: %s", e.Key)
+	}
+	return fmt.Sprintf("unknown config key: %s (did you mean %s?)", e.Key, strings.Join(e.Suggestions, ", "))
+}
+
+// keyAliases are older names still accepted for a key.
+var keyAliases = map[string]string{
+	"rotation_algorithm": "stealth.rotation.algorithm",
+	"algorithm":          "stealth.rotation.algorithm",
+}
+
+var (
+	durationType = reflect.TypeOf(Duration(0))
+	stdDuration  = reflect.TypeOf(time.Duration(0))
+	riskTierType = reflect.TypeOf(risk.Tier(""))
+)
+
+// Settings lists the keys SetKey accepts, sorted by key.
+func Settings() []Setting {
+	var out []Setting
+	collectSettings(reflect.TypeOf(Config{}), "json", "", FileConfigJSON, &out)
+	collectSettings(reflect.TypeOf(SPMConfig{}), "yaml", "", FileSPMYAML, &out)
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+func collectSettings(t reflect.Type, tag, prefix, file string, out *[]Setting) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := fieldKey(f, tag)
+		if !ok {
+			continue
+		}
+		key := joinKey(prefix, name)
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch {
+		case ft.Kind() == reflect.Struct:
+			collectSettings(ft, tag, key, file, out)
+		case ft.Kind() == reflect.Map && ft.Key().Kind() == reflect.String:
+			placeholder := joinKey(key, "<"+mapPlaceholder(name)+">")
+			elem := ft.Elem()
+			if elem.Kind() == reflect.Struct {
+				collectSettings(elem, tag, placeholder, file, out)
+			} else if typ := scalarType(elem); typ != "" {
+				*out = append(*out, Setting{Key: placeholder, Type: typ, File: file})
+			}
+		default:
+			if typ := scalarType(ft); typ != "" {
+				*out = append(*out, Setting{Key: key, Type: typ, File: file})
+			}
+		}
+	}
+}
+
+// mapPlaceholder names the key of a map setting for the schema.
+func mapPlaceholder(field string) string {
+	switch field {
+	case "automation", "default_profiles", "favorites", "subscriptions":
+		return "provider"
+	case "aliases", "profile_weights", "risk_tiers", "profiles":
+		return "provider/profile"
+	case "plan_weights":
+		return "plan"
+	case "workspaces":
+		return "workspace"
+	case "events":
+		return "event"
+	case "webhook_headers":
+		return "header"
+	default:
+		return "key"
+	}
+}
+
+func scalarType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType || t == stdDuration:
+		return "duration"
+	case t == riskTierType:
+		return "risk_tier"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			return "list"
+		}
+	}
+	return ""
+}
+
+func fieldKey(f reflect.StructField, tag string) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	name := strings.Split(f.Tag.Get(tag), ",")[0]
+	if name == "-" || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// SetKey sets the setting at the dotted key to value, parsed according to
+// the setting's type, in cfg (config.json) or spm (config.yaml). It returns
+// the canonical key, the file changed, and the value as stored. Nothing is
+// saved; config.yaml changes are validated.
+func SetKey(cfg *Config, spm *SPMConfig, key, value string) (string, string, interface{}, error) {
+	key = strings.TrimSpace(key)
+	if alias, ok := keyAliases[key]; ok {
+		key = alias
+	}
+	parts := strings.Split(key, ".")
+
+	var (
+		root reflect.Value
+		tag  string
+		file string
+	)
+	switch {
+	case hasTopLevel(reflect.TypeOf(Config{}), "json", parts[0]):
+		root, tag, file = reflect.ValueOf(cfg).Elem(), "json", FileConfigJSON
+	case hasTopLevel(reflect.TypeOf(SPMConfig{}), "yaml", parts[0]):
+		root, tag, file = reflect.ValueOf(spm).Elem(), "yaml", FileSPMYAML
+	default:
+		return key, "", nil, unknownKey(key)
+	}
+
+	stored, err := setPath(root, tag, parts, key, value)
+	if err != nil {
+		return key, file, nil, err
+	}
+	if file == FileSPMYAML {
+		if err := spm.Validate(); err != nil {
+			return key, file, nil, fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+	return key, file, stored, nil
+}
+
+func hasTopLevel(t reflect.Type, tag, name string) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if n, ok := fieldKey(t.Field(i), tag); ok && n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setPath walks v along parts and sets the value at the end.
+func setPath(v reflect.Value, tag string, parts []string, key, value string) (interface{}, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if len(parts) == 0 {
+			return nil, fmt.Errorf("%s is a section; set one of its keys", key)
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if name, ok := fieldKey(v.Type().Field(i), tag); ok && name == parts[0] {
+				return setPath(v.Field(i), tag, parts[1:], key, value)
+			}
+		}
+		return nil, unknownKey(key)
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || len(parts) == 0 {
+			return nil, fmt.Errorf("%s is a map; set an entry with %s.<key>", key, key)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		elemType := v.Type().Elem()
+		mapKey, rest := parts[0], parts[1:]
+		if elemType.Kind() != reflect.Struct {
+			// Scalar maps take the rest of the key, so entries like
+			// "claude/work" or dotted names work.
+			mapKey, rest = strings.Join(parts, "."), nil
+		}
+		elem := reflect.New(elemType).Elem()
+		if existing := v.MapIndex(reflect.ValueOf(mapKey).Convert(v.Type().Key())); existing.IsValid() {
+			elem.Set(existing)
+		}
+		stored, err := setPath(elem, tag, rest, key, value)
+		if err != nil {
+			return nil, err
+		}
+		v.SetMapIndex(reflect.ValueOf(mapKey).Convert(v.Type().Key()), elem)
+		return stored, nil
+	}
+
+	if len(parts) > 0 {
+		return nil, unknownKey(key)
+	}
+	if scalarType(v.Type()) == "" {
+		return nil, fmt.Errorf("%s can't be set from the command line; edit the file or use 'caam config apply'", key)
+	}
+	return setScalar(v, key, value)
+}
+
+func setScalar(v reflect.Value, key, value string) (interface{}, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case v.Type() == durationType || v.Type() == stdDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects a duration like 30s, 10m, or 2h; got %q", key, value)
+		}
+		v.SetInt(int64(d))
+		return d.String(), nil
+	case v.Type() == riskTierType:
+		tier, err := risk.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		v.SetString(string(tier))
+		return string(tier), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+		return value, nil
+	case reflect.Bool:
+		b, err := parseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects true or false; got %q", key, value)
+		}
+		v.SetBool(b)
+		return b, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return nil, fmt.Errorf("%s expects an integer; got %q", key, value)
+		}
+		v.SetInt(n)
+		return n, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return nil, fmt.Errorf("%s expects a non-negative integer; got %q", key, value)
+		}
+		v.SetUint(n)
+		return n, nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return nil, fmt.Errorf("%s expects a number; got %q", key, value)
+		}
+		v.SetFloat(f)
+		return f, nil
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			list.Index(i).SetString(item)
+		}
+		v.Set(list)
+		return items, nil
+	}
+	return nil, fmt.Errorf("%s can't be set from the command line", key)
+}
+
+func unknownKey(key string) error {
+	var keys []string
+	for _, s := range Settings() {
+		keys = append(keys, s.Key)
+	}
+	return &UnknownKeyError{Key: key, Suggestions: SuggestKeys(key, keys)}
+}
+
+// SuggestKeys returns up to five of keys that key was probably meant to
+// be: those sharing its last segment, then the closest by edit distance.
+func SuggestKeys(key string, keys []string) []string {
+	const maxSuggestions = 5
+	key = strings.ToLower(key)
+	last := key[strings.LastIndex(key, ".")+1:]
+
+	type candidate struct {
+		key  string
+		dist int
+	}
+	var cands []candidate
+	for _, k := range keys {
+		lk := strings.ToLower(k)
+		dist := editDistance(key, lk)
+		switch {
+		case strings.HasSuffix(lk, "."+last) || lk == last:
+			dist = 0
+		case strings.HasPrefix(lk, key+"."):
+			dist = 1
+		}
+		if limit := len(key)/3 + 1; dist <= limit {
+			cands = append(cands, candidate{k, dist})
+		}
+	}
+	sort.SliceStable(cands, func(i, j int) bool {
+		if cands[i].dist != cands[j].dist {
+			return cands[i].dist < cands[j].dist
+		}
+		return cands[i].key < cands[j].key
+	})
+	var out []string
+	for _, c := range cands {
+		if len(out) == maxSuggestions {
+			break
+		}
+		out = append(out, c.key)
+	}
+	return out
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
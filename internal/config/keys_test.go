@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
+)
+
+func TestSetKey(t *testing.T) {
+	cfg, spm := DefaultConfig(), DefaultSPMConfig()
+
+	tests := []struct {
+		key, value string
+		wantKey    string
+		wantFile   string
+	}{
+		{"stealth.rotation.algorithm", "round_robin", "stealth.rotation.algorithm", FileSPMYAML},
+		{"rotation_algorithm", "random", "stealth.rotation.algorithm", FileSPMYAML},
+		{"health.refresh_threshold", "5m", "health.refresh_threshold", FileSPMYAML},
+		{"analytics.retention_days", "30", "analytics.retention_days", FileSPMYAML},
+		{"automation.claude.auto_rotate", "false", "automation.claude.auto_rotate", FileSPMYAML},
+		{"notifications.enabled", "yes", "notifications.enabled", FileConfigJSON},
+		{"notifications.events.token_expiring", "false", "notifications.events.token_expiring", FileConfigJSON},
+		{"plan_weights.max", "2.5", "plan_weights.max", FileConfigJSON},
+		{"risk_tiers.claude/personal", "high", "risk_tiers.claude/personal", FileConfigJSON},
+		{"passthroughs", ".gitconfig, .npmrc", "passthroughs", FileConfigJSON},
+	}
+	for _, tt := range tests {
+		key, file, _, err := SetKey(cfg, spm, tt.key, tt.value)
+		if err != nil || key != tt.wantKey || file != tt.wantFile {
+			t.Errorf("SetKey(%s) = %s, %s, %v; want %s in %s", tt.key, key, file, err, tt.wantKey, tt.wantFile)
+		}
+	}
+
+	if spm.Stealth.Rotation.Algorithm != "random" || spm.Health.RefreshThreshold.Duration() != 5*time.Minute || spm.Analytics.RetentionDays != 30 {
+		t.Errorf("spm = %+v", spm)
+	}
+	if spm.AutomationEnabled("claude", AutomationRotate) {
+		t.Error("automation.claude.auto_rotate not disabled")
+	}
+	if !cfg.Notifications.Enabled || cfg.Notifications.EventEnabled(NotifyTokenExpiring) {
+		t.Errorf("notifications = %+v", cfg.Notifications)
+	}
+	if cfg.PlanWeights["max"] != 2.5 || cfg.GetRiskTier("claude", "personal") != risk.High || len(cfg.Passthroughs) != 2 {
+		t.Errorf("cfg = %+v", cfg)
+	}
+}
+
+func TestSetKeyErrors(t *testing.T) {
+	cfg, spm := DefaultConfig(), DefaultSPMConfig()
+
+	_, _, _, err := SetKey(cfg, spm, "stealth.rotaton.algorithm", "smart")
+	var unknown *UnknownKeyError
+	if !errors.As(err, &unknown) || len(unknown.Suggestions) == 0 || unknown.Suggestions[0] != "stealth.rotation.algorithm" {
+		t.Errorf("misspelled key error = %v", err)
+	}
+	if _, _, _, err := SetKey(cfg, spm, "nonsense", "1"); !errors.As(err, &unknown) {
+		t.Errorf("unknown top-level key error = %v", err)
+	}
+
+	for _, tt := range []struct{ key, value string }{
+		{"health.refresh_threshold", "soon"},
+		{"analytics.retention_days", "many"},
+		{"runtime.file_watching", "maybe"},
+		{"stealth.rotation.algorithm", "fastest"},
+		{"risk_tiers.claude/work", "extreme"},
+		{"health", "1"},
+		{"policies", "x"},
+	} {
+		if _, _, _, err := SetKey(cfg, spm, tt.key, tt.value); err == nil || errors.As(err, &unknown) {
+			t.Errorf("SetKey(%s, %s) error = %v, want a value error", tt.key, tt.value, err)
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	byKey := make(map[string]Setting)
+	for _, s := range Settings() {
+		byKey[s.Key] = s
+	}
+	for key, typ := range map[string]string{
+		"stealth.rotation.algorithm":             "string",
+		"health.refresh_threshold":               "duration",
+		"automation.<provider>.auto_rotate":      "bool",
+		"plan_weights.<plan>":                    "float",
+		"notifications.events.<event>":           "bool",
+		"wrap.max_retries":                       "int",
+		"risk_tiers.<provider/profile>":          "risk_tier",
+		"stealth.cooldown.default_minutes":       "int",
+		"notifications.webhook_headers.<header>": "string",
+	} {
+		if s, ok := byKey[key]; !ok || s.Type != typ {
+			t.Errorf("Settings()[%s] = %+v, want type %s", key, s, typ)
+		}
+	}
+	if _, ok := byKey["policies"]; ok {
+		t.Error("policies listed as settable")
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// LoggingConfig controls caam's own logs: JSON lines written per command to
+// logs/ in the data directory, read back with caam logs tail.
+//
+//	logging:
+//	  level: info
+//	  commands:
+//	    run: debug
+//	    robot next: warn
+//	  max_size_mb: 10
+//	  max_files: 3
+type LoggingConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Level is the minimum level written: debug, info, warn, or error.
+	// Environment override: CAAM_LOG_LEVEL, which also replaces Commands.
+	Level string `yaml:"level"`
+
+	// Commands overrides Level per command. Keys are command paths without
+	// "caam", such as "run" or "robot next"; the longest matching path wins.
+	Commands map[string]string `yaml:"commands,omitempty"`
+
+	// MaxSizeMB is how large a log file grows before it is rotated, and
+	// MaxFiles how many rotated files are kept per command.
+	MaxSizeMB int `yaml:"max_size_mb"`
+	MaxFiles  int `yaml:"max_files"`
+}
+
+// DefaultLoggingConfig returns the built-in logging settings.
+func DefaultLoggingConfig() LoggingConfig {
+	return LoggingConfig{
+		Enabled:   true,
+		Level:     "info",
+		MaxSizeMB: 10,
+		MaxFiles:  3,
+	}
+}
+
+// LevelFor returns the level for a command path such as "robot next".
+func (l LoggingConfig) LevelFor(command string) slog.Level {
+	words := strings.Fields(command)
+	for n := len(words); n > 0; n-- {
+		if s, ok := l.Commands[strings.Join(words[:n], " ")]; ok {
+			if level, err := parseLogLevel(s); err == nil {
+				return level
+			}
+		}
+	}
+	level, err := parseLogLevel(l.Level)
+	if err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if strings.TrimSpace(s) == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return slog.LevelInfo, err
+	}
+	return level, nil
+}
+
+func validateLogging(l LoggingConfig) error {
+	if _, err := parseLogLevel(l.Level); err != nil {
+		return fmt.Errorf("logging.level must be debug, info, warn, or error, got %q", l.Level)
+	}
+	for command, s := range l.Commands {
+		if _, err := parseLogLevel(s); err != nil {
+			return fmt.Errorf("logging.commands.%s must be debug, info, warn, or error, got %q", command, s)
+		}
+	}
+	if l.MaxSizeMB < 0 {
+		return fmt.Errorf("logging.max_size_mb cannot be negative")
+	}
+	if l.MaxFiles < 0 {
+		return fmt.Errorf("logging.max_files cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLoggingLevelFor(t *testing.T) {
+	l := LoggingConfig{
+		Level:    "warn",
+		Commands: map[string]string{"robot": "info", "robot next": "debug"},
+	}
+	tests := map[string]slog.Level{
+		"run":            slog.LevelWarn,
+		"robot":          slog.LevelInfo,
+		"robot status":   slog.LevelInfo,
+		"robot next":     slog.LevelDebug,
+		"robot next-all": slog.LevelInfo,
+		"":               slog.LevelWarn,
+	}
+	for command, want := range tests {
+		if got := l.LevelFor(command); got != want {
+			t.Errorf("LevelFor(%q) = %v, want %v", command, got, want)
+		}
+	}
+	if got := (LoggingConfig{}).LevelFor("run"); got != slog.LevelInfo {
+		t.Errorf("LevelFor with no level = %v, want info", got)
+	}
+}
+
+func TestValidateLogging(t *testing.T) {
+	tests := []struct {
+		name string
+		l    LoggingConfig
+		want string
+	}{
+		{"default", DefaultLoggingConfig(), ""},
+		{"bad level", LoggingConfig{Level: "loud"}, "logging.level"},
+		{"bad command level", LoggingConfig{Commands: map[string]string{"run": "loud"}}, "logging.commands.run"},
+		{"negative size", LoggingConfig{MaxSizeMB: -1}, "max_size_mb"},
+		{"negative files", LoggingConfig{MaxFiles: -1}, "max_files"},
+	}
+	for _, tt := range tests {
+		err := validateLogging(tt.l)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want one mentioning %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestLoggingEnvOverride(t *testing.T) {
+	t.Setenv("CAAM_LOG_LEVEL", "debug")
+	c := DefaultSPMConfig()
+	c.Logging.Commands = map[string]string{"run": "error"}
+	c.ApplyEnvOverrides()
+	if got := c.Logging.LevelFor("run"); got != slog.LevelDebug {
+		t.Errorf("LevelFor(run) with CAAM_LOG_LEVEL=debug = %v, want debug", got)
+	}
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// NetworkConfig sets the proxy and user agent caam launch gives a
+// profile's sandbox. The top-level values apply to every profile; Profiles
+// overrides them per "provider/profile".
+//
+//	stealth:
+//	  network:
+//	    proxy: http://127.0.0.1:8080
+//	    profiles:
+//	      claude/work:
+//	        proxy: socks5://10.0.0.2:1080
+//	        user_agent: Mozilla/5.0 (X11; Linux x86_64)
+type NetworkConfig struct {
+	Proxy     string `yaml:"proxy,omitempty"`
+	NoProxy   string `yaml:"no_proxy,omitempty"`
+	UserAgent string `yaml:"user_agent,omitempty"`
+
+	Profiles map[string]NetworkSettings `yaml:"profiles,omitempty"`
+}
+
+// NetworkSettings are one profile's network settings.
+type NetworkSettings struct {
+	// Proxy is the HTTP(S) proxy URL, exported as HTTP_PROXY and
+	// HTTPS_PROXY.
+	Proxy string `yaml:"proxy,omitempty"`
+
+	// NoProxy lists hosts that bypass Proxy, exported as NO_PROXY.
+	NoProxy string `yaml:"no_proxy,omitempty"`
+
+	// UserAgent is exported as CAAM_USER_AGENT for tools and wrappers
+	// that read it.
+	UserAgent string `yaml:"user_agent,omitempty"`
+}
+
+// For returns the network settings for a profile, with its overrides
+// applied on top of the defaults.
+func (n NetworkConfig) For(provider, profile string) NetworkSettings {
+	out := NetworkSettings{Proxy: n.Proxy, NoProxy: n.NoProxy, UserAgent: n.UserAgent}
+	o, ok := n.Profiles[provider+"/"+profile]
+	if !ok {
+		return out
+	}
+	if o.Proxy != "" {
+		out.Proxy = o.Proxy
+	}
+	if o.NoProxy != "" {
+		out.NoProxy = o.NoProxy
+	}
+	if o.UserAgent != "" {
+		out.UserAgent = o.UserAgent
+	}
+	return out
+}
+
+// Env returns the environment variables that apply the settings. Both
+// upper and lower case proxy variables are set, since tools disagree on
+// which they read.
+func (s NetworkSettings) Env() map[string]string {
+	env := make(map[string]string)
+	if s.Proxy != "" {
+		for _, k := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+			env[k] = s.Proxy
+		}
+	}
+	if s.NoProxy != "" {
+		env["NO_PROXY"] = s.NoProxy
+		env["no_proxy"] = s.NoProxy
+	}
+	if s.UserAgent != "" {
+		env["CAAM_USER_AGENT"] = s.UserAgent
+	}
+	return env
+}
+
+func validateNetwork(n NetworkConfig) error {
+	if err := validateNetworkSettings("stealth.network", NetworkSettings{Proxy: n.Proxy}); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(n.Profiles))
+	for k := range n.Profiles {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		provider, profile, ok := strings.Cut(k, "/")
+		if !ok || provider == "" || profile == "" {
+			return fmt.Errorf("stealth.network.profiles: %q must be provider/profile", k)
+		}
+		if err := validateNetworkSettings("stealth.network.profiles."+k, n.Profiles[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateNetworkSettings(key string, s NetworkSettings) error {
+	if s.Proxy == "" {
+		return nil
+	}
+	u, err := url.Parse(s.Proxy)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%s.proxy must be a URL like http://host:port", key)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return nil
+	default:
+		return fmt.Errorf("%s.proxy scheme must be http, https, socks5 or socks5h", key)
+	}
+}
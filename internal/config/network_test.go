@@ -0,0 +1,62 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNetworkConfigFor(t *testing.T) {
+	n := NetworkConfig{
+		Proxy:   "http://default:8080",
+		NoProxy: "localhost",
+		Profiles: map[string]NetworkSettings{
+			"claude/work": {Proxy: "socks5://work:1080", UserAgent: "work-agent"},
+		},
+	}
+
+	got := n.For("claude", "work")
+	want := NetworkSettings{Proxy: "socks5://work:1080", NoProxy: "localhost", UserAgent: "work-agent"}
+	if got != want {
+		t.Errorf("For(claude, work) = %+v, want %+v", got, want)
+	}
+	if got := n.For("codex", "work"); got.Proxy != "http://default:8080" || got.UserAgent != "" {
+		t.Errorf("For(codex, work) = %+v, want the defaults", got)
+	}
+
+	env := got.Env()
+	for _, k := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+		if env[k] != "socks5://work:1080" {
+			t.Errorf("%s = %q", k, env[k])
+		}
+	}
+	if env["NO_PROXY"] != "localhost" || env["CAAM_USER_AGENT"] != "work-agent" {
+		t.Errorf("env = %v", env)
+	}
+	if env := (NetworkSettings{}).Env(); len(env) != 0 {
+		t.Errorf("empty settings env = %v", env)
+	}
+}
+
+func TestValidateNetwork(t *testing.T) {
+	tests := []struct {
+		name string
+		n    NetworkConfig
+		want string
+	}{
+		{"empty", NetworkConfig{}, ""},
+		{"http", NetworkConfig{Proxy: "http://127.0.0.1:8080"}, ""},
+		{"no host", NetworkConfig{Proxy: "127.0.0.1:8080"}, "must be a URL"},
+		{"bad scheme", NetworkConfig{Proxy: "ftp://proxy:21"}, "scheme"},
+		{"bad key", NetworkConfig{Profiles: map[string]NetworkSettings{"work": {}}}, "provider/profile"},
+		{"bad profile proxy", NetworkConfig{Profiles: map[string]NetworkSettings{"claude/work": {Proxy: "nope"}}}, "claude/work.proxy"},
+	}
+	for _, tt := range tests {
+		err := validateNetwork(tt.n)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: error = %v, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+}
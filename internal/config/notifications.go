@@ -0,0 +1,85 @@
+package config
+
+import "time"
+
+// Notification events.
+const (
+	// NotifyAllBlocked fires when every profile of a provider is in cooldown
+	// or revoked.
+	NotifyAllBlocked = "all_blocked"
+
+	// NotifyTokenExpiring fires when a profile's token expires within
+	// NotificationsConfig.ExpiryWarning.
+	NotifyTokenExpiring = "token_expiring"
+
+	// NotifyCooldownStarted fires when a profile is put into cooldown.
+	NotifyCooldownStarted = "cooldown_started"
+
+	// NotifySyncFailed fires when a sync with a machine fails.
+	NotifySyncFailed = "sync_failed"
+
+	// NotifyBudgetThreshold fires when a month's spend crosses one of a
+	// budget's thresholds (costs.budgets in config.yaml).
+	NotifyBudgetThreshold = "budget_threshold"
+)
+
+// NotifyEvents lists every notification event, in the order they are
+// documented.
+func NotifyEvents() []string {
+	return []string{NotifyAllBlocked, NotifyTokenExpiring, NotifyCooldownStarted, NotifySyncFailed, NotifyBudgetThreshold}
+}
+
+// NotificationsConfig sends notifications on key events from the daemon.
+type NotificationsConfig struct {
+	// Enabled turns notifications on. Default: false
+	Enabled bool `json:"enabled"`
+
+	// Webhook is a URL that receives each notification as a JSON POST.
+	Webhook string `json:"webhook,omitempty"`
+
+	// WebhookHeaders are extra headers sent with each webhook request,
+	// e.g. {"Authorization": "Bearer ..."}.
+	WebhookHeaders map[string]string `json:"webhook_headers,omitempty"`
+
+	// Slack is a Slack incoming webhook URL.
+	Slack string `json:"slack,omitempty"`
+
+	// Desktop shows desktop notifications (notify-send or osascript).
+	Desktop bool `json:"desktop"`
+
+	// Events enables or disables individual events. Events without an
+	// entry are enabled.
+	// Example: {"token_expiring": false}
+	Events map[string]bool `json:"events,omitempty"`
+
+	// ExpiryWarning is how long before a token expires to warn.
+	// Default: 2h
+	ExpiryWarning Duration `json:"expiry_warning,omitempty"`
+
+	// RateLimit is the minimum time between notifications for the same
+	// event and profile. Default: 1h
+	RateLimit Duration `json:"rate_limit,omitempty"`
+}
+
+// EventEnabled reports whether notifications for event are enabled.
+func (c NotificationsConfig) EventEnabled(event string) bool {
+	enabled, ok := c.Events[event]
+	return !ok || enabled
+}
+
+// GetExpiryWarning returns the expiry warning window, using the default if
+// not set.
+func (c NotificationsConfig) GetExpiryWarning() time.Duration {
+	if c.ExpiryWarning <= 0 {
+		return 2 * time.Hour
+	}
+	return c.ExpiryWarning.Duration()
+}
+
+// GetRateLimit returns the rate limit window, using the default if not set.
+func (c NotificationsConfig) GetRateLimit() time.Duration {
+	if c.RateLimit <= 0 {
+		return time.Hour
+	}
+	return c.RateLimit.Duration()
+}
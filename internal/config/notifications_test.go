@@ -0,0 +1,29 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNotificationsConfig(t *testing.T) {
+	var cfg Config
+	raw := `{"notifications": {"enabled": true, "slack": "https://hooks.slack.com/x", "events": {"token_expiring": false}, "rate_limit": "15m"}}`
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	n := cfg.Notifications
+
+	if n.EventEnabled(NotifyTokenExpiring) {
+		t.Error("token_expiring should be disabled")
+	}
+	if !n.EventEnabled(NotifyAllBlocked) {
+		t.Error("events without an entry should be enabled")
+	}
+	if got := n.GetRateLimit(); got != 15*time.Minute {
+		t.Errorf("GetRateLimit() = %v, want 15m", got)
+	}
+	if got := n.GetExpiryWarning(); got != 2*time.Hour {
+		t.Errorf("GetExpiryWarning() = %v, want default 2h", got)
+	}
+}
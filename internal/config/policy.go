@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RotationPolicy rotates a provider's active profile automatically, either
+// on a schedule or when a condition on the active profile holds. Policies
+// are applied by `caam rotate --apply-policies` and by the daemon.
+//
+//	policies:
+//	  - name: claude-shifts
+//	    provider: claude
+//	    every: 4h
+//	    algorithm: round_robin
+//	  - provider: codex
+//	    when: error_count_1h >= 3
+type RotationPolicy struct {
+	// Name identifies the policy in logs. Defaults to "<provider>-every" or
+	// "<provider>-when".
+	Name string `yaml:"name,omitempty"`
+
+	// Provider is the tool whose active profile is rotated.
+	Provider string `yaml:"provider"`
+
+	// Every rotates once the active profile has been active this long.
+	Every Duration `yaml:"every,omitempty"`
+
+	// When rotates as soon as the condition holds for the active profile,
+	// e.g. "error_count_1h >= 3". See PolicyMetrics.
+	When string `yaml:"when,omitempty"`
+
+	// Algorithm picks the next profile: "smart", "round_robin", or
+	// "random". Defaults to stealth.rotation.algorithm.
+	Algorithm string `yaml:"algorithm,omitempty"`
+}
+
+// PolicyName returns the policy's name, or a default derived from its
+// provider and trigger.
+func (p RotationPolicy) PolicyName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	if p.When != "" {
+		return p.Provider + "-when"
+	}
+	return p.Provider + "-every"
+}
+
+// PolicyMetrics lists the values a policy condition can test.
+var PolicyMetrics = map[string]string{
+	"error_count_1h":    "errors recorded in the last hour",
+	"penalty":           "current health penalty",
+	"token_expires_min": "minutes until the token expires",
+	"usage_pct":         "primary rate-limit window used, from the last cached reading",
+}
+
+// PolicyCondition is a parsed "metric op value" condition.
+type PolicyCondition struct {
+	Metric string
+	Op     string
+	Value  float64
+}
+
+// ParsePolicyCondition parses a condition such as "error_count_1h >= 3".
+// Supported operators are >=, <=, >, <, and ==.
+func ParsePolicyCondition(s string) (PolicyCondition, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 3 {
+		return PolicyCondition{}, fmt.Errorf("condition %q must be \"<metric> <op> <number>\"", s)
+	}
+	cond := PolicyCondition{Metric: fields[0], Op: fields[1]}
+	if _, ok := PolicyMetrics[cond.Metric]; !ok {
+		return PolicyCondition{}, fmt.Errorf("unknown metric %q (supported: error_count_1h, penalty, token_expires_min, usage_pct)", cond.Metric)
+	}
+	switch cond.Op {
+	case ">=", "<=", ">", "<", "==":
+	default:
+		return PolicyCondition{}, fmt.Errorf("unknown operator %q (supported: >=, <=, >, <, ==)", cond.Op)
+	}
+	v, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return PolicyCondition{}, fmt.Errorf("invalid value %q in condition", fields[2])
+	}
+	cond.Value = v
+	return cond, nil
+}
+
+// Holds reports whether v satisfies the condition.
+func (c PolicyCondition) Holds(v float64) bool {
+	switch c.Op {
+	case ">=":
+		return v >= c.Value
+	case "<=":
+		return v <= c.Value
+	case ">":
+		return v > c.Value
+	case "<":
+		return v < c.Value
+	case "==":
+		return v == c.Value
+	default:
+		return false
+	}
+}
+
+func (c PolicyCondition) String() string {
+	return fmt.Sprintf("%s %s %s", c.Metric, c.Op, strconv.FormatFloat(c.Value, 'f', -1, 64))
+}
+
+func validatePolicies(policies []RotationPolicy) error {
+	validAlgorithms := map[string]bool{"smart": true, "round_robin": true, "random": true}
+	for i, p := range policies {
+		field := fmt.Sprintf("policies[%d]", i)
+		if strings.TrimSpace(p.Provider) == "" {
+			return fmt.Errorf("%s.provider is required", field)
+		}
+		if p.Every.Duration() < 0 {
+			return fmt.Errorf("%s.every cannot be negative", field)
+		}
+		if (p.Every.Duration() > 0) == (p.When != "") {
+			return fmt.Errorf("%s must set exactly one of every or when", field)
+		}
+		if p.When != "" {
+			if _, err := ParsePolicyCondition(p.When); err != nil {
+				return fmt.Errorf("%s.when: %w", field, err)
+			}
+		}
+		if p.Algorithm != "" && !validAlgorithms[p.Algorithm] {
+			return fmt.Errorf("%s.algorithm must be one of: smart, round_robin, random", field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ScoringConfig tunes how robot next and robot precheck score profiles.
+// Weights replace the built-in points; Script, if set, is run after the
+// built-in scoring and can adjust any candidate's score.
+//
+//	scoring:
+//	  weights:
+//	    cooldown: -300
+//	    recent_use: -80
+//	  recent_use_window: 30m
+//	  script: ~/.config/caam/score.py
+//	  script_timeout: 5s
+type ScoringConfig struct {
+	Weights ScoringWeights `yaml:"weights"`
+
+	// RecentUseWindow is how recently a profile must have been activated
+	// for Weights.RecentUse to apply.
+	RecentUseWindow Duration `yaml:"recent_use_window"`
+
+	// Script is a command that reads the scored candidates as JSON on
+	// stdin and prints score adjustments as JSON on stdout.
+	Script string `yaml:"script,omitempty"`
+
+	// ScriptTimeout bounds how long Script may run before its adjustments
+	// are ignored.
+	ScriptTimeout Duration `yaml:"script_timeout"`
+}
+
+// ScoringWeights are the points each scoring factor contributes.
+type ScoringWeights struct {
+	Healthy  float64 `yaml:"healthy"`
+	Warning  float64 `yaml:"warning"`
+	Critical float64 `yaml:"critical"`
+	Unknown  float64 `yaml:"unknown"`
+
+	// Cooldown applies to profiles in cooldown (with --include-cooldown).
+	Cooldown float64 `yaml:"cooldown"`
+
+	// Error applies once per error in the last hour.
+	Error float64 `yaml:"error"`
+
+	TokenLongLived float64 `yaml:"token_long_lived"` // valid for more than 7 days
+	TokenValid     float64 `yaml:"token_valid"`      // valid for more than a day
+	TokenExpiring  float64 `yaml:"token_expiring"`   // valid for less than a day
+	TokenExpired   float64 `yaml:"token_expired"`
+
+	StaleVsPool float64 `yaml:"stale_vs_pool"`
+
+	RiskHigh       float64 `yaml:"risk_high"`
+	RiskExpendable float64 `yaml:"risk_expendable"`
+
+	// RecentUse applies to profiles activated within RecentUseWindow.
+	RecentUse float64 `yaml:"recent_use"`
+
+	// NearLimit and DepletesInSession come from usage forecasts and are
+	// applied by robot precheck: NearLimit to profiles with 80% or more of
+	// their limit used, DepletesInSession to profiles expected to hit their
+	// limit before the planned session ends.
+	NearLimit         float64 `yaml:"near_limit"`
+	DepletesInSession float64 `yaml:"depletes_in_session"`
+}
+
+// DefaultScoringConfig returns the built-in scoring.
+func DefaultScoringConfig() ScoringConfig {
+	return ScoringConfig{
+		Weights: ScoringWeights{
+			Healthy:        100,
+			Warning:        50,
+			Critical:       10,
+			Unknown:        30,
+			Cooldown:       -200,
+			Error:          -10,
+			TokenLongLived: 20,
+			TokenValid:     10,
+			TokenExpiring:  -20,
+			TokenExpired:   -100,
+			StaleVsPool:    -30,
+			RiskHigh:       -150,
+			RiskExpendable: 40,
+			RecentUse:      0, // Opt-in
+
+			NearLimit:         -40,
+			DepletesInSession: -80,
+		},
+		RecentUseWindow: Duration(30 * time.Minute),
+		ScriptTimeout:   Duration(5 * time.Second),
+	}
+}
+
+func validateScoring(s ScoringConfig) error {
+	if s.RecentUseWindow.Duration() < 0 {
+		return fmt.Errorf("scoring.recent_use_window cannot be negative")
+	}
+	if s.ScriptTimeout.Duration() < 0 {
+		return fmt.Errorf("scoring.script_timeout cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShapingConfig makes rotation follow a person's rhythm instead of a
+// machine's: a profile stays active for a while before caam rotates away
+// from it, cooldowns vary in length, and profiles are only picked during
+// their active hours and a few times a day. Shaping is enforced by robot
+// next, rotation policies, and robot act activate.
+//
+//	stealth:
+//	  shaping:
+//	    enabled: true
+//	    min_dwell: 45m
+//	    cooldown_jitter: 0.2
+//	    active_hours: 08:30-12:00,13:00-19:00
+//	    max_activations_per_day: 6
+//	    profiles:
+//	      claude/night:
+//	        active_hours: 20:00-02:00
+type ShapingConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// MinDwell is how long a profile stays active before caam rotates
+	// away from it.
+	MinDwell Duration `yaml:"min_dwell"`
+
+	// CooldownJitter lengthens each cooldown by a random fraction of it,
+	// up to this much: 0.2 adds 0-20%.
+	CooldownJitter float64 `yaml:"cooldown_jitter"`
+
+	// ActiveHours lists the local times profiles may be activated, as
+	// comma-separated HH:MM-HH:MM windows. A window may cross midnight.
+	// Empty means any time.
+	ActiveHours string `yaml:"active_hours,omitempty"`
+
+	// MaxActivationsPerDay caps how often a profile is activated per
+	// calendar day. 0 means no cap.
+	MaxActivationsPerDay int `yaml:"max_activations_per_day"`
+
+	// Profiles overrides the rules per "provider/profile".
+	Profiles map[string]ShapingRules `yaml:"profiles,omitempty"`
+}
+
+// ShapingRules are the shaping rules for one profile. Zero values in a
+// per-profile override inherit the top-level rule.
+type ShapingRules struct {
+	MinDwell             Duration `yaml:"min_dwell,omitempty"`
+	ActiveHours          string   `yaml:"active_hours,omitempty"`
+	MaxActivationsPerDay int      `yaml:"max_activations_per_day,omitempty"`
+}
+
+// DefaultShapingConfig returns shaping, off.
+func DefaultShapingConfig() ShapingConfig {
+	return ShapingConfig{
+		Enabled:        false, // Opt-in
+		MinDwell:       Duration(30 * time.Minute),
+		CooldownJitter: 0.15,
+	}
+}
+
+// For returns the rules for a profile, with its overrides applied.
+func (s ShapingConfig) For(provider, profile string) ShapingRules {
+	rules := ShapingRules{
+		MinDwell:             s.MinDwell,
+		ActiveHours:          s.ActiveHours,
+		MaxActivationsPerDay: s.MaxActivationsPerDay,
+	}
+	o, ok := s.Profiles[provider+"/"+profile]
+	if !ok {
+		return rules
+	}
+	if o.MinDwell != 0 {
+		rules.MinDwell = o.MinDwell
+	}
+	if o.ActiveHours != "" {
+		rules.ActiveHours = o.ActiveHours
+	}
+	if o.MaxActivationsPerDay != 0 {
+		rules.MaxActivationsPerDay = o.MaxActivationsPerDay
+	}
+	return rules
+}
+
+// HourWindow is a daily window of local time, in minutes since midnight.
+// End before Start means the window crosses midnight.
+type HourWindow struct {
+	Start, End int
+}
+
+// Contains reports whether t's time of day is in the window.
+func (w HourWindow) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.Start <= w.End {
+		return m >= w.Start && m < w.End
+	}
+	return m >= w.Start || m < w.End
+}
+
+func (w HourWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// ParseActiveHours parses comma-separated HH:MM-HH:MM windows.
+func ParseActiveHours(s string) ([]HourWindow, error) {
+	var windows []HourWindow
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("window %q must be HH:MM-HH:MM", part)
+		}
+		start, err := parseClock(from)
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %w", part, err)
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %w", part, err)
+		}
+		if start == end {
+			return nil, fmt.Errorf("window %q is empty", part)
+		}
+		windows = append(windows, HourWindow{Start: start, End: end})
+	}
+	return windows, nil
+}
+
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(strings.TrimSpace(s), ":")
+	hour, herr := strconv.Atoi(h)
+	minute, merr := strconv.Atoi(m)
+	if !ok || herr != nil || merr != nil || hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("%q is not a time of day", s)
+	}
+	return hour*60 + minute, nil
+}
+
+func validateShaping(s ShapingConfig) error {
+	if s.MinDwell.Duration() < 0 {
+		return fmt.Errorf("stealth.shaping.min_dwell cannot be negative")
+	}
+	if s.CooldownJitter < 0 || s.CooldownJitter > 1 {
+		return fmt.Errorf("stealth.shaping.cooldown_jitter must be between 0 and 1")
+	}
+	if err := validateShapingRules("stealth.shaping", ShapingRules{ActiveHours: s.ActiveHours, MaxActivationsPerDay: s.MaxActivationsPerDay}); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(s.Profiles))
+	for k := range s.Profiles {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if provider, profile, ok := strings.Cut(k, "/"); !ok || provider == "" || profile == "" {
+			return fmt.Errorf("stealth.shaping.profiles: %q must be provider/profile", k)
+		}
+		if err := validateShapingRules("stealth.shaping.profiles."+k, s.Profiles[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateShapingRules(key string, r ShapingRules) error {
+	if r.MinDwell.Duration() < 0 {
+		return fmt.Errorf("%s.min_dwell cannot be negative", key)
+	}
+	if r.MaxActivationsPerDay < 0 {
+		return fmt.Errorf("%s.max_activations_per_day cannot be negative", key)
+	}
+	if _, err := ParseActiveHours(r.ActiveHours); err != nil {
+		return fmt.Errorf("%s.active_hours: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShapingConfigFor(t *testing.T) {
+	s := ShapingConfig{
+		MinDwell:             Duration(30 * time.Minute),
+		ActiveHours:          "08:00-18:00",
+		MaxActivationsPerDay: 4,
+		Profiles: map[string]ShapingRules{
+			"claude/night": {ActiveHours: "20:00-02:00"},
+		},
+	}
+
+	got := s.For("claude", "night")
+	want := ShapingRules{MinDwell: Duration(30 * time.Minute), ActiveHours: "20:00-02:00", MaxActivationsPerDay: 4}
+	if got != want {
+		t.Errorf("For(claude, night) = %+v, want %+v", got, want)
+	}
+	if got := s.For("claude", "work"); got.ActiveHours != "08:00-18:00" {
+		t.Errorf("For(claude, work) = %+v, want the defaults", got)
+	}
+}
+
+func TestParseActiveHours(t *testing.T) {
+	windows, err := ParseActiveHours(" 08:30-12:00, 22:00-24:00 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 2 || windows[0] != (HourWindow{Start: 510, End: 720}) || windows[1].String() != "22:00-24:00" {
+		t.Errorf("windows = %+v", windows)
+	}
+	if windows, err := ParseActiveHours(""); err != nil || windows != nil {
+		t.Errorf("empty = %+v, %v", windows, err)
+	}
+	for _, bad := range []string{"8-12", "08:00", "25:00-26:00", "09:60-10:00", "10:00-10:00"} {
+		if _, err := ParseActiveHours(bad); err == nil {
+			t.Errorf("ParseActiveHours(%q) did not fail", bad)
+		}
+	}
+}
+
+func TestValidateShaping(t *testing.T) {
+	tests := []struct {
+		name string
+		s    ShapingConfig
+		want string
+	}{
+		{"default", DefaultShapingConfig(), ""},
+		{"jitter", ShapingConfig{CooldownJitter: 1.5}, "cooldown_jitter"},
+		{"negative dwell", ShapingConfig{MinDwell: Duration(-time.Minute)}, "min_dwell"},
+		{"bad hours", ShapingConfig{ActiveHours: "nine-five"}, "stealth.shaping.active_hours"},
+		{"negative cap", ShapingConfig{MaxActivationsPerDay: -1}, "max_activations_per_day"},
+		{"bad key", ShapingConfig{Profiles: map[string]ShapingRules{"work": {}}}, "provider/profile"},
+		{"bad profile hours", ShapingConfig{Profiles: map[string]ShapingRules{"claude/work": {ActiveHours: "x"}}}, "claude/work.active_hours"},
+	}
+	for _, tt := range tests {
+		err := validateShaping(tt.s)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: error = %v, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+}
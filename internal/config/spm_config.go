@@ -6,10 +6,15 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/i18n"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"gopkg.in/yaml.v3"
 )
 
@@ -31,6 +36,18 @@ type SPMConfig struct {
 	Daemon              DaemonConfig                 `yaml:"daemon"`
 	TUI                 TUIConfig                    `yaml:"tui"`
 	CompactionReminder  CompactionReminderConfig     `yaml:"compaction_reminder"`
+	Injections          InjectionsConfig             `yaml:"injections"`
+	Automation          map[string]ProviderAutomation `yaml:"automation,omitempty"`
+	Policies            []RotationPolicy             `yaml:"policies,omitempty"`
+	Costs               CostsConfig                  `yaml:"costs,omitempty"`
+	Scoring             ScoringConfig                `yaml:"scoring"`
+	Logging             LoggingConfig                `yaml:"logging"`
+	Tracing             TracingConfig                `yaml:"tracing"`
+
+	// Language selects the language of human-readable output: "en", "de",
+	// "ja", or "zh". Empty follows LC_ALL/LC_MESSAGES/LANG.
+	// Environment override: CAAM_LANG
+	Language string `yaml:"language,omitempty"`
 }
 
 // TUIConfig holds TUI appearance and behavior preferences.
@@ -106,6 +123,8 @@ type StealthConfig struct {
 	SwitchDelay SwitchDelayConfig `yaml:"switch_delay"`
 	Cooldown    CooldownConfig    `yaml:"cooldown"`
 	Rotation    RotationConfig    `yaml:"rotation"`
+	Network     NetworkConfig     `yaml:"network"`
+	Shaping     ShapingConfig     `yaml:"shaping"`
 }
 
 // SwitchDelayConfig controls delays before profile switches complete.
@@ -123,6 +142,9 @@ type CooldownConfig struct {
 	Enabled        bool `yaml:"enabled"`          // Master switch for cooldown feature
 	DefaultMinutes int  `yaml:"default_minutes"`  // Default cooldown duration
 	TrackLimitHits bool `yaml:"track_limit_hits"` // Auto-track when limits are detected
+
+	// Templates set the cooldown per provider and plan when none is given.
+	Templates []CooldownTemplate `yaml:"templates,omitempty"`
 }
 
 // RotationConfig controls smart profile selection algorithms.
@@ -137,7 +159,8 @@ type RotationConfig struct {
 type SafetyConfig struct {
 	// AutoBackupBeforeSwitch controls when backups are made before profile switches.
 	// "always": Backup before every switch
-	// "smart": Backup only if current state doesn't match any vault profile (default)
+	// "smart": Backup only if current state doesn't match any vault profile (default);
+	//          a refreshed token of the last activated profile is saved back to it
 	// "never": No automatic backups (not recommended)
 	AutoBackupBeforeSwitch string `yaml:"auto_backup_before_switch"`
 
@@ -194,6 +217,11 @@ type ProviderLoginPatterns struct {
 type SubscriptionConfig struct {
 	Plan        string  `yaml:"plan"`         // e.g., "max", "pro", "free"
 	MonthlyCost float64 `yaml:"monthly_cost"` // Monthly cost in USD
+
+	// TokenLimit is the plan's token allowance per LimitWindow. Together
+	// they let usage forecasts turn a burn rate into a depletion time.
+	TokenLimit  int64    `yaml:"token_limit,omitempty"`
+	LimitWindow Duration `yaml:"limit_window,omitempty"`
 }
 
 // DaemonConfig holds daemon-specific settings.
@@ -202,6 +230,99 @@ type DaemonConfig struct {
 	CheckInterval    Duration       `yaml:"check_interval"`
 	RefreshThreshold Duration       `yaml:"refresh_threshold"`
 	Verbose          bool           `yaml:"verbose"`
+
+	// AutoDiscover controls what the daemon does when a live login belongs
+	// to an account that is not in the vault yet.
+	// "off": Don't watch auth files (default)
+	// "suggest": Log the account and the command to save it
+	// "auto": Back it up to a new profile immediately
+	AutoDiscover string `yaml:"auto_discover"`
+
+	// SyncWatch saves tokens the CLIs refresh themselves back to their
+	// vault profiles and pushes changed profiles to the sync pool.
+	SyncWatch bool `yaml:"sync_watch"`
+
+	// CooldownProbe controls the validation probe run when a cooldown
+	// expires, before the profile is treated as healthy again.
+	CooldownProbe CooldownProbeConfig `yaml:"cooldown_probe"`
+
+	// UsageWebhook receives provider usage alerts so profiles nearing a
+	// limit are cooled down before they hit it.
+	UsageWebhook UsageWebhookConfig `yaml:"usage_webhook"`
+
+	// Federation aggregates auth coordinator status from other machines.
+	Federation FederationConfig `yaml:"federation"`
+
+	// Announce lets other machines find this one with
+	// 'caam sync discover --network'.
+	Announce AnnounceConfig `yaml:"announce"`
+}
+
+// FederationConfig holds settings for polling other machines' auth
+// coordinators, so pending logins everywhere show up in one place.
+type FederationConfig struct {
+	// Enabled makes the daemon poll every SSH machine in the sync pool and
+	// every listed peer.
+	Enabled bool `yaml:"enabled"`
+
+	// Interval is how often to poll.
+	// Default: 30s
+	Interval Duration `yaml:"interval"`
+
+	// CoordinatorPort is the coordinator API port on sync pool machines,
+	// reached over an SSH tunnel to the machine's loopback.
+	// Default: 7890
+	CoordinatorPort int `yaml:"coordinator_port"`
+
+	// Token is the coordinator API token for sync pool machines and for
+	// peers without their own. Empty uses CAAM_COORDINATOR_TOKEN.
+	Token string `yaml:"token"`
+
+	// Peers are coordinators reached directly over HTTP.
+	Peers []FederationPeer `yaml:"peers"`
+}
+
+// FederationPeer is a coordinator reached over HTTP.
+type FederationPeer struct {
+	Name  string `yaml:"name"`
+	URL   string `yaml:"url"`
+	Token string `yaml:"token"`
+}
+
+// AnnounceConfig holds settings for advertising this machine to other caam
+// instances on the LAN (mDNS) and tailnet.
+type AnnounceConfig struct {
+	// Listen is the address to serve the sync beacon on, e.g. ":7899".
+	// Tailscale peers are probed on port 7899. Empty disables announcing.
+	Listen string `yaml:"listen"`
+}
+
+// UsageWebhookConfig holds the daemon's usage-alert webhook receiver settings.
+type UsageWebhookConfig struct {
+	// Listen is the address to accept alerts on, e.g. "127.0.0.1:7895".
+	// Empty disables the receiver.
+	Listen string `yaml:"listen"`
+
+	// Secret must accompany every request (Bearer token, X-Caam-Secret
+	// header, or ?secret= query parameter).
+	Secret string `yaml:"secret"`
+}
+
+// CooldownProbeConfig holds end-of-cooldown probe settings.
+type CooldownProbeConfig struct {
+	// Enabled runs the passive token check when a cooldown expires.
+	// Default: true
+	Enabled bool `yaml:"enabled"`
+
+	// UsagePing also queries the provider's usage API (Claude and Codex) to
+	// confirm the limit has actually reset.
+	// Default: false
+	UsagePing bool `yaml:"usage_ping"`
+
+	// Extension is how long to extend a cooldown when the probe fails and
+	// the provider doesn't report a reset time.
+	// Default: 30m
+	Extension Duration `yaml:"extension"`
 }
 
 // AuthPoolConfig holds auth pool settings.
@@ -234,6 +355,139 @@ type CompactionReminderConfig struct {
 	RegexOverride string `yaml:"regex_override,omitempty"`
 }
 
+// InjectionsConfig holds safety rules that every text injection into a
+// terminal pane (wezterm login-all/recover, the coordinator) must pass.
+type InjectionsConfig struct {
+	// ExcludeTitles are regexes matched against pane titles; matching panes
+	// never receive injected text (e.g. "(?i)prod", "^ssh ").
+	ExcludeTitles []string `yaml:"exclude_titles,omitempty"`
+
+	// ExcludeProcesses are foreground program names (editors, pagers, remote
+	// shells) that block injection into their pane.
+	ExcludeProcesses []string `yaml:"exclude_processes"`
+
+	// HumanActiveWindow skips panes that were focused this recently, since
+	// someone may be typing there. Zero disables the check.
+	// Default: 0
+	HumanActiveWindow Duration `yaml:"human_active_window"`
+
+	// RequireClaudePrompt refuses to inject unless the bottom of the pane
+	// shows Claude Code's UI. Recommended for unattended use.
+	// Default: false
+	RequireClaudePrompt bool `yaml:"require_claude_prompt"`
+}
+
+// Automation feature names, as used in automation.<provider>.<feature> keys.
+const (
+	AutomationLoginInject = "auto_login_inject"
+	AutomationRefresh     = "auto_refresh"
+	AutomationRotate      = "auto_rotate"
+)
+
+// ProviderAutomation switches individual automatic actions on or off for one
+// provider. Unset switches are on, so a provider keeps the existing behavior
+// until it is explicitly opted out.
+type ProviderAutomation struct {
+	// AutoLoginInject lets the coordinator type /login into panes that hit
+	// a rate limit.
+	AutoLoginInject *bool `yaml:"auto_login_inject,omitempty"`
+
+	// AutoRefresh lets the daemon and auth pool refresh tokens before expiry.
+	AutoRefresh *bool `yaml:"auto_refresh,omitempty"`
+
+	// AutoRotate lets run and wrap switch to another profile on rate limit,
+	// and rotation policies switch profiles on their own.
+	AutoRotate *bool `yaml:"auto_rotate,omitempty"`
+}
+
+// field returns the switch for a feature, or nil if the feature is unknown.
+func (a *ProviderAutomation) field(feature string) **bool {
+	switch feature {
+	case AutomationLoginInject:
+		return &a.AutoLoginInject
+	case AutomationRefresh:
+		return &a.AutoRefresh
+	case AutomationRotate:
+		return &a.AutoRotate
+	default:
+		return nil
+	}
+}
+
+// AutomationEnabled reports whether an automatic feature is allowed for a
+// provider. Features default to enabled.
+func (c *SPMConfig) AutomationEnabled(provider, feature string) bool {
+	if c == nil || c.Automation == nil {
+		return true
+	}
+	a, ok := c.Automation[provider]
+	if !ok {
+		return true
+	}
+	sw := a.field(feature)
+	if sw == nil || *sw == nil {
+		return true
+	}
+	return **sw
+}
+
+// DisabledProviders returns the providers that have explicitly turned off
+// an automatic feature.
+func (c *SPMConfig) DisabledProviders(feature string) map[string]bool {
+	disabled := make(map[string]bool)
+	if c == nil {
+		return disabled
+	}
+	for provider := range c.Automation {
+		if !c.AutomationEnabled(provider, feature) {
+			disabled[provider] = true
+		}
+	}
+	return disabled
+}
+
+// ValidateAutomationKey checks that provider and feature name a real
+// automation switch.
+func ValidateAutomationKey(provider, feature string) error {
+	if !isKnownProvider(provider) {
+		return fmt.Errorf("unknown provider: %s (supported: %s)", provider, strings.Join(knownProviders(), ", "))
+	}
+	var a ProviderAutomation
+	if a.field(feature) == nil {
+		return fmt.Errorf("unknown automation feature: %s (supported: %s, %s, %s)",
+			feature, AutomationLoginInject, AutomationRefresh, AutomationRotate)
+	}
+	return nil
+}
+
+// SetAutomation enables or disables an automatic feature for a provider.
+func (c *SPMConfig) SetAutomation(provider, feature string, enabled bool) error {
+	if err := ValidateAutomationKey(provider, feature); err != nil {
+		return err
+	}
+	a := c.Automation[provider]
+	*a.field(feature) = &enabled
+	if c.Automation == nil {
+		c.Automation = make(map[string]ProviderAutomation)
+	}
+	c.Automation[provider] = a
+	return nil
+}
+
+// knownProviders returns the providers automation switches can name: the
+// registered providers, including loaded plugins, and the Gemini
+// sub-providers.
+func knownProviders() []string {
+	names := append(provider.KnownProviderIDs(),
+		authfile.GeminiVariantCLI, authfile.GeminiVariantCodeAssist, authfile.GeminiVariantADC)
+	sort.Strings(names)
+	return names
+}
+
+func isKnownProvider(name string) bool {
+	return slices.Contains(knownProviders(), name)
+}
+
 // Duration is a time.Duration that supports YAML marshaling/unmarshaling
 // with human-readable formats like "10m", "1h", "30s".
 type Duration time.Duration
@@ -340,6 +594,7 @@ func DefaultSPMConfig() *SPMConfig {
 				Enabled:   false, // Opt-in
 				Algorithm: "smart",
 			},
+			Shaping: DefaultShapingConfig(),
 		},
 		Safety: SafetyConfig{
 			AutoBackupBeforeSwitch: "smart", // Backup if state doesn't match any profile
@@ -427,6 +682,17 @@ func DefaultSPMConfig() *SPMConfig {
 			CheckInterval:    Duration(5 * time.Minute),
 			RefreshThreshold: Duration(30 * time.Minute),
 			Verbose:          false,
+			AutoDiscover:     "off", // Opt-in
+			CooldownProbe: CooldownProbeConfig{
+				Enabled:   true,
+				UsagePing: false,
+				Extension: Duration(30 * time.Minute),
+			},
+			Federation: FederationConfig{
+				Enabled:         false, // Opt-in
+				Interval:        Duration(30 * time.Second),
+				CoordinatorPort: 7890,
+			},
 		},
 		TUI: TUIConfig{
 			Theme:         "auto",
@@ -444,12 +710,26 @@ func DefaultSPMConfig() *SPMConfig {
 			Cooldown:      Duration(10 * time.Minute),
 			RegexOverride: "", // Use built-in pattern
 		},
+		Injections: InjectionsConfig{
+			ExcludeProcesses: []string{
+				"vim", "nvim", "vi", "nano", "emacs", "hx", "micro",
+				"less", "more", "man", "ssh", "mosh-client",
+				"htop", "top", "psql", "mysql", "caam",
+			},
+		},
+		Scoring: DefaultScoringConfig(),
+		Logging: DefaultLoggingConfig(),
+		Tracing: DefaultTracingConfig(),
 	}
 }
 
 // SPMConfigPath returns the path to the SPM config file.
-// Uses ~/.caam/config.yaml by default.
+// Uses ~/.caam/config.yaml by default, or config.yaml in the active config
+// environment.
 func SPMConfigPath() string {
+	if env := ActiveEnv(); env != "" {
+		return filepath.Join(EnvDir(env), "config.yaml")
+	}
 	if caamHome := os.Getenv("CAAM_HOME"); caamHome != "" {
 		return filepath.Join(caamHome, "config.yaml")
 	}
@@ -462,21 +742,20 @@ func SPMConfigPath() string {
 }
 
 // LoadSPMConfig reads the SPM configuration from disk.
-// Returns defaults if the file doesn't exist.
+// Returns defaults if the file doesn't exist. Environment overrides apply
+// either way.
 func LoadSPMConfig() (*SPMConfig, error) {
 	configPath := SPMConfigPath()
 
+	config := DefaultSPMConfig() // Start with defaults
 	data, err := os.ReadFile(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return DefaultSPMConfig(), nil
-		}
+	if err != nil && !os.IsNotExist(err) {
 		return nil, fmt.Errorf("read SPM config: %w", err)
 	}
-
-	config := DefaultSPMConfig() // Start with defaults
-	if err := yaml.Unmarshal(data, config); err != nil {
-		return nil, fmt.Errorf("parse SPM config: %w", err)
+	if err == nil {
+		if err := yaml.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("parse SPM config: %w", err)
+		}
 	}
 
 	config.ApplyEnvOverrides()
@@ -587,6 +866,9 @@ func (c *SPMConfig) Validate() error {
 	if c.Stealth.Cooldown.DefaultMinutes < 0 {
 		return fmt.Errorf("stealth.cooldown.default_minutes cannot be negative")
 	}
+	if err := validateCooldownTemplates(c.Stealth.Cooldown.Templates); err != nil {
+		return err
+	}
 	validAlgorithms := map[string]bool{"smart": true, "round_robin": true, "random": true}
 	if c.Stealth.Rotation.Algorithm != "" && !validAlgorithms[c.Stealth.Rotation.Algorithm] {
 		return fmt.Errorf("stealth.rotation.algorithm must be one of: smart, round_robin, random")
@@ -636,12 +918,43 @@ func (c *SPMConfig) Validate() error {
 	if c.Daemon.AuthPool.MaxRefreshRetries < 0 {
 		return fmt.Errorf("daemon.auth_pool.max_refresh_retries cannot be negative")
 	}
+	if c.Daemon.CooldownProbe.Extension.Duration() < 0 {
+		return fmt.Errorf("daemon.cooldown_probe.extension cannot be negative")
+	}
+	validDiscoverModes := map[string]bool{"off": true, "suggest": true, "auto": true}
+	if c.Daemon.AutoDiscover != "" && !validDiscoverModes[c.Daemon.AutoDiscover] {
+		return fmt.Errorf("daemon.auto_discover must be one of: off, suggest, auto")
+	}
+	if c.Daemon.UsageWebhook.Listen != "" && c.Daemon.UsageWebhook.Secret == "" {
+		return fmt.Errorf("daemon.usage_webhook.secret is required when listen is set")
+	}
+	if c.Daemon.Federation.Interval.Duration() < 0 {
+		return fmt.Errorf("daemon.federation.interval cannot be negative")
+	}
+	if p := c.Daemon.Federation.CoordinatorPort; p < 0 || p > 65535 {
+		return fmt.Errorf("daemon.federation.coordinator_port must be between 0 and 65535")
+	}
+	for i, peer := range c.Daemon.Federation.Peers {
+		if strings.TrimSpace(peer.URL) == "" {
+			return fmt.Errorf("daemon.federation.peers[%d].url is required", i)
+		}
+	}
 
 	// Subscription validation
 	for name, sub := range c.Subscriptions {
 		if sub.MonthlyCost < 0 {
 			return fmt.Errorf("subscriptions.%s.monthly_cost cannot be negative", name)
 		}
+		if sub.TokenLimit < 0 {
+			return fmt.Errorf("subscriptions.%s.token_limit cannot be negative", name)
+		}
+		if sub.TokenLimit > 0 && sub.LimitWindow.Duration() <= 0 {
+			return fmt.Errorf("subscriptions.%s.limit_window is required when token_limit is set", name)
+		}
+	}
+
+	if err := validateCosts(c.Costs); err != nil {
+		return err
 	}
 
 	// Pattern validation
@@ -675,6 +988,32 @@ func (c *SPMConfig) Validate() error {
 		return fmt.Errorf("tui.density must be one of: cozy, compact")
 	}
 
+	// Automation validation
+	for provider := range c.Automation {
+		if !isKnownProvider(provider) {
+			return fmt.Errorf("automation.%s: unknown provider (supported: %s)", provider, strings.Join(knownProviders(), ", "))
+		}
+	}
+
+	if err := validatePolicies(c.Policies); err != nil {
+		return err
+	}
+	if err := validateNetwork(c.Stealth.Network); err != nil {
+		return err
+	}
+	if err := validateShaping(c.Stealth.Shaping); err != nil {
+		return err
+	}
+	if err := validateScoring(c.Scoring); err != nil {
+		return err
+	}
+	if err := validateLogging(c.Logging); err != nil {
+		return err
+	}
+	if err := validateTracing(c.Tracing); err != nil {
+		return err
+	}
+
 	// CompactionReminder validation
 	if c.CompactionReminder.Cooldown.Duration() < 0 {
 		return fmt.Errorf("compaction_reminder.cooldown cannot be negative")
@@ -688,6 +1027,19 @@ func (c *SPMConfig) Validate() error {
 		}
 	}
 
+	if err := validatePatterns("injections.exclude_titles", c.Injections.ExcludeTitles); err != nil {
+		return err
+	}
+	if c.Injections.HumanActiveWindow.Duration() < 0 {
+		return fmt.Errorf("injections.human_active_window cannot be negative")
+	}
+
+	if c.Language != "" {
+		if _, ok := i18n.Parse(c.Language); !ok {
+			return fmt.Errorf("language must be one of %v, got %q", i18n.Supported, c.Language)
+		}
+	}
+
 	return nil
 }
 
@@ -780,6 +1132,17 @@ func (c *SPMConfig) ApplyEnvOverrides() {
 			c.Daemon.Verbose = b
 		}
 	}
+	if v := os.Getenv("CAAM_DAEMON_AUTO_DISCOVER"); v != "" {
+		c.Daemon.AutoDiscover = strings.ToLower(strings.TrimSpace(v))
+	}
+	if v := os.Getenv("CAAM_DAEMON_ANNOUNCE_LISTEN"); v != "" {
+		c.Daemon.Announce.Listen = strings.TrimSpace(v)
+	}
+	if v := os.Getenv("CAAM_DAEMON_SYNC_WATCH"); v != "" {
+		if b, err := parseBool(v); err == nil {
+			c.Daemon.SyncWatch = b
+		}
+	}
 	
 	// Health
 	if v := os.Getenv("CAAM_HEALTH_REFRESH_THRESHOLD"); v != "" {
@@ -848,6 +1211,24 @@ func (c *SPMConfig) ApplyEnvOverrides() {
 			c.TUI.NoTUI = b
 		}
 	}
+
+	// Logging
+	if v := os.Getenv("CAAM_LOG_LEVEL"); v != "" {
+		if _, err := parseLogLevel(v); err == nil {
+			c.Logging.Level = strings.TrimSpace(v)
+			c.Logging.Commands = nil
+		}
+	}
+
+	// Tracing
+	if v := os.Getenv("CAAM_TRACING"); v != "" {
+		if b, err := parseBool(v); err == nil {
+			c.Tracing.Enabled = b
+		}
+	}
+	if v := os.Getenv("CAAM_TRACING_ENDPOINT"); v != "" {
+		c.Tracing.Endpoint = strings.TrimSpace(v)
+	}
 }
 
 // parseBool parses various boolean representations.
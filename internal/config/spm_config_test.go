@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"gopkg.in/yaml.v3"
 )
 
@@ -813,6 +814,72 @@ func TestSPMConfigHelpers(t *testing.T) {
 	})
 }
 
+func TestSPMConfigAutomation(t *testing.T) {
+	cfg := DefaultSPMConfig()
+
+	// Everything defaults to enabled.
+	if !cfg.AutomationEnabled("codex", AutomationRefresh) {
+		t.Error("AutomationEnabled(codex, auto_refresh) should default to true")
+	}
+
+	if err := cfg.SetAutomation("codex", AutomationRefresh, false); err != nil {
+		t.Fatalf("SetAutomation() error = %v", err)
+	}
+	if err := cfg.SetAutomation("claude", AutomationLoginInject, true); err != nil {
+		t.Fatalf("SetAutomation() error = %v", err)
+	}
+
+	if cfg.AutomationEnabled("codex", AutomationRefresh) {
+		t.Error("codex auto_refresh should be disabled")
+	}
+	if !cfg.AutomationEnabled("codex", AutomationRotate) {
+		t.Error("codex auto_rotate should still default to true")
+	}
+	if !cfg.AutomationEnabled("claude", AutomationLoginInject) {
+		t.Error("claude auto_login_inject should be enabled")
+	}
+
+	disabled := cfg.DisabledProviders(AutomationRefresh)
+	if len(disabled) != 1 || !disabled["codex"] {
+		t.Errorf("DisabledProviders(auto_refresh) = %v, want only codex", disabled)
+	}
+
+	for _, p := range []string{"cursor", "copilot", "gemini-cli"} {
+		if err := cfg.SetAutomation(p, AutomationRefresh, false); err != nil {
+			t.Errorf("SetAutomation(%s) error = %v", p, err)
+		}
+	}
+	provider.RegisterProviderMeta(provider.ProviderMeta{ID: "acme"})
+	if err := cfg.SetAutomation("acme", AutomationRotate, false); err != nil {
+		t.Errorf("SetAutomation(plugin provider) error = %v", err)
+	}
+	if err := cfg.SetAutomation("bogus", AutomationRefresh, false); err == nil {
+		t.Error("SetAutomation should reject unknown provider")
+	}
+	if err := cfg.SetAutomation("codex", "auto_launch", false); err == nil {
+		t.Error("SetAutomation should reject unknown feature")
+	}
+
+	// Round-trip through YAML.
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", tmpDir)
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadSPMConfig()
+	if err != nil {
+		t.Fatalf("LoadSPMConfig() error = %v", err)
+	}
+	if loaded.AutomationEnabled("codex", AutomationRefresh) {
+		t.Error("codex auto_refresh should stay disabled after reload")
+	}
+
+	loaded.Automation["bogus"] = ProviderAutomation{}
+	if err := loaded.Validate(); err == nil {
+		t.Error("Validate() should reject unknown automation provider")
+	}
+}
+
 func TestSPMConfigForwardCompatibility(t *testing.T) {
 	// Save original env
 	origCaamHome := os.Getenv("CAAM_HOME")
@@ -1322,6 +1389,34 @@ handoff:
 `,
 			wantErr: "handoff.max_retries cannot be negative",
 		},
+		{
+			name: "invalid injections title regex",
+			yaml: `
+version: 1
+health:
+  refresh_threshold: 10m
+  warning_threshold: 1h
+  penalty_decay_rate: 0.8
+  penalty_decay_interval: 5m
+injections:
+  exclude_titles: ["("]
+`,
+			wantErr: "invalid regex in injections.exclude_titles[0]",
+		},
+		{
+			name: "invalid daemon auto_discover",
+			yaml: `
+version: 1
+health:
+  refresh_threshold: 10m
+  warning_threshold: 1h
+  penalty_decay_rate: 0.8
+  penalty_decay_interval: 5m
+daemon:
+  auto_discover: always
+`,
+			wantErr: "daemon.auto_discover must be one of: off, suggest, auto",
+		},
 		{
 			name: "negative subscription cost",
 			yaml: `
@@ -1338,6 +1433,53 @@ subscriptions:
 `,
 			wantErr: "monthly_cost cannot be negative",
 		},
+		{
+			name: "token limit without window",
+			yaml: `
+version: 1
+health:
+  refresh_threshold: 10m
+  warning_threshold: 1h
+  penalty_decay_rate: 0.8
+  penalty_decay_interval: 5m
+subscriptions:
+  claude:
+    plan: max
+    token_limit: 5000000
+`,
+			wantErr: "limit_window is required",
+		},
+		{
+			name: "policy with both triggers",
+			yaml: `
+version: 1
+health:
+  refresh_threshold: 10m
+  warning_threshold: 1h
+  penalty_decay_rate: 0.8
+  penalty_decay_interval: 5m
+policies:
+  - provider: claude
+    every: 4h
+    when: error_count_1h >= 3
+`,
+			wantErr: "exactly one of every or when",
+		},
+		{
+			name: "policy with unknown metric",
+			yaml: `
+version: 1
+health:
+  refresh_threshold: 10m
+  warning_threshold: 1h
+  penalty_decay_rate: 0.8
+  penalty_decay_interval: 5m
+policies:
+  - provider: codex
+    when: vibes > 3
+`,
+			wantErr: "unknown metric",
+		},
 	}
 
 	for _, tc := range tests {
@@ -0,0 +1,47 @@
+package config
+
+import "fmt"
+
+// TracingConfig controls OpenTelemetry trace export. When enabled, each
+// command is one trace, with spans for vault listing, health computation,
+// database queries, provider API calls, and SSH sync transfers.
+//
+//	tracing:
+//	  enabled: true
+//	  endpoint: localhost:4318
+//	  insecure: true
+//	  sample_ratio: 1
+type TracingConfig struct {
+	// Enabled turns on export.
+	// Environment override: CAAM_TRACING
+	Enabled bool `yaml:"enabled"`
+
+	// Endpoint is the OTLP/HTTP collector, as host:port or a URL. Empty
+	// follows OTEL_EXPORTER_OTLP_ENDPOINT, or localhost:4318.
+	// Environment override: CAAM_TRACING_ENDPOINT
+	Endpoint string `yaml:"endpoint,omitempty"`
+
+	// Insecure sends spans over plain HTTP.
+	Insecure bool `yaml:"insecure"`
+
+	// Headers are sent with every export, e.g. a collector API key.
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// SampleRatio is the fraction of commands traced, from 0 to 1.
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// DefaultTracingConfig returns the built-in tracing settings.
+func DefaultTracingConfig() TracingConfig {
+	return TracingConfig{
+		Enabled:     false, // Opt-in
+		SampleRatio: 1,
+	}
+}
+
+func validateTracing(t TracingConfig) error {
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample_ratio must be between 0 and 1")
+	}
+	return nil
+}
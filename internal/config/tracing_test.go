@@ -0,0 +1,23 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTracingConfig(t *testing.T) {
+	if err := validateTracing(DefaultTracingConfig()); err != nil {
+		t.Errorf("default tracing config invalid: %v", err)
+	}
+	if err := validateTracing(TracingConfig{SampleRatio: 1.5}); err == nil || !strings.Contains(err.Error(), "sample_ratio") {
+		t.Errorf("sample_ratio 1.5 error = %v", err)
+	}
+
+	t.Setenv("CAAM_TRACING", "1")
+	t.Setenv("CAAM_TRACING_ENDPOINT", "collector:4318")
+	c := DefaultSPMConfig()
+	c.ApplyEnvOverrides()
+	if !c.Tracing.Enabled || c.Tracing.Endpoint != "collector:4318" {
+		t.Errorf("tracing after env overrides = %+v", c.Tracing)
+	}
+}
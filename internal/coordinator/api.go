@@ -4,9 +4,11 @@ import (
 	"context"
 	"crypto/subtle"
 	"encoding/json"
-	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -30,20 +32,28 @@ func NewAPIServer(coordinator *Coordinator, port int, logger *slog.Logger) *APIS
 		logger:      logger,
 		token:       "",
 	}
+	host := "127.0.0.1"
 	if coordinator != nil {
 		api.token = strings.TrimSpace(coordinator.config.AuthToken)
+		if h := strings.TrimSpace(coordinator.config.APIHost); h != "" {
+			host = h
+		}
 	}
 
 	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", api.authMiddleware(api.handleIndex))
 	mux.HandleFunc("GET /health", api.handleHealth)
 	mux.HandleFunc("GET /status", api.authMiddleware(api.handleStatus))
 	mux.HandleFunc("GET /auth/pending", api.authMiddleware(api.handleGetPending))
 	mux.HandleFunc("POST /auth/complete", api.authMiddleware(api.handleComplete))
-	mux.HandleFunc("POST /auth/submit", api.authMiddleware(api.handleComplete)) // alias
+	mux.HandleFunc("POST /auth/submit", api.authMiddleware(api.handleComplete))   // alias
+	mux.HandleFunc("POST /auth/response", api.authMiddleware(api.handleComplete)) // alias
 	mux.HandleFunc("GET /panes", api.authMiddleware(api.handleListPanes))
+	mux.HandleFunc("POST /pause", api.authMiddleware(api.handlePause))
+	mux.HandleFunc("POST /resume", api.authMiddleware(api.handleResume))
 
 	api.server = &http.Server{
-		Addr:         fmt.Sprintf("127.0.0.1:%d", port),
+		Addr:         net.JoinHostPort(host, strconv.Itoa(port)),
 		Handler:      api.withLogging(mux),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
@@ -59,13 +69,7 @@ func (a *APIServer) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		auth := r.Header.Get("Authorization")
-		const prefix = "Bearer "
-		if !strings.HasPrefix(auth, prefix) {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-		provided := strings.TrimSpace(auth[len(prefix):])
+		provided := requestToken(r)
 		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			return
@@ -74,6 +78,17 @@ func (a *APIServer) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// requestToken returns the bearer token from the Authorization header, or
+// the "token" query parameter for browsers, which can't set headers on links
+// and form posts.
+func requestToken(r *http.Request) string {
+	const prefix = "Bearer "
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, prefix) {
+		return strings.TrimSpace(auth[len(prefix):])
+	}
+	return strings.TrimSpace(r.URL.Query().Get("token"))
+}
+
 // Start begins serving the API.
 func (a *APIServer) Start() error {
 	a.logger.Info("starting API server", "addr", a.server.Addr)
@@ -118,16 +133,27 @@ func (a *APIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 // StatusResponse is the response from /status endpoint.
 type StatusResponse struct {
 	Running        bool                 `json:"running"`
+	Paused         bool                 `json:"paused"`
 	Backend        string               `json:"backend"`
 	PaneCount      int                  `json:"pane_count"`
 	PendingAuths   int                  `json:"pending_auths"`
 	Panes          []PaneStatusResponse `json:"panes"`
 	PendingDetails []*AuthRequest       `json:"pending_details,omitempty"`
+	Leases         []LeaseStatus        `json:"leases,omitempty"`
+}
+
+// LeaseStatus is a profile lease held on the coordinator's machine.
+type LeaseStatus struct {
+	Provider  string    `json:"provider"`
+	Profile   string    `json:"profile"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 // PaneStatusResponse is the status of a single pane.
 type PaneStatusResponse struct {
 	PaneID       int       `json:"pane_id"`
+	Provider     string    `json:"provider,omitempty"`
 	State        string    `json:"state"`
 	StateEntered time.Time `json:"state_entered"`
 	RequestID    string    `json:"request_id,omitempty"`
@@ -144,6 +170,7 @@ func (a *APIServer) handleStatus(w http.ResponseWriter, r *http.Request) {
 		t.mu.RLock()
 		panes = append(panes, PaneStatusResponse{
 			PaneID:       t.PaneID,
+			Provider:     t.Provider,
 			State:        t.State.String(),
 			StateEntered: t.StateEntered,
 			RequestID:    t.RequestID,
@@ -155,12 +182,16 @@ func (a *APIServer) handleStatus(w http.ResponseWriter, r *http.Request) {
 
 	resp := StatusResponse{
 		Running:        true,
+		Paused:         a.coordinator.IsPaused(),
 		Backend:        a.coordinator.Backend(),
 		PaneCount:      len(trackers),
 		PendingAuths:   len(pending),
 		Panes:          panes,
 		PendingDetails: pending,
 	}
+	if a.coordinator.Leases != nil {
+		resp.Leases = a.coordinator.Leases()
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
@@ -182,8 +213,28 @@ type CompleteRequest struct {
 }
 
 func (a *APIServer) handleComplete(w http.ResponseWriter, r *http.Request) {
+	// The web page posts a form and expects to land back on the page.
+	isForm := strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+
 	var req CompleteRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if isForm {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid form", http.StatusBadRequest)
+			return
+		}
+		req = CompleteRequest{
+			RequestID: r.PostForm.Get("request_id"),
+			Code:      strings.TrimSpace(r.PostForm.Get("code")),
+			Account:   strings.TrimSpace(r.PostForm.Get("account")),
+		}
+		if req.Code == "" {
+			http.Error(w, "code required", http.StatusBadRequest)
+			return
+		}
+		if req.Account == "" {
+			req.Account = "manual"
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
@@ -207,6 +258,15 @@ func (a *APIServer) handleComplete(w http.ResponseWriter, r *http.Request) {
 		"request_id", req.RequestID,
 		"account", req.Account)
 
+	if isForm {
+		back := "/?submitted=" + url.QueryEscape(req.RequestID)
+		if token := r.URL.Query().Get("token"); token != "" {
+			back += "&token=" + url.QueryEscape(token)
+		}
+		http.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
@@ -221,3 +281,15 @@ func (a *APIServer) handleListPanes(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(panes)
 }
+
+func (a *APIServer) handlePause(w http.ResponseWriter, r *http.Request) {
+	a.coordinator.Pause()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "paused"})
+}
+
+func (a *APIServer) handleResume(w http.ResponseWriter, r *http.Request) {
+	a.coordinator.Resume()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "resumed"})
+}
@@ -26,14 +26,23 @@ const (
 	// Limitations: no domain awareness, extra process layer, less metadata.
 	BackendTmux Backend = "tmux"
 
-	// BackendAuto tries WezTerm first, falls back to tmux.
+	// BackendKitty uses Kitty's remote control protocol (kitty @).
+	// Requires allow_remote_control in kitty.conf.
+	BackendKitty Backend = "kitty"
+
+	// BackendZellij uses the zellij CLI. Zellij actions target focused
+	// panes, so only the pane each attached client has focused is monitored.
+	BackendZellij Backend = "zellij"
+
+	// BackendAuto tries WezTerm first, then tmux, Kitty, and Zellij.
 	BackendAuto Backend = "auto"
 )
 
 // Config configures the coordinator.
 type Config struct {
 	// Backend specifies which terminal multiplexer to use.
-	// Options: "wezterm" (preferred), "tmux", or "auto" (try wezterm, fall back to tmux).
+	// Options: "wezterm" (preferred), "tmux", "kitty", "zellij", or "auto"
+	// (try each in that order).
 	// Default: "auto"
 	Backend Backend
 
@@ -63,12 +72,23 @@ type Config struct {
 	LocalAgentURL string
 
 	// AuthToken is an optional shared secret required by the coordinator API.
-	// When set, clients must send "Authorization: Bearer <token>".
+	// When set, clients must send "Authorization: Bearer <token>" (or, from
+	// a browser, a "token" query parameter).
 	AuthToken string
 
+	// APIHost is the address the API server binds to. Bind a LAN or
+	// Tailscale address to complete logins from a phone; set AuthToken too.
+	// Default: 127.0.0.1
+	APIHost string
+
 	// LoginCooldown is the minimum time between /login injections per pane.
 	LoginCooldown time.Duration
 
+	// DisableLoginInject lists the providers whose rate limited panes the
+	// coordinator leaves for the user to recover instead of typing /login.
+	// Set from the automation.<provider>.auto_login_inject config switches.
+	DisableLoginInject map[string]bool
+
 	// MethodSelectCooldown is the minimum time between method selection injections per pane.
 	MethodSelectCooldown time.Duration
 
@@ -95,6 +115,15 @@ type Config struct {
 	// CompactionReminderRegex allows a custom regex pattern for compaction detection.
 	// If nil, uses the default Patterns.CompactingBanner.
 	CompactionReminderRegex *regexp.Regexp
+
+	// Guard vetoes injections into panes that look unsafe (editors, SSH
+	// sessions, panes the user is typing in). If nil, every pane is allowed.
+	Guard *InjectionGuard
+
+	// RunID is the correlation ID logged as run_id, so the coordinator's
+	// records match those of the caam invocation that started it. If empty,
+	// a new one is generated.
+	RunID string
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -122,6 +151,7 @@ type AuthRequest struct {
 	ID        string    `json:"id"`
 	PaneID    int       `json:"pane_id"`
 	URL       string    `json:"url"`
+	Provider  string    `json:"provider,omitempty"` // CLI that showed the URL
 	CreatedAt time.Time `json:"created_at"`
 	Status    string    `json:"status"` // pending, processing, completed, failed
 }
@@ -140,19 +170,32 @@ type Coordinator struct {
 	paneClient PaneClient
 	logger     *slog.Logger
 	trackers   map[int]*PaneTracker // paneID -> tracker
+	panes      map[int]Pane         // paneID -> latest listing, for the guard
 	requests   map[string]*AuthRequest
 	mu         sync.RWMutex
 	stopCh     chan struct{}
 	doneCh     chan struct{}
 	running    bool
+	paused     bool   // Set by Pause; skips pane polling and injections
 	runID      string // Correlation ID for this coordinator run
 
 	// Callbacks
 	OnAuthRequest  func(req *AuthRequest)
 	OnAuthComplete func(paneID int, account string)
 	OnAuthFailed   func(paneID int, err error)
+
+	// OnRateLimit is called when a pane shows a rate limit message, with
+	// the reset time text from it (empty if none was found).
+	OnRateLimit func(paneID int, provider, resetText string)
+
+	// Leases, if set, returns the profile leases held on this machine, for
+	// the status API to share with other machines.
+	Leases func() []LeaseStatus
 }
 
+// rateLimitReportInterval is how often OnRateLimit may fire for one pane.
+const rateLimitReportInterval = 5 * time.Minute
+
 // RedactURL returns a redacted version of a URL for safe logging.
 // Only shows the base path, hiding query parameters that may contain sensitive data.
 func RedactURL(url string) string {
@@ -185,7 +228,10 @@ func New(config Config) *Coordinator {
 	}
 
 	// Generate a run ID for correlation across all logs from this coordinator instance
-	runID := uuid.New().String()[:8]
+	runID := config.RunID
+	if runID == "" {
+		runID = uuid.New().String()[:8]
+	}
 
 	// Select pane client based on backend configuration, unless provided
 	paneClient := config.PaneClient
@@ -201,6 +247,7 @@ func New(config Config) *Coordinator {
 		paneClient: paneClient,
 		logger:     logger,
 		trackers:   make(map[int]*PaneTracker),
+		panes:      make(map[int]Pane),
 		requests:   make(map[string]*AuthRequest),
 		stopCh:     make(chan struct{}),
 		doneCh:     make(chan struct{}),
@@ -224,6 +271,12 @@ func selectPaneClient(backend Backend, logger *slog.Logger) PaneClient {
 	case BackendTmux:
 		return NewTmuxClient()
 
+	case BackendKitty:
+		return NewKittyClient()
+
+	case BackendZellij:
+		return NewZellijClient()
+
 	case BackendAuto:
 		fallthrough
 	default:
@@ -234,17 +287,18 @@ func selectPaneClient(backend Backend, logger *slog.Logger) PaneClient {
 			return wezterm
 		}
 
-		// Fall back to tmux
-		tmux := NewTmuxClient()
-		if tmux.IsAvailable(ctx) {
-			logger.Info("WezTerm not available, using tmux backend",
-				"note", "WezTerm is recommended for better integration")
-			return tmux
+		// Fall back to tmux, Kitty, then Zellij (which only sees focused panes)
+		for _, client := range []PaneClient{NewTmuxClient(), NewKittyClient(), NewZellijClient()} {
+			if client.IsAvailable(ctx) {
+				logger.Info("WezTerm not available, using "+client.Backend()+" backend",
+					"note", "WezTerm is recommended for better integration")
+				return client
+			}
 		}
 
-		// Neither available - return WezTerm anyway, errors will surface later
+		// Nothing available - return WezTerm anyway, errors will surface later
 		logger.Warn("no terminal multiplexer detected",
-			"hint", "start WezTerm or tmux before running the coordinator")
+			"hint", "start WezTerm, tmux, Kitty (with remote control), or Zellij before running the coordinator")
 		return wezterm
 	}
 }
@@ -291,6 +345,34 @@ func (c *Coordinator) Stop() error {
 	return nil
 }
 
+// Pause suspends pane polling and auth injections without stopping the
+// coordinator, so a human can work in panes without it interfering.
+func (c *Coordinator) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		c.paused = true
+		c.logger.Info("coordinator paused")
+	}
+}
+
+// Resume re-enables pane polling after Pause.
+func (c *Coordinator) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		c.paused = false
+		c.logger.Info("coordinator resumed")
+	}
+}
+
+// IsPaused returns whether the coordinator is paused.
+func (c *Coordinator) IsPaused() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.paused
+}
+
 // monitorLoop is the main polling loop.
 func (c *Coordinator) monitorLoop(ctx context.Context) {
 	defer close(c.doneCh)
@@ -305,6 +387,9 @@ func (c *Coordinator) monitorLoop(ctx context.Context) {
 		case <-c.stopCh:
 			return
 		case <-ticker.C:
+			if c.IsPaused() {
+				continue
+			}
 			c.pollPanes(ctx)
 		}
 	}
@@ -318,6 +403,8 @@ func (c *Coordinator) pollPanes(ctx context.Context) {
 		return
 	}
 
+	c.config.Guard.Observe(panes, time.Now())
+
 	// Track which panes we've seen
 	seenPanes := make(map[int]bool)
 
@@ -338,6 +425,7 @@ func (c *Coordinator) pollPanes(ctx context.Context) {
 		if !seenPanes[paneID] {
 			c.logger.Debug("pane disappeared, removing tracker", "pane_id", paneID)
 			delete(c.trackers, paneID)
+			delete(c.panes, paneID)
 		}
 	}
 	c.mu.Unlock()
@@ -351,6 +439,7 @@ func (c *Coordinator) processPaneState(ctx context.Context, pane Pane) {
 		tracker = NewPaneTracker(pane.PaneID)
 		c.trackers[pane.PaneID] = tracker
 	}
+	c.panes[pane.PaneID] = pane
 	c.mu.Unlock()
 
 	// Get pane output
@@ -375,6 +464,12 @@ func (c *Coordinator) processPaneState(ctx context.Context, pane Pane) {
 		return
 	}
 
+	// Re-detect the CLI only while idle so a login in progress keeps
+	// the machine it started with.
+	if currentState == StateIdle {
+		tracker.SetProvider(DetectProvider(pane, output).Name)
+	}
+
 	// Handle state-specific logic
 	switch currentState {
 	case StateIdle:
@@ -413,15 +508,51 @@ func (c *Coordinator) processPaneState(ctx context.Context, pane Pane) {
 	}
 }
 
+// inject types text into a tracked pane once the guard allows it.
+func (c *Coordinator) inject(ctx context.Context, tracker *PaneTracker, output, text string) error {
+	c.mu.RLock()
+	pane, ok := c.panes[tracker.PaneID]
+	c.mu.RUnlock()
+	if !ok {
+		pane = Pane{PaneID: tracker.PaneID}
+	}
+	if allowed, reason := c.config.Guard.Check(pane, output, time.Now()); !allowed {
+		c.logger.Info("injection blocked by guard",
+			"pane_id", tracker.PaneID,
+			"reason", reason,
+			"action", "inject_blocked")
+		return fmt.Errorf("%w: %s", ErrInjectionBlocked, reason)
+	}
+	return c.paneClient.SendText(ctx, tracker.PaneID, text, true)
+}
+
 func (c *Coordinator) handleIdleState(ctx context.Context, tracker *PaneTracker, output string) {
-	detected, metadata := DetectState(output)
+	machine := tracker.Machine()
+	detected, metadata := machine.DetectState(output)
 
 	if detected == StateRateLimited {
+		// The message stays on screen for a while, so report it once per
+		// rateLimitReportInterval rather than on every poll.
+		if c.OnRateLimit != nil && !tracker.IsOnCooldown("rate_limit_report") {
+			tracker.SetCooldown("rate_limit_report", rateLimitReportInterval)
+			c.OnRateLimit(tracker.PaneID, machine.Name, metadata["reset_time"])
+		}
+
+		if c.config.DisableLoginInject[machine.Name] {
+			c.logger.Info("rate limit detected; login injection disabled by config",
+				"pane_id", tracker.PaneID,
+				"provider", machine.Name,
+				"reset_time", metadata["reset_time"],
+				"action", "inject_disabled")
+			return
+		}
+
 		c.logger.Info("state transition",
 			"pane_id", tracker.PaneID,
 			"from_state", StateIdle.String(),
 			"to_state", StateRateLimited.String(),
 			"reason", "rate_limit_detected",
+			"provider", machine.Name,
 			"reset_time", metadata["reset_time"],
 			"action", "transition")
 		tracker.SetState(StateRateLimited)
@@ -437,8 +568,8 @@ func (c *Coordinator) handleIdleState(ctx context.Context, tracker *PaneTracker,
 			return
 		}
 
-		// Auto-inject /login command
-		if err := c.paneClient.SendText(ctx, tracker.PaneID, "/login\n", true); err != nil {
+		// Auto-inject the provider's login command
+		if err := c.inject(ctx, tracker, output, machine.LoginCommand); err != nil {
 			c.logger.Error("injection failed",
 				"pane_id", tracker.PaneID,
 				"state", StateRateLimited.String(),
@@ -457,8 +588,11 @@ func (c *Coordinator) handleIdleState(ctx context.Context, tracker *PaneTracker,
 		return // Don't check for compaction if rate limited
 	}
 
-	// Check for compaction reminder (only if enabled and not rate limited)
-	c.handleCompactionReminder(ctx, tracker, output)
+	// Check for compaction reminder (only if enabled and not rate limited).
+	// The banner is Claude Code's.
+	if machine.Name == ProviderClaude {
+		c.handleCompactionReminder(ctx, tracker, output)
+	}
 }
 
 // handleCompactionReminder checks for Claude's compaction banner and injects a reminder.
@@ -514,7 +648,7 @@ func (c *Coordinator) handleCompactionReminder(ctx context.Context, tracker *Pan
 		"state", StateIdle.String(),
 		"action", "inject_compaction_reminder")
 
-	if err := c.paneClient.SendText(ctx, tracker.PaneID, prompt, true); err != nil {
+	if err := c.inject(ctx, tracker, output, prompt); err != nil {
 		c.logger.Error("injection failed",
 			"pane_id", tracker.PaneID,
 			"state", StateIdle.String(),
@@ -535,7 +669,8 @@ func (c *Coordinator) handleCompactionReminder(ctx context.Context, tracker *Pan
 }
 
 func (c *Coordinator) handleRateLimitedState(ctx context.Context, tracker *PaneTracker, output string) {
-	detected, _ := DetectState(output)
+	machine := tracker.Machine()
+	detected, _ := machine.DetectState(output)
 
 	switch detected {
 	case StateAwaitingMethodSelect:
@@ -558,9 +693,9 @@ func (c *Coordinator) handleRateLimitedState(ctx context.Context, tracker *PaneT
 			return
 		}
 
-		// Auto-select option 1 (Claude account with subscription)
+		// Auto-select the subscription login (e.g., option 1 for Claude)
 		time.Sleep(200 * time.Millisecond)
-		if err := c.paneClient.SendText(ctx, tracker.PaneID, "1\n", true); err != nil {
+		if err := c.inject(ctx, tracker, output, machine.MethodChoice); err != nil {
 			c.logger.Error("injection failed",
 				"pane_id", tracker.PaneID,
 				"state", StateAwaitingMethodSelect.String(),
@@ -579,7 +714,7 @@ func (c *Coordinator) handleRateLimitedState(ctx context.Context, tracker *PaneT
 
 	case StateAwaitingURL:
 		// Skip method select, URL shown directly
-		url := ExtractOAuthURL(output)
+		url := machine.ExtractOAuthURL(output)
 		if url != "" {
 			tracker.SetOAuthURL(url)
 			tracker.SetState(StateAwaitingURL)
@@ -605,12 +740,13 @@ func (c *Coordinator) handleRateLimitedState(ctx context.Context, tracker *PaneT
 }
 
 func (c *Coordinator) handleAwaitingMethodSelectState(ctx context.Context, tracker *PaneTracker, output string) {
-	detected, metadata := DetectState(output)
+	machine := tracker.Machine()
+	detected, metadata := machine.DetectState(output)
 
 	if detected == StateAwaitingURL {
 		url := metadata["oauth_url"]
 		if url == "" {
-			url = ExtractOAuthURL(output)
+			url = machine.ExtractOAuthURL(output)
 		}
 		if url != "" {
 			tracker.SetOAuthURL(url)
@@ -640,7 +776,7 @@ func (c *Coordinator) handleAwaitingURLState(ctx context.Context, tracker *PaneT
 	// Extract URL if not already have it
 	oauthURL := tracker.GetOAuthURL()
 	if oauthURL == "" {
-		url := ExtractOAuthURL(output)
+		url := tracker.Machine().ExtractOAuthURL(output)
 		if url != "" {
 			tracker.SetOAuthURL(url)
 			oauthURL = url
@@ -653,6 +789,7 @@ func (c *Coordinator) handleAwaitingURLState(ctx context.Context, tracker *PaneT
 			ID:        uuid.New().String(),
 			PaneID:    tracker.PaneID,
 			URL:       oauthURL,
+			Provider:  tracker.Machine().Name,
 			CreatedAt: time.Now(),
 			Status:    "pending",
 		}
@@ -667,6 +804,7 @@ func (c *Coordinator) handleAwaitingURLState(ctx context.Context, tracker *PaneT
 		c.logger.Info("auth request created",
 			"pane_id", tracker.PaneID,
 			"request_id", req.ID,
+			"provider", req.Provider,
 			"from_state", StateAwaitingURL.String(),
 			"to_state", StateAuthPending.String(),
 			"url_redacted", RedactURL(oauthURL),
@@ -742,7 +880,14 @@ func (c *Coordinator) handleCodeReceivedState(ctx context.Context, tracker *Pane
 		"request_id", tracker.GetRequestID(),
 		"action", "inject_code")
 
-	if err := c.paneClient.SendText(ctx, tracker.PaneID, code+"\n", true); err != nil {
+	var err error
+	if tracker.Machine().CallbackCode {
+		// The CLI waits on a localhost redirect rather than a paste prompt.
+		err = deliverCallback(ctx, code)
+	} else {
+		err = c.inject(ctx, tracker, output, code+"\n")
+	}
+	if err != nil {
 		c.logger.Error("injection failed",
 			"pane_id", tracker.PaneID,
 			"state", StateCodeReceived.String(),
@@ -764,7 +909,7 @@ func (c *Coordinator) handleCodeReceivedState(ctx context.Context, tracker *Pane
 }
 
 func (c *Coordinator) handleAwaitingConfirmState(ctx context.Context, tracker *PaneTracker, output string) {
-	detected, _ := DetectState(output)
+	detected, _ := tracker.Machine().DetectState(output)
 
 	switch detected {
 	case StateResuming:
@@ -827,7 +972,7 @@ func (c *Coordinator) handleResumingState(ctx context.Context, tracker *PaneTrac
 		"action", "inject_resume")
 
 	time.Sleep(500 * time.Millisecond)
-	if err := c.paneClient.SendText(ctx, tracker.PaneID, c.config.ResumePrompt, true); err != nil {
+	if err := c.inject(ctx, tracker, output, c.config.ResumePrompt); err != nil {
 		c.logger.Error("injection failed",
 			"pane_id", tracker.PaneID,
 			"state", StateResuming.String(),
@@ -150,6 +150,19 @@ func TestCoordinatorConfig(t *testing.T) {
 	}
 }
 
+// TestCoordinatorRunID tests that a configured run ID is used as given.
+func TestCoordinatorRunID(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PaneClient = &fakePaneClient{}
+	if id := New(cfg).RunID(); len(id) != 8 {
+		t.Errorf("generated run ID = %q, want 8 characters", id)
+	}
+	cfg.RunID = "abc12345"
+	if id := New(cfg).RunID(); id != "abc12345" {
+		t.Errorf("RunID() = %q, want the configured abc12345", id)
+	}
+}
+
 // TestCoordinatorStartStop tests starting and stopping the coordinator.
 func TestCoordinatorStartStop(t *testing.T) {
 	cfg := DefaultConfig()
@@ -356,6 +369,9 @@ func TestAPIStatusEndpoint(t *testing.T) {
 	tracker.SetRequestID("req-1")
 	coord.trackers[1] = tracker
 	coord.requests["req-1"] = &AuthRequest{ID: "req-1", PaneID: 1, Status: "pending"}
+	coord.Leases = func() []LeaseStatus {
+		return []LeaseStatus{{Provider: "claude", Profile: "work1", Holder: "alice@laptop"}}
+	}
 
 	api := NewAPIServer(coord, 0, nil)
 
@@ -388,6 +404,44 @@ func TestAPIStatusEndpoint(t *testing.T) {
 	if resp.Panes[0].State != "AUTH_PENDING" {
 		t.Errorf("expected AUTH_PENDING state, got %q", resp.Panes[0].State)
 	}
+	if len(resp.Leases) != 1 || resp.Leases[0].Holder != "alice@laptop" {
+		t.Errorf("expected alice's lease, got %+v", resp.Leases)
+	}
+}
+
+// TestAPIPauseResume tests the /pause and /resume endpoints.
+func TestAPIPauseResume(t *testing.T) {
+	coord := New(DefaultConfig())
+	coord.paneClient = &fakePaneClient{}
+	api := NewAPIServer(coord, 0, nil)
+
+	w := httptest.NewRecorder()
+	api.server.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/pause", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("pause: expected status 200, got %d", w.Code)
+	}
+	if !coord.IsPaused() {
+		t.Error("expected coordinator to be paused")
+	}
+
+	w = httptest.NewRecorder()
+	api.handleStatus(w, httptest.NewRequest("GET", "/status", nil))
+	var resp StatusResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !resp.Paused {
+		t.Error("expected status to report paused")
+	}
+
+	w = httptest.NewRecorder()
+	api.server.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/resume", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("resume: expected status 200, got %d", w.Code)
+	}
+	if coord.IsPaused() {
+		t.Error("expected coordinator to be resumed")
+	}
 }
 
 func TestAPITokenAuth(t *testing.T) {
@@ -1039,6 +1093,44 @@ func TestCompactionReminderNotInjectedWhenRateLimited(t *testing.T) {
 	}
 }
 
+func TestLoginInjectDisabled(t *testing.T) {
+	client := &fakePaneClient{
+		panes:  []Pane{{PaneID: 1, Title: "claude-code"}},
+		output: "You've hit your limit on Claude usage today. This resets 2pm",
+	}
+
+	cfg := DefaultConfig()
+	cfg.DisableLoginInject = map[string]bool{ProviderClaude: true}
+	coord := New(cfg)
+	coord.paneClient = client
+
+	coord.pollPanes(context.Background())
+
+	if sent := client.sentText(); len(sent) != 0 {
+		t.Fatalf("expected no injections with login injection disabled, got %v", sent)
+	}
+	for _, tracker := range coord.GetTrackers() {
+		if tracker.GetState() != StateIdle {
+			t.Errorf("pane %d state = %v, want idle", tracker.PaneID, tracker.GetState())
+		}
+	}
+
+	// Another provider's switch leaves Claude panes alone.
+	client = &fakePaneClient{
+		panes:  []Pane{{PaneID: 1, Title: "claude-code"}},
+		output: "You've hit your limit on Claude usage today. This resets 2pm",
+	}
+	cfg.DisableLoginInject = map[string]bool{ProviderCodex: true}
+	coord = New(cfg)
+	coord.paneClient = client
+
+	coord.pollPanes(context.Background())
+
+	if sent := client.sentText(); len(sent) == 0 {
+		t.Fatal("expected /login injection with only codex disabled")
+	}
+}
+
 // TestCompactionReminderCustomPattern tests custom regex pattern for detection.
 func TestCompactionReminderCustomPattern(t *testing.T) {
 	client := &fakePaneClient{
@@ -0,0 +1,202 @@
+package coordinator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInjectionBlocked is returned when the guard refuses to type into a pane.
+var ErrInjectionBlocked = errors.New("injection blocked")
+
+// claudePromptRe matches Claude Code's interactive UI: the prompt footer, the
+// working indicator, and the login flow screens.
+var claudePromptRe = regexp.MustCompile(`(?i)(\? for shortcuts|esc to interrupt|claude code|auto-accept edits|bypass permissions|you've hit your limit|select login method|paste code here)`)
+
+// claudePromptTailLines is how much of the bottom of a pane is searched for
+// the Claude prompt, so a file mentioning "Claude Code" higher up in an
+// editor or pager doesn't count.
+const claudePromptTailLines = 12
+
+// GuardConfig configures an InjectionGuard.
+type GuardConfig struct {
+	// ExcludeTitles are regexes; a pane whose title matches any is skipped.
+	ExcludeTitles []string
+
+	// ExcludeProcesses are foreground process names that block injection.
+	ExcludeProcesses []string
+
+	// HumanActiveWindow skips panes that were focused within this window,
+	// since someone is probably typing there. Zero disables the check.
+	HumanActiveWindow time.Duration
+
+	// RequireClaudePrompt refuses to inject unless the bottom of the pane
+	// shows the UI of a supported CLI (Claude Code, Codex, or Gemini).
+	RequireClaudePrompt bool
+}
+
+// InjectionGuard decides whether automation may type into a pane. It is safe
+// for concurrent use.
+type InjectionGuard struct {
+	titles            []*regexp.Regexp
+	processes         map[string]bool
+	humanActiveWindow time.Duration
+	requirePrompt     bool
+
+	// ForegroundProcess resolves a pane's foreground process name. The
+	// default asks ps about the pane's PID or TTY.
+	ForegroundProcess func(Pane) string
+
+	mu        sync.Mutex
+	lastFocus map[int]time.Time
+}
+
+// NewInjectionGuard compiles cfg into a guard.
+func NewInjectionGuard(cfg GuardConfig) (*InjectionGuard, error) {
+	g := &InjectionGuard{
+		processes:         make(map[string]bool, len(cfg.ExcludeProcesses)),
+		humanActiveWindow: cfg.HumanActiveWindow,
+		requirePrompt:     cfg.RequireClaudePrompt,
+		ForegroundProcess: foregroundProcess,
+		lastFocus:         make(map[int]time.Time),
+	}
+	for _, pattern := range cfg.ExcludeTitles {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid title exclusion %q: %w", pattern, err)
+		}
+		g.titles = append(g.titles, re)
+	}
+	for _, name := range cfg.ExcludeProcesses {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			g.processes[name] = true
+		}
+	}
+	return g, nil
+}
+
+// Observe records which panes currently have focus. Call it on every pane
+// listing so HumanActiveWindow covers panes the user just left.
+func (g *InjectionGuard) Observe(panes []Pane, now time.Time) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, pane := range panes {
+		if pane.IsActive {
+			g.lastFocus[pane.PaneID] = now
+		}
+	}
+}
+
+// Check reports whether text may be injected into pane given its current
+// output. When it may not, reason says which rule blocked it. A nil guard
+// allows everything.
+func (g *InjectionGuard) Check(pane Pane, output string, now time.Time) (bool, string) {
+	if g == nil {
+		return true, ""
+	}
+
+	for _, re := range g.titles {
+		if re.MatchString(pane.Title) {
+			return false, fmt.Sprintf("title matches exclusion %q", re.String())
+		}
+	}
+
+	if len(g.processes) > 0 {
+		name := ""
+		if g.ForegroundProcess != nil {
+			name = g.ForegroundProcess(pane)
+		}
+		if name == "" {
+			// Terminals usually title a pane after its foreground program.
+			if fields := strings.Fields(pane.Title); len(fields) > 0 {
+				name = fields[0]
+			}
+		}
+		name = strings.ToLower(filepath.Base(name))
+		if g.processes[name] {
+			return false, fmt.Sprintf("foreground process is %s", name)
+		}
+	}
+
+	if g.humanActiveWindow > 0 {
+		if pane.IsActive {
+			return false, "pane has focus"
+		}
+		g.mu.Lock()
+		last, ok := g.lastFocus[pane.PaneID]
+		g.mu.Unlock()
+		if ok && now.Sub(last) < g.humanActiveWindow {
+			return false, fmt.Sprintf("pane was focused %s ago", now.Sub(last).Round(time.Second))
+		}
+	}
+
+	if g.requirePrompt && !hasAgentPrompt(output) {
+		return false, "no agent prompt visible"
+	}
+
+	return true, ""
+}
+
+// HasClaudePrompt reports whether the bottom of a pane's output shows Claude
+// Code's UI.
+func HasClaudePrompt(output string) bool {
+	return ClaudeMachine.HasPrompt(output)
+}
+
+// hasAgentPrompt reports whether the bottom of a pane's output shows the UI
+// of any supported CLI.
+func hasAgentPrompt(output string) bool {
+	for _, m := range ProviderMachines {
+		if m.HasPrompt(output) {
+			return true
+		}
+	}
+	return false
+}
+
+// foregroundProcess looks up a pane's foreground program with ps. It returns
+// "" when the pane has no PID or TTY, or ps is unavailable.
+func foregroundProcess(pane Pane) string {
+	if pane.ForegroundProcess != "" {
+		return pane.ForegroundProcess
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if pane.ForegroundPID > 0 {
+		out, err := exec.CommandContext(ctx, "ps", "-o", "comm=", "-p", strconv.Itoa(pane.ForegroundPID)).Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	if pane.TTYName == "" {
+		return ""
+	}
+	out, err := exec.CommandContext(ctx, "ps", "-t", strings.TrimPrefix(pane.TTYName, "/dev/"), "-o", "stat=,comm=").Output()
+	if err != nil {
+		return ""
+	}
+	// The foreground process group is marked with "+" in STAT; the last
+	// one listed is the most recently started.
+	name := ""
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		fields := strings.Fields(string(line))
+		if len(fields) >= 2 && strings.Contains(fields[0], "+") {
+			name = fields[1]
+		}
+	}
+	return name
+}
@@ -0,0 +1,110 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInjectionGuardCheck(t *testing.T) {
+	g, err := NewInjectionGuard(GuardConfig{
+		ExcludeTitles:       []string{`(?i)prod`},
+		ExcludeProcesses:    []string{"vim", "ssh"},
+		HumanActiveWindow:   time.Minute,
+		RequireClaudePrompt: true,
+	})
+	if err != nil {
+		t.Fatalf("NewInjectionGuard() error = %v", err)
+	}
+	g.ForegroundProcess = func(p Pane) string {
+		if p.PaneID == 2 {
+			return "/usr/bin/vim"
+		}
+		return ""
+	}
+
+	now := time.Now()
+	prompt := "some output\n> \n? for shortcuts"
+
+	tests := []struct {
+		name   string
+		pane   Pane
+		output string
+		want   bool
+		reason string
+	}{
+		{"allowed", Pane{PaneID: 1, Title: "claude"}, prompt, true, ""},
+		{"title", Pane{PaneID: 1, Title: "PROD shell"}, prompt, false, "title"},
+		{"process", Pane{PaneID: 2, Title: "notes"}, prompt, false, "vim"},
+		{"process from title", Pane{PaneID: 3, Title: "ssh db1"}, prompt, false, "ssh"},
+		{"focused", Pane{PaneID: 4, IsActive: true}, prompt, false, "focus"},
+		{"no prompt", Pane{PaneID: 5}, "$ ls\nfile.go", false, "agent prompt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := g.Check(tt.pane, tt.output, now)
+			if got != tt.want || !strings.Contains(reason, tt.reason) {
+				t.Errorf("Check() = %v, %q; want %v, reason containing %q", got, reason, tt.want, tt.reason)
+			}
+		})
+	}
+
+	// A pane the user just left is still considered human-active.
+	g.Observe([]Pane{{PaneID: 6, IsActive: true}}, now.Add(-10*time.Second))
+	if ok, _ := g.Check(Pane{PaneID: 6}, prompt, now); ok {
+		t.Error("Check() allowed a pane focused 10s ago")
+	}
+	if ok, _ := g.Check(Pane{PaneID: 6}, prompt, now.Add(2*time.Minute)); !ok {
+		t.Error("Check() blocked a pane focused over a minute ago")
+	}
+
+	var nilGuard *InjectionGuard
+	if ok, _ := nilGuard.Check(Pane{PaneID: 1, IsActive: true}, "", now); !ok {
+		t.Error("nil guard should allow everything")
+	}
+}
+
+func TestNewInjectionGuardInvalidTitle(t *testing.T) {
+	if _, err := NewInjectionGuard(GuardConfig{ExcludeTitles: []string{"("}}); err == nil {
+		t.Fatal("expected error for invalid title regex")
+	}
+}
+
+func TestHasClaudePromptOnlyChecksTail(t *testing.T) {
+	if !HasClaudePrompt("working...\n\x1b[2m? for shortcuts\x1b[0m\n") {
+		t.Error("expected prompt footer to match")
+	}
+	output := "Claude Code notes\n" + strings.Repeat("~\n", 30) + "-- INSERT --"
+	if HasClaudePrompt(output) {
+		t.Error("text far above the bottom of the pane should not count")
+	}
+}
+
+func TestCoordinatorGuardBlocksInjection(t *testing.T) {
+	client := &fakePaneClient{
+		panes:  []Pane{{PaneID: 1, Title: "vim notes.md"}},
+		output: "You've hit your limit on Claude usage today. This resets 2pm",
+	}
+	guard, err := NewInjectionGuard(GuardConfig{ExcludeProcesses: []string{"vim"}})
+	if err != nil {
+		t.Fatalf("NewInjectionGuard() error = %v", err)
+	}
+	guard.ForegroundProcess = func(Pane) string { return "" }
+
+	cfg := DefaultConfig()
+	cfg.Guard = guard
+	coord := New(cfg)
+	coord.paneClient = client
+
+	coord.pollPanes(context.Background())
+	if sent := client.sentText(); len(sent) != 0 {
+		t.Fatalf("expected guard to block injection, got %d sends", len(sent))
+	}
+
+	err = coord.inject(context.Background(), coord.trackers[1], client.output, "/login\n")
+	if !errors.Is(err, ErrInjectionBlocked) {
+		t.Fatalf("inject() error = %v, want ErrInjectionBlocked", err)
+	}
+}
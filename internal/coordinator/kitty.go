@@ -0,0 +1,163 @@
+// Package coordinator implements the auth recovery coordinator daemon.
+package coordinator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// KittyClient drives Kitty windows through its remote control protocol
+// (kitty @). Remote control must be enabled with allow_remote_control in
+// kitty.conf; outside Kitty, listen_on must be set and KITTY_LISTEN_ON must
+// point at its socket.
+//
+// Kitty "windows" are what other backends call panes. Their IDs are
+// integers that are unique for the life of the Kitty instance, so they map
+// directly to PaneID. Kitty has no domains; Domain is left empty.
+type KittyClient struct {
+	binaryPath string
+}
+
+// NewKittyClient creates a new Kitty remote control client.
+func NewKittyClient() *KittyClient {
+	return &KittyClient{
+		binaryPath: "kitty",
+	}
+}
+
+// kittyOSWindow is one entry of `kitty @ ls` output.
+type kittyOSWindow struct {
+	ID        int  `json:"id"`
+	IsFocused bool `json:"is_focused"`
+	Tabs      []struct {
+		ID      int `json:"id"`
+		Windows []struct {
+			ID                  int    `json:"id"`
+			Title               string `json:"title"`
+			CWD                 string `json:"cwd"`
+			PID                 int    `json:"pid"`
+			IsFocused           bool   `json:"is_focused"`
+			Columns             int    `json:"columns"`
+			Lines               int    `json:"lines"`
+			ForegroundProcesses []struct {
+				PID     int      `json:"pid"`
+				Cmdline []string `json:"cmdline"`
+			} `json:"foreground_processes"`
+		} `json:"windows"`
+	} `json:"tabs"`
+}
+
+// ListPanes returns every Kitty window across all OS windows and tabs.
+func (c *KittyClient) ListPanes(ctx context.Context) ([]Pane, error) {
+	stdout, err := c.run(ctx, nil, "ls")
+	if err != nil {
+		return nil, err
+	}
+	return parseKittyPanes(stdout)
+}
+
+// parseKittyPanes converts `kitty @ ls` output to panes.
+func parseKittyPanes(data []byte) ([]Pane, error) {
+	var osWindows []kittyOSWindow
+	if err := json.Unmarshal(data, &osWindows); err != nil {
+		return nil, fmt.Errorf("parse kitty window list: %w", err)
+	}
+
+	var panes []Pane
+	for _, osw := range osWindows {
+		for _, tab := range osw.Tabs {
+			for _, w := range tab.Windows {
+				pane := Pane{
+					PaneID:   w.ID,
+					WindowID: osw.ID,
+					TabID:    tab.ID,
+					Title:    w.Title,
+					CWD:      w.CWD,
+					IsActive: osw.IsFocused && w.IsFocused,
+					Cols:     w.Columns,
+					Rows:     w.Lines,
+				}
+				if len(w.ForegroundProcesses) > 0 {
+					fg := w.ForegroundProcesses[0]
+					pane.ForegroundPID = fg.PID
+					if len(fg.Cmdline) > 0 {
+						pane.ForegroundProcess = filepath.Base(fg.Cmdline[0])
+					}
+				}
+				panes = append(panes, pane)
+			}
+		}
+	}
+	return panes, nil
+}
+
+// GetText retrieves text content from a window.
+// startLine is negative for lines from the end (e.g., -50 for last 50 lines);
+// zero returns the visible screen.
+func (c *KittyClient) GetText(ctx context.Context, paneID int, startLine int) (string, error) {
+	extent := "screen"
+	if startLine < 0 {
+		extent = "all"
+	}
+
+	stdout, err := c.run(ctx, nil, "get-text", "--match", "id:"+strconv.Itoa(paneID), "--extent", extent)
+	if err != nil {
+		return "", err
+	}
+
+	text := string(stdout)
+	if startLine < 0 {
+		text = lastLines(text, -startLine)
+	}
+	return text, nil
+}
+
+// SendText injects text into a window. The text is passed on stdin so Kitty
+// sends it verbatim instead of interpreting escapes. Kitty always sends it
+// as typed input, so noPaste has no effect.
+func (c *KittyClient) SendText(ctx context.Context, paneID int, text string, noPaste bool) error {
+	_, err := c.run(ctx, strings.NewReader(text), "send-text", "--match", "id:"+strconv.Itoa(paneID), "--stdin")
+	return err
+}
+
+// IsAvailable checks if Kitty remote control is reachable.
+func (c *KittyClient) IsAvailable(ctx context.Context) bool {
+	_, err := c.run(ctx, nil, "ls")
+	return err == nil
+}
+
+// Backend returns the backend name.
+func (c *KittyClient) Backend() string {
+	return "kitty"
+}
+
+// run executes a kitty @ subcommand and returns its stdout.
+func (c *KittyClient) run(ctx context.Context, stdin *strings.Reader, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, c.binaryPath, append([]string{"@"}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("kitty @ %s: %w (stderr: %s)", args[0], err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+// lastLines returns the last n lines of text, ignoring trailing blank lines.
+func lastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -6,7 +6,8 @@ import (
 )
 
 // PaneClient is the interface for terminal multiplexer backends.
-// Implementations include WezTermClient (preferred) and TmuxClient (fallback).
+// Implementations include WezTermClient (preferred), TmuxClient (fallback),
+// KittyClient, and ZellijClient.
 //
 // WezTerm is the PREFERRED backend because:
 //   - Integrated multiplexer - panes ARE your terminal panes, no extra layer
@@ -20,6 +21,10 @@ import (
 //   - No machine context - can't automatically know which pane connects where
 //   - Session management - requires tmux server running, attach/detach workflow
 //   - Less metadata - no equivalent of WezTerm's domain/workspace concepts
+//
+// Kitty and Zellij are supported for teams standardized on them. Kitty's
+// remote control sees every window; Zellij's CLI only reaches the pane each
+// attached client has focused.
 type PaneClient interface {
 	// ListPanes returns all panes across all windows/sessions.
 	ListPanes(ctx context.Context) ([]Pane, error)
@@ -35,7 +40,8 @@ type PaneClient interface {
 	// IsAvailable checks if the backend is available and functional.
 	IsAvailable(ctx context.Context) bool
 
-	// Backend returns the name of this backend ("wezterm" or "tmux").
+	// Backend returns the name of this backend ("wezterm", "tmux", "kitty",
+	// or "zellij").
 	Backend() string
 }
 
@@ -43,4 +49,6 @@ type PaneClient interface {
 var (
 	_ PaneClient = (*WezTermClient)(nil)
 	_ PaneClient = (*TmuxClient)(nil)
+	_ PaneClient = (*KittyClient)(nil)
+	_ PaneClient = (*ZellijClient)(nil)
 )
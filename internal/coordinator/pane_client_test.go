@@ -0,0 +1,79 @@
+package coordinator
+
+import (
+	"testing"
+)
+
+func TestParseKittyPanes(t *testing.T) {
+	out := `[
+  {"id": 1, "is_focused": true, "tabs": [
+    {"id": 2, "windows": [
+      {"id": 5, "title": "claude", "cwd": "/work", "pid": 100, "is_focused": true, "columns": 120, "lines": 40,
+       "foreground_processes": [{"pid": 101, "cmdline": ["/usr/local/bin/claude", "--resume"]}]},
+      {"id": 6, "title": "shell", "cwd": "/tmp", "pid": 200, "is_focused": false, "columns": 80, "lines": 24,
+       "foreground_processes": []}
+    ]}
+  ]}
+]`
+	panes, err := parseKittyPanes([]byte(out))
+	if err != nil {
+		t.Fatalf("parseKittyPanes: %v", err)
+	}
+	if len(panes) != 2 {
+		t.Fatalf("got %d panes, want 2", len(panes))
+	}
+	p := panes[0]
+	if p.PaneID != 5 || p.WindowID != 1 || p.TabID != 2 || p.CWD != "/work" || !p.IsActive {
+		t.Errorf("pane = %+v", p)
+	}
+	if p.ForegroundPID != 101 || p.ForegroundProcess != "claude" || p.Cols != 120 || p.Rows != 40 {
+		t.Errorf("pane foreground/size = %+v", p)
+	}
+	if panes[1].IsActive || panes[1].ForegroundProcess != "" {
+		t.Errorf("second pane = %+v", panes[1])
+	}
+
+	if _, err := parseKittyPanes([]byte("not json")); err == nil {
+		t.Error("expected error for bad output")
+	}
+}
+
+func TestParseZellijClients(t *testing.T) {
+	out := `CLIENT_ID ZELLIJ_PANE_ID RUNNING_COMMAND
+1         terminal_3     /usr/bin/claude --resume
+2         plugin_1       N/A
+3         terminal_3     /usr/bin/claude --resume
+4         terminal_7
+`
+	panes := parseZellijClients(out)
+	if len(panes) != 2 {
+		t.Fatalf("got %+v, want 2 panes", panes)
+	}
+	if panes[0].pane != "terminal_3" || panes[0].command != "claude" {
+		t.Errorf("first pane = %+v", panes[0])
+	}
+	if panes[1].pane != "terminal_7" || panes[1].command != "" {
+		t.Errorf("second pane = %+v", panes[1])
+	}
+}
+
+func TestZellijPaneIDsStable(t *testing.T) {
+	c := NewZellijClient()
+	a := c.paneID(zellijPaneRef{session: "work", pane: "terminal_1"})
+	b := c.paneID(zellijPaneRef{session: "play", pane: "terminal_1"})
+	if a == b {
+		t.Fatalf("panes in different sessions share ID %d", a)
+	}
+	if again := c.paneID(zellijPaneRef{session: "work", pane: "terminal_1"}); again != a {
+		t.Errorf("ID changed from %d to %d", a, again)
+	}
+}
+
+func TestLastLines(t *testing.T) {
+	if got := lastLines("a\nb\nc\nd\n\n", 2); got != "c\nd\n" {
+		t.Errorf("lastLines = %q", got)
+	}
+	if got := lastLines("a\nb\n", 5); got != "a\nb\n" {
+		t.Errorf("lastLines short = %q", got)
+	}
+}
@@ -0,0 +1,280 @@
+package coordinator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Provider names.
+const (
+	ProviderClaude = "claude"
+	ProviderCodex  = "codex"
+	ProviderGemini = "gemini"
+)
+
+// ProviderMachine describes how one CLI reports a rate limit and walks
+// through an in-session login, so the coordinator can drive the same state
+// machine (IDLE -> RATE_LIMITED -> AWAITING_METHOD_SELECT -> AWAITING_URL
+// -> AUTH_PENDING -> ... -> RESUMING) for every provider.
+type ProviderMachine struct {
+	// Name is the provider name (ProviderClaude, ...).
+	Name string
+
+	// Processes are foreground process names that identify the CLI.
+	Processes []string
+
+	// Marker matches output that identifies the CLI when the foreground
+	// process is ambiguous (e.g., "node").
+	Marker *regexp.Regexp
+
+	// Prompt matches the CLI's interactive UI near the bottom of a pane.
+	// Used by InjectionGuard.RequireClaudePrompt.
+	Prompt *regexp.Regexp
+
+	// RateLimit matches the CLI's rate limit message.
+	RateLimit *regexp.Regexp
+
+	// ResetTime extracts the reset time (first submatch) from the rate
+	// limit message. Optional.
+	ResetTime *regexp.Regexp
+
+	// LoginCommand is typed into a rate limited pane to start login.
+	LoginCommand string
+
+	// SelectMethod matches the login method menu. Optional; when nil the
+	// CLI goes straight to the OAuth URL.
+	SelectMethod *regexp.Regexp
+
+	// MethodChoice is typed at the login method menu.
+	MethodChoice string
+
+	// OAuthURL matches the authorization URL shown during login.
+	OAuthURL *regexp.Regexp
+
+	// PastePrompt matches the prompt for the authorization code. Optional.
+	PastePrompt *regexp.Regexp
+
+	// LoginSuccess and LoginFailed match the outcome of the login.
+	LoginSuccess *regexp.Regexp
+	LoginFailed  *regexp.Regexp
+
+	// CallbackCode means the CLI receives the code on a localhost redirect
+	// instead of a paste prompt. The local agent returns the full redirect
+	// URL as the code, and the coordinator requests it on this machine.
+	CallbackCode bool
+}
+
+// ClaudeMachine drives Claude Code's /login flow: pick the subscription
+// login method, then paste the code from claude.ai.
+var ClaudeMachine = &ProviderMachine{
+	Name:         ProviderClaude,
+	Processes:    []string{"claude"},
+	Marker:       regexp.MustCompile(`(?i)(claude code|claude\.ai|\? for shortcuts)`),
+	Prompt:       claudePromptRe,
+	RateLimit:    Patterns.RateLimit,
+	ResetTime:    Patterns.UsageLimitReset,
+	LoginCommand: "/login\n",
+	SelectMethod: Patterns.SelectMethod,
+	MethodChoice: "1\n",
+	OAuthURL:     Patterns.OAuthURL,
+	PastePrompt:  Patterns.PastePrompt,
+	LoginSuccess: Patterns.LoginSuccess,
+	LoginFailed:  Patterns.LoginFailed,
+}
+
+// CodexMachine drives the Codex CLI's ChatGPT sign-in. Codex serves the
+// OAuth redirect on localhost:1455, so the code comes back as a callback URL.
+var CodexMachine = &ProviderMachine{
+	Name:         ProviderCodex,
+	Processes:    []string{"codex", "codex-tui"},
+	Marker:       regexp.MustCompile(`(?i)(openai codex|>_ codex|chatgpt\.com/codex)`),
+	Prompt:       regexp.MustCompile(`(?i)(openai codex|esc to interrupt|ctrl\+j newline|⏎ send|you've hit your usage limit|sign in with chatgpt)`),
+	RateLimit:    regexp.MustCompile(`(?i)(you've hit your usage limit|usage limit reached|exceeded retry limit, last status: 429)`),
+	ResetTime:    regexp.MustCompile(`(?i)try again (?:in|at) ([^.\n]+)`),
+	LoginCommand: "/login\n",
+	SelectMethod: regexp.MustCompile(`(?i)sign in with chatgpt`),
+	MethodChoice: "1\n",
+	OAuthURL:     regexp.MustCompile(`https://auth\.openai\.com/oauth/authorize\?[^\s]+`),
+	LoginSuccess: regexp.MustCompile(`(?i)(signed in with (your )?chatgpt|successfully logged in|logged in using chatgpt)`),
+	LoginFailed:  regexp.MustCompile(`(?i)(login failed|sign-in failed|authentication failed|invalid_grant|token exchange failed)`),
+	CallbackCode: true,
+}
+
+// GeminiMachine drives the Gemini CLI's /auth flow with "Login with Google",
+// which prints the URL and asks for the code when no browser is available.
+var GeminiMachine = &ProviderMachine{
+	Name:         ProviderGemini,
+	Processes:    []string{"gemini"},
+	Marker:       regexp.MustCompile(`(?i)(gemini cli|gemini\.md|gemini-[0-9.]+-(pro|flash))`),
+	Prompt:       regexp.MustCompile(`(?i)(type your message|gemini\.md|esc to cancel|select auth method|enter the authorization code|quota exceeded)`),
+	RateLimit:    regexp.MustCompile(`(?i)(quota exceeded|resource_exhausted|you have exhausted your (daily )?quota|reached your daily quota)`),
+	ResetTime:    regexp.MustCompile(`(?i)(?:resets?|try again) (?:in|at|after) ([^.\n]+)`),
+	LoginCommand: "/auth\n",
+	SelectMethod: regexp.MustCompile(`(?i)(select auth method|how would you like to authenticate)`),
+	MethodChoice: "\n", // "Login with Google" is the highlighted first option
+	OAuthURL:     regexp.MustCompile(`https://accounts\.google\.com/o/oauth2/[^\s?]*auth\?[^\s]+`),
+	PastePrompt:  regexp.MustCompile(`(?i)enter the authorization code`),
+	LoginSuccess: regexp.MustCompile(`(?i)(authentication succeeded|successfully authenticated|logged in with google|authenticated via "?oauth)`),
+	LoginFailed:  regexp.MustCompile(`(?i)(failed to login|authentication failed|invalid_grant|error authenticating)`),
+}
+
+// ProviderMachines lists the supported providers in detection order.
+var ProviderMachines = []*ProviderMachine{ClaudeMachine, CodexMachine, GeminiMachine}
+
+// MachineFor returns the machine for a provider name, defaulting to Claude.
+func MachineFor(name string) *ProviderMachine {
+	for _, m := range ProviderMachines {
+		if m.Name == name {
+			return m
+		}
+	}
+	return ClaudeMachine
+}
+
+// DetectProvider works out which CLI a pane is running: first from its
+// foreground process, then its title, then whichever CLI's marker appears
+// last in its output. Panes that can't be identified are treated as Claude.
+func DetectProvider(pane Pane, output string) *ProviderMachine {
+	if name := strings.ToLower(filepath.Base(pane.ForegroundProcess)); name != "" {
+		for _, m := range ProviderMachines {
+			for _, p := range m.Processes {
+				if name == p {
+					return m
+				}
+			}
+		}
+	}
+
+	title := strings.ToLower(pane.Title)
+	for _, m := range ProviderMachines {
+		if strings.Contains(title, m.Name) {
+			return m
+		}
+	}
+
+	normalized := StripANSI(output)
+	best, bestAt := ClaudeMachine, -1
+	for _, m := range ProviderMachines {
+		locs := m.Marker.FindAllStringIndex(normalized, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		if at := locs[len(locs)-1][0]; at > bestAt {
+			best, bestAt = m, at
+		}
+	}
+	return best
+}
+
+// DetectState analyzes pane output and returns the detected state.
+// Output is ANSI-normalized before pattern matching to handle colored
+// terminal output.
+func (m *ProviderMachine) DetectState(output string) (PaneState, map[string]string) {
+	metadata := make(map[string]string)
+
+	// Strip ANSI codes for reliable pattern matching
+	normalizedOutput := StripANSI(output)
+
+	// Check for login success first (highest priority)
+	if m.LoginSuccess.MatchString(normalizedOutput) {
+		return StateResuming, metadata
+	}
+
+	// Check for login failure
+	if m.LoginFailed.MatchString(normalizedOutput) {
+		return StateFailed, metadata
+	}
+
+	// Check for OAuth URL (implies awaiting URL state)
+	// Note: URLs should still be extracted from original output to preserve full URL
+	if match := m.OAuthURL.FindString(normalizedOutput); match != "" {
+		// Extract from original to preserve any URL-encoded characters
+		if origMatch := m.OAuthURL.FindString(output); origMatch != "" {
+			metadata["oauth_url"] = origMatch
+		} else {
+			metadata["oauth_url"] = match
+		}
+		return StateAwaitingURL, metadata
+	}
+
+	// Check for paste prompt (means URL was shown, awaiting code)
+	if m.PastePrompt != nil && m.PastePrompt.MatchString(normalizedOutput) {
+		// Try to extract URL from original output too
+		if match := m.OAuthURL.FindString(output); match != "" {
+			metadata["oauth_url"] = match
+		}
+		return StateAwaitingURL, metadata
+	}
+
+	// Check for login method selection prompt
+	if m.SelectMethod != nil && m.SelectMethod.MatchString(normalizedOutput) {
+		return StateAwaitingMethodSelect, metadata
+	}
+
+	// Check for rate limit last (lowest priority, as it might be in history)
+	if m.RateLimit.MatchString(normalizedOutput) {
+		if m.ResetTime != nil {
+			if match := m.ResetTime.FindStringSubmatch(normalizedOutput); len(match) > 1 {
+				metadata["reset_time"] = strings.TrimSpace(match[1])
+			}
+		}
+		return StateRateLimited, metadata
+	}
+
+	return StateIdle, metadata
+}
+
+// ExtractOAuthURL finds and returns the OAuth URL from output, using
+// ANSI-stripped output so escape codes never end up in the URL.
+func (m *ProviderMachine) ExtractOAuthURL(output string) string {
+	return m.OAuthURL.FindString(StripANSI(output))
+}
+
+// HasPrompt reports whether the bottom of a pane's output shows the CLI's UI.
+func (m *ProviderMachine) HasPrompt(output string) bool {
+	lines := strings.Split(strings.TrimRight(StripANSI(output), "\n \t"), "\n")
+	if len(lines) > claudePromptTailLines {
+		lines = lines[len(lines)-claudePromptTailLines:]
+	}
+	return m.Prompt.MatchString(strings.Join(lines, "\n"))
+}
+
+// deliverCallback completes a localhost OAuth redirect by requesting the
+// callback URL the local agent returned. Only loopback URLs are accepted,
+// so a bad response can't make the coordinator fetch arbitrary hosts.
+func deliverCallback(ctx context.Context, callback string) error {
+	u, err := url.Parse(strings.TrimSpace(callback))
+	if err != nil {
+		return fmt.Errorf("parse callback URL: %w", err)
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+	default:
+		return fmt.Errorf("callback URL must point at localhost, got %q", u.Host)
+	}
+	if u.Scheme != "http" {
+		return fmt.Errorf("callback URL must use http, got %q", u.Scheme)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("deliver callback: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("login server rejected callback: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package coordinator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProviderMachineDetectState(t *testing.T) {
+	tests := []struct {
+		name     string
+		machine  *ProviderMachine
+		output   string
+		expected PaneState
+		reset    string
+	}{
+		{"claude rate limit", ClaudeMachine, "You've hit your limit · resets 2pm (America/New_York)", StateRateLimited, "2pm"},
+		{"codex rate limit", CodexMachine, "■ You've hit your usage limit. Upgrade to Pro or try again in 2 hours 14 minutes.", StateRateLimited, "2 hours 14 minutes"},
+		{"codex method select", CodexMachine, "> 1. Sign in with ChatGPT\n  2. Provide your own API key", StateAwaitingMethodSelect, ""},
+		{"codex oauth url", CodexMachine, "If the link doesn't open automatically, open the following link to authenticate:\nhttps://auth.openai.com/oauth/authorize?response_type=code&client_id=app_x&redirect_uri=http%3A%2F%2Flocalhost%3A1455%2Fauth%2Fcallback", StateAwaitingURL, ""},
+		{"codex success", CodexMachine, "✔ Signed in with your ChatGPT account", StateResuming, ""},
+		{"gemini rate limit", GeminiMachine, "✕ [API Error: Quota exceeded for quota metric 'Gemini 2.5 Pro Requests']", StateRateLimited, ""},
+		{"gemini method select", GeminiMachine, "Select Auth Method\n● 1. Login with Google\n○ 2. Use Gemini API Key", StateAwaitingMethodSelect, ""},
+		{"gemini paste prompt", GeminiMachine, "Please visit the following URL to authorize the application:\n\nhttps://accounts.google.com/o/oauth2/v2/auth?redirect_uri=https%3A%2F%2Fcodeassist.google.com%2Fauthcode&client_id=x\n\nEnter the authorization code:", StateAwaitingURL, ""},
+		{"gemini failure", GeminiMachine, "Failed to login. Message: invalid_grant", StateFailed, ""},
+		{"claude url ignored by codex", CodexMachine, "https://claude.ai/oauth/authorize?code=true", StateIdle, ""},
+		{"codex limit ignored by claude", ClaudeMachine, "You've hit your usage limit. Try again in 2 hours.", StateIdle, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, metadata := tt.machine.DetectState(tt.output)
+			if state != tt.expected {
+				t.Errorf("DetectState() = %v, want %v", state, tt.expected)
+			}
+			if tt.reset != "" && metadata["reset_time"] != tt.reset {
+				t.Errorf("reset_time = %q, want %q", metadata["reset_time"], tt.reset)
+			}
+		})
+	}
+}
+
+func TestProviderMachineExtractOAuthURL(t *testing.T) {
+	codex := "\x1b[36mhttps://auth.openai.com/oauth/authorize?response_type=code&state=abc\x1b[0m"
+	if got := CodexMachine.ExtractOAuthURL(codex); got != "https://auth.openai.com/oauth/authorize?response_type=code&state=abc" {
+		t.Errorf("codex URL = %q", got)
+	}
+
+	gemini := "https://accounts.google.com/o/oauth2/v2/auth?client_id=x&scope=openid\nEnter the authorization code:"
+	if got := GeminiMachine.ExtractOAuthURL(gemini); got != "https://accounts.google.com/o/oauth2/v2/auth?client_id=x&scope=openid" {
+		t.Errorf("gemini URL = %q", got)
+	}
+
+	if got := GeminiMachine.ExtractOAuthURL("https://accounts.google.com/signin?continue=x"); got != "" {
+		t.Errorf("non-OAuth Google URL should not match, got %q", got)
+	}
+}
+
+func TestDetectProvider(t *testing.T) {
+	tests := []struct {
+		name   string
+		pane   Pane
+		output string
+		want   string
+	}{
+		{"process codex", Pane{ForegroundProcess: "/usr/local/bin/codex"}, "", ProviderCodex},
+		{"process gemini", Pane{ForegroundProcess: "gemini"}, "", ProviderGemini},
+		{"title", Pane{ForegroundProcess: "node", Title: "Gemini - myproject"}, "", ProviderGemini},
+		{"output marker", Pane{ForegroundProcess: "node"}, ">_ OpenAI Codex (v0.46.0)\n\n▌ fix the tests", ProviderCodex},
+		{"latest marker wins", Pane{}, "Claude Code v2.0\n$ exit\n$ gemini\nGemini CLI\nUsing: 1 GEMINI.md file", ProviderGemini},
+		{"default", Pane{Title: "zsh"}, "$ ls", ProviderClaude},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectProvider(tt.pane, tt.output).Name; got != tt.want {
+				t.Errorf("DetectProvider() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMachineFor(t *testing.T) {
+	if MachineFor(ProviderGemini) != GeminiMachine {
+		t.Error("MachineFor(gemini) should return GeminiMachine")
+	}
+	if MachineFor("") != ClaudeMachine || MachineFor("unknown") != ClaudeMachine {
+		t.Error("MachineFor should default to ClaudeMachine")
+	}
+}
+
+func TestCoordinator_GeminiLoginSequence(t *testing.T) {
+	client := &fakePaneClient{
+		panes:  []Pane{{PaneID: 1, ForegroundProcess: "gemini"}},
+		output: "✕ [API Error: RESOURCE_EXHAUSTED: Quota exceeded]",
+	}
+
+	coord := New(DefaultConfig())
+	coord.paneClient = client
+	ctx := context.Background()
+
+	coord.processPaneState(ctx, client.panes[0])
+	tracker := coord.trackers[1]
+	if tracker.GetProvider() != ProviderGemini {
+		t.Fatalf("provider = %q, want %q", tracker.GetProvider(), ProviderGemini)
+	}
+	if tracker.GetState() != StateRateLimited {
+		t.Fatalf("state = %v, want %v", tracker.GetState(), StateRateLimited)
+	}
+
+	client.mu.Lock()
+	client.output = "Select Auth Method\n● 1. Login with Google"
+	client.mu.Unlock()
+	coord.processPaneState(ctx, client.panes[0])
+
+	client.mu.Lock()
+	client.output = "https://accounts.google.com/o/oauth2/v2/auth?client_id=x\nEnter the authorization code:"
+	client.mu.Unlock()
+	coord.processPaneState(ctx, client.panes[0])
+	coord.processPaneState(ctx, client.panes[0])
+
+	if tracker.GetState() != StateAuthPending {
+		t.Fatalf("state = %v, want %v", tracker.GetState(), StateAuthPending)
+	}
+	pending := coord.GetPendingRequests()
+	if len(pending) != 1 || pending[0].Provider != ProviderGemini {
+		t.Fatalf("pending = %+v, want one gemini request", pending)
+	}
+
+	sent := client.sentText()
+	if len(sent) != 2 || sent[0] != "/auth\n" || sent[1] != "\n" {
+		t.Fatalf("sent = %q, want [\"/auth\\n\" \"\\n\"]", sent)
+	}
+}
+
+func TestCoordinator_CodexCallbackDelivery(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.RequestURI()
+	}))
+	defer srv.Close()
+
+	client := &fakePaneClient{panes: []Pane{{PaneID: 1}}, output: "Finish signing in via your browser"}
+	coord := New(DefaultConfig())
+	coord.paneClient = client
+
+	tracker := NewPaneTracker(1)
+	tracker.SetProvider(ProviderCodex)
+	tracker.SetState(StateCodeReceived)
+	tracker.SetAuthResponse(srv.URL+"/auth/callback?code=abc&state=xyz", "user@example.com")
+	coord.trackers[1] = tracker
+
+	coord.handleCodeReceivedState(context.Background(), tracker, client.output)
+
+	if tracker.GetState() != StateAwaitingConfirm {
+		t.Fatalf("state = %v, want %v (error %q)", tracker.GetState(), StateAwaitingConfirm, tracker.GetErrorMessage())
+	}
+	if gotPath != "/auth/callback?code=abc&state=xyz" {
+		t.Errorf("callback path = %q", gotPath)
+	}
+	if sent := client.sentText(); len(sent) != 0 {
+		t.Errorf("callback delivery should not type into the pane, sent=%q", sent)
+	}
+}
+
+func TestDeliverCallbackRejectsRemoteHosts(t *testing.T) {
+	for _, u := range []string{
+		"https://evil.example.com/auth/callback?code=abc",
+		"http://10.0.0.5:1455/auth/callback?code=abc",
+		"file:///etc/passwd",
+	} {
+		if err := deliverCallback(context.Background(), u); err == nil {
+			t.Errorf("deliverCallback(%q) should fail", u)
+		}
+	}
+}
+
+func TestGuardAcceptsOtherProviderPrompts(t *testing.T) {
+	guard, err := NewInjectionGuard(GuardConfig{RequireClaudePrompt: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, output := range []string{
+		"■ You've hit your usage limit.\n\n▌ \n ⏎ send   ⌃J newline",
+		"✕ Quota exceeded\n\n> Type your message or @path/to/file",
+	} {
+		if ok, reason := guard.Check(Pane{PaneID: 1}, output, time.Now()); !ok {
+			t.Errorf("Check(%q) blocked: %s", strings.SplitN(output, "\n", 2)[0], reason)
+		}
+	}
+}
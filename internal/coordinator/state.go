@@ -60,6 +60,7 @@ func (s PaneState) String() string {
 // PaneTracker tracks the state of a single pane.
 type PaneTracker struct {
 	PaneID        int
+	Provider      string // CLI running in the pane (ProviderClaude, ...)
 	State         PaneState
 	LastCheck     time.Time
 	StateEntered  time.Time
@@ -94,6 +95,25 @@ func (t *PaneTracker) SetState(state PaneState) {
 	t.StateEntered = time.Now()
 }
 
+// GetProvider returns the provider detected for the pane.
+func (t *PaneTracker) GetProvider() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.Provider
+}
+
+// SetProvider sets the provider detected for the pane.
+func (t *PaneTracker) SetProvider(provider string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Provider = provider
+}
+
+// Machine returns the state machine for the pane's provider.
+func (t *PaneTracker) Machine() *ProviderMachine {
+	return MachineFor(t.GetProvider())
+}
+
 // GetState returns the current state.
 func (t *PaneTracker) GetState() PaneState {
 	t.mu.RLock()
@@ -297,69 +317,15 @@ func StripANSI(s string) string {
 	return ansi.Strip(s)
 }
 
-// DetectState analyzes pane output and returns the detected state.
-// Output is ANSI-normalized before pattern matching to handle colored terminal output.
+// DetectState analyzes Claude Code pane output and returns the detected
+// state. Use ProviderMachine.DetectState for other CLIs.
 func DetectState(output string) (PaneState, map[string]string) {
-	metadata := make(map[string]string)
-
-	// Strip ANSI codes for reliable pattern matching
-	normalizedOutput := StripANSI(output)
-
-	// Check for login success first (highest priority)
-	if Patterns.LoginSuccess.MatchString(normalizedOutput) {
-		return StateResuming, metadata
-	}
-
-	// Check for login failure
-	if Patterns.LoginFailed.MatchString(normalizedOutput) {
-		return StateFailed, metadata
-	}
-
-	// Check for OAuth URL (implies awaiting URL state)
-	// Note: URLs should still be extracted from original output to preserve full URL
-	if match := Patterns.OAuthURL.FindString(normalizedOutput); match != "" {
-		// Extract from original to preserve any URL-encoded characters
-		if origMatch := Patterns.OAuthURL.FindString(output); origMatch != "" {
-			metadata["oauth_url"] = origMatch
-		} else {
-			metadata["oauth_url"] = match
-		}
-		return StateAwaitingURL, metadata
-	}
-
-	// Check for paste prompt (means URL was shown, awaiting code)
-	if Patterns.PastePrompt.MatchString(normalizedOutput) {
-		// Try to extract URL from original output too
-		if match := Patterns.OAuthURL.FindString(output); match != "" {
-			metadata["oauth_url"] = match
-		}
-		return StateAwaitingURL, metadata
-	}
-
-	// Check for login method selection prompt
-	if Patterns.SelectMethod.MatchString(normalizedOutput) {
-		return StateAwaitingMethodSelect, metadata
-	}
-
-	// Check for rate limit last (lowest priority, as it might be in history)
-	if Patterns.RateLimit.MatchString(normalizedOutput) {
-		if match := Patterns.UsageLimitReset.FindStringSubmatch(normalizedOutput); len(match) > 1 {
-			metadata["reset_time"] = match[1]
-		}
-		return StateRateLimited, metadata
-	}
-
-	return StateIdle, metadata
+	return ClaudeMachine.DetectState(output)
 }
 
-// ExtractOAuthURL finds and returns the OAuth URL from output.
-// Uses ANSI-stripped output to ensure clean URL extraction without
-// terminal escape codes contaminating the URL.
+// ExtractOAuthURL finds and returns the Claude OAuth URL from output.
 func ExtractOAuthURL(output string) string {
-	// Always use normalized output for URL extraction to avoid ANSI codes
-	// being captured as part of the URL (e.g., trailing \x1b[0m)
-	normalizedOutput := StripANSI(output)
-	return Patterns.OAuthURL.FindString(normalizedOutput)
+	return ClaudeMachine.ExtractOAuthURL(output)
 }
 
 // DetectCompactingBanner checks if the output contains a Claude Code compacting banner.
@@ -47,7 +47,9 @@ func (c *TmuxClient) ListPanes(ctx context.Context) ([]Pane, error) {
 	// #{pane_active} - 1 if active, 0 otherwise
 	// #{pane_width} - width in columns
 	// #{pane_height} - height in rows
-	format := "#{pane_id}\t#{session_name}\t#{window_index}\t#{pane_index}\t#{pane_title}\t#{pane_current_path}\t#{pane_active}\t#{pane_width}\t#{pane_height}"
+	// #{pane_current_command} - foreground program
+	// #{pane_tty} - pane TTY
+	format := "#{pane_id}\t#{session_name}\t#{window_index}\t#{pane_index}\t#{pane_title}\t#{pane_current_path}\t#{pane_active}\t#{pane_width}\t#{pane_height}\t#{pane_current_command}\t#{pane_tty}"
 
 	cmd := exec.CommandContext(ctx, c.binaryPath, "list-panes", "-a", "-F", format)
 	var stdout, stderr bytes.Buffer
@@ -113,7 +115,7 @@ func (c *TmuxClient) parsePaneLine(line string) (Pane, error) {
 	paneIndex := parts[3]
 	domain := fmt.Sprintf("%s:%s.%s", sessionName, parts[2], paneIndex)
 
-	return Pane{
+	pane := Pane{
 		PaneID:   paneID,
 		WindowID: windowIndex, // Map to window index
 		TabID:    0,           // tmux doesn't have tabs
@@ -124,7 +126,12 @@ func (c *TmuxClient) parsePaneLine(line string) (Pane, error) {
 		Cols:     cols,
 		Rows:     rows,
 		// Note: tmux doesn't provide cursor position or foreground PID easily
-	}, nil
+	}
+	if len(parts) >= 11 {
+		pane.ForegroundProcess = parts[9]
+		pane.TTYName = parts[10]
+	}
+	return pane, nil
 }
 
 // GetText retrieves text content from a pane.
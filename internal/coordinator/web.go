@@ -0,0 +1,88 @@
+package coordinator
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// indexTemplate is a small page for completing logins by hand, e.g. from a
+// phone: open each pending URL, sign in, and paste the code back.
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>caam auth coordinator</title>
+<style>
+body { font-family: -apple-system, system-ui, sans-serif; margin: 1rem; max-width: 40rem; }
+.req { border: 1px solid #ccc; border-radius: 8px; padding: 0.75rem; margin-bottom: 1rem; }
+.meta { color: #666; font-size: 0.9rem; }
+a.open { display: inline-block; margin: 0.5rem 0; font-size: 1.1rem; }
+input[type=text] { width: 100%; box-sizing: border-box; padding: 0.5rem; font-size: 1rem; }
+button { margin-top: 0.5rem; padding: 0.5rem 1rem; font-size: 1rem; }
+.ok { color: #080; }
+</style>
+</head>
+<body>
+<h1>Pending logins</h1>
+{{if .Paused}}<p><strong>Coordinator is paused.</strong></p>{{end}}
+{{if .Submitted}}<p class="ok">Code submitted for {{.Submitted}}.</p>{{end}}
+{{range .Requests}}
+<div class="req">
+  <div class="meta">Pane {{.PaneID}}{{if .Provider}} · {{.Provider}}{{end}} · waiting {{.Age}}</div>
+  <a class="open" href="{{.URL}}" target="_blank" rel="noopener noreferrer">Open login page</a>
+  <form method="post" action="/auth/response{{$.TokenQuery}}">
+    <input type="hidden" name="request_id" value="{{.ID}}">
+    <input type="text" name="code" placeholder="{{if eq .Provider "codex"}}Paste the full localhost URL from the address bar{{else}}Paste the code{{end}}" autocomplete="off" autocapitalize="off" required>
+    <button type="submit">Submit</button>
+  </form>
+</div>
+{{else}}
+<p>No pending logins.</p>
+{{end}}
+<p><a href="/{{.TokenQuery}}">Refresh</a></p>
+</body>
+</html>
+`))
+
+type indexRequest struct {
+	*AuthRequest
+	Age string
+}
+
+type indexData struct {
+	Requests   []indexRequest
+	Paused     bool
+	Submitted  string
+	TokenQuery template.URL
+}
+
+func (a *APIServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	pending := a.coordinator.GetPendingRequests()
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+
+	data := indexData{
+		Paused:    a.coordinator.IsPaused(),
+		Submitted: r.URL.Query().Get("submitted"),
+	}
+	if token := r.URL.Query().Get("token"); token != "" {
+		data.TokenQuery = template.URL("?token=" + template.URLQueryEscaper(token))
+	}
+	for _, req := range pending {
+		data.Requests = append(data.Requests, indexRequest{
+			AuthRequest: req,
+			Age:         time.Since(req.CreatedAt).Round(time.Second).String(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	if err := indexTemplate.Execute(w, data); err != nil {
+		a.logger.Error("render index", "error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package coordinator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newWebTestAPI(t *testing.T, token string) (*APIServer, *PaneTracker) {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.AuthToken = token
+	coord := New(cfg)
+	coord.paneClient = &fakePaneClient{}
+
+	tracker := NewPaneTracker(1)
+	tracker.SetProvider(ProviderGemini)
+	tracker.SetState(StateAuthPending)
+	tracker.SetRequestID("req-1")
+	coord.trackers[1] = tracker
+	coord.requests["req-1"] = &AuthRequest{
+		ID:        "req-1",
+		PaneID:    1,
+		URL:       "https://accounts.google.com/o/oauth2/v2/auth?client_id=x&state=<s>",
+		Provider:  ProviderGemini,
+		CreatedAt: time.Now().Add(-time.Minute),
+		Status:    "pending",
+	}
+
+	return NewAPIServer(coord, 0, nil), tracker
+}
+
+func TestAPIIndexPage(t *testing.T) {
+	api, _ := newWebTestAPI(t, "s3cret")
+
+	req := httptest.NewRequest("GET", "/?token=s3cret", nil)
+	w := httptest.NewRecorder()
+	api.server.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`name="request_id" value="req-1"`,
+		`action="/auth/response?token=s3cret"`,
+		`href="https://accounts.google.com/o/oauth2/v2/auth?client_id=x&amp;state=%3cs%3e"`,
+		"gemini",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+}
+
+func TestAPIIndexRequiresToken(t *testing.T) {
+	api, _ := newWebTestAPI(t, "s3cret")
+
+	for _, target := range []string{"/", "/?token=wrong"} {
+		w := httptest.NewRecorder()
+		api.server.Handler.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("GET %s status = %d, want 401", target, w.Code)
+		}
+	}
+}
+
+func TestAPIAuthResponseForm(t *testing.T) {
+	api, tracker := newWebTestAPI(t, "s3cret")
+
+	form := url.Values{"request_id": {"req-1"}, "code": {"  4/0Axyz  "}}
+	req := httptest.NewRequest("POST", "/auth/response?token=s3cret", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	api.server.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want 303", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/?submitted=req-1&token=s3cret" {
+		t.Errorf("Location = %q", loc)
+	}
+	if tracker.GetReceivedCode() != "4/0Axyz" {
+		t.Errorf("code = %q, want %q", tracker.GetReceivedCode(), "4/0Axyz")
+	}
+	if tracker.GetUsedAccount() != "manual" {
+		t.Errorf("account = %q, want manual", tracker.GetUsedAccount())
+	}
+}
+
+func TestAPIAuthResponseJSON(t *testing.T) {
+	api, tracker := newWebTestAPI(t, "s3cret")
+
+	req := httptest.NewRequest("POST", "/auth/response",
+		strings.NewReader(`{"request_id":"req-1","code":"ABC","account":"me@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w := httptest.NewRecorder()
+	api.server.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if tracker.GetReceivedCode() != "ABC" || tracker.GetUsedAccount() != "me@example.com" {
+		t.Errorf("tracker = (%q, %q)", tracker.GetReceivedCode(), tracker.GetUsedAccount())
+	}
+}
+
+func TestAPIServerHost(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.APIHost = "100.64.0.5"
+	api := NewAPIServer(New(cfg), 7890, nil)
+	if api.server.Addr != "100.64.0.5:7890" {
+		t.Errorf("Addr = %q, want 100.64.0.5:7890", api.server.Addr)
+	}
+
+	api = NewAPIServer(New(DefaultConfig()), 7890, nil)
+	if api.server.Addr != "127.0.0.1:7890" {
+		t.Errorf("default Addr = %q, want 127.0.0.1:7890", api.server.Addr)
+	}
+}
@@ -41,6 +41,11 @@ type Pane struct {
 	Rows         int    `json:"size,omitempty"`
 	Cols         int    `json:"cols,omitempty"`
 	ForegroundPID int   `json:"foreground_process_id,omitempty"`
+
+	// ForegroundProcess is the name of the program in the foreground, when
+	// the backend reports it (tmux does; WezTerm is resolved from TTYName).
+	ForegroundProcess string `json:"foreground_process_name,omitempty"`
+	TTYName           string `json:"tty_name,omitempty"`
 }
 
 // ListPanes returns all panes across all windows.
@@ -0,0 +1,261 @@
+// Package coordinator implements the auth recovery coordinator daemon.
+package coordinator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ZellijClient wraps the zellij CLI for pane operations.
+//
+// Zellij's CLI actions (dump-screen, write-chars) act on the focused pane of
+// a session, so this backend only sees the pane each connected client has
+// focused, as reported by `zellij action list-clients`. Before reading or
+// writing, the client checks that the target pane is still focused, so text
+// never lands in a pane the user has since switched to.
+//
+// Zellij pane IDs ("terminal_3") are only unique within a session. Each
+// session/pane pair is given a stable integer PaneID for the life of the
+// client. Domain is the session name.
+type ZellijClient struct {
+	binaryPath string
+
+	mu     sync.Mutex
+	ids    map[zellijPaneRef]int
+	refs   map[int]zellijPaneRef
+	nextID int
+}
+
+// zellijPaneRef identifies a pane within a Zellij session.
+type zellijPaneRef struct {
+	session string
+	pane    string // e.g. "terminal_3"
+}
+
+// NewZellijClient creates a new zellij CLI client.
+func NewZellijClient() *ZellijClient {
+	return &ZellijClient{
+		binaryPath: "zellij",
+		ids:        make(map[zellijPaneRef]int),
+		refs:       make(map[int]zellijPaneRef),
+		nextID:     1,
+	}
+}
+
+// ListPanes returns the focused terminal pane of every client attached to a
+// running session.
+func (c *ZellijClient) ListPanes(ctx context.Context) ([]Pane, error) {
+	sessions, err := c.listSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var panes []Pane
+	for _, session := range sessions {
+		clients, err := c.listClients(ctx, session)
+		if err != nil {
+			continue // Exited or unreachable session
+		}
+		for _, cl := range clients {
+			ref := zellijPaneRef{session: session, pane: cl.pane}
+			panes = append(panes, Pane{
+				PaneID:            c.paneID(ref),
+				Domain:            session,
+				Title:             cl.pane,
+				IsActive:          true,
+				ForegroundProcess: cl.command,
+			})
+		}
+	}
+	return panes, nil
+}
+
+// GetText retrieves text content from a pane.
+// startLine is negative for lines from the end (e.g., -50 for last 50 lines);
+// zero returns the visible screen.
+func (c *ZellijClient) GetText(ctx context.Context, paneID int, startLine int) (string, error) {
+	ref, err := c.focusedRef(ctx, paneID)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp("", "caam-zellij-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("create dump file: %w", err)
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	args := []string{"action", "dump-screen"}
+	if startLine < 0 {
+		args = append(args, "--full")
+	}
+	if _, err := c.run(ctx, ref.session, append(args, path)...); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read dump file: %w", err)
+	}
+	text := string(data)
+	if startLine < 0 {
+		text = lastLines(text, -startLine)
+	}
+	return text, nil
+}
+
+// SendText injects text into a pane as typed characters. Zellij has no
+// paste mode for CLI input, so noPaste has no effect.
+func (c *ZellijClient) SendText(ctx context.Context, paneID int, text string, noPaste bool) error {
+	ref, err := c.focusedRef(ctx, paneID)
+	if err != nil {
+		return err
+	}
+	_, err = c.run(ctx, ref.session, "action", "write-chars", text)
+	return err
+}
+
+// IsAvailable checks if zellij is installed and a session is running.
+func (c *ZellijClient) IsAvailable(ctx context.Context) bool {
+	sessions, err := c.listSessions(ctx)
+	return err == nil && len(sessions) > 0
+}
+
+// Backend returns the backend name.
+func (c *ZellijClient) Backend() string {
+	return "zellij"
+}
+
+// paneID returns the stable integer ID for a session pane.
+func (c *ZellijClient) paneID(ref zellijPaneRef) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if id, ok := c.ids[ref]; ok {
+		return id
+	}
+	id := c.nextID
+	c.nextID++
+	c.ids[ref] = id
+	c.refs[id] = ref
+	return id
+}
+
+// focusedRef resolves paneID and checks that the pane is still focused, since
+// zellij actions always target the focused pane.
+func (c *ZellijClient) focusedRef(ctx context.Context, paneID int) (zellijPaneRef, error) {
+	c.mu.Lock()
+	ref, ok := c.refs[paneID]
+	c.mu.Unlock()
+	if !ok {
+		return zellijPaneRef{}, fmt.Errorf("unknown zellij pane %d", paneID)
+	}
+
+	clients, err := c.listClients(ctx, ref.session)
+	if err != nil {
+		return zellijPaneRef{}, err
+	}
+	for _, cl := range clients {
+		if cl.pane == ref.pane {
+			return ref, nil
+		}
+	}
+	return zellijPaneRef{}, fmt.Errorf("zellij pane %s in session %s is no longer focused", ref.pane, ref.session)
+}
+
+// listSessions returns the names of running sessions.
+func (c *ZellijClient) listSessions(ctx context.Context) ([]string, error) {
+	stdout, err := c.run(ctx, "", "list-sessions", "--short", "--no-formatting")
+	if err != nil {
+		if strings.Contains(err.Error(), "No active zellij sessions") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var sessions []string
+	for _, line := range strings.Split(string(stdout), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			sessions = append(sessions, name)
+		}
+	}
+	return sessions, nil
+}
+
+// zellijClientPane is one row of `zellij action list-clients`.
+type zellijClientPane struct {
+	pane    string
+	command string
+}
+
+// listClients returns the focused terminal pane of each client in session.
+func (c *ZellijClient) listClients(ctx context.Context, session string) ([]zellijClientPane, error) {
+	stdout, err := c.run(ctx, session, "action", "list-clients")
+	if err != nil {
+		return nil, err
+	}
+	return parseZellijClients(string(stdout)), nil
+}
+
+// parseZellijClients parses `zellij action list-clients` output:
+//
+//	CLIENT_ID ZELLIJ_PANE_ID RUNNING_COMMAND
+//	1         terminal_3     claude --resume
+//
+// Plugin panes are skipped, and a pane focused by several clients is
+// listed once.
+func parseZellijClients(out string) []zellijClientPane {
+	var panes []zellijClientPane
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] == "CLIENT_ID" {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			continue
+		}
+		pane := fields[1]
+		if !strings.HasPrefix(pane, "terminal_") || seen[pane] {
+			continue
+		}
+		seen[pane] = true
+
+		p := zellijClientPane{pane: pane}
+		if len(fields) > 2 {
+			p.command = filepath.Base(fields[2])
+		}
+		panes = append(panes, p)
+	}
+	return panes
+}
+
+// run executes a zellij command, against session if one is given, and
+// returns its stdout.
+func (c *ZellijClient) run(ctx context.Context, session string, args ...string) ([]byte, error) {
+	// Name the command without its arguments, which may be injected text.
+	name := args[0]
+	if name == "action" && len(args) > 1 {
+		name += " " + args[1]
+	}
+	if session != "" {
+		args = append([]string{"--session", session}, args...)
+	}
+	cmd := exec.CommandContext(ctx, c.binaryPath, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("zellij %s: %w (stderr: %s)", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+// startAnnounce serves the sync beacon on AnnounceListen and advertises it
+// on the LAN over mDNS until the daemon stops.
+func (d *Daemon) startAnnounce() {
+	ln, err := net.Listen("tcp", d.config.AnnounceListen)
+	if err != nil {
+		d.logger.Printf("Warning: failed to start sync announce: %v", err)
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(syncstate.BeaconPath, syncstate.BeaconHandler(func() syncstate.BeaconInfo {
+		return syncstate.LocalBeaconInfo(d.vault)
+	}))
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	d.wg.Add(3)
+	go func() {
+		defer d.wg.Done()
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			d.logger.Printf("Sync announce stopped: %v", err)
+		}
+	}()
+	go func() {
+		defer d.wg.Done()
+		<-d.ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}()
+	go func() {
+		defer d.wg.Done()
+		port := ln.Addr().(*net.TCPAddr).Port
+		if err := syncstate.AnnounceBeacon(d.ctx, port, syncstate.LocalBeaconInfo(d.vault)); err != nil {
+			// The beacon still answers Tailscale peers.
+			d.logger.Printf("Warning: mDNS announce failed: %v", err)
+		}
+	}()
+	d.logger.Printf("Sync beacon listening on %s", ln.Addr())
+}
@@ -0,0 +1,192 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+// DefaultCooldownProbeInterval is how often the daemon looks for cooldowns
+// that have just expired. It is shorter than the refresh check interval so
+// profiles are verified soon after their advertised reset.
+const DefaultCooldownProbeInterval = time.Minute
+
+// DefaultCooldownExtension is how long a cooldown is extended when the probe
+// finds the profile still blocked and the provider gives no reset time.
+const DefaultCooldownExtension = 30 * time.Minute
+
+// CooldownProbeLookback bounds how far back expired cooldowns are probed, so
+// a daemon started after a long break doesn't probe week-old limits.
+const CooldownProbeLookback = 24 * time.Hour
+
+// Probe results recorded on the cooldown entry.
+const (
+	ProbeResultPassed   = "passed"
+	ProbeResultExtended = "extended"
+)
+
+// ProbeOutcome describes one end-of-cooldown probe.
+type ProbeOutcome struct {
+	Provider      string
+	Profile       string
+	Passed        bool
+	Reason        string    // Why the probe failed
+	ExtendedUntil time.Time // New cooldown end when the probe failed
+}
+
+// CooldownProber verifies profiles whose cooldown has just expired before
+// they are treated as healthy again. Providers sometimes keep a block in
+// place past the advertised reset, so the prober runs a passive token check
+// and, optionally, a usage API ping. A profile that passes has its error
+// count cleared; one that fails gets its cooldown extended with a note.
+type CooldownProber struct {
+	db          *caamdb.DB
+	healthStore *health.Storage
+	extension   time.Duration
+	logger      interface {
+		Printf(format string, v ...interface{})
+	}
+
+	// tokenExpiry returns a profile's token expiry (zero if unknown).
+	tokenExpiry func(provider, profile string) (time.Time, error)
+
+	// fetchUsage pings the provider's usage API; nil disables the ping.
+	fetchUsage func(ctx context.Context, provider, profile string) (*usage.UsageInfo, error)
+
+	now func() time.Time
+}
+
+// NewCooldownProber creates a prober. usagePing enables the usage API ping
+// for providers that support it.
+func NewCooldownProber(vault *authfile.Vault, healthStore *health.Storage, db *caamdb.DB, extension time.Duration, usagePing bool, logger interface {
+	Printf(format string, v ...interface{})
+}) *CooldownProber {
+	if extension <= 0 {
+		extension = DefaultCooldownExtension
+	}
+	refresher := NewPoolRefresher(vault, healthStore)
+	p := &CooldownProber{
+		db:          db,
+		healthStore: healthStore,
+		extension:   extension,
+		logger:      logger,
+		tokenExpiry: refresher.getTokenExpiry,
+		now:         time.Now,
+	}
+	if usagePing {
+		p.fetchUsage = func(ctx context.Context, provider, profile string) (*usage.UsageInfo, error) {
+			return fetchProfileUsage(ctx, vault, provider, profile)
+		}
+	}
+	return p
+}
+
+// ProbeExpired probes every cooldown that expired since the lookback window
+// and hasn't been probed yet.
+func (p *CooldownProber) ProbeExpired(ctx context.Context) ([]ProbeOutcome, error) {
+	now := p.now()
+	events, err := p.db.ExpiredUnprobedCooldowns(now.Add(-CooldownProbeLookback), now)
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]ProbeOutcome, 0, len(events))
+	for _, ev := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		outcome := p.probe(ctx, ev, now)
+
+		result := ProbeResultPassed
+		if outcome.Passed {
+			if p.healthStore != nil {
+				if err := p.healthStore.ClearErrors(ev.Provider, ev.ProfileName); err != nil {
+					p.logger.Printf("%s/%s: clear errors after cooldown probe: %v", ev.Provider, ev.ProfileName, err)
+				}
+			}
+			p.logger.Printf("%s/%s: cooldown ended, probe passed", ev.Provider, ev.ProfileName)
+		} else {
+			result = ProbeResultExtended
+			note := fmt.Sprintf("extended after end-of-cooldown probe: %s", outcome.Reason)
+			if _, err := p.db.SetCooldown(ev.Provider, ev.ProfileName, now, outcome.ExtendedUntil.Sub(now), note); err != nil {
+				p.logger.Printf("%s/%s: extend cooldown: %v", ev.Provider, ev.ProfileName, err)
+				continue
+			}
+			p.logger.Printf("%s/%s: cooldown extended until %s (%s)", ev.Provider, ev.ProfileName,
+				outcome.ExtendedUntil.Local().Format(time.Kitchen), outcome.Reason)
+		}
+
+		if err := p.db.MarkCooldownProbed(ev.ID, result, now); err != nil {
+			p.logger.Printf("%s/%s: record cooldown probe: %v", ev.Provider, ev.ProfileName, err)
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes, nil
+}
+
+// probe runs the checks for one expired cooldown.
+func (p *CooldownProber) probe(ctx context.Context, ev caamdb.CooldownEvent, now time.Time) ProbeOutcome {
+	outcome := ProbeOutcome{Provider: ev.Provider, Profile: ev.ProfileName, Passed: true}
+	fail := func(reason string, until time.Time) ProbeOutcome {
+		outcome.Passed = false
+		outcome.Reason = reason
+		outcome.ExtendedUntil = until
+		return outcome
+	}
+
+	// Passive check: the stored token must still be usable.
+	if p.tokenExpiry != nil {
+		if exp, err := p.tokenExpiry(ev.Provider, ev.ProfileName); err == nil && !exp.IsZero() && !exp.After(now) {
+			return fail("token expired", now.Add(p.extension))
+		}
+	}
+
+	if p.fetchUsage == nil {
+		return outcome
+	}
+
+	// The usage ping is best effort: an unreachable API isn't evidence of a
+	// block, so only an explicit at-limit reading fails the probe.
+	probeCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	info, err := p.fetchUsage(probeCtx, ev.Provider, ev.ProfileName)
+	if err != nil || info == nil || info.Error != "" {
+		return outcome
+	}
+	if info.IsNearLimit(1.0) {
+		until := now.Add(p.extension)
+		if ttl := info.TimeUntilReset(); ttl > 0 {
+			until = now.Add(ttl)
+		}
+		return fail("usage still at limit", until)
+	}
+	return outcome
+}
+
+// fetchProfileUsage queries the usage API for one vault profile.
+func fetchProfileUsage(ctx context.Context, vault *authfile.Vault, provider, profile string) (*usage.UsageInfo, error) {
+	var fetcher usage.Fetcher
+	switch provider {
+	case "claude":
+		fetcher = usage.NewClaudeFetcher()
+	case "codex":
+		fetcher = usage.NewCodexFetcher()
+	default:
+		return nil, fmt.Errorf("usage API not supported for %s", provider)
+	}
+
+	creds, err := usage.LoadProfileCredentials(vault.BasePath(), provider)
+	if err != nil {
+		return nil, err
+	}
+	token, ok := creds[profile]
+	if !ok {
+		return nil, fmt.Errorf("no access token for %s/%s", provider, profile)
+	}
+	return fetcher.Fetch(ctx, token)
+}
@@ -0,0 +1,148 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+type discardLogger struct{}
+
+func (discardLogger) Printf(string, ...interface{}) {}
+
+func newTestProber(t *testing.T, now time.Time) (*CooldownProber, *caamdb.DB, *health.Storage) {
+	t.Helper()
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	store := health.NewStorage(filepath.Join(t.TempDir(), "health.json"))
+
+	p := &CooldownProber{
+		db:          db,
+		healthStore: store,
+		extension:   30 * time.Minute,
+		logger:      discardLogger{},
+		tokenExpiry: func(string, string) (time.Time, error) { return now.Add(24 * time.Hour), nil },
+		now:         func() time.Time { return now },
+	}
+	return p, db, store
+}
+
+func TestCooldownProber_PassClearsErrors(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	p, db, store := newTestProber(t, now)
+
+	if _, err := db.SetCooldown("claude", "work", now.Add(-70*time.Minute), time.Hour, "rate limit"); err != nil {
+		t.Fatalf("SetCooldown: %v", err)
+	}
+	if err := store.RecordError("claude", "work", fmt.Errorf("rate limited")); err != nil {
+		t.Fatalf("RecordError: %v", err)
+	}
+
+	outcomes, err := p.ProbeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("ProbeExpired: %v", err)
+	}
+	if len(outcomes) != 1 || !outcomes[0].Passed {
+		t.Fatalf("outcomes = %+v, want one pass", outcomes)
+	}
+
+	ph, _ := store.GetProfile("claude", "work")
+	if ph != nil && ph.ErrorCount1h != 0 {
+		t.Errorf("ErrorCount1h = %d, want 0 after passing probe", ph.ErrorCount1h)
+	}
+
+	// Already probed: nothing to do on the next round.
+	outcomes, err = p.ProbeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("ProbeExpired: %v", err)
+	}
+	if len(outcomes) != 0 {
+		t.Errorf("second round outcomes = %+v, want none", outcomes)
+	}
+}
+
+func TestCooldownProber_ExtendsWhenStillLimited(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	p, db, _ := newTestProber(t, now)
+
+	resetsAt := now.Add(2 * time.Hour)
+	p.fetchUsage = func(context.Context, string, string) (*usage.UsageInfo, error) {
+		return &usage.UsageInfo{
+			PrimaryWindow: &usage.UsageWindow{UsedPercent: 100, ResetsAt: resetsAt},
+		}, nil
+	}
+
+	if _, err := db.SetCooldown("codex", "pool-1", now.Add(-65*time.Minute), time.Hour, ""); err != nil {
+		t.Fatalf("SetCooldown: %v", err)
+	}
+
+	outcomes, err := p.ProbeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("ProbeExpired: %v", err)
+	}
+	if len(outcomes) != 1 || outcomes[0].Passed {
+		t.Fatalf("outcomes = %+v, want one failure", outcomes)
+	}
+
+	active, err := db.ActiveCooldown("codex", "pool-1", now)
+	if err != nil || active == nil {
+		t.Fatalf("ActiveCooldown = %v, %v; want extended cooldown", active, err)
+	}
+	if active.CooldownUntil.Sub(resetsAt).Abs() > time.Minute {
+		t.Errorf("extended until %s, want about %s", active.CooldownUntil, resetsAt)
+	}
+	if !strings.Contains(active.Notes, "usage still at limit") {
+		t.Errorf("notes = %q, want probe reason", active.Notes)
+	}
+}
+
+func TestCooldownProber_ExpiredToken(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	p, db, _ := newTestProber(t, now)
+	p.tokenExpiry = func(string, string) (time.Time, error) { return now.Add(-time.Minute), nil }
+
+	if _, err := db.SetCooldown("claude", "work", now.Add(-70*time.Minute), time.Hour, ""); err != nil {
+		t.Fatalf("SetCooldown: %v", err)
+	}
+
+	outcomes, err := p.ProbeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("ProbeExpired: %v", err)
+	}
+	if len(outcomes) != 1 || outcomes[0].Passed || outcomes[0].Reason != "token expired" {
+		t.Fatalf("outcomes = %+v, want token expired failure", outcomes)
+	}
+	if want := now.Add(30 * time.Minute); !outcomes[0].ExtendedUntil.Equal(want) {
+		t.Errorf("ExtendedUntil = %s, want %s", outcomes[0].ExtendedUntil, want)
+	}
+}
+
+func TestCooldownProber_UsageErrorIsNotABlock(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	p, db, _ := newTestProber(t, now)
+	p.fetchUsage = func(context.Context, string, string) (*usage.UsageInfo, error) {
+		return nil, fmt.Errorf("network unreachable")
+	}
+
+	if _, err := db.SetCooldown("claude", "work", now.Add(-70*time.Minute), time.Hour, ""); err != nil {
+		t.Fatalf("SetCooldown: %v", err)
+	}
+
+	outcomes, err := p.ProbeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("ProbeExpired: %v", err)
+	}
+	if len(outcomes) != 1 || !outcomes[0].Passed {
+		t.Fatalf("outcomes = %+v, want pass when usage API is unreachable", outcomes)
+	}
+}
@@ -6,18 +6,31 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authpool"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/chaos"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/discovery"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/federation"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/refresh"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/signals"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 )
 
 // DefaultCheckInterval is the default time between refresh checks.
@@ -47,6 +60,81 @@ type Config struct {
 	// MaxConcurrentRefreshes limits concurrent refresh operations when using AuthPool.
 	// Default: 3
 	MaxConcurrentRefreshes int
+
+	// AutoDiscover watches live auth files for logins to accounts that are
+	// not in the vault: "off" (default), "suggest", or "auto".
+	AutoDiscover string
+
+	// SyncWatch saves tokens the CLIs refresh themselves to their vault
+	// profiles and pushes changed profiles to the sync pool as they happen.
+	SyncWatch bool
+
+	// NoAutoRefresh lists providers whose tokens must never be refreshed
+	// automatically (automation.<provider>.auto_refresh: false).
+	NoAutoRefresh map[string]bool
+
+	// CooldownProbe verifies profiles whose cooldown just expired and
+	// extends the cooldown if they are still blocked.
+	CooldownProbe bool
+
+	// CooldownProbeUsage adds a usage API ping to the cooldown probe.
+	CooldownProbeUsage bool
+
+	// CooldownExtension is how long to extend a cooldown when the probe
+	// fails without a provider-reported reset time.
+	CooldownExtension time.Duration
+
+	// WatchSnapshot, when set, serves `caam robot watch` clients from one
+	// shared poller over WatchSocketPath() instead of each client scanning
+	// the vault itself.
+	WatchSnapshot WatchSnapshotFunc
+
+	// EventBus, when set, is followed for events that other caam processes
+	// append and streamed to `caam events tail` over EventsSocketPath().
+	EventBus *events.Bus
+
+	// WipePaths are deleted, along with the vault and backups, when a signed
+	// remote wipe order from `caam fleet wipe` is delivered to this machine.
+	WipePaths []string
+
+	// JobCommand is the caam executable used to run jobs queued with
+	// --async. Empty disables job execution.
+	JobCommand string
+
+	// UsageWebhookListen and UsageWebhookHandler serve the provider
+	// usage-alert receiver (daemon.usage_webhook). Both must be set.
+	UsageWebhookListen  string
+	UsageWebhookHandler http.Handler
+
+	// UsageRecordHandler, when set, is served at /usage/record on the same
+	// listener to ingest per-request token usage.
+	UsageRecordHandler http.Handler
+
+	// AnnounceListen, when set, serves this machine's sync beacon there and
+	// advertises it over mDNS so 'caam sync discover --network' finds it
+	// (daemon.announce).
+	AnnounceListen string
+
+	// ApplyPolicies, when set, applies the configured rotation policies on
+	// every check and returns a line for each activation or failure.
+	ApplyPolicies func() ([]string, error)
+
+	// Notifier, when set, sends notifications for cooldowns, failed syncs,
+	// expiring tokens, and providers whose profiles are all blocked.
+	Notifier *notify.Dispatcher
+
+	// ExpiryWarning is how long before a token expires to notify.
+	// Default: DefaultExpiryWarning
+	ExpiryWarning time.Duration
+
+	// BudgetReport, when set, returns the month's spend against the
+	// configured budgets so crossed thresholds can be notified.
+	BudgetReport func(now time.Time) (*usage.MonthReport, error)
+
+	// FederationPeers, when set, lists the other machines' coordinators to
+	// poll every FederationInterval into the federation snapshot.
+	FederationPeers    func() []federation.Peer
+	FederationInterval time.Duration
 }
 
 // DefaultConfig returns the default daemon configuration.
@@ -76,6 +164,24 @@ type Daemon struct {
 	// poolMonitor runs the background token monitoring (may be nil if not enabled)
 	poolMonitor *authpool.Monitor
 
+	// discoveryWatcher backs up or reports new logins (may be nil if disabled)
+	discoveryWatcher *discovery.Watcher
+
+	// cooldownProber verifies expired cooldowns (may be nil if disabled)
+	cooldownProber *CooldownProber
+	cooldownDB     *caamdb.DB
+
+	// watchHub fans robot watch snapshots out to clients (may be nil if disabled)
+	watchHub *WatchHub
+
+	// eventsServing is set once the event stream socket is listening.
+	eventsServing bool
+
+	// budgetNotified is the highest threshold notified per budget in
+	// budgetMonth. Only the run loop touches it.
+	budgetNotified map[string]int
+	budgetMonth    string
+
 	ctx           context.Context
 	cancel        context.CancelFunc
 	configChanged chan struct{} // Signal to reload config in runLoop
@@ -83,6 +189,8 @@ type Daemon struct {
 
 	mu      sync.Mutex
 	running bool
+	paused  bool
+	resumed bool // Set by Resume so runLoop checks immediately
 	stats   Stats
 
 	configMu sync.RWMutex // Protects config access during runtime reloads
@@ -108,6 +216,34 @@ type Stats struct {
 	PoolEnabled       bool
 	PoolMonitorActive bool
 	PoolSummary       *authpool.PoolSummary
+
+	// Discovery stats (when AutoDiscover is not "off")
+	DiscoveryMode   string
+	DiscoveredCount int64
+	SuggestedCount  int64
+
+	// Cooldown probe stats (when CooldownProbe is enabled)
+	CooldownProbes    int64
+	CooldownsExtended int64
+
+	// Watch hub stats (when WatchSnapshot is set)
+	WatchSubscribers int
+	WatchScans       int64
+
+	// Event stream subscribers (when EventBus is set)
+	EventSubscribers int
+
+	// Pause state (see Pause)
+	Paused   bool
+	PausedAt time.Time
+}
+
+// autoRefreshAllowed reports whether automation may refresh a provider's
+// tokens.
+func (d *Daemon) autoRefreshAllowed(provider string) bool {
+	d.configMu.RLock()
+	defer d.configMu.RUnlock()
+	return !d.config.NoAutoRefresh[provider]
 }
 
 // getCheckInterval returns the check interval with proper locking.
@@ -175,9 +311,96 @@ func New(vault *authfile.Vault, healthStore *health.Storage, cfg *Config) *Daemo
 		d.initAuthPool()
 	}
 
+	// Initialize login discovery if enabled
+	if cfg.AutoDiscover == "suggest" || cfg.AutoDiscover == "auto" {
+		d.initDiscovery()
+	}
+
+	// Initialize end-of-cooldown probing if enabled
+	if cfg.CooldownProbe {
+		d.initCooldownProber()
+	}
+
+	if cfg.Notifier != nil {
+		d.initNotifications()
+	}
+
 	return d
 }
 
+// runSyncWatch saves refreshed tokens and pushes changed profiles until the
+// daemon stops. Changes made while the daemon is paused are left alone.
+func (d *Daemon) runSyncWatch() {
+	d.logger.Println("Sync watch started")
+	err := syncstate.Watch(d.ctx, syncstate.WatchConfig{
+		Vault:  d.vault,
+		Paused: d.IsPaused,
+		OnEvent: func(e syncstate.WatchEvent) {
+			if e.Action == syncstate.WatchSkipped && !d.isVerbose() {
+				return
+			}
+			msg := e.Message
+			if e.Err != nil {
+				msg += ": " + e.Err.Error()
+			}
+			d.logger.Printf("Sync watch: %s %s/%s: %s", e.Action, e.Provider, e.Profile, msg)
+		},
+	})
+	if err != nil {
+		d.logger.Printf("Warning: sync watch stopped: %v", err)
+	}
+}
+
+// initCooldownProber opens the activity database that holds cooldowns and
+// sets up the prober. Probing is skipped if the database can't be opened.
+func (d *Daemon) initCooldownProber() {
+	db, err := caamdb.Open()
+	if err != nil {
+		d.logger.Printf("Warning: cooldown probe disabled (open database: %v)", err)
+		return
+	}
+	d.cooldownDB = db
+	d.cooldownProber = NewCooldownProber(d.vault, d.healthStore, db, d.config.CooldownExtension, d.config.CooldownProbeUsage, d.logger)
+}
+
+// initDiscovery sets up the auth file watcher that catches manual logins
+// before the next activation overwrites them. Logins made while the daemon
+// is paused are neither saved nor suggested.
+func (d *Daemon) initDiscovery() {
+	level := slog.LevelInfo
+	if d.config.Verbose {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(d.logger.Writer(), &slog.HandlerOptions{Level: level}))
+
+	watcher, err := discovery.NewWatcher(d.vault, discovery.WatcherConfig{
+		Logger:      logger,
+		SuggestOnly: d.config.AutoDiscover == "suggest",
+		OnDiscovery: func(provider, name string, ident *identity.Identity) {
+			d.mu.Lock()
+			d.stats.DiscoveredCount++
+			d.mu.Unlock()
+			d.logger.Printf("Discovery: saved %s login as profile %s/%s", provider, provider, name)
+		},
+		OnSuggest: func(provider, name string, ident *identity.Identity) {
+			d.mu.Lock()
+			d.stats.SuggestedCount++
+			d.mu.Unlock()
+			d.logger.Printf("Discovery: %s login is not in the vault; save it with: caam backup %s %s",
+				provider, provider, name)
+		},
+		OnError: func(err error) {
+			d.logger.Printf("Discovery error: %v", err)
+		},
+		Paused: d.IsPaused,
+	})
+	if err != nil {
+		d.logger.Printf("Warning: failed to create discovery watcher: %v", err)
+		return
+	}
+	d.discoveryWatcher = watcher
+}
+
 // initAuthPool sets up the AuthPool and its monitor.
 func (d *Daemon) initAuthPool() {
 	// Create pool with callbacks for logging
@@ -216,6 +439,9 @@ func (d *Daemon) initAuthPool() {
 				d.logger.Printf("Pool: starting refresh for %s/%s", provider, profile)
 			}
 		},
+		SkipRefresh: func(provider, profile string) bool {
+			return !d.autoRefreshAllowed(provider)
+		},
 		OnRefreshComplete: func(provider, profile string, newExpiry time.Time, err error) {
 			d.mu.Lock()
 			if err != nil {
@@ -251,6 +477,7 @@ func (d *Daemon) Start() error {
 		return fmt.Errorf("acquire pid lock: %w", err)
 	}
 
+	removePauseFile() // Stale marker from a daemon that didn't exit cleanly
 	d.running = true
 	d.stats.StartTime = time.Now()
 	d.ctx, d.cancel = context.WithCancel(context.Background())
@@ -273,9 +500,39 @@ func (d *Daemon) Start() error {
 		}
 	}
 
+	// Start login discovery if enabled
+	if d.discoveryWatcher != nil {
+		if d.config.AutoDiscover == "auto" && !d.IsPaused() {
+			discovered, err := discovery.WatchOnce(d.vault, nil, nil)
+			if err != nil {
+				d.logger.Printf("Warning: initial discovery scan failed: %v", err)
+			}
+			for _, p := range discovered {
+				d.logger.Printf("Discovery: saved %s", p)
+			}
+			d.mu.Lock()
+			d.stats.DiscoveredCount += int64(len(discovered))
+			d.mu.Unlock()
+		}
+		if err := d.discoveryWatcher.Start(d.ctx); err != nil {
+			d.logger.Printf("Warning: failed to start discovery watcher: %v", err)
+		} else {
+			d.logger.Printf("Discovery watcher started (mode: %s)", d.config.AutoDiscover)
+		}
+	}
+
+	// Push token refreshes to the sync pool if enabled
+	if d.config.SyncWatch {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.runSyncWatch()
+		}()
+	}
+
 	// Set up signal handling
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	signal.Notify(sigCh, append([]os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}, signals.PauseResumeSignals()...)...)
 
 	d.wg.Add(1)
 	go func() {
@@ -283,6 +540,46 @@ func (d *Daemon) Start() error {
 		d.runLoop()
 	}()
 
+	if d.cooldownProber != nil {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.cooldownProbeLoop()
+		}()
+	}
+
+	if d.config.JobCommand != "" {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.jobLoop()
+		}()
+	}
+
+	if d.config.WatchSnapshot != nil {
+		d.startWatchHub()
+	}
+
+	if d.config.EventBus != nil {
+		d.startEventHub()
+	}
+
+	if d.config.Notifier != nil {
+		d.startNotifications()
+	}
+
+	if d.config.UsageWebhookListen != "" && d.config.UsageWebhookHandler != nil {
+		d.startUsageWebhook()
+	}
+
+	if d.config.AnnounceListen != "" {
+		d.startAnnounce()
+	}
+
+	if d.config.FederationPeers != nil {
+		d.startFederation()
+	}
+
 	// Wait for signal
 	for {
 		select {
@@ -292,6 +589,14 @@ func (d *Daemon) Start() error {
 				d.ReloadConfig()
 				continue
 			}
+			if sig == signals.PauseSignal {
+				d.Pause()
+				continue
+			}
+			if sig == signals.ResumeSignal {
+				d.Resume()
+				continue
+			}
 			d.logger.Printf("Received signal %v, shutting down...", sig)
 			signal.Stop(sigCh) // Clean up signal handler before stopping
 			return d.Stop()
@@ -328,6 +633,7 @@ func (d *Daemon) ReloadConfig() {
 	if d.config.RefreshThreshold <= 0 {
 		d.config.RefreshThreshold = DefaultRefreshThreshold
 	}
+	d.config.NoAutoRefresh = globalCfg.DisabledProviders(config.AutomationRefresh)
 	d.configMu.Unlock()
 
 	d.logger.Println("Config reloaded (runtime settings applied)")
@@ -340,6 +646,74 @@ func (d *Daemon) ReloadConfig() {
 	}
 }
 
+// Pause suspends automatic actions (refresh checks, pool refreshes, and
+// scheduled backups) without stopping the daemon, so a human can take manual
+// control of tokens without the daemon fighting them.
+func (d *Daemon) Pause() {
+	d.mu.Lock()
+	if d.paused {
+		d.mu.Unlock()
+		return
+	}
+	pausedAt := time.Now()
+	d.paused = true
+	d.stats.Paused = true
+	d.stats.PausedAt = pausedAt
+	d.mu.Unlock()
+
+	if d.poolMonitor != nil {
+		d.poolMonitor.Pause()
+	}
+	if err := writePauseFile(pausedAt); err != nil {
+		d.logger.Printf("Warning: failed to write pause marker: %v", err)
+	}
+	d.logger.Println("Paused automatic actions")
+}
+
+// Resume re-enables automatic actions after Pause and runs a check
+// immediately to catch up on anything missed.
+func (d *Daemon) Resume() {
+	d.mu.Lock()
+	if !d.paused {
+		d.mu.Unlock()
+		return
+	}
+	pausedFor := time.Since(d.stats.PausedAt)
+	d.paused = false
+	d.resumed = true
+	d.stats.Paused = false
+	d.stats.PausedAt = time.Time{}
+	d.mu.Unlock()
+
+	if d.poolMonitor != nil {
+		d.poolMonitor.Resume()
+	}
+	removePauseFile()
+	d.logger.Printf("Resumed automatic actions (paused for %v)", pausedFor.Round(time.Second))
+
+	// Nudge runLoop to check right away
+	select {
+	case d.configChanged <- struct{}{}:
+	default:
+	}
+}
+
+// checkAfterResume reports (and clears) whether Resume was just called.
+func (d *Daemon) checkAfterResume() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	resumed := d.resumed
+	d.resumed = false
+	return resumed
+}
+
+// IsPaused returns whether automatic actions are paused.
+func (d *Daemon) IsPaused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
 // Stop gracefully stops the daemon.
 func (d *Daemon) Stop() error {
 	d.mu.Lock()
@@ -348,7 +722,9 @@ func (d *Daemon) Stop() error {
 		return nil
 	}
 	d.running = false
+	d.paused = false
 	d.mu.Unlock()
+	removePauseFile()
 
 	// Stop pool monitor if running
 	if d.poolMonitor != nil {
@@ -364,6 +740,12 @@ func (d *Daemon) Stop() error {
 		}
 	}
 
+	if d.discoveryWatcher != nil {
+		if err := d.discoveryWatcher.Stop(); err != nil {
+			d.logger.Printf("Warning: failed to stop discovery watcher: %v", err)
+		}
+	}
+
 	if d.cancel != nil {
 		d.cancel()
 	}
@@ -382,6 +764,18 @@ func (d *Daemon) Stop() error {
 		d.logger.Println("Daemon stop timed out")
 	}
 
+	if d.watchHub != nil {
+		os.Remove(WatchSocketPath())
+	}
+	if d.eventsServing {
+		os.Remove(EventsSocketPath())
+	}
+
+	if d.cooldownDB != nil {
+		d.cooldownDB.Close()
+		d.cooldownDB = nil
+	}
+
 	// Close log file if we opened one
 	if d.logFile != nil {
 		d.logFile.Close()
@@ -433,6 +827,19 @@ func (d *Daemon) GetStats() Stats {
 		stats.PoolSummary = d.authPool.Summary()
 	}
 
+	if d.discoveryWatcher != nil {
+		stats.DiscoveryMode = d.config.AutoDiscover
+	}
+
+	if d.watchHub != nil {
+		stats.WatchSubscribers = d.watchHub.Subscribers()
+		stats.WatchScans = d.watchHub.Scans()
+	}
+
+	if d.config.EventBus != nil {
+		stats.EventSubscribers = d.config.EventBus.Subscribers()
+	}
+
 	return stats
 }
 
@@ -453,11 +860,19 @@ func (d *Daemon) runLoop() {
 		return d.poolMonitor != nil && d.poolMonitor.IsRunning()
 	}
 
+	if d.checkRemoteWipe() {
+		return
+	}
+
 	// Do an initial check immediately
-	if !shouldUsePoolRefresh() {
-		d.checkAndRefresh()
+	if !d.IsPaused() {
+		if !shouldUsePoolRefresh() {
+			d.checkAndRefresh()
+		}
+		d.checkAndBackup()
+		d.checkPolicies()
+		d.checkNotifications()
 	}
-	d.checkAndBackup()
 
 	interval := d.getCheckInterval()
 	if interval <= 0 {
@@ -481,14 +896,72 @@ func (d *Daemon) runLoop() {
 				ticker.Reset(interval)
 				d.logger.Printf("Updated check interval to %v", interval)
 			}
+			if d.checkAfterResume() {
+				if !shouldUsePoolRefresh() {
+					d.checkAndRefresh()
+				}
+				d.checkAndBackup()
+				d.checkPolicies()
+				d.checkNotifications()
+			}
 		case <-ticker.C:
+			if d.checkRemoteWipe() {
+				return
+			}
+			if d.IsPaused() {
+				if d.isVerbose() {
+					d.logger.Println("Paused, skipping check")
+				}
+				continue
+			}
 			// Check each iteration in case pool monitor state changed
 			if !shouldUsePoolRefresh() {
 				d.checkAndRefresh()
 			}
 			d.checkAndBackup()
+			d.checkPolicies()
+			d.checkNotifications()
+		}
+	}
+}
+
+// cooldownProbeLoop probes expired cooldowns on its own, shorter interval so
+// profiles are verified soon after their advertised reset.
+func (d *Daemon) cooldownProbeLoop() {
+	ticker := time.NewTicker(DefaultCooldownProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		d.checkCooldowns()
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkCooldowns runs one round of end-of-cooldown probes.
+func (d *Daemon) checkCooldowns() {
+	if d.IsPaused() {
+		return
+	}
+	outcomes, err := d.cooldownProber.ProbeExpired(d.ctx)
+	if err != nil {
+		d.logger.Printf("Cooldown probe failed: %v", err)
+		return
+	}
+
+	var extended int64
+	for _, o := range outcomes {
+		if !o.Passed {
+			extended++
 		}
 	}
+	d.mu.Lock()
+	d.stats.CooldownProbes += int64(len(outcomes))
+	d.stats.CooldownsExtended += extended
+	d.mu.Unlock()
 }
 
 // acquirePIDLock securely acquires the PID file lock
@@ -590,6 +1063,52 @@ func (d *Daemon) checkAndBackup() {
 	}
 }
 
+// checkPolicies applies the configured rotation policies.
+func (d *Daemon) checkPolicies() {
+	if d.config.ApplyPolicies == nil {
+		return
+	}
+
+	lines, err := d.config.ApplyPolicies()
+	if err != nil {
+		d.logger.Printf("Rotation policies failed: %v", err)
+		return
+	}
+	for _, line := range lines {
+		d.logger.Printf("Policy %s", line)
+	}
+}
+
+// checkRemoteWipe executes a remote wipe order delivered through the sync
+// pool. It reports whether the machine was wiped, in which case the daemon
+// shuts down.
+func (d *Daemon) checkRemoteWipe() bool {
+	var paths []string
+	if d.vault != nil {
+		paths = append(paths, d.vault.BasePath())
+	}
+	if d.backupScheduler != nil {
+		paths = append(paths, d.backupScheduler.config.GetLocation())
+	}
+	paths = append(paths, d.config.WipePaths...)
+
+	record, err := syncstate.CheckIncomingWipe(paths)
+	if err != nil {
+		d.logger.Printf("Remote wipe order rejected: %v", err)
+		return false
+	}
+	if record == nil {
+		return false
+	}
+
+	d.logger.Printf("Remote wipe ordered by %s: removed %s", record.Order.IssuerHost, strings.Join(record.Removed, ", "))
+	for _, e := range record.Errors {
+		d.logger.Printf("Remote wipe error: %v", e)
+	}
+	d.cancel()
+	return true
+}
+
 // checkAndRefresh checks all profiles and refreshes those that need it.
 func (d *Daemon) checkAndRefresh() {
 	d.mu.Lock()
@@ -609,6 +1128,13 @@ func (d *Daemon) checkAndRefresh() {
 	var wg sync.WaitGroup
 
 	for _, provider := range providers {
+		if !d.autoRefreshAllowed(provider) {
+			if d.isVerbose() {
+				d.logger.Printf("Skipping %s: automatic refresh disabled in config", provider)
+			}
+			continue
+		}
+
 		profiles, err := d.vault.List(provider)
 		if err != nil {
 			if d.isVerbose() {
@@ -642,6 +1168,16 @@ func (d *Daemon) checkAndRefresh() {
 
 // checkProfile checks a single profile and refreshes if needed.
 func (d *Daemon) checkProfile(provider, profile string) {
+	// A revoked token can't be refreshed; wait for a fresh login.
+	if d.cooldownDB != nil {
+		if rev, err := d.cooldownDB.ActiveRevocation(provider, profile); err == nil && rev != nil {
+			if d.isVerbose() {
+				d.logger.Printf("%s/%s: skipped (token revoked, awaiting re-login)", provider, profile)
+			}
+			return
+		}
+	}
+
 	// Get health data for this profile
 	ph := d.getProfileHealth(provider, profile)
 	if ph == nil {
@@ -672,7 +1208,15 @@ func (d *Daemon) checkProfile(provider, profile string) {
 
 		// Don't log unsupported errors as failures
 		var unsupErr *refresh.UnsupportedError
-		if ok := isUnsupportedError(err, &unsupErr); ok {
+		var revErr *refresh.RevokedError
+		if errors.As(err, &revErr) {
+			d.logger.Printf("%s/%s: token revoked (%s); quarantined until re-login (caam backup %s %s)", provider, profile, revErr.Reason, provider, profile)
+			if d.cooldownDB != nil {
+				if _, qerr := d.cooldownDB.MarkRevoked(provider, profile, time.Now(), revErr.Reason, "refresh"); qerr != nil {
+					d.logger.Printf("%s/%s: quarantine failed: %v", provider, profile, qerr)
+				}
+			}
+		} else if ok := isUnsupportedError(err, &unsupErr); ok {
 			if d.isVerbose() {
 				d.logger.Printf("%s/%s: refresh not supported (%s)", provider, profile, unsupErr.Reason)
 			}
@@ -688,6 +1232,10 @@ func (d *Daemon) checkProfile(provider, profile string) {
 
 // getProfileHealth returns the health data for a profile.
 func (d *Daemon) getProfileHealth(provider, profile string) *health.ProfileHealth {
+	if chaos.Active(chaos.ExpiredTokens) {
+		return &health.ProfileHealth{TokenExpiresAt: time.Now().Add(-time.Hour)}
+	}
+
 	// First try the health store
 	if d.healthStore != nil {
 		ph, err := d.healthStore.GetProfile(provider, profile)
@@ -762,6 +1310,34 @@ func LogFilePath() string {
 	return filepath.Join(homeDir, ".local", "share", "caam", "daemon.log")
 }
 
+// PauseFilePath returns the path of the marker file that records a paused
+// daemon, so other caam processes can report it.
+func PauseFilePath() string {
+	return PIDFilePath() + ".paused"
+}
+
+func writePauseFile(at time.Time) error {
+	return os.WriteFile(PauseFilePath(), []byte(at.Format(time.RFC3339)+"\n"), 0600)
+}
+
+func removePauseFile() {
+	_ = os.Remove(PauseFilePath())
+}
+
+// PausedSince returns when the running daemon was paused, or the zero time
+// if it isn't paused.
+func PausedSince() time.Time {
+	data, err := os.ReadFile(PauseFilePath())
+	if err != nil {
+		return time.Time{}
+	}
+	at, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}
+	}
+	return at
+}
+
 // RemovePIDFile removes the PID file.
 func RemovePIDFile() error {
 	return os.Remove(PIDFilePath())
@@ -613,6 +613,56 @@ func TestDaemonStartAndStop(t *testing.T) {
 	}
 }
 
+func TestDaemonPauseResume(t *testing.T) {
+	tmpDir := t.TempDir()
+	original := PIDFilePath()
+	SetPIDFilePath(filepath.Join(tmpDir, "caam-daemon.pid"))
+	defer SetPIDFilePath(original)
+
+	v := authfile.NewVault(tmpDir)
+	hs := health.NewStorage(filepath.Join(tmpDir, "health.json"))
+	d := New(v, hs, &Config{
+		CheckInterval:    20 * time.Millisecond,
+		RefreshThreshold: time.Minute,
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.Start()
+	}()
+	defer func() {
+		d.Stop()
+		<-errCh
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	d.Pause()
+	if !d.IsPaused() || !d.GetStats().Paused {
+		t.Fatal("daemon should report paused")
+	}
+	if PausedSince().IsZero() {
+		t.Error("pause marker should be written")
+	}
+
+	before := d.GetStats().CheckCount
+	time.Sleep(100 * time.Millisecond)
+	if after := d.GetStats().CheckCount; after != before {
+		t.Errorf("paused daemon ran %d checks", after-before)
+	}
+
+	d.Resume()
+	if d.IsPaused() {
+		t.Fatal("daemon should not be paused after Resume")
+	}
+	if !PausedSince().IsZero() {
+		t.Error("pause marker should be removed")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if after := d.GetStats().CheckCount; after <= before {
+		t.Error("resumed daemon should run checks again")
+	}
+}
+
 func TestDaemonDoubleStart(t *testing.T) {
 	tmpDir := t.TempDir()
 	v := authfile.NewVault(tmpDir)
@@ -0,0 +1,208 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+)
+
+// eventsFollowInterval is how often the daemon checks the store for events
+// appended by other caam processes.
+const eventsFollowInterval = time.Second
+
+// eventsReplayBatch bounds each replay query.
+const eventsReplayBatch = 500
+
+// EventsSocketPath returns the unix socket the daemon streams bus events on.
+func EventsSocketPath() string {
+	return PIDFilePath() + ".events.sock"
+}
+
+// EventsRequest is the first line an events client sends after connecting.
+type EventsRequest struct {
+	// AfterID replays stored events newer than this ID before streaming
+	// live ones, so a client that read history first misses nothing.
+	AfterID int64 `json:"after_id"`
+
+	events.Filter
+}
+
+// ServeEvents streams bus events to clients on ln until ctx is cancelled.
+// Each client gets newline-delimited JSON events matching its request.
+func ServeEvents(ctx context.Context, ln net.Listener, bus *events.Bus, logger interface {
+	Printf(format string, v ...interface{})
+}) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	var wg sync.WaitGroup
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				wg.Wait()
+				return nil
+			}
+			logger.Printf("Events: accept failed: %v", err)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handleEventsConn(ctx, conn, bus)
+		}()
+	}
+}
+
+func handleEventsConn(ctx context.Context, conn net.Conn, bus *events.Bus) {
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(watchHandshakeTimeout))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	var req EventsRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	// Subscribe before replaying so nothing published in between is lost;
+	// IDs already sent are skipped.
+	ch, cancel := bus.Subscribe(req.Filter, 256)
+	defer cancel()
+
+	lastID := req.AfterID
+	send := func(ev events.Event) bool {
+		if ev.ID != 0 && ev.ID <= lastID {
+			return true
+		}
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return true
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(watchWriteTimeout))
+		if _, err := conn.Write(append(data, '\n')); err != nil {
+			return false
+		}
+		if ev.ID > lastID {
+			lastID = ev.ID
+		}
+		return true
+	}
+
+	if store := bus.Store(); store != nil {
+		for {
+			batch, err := store.EventsAfter(lastID, eventsReplayBatch)
+			if err != nil || len(batch) == 0 {
+				break
+			}
+			for _, ev := range batch {
+				if req.Filter.Match(ev) && !send(ev) {
+					return
+				}
+				if ev.ID > lastID {
+					lastID = ev.ID
+				}
+			}
+			if len(batch) < eventsReplayBatch {
+				break
+			}
+		}
+	}
+
+	// The client never sends anything after the handshake; a read returning
+	// means it hung up.
+	closed := make(chan struct{})
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				close(closed)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-closed:
+			return
+		case ev, ok := <-ch:
+			if !ok || !send(ev) {
+				return
+			}
+		}
+	}
+}
+
+// DialEvents connects to the daemon's event stream and sends req. The
+// returned connection streams newline-delimited events.
+func DialEvents(req EventsRequest) (net.Conn, error) {
+	conn, err := net.DialTimeout("unix", EventsSocketPath(), 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("send events request: %w", err)
+	}
+	return conn, nil
+}
+
+// startEventHub follows the event store and serves EventsSocketPath until
+// the daemon stops. Failure to listen is logged; clients then poll the
+// database themselves.
+func (d *Daemon) startEventHub() {
+	bus := d.config.EventBus
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := bus.Follow(d.ctx, eventsFollowInterval); err != nil {
+			d.logger.Printf("Events: follow stopped: %v", err)
+		}
+	}()
+
+	path := EventsSocketPath()
+	os.Remove(path) // Stale socket from a daemon that didn't exit cleanly
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		d.logger.Printf("Warning: failed to start event stream: %v", err)
+		return
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		d.logger.Printf("Warning: failed to restrict events socket: %v", err)
+	}
+
+	d.mu.Lock()
+	d.eventsServing = true
+	d.mu.Unlock()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := ServeEvents(d.ctx, ln, bus, d.logger); err != nil {
+			d.logger.Printf("Event stream stopped: %v", err)
+		}
+	}()
+	d.logger.Printf("Event stream listening on %s", path)
+}
@@ -0,0 +1,56 @@
+package daemon
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/federation"
+)
+
+// federationTimeout bounds one peer's status request, including the SSH
+// handshake for pool machines.
+const federationTimeout = 15 * time.Second
+
+// startFederation polls FederationPeers every FederationInterval and
+// stores the result for `caam robot status --include-coordinators`.
+func (d *Daemon) startFederation() {
+	interval := d.config.FederationInterval
+	if interval <= 0 {
+		interval = federation.DefaultInterval
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			d.pollFederation()
+			select {
+			case <-d.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	d.logger.Printf("Coordinator federation polling every %s", interval)
+}
+
+func (d *Daemon) pollFederation() {
+	peers := d.config.FederationPeers()
+	if len(peers) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(d.ctx, 2*federationTimeout)
+	defer cancel()
+	snap := federation.Poll(ctx, peers, federationTimeout)
+	for _, m := range snap.Machines {
+		if !m.Healthy && d.config.Verbose {
+			d.logger.Printf("Federation: %s unreachable: %s", m.Machine, m.Error)
+		}
+	}
+	if err := snap.Save(federation.SnapshotPath()); err != nil {
+		d.logger.Printf("Warning: failed to save federation snapshot: %v", err)
+	}
+}
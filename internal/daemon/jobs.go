@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/jobs"
+)
+
+// DefaultJobPollInterval is how often the daemon looks for jobs queued with
+// --async.
+const DefaultJobPollInterval = 5 * time.Second
+
+// jobLoop runs queued jobs until the daemon stops.
+func (d *Daemon) jobLoop() {
+	ticker := time.NewTicker(DefaultJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.runQueuedJobs()
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runQueuedJobs runs queued jobs one at a time, oldest first, until none are
+// left. A job still running when the daemon stops is left to finish on its
+// own; it records its own outcome.
+func (d *Daemon) runQueuedJobs() {
+	for d.ctx.Err() == nil && !d.IsPaused() {
+		job, err := jobs.NextQueued()
+		if err != nil {
+			d.logger.Printf("Job queue: %v", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			d.runJob(job)
+		}()
+		select {
+		case <-done:
+		case <-d.ctx.Done():
+			d.logger.Printf("Job %s still running; leaving it to finish", job.ID)
+			return
+		}
+	}
+}
+
+// runJob executes one queued job by re-running its caam command with
+// CAAM_JOB_ID set, sending the command's output to the job log.
+func (d *Daemon) runJob(job *jobs.Job) {
+	d.logger.Printf("Running job %s (%s)", job.ID, job.Kind)
+
+	var runErr error
+	logFile, err := job.OpenLog()
+	if err != nil {
+		runErr = err
+	} else {
+		cmd := exec.Command(d.config.JobCommand, job.Args...)
+		cmd.Dir = job.Dir
+		cmd.Env = append(os.Environ(), jobs.EnvJobID+"="+job.ID)
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+		runErr = cmd.Run()
+		logFile.Close()
+	}
+
+	// The command records its own outcome; catch jobs it never got to, such
+	// as a command that failed before starting.
+	final, err := jobs.Load(job.ID)
+	if err != nil {
+		d.logger.Printf("Job %s: %v", job.ID, err)
+		return
+	}
+	if !final.Status.Finished() {
+		if runErr == nil {
+			runErr = fmt.Errorf("command exited without recording a result")
+		}
+		if err := final.Finish(runErr); err != nil {
+			d.logger.Printf("Job %s: %v", job.ID, err)
+		}
+	}
+	d.logger.Printf("Job %s %s", job.ID, final.Status)
+}
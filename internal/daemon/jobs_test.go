@@ -0,0 +1,55 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/jobs"
+)
+
+func TestRunQueuedJobs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh as the job command")
+	}
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", tmpDir)
+
+	cfg := DefaultConfig()
+	cfg.JobCommand = "/bin/sh"
+	d := New(authfile.NewVault(tmpDir), health.NewStorage(filepath.Join(tmpDir, "health.json")), cfg)
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	defer d.cancel()
+
+	// The shell never records a result, so the daemon must.
+	job, err := jobs.New("sync", []string{"-c", `echo "job=$CAAM_JOB_ID"; exit 3`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Dir = tmpDir
+	if err := job.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	d.runQueuedJobs()
+
+	got, err := jobs.Load(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != jobs.StatusFailed || !strings.Contains(got.Error, "exit status 3") {
+		t.Errorf("job = %s (%q), want failed with exit status 3", got.Status, got.Error)
+	}
+	log, _ := os.ReadFile(jobs.LogPath(job.ID))
+	if !strings.Contains(string(log), "job="+job.ID) {
+		t.Errorf("log = %q, want command output with CAAM_JOB_ID", log)
+	}
+	if next, _ := jobs.NextQueued(); next != nil {
+		t.Errorf("job %s still queued", next.ID)
+	}
+}
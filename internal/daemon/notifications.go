@@ -0,0 +1,254 @@
+package daemon
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
+)
+
+// DefaultExpiryWarning is how long before a token expires to notify.
+const DefaultExpiryWarning = 2 * time.Hour
+
+// initNotifications opens the activity database the all-blocked check
+// reads cooldowns from, unless the cooldown probe already did.
+func (d *Daemon) initNotifications() {
+	if d.cooldownDB != nil {
+		return
+	}
+	db, err := caamdb.Open()
+	if err != nil {
+		d.logger.Printf("Warning: all-blocked notifications disabled (open database: %v)", err)
+		return
+	}
+	d.cooldownDB = db
+}
+
+// startNotifications turns cooldown and sync events on the bus into
+// notifications until the daemon stops.
+func (d *Daemon) startNotifications() {
+	if d.config.EventBus == nil {
+		return
+	}
+	ch, cancel := d.config.EventBus.Subscribe(events.Filter{Types: []events.Type{events.CooldownSet, events.SyncCompleted}}, 64)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer cancel()
+		for {
+			select {
+			case <-d.ctx.Done():
+				return
+			case ev, ok := <-ch:
+				if !ok {
+					return
+				}
+				d.notifyEvent(ev)
+			}
+		}
+	}()
+}
+
+// notifyEvent sends the notification for a bus event, if it warrants one.
+func (d *Daemon) notifyEvent(ev events.Event) {
+	var event, key string
+	var alert *notify.Alert
+
+	switch ev.Type {
+	case events.CooldownSet:
+		key = ev.Provider + "/" + ev.Profile
+		message := fmt.Sprintf("%s is in cooldown", key)
+		if until, err := time.Parse(time.RFC3339, fmt.Sprint(ev.Data["until"])); err == nil {
+			message = fmt.Sprintf("%s is in cooldown until %s", key, until.Local().Format("15:04"))
+		}
+		if notes, ok := ev.Data["notes"].(string); ok && notes != "" {
+			message += " (" + notes + ")"
+		}
+		event = config.NotifyCooldownStarted
+		alert = &notify.Alert{
+			Level:   notify.Warning,
+			Title:   "Cooldown started",
+			Message: message,
+			Profile: key,
+		}
+
+	case events.SyncCompleted:
+		machine := fmt.Sprint(ev.Data["machine"])
+		var message string
+		if errMsg, ok := ev.Data["error"].(string); ok && errMsg != "" {
+			message = fmt.Sprintf("Sync with %s failed: %s", machine, errMsg)
+		} else if failed := eventCount(ev.Data["failed"]); failed > 0 {
+			message = fmt.Sprintf("%d profile(s) failed to sync with %s", failed, machine)
+		} else {
+			return
+		}
+		event, key = config.NotifySyncFailed, machine
+		alert = &notify.Alert{
+			Level:   notify.Warning,
+			Title:   "Sync failed",
+			Message: message,
+			Action:  "caam sync status",
+		}
+
+	default:
+		return
+	}
+
+	d.sendNotification(event, key, alert)
+}
+
+// checkNotifications warns about tokens close to expiry, providers whose
+// profiles are all blocked, and budgets whose thresholds were crossed.
+func (d *Daemon) checkNotifications() {
+	if d.config.Notifier == nil {
+		return
+	}
+
+	warn := d.config.ExpiryWarning
+	if warn <= 0 {
+		warn = DefaultExpiryWarning
+	}
+	now := time.Now()
+
+	for _, provider := range []string{"claude", "codex", "gemini"} {
+		all, err := d.vault.List(provider)
+		if err != nil {
+			continue
+		}
+
+		var profiles []string
+		for _, profile := range all {
+			if !authfile.IsSystemProfile(profile) {
+				profiles = append(profiles, profile)
+			}
+		}
+
+		blocked := 0
+		for _, profile := range profiles {
+			key := provider + "/" + profile
+			if d.profileBlocked(provider, profile, now) {
+				blocked++
+				// A blocked profile can't be used anyway; don't also warn
+				// that its token is expiring.
+				continue
+			}
+
+			ph := d.getProfileHealth(provider, profile)
+			if ph == nil || ph.TokenExpiresAt.IsZero() {
+				continue
+			}
+			ttl := ph.TokenExpiresAt.Sub(now)
+			if ttl <= 0 || ttl > warn {
+				d.config.Notifier.Reset(config.NotifyTokenExpiring, key)
+				continue
+			}
+			d.sendNotification(config.NotifyTokenExpiring, key, &notify.Alert{
+				Level:   notify.Warning,
+				Title:   "Token expiring",
+				Message: fmt.Sprintf("%s token expires in %v", key, ttl.Round(time.Minute)),
+				Profile: key,
+				Action:  fmt.Sprintf("caam refresh %s %s", provider, profile),
+			})
+		}
+
+		if len(profiles) == 0 || blocked < len(profiles) {
+			d.config.Notifier.Reset(config.NotifyAllBlocked, provider)
+			continue
+		}
+		d.sendNotification(config.NotifyAllBlocked, provider, &notify.Alert{
+			Level:   notify.Critical,
+			Title:   fmt.Sprintf("All %s profiles blocked", provider),
+			Message: fmt.Sprintf("All %d %s profile(s) are in cooldown or revoked", len(profiles), provider),
+			Action:  "caam cooldown list",
+		})
+	}
+
+	d.checkBudgets(now)
+}
+
+// checkBudgets notifies once for each budget threshold the month's spend
+// crosses. A new month starts over.
+func (d *Daemon) checkBudgets(now time.Time) {
+	if d.config.BudgetReport == nil {
+		return
+	}
+	report, err := d.config.BudgetReport(now)
+	if err != nil {
+		if d.isVerbose() {
+			d.logger.Printf("Budget check failed: %v", err)
+		}
+		return
+	}
+	if report.Month != d.budgetMonth || d.budgetNotified == nil {
+		d.budgetMonth = report.Month
+		d.budgetNotified = make(map[string]int)
+	}
+
+	for _, b := range report.Budgets {
+		if b.Crossed <= d.budgetNotified[b.Name] {
+			continue
+		}
+		d.budgetNotified[b.Name] = b.Crossed
+		level := notify.Warning
+		if b.Crossed >= 100 {
+			level = notify.Critical
+		}
+		alert := &notify.Alert{
+			Level: level,
+			Title: fmt.Sprintf("Budget %s at %d%%", b.Name, b.Crossed),
+			Message: fmt.Sprintf("%s spent $%.2f of its $%.2f budget for %s (projected $%.2f)",
+				b.Name, b.SpentUSD, b.BudgetUSD, report.Month, b.ProjectedUSD),
+			Action: "caam cost report",
+		}
+		if b.Profile != "" {
+			alert.Profile = b.Provider + "/" + b.Profile
+		}
+		d.sendNotification(config.NotifyBudgetThreshold, fmt.Sprintf("%s %s@%d", report.Month, b.Name, b.Crossed), alert)
+	}
+}
+
+// profileBlocked reports whether a profile is in cooldown or revoked.
+func (d *Daemon) profileBlocked(provider, profile string, now time.Time) bool {
+	if d.cooldownDB == nil {
+		return false
+	}
+	if ev, err := d.cooldownDB.ActiveCooldown(provider, profile, now); err == nil && ev != nil {
+		return true
+	}
+	if rev, err := d.cooldownDB.ActiveRevocation(provider, profile); err == nil && rev != nil {
+		return true
+	}
+	return false
+}
+
+// sendNotification delivers an alert through the configured dispatcher and
+// logs delivery failures.
+func (d *Daemon) sendNotification(event, key string, alert *notify.Alert) {
+	sent, err := d.config.Notifier.Send(event, key, alert)
+	if err != nil {
+		d.logger.Printf("Notification %s (%s) failed: %v", event, key, err)
+		return
+	}
+	if sent && d.isVerbose() {
+		d.logger.Printf("Notification %s sent: %s", event, alert.Message)
+	}
+}
+
+// eventCount reads a count from event data, which is an int when published
+// in-process and a float64 after a round trip through the event store.
+func eventCount(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
@@ -0,0 +1,177 @@
+package daemon
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+type recordingNotifier struct {
+	alerts []*notify.Alert
+}
+
+func (r *recordingNotifier) Notify(a *notify.Alert) error { r.alerts = append(r.alerts, a); return nil }
+func (r *recordingNotifier) Name() string                 { return "recording" }
+func (r *recordingNotifier) Available() bool              { return true }
+
+func newNotifyTestDaemon(t *testing.T, profiles ...string) (*Daemon, *recordingNotifier, *caamdb.DB, *health.Storage) {
+	t.Helper()
+	vaultDir := t.TempDir()
+	for _, p := range profiles {
+		if err := os.MkdirAll(filepath.Join(vaultDir, "claude", p), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	store := health.NewStorage(filepath.Join(t.TempDir(), "health.json"))
+
+	rec := &recordingNotifier{}
+	d := &Daemon{
+		config: &Config{
+			Notifier:      notify.NewDispatcher(rec, nil, time.Hour),
+			ExpiryWarning: 2 * time.Hour,
+		},
+		vault:       authfile.NewVault(vaultDir),
+		healthStore: store,
+		cooldownDB:  db,
+		logger:      log.New(io.Discard, "", 0),
+	}
+	return d, rec, db, store
+}
+
+func TestCheckNotifications_AllBlocked(t *testing.T) {
+	d, rec, db, _ := newNotifyTestDaemon(t, "a", "b")
+	now := time.Now()
+
+	if _, err := db.SetCooldown("claude", "a", now, time.Hour, ""); err != nil {
+		t.Fatal(err)
+	}
+	d.checkNotifications()
+	if len(rec.alerts) != 0 {
+		t.Fatalf("alerts = %d with one profile still usable, want 0", len(rec.alerts))
+	}
+
+	if _, err := db.SetCooldown("claude", "b", now, time.Hour, ""); err != nil {
+		t.Fatal(err)
+	}
+	d.checkNotifications()
+	d.checkNotifications()
+	if len(rec.alerts) != 1 {
+		t.Fatalf("alerts = %d, want 1 (repeat rate limited)", len(rec.alerts))
+	}
+	if a := rec.alerts[0]; a.Event != config.NotifyAllBlocked || a.Level != notify.Critical {
+		t.Errorf("alert = %+v, want critical all_blocked", a)
+	}
+
+	// Once a profile is usable again the next blockage is reported at once.
+	if _, err := db.ClearCooldown("claude", "b"); err != nil {
+		t.Fatal(err)
+	}
+	d.checkNotifications()
+	if _, err := db.SetCooldown("claude", "b", now, time.Hour, ""); err != nil {
+		t.Fatal(err)
+	}
+	d.checkNotifications()
+	if len(rec.alerts) != 2 {
+		t.Errorf("alerts = %d after blockage recurred, want 2", len(rec.alerts))
+	}
+}
+
+func TestCheckNotifications_TokenExpiring(t *testing.T) {
+	d, rec, _, store := newNotifyTestDaemon(t, "soon", "later")
+
+	if err := store.UpdateProfile("claude", "soon", &health.ProfileHealth{TokenExpiresAt: time.Now().Add(90 * time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateProfile("claude", "later", &health.ProfileHealth{TokenExpiresAt: time.Now().Add(5 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	d.checkNotifications()
+	if len(rec.alerts) != 1 {
+		t.Fatalf("alerts = %d, want 1", len(rec.alerts))
+	}
+	if a := rec.alerts[0]; a.Event != config.NotifyTokenExpiring || a.Profile != "claude/soon" {
+		t.Errorf("alert = %+v, want token_expiring for claude/soon", a)
+	}
+}
+
+func TestCheckNotifications_BudgetThreshold(t *testing.T) {
+	d, rec, _, _ := newNotifyTestDaemon(t)
+	report := &usage.MonthReport{
+		Month:   "2024-07",
+		Budgets: []usage.BudgetStatus{{Name: "claude/work", Provider: "claude", Profile: "work", BudgetUSD: 100, SpentUSD: 50, UsedPercent: 50}},
+	}
+	d.config.BudgetReport = func(time.Time) (*usage.MonthReport, error) { return report, nil }
+
+	d.checkNotifications()
+	if len(rec.alerts) != 0 {
+		t.Fatalf("alerts = %d below every threshold, want 0", len(rec.alerts))
+	}
+
+	report.Budgets[0].SpentUSD, report.Budgets[0].UsedPercent, report.Budgets[0].Crossed = 85, 85, 80
+	d.checkNotifications()
+	d.checkNotifications()
+	if len(rec.alerts) != 1 {
+		t.Fatalf("alerts = %d after crossing 80%%, want 1", len(rec.alerts))
+	}
+	if a := rec.alerts[0]; a.Event != config.NotifyBudgetThreshold || a.Level != notify.Warning || a.Profile != "claude/work" {
+		t.Errorf("alert = %+v, want warning budget_threshold for claude/work", a)
+	}
+
+	// Crossing the next threshold notifies at once despite the rate limit.
+	report.Budgets[0].SpentUSD, report.Budgets[0].UsedPercent, report.Budgets[0].Crossed = 120, 120, 100
+	d.checkNotifications()
+	if len(rec.alerts) != 2 || rec.alerts[1].Level != notify.Critical {
+		t.Fatalf("alerts = %d after crossing 100%%, want a second, critical one", len(rec.alerts))
+	}
+
+	// A new month starts over.
+	report.Month = "2024-08"
+	report.Budgets[0].Crossed = 80
+	d.checkNotifications()
+	if len(rec.alerts) != 3 {
+		t.Errorf("alerts = %d in a new month, want 3", len(rec.alerts))
+	}
+}
+
+func TestNotifyEvent(t *testing.T) {
+	d, rec, _, _ := newNotifyTestDaemon(t)
+
+	d.notifyEvent(events.Event{
+		Type: events.CooldownSet, Provider: "claude", Profile: "work",
+		Data: map[string]any{"until": time.Now().Add(time.Hour).Format(time.RFC3339), "notes": "rate limit"},
+	})
+	d.notifyEvent(events.Event{Type: events.SyncCompleted, Data: map[string]any{"machine": "laptop", "failed": float64(0)}})
+	d.notifyEvent(events.Event{Type: events.SyncCompleted, Data: map[string]any{"machine": "laptop", "failed": float64(2)}})
+	d.notifyEvent(events.Event{Type: events.SyncCompleted, Data: map[string]any{"machine": "desk", "error": "connection refused"}})
+
+	if len(rec.alerts) != 3 {
+		t.Fatalf("alerts = %d, want 3", len(rec.alerts))
+	}
+	if a := rec.alerts[0]; a.Event != config.NotifyCooldownStarted || !strings.Contains(a.Message, "rate limit") {
+		t.Errorf("cooldown alert = %+v", a)
+	}
+	if a := rec.alerts[1]; a.Event != config.NotifySyncFailed || !strings.Contains(a.Message, "2 profile(s)") {
+		t.Errorf("sync alert = %+v", a)
+	}
+	if a := rec.alerts[2]; !strings.Contains(a.Message, "connection refused") {
+		t.Errorf("sync error alert = %+v", a)
+	}
+}
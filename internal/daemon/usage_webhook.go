@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// startUsageWebhook serves UsageWebhookHandler (and UsageRecordHandler) on
+// UsageWebhookListen until the daemon stops.
+func (d *Daemon) startUsageWebhook() {
+	ln, err := net.Listen("tcp", d.config.UsageWebhookListen)
+	if err != nil {
+		d.logger.Printf("Warning: failed to start usage webhook: %v", err)
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/usage/", d.config.UsageWebhookHandler)
+	if d.config.UsageRecordHandler != nil {
+		mux.Handle("/usage/record", d.config.UsageRecordHandler)
+	}
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	d.wg.Add(2)
+	go func() {
+		defer d.wg.Done()
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			d.logger.Printf("Usage webhook stopped: %v", err)
+		}
+	}()
+	go func() {
+		defer d.wg.Done()
+		<-d.ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}()
+	d.logger.Printf("Usage webhook listening on %s", ln.Addr())
+}
@@ -0,0 +1,379 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// ProviderSnapshot is one provider's robot status, already JSON-encoded.
+type ProviderSnapshot struct {
+	Provider string
+	Data     json.RawMessage
+}
+
+// WatchSnapshotFunc scans the vault and returns the status of every
+// provider. The hub calls it once per poll, however many clients watch.
+type WatchSnapshotFunc func() ([]ProviderSnapshot, error)
+
+// WatchRequest is the first line a watch client sends after connecting.
+type WatchRequest struct {
+	// Interval is how often the client wants an update, in seconds.
+	Interval int `json:"interval"`
+
+	// Provider restricts updates to one provider ("" = all).
+	Provider string `json:"provider,omitempty"`
+}
+
+const (
+	// watchSnapshotReuse lets subscribers that come due at nearly the same
+	// time share one scan.
+	watchSnapshotReuse = 500 * time.Millisecond
+
+	watchHandshakeTimeout = 5 * time.Second
+	watchWriteTimeout     = 10 * time.Second
+)
+
+// WatchSocketPath returns the unix socket the daemon serves watch clients on.
+func WatchSocketPath() string {
+	return PIDFilePath() + ".watch.sock"
+}
+
+// watchEvent matches the line format of a standalone `caam robot watch`.
+type watchEvent struct {
+	Timestamp string            `json:"timestamp"`
+	Providers []json.RawMessage `json:"providers"`
+}
+
+type watchSubscriber struct {
+	interval time.Duration
+	provider string
+	nextDue  time.Time
+	ch       chan []byte
+}
+
+// WatchHub runs a single status poller and fans its snapshots out to every
+// connected `robot watch` client, so N watchers cost one vault scan per poll
+// instead of N.
+type WatchHub struct {
+	snapshot WatchSnapshotFunc
+	logger   interface {
+		Printf(format string, v ...interface{})
+	}
+	now func() time.Time
+
+	mu          sync.Mutex
+	subscribers map[*watchSubscriber]struct{}
+	lastSnap    []ProviderSnapshot
+	lastSnapAt  time.Time
+	scans       int64
+	wake        chan struct{}
+}
+
+// NewWatchHub creates a hub that polls with snapshot.
+func NewWatchHub(snapshot WatchSnapshotFunc, logger interface {
+	Printf(format string, v ...interface{})
+}) *WatchHub {
+	return &WatchHub{
+		snapshot:    snapshot,
+		logger:      logger,
+		now:         time.Now,
+		subscribers: make(map[*watchSubscriber]struct{}),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Subscribers returns the number of attached watch clients.
+func (h *WatchHub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// Scans returns how many snapshots the hub has taken.
+func (h *WatchHub) Scans() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.scans
+}
+
+// Serve accepts watch clients on ln until ctx is cancelled.
+func (h *WatchHub) Serve(ctx context.Context, ln net.Listener) error {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.pollLoop(ctx)
+	}()
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				wg.Wait()
+				return nil
+			}
+			h.logger.Printf("Watch: accept failed: %v", err)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.handleConn(ctx, conn)
+		}()
+	}
+}
+
+func (h *WatchHub) handleConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(watchHandshakeTimeout))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	var req WatchRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	sub := h.subscribe(req)
+	defer h.unsubscribe(sub)
+
+	// The client never sends anything after the handshake; a read returning
+	// means it hung up.
+	closed := make(chan struct{})
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				close(closed)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-closed:
+			return
+		case msg := <-sub.ch:
+			_ = conn.SetWriteDeadline(time.Now().Add(watchWriteTimeout))
+			if _, err := conn.Write(msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *WatchHub) subscribe(req WatchRequest) *watchSubscriber {
+	interval := time.Duration(req.Interval) * time.Second
+	if interval < time.Second {
+		interval = time.Second
+	}
+	sub := &watchSubscriber{
+		interval: interval,
+		provider: req.Provider,
+		ch:       make(chan []byte, 1),
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	// Due immediately, so the client gets an initial status like a
+	// standalone watch would.
+	h.poke()
+	return sub
+}
+
+func (h *WatchHub) unsubscribe(sub *watchSubscriber) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+}
+
+func (h *WatchHub) poke() {
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (h *WatchHub) pollLoop(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.wake:
+		case <-timer.C:
+		}
+
+		wait := h.dispatch()
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+// dispatch sends a snapshot to every subscriber that is due and returns how
+// long to wait until the next one is.
+func (h *WatchHub) dispatch() time.Duration {
+	now := h.now()
+
+	h.mu.Lock()
+	var due []*watchSubscriber
+	for sub := range h.subscribers {
+		if !sub.nextDue.After(now) {
+			due = append(due, sub)
+		}
+	}
+	h.mu.Unlock()
+
+	if len(due) > 0 {
+		snap, at, err := h.currentSnapshot(now)
+		if err != nil {
+			h.logger.Printf("Watch: status snapshot failed: %v", err)
+		} else {
+			for _, sub := range due {
+				msg, err := renderWatchEvent(snap, at, sub.provider)
+				if err != nil {
+					continue
+				}
+				select {
+				case sub.ch <- msg:
+				default:
+					// Client hasn't drained its last update; skip this
+					// one rather than stall every other watcher.
+				}
+			}
+		}
+
+		h.mu.Lock()
+		for _, sub := range due {
+			sub.nextDue = now.Add(sub.interval)
+		}
+		h.mu.Unlock()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	wait := time.Hour
+	for sub := range h.subscribers {
+		if d := sub.nextDue.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+func (h *WatchHub) currentSnapshot(now time.Time) ([]ProviderSnapshot, time.Time, error) {
+	h.mu.Lock()
+	if h.lastSnap != nil && now.Sub(h.lastSnapAt) < watchSnapshotReuse {
+		snap, at := h.lastSnap, h.lastSnapAt
+		h.mu.Unlock()
+		return snap, at, nil
+	}
+	h.mu.Unlock()
+
+	snap, err := h.snapshot()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	h.mu.Lock()
+	h.lastSnap = snap
+	h.lastSnapAt = now
+	h.scans++
+	h.mu.Unlock()
+	return snap, now, nil
+}
+
+func renderWatchEvent(snap []ProviderSnapshot, at time.Time, provider string) ([]byte, error) {
+	event := watchEvent{
+		Timestamp: at.UTC().Format(time.RFC3339),
+		Providers: make([]json.RawMessage, 0, len(snap)),
+	}
+	for _, p := range snap {
+		if provider == "" || p.Provider == provider {
+			event.Providers = append(event.Providers, p.Data)
+		}
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// DialWatch connects to the daemon's watch hub and sends req. The returned
+// connection streams newline-delimited status events.
+func DialWatch(req WatchRequest) (net.Conn, error) {
+	conn, err := net.DialTimeout("unix", WatchSocketPath(), 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("send watch request: %w", err)
+	}
+	return conn, nil
+}
+
+// startWatchHub listens on WatchSocketPath and serves watch clients until the
+// daemon stops. Failure to listen is logged; clients then poll on their own.
+func (d *Daemon) startWatchHub() {
+	path := WatchSocketPath()
+	os.Remove(path) // Stale socket from a daemon that didn't exit cleanly
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		d.logger.Printf("Warning: failed to start watch hub: %v", err)
+		return
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		d.logger.Printf("Warning: failed to restrict watch socket: %v", err)
+	}
+
+	hub := NewWatchHub(d.config.WatchSnapshot, d.logger)
+	d.mu.Lock()
+	d.watchHub = hub
+	d.mu.Unlock()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := hub.Serve(d.ctx, ln); err != nil {
+			d.logger.Printf("Watch hub stopped: %v", err)
+		}
+	}()
+	d.logger.Printf("Watch hub listening on %s", path)
+}
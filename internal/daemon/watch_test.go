@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func startTestWatchHub(t *testing.T, snapshot WatchSnapshotFunc) (*WatchHub, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "watch.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	hub := NewWatchHub(snapshot, discardLogger{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hub.Serve(ctx, ln)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return hub, path
+}
+
+func dialTestWatch(t *testing.T, path string, req WatchRequest) *bufio.Scanner {
+	t.Helper()
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	data, _ := json.Marshal(req)
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		t.Fatalf("write request: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return bufio.NewScanner(conn)
+}
+
+func readWatchEvent(t *testing.T, sc *bufio.Scanner) watchEvent {
+	t.Helper()
+	if !sc.Scan() {
+		t.Fatalf("no watch event: %v", sc.Err())
+	}
+	var ev watchEvent
+	if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+		t.Fatalf("decode event %q: %v", sc.Text(), err)
+	}
+	return ev
+}
+
+func testSnapshot(scans *int64) WatchSnapshotFunc {
+	return func() ([]ProviderSnapshot, error) {
+		atomic.AddInt64(scans, 1)
+		return []ProviderSnapshot{
+			{Provider: "codex", Data: json.RawMessage(`{"id":"codex"}`)},
+			{Provider: "claude", Data: json.RawMessage(`{"id":"claude"}`)},
+		}, nil
+	}
+}
+
+func TestWatchHub_FanOutSharesScan(t *testing.T) {
+	var scans int64
+	hub, path := startTestWatchHub(t, testSnapshot(&scans))
+
+	var clients []*bufio.Scanner
+	for i := 0; i < 3; i++ {
+		clients = append(clients, dialTestWatch(t, path, WatchRequest{Interval: 1}))
+	}
+	for _, c := range clients {
+		if ev := readWatchEvent(t, c); len(ev.Providers) != 2 {
+			t.Fatalf("initial event providers = %d, want 2", len(ev.Providers))
+		}
+	}
+	for _, c := range clients {
+		readWatchEvent(t, c)
+	}
+
+	if got := hub.Subscribers(); got != 3 {
+		t.Errorf("Subscribers() = %d, want 3", got)
+	}
+	// Six events delivered; clients attaching at slightly different times
+	// may each trigger an initial scan, but polls after that are shared.
+	if got := atomic.LoadInt64(&scans); got > 4 {
+		t.Errorf("snapshot scans = %d for 3 clients x 2 events, want shared polling", got)
+	}
+}
+
+func TestWatchHub_ProviderFilter(t *testing.T) {
+	var scans int64
+	_, path := startTestWatchHub(t, testSnapshot(&scans))
+
+	ev := readWatchEvent(t, dialTestWatch(t, path, WatchRequest{Interval: 1, Provider: "claude"}))
+	if len(ev.Providers) != 1 || string(ev.Providers[0]) != `{"id":"claude"}` {
+		t.Errorf("filtered providers = %s, want only claude", ev.Providers)
+	}
+	if ev.Timestamp == "" {
+		t.Error("event missing timestamp")
+	}
+}
+
+func TestWatchHub_UnsubscribeOnDisconnect(t *testing.T) {
+	var scans int64
+	hub, path := startTestWatchHub(t, testSnapshot(&scans))
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte(`{"interval":1}` + "\n"))
+	sc := bufio.NewScanner(conn)
+	if !sc.Scan() {
+		t.Fatal("no initial event")
+	}
+	conn.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.Subscribers() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Subscribers() = %d after disconnect, want 0", hub.Subscribers())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,217 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Approval statuses. A proposal starts pending; approving it moves it to
+// approved while the action runs, then to executed or failed.
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalDenied   = "denied"
+	ApprovalExecuted = "executed"
+	ApprovalFailed   = "failed"
+)
+
+// Approval is an agent-initiated robot action queued for a human decision.
+type Approval struct {
+	ID          int64
+	CreatedAt   time.Time
+	Requester   string
+	Action      string
+	Provider    string
+	ProfileName string
+	// Args are the full `caam robot act` arguments to run on approval.
+	Args     []string
+	RiskTier string
+	Status   string
+
+	DecidedAt time.Time
+	DecidedBy string
+	Reason    string
+	// Result is the robot output of the executed action.
+	Result string
+}
+
+// ProposeApproval queues a pending approval. If an identical proposal is
+// already pending it is returned instead, so an agent retrying the same
+// action doesn't flood the queue.
+func (d *DB) ProposeApproval(a Approval) (*Approval, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	a.Action = strings.TrimSpace(a.Action)
+	a.Provider = strings.TrimSpace(a.Provider)
+	a.ProfileName = strings.TrimSpace(a.ProfileName)
+	if a.Action == "" || a.Provider == "" {
+		return nil, fmt.Errorf("action and provider are required")
+	}
+	args, err := json.Marshal(a.Args)
+	if err != nil {
+		return nil, fmt.Errorf("marshal args: %w", err)
+	}
+
+	existing, err := d.queryApprovals(
+		`WHERE status = ? AND action = ? AND provider = ? AND profile_name = ? AND args = ? ORDER BY id LIMIT 1`,
+		ApprovalPending, a.Action, a.Provider, a.ProfileName, string(args),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return &existing[0], nil
+	}
+
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC()
+	} else {
+		a.CreatedAt = a.CreatedAt.UTC()
+	}
+	a.Status = ApprovalPending
+
+	res, err := d.conn.Exec(
+		`INSERT INTO approvals (created_at, requester, action, provider, profile_name, args, risk_tier, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		formatSQLiteTime(a.CreatedAt),
+		a.Requester,
+		a.Action,
+		a.Provider,
+		a.ProfileName,
+		string(args),
+		a.RiskTier,
+		a.Status,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("insert approvals: %w", err)
+	}
+	a.ID, _ = res.LastInsertId()
+	return &a, nil
+}
+
+// GetApproval returns an approval by ID, or (nil, nil) if there is none.
+func (d *DB) GetApproval(id int64) (*Approval, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	approvals, err := d.queryApprovals(`WHERE id = ?`, id)
+	if err != nil || len(approvals) == 0 {
+		return nil, err
+	}
+	return &approvals[0], nil
+}
+
+// ListApprovals returns up to limit approvals, newest first. An empty status
+// lists every approval.
+func (d *DB) ListApprovals(status string, limit int) ([]Approval, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if status == "" {
+		return d.queryApprovals(`ORDER BY id DESC LIMIT ?`, limit)
+	}
+	return d.queryApprovals(`WHERE status = ? ORDER BY id DESC LIMIT ?`, status, limit)
+}
+
+// DecideApproval moves a pending approval to status (approved or denied).
+// It reports false if the approval was no longer pending, so two operators
+// can't both act on one proposal.
+func (d *DB) DecideApproval(id int64, status, decidedBy, reason string, at time.Time) (bool, error) {
+	if d == nil || d.conn == nil {
+		return false, fmt.Errorf("db is not open")
+	}
+	if status != ApprovalApproved && status != ApprovalDenied {
+		return false, fmt.Errorf("invalid decision status %q", status)
+	}
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	var reasonStr sql.NullString
+	if reason = strings.TrimSpace(reason); reason != "" {
+		reasonStr = sql.NullString{String: reason, Valid: true}
+	}
+
+	res, err := d.conn.Exec(
+		`UPDATE approvals SET status = ?, decided_at = ?, decided_by = ?, reason = ? WHERE id = ? AND status = ?`,
+		status,
+		formatSQLiteTime(at.UTC()),
+		decidedBy,
+		reasonStr,
+		id,
+		ApprovalPending,
+	)
+	if err != nil {
+		return false, fmt.Errorf("update approvals: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
+
+// FinishApproval records the outcome (executed or failed) of an approved
+// action.
+func (d *DB) FinishApproval(id int64, status, result string) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+	if status != ApprovalExecuted && status != ApprovalFailed {
+		return fmt.Errorf("invalid outcome status %q", status)
+	}
+	_, err := d.conn.Exec(
+		`UPDATE approvals SET status = ?, result = ? WHERE id = ? AND status = ?`,
+		status, result, id, ApprovalApproved,
+	)
+	if err != nil {
+		return fmt.Errorf("update approvals: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) queryApprovals(where string, args ...interface{}) ([]Approval, error) {
+	rows, err := d.conn.Query(
+		`SELECT id, created_at, requester, action, provider, profile_name, args, risk_tier, status, decided_at, decided_by, reason, result FROM approvals `+where,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query approvals: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Approval
+	for rows.Next() {
+		var (
+			a                    Approval
+			createdStr, argsStr  string
+			decidedAt, decidedBy sql.NullString
+			reason, result       sql.NullString
+		)
+		if err := rows.Scan(&a.ID, &createdStr, &a.Requester, &a.Action, &a.Provider, &a.ProfileName, &argsStr,
+			&a.RiskTier, &a.Status, &decidedAt, &decidedBy, &reason, &result); err != nil {
+			return nil, fmt.Errorf("scan approvals: %w", err)
+		}
+		createdAt, err := parseSQLiteTime(createdStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse created_at %q: %w", createdStr, err)
+		}
+		a.CreatedAt = createdAt
+		if err := json.Unmarshal([]byte(argsStr), &a.Args); err != nil {
+			return nil, fmt.Errorf("parse args for approval %d: %w", a.ID, err)
+		}
+		if decidedAt.Valid {
+			if t, err := parseSQLiteTime(decidedAt.String); err == nil {
+				a.DecidedAt = t
+			}
+		}
+		a.DecidedBy = decidedBy.String
+		a.Reason = reason.String
+		a.Result = result.String
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestApprovals_ProposeDecideFinish(t *testing.T) {
+	tmpDir := t.TempDir()
+	d, err := OpenAt(filepath.Join(tmpDir, "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	proposal := Approval{
+		Requester:   "agent",
+		Action:      "activate",
+		Provider:    "claude",
+		ProfileName: "personal",
+		Args:        []string{"activate", "claude", "personal"},
+		RiskTier:    "high",
+	}
+	created, err := d.ProposeApproval(proposal)
+	if err != nil {
+		t.Fatalf("ProposeApproval() error = %v", err)
+	}
+	if created.ID == 0 || created.Status != ApprovalPending {
+		t.Fatalf("created = %+v, want pending with ID", created)
+	}
+
+	again, err := d.ProposeApproval(proposal)
+	if err != nil {
+		t.Fatalf("ProposeApproval() again error = %v", err)
+	}
+	if again.ID != created.ID {
+		t.Fatalf("duplicate proposal ID = %d, want existing %d", again.ID, created.ID)
+	}
+
+	pending, err := d.ListApprovals(ApprovalPending, 10)
+	if err != nil {
+		t.Fatalf("ListApprovals() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].Args[2] != "personal" || pending[0].RiskTier != "high" {
+		t.Fatalf("pending = %+v, want the one proposal", pending)
+	}
+
+	ok, err := d.DecideApproval(created.ID, ApprovalApproved, "me", "", time.Now())
+	if err != nil || !ok {
+		t.Fatalf("DecideApproval() = %v, %v; want true", ok, err)
+	}
+	ok, err = d.DecideApproval(created.ID, ApprovalDenied, "someone else", "too late", time.Now())
+	if err != nil || ok {
+		t.Fatalf("second DecideApproval() = %v, %v; want false", ok, err)
+	}
+
+	if err := d.FinishApproval(created.ID, ApprovalExecuted, `{"success":true}`); err != nil {
+		t.Fatalf("FinishApproval() error = %v", err)
+	}
+	got, err := d.GetApproval(created.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetApproval() = %v, %v", got, err)
+	}
+	if got.Status != ApprovalExecuted || got.DecidedBy != "me" || got.DecidedAt.IsZero() || got.Result == "" {
+		t.Fatalf("approval = %+v, want executed by me with result", got)
+	}
+
+	// Once decided, the same action can be proposed afresh.
+	next, err := d.ProposeApproval(proposal)
+	if err != nil {
+		t.Fatalf("ProposeApproval() after decision error = %v", err)
+	}
+	if next.ID == created.ID {
+		t.Fatal("new proposal reused a decided approval")
+	}
+
+	if missing, err := d.GetApproval(9999); err != nil || missing != nil {
+		t.Fatalf("GetApproval(missing) = %v, %v; want nil, nil", missing, err)
+	}
+	if _, err := d.DecideApproval(next.ID, ApprovalExecuted, "me", "", time.Now()); err == nil {
+		t.Fatal("DecideApproval(executed) succeeded, want error")
+	}
+}
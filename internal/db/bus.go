@@ -0,0 +1,136 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+)
+
+// AppendEvent stores an event-bus event and returns it with its ID. It
+// implements events.Store.
+func (d *DB) AppendEvent(ev events.Event) (events.Event, error) {
+	if d == nil || d.conn == nil {
+		return ev, fmt.Errorf("db is not open")
+	}
+	if strings.TrimSpace(string(ev.Type)) == "" {
+		return ev, fmt.Errorf("event type is required")
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	ev.Time = ev.Time.UTC()
+
+	var data sql.NullString
+	if len(ev.Data) > 0 {
+		b, err := json.Marshal(ev.Data)
+		if err != nil {
+			return ev, fmt.Errorf("marshal event data: %w", err)
+		}
+		data = sql.NullString{String: string(b), Valid: true}
+	}
+
+	res, err := d.conn.Exec(
+		`INSERT INTO events (timestamp, type, provider, profile_name, source, data) VALUES (?, ?, ?, ?, ?, ?)`,
+		ev.Time.Format(time.RFC3339Nano),
+		string(ev.Type),
+		ev.Provider,
+		ev.Profile,
+		ev.Source,
+		data,
+	)
+	if err != nil {
+		return ev, fmt.Errorf("insert events: %w", err)
+	}
+	ev.ID, _ = res.LastInsertId()
+	return ev, nil
+}
+
+// EventsAfter returns up to limit events with an ID greater than afterID,
+// oldest first. It implements events.Store.
+func (d *DB) EventsAfter(afterID int64, limit int) ([]events.Event, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	return d.queryBusEvents(`WHERE id > ? ORDER BY id ASC LIMIT ?`, afterID, limit)
+}
+
+// LatestEvents returns the newest limit events matching f, oldest first.
+func (d *DB) LatestEvents(f events.Filter, limit int) ([]events.Event, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var where []string
+	var args []any
+	if f.Provider != "" {
+		where = append(where, "provider = ?")
+		args = append(args, f.Provider)
+	}
+	if len(f.Types) > 0 {
+		placeholders := make([]string, len(f.Types))
+		for i, t := range f.Types {
+			placeholders[i] = "?"
+			args = append(args, string(t))
+		}
+		where = append(where, "type IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	clause := ""
+	if len(where) > 0 {
+		clause = "WHERE " + strings.Join(where, " AND ") + " "
+	}
+	args = append(args, limit)
+
+	evs, err := d.queryBusEvents(clause+`ORDER BY id DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(evs)-1; i < j; i, j = i+1, j-1 {
+		evs[i], evs[j] = evs[j], evs[i]
+	}
+	return evs, nil
+}
+
+func (d *DB) queryBusEvents(clause string, args ...any) ([]events.Event, error) {
+	rows, err := d.conn.Query(
+		`SELECT id, timestamp, type, provider, profile_name, source, data FROM events `+clause,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []events.Event
+	for rows.Next() {
+		var (
+			ev      events.Event
+			ts, typ string
+			data    sql.NullString
+		)
+		if err := rows.Scan(&ev.ID, &ts, &typ, &ev.Provider, &ev.Profile, &ev.Source, &data); err != nil {
+			return nil, fmt.Errorf("scan events: %w", err)
+		}
+		ev.Type = events.Type(typ)
+		if t, err := parseSQLiteTime(ts); err == nil {
+			ev.Time = t
+		}
+		if data.Valid && data.String != "" {
+			_ = json.Unmarshal([]byte(data.String), &ev.Data)
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate events: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+)
+
+func TestBusEvents_AppendAndQuery(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	in := []events.Event{
+		{Type: events.ProfileActivated, Provider: "claude", Profile: "work", Source: "activate"},
+		{Type: events.CooldownSet, Provider: "claude", Profile: "work", Data: map[string]any{"notes": "rate limit"}},
+		{Type: events.ProfileActivated, Provider: "codex", Profile: "main", Source: "next"},
+	}
+	var ids []int64
+	for _, ev := range in {
+		stored, err := d.AppendEvent(ev)
+		if err != nil {
+			t.Fatalf("AppendEvent() error = %v", err)
+		}
+		if stored.ID == 0 || stored.Time.IsZero() {
+			t.Fatalf("AppendEvent() = %+v, want ID and time set", stored)
+		}
+		ids = append(ids, stored.ID)
+	}
+
+	after, err := d.EventsAfter(ids[0], 10)
+	if err != nil {
+		t.Fatalf("EventsAfter() error = %v", err)
+	}
+	if len(after) != 2 || after[0].ID != ids[1] || after[1].ID != ids[2] {
+		t.Fatalf("EventsAfter() = %+v, want events %d and %d", after, ids[1], ids[2])
+	}
+	if after[0].Data["notes"] != "rate limit" {
+		t.Fatalf("EventsAfter() data = %v, want notes preserved", after[0].Data)
+	}
+
+	latest, err := d.LatestEvents(events.Filter{Types: []events.Type{events.ProfileActivated}}, 10)
+	if err != nil {
+		t.Fatalf("LatestEvents() error = %v", err)
+	}
+	if len(latest) != 2 || latest[0].Provider != "claude" || latest[1].Provider != "codex" {
+		t.Fatalf("LatestEvents() = %+v, want claude then codex activations", latest)
+	}
+
+	latest, err = d.LatestEvents(events.Filter{Provider: "claude"}, 1)
+	if err != nil {
+		t.Fatalf("LatestEvents() error = %v", err)
+	}
+	if len(latest) != 1 || latest[0].Type != events.CooldownSet {
+		t.Fatalf("LatestEvents(limit 1) = %+v, want newest claude event", latest)
+	}
+}
+
+func TestBusEvents_AppendOnly(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	stored, err := d.AppendEvent(events.Event{Type: events.TokenRefreshed, Provider: "claude", Profile: "work"})
+	if err != nil {
+		t.Fatalf("AppendEvent() error = %v", err)
+	}
+	if _, err := d.Conn().Exec(`UPDATE events SET profile_name = 'other' WHERE id = ?`, stored.ID); err == nil {
+		t.Fatal("UPDATE events succeeded, want append-only trigger to abort")
+	}
+	if _, err := d.AppendEvent(events.Event{}); err == nil {
+		t.Fatal("AppendEvent() without type succeeded, want error")
+	}
+}
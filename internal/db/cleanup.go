@@ -27,11 +27,12 @@ func DefaultCleanupConfig() CleanupConfig {
 type CleanupResult struct {
 	ActivityLogsDeleted int
 	StatsEntriesDeleted int
+	SyncHistoryDeleted  int
 	VacuumRan           bool
 }
 
 // Cleanup removes old records based on the retention configuration.
-// It deletes activity_log entries older than RetentionDays and
+// It deletes activity_log and sync_history entries older than RetentionDays and
 // profile_stats entries for profiles with no recent activity.
 // If RetentionDays or AggregateRetentionDays is <= 0, that cleanup is skipped
 // (treated as "keep forever").
@@ -55,6 +56,16 @@ func (d *DB) Cleanup(cfg CleanupConfig) (*CleanupResult, error) {
 		}
 		deleted, _ := activityResult.RowsAffected()
 		result.ActivityLogsDeleted = int(deleted)
+
+		syncResult, err := d.conn.Exec(`
+			DELETE FROM sync_history
+			WHERE timestamp < ?
+		`, formatSQLiteTime(activityCutoff))
+		if err != nil {
+			return nil, fmt.Errorf("delete old sync history: %w", err)
+		}
+		syncDeleted, _ := syncResult.RowsAffected()
+		result.SyncHistoryDeleted = int(syncDeleted)
 	}
 
 	// Delete stale profile_stats (skip if aggregate retention <= 0)
@@ -113,6 +124,16 @@ func (d *DB) CleanupDryRun(cfg CleanupConfig) (*CleanupResult, error) {
 			return nil, fmt.Errorf("count old activity logs: %w", err)
 		}
 		result.ActivityLogsDeleted = activityCount
+
+		var syncCount int
+		err = d.conn.QueryRow(`
+			SELECT COUNT(*) FROM sync_history
+			WHERE timestamp < ?
+		`, formatSQLiteTime(activityCutoff)).Scan(&syncCount)
+		if err != nil {
+			return nil, fmt.Errorf("count old sync history: %w", err)
+		}
+		result.SyncHistoryDeleted = syncCount
 	}
 
 	// Count profile_stats that would be deleted (skip if aggregate retention <= 0)
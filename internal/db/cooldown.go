@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 )
 
 // CooldownEvent records a provider/profile limit hit and its enforced cooldown.
@@ -67,6 +69,12 @@ func (d *DB) SetCooldown(provider, profile string, hitAt time.Time, duration tim
 	}
 
 	id, _ := res.LastInsertId()
+	data := map[string]any{"until": cooldownUntil.Format(time.RFC3339)}
+	if notes != "" {
+		data["notes"] = notes
+	}
+	events.Publish(events.Event{Type: events.CooldownSet, Provider: provider, Profile: profile, Source: "cooldown", Data: data})
+
 	return &CooldownEvent{
 		ID:            id,
 		Provider:      provider,
@@ -207,6 +215,215 @@ func (d *DB) ListActiveCooldowns(now time.Time) ([]CooldownEvent, error) {
 	return out, nil
 }
 
+// CooldownHistory returns a profile's most recent cooldowns, active or not,
+// newest first. limit <= 0 returns all of them.
+func (d *DB) CooldownHistory(provider, profile string, limit int) ([]CooldownEvent, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	provider = strings.TrimSpace(provider)
+	profile = strings.TrimSpace(profile)
+	if provider == "" {
+		return nil, fmt.Errorf("provider is required")
+	}
+	if profile == "" {
+		return nil, fmt.Errorf("profile name is required")
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := d.conn.Query(
+		`SELECT id, provider, profile_name, hit_at, cooldown_until, notes
+		   FROM limit_events
+		  WHERE provider = ? AND profile_name = ?
+		  ORDER BY datetime(hit_at) DESC, id DESC
+		  LIMIT ?`,
+		provider,
+		profile,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query limit_events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []CooldownEvent
+	for rows.Next() {
+		var (
+			ev               CooldownEvent
+			hitAtStr         string
+			cooldownUntilStr string
+			notes            sql.NullString
+		)
+		if err := rows.Scan(&ev.ID, &ev.Provider, &ev.ProfileName, &hitAtStr, &cooldownUntilStr, &notes); err != nil {
+			return nil, fmt.Errorf("scan limit_events: %w", err)
+		}
+		hitAt, err := parseSQLiteTime(hitAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse hit_at %q: %w", hitAtStr, err)
+		}
+		cooldownUntil, err := parseSQLiteTime(cooldownUntilStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse cooldown_until %q: %w", cooldownUntilStr, err)
+		}
+		ev.HitAt = hitAt
+		ev.CooldownUntil = cooldownUntil
+		if notes.Valid {
+			ev.Notes = notes.String
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate limit_events: %w", err)
+	}
+	return out, nil
+}
+
+// CooldownsSince returns every profile's cooldowns that started at or
+// after since, active or not, newest first.
+func (d *DB) CooldownsSince(since time.Time) ([]CooldownEvent, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	rows, err := d.conn.Query(
+		`SELECT id, provider, profile_name, hit_at, cooldown_until, notes
+		   FROM limit_events
+		  WHERE datetime(hit_at) >= datetime(?)
+		  ORDER BY datetime(hit_at) DESC, id DESC`,
+		formatSQLiteTime(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query limit_events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []CooldownEvent
+	for rows.Next() {
+		var (
+			ev               CooldownEvent
+			hitAtStr         string
+			cooldownUntilStr string
+			notes            sql.NullString
+		)
+		if err := rows.Scan(&ev.ID, &ev.Provider, &ev.ProfileName, &hitAtStr, &cooldownUntilStr, &notes); err != nil {
+			return nil, fmt.Errorf("scan limit_events: %w", err)
+		}
+		hitAt, err := parseSQLiteTime(hitAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse hit_at %q: %w", hitAtStr, err)
+		}
+		cooldownUntil, err := parseSQLiteTime(cooldownUntilStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse cooldown_until %q: %w", cooldownUntilStr, err)
+		}
+		ev.HitAt = hitAt
+		ev.CooldownUntil = cooldownUntil
+		if notes.Valid {
+			ev.Notes = notes.String
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate limit_events: %w", err)
+	}
+	return out, nil
+}
+
+// ExpiredUnprobedCooldowns returns cooldowns that ended in (since, now] and
+// have not been probed yet. Only each profile's newest cooldown is
+// considered, so a profile that is still cooling down (or was already
+// re-limited) is skipped.
+func (d *DB) ExpiredUnprobedCooldowns(since, now time.Time) ([]CooldownEvent, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	if now.IsZero() {
+		now = time.Now().UTC()
+	} else {
+		now = now.UTC()
+	}
+
+	rows, err := d.conn.Query(
+		`SELECT le.id, le.provider, le.profile_name, le.hit_at, le.cooldown_until, le.notes
+		   FROM limit_events le
+		  WHERE datetime(le.cooldown_until) <= datetime(?)
+		    AND datetime(le.cooldown_until) > datetime(?)
+		    AND le.probed_at IS NULL
+		    AND le.id = (
+		      SELECT le2.id
+		        FROM limit_events le2
+		       WHERE le2.provider = le.provider
+		         AND le2.profile_name = le.profile_name
+		       ORDER BY datetime(le2.cooldown_until) DESC, datetime(le2.hit_at) DESC, le2.id DESC
+		       LIMIT 1
+		    )
+		  ORDER BY datetime(le.cooldown_until) ASC, le.id ASC`,
+		formatSQLiteTime(now),
+		formatSQLiteTime(since.UTC()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query limit_events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []CooldownEvent
+	for rows.Next() {
+		var (
+			ev               CooldownEvent
+			hitAtStr         string
+			cooldownUntilStr string
+			notes            sql.NullString
+		)
+		if err := rows.Scan(&ev.ID, &ev.Provider, &ev.ProfileName, &hitAtStr, &cooldownUntilStr, &notes); err != nil {
+			return nil, fmt.Errorf("scan limit_events: %w", err)
+		}
+		hitAt, err := parseSQLiteTime(hitAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse hit_at %q: %w", hitAtStr, err)
+		}
+		cooldownUntil, err := parseSQLiteTime(cooldownUntilStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse cooldown_until %q: %w", cooldownUntilStr, err)
+		}
+		ev.HitAt = hitAt
+		ev.CooldownUntil = cooldownUntil
+		if notes.Valid {
+			ev.Notes = notes.String
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate limit_events: %w", err)
+	}
+	return out, nil
+}
+
+// MarkCooldownProbed records the outcome of the end-of-cooldown probe for a
+// cooldown entry so it isn't probed again.
+func (d *DB) MarkCooldownProbed(id int64, result string, at time.Time) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	_, err := d.conn.Exec(
+		`UPDATE limit_events SET probed_at = ?, probe_result = ? WHERE id = ?`,
+		formatSQLiteTime(at.UTC()),
+		strings.TrimSpace(result),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("update limit_events: %w", err)
+	}
+	return nil
+}
+
 // ClearCooldown deletes cooldown history for a specific provider/profile.
 func (d *DB) ClearCooldown(provider, profile string) (int64, error) {
 	if d == nil || d.conn == nil {
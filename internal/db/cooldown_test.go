@@ -161,3 +161,92 @@ func TestCooldown_Clear(t *testing.T) {
 		t.Fatalf("ClearAllCooldowns() deleted = %d, want > 0", allDeleted)
 	}
 }
+
+func TestCooldown_History(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, notes := range []string{"oldest", "middle", "newest"} {
+		if _, err := d.SetCooldown("claude", "work", now.Add(time.Duration(i-3)*time.Hour), 30*time.Minute, notes); err != nil {
+			t.Fatalf("SetCooldown() error = %v", err)
+		}
+	}
+	if _, err := d.SetCooldown("claude", "other", now, time.Hour, ""); err != nil {
+		t.Fatalf("SetCooldown() error = %v", err)
+	}
+
+	history, err := d.CooldownHistory("claude", "work", 2)
+	if err != nil {
+		t.Fatalf("CooldownHistory() error = %v", err)
+	}
+	if len(history) != 2 || history[0].Notes != "newest" || history[1].Notes != "middle" {
+		t.Fatalf("CooldownHistory() = %+v, want newest two, newest first", history)
+	}
+	if all, _ := d.CooldownHistory("claude", "work", 0); len(all) != 3 {
+		t.Errorf("CooldownHistory(no limit) len = %d, want 3", len(all))
+	}
+
+	since, err := d.CooldownsSince(now.Add(-150 * time.Minute))
+	if err != nil {
+		t.Fatalf("CooldownsSince() error = %v", err)
+	}
+	if len(since) != 3 || since[0].ProfileName != "other" || since[2].Notes != "middle" {
+		t.Fatalf("CooldownsSince() = %+v, want other, newest, middle", since)
+	}
+}
+
+func TestCooldown_ExpiredUnprobed(t *testing.T) {
+	tmpDir := t.TempDir()
+	d, err := OpenAt(filepath.Join(tmpDir, "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	now := time.Now().UTC().Truncate(time.Second)
+	since := now.Add(-time.Hour)
+
+	// Expired 10 minutes ago: should be returned.
+	expired, err := d.SetCooldown("claude", "work", now.Add(-70*time.Minute), time.Hour, "rate limit")
+	if err != nil {
+		t.Fatalf("SetCooldown() error = %v", err)
+	}
+	// Still active: skipped.
+	if _, err := d.SetCooldown("claude", "busy", now.Add(-10*time.Minute), time.Hour, ""); err != nil {
+		t.Fatalf("SetCooldown() error = %v", err)
+	}
+	// Expired before the lookback window: skipped.
+	if _, err := d.SetCooldown("codex", "old", now.Add(-5*time.Hour), time.Hour, ""); err != nil {
+		t.Fatalf("SetCooldown() error = %v", err)
+	}
+	// Expired, but superseded by a newer active cooldown: skipped.
+	if _, err := d.SetCooldown("codex", "again", now.Add(-70*time.Minute), time.Hour, ""); err != nil {
+		t.Fatalf("SetCooldown() error = %v", err)
+	}
+	if _, err := d.SetCooldown("codex", "again", now.Add(-time.Minute), time.Hour, ""); err != nil {
+		t.Fatalf("SetCooldown() error = %v", err)
+	}
+
+	got, err := d.ExpiredUnprobedCooldowns(since, now)
+	if err != nil {
+		t.Fatalf("ExpiredUnprobedCooldowns() error = %v", err)
+	}
+	if len(got) != 1 || got[0].ID != expired.ID || got[0].Notes != "rate limit" {
+		t.Fatalf("ExpiredUnprobedCooldowns() = %+v, want only claude/work", got)
+	}
+
+	if err := d.MarkCooldownProbed(expired.ID, "passed", now); err != nil {
+		t.Fatalf("MarkCooldownProbed() error = %v", err)
+	}
+	got, err = d.ExpiredUnprobedCooldowns(since, now)
+	if err != nil {
+		t.Fatalf("ExpiredUnprobedCooldowns() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("ExpiredUnprobedCooldowns() after probe = %+v, want none", got)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/chaos"
 	_ "modernc.org/sqlite"
 )
 
@@ -25,6 +26,9 @@ func OpenAt(path string) (*DB, error) {
 	if strings.TrimSpace(path) == "" {
 		return nil, fmt.Errorf("path is required")
 	}
+	if err := chaos.Err(chaos.DBLocked); err != nil {
+		return nil, err
+	}
 
 	clean := filepath.Clean(path)
 	if err := os.MkdirAll(filepath.Dir(clean), 0700); err != nil {
@@ -68,30 +72,12 @@ func (d *DB) Close() error {
 // Checkpoint performs a best-effort WAL checkpoint to flush data to the main DB file.
 // It does not run migrations and should be safe to call before file-based backups.
 func Checkpoint(path string) error {
-	if strings.TrimSpace(path) == "" {
-		return fmt.Errorf("path is required")
-	}
-
-	info, err := os.Stat(path)
+	conn, err := openExisting(path)
 	if err != nil {
 		return err
 	}
-	if info.IsDir() {
-		return fmt.Errorf("path is a directory: %s", path)
-	}
-
-	conn, err := sql.Open("sqlite", dsn(path))
-	if err != nil {
-		return fmt.Errorf("open sqlite: %w", err)
-	}
 	defer conn.Close()
 
-	conn.SetMaxOpenConns(1)
-	conn.SetMaxIdleConns(1)
-
-	if _, err := conn.Exec(`PRAGMA busy_timeout=5000;`); err != nil {
-		return fmt.Errorf("set busy_timeout: %w", err)
-	}
 	if _, err := conn.Exec(`PRAGMA wal_checkpoint(TRUNCATE);`); err != nil {
 		return fmt.Errorf("wal checkpoint: %w", err)
 	}
@@ -1,12 +1,25 @@
 package db
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/chaos"
 )
 
+func TestOpenAt_ChaosDBLocked(t *testing.T) {
+	restore := chaos.Inject(chaos.DBLocked)
+	defer restore()
+
+	_, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if !errors.Is(err, chaos.ErrInjected) {
+		t.Fatalf("OpenAt() error = %v, want injected failure", err)
+	}
+}
+
 func TestOpenAt_CreatesDBAndRunsMigrations(t *testing.T) {
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "caam.db")
@@ -38,8 +51,8 @@ func TestOpenAt_CreatesDBAndRunsMigrations(t *testing.T) {
 	if err := d.Conn().QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
 		t.Fatalf("read schema_version error = %v", err)
 	}
-	if version != 3 {
-		t.Fatalf("schema_version max = %d, want 3", version)
+	if version != 16 {
+		t.Fatalf("schema_version max = %d, want 16", version)
 	}
 }
 
@@ -15,6 +15,8 @@ const (
 	EventError       = "error"
 	EventSwitch      = "switch"
 	EventDeactivate  = "deactivate"
+	EventNote        = "note"
+	EventRateLimit   = "rate_limit"
 	sqliteTimeLayout = "2006-01-02 15:04:05"
 )
 
@@ -352,6 +354,30 @@ func (d *DB) LastActivation(provider, profile string) (time.Time, error) {
 	return ts, nil
 }
 
+// CountActivations returns how many times a provider/profile has been
+// activated since the given time.
+func (d *DB) CountActivations(provider, profile string, since time.Time) (int, error) {
+	if d == nil || d.conn == nil {
+		return 0, fmt.Errorf("db is not open")
+	}
+
+	var n int
+	err := d.conn.QueryRow(
+		`SELECT COUNT(*)
+		 FROM activity_log
+		 WHERE provider = ? AND profile_name = ? AND event_type = ?
+		   AND datetime(timestamp) >= datetime(?)`,
+		strings.TrimSpace(provider),
+		strings.TrimSpace(profile),
+		EventActivate,
+		formatSQLiteTime(since),
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count activations: %w", err)
+	}
+	return n, nil
+}
+
 func updateProfileStats(tx *sql.Tx, eventType, provider, profile, ts string, durationSeconds int64) error {
 	switch eventType {
 	case EventActivate:
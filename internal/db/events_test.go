@@ -221,3 +221,33 @@ func TestDB_ProfileStats_LastErrorIsMonotonic(t *testing.T) {
 		t.Fatalf("LastError = %s, want %s", stats.LastError.Format(time.RFC3339Nano), newer.Format(time.RFC3339Nano))
 	}
 }
+
+func TestDB_CountActivations(t *testing.T) {
+	tmpDir := t.TempDir()
+	d, err := OpenAt(tmpDir + "/caam.db")
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, ago := range []time.Duration{30 * time.Hour, 3 * time.Hour, time.Hour} {
+		if err := d.LogEvent(Event{Type: EventActivate, Provider: "codex", ProfileName: "work", Timestamp: now.Add(-ago)}); err != nil {
+			t.Fatalf("LogEvent(activate) error = %v", err)
+		}
+	}
+	if err := d.LogEvent(Event{Type: EventDeactivate, Provider: "codex", ProfileName: "work", Timestamp: now}); err != nil {
+		t.Fatalf("LogEvent(deactivate) error = %v", err)
+	}
+
+	got, err := d.CountActivations("codex", "work", now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("CountActivations() error = %v", err)
+	}
+	if got != 2 {
+		t.Fatalf("CountActivations() = %d, want 2", got)
+	}
+	if got, _ := d.CountActivations("codex", "other", time.Time{}); got != 0 {
+		t.Fatalf("CountActivations(other) = %d, want 0", got)
+	}
+}
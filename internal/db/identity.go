@@ -0,0 +1,134 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProfileIdentity is the account identity recorded for a vault profile. It
+// lets status and email matching work for profiles whose auth files don't
+// carry an email or plan (typically ones saved before identity extraction
+// existed).
+type ProfileIdentity struct {
+	Provider     string
+	ProfileName  string
+	Email        string
+	PlanType     string
+	AccountID    string
+	Organization string
+	// Source is where the data came from ("vault" or "api").
+	Source    string
+	UpdatedAt time.Time
+}
+
+// UpsertProfileIdentity records identity for a profile. Empty fields never
+// overwrite values already stored.
+func (d *DB) UpsertProfileIdentity(pi ProfileIdentity) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+
+	provider := strings.TrimSpace(pi.Provider)
+	profile := strings.TrimSpace(pi.ProfileName)
+	if provider == "" {
+		return fmt.Errorf("provider is required")
+	}
+	if profile == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if pi.UpdatedAt.IsZero() {
+		pi.UpdatedAt = time.Now().UTC()
+	}
+
+	_, err := d.conn.Exec(`
+INSERT INTO profile_identities (provider, profile_name, email, plan_type, account_id, organization, source, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(provider, profile_name) DO UPDATE SET
+    email = COALESCE(NULLIF(excluded.email, ''), profile_identities.email),
+    plan_type = COALESCE(NULLIF(excluded.plan_type, ''), profile_identities.plan_type),
+    account_id = COALESCE(NULLIF(excluded.account_id, ''), profile_identities.account_id),
+    organization = COALESCE(NULLIF(excluded.organization, ''), profile_identities.organization),
+    source = excluded.source,
+    updated_at = excluded.updated_at`,
+		provider,
+		profile,
+		strings.TrimSpace(pi.Email),
+		strings.TrimSpace(pi.PlanType),
+		strings.TrimSpace(pi.AccountID),
+		strings.TrimSpace(pi.Organization),
+		pi.Source,
+		formatSQLiteTime(pi.UpdatedAt.UTC()),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert profile_identities: %w", err)
+	}
+	return nil
+}
+
+// ProfileIdentityFor returns the recorded identity for a profile, or
+// (nil, nil) if none has been recorded.
+func (d *DB) ProfileIdentityFor(provider, profile string) (*ProfileIdentity, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	ids, err := d.queryProfileIdentities(`WHERE provider = ? AND profile_name = ?`, strings.TrimSpace(provider), strings.TrimSpace(profile))
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	return &ids[0], nil
+}
+
+// ListProfileIdentities returns recorded identities, optionally limited to
+// one provider, ordered by provider and profile.
+func (d *DB) ListProfileIdentities(provider string) ([]ProfileIdentity, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	provider = strings.TrimSpace(provider)
+	if provider != "" {
+		return d.queryProfileIdentities(`WHERE provider = ? ORDER BY profile_name`, provider)
+	}
+	return d.queryProfileIdentities(`ORDER BY provider, profile_name`)
+}
+
+// DeleteProfileIdentity forgets the identity recorded for a profile.
+func (d *DB) DeleteProfileIdentity(provider, profile string) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+	if _, err := d.conn.Exec(`DELETE FROM profile_identities WHERE provider = ? AND profile_name = ?`,
+		strings.TrimSpace(provider), strings.TrimSpace(profile)); err != nil {
+		return fmt.Errorf("delete profile_identities: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) queryProfileIdentities(where string, args ...interface{}) ([]ProfileIdentity, error) {
+	rows, err := d.conn.Query(
+		`SELECT provider, profile_name, email, plan_type, account_id, organization, source, updated_at FROM profile_identities `+where,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query profile_identities: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []ProfileIdentity
+	for rows.Next() {
+		var (
+			pi         ProfileIdentity
+			updatedStr string
+		)
+		if err := rows.Scan(&pi.Provider, &pi.ProfileName, &pi.Email, &pi.PlanType, &pi.AccountID, &pi.Organization, &pi.Source, &updatedStr); err != nil {
+			return nil, fmt.Errorf("scan profile_identities: %w", err)
+		}
+		updatedAt, err := parseSQLiteTime(updatedStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse updated_at %q: %w", updatedStr, err)
+		}
+		pi.UpdatedAt = updatedAt
+		ids = append(ids, pi)
+	}
+	return ids, rows.Err()
+}
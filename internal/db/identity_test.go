@@ -0,0 +1,61 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileIdentity_UpsertKeepsKnownFields(t *testing.T) {
+	tmpDir := t.TempDir()
+	d, err := OpenAt(filepath.Join(tmpDir, "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	if err := d.UpsertProfileIdentity(ProfileIdentity{Provider: "claude", ProfileName: "work", Email: "dev@example.com", Source: "vault"}); err != nil {
+		t.Fatalf("UpsertProfileIdentity() error = %v", err)
+	}
+	// A later refresh that only learns the plan must not blank the email.
+	if err := d.UpsertProfileIdentity(ProfileIdentity{Provider: "claude", ProfileName: "work", PlanType: "max", Source: "api"}); err != nil {
+		t.Fatalf("UpsertProfileIdentity() second error = %v", err)
+	}
+
+	got, err := d.ProfileIdentityFor("claude", "work")
+	if err != nil {
+		t.Fatalf("ProfileIdentityFor() error = %v", err)
+	}
+	if got == nil {
+		t.Fatal("ProfileIdentityFor() = nil")
+	}
+	if got.Email != "dev@example.com" || got.PlanType != "max" || got.Source != "api" {
+		t.Fatalf("ProfileIdentityFor() = %+v", got)
+	}
+	if got.UpdatedAt.IsZero() {
+		t.Fatal("UpdatedAt not set")
+	}
+
+	missing, err := d.ProfileIdentityFor("codex", "work")
+	if err != nil || missing != nil {
+		t.Fatalf("ProfileIdentityFor(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	if err := d.UpsertProfileIdentity(ProfileIdentity{Provider: "codex", ProfileName: "main", PlanType: "plus", Source: "vault"}); err != nil {
+		t.Fatalf("UpsertProfileIdentity(codex) error = %v", err)
+	}
+	all, err := d.ListProfileIdentities("")
+	if err != nil || len(all) != 2 {
+		t.Fatalf("ListProfileIdentities() = %d, %v; want 2", len(all), err)
+	}
+	claudeOnly, err := d.ListProfileIdentities("claude")
+	if err != nil || len(claudeOnly) != 1 {
+		t.Fatalf("ListProfileIdentities(claude) = %d, %v; want 1", len(claudeOnly), err)
+	}
+
+	if err := d.DeleteProfileIdentity("claude", "work"); err != nil {
+		t.Fatalf("DeleteProfileIdentity() error = %v", err)
+	}
+	if got, _ := d.ProfileIdentityFor("claude", "work"); got != nil {
+		t.Fatalf("identity still present after delete: %+v", got)
+	}
+}
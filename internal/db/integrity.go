@@ -0,0 +1,158 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// IntegrityCheck runs PRAGMA integrity_check on the database at path without
+// running migrations. It returns the problems SQLite reports, or nil when the
+// database is intact. A file that is not a database at all is reported as a
+// problem rather than an error.
+func IntegrityCheck(path string) ([]string, error) {
+	conn, err := openExisting(path)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query(`PRAGMA integrity_check;`)
+	if err != nil {
+		if isCorruptSQLiteError(err) {
+			return []string{err.Error()}, nil
+		}
+		return nil, fmt.Errorf("integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("read integrity check: %w", err)
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		if isCorruptSQLiteError(err) {
+			return append(problems, err.Error()), nil
+		}
+		return nil, fmt.Errorf("read integrity check: %w", err)
+	}
+	return problems, nil
+}
+
+// Vacuum rebuilds the database at path, which drops free pages and
+// recreates every index from its table.
+func Vacuum(path string) error {
+	conn, err := openExisting(path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec(`VACUUM;`); err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	return nil
+}
+
+// RestoreFrom replaces the database at path with the copy read from src,
+// after checking that the copy passes an integrity check. The database it
+// replaces is kept next to it as path.corrupt.<timestamp>, whose path is
+// returned ("" if there was no database). No connection to path may be open.
+func RestoreFrom(path string, src io.Reader) (string, error) {
+	tmpPath := path + ".restore.tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("create restore file: %w", err)
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("write restore file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("close restore file: %w", err)
+	}
+
+	problems, err := IntegrityCheck(tmpPath)
+	if err == nil && len(problems) > 0 {
+		err = fmt.Errorf("copy fails integrity check: %s", problems[0])
+	}
+	if err != nil {
+		_ = removeSQLiteFiles(tmpPath)
+		return "", err
+	}
+	// The check may have left sidecars for the copy; they are empty.
+	_ = removeSQLiteSidecars(tmpPath)
+
+	var backupPath string
+	if _, err := os.Stat(path); err == nil {
+		backupPath = path + ".corrupt." + time.Now().UTC().Format("20060102T150405Z")
+		if err := os.Rename(path, backupPath); err != nil {
+			os.Remove(tmpPath)
+			return "", fmt.Errorf("move damaged db aside: %w", err)
+		}
+		if err := renameSQLiteSidecars(path, backupPath); err != nil {
+			os.Remove(tmpPath)
+			return "", fmt.Errorf("move damaged db sidecars aside: %w", err)
+		}
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return backupPath, fmt.Errorf("install restored db: %w", err)
+	}
+	return backupPath, nil
+}
+
+// openExisting opens the database at path for maintenance. Unlike OpenAt it
+// neither creates the file nor runs migrations.
+func openExisting(path string) (*sql.DB, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path is a directory: %s", path)
+	}
+
+	conn, err := sql.Open("sqlite", dsn(path))
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	conn.SetMaxOpenConns(1)
+	conn.SetMaxIdleConns(1)
+
+	if _, err := conn.Exec(`PRAGMA busy_timeout=5000;`); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("set busy_timeout: %w", err)
+	}
+	return conn, nil
+}
+
+func removeSQLiteSidecars(path string) error {
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeSQLiteFiles(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return removeSQLiteSidecars(path)
+}
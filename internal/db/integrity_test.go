@@ -0,0 +1,84 @@
+package db
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestDBFile(t *testing.T, path string) {
+	t.Helper()
+	d, err := OpenAt(path)
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func TestIntegrityCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "caam.db")
+	newTestDBFile(t, path)
+
+	problems, err := IntegrityCheck(path)
+	if err != nil || len(problems) != 0 {
+		t.Fatalf("IntegrityCheck(healthy) = %v, %v; want no problems", problems, err)
+	}
+	if err := Vacuum(path); err != nil {
+		t.Fatalf("Vacuum() error = %v", err)
+	}
+
+	garbage := filepath.Join(tmpDir, "garbage.db")
+	if err := os.WriteFile(garbage, bytes.Repeat([]byte("not a database "), 512), 0600); err != nil {
+		t.Fatal(err)
+	}
+	problems, err = IntegrityCheck(garbage)
+	if err != nil || len(problems) == 0 {
+		t.Fatalf("IntegrityCheck(garbage) = %v, %v; want a problem", problems, err)
+	}
+
+	if _, err := IntegrityCheck(filepath.Join(tmpDir, "missing.db")); !os.IsNotExist(err) {
+		t.Errorf("IntegrityCheck(missing) error = %v, want not-exist", err)
+	}
+}
+
+func TestRestoreFrom(t *testing.T) {
+	tmpDir := t.TempDir()
+	good := filepath.Join(tmpDir, "good.db")
+	newTestDBFile(t, good)
+	goodData, err := os.ReadFile(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(tmpDir, "caam.db")
+	if err := os.WriteFile(path, []byte("damaged"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A bad copy is rejected and the current file left alone.
+	if _, err := RestoreFrom(path, strings.NewReader("also damaged")); err == nil {
+		t.Fatal("RestoreFrom(bad copy) should fail")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "damaged" {
+		t.Fatalf("db replaced by a bad copy: %q", data)
+	}
+
+	kept, err := RestoreFrom(path, bytes.NewReader(goodData))
+	if err != nil {
+		t.Fatalf("RestoreFrom() error = %v", err)
+	}
+	if data, _ := os.ReadFile(kept); string(data) != "damaged" {
+		t.Errorf("damaged db not kept at %q", kept)
+	}
+	if problems, err := IntegrityCheck(path); err != nil || len(problems) != 0 {
+		t.Errorf("restored db IntegrityCheck() = %v, %v", problems, err)
+	}
+	if leftovers, _ := filepath.Glob(path + ".restore.tmp*"); len(leftovers) != 0 {
+		t.Errorf("restore left temp files: %v", leftovers)
+	}
+}
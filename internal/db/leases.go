@@ -0,0 +1,212 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrLeaseHeld is returned when someone else holds an unexpired lease.
+var ErrLeaseHeld = errors.New("profile is leased")
+
+// Lease is an exclusive hold on a profile by one holder until ExpiresAt.
+type Lease struct {
+	Provider    string
+	ProfileName string
+	Holder      string
+	AcquiredAt  time.Time
+	ExpiresAt   time.Time
+	Note        string
+}
+
+// Active reports whether the lease is still in force at now.
+func (l Lease) Active(now time.Time) bool {
+	return l.ExpiresAt.After(now)
+}
+
+// AcquireLease gives holder the lease on provider/profile for ttl from now.
+// A holder acquiring its own lease again renews it. If another holder's
+// lease is still active, that lease is returned with ErrLeaseHeld.
+func (d *DB) AcquireLease(provider, profile, holder string, ttl time.Duration, note string, now time.Time) (*Lease, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	provider = strings.TrimSpace(provider)
+	profile = strings.TrimSpace(profile)
+	holder = strings.TrimSpace(holder)
+	if provider == "" {
+		return nil, fmt.Errorf("provider is required")
+	}
+	if profile == "" {
+		return nil, fmt.Errorf("profile name is required")
+	}
+	if holder == "" {
+		return nil, fmt.Errorf("holder is required")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("ttl must be > 0")
+	}
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin lease tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	current, err := scanLease(tx.QueryRow(
+		`SELECT provider, profile_name, holder, acquired_at, expires_at, note FROM leases WHERE provider = ? AND profile_name = ?`,
+		provider, profile,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	lease := Lease{
+		Provider:    provider,
+		ProfileName: profile,
+		Holder:      holder,
+		AcquiredAt:  now,
+		ExpiresAt:   now.Add(ttl),
+		Note:        strings.TrimSpace(note),
+	}
+	if current != nil && current.Active(now) {
+		if current.Holder != holder {
+			return current, fmt.Errorf("%s/%s held by %s until %s: %w",
+				provider, profile, current.Holder, current.ExpiresAt.Format(time.RFC3339), ErrLeaseHeld)
+		}
+		// A renewal keeps the original acquisition time.
+		lease.AcquiredAt = current.AcquiredAt
+		if lease.Note == "" {
+			lease.Note = current.Note
+		}
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO leases (provider, profile_name, holder, acquired_at, expires_at, note)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(provider, profile_name) DO UPDATE SET
+		     holder = excluded.holder,
+		     acquired_at = excluded.acquired_at,
+		     expires_at = excluded.expires_at,
+		     note = excluded.note`,
+		lease.Provider,
+		lease.ProfileName,
+		lease.Holder,
+		formatSQLiteTime(lease.AcquiredAt),
+		formatSQLiteTime(lease.ExpiresAt),
+		lease.Note,
+	); err != nil {
+		return nil, fmt.Errorf("upsert leases: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit lease: %w", err)
+	}
+	return &lease, nil
+}
+
+// ReleaseLease drops the lease on provider/profile. A non-empty holder only
+// releases that holder's lease. It reports whether a lease was removed.
+func (d *DB) ReleaseLease(provider, profile, holder string) (bool, error) {
+	if d == nil || d.conn == nil {
+		return false, fmt.Errorf("db is not open")
+	}
+	provider = strings.TrimSpace(provider)
+	profile = strings.TrimSpace(profile)
+	holder = strings.TrimSpace(holder)
+
+	var (
+		res sql.Result
+		err error
+	)
+	if holder == "" {
+		res, err = d.conn.Exec(`DELETE FROM leases WHERE provider = ? AND profile_name = ?`, provider, profile)
+	} else {
+		res, err = d.conn.Exec(`DELETE FROM leases WHERE provider = ? AND profile_name = ? AND holder = ?`, provider, profile, holder)
+	}
+	if err != nil {
+		return false, fmt.Errorf("delete leases: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
+
+// ActiveLease returns the lease in force on provider/profile at now, or nil.
+func (d *DB) ActiveLease(provider, profile string, now time.Time) (*Lease, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	lease, err := scanLease(d.conn.QueryRow(
+		`SELECT provider, profile_name, holder, acquired_at, expires_at, note FROM leases WHERE provider = ? AND profile_name = ?`,
+		strings.TrimSpace(provider), strings.TrimSpace(profile),
+	))
+	if err != nil || lease == nil || !lease.Active(now) {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// ActiveLeases returns the leases in force at now, soonest to expire first.
+// An empty provider matches every provider.
+func (d *DB) ActiveLeases(provider string, now time.Time) ([]Lease, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	query := `SELECT provider, profile_name, holder, acquired_at, expires_at, note FROM leases
+		WHERE datetime(expires_at) > datetime(?)`
+	args := []interface{}{formatSQLiteTime(now)}
+	if provider = strings.TrimSpace(provider); provider != "" {
+		query += ` AND provider = ?`
+		args = append(args, provider)
+	}
+	query += ` ORDER BY expires_at ASC, provider, profile_name`
+
+	rows, err := d.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query leases: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Lease
+	for rows.Next() {
+		lease, err := scanLease(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *lease)
+	}
+	return out, rows.Err()
+}
+
+type leaseScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanLease reads one leases row; a missing row gives nil, nil.
+func scanLease(row leaseScanner) (*Lease, error) {
+	var (
+		l                       Lease
+		acquiredStr, expiresStr string
+	)
+	if err := row.Scan(&l.Provider, &l.ProfileName, &l.Holder, &acquiredStr, &expiresStr, &l.Note); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan leases: %w", err)
+	}
+	acquiredAt, err := parseSQLiteTime(acquiredStr)
+	if err != nil {
+		return nil, fmt.Errorf("parse acquired_at %q: %w", acquiredStr, err)
+	}
+	expiresAt, err := parseSQLiteTime(expiresStr)
+	if err != nil {
+		return nil, fmt.Errorf("parse expires_at %q: %w", expiresStr, err)
+	}
+	l.AcquiredAt = acquiredAt
+	l.ExpiresAt = expiresAt
+	return &l, nil
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLeases_AcquireRenewRelease(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	now := time.Now().Truncate(time.Second)
+	lease, err := d.AcquireLease("claude", "pool1", "alice", 2*time.Hour, "refactor", now)
+	if err != nil {
+		t.Fatalf("AcquireLease(alice) error = %v", err)
+	}
+	if lease.Holder != "alice" || !lease.ExpiresAt.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("lease = %+v", lease)
+	}
+
+	held, err := d.AcquireLease("claude", "pool1", "bob", time.Hour, "", now.Add(time.Minute))
+	if !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("AcquireLease(bob) error = %v, want ErrLeaseHeld", err)
+	}
+	if held == nil || held.Holder != "alice" {
+		t.Errorf("held lease = %+v, want alice's", held)
+	}
+
+	// Alice renews: the expiry moves, the acquisition time and note stay.
+	renewed, err := d.AcquireLease("claude", "pool1", "alice", 3*time.Hour, "", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("renew error = %v", err)
+	}
+	if !renewed.AcquiredAt.Equal(now) || !renewed.ExpiresAt.Equal(now.Add(4*time.Hour)) || renewed.Note != "refactor" {
+		t.Errorf("renewed = %+v", renewed)
+	}
+
+	active, err := d.ActiveLease("claude", "pool1", now.Add(2*time.Hour))
+	if err != nil || active == nil || active.Holder != "alice" {
+		t.Fatalf("ActiveLease() = %+v, %v", active, err)
+	}
+	list, err := d.ActiveLeases("", now)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ActiveLeases() = %+v, %v", list, err)
+	}
+
+	if released, err := d.ReleaseLease("claude", "pool1", "bob"); err != nil || released {
+		t.Errorf("ReleaseLease(bob) = %v, %v; want false", released, err)
+	}
+	if released, err := d.ReleaseLease("claude", "pool1", "alice"); err != nil || !released {
+		t.Errorf("ReleaseLease(alice) = %v, %v; want true", released, err)
+	}
+	if active, err := d.ActiveLease("claude", "pool1", now); err != nil || active != nil {
+		t.Errorf("ActiveLease() after release = %+v, %v", active, err)
+	}
+}
+
+func TestLeases_ExpiredLeaseIsTakenOver(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	now := time.Now().Truncate(time.Second)
+	if _, err := d.AcquireLease("codex", "shared", "alice", time.Hour, "", now.Add(-2*time.Hour)); err != nil {
+		t.Fatalf("AcquireLease(alice) error = %v", err)
+	}
+	if active, _ := d.ActiveLease("codex", "shared", now); active != nil {
+		t.Fatalf("expired lease still active: %+v", active)
+	}
+	lease, err := d.AcquireLease("codex", "shared", "bob", time.Hour, "", now)
+	if err != nil {
+		t.Fatalf("AcquireLease(bob) error = %v", err)
+	}
+	if lease.Holder != "bob" || !lease.AcquiredAt.Equal(now) {
+		t.Errorf("lease = %+v", lease)
+	}
+}
@@ -94,6 +94,262 @@ VALUES
     ('claude', 5, 0, CURRENT_TIMESTAMP),
     ('codex', 3, 0, CURRENT_TIMESTAMP),
     ('gemini', 2, 0, CURRENT_TIMESTAMP);
+`,
+	},
+	{
+		Version: 4,
+		Name:    "cooldown_probes",
+		Up: `
+-- Result of the validation probe run when a cooldown expires
+ALTER TABLE limit_events ADD COLUMN probed_at DATETIME;
+ALTER TABLE limit_events ADD COLUMN probe_result TEXT;
+`,
+	},
+	{
+		Version: 5,
+		Name:    "sync_history",
+		Up: `
+-- Sync history and retry queue (previously history.json and queue.json)
+CREATE TABLE IF NOT EXISTS sync_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp DATETIME NOT NULL,
+    trigger_source TEXT NOT NULL DEFAULT '',
+    provider TEXT NOT NULL,
+    profile_name TEXT NOT NULL,
+    machine TEXT NOT NULL,
+    action TEXT NOT NULL DEFAULT '',
+    success INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    duration_ms INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_history_timestamp ON sync_history(timestamp);
+CREATE INDEX IF NOT EXISTS idx_sync_history_profile ON sync_history(provider, profile_name, timestamp);
+CREATE INDEX IF NOT EXISTS idx_sync_history_machine ON sync_history(machine, timestamp);
+
+CREATE TABLE IF NOT EXISTS sync_queue (
+    provider TEXT NOT NULL,
+    profile_name TEXT NOT NULL,
+    machine TEXT NOT NULL,
+    added_at DATETIME NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_attempt DATETIME,
+    last_error TEXT,
+    PRIMARY KEY (provider, profile_name, machine)
+);
+`,
+	},
+	{
+		Version: 6,
+		Name:    "revocations",
+		Up: `
+-- Profiles quarantined because their tokens were revoked
+CREATE TABLE IF NOT EXISTS revocations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider TEXT NOT NULL,
+    profile_name TEXT NOT NULL,
+    detected_at DATETIME NOT NULL,
+    reason TEXT,
+    source TEXT NOT NULL DEFAULT '',
+    cleared_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_revocations_active ON revocations(provider, profile_name, cleared_at);
+`,
+	},
+	{
+		Version: 7,
+		Name:    "profile_identities",
+		Up: `
+-- Account identity recorded per vault profile, backfilled by caam identity refresh
+CREATE TABLE IF NOT EXISTS profile_identities (
+    provider TEXT NOT NULL,
+    profile_name TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    plan_type TEXT NOT NULL DEFAULT '',
+    account_id TEXT NOT NULL DEFAULT '',
+    organization TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (provider, profile_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_identities_email ON profile_identities(email);
+`,
+	},
+	{
+		Version: 8,
+		Name:    "event_bus",
+		Up: `
+-- Append-only stream behind internal/events; rows are never updated
+CREATE TABLE IF NOT EXISTS events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp DATETIME NOT NULL,
+    type TEXT NOT NULL,
+    provider TEXT NOT NULL DEFAULT '',
+    profile_name TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL DEFAULT '',
+    data TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_type ON events(type, id);
+
+CREATE TRIGGER IF NOT EXISTS events_append_only
+BEFORE UPDATE ON events
+BEGIN
+    SELECT RAISE(ABORT, 'events are append-only');
+END;
+`,
+	},
+	{
+		Version: 9,
+		Name:    "approvals",
+		Up: `
+-- Agent-initiated robot actions waiting on (or decided by) a human
+CREATE TABLE IF NOT EXISTS approvals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL,
+    requester TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    profile_name TEXT NOT NULL DEFAULT '',
+    args TEXT NOT NULL,
+    risk_tier TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending',
+    decided_at DATETIME,
+    decided_by TEXT,
+    reason TEXT,
+    result TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_approvals_status ON approvals(status, id);
+`,
+	},
+	{
+		Version: 10,
+		Name:    "usage",
+		Up: `
+-- Token usage reported by agents and wrappers after each request
+CREATE TABLE IF NOT EXISTS usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp DATETIME NOT NULL,
+    provider TEXT NOT NULL,
+    profile_name TEXT NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
+    input_tokens INTEGER NOT NULL DEFAULT 0,
+    output_tokens INTEGER NOT NULL DEFAULT 0,
+    cache_read_tokens INTEGER NOT NULL DEFAULT 0,
+    cache_create_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    source TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_profile_time ON usage(provider, profile_name, timestamp);
+`,
+	},
+	{
+		Version: 11,
+		Name:    "rate_limit_cache",
+		Up: `
+-- Last rate-limit reading fetched from each profile's provider API
+CREATE TABLE IF NOT EXISTS rate_limit_cache (
+    provider TEXT NOT NULL,
+    profile_name TEXT NOT NULL,
+    fetched_at DATETIME NOT NULL,
+    data TEXT NOT NULL,
+    PRIMARY KEY (provider, profile_name)
+);
+`,
+	},
+	{
+		Version: 12,
+		Name:    "rotation_cursor",
+		Up: `
+-- Last profile picked by round-robin selection, per provider
+CREATE TABLE IF NOT EXISTS rotation_cursor (
+    provider TEXT PRIMARY KEY,
+    profile_name TEXT NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+`,
+	},
+	{
+		Version: 13,
+		Name:    "profile_tags",
+		Up: `
+-- User-defined key=value tags on vault profiles (a bare tag has value '')
+CREATE TABLE IF NOT EXISTS profile_tags (
+    provider TEXT NOT NULL,
+    profile_name TEXT NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (provider, profile_name, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_tags_key ON profile_tags(key, value);
+`,
+	},
+	{
+		Version: 14,
+		Name:    "sessions",
+		Up: `
+-- Working sessions: which profile served which directory, and for how long
+CREATE TABLE IF NOT EXISTS sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider TEXT NOT NULL,
+    profile_name TEXT NOT NULL,
+    work_dir TEXT NOT NULL DEFAULT '',
+    git_repo TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL DEFAULT '',
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME,
+    duration_seconds INTEGER,
+    exit_code INTEGER,
+    notes TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_started_at ON sessions(started_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_provider_profile ON sessions(provider, profile_name);
+`,
+	},
+	{
+		Version: 15,
+		Name:    "leases",
+		Up: `
+-- Exclusive holds on profiles, so two users or agents never share an account
+CREATE TABLE IF NOT EXISTS leases (
+    provider TEXT NOT NULL,
+    profile_name TEXT NOT NULL,
+    holder TEXT NOT NULL,
+    acquired_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (provider, profile_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_leases_expires_at ON leases(expires_at);
+`,
+	},
+	{
+		Version: 16,
+		Name:    "probes",
+		Up: `
+-- Synthetic requests sent to check that a profile's account still works
+CREATE TABLE IF NOT EXISTS probes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider TEXT NOT NULL,
+    profile_name TEXT NOT NULL,
+    probed_at DATETIME NOT NULL,
+    endpoint TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    success INTEGER NOT NULL,
+    http_status INTEGER NOT NULL DEFAULT 0,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_probes_provider_profile ON probes(provider, profile_name, probed_at);
 `,
 	},
 }
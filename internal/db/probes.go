@@ -0,0 +1,143 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Probe is one synthetic request sent to check that a profile's account
+// still serves real requests.
+type Probe struct {
+	ID          int64
+	Provider    string
+	ProfileName string
+	ProbedAt    time.Time
+	Endpoint    string
+	Model       string
+	Success     bool
+	HTTPStatus  int
+	Latency     time.Duration
+	Error       string
+}
+
+// RecordProbe stores a probe and returns its ID.
+func (d *DB) RecordProbe(p Probe) (int64, error) {
+	if d == nil || d.conn == nil {
+		return 0, fmt.Errorf("db is not open")
+	}
+
+	provider := strings.TrimSpace(p.Provider)
+	profile := strings.TrimSpace(p.ProfileName)
+	if provider == "" {
+		return 0, fmt.Errorf("provider is required")
+	}
+	if profile == "" {
+		return 0, fmt.Errorf("profile name is required")
+	}
+	probedAt := p.ProbedAt
+	if probedAt.IsZero() {
+		probedAt = time.Now()
+	}
+	success := 0
+	if p.Success {
+		success = 1
+	}
+
+	res, err := d.conn.Exec(
+		`INSERT INTO probes (provider, profile_name, probed_at, endpoint, model, success, http_status, latency_ms, error)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		provider,
+		profile,
+		formatSQLiteTime(probedAt),
+		p.Endpoint,
+		p.Model,
+		success,
+		p.HTTPStatus,
+		p.Latency.Milliseconds(),
+		p.Error,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert probes: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	return id, nil
+}
+
+// RecentProbes returns up to limit probes of a provider/profile, newest
+// first. An empty profile matches every profile of the provider, and an
+// empty provider every probe. limit <= 0 means no limit.
+func (d *DB) RecentProbes(provider, profile string, limit int) ([]Probe, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	var (
+		query string
+		where []string
+		args  []interface{}
+	)
+	if provider = strings.TrimSpace(provider); provider != "" {
+		where = append(where, "provider = ?")
+		args = append(args, provider)
+	}
+	if profile = strings.TrimSpace(profile); profile != "" {
+		where = append(where, "profile_name = ?")
+		args = append(args, profile)
+	}
+	if len(where) > 0 {
+		query = "WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY datetime(probed_at) DESC, id DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	return d.queryProbes(query, args...)
+}
+
+// ProbesSince returns every profile's probes sent at or after since,
+// newest first.
+func (d *DB) ProbesSince(since time.Time) ([]Probe, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	return d.queryProbes(
+		`WHERE datetime(probed_at) >= datetime(?) ORDER BY datetime(probed_at) DESC, id DESC`,
+		formatSQLiteTime(since),
+	)
+}
+
+func (d *DB) queryProbes(where string, args ...interface{}) ([]Probe, error) {
+	rows, err := d.conn.Query(
+		`SELECT id, provider, profile_name, probed_at, endpoint, model, success, http_status, latency_ms, error FROM probes `+where,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query probes: %w", err)
+	}
+	defer rows.Close()
+
+	var probes []Probe
+	for rows.Next() {
+		var (
+			p         Probe
+			probedStr string
+			success   int
+			latencyMs int64
+		)
+		if err := rows.Scan(&p.ID, &p.Provider, &p.ProfileName, &probedStr, &p.Endpoint, &p.Model, &success, &p.HTTPStatus, &latencyMs, &p.Error); err != nil {
+			return nil, fmt.Errorf("scan probes: %w", err)
+		}
+		probedAt, err := parseSQLiteTime(probedStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse probed_at %q: %w", probedStr, err)
+		}
+		p.ProbedAt = probedAt
+		p.Success = success != 0
+		p.Latency = time.Duration(latencyMs) * time.Millisecond
+		probes = append(probes, p)
+	}
+	return probes, rows.Err()
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProbes_RecordRecent(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, p := range []Probe{
+		{Provider: "claude", ProfileName: "work", ProbedAt: now.Add(-2 * time.Hour), Success: true, HTTPStatus: 200, Latency: 420 * time.Millisecond, Model: "claude-haiku-4-5"},
+		{Provider: "claude", ProfileName: "work", ProbedAt: now, HTTPStatus: 403, Latency: 90 * time.Millisecond, Error: "unauthorized: status 403"},
+		{Provider: "codex", ProfileName: "main", ProbedAt: now.Add(-time.Hour), Success: true, HTTPStatus: 200},
+	} {
+		if _, err := d.RecordProbe(p); err != nil {
+			t.Fatalf("RecordProbe(%d) error = %v", i, err)
+		}
+	}
+	if _, err := d.RecordProbe(Probe{Provider: "claude"}); err == nil {
+		t.Fatal("RecordProbe() without profile succeeded, want error")
+	}
+
+	work, err := d.RecentProbes("claude", "work", 0)
+	if err != nil {
+		t.Fatalf("RecentProbes() error = %v", err)
+	}
+	if len(work) != 2 {
+		t.Fatalf("RecentProbes(claude, work) = %d probes, want 2", len(work))
+	}
+	latest := work[0]
+	if latest.Success || latest.HTTPStatus != 403 || latest.Latency != 90*time.Millisecond || !latest.ProbedAt.Equal(now) || latest.Error == "" {
+		t.Fatalf("latest probe = %+v, want the 403 at %s", latest, now)
+	}
+	if !work[1].Success || work[1].Model != "claude-haiku-4-5" {
+		t.Fatalf("older probe = %+v, want the successful one", work[1])
+	}
+
+	all, err := d.RecentProbes("", "", 2)
+	if err != nil {
+		t.Fatalf("RecentProbes(all) error = %v", err)
+	}
+	if len(all) != 2 || all[0].Provider != "claude" || all[1].Provider != "codex" {
+		t.Fatalf("RecentProbes(all, 2) = %+v, want claude then codex", all)
+	}
+
+	since, err := d.ProbesSince(now.Add(-90 * time.Minute))
+	if err != nil {
+		t.Fatalf("ProbesSince() error = %v", err)
+	}
+	if len(since) != 2 || since[0].HTTPStatus != 403 || since[1].Provider != "codex" {
+		t.Fatalf("ProbesSince() = %+v, want the 403 then codex", since)
+	}
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RateLimitSnapshot is the last rate-limit reading fetched for a profile.
+// Data holds the reading as JSON; its shape belongs to the caller.
+type RateLimitSnapshot struct {
+	Provider    string
+	ProfileName string
+	FetchedAt   time.Time
+	Data        string
+}
+
+// SaveRateLimitSnapshot stores a profile's reading, replacing the previous one.
+func (d *DB) SaveRateLimitSnapshot(snap RateLimitSnapshot) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+
+	provider := strings.TrimSpace(snap.Provider)
+	profile := strings.TrimSpace(snap.ProfileName)
+	if provider == "" {
+		return fmt.Errorf("provider is required")
+	}
+	if profile == "" {
+		return fmt.Errorf("profile name is required")
+	}
+
+	fetchedAt := snap.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+
+	_, err := d.conn.Exec(
+		`INSERT INTO rate_limit_cache (provider, profile_name, fetched_at, data)
+		 VALUES (?, ?, ?, ?)
+		 ON CONFLICT(provider, profile_name) DO UPDATE SET
+		   fetched_at = excluded.fetched_at,
+		   data = excluded.data`,
+		provider,
+		profile,
+		formatSQLiteTime(fetchedAt),
+		snap.Data,
+	)
+	if err != nil {
+		return fmt.Errorf("upsert rate_limit_cache: %w", err)
+	}
+	return nil
+}
+
+// GetRateLimitSnapshot returns a profile's cached reading, or nil if none.
+func (d *DB) GetRateLimitSnapshot(provider, profile string) (*RateLimitSnapshot, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	snap := RateLimitSnapshot{
+		Provider:    strings.TrimSpace(provider),
+		ProfileName: strings.TrimSpace(profile),
+	}
+	var fetchedAt string
+	err := d.conn.QueryRow(
+		`SELECT fetched_at, data FROM rate_limit_cache WHERE provider = ? AND profile_name = ?`,
+		snap.Provider, snap.ProfileName,
+	).Scan(&fetchedAt, &snap.Data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query rate_limit_cache: %w", err)
+	}
+	if ts, err := parseSQLiteTime(fetchedAt); err == nil {
+		snap.FetchedAt = ts
+	}
+	return &snap, nil
+}
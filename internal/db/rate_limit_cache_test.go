@@ -0,0 +1,40 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRateLimitSnapshot(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer d.Close()
+
+	if snap, err := d.GetRateLimitSnapshot("claude", "work"); err != nil || snap != nil {
+		t.Fatalf("GetRateLimitSnapshot(empty) = %+v, %v", snap, err)
+	}
+
+	first := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	if err := d.SaveRateLimitSnapshot(RateLimitSnapshot{Provider: "claude", ProfileName: "work", FetchedAt: first, Data: `{"a":1}`}); err != nil {
+		t.Fatalf("SaveRateLimitSnapshot() error = %v", err)
+	}
+	second := first.Add(30 * time.Minute)
+	if err := d.SaveRateLimitSnapshot(RateLimitSnapshot{Provider: "claude", ProfileName: "work", FetchedAt: second, Data: `{"a":2}`}); err != nil {
+		t.Fatalf("SaveRateLimitSnapshot() error = %v", err)
+	}
+
+	snap, err := d.GetRateLimitSnapshot("claude", "work")
+	if err != nil || snap == nil {
+		t.Fatalf("GetRateLimitSnapshot() = %+v, %v", snap, err)
+	}
+	if snap.Data != `{"a":2}` || !snap.FetchedAt.Equal(second) {
+		t.Errorf("snapshot = %+v, want the latest reading", snap)
+	}
+
+	if err := d.SaveRateLimitSnapshot(RateLimitSnapshot{Provider: "claude"}); err == nil {
+		t.Error("SaveRateLimitSnapshot without profile succeeded")
+	}
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Revocation records a profile quarantined because its token was revoked.
+// Unlike a cooldown it never expires; it is cleared when the profile is
+// backed up again after a fresh login, or by hand.
+type Revocation struct {
+	ID          int64
+	Provider    string
+	ProfileName string
+	DetectedAt  time.Time
+	Reason      string
+	// Source is what noticed the revocation (run, wrap, refresh, ingest).
+	Source string
+}
+
+// MarkRevoked quarantines a provider/profile. If the profile is already
+// quarantined, the existing record is returned unchanged.
+func (d *DB) MarkRevoked(provider, profile string, detectedAt time.Time, reason, source string) (*Revocation, error) {
+	existing, err := d.ActiveRevocation(provider, profile)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	provider = strings.TrimSpace(provider)
+	profile = strings.TrimSpace(profile)
+	reason = strings.TrimSpace(reason)
+	if detectedAt.IsZero() {
+		detectedAt = time.Now().UTC()
+	} else {
+		detectedAt = detectedAt.UTC()
+	}
+
+	var reasonStr sql.NullString
+	if reason != "" {
+		reasonStr = sql.NullString{String: reason, Valid: true}
+	}
+
+	res, err := d.conn.Exec(
+		`INSERT INTO revocations (provider, profile_name, detected_at, reason, source) VALUES (?, ?, ?, ?, ?)`,
+		provider,
+		profile,
+		formatSQLiteTime(detectedAt),
+		reasonStr,
+		source,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("insert revocations: %w", err)
+	}
+
+	id, _ := res.LastInsertId()
+	return &Revocation{
+		ID:          id,
+		Provider:    provider,
+		ProfileName: profile,
+		DetectedAt:  detectedAt,
+		Reason:      reason,
+		Source:      source,
+	}, nil
+}
+
+// ActiveRevocation returns the open quarantine for a provider/profile.
+// Returns (nil, nil) if the profile is not quarantined.
+func (d *DB) ActiveRevocation(provider, profile string) (*Revocation, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	provider = strings.TrimSpace(provider)
+	profile = strings.TrimSpace(profile)
+	if provider == "" {
+		return nil, fmt.Errorf("provider is required")
+	}
+	if profile == "" {
+		return nil, fmt.Errorf("profile name is required")
+	}
+
+	revs, err := d.queryRevocations(
+		`WHERE provider = ? AND profile_name = ? AND cleared_at IS NULL ORDER BY id DESC LIMIT 1`,
+		provider, profile,
+	)
+	if err != nil || len(revs) == 0 {
+		return nil, err
+	}
+	return &revs[0], nil
+}
+
+// ListActiveRevocations returns every open quarantine, newest first.
+func (d *DB) ListActiveRevocations() ([]Revocation, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	return d.queryRevocations(`WHERE cleared_at IS NULL ORDER BY datetime(detected_at) DESC, id DESC`)
+}
+
+// RevocationsSince returns every revocation detected at or after since,
+// cleared or not, newest first.
+func (d *DB) RevocationsSince(since time.Time) ([]Revocation, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	return d.queryRevocations(
+		`WHERE datetime(detected_at) >= datetime(?) ORDER BY datetime(detected_at) DESC, id DESC`,
+		formatSQLiteTime(since),
+	)
+}
+
+// ClearRevocation lifts the quarantine on a provider/profile, keeping the
+// record as history.
+func (d *DB) ClearRevocation(provider, profile string, at time.Time) (int64, error) {
+	if d == nil || d.conn == nil {
+		return 0, fmt.Errorf("db is not open")
+	}
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	res, err := d.conn.Exec(
+		`UPDATE revocations SET cleared_at = ? WHERE provider = ? AND profile_name = ? AND cleared_at IS NULL`,
+		formatSQLiteTime(at.UTC()),
+		strings.TrimSpace(provider),
+		strings.TrimSpace(profile),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("update revocations: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	return affected, nil
+}
+
+func (d *DB) queryRevocations(where string, args ...interface{}) ([]Revocation, error) {
+	rows, err := d.conn.Query(
+		`SELECT id, provider, profile_name, detected_at, reason, source FROM revocations `+where,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query revocations: %w", err)
+	}
+	defer rows.Close()
+
+	var revs []Revocation
+	for rows.Next() {
+		var (
+			rev         Revocation
+			detectedStr string
+			reason      sql.NullString
+		)
+		if err := rows.Scan(&rev.ID, &rev.Provider, &rev.ProfileName, &detectedStr, &reason, &rev.Source); err != nil {
+			return nil, fmt.Errorf("scan revocations: %w", err)
+		}
+		detectedAt, err := parseSQLiteTime(detectedStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse detected_at %q: %w", detectedStr, err)
+		}
+		rev.DetectedAt = detectedAt
+		if reason.Valid {
+			rev.Reason = reason.String
+		}
+		revs = append(revs, rev)
+	}
+	return revs, rows.Err()
+}
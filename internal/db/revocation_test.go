@@ -0,0 +1,92 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRevocation_MarkListClear(t *testing.T) {
+	tmpDir := t.TempDir()
+	d, err := OpenAt(filepath.Join(tmpDir, "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	created, err := d.MarkRevoked("claude", "work", now, "invalid_grant", "run")
+	if err != nil {
+		t.Fatalf("MarkRevoked() error = %v", err)
+	}
+	if created.Provider != "claude" || created.ProfileName != "work" || created.Source != "run" {
+		t.Fatalf("created = %+v, want claude/work from run", created)
+	}
+
+	// Marking again keeps the original record.
+	again, err := d.MarkRevoked("claude", "work", now.Add(time.Minute), "401 Unauthorized", "refresh")
+	if err != nil {
+		t.Fatalf("MarkRevoked() second error = %v", err)
+	}
+	if again.ID != created.ID || again.Reason != "invalid_grant" {
+		t.Fatalf("second MarkRevoked() = %+v, want existing record %d", again, created.ID)
+	}
+
+	active, err := d.ActiveRevocation("claude", "work")
+	if err != nil {
+		t.Fatalf("ActiveRevocation() error = %v", err)
+	}
+	if active == nil || !active.DetectedAt.Equal(now) || active.Reason != "invalid_grant" {
+		t.Fatalf("ActiveRevocation() = %+v, want invalid_grant at %s", active, now)
+	}
+
+	if _, err := d.MarkRevoked("codex", "main", now, "", "ingest"); err != nil {
+		t.Fatalf("MarkRevoked(codex) error = %v", err)
+	}
+	list, err := d.ListActiveRevocations()
+	if err != nil {
+		t.Fatalf("ListActiveRevocations() error = %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("ListActiveRevocations() len = %d, want 2", len(list))
+	}
+
+	n, err := d.ClearRevocation("claude", "work", now)
+	if err != nil {
+		t.Fatalf("ClearRevocation() error = %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("ClearRevocation() affected = %d, want 1", n)
+	}
+	active, err = d.ActiveRevocation("claude", "work")
+	if err != nil {
+		t.Fatalf("ActiveRevocation() after clear error = %v", err)
+	}
+	if active != nil {
+		t.Fatalf("ActiveRevocation() after clear = %+v, want nil", active)
+	}
+
+	n, err = d.ClearRevocation("claude", "work", now)
+	if err != nil {
+		t.Fatalf("ClearRevocation() second error = %v", err)
+	}
+	if n != 0 {
+		t.Fatalf("ClearRevocation() second affected = %d, want 0", n)
+	}
+
+	// Cleared revocations stay in the history.
+	if _, err := d.MarkRevoked("claude", "work", now.Add(time.Hour), "invalid_grant", "refresh"); err != nil {
+		t.Fatalf("MarkRevoked() after clear error = %v", err)
+	}
+	since, err := d.RevocationsSince(now)
+	if err != nil {
+		t.Fatalf("RevocationsSince() error = %v", err)
+	}
+	if len(since) != 3 || since[0].Source != "refresh" {
+		t.Fatalf("RevocationsSince() = %+v, want 3 revocations, newest first", since)
+	}
+	if later, _ := d.RevocationsSince(now.Add(time.Minute)); len(later) != 1 {
+		t.Fatalf("RevocationsSince(later) len = %d, want 1", len(later))
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RotationCursor returns the profile round-robin selection last picked for
+// a provider, or "" if it has not picked one.
+func (d *DB) RotationCursor(provider string) (string, error) {
+	if d == nil || d.conn == nil {
+		return "", fmt.Errorf("db is not open")
+	}
+
+	provider = strings.TrimSpace(provider)
+	if provider == "" {
+		return "", fmt.Errorf("provider is required")
+	}
+
+	var profile string
+	err := d.conn.QueryRow(
+		`SELECT profile_name FROM rotation_cursor WHERE provider = ?`,
+		provider,
+	).Scan(&profile)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("query rotation_cursor: %w", err)
+	}
+	return profile, nil
+}
+
+// SetRotationCursor records the profile round-robin selection picked for a
+// provider, so the next pick continues after it.
+func (d *DB) SetRotationCursor(provider, profile string) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+
+	provider = strings.TrimSpace(provider)
+	profile = strings.TrimSpace(profile)
+	if provider == "" {
+		return fmt.Errorf("provider is required")
+	}
+	if profile == "" {
+		return fmt.Errorf("profile name is required")
+	}
+
+	_, err := d.conn.Exec(
+		`INSERT INTO rotation_cursor (provider, profile_name, updated_at)
+		 VALUES (?, ?, ?)
+		 ON CONFLICT(provider) DO UPDATE SET
+		   profile_name = excluded.profile_name,
+		   updated_at = excluded.updated_at`,
+		provider,
+		profile,
+		formatSQLiteTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert rotation_cursor: %w", err)
+	}
+	return nil
+}
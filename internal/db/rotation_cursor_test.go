@@ -0,0 +1,32 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRotationCursor(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer d.Close()
+
+	if got, err := d.RotationCursor("claude"); err != nil || got != "" {
+		t.Fatalf("RotationCursor(empty) = %q, %v", got, err)
+	}
+	for _, profile := range []string{"a", "b"} {
+		if err := d.SetRotationCursor("claude", profile); err != nil {
+			t.Fatalf("SetRotationCursor(%s) error = %v", profile, err)
+		}
+	}
+	if got, err := d.RotationCursor("claude"); err != nil || got != "b" {
+		t.Errorf("RotationCursor() = %q, %v; want b", got, err)
+	}
+	if got, _ := d.RotationCursor("codex"); got != "" {
+		t.Errorf("RotationCursor(codex) = %q, want empty", got)
+	}
+	if err := d.SetRotationCursor("claude", " "); err == nil {
+		t.Error("SetRotationCursor without profile succeeded")
+	}
+}
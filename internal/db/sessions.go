@@ -0,0 +1,305 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Session sources.
+const (
+	SessionSourceRun    = "run"
+	SessionSourceManual = "manual"
+)
+
+// ErrSessionNotFound is returned for a session id with no row.
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is one working session: a stretch of work in a directory served
+// by one profile. caam run records them automatically; 'caam session start'
+// and 'stop' record them by hand.
+type Session struct {
+	ID          int64
+	Provider    string
+	ProfileName string
+	WorkDir     string
+	GitRepo     string
+	Source      string
+	StartedAt   time.Time
+
+	// EndedAt is zero while the session is open.
+	EndedAt  time.Time
+	Duration time.Duration
+
+	// ExitCode is nil while the session is open or when unknown.
+	ExitCode *int
+	Notes    string
+}
+
+// Open reports whether the session has not been stopped.
+func (s Session) Open() bool {
+	return s.EndedAt.IsZero()
+}
+
+// Elapsed returns the session's duration, or the time since it started for
+// an open session.
+func (s Session) Elapsed(now time.Time) time.Duration {
+	if s.Open() {
+		if now.Before(s.StartedAt) {
+			return 0
+		}
+		return now.Sub(s.StartedAt)
+	}
+	return s.Duration
+}
+
+// StartSession records the start of a session and returns its id.
+func (d *DB) StartSession(s Session) (int64, error) {
+	if d == nil || d.conn == nil {
+		return 0, fmt.Errorf("db is not open")
+	}
+	provider := strings.TrimSpace(s.Provider)
+	profile := strings.TrimSpace(s.ProfileName)
+	if provider == "" {
+		return 0, fmt.Errorf("provider is required")
+	}
+	if profile == "" {
+		return 0, fmt.Errorf("profile name is required")
+	}
+	startedAt := s.StartedAt
+	if startedAt.IsZero() {
+		startedAt = time.Now()
+	}
+
+	res, err := d.conn.Exec(
+		`INSERT INTO sessions (provider, profile_name, work_dir, git_repo, source, started_at, notes)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		provider,
+		profile,
+		s.WorkDir,
+		s.GitRepo,
+		s.Source,
+		formatSQLiteTime(startedAt),
+		strings.TrimSpace(s.Notes),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert sessions: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// EndSession stops an open session. exitCode may be nil when unknown. It
+// reports false if the session was already stopped.
+func (d *DB) EndSession(id int64, endedAt time.Time, exitCode *int) (bool, error) {
+	if d == nil || d.conn == nil {
+		return false, fmt.Errorf("db is not open")
+	}
+	s, err := d.GetSession(id)
+	if err != nil {
+		return false, err
+	}
+	if !s.Open() {
+		return false, nil
+	}
+	if endedAt.IsZero() {
+		endedAt = time.Now()
+	}
+	duration := int64(0)
+	if endedAt.After(s.StartedAt) {
+		duration = int64(endedAt.Sub(s.StartedAt).Seconds())
+	}
+	var code sql.NullInt64
+	if exitCode != nil {
+		code = sql.NullInt64{Int64: int64(*exitCode), Valid: true}
+	}
+
+	res, err := d.conn.Exec(
+		`UPDATE sessions SET ended_at = ?, duration_seconds = ?, exit_code = ? WHERE id = ? AND ended_at IS NULL`,
+		formatSQLiteTime(endedAt),
+		duration,
+		code,
+		id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("update sessions: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
+
+// AnnotateSession appends a note to a session, open or stopped.
+func (d *DB) AnnotateSession(id int64, note string) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return fmt.Errorf("note is required")
+	}
+	res, err := d.conn.Exec(
+		`UPDATE sessions SET notes = CASE WHEN notes = '' THEN ? ELSE notes || char(10) || ? END WHERE id = ?`,
+		note, note, id,
+	)
+	if err != nil {
+		return fmt.Errorf("update sessions: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("session %d: %w", id, ErrSessionNotFound)
+	}
+	return nil
+}
+
+// GetSession returns one session.
+func (d *DB) GetSession(id int64) (*Session, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	sessions, err := d.querySessions(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("session %d: %w", id, ErrSessionNotFound)
+	}
+	return &sessions[0], nil
+}
+
+// OpenSessions returns the sessions not yet stopped, newest first. An empty
+// provider matches every provider.
+func (d *DB) OpenSessions(provider string) ([]Session, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	provider = strings.TrimSpace(provider)
+	if provider == "" {
+		return d.querySessions(`WHERE ended_at IS NULL ORDER BY started_at DESC, id DESC`)
+	}
+	return d.querySessions(`WHERE ended_at IS NULL AND provider = ? ORDER BY started_at DESC, id DESC`, provider)
+}
+
+// ListSessions returns up to limit sessions started since since, newest
+// first. An empty provider matches every provider.
+func (d *DB) ListSessions(provider string, since time.Time, limit int) ([]Session, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	provider = strings.TrimSpace(provider)
+	sinceStr := formatSQLiteTime(since)
+	if provider == "" {
+		return d.querySessions(
+			`WHERE datetime(started_at) >= datetime(?) ORDER BY started_at DESC, id DESC LIMIT ?`,
+			sinceStr, limit,
+		)
+	}
+	return d.querySessions(
+		`WHERE provider = ? AND datetime(started_at) >= datetime(?) ORDER BY started_at DESC, id DESC LIMIT ?`,
+		provider, sinceStr, limit,
+	)
+}
+
+// SessionUsage is the time one profile spent serving sessions.
+type SessionUsage struct {
+	Provider    string
+	ProfileName string
+	Sessions    int
+	Duration    time.Duration
+}
+
+// SessionUsageSince totals session time per profile for sessions started
+// since since, counting open sessions up to now. Profiles are ordered by
+// provider, then most time first. An empty provider matches every provider.
+func (d *DB) SessionUsageSince(provider string, since, now time.Time) ([]SessionUsage, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	provider = strings.TrimSpace(provider)
+	sinceStr := formatSQLiteTime(since)
+	var sessions []Session
+	var err error
+	if provider == "" {
+		sessions, err = d.querySessions(`WHERE datetime(started_at) >= datetime(?)`, sinceStr)
+	} else {
+		sessions, err = d.querySessions(`WHERE provider = ? AND datetime(started_at) >= datetime(?)`, provider, sinceStr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byProfile := make(map[string]*SessionUsage)
+	for _, s := range sessions {
+		key := s.Provider + "/" + s.ProfileName
+		u := byProfile[key]
+		if u == nil {
+			u = &SessionUsage{Provider: s.Provider, ProfileName: s.ProfileName}
+			byProfile[key] = u
+		}
+		u.Sessions++
+		u.Duration += s.Elapsed(now)
+	}
+
+	usage := make([]SessionUsage, 0, len(byProfile))
+	for _, u := range byProfile {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Provider != usage[j].Provider {
+			return usage[i].Provider < usage[j].Provider
+		}
+		if usage[i].Duration != usage[j].Duration {
+			return usage[i].Duration > usage[j].Duration
+		}
+		return usage[i].ProfileName < usage[j].ProfileName
+	})
+	return usage, nil
+}
+
+func (d *DB) querySessions(where string, args ...interface{}) ([]Session, error) {
+	rows, err := d.conn.Query(
+		`SELECT id, provider, profile_name, work_dir, git_repo, source, started_at, ended_at, duration_seconds, exit_code, notes FROM sessions `+where,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Session
+	for rows.Next() {
+		var (
+			s          Session
+			startedStr string
+			endedAt    sql.NullString
+			duration   sql.NullInt64
+			exitCode   sql.NullInt64
+		)
+		if err := rows.Scan(&s.ID, &s.Provider, &s.ProfileName, &s.WorkDir, &s.GitRepo, &s.Source,
+			&startedStr, &endedAt, &duration, &exitCode, &s.Notes); err != nil {
+			return nil, fmt.Errorf("scan sessions: %w", err)
+		}
+		startedAt, err := parseSQLiteTime(startedStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse started_at %q: %w", startedStr, err)
+		}
+		s.StartedAt = startedAt
+		if endedAt.Valid {
+			if t, err := parseSQLiteTime(endedAt.String); err == nil {
+				s.EndedAt = t
+			}
+		}
+		if duration.Valid {
+			s.Duration = time.Duration(duration.Int64) * time.Second
+		}
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			s.ExitCode = &code
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
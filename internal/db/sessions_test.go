@@ -0,0 +1,112 @@
+package db
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessions_StartEndAnnotate(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	id, err := d.StartSession(Session{
+		Provider:    "claude",
+		ProfileName: "work",
+		WorkDir:     "/src/app",
+		GitRepo:     "/src/app",
+		Source:      SessionSourceManual,
+		StartedAt:   start,
+	})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+
+	open, err := d.OpenSessions("claude")
+	if err != nil || len(open) != 1 || open[0].ID != id {
+		t.Fatalf("OpenSessions() = %+v, %v", open, err)
+	}
+
+	if err := d.AnnotateSession(id, "refactor auth"); err != nil {
+		t.Fatalf("AnnotateSession() error = %v", err)
+	}
+	if err := d.AnnotateSession(id, "tests green"); err != nil {
+		t.Fatalf("AnnotateSession() error = %v", err)
+	}
+	if err := d.AnnotateSession(id+100, "nope"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("AnnotateSession(missing) error = %v, want ErrSessionNotFound", err)
+	}
+
+	code := 3
+	ended, err := d.EndSession(id, start.Add(90*time.Minute), &code)
+	if err != nil || !ended {
+		t.Fatalf("EndSession() = %v, %v", ended, err)
+	}
+	if again, err := d.EndSession(id, time.Now(), nil); err != nil || again {
+		t.Errorf("second EndSession() = %v, %v; want false", again, err)
+	}
+
+	s, err := d.GetSession(id)
+	if err != nil {
+		t.Fatalf("GetSession() error = %v", err)
+	}
+	if s.Open() || s.Duration != 90*time.Minute || s.ExitCode == nil || *s.ExitCode != 3 {
+		t.Errorf("session = %+v, want stopped after 90m with exit 3", s)
+	}
+	if s.Notes != "refactor auth\ntests green" {
+		t.Errorf("notes = %q", s.Notes)
+	}
+	if s.WorkDir != "/src/app" || s.Source != SessionSourceManual {
+		t.Errorf("session = %+v", s)
+	}
+}
+
+func TestSessions_UsageSince(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	now := time.Now().Truncate(time.Second)
+	add := func(profile string, startedAgo, length time.Duration) {
+		t.Helper()
+		id, err := d.StartSession(Session{Provider: "codex", ProfileName: profile, StartedAt: now.Add(-startedAgo)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if length > 0 {
+			if _, err := d.EndSession(id, now.Add(-startedAgo+length), nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	add("a", 5*time.Hour, time.Hour)
+	add("a", 3*time.Hour, 30*time.Minute)
+	add("b", 2*time.Hour, 0) // still open
+	add("c", 10*24*time.Hour, time.Hour)
+
+	usage, err := d.SessionUsageSince("codex", now.Add(-7*24*time.Hour), now)
+	if err != nil {
+		t.Fatalf("SessionUsageSince() error = %v", err)
+	}
+	if len(usage) != 2 {
+		t.Fatalf("usage = %+v, want profiles a and b", usage)
+	}
+	if usage[0].ProfileName != "b" || usage[0].Duration != 2*time.Hour || usage[0].Sessions != 1 {
+		t.Errorf("usage[0] = %+v, want b with 2h from its open session", usage[0])
+	}
+	if usage[1].ProfileName != "a" || usage[1].Duration != 90*time.Minute || usage[1].Sessions != 2 {
+		t.Errorf("usage[1] = %+v, want a with 1h30m over 2 sessions", usage[1])
+	}
+
+	recent, err := d.ListSessions("", now.Add(-24*time.Hour), 10)
+	if err != nil || len(recent) != 3 || recent[0].ProfileName != "b" {
+		t.Errorf("ListSessions() = %+v, %v", recent, err)
+	}
+}
@@ -0,0 +1,281 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SyncHistoryRecord is one recorded sync operation.
+type SyncHistoryRecord struct {
+	ID          int64
+	Timestamp   time.Time
+	Trigger     string
+	Provider    string
+	ProfileName string
+	Machine     string
+	Action      string
+	Success     bool
+	Error       string
+	Duration    time.Duration
+}
+
+// SyncHistoryQuery filters sync history. Zero values match everything.
+type SyncHistoryQuery struct {
+	Provider    string
+	ProfileName string
+	Machine     string
+	ErrorsOnly  bool
+	SuccessOnly bool
+	Since       time.Time
+	// Limit caps the number of records returned (0 = no limit).
+	Limit int
+}
+
+// SyncQueueRecord is a pending sync retry.
+type SyncQueueRecord struct {
+	Provider    string
+	ProfileName string
+	Machine     string
+	AddedAt     time.Time
+	Attempts    int
+	LastAttempt time.Time
+	LastError   string
+}
+
+// InsertSyncHistory appends sync history records in a single transaction.
+func (d *DB) InsertSyncHistory(records []SyncHistoryRecord) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.Prepare(`INSERT INTO sync_history
+		(timestamp, trigger_source, provider, profile_name, machine, action, success, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("prepare insert sync_history: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		ts := r.Timestamp
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		var errStr sql.NullString
+		if r.Error != "" {
+			errStr = sql.NullString{String: r.Error, Valid: true}
+		}
+		success := 0
+		if r.Success {
+			success = 1
+		}
+		if _, err := stmt.Exec(
+			formatSQLiteTime(ts),
+			r.Trigger,
+			strings.TrimSpace(r.Provider),
+			strings.TrimSpace(r.ProfileName),
+			r.Machine,
+			r.Action,
+			success,
+			errStr,
+			r.Duration.Milliseconds(),
+		); err != nil {
+			return fmt.Errorf("insert sync_history: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// QuerySyncHistory returns matching sync history records, newest first.
+func (d *DB) QuerySyncHistory(q SyncHistoryQuery) ([]SyncHistoryRecord, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	var (
+		where []string
+		args  []any
+	)
+	if q.Provider != "" {
+		where = append(where, "provider = ?")
+		args = append(args, q.Provider)
+	}
+	if q.ProfileName != "" {
+		where = append(where, "profile_name = ?")
+		args = append(args, q.ProfileName)
+	}
+	if q.Machine != "" {
+		where = append(where, "machine = ?")
+		args = append(args, q.Machine)
+	}
+	if q.ErrorsOnly {
+		where = append(where, "success = 0")
+	}
+	if q.SuccessOnly {
+		where = append(where, "success = 1")
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "timestamp >= ?")
+		args = append(args, formatSQLiteTime(q.Since))
+	}
+
+	query := `SELECT id, timestamp, trigger_source, provider, profile_name, machine, action, success, error, duration_ms
+		FROM sync_history`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := d.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query sync_history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SyncHistoryRecord
+	for rows.Next() {
+		var (
+			r          SyncHistoryRecord
+			tsStr      string
+			success    int
+			errStr     sql.NullString
+			durationMS int64
+		)
+		if err := rows.Scan(&r.ID, &tsStr, &r.Trigger, &r.Provider, &r.ProfileName, &r.Machine, &r.Action, &success, &errStr, &durationMS); err != nil {
+			return nil, fmt.Errorf("scan sync_history: %w", err)
+		}
+		ts, err := parseSQLiteTime(tsStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse timestamp %q: %w", tsStr, err)
+		}
+		r.Timestamp = ts
+		r.Success = success != 0
+		if errStr.Valid {
+			r.Error = errStr.String
+		}
+		r.Duration = time.Duration(durationMS) * time.Millisecond
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sync_history: %w", err)
+	}
+	return out, nil
+}
+
+// CountSyncHistory returns the number of recorded sync operations.
+func (d *DB) CountSyncHistory() (int, error) {
+	if d == nil || d.conn == nil {
+		return 0, fmt.Errorf("db is not open")
+	}
+	var n int
+	if err := d.conn.QueryRow(`SELECT COUNT(*) FROM sync_history`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count sync_history: %w", err)
+	}
+	return n, nil
+}
+
+// ListSyncQueue returns pending sync retries, oldest first.
+func (d *DB) ListSyncQueue() ([]SyncQueueRecord, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	rows, err := d.conn.Query(`SELECT provider, profile_name, machine, added_at, attempts, last_attempt, last_error
+		FROM sync_queue ORDER BY added_at, provider, profile_name, machine`)
+	if err != nil {
+		return nil, fmt.Errorf("query sync_queue: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SyncQueueRecord
+	for rows.Next() {
+		var (
+			r              SyncQueueRecord
+			addedStr       string
+			lastAttemptStr sql.NullString
+			lastError      sql.NullString
+		)
+		if err := rows.Scan(&r.Provider, &r.ProfileName, &r.Machine, &addedStr, &r.Attempts, &lastAttemptStr, &lastError); err != nil {
+			return nil, fmt.Errorf("scan sync_queue: %w", err)
+		}
+		addedAt, err := parseSQLiteTime(addedStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse added_at %q: %w", addedStr, err)
+		}
+		r.AddedAt = addedAt
+		if lastAttemptStr.Valid {
+			if ts, err := parseSQLiteTime(lastAttemptStr.String); err == nil {
+				r.LastAttempt = ts
+			}
+		}
+		if lastError.Valid {
+			r.LastError = lastError.String
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sync_queue: %w", err)
+	}
+	return out, nil
+}
+
+// ReplaceSyncQueue replaces the whole retry queue with records.
+func (d *DB) ReplaceSyncQueue(records []SyncQueueRecord) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.Exec(`DELETE FROM sync_queue`); err != nil {
+		return fmt.Errorf("clear sync_queue: %w", err)
+	}
+	for _, r := range records {
+		var lastAttempt, lastError sql.NullString
+		if !r.LastAttempt.IsZero() {
+			lastAttempt = sql.NullString{String: formatSQLiteTime(r.LastAttempt), Valid: true}
+		}
+		if r.LastError != "" {
+			lastError = sql.NullString{String: r.LastError, Valid: true}
+		}
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO sync_queue
+			(provider, profile_name, machine, added_at, attempts, last_attempt, last_error)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			r.Provider, r.ProfileName, r.Machine, formatSQLiteTime(r.AddedAt), r.Attempts, lastAttempt, lastError,
+		); err != nil {
+			return fmt.Errorf("insert sync_queue: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSyncHistory_InsertAndQuery(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	base := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	var records []SyncHistoryRecord
+	for i := 0; i < 6; i++ {
+		machine := "laptop"
+		if i%2 == 1 {
+			machine = "desktop"
+		}
+		records = append(records, SyncHistoryRecord{
+			Timestamp:   base.Add(time.Duration(i) * time.Minute),
+			Trigger:     "manual",
+			Provider:    "claude",
+			ProfileName: "work",
+			Machine:     machine,
+			Action:      "push",
+			Success:     i != 4,
+			Error:       map[bool]string{true: "", false: "timeout"}[i != 4],
+			Duration:    1500 * time.Millisecond,
+		})
+	}
+	if err := d.InsertSyncHistory(records); err != nil {
+		t.Fatalf("InsertSyncHistory() error = %v", err)
+	}
+
+	if n, err := d.CountSyncHistory(); err != nil || n != 6 {
+		t.Fatalf("CountSyncHistory() = %d, %v; want 6", n, err)
+	}
+
+	recent, err := d.QuerySyncHistory(SyncHistoryQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("QuerySyncHistory() error = %v", err)
+	}
+	if len(recent) != 2 || !recent[0].Timestamp.Equal(base.Add(5*time.Minute)) {
+		t.Fatalf("recent = %+v, want newest 2", recent)
+	}
+	if recent[0].Duration != 1500*time.Millisecond || recent[0].Trigger != "manual" {
+		t.Errorf("record round-trip = %+v", recent[0])
+	}
+
+	failed, err := d.QuerySyncHistory(SyncHistoryQuery{ErrorsOnly: true})
+	if err != nil {
+		t.Fatalf("QuerySyncHistory(errors) error = %v", err)
+	}
+	if len(failed) != 1 || failed[0].Error != "timeout" || failed[0].Machine != "laptop" {
+		t.Errorf("failed = %+v, want the single laptop timeout", failed)
+	}
+
+	desktop, err := d.QuerySyncHistory(SyncHistoryQuery{Machine: "desktop", SuccessOnly: true, Since: base.Add(2 * time.Minute)})
+	if err != nil {
+		t.Fatalf("QuerySyncHistory(desktop) error = %v", err)
+	}
+	if len(desktop) != 2 {
+		t.Errorf("desktop since +2m = %d records, want 2", len(desktop))
+	}
+}
+
+func TestSyncQueue_ReplaceAndList(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	now := time.Now().UTC().Truncate(time.Second)
+	queue := []SyncQueueRecord{
+		{Provider: "claude", ProfileName: "work", Machine: "m1", AddedAt: now.Add(-time.Minute), Attempts: 2, LastAttempt: now, LastError: "refused"},
+		{Provider: "codex", ProfileName: "main", Machine: "m2", AddedAt: now},
+	}
+	if err := d.ReplaceSyncQueue(queue); err != nil {
+		t.Fatalf("ReplaceSyncQueue() error = %v", err)
+	}
+
+	got, err := d.ListSyncQueue()
+	if err != nil {
+		t.Fatalf("ListSyncQueue() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Machine != "m1" || got[0].Attempts != 2 || got[0].LastError != "refused" || !got[0].LastAttempt.Equal(now) {
+		t.Fatalf("ListSyncQueue() = %+v", got)
+	}
+	if !got[1].LastAttempt.IsZero() {
+		t.Errorf("unset last_attempt = %v, want zero", got[1].LastAttempt)
+	}
+
+	if err := d.ReplaceSyncQueue(nil); err != nil {
+		t.Fatalf("ReplaceSyncQueue(nil) error = %v", err)
+	}
+	if got, _ := d.ListSyncQueue(); len(got) != 0 {
+		t.Errorf("queue after clear = %+v, want empty", got)
+	}
+}
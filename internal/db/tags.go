@@ -0,0 +1,202 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProfileTag is a key=value label on a vault profile. Bare tags have an
+// empty Value.
+type ProfileTag struct {
+	Provider    string `json:"provider"`
+	ProfileName string `json:"profile"`
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+}
+
+// String formats the tag as key=value, or key for a bare tag.
+func (t ProfileTag) String() string {
+	if t.Value == "" {
+		return t.Key
+	}
+	return t.Key + "=" + t.Value
+}
+
+// ParseProfileTag parses "key=value" or "key". Keys are trimmed and
+// lowercased; values are kept as given apart from surrounding space.
+func ParseProfileTag(s string) (key, value string, err error) {
+	key, value, _ = strings.Cut(s, "=")
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+	if key == "" {
+		return "", "", fmt.Errorf("tag %q has no key", s)
+	}
+	if strings.ContainsAny(key, " \t\n,") {
+		return "", "", fmt.Errorf("tag key %q cannot contain spaces or commas", key)
+	}
+	return key, value, nil
+}
+
+// SetProfileTags sets tags on a profile, replacing the values of keys it
+// already has.
+func (d *DB) SetProfileTags(provider, profile string, tags map[string]string) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+	provider = strings.TrimSpace(provider)
+	profile = strings.TrimSpace(profile)
+	if provider == "" || profile == "" {
+		return fmt.Errorf("provider and profile are required")
+	}
+
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := formatSQLiteTime(time.Now())
+	for key, value := range tags {
+		_, err := tx.Exec(
+			`INSERT INTO profile_tags (provider, profile_name, key, value, updated_at)
+			 VALUES (?, ?, ?, ?, ?)
+			 ON CONFLICT(provider, profile_name, key) DO UPDATE SET
+			   value = excluded.value,
+			   updated_at = excluded.updated_at`,
+			provider, profile, key, value, now,
+		)
+		if err != nil {
+			return fmt.Errorf("upsert profile_tags: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// RemoveProfileTags removes the given keys from a profile, or every tag
+// when keys is empty. It returns how many tags were removed.
+func (d *DB) RemoveProfileTags(provider, profile string, keys ...string) (int64, error) {
+	if d == nil || d.conn == nil {
+		return 0, fmt.Errorf("db is not open")
+	}
+
+	if len(keys) == 0 {
+		res, err := d.conn.Exec(
+			`DELETE FROM profile_tags WHERE provider = ? AND profile_name = ?`,
+			provider, profile,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("delete profile_tags: %w", err)
+		}
+		return res.RowsAffected()
+	}
+
+	var removed int64
+	for _, key := range keys {
+		res, err := d.conn.Exec(
+			`DELETE FROM profile_tags WHERE provider = ? AND profile_name = ? AND key = ?`,
+			provider, profile, strings.ToLower(strings.TrimSpace(key)),
+		)
+		if err != nil {
+			return removed, fmt.Errorf("delete profile_tags: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		removed += n
+	}
+	return removed, nil
+}
+
+// ProfileTags returns a profile's tags, sorted by key.
+func (d *DB) ProfileTags(provider, profile string) ([]ProfileTag, error) {
+	tags, err := d.ListProfileTags(provider)
+	if err != nil {
+		return nil, err
+	}
+	var out []ProfileTag
+	for _, t := range tags {
+		if t.ProfileName == profile {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// ListProfileTags returns every tag, or one provider's when provider is
+// set, sorted by provider, profile, and key.
+func (d *DB) ListProfileTags(provider string) ([]ProfileTag, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	query := `SELECT provider, profile_name, key, value FROM profile_tags`
+	var args []interface{}
+	if provider = strings.TrimSpace(provider); provider != "" {
+		query += ` WHERE provider = ?`
+		args = append(args, provider)
+	}
+	rows, err := d.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query profile_tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []ProfileTag
+	for rows.Next() {
+		var t ProfileTag
+		if err := rows.Scan(&t.Provider, &t.ProfileName, &t.Key, &t.Value); err != nil {
+			return nil, fmt.Errorf("scan profile_tags: %w", err)
+		}
+		tags = append(tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate profile_tags: %w", err)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		a, b := tags[i], tags[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.ProfileName != b.ProfileName {
+			return a.ProfileName < b.ProfileName
+		}
+		return a.Key < b.Key
+	})
+	return tags, nil
+}
+
+// ProfileTagMap returns every profile's tags keyed by "provider/profile".
+func (d *DB) ProfileTagMap() (map[string]map[string]string, error) {
+	tags, err := d.ListProfileTags("")
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]map[string]string)
+	for _, t := range tags {
+		k := t.Provider + "/" + t.ProfileName
+		if out[k] == nil {
+			out[k] = make(map[string]string)
+		}
+		out[k][t.Key] = t.Value
+	}
+	return out, nil
+}
+
+// TagsMatch reports whether tags satisfy every filter. A filter "key=value"
+// needs that exact value; a bare "key" needs the key with any value.
+func TagsMatch(tags map[string]string, filters []string) bool {
+	for _, f := range filters {
+		key, value, err := ParseProfileTag(f)
+		if err != nil {
+			return false
+		}
+		got, ok := tags[key]
+		if !ok {
+			return false
+		}
+		if strings.Contains(f, "=") && got != value {
+			return false
+		}
+	}
+	return true
+}
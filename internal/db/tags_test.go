@@ -0,0 +1,78 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileTags(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer d.Close()
+
+	if err := d.SetProfileTags("claude", "work", map[string]string{"client": "acme", "tier": "max"}); err != nil {
+		t.Fatalf("SetProfileTags() error = %v", err)
+	}
+	if err := d.SetProfileTags("claude", "work", map[string]string{"tier": "pro", "shared": ""}); err != nil {
+		t.Fatalf("SetProfileTags() update error = %v", err)
+	}
+	if err := d.SetProfileTags("codex", "main", map[string]string{"client": "globex"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := d.ProfileTags("claude", "work")
+	if err != nil {
+		t.Fatalf("ProfileTags() error = %v", err)
+	}
+	var got []string
+	for _, tag := range tags {
+		got = append(got, tag.String())
+	}
+	want := []string{"client=acme", "shared", "tier=pro"}
+	if len(got) != len(want) {
+		t.Fatalf("tags = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("tags = %v, want %v", got, want)
+		}
+	}
+
+	all, err := d.ProfileTagMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all["codex/main"]["client"] != "globex" || len(all) != 2 {
+		t.Errorf("ProfileTagMap() = %v", all)
+	}
+
+	if n, err := d.RemoveProfileTags("claude", "work", "TIER"); err != nil || n != 1 {
+		t.Errorf("RemoveProfileTags(tier) = %d, %v", n, err)
+	}
+	if n, err := d.RemoveProfileTags("claude", "work"); err != nil || n != 2 {
+		t.Errorf("RemoveProfileTags(all) = %d, %v", n, err)
+	}
+}
+
+func TestTagsMatch(t *testing.T) {
+	tags := map[string]string{"client": "acme", "shared": ""}
+	tests := []struct {
+		filters []string
+		want    bool
+	}{
+		{nil, true},
+		{[]string{"client=acme"}, true},
+		{[]string{"client"}, true},
+		{[]string{"Client=acme", "shared"}, true},
+		{[]string{"client=globex"}, false},
+		{[]string{"tier"}, false},
+		{[]string{"shared="}, true},
+	}
+	for _, tt := range tests {
+		if got := TagsMatch(tags, tt.filters); got != tt.want {
+			t.Errorf("TagsMatch(%v) = %v, want %v", tt.filters, got, tt.want)
+		}
+	}
+}
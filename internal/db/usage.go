@@ -0,0 +1,208 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UsageRecord is one token-usage sample reported after a request.
+type UsageRecord struct {
+	ID                int64     `json:"id,omitempty"`
+	Timestamp         time.Time `json:"timestamp"`
+	Provider          string    `json:"provider"`
+	ProfileName       string    `json:"profile"`
+	Model             string    `json:"model,omitempty"`
+	InputTokens       int64     `json:"input_tokens"`
+	OutputTokens      int64     `json:"output_tokens"`
+	CacheReadTokens   int64     `json:"cache_read_tokens,omitempty"`
+	CacheCreateTokens int64     `json:"cache_create_tokens,omitempty"`
+	TotalTokens       int64     `json:"total_tokens"`
+	Source            string    `json:"source,omitempty"`
+}
+
+// UsageTotals aggregates usage records for one profile.
+type UsageTotals struct {
+	Provider     string    `json:"provider"`
+	ProfileName  string    `json:"profile"`
+	Requests     int       `json:"requests"`
+	InputTokens  int64     `json:"input_tokens"`
+	OutputTokens int64     `json:"output_tokens"`
+	TotalTokens  int64     `json:"total_tokens"`
+	FirstAt      time.Time `json:"first_at"`
+	LastAt       time.Time `json:"last_at"`
+}
+
+// RecordUsage stores a usage sample and returns it with its ID, timestamp,
+// and total filled in. TotalTokens defaults to the sum of the token fields.
+func (d *DB) RecordUsage(rec UsageRecord) (UsageRecord, error) {
+	if d == nil || d.conn == nil {
+		return rec, fmt.Errorf("db is not open")
+	}
+
+	rec.Provider = strings.ToLower(strings.TrimSpace(rec.Provider))
+	rec.ProfileName = strings.TrimSpace(rec.ProfileName)
+	if rec.Provider == "" {
+		return rec, fmt.Errorf("provider is required")
+	}
+	if rec.ProfileName == "" {
+		return rec, fmt.Errorf("profile name is required")
+	}
+	if rec.InputTokens < 0 || rec.OutputTokens < 0 || rec.CacheReadTokens < 0 || rec.CacheCreateTokens < 0 || rec.TotalTokens < 0 {
+		return rec, fmt.Errorf("token counts must not be negative")
+	}
+	if rec.TotalTokens == 0 {
+		rec.TotalTokens = rec.InputTokens + rec.OutputTokens + rec.CacheReadTokens + rec.CacheCreateTokens
+	}
+	if rec.TotalTokens == 0 {
+		return rec, fmt.Errorf("usage record has no tokens")
+	}
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = time.Now()
+	}
+	rec.Timestamp = rec.Timestamp.UTC()
+
+	res, err := d.conn.Exec(
+		`INSERT INTO usage (timestamp, provider, profile_name, model, input_tokens, output_tokens, cache_read_tokens, cache_create_tokens, total_tokens, source)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		formatSQLiteTime(rec.Timestamp),
+		rec.Provider,
+		rec.ProfileName,
+		strings.TrimSpace(rec.Model),
+		rec.InputTokens,
+		rec.OutputTokens,
+		rec.CacheReadTokens,
+		rec.CacheCreateTokens,
+		rec.TotalTokens,
+		strings.TrimSpace(rec.Source),
+	)
+	if err != nil {
+		return rec, fmt.Errorf("insert usage: %w", err)
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		rec.ID = id
+	}
+	return rec, nil
+}
+
+// UsageSince returns a profile's usage records at or after since, oldest
+// first.
+func (d *DB) UsageSince(provider, profile string, since time.Time) ([]UsageRecord, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	provider = strings.TrimSpace(provider)
+	profile = strings.TrimSpace(profile)
+	if provider == "" {
+		return nil, fmt.Errorf("provider is required")
+	}
+	if profile == "" {
+		return nil, fmt.Errorf("profile name is required")
+	}
+
+	rows, err := d.conn.Query(
+		`SELECT id, timestamp, provider, profile_name, model, input_tokens, output_tokens, cache_read_tokens, cache_create_tokens, total_tokens, source
+		 FROM usage
+		 WHERE provider = ? AND profile_name = ? AND datetime(timestamp) >= datetime(?)
+		 ORDER BY timestamp ASC, id ASC`,
+		provider, profile, formatSQLiteTime(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query usage: %w", err)
+	}
+	defer rows.Close()
+
+	var records []UsageRecord
+	for rows.Next() {
+		var rec UsageRecord
+		var ts string
+		if err := rows.Scan(&rec.ID, &ts, &rec.Provider, &rec.ProfileName, &rec.Model,
+			&rec.InputTokens, &rec.OutputTokens, &rec.CacheReadTokens, &rec.CacheCreateTokens,
+			&rec.TotalTokens, &rec.Source); err != nil {
+			return nil, fmt.Errorf("scan usage: %w", err)
+		}
+		if t, err := parseSQLiteTime(ts); err == nil {
+			rec.Timestamp = t
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// UsageTotalsSince aggregates usage per profile at or after since. An empty
+// provider includes every provider.
+func (d *DB) UsageTotalsSince(provider string, since time.Time) ([]UsageTotals, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	provider = strings.TrimSpace(provider)
+	rows, err := d.conn.Query(
+		`SELECT provider, profile_name, COUNT(*), SUM(input_tokens), SUM(output_tokens), SUM(total_tokens), MIN(timestamp), MAX(timestamp)
+		 FROM usage
+		 WHERE (? = '' OR provider = ?) AND datetime(timestamp) >= datetime(?)
+		 GROUP BY provider, profile_name
+		 ORDER BY provider, profile_name`,
+		provider, provider, formatSQLiteTime(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query usage totals: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []UsageTotals
+	for rows.Next() {
+		var t UsageTotals
+		var first, last string
+		if err := rows.Scan(&t.Provider, &t.ProfileName, &t.Requests, &t.InputTokens, &t.OutputTokens,
+			&t.TotalTokens, &first, &last); err != nil {
+			return nil, fmt.Errorf("scan usage totals: %w", err)
+		}
+		if ts, err := parseSQLiteTime(first); err == nil {
+			t.FirstAt = ts
+		}
+		if ts, err := parseSQLiteTime(last); err == nil {
+			t.LastAt = ts
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+// UsageBetween returns usage records in [from, to), oldest first. An empty
+// provider includes every provider.
+func (d *DB) UsageBetween(provider string, from, to time.Time) ([]UsageRecord, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	provider = strings.TrimSpace(provider)
+	rows, err := d.conn.Query(
+		`SELECT id, timestamp, provider, profile_name, model, input_tokens, output_tokens, cache_read_tokens, cache_create_tokens, total_tokens, source
+		 FROM usage
+		 WHERE (? = '' OR provider = ?) AND datetime(timestamp) >= datetime(?) AND datetime(timestamp) < datetime(?)
+		 ORDER BY timestamp ASC, id ASC`,
+		provider, provider, formatSQLiteTime(from), formatSQLiteTime(to),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query usage: %w", err)
+	}
+	defer rows.Close()
+
+	var records []UsageRecord
+	for rows.Next() {
+		var rec UsageRecord
+		var ts string
+		if err := rows.Scan(&rec.ID, &ts, &rec.Provider, &rec.ProfileName, &rec.Model,
+			&rec.InputTokens, &rec.OutputTokens, &rec.CacheReadTokens, &rec.CacheCreateTokens,
+			&rec.TotalTokens, &rec.Source); err != nil {
+			return nil, fmt.Errorf("scan usage: %w", err)
+		}
+		if t, err := parseSQLiteTime(ts); err == nil {
+			rec.Timestamp = t
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
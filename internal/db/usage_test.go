@@ -0,0 +1,89 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordUsage(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer d.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	rec, err := d.RecordUsage(UsageRecord{
+		Timestamp:    now.Add(-time.Hour),
+		Provider:     " Claude ",
+		ProfileName:  "work",
+		Model:        "sonnet",
+		InputTokens:  1000,
+		OutputTokens: 200,
+		Source:       "wrapper",
+	})
+	if err != nil {
+		t.Fatalf("RecordUsage() error = %v", err)
+	}
+	if rec.ID == 0 || rec.Provider != "claude" || rec.TotalTokens != 1200 {
+		t.Errorf("RecordUsage() = %+v", rec)
+	}
+	if _, err := d.RecordUsage(UsageRecord{Provider: "claude", ProfileName: "work", TotalTokens: 500, Timestamp: now}); err != nil {
+		t.Fatalf("RecordUsage() error = %v", err)
+	}
+	if _, err := d.RecordUsage(UsageRecord{Provider: "claude", ProfileName: "other", TotalTokens: 50, Timestamp: now}); err != nil {
+		t.Fatalf("RecordUsage() error = %v", err)
+	}
+
+	records, err := d.UsageSince("claude", "work", now.Add(-2*time.Hour))
+	if err != nil {
+		t.Fatalf("UsageSince() error = %v", err)
+	}
+	if len(records) != 2 || records[0].Model != "sonnet" || !records[0].Timestamp.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("UsageSince() = %+v", records)
+	}
+	if records, _ := d.UsageSince("claude", "work", now.Add(-time.Minute)); len(records) != 1 {
+		t.Errorf("UsageSince(recent) returned %d records, want 1", len(records))
+	}
+
+	totals, err := d.UsageTotalsSince("claude", time.Time{})
+	if err != nil {
+		t.Fatalf("UsageTotalsSince() error = %v", err)
+	}
+	if len(totals) != 2 || totals[1].ProfileName != "work" || totals[1].Requests != 2 || totals[1].TotalTokens != 1700 {
+		t.Errorf("UsageTotalsSince() = %+v", totals)
+	}
+
+	between, err := d.UsageBetween("", now.Add(-2*time.Hour), now)
+	if err != nil {
+		t.Fatalf("UsageBetween() error = %v", err)
+	}
+	if len(between) != 1 || between[0].Model != "sonnet" {
+		t.Errorf("UsageBetween() = %+v, want only the earlier record", between)
+	}
+}
+
+func TestRecordUsage_Invalid(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer d.Close()
+
+	for _, rec := range []UsageRecord{
+		{ProfileName: "work", TotalTokens: 1},
+		{Provider: "claude", TotalTokens: 1},
+		{Provider: "claude", ProfileName: "work"},
+		{Provider: "claude", ProfileName: "work", InputTokens: -5},
+	} {
+		if _, err := d.RecordUsage(rec); err == nil {
+			t.Errorf("RecordUsage(%+v) succeeded, want error", rec)
+		}
+	}
+
+	var nilDB *DB
+	if _, err := nilDB.RecordUsage(UsageRecord{}); err == nil {
+		t.Error("RecordUsage on nil db succeeded")
+	}
+}
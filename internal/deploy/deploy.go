@@ -400,7 +400,7 @@ WantedBy=default.target
 
 // SystemdUnitConfig holds the configuration for generating a systemd unit.
 type SystemdUnitConfig struct {
-	Type      string // "coordinator" or "agent"
+	Type      string // "coordinator", "agent", or "token refresh"
 	ExecStart string // Full command to run
 }
 
@@ -35,9 +35,23 @@ type WatcherConfig struct {
 	// OnChange is called when an auth file changes (even if not a new account).
 	OnChange func(provider, path string)
 
+	// SuggestOnly disables automatic backup of accounts that are not yet in
+	// the vault. Instead, OnSuggest is called with the profile name that
+	// would have been used. Existing profiles are still kept up to date.
+	SuggestOnly bool
+
+	// OnSuggest is called in SuggestOnly mode when the live auth files belong
+	// to an account that has no vault profile.
+	OnSuggest func(provider, name string, ident *identity.Identity)
+
 	// OnError is called when an error occurs during watching or processing.
 	OnError func(err error)
 
+	// Paused, if set, is asked before processing changes. Changes that
+	// settle while it returns true are dropped: nothing is saved or
+	// suggested for them.
+	Paused func() bool
+
 	// Logger for structured logging.
 	Logger *slog.Logger
 }
@@ -260,6 +274,10 @@ func (w *Watcher) processPending() {
 	}
 	w.mu.Unlock()
 
+	if len(toProcess) > 0 && w.config.Paused != nil && w.config.Paused() {
+		w.logger.Debug("paused; ignoring auth file changes", "count", len(toProcess))
+		return
+	}
 	for _, path := range toProcess {
 		w.processChange(path)
 	}
@@ -325,6 +343,10 @@ func (w *Watcher) processChange(path string) {
 
 		// No identity available; still back up with an auto-generated name.
 		autoName := w.autoProfileName(provider)
+		if w.config.SuggestOnly {
+			w.suggest(provider, autoName, ident)
+			return
+		}
 		w.logger.Info("identity missing; backing up with auto profile name",
 			"provider", provider,
 			"profile", autoName)
@@ -382,6 +404,11 @@ func (w *Watcher) processChange(path string) {
 		}
 	}
 
+	if w.config.SuggestOnly {
+		w.suggest(provider, email, ident)
+		return
+	}
+
 	// New profile - backup it
 	w.logger.Info("discovered new account",
 		"provider", provider,
@@ -404,6 +431,16 @@ func (w *Watcher) processChange(path string) {
 	}
 }
 
+// suggest reports an unsaved account without touching the vault.
+func (w *Watcher) suggest(provider, name string, ident *identity.Identity) {
+	w.logger.Info("new account not in vault",
+		"provider", provider,
+		"suggested_profile", name)
+	if w.config.OnSuggest != nil {
+		w.config.OnSuggest(provider, name, ident)
+	}
+}
+
 // autoProfileName generates a unique, user-deletable profile name when identity is missing.
 func (w *Watcher) autoProfileName(provider string) string {
 	base := "auto-" + time.Now().Format("20060102-150405")
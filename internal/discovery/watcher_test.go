@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -129,6 +130,133 @@ func TestWatcher_Discovery(t *testing.T) {
 	assert.Equal(t, "claude/newuser@example.com", discoveries[0])
 }
 
+func TestWatcher_Paused(t *testing.T) {
+	tmpDir := t.TempDir()
+	vaultDir := filepath.Join(tmpDir, "vault")
+	homeDir := filepath.Join(tmpDir, "home")
+
+	require.NoError(t, os.MkdirAll(vaultDir, 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".claude"), 0700))
+	t.Setenv("HOME", homeDir)
+
+	vault := authfile.NewVault(vaultDir)
+
+	var paused atomic.Bool
+	paused.Store(true)
+	var mu sync.Mutex
+	var discoveries []string
+
+	watcher, err := NewWatcher(vault, WatcherConfig{
+		Providers:        []string{"claude"},
+		DebounceInterval: 100 * time.Millisecond,
+		OnDiscovery: func(provider, email string, ident *identity.Identity) {
+			mu.Lock()
+			discoveries = append(discoveries, provider+"/"+email)
+			mu.Unlock()
+		},
+		Paused: paused.Load,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, watcher.Start(ctx))
+	defer watcher.Stop()
+	time.Sleep(200 * time.Millisecond)
+
+	writeCreds := func(email string) {
+		creds := map[string]interface{}{
+			"claudeAiOauth": map[string]interface{}{
+				"email":     email,
+				"accountId": "acct_" + email,
+				"expiresAt": time.Now().Add(time.Hour).Unix(),
+			},
+		}
+		credsData, _ := json.Marshal(creds)
+		require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".claude", ".credentials.json"), credsData, 0600))
+	}
+
+	// A login made while paused is left alone.
+	writeCreds("paused@example.com")
+	time.Sleep(500 * time.Millisecond)
+	profiles, err := vault.List("claude")
+	require.NoError(t, err)
+	assert.Empty(t, profiles)
+
+	paused.Store(false)
+	writeCreds("resumed@example.com")
+	time.Sleep(500 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"claude/resumed@example.com"}, discoveries)
+}
+
+func TestWatcher_SuggestOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	vaultDir := filepath.Join(tmpDir, "vault")
+	homeDir := filepath.Join(tmpDir, "home")
+
+	require.NoError(t, os.MkdirAll(vaultDir, 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".claude"), 0700))
+
+	vault := authfile.NewVault(vaultDir)
+	t.Setenv("HOME", homeDir)
+
+	var mu sync.Mutex
+	var suggestions, discoveries []string
+
+	watcher, err := NewWatcher(vault, WatcherConfig{
+		Providers:        []string{"claude"},
+		DebounceInterval: 100 * time.Millisecond,
+		SuggestOnly:      true,
+		OnSuggest: func(provider, name string, ident *identity.Identity) {
+			mu.Lock()
+			suggestions = append(suggestions, provider+"/"+name)
+			mu.Unlock()
+		},
+		OnDiscovery: func(provider, email string, ident *identity.Identity) {
+			mu.Lock()
+			discoveries = append(discoveries, provider+"/"+email)
+			mu.Unlock()
+		},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, watcher.Start(ctx))
+	defer watcher.Stop()
+
+	time.Sleep(200 * time.Millisecond)
+
+	creds := map[string]interface{}{
+		"claudeAiOauth": map[string]interface{}{
+			"email":            "manual@example.com",
+			"subscriptionType": "max",
+			"accountId":        "acct_999",
+			"expiresAt":        time.Now().Add(time.Hour).Unix(),
+		},
+	}
+	credsData, _ := json.Marshal(creds)
+	credsPath := filepath.Join(homeDir, ".claude", ".credentials.json")
+	require.NoError(t, os.WriteFile(credsPath, credsData, 0600))
+
+	time.Sleep(500 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []string{"claude/manual@example.com"}, suggestions)
+	assert.Empty(t, discoveries)
+
+	profiles, err := vault.List("claude")
+	require.NoError(t, err)
+	assert.NotContains(t, profiles, "manual@example.com")
+}
+
 func TestWatcher_UpdateExisting(t *testing.T) {
 	// Create temp directories
 	tmpDir := t.TempDir()
@@ -562,7 +562,7 @@ func TestE2E_SyncHistoryTracking(t *testing.T) {
 	}
 
 	h.LogInfo("History entries added", map[string]interface{}{
-		"count": len(state.History.Entries),
+		"count": state.HistoryCount(),
 	})
 	h.EndStep("add_history")
 
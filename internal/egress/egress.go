@@ -0,0 +1,281 @@
+// Package egress sends a process's traffic out through a chosen network
+// interface. A Relay listens on localhost and is handed to the process as
+// its proxy; every connection it makes upstream is dialed from the
+// interface's address.
+package egress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// InterfaceAddr returns the address connections leaving through the named
+// interface are dialed from: its first IPv4 address, or failing that its
+// first global IPv6 address.
+func InterfaceAddr(name string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("interface %s: %w", name, err)
+	}
+	if iface.Flags&net.FlagUp == 0 {
+		return nil, fmt.Errorf("interface %s is down", name)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("interface %s: %w", name, err)
+	}
+	var v6 net.IP
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ip4 := ipnet.IP.To4(); ip4 != nil {
+			return ip4, nil
+		}
+		if v6 == nil && ipnet.IP.IsGlobalUnicast() {
+			v6 = ipnet.IP
+		}
+	}
+	if v6 == nil {
+		return nil, fmt.Errorf("interface %s has no usable address", name)
+	}
+	return v6, nil
+}
+
+// Relay is a local proxy whose outgoing connections leave from one address.
+//
+// With an upstream proxy, the relay forwards bytes to it unchanged, so the
+// process speaks the upstream's protocol (HTTP or SOCKS) through it. With
+// no upstream, the relay is itself an HTTP proxy that supports CONNECT.
+type Relay struct {
+	ln       net.Listener
+	dialer   *net.Dialer
+	upstream *url.URL
+	server   *http.Server
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Start starts a relay dialing from localAddr. upstream is a proxy URL, or
+// empty to connect to destinations directly.
+func Start(localAddr net.IP, upstream string) (*Relay, error) {
+	r := &Relay{
+		dialer: &net.Dialer{
+			LocalAddr: &net.TCPAddr{IP: localAddr},
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+		conns: make(map[net.Conn]struct{}),
+	}
+	if upstream != "" {
+		u, err := url.Parse(upstream)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid upstream proxy %q", upstream)
+		}
+		r.upstream = u
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	r.ln = ln
+
+	if r.upstream != nil {
+		r.wg.Add(1)
+		go r.acceptLoop()
+		return r, nil
+	}
+
+	transport := &http.Transport{
+		DialContext:         r.dialer.DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	r.server = &http.Server{
+		Handler:           &httpProxy{relay: r, transport: transport},
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		_ = r.server.Serve(ln)
+	}()
+	return r, nil
+}
+
+// ProxyURL is the URL the process should use as its proxy.
+func (r *Relay) ProxyURL() string {
+	if r.upstream == nil {
+		return "http://" + r.ln.Addr().String()
+	}
+	u := *r.upstream
+	u.Host = r.ln.Addr().String()
+	return u.String()
+}
+
+// Close stops the relay and drops its open connections.
+func (r *Relay) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	for c := range r.conns {
+		c.Close()
+	}
+	r.mu.Unlock()
+
+	var err error
+	if r.server != nil {
+		err = r.server.Close()
+	} else {
+		err = r.ln.Close()
+	}
+	r.wg.Wait()
+	return err
+}
+
+func (r *Relay) track(c net.Conn) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		c.Close()
+		return false
+	}
+	r.conns[c] = struct{}{}
+	return true
+}
+
+func (r *Relay) untrack(c net.Conn) {
+	r.mu.Lock()
+	delete(r.conns, c)
+	r.mu.Unlock()
+	c.Close()
+}
+
+func (r *Relay) acceptLoop() {
+	defer r.wg.Done()
+	for {
+		c, err := r.ln.Accept()
+		if err != nil {
+			return
+		}
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			up, err := r.dialer.Dial("tcp", r.upstream.Host)
+			if err != nil {
+				c.Close()
+				return
+			}
+			r.pipe(c, up)
+		}()
+	}
+}
+
+// pipe copies between a and b until either side closes.
+func (r *Relay) pipe(a, b net.Conn) {
+	if !r.track(a) {
+		b.Close()
+		return
+	}
+	if !r.track(b) {
+		r.untrack(a)
+		return
+	}
+	defer r.untrack(a)
+	defer r.untrack(b)
+
+	done := make(chan struct{}, 2)
+	go func() { _, _ = io.Copy(a, b); done <- struct{}{} }()
+	go func() { _, _ = io.Copy(b, a); done <- struct{}{} }()
+	<-done
+}
+
+// httpProxy is a minimal forward proxy.
+type httpProxy struct {
+	relay     *Relay
+	transport *http.Transport
+}
+
+// hopHeaders are not forwarded by proxies (RFC 9110, section 7.6.1).
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate",
+	"Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+func (p *httpProxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodConnect {
+		p.connect(w, req)
+		return
+	}
+	if req.URL.Host == "" {
+		http.Error(w, "not a proxy request", http.StatusBadRequest)
+		return
+	}
+
+	out := req.Clone(req.Context())
+	out.RequestURI = ""
+	for _, h := range hopHeaders {
+		out.Header.Del(h)
+	}
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for _, h := range hopHeaders {
+		resp.Header.Del(h)
+	}
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+func (p *httpProxy) connect(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), p.relay.dialer.Timeout)
+	up, err := p.relay.dialer.DialContext(ctx, "tcp", req.Host)
+	cancel()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		up.Close()
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	c, buf, err := hj.Hijack()
+	if err != nil {
+		up.Close()
+		return
+	}
+	if _, err := c.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		c.Close()
+		up.Close()
+		return
+	}
+	// Bytes the client sent after the CONNECT request are already buffered.
+	if n := buf.Reader.Buffered(); n > 0 {
+		pending, _ := buf.Reader.Peek(n)
+		if _, err := up.Write(pending); err != nil {
+			c.Close()
+			up.Close()
+			return
+		}
+	}
+	p.relay.pipe(c, up)
+}
@@ -0,0 +1,106 @@
+package egress
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, proxyURL, target string, client *http.Client) string {
+	t.Helper()
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := client.Transport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(u)
+	resp, err := (&http.Client{Transport: transport}).Get(target)
+	if err != nil {
+		t.Fatalf("GET %s via %s: %v", target, proxyURL, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestRelay(t *testing.T) {
+	// The backend reports the address connections come from.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		io.WriteString(w, host)
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	tlsServer := httptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+
+	local := net.ParseIP("127.0.0.1")
+
+	direct, err := Start(local, "")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer direct.Close()
+	if !strings.HasPrefix(direct.ProxyURL(), "http://127.0.0.1:") {
+		t.Errorf("ProxyURL = %q", direct.ProxyURL())
+	}
+	if got := get(t, direct.ProxyURL(), plain.URL, plain.Client()); got != "127.0.0.1" {
+		t.Errorf("plain request came from %q", got)
+	}
+	if got := get(t, direct.ProxyURL(), tlsServer.URL, tlsServer.Client()); got != "127.0.0.1" {
+		t.Errorf("CONNECT request came from %q", got)
+	}
+
+	// With an upstream, the relay forwards bytes to it and keeps its scheme
+	// and credentials.
+	upstream, err := Start(local, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+	chained, err := Start(local, strings.Replace(upstream.ProxyURL(), "http://", "http://user:pw@", 1))
+	if err != nil {
+		t.Fatalf("Start with upstream: %v", err)
+	}
+	defer chained.Close()
+	if u, _ := url.Parse(chained.ProxyURL()); u.User.Username() != "user" || u.Host == upstream.ln.Addr().String() {
+		t.Errorf("chained ProxyURL = %q", chained.ProxyURL())
+	}
+	if got := get(t, chained.ProxyURL(), tlsServer.URL, tlsServer.Client()); got != "127.0.0.1" {
+		t.Errorf("chained request came from %q", got)
+	}
+
+	if err := chained.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if _, err := net.Dial("tcp", chained.ln.Addr().String()); err == nil {
+		t.Error("relay still listening after Close")
+	}
+}
+
+func TestInterfaceAddr(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback == 0 || iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		ip, err := InterfaceAddr(iface.Name)
+		if err != nil {
+			t.Fatalf("InterfaceAddr(%s): %v", iface.Name, err)
+		}
+		if !ip.IsLoopback() {
+			t.Errorf("InterfaceAddr(%s) = %s, want a loopback address", iface.Name, ip)
+		}
+		break
+	}
+	if _, err := InterfaceAddr("caam-no-such-if0"); err == nil {
+		t.Error("InterfaceAddr of a missing interface succeeded")
+	}
+}
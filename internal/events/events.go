@@ -0,0 +1,326 @@
+// Package events is caam's append-only event bus. Subsystems publish what
+// happened (a profile was activated, a cooldown set, a token refreshed, ...)
+// to one canonical stream that is persisted and can be followed by the
+// daemon, the HTTP API, and `caam events tail`.
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Type names an event.
+type Type string
+
+const (
+	// ProfileActivated is published when a vault profile becomes the live
+	// auth for its provider.
+	ProfileActivated Type = "profile_activated"
+
+	// CooldownSet is published when a profile is put into cooldown.
+	CooldownSet Type = "cooldown_set"
+
+	// TokenRefreshed is published when a profile's OAuth token is refreshed.
+	TokenRefreshed Type = "token_refreshed"
+
+	// SyncCompleted is published when a sync with a machine finishes.
+	SyncCompleted Type = "sync_completed"
+
+	// HealthChanged is published when a profile's health status changes.
+	HealthChanged Type = "health_changed"
+)
+
+// Types lists every event type, in the order they are documented.
+func Types() []Type {
+	return []Type{ProfileActivated, CooldownSet, TokenRefreshed, SyncCompleted, HealthChanged}
+}
+
+// Event is one entry in the stream. ID is assigned by the store and
+// increases monotonically.
+type Event struct {
+	ID       int64          `json:"id"`
+	Type     Type           `json:"type"`
+	Time     time.Time      `json:"time"`
+	Provider string         `json:"provider,omitempty"`
+	Profile  string         `json:"profile,omitempty"`
+	Source   string         `json:"source,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+}
+
+// Filter selects events. The zero value matches everything.
+type Filter struct {
+	Types    []Type `json:"types,omitempty"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// Match reports whether ev passes the filter.
+func (f Filter) Match(ev Event) bool {
+	if f.Provider != "" && ev.Provider != f.Provider {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == ev.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Store persists events. AppendEvent assigns ID (and Time, if unset) and
+// returns the stored event; EventsAfter returns events with an ID greater
+// than afterID, oldest first.
+type Store interface {
+	AppendEvent(ev Event) (Event, error)
+	EventsAfter(afterID int64, limit int) ([]Event, error)
+}
+
+// followBatch is how many events Follow reads from the store per query.
+const followBatch = 500
+
+type subscription struct {
+	filter Filter
+	ch     chan Event
+}
+
+// Bus persists published events and delivers them to subscribers. Events
+// appended to the store by other processes reach subscribers through Follow.
+type Bus struct {
+	store Store
+
+	mu        sync.Mutex
+	subs      map[*subscription]struct{}
+	following bool
+	cursor    int64
+	// local holds IDs published in this process that Follow has not
+	// passed yet, so they aren't delivered twice.
+	local     map[int64]bool
+	observers []func(Event)
+}
+
+// NewBus creates a bus backed by store. A nil store keeps events in memory
+// only and delivers them to this process's subscribers.
+func NewBus(store Store) *Bus {
+	return &Bus{
+		store: store,
+		subs:  make(map[*subscription]struct{}),
+		local: make(map[int64]bool),
+	}
+}
+
+// Store returns the bus's store, which may be nil.
+func (b *Bus) Store() Store {
+	return b.store
+}
+
+// Publish persists ev and delivers it to matching subscribers.
+func (b *Bus) Publish(ev Event) (Event, error) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	if b.store != nil {
+		stored, err := b.store.AppendEvent(ev)
+		if err != nil {
+			return ev, err
+		}
+		ev = stored
+	}
+
+	b.mu.Lock()
+	// Follow may already have read the row back and delivered it.
+	followed := b.following && ev.ID != 0 && ev.ID <= b.cursor
+	if b.following && ev.ID > b.cursor {
+		b.local[ev.ID] = true
+	}
+	b.mu.Unlock()
+
+	if !followed {
+		b.deliver(ev)
+	}
+	b.observe(ev)
+	return ev, nil
+}
+
+// OnPublish registers fn to be called with each event published through
+// this bus, before Publish returns. Events other processes appended, which
+// reach subscribers through Follow, are not passed to fn.
+func (b *Bus) OnPublish(fn func(Event)) {
+	b.mu.Lock()
+	b.observers = append(b.observers, fn)
+	b.mu.Unlock()
+}
+
+func (b *Bus) observe(ev Event) {
+	b.mu.Lock()
+	observers := b.observers
+	b.mu.Unlock()
+	for _, fn := range observers {
+		fn(ev)
+	}
+}
+
+// Subscribe returns a channel of events matching f and a function that
+// cancels the subscription. Events are dropped for a subscriber whose buffer
+// is full rather than blocking publishers.
+func (b *Bus) Subscribe(f Filter, buffer int) (<-chan Event, func()) {
+	if buffer <= 0 {
+		buffer = 64
+	}
+	sub := &subscription{filter: f, ch: make(chan Event, buffer)}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, sub)
+			b.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// Subscribers returns the number of active subscriptions.
+func (b *Bus) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+func (b *Bus) deliver(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		if !sub.filter.Match(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+		}
+	}
+}
+
+// Follow polls the store every interval and delivers events appended by
+// other processes, starting after the newest event present when it is
+// called. It returns when ctx is cancelled or the bus has no store.
+func (b *Bus) Follow(ctx context.Context, interval time.Duration) error {
+	if b.store == nil {
+		return nil
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	b.mu.Lock()
+	b.following = true
+	b.mu.Unlock()
+	if err := b.skipToEnd(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		b.poll()
+	}
+}
+
+// skipToEnd moves the cursor past every stored event.
+func (b *Bus) skipToEnd() error {
+	for {
+		b.mu.Lock()
+		cursor := b.cursor
+		b.mu.Unlock()
+
+		batch, err := b.store.EventsAfter(cursor, followBatch)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		b.mu.Lock()
+		b.advance(batch[len(batch)-1].ID)
+		b.mu.Unlock()
+	}
+}
+
+func (b *Bus) poll() {
+	for {
+		b.mu.Lock()
+		cursor := b.cursor
+		b.mu.Unlock()
+
+		batch, err := b.store.EventsAfter(cursor, followBatch)
+		if err != nil || len(batch) == 0 {
+			return
+		}
+		for _, ev := range batch {
+			b.mu.Lock()
+			seen := b.local[ev.ID]
+			b.advance(ev.ID)
+			b.mu.Unlock()
+			if !seen {
+				b.deliver(ev)
+			}
+		}
+		if len(batch) < followBatch {
+			return
+		}
+	}
+}
+
+// advance moves the cursor to id and forgets local IDs it has passed.
+// Callers hold b.mu.
+func (b *Bus) advance(id int64) {
+	if id <= b.cursor {
+		return
+	}
+	b.cursor = id
+	for localID := range b.local {
+		if localID <= id {
+			delete(b.local, localID)
+		}
+	}
+}
+
+var defaultBus atomic.Pointer[Bus]
+
+// SetDefault installs the process-wide bus used by Publish. Passing nil
+// turns publishing off.
+func SetDefault(b *Bus) {
+	defaultBus.Store(b)
+}
+
+// Default returns the process-wide bus, or nil if none is installed.
+func Default() *Bus {
+	return defaultBus.Load()
+}
+
+// Publish sends ev to the process-wide bus. It is a no-op when no bus is
+// installed (library use and tests), and errors are dropped: the event
+// stream must never break the operation that produced the event.
+func Publish(ev Event) {
+	if b := defaultBus.Load(); b != nil {
+		_, _ = b.Publish(ev)
+	}
+}
+
+// PublishActivated publishes a ProfileActivated event. source names what did
+// the switch ("activate", "next", "run", ...).
+func PublishActivated(provider, profile, source string) {
+	Publish(Event{Type: ProfileActivated, Provider: provider, Profile: profile, Source: source})
+}
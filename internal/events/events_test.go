@@ -0,0 +1,156 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory Store shared by buses standing in for separate
+// processes.
+type memStore struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *memStore) AppendEvent(ev Event) (Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ev.ID = int64(len(s.events) + 1)
+	s.events = append(s.events, ev)
+	return ev, nil
+}
+
+func (s *memStore) EventsAfter(afterID int64, limit int) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Event
+	for _, ev := range s.events {
+		if ev.ID > afterID && len(out) < limit {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func receive(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return Event{}
+	}
+}
+
+func expectNone(t *testing.T, ch <-chan Event, wait time.Duration) {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		t.Fatalf("unexpected event %+v", ev)
+	case <-time.After(wait):
+	}
+}
+
+func TestBus_PublishFiltersSubscribers(t *testing.T) {
+	bus := NewBus(&memStore{})
+
+	all, cancelAll := bus.Subscribe(Filter{}, 8)
+	defer cancelAll()
+	cooldowns, cancelCooldowns := bus.Subscribe(Filter{Types: []Type{CooldownSet}, Provider: "claude"}, 8)
+	defer cancelCooldowns()
+
+	if _, err := bus.Publish(Event{Type: ProfileActivated, Provider: "claude", Profile: "work"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if _, err := bus.Publish(Event{Type: CooldownSet, Provider: "codex", Profile: "main"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if _, err := bus.Publish(Event{Type: CooldownSet, Provider: "claude", Profile: "work"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	for want := int64(1); want <= 3; want++ {
+		if ev := receive(t, all); ev.ID != want || ev.Time.IsZero() {
+			t.Fatalf("all subscriber got %+v, want ID %d with time", ev, want)
+		}
+	}
+	if ev := receive(t, cooldowns); ev.ID != 3 {
+		t.Fatalf("filtered subscriber got %+v, want claude cooldown", ev)
+	}
+	expectNone(t, cooldowns, 50*time.Millisecond)
+
+	if bus.Subscribers() != 2 {
+		t.Fatalf("Subscribers() = %d, want 2", bus.Subscribers())
+	}
+	cancelCooldowns()
+	cancelCooldowns()
+	if bus.Subscribers() != 1 {
+		t.Fatalf("Subscribers() after cancel = %d, want 1", bus.Subscribers())
+	}
+}
+
+func TestBus_FollowDeliversOtherProcessesOnce(t *testing.T) {
+	store := &memStore{}
+	other := NewBus(store)
+	if _, err := other.Publish(Event{Type: TokenRefreshed, Provider: "claude", Profile: "old"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	bus := NewBus(store)
+	ch, cancel := bus.Subscribe(Filter{}, 16)
+	defer cancel()
+	var observed []Type
+	bus.OnPublish(func(ev Event) { observed = append(observed, ev.Type) })
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = bus.Follow(ctx, 10*time.Millisecond)
+	}()
+
+	// Let Follow skip past the existing event before publishing more.
+	time.Sleep(50 * time.Millisecond)
+
+	if _, err := other.Publish(Event{Type: SyncCompleted, Provider: "", Source: "sync"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if _, err := bus.Publish(Event{Type: ProfileActivated, Provider: "claude", Profile: "work"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	got := map[int64]int{}
+	for i := 0; i < 2; i++ {
+		got[receive(t, ch).ID]++
+	}
+	expectNone(t, ch, 100*time.Millisecond)
+	if got[2] != 1 || got[3] != 1 {
+		t.Fatalf("delivered IDs = %v, want 2 and 3 exactly once", got)
+	}
+	if len(observed) != 1 || observed[0] != ProfileActivated {
+		t.Errorf("observed = %v, want only this bus's activation", observed)
+	}
+
+	stop()
+	<-done
+}
+
+func TestPublish_NoDefaultIsNoop(t *testing.T) {
+	prev := Default()
+	t.Cleanup(func() { SetDefault(prev) })
+
+	SetDefault(nil)
+	PublishActivated("claude", "work", "test")
+
+	store := &memStore{}
+	SetDefault(NewBus(store))
+	PublishActivated("claude", "work", "test")
+	evs, _ := store.EventsAfter(0, 10)
+	if len(evs) != 1 || evs[0].Type != ProfileActivated || evs[0].Source != "test" {
+		t.Fatalf("stored events = %+v, want one activation", evs)
+	}
+}
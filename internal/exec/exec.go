@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"runtime"
 	"strings"
 	"sync"
 	"syscall"
@@ -59,6 +60,15 @@ type RunOptions struct {
 	// to use the global user environment. This is required for vault-based
 	// auth file swapping (caam run).
 	UseGlobalEnv bool
+
+	// Command runs this command instead of the provider's CLI. Args are
+	// appended to it.
+	Command []string
+
+	// Sandbox gives the command the profile's HOME and XDG directories
+	// even where the provider doesn't set them, so nothing the command
+	// writes lands in the real home directory.
+	Sandbox bool
 }
 
 // ExitCodeError wraps a process exit code.
@@ -90,10 +100,22 @@ func (r *Runner) Run(ctx context.Context, opts RunOptions) error {
 			return fmt.Errorf("get provider env: %w", err)
 		}
 	}
+	var sandboxEnv map[string]string
+	if opts.Sandbox {
+		sandboxEnv, err = SandboxEnv(opts.Profile)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Build command
 	bin := opts.Provider.DefaultBin()
-	cmd := exec.CommandContext(ctx, bin, opts.Args...)
+	args := opts.Args
+	if len(opts.Command) > 0 {
+		bin = opts.Command[0]
+		args = append(append([]string{}, opts.Command[1:]...), opts.Args...)
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
 
 	// Set up environment with deduplication (last one wins in our map logic)
 	envMap := make(map[string]string)
@@ -106,12 +128,17 @@ func (r *Runner) Run(ctx context.Context, opts RunOptions) error {
 		}
 	}
 
-	// 2. Apply provider environment (overrides inherited)
+	// 2. Apply sandbox directories (overrides inherited)
+	for k, v := range sandboxEnv {
+		envMap[k] = v
+	}
+
+	// 3. Apply provider environment (overrides sandbox)
 	for k, v := range providerEnv {
 		envMap[k] = v
 	}
 
-	// 3. Apply custom environment options (overrides provider)
+	// 4. Apply custom environment options (overrides provider)
 	for k, v := range opts.Env {
 		envMap[k] = v
 	}
@@ -258,6 +285,27 @@ func (r *Runner) Run(ctx context.Context, opts RunOptions) error {
 	return nil
 }
 
+// SandboxEnv creates a profile's HOME and XDG directories and returns the
+// environment variables pointing at them.
+func SandboxEnv(prof *profile.Profile) (map[string]string, error) {
+	env := map[string]string{
+		"HOME":            prof.HomePath(),
+		"XDG_CONFIG_HOME": prof.XDGConfigPath(),
+		"XDG_DATA_HOME":   prof.XDGDataPath(),
+		"XDG_CACHE_HOME":  prof.XDGCachePath(),
+		"XDG_STATE_HOME":  prof.XDGStatePath(),
+	}
+	if runtime.GOOS == "windows" {
+		env["USERPROFILE"] = prof.HomePath()
+	}
+	for _, dir := range env {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("create sandbox dir: %w", err)
+		}
+	}
+	return env, nil
+}
+
 // RunInteractive runs an interactive session with the AI CLI.
 func (r *Runner) RunInteractive(ctx context.Context, opts RunOptions) error {
 	return r.Run(ctx, opts)
@@ -830,3 +830,38 @@ func TestRun_RateLimitCallbackWithDelay(t *testing.T) {
 		t.Error("Rate limit callback was not invoked")
 	}
 }
+
+func TestRun_SandboxCommand(t *testing.T) {
+	prof := &profile.Profile{
+		Name:     "test",
+		Provider: "test",
+		BasePath: t.TempDir(),
+	}
+
+	// The provider's CLI isn't run; its env still applies on top of the
+	// sandbox directories.
+	mock := &mockProvider{
+		id:         "test",
+		defaultBin: "false",
+		envVars:    map[string]string{"XDG_CONFIG_HOME": "/provider/config"},
+	}
+
+	runner := NewRunner(provider.NewRegistry())
+	check := `test "$HOME" = "$1/home" && test "$XDG_DATA_HOME" = "$1/xdg_data" && ` +
+		`test "$XDG_STATE_HOME" = "$1/xdg_state" && test "$XDG_CONFIG_HOME" = /provider/config && ` +
+		`test -d "$XDG_CACHE_HOME" && test "$HTTPS_PROXY" = http://proxy:8080`
+	err := runner.Run(context.Background(), RunOptions{
+		Profile:  prof,
+		Provider: mock,
+		Command:  []string{"sh", "-c", check, "sh"},
+		Args:     []string{prof.BasePath},
+		Env:      map[string]string{"HTTPS_PROXY": "http://proxy:8080"},
+		Sandbox:  true,
+	})
+	if err != nil {
+		t.Errorf("sandboxed command saw the wrong environment: %v", err)
+	}
+	if prof.IsLocked() {
+		t.Error("profile still locked after the command exited")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authpool"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/handoff"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/pty"
@@ -48,12 +49,16 @@ type SmartRunner struct {
 	// Cooldown duration to apply when rate limit is detected
 	cooldownDuration time.Duration
 
+	// disableRotation reports rate limits instead of switching profiles
+	disableRotation bool
+
 	// State (protected by mu)
 	mu              sync.Mutex
 	currentProfile  string
 	previousProfile string // For rollback
 	handoffCount    int
 	state           HandoffState
+	unresolved      bool // Rate limit hit that no handoff resolved
 
 	// WaitGroup to track background goroutines (handleRateLimit)
 	wg sync.WaitGroup
@@ -77,6 +82,11 @@ type SmartRunnerOptions struct {
 	AuthPool         *authpool.AuthPool
 	Rotation         *rotation.Selector
 	CooldownDuration time.Duration
+
+	// DisableRotation stops automatic profile switching on rate limit
+	// (automation.<provider>.auto_rotate: false). The rate limit is still
+	// reported with manual instructions.
+	DisableRotation bool
 }
 
 // NewSmartRunner creates a new SmartRunner.
@@ -96,6 +106,7 @@ func NewSmartRunner(runner *Runner, opts SmartRunnerOptions) *SmartRunner {
 		handoffConfig:    opts.HandoffConfig,
 		notifier:         notifier,
 		cooldownDuration: opts.CooldownDuration,
+		disableRotation:  opts.DisableRotation,
 		state:            Running,
 		loginDone:        make(chan loginResult, 1),
 	}
@@ -120,7 +131,10 @@ func (r *SmartRunner) Run(ctx context.Context, opts RunOptions) (err error) {
 		return r.Runner.Run(ctx, opts)
 	}
 
+	r.mu.Lock()
 	r.currentProfile = opts.Profile.Name
+	r.unresolved = false
+	r.mu.Unlock()
 
 	// Log activation event
 	if r.db != nil {
@@ -275,6 +289,15 @@ func (r *SmartRunner) handleRateLimit(ctx context.Context) {
 	r.state = RateLimited
 	r.mu.Unlock()
 
+	if r.disableRotation {
+		if r.detector.Revoked() {
+			r.quarantineCurrent(r.detector.RevokedReason())
+		}
+		provider := r.loginHandler.Provider()
+		r.failWithManual("automatic rotation is disabled for %s (automation.%s.auto_rotate)", provider, provider)
+		return
+	}
+
 	// Notify detection
 	r.notifyHandoff(r.currentProfile, "selecting backup...")
 
@@ -323,24 +346,30 @@ func (r *SmartRunner) handleRateLimit(ctx context.Context) {
 
 	r.notifyHandoff(r.currentProfile, nextProfile)
 
-	// 3. Mark current profile as in cooldown (if authPool is available)
-	cooldownDuration := r.cooldownDuration
-	if cooldownDuration == 0 {
-		cooldownDuration = 60 * time.Minute
-	}
-	if r.authPool != nil {
-		r.authPool.SetCooldown(r.loginHandler.Provider(), r.currentProfile, cooldownDuration)
-	}
-	if r.db != nil {
-		r.db.SetCooldown(r.loginHandler.Provider(), r.currentProfile, time.Now(), cooldownDuration, "auto-detected via SmartRunner")
+	// 3. Take the current profile out of rotation: quarantine a revoked
+	// token (it needs a fresh login), otherwise cool it down.
+	if r.detector.Revoked() {
+		r.quarantineCurrent(r.detector.RevokedReason())
+	} else {
+		cooldownDuration := r.cooldownDuration
+		if cooldownDuration == 0 {
+			cooldownDuration = 60 * time.Minute
+		}
+		if r.authPool != nil {
+			r.authPool.SetCooldown(r.loginHandler.Provider(), r.currentProfile, cooldownDuration)
+		}
+		if r.db != nil {
+			r.db.SetCooldown(r.loginHandler.Provider(), r.currentProfile, time.Now(), cooldownDuration, "auto-detected via SmartRunner")
+		}
 	}
 
 	// 4. Swap auth files
 	r.setState(SwappingAuth)
-	if err := r.vault.Restore(fileSet, nextProfile); err != nil {
+	if err := r.vault.Activate(fileSet, nextProfile, "smart_runner"); err != nil {
 		r.failWithManual("auth swap failed: %v", err)
 		return
 	}
+	events.PublishActivated(r.loginHandler.Provider(), nextProfile, "smart_runner")
 
 	// 5. Inject login command
 	r.drainLoginDone()
@@ -375,7 +404,10 @@ func (r *SmartRunner) handleRateLimit(ctx context.Context) {
 
 	// 7. Success!
 	r.setState(LoginComplete)
+	r.mu.Lock()
 	r.currentProfile = nextProfile
+	r.unresolved = false
+	r.mu.Unlock()
 	r.handoffCount++
 
 	r.notifier.Notify(&notify.Alert{
@@ -389,6 +421,22 @@ func (r *SmartRunner) handleRateLimit(ctx context.Context) {
 	r.setState(Running)
 }
 
+// quarantineCurrent records the current profile's token as revoked and tells
+// the user how to log in again.
+func (r *SmartRunner) quarantineCurrent(reason string) {
+	provider := r.loginHandler.Provider()
+	if r.db != nil {
+		if _, err := r.db.MarkRevoked(provider, r.currentProfile, time.Now(), reason, "run"); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to quarantine %s/%s: %v\n", provider, r.currentProfile, err)
+		}
+	}
+	r.notifier.Notify(&notify.Alert{
+		Level:   notify.Warning,
+		Title:   "Token revoked",
+		Message: fmt.Sprintf("%s/%s was quarantined (%s). Log in again, then run: caam backup %s %s", provider, r.currentProfile, reason, provider, r.currentProfile),
+	})
+}
+
 func (r *SmartRunner) rollback(fileSet authfile.AuthFileSet) {
 	fmt.Fprintf(os.Stderr, "Rolling back to %s...\n", r.previousProfile)
 	if err := r.vault.Restore(fileSet, r.previousProfile); err != nil {
@@ -400,7 +448,10 @@ func (r *SmartRunner) rollback(fileSet authfile.AuthFileSet) {
 }
 
 func (r *SmartRunner) failWithManual(format string, args ...interface{}) {
-	r.setState(HandoffFailed)
+	r.mu.Lock()
+	r.state = HandoffFailed
+	r.unresolved = true
+	r.mu.Unlock()
 	msg := fmt.Sprintf(format, args...)
 
 	r.notifier.Notify(&notify.Alert{
@@ -425,6 +476,22 @@ func (r *SmartRunner) notifyHandoff(from, to string, msg ...string) {
 	})
 }
 
+// RateLimitUnresolved reports whether the last Run hit a rate limit or a
+// revoked token that no in-session handoff resolved. The caller can then fail
+// over by restarting the command on another profile.
+func (r *SmartRunner) RateLimitUnresolved() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.unresolved
+}
+
+// CurrentProfile returns the profile the last Run ended on.
+func (r *SmartRunner) CurrentProfile() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.currentProfile
+}
+
 func (r *SmartRunner) setState(s HandoffState) {
 	r.mu.Lock()
 	r.state = s
@@ -459,7 +526,7 @@ func (r *SmartRunner) monitorOutput(ctx context.Context, ctrl pty.Controller, do
 			observer(line)
 		}
 		// This callback is triggered when a complete line is processed
-		if !dispatched && r.detector.Detected() {
+		if !dispatched && (r.detector.Detected() || r.detector.Revoked()) {
 			dispatched = true
 			r.wg.Add(1)
 			go func() {
@@ -490,7 +557,7 @@ func (r *SmartRunner) monitorOutput(ctx context.Context, ctrl pty.Controller, do
 
 			if state == Running {
 				// If detector was reset (e.g. after successful handoff), allow new dispatch
-				if !r.detector.Detected() {
+				if !r.detector.Detected() && !r.detector.Revoked() {
 					dispatched = false
 				}
 
@@ -228,6 +228,9 @@ func TestSmartRunner_FailWithManual(t *testing.T) {
 	if sr.state != HandoffFailed {
 		t.Errorf("state = %v, want %v", sr.state, HandoffFailed)
 	}
+	if !sr.RateLimitUnresolved() {
+		t.Error("RateLimitUnresolved() = false after a failed handoff")
+	}
 	if len(notifier.alerts) != 1 {
 		t.Fatalf("expected 1 alert, got %d", len(notifier.alerts))
 	}
@@ -0,0 +1,305 @@
+// Package federation aggregates auth coordinator status from other machines
+// and routes auth codes back to the machine whose pane is waiting for them.
+//
+// Machines in the sync pool are reached over an SSH tunnel to their
+// coordinator's loopback port; other peers are reached directly over HTTP.
+package federation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/coordinator"
+	syncstate "github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+// Transports a peer can be reached over.
+const (
+	TransportHTTP = "http"
+	TransportSSH  = "ssh"
+)
+
+// DefaultInterval is how often the daemon polls peers.
+const DefaultInterval = 30 * time.Second
+
+// DefaultCoordinatorPort is the coordinator API port on sync pool machines.
+const DefaultCoordinatorPort = 7890
+
+// snapshotFileName is where the daemon stores the last poll.
+const snapshotFileName = "federation.json"
+
+// Peer is another machine's coordinator.
+type Peer struct {
+	// Name identifies the machine (the sync pool name for SSH peers).
+	Name string
+
+	// Transport is TransportHTTP or TransportSSH.
+	Transport string
+
+	// URL is the coordinator's base URL. For SSH peers it is resolved on
+	// the remote machine, e.g. http://127.0.0.1:7890.
+	URL string
+
+	// Token is the coordinator API token, if it requires one.
+	Token string
+
+	// Machine is the sync pool machine to tunnel through (SSH only).
+	Machine *syncstate.Machine
+}
+
+// Peers builds the peer list from the federation config: every SSH machine
+// in the sync pool plus the listed HTTP peers. pool may be nil.
+func Peers(cfg config.FederationConfig, pool *syncstate.SyncPool) []Peer {
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		token = strings.TrimSpace(os.Getenv("CAAM_COORDINATOR_TOKEN"))
+	}
+	port := cfg.CoordinatorPort
+	if port == 0 {
+		port = DefaultCoordinatorPort
+	}
+
+	var peers []Peer
+	if pool != nil {
+		for _, m := range pool.ListMachines() {
+			// HTTPS machines are WebDAV storage, not hosts we can tunnel into.
+			if m.TransportName() != syncstate.TransportSSH || !m.WipeRequestedAt.IsZero() {
+				continue
+			}
+			peers = append(peers, Peer{
+				Name:      m.Name,
+				Transport: TransportSSH,
+				URL:       "http://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(port)),
+				Token:     token,
+				Machine:   m,
+			})
+		}
+	}
+	for _, p := range cfg.Peers {
+		name := p.Name
+		if name == "" {
+			name = p.URL
+		}
+		peerToken := p.Token
+		if peerToken == "" {
+			peerToken = token
+		}
+		peers = append(peers, Peer{
+			Name:      name,
+			Transport: TransportHTTP,
+			URL:       strings.TrimRight(p.URL, "/"),
+			Token:     peerToken,
+		})
+	}
+	return peers
+}
+
+// client returns an HTTP client that reaches the peer, and a function to
+// release the underlying connection.
+func (p Peer) client(timeout time.Duration) (*http.Client, func(), error) {
+	if p.Transport != TransportSSH {
+		return &http.Client{Timeout: timeout}, func() {}, nil
+	}
+	if p.Machine == nil {
+		return nil, nil, fmt.Errorf("ssh peer %s has no machine", p.Name)
+	}
+
+	ssh := syncstate.NewSSHClient(p.Machine)
+	opts := syncstate.DefaultConnectOptions()
+	opts.Timeout = timeout
+	if err := ssh.Connect(opts); err != nil {
+		return nil, nil, err
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return ssh.Dial(network, addr)
+		},
+		DisableKeepAlives: true,
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, func() { ssh.Disconnect() }, nil
+}
+
+func (p Peer) do(ctx context.Context, client *http.Client, method, path string, body []byte) (*http.Response, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.URL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+	return client.Do(req)
+}
+
+// Status reads the peer's GET /status.
+func (p Peer) Status(ctx context.Context, timeout time.Duration) (*coordinator.StatusResponse, error) {
+	client, release, err := p.client(timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	resp, err := p.do(ctx, client, http.MethodGet, "/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coordinator returned %s", resp.Status)
+	}
+
+	var status coordinator.StatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("decode status: %w", err)
+	}
+	return &status, nil
+}
+
+// Submit sends an auth code to the peer's coordinator.
+func (p Peer) Submit(ctx context.Context, timeout time.Duration, resp coordinator.AuthResponse) error {
+	client, release, err := p.client(timeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	body, err := json.Marshal(coordinator.CompleteRequest(resp))
+	if err != nil {
+		return err
+	}
+	httpResp, err := p.do(ctx, client, http.MethodPost, "/auth/response", body)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("coordinator on %s returned %s", p.Name, httpResp.Status)
+	}
+	return nil
+}
+
+// MachineStatus is one peer's coordinator status at the last poll.
+type MachineStatus struct {
+	Machine   string                      `json:"machine"`
+	Transport string                      `json:"transport"`
+	URL       string                      `json:"url"`
+	Healthy   bool                        `json:"healthy"`
+	Error     string                      `json:"error,omitempty"`
+	LatencyMs int64                       `json:"latency_ms"`
+	CheckedAt time.Time                   `json:"checked_at"`
+	Status    *coordinator.StatusResponse `json:"status,omitempty"`
+}
+
+// Snapshot is the result of polling every peer.
+type Snapshot struct {
+	UpdatedAt time.Time       `json:"updated_at"`
+	Machines  []MachineStatus `json:"machines"`
+}
+
+// Poll queries every peer concurrently.
+func Poll(ctx context.Context, peers []Peer, timeout time.Duration) *Snapshot {
+	snap := &Snapshot{Machines: make([]MachineStatus, len(peers))}
+
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(i int, peer Peer) {
+			defer wg.Done()
+			start := time.Now()
+			status, err := peer.Status(ctx, timeout)
+			ms := MachineStatus{
+				Machine:   peer.Name,
+				Transport: peer.Transport,
+				URL:       peer.URL,
+				LatencyMs: time.Since(start).Milliseconds(),
+				CheckedAt: time.Now(),
+			}
+			if err != nil {
+				ms.Error = err.Error()
+			} else {
+				ms.Healthy = true
+				ms.Status = status
+			}
+			snap.Machines[i] = ms
+		}(i, peer)
+	}
+	wg.Wait()
+
+	snap.UpdatedAt = time.Now()
+	return snap
+}
+
+// FindRequest returns the machine holding a pending auth request.
+func (s *Snapshot) FindRequest(requestID string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	for _, m := range s.Machines {
+		if m.Status == nil {
+			continue
+		}
+		for _, req := range m.Status.PendingDetails {
+			if req.ID == requestID {
+				return m.Machine, true
+			}
+		}
+	}
+	return "", false
+}
+
+// SnapshotPath returns where the daemon stores the last poll.
+func SnapshotPath() string {
+	return filepath.Join(syncstate.SyncDataDir(), snapshotFileName)
+}
+
+// Save writes the snapshot atomically.
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadSnapshot reads a saved snapshot. It returns (nil, nil) when there
+// is none.
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &snap, nil
+}
@@ -0,0 +1,131 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/coordinator"
+)
+
+func fakeCoordinator(t *testing.T, token string, got *coordinator.CompleteRequest) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(coordinator.StatusResponse{
+			Running:      true,
+			PendingAuths: 1,
+			PendingDetails: []*coordinator.AuthRequest{
+				{ID: "req-1", PaneID: 3, URL: "https://claude.ai/oauth", Status: "pending"},
+			},
+		})
+	})
+	mux.HandleFunc("POST /auth/response", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPollAndFindRequest(t *testing.T) {
+	var got coordinator.CompleteRequest
+	srv := fakeCoordinator(t, "secret", &got)
+
+	peers := []Peer{
+		{Name: "mac", Transport: TransportHTTP, URL: srv.URL, Token: "secret"},
+		{Name: "linux", Transport: TransportHTTP, URL: srv.URL, Token: "wrong"},
+	}
+	snap := Poll(context.Background(), peers, 5*time.Second)
+
+	if len(snap.Machines) != 2 {
+		t.Fatalf("machines = %d, want 2", len(snap.Machines))
+	}
+	if !snap.Machines[0].Healthy || snap.Machines[0].Status.PendingAuths != 1 {
+		t.Errorf("mac = %+v, want healthy with 1 pending", snap.Machines[0])
+	}
+	if snap.Machines[1].Healthy || snap.Machines[1].Error == "" {
+		t.Errorf("linux = %+v, want unhealthy with error", snap.Machines[1])
+	}
+
+	machine, ok := snap.FindRequest("req-1")
+	if !ok || machine != "mac" {
+		t.Errorf("FindRequest = %q, %v; want mac, true", machine, ok)
+	}
+	if _, ok := snap.FindRequest("missing"); ok {
+		t.Error("FindRequest found a request that does not exist")
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	var got coordinator.CompleteRequest
+	srv := fakeCoordinator(t, "", &got)
+
+	peer := Peer{Name: "mac", Transport: TransportHTTP, URL: srv.URL}
+	err := peer.Submit(context.Background(), 5*time.Second, coordinator.AuthResponse{
+		RequestID: "req-1", Code: "abc123", Account: "me@example.com",
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if got.RequestID != "req-1" || got.Code != "abc123" || got.Account != "me@example.com" {
+		t.Errorf("coordinator received %+v", got)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "federation.json")
+
+	if snap, err := LoadSnapshot(path); err != nil || snap != nil {
+		t.Fatalf("LoadSnapshot(missing) = %v, %v; want nil, nil", snap, err)
+	}
+
+	want := &Snapshot{
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+		Machines:  []MachineStatus{{Machine: "mac", Healthy: true}},
+	}
+	if err := want.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if !got.UpdatedAt.Equal(want.UpdatedAt) || len(got.Machines) != 1 || got.Machines[0].Machine != "mac" {
+		t.Errorf("LoadSnapshot = %+v, want %+v", got, want)
+	}
+}
+
+func TestPeersFromConfig(t *testing.T) {
+	t.Setenv("CAAM_COORDINATOR_TOKEN", "env-token")
+
+	peers := Peers(config.FederationConfig{
+		Peers: []config.FederationPeer{
+			{Name: "desk", URL: "http://desk:7890/", Token: "desk-token"},
+			{URL: "http://laptop:7890"},
+		},
+	}, nil)
+
+	if len(peers) != 2 {
+		t.Fatalf("peers = %d, want 2", len(peers))
+	}
+	if peers[0].Name != "desk" || peers[0].URL != "http://desk:7890" || peers[0].Token != "desk-token" {
+		t.Errorf("peers[0] = %+v", peers[0])
+	}
+	if peers[1].Name != "http://laptop:7890" || peers[1].Transport != TransportHTTP || peers[1].Token != "env-token" {
+		t.Errorf("peers[1] = %+v", peers[1])
+	}
+}
@@ -13,7 +13,11 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // ErrNoExpiry indicates that expiry information could not be determined.
@@ -173,7 +177,7 @@ type claudeOAuthJSON struct {
 
 // parseClaudeCredentialsFile parses the Claude Code credentials file format.
 func parseClaudeCredentialsFile(path string) (*ExpiryInfo, error) {
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -307,6 +311,119 @@ func ParseGeminiExpiry(authDir string) (*ExpiryInfo, error) {
 	return nil, ErrNoExpiry
 }
 
+// ParseCursorExpiry extracts token expiry from the Cursor CLI auth file.
+//
+// Cursor CLI stores tokens in $XDG_CONFIG_HOME/cursor/auth.json:
+//
+//	{
+//	  "accessToken": "<jwt>",
+//	  "refreshToken": "<jwt>"
+//	}
+//
+// The file carries no expiry field; it is read from the access token's exp claim.
+func ParseCursorExpiry(authDir string) (*ExpiryInfo, error) {
+	if authDir == "" {
+		xdgConfig := os.Getenv("XDG_CONFIG_HOME")
+		if xdgConfig == "" {
+			homeDir, _ := os.UserHomeDir()
+			xdgConfig = filepath.Join(homeDir, ".config")
+		}
+		authDir = filepath.Join(xdgConfig, "cursor")
+	}
+	authPath := filepath.Join(authDir, "auth.json")
+
+	data, err := vaultcrypt.ReadFile(authPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoAuthFile
+		}
+		return nil, err
+	}
+	var auth struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := json.Unmarshal(data, &auth); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", authPath, err)
+	}
+	if auth.AccessToken == "" {
+		return nil, ErrNoExpiry
+	}
+	id, err := identity.ExtractFromJWT(auth.AccessToken)
+	if err != nil || id.ExpiresAt.IsZero() {
+		return nil, ErrNoExpiry
+	}
+
+	return &ExpiryInfo{
+		ExpiresAt:       id.ExpiresAt,
+		HasRefreshToken: auth.RefreshToken != "",
+		Source:          authPath,
+	}, nil
+}
+
+// ParseCopilotExpiry extracts token expiry from GitHub Copilot auth files.
+//
+// Copilot stores one entry per GitHub host in
+// $XDG_CONFIG_HOME/github-copilot/hosts.json (or apps.json):
+//
+//	{
+//	  "github.com": {
+//	    "user": "octocat",
+//	    "oauth_token": "ghu_...",
+//	    "refresh_token": "ghr_...",
+//	    "expires_at": "2026-03-01T12:00:00Z"
+//	  }
+//	}
+//
+// Classic gho_ tokens never expire and carry no expires_at; those report
+// ErrNoExpiry.
+func ParseCopilotExpiry(authDir string) (*ExpiryInfo, error) {
+	if authDir == "" {
+		xdgConfig := os.Getenv("XDG_CONFIG_HOME")
+		if xdgConfig == "" {
+			homeDir, _ := os.UserHomeDir()
+			xdgConfig = filepath.Join(homeDir, ".config")
+		}
+		authDir = filepath.Join(xdgConfig, "github-copilot")
+	}
+
+	found := false
+	for _, name := range []string{"hosts.json", "apps.json"} {
+		path := filepath.Join(authDir, name)
+		data, err := vaultcrypt.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		found = true
+
+		var hosts map[string]map[string]any
+		if err := json.Unmarshal(data, &hosts); err != nil {
+			continue
+		}
+		info := &ExpiryInfo{Source: path}
+		for _, entry := range hosts {
+			expiresAt := parseExpiryField(entry["expires_at"])
+			if expiresAt.IsZero() {
+				continue
+			}
+			// With several hosts signed in, the first to expire matters.
+			if info.ExpiresAt.IsZero() || expiresAt.Before(info.ExpiresAt) {
+				info.ExpiresAt = expiresAt
+				token, _ := entry["refresh_token"].(string)
+				info.HasRefreshToken = token != ""
+			}
+		}
+		if !info.ExpiresAt.IsZero() {
+			return info, nil
+		}
+	}
+
+	if !found {
+		return nil, ErrNoAuthFile
+	}
+	return nil, ErrNoExpiry
+}
+
 func getADCPath() string {
 	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
 		return path
@@ -351,7 +468,7 @@ type oauthJSON struct {
 
 // parseOAuthFile reads an OAuth token file and extracts expiry info.
 func parseOAuthFile(path string) (*ExpiryInfo, error) {
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -424,7 +541,7 @@ type adcJSON struct {
 // parseADCFile reads Google ADC credentials.
 // ADC files don't contain expiry - they contain refresh tokens.
 func parseADCFile(path string) (*ExpiryInfo, error) {
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -554,6 +671,34 @@ func parseExpiresIn(v any) int64 {
 	return 0
 }
 
+// ExpiryParser parses token expiry from a vault profile directory.
+type ExpiryParser func(profileDir string) (*ExpiryInfo, error)
+
+var (
+	expiryParsersMu sync.RWMutex
+	expiryParsers   = make(map[string]ExpiryParser)
+)
+
+// RegisterExpiryParser adds an expiry parser for a provider that has no
+// built-in parser, such as one loaded from a provider plugin.
+func RegisterExpiryParser(provider string, parse ExpiryParser) {
+	expiryParsersMu.Lock()
+	defer expiryParsersMu.Unlock()
+	expiryParsers[provider] = parse
+}
+
+// ParseRegisteredExpiry parses expiry for a provider registered with
+// RegisterExpiryParser. It returns ErrNoExpiry for unknown providers.
+func ParseRegisteredExpiry(provider, profileDir string) (*ExpiryInfo, error) {
+	expiryParsersMu.RLock()
+	parse, ok := expiryParsers[provider]
+	expiryParsersMu.RUnlock()
+	if !ok {
+		return nil, ErrNoExpiry
+	}
+	return parse(profileDir)
+}
+
 // ParseAllExpiry attempts to parse expiry for all providers and returns combined results.
 func ParseAllExpiry() map[string]*ExpiryInfo {
 	results := make(map[string]*ExpiryInfo)
@@ -567,6 +712,12 @@ func ParseAllExpiry() map[string]*ExpiryInfo {
 	if info, err := ParseGeminiExpiry(""); err == nil {
 		results["gemini"] = info
 	}
+	if info, err := ParseCursorExpiry(""); err == nil {
+		results["cursor"] = info
+	}
+	if info, err := ParseCopilotExpiry(""); err == nil {
+		results["copilot"] = info
+	}
 
 	return results
 }
@@ -413,6 +413,40 @@ func TestParseGeminiExpiry_OAuthCredentialsFileWithoutSettingsReturnsNoExpiry(t
 	}
 }
 
+func TestParseCopilotExpiry(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if _, err := ParseCopilotExpiry(tmpDir); err != ErrNoAuthFile {
+		t.Fatalf("expected ErrNoAuthFile, got %v", err)
+	}
+
+	hosts := `{"github.com":{"user":"octocat","oauth_token":"gho_x"}}`
+	if err := os.WriteFile(filepath.Join(tmpDir, "hosts.json"), []byte(hosts), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if _, err := ParseCopilotExpiry(tmpDir); err != ErrNoExpiry {
+		t.Fatalf("expected ErrNoExpiry for a non-expiring token, got %v", err)
+	}
+
+	hosts = `{
+		"github.com": {"oauth_token": "ghu_a", "refresh_token": "ghr_a", "expires_at": "2026-03-01T12:00:00Z"},
+		"ghe.example.com": {"oauth_token": "ghu_b", "expires_at": "2026-04-01T12:00:00Z"}
+	}`
+	if err := os.WriteFile(filepath.Join(tmpDir, "hosts.json"), []byte(hosts), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	info, err := ParseCopilotExpiry(tmpDir)
+	if err != nil {
+		t.Fatalf("ParseCopilotExpiry() error = %v", err)
+	}
+	if want := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC); !info.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want the earliest host expiry %v", info.ExpiresAt, want)
+	}
+	if !info.HasRefreshToken {
+		t.Error("expected HasRefreshToken")
+	}
+}
+
 func TestErrNoAuthFile(t *testing.T) {
 	tmpDir := t.TempDir()
 
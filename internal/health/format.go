@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
-	"unicode"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/i18n"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -31,11 +31,11 @@ func FormatHealthStatus(status HealthStatus, health *ProfileHealth, opts FormatO
 
 	var text string
 	if health == nil {
-		text = "Unknown"
+		text = i18n.T("status.unknown")
 	} else if !health.TokenExpiresAt.IsZero() {
 		ttl := time.Until(health.TokenExpiresAt)
 		if ttl <= 0 {
-			text = "Expired"
+			text = i18n.T("status.expired")
 		} else {
 			text = FormatTimeRemaining(health.TokenExpiresAt)
 		}
@@ -43,21 +43,21 @@ func FormatHealthStatus(status HealthStatus, health *ProfileHealth, opts FormatO
 		// No expiry info
 		switch status {
 		case StatusHealthy:
-			text = "Valid"
+			text = i18n.T("status.valid")
 		case StatusWarning:
 			if health.ErrorCount1h > 0 {
-				text = fmt.Sprintf("%d errors", health.ErrorCount1h)
+				text = i18n.N("status.errors", health.ErrorCount1h)
 			} else {
-				text = "Warning"
+				text = i18n.T("status.warning")
 			}
 		case StatusCritical:
 			if health.ErrorCount1h >= 3 {
-				text = fmt.Sprintf("%d errors", health.ErrorCount1h)
+				text = i18n.N("status.errors", health.ErrorCount1h)
 			} else {
-				text = "Critical"
+				text = i18n.T("status.critical")
 			}
 		default:
-			text = "Unknown"
+			text = i18n.T("status.unknown")
 		}
 	}
 
@@ -70,40 +70,21 @@ func FormatHealthStatus(status HealthStatus, health *ProfileHealth, opts FormatO
 	return result
 }
 
-// FormatTimeRemaining returns a human-readable time remaining string.
+// FormatTimeRemaining returns a human-readable time remaining string in the
+// current locale.
 // Examples: "59m left", "23h left", "3d left", "< 1m left"
 func FormatTimeRemaining(expiry time.Time) string {
 	ttl := time.Until(expiry)
 	if ttl <= 0 {
-		return "Expired"
-	}
-
-	// Round to avoid showing seconds
-	ttl = ttl.Round(time.Minute)
-
-	switch {
-	case ttl < time.Minute:
-		return "< 1m left"
-	case ttl < time.Hour:
-		return fmt.Sprintf("%dm left", int(ttl.Minutes()))
-	case ttl < 24*time.Hour:
-		hours := int(ttl.Hours())
-		mins := int(ttl.Minutes()) % 60
-		if mins > 0 && hours < 12 {
-			return fmt.Sprintf("%dh%dm left", hours, mins)
-		}
-		return fmt.Sprintf("%dh left", hours)
-	default:
-		days := int(ttl.Hours() / 24)
-		return fmt.Sprintf("%dd left", days)
+		return i18n.T("status.expired")
 	}
+	return i18n.Remaining(ttl)
 }
 
 // FormatStatusWithReason returns a detailed status string with explanation.
 // Example: "🟡 Warning - Token expires in 12 minutes"
 func FormatStatusWithReason(status HealthStatus, health *ProfileHealth, opts FormatOptions) string {
 	icon := status.Icon()
-	statusStr := status.String()
 
 	var reasons []string
 
@@ -112,43 +93,42 @@ func FormatStatusWithReason(status HealthStatus, health *ProfileHealth, opts For
 		if !health.TokenExpiresAt.IsZero() {
 			ttl := time.Until(health.TokenExpiresAt)
 			if ttl <= 0 {
-				reasons = append(reasons, "Token expired")
+				reasons = append(reasons, i18n.T("reason.token_expired"))
 			} else if ttl < 15*time.Minute {
-				reasons = append(reasons, fmt.Sprintf("Token expires in %s", formatDurationNatural(ttl)))
+				reasons = append(reasons, i18n.T("reason.token_expires_in", formatDurationNatural(ttl)))
 			} else if ttl < time.Hour {
-				reasons = append(reasons, fmt.Sprintf("Token expires in %s", formatDurationNatural(ttl)))
+				reasons = append(reasons, i18n.T("reason.token_expires_in", formatDurationNatural(ttl)))
 			}
 		}
 
 		// Check errors
 		if health.ErrorCount1h > 0 {
-			if health.ErrorCount1h == 1 {
-				reasons = append(reasons, "1 recent error")
-			} else {
-				reasons = append(reasons, fmt.Sprintf("%d recent errors", health.ErrorCount1h))
-			}
+			reasons = append(reasons, i18n.N("reason.recent_errors", health.ErrorCount1h))
 		}
 
 		// Check penalty
 		if health.Penalty >= 1.0 {
-			reasons = append(reasons, "High penalty from errors")
+			reasons = append(reasons, i18n.T("reason.high_penalty"))
 		}
 	}
 
+	var statusText string
+	switch status {
+	case StatusHealthy:
+		statusText = i18n.T("status.healthy")
+	case StatusWarning:
+		statusText = i18n.T("status.warning")
+	case StatusCritical:
+		statusText = i18n.T("status.critical")
+	default:
+		statusText = i18n.T("status.unknown")
+	}
+
 	var result string
 	if len(reasons) > 0 {
-		result = fmt.Sprintf("%s %s - %s", icon, capitalizeFirst(statusStr), strings.Join(reasons, ", "))
+		result = fmt.Sprintf("%s %s - %s", icon, statusText, strings.Join(reasons, ", "))
 	} else {
-		switch status {
-		case StatusHealthy:
-			result = fmt.Sprintf("%s Healthy", icon)
-		case StatusWarning:
-			result = fmt.Sprintf("%s Warning", icon)
-		case StatusCritical:
-			result = fmt.Sprintf("%s Critical", icon)
-		default:
-			result = fmt.Sprintf("%s Unknown", icon)
-		}
+		result = fmt.Sprintf("%s %s", icon, statusText)
 	}
 
 	if !opts.NoColor {
@@ -170,15 +150,15 @@ func FormatRecommendation(provider, profile string, health *ProfileHealth) strin
 	if !health.TokenExpiresAt.IsZero() {
 		ttl := time.Until(health.TokenExpiresAt)
 		if ttl <= 0 {
-			recs = append(recs, fmt.Sprintf("Run \"caam login %s %s\" to re-authenticate", provider, profile))
+			recs = append(recs, i18n.T("suggest.login", provider, profile))
 		} else if ttl < time.Hour {
-			recs = append(recs, fmt.Sprintf("Run \"caam refresh %s %s\" to refresh expiring token", provider, profile))
+			recs = append(recs, i18n.T("suggest.refresh", provider, profile))
 		}
 	}
 
 	// High error count
 	if health.ErrorCount1h >= 3 {
-		recs = append(recs, fmt.Sprintf("Profile %s/%s has frequent errors - consider switching to another profile", provider, profile))
+		recs = append(recs, i18n.T("suggest.switch_profile", provider, profile))
 	}
 
 	return strings.Join(recs, "\n")
@@ -195,6 +175,8 @@ func FormatPlanType(planType string) string {
 		return "Team"
 	case "free":
 		return "Free"
+	case "api":
+		return "API key"
 	default:
 		if planType == "" {
 			return ""
@@ -219,38 +201,5 @@ func colorizeStatus(status HealthStatus, text string) string {
 
 // formatDurationNatural formats a duration in a natural way.
 func formatDurationNatural(d time.Duration) string {
-	if d < time.Minute {
-		return "less than a minute"
-	}
-	if d < time.Hour {
-		mins := int(d.Minutes())
-		if mins == 1 {
-			return "1 minute"
-		}
-		return fmt.Sprintf("%d minutes", mins)
-	}
-	if d < 24*time.Hour {
-		hours := int(d.Hours())
-		if hours == 1 {
-			return "1 hour"
-		}
-		return fmt.Sprintf("%d hours", hours)
-	}
-	days := int(d.Hours() / 24)
-	if days == 1 {
-		return "1 day"
-	}
-	return fmt.Sprintf("%d days", days)
-}
-
-// capitalizeFirst returns the string with its first letter capitalized.
-// This is a replacement for the deprecated strings.Title function.
-// Uses Unicode-aware rune handling for proper UTF-8 support.
-func capitalizeFirst(s string) string {
-	if s == "" {
-		return s
-	}
-	runes := []rune(s)
-	runes[0] = unicode.ToUpper(runes[0])
-	return string(runes)
+	return i18n.Duration(d)
 }
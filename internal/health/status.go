@@ -117,6 +117,11 @@ func CalculateHealth(h *ProfileHealth, config HealthConfig) (HealthStatus, float
 	// Factor 4: Penalty (from errors, with decay)
 	score -= h.Penalty
 
+	// Factor 5: Staleness relative to the sync pool
+	if h.StaleVsPool {
+		score -= 0.3
+	}
+
 	// Convert to status
 	status := StatusHealthy
 	if score < 0 {
@@ -148,5 +153,10 @@ func CalculateHealth(h *ProfileHealth, config HealthConfig) (HealthStatus, float
 		status = StatusCritical
 	}
 
+	// A pool-stale profile works locally but may be invalidated at any time
+	if h.StaleVsPool && status == StatusHealthy {
+		status = StatusWarning
+	}
+
 	return status, score
 }
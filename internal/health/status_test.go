@@ -76,6 +76,15 @@ func TestCalculateHealth(t *testing.T) {
 			},
 			expectedStatus: StatusWarning, // Score reduced below 0.5
 		},
+		{
+			name: "Stale vs pool",
+			health: &ProfileHealth{
+				TokenExpiresAt: now.Add(48 * time.Hour),
+				PlanType:       "enterprise",
+				StaleVsPool:    true,
+			},
+			expectedStatus: StatusWarning,
+		},
 	}
 
 	for _, tt := range tests {
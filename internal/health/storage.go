@@ -14,6 +14,8 @@ import (
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 )
 
 // ProfileHealth holds health metadata for a single profile.
@@ -39,6 +41,14 @@ type ProfileHealth struct {
 
 	// LastChecked is when health was last verified.
 	LastChecked time.Time `json:"last_checked,omitempty"`
+
+	// StaleVsPool indicates the profile hasn't synced with the sync pool
+	// recently, so a peer's refresh may be about to invalidate it.
+	// Computed at read time; not persisted.
+	StaleVsPool bool `json:"-"`
+
+	// LastPoolSync is when the profile last synced with the pool.
+	LastPoolSync time.Time `json:"-"`
 }
 
 // HealthStore holds health data for all profiles.
@@ -213,9 +223,14 @@ func (s *Storage) UpdateProfile(provider, name string, health *ProfileHealth) er
 	}
 
 	key := profileKey(provider, name)
+	before := CalculateStatus(store.Profiles[key])
 	store.Profiles[key] = health
 
-	return s.saveLocked(store)
+	if err := s.saveLocked(store); err != nil {
+		return err
+	}
+	publishStatusChange(provider, name, before, health)
+	return nil
 }
 
 // DeleteProfile removes health data for a profile.
@@ -260,6 +275,7 @@ func (s *Storage) RecordError(provider, name string, errCause error) error {
 
 	key := profileKey(provider, name)
 	health := store.Profiles[key]
+	before := CalculateStatus(health)
 	if health == nil {
 		health = &ProfileHealth{}
 		store.Profiles[key] = health
@@ -272,7 +288,11 @@ func (s *Storage) RecordError(provider, name string, errCause error) error {
 	penaltyAmount := PenaltyForError(errCause)
 	health.AddPenalty(penaltyAmount, time.Now())
 
-	return s.saveLocked(store)
+	if err := s.saveLocked(store); err != nil {
+		return err
+	}
+	publishStatusChange(provider, name, before, health)
+	return nil
 }
 
 // ClearErrors resets the error count for a profile.
@@ -297,11 +317,16 @@ func (s *Storage) ClearErrors(provider, name string) error {
 	if health == nil {
 		return nil // Nothing to clear
 	}
+	before := CalculateStatus(health)
 
 	health.ErrorCount1h = 0
 	health.LastError = time.Time{}
 
-	return s.saveLocked(store)
+	if err := s.saveLocked(store); err != nil {
+		return err
+	}
+	publishStatusChange(provider, name, before, health)
+	return nil
 }
 
 // SetTokenExpiry updates the token expiry time for a profile.
@@ -323,6 +348,7 @@ func (s *Storage) SetTokenExpiry(provider, name string, expiresAt time.Time) err
 
 	key := profileKey(provider, name)
 	health := store.Profiles[key]
+	before := CalculateStatus(health)
 	if health == nil {
 		health = &ProfileHealth{}
 		store.Profiles[key] = health
@@ -331,7 +357,11 @@ func (s *Storage) SetTokenExpiry(provider, name string, expiresAt time.Time) err
 	health.TokenExpiresAt = expiresAt
 	health.LastChecked = time.Now()
 
-	return s.saveLocked(store)
+	if err := s.saveLocked(store); err != nil {
+		return err
+	}
+	publishStatusChange(provider, name, before, health)
+	return nil
 }
 
 // SetPlanType updates the plan type for a profile.
@@ -363,6 +393,22 @@ func (s *Storage) SetPlanType(provider, name, planType string) error {
 	return s.saveLocked(store)
 }
 
+// publishStatusChange publishes a HealthChanged event when a write moved a
+// profile to a different status.
+func publishStatusChange(provider, name string, before HealthStatus, after *ProfileHealth) {
+	now := CalculateStatus(after)
+	if now == before {
+		return
+	}
+	events.Publish(events.Event{
+		Type:     events.HealthChanged,
+		Provider: provider,
+		Profile:  name,
+		Source:   "health",
+		Data:     map[string]any{"from": before.String(), "to": now.String()},
+	})
+}
+
 // DecayPenalties applies penalty decay to all profiles.
 // Call this periodically (e.g., every 5 minutes).
 func (s *Storage) DecayPenalties() error {
@@ -0,0 +1,211 @@
+// Package hooks runs user executables on caam lifecycle events. A hook is
+// an executable in the hooks directory named after its event (post-activate,
+// pre-cooldown, ...). It reads the event as JSON on stdin.
+//
+// A pre- hook runs before the action and vetoes it by exiting non-zero. A
+// post- hook runs after the action; its failure is logged and otherwise
+// ignored.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+)
+
+// Name names a hook, and the executable that implements it.
+type Name string
+
+const (
+	PreActivate  Name = "pre-activate"
+	PostActivate Name = "post-activate"
+	PreBackup    Name = "pre-backup"
+	PostBackup   Name = "post-backup"
+	PreCooldown  Name = "pre-cooldown"
+	PostCooldown Name = "post-cooldown"
+	PostRefresh  Name = "post-refresh"
+	PostSync     Name = "post-sync"
+
+	// AllBlocked runs when a cooldown leaves every profile of a provider in
+	// cooldown or revoked.
+	AllBlocked Name = "all-blocked"
+)
+
+// Names lists every hook, in the order they are documented.
+func Names() []Name {
+	return []Name{PreActivate, PostActivate, PreBackup, PostBackup, PreCooldown, PostCooldown, PostRefresh, PostSync, AllBlocked}
+}
+
+// IsPre reports whether the hook runs before its action and can veto it.
+func (n Name) IsPre() bool {
+	return strings.HasPrefix(string(n), "pre-")
+}
+
+// ForEvent returns the post- hook run for a bus event.
+func ForEvent(t events.Type) (Name, bool) {
+	switch t {
+	case events.ProfileActivated:
+		return PostActivate, true
+	case events.CooldownSet:
+		return PostCooldown, true
+	case events.TokenRefreshed:
+		return PostRefresh, true
+	case events.SyncCompleted:
+		return PostSync, true
+	}
+	return "", false
+}
+
+// EnvVar is set to the hook's name in the environment of a running hook.
+// caam commands started by a hook see it and run no hooks themselves, so a
+// post-activate hook that activates another profile doesn't loop.
+const EnvVar = "CAAM_HOOK"
+
+// DisableEnvVar turns hooks off when set to a non-empty value.
+const DisableEnvVar = "CAAM_NO_HOOKS"
+
+// DefaultTimeout is how long a hook may run before it is killed.
+const DefaultTimeout = 30 * time.Second
+
+// Payload is what a hook reads on stdin.
+type Payload struct {
+	Hook     Name           `json:"hook"`
+	Time     time.Time      `json:"time"`
+	Provider string         `json:"provider,omitempty"`
+	Profile  string         `json:"profile,omitempty"`
+	Source   string         `json:"source,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+}
+
+// PayloadFor builds the payload of the post- hook for a bus event.
+func PayloadFor(name Name, ev events.Event) Payload {
+	return Payload{
+		Hook:     name,
+		Time:     ev.Time,
+		Provider: ev.Provider,
+		Profile:  ev.Profile,
+		Source:   ev.Source,
+		Data:     ev.Data,
+	}
+}
+
+// Error is a hook that failed or, for a pre- hook, vetoed its action.
+type Error struct {
+	Hook Name
+	Err  error
+	// Output is what the hook wrote to stderr, or stdout if stderr was
+	// empty, trimmed.
+	Output string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s hook: %v", e.Hook, e.Err)
+	if e.Output != "" {
+		msg += ": " + e.Output
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Dir returns the hooks directory.
+func Dir() string {
+	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+		return filepath.Join(xdgConfig, "caam", "hooks")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".config", "caam", "hooks")
+	}
+	return filepath.Join(homeDir, ".config", "caam", "hooks")
+}
+
+// Disabled reports whether hooks are off for this process, because
+// DisableEnvVar is set or the process was started by a hook.
+func Disabled() bool {
+	return os.Getenv(DisableEnvVar) != "" || os.Getenv(EnvVar) != ""
+}
+
+// Path returns the executable implementing name, or "" if none is
+// installed. On Windows, name.exe, name.cmd and name.bat are also found.
+func Path(name Name) string {
+	candidates := []string{string(name)}
+	if runtime.GOOS == "windows" {
+		candidates = append(candidates, string(name)+".exe", string(name)+".cmd", string(name)+".bat")
+	}
+	for _, c := range candidates {
+		path := filepath.Join(Dir(), c)
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		return path
+	}
+	return ""
+}
+
+// Run runs hook name with p as JSON on stdin, killing it after timeout (if
+// positive). It returns nil if the hook isn't installed or hooks are
+// disabled, and an *Error if the hook exits non-zero or times out.
+func Run(ctx context.Context, name Name, p Payload, timeout time.Duration) error {
+	if Disabled() {
+		return nil
+	}
+	path := Path(name)
+	if path == "" {
+		return nil
+	}
+
+	p.Hook = name
+	if p.Time.IsZero() {
+		p.Time = time.Now().UTC()
+	}
+	input, err := json.Marshal(p)
+	if err != nil {
+		return &Error{Hook: name, Err: fmt.Errorf("marshal payload: %w", err)}
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Dir = Dir()
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(),
+		EnvVar+"="+string(name),
+		"CAAM_HOOK_PROVIDER="+p.Provider,
+		"CAAM_HOOK_PROFILE="+p.Profile,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait on children that outlive a killed hook.
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		output := strings.TrimSpace(stderr.String())
+		if output == "" {
+			output = strings.TrimSpace(stdout.String())
+		}
+		return &Error{Hook: name, Err: err, Output: output}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+)
+
+func writeHook(t *testing.T, name Name, body string) {
+	t.Helper()
+	if err := os.MkdirAll(Dir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(Dir(), string(name))
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test hooks are shell scripts")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(EnvVar, "")
+	t.Setenv(DisableEnvVar, "")
+	ctx := context.Background()
+
+	// Not installed.
+	if err := Run(ctx, PostActivate, Payload{}, time.Second); err != nil {
+		t.Fatalf("Run() without a hook = %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "payload.json")
+	writeHook(t, PostActivate, `cat > `+out+`; echo "$CAAM_HOOK $CAAM_HOOK_PROFILE" >> `+out+`.env`)
+	ev := events.Event{Type: events.ProfileActivated, Provider: "claude", Profile: "work", Source: "activate", Time: time.Now().UTC()}
+	name, ok := ForEvent(ev.Type)
+	if !ok || name != PostActivate {
+		t.Fatalf("ForEvent() = %q, %v", name, ok)
+	}
+	if err := Run(ctx, name, PayloadFor(name, ev), time.Second); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Payload
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("payload %q: %v", data, err)
+	}
+	if got.Hook != PostActivate || got.Provider != "claude" || got.Profile != "work" || got.Source != "activate" {
+		t.Errorf("payload = %+v", got)
+	}
+	if env, _ := os.ReadFile(out + ".env"); strings.TrimSpace(string(env)) != "post-activate work" {
+		t.Errorf("hook environment = %q", env)
+	}
+
+	// A pre- hook vetoes by exiting non-zero; its output is the reason.
+	writeHook(t, PreCooldown, `echo "not during the demo" >&2; exit 1`)
+	err = Run(ctx, PreCooldown, Payload{Provider: "claude"}, time.Second)
+	var hookErr *Error
+	if !errors.As(err, &hookErr) || hookErr.Output != "not during the demo" || !PreCooldown.IsPre() {
+		t.Fatalf("Run() veto = %v", err)
+	}
+
+	writeHook(t, PostSync, `sleep 5`)
+	if err := Run(ctx, PostSync, Payload{}, 100*time.Millisecond); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Run() slow hook = %v, want timeout", err)
+	}
+
+	// Hooks don't run inside a hook, or when disabled.
+	t.Setenv(EnvVar, "post-activate")
+	if err := Run(ctx, PreCooldown, Payload{}, time.Second); err != nil {
+		t.Errorf("Run() inside a hook = %v, want nil", err)
+	}
+	t.Setenv(EnvVar, "")
+	t.Setenv(DisableEnvVar, "1")
+	if err := Run(ctx, PreCooldown, Payload{}, time.Second); err != nil {
+		t.Errorf("Run() with hooks disabled = %v, want nil", err)
+	}
+}
+
+func TestPathSkipsNonExecutables(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bits are a Unix concept")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := os.MkdirAll(Dir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(Dir(), "post-backup"), []byte("#!/bin/sh\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := Path(PostBackup); got != "" {
+		t.Errorf("Path() = %q for a non-executable file", got)
+	}
+}
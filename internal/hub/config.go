@@ -0,0 +1,178 @@
+// Package hub syncs vault profiles through a cloud storage bucket (S3, GCS,
+// or Backblaze B2) instead of peer to peer over SSH. Every machine talks only
+// to the bucket, so machines behind NAT never need to reach each other.
+//
+// Profiles are encrypted client-side with the bundle crypto before upload.
+// Each push writes a new immutable version; pulls take the freshest one.
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+// DefaultKeepVersions is how many versions of each profile are kept.
+const DefaultKeepVersions = 5
+
+// Config is the hub configuration, stored as hub.json in the sync data
+// directory.
+type Config struct {
+	// URL is the bucket and key prefix: s3://bucket/prefix, gs://bucket/prefix,
+	// or b2://bucket/prefix.
+	URL string `json:"url"`
+
+	// Endpoint overrides the storage API URL (required for B2, useful for
+	// MinIO and other S3-compatible services).
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region is the bucket region. Default: AWS_REGION, or us-east-1.
+	Region string `json:"region,omitempty"`
+
+	// KeepVersions is how many versions of each profile to keep.
+	// Default: 5
+	KeepVersions int `json:"keep_versions,omitempty"`
+}
+
+// ConfigPath returns the hub config file path.
+func ConfigPath() string {
+	return filepath.Join(sync.SyncDataDir(), "hub.json")
+}
+
+// LoadConfig reads the hub config. It returns (nil, nil) when no hub is
+// configured.
+func LoadConfig() (*Config, error) {
+	data, err := os.ReadFile(ConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ConfigPath(), err)
+	}
+	return &c, nil
+}
+
+// Save writes the hub config.
+func (c *Config) Save() error {
+	if err := os.MkdirAll(filepath.Dir(ConfigPath()), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ConfigPath(), append(data, '\n'), 0600)
+}
+
+// Keep returns the number of versions to keep per profile.
+func (c *Config) Keep() int {
+	if c.KeepVersions > 0 {
+		return c.KeepVersions
+	}
+	return DefaultKeepVersions
+}
+
+// Location is a parsed hub URL.
+type Location struct {
+	Scheme string
+	Bucket string
+	Prefix string
+}
+
+// ParseURL parses s3://, gs:// and b2:// hub URLs.
+func ParseURL(raw string) (Location, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid hub URL: %w", err)
+	}
+	switch u.Scheme {
+	case "s3", "gs", "b2":
+	default:
+		return Location{}, fmt.Errorf("hub URL must start with s3://, gs://, or b2:// (got %q)", raw)
+	}
+	if u.Host == "" {
+		return Location{}, fmt.Errorf("hub URL %q has no bucket", raw)
+	}
+	return Location{Scheme: u.Scheme, Bucket: u.Host, Prefix: strings.Trim(u.Path, "/")}, nil
+}
+
+// Validate checks the URL and endpoint.
+func (c *Config) Validate() error {
+	loc, err := ParseURL(c.URL)
+	if err != nil {
+		return err
+	}
+	if loc.Scheme == "b2" && c.Endpoint == "" {
+		return fmt.Errorf("b2 hubs need --endpoint, e.g. https://s3.us-west-004.backblazeb2.com")
+	}
+	if c.Endpoint != "" {
+		u, err := url.Parse(c.Endpoint)
+		if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			return fmt.Errorf("invalid endpoint %q", c.Endpoint)
+		}
+	}
+	return nil
+}
+
+// Client returns an S3-compatible client for the hub's bucket.
+func (c *Config) Client(creds Credentials) (*S3Client, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	loc, _ := ParseURL(c.URL)
+
+	region := c.Region
+	endpoint := c.Endpoint
+	pathStyle := endpoint != "" || strings.Contains(loc.Bucket, ".")
+	switch loc.Scheme {
+	case "gs":
+		if endpoint == "" {
+			endpoint = "https://storage.googleapis.com"
+		}
+		if region == "" {
+			region = "auto"
+		}
+		pathStyle = true
+	case "b2":
+		if region == "" {
+			// s3.<region>.backblazeb2.com
+			u, _ := url.Parse(endpoint)
+			if parts := strings.Split(u.Hostname(), "."); len(parts) > 2 && parts[0] == "s3" {
+				region = parts[1]
+			}
+		}
+	}
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	return &S3Client{
+		Endpoint:  u,
+		Bucket:    loc.Bucket,
+		Region:    region,
+		Creds:     creds,
+		PathStyle: pathStyle,
+	}, nil
+}
@@ -0,0 +1,554 @@
+package hub
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/bundle"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+// Hub sync actions.
+const (
+	ActionPush = "push"
+	ActionPull = "pull"
+	ActionSkip = "skip"
+)
+
+// Hub syncs the local vault with a bucket.
+type Hub struct {
+	Store ObjectStore
+
+	// Prefix is the key prefix inside the bucket.
+	Prefix string
+
+	// Passphrase encrypts every profile version.
+	Passphrase string
+
+	// VaultPath is the local vault directory.
+	VaultPath string
+
+	// MachineID and MachineName identify this machine in uploaded versions.
+	MachineID   string
+	MachineName string
+
+	// KeepVersions is how many versions of each profile to keep.
+	KeepVersions int
+
+	// CachePath stores decrypted version headers, so unchanged versions
+	// aren't downloaded and decrypted on every sync. Versions are immutable,
+	// so a cached header never goes stale. Empty disables the cache.
+	CachePath string
+
+	now func() time.Time
+}
+
+// Open returns a Hub for the configured bucket, using credentials from the
+// environment, the sync passphrase, and this machine's sync identity.
+func Open(c *Config, vaultPath string) (*Hub, error) {
+	creds, err := CredentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	client, err := c.Client(creds)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := sync.SyncPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	identity, err := sync.GetOrCreateLocalIdentity()
+	if err != nil {
+		return nil, fmt.Errorf("load identity: %w", err)
+	}
+	loc, _ := ParseURL(c.URL)
+	return &Hub{
+		Store:        client,
+		Prefix:       loc.Prefix,
+		Passphrase:   passphrase,
+		VaultPath:    vaultPath,
+		MachineID:    identity.ID,
+		MachineName:  identity.Hostname,
+		KeepVersions: c.Keep(),
+		CachePath:    filepath.Join(sync.SyncDataDir(), "hub-cache.json"),
+	}, nil
+}
+
+// Result is the outcome of syncing one profile with the hub.
+type Result struct {
+	Provider string `json:"provider"`
+	Profile  string `json:"profile"`
+	Action   string `json:"action"`
+	// Version is the key of the version pushed or pulled.
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Version describes one uploaded profile version.
+type Version struct {
+	Key         string    `json:"key"`
+	Provider    string    `json:"provider"`
+	Profile     string    `json:"profile"`
+	Machine     string    `json:"machine"`
+	MachineName string    `json:"machine_name"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"`
+	ModifiedAt  time.Time `json:"modified_at"`
+}
+
+// RemoteProfile is a profile stored in the hub.
+type RemoteProfile struct {
+	Latest   Version `json:"latest"`
+	Versions int     `json:"versions"`
+}
+
+// payload is the plaintext of a version object.
+type payload struct {
+	Version
+	Files map[string][]byte `json:"files"`
+}
+
+// envelope is the stored form of a version object.
+type envelope struct {
+	Encryption *bundle.EncryptionMetadata `json:"encryption"`
+	Payload    []byte                     `json:"payload"`
+}
+
+// versionExt marks version objects.
+const versionExt = ".caam"
+
+func (h *Hub) clock() time.Time {
+	if h.now != nil {
+		return h.now()
+	}
+	return time.Now()
+}
+
+func (h *Hub) profilesPrefix() string {
+	if h.Prefix == "" {
+		return "profiles/"
+	}
+	return strings.Trim(h.Prefix, "/") + "/profiles/"
+}
+
+// profileID names a profile in the bucket without revealing its name (often
+// an email address).
+func profileID(provider, profile string) string {
+	sum := sha256.Sum256([]byte("caam-hub/" + provider + "/" + profile))
+	return hex.EncodeToString(sum[:16])
+}
+
+// versionKey returns a new, lexically increasing key for a profile version.
+func (h *Hub) versionKey(provider, profile string) string {
+	machine := h.MachineID
+	if len(machine) > 8 {
+		machine = machine[:8]
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return h.profilesPrefix() + profileID(provider, profile) + "/" +
+		h.clock().UTC().Format("20060102T150405.000000000Z") + "-" + machine + "-" + hex.EncodeToString(suffix) + versionExt
+}
+
+// listVersions groups version keys by profile ID, oldest first.
+func (h *Hub) listVersions(ctx context.Context) (map[string][]string, error) {
+	objects, err := h.Store.List(ctx, h.profilesPrefix())
+	if err != nil {
+		return nil, err
+	}
+	byProfile := make(map[string][]string)
+	for _, obj := range objects {
+		rest := strings.TrimPrefix(obj.Key, h.profilesPrefix())
+		id, _, ok := strings.Cut(rest, "/")
+		if !ok || !strings.HasSuffix(obj.Key, versionExt) {
+			continue
+		}
+		byProfile[id] = append(byProfile[id], obj.Key)
+	}
+	for id := range byProfile {
+		sort.Strings(byProfile[id])
+	}
+	return byProfile, nil
+}
+
+// Remote returns the profiles stored in the hub, keyed by provider/profile.
+func (h *Hub) Remote(ctx context.Context) (map[string]RemoteProfile, error) {
+	remote, _, err := h.remote(ctx)
+	return remote, err
+}
+
+// remote lists the hub's profiles and also returns any payloads it had to
+// download, so a pull in the same sync doesn't fetch them again.
+func (h *Hub) remote(ctx context.Context) (map[string]RemoteProfile, map[string]*payload, error) {
+	byProfile, err := h.listVersions(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cache := h.loadCache()
+	fetched := make(map[string]*payload)
+	remote := make(map[string]RemoteProfile)
+	live := make(map[string]Version)
+	for _, keys := range byProfile {
+		latest := keys[len(keys)-1]
+		v, ok := cache[latest]
+		if !ok {
+			p, err := h.fetch(ctx, latest)
+			if err != nil {
+				return nil, nil, err
+			}
+			fetched[latest] = p
+			v = p.Version
+		}
+		live[latest] = v
+		remote[v.Provider+"/"+v.Profile] = RemoteProfile{Latest: v, Versions: len(keys)}
+	}
+	h.saveCache(live)
+	return remote, fetched, nil
+}
+
+// fetch downloads and decrypts a version.
+func (h *Hub) fetch(ctx context.Context, key string) (*payload, error) {
+	data, err := h.Store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", key, err)
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", key, err)
+	}
+	plain, err := bundle.DecryptBundle(env.Payload, env.Encryption, h.Passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", key, err)
+	}
+	defer bundle.SecureWipe(plain)
+	var p payload
+	if err := json.Unmarshal(plain, &p); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", key, err)
+	}
+	p.Key = key
+	return &p, nil
+}
+
+// Sync pushes local profiles that are fresher than the hub's copy and pulls
+// those that are staler, comparing token expiry and then modification time.
+func (h *Hub) Sync(ctx context.Context) ([]Result, error) {
+	if h.Passphrase == "" {
+		return nil, errors.New("hub passphrase is required")
+	}
+
+	remote, fetched, err := h.remote(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list hub: %w", err)
+	}
+	local, err := h.localProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("list local profiles: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for name := range remote {
+		names[name] = true
+	}
+	for name := range local {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var results []Result
+	for _, name := range sorted {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		provider, profile, _ := strings.Cut(name, "/")
+		result := Result{Provider: provider, Profile: profile, Action: ActionSkip}
+
+		lf, inLocal := local[name]
+		rp, inRemote := remote[name]
+		var rf *sync.TokenFreshness
+		if inRemote {
+			rf = &sync.TokenFreshness{ExpiresAt: rp.Latest.ExpiresAt, ModifiedAt: rp.Latest.ModifiedAt}
+		}
+
+		switch {
+		case inLocal && (!inRemote || sync.CompareFreshness(lf, rf)):
+			result.Action = ActionPush
+			result.Version, err = h.push(ctx, provider, profile, lf)
+		case inRemote && (!inLocal || sync.CompareFreshness(rf, lf)):
+			result.Action = ActionPull
+			result.Version = rp.Latest.Key
+			err = h.pull(ctx, rp.Latest.Key, fetched[rp.Latest.Key])
+		default:
+			err = nil
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	var pushed, pulled, failed int
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			failed++
+		case r.Action == ActionPush:
+			pushed++
+		case r.Action == ActionPull:
+			pulled++
+		}
+	}
+	events.Publish(events.Event{
+		Type:   events.SyncCompleted,
+		Source: "sync",
+		Data: map[string]any{
+			"machine": "hub",
+			"pushed":  pushed,
+			"pulled":  pulled,
+			"failed":  failed,
+		},
+	})
+
+	return results, nil
+}
+
+// localProfiles returns the freshness of every profile in the vault.
+func (h *Hub) localProfiles() (map[string]*sync.TokenFreshness, error) {
+	out := make(map[string]*sync.TokenFreshness)
+	providers, err := os.ReadDir(h.VaultPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return out, nil
+		}
+		return nil, err
+	}
+	for _, p := range providers {
+		if !p.IsDir() || sync.GetExtractor(p.Name()) == nil {
+			continue
+		}
+		profiles, err := os.ReadDir(filepath.Join(h.VaultPath, p.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, prof := range profiles {
+			if !prof.IsDir() {
+				continue
+			}
+			files, err := profileFiles(filepath.Join(h.VaultPath, p.Name(), prof.Name()))
+			if err != nil || len(files) == 0 {
+				continue
+			}
+			var paths []string
+			for name := range files {
+				paths = append(paths, filepath.Join(h.VaultPath, p.Name(), prof.Name(), name))
+			}
+			fresh, err := sync.ExtractFreshnessFromFiles(p.Name(), prof.Name(), paths)
+			if err != nil {
+				continue
+			}
+			out[p.Name()+"/"+prof.Name()] = fresh
+		}
+	}
+	return out, nil
+}
+
+// profileFiles reads the regular files of a profile directory.
+func profileFiles(dir string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[e.Name()] = data
+	}
+	return files, nil
+}
+
+// push uploads a new version of a local profile and prunes old versions.
+func (h *Hub) push(ctx context.Context, provider, profile string, fresh *sync.TokenFreshness) (string, error) {
+	files, err := profileFiles(filepath.Join(h.VaultPath, provider, profile))
+	if err != nil {
+		return "", fmt.Errorf("read profile: %w", err)
+	}
+
+	key := h.versionKey(provider, profile)
+	p := payload{
+		Version: Version{
+			Key:         key,
+			Provider:    provider,
+			Profile:     profile,
+			Machine:     h.MachineID,
+			MachineName: h.MachineName,
+			UploadedAt:  h.clock().UTC(),
+			ExpiresAt:   fresh.ExpiresAt,
+			ModifiedAt:  fresh.ModifiedAt,
+		},
+		Files: files,
+	}
+	plain, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	defer bundle.SecureWipe(plain)
+	ciphertext, meta, err := bundle.EncryptBundle(plain, h.Passphrase)
+	if err != nil {
+		return "", fmt.Errorf("encrypt: %w", err)
+	}
+	data, err := json.Marshal(envelope{Encryption: meta, Payload: ciphertext})
+	if err != nil {
+		return "", err
+	}
+	if err := h.Store.Put(ctx, key, data); err != nil {
+		return "", fmt.Errorf("upload: %w", err)
+	}
+	h.addToCache(p.Version)
+
+	h.prune(ctx, provider, profile)
+	return key, nil
+}
+
+// prune deletes all but the newest KeepVersions versions of a profile.
+// Failures are ignored; old versions are just retried on the next push.
+func (h *Hub) prune(ctx context.Context, provider, profile string) {
+	keep := h.KeepVersions
+	if keep <= 0 {
+		keep = DefaultKeepVersions
+	}
+	objects, err := h.Store.List(ctx, h.profilesPrefix()+profileID(provider, profile)+"/")
+	if err != nil {
+		return
+	}
+	var keys []string
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, versionExt) {
+			keys = append(keys, obj.Key)
+		}
+	}
+	sort.Strings(keys)
+	for len(keys) > keep {
+		_ = h.Store.Delete(ctx, keys[0])
+		keys = keys[1:]
+	}
+}
+
+// pull writes a version into the local vault.
+func (h *Hub) pull(ctx context.Context, key string, p *payload) error {
+	if p == nil {
+		var err error
+		if p, err = h.fetch(ctx, key); err != nil {
+			return err
+		}
+	}
+	dir := filepath.Join(h.VaultPath, p.Provider, p.Profile)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("create profile directory: %w", err)
+	}
+	for name, data := range p.Files {
+		if name != filepath.Base(name) || name == "." || name == ".." {
+			return fmt.Errorf("refusing unsafe file name %q in %s", name, key)
+		}
+		path := filepath.Join(dir, name)
+		if err := atomicWriteFile(path, data, 0600); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+		// Keep the uploader's modification time so the pulled copy doesn't
+		// look fresher than the hub's and get pushed straight back.
+		if !p.ModifiedAt.IsZero() {
+			_ = os.Chtimes(path, p.ModifiedAt, p.ModifiedAt)
+		}
+	}
+	return nil
+}
+
+// atomicWriteFile writes data via a temp file and rename.
+func atomicWriteFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".caam_hub_*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+func (h *Hub) loadCache() map[string]Version {
+	cache := make(map[string]Version)
+	if h.CachePath == "" {
+		return cache
+	}
+	data, err := os.ReadFile(h.CachePath)
+	if err != nil {
+		return cache
+	}
+	_ = json.Unmarshal(data, &cache)
+	return cache
+}
+
+// saveCache replaces the cache with the versions still in the hub.
+func (h *Hub) saveCache(cache map[string]Version) {
+	if h.CachePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(h.CachePath), 0700); err != nil {
+		return
+	}
+	_ = atomicWriteFile(h.CachePath, data, 0600)
+}
+
+func (h *Hub) addToCache(v Version) {
+	if h.CachePath == "" {
+		return
+	}
+	cache := h.loadCache()
+	cache[v.Key] = v
+	h.saveCache(cache)
+}
@@ -0,0 +1,295 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory ObjectStore.
+type memStore struct {
+	mu      gosync.Mutex
+	objects map[string][]byte
+	gets    int
+}
+
+func newMemStore() *memStore {
+	return &memStore{objects: make(map[string][]byte)}
+}
+
+func (m *memStore) Put(_ context.Context, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *memStore) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gets++
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (m *memStore) List(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []ObjectInfo
+	for key, data := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			out = append(out, ObjectInfo{Key: key, Size: int64(len(data))})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+func (m *memStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+func writeClaudeProfile(t *testing.T, vault, profile string, expires time.Time) []byte {
+	t.Helper()
+	dir := filepath.Join(vault, "claude", profile)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	creds := []byte(fmt.Sprintf(`{"claudeAiOauth":{"accessToken":"sk-secret","expiresAt":%d}}`, expires.UnixMilli()))
+	if err := os.WriteFile(filepath.Join(dir, ".credentials.json"), creds, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return creds
+}
+
+func newTestHub(store ObjectStore, vault, machine string) *Hub {
+	return &Hub{
+		Store:        store,
+		Prefix:       "team",
+		Passphrase:   "correct horse",
+		VaultPath:    vault,
+		MachineID:    machine,
+		MachineName:  machine,
+		KeepVersions: 2,
+	}
+}
+
+func TestHubPushPull(t *testing.T) {
+	store := newMemStore()
+	ctx := context.Background()
+
+	vaultA := t.TempDir()
+	creds := writeClaudeProfile(t, vaultA, "work", time.Now().Add(time.Hour))
+	hubA := newTestHub(store, vaultA, "machine-a")
+
+	results, err := hubA.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync(A): %v", err)
+	}
+	if len(results) != 1 || results[0].Action != ActionPush || results[0].Error != "" {
+		t.Fatalf("A results = %+v", results)
+	}
+
+	// Only ciphertext is stored, and keys don't reveal profile names.
+	for key, data := range store.objects {
+		if !strings.HasPrefix(key, "team/profiles/") || strings.Contains(key, "work") {
+			t.Errorf("unexpected key %q", key)
+		}
+		if bytes.Contains(data, []byte("sk-secret")) {
+			t.Errorf("%s stored in plaintext", key)
+		}
+	}
+
+	vaultB := t.TempDir()
+	hubB := newTestHub(store, vaultB, "machine-b")
+	results, err = hubB.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync(B): %v", err)
+	}
+	if len(results) != 1 || results[0].Action != ActionPull || results[0].Error != "" {
+		t.Fatalf("B results = %+v", results)
+	}
+	got, err := os.ReadFile(filepath.Join(vaultB, "claude", "work", ".credentials.json"))
+	if err != nil || !bytes.Equal(got, creds) {
+		t.Fatalf("pulled credentials = %q, %v", got, err)
+	}
+
+	// Both sides now agree, so neither pushes again.
+	for name, h := range map[string]*Hub{"A": hubA, "B": hubB} {
+		results, err := h.Sync(ctx)
+		if err != nil {
+			t.Fatalf("resync %s: %v", name, err)
+		}
+		if len(results) != 1 || results[0].Action != ActionSkip {
+			t.Errorf("resync %s = %+v, want skip", name, results)
+		}
+	}
+}
+
+func TestHubFreshestWins(t *testing.T) {
+	store := newMemStore()
+	ctx := context.Background()
+
+	vaultA := t.TempDir()
+	writeClaudeProfile(t, vaultA, "work", time.Now().Add(time.Hour))
+	hubA := newTestHub(store, vaultA, "machine-a")
+	if _, err := hubA.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// B refreshed the token more recently, so its copy replaces A's.
+	vaultB := t.TempDir()
+	fresher := writeClaudeProfile(t, vaultB, "work", time.Now().Add(8*time.Hour))
+	hubB := newTestHub(store, vaultB, "machine-b")
+	results, err := hubB.Sync(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Action != ActionPush {
+		t.Fatalf("B results = %+v, want push", results)
+	}
+
+	results, err = hubA.Sync(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Action != ActionPull {
+		t.Fatalf("A results = %+v, want pull", results)
+	}
+	got, _ := os.ReadFile(filepath.Join(vaultA, "claude", "work", ".credentials.json"))
+	if !bytes.Equal(got, fresher) {
+		t.Errorf("A has %q, want B's fresher token", got)
+	}
+}
+
+func TestHubPrunesVersions(t *testing.T) {
+	store := newMemStore()
+	ctx := context.Background()
+	vault := t.TempDir()
+	h := newTestHub(store, vault, "machine-a")
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		now := base.Add(time.Duration(i) * time.Minute)
+		h.now = func() time.Time { return now }
+		writeClaudeProfile(t, vault, "work", now.Add(time.Hour))
+		if _, err := h.Sync(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	remote, err := h.Remote(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp, ok := remote["claude/work"]
+	if !ok {
+		t.Fatalf("Remote = %+v", remote)
+	}
+	if rp.Versions != 2 {
+		t.Errorf("Versions = %d, want 2", rp.Versions)
+	}
+	if want := base.Add(3 * time.Minute); !rp.Latest.UploadedAt.Equal(want) {
+		t.Errorf("latest UploadedAt = %v, want %v", rp.Latest.UploadedAt, want)
+	}
+}
+
+func TestHubWrongPassphrase(t *testing.T) {
+	store := newMemStore()
+	ctx := context.Background()
+	vault := t.TempDir()
+	writeClaudeProfile(t, vault, "work", time.Now().Add(time.Hour))
+	if _, err := newTestHub(store, vault, "machine-a").Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	other := newTestHub(store, t.TempDir(), "machine-b")
+	other.Passphrase = "wrong"
+	if _, err := other.Sync(ctx); err == nil {
+		t.Fatal("expected decrypt failure with the wrong passphrase")
+	}
+}
+
+func TestHubHeaderCache(t *testing.T) {
+	store := newMemStore()
+	ctx := context.Background()
+	vault := t.TempDir()
+	writeClaudeProfile(t, vault, "work", time.Now().Add(time.Hour))
+	h := newTestHub(store, vault, "machine-a")
+	h.CachePath = filepath.Join(t.TempDir(), "hub-cache.json")
+	if _, err := h.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	store.gets = 0
+	if _, err := h.Remote(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if store.gets != 0 {
+		t.Errorf("Remote downloaded %d objects, want 0 with a warm cache", store.gets)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	for _, tt := range []struct {
+		url  string
+		want Location
+		ok   bool
+	}{
+		{"s3://bucket/caam/team", Location{"s3", "bucket", "caam/team"}, true},
+		{"gs://bucket", Location{"gs", "bucket", ""}, true},
+		{"b2://bucket/x/", Location{"b2", "bucket", "x"}, true},
+		{"https://bucket/x", Location{}, false},
+		{"s3:///x", Location{}, false},
+	} {
+		got, err := ParseURL(tt.url)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseURL(%q) = %+v, %v", tt.url, got, err)
+		}
+	}
+}
+
+func TestConfigClient(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+
+	c, err := (&Config{URL: "s3://bucket/p"}).Client(Credentials{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoint.Host != "s3.us-east-1.amazonaws.com" || c.PathStyle || c.Region != "us-east-1" {
+		t.Errorf("s3 client = %+v", c)
+	}
+
+	c, err = (&Config{URL: "gs://bucket"}).Client(Credentials{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoint.Host != "storage.googleapis.com" || !c.PathStyle {
+		t.Errorf("gs client = %+v", c)
+	}
+
+	if _, err := (&Config{URL: "b2://bucket"}).Client(Credentials{}); err == nil {
+		t.Error("expected b2 without an endpoint to fail")
+	}
+	c, err = (&Config{URL: "b2://bucket", Endpoint: "https://s3.us-west-004.backblazeb2.com"}).Client(Credentials{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Region != "us-west-004" {
+		t.Errorf("b2 region = %q", c.Region)
+	}
+}
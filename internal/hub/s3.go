@@ -0,0 +1,321 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by ObjectStore.Get for a missing key.
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo describes one stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ObjectStore is the subset of an object storage API the hub needs.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Credentials are S3-style HMAC credentials. GCS and B2 issue the same kind
+// of key pair for their S3-compatible APIs.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// CredentialsFromEnv reads the standard AWS_* variables.
+func CredentialsFromEnv() (Credentials, error) {
+	c := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return c, fmt.Errorf("hub credentials not set: export AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY (HMAC keys for GCS and B2)")
+	}
+	return c, nil
+}
+
+// S3Client talks to an S3-compatible API with Signature Version 4.
+type S3Client struct {
+	// Endpoint is the service URL, e.g. https://s3.us-east-1.amazonaws.com.
+	Endpoint *url.URL
+	Bucket   string
+	Region   string
+	Creds    Credentials
+
+	// PathStyle addresses the bucket as /bucket/key instead of as a
+	// bucket.host subdomain. Custom endpoints use path style.
+	PathStyle bool
+
+	HTTP *http.Client
+
+	now func() time.Time
+}
+
+// Put uploads an object.
+func (c *S3Client) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := c.do(ctx, http.MethodPut, key, nil, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("PUT", key, resp)
+	}
+	return nil
+}
+
+// Get downloads an object.
+func (c *S3Client) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	}
+	return nil, s3Error("GET", key, resp)
+}
+
+// Delete removes an object. Deleting a missing object is not an error.
+func (c *S3Client) Delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3Error("DELETE", key, resp)
+	}
+	return nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string `xml:"Key"`
+		Size         int64  `xml:"Size"`
+		LastModified string `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns every object whose key starts with prefix.
+func (c *S3Client) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var out []ObjectInfo
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := s3Error("LIST", prefix, resp)
+			resp.Body.Close()
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parse bucket listing: %w", err)
+		}
+		for _, obj := range result.Contents {
+			info := ObjectInfo{Key: obj.Key, Size: obj.Size}
+			info.LastModified, _ = time.Parse(time.RFC3339, obj.LastModified)
+			out = append(out, info)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return out, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (c *S3Client) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	u := *c.Endpoint
+	if c.PathStyle {
+		u.Path = "/" + c.Bucket
+		if key != "" {
+			u.Path += "/" + key
+		}
+	} else {
+		u.Host = c.Bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	// SigV4 signs the strictly escaped path, so send exactly that.
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	c.sign(req, body)
+
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, u.Redacted(), err)
+	}
+	return resp, nil
+}
+
+// sign adds SigV4 headers to req.
+func (c *S3Client) sign(req *http.Request, body []byte) {
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if c.Creds.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", c.Creds.SessionToken)
+	}
+
+	canonical, signedHeaders := canonicalRequest(req, payloadHash)
+	scope := date + "/" + c.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+c.Creds.SecretAccessKey), date)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.Creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalRequest builds the SigV4 canonical request. It signs the host,
+// content-type, range, and every x-amz-* header.
+func canonicalRequest(req *http.Request, payloadHash string) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, name := range names {
+		canonHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n"), signedHeaders
+}
+
+// canonicalQuery encodes query parameters sorted by key, with spaces as %20
+// as SigV4 requires.
+func canonicalQuery(v url.Values) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return strings.ReplaceAll(v.Encode(), "+", "%20")
+}
+
+// s3EscapePath escapes everything but unreserved characters and slashes.
+func s3EscapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		ch := p[i]
+		if ch == '/' || ch == '-' || ch == '_' || ch == '.' || ch == '~' ||
+			('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ('0' <= ch && ch <= '9') {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// S3Error is an error response from the storage service.
+type S3Error struct {
+	Op     string
+	Key    string
+	Status int
+	Code   string
+}
+
+func (e *S3Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s %s: %d %s", e.Op, e.Key, e.Status, e.Code)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Op, e.Key, e.Status, http.StatusText(e.Status))
+}
+
+func s3Error(op, key string, resp *http.Response) error {
+	var body struct {
+		Code string `xml:"Code"`
+	}
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body)
+	return &S3Error{Op: op, Key: key, Status: resp.StatusCode, Code: body.Code}
+}
@@ -0,0 +1,158 @@
+package i18n
+
+// catalog maps message keys to their translations. Counted messages use
+// ".one"/".other" variants (see N); locales without a singular form only
+// define ".other".
+var catalog = map[string]map[Locale]string{
+	// Durations
+	"duration.less_than_minute": {
+		English:  "less than a minute",
+		German:   "weniger als eine Minute",
+		Japanese: "1分未満",
+		Chinese:  "不到一分钟",
+	},
+	"duration.minutes.one": {
+		English: "%d minute",
+		German:  "%d Minute",
+	},
+	"duration.minutes.other": {
+		English:  "%d minutes",
+		German:   "%d Minuten",
+		Japanese: "%d分",
+		Chinese:  "%d分钟",
+	},
+	"duration.hours.one": {
+		English: "%d hour",
+		German:  "%d Stunde",
+	},
+	"duration.hours.other": {
+		English:  "%d hours",
+		German:   "%d Stunden",
+		Japanese: "%d時間",
+		Chinese:  "%d小时",
+	},
+	"duration.days.one": {
+		English: "%d day",
+		German:  "%d Tag",
+	},
+	"duration.days.other": {
+		English:  "%d days",
+		German:   "%d Tage",
+		Japanese: "%d日",
+		Chinese:  "%d天",
+	},
+	"duration.left": {
+		English:  "%s left",
+		German:   "noch %s",
+		Japanese: "残り%s",
+		Chinese:  "剩余%s",
+	},
+
+	// Health status
+	"status.healthy": {
+		English:  "Healthy",
+		German:   "Gesund",
+		Japanese: "正常",
+		Chinese:  "正常",
+	},
+	"status.warning": {
+		English:  "Warning",
+		German:   "Warnung",
+		Japanese: "警告",
+		Chinese:  "警告",
+	},
+	"status.critical": {
+		English:  "Critical",
+		German:   "Kritisch",
+		Japanese: "危険",
+		Chinese:  "严重",
+	},
+	"status.unknown": {
+		English:  "Unknown",
+		German:   "Unbekannt",
+		Japanese: "不明",
+		Chinese:  "未知",
+	},
+	"status.valid": {
+		English:  "Valid",
+		German:   "Gültig",
+		Japanese: "有効",
+		Chinese:  "有效",
+	},
+	"status.expired": {
+		English:  "Expired",
+		German:   "Abgelaufen",
+		Japanese: "期限切れ",
+		Chinese:  "已过期",
+	},
+	"status.errors.other": {
+		English:  "%d errors",
+		German:   "%d Fehler",
+		Japanese: "エラー%d件",
+		Chinese:  "%d个错误",
+	},
+
+	// Health reasons
+	"reason.token_expired": {
+		English:  "Token expired",
+		German:   "Token abgelaufen",
+		Japanese: "トークンの有効期限が切れています",
+		Chinese:  "令牌已过期",
+	},
+	"reason.token_expires_in": {
+		English:  "Token expires in %s",
+		German:   "Token läuft in %s ab",
+		Japanese: "トークンはあと%sで期限切れになります",
+		Chinese:  "令牌将在%s后过期",
+	},
+	"reason.recent_errors.one": {
+		English: "%d recent error",
+		German:  "%d aktueller Fehler",
+	},
+	"reason.recent_errors.other": {
+		English:  "%d recent errors",
+		German:   "%d aktuelle Fehler",
+		Japanese: "最近のエラー%d件",
+		Chinese:  "最近%d个错误",
+	},
+	"reason.high_penalty": {
+		English:  "High penalty from errors",
+		German:   "Hohe Fehlerstrafe",
+		Japanese: "エラーによるペナルティが高い",
+		Chinese:  "错误惩罚过高",
+	},
+
+	// Suggested actions
+	"suggest.login": {
+		English:  "Run \"caam login %s %s\" to re-authenticate",
+		German:   "Mit \"caam login %s %s\" erneut anmelden",
+		Japanese: "\"caam login %s %s\" を実行して再認証してください",
+		Chinese:  "运行 \"caam login %s %s\" 重新认证",
+	},
+	"suggest.refresh": {
+		English:  "Run \"caam refresh %s %s\" to refresh expiring token",
+		German:   "Mit \"caam refresh %s %s\" das ablaufende Token erneuern",
+		Japanese: "\"caam refresh %s %s\" を実行して期限切れ間近のトークンを更新してください",
+		Chinese:  "运行 \"caam refresh %s %s\" 刷新即将过期的令牌",
+	},
+	"suggest.switch_profile": {
+		English:  "Profile %s/%s has frequent errors - consider switching to another profile",
+		German:   "Profil %s/%s hat häufig Fehler - ein Wechsel zu einem anderen Profil wird empfohlen",
+		Japanese: "プロファイル %s/%s でエラーが頻発しています - 別のプロファイルへの切り替えを検討してください",
+		Chinese:  "配置文件 %s/%s 频繁出错 - 建议切换到其他配置文件",
+	},
+
+	// Warnings
+	"warning.token_expired": {
+		English:  "Token EXPIRED",
+		German:   "Token ABGELAUFEN",
+		Japanese: "トークンの有効期限切れ",
+		Chinese:  "令牌已过期",
+	},
+	"warning.all_cooldown": {
+		English:  "%s: ⚠️  ALL profiles in cooldown (next available: %s in %s)",
+		German:   "%s: ⚠️  ALLE Profile in Abkühlphase (nächstes verfügbar: %s in %s)",
+		Japanese: "%s: ⚠️  全プロファイルがクールダウン中 (次に利用可能: %s、あと%s)",
+		Chinese:  "%s: ⚠️  所有配置文件均在冷却中 (下一个可用: %s，%s后)",
+	},
+}
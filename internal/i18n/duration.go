@@ -0,0 +1,75 @@
+package i18n
+
+import (
+	"fmt"
+	"time"
+)
+
+// Duration formats d in words, to the largest whole unit:
+// "less than a minute", "12 minutes", "3 hours", "2 days".
+func Duration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return T("duration.less_than_minute")
+	case d < time.Hour:
+		return N("duration.minutes", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return N("duration.hours", int(d.Hours()))
+	default:
+		return N("duration.days", int(d.Hours()/24))
+	}
+}
+
+// Compact formats d with abbreviated units: "45m", "3h20m", "2d" in English.
+// Minutes are shown next to hours only below 12h, where they still matter.
+func Compact(d time.Duration) string {
+	d = d.Round(time.Minute)
+	u := compactUnits[Current()]
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("< 1%s", u.minute)
+	case d < time.Hour:
+		return fmt.Sprintf("%d%s", int(d.Minutes()), u.minute)
+	case d < 24*time.Hour:
+		hours := int(d.Hours())
+		mins := int(d.Minutes()) % 60
+		if mins > 0 && hours < 12 {
+			return fmt.Sprintf("%d%s%s%d%s", hours, u.hour, u.sep, mins, u.minute)
+		}
+		return fmt.Sprintf("%d%s", hours, u.hour)
+	default:
+		return fmt.Sprintf("%d%s", int(d.Hours()/24), u.day)
+	}
+}
+
+// HoursMinutes formats d as hours and minutes, always showing both above an
+// hour: "<1m", "15m", "2h 30m", "1h 0m" in English.
+func HoursMinutes(d time.Duration) string {
+	u := compactUnits[Current()]
+	if d < time.Hour {
+		mins := int(d.Minutes())
+		if mins < 1 {
+			return fmt.Sprintf("<1%s", u.minute)
+		}
+		return fmt.Sprintf("%d%s", mins, u.minute)
+	}
+	return fmt.Sprintf("%d%s%s%d%s", int(d.Hours()), u.hour, u.hmSep, int(d.Minutes())%60, u.minute)
+}
+
+// Remaining formats a countdown: "59m left", "noch 59 Min.", "残り59分".
+func Remaining(d time.Duration) string {
+	return T("duration.left", Compact(d))
+}
+
+type units struct {
+	minute, hour, day string
+	sep               string // between hours and minutes in Compact
+	hmSep             string // between hours and minutes in HoursMinutes
+}
+
+var compactUnits = map[Locale]units{
+	English:  {minute: "m", hour: "h", day: "d", hmSep: " "},
+	German:   {minute: " Min.", hour: " Std.", day: " T.", sep: " ", hmSep: " "},
+	Japanese: {minute: "分", hour: "時間", day: "日"},
+	Chinese:  {minute: "分钟", hour: "小时", day: "天"},
+}
@@ -0,0 +1,132 @@
+// Package i18n localizes caam's human-facing CLI text: status words,
+// countdowns, warnings, and suggested actions.
+//
+// Only text meant for people goes through this package. JSON and robot output
+// stay in English so scripts and agents can keep parsing them.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Locale is a supported output language.
+type Locale string
+
+const (
+	English  Locale = "en"
+	German   Locale = "de"
+	Japanese Locale = "ja"
+	Chinese  Locale = "zh"
+)
+
+// Supported lists the locales caam has translations for.
+var Supported = []Locale{English, German, Japanese, Chinese}
+
+// EnvVar overrides both the config file and the standard locale variables.
+const EnvVar = "CAAM_LANG"
+
+var current atomic.Value // Locale
+
+func init() {
+	current.Store(English)
+}
+
+// Parse maps a language tag or POSIX locale ("de", "de-AT", "ja_JP.UTF-8",
+// "zh_CN") to a supported Locale. ok is false if the language isn't
+// supported.
+func Parse(s string) (Locale, bool) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if i := strings.IndexAny(s, ".@"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexAny(s, "_-"); i >= 0 {
+		s = s[:i]
+	}
+	switch s {
+	case "c", "posix":
+		return English, true
+	}
+	for _, l := range Supported {
+		if s == string(l) {
+			return l, true
+		}
+	}
+	return English, false
+}
+
+// Detect picks the locale from CAAM_LANG, then the configured language, then
+// LC_ALL, LC_MESSAGES, and LANG. Unsupported or empty values are skipped;
+// the fallback is English.
+func Detect(configured string) Locale {
+	candidates := []string{
+		os.Getenv(EnvVar),
+		configured,
+		os.Getenv("LC_ALL"),
+		os.Getenv("LC_MESSAGES"),
+		os.Getenv("LANG"),
+	}
+	for _, c := range candidates {
+		if strings.TrimSpace(c) == "" {
+			continue
+		}
+		if l, ok := Parse(c); ok {
+			return l
+		}
+	}
+	return English
+}
+
+// SetLocale sets the locale used by T, N, and the duration formatters.
+func SetLocale(l Locale) {
+	if _, ok := Parse(string(l)); !ok {
+		l = English
+	}
+	current.Store(l)
+}
+
+// Current returns the active locale.
+func Current() Locale {
+	return current.Load().(Locale)
+}
+
+// T returns the message for key in the current locale, formatted with args.
+// Missing translations fall back to English, and unknown keys to the key
+// itself.
+func T(key string, args ...interface{}) string {
+	msg := lookup(Current(), key)
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// N is T for counted messages: it uses key+".one" when n is 1 and the locale
+// has a singular form, and key+".other" otherwise. n is passed as the first
+// format argument.
+func N(key string, n int, args ...interface{}) string {
+	l := Current()
+	variant := key + ".other"
+	if n == 1 {
+		if _, ok := catalog[key+".one"][l]; ok {
+			variant = key + ".one"
+		}
+	}
+	return fmt.Sprintf(lookup(l, variant), append([]interface{}{n}, args...)...)
+}
+
+func lookup(l Locale, key string) string {
+	msgs, ok := catalog[key]
+	if !ok {
+		return key
+	}
+	if msg, ok := msgs[l]; ok {
+		return msg
+	}
+	if msg, ok := msgs[English]; ok {
+		return msg
+	}
+	return key
+}
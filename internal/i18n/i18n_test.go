@@ -0,0 +1,124 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func withLocale(t *testing.T, l Locale) {
+	t.Helper()
+	prev := Current()
+	SetLocale(l)
+	t.Cleanup(func() { SetLocale(prev) })
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Locale
+		ok   bool
+	}{
+		{"de", German, true},
+		{"de_DE.UTF-8", German, true},
+		{"ja-JP", Japanese, true},
+		{"zh_CN.GB2312", Chinese, true},
+		{"en_US@euro", English, true},
+		{"C", English, true},
+		{"POSIX", English, true},
+		{"fr_FR.UTF-8", English, false},
+		{"", English, false},
+	}
+	for _, tt := range tests {
+		got, ok := Parse(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Parse(%q) = (%q, %v), want (%q, %v)", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDetectPrecedence(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "ja_JP.UTF-8")
+	t.Setenv(EnvVar, "")
+
+	if got := Detect(""); got != Japanese {
+		t.Errorf("Detect from LANG = %q, want ja", got)
+	}
+	if got := Detect("zh"); got != Chinese {
+		t.Errorf("Detect with config = %q, want zh", got)
+	}
+	t.Setenv(EnvVar, "de")
+	if got := Detect("zh"); got != German {
+		t.Errorf("Detect with %s = %q, want de", EnvVar, got)
+	}
+	t.Setenv(EnvVar, "fr")
+	if got := Detect("klingon"); got != Japanese {
+		t.Errorf("Detect skipping unsupported = %q, want ja", got)
+	}
+}
+
+func TestTFallbacks(t *testing.T) {
+	withLocale(t, German)
+
+	if got := T("status.expired"); got != "Abgelaufen" {
+		t.Errorf("T(status.expired) = %q", got)
+	}
+	if got := T("no.such.key"); got != "no.such.key" {
+		t.Errorf("unknown key = %q, want key echoed", got)
+	}
+}
+
+func TestNPlurals(t *testing.T) {
+	withLocale(t, English)
+	if got := N("reason.recent_errors", 1); got != "1 recent error" {
+		t.Errorf("en one = %q", got)
+	}
+	if got := N("reason.recent_errors", 3); got != "3 recent errors" {
+		t.Errorf("en other = %q", got)
+	}
+
+	// Japanese has no singular form and falls through to .other.
+	SetLocale(Japanese)
+	if got := N("duration.minutes", 1); got != "1分" {
+		t.Errorf("ja one = %q", got)
+	}
+}
+
+func TestDurations(t *testing.T) {
+	tests := []struct {
+		locale   Locale
+		d        time.Duration
+		duration string
+		left     string
+		hm       string
+	}{
+		{English, 20 * time.Second, "less than a minute", "< 1m left", "<1m"},
+		{English, 2*time.Hour + 30*time.Minute, "2 hours", "2h30m left", "2h 30m"},
+		{English, 49 * time.Hour, "2 days", "2d left", "49h 0m"},
+		{German, 45 * time.Minute, "45 Minuten", "noch 45 Min.", "45 Min."},
+		{German, 2*time.Hour + 30*time.Minute, "2 Stunden", "noch 2 Std. 30 Min.", "2 Std. 30 Min."},
+		{Japanese, 2*time.Hour + 30*time.Minute, "2時間", "残り2時間30分", "2時間30分"},
+		{Chinese, 24 * time.Hour, "1天", "剩余1天", "24小时0分钟"},
+	}
+	for _, tt := range tests {
+		withLocale(t, tt.locale)
+		if got := Duration(tt.d); got != tt.duration {
+			t.Errorf("[%s] Duration(%v) = %q, want %q", tt.locale, tt.d, got, tt.duration)
+		}
+		if got := Remaining(tt.d); got != tt.left {
+			t.Errorf("[%s] Remaining(%v) = %q, want %q", tt.locale, tt.d, got, tt.left)
+		}
+		if got := HoursMinutes(tt.d); got != tt.hm {
+			t.Errorf("[%s] HoursMinutes(%v) = %q, want %q", tt.locale, tt.d, got, tt.hm)
+		}
+	}
+}
+
+func TestCatalogHasEnglish(t *testing.T) {
+	for key, msgs := range catalog {
+		if _, ok := msgs[English]; !ok {
+			t.Errorf("catalog key %q has no English message", key)
+		}
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // ExtractFromClaudeCredentials reads Claude .credentials.json and extracts identity.
@@ -19,7 +20,7 @@ import (
 //
 // See: docs/CLAUDE_AUTH_INVENTORY.md (CLAUDE-001)
 func ExtractFromClaudeCredentials(path string) (*Identity, error) {
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read claude credentials: %w", err)
 	}
@@ -49,6 +50,34 @@ func ExtractFromClaudeCredentials(path string) (*Identity, error) {
 	return identity, nil
 }
 
+// ExtractFromClaudeConfig reads the oauthAccount block of Claude Code's
+// ~/.claude.json, which records the signed-in account and, for Team and
+// Enterprise accounts, the organization selected at login.
+func ExtractFromClaudeConfig(path string) (*Identity, error) {
+	data, err := vaultcrypt.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read claude config: %w", err)
+	}
+
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parse claude config: %w", err)
+	}
+
+	identity := &Identity{Provider: "claude"}
+
+	account, ok := root["oauthAccount"].(map[string]interface{})
+	if !ok {
+		return identity, nil
+	}
+
+	identity.Email = valueAsString(account["emailAddress"])
+	identity.AccountID = valueAsString(account["accountUuid"])
+	identity.Organization = valueAsString(account["organizationName"])
+	identity.OrganizationID = valueAsString(account["organizationUuid"])
+	return identity, nil
+}
+
 func parseEpoch(value interface{}) (time.Time, bool) {
 	secs, ok := epochSeconds(value)
 	if !ok {
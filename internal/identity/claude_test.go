@@ -189,6 +189,38 @@ func writeClaudeFile(t *testing.T, content map[string]interface{}) string {
 
 // Fixture-based tests for comprehensive coverage
 
+func TestExtractFromClaudeConfig_Organization(t *testing.T) {
+	path := writeClaudeFile(t, map[string]interface{}{
+		"numStartups": 3,
+		"oauthAccount": map[string]interface{}{
+			"accountUuid":      "acct-1",
+			"emailAddress":     "dev@acme.com",
+			"organizationUuid": "org-42",
+			"organizationName": "Acme Corp",
+		},
+	})
+
+	identity, err := ExtractFromClaudeConfig(path)
+	if err != nil {
+		t.Fatalf("ExtractFromClaudeConfig error: %v", err)
+	}
+	if identity.Email != "dev@acme.com" || identity.AccountID != "acct-1" {
+		t.Errorf("account = %q/%q, want dev@acme.com/acct-1", identity.Email, identity.AccountID)
+	}
+	if identity.Organization != "Acme Corp" || identity.OrganizationID != "org-42" {
+		t.Errorf("organization = %q/%q, want Acme Corp/org-42", identity.Organization, identity.OrganizationID)
+	}
+
+	personal := writeClaudeFile(t, map[string]interface{}{"numStartups": 1})
+	identity, err = ExtractFromClaudeConfig(personal)
+	if err != nil {
+		t.Fatalf("ExtractFromClaudeConfig error: %v", err)
+	}
+	if identity.OrganizationID != "" || identity.Provider != "claude" {
+		t.Errorf("identity without oauthAccount = %+v", identity)
+	}
+}
+
 func TestFixture_ClaudeCurrentFormat(t *testing.T) {
 	identity, err := ExtractFromClaudeCredentials("testdata/claude_current_format.json")
 	if err != nil {
@@ -3,12 +3,13 @@ package identity
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // ExtractFromCodexAuth reads a Codex auth.json file and extracts identity from the JWT.
 func ExtractFromCodexAuth(path string) (*Identity, error) {
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read codex auth.json: %w", err)
 	}
@@ -0,0 +1,99 @@
+package identity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// ExtractFromCopilotHosts reads a GitHub Copilot hosts.json or apps.json
+// and extracts the signed-in GitHub account.
+func ExtractFromCopilotHosts(path string) (*Identity, error) {
+	data, err := vaultcrypt.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read copilot hosts file: %w", err)
+	}
+	return ParseCopilotHosts(data)
+}
+
+// ParseCopilotHosts parses the contents of a Copilot hosts.json or
+// apps.json. Both map a host (apps.json: "host:appId") to the account
+// signed in there:
+//
+//	{"github.com": {"user": "octocat", "oauth_token": "ghu_...",
+//	                "expires_at": "2026-03-01T12:00:00Z"}}
+//
+// The github.com entry wins when several hosts are signed in. GitHub
+// Enterprise hosts are reported as the Organization.
+func ParseCopilotHosts(data []byte) (*Identity, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var hosts map[string]map[string]interface{}
+	if err := dec.Decode(&hosts); err != nil {
+		return nil, fmt.Errorf("parse copilot hosts file: %w", err)
+	}
+
+	keys := make([]string, 0, len(hosts))
+	for key := range hosts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		gi, gj := copilotHost(keys[i]) == "github.com", copilotHost(keys[j]) == "github.com"
+		if gi != gj {
+			return gi
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys {
+		entry := hosts[key]
+		if pickString(entry, "oauth_token", "token") == "" {
+			continue
+		}
+		identity := &Identity{
+			Provider:  "copilot",
+			Email:     pickString(entry, "email"),
+			AccountID: pickString(entry, "user", "login"),
+		}
+		if host := copilotHost(key); host != "github.com" {
+			identity.Organization = host
+		}
+		identity.ExpiresAt = parseCopilotTime(entry["expires_at"])
+		return identity, nil
+	}
+	return nil, fmt.Errorf("no signed-in host in copilot hosts file")
+}
+
+// copilotHost strips the app ID from an apps.json key.
+func copilotHost(key string) string {
+	host, _, _ := strings.Cut(key, ":")
+	return host
+}
+
+func parseCopilotTime(v interface{}) time.Time {
+	switch value := v.(type) {
+	case json.Number:
+		secs, err := value.Int64()
+		if err != nil {
+			return time.Time{}
+		}
+		if secs > 1e12 {
+			return time.UnixMilli(secs).UTC()
+		}
+		return time.Unix(secs, 0).UTC()
+	case string:
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t
+		}
+		if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(secs, 0).UTC()
+		}
+	}
+	return time.Time{}
+}
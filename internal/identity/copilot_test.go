@@ -0,0 +1,65 @@
+package identity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseCopilotHosts(t *testing.T) {
+	identity, err := ParseCopilotHosts([]byte(`{
+		"ghe.example.com": {"user": "octo-ent", "oauth_token": "ghu_ent"},
+		"github.com": {"user": "octocat", "oauth_token": "ghu_abc", "expires_at": "2026-03-01T12:00:00Z"}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseCopilotHosts error: %v", err)
+	}
+	if identity.AccountID != "octocat" || identity.Organization != "" {
+		t.Errorf("identity = %+v, want the github.com account", identity)
+	}
+	if want := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC); !identity.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", identity.ExpiresAt, want)
+	}
+	if identity.Provider != "copilot" {
+		t.Errorf("Provider = %q, want copilot", identity.Provider)
+	}
+}
+
+func TestParseCopilotHosts_Apps(t *testing.T) {
+	identity, err := ParseCopilotHosts([]byte(`{
+		"ghe.example.com:Iv1.abc": {"user": "octo-ent", "oauth_token": "ghu_ent", "expires_at": 1772366400}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseCopilotHosts error: %v", err)
+	}
+	if identity.AccountID != "octo-ent" || identity.Organization != "ghe.example.com" {
+		t.Errorf("identity = %+v", identity)
+	}
+	if identity.ExpiresAt.Unix() != 1772366400 {
+		t.Errorf("ExpiresAt = %v", identity.ExpiresAt)
+	}
+}
+
+func TestParseCopilotHosts_NoToken(t *testing.T) {
+	if _, err := ParseCopilotHosts([]byte(`{"github.com": {"user": "octocat"}}`)); err == nil {
+		t.Error("expected error for a host without a token")
+	}
+	if _, err := ParseCopilotHosts([]byte(`[]`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestExtractFromCopilotHosts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.json")
+	if err := os.WriteFile(path, []byte(`{"github.com":{"user":"octocat","oauth_token":"gho_x"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	identity, err := ExtractFromCopilotHosts(path)
+	if err != nil || identity.AccountID != "octocat" || !identity.ExpiresAt.IsZero() {
+		t.Errorf("ExtractFromCopilotHosts = %+v, %v", identity, err)
+	}
+	if _, err := ExtractFromCopilotHosts(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
@@ -0,0 +1,89 @@
+package identity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// ExtractFromCursorAuth reads a Cursor CLI auth.json and extracts identity
+// from the access token. The token carries the account ID and expiry but
+// not the email; that lives in cli-config.json, which is read too when it
+// sits next to auth.json (as it does in a vault profile).
+//
+// auth.json:
+//
+//	{"accessToken": "<jwt>", "refreshToken": "<jwt>"}
+func ExtractFromCursorAuth(path string) (*Identity, error) {
+	data, err := vaultcrypt.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cursor auth.json: %w", err)
+	}
+
+	var auth map[string]interface{}
+	if err := json.Unmarshal(data, &auth); err != nil {
+		return nil, fmt.Errorf("parse cursor auth.json: %w", err)
+	}
+
+	token := pickString(auth, "accessToken", "access_token")
+	if token == "" {
+		return nil, fmt.Errorf("no access token found in auth.json")
+	}
+	identity, err := ExtractFromJWT(token)
+	if err != nil {
+		return nil, fmt.Errorf("parse cursor access token: %w", err)
+	}
+	identity.Provider = "cursor"
+	if identity.AccountID == "" {
+		if claims, err := parseJWTClaims(token); err == nil {
+			identity.AccountID = pickString(claims, "sub")
+		}
+	}
+
+	if cfg, err := ExtractFromCursorConfig(filepath.Join(filepath.Dir(path), "cli-config.json")); err == nil {
+		if cfg.Email != "" {
+			identity.Email = cfg.Email
+		}
+		if cfg.PlanType != "" {
+			identity.PlanType = cfg.PlanType
+		}
+		if cfg.Organization != "" {
+			identity.Organization = cfg.Organization
+		}
+	}
+	return identity, nil
+}
+
+// ExtractFromCursorConfig reads the authInfo block of Cursor CLI's
+// cli-config.json:
+//
+//	{"authInfo": {"email": "...", "displayName": "...", "userId": 123,
+//	              "membershipType": "pro", "teamName": "..."}}
+func ExtractFromCursorConfig(path string) (*Identity, error) {
+	data, err := vaultcrypt.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cursor cli-config.json: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var root map[string]interface{}
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("parse cursor cli-config.json: %w", err)
+	}
+
+	info, ok := root["authInfo"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no authInfo in cli-config.json")
+	}
+	return &Identity{
+		Provider:     "cursor",
+		Email:        pickString(info, "email"),
+		AccountID:    pickString(info, "userId", "authId"),
+		PlanType:     pickString(info, "membershipType", "planType", "plan"),
+		Organization: pickString(info, "teamName", "team"),
+	}, nil
+}
@@ -0,0 +1,103 @@
+package identity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCursorFiles(t *testing.T, auth, config map[string]interface{}) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	write := func(name string, content map[string]interface{}) {
+		data, err := json.Marshal(content)
+		if err != nil {
+			t.Fatalf("marshal %s: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	write("auth.json", auth)
+	if config != nil {
+		write("cli-config.json", config)
+	}
+	return filepath.Join(dir, "auth.json")
+}
+
+func TestExtractFromCursorAuth(t *testing.T) {
+	exp := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	token := buildJWT(t, map[string]interface{}{
+		"sub": "auth0|user_01",
+		"exp": exp.Unix(),
+	})
+	path := writeCursorFiles(t,
+		map[string]interface{}{"accessToken": token, "refreshToken": token},
+		map[string]interface{}{"authInfo": map[string]interface{}{
+			"email":          "dev@example.com",
+			"userId":         12345,
+			"membershipType": "pro",
+		}},
+	)
+
+	identity, err := ExtractFromCursorAuth(path)
+	if err != nil {
+		t.Fatalf("ExtractFromCursorAuth error: %v", err)
+	}
+	if identity.Email != "dev@example.com" {
+		t.Errorf("Email = %q, want dev@example.com", identity.Email)
+	}
+	if identity.PlanType != "pro" {
+		t.Errorf("PlanType = %q, want pro", identity.PlanType)
+	}
+	if identity.AccountID != "auth0|user_01" {
+		t.Errorf("AccountID = %q, want the token subject", identity.AccountID)
+	}
+	if !identity.ExpiresAt.Equal(exp) {
+		t.Errorf("ExpiresAt = %v, want %v", identity.ExpiresAt, exp)
+	}
+	if identity.Provider != "cursor" {
+		t.Errorf("Provider = %q, want cursor", identity.Provider)
+	}
+}
+
+func TestExtractFromCursorAuth_NoConfig(t *testing.T) {
+	token := buildJWT(t, map[string]interface{}{"sub": "user_02", "exp": time.Now().Add(time.Hour).Unix()})
+	path := writeCursorFiles(t, map[string]interface{}{"accessToken": token}, nil)
+
+	identity, err := ExtractFromCursorAuth(path)
+	if err != nil {
+		t.Fatalf("ExtractFromCursorAuth error: %v", err)
+	}
+	if identity.Email != "" || identity.AccountID != "user_02" {
+		t.Errorf("identity = %+v", identity)
+	}
+}
+
+func TestExtractFromCursorAuth_MissingToken(t *testing.T) {
+	path := writeCursorFiles(t, map[string]interface{}{"refreshToken": "x"}, nil)
+	if _, err := ExtractFromCursorAuth(path); err == nil {
+		t.Fatal("expected error when accessToken is missing")
+	}
+}
+
+func TestExtractFromCursorConfig(t *testing.T) {
+	path := writeCursorFiles(t, map[string]interface{}{}, map[string]interface{}{
+		"authInfo": map[string]interface{}{"email": "dev@example.com", "userId": 42, "teamName": "Acme"},
+	})
+
+	identity, err := ExtractFromCursorConfig(filepath.Join(filepath.Dir(path), "cli-config.json"))
+	if err != nil {
+		t.Fatalf("ExtractFromCursorConfig error: %v", err)
+	}
+	if identity.Email != "dev@example.com" || identity.AccountID != "42" || identity.Organization != "Acme" {
+		t.Errorf("identity = %+v", identity)
+	}
+
+	if _, err := ExtractFromCursorConfig(path); err == nil {
+		t.Error("expected error for a file without authInfo")
+	}
+}
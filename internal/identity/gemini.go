@@ -3,12 +3,13 @@ package identity
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // ExtractFromGeminiConfig reads Gemini/Google auth config and extracts identity.
 func ExtractFromGeminiConfig(path string) (*Identity, error) {
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read gemini config: %w", err)
 	}
@@ -20,7 +21,9 @@ func ExtractFromGeminiConfig(path string) (*Identity, error) {
 
 	identity := &Identity{Provider: "gemini"}
 
-	identity.Email = pickString(root, "client_email", "user_email", "email")
+	// gcloud Application Default Credentials record the account as a
+	// plain string; Gemini settings nest it in an object.
+	identity.Email = pickString(root, "client_email", "user_email", "email", "account")
 	if identity.Email == "" {
 		if account, ok := root["account"].(map[string]interface{}); ok {
 			identity.Email = pickString(account, "email", "user_email")
@@ -51,6 +51,28 @@ func TestExtractFromGeminiConfig_ServiceAccount(t *testing.T) {
 	}
 }
 
+func TestExtractFromGeminiConfig_ApplicationDefaultCredentials(t *testing.T) {
+	config := map[string]interface{}{
+		"type":             "authorized_user",
+		"account":          "adc-user@example.com",
+		"client_id":        "123.apps.googleusercontent.com",
+		"refresh_token":    "1//refresh",
+		"quota_project_id": "billing-proj",
+	}
+	path := writeGeminiFile(t, config)
+
+	identity, err := ExtractFromGeminiConfig(path)
+	if err != nil {
+		t.Fatalf("ExtractFromGeminiConfig error: %v", err)
+	}
+	if identity.Email != "adc-user@example.com" {
+		t.Errorf("Email = %q, want %q", identity.Email, "adc-user@example.com")
+	}
+	if identity.Organization != "billing-proj" {
+		t.Errorf("Organization = %q, want %q", identity.Organization, "billing-proj")
+	}
+}
+
 func TestExtractFromGeminiConfig_MissingFields(t *testing.T) {
 	config := map[string]interface{}{
 		"type": "authorized_user",
@@ -1,14 +1,47 @@
 // Package identity extracts account identity details from provider auth artifacts.
 package identity
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+// PlanAPI is the plan type of profiles that authenticate with a
+// pay-per-token API key instead of a subscription login.
+const PlanAPI = "api"
 
 // Identity captures account metadata extracted from auth files.
 type Identity struct {
-	Email        string    `json:"email,omitempty"`
-	Organization string    `json:"organization,omitempty"`
-	PlanType     string    `json:"plan_type,omitempty"`
-	AccountID    string    `json:"account_id,omitempty"`
-	ExpiresAt    time.Time `json:"expires_at,omitempty"`
-	Provider     string    `json:"provider"`
+	Email          string    `json:"email,omitempty"`
+	Organization   string    `json:"organization,omitempty"`
+	OrganizationID string    `json:"organization_id,omitempty"`
+	PlanType       string    `json:"plan_type,omitempty"`
+	AccountID      string    `json:"account_id,omitempty"`
+	ExpiresAt      time.Time `json:"expires_at,omitempty"`
+	Provider       string    `json:"provider"`
+
+	// Workspaces lists the workspaces or organizations the credentials are
+	// authorized for (Codex: the ChatGPT workspace and API organizations).
+	Workspaces []Workspace `json:"workspaces,omitempty"`
+}
+
+// Workspace is a provider workspace or organization an account can act in.
+type Workspace struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// HasWorkspace reports whether the identity is authorized for a workspace,
+// matched by ID or case-insensitive name.
+func (id *Identity) HasWorkspace(query string) bool {
+	if id == nil {
+		return false
+	}
+	query = strings.TrimSpace(query)
+	for _, ws := range id.Workspaces {
+		if ws.ID == query || (ws.Name != "" && strings.EqualFold(ws.Name, query)) {
+			return true
+		}
+	}
+	return false
 }
@@ -41,6 +41,40 @@ func TestExtractFromJWT_AllFields(t *testing.T) {
 	}
 }
 
+func TestExtractFromJWT_OpenAIWorkspaces(t *testing.T) {
+	token := buildJWT(t, map[string]interface{}{
+		"email": "dev@example.com",
+		"https://api.openai.com/auth": map[string]interface{}{
+			"chatgpt_account_id": "ws-team",
+			"organizations": []interface{}{
+				map[string]interface{}{"id": "org-1", "title": "Acme Research"},
+				map[string]interface{}{"id": "ws-team"},
+			},
+		},
+	})
+
+	identity, err := ExtractFromJWT(token)
+	if err != nil {
+		t.Fatalf("ExtractFromJWT error: %v", err)
+	}
+	if len(identity.Workspaces) != 2 {
+		t.Fatalf("Workspaces = %+v, want 2 entries", identity.Workspaces)
+	}
+	for _, query := range []string{"ws-team", "org-1", "acme research"} {
+		if !identity.HasWorkspace(query) {
+			t.Errorf("HasWorkspace(%q) = false, want true", query)
+		}
+	}
+	if identity.HasWorkspace("ws-other") {
+		t.Error("HasWorkspace(ws-other) = true, want false")
+	}
+
+	var none *Identity
+	if none.HasWorkspace("ws-team") {
+		t.Error("nil identity should match no workspace")
+	}
+}
+
 func TestExtractFromJWT_Minimal(t *testing.T) {
 	payload := map[string]interface{}{
 		"preferred_username": "minimal@example.com",
@@ -32,9 +32,45 @@ func ExtractFromJWT(token string) (*Identity, error) {
 		identity.ExpiresAt = exp
 	}
 
+	identity.Workspaces = extractWorkspaces(claims)
+
 	return identity, nil
 }
 
+// openAIAuthClaim is the namespaced claim OpenAI puts ChatGPT account and
+// organization details under.
+const openAIAuthClaim = "https://api.openai.com/auth"
+
+// extractWorkspaces reads the workspaces a token is scoped to: the ChatGPT
+// workspace selected at login (chatgpt_account_id, which Codex's
+// forced_chatgpt_workspace_id refers to) and any API organizations.
+func extractWorkspaces(claims map[string]interface{}) []Workspace {
+	auth, ok := claims[openAIAuthClaim].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var workspaces []Workspace
+	seen := make(map[string]bool)
+	add := func(id, name string) {
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+		workspaces = append(workspaces, Workspace{ID: id, Name: name})
+	}
+
+	add(pickString(auth, "chatgpt_account_id", "account_id"), "")
+	if orgs, ok := auth["organizations"].([]interface{}); ok {
+		for _, raw := range orgs {
+			if org, ok := raw.(map[string]interface{}); ok {
+				add(pickString(org, "id"), pickString(org, "title", "name"))
+			}
+		}
+	}
+	return workspaces
+}
+
 func parseJWTClaims(token string) (map[string]interface{}, error) {
 	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
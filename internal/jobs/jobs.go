@@ -0,0 +1,348 @@
+// Package jobs tracks caam's long-running operations (sync runs, bulk
+// refreshes, bundle export/import, distributed setup) as persistent jobs.
+//
+// Each job is a JSON record plus a log file under <data>/jobs. A job runs
+// either in the foreground, inside the CLI process that created it, or is
+// queued with --async and executed later by the daemon, which re-runs the
+// recorded caam command with CAAM_JOB_ID pointing at the record. Either way
+// the record survives the process: progress, logs, and the final status can
+// be inspected with `caam jobs`, and a job whose process died is reported as
+// interrupted instead of vanishing.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/progress"
+)
+
+// EnvJobID tells a caam process started by the daemon which job it is running.
+const EnvJobID = "CAAM_JOB_ID"
+
+// Status is a job's lifecycle state.
+type Status string
+
+// Job statuses.
+const (
+	StatusQueued      Status = "queued"
+	StatusRunning     Status = "running"
+	StatusSucceeded   Status = "succeeded"
+	StatusFailed      Status = "failed"
+	StatusCancelled   Status = "cancelled"
+	StatusInterrupted Status = "interrupted"
+)
+
+// Finished reports whether the status is terminal.
+func (s Status) Finished() bool {
+	switch s {
+	case StatusSucceeded, StatusFailed, StatusCancelled, StatusInterrupted:
+		return true
+	}
+	return false
+}
+
+// ErrNotFound is returned when no job matches an ID.
+var ErrNotFound = errors.New("job not found")
+
+// cancelPollInterval is how often a running job checks for a cancel request.
+const cancelPollInterval = time.Second
+
+// progressSaveInterval throttles how often progress events are persisted.
+const progressSaveInterval = 500 * time.Millisecond
+
+// Job is one long-running operation.
+type Job struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+
+	// Args is the caam command line (without the binary) that runs the job.
+	Args []string `json:"args"`
+
+	// Dir is the working directory the command was issued from, so relative
+	// paths in Args resolve the same when the daemon runs it.
+	Dir string `json:"dir,omitempty"`
+
+	Status     Status    `json:"status"`
+	Async      bool      `json:"async,omitempty"`
+	PID        int       `json:"pid,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+
+	// Progress is the most recent progress event reported by the command.
+	Progress *progress.Event `json:"progress,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	lastSave time.Time
+}
+
+// Dir returns the directory holding job records and logs.
+func Dir() string {
+	return filepath.Join(config.DefaultDataPath(), "jobs")
+}
+
+// Path returns the record path for a job ID.
+func Path(id string) string {
+	return filepath.Join(Dir(), id+".json")
+}
+
+// LogPath returns the log path for a job ID.
+func LogPath(id string) string {
+	return filepath.Join(Dir(), id+".log")
+}
+
+func cancelPath(id string) string {
+	return filepath.Join(Dir(), id+".cancel")
+}
+
+// New returns a queued job with a fresh ID. It is not persisted until Save
+// or Start.
+func New(kind string, args []string) (*Job, error) {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("generate job id: %w", err)
+	}
+	now := time.Now().UTC()
+	j := &Job{
+		ID:        now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix),
+		Kind:      kind,
+		Args:      args,
+		Status:    StatusQueued,
+		CreatedAt: now,
+	}
+	return j, nil
+}
+
+// Load reads a job record by ID.
+func Load(id string) (*Job, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	data, err := os.ReadFile(Path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("read job %s: %w", id, err)
+	}
+	var j Job
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("parse job %s: %w", id, err)
+	}
+	j.reconcile()
+	return &j, nil
+}
+
+// List returns all jobs, newest first.
+func List() ([]*Job, error) {
+	entries, err := os.ReadDir(Dir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read jobs dir: %w", err)
+	}
+
+	var out []*Job
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		j, err := Load(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		out = append(out, j)
+	}
+	sort.Slice(out, func(i, k int) bool {
+		return out[i].CreatedAt.After(out[k].CreatedAt)
+	})
+	return out, nil
+}
+
+// NextQueued returns the oldest queued job, or nil if none is waiting.
+func NextQueued() (*Job, error) {
+	all, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(all) - 1; i >= 0; i-- {
+		if all[i].Status == StatusQueued {
+			return all[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Save writes the job record atomically.
+func (j *Job) Save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal job: %w", err)
+	}
+	if err := os.MkdirAll(Dir(), 0700); err != nil {
+		return fmt.Errorf("create jobs dir: %w", err)
+	}
+	path := Path(j.ID)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("write job: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("rename job: %w", err)
+	}
+	j.lastSave = time.Now()
+	return nil
+}
+
+// reconcile marks a running job whose process is gone as interrupted.
+func (j *Job) reconcile() {
+	if j.Status != StatusRunning || j.PID == 0 || processAlive(j.PID) {
+		return
+	}
+	j.Status = StatusInterrupted
+	if j.Error == "" {
+		j.Error = "process exited before the job finished"
+	}
+	if j.FinishedAt.IsZero() {
+		j.FinishedAt = time.Now().UTC()
+	}
+	_ = j.Save()
+}
+
+// Start marks the job as running in this process and returns a context that
+// is cancelled when `caam jobs cancel` is requested. Call stop when done.
+func (j *Job) Start(parent context.Context) (ctx context.Context, stop func(), err error) {
+	j.Status = StatusRunning
+	j.PID = os.Getpid()
+	j.StartedAt = time.Now().UTC()
+	if err := j.Save(); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(cancelPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if CancelRequested(j.ID) {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return ctx, func() { close(done); cancel() }, nil
+}
+
+// Finish records the job's outcome.
+func (j *Job) Finish(runErr error) error {
+	j.FinishedAt = time.Now().UTC()
+	switch {
+	case runErr == nil:
+		j.Status = StatusSucceeded
+	case CancelRequested(j.ID):
+		j.Status = StatusCancelled
+		j.Error = runErr.Error()
+	default:
+		j.Status = StatusFailed
+		j.Error = runErr.Error()
+	}
+	_ = os.Remove(cancelPath(j.ID))
+	j.Logf("job %s: %s", j.Status, j.Error)
+	return j.Save()
+}
+
+// Track records a progress event, appending it to the job log. It matches
+// the signature progress.Reporter observers expect.
+func (j *Job) Track(ev progress.Event) {
+	j.Progress = &ev
+
+	var b strings.Builder
+	b.WriteString(ev.Type)
+	if ev.Phase != "" {
+		b.WriteString(" [" + ev.Phase + "]")
+	}
+	if ev.Total > 0 {
+		fmt.Fprintf(&b, " %d/%d", ev.Current, ev.Total)
+	}
+	if ev.Item != "" {
+		b.WriteString(" " + ev.Item)
+	}
+	if ev.Status != "" {
+		b.WriteString(": " + ev.Status)
+	}
+	if ev.Message != "" {
+		b.WriteString(" (" + ev.Message + ")")
+	}
+	j.Logf("%s", b.String())
+
+	if ev.Type != progress.EventProgress || time.Since(j.lastSave) >= progressSaveInterval {
+		_ = j.Save()
+	}
+}
+
+// Logf appends a timestamped line to the job log. Logging is best-effort.
+func (j *Job) Logf(format string, args ...interface{}) {
+	f, err := j.OpenLog()
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	line := strings.TrimRight(fmt.Sprintf(format, args...), " :\n")
+	fmt.Fprintf(f, "%s %s\n", time.Now().Format(time.RFC3339), line)
+}
+
+// OpenLog opens the job log for appending.
+func (j *Job) OpenLog() (*os.File, error) {
+	if err := os.MkdirAll(Dir(), 0700); err != nil {
+		return nil, fmt.Errorf("create jobs dir: %w", err)
+	}
+	return os.OpenFile(LogPath(j.ID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+}
+
+// Cancel cancels a job. A queued job is cancelled immediately; a running
+// job is asked to stop and finishes as cancelled once its command notices.
+func Cancel(id string) (*Job, error) {
+	j, err := Load(id)
+	if err != nil {
+		return nil, err
+	}
+	switch j.Status {
+	case StatusQueued:
+		j.Status = StatusCancelled
+		j.FinishedAt = time.Now().UTC()
+		return j, j.Save()
+	case StatusRunning:
+		if err := os.WriteFile(cancelPath(id), []byte(time.Now().UTC().Format(time.RFC3339)), 0600); err != nil {
+			return nil, fmt.Errorf("request cancel: %w", err)
+		}
+		return j, nil
+	default:
+		return nil, fmt.Errorf("job %s already %s", id, j.Status)
+	}
+}
+
+// CancelRequested reports whether `caam jobs cancel` was run for the job.
+func CancelRequested(id string) bool {
+	_, err := os.Stat(cancelPath(id))
+	return err == nil
+}
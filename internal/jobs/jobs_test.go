@@ -0,0 +1,135 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/progress"
+)
+
+func TestJobLifecycle(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+
+	j, err := New("sync", []string{"sync"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(j.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Load() before Save = %v, want ErrNotFound", err)
+	}
+
+	_, stop, err := j.Start(context.Background())
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer stop()
+
+	j.Track(progress.Event{Type: progress.EventProgress, Phase: "machine:laptop", Current: 1, Total: 2, Item: "claude/work", Status: "pushed"})
+	if err := j.Finish(nil); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	got, err := Load(j.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != StatusSucceeded || got.PID != os.Getpid() || got.FinishedAt.IsZero() {
+		t.Errorf("job = %+v", got)
+	}
+	if got.Progress == nil || got.Progress.Item != "claude/work" {
+		t.Errorf("progress = %+v", got.Progress)
+	}
+
+	log, err := os.ReadFile(LogPath(j.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(log), "1/2 claude/work: pushed") || !strings.Contains(string(log), "job succeeded") {
+		t.Errorf("log = %q", log)
+	}
+}
+
+func TestListAndNextQueued(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+
+	older, _ := New("refresh", []string{"refresh", "--all=true"})
+	older.CreatedAt = older.CreatedAt.Add(-time.Minute)
+	newer, _ := New("sync", []string{"sync"})
+	for _, j := range []*Job{older, newer} {
+		if err := j.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := List()
+	if err != nil || len(all) != 2 || all[0].ID != newer.ID {
+		t.Fatalf("List() = %v, %v; want newest first", all, err)
+	}
+	next, err := NextQueued()
+	if err != nil || next == nil || next.ID != older.ID {
+		t.Fatalf("NextQueued() = %v, %v; want oldest", next, err)
+	}
+}
+
+func TestInterruptedJob(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process liveness is not checked on Windows")
+	}
+	t.Setenv("CAAM_HOME", t.TempDir())
+
+	j, _ := New("sync", nil)
+	j.Status = StatusRunning
+	j.PID = 1 << 30 // no such process
+	if err := j.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(j.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != StatusInterrupted || got.Error == "" {
+		t.Errorf("status = %s (%q), want interrupted", got.Status, got.Error)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+
+	queued, _ := New("sync", nil)
+	_ = queued.Save()
+	got, err := Cancel(queued.ID)
+	if err != nil || got.Status != StatusCancelled {
+		t.Fatalf("Cancel(queued) = %+v, %v", got, err)
+	}
+	if _, err := Cancel(queued.ID); err == nil {
+		t.Error("Cancel() of a finished job should fail")
+	}
+
+	running, _ := New("sync", nil)
+	ctx, stop, err := running.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	if _, err := Cancel(running.ID); err != nil {
+		t.Fatalf("Cancel(running) error = %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("job context was not cancelled")
+	}
+	if err := running.Finish(ctx.Err()); err != nil {
+		t.Fatal(err)
+	}
+	if running.Status != StatusCancelled || CancelRequested(running.ID) {
+		t.Errorf("status = %s, cancel marker kept = %v", running.Status, CancelRequested(running.ID))
+	}
+}
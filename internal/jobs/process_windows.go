@@ -0,0 +1,9 @@
+//go:build windows
+
+package jobs
+
+// processAlive cannot check liveness on Windows without platform-specific
+// APIs, so running jobs are left as they are rather than marked interrupted.
+func processAlive(pid int) bool {
+	return true
+}
@@ -0,0 +1,265 @@
+// Package namespace splits one caam installation into separate vaults for
+// the people sharing a machine.
+//
+// A namespace is a directory under <base>/namespaces/<name> that serves as
+// CAAM_HOME for everything caam stores: the vault, profiles, the database,
+// and config. Entering a namespace repoints CAAM_HOME there, so the rest of
+// caam needs no changes and child processes (exec, run, the daemon) stay in
+// the same namespace.
+//
+// The namespace named "shared" holds a pool everyone can use. Its policy
+// decides who may change it: anyone (open), only its writers (read_only), or
+// anyone holding a lease on the profile they activate (lease).
+package namespace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Env selects the namespace when --namespace is not given.
+	Env = "CAAM_NAMESPACE"
+
+	// BaseHomeEnv records the CAAM_HOME in effect before a namespace was
+	// entered, so child processes resolve namespaces from the same place.
+	BaseHomeEnv = "CAAM_BASE_HOME"
+
+	// UserEnv overrides the caller name used for writer checks and leases.
+	UserEnv = "CAAM_USER"
+
+	// Shared is the namespace holding the shared account pool.
+	Shared = "shared"
+
+	// DefaultLeaseTTL is how long a lease taken on activation lasts when
+	// the shared policy does not say.
+	DefaultLeaseTTL = 2 * time.Hour
+)
+
+// Access modes for the shared namespace.
+const (
+	ModeOpen     = "open"
+	ModeReadOnly = "read_only"
+	ModeLease    = "lease"
+)
+
+var nameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ErrInvalidName is returned for namespace names that are not usable as a
+// directory name.
+var ErrInvalidName = errors.New("namespace names are 1-32 lowercase letters, digits, '-' or '_'")
+
+// Validate checks that name is a usable namespace name.
+func Validate(name string) error {
+	if !nameRe.MatchString(name) {
+		return fmt.Errorf("%q: %w", name, ErrInvalidName)
+	}
+	return nil
+}
+
+// BaseHome returns the caam home that namespaces live under.
+func BaseHome() string {
+	if base := os.Getenv(BaseHomeEnv); base != "" {
+		return base
+	}
+	if caamHome := os.Getenv("CAAM_HOME"); caamHome != "" {
+		return caamHome
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ".caam"
+	}
+	return filepath.Join(homeDir, ".caam")
+}
+
+// Dir returns the home directory of namespace name under base.
+func Dir(base, name string) string {
+	return filepath.Join(base, "namespaces", name)
+}
+
+// Current returns the namespace in effect, or "" for the default one.
+func Current() string {
+	return os.Getenv(Env)
+}
+
+// Enter makes name the namespace for this process and its children by
+// pointing CAAM_HOME at its directory, creating it if needed. Entering the
+// namespace that is already active does nothing.
+func Enter(name string) (string, error) {
+	if err := Validate(name); err != nil {
+		return "", err
+	}
+	base := BaseHome()
+	dir := Dir(base, name)
+
+	// The shared namespace is used by several accounts, so its group may
+	// read and write it; personal namespaces stay private.
+	perm := os.FileMode(0700)
+	if name == Shared {
+		perm = 0770
+	}
+	if err := os.MkdirAll(dir, perm); err != nil {
+		return "", fmt.Errorf("create namespace %s: %w", name, err)
+	}
+
+	for key, value := range map[string]string{BaseHomeEnv: base, "CAAM_HOME": dir, Env: name} {
+		if err := os.Setenv(key, value); err != nil {
+			return "", fmt.Errorf("set %s: %w", key, err)
+		}
+	}
+	return dir, nil
+}
+
+// List returns the namespaces that exist under base, sorted by name.
+func List(base string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(base, "namespaces"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read namespaces: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && Validate(e.Name()) == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Caller returns the name of the person running caam: CAAM_USER if set,
+// otherwise the OS user name.
+func Caller() string {
+	if name := strings.TrimSpace(os.Getenv(UserEnv)); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Policy controls who may change the shared namespace.
+type Policy struct {
+	// Mode is open, read_only, or lease.
+	Mode string `json:"mode"`
+
+	// Writers may add, remove, and re-login profiles in read_only and
+	// lease mode, and change the policy.
+	Writers []string `json:"writers,omitempty"`
+
+	// LeaseTTL is how long an activation lease lasts in lease mode.
+	LeaseTTL string `json:"lease_ttl,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+}
+
+// Validate checks the mode and lease TTL.
+func (p Policy) Validate() error {
+	switch p.Mode {
+	case ModeOpen, ModeReadOnly, ModeLease:
+	default:
+		return fmt.Errorf("mode must be %s, %s, or %s (got %q)", ModeOpen, ModeReadOnly, ModeLease, p.Mode)
+	}
+	if p.LeaseTTL != "" {
+		ttl, err := time.ParseDuration(p.LeaseTTL)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("lease_ttl must be a positive duration (got %q)", p.LeaseTTL)
+		}
+	}
+	return nil
+}
+
+// IsWriter reports whether caller is one of the policy's writers.
+func (p Policy) IsWriter(caller string) bool {
+	for _, w := range p.Writers {
+		if w == caller {
+			return true
+		}
+	}
+	return false
+}
+
+// CanWrite reports whether caller may change the namespace's profiles.
+// With no writers configured, everyone may.
+func (p Policy) CanWrite(caller string) bool {
+	if p.Mode == ModeOpen || len(p.Writers) == 0 {
+		return true
+	}
+	return p.IsWriter(caller)
+}
+
+// NeedsLease reports whether activating a profile requires a lease.
+func (p Policy) NeedsLease() bool {
+	return p.Mode == ModeLease
+}
+
+// TTL returns the activation lease duration.
+func (p Policy) TTL() time.Duration {
+	if ttl, err := time.ParseDuration(p.LeaseTTL); err == nil && ttl > 0 {
+		return ttl
+	}
+	return DefaultLeaseTTL
+}
+
+func policyPath(base string) string {
+	return filepath.Join(Dir(base, Shared), "policy.json")
+}
+
+// LoadPolicy reads the shared namespace policy under base. A missing policy
+// file means open access.
+func LoadPolicy(base string) (Policy, error) {
+	data, err := os.ReadFile(policyPath(base))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Policy{Mode: ModeOpen}, nil
+		}
+		return Policy{}, fmt.Errorf("read namespace policy: %w", err)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Policy{}, fmt.Errorf("parse namespace policy: %w", err)
+	}
+	if p.Mode == "" {
+		p.Mode = ModeOpen
+	}
+	if err := p.Validate(); err != nil {
+		return Policy{}, fmt.Errorf("namespace policy: %w", err)
+	}
+	return p, nil
+}
+
+// SavePolicy writes the shared namespace policy under base.
+func SavePolicy(base string, p Policy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	path := policyPath(base)
+	if err := os.MkdirAll(filepath.Dir(path), 0770); err != nil {
+		return fmt.Errorf("create shared namespace: %w", err)
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal namespace policy: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0640); err != nil {
+		return fmt.Errorf("write namespace policy: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("save namespace policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package namespace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	for _, name := range []string{"alice", "team-a", "bob_2", "shared"} {
+		if err := Validate(name); err != nil {
+			t.Errorf("Validate(%q) error = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "Alice", "../etc", "a b", "-x", "abcdefghijklmnopqrstuvwxyz0123456789"} {
+		if err := Validate(name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Validate(%q) error = %v, want ErrInvalidName", name, err)
+		}
+	}
+}
+
+func TestEnter(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("CAAM_HOME", base)
+	t.Setenv(BaseHomeEnv, "")
+	t.Setenv(Env, "")
+
+	dir, err := Enter("alice")
+	if err != nil {
+		t.Fatalf("Enter() error = %v", err)
+	}
+	if dir != filepath.Join(base, "namespaces", "alice") {
+		t.Errorf("dir = %q", dir)
+	}
+	if os.Getenv("CAAM_HOME") != dir || os.Getenv(BaseHomeEnv) != base || Current() != "alice" {
+		t.Errorf("env after Enter: CAAM_HOME=%q base=%q current=%q", os.Getenv("CAAM_HOME"), os.Getenv(BaseHomeEnv), Current())
+	}
+
+	// Entering again from inside a namespace resolves against the same base.
+	if dir, err := Enter("shared"); err != nil || dir != filepath.Join(base, "namespaces", "shared") {
+		t.Fatalf("Enter(shared) = %q, %v", dir, err)
+	}
+
+	names, err := List(base)
+	if err != nil || len(names) != 2 || names[0] != "alice" || names[1] != "shared" {
+		t.Errorf("List() = %v, %v", names, err)
+	}
+
+	if _, err := Enter("../escape"); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("Enter(../escape) error = %v", err)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	base := t.TempDir()
+
+	p, err := LoadPolicy(base)
+	if err != nil || p.Mode != ModeOpen || !p.CanWrite("anyone") || p.NeedsLease() {
+		t.Fatalf("default policy = %+v, %v", p, err)
+	}
+
+	p = Policy{Mode: ModeLease, Writers: []string{"alice"}, LeaseTTL: "90m"}
+	if err := SavePolicy(base, p); err != nil {
+		t.Fatalf("SavePolicy() error = %v", err)
+	}
+	got, err := LoadPolicy(base)
+	if err != nil {
+		t.Fatalf("LoadPolicy() error = %v", err)
+	}
+	if !got.NeedsLease() || got.TTL() != 90*time.Minute {
+		t.Errorf("policy = %+v", got)
+	}
+	if !got.CanWrite("alice") || got.CanWrite("bob") {
+		t.Errorf("CanWrite: alice=%v bob=%v", got.CanWrite("alice"), got.CanWrite("bob"))
+	}
+
+	if err := SavePolicy(base, Policy{Mode: "locked"}); err == nil {
+		t.Error("SavePolicy accepted an unknown mode")
+	}
+	if err := SavePolicy(base, Policy{Mode: ModeLease, LeaseTTL: "-1h"}); err == nil {
+		t.Error("SavePolicy accepted a negative lease TTL")
+	}
+}
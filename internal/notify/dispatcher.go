@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// Dispatcher sends alerts for named events, dropping events that are
+// disabled and repeats of the same event and key within the rate limit
+// window.
+type Dispatcher struct {
+	Notifier Notifier
+
+	// Enabled reports whether an event should be sent. Nil enables all.
+	Enabled func(event string) bool
+
+	// Window is the minimum time between alerts for the same event and
+	// key. Zero disables rate limiting.
+	Window time.Duration
+
+	now func() time.Time
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// NewDispatcher returns a dispatcher that sends through n.
+func NewDispatcher(n Notifier, enabled func(event string) bool, window time.Duration) *Dispatcher {
+	return &Dispatcher{
+		Notifier: n,
+		Enabled:  enabled,
+		Window:   window,
+	}
+}
+
+// Send delivers alert for event. The key (usually provider/profile)
+// scopes rate limiting so one noisy profile doesn't silence the others.
+// It reports whether the alert was sent.
+func (d *Dispatcher) Send(event, key string, alert *Alert) (bool, error) {
+	if d == nil || d.Notifier == nil {
+		return false, nil
+	}
+	if d.Enabled != nil && !d.Enabled(event) {
+		return false, nil
+	}
+
+	now := time.Now()
+	if d.now != nil {
+		now = d.now()
+	}
+
+	id := event + "\x00" + key
+	d.mu.Lock()
+	if last, ok := d.last[id]; ok && d.Window > 0 && now.Sub(last) < d.Window {
+		d.mu.Unlock()
+		return false, nil
+	}
+	if d.last == nil {
+		d.last = make(map[string]time.Time)
+	}
+	d.last[id] = now
+	d.mu.Unlock()
+
+	alert.Event = event
+	if alert.Timestamp.IsZero() {
+		alert.Timestamp = now
+	}
+	return true, d.Notifier.Notify(alert)
+}
+
+// Reset forgets when event was last sent for key, so the next occurrence is
+// sent immediately. Use it when the condition clears.
+func (d *Dispatcher) Reset(event, key string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	delete(d.last, event+"\x00"+key)
+	d.mu.Unlock()
+}
@@ -34,6 +34,7 @@ type Alert struct {
 	Profile   string
 	Timestamp time.Time
 	Action    string // Suggested action for the user
+	Event     string // Event that raised the alert, e.g. "cooldown_started"
 }
 
 // Notifier defines the interface for delivering notifications.
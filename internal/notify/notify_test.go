@@ -94,3 +94,83 @@ func TestMultiNotifier(t *testing.T) {
 		t.Fatal("Expected error from n2")
 	}
 }
+
+func TestSlackNotifier(t *testing.T) {
+	var text string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		text = payload["text"]
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := NewSlackNotifier(server.URL)
+	err := n.Notify(&Alert{
+		Level:   Critical,
+		Title:   "All claude profiles blocked",
+		Message: "Every profile is in cooldown",
+		Profile: "claude/work",
+	})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	for _, want := range []string{":rotating_light:", "*All claude profiles blocked*", "`claude/work`", "Every profile is in cooldown"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text %q missing %q", text, want)
+		}
+	}
+}
+
+type countingNotifier struct {
+	alerts []*Alert
+}
+
+func (c *countingNotifier) Notify(alert *Alert) error { c.alerts = append(c.alerts, alert); return nil }
+func (c *countingNotifier) Name() string              { return "counting" }
+func (c *countingNotifier) Available() bool           { return true }
+
+func TestDispatcher(t *testing.T) {
+	counter := &countingNotifier{}
+	d := NewDispatcher(counter, func(event string) bool { return event != "sync_failed" }, time.Hour)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	send := func(event, key string) bool {
+		sent, err := d.Send(event, key, &Alert{Title: event})
+		if err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		return sent
+	}
+
+	if !send("cooldown_started", "claude/a") {
+		t.Error("first alert was not sent")
+	}
+	if send("cooldown_started", "claude/a") {
+		t.Error("repeat within window was sent")
+	}
+	if !send("cooldown_started", "claude/b") {
+		t.Error("alert for another key was rate limited")
+	}
+	if send("sync_failed", "hub") {
+		t.Error("disabled event was sent")
+	}
+
+	now = now.Add(time.Hour)
+	if !send("cooldown_started", "claude/a") {
+		t.Error("alert after window was not sent")
+	}
+
+	d.Reset("cooldown_started", "claude/a")
+	if !send("cooldown_started", "claude/a") {
+		t.Error("alert after Reset was not sent")
+	}
+
+	if len(counter.alerts) != 4 {
+		t.Fatalf("delivered %d alerts, want 4", len(counter.alerts))
+	}
+	if counter.alerts[0].Event != "cooldown_started" || !counter.alerts[0].Timestamp.Equal(now.Add(-time.Hour)) {
+		t.Errorf("alert = %+v, want event and timestamp set", counter.alerts[0])
+	}
+}
@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SlackNotifier delivers alerts to a Slack incoming webhook.
+type SlackNotifier struct {
+	URL     string
+	Timeout time.Duration
+}
+
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{
+		URL:     url,
+		Timeout: 5 * time.Second,
+	}
+}
+
+func (n *SlackNotifier) Name() string {
+	return "slack"
+}
+
+func (n *SlackNotifier) Available() bool {
+	return n.URL != ""
+}
+
+func (n *SlackNotifier) Notify(alert *Alert) error {
+	if !n.Available() {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]string{"text": slackText(alert)})
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	client := &http.Client{
+		Timeout: n.Timeout,
+	}
+
+	resp, err := client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("slack webhook failed with status: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// slackText formats an alert as Slack mrkdwn.
+func slackText(alert *Alert) string {
+	icon := ":information_source:"
+	switch alert.Level {
+	case Warning:
+		icon = ":warning:"
+	case Critical:
+		icon = ":rotating_light:"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s *%s*", icon, alert.Title)
+	if alert.Profile != "" {
+		fmt.Fprintf(&b, " (`%s`)", alert.Profile)
+	}
+	if alert.Message != "" {
+		fmt.Fprintf(&b, "\n%s", alert.Message)
+	}
+	if alert.Action != "" {
+		fmt.Fprintf(&b, "\n> %s", alert.Action)
+	}
+	return b.String()
+}
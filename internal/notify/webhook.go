@@ -44,6 +44,9 @@ func (n *WebhookNotifier) Notify(alert *Alert) error {
 		"timestamp": alert.Timestamp.Format(time.RFC3339),
 		"action":    alert.Action,
 	}
+	if alert.Event != "" {
+		payload["event"] = alert.Event
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
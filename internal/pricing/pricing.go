@@ -110,6 +110,16 @@ func CalculateCost(usage *logs.TokenUsage, model, provider string) float64 {
 	)
 }
 
+// TokenCost prices token counts for one provider+model. It reports false
+// when the model has no known price.
+func TokenCost(provider, model string, input, output, cacheRead, cacheCreate int64) (float64, bool) {
+	price, ok := PriceFor(provider, model)
+	if !ok {
+		return 0, false
+	}
+	return costFromTokens(input, output, cacheRead, cacheCreate, price), true
+}
+
 func calculateCostAllModels(usage *logs.TokenUsage, provider string) float64 {
 	if usage == nil || len(usage.ByModel) == 0 {
 		return 0
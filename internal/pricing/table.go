@@ -0,0 +1,53 @@
+package pricing
+
+// Table prices usage with configured per-model prices ahead of the built-in
+// ones. A nil Table uses the built-in prices only.
+type Table struct {
+	overrides map[string]TokenPrice
+}
+
+// NewTable returns an empty price table.
+func NewTable() *Table {
+	return &Table{overrides: make(map[string]TokenPrice)}
+}
+
+// Set configures the price of a provider's model. An empty model or "*"
+// sets the provider's fallback price for models without a price of their
+// own, built-in or configured.
+func (t *Table) Set(provider, model string, price TokenPrice) {
+	t.overrides[tableKey(provider, model)] = price
+}
+
+// PriceFor returns the configured price of a model, then its built-in
+// price, then the provider's configured fallback.
+func (t *Table) PriceFor(provider, model string) (TokenPrice, bool) {
+	if t == nil {
+		return PriceFor(provider, model)
+	}
+	if price, ok := t.overrides[tableKey(provider, model)]; ok && model != "" && model != "*" {
+		return price, true
+	}
+	if price, ok := PriceFor(provider, model); ok {
+		return price, true
+	}
+	price, ok := t.overrides[tableKey(provider, "")]
+	return price, ok
+}
+
+// TokenCost prices token counts like the package-level TokenCost, using the
+// table's prices.
+func (t *Table) TokenCost(provider, model string, input, output, cacheRead, cacheCreate int64) (float64, bool) {
+	price, ok := t.PriceFor(provider, model)
+	if !ok {
+		return 0, false
+	}
+	return costFromTokens(input, output, cacheRead, cacheCreate, price), true
+}
+
+func tableKey(provider, model string) string {
+	provider = normalizeProvider(provider)
+	if model == "*" {
+		model = ""
+	}
+	return provider + "/" + normalizeModelForProvider(provider, model)
+}
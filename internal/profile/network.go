@@ -0,0 +1,62 @@
+package profile
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// Network is the network fingerprint a profile presents when it is run
+// through caam run or caam launch, so rotated accounts don't all come from
+// the same address with the same timezone and locale.
+type Network struct {
+	// Proxy is an http, https, socks5 or socks5h proxy URL. It overrides
+	// stealth.network in config.yaml.
+	Proxy string `json:"proxy,omitempty"`
+
+	// Interface is the network interface traffic leaves through, e.g.
+	// "wg1". Traffic is sent from the interface's address by a local relay.
+	Interface string `json:"interface,omitempty"`
+
+	// Timezone is an IANA zone such as "Europe/Berlin", exported as TZ.
+	Timezone string `json:"timezone,omitempty"`
+
+	// Locale such as "de_DE.UTF-8", exported as LANG and LC_ALL.
+	Locale string `json:"locale,omitempty"`
+}
+
+// IsZero reports whether n sets nothing.
+func (n *Network) IsZero() bool {
+	return n == nil || *n == Network{}
+}
+
+var localePattern = regexp.MustCompile(`^([A-Za-z]{2,3}(_[A-Za-z]{2})?(\.[A-Za-z0-9-]+)?(@[A-Za-z0-9]+)?|C(\.[A-Za-z0-9-]+)?|POSIX)$`)
+
+// Validate checks the settings are well formed. It does not check that the
+// interface exists, since it may only be up while the profile runs.
+func (n *Network) Validate() error {
+	if n == nil {
+		return nil
+	}
+	if n.Proxy != "" {
+		u, err := url.Parse(n.Proxy)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("proxy must be a URL like socks5://host:port")
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("proxy scheme must be http, https, socks5 or socks5h")
+		}
+	}
+	if n.Timezone != "" {
+		if _, err := time.LoadLocation(n.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", n.Timezone)
+		}
+	}
+	if n.Locale != "" && !localePattern.MatchString(n.Locale) {
+		return fmt.Errorf("locale %q should look like en_US.UTF-8", n.Locale)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNetworkValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		n    *Network
+		want string
+	}{
+		{"nil", nil, ""},
+		{"full", &Network{Proxy: "socks5://10.0.0.2:1080", Interface: "wg1", Timezone: "Europe/Berlin", Locale: "de_DE.UTF-8"}, ""},
+		{"posix locale", &Network{Locale: "C.UTF-8"}, ""},
+		{"proxy without scheme", &Network{Proxy: "10.0.0.2:1080"}, "proxy"},
+		{"ftp proxy", &Network{Proxy: "ftp://host:21"}, "scheme"},
+		{"bad timezone", &Network{Timezone: "Mars/Olympus"}, "timezone"},
+		{"bad locale", &Network{Locale: "german please"}, "locale"},
+	}
+	for _, tt := range tests {
+		err := tt.n.Validate()
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: error = %v, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+
+	if !(*Network)(nil).IsZero() || !(&Network{}).IsZero() || (&Network{Locale: "C"}).IsZero() {
+		t.Error("IsZero is wrong")
+	}
+}
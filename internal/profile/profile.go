@@ -83,6 +83,10 @@ type Profile struct {
 	// Examples: "Work Google", "Personal GitHub"
 	// Used for display purposes only.
 	BrowserProfileName string `json:"browser_profile_name,omitempty"`
+
+	// Network is the proxy, egress interface, timezone and locale the
+	// profile runs with.
+	Network *Network `json:"network,omitempty"`
 }
 
 // HomePath returns the pseudo-HOME directory for this profile.
@@ -97,6 +101,21 @@ func (p *Profile) XDGConfigPath() string {
 	return filepath.Join(p.BasePath, "xdg_config")
 }
 
+// XDGDataPath returns the pseudo-XDG_DATA_HOME directory.
+func (p *Profile) XDGDataPath() string {
+	return filepath.Join(p.BasePath, "xdg_data")
+}
+
+// XDGCachePath returns the pseudo-XDG_CACHE_HOME directory.
+func (p *Profile) XDGCachePath() string {
+	return filepath.Join(p.BasePath, "xdg_cache")
+}
+
+// XDGStatePath returns the pseudo-XDG_STATE_HOME directory.
+func (p *Profile) XDGStatePath() string {
+	return filepath.Join(p.BasePath, "xdg_state")
+}
+
 // CodexHomePath returns the CODEX_HOME directory for this profile.
 // Codex CLI specifically uses this for auth.json.
 func (p *Profile) CodexHomePath() string {
@@ -139,6 +158,24 @@ func (p *Profile) LoadIdentity() {
 			candidates = append(candidates, filepath.Join(p.BasePath, "gcloud", "application_default_credentials.json"))
 		}
 		id = loadIdentityFromPaths(candidates, identity.ExtractFromGeminiConfig)
+	case "cursor":
+		id = loadIdentityFromPaths([]string{
+			filepath.Join(p.XDGConfigPath(), "cursor", "auth.json"),
+		}, identity.ExtractFromCursorAuth)
+		// The account email and plan live under HOME rather than beside auth.json.
+		if acct, err := identity.ExtractFromCursorConfig(filepath.Join(p.HomePath(), ".cursor", "cli-config.json")); err == nil {
+			if id == nil {
+				id = acct
+			} else if id.Email == "" {
+				id.Email = acct.Email
+				id.PlanType = acct.PlanType
+			}
+		}
+	case "copilot":
+		id = loadIdentityFromPaths([]string{
+			filepath.Join(p.XDGConfigPath(), "github-copilot", "hosts.json"),
+			filepath.Join(p.XDGConfigPath(), "github-copilot", "apps.json"),
+		}, identity.ExtractFromCopilotHosts)
 	}
 
 	if id != nil {
@@ -0,0 +1,192 @@
+// Package progress emits machine-readable progress events for long-running
+// commands.
+//
+// Commands that can take minutes (sync, import, export, distributed setup)
+// accept --progress json and write one JSON object per line (NDJSON) to
+// stderr as they work, so wrapping UIs and agents can render a progress bar
+// instead of watching an opaque command.
+//
+// A nil *Reporter is valid and discards everything, so callers can thread
+// one through unconditionally.
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	EventStart    = "start"
+	EventPhase    = "phase"
+	EventProgress = "progress"
+	EventDone     = "done"
+)
+
+// Event is a single NDJSON progress line.
+type Event struct {
+	Type      string  `json:"type"`
+	Operation string  `json:"operation"`
+	Phase     string  `json:"phase,omitempty"`
+	Current   int     `json:"current"`
+	Total     int     `json:"total"`
+	Percent   float64 `json:"percent"`
+	Item      string  `json:"item,omitempty"`
+	Status    string  `json:"status,omitempty"`
+	Message   string  `json:"message,omitempty"`
+	ElapsedMs int64   `json:"elapsed_ms"`
+	ETAMs     int64   `json:"eta_ms,omitempty"`
+	Timestamp string  `json:"timestamp"`
+}
+
+// Reporter writes progress events for one operation.
+type Reporter struct {
+	mu         sync.Mutex
+	enc        *json.Encoder
+	operation  string
+	start      time.Time
+	phase      string
+	phaseStart time.Time
+	current    int
+	total      int
+	observers  []func(Event)
+
+	now func() time.Time // for tests
+}
+
+// New returns a Reporter that writes NDJSON events to w and emits a start
+// event.
+func New(w io.Writer, operation string) *Reporter {
+	r := &Reporter{
+		enc:       json.NewEncoder(w),
+		operation: operation,
+		now:       time.Now,
+	}
+	r.start = r.now()
+	r.phaseStart = r.start
+	r.emit(EventStart, "", "", "")
+	return r
+}
+
+// FromMode returns a Reporter for a --progress flag value: "json" reports to
+// w, while "" and "none" return nil.
+func FromMode(mode string, w io.Writer, operation string) (*Reporter, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		return nil, nil
+	case "json":
+		return New(w, operation), nil
+	default:
+		return nil, fmt.Errorf("invalid --progress %q (valid: json, none)", mode)
+	}
+}
+
+// Observe calls fn with every event emitted after it is registered, in
+// addition to writing it out.
+func (r *Reporter) Observe(fn func(Event)) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observers = append(r.observers, fn)
+}
+
+// Phase starts a new phase with the given number of items (0 if unknown)
+// and resets the item counter.
+func (r *Reporter) Phase(name string, total int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phase = name
+	r.phaseStart = r.now()
+	r.current = 0
+	r.total = total
+	r.emit(EventPhase, "", "", "")
+}
+
+// SetTotal updates the item count for the current phase once it's known.
+func (r *Reporter) SetTotal(total int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.total = total
+}
+
+// Update reports work on an item without counting it as finished.
+func (r *Reporter) Update(item, status, message string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emit(EventProgress, item, status, message)
+}
+
+// Advance marks an item finished and reports it.
+func (r *Reporter) Advance(item, status, message string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.current++
+	if r.total > 0 && r.current > r.total {
+		r.total = r.current
+	}
+	r.emit(EventProgress, item, status, message)
+}
+
+// Done emits the final event. A non-nil err is reported as a failure.
+func (r *Reporter) Done(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.emit(EventDone, "", "failed", err.Error())
+		return
+	}
+	r.emit(EventDone, "", "ok", "")
+}
+
+// emit writes one event. Callers must hold mu (or be in New).
+func (r *Reporter) emit(eventType, item, status, message string) {
+	now := r.now()
+	ev := Event{
+		Type:      eventType,
+		Operation: r.operation,
+		Phase:     r.phase,
+		Current:   r.current,
+		Total:     r.total,
+		Item:      item,
+		Status:    status,
+		Message:   message,
+		ElapsedMs: now.Sub(r.start).Milliseconds(),
+		Timestamp: now.UTC().Format(time.RFC3339),
+	}
+	if r.total > 0 {
+		ev.Percent = float64(int(float64(r.current)/float64(r.total)*1000)) / 10
+		if r.current > 0 && r.current < r.total {
+			perItem := now.Sub(r.phaseStart) / time.Duration(r.current)
+			ev.ETAMs = (perItem * time.Duration(r.total-r.current)).Milliseconds()
+		}
+	}
+	if eventType == EventDone && status == "ok" {
+		ev.Percent = 100
+	}
+	// Progress is best-effort; a closed stderr shouldn't fail the command.
+	_ = r.enc.Encode(ev)
+	for _, fn := range r.observers {
+		fn(ev)
+	}
+}
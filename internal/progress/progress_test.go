@@ -0,0 +1,121 @@
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeEvents(t *testing.T, buf *bytes.Buffer) []Event {
+	t.Helper()
+	var events []Event
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var ev Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &ev), "line: %s", scanner.Text())
+		events = append(events, ev)
+	}
+	return events
+}
+
+func TestReporter(t *testing.T) {
+	var buf bytes.Buffer
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	r := &Reporter{now: func() time.Time { return clock }}
+	r.enc = json.NewEncoder(&buf)
+	r.operation = "sync"
+	r.start = clock
+	r.emit(EventStart, "", "", "")
+
+	r.Phase("push", 4)
+	clock = clock.Add(2 * time.Second)
+	r.Advance("claude/work", "ok", "")
+	r.Update("claude/home", "running", "connecting")
+	r.Done(nil)
+
+	events := decodeEvents(t, &buf)
+	require.Len(t, events, 5)
+
+	assert.Equal(t, EventStart, events[0].Type)
+	assert.Equal(t, "sync", events[0].Operation)
+
+	assert.Equal(t, EventPhase, events[1].Type)
+	assert.Equal(t, "push", events[1].Phase)
+	assert.Equal(t, 4, events[1].Total)
+
+	adv := events[2]
+	assert.Equal(t, EventProgress, adv.Type)
+	assert.Equal(t, 1, adv.Current)
+	assert.Equal(t, 25.0, adv.Percent)
+	assert.Equal(t, "claude/work", adv.Item)
+	assert.Equal(t, int64(2000), adv.ElapsedMs)
+	assert.Equal(t, int64(6000), adv.ETAMs, "3 items left at 2s each")
+
+	assert.Equal(t, 1, events[3].Current, "Update does not advance")
+	assert.Equal(t, "connecting", events[3].Message)
+
+	assert.Equal(t, EventDone, events[4].Type)
+	assert.Equal(t, "ok", events[4].Status)
+	assert.Equal(t, 100.0, events[4].Percent)
+}
+
+func TestReporter_DoneWithError(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, "import")
+	r.Done(errors.New("checksum mismatch"))
+
+	events := decodeEvents(t, &buf)
+	require.Len(t, events, 2)
+	assert.Equal(t, "failed", events[1].Status)
+	assert.Equal(t, "checksum mismatch", events[1].Message)
+}
+
+func TestReporter_Nil(t *testing.T) {
+	var r *Reporter
+	r.Phase("x", 1)
+	r.SetTotal(2)
+	r.Update("a", "", "")
+	r.Advance("a", "", "")
+	r.Done(nil)
+}
+
+func TestFromMode(t *testing.T) {
+	var buf bytes.Buffer
+
+	r, err := FromMode("", &buf, "export")
+	require.NoError(t, err)
+	assert.Nil(t, r)
+
+	r, err = FromMode("none", &buf, "export")
+	require.NoError(t, err)
+	assert.Nil(t, r)
+
+	r, err = FromMode("JSON", &buf, "export")
+	require.NoError(t, err)
+	assert.NotNil(t, r)
+
+	_, err = FromMode("bar", &buf, "export")
+	assert.Error(t, err)
+}
+
+func TestReporter_Observe(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, "sync")
+
+	var seen []Event
+	r.Observe(func(ev Event) { seen = append(seen, ev) })
+	r.Phase("machine:laptop", 1)
+	r.Advance("claude/work", "pushed", "")
+
+	require.Len(t, seen, 2)
+	assert.Equal(t, EventPhase, seen[0].Type)
+	assert.Equal(t, "claude/work", seen[1].Item)
+	assert.Len(t, decodeEvents(t, &buf), 3)
+}
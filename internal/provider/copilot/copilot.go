@@ -0,0 +1,401 @@
+// Package copilot implements the provider adapter for the GitHub Copilot CLI.
+//
+// Authentication mechanics:
+// - `/login` inside `copilot` runs GitHub's device-code flow (github.com/login/device).
+// - The GitHub token is stored per host in $XDG_CONFIG_HOME/github-copilot/hosts.json.
+// - GitHub App sign-ins write apps.json in the same directory instead.
+// - Token alternative: GH_TOKEN / GITHUB_TOKEN in the environment.
+//
+// Context isolation for caam:
+// - Set XDG_CONFIG_HOME to the profile's xdg_config directory.
+//
+// Auth file swapping (PRIMARY use case):
+// - Backup hosts.json after logging in with each Copilot seat
+// - Restore to instantly switch seats without the device-code flow
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/passthrough"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+)
+
+// authFileNames are the files Copilot may keep its sign-in in, preferred first.
+var authFileNames = []string{"hosts.json", "apps.json"}
+
+// Provider implements the GitHub Copilot CLI adapter.
+type Provider struct{}
+
+// New creates a new Copilot provider.
+func New() *Provider {
+	return &Provider{}
+}
+
+// ID returns the provider identifier.
+func (p *Provider) ID() string {
+	return "copilot"
+}
+
+// DisplayName returns the human-friendly name.
+func (p *Provider) DisplayName() string {
+	return "GitHub Copilot CLI"
+}
+
+// DefaultBin returns the default binary name.
+func (p *Provider) DefaultBin() string {
+	return "copilot"
+}
+
+// SupportedAuthModes returns the authentication modes supported by Copilot.
+func (p *Provider) SupportedAuthModes() []provider.AuthMode {
+	return []provider.AuthMode{
+		provider.AuthModeDeviceCode, // /login device-code flow
+		provider.AuthModeAPIKey,     // GH_TOKEN / GITHUB_TOKEN
+	}
+}
+
+// copilotConfigDir returns the directory Copilot keeps hosts.json in.
+func copilotConfigDir() string {
+	xdgConfig := os.Getenv("XDG_CONFIG_HOME")
+	if xdgConfig == "" {
+		homeDir, _ := os.UserHomeDir()
+		xdgConfig = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(xdgConfig, "github-copilot")
+}
+
+func profileConfigDir(prof *profile.Profile) string {
+	return filepath.Join(prof.XDGConfigPath(), "github-copilot")
+}
+
+// profileAuthPath returns the first Copilot auth file in the profile with a
+// signed-in host, falling back to the first one present, or "" when it has
+// none.
+func profileAuthPath(prof *profile.Profile) string {
+	existing := ""
+	for _, name := range authFileNames {
+		path := filepath.Join(profileConfigDir(prof), name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if _, err := identity.ExtractFromCopilotHosts(path); err == nil {
+			return path
+		}
+		if existing == "" {
+			existing = path
+		}
+	}
+	return existing
+}
+
+// AuthFiles returns the auth file specifications for Copilot.
+func (p *Provider) AuthFiles() []provider.AuthFileSpec {
+	dir := copilotConfigDir()
+	return []provider.AuthFileSpec{
+		{
+			Path:        filepath.Join(dir, "hosts.json"),
+			Description: "GitHub Copilot OAuth token per GitHub host",
+			Required:    false,
+		},
+		{
+			Path:        filepath.Join(dir, "apps.json"),
+			Description: "GitHub Copilot app tokens per GitHub host",
+			Required:    false,
+		},
+	}
+}
+
+// PrepareProfile sets up the profile directory structure.
+func (p *Provider) PrepareProfile(ctx context.Context, prof *profile.Profile) error {
+	if err := os.MkdirAll(profileConfigDir(prof), 0700); err != nil {
+		return fmt.Errorf("create xdg_config: %w", err)
+	}
+
+	homePath := prof.HomePath()
+	if err := os.MkdirAll(homePath, 0700); err != nil {
+		return fmt.Errorf("create home: %w", err)
+	}
+
+	mgr, err := passthrough.NewManager()
+	if err != nil {
+		return fmt.Errorf("create passthrough manager: %w", err)
+	}
+	if err := mgr.SetupPassthroughs(homePath); err != nil {
+		return fmt.Errorf("setup passthroughs: %w", err)
+	}
+
+	return nil
+}
+
+// Env returns the environment variables for running Copilot in this profile's context.
+func (p *Provider) Env(ctx context.Context, prof *profile.Profile) (map[string]string, error) {
+	env := map[string]string{
+		"HOME":            prof.HomePath(),
+		"XDG_CONFIG_HOME": prof.XDGConfigPath(),
+	}
+	return env, nil
+}
+
+// Login initiates the authentication flow.
+func (p *Provider) Login(ctx context.Context, prof *profile.Profile) error {
+	if provider.AuthMode(prof.AuthMode) == provider.AuthModeAPIKey {
+		if os.Getenv("GH_TOKEN") == "" && os.Getenv("GITHUB_TOKEN") == "" {
+			return fmt.Errorf("GH_TOKEN or GITHUB_TOKEN is not set")
+		}
+		fmt.Println("Copilot CLI reads GH_TOKEN / GITHUB_TOKEN from the environment; no login needed.")
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "copilot")
+	cmd.Env = append(os.Environ(),
+		"HOME="+prof.HomePath(),
+		"XDG_CONFIG_HOME="+prof.XDGConfigPath(),
+	)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	fmt.Println("Starting GitHub Copilot CLI...")
+	fmt.Println("Type /login, then enter the code at https://github.com/login/device.")
+	fmt.Println("Exit Copilot once the login completes.")
+
+	return cmd.Run()
+}
+
+// Logout clears authentication credentials.
+func (p *Provider) Logout(ctx context.Context, prof *profile.Profile) error {
+	for _, name := range authFileNames {
+		path := filepath.Join(profileConfigDir(prof), name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Status checks the current authentication state.
+func (p *Provider) Status(ctx context.Context, prof *profile.Profile) (*provider.ProfileStatus, error) {
+	status := &provider.ProfileStatus{
+		HasLockFile: prof.IsLocked(),
+	}
+
+	if path := profileAuthPath(prof); path != "" {
+		status.LoggedIn = true
+		if id, err := identity.ExtractFromCopilotHosts(path); err == nil {
+			status.AccountID = id.AccountID
+			if !id.ExpiresAt.IsZero() {
+				status.ExpiresAt = id.ExpiresAt.Format(time.RFC3339)
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// ValidateProfile checks if the profile is correctly configured.
+func (p *Provider) ValidateProfile(ctx context.Context, prof *profile.Profile) error {
+	if _, err := os.Stat(prof.XDGConfigPath()); os.IsNotExist(err) {
+		return fmt.Errorf("xdg_config directory missing")
+	}
+
+	homePath := prof.HomePath()
+	if _, err := os.Stat(homePath); err == nil {
+		mgr, err := passthrough.NewManager()
+		if err != nil {
+			return fmt.Errorf("create passthrough manager: %w", err)
+		}
+
+		statuses, err := mgr.VerifyPassthroughs(homePath)
+		if err != nil {
+			return fmt.Errorf("verify passthroughs: %w", err)
+		}
+
+		for _, s := range statuses {
+			if s.SourceExists && !s.LinkValid {
+				return fmt.Errorf("passthrough %s is invalid: %s", s.Path, s.Error)
+			}
+		}
+	}
+
+	return nil
+}
+
+// DetectExistingAuth detects existing Copilot authentication files.
+// Locations checked:
+// - $XDG_CONFIG_HOME/github-copilot/hosts.json (default ~/.config/github-copilot/hosts.json)
+// - $XDG_CONFIG_HOME/github-copilot/apps.json
+func (p *Provider) DetectExistingAuth() (*provider.AuthDetection, error) {
+	detection := &provider.AuthDetection{
+		Provider:  p.ID(),
+		Locations: []provider.AuthLocation{},
+	}
+
+	for _, spec := range p.AuthFiles() {
+		loc := provider.AuthLocation{
+			Path:        spec.Path,
+			Description: spec.Description,
+		}
+
+		info, err := os.Stat(spec.Path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				loc.ValidationError = fmt.Sprintf("stat error: %v", err)
+			}
+			detection.Locations = append(detection.Locations, loc)
+			continue
+		}
+
+		loc.Exists = true
+		loc.LastModified = info.ModTime()
+		loc.FileSize = info.Size()
+
+		if _, err := identity.ExtractFromCopilotHosts(spec.Path); err != nil {
+			loc.ValidationError = err.Error()
+		} else {
+			loc.IsValid = true
+		}
+
+		detection.Locations = append(detection.Locations, loc)
+
+		// hosts.json is listed first, so it wins when both are signed in.
+		if loc.IsValid && detection.Primary == nil {
+			locCopy := loc
+			detection.Primary = &locCopy
+			detection.Found = true
+		}
+	}
+
+	return detection, nil
+}
+
+// ImportAuth imports a detected hosts.json or apps.json into a profile.
+func (p *Provider) ImportAuth(ctx context.Context, sourcePath string, prof *profile.Profile) ([]string, error) {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("source auth file not found: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("source path is a directory, not a file")
+	}
+
+	basename := filepath.Base(sourcePath)
+	if basename != "hosts.json" && basename != "apps.json" {
+		return nil, fmt.Errorf("%s is not a Copilot auth file", sourcePath)
+	}
+
+	target := filepath.Join(profileConfigDir(prof), basename)
+	if err := copyFile(sourcePath, target); err != nil {
+		return nil, fmt.Errorf("copy %s: %w", basename, err)
+	}
+	return []string{target}, nil
+}
+
+// copyFile copies a file from src to dst with fsync for durability.
+func copyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+
+	tmpPath := dst + ".tmp"
+	dstFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := dstFile.Sync(); err != nil {
+		dstFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := dstFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, dst)
+}
+
+// ValidateToken validates that the authentication token works.
+// Passive validation checks that a signed-in host exists and that its token,
+// if it carries an expires_at, has not expired without a refresh token.
+// Active validation falls back to the passive checks.
+func (p *Provider) ValidateToken(ctx context.Context, prof *profile.Profile, passive bool) (*provider.ValidationResult, error) {
+	result := &provider.ValidationResult{
+		Provider:  p.ID(),
+		Profile:   prof.Name,
+		Method:    "passive",
+		CheckedAt: time.Now(),
+	}
+	if !passive {
+		result.Method = "active"
+	}
+
+	path := profileAuthPath(prof)
+	if path == "" {
+		result.Error = "hosts.json not found"
+		return result, nil
+	}
+
+	id, err := identity.ExtractFromCopilotHosts(path)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	if !id.ExpiresAt.IsZero() {
+		result.ExpiresAt = id.ExpiresAt
+		if id.ExpiresAt.Before(time.Now()) && !hasRefreshToken(path) {
+			result.Error = "token has expired"
+			return result, nil
+		}
+	}
+
+	result.Valid = true
+	return result, nil
+}
+
+// hasRefreshToken reports whether any host in the file has a refresh token.
+func hasRefreshToken(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var hosts map[string]struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(data, &hosts); err != nil {
+		return false
+	}
+	for _, h := range hosts {
+		if h.RefreshToken != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Ensure Provider implements the interface.
+var _ provider.Provider = (*Provider)(nil)
@@ -0,0 +1,140 @@
+package copilot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+func writeHosts(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testProfile(t *testing.T) *profile.Profile {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	return &profile.Profile{Name: "work", Provider: "copilot", BasePath: t.TempDir()}
+}
+
+func TestProviderBasics(t *testing.T) {
+	p := New()
+	if p.ID() != "copilot" || p.DefaultBin() != "copilot" {
+		t.Errorf("ID/DefaultBin = %q/%q", p.ID(), p.DefaultBin())
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	files := p.AuthFiles()
+	if len(files) != 2 || files[0].Path != filepath.Join("/xdg", "github-copilot", "hosts.json") {
+		t.Errorf("AuthFiles = %+v", files)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	prof := testProfile(t)
+	env, err := New().Env(context.Background(), prof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env["HOME"] != prof.HomePath() || env["XDG_CONFIG_HOME"] != prof.XDGConfigPath() {
+		t.Errorf("Env = %v", env)
+	}
+}
+
+func TestStatusAndValidateToken(t *testing.T) {
+	p := New()
+	prof := testProfile(t)
+	ctx := context.Background()
+
+	if err := p.PrepareProfile(ctx, prof); err != nil {
+		t.Fatalf("PrepareProfile: %v", err)
+	}
+	if err := p.ValidateProfile(ctx, prof); err != nil {
+		t.Errorf("ValidateProfile: %v", err)
+	}
+
+	if status, _ := p.Status(ctx, prof); status.LoggedIn {
+		t.Error("logged in without hosts.json")
+	}
+	result, _ := p.ValidateToken(ctx, prof, true)
+	if result.Valid || result.Error != "hosts.json not found" {
+		t.Errorf("ValidateToken = %+v", result)
+	}
+
+	hostsPath := filepath.Join(profileConfigDir(prof), "hosts.json")
+	writeHosts(t, hostsPath, `{"github.com":{"user":"octocat","oauth_token":"gho_x"}}`)
+	status, _ := p.Status(ctx, prof)
+	if !status.LoggedIn || status.AccountID != "octocat" || status.ExpiresAt != "" {
+		t.Errorf("Status = %+v", status)
+	}
+	if result, _ := p.ValidateToken(ctx, prof, true); !result.Valid {
+		t.Errorf("ValidateToken(non-expiring) = %+v", result)
+	}
+
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	writeHosts(t, hostsPath, `{"github.com":{"user":"octocat","oauth_token":"ghu_x","expires_at":"`+past+`"}}`)
+	result, _ = p.ValidateToken(ctx, prof, true)
+	if result.Valid || result.Error != "token has expired" {
+		t.Errorf("ValidateToken(expired) = %+v", result)
+	}
+
+	writeHosts(t, hostsPath, `{"github.com":{"user":"octocat","oauth_token":"ghu_x","refresh_token":"ghr_x","expires_at":"`+past+`"}}`)
+	if result, _ := p.ValidateToken(ctx, prof, true); !result.Valid {
+		t.Errorf("ValidateToken(expired with refresh token) = %+v", result)
+	}
+
+	if err := p.Logout(ctx, prof); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := p.Status(ctx, prof); status.LoggedIn {
+		t.Error("logged in after logout")
+	}
+}
+
+func TestDetectAndImport(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	p := New()
+
+	detection, err := p.DetectExistingAuth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detection.Found {
+		t.Error("found auth in an empty directory")
+	}
+
+	appsPath := filepath.Join(xdg, "github-copilot", "apps.json")
+	writeHosts(t, appsPath, `{"github.com:Iv1.abc":{"user":"octocat","oauth_token":"ghu_x"}}`)
+	writeHosts(t, filepath.Join(xdg, "github-copilot", "hosts.json"), `{}`)
+
+	detection, _ = p.DetectExistingAuth()
+	if !detection.Found || detection.Primary == nil || detection.Primary.Path != appsPath {
+		t.Fatalf("detection = %+v, want apps.json as the only signed-in file", detection)
+	}
+
+	prof := testProfile(t)
+	copied, err := p.ImportAuth(context.Background(), appsPath, prof)
+	if err != nil {
+		t.Fatalf("ImportAuth: %v", err)
+	}
+	if len(copied) != 1 || copied[0] != filepath.Join(profileConfigDir(prof), "apps.json") {
+		t.Errorf("copied = %v", copied)
+	}
+	if status, _ := p.Status(context.Background(), prof); status.AccountID != "octocat" {
+		t.Errorf("AccountID = %q", status.AccountID)
+	}
+
+	if _, err := p.ImportAuth(context.Background(), filepath.Join(xdg, "github-copilot"), prof); err == nil {
+		t.Error("expected error importing a directory")
+	}
+}
@@ -0,0 +1,433 @@
+// Package cursor implements the provider adapter for the Cursor CLI
+// (cursor-agent).
+//
+// Authentication mechanics:
+// - `cursor-agent login` opens a browser sign-in and stores tokens in $XDG_CONFIG_HOME/cursor/auth.json.
+// - The access and refresh tokens are JWTs; expiry comes from the exp claim.
+// - The signed-in account (email, plan) is written to ~/.cursor/cli-config.json.
+// - API key alternative: CURSOR_API_KEY in the environment.
+//
+// Context isolation for caam:
+// - Set XDG_CONFIG_HOME to the profile's xdg_config directory for auth.json.
+// - Set HOME to the profile's home directory for ~/.cursor/cli-config.json.
+//
+// Auth file swapping (PRIMARY use case):
+// - Backup auth.json and cli-config.json after logging in with each account
+// - Restore to instantly switch accounts without browser login flows
+package cursor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/browser"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/passthrough"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+)
+
+// Provider implements the Cursor CLI adapter.
+type Provider struct{}
+
+// New creates a new Cursor provider.
+func New() *Provider {
+	return &Provider{}
+}
+
+// ID returns the provider identifier.
+func (p *Provider) ID() string {
+	return "cursor"
+}
+
+// DisplayName returns the human-friendly name.
+func (p *Provider) DisplayName() string {
+	return "Cursor CLI"
+}
+
+// DefaultBin returns the default binary name.
+func (p *Provider) DefaultBin() string {
+	return "cursor-agent"
+}
+
+// SupportedAuthModes returns the authentication modes supported by Cursor.
+func (p *Provider) SupportedAuthModes() []provider.AuthMode {
+	return []provider.AuthMode{
+		provider.AuthModeOAuth,  // Browser-based sign-in (cursor-agent login)
+		provider.AuthModeAPIKey, // CURSOR_API_KEY
+	}
+}
+
+// xdgConfigHome returns the XDG config directory Cursor reads auth.json from.
+func xdgConfigHome() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config")
+}
+
+// configDir returns the directory holding cli-config.json.
+func configDir() string {
+	if dir := os.Getenv("CURSOR_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".cursor")
+}
+
+func authPathForProfile(prof *profile.Profile) string {
+	return filepath.Join(prof.XDGConfigPath(), "cursor", "auth.json")
+}
+
+func configPathForProfile(prof *profile.Profile) string {
+	return filepath.Join(prof.HomePath(), ".cursor", "cli-config.json")
+}
+
+// AuthFiles returns the auth file specifications for Cursor.
+func (p *Provider) AuthFiles() []provider.AuthFileSpec {
+	return []provider.AuthFileSpec{
+		{
+			Path:        filepath.Join(xdgConfigHome(), "cursor", "auth.json"),
+			Description: "Cursor CLI access and refresh tokens",
+			Required:    true,
+		},
+		{
+			Path:        filepath.Join(configDir(), "cli-config.json"),
+			Description: "Cursor CLI config with signed-in account info",
+			Required:    false,
+		},
+	}
+}
+
+// PrepareProfile sets up the profile directory structure.
+func (p *Provider) PrepareProfile(ctx context.Context, prof *profile.Profile) error {
+	if err := os.MkdirAll(filepath.Join(prof.XDGConfigPath(), "cursor"), 0700); err != nil {
+		return fmt.Errorf("create xdg_config: %w", err)
+	}
+
+	homePath := prof.HomePath()
+	if err := os.MkdirAll(filepath.Join(homePath, ".cursor"), 0700); err != nil {
+		return fmt.Errorf("create home: %w", err)
+	}
+
+	mgr, err := passthrough.NewManager()
+	if err != nil {
+		return fmt.Errorf("create passthrough manager: %w", err)
+	}
+	if err := mgr.SetupPassthroughs(homePath); err != nil {
+		return fmt.Errorf("setup passthroughs: %w", err)
+	}
+
+	return nil
+}
+
+// Env returns the environment variables for running Cursor in this profile's context.
+func (p *Provider) Env(ctx context.Context, prof *profile.Profile) (map[string]string, error) {
+	env := map[string]string{
+		"HOME":            prof.HomePath(),
+		"XDG_CONFIG_HOME": prof.XDGConfigPath(),
+	}
+	return env, nil
+}
+
+// Login initiates the authentication flow.
+func (p *Provider) Login(ctx context.Context, prof *profile.Profile) error {
+	if provider.AuthMode(prof.AuthMode) == provider.AuthModeAPIKey {
+		if os.Getenv("CURSOR_API_KEY") == "" {
+			return fmt.Errorf("CURSOR_API_KEY is not set")
+		}
+		fmt.Println("Cursor CLI reads CURSOR_API_KEY from the environment; no login needed.")
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "cursor-agent", "login")
+	cmd.Env = append(os.Environ(),
+		"HOME="+prof.HomePath(),
+		"XDG_CONFIG_HOME="+prof.XDGConfigPath(),
+	)
+
+	var capture *browser.OutputCapture
+	if prof.HasBrowserConfig() {
+		launcher := browser.NewLauncher(&browser.Config{
+			Command:    prof.BrowserCommand,
+			ProfileDir: prof.BrowserProfileDir,
+		})
+		fmt.Printf("Using browser profile: %s\n", prof.BrowserDisplayName())
+
+		capture = browser.NewOutputCapture(os.Stdout, os.Stderr)
+		capture.OnURL = func(url, source string) {
+			if err := launcher.Open(url); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to open browser: %v\n", err)
+			}
+		}
+		cmd.Stdout = capture.StdoutWriter()
+		cmd.Stderr = capture.StderrWriter()
+	} else {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+	cmd.Stdin = os.Stdin
+
+	fmt.Println("Starting Cursor login flow...")
+	err := cmd.Run()
+	if capture != nil {
+		capture.Flush()
+	}
+	return err
+}
+
+// Logout clears authentication credentials.
+func (p *Provider) Logout(ctx context.Context, prof *profile.Profile) error {
+	if err := os.Remove(authPathForProfile(prof)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove auth.json: %w", err)
+	}
+	return nil
+}
+
+// Status checks the current authentication state.
+func (p *Provider) Status(ctx context.Context, prof *profile.Profile) (*provider.ProfileStatus, error) {
+	status := &provider.ProfileStatus{
+		HasLockFile: prof.IsLocked(),
+	}
+
+	authPath := authPathForProfile(prof)
+	if _, err := os.Stat(authPath); err == nil {
+		status.LoggedIn = true
+		if id, err := identity.ExtractFromCursorAuth(authPath); err == nil {
+			status.AccountID = id.Email
+			if !id.ExpiresAt.IsZero() {
+				status.ExpiresAt = id.ExpiresAt.Format(time.RFC3339)
+			}
+		}
+		// In a profile the account info lives under HOME, not beside auth.json.
+		if status.AccountID == "" {
+			if id, err := identity.ExtractFromCursorConfig(configPathForProfile(prof)); err == nil {
+				status.AccountID = id.Email
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// ValidateProfile checks if the profile is correctly configured.
+func (p *Provider) ValidateProfile(ctx context.Context, prof *profile.Profile) error {
+	if _, err := os.Stat(prof.XDGConfigPath()); os.IsNotExist(err) {
+		return fmt.Errorf("xdg_config directory missing")
+	}
+
+	homePath := prof.HomePath()
+	if _, err := os.Stat(homePath); err == nil {
+		mgr, err := passthrough.NewManager()
+		if err != nil {
+			return fmt.Errorf("create passthrough manager: %w", err)
+		}
+
+		statuses, err := mgr.VerifyPassthroughs(homePath)
+		if err != nil {
+			return fmt.Errorf("verify passthroughs: %w", err)
+		}
+
+		for _, s := range statuses {
+			if s.SourceExists && !s.LinkValid {
+				return fmt.Errorf("passthrough %s is invalid: %s", s.Path, s.Error)
+			}
+		}
+	}
+
+	return nil
+}
+
+// DetectExistingAuth detects existing Cursor authentication files.
+// Locations checked:
+// - $XDG_CONFIG_HOME/cursor/auth.json (default ~/.config/cursor/auth.json)
+// - ~/.cursor/cli-config.json (account info only)
+func (p *Provider) DetectExistingAuth() (*provider.AuthDetection, error) {
+	detection := &provider.AuthDetection{
+		Provider:  p.ID(),
+		Locations: []provider.AuthLocation{},
+	}
+
+	for _, spec := range p.AuthFiles() {
+		loc := provider.AuthLocation{
+			Path:        spec.Path,
+			Description: spec.Description,
+		}
+
+		info, err := os.Stat(spec.Path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				loc.ValidationError = fmt.Sprintf("stat error: %v", err)
+			}
+			detection.Locations = append(detection.Locations, loc)
+			continue
+		}
+
+		loc.Exists = true
+		loc.LastModified = info.ModTime()
+		loc.FileSize = info.Size()
+
+		data, err := os.ReadFile(spec.Path)
+		if err != nil {
+			loc.ValidationError = fmt.Sprintf("read error: %v", err)
+		} else {
+			var parsed map[string]interface{}
+			if err := json.Unmarshal(data, &parsed); err != nil {
+				loc.ValidationError = fmt.Sprintf("invalid JSON: %v", err)
+			} else if !spec.Required {
+				loc.IsValid = true
+			} else if token, _ := parsed["accessToken"].(string); token != "" {
+				loc.IsValid = true
+			} else {
+				loc.ValidationError = "missing accessToken"
+			}
+		}
+
+		detection.Locations = append(detection.Locations, loc)
+
+		// Only auth.json carries credentials; cli-config.json alone is not a login.
+		if spec.Required && loc.IsValid {
+			locCopy := loc
+			detection.Primary = &locCopy
+			detection.Found = true
+		}
+	}
+
+	return detection, nil
+}
+
+// ImportAuth imports detected auth files into a profile directory. When
+// auth.json is imported, a cli-config.json in the system location is
+// copied along with it so the profile keeps its account info.
+func (p *Provider) ImportAuth(ctx context.Context, sourcePath string, prof *profile.Profile) ([]string, error) {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("source auth file not found: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("source path is a directory, not a file")
+	}
+
+	var copiedFiles []string
+	switch filepath.Base(sourcePath) {
+	case "auth.json":
+		target := authPathForProfile(prof)
+		if err := copyFile(sourcePath, target); err != nil {
+			return nil, fmt.Errorf("copy auth.json: %w", err)
+		}
+		copiedFiles = append(copiedFiles, target)
+
+		configPath := filepath.Join(configDir(), "cli-config.json")
+		if _, err := os.Stat(configPath); err == nil {
+			target := configPathForProfile(prof)
+			if err := copyFile(configPath, target); err != nil {
+				return nil, fmt.Errorf("copy cli-config.json: %w", err)
+			}
+			copiedFiles = append(copiedFiles, target)
+		}
+	case "cli-config.json":
+		target := configPathForProfile(prof)
+		if err := copyFile(sourcePath, target); err != nil {
+			return nil, fmt.Errorf("copy cli-config.json: %w", err)
+		}
+		copiedFiles = append(copiedFiles, target)
+	default:
+		return nil, fmt.Errorf("%s is not a Cursor auth file", sourcePath)
+	}
+
+	return copiedFiles, nil
+}
+
+// copyFile copies a file from src to dst with fsync for durability.
+func copyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+
+	tmpPath := dst + ".tmp"
+	dstFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := dstFile.Sync(); err != nil {
+		dstFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := dstFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, dst)
+}
+
+// ValidateToken validates that the authentication token works.
+// Passive validation checks that auth.json has an access token and that its
+// exp claim is in the future. Cursor has no lightweight token-check
+// endpoint, so active validation falls back to the passive checks.
+func (p *Provider) ValidateToken(ctx context.Context, prof *profile.Profile, passive bool) (*provider.ValidationResult, error) {
+	result := &provider.ValidationResult{
+		Provider:  p.ID(),
+		Profile:   prof.Name,
+		Method:    "passive",
+		CheckedAt: time.Now(),
+	}
+	if !passive {
+		result.Method = "active"
+	}
+
+	authPath := authPathForProfile(prof)
+	if _, err := os.Stat(authPath); os.IsNotExist(err) {
+		result.Error = "auth.json not found"
+		return result, nil
+	}
+
+	id, err := identity.ExtractFromCursorAuth(authPath)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	if !id.ExpiresAt.IsZero() {
+		result.ExpiresAt = id.ExpiresAt
+		if id.ExpiresAt.Before(time.Now()) {
+			data, _ := os.ReadFile(authPath)
+			var auth struct {
+				RefreshToken string `json:"refreshToken"`
+			}
+			_ = json.Unmarshal(data, &auth)
+			if auth.RefreshToken == "" {
+				result.Error = "token has expired"
+				return result, nil
+			}
+		}
+	}
+
+	result.Valid = true
+	return result, nil
+}
+
+// Ensure Provider implements the interface.
+var _ provider.Provider = (*Provider)(nil)
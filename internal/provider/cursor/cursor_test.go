@@ -0,0 +1,172 @@
+package cursor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+func testJWT(t *testing.T, exp time.Time) string {
+	t.Helper()
+	payload, err := json.Marshal(map[string]interface{}{"sub": "user_01", "exp": exp.Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+func writeAuth(t *testing.T, path string, auth map[string]string) {
+	t.Helper()
+	data, _ := json.Marshal(auth)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testProfile(t *testing.T) *profile.Profile {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	return &profile.Profile{Name: "work", Provider: "cursor", BasePath: t.TempDir()}
+}
+
+func TestProviderBasics(t *testing.T) {
+	p := New()
+	if p.ID() != "cursor" || p.DefaultBin() != "cursor-agent" {
+		t.Errorf("ID/DefaultBin = %q/%q", p.ID(), p.DefaultBin())
+	}
+	if len(p.SupportedAuthModes()) != 2 {
+		t.Errorf("SupportedAuthModes = %v", p.SupportedAuthModes())
+	}
+}
+
+func TestAuthFiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	t.Setenv("CURSOR_CONFIG_DIR", "/cursor-config")
+
+	files := New().AuthFiles()
+	if len(files) != 2 {
+		t.Fatalf("AuthFiles len = %d, want 2", len(files))
+	}
+	if files[0].Path != filepath.Join("/xdg", "cursor", "auth.json") || !files[0].Required {
+		t.Errorf("files[0] = %+v", files[0])
+	}
+	if files[1].Path != filepath.Join("/cursor-config", "cli-config.json") || files[1].Required {
+		t.Errorf("files[1] = %+v", files[1])
+	}
+}
+
+func TestEnv(t *testing.T) {
+	prof := testProfile(t)
+	env, err := New().Env(context.Background(), prof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env["HOME"] != prof.HomePath() || env["XDG_CONFIG_HOME"] != prof.XDGConfigPath() {
+		t.Errorf("Env = %v", env)
+	}
+}
+
+func TestStatusAndValidateToken(t *testing.T) {
+	p := New()
+	prof := testProfile(t)
+	ctx := context.Background()
+
+	if err := p.PrepareProfile(ctx, prof); err != nil {
+		t.Fatalf("PrepareProfile: %v", err)
+	}
+	if err := p.ValidateProfile(ctx, prof); err != nil {
+		t.Errorf("ValidateProfile: %v", err)
+	}
+
+	status, _ := p.Status(ctx, prof)
+	if status.LoggedIn {
+		t.Error("logged in without auth.json")
+	}
+	result, _ := p.ValidateToken(ctx, prof, true)
+	if result.Valid || result.Error != "auth.json not found" {
+		t.Errorf("ValidateToken = %+v", result)
+	}
+
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	writeAuth(t, authPathForProfile(prof), map[string]string{"accessToken": testJWT(t, exp)})
+	status, _ = p.Status(ctx, prof)
+	if !status.LoggedIn || status.ExpiresAt != exp.Format(time.RFC3339) {
+		t.Errorf("Status = %+v", status)
+	}
+	result, _ = p.ValidateToken(ctx, prof, true)
+	if !result.Valid || !result.ExpiresAt.Equal(exp) {
+		t.Errorf("ValidateToken = %+v", result)
+	}
+
+	expired := testJWT(t, time.Now().Add(-time.Hour))
+	writeAuth(t, authPathForProfile(prof), map[string]string{"accessToken": expired})
+	result, _ = p.ValidateToken(ctx, prof, true)
+	if result.Valid || result.Error != "token has expired" {
+		t.Errorf("ValidateToken(expired) = %+v", result)
+	}
+
+	writeAuth(t, authPathForProfile(prof), map[string]string{"accessToken": expired, "refreshToken": "rt"})
+	result, _ = p.ValidateToken(ctx, prof, true)
+	if !result.Valid {
+		t.Errorf("ValidateToken(expired with refresh token) = %+v", result)
+	}
+
+	if err := p.Logout(ctx, prof); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := p.Status(ctx, prof); status.LoggedIn {
+		t.Error("logged in after logout")
+	}
+}
+
+func TestDetectAndImport(t *testing.T) {
+	xdg := t.TempDir()
+	cfgDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("CURSOR_CONFIG_DIR", cfgDir)
+	p := New()
+
+	detection, err := p.DetectExistingAuth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detection.Found {
+		t.Error("found auth in empty directories")
+	}
+
+	authPath := filepath.Join(xdg, "cursor", "auth.json")
+	writeAuth(t, authPath, map[string]string{"accessToken": testJWT(t, time.Now().Add(time.Hour))})
+	if err := os.WriteFile(filepath.Join(cfgDir, "cli-config.json"), []byte(`{"authInfo":{"email":"dev@example.com"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	detection, _ = p.DetectExistingAuth()
+	if !detection.Found || detection.Primary == nil || detection.Primary.Path != authPath {
+		t.Fatalf("detection = %+v", detection)
+	}
+
+	prof := testProfile(t)
+	copied, err := p.ImportAuth(context.Background(), authPath, prof)
+	if err != nil {
+		t.Fatalf("ImportAuth: %v", err)
+	}
+	if len(copied) != 2 || copied[0] != authPathForProfile(prof) || copied[1] != configPathForProfile(prof) {
+		t.Errorf("copied = %v", copied)
+	}
+	if status, _ := p.Status(context.Background(), prof); status.AccountID != "dev@example.com" {
+		t.Errorf("AccountID = %q, want the email from cli-config.json", status.AccountID)
+	}
+
+	if _, err := p.ImportAuth(context.Background(), filepath.Join(cfgDir, "cli-config.json"), prof); err != nil {
+		t.Errorf("ImportAuth(cli-config.json): %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+// Package plugin loads provider descriptors, so new CLI tools can be managed
+// like the built-in providers without recompiling caam.
+//
+// A descriptor is a JSON or YAML file in ~/.config/caam/providers.d/ that
+// names the tool's auth files and tells caam where the token expiry lives:
+//
+//	id: amp
+//	display_name: Amp
+//	binary: amp
+//	login_command: amp login
+//	auth_files:
+//	  - path: ~/.local/share/amp/secrets.json
+//	    description: Amp CLI session
+//	    required: true
+//	expiry:
+//	  file: secrets.json
+//	  path: $.expiresAt
+//	  format: unix_ms
+//
+// Loaded providers get vault backup/activate, health, sync, and robot support.
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Descriptor describes a provider plugin.
+type Descriptor struct {
+	// ID is the provider name used on the command line (e.g. "amp").
+	ID string `json:"id" yaml:"id"`
+
+	// DisplayName is a human-friendly name. Default: ID.
+	DisplayName string `json:"display_name,omitempty" yaml:"display_name,omitempty"`
+
+	// Binary is the CLI executable. Default: ID.
+	Binary string `json:"binary,omitempty" yaml:"binary,omitempty"`
+
+	// LoginCommand is the command a human runs to log in.
+	// Default: "<binary> login".
+	LoginCommand string `json:"login_command,omitempty" yaml:"login_command,omitempty"`
+
+	// AccountURL is the provider's account page, for 'caam open'.
+	AccountURL string `json:"account_url,omitempty" yaml:"account_url,omitempty"`
+
+	// AuthFiles are the files that hold the tool's credentials. Paths may
+	// start with ~/ and reference environment variables.
+	AuthFiles []AuthFile `json:"auth_files" yaml:"auth_files"`
+
+	// Expiry locates the token expiry inside an auth file. Optional.
+	Expiry *Expiry `json:"expiry,omitempty" yaml:"expiry,omitempty"`
+
+	// Env is extra environment for running the tool in an isolated profile.
+	// ${HOME} expands to the profile's home directory.
+	Env map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+
+	// Source is the file the descriptor was loaded from.
+	Source string `json:"-" yaml:"-"`
+}
+
+// AuthFile is one credential file.
+type AuthFile struct {
+	Path        string `json:"path" yaml:"path"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool   `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// Expiry formats.
+const (
+	FormatAuto    = "auto"
+	FormatUnix    = "unix"
+	FormatUnixMs  = "unix_ms"
+	FormatRFC3339 = "rfc3339"
+)
+
+// Expiry locates the token expiry.
+type Expiry struct {
+	// File is the base name of the auth file holding the expiry.
+	// Default: the first required auth file.
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+
+	// Path is a JSONPath-style key path, e.g. $.tokens.expires_at or
+	// $.accounts[0].expiry.
+	Path string `json:"path" yaml:"path"`
+
+	// Format is unix, unix_ms, rfc3339, or auto (the default), which
+	// accepts any of them.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+
+	// RefreshTokenPath, if set, marks the token refreshable when the key
+	// is present and non-empty.
+	RefreshTokenPath string `json:"refresh_token_path,omitempty" yaml:"refresh_token_path,omitempty"`
+}
+
+// builtinIDs are provider names plugins may not take over.
+var builtinIDs = map[string]bool{
+	"claude":             true,
+	"codex":              true,
+	"copilot":            true,
+	"cursor":             true,
+	"gemini":             true,
+	"gemini-cli":         true,
+	"gemini-code-assist": true,
+	"gemini-adc":         true,
+}
+
+var idPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// Dir returns the provider descriptor directory.
+func Dir() string {
+	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+		return filepath.Join(xdgConfig, "caam", "providers.d")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".config", "caam", "providers.d")
+	}
+	return filepath.Join(homeDir, ".config", "caam", "providers.d")
+}
+
+// Validate checks a descriptor and fills in defaults.
+func (d *Descriptor) Validate() error {
+	d.ID = strings.TrimSpace(d.ID)
+	if !idPattern.MatchString(d.ID) {
+		return fmt.Errorf("invalid provider id %q: use lowercase letters, digits, '-' and '_'", d.ID)
+	}
+	if builtinIDs[d.ID] {
+		return fmt.Errorf("provider id %q is built in", d.ID)
+	}
+	if len(d.AuthFiles) == 0 {
+		return fmt.Errorf("provider %s: auth_files is empty", d.ID)
+	}
+	seen := make(map[string]bool)
+	for i, f := range d.AuthFiles {
+		if strings.TrimSpace(f.Path) == "" {
+			return fmt.Errorf("provider %s: auth_files[%d] has no path", d.ID, i)
+		}
+		// The vault stores files by base name, so names must be unique.
+		base := filepath.Base(f.Path)
+		if seen[base] {
+			return fmt.Errorf("provider %s: two auth files named %s", d.ID, base)
+		}
+		seen[base] = true
+	}
+
+	if d.DisplayName == "" {
+		d.DisplayName = d.ID
+	}
+	if d.Binary == "" {
+		d.Binary = d.ID
+	}
+	if d.LoginCommand == "" {
+		d.LoginCommand = d.Binary + " login"
+	}
+
+	if e := d.Expiry; e != nil {
+		if e.Path == "" {
+			return fmt.Errorf("provider %s: expiry.path is required", d.ID)
+		}
+		if _, err := parseKeyPath(e.Path); err != nil {
+			return fmt.Errorf("provider %s: expiry.path: %w", d.ID, err)
+		}
+		switch e.Format {
+		case "":
+			e.Format = FormatAuto
+		case FormatAuto, FormatUnix, FormatUnixMs, FormatRFC3339:
+		default:
+			return fmt.Errorf("provider %s: unknown expiry.format %q", d.ID, e.Format)
+		}
+		if e.File == "" {
+			e.File = filepath.Base(d.primaryAuthFile().Path)
+		} else if !seen[e.File] {
+			return fmt.Errorf("provider %s: expiry.file %q is not one of auth_files", d.ID, e.File)
+		}
+		if e.RefreshTokenPath != "" {
+			if _, err := parseKeyPath(e.RefreshTokenPath); err != nil {
+				return fmt.Errorf("provider %s: expiry.refresh_token_path: %w", d.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// primaryAuthFile returns the first required auth file, or the first one.
+func (d *Descriptor) primaryAuthFile() AuthFile {
+	for _, f := range d.AuthFiles {
+		if f.Required {
+			return f
+		}
+	}
+	return d.AuthFiles[0]
+}
+
+// Parse decodes a descriptor from JSON or YAML, chosen by file extension.
+func Parse(name string, data []byte) (*Descriptor, error) {
+	var d Descriptor
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		err = json.Unmarshal(data, &d)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &d)
+	default:
+		return nil, fmt.Errorf("%s: descriptors must be .json, .yaml, or .yml", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	if err := d.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	d.Source = name
+	return &d, nil
+}
+
+// LoadDir loads every descriptor in dir, sorted by ID. A missing directory
+// loads nothing. Bad descriptors are skipped and reported in errs, so one
+// broken file doesn't disable the others.
+func LoadDir(dir string) (descriptors []*Descriptor, errs []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{err}
+	}
+
+	byID := make(map[string]*Descriptor)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		d, err := Parse(path, data)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if prev, ok := byID[d.ID]; ok {
+			errs = append(errs, fmt.Errorf("%s: provider %s is already defined in %s", path, d.ID, prev.Source))
+			continue
+		}
+		byID[d.ID] = d
+	}
+
+	for _, d := range byID {
+		descriptors = append(descriptors, d)
+	}
+	sort.Slice(descriptors, func(i, j int) bool { return descriptors[i].ID < descriptors[j].ID })
+	return descriptors, errs
+}
+
+// expandPath expands ~/ and environment variables. home replaces the user's
+// home directory, so the same descriptor path can point into a profile.
+func expandPath(path, home string) string {
+	path = os.Expand(path, func(name string) string {
+		if name == "HOME" {
+			return home
+		}
+		return os.Getenv(name)
+	})
+	if path == "~" {
+		return home
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(home, path[2:])
+	}
+	return filepath.Clean(path)
+}
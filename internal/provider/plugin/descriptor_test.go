@@ -0,0 +1,115 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const ampYAML = `
+id: amp
+display_name: Amp
+binary: amp
+auth_files:
+  - path: ~/.local/share/amp/secrets.json
+    description: Amp CLI session
+    required: true
+  - path: ${HOME}/.amp/extra.json
+expiry:
+  path: $.expiresAt
+  format: unix_ms
+`
+
+func TestParseYAML(t *testing.T) {
+	d, err := Parse("amp.yaml", []byte(ampYAML))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if d.ID != "amp" || d.DisplayName != "Amp" || d.Binary != "amp" {
+		t.Errorf("descriptor = %+v", d)
+	}
+	if d.LoginCommand != "amp login" {
+		t.Errorf("LoginCommand = %q, want default", d.LoginCommand)
+	}
+	if d.Expiry.File != "secrets.json" {
+		t.Errorf("Expiry.File = %q, want the required auth file", d.Expiry.File)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	d, err := Parse("aider.json", []byte(`{"id":"aider","auth_files":[{"path":"~/.aider/oauth-keys.env"}]}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if d.DisplayName != "aider" || d.Expiry != nil {
+		t.Errorf("descriptor = %+v", d)
+	}
+	if !NewProvider(d).AuthFileSet().AllowOptionalOnly {
+		t.Error("a descriptor without required files should allow optional-only auth")
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		json string
+		want string
+	}{
+		{"bad id", `{"id":"Amp!","auth_files":[{"path":"~/a"}]}`, "invalid provider id"},
+		{"builtin", `{"id":"claude","auth_files":[{"path":"~/a"}]}`, "built in"},
+		{"no files", `{"id":"x"}`, "auth_files is empty"},
+		{"dup names", `{"id":"x","auth_files":[{"path":"~/a/auth.json"},{"path":"~/b/auth.json"}]}`, "two auth files"},
+		{"bad format", `{"id":"x","auth_files":[{"path":"~/a"}],"expiry":{"path":"$.e","format":"weird"}}`, "expiry.format"},
+		{"bad file", `{"id":"x","auth_files":[{"path":"~/a"}],"expiry":{"path":"$.e","file":"b"}}`, "not one of auth_files"},
+		{"bad path", `{"id":"x","auth_files":[{"path":"~/a"}],"expiry":{"path":"$.e[x"}}`, "expiry.path"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("p.json", []byte(tt.json))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("amp.yaml", ampYAML)
+	write("aider.json", `{"id":"aider","auth_files":[{"path":"~/.aider/key"}]}`)
+	write("broken.json", `{"id":`)
+	write("dup.yml", "id: amp\nauth_files:\n  - path: ~/x\n")
+	write("README.md", "not a descriptor")
+
+	descriptors, errs := LoadDir(dir)
+	if len(descriptors) != 2 || descriptors[0].ID != "aider" || descriptors[1].ID != "amp" {
+		t.Fatalf("descriptors = %+v", descriptors)
+	}
+	if len(errs) != 2 {
+		t.Errorf("errs = %v, want broken.json and the duplicate", errs)
+	}
+
+	if d, errs := LoadDir(filepath.Join(dir, "missing")); d != nil || errs != nil {
+		t.Errorf("LoadDir(missing) = %v, %v", d, errs)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	t.Setenv("CAAM_PLUGIN_TEST", "/opt/tool")
+	for in, want := range map[string]string{
+		"~/.cursor/a.json":         "/home/p/.cursor/a.json",
+		"${HOME}/.cursor/a.json":   "/home/p/.cursor/a.json",
+		"$CAAM_PLUGIN_TEST/a.json": "/opt/tool/a.json",
+		"/etc/tool/../tool/a.json": "/etc/tool/a.json",
+	} {
+		if got := expandPath(in, "/home/p"); got != filepath.FromSlash(want) {
+			t.Errorf("expandPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+// keyStep is one step of a key path: a map key or an array index.
+type keyStep struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// parseKeyPath parses $.a.b[0].c. The leading $ is optional.
+func parseKeyPath(path string) ([]keyStep, error) {
+	p := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var steps []keyStep
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in %q", path)
+			}
+			steps = append(steps, keyStep{key: p[:end]})
+			p = p[end:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %q", path)
+			}
+			inner := p[1:end]
+			if n, err := strconv.Atoi(inner); err == nil && n >= 0 {
+				steps = append(steps, keyStep{index: n, isIdx: true})
+			} else if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, keyStep{key: inner[1 : len(inner)-1]})
+			} else {
+				return nil, fmt.Errorf("bad index [%s] in %q", inner, path)
+			}
+			p = p[end+1:]
+		default:
+			// Allow a bare first key: "tokens.expires_at".
+			if len(steps) > 0 {
+				return nil, fmt.Errorf("unexpected %q in %q", p[0], path)
+			}
+			p = "." + p
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	return steps, nil
+}
+
+// lookup follows a key path through decoded JSON.
+func lookup(v any, path string) (any, bool) {
+	steps, err := parseKeyPath(path)
+	if err != nil {
+		return nil, false
+	}
+	for _, s := range steps {
+		if s.isIdx {
+			arr, ok := v.([]any)
+			if !ok || s.index >= len(arr) {
+				return nil, false
+			}
+			v = arr[s.index]
+			continue
+		}
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = obj[s.key]; !ok {
+			return nil, false
+		}
+	}
+	return v, v != nil
+}
+
+// parseTime converts an expiry value in the given format.
+func parseTime(v any, format string) (time.Time, error) {
+	var num float64
+	var isNum bool
+	switch val := v.(type) {
+	case float64:
+		num, isNum = val, true
+	case string:
+		if n, err := strconv.ParseFloat(val, 64); err == nil {
+			num, isNum = n, true
+		} else if format == FormatAuto || format == FormatRFC3339 {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("expiry %q is not RFC 3339", val)
+			}
+			return t, nil
+		}
+	}
+	if !isNum || format == FormatRFC3339 {
+		return time.Time{}, fmt.Errorf("expiry %v is not in %s format", v, format)
+	}
+	if num <= 0 || math.IsInf(num, 0) || math.IsNaN(num) {
+		return time.Time{}, health.ErrNoExpiry
+	}
+
+	// Auto: seconds until the year 33658, milliseconds after.
+	if format == FormatUnixMs || (format == FormatAuto && num > 1e12) {
+		return time.UnixMilli(int64(num)), nil
+	}
+	return time.Unix(int64(num), 0), nil
+}
+
+// ParseExpiry extracts the token expiry from the contents of the expiry file.
+func (d *Descriptor) ParseExpiry(data []byte) (*health.ExpiryInfo, error) {
+	if d.Expiry == nil {
+		return nil, health.ErrNoExpiry
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", d.Expiry.File, err)
+	}
+	v, ok := lookup(doc, d.Expiry.Path)
+	if !ok {
+		return nil, health.ErrNoExpiry
+	}
+	expiresAt, err := parseTime(v, d.Expiry.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &health.ExpiryInfo{ExpiresAt: expiresAt, Source: d.Expiry.File}
+	if d.Expiry.RefreshTokenPath != "" {
+		if rt, ok := lookup(doc, d.Expiry.RefreshTokenPath); ok {
+			if s, isStr := rt.(string); !isStr || s != "" {
+				info.HasRefreshToken = true
+			}
+		}
+	}
+	return info, nil
+}
+
+// ParseProfileExpiry reads the expiry from a vault profile directory.
+func (d *Descriptor) ParseProfileExpiry(profileDir string) (*health.ExpiryInfo, error) {
+	if d.Expiry == nil {
+		return nil, health.ErrNoExpiry
+	}
+	data, err := os.ReadFile(filepath.Join(profileDir, d.Expiry.File))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, health.ErrNoAuthFile
+		}
+		return nil, err
+	}
+	return d.ParseExpiry(data)
+}
+
+// freshnessExtractor adapts a descriptor to sync.FreshnessExtractor.
+type freshnessExtractor struct {
+	d *Descriptor
+}
+
+// Extract finds the expiry file among authFiles (keyed by path) and uses
+// the newest file's modification time as ModifiedAt.
+func (e freshnessExtractor) Extract(provider, profile string, authFiles map[string][]byte) (*sync.TokenFreshness, error) {
+	fresh := &sync.TokenFreshness{Provider: provider, Profile: profile, Source: "local"}
+	for path, data := range authFiles {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(fresh.ModifiedAt) {
+			fresh.ModifiedAt = info.ModTime()
+		}
+		if e.d.Expiry == nil || filepath.Base(path) != e.d.Expiry.File {
+			continue
+		}
+		if info, err := e.d.ParseExpiry(data); err == nil {
+			fresh.ExpiresAt = info.ExpiresAt
+			fresh.IsExpired = time.Now().After(info.ExpiresAt)
+		}
+	}
+	return fresh, nil
+}
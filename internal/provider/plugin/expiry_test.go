@@ -0,0 +1,165 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+func TestLookup(t *testing.T) {
+	doc := map[string]any{
+		"tokens":   map[string]any{"expires_at": 1.0},
+		"accounts": []any{map[string]any{"exp": 2.0}},
+		"odd.key":  3.0,
+	}
+	for path, want := range map[string]any{
+		"$.tokens.expires_at": 1.0,
+		"tokens.expires_at":   1.0,
+		"$.accounts[0].exp":   2.0,
+		"$['odd.key']":        3.0,
+	} {
+		got, ok := lookup(doc, path)
+		if !ok || got != want {
+			t.Errorf("lookup(%q) = %v, %v", path, got, ok)
+		}
+	}
+	for _, path := range []string{"$.tokens.missing", "$.accounts[1].exp", "$.tokens[0]"} {
+		if _, ok := lookup(doc, path); ok {
+			t.Errorf("lookup(%q) found a value", path)
+		}
+	}
+}
+
+func TestParseExpiryFormats(t *testing.T) {
+	want := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		format string
+		value  string
+	}{
+		{FormatUnix, `1772366400`},
+		{FormatUnixMs, `1772366400000`},
+		{FormatRFC3339, `"2026-03-01T12:00:00Z"`},
+		{FormatAuto, `1772366400`},
+		{FormatAuto, `1772366400000`},
+		{FormatAuto, `"1772366400"`},
+		{FormatAuto, `"2026-03-01T12:00:00Z"`},
+	} {
+		d := &Descriptor{Expiry: &Expiry{File: "auth.json", Path: "$.exp", Format: tt.format}}
+		info, err := d.ParseExpiry([]byte(`{"exp":` + tt.value + `}`))
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.format, tt.value, err)
+			continue
+		}
+		if !info.ExpiresAt.Equal(want) {
+			t.Errorf("%s %s = %v, want %v", tt.format, tt.value, info.ExpiresAt, want)
+		}
+	}
+
+	d := &Descriptor{Expiry: &Expiry{File: "auth.json", Path: "$.exp", Format: FormatRFC3339}}
+	if _, err := d.ParseExpiry([]byte(`{"exp":1772366400}`)); err == nil {
+		t.Error("expected a number to fail rfc3339")
+	}
+	if _, err := d.ParseExpiry([]byte(`{}`)); !errors.Is(err, health.ErrNoExpiry) {
+		t.Errorf("missing key error = %v, want ErrNoExpiry", err)
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	d := &Descriptor{Expiry: &Expiry{File: "a", Path: "$.exp", Format: FormatAuto, RefreshTokenPath: "$.refresh"}}
+	info, err := d.ParseExpiry([]byte(`{"exp":1772366400,"refresh":"rt"}`))
+	if err != nil || !info.HasRefreshToken {
+		t.Errorf("info = %+v, %v", info, err)
+	}
+	info, _ = d.ParseExpiry([]byte(`{"exp":1772366400,"refresh":""}`))
+	if info.HasRefreshToken {
+		t.Error("empty refresh token counted")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	d, err := Parse("tool.yaml", []byte(`
+id: plugtest
+auth_files:
+  - path: ~/.plugtest/auth.json
+    required: true
+expiry:
+  path: $.expires_at
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Register(d)
+
+	expires := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	vaultDir := t.TempDir()
+	authPath := filepath.Join(vaultDir, "auth.json")
+	data := []byte(`{"expires_at":` + strconv.FormatInt(expires.Unix(), 10) + `}`)
+	if err := os.WriteFile(authPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := health.ParseRegisteredExpiry("plugtest", vaultDir)
+	if err != nil || !info.ExpiresAt.Equal(expires) {
+		t.Errorf("ParseRegisteredExpiry = %+v, %v", info, err)
+	}
+
+	fresh, err := sync.ExtractFreshnessFromFiles("plugtest", "work", []string{authPath})
+	if err != nil || !fresh.ExpiresAt.Equal(expires) || fresh.ModifiedAt.IsZero() {
+		t.Errorf("freshness = %+v, %v", fresh, err)
+	}
+	found := false
+	for _, p := range sync.Providers() {
+		found = found || p == "plugtest"
+	}
+	if !found {
+		t.Error("plugin provider not synced")
+	}
+}
+
+func TestProviderProfile(t *testing.T) {
+	d, err := Parse("tool.json", []byte(`{"id":"plugtool","auth_files":[{"path":"~/.plugtool/auth.json","required":true}],"expiry":{"path":"$.exp"},"env":{"PLUGTOOL_HOME":"${HOME}/.plugtool"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProvider(d)
+	prof := &profile.Profile{Name: "work", Provider: "plugtool", BasePath: t.TempDir()}
+	ctx := context.Background()
+
+	if err := p.PrepareProfile(ctx, prof); err != nil {
+		t.Fatal(err)
+	}
+	env, _ := p.Env(ctx, prof)
+	if env["HOME"] != prof.HomePath() || env["PLUGTOOL_HOME"] != filepath.Join(prof.HomePath(), ".plugtool") {
+		t.Errorf("Env = %v", env)
+	}
+
+	status, _ := p.Status(ctx, prof)
+	if status.LoggedIn {
+		t.Error("logged in without auth files")
+	}
+
+	src := filepath.Join(t.TempDir(), "auth.json")
+	if err := os.WriteFile(src, []byte(`{"exp":1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	copied, err := p.ImportAuth(ctx, src, prof)
+	if err != nil || len(copied) != 1 || copied[0] != filepath.Join(prof.HomePath(), ".plugtool", "auth.json") {
+		t.Fatalf("ImportAuth = %v, %v", copied, err)
+	}
+	status, _ = p.Status(ctx, prof)
+	if !status.LoggedIn {
+		t.Error("not logged in after import")
+	}
+	result, _ := p.ValidateToken(ctx, prof, true)
+	if result.Valid || result.Error != "token expired" {
+		t.Errorf("ValidateToken = %+v, want expired", result)
+	}
+}
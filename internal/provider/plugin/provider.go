@@ -0,0 +1,310 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+// Provider is a provider.Provider backed by a descriptor. Profiles are
+// isolated by pointing HOME at the profile's home directory, so auth files
+// under ~ land inside the profile.
+type Provider struct {
+	d *Descriptor
+}
+
+// NewProvider creates a provider from a validated descriptor.
+func NewProvider(d *Descriptor) *Provider {
+	return &Provider{d: d}
+}
+
+// Register makes a descriptor's provider known to the health, sync, and
+// provider metadata registries.
+func Register(d *Descriptor) {
+	health.RegisterExpiryParser(d.ID, d.ParseProfileExpiry)
+	sync.RegisterExtractor(d.ID, freshnessExtractor{d: d})
+	provider.RegisterProviderMeta(provider.ProviderMeta{
+		ID:           d.ID,
+		DisplayName:  d.DisplayName,
+		AccountURL:   d.AccountURL,
+		Description:  d.DisplayName + " account page",
+		LoginCommand: d.LoginCommand,
+	})
+}
+
+// Descriptor returns the provider's descriptor.
+func (p *Provider) Descriptor() *Descriptor {
+	return p.d
+}
+
+// ID returns the provider identifier.
+func (p *Provider) ID() string {
+	return p.d.ID
+}
+
+// DisplayName returns the human-friendly name.
+func (p *Provider) DisplayName() string {
+	return p.d.DisplayName
+}
+
+// DefaultBin returns the default binary name.
+func (p *Provider) DefaultBin() string {
+	return p.d.Binary
+}
+
+// SupportedAuthModes returns the authentication modes. Plugins only know
+// how to run the tool's own login command.
+func (p *Provider) SupportedAuthModes() []provider.AuthMode {
+	return []provider.AuthMode{provider.AuthModeOAuth}
+}
+
+func userHome() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// AuthFileSet returns the auth files for vault backup and activate.
+func (p *Provider) AuthFileSet() authfile.AuthFileSet {
+	set := authfile.AuthFileSet{Tool: p.d.ID}
+	hasRequired := false
+	for _, f := range p.d.AuthFiles {
+		set.Files = append(set.Files, authfile.AuthFileSpec{
+			Tool:        p.d.ID,
+			Path:        expandPath(f.Path, userHome()),
+			Description: f.Description,
+			Required:    f.Required,
+		})
+		hasRequired = hasRequired || f.Required
+	}
+	set.AllowOptionalOnly = !hasRequired
+	return set
+}
+
+// AuthFiles returns the auth file specifications.
+func (p *Provider) AuthFiles() []provider.AuthFileSpec {
+	var specs []provider.AuthFileSpec
+	for _, f := range p.d.AuthFiles {
+		specs = append(specs, provider.AuthFileSpec{
+			Path:        expandPath(f.Path, userHome()),
+			Description: f.Description,
+			Required:    f.Required,
+		})
+	}
+	return specs
+}
+
+// profileAuthPath returns where an auth file lives inside a profile.
+func profileAuthPath(f AuthFile, prof *profile.Profile) string {
+	return expandPath(f.Path, prof.HomePath())
+}
+
+// PrepareProfile creates the profile's home directory.
+func (p *Provider) PrepareProfile(ctx context.Context, prof *profile.Profile) error {
+	if err := os.MkdirAll(prof.HomePath(), 0700); err != nil {
+		return fmt.Errorf("create home: %w", err)
+	}
+	return nil
+}
+
+// Env returns HOME set to the profile's home plus the descriptor's env.
+func (p *Provider) Env(ctx context.Context, prof *profile.Profile) (map[string]string, error) {
+	env := map[string]string{"HOME": prof.HomePath()}
+	for k, v := range p.d.Env {
+		env[k] = os.Expand(v, func(name string) string {
+			if name == "HOME" {
+				return prof.HomePath()
+			}
+			return os.Getenv(name)
+		})
+	}
+	return env, nil
+}
+
+// Login runs the descriptor's login command in the profile's environment.
+func (p *Provider) Login(ctx context.Context, prof *profile.Profile) error {
+	args := strings.Fields(p.d.LoginCommand)
+	if len(args) == 0 {
+		return fmt.Errorf("provider %s has no login command", p.d.ID)
+	}
+	env, err := p.Env(ctx, prof)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Logout removes the profile's auth files.
+func (p *Provider) Logout(ctx context.Context, prof *profile.Profile) error {
+	for _, f := range p.d.AuthFiles {
+		path := profileAuthPath(f, prof)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove %s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// Status reports the profile logged in when its required auth files exist.
+func (p *Provider) Status(ctx context.Context, prof *profile.Profile) (*provider.ProfileStatus, error) {
+	status := &provider.ProfileStatus{HasLockFile: prof.IsLocked()}
+	found, missing := false, false
+	for _, f := range p.d.AuthFiles {
+		if _, err := os.Stat(profileAuthPath(f, prof)); err == nil {
+			found = true
+		} else if f.Required {
+			missing = true
+		}
+	}
+	status.LoggedIn = found && !missing
+	if p.d.Expiry != nil {
+		if data, err := os.ReadFile(p.profileExpiryPath(prof)); err == nil {
+			if info, err := p.d.ParseExpiry(data); err == nil {
+				status.ExpiresAt = info.ExpiresAt.Format(time.RFC3339)
+			}
+		}
+	}
+	return status, nil
+}
+
+func (p *Provider) profileExpiryPath(prof *profile.Profile) string {
+	for _, f := range p.d.AuthFiles {
+		if filepath.Base(f.Path) == p.d.Expiry.File {
+			return profileAuthPath(f, prof)
+		}
+	}
+	return ""
+}
+
+// ValidateProfile checks that the profile's home directory exists.
+func (p *Provider) ValidateProfile(ctx context.Context, prof *profile.Profile) error {
+	if _, err := os.Stat(prof.HomePath()); os.IsNotExist(err) {
+		return fmt.Errorf("home directory missing")
+	}
+	return nil
+}
+
+// DetectExistingAuth looks for the auth files in their system locations.
+func (p *Provider) DetectExistingAuth() (*provider.AuthDetection, error) {
+	detection := &provider.AuthDetection{
+		Provider:  p.d.ID,
+		Locations: []provider.AuthLocation{},
+	}
+	for _, spec := range p.AuthFiles() {
+		loc := provider.AuthLocation{Path: spec.Path, Description: spec.Description}
+		info, err := os.Stat(spec.Path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				loc.ValidationError = fmt.Sprintf("stat error: %v", err)
+			}
+			detection.Locations = append(detection.Locations, loc)
+			continue
+		}
+		loc.Exists = true
+		loc.LastModified = info.ModTime()
+		loc.FileSize = info.Size()
+		loc.IsValid = true
+		if strings.EqualFold(filepath.Ext(spec.Path), ".json") {
+			if data, err := os.ReadFile(spec.Path); err == nil && !json.Valid(data) {
+				loc.IsValid = false
+				loc.ValidationError = "invalid JSON"
+			}
+		}
+		detection.Locations = append(detection.Locations, loc)
+
+		if loc.IsValid && (detection.Primary == nil || spec.Required) {
+			locCopy := loc
+			detection.Primary = &locCopy
+			detection.Found = true
+		}
+	}
+	return detection, nil
+}
+
+// ImportAuth copies a detected auth file into the matching place in the
+// profile's home.
+func (p *Provider) ImportAuth(ctx context.Context, sourcePath string, prof *profile.Profile) ([]string, error) {
+	for _, f := range p.d.AuthFiles {
+		if filepath.Base(f.Path) != filepath.Base(sourcePath) {
+			continue
+		}
+		target := profileAuthPath(f, prof)
+		if err := copyFile(sourcePath, target); err != nil {
+			return nil, fmt.Errorf("copy %s: %w", filepath.Base(sourcePath), err)
+		}
+		return []string{target}, nil
+	}
+	return nil, fmt.Errorf("%s is not an auth file of %s", sourcePath, p.d.ID)
+}
+
+// ValidateToken checks token expiry. Plugins have no API to call, so
+// active validation is the same as passive.
+func (p *Provider) ValidateToken(ctx context.Context, prof *profile.Profile, passive bool) (*provider.ValidationResult, error) {
+	result := &provider.ValidationResult{
+		Provider:  p.d.ID,
+		Profile:   prof.Name,
+		Method:    "passive",
+		CheckedAt: time.Now(),
+	}
+	status, _ := p.Status(ctx, prof)
+	if !status.LoggedIn {
+		result.Error = "auth files missing"
+		return result, nil
+	}
+	result.Valid = true
+	if p.d.Expiry != nil {
+		data, err := os.ReadFile(p.profileExpiryPath(prof))
+		if err == nil {
+			if info, err := p.d.ParseExpiry(data); err == nil {
+				result.ExpiresAt = info.ExpiresAt
+				if info.IsExpired() {
+					result.Valid = false
+					result.Error = "token expired"
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Ensure Provider implements the interface.
+var _ provider.Provider = (*Provider)(nil)
@@ -3,6 +3,7 @@ package provider
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
@@ -186,39 +187,104 @@ type ProviderMeta struct {
 	DisplayName string // Human-friendly name
 	AccountURL  string // URL to the provider's account/console page
 	Description string // Short description of the account page
+
+	LoginCommand string   // Command a human runs to log in interactively
+	LoginPrompts []string // What the login flow asks for, in order
+	LoginURL     string   // Provider sign-in page the login flow ends up at
 }
 
 // providerMetaRegistry holds static metadata for all known providers.
 var providerMetaRegistry = map[string]ProviderMeta{
 	"codex": {
-		ID:          "codex",
-		DisplayName: "Codex (OpenAI)",
-		AccountURL:  "https://platform.openai.com/account",
-		Description: "OpenAI Platform account settings",
+		ID:           "codex",
+		DisplayName:  "Codex (OpenAI)",
+		AccountURL:   "https://platform.openai.com/account",
+		Description:  "OpenAI Platform account settings",
+		LoginCommand: "codex login",
+		LoginPrompts: []string{
+			"A browser window opens to sign in with ChatGPT",
+			"Sign in with the account for this profile",
+			"The CLI prints \"Successfully logged in\"",
+		},
+		LoginURL: "https://chatgpt.com/auth/login",
 	},
 	"claude": {
-		ID:          "claude",
-		DisplayName: "Claude (Anthropic)",
-		AccountURL:  "https://console.anthropic.com/",
-		Description: "Anthropic Console dashboard",
+		ID:           "claude",
+		DisplayName:  "Claude (Anthropic)",
+		AccountURL:   "https://console.anthropic.com/",
+		Description:  "Anthropic Console dashboard",
+		LoginCommand: "claude /login",
+		LoginPrompts: []string{
+			"Select login method: choose \"Claude account with subscription\"",
+			"Open the printed URL and sign in with the account for this profile",
+			"Paste the authorization code back into the terminal",
+		},
+		LoginURL: "https://claude.ai/login",
 	},
 	"gemini": {
-		ID:          "gemini",
-		DisplayName: "Gemini (Google)",
-		AccountURL:  "https://aistudio.google.com/",
-		Description: "Google AI Studio dashboard",
+		ID:           "gemini",
+		DisplayName:  "Gemini (Google)",
+		AccountURL:   "https://aistudio.google.com/",
+		Description:  "Google AI Studio dashboard",
+		LoginCommand: "gemini",
+		LoginPrompts: []string{
+			"Select \"Login with Google\" when asked how to authenticate",
+			"Sign in with the Google account for this profile in the browser",
+		},
+		LoginURL: "https://accounts.google.com/",
+	},
+	"cursor": {
+		ID:           "cursor",
+		DisplayName:  "Cursor",
+		AccountURL:   "https://cursor.com/dashboard",
+		Description:  "Cursor account dashboard",
+		LoginCommand: "cursor-agent login",
+		LoginPrompts: []string{
+			"A browser window opens to sign in to Cursor",
+			"Sign in with the account for this profile",
+			"The CLI prints \"Login successful\"",
+		},
+		LoginURL: "https://cursor.com/login",
+	},
+	"copilot": {
+		ID:           "copilot",
+		DisplayName:  "GitHub Copilot",
+		AccountURL:   "https://github.com/settings/copilot",
+		Description:  "GitHub Copilot settings",
+		LoginCommand: "copilot",
+		LoginPrompts: []string{
+			"Type /login inside Copilot",
+			"Open https://github.com/login/device and enter the one-time code",
+			"Authorize with the GitHub account for this profile",
+		},
+		LoginURL: "https://github.com/login/device",
 	},
 }
 
+// providerMetaMu guards providerMetaRegistry against plugin registration.
+var providerMetaMu sync.RWMutex
+
+// RegisterProviderMeta adds metadata for a provider that isn't built in,
+// such as one loaded from a provider plugin.
+func RegisterProviderMeta(meta ProviderMeta) {
+	providerMetaMu.Lock()
+	defer providerMetaMu.Unlock()
+	providerMetaRegistry[meta.ID] = meta
+}
+
 // GetProviderMeta returns metadata for a provider by ID.
 // Returns the metadata and true if found, or zero value and false if not.
 func GetProviderMeta(id string) (ProviderMeta, bool) {
+	providerMetaMu.RLock()
+	defer providerMetaMu.RUnlock()
 	meta, ok := providerMetaRegistry[id]
 	return meta, ok
 }
 
 // AllProviderMeta returns metadata for all known providers.
 func AllProviderMeta() []ProviderMeta {
+	providerMetaMu.RLock()
+	defer providerMetaMu.RUnlock()
 	result := make([]ProviderMeta, 0, len(providerMetaRegistry))
 	for _, meta := range providerMetaRegistry {
 		result = append(result, meta)
@@ -228,6 +294,8 @@ func AllProviderMeta() []ProviderMeta {
 
 // KnownProviderIDs returns the IDs of all known providers.
 func KnownProviderIDs() []string {
+	providerMetaMu.RLock()
+	defer providerMetaMu.RUnlock()
 	result := make([]string, 0, len(providerMetaRegistry))
 	for id := range providerMetaRegistry {
 		result = append(result, id)
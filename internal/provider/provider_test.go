@@ -343,6 +343,8 @@ func TestGetProviderMeta(t *testing.T) {
 		{"codex", true, "https://platform.openai.com/account", "Codex (OpenAI)"},
 		{"claude", true, "https://console.anthropic.com/", "Claude (Anthropic)"},
 		{"gemini", true, "https://aistudio.google.com/", "Gemini (Google)"},
+		{"cursor", true, "https://cursor.com/dashboard", "Cursor"},
+		{"copilot", true, "https://github.com/settings/copilot", "GitHub Copilot"},
 		{"unknown", false, "", ""},
 	}
 
@@ -370,8 +372,8 @@ func TestGetProviderMeta(t *testing.T) {
 func TestAllProviderMeta(t *testing.T) {
 	all := AllProviderMeta()
 
-	if len(all) != 3 {
-		t.Errorf("AllProviderMeta() len = %d, want 3", len(all))
+	if len(all) != 5 {
+		t.Errorf("AllProviderMeta() len = %d, want 5", len(all))
 	}
 
 	// Verify all known providers are present
@@ -388,9 +390,15 @@ func TestAllProviderMeta(t *testing.T) {
 		if meta.Description == "" {
 			t.Errorf("provider %q has empty Description", meta.ID)
 		}
+		if meta.LoginCommand == "" {
+			t.Errorf("provider %q has empty LoginCommand", meta.ID)
+		}
+		if meta.LoginURL == "" {
+			t.Errorf("provider %q has empty LoginURL", meta.ID)
+		}
 	}
 
-	for _, expected := range []string{"codex", "claude", "gemini"} {
+	for _, expected := range []string{"codex", "claude", "gemini", "cursor", "copilot"} {
 		if !ids[expected] {
 			t.Errorf("AllProviderMeta() missing %q", expected)
 		}
@@ -400,8 +408,8 @@ func TestAllProviderMeta(t *testing.T) {
 func TestKnownProviderIDs(t *testing.T) {
 	ids := KnownProviderIDs()
 
-	if len(ids) != 3 {
-		t.Errorf("KnownProviderIDs() len = %d, want 3", len(ids))
+	if len(ids) != 5 {
+		t.Errorf("KnownProviderIDs() len = %d, want 5", len(ids))
 	}
 
 	// Verify all expected IDs are present
@@ -410,7 +418,7 @@ func TestKnownProviderIDs(t *testing.T) {
 		idMap[id] = true
 	}
 
-	for _, expected := range []string{"codex", "claude", "gemini"} {
+	for _, expected := range []string{"codex", "claude", "gemini", "cursor", "copilot"} {
 		if !idMap[expected] {
 			t.Errorf("KnownProviderIDs() missing %q", expected)
 		}
@@ -2,6 +2,8 @@
 //
 // It monitors stdout/stderr output for provider-specific rate limit patterns
 // and signals when a rate limit is detected, enabling automatic profile switching.
+// Revoked-token fingerprints are tracked separately (see Classify) so a dead
+// token is quarantined for re-login rather than cooled down and retried.
 package ratelimit
 
 import (
@@ -85,6 +87,9 @@ type Detector struct {
 	patterns []*regexp.Regexp
 	detected bool
 	reason   string
+
+	revoked       bool
+	revokedReason string
 }
 
 // NewDetector creates a new rate limit detector for the given provider.
@@ -123,6 +128,9 @@ func NewDetector(provider Provider, customPatterns []string) (*Detector, error)
 // Check examines text for rate limit patterns.
 // Returns true if a rate limit pattern is detected.
 // The detection is sticky - once detected, it remains true.
+//
+// Text matching a revoked-token fingerprint is recorded (see Revoked) and is
+// not treated as a rate limit.
 func (d *Detector) Check(text string) bool {
 	// Fast path: check if already detected using read lock
 	d.mu.RLock()
@@ -130,6 +138,19 @@ func (d *Detector) Check(text string) bool {
 		d.mu.RUnlock()
 		return true
 	}
+	d.mu.RUnlock()
+
+	if match := matchRevoked(d.provider, text); match != "" {
+		d.mu.Lock()
+		if !d.revoked {
+			d.revoked = true
+			d.revokedReason = match
+		}
+		d.mu.Unlock()
+		return false
+	}
+
+	d.mu.RLock()
 	// Capture patterns while holding read lock (patterns slice is immutable after creation)
 	patterns := d.patterns
 	d.mu.RUnlock()
@@ -168,12 +189,28 @@ func (d *Detector) Reason() string {
 	return d.reason
 }
 
+// Revoked returns whether output matched a revoked-token fingerprint.
+func (d *Detector) Revoked() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.revoked
+}
+
+// RevokedReason returns the matched revoked-token text, if any.
+func (d *Detector) RevokedReason() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.revokedReason
+}
+
 // Reset clears the detection state.
 func (d *Detector) Reset() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	d.detected = false
 	d.reason = ""
+	d.revoked = false
+	d.revokedReason = ""
 }
 
 // Provider returns the provider this detector is configured for.
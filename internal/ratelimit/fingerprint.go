@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Kind classifies a provider error by what caam should do about it.
+type Kind string
+
+const (
+	// KindNone means the text matched no known fingerprint.
+	KindNone Kind = ""
+
+	// KindRateLimited is a usage or rate limit; the profile goes into
+	// cooldown and recovers on its own.
+	KindRateLimited Kind = "rate_limited"
+
+	// KindRevoked means the credentials were revoked or can no longer be
+	// refreshed; the profile needs a fresh login and must not be rotated
+	// back into use until then.
+	KindRevoked Kind = "revoked"
+
+	// KindNetwork is a connectivity failure that says nothing about the
+	// account; the profile is left alone.
+	KindNetwork Kind = "network"
+)
+
+// RevokedPatterns returns the token-revoked fingerprints for each provider.
+// These are checked before rate limit patterns, so a 401 from a dead token
+// is never mistaken for a limit and cooled down instead of quarantined.
+func RevokedPatterns() map[Provider][]string {
+	common := []string{
+		`(?i)invalid[_ ]grant`,
+		`(?i)\b401\b[^\n]*unauthori[sz]ed`,
+		`(?i)unauthori[sz]ed[^\n]*\b401\b`,
+	}
+	return map[Provider][]string{
+		ProviderClaude: append([]string{
+			`(?i)oauth token (has been |was )?revoked`,
+			`(?i)authentication_error`,
+			`(?i)invalid (bearer token|api key|x-api-key)`,
+			`(?i)please run /login`,
+		}, common...),
+		ProviderCodex: append([]string{
+			`(?i)refresh[_ ]token[^\n]*(revoked|invalidated|already (been )?used|reused)`,
+			`(?i)token[_ ](revoked|invalidated)`,
+			`(?i)access token could not be refreshed`,
+			`(?i)please (log|sign) ?in again`,
+		}, common...),
+		ProviderGemini: append([]string{
+			`(?i)token has been expired or revoked`,
+			`(?i)\bUNAUTHENTICATED\b`,
+			`(?i)invalid authentication credentials`,
+		}, common...),
+	}
+}
+
+// NetworkPatterns returns fingerprints for connectivity failures, shared by
+// all providers.
+func NetworkPatterns() []string {
+	return []string{
+		`\b(ECONNREFUSED|ECONNRESET|ENOTFOUND|ETIMEDOUT|EAI_AGAIN|ENETUNREACH)\b`,
+		`(?i)connection (refused|reset|timed out)`,
+		`(?i)no such host`,
+		`(?i)network is unreachable`,
+		`(?i)tls handshake timeout`,
+		`(?i)i/o timeout`,
+		`(?i)getaddrinfo`,
+		`(?i)fetch failed`,
+	}
+}
+
+var (
+	revokedCompiled  map[Provider][]*regexp.Regexp
+	networkCompiled  []*regexp.Regexp
+	fingerprintsOnce sync.Once
+)
+
+func initFingerprints() {
+	revokedCompiled = make(map[Provider][]*regexp.Regexp)
+	for provider, patterns := range RevokedPatterns() {
+		revokedCompiled[provider] = compileAll(patterns)
+	}
+	networkCompiled = compileAll(NetworkPatterns())
+}
+
+func compileAll(patterns []string) []*regexp.Regexp {
+	var compiled []*regexp.Regexp
+	for _, p := range patterns {
+		if re, err := regexp.Compile(p); err == nil {
+			compiled = append(compiled, re)
+		}
+	}
+	return compiled
+}
+
+// Classify matches text against the provider's fingerprints and returns the
+// kind of error along with the matching text. Revoked tokens win over rate
+// limits, which win over network errors.
+func Classify(provider Provider, text string) (Kind, string) {
+	if match := matchRevoked(provider, text); match != "" {
+		return KindRevoked, match
+	}
+
+	initDefaultsOnce.Do(initDefaults)
+	for _, re := range defaultCompiledPatterns[provider] {
+		if match := re.FindString(text); match != "" {
+			return KindRateLimited, strings.TrimSpace(match)
+		}
+	}
+
+	fingerprintsOnce.Do(initFingerprints)
+	for _, re := range networkCompiled {
+		if match := re.FindString(text); match != "" {
+			return KindNetwork, strings.TrimSpace(match)
+		}
+	}
+	return KindNone, ""
+}
+
+// matchRevoked returns the revoked-token fingerprint text found in text, or
+// "" if none matched.
+func matchRevoked(provider Provider, text string) string {
+	fingerprintsOnce.Do(initFingerprints)
+	for _, re := range revokedCompiled[provider] {
+		if match := re.FindString(text); match != "" {
+			return strings.TrimSpace(match)
+		}
+	}
+	return ""
+}
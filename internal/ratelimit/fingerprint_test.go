@@ -0,0 +1,61 @@
+package ratelimit
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		text     string
+		want     Kind
+	}{
+		{"claude revoked oauth", ProviderClaude, "Error: OAuth token has been revoked. Please run /login", KindRevoked},
+		{"claude 401", ProviderClaude, "API Error: 401 Unauthorized", KindRevoked},
+		{"claude rate limit", ProviderClaude, "Error: rate limit exceeded", KindRateLimited},
+		{"codex refresh reused", ProviderCodex, "refresh_token was already used", KindRevoked},
+		{"codex invalid grant", ProviderCodex, `{"error":"invalid_grant"}`, KindRevoked},
+		{"codex 429", ProviderCodex, "HTTP 429 Too Many Requests", KindRateLimited},
+		{"gemini revoked", ProviderGemini, "Token has been expired or revoked.", KindRevoked},
+		{"gemini quota", ProviderGemini, "RESOURCE_EXHAUSTED: quota exceeded", KindRateLimited},
+		{"network refused", ProviderClaude, "connect ECONNREFUSED 127.0.0.1:443", KindNetwork},
+		{"network dns", ProviderCodex, "dial tcp: lookup api.openai.com: no such host", KindNetwork},
+		{"nothing", ProviderClaude, "all good", KindNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, match := Classify(tt.provider, tt.text)
+			if got != tt.want {
+				t.Errorf("Classify(%q) = %q (%q), want %q", tt.text, got, match, tt.want)
+			}
+			if got != KindNone && match == "" {
+				t.Errorf("Classify(%q) returned empty match for %q", tt.text, got)
+			}
+		})
+	}
+}
+
+func TestDetector_RevokedIsNotRateLimit(t *testing.T) {
+	d, err := NewDetector(ProviderClaude, nil)
+	if err != nil {
+		t.Fatalf("NewDetector() error = %v", err)
+	}
+
+	if d.Check("API Error: 401 Unauthorized") {
+		t.Error("Check() = true for revoked token, want false")
+	}
+	if d.Detected() {
+		t.Error("Detected() = true for revoked token")
+	}
+	if !d.Revoked() {
+		t.Fatal("Revoked() = false, want true")
+	}
+	if d.RevokedReason() == "" {
+		t.Error("RevokedReason() is empty")
+	}
+
+	d.Reset()
+	if d.Revoked() || d.RevokedReason() != "" {
+		t.Error("Reset() did not clear revoked state")
+	}
+}
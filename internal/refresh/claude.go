@@ -9,6 +9,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // Claude Constants
@@ -84,7 +86,7 @@ var RefreshClaudeToken = func(ctx context.Context, refreshToken string) (*TokenR
 // UpdateClaudeAuth updates the auth files with the new token.
 func UpdateClaudeAuth(path string, resp *TokenResponse) error {
 	// Read existing file to preserve other fields
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("read auth file: %w", err)
 	}
@@ -174,6 +176,11 @@ func writeAuthFile(path string, auth map[string]interface{}) error {
 	if err != nil {
 		return fmt.Errorf("marshal updated auth: %w", err)
 	}
+	// Vault copies stay encrypted when the vault is.
+	updatedData, err = vaultcrypt.SealFor(path, updatedData)
+	if err != nil {
+		return fmt.Errorf("encrypt updated auth: %w", err)
+	}
 
 	tmpPath := path + ".tmp"
 	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
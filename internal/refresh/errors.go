@@ -39,3 +39,25 @@ func (e *UnsupportedError) Error() string {
 func (e *UnsupportedError) Unwrap() error {
 	return ErrUnsupported
 }
+
+// ErrRevoked indicates the provider rejected the refresh because the token
+// was revoked; the profile needs a fresh login.
+var ErrRevoked = errors.New("token revoked")
+
+// RevokedError is returned when a refresh failure matches a revoked-token
+// fingerprint (see ratelimit.Classify).
+type RevokedError struct {
+	Provider string
+	Profile  string
+	// Reason is the fingerprint text that matched.
+	Reason string
+	Err    error
+}
+
+func (e *RevokedError) Error() string {
+	return fmt.Sprintf("%s/%s token revoked (%s): %v", e.Provider, e.Profile, e.Reason, e.Err)
+}
+
+func (e *RevokedError) Unwrap() []error {
+	return []error{ErrRevoked, e.Err}
+}
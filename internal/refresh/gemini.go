@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // Gemini Constants
@@ -85,7 +86,7 @@ var RefreshGeminiToken = func(ctx context.Context, clientID, clientSecret, refre
 
 // ReadADC reads the ADC file to get credentials.
 func ReadADC(path string) (*ADC, error) {
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read ADC file: %w", err)
 	}
@@ -104,7 +105,7 @@ func ReadADC(path string) (*ADC, error) {
 
 // UpdateGeminiAuth updates Gemini auth settings with a refreshed access token and expiry.
 func UpdateGeminiAuth(path string, resp *GoogleTokenResponse) error {
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("read auth file: %w", err)
 	}
@@ -137,6 +138,11 @@ func UpdateGeminiAuth(path string, resp *GoogleTokenResponse) error {
 	if err != nil {
 		return fmt.Errorf("marshal updated auth: %w", err)
 	}
+	// Vault copies stay encrypted when the vault is.
+	updatedData, err = vaultcrypt.SealFor(path, updatedData)
+	if err != nil {
+		return fmt.Errorf("encrypt updated auth: %w", err)
+	}
 
 	tmpPath := path + ".tmp"
 	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
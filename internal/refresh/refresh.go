@@ -12,7 +12,11 @@ import (
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/ratelimit"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/tracing"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // maxErrorBodySize limits how much of an error response body we read.
@@ -38,6 +42,13 @@ func ShouldRefresh(h *health.ProfileHealth, threshold time.Duration) bool {
 
 // RefreshProfile orchestrates the refresh for a specific provider/profile.
 func RefreshProfile(ctx context.Context, provider, profile string, vault *authfile.Vault, store *health.Storage) error {
+	ctx, span := tracing.Start(ctx, "provider.refresh_token", tracing.Provider(provider), tracing.Profile(profile))
+	err := refreshProfile(ctx, provider, profile, vault, store)
+	tracing.End(span, err)
+	return err
+}
+
+func refreshProfile(ctx context.Context, provider, profile string, vault *authfile.Vault, store *health.Storage) error {
 	// Check if this profile is currently active before we modify the vault
 	// (which would change the hash and break ActiveProfile detection).
 	//
@@ -70,9 +81,14 @@ func RefreshProfile(ctx context.Context, provider, profile string, vault *authfi
 	}
 
 	if err != nil {
+		if kind, match := ratelimit.Classify(ratelimit.ProviderFromString(provider), err.Error()); kind == ratelimit.KindRevoked {
+			return &RevokedError{Provider: provider, Profile: profile, Reason: match, Err: err}
+		}
 		return err
 	}
 
+	events.Publish(events.Event{Type: events.TokenRefreshed, Provider: provider, Profile: profile, Source: "refresh"})
+
 	// If the profile was active, restore the updated files to the active location
 	if isActive && len(preRefreshState) > 0 {
 		// Re-verify that the live files haven't changed since we started.
@@ -186,7 +202,7 @@ func refreshGemini(ctx context.Context, provider, profile string, store *health.
 // getRefreshTokenFromJSON reads a JSON file and extracts the refresh_token field.
 // Supports snake_case and camelCase.
 func getRefreshTokenFromJSON(path string) (string, error) {
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return "", err
 	}
@@ -276,8 +292,8 @@ func snapshotMatchesProfile(fileSet authfile.AuthFileSet, vault *authfile.Vault,
 		if !ok {
 			continue
 		}
-		backupPath := vault.BackupPath(fileSet.Tool, profile, filepath.Base(spec.Path))
-		backupData, err := os.ReadFile(backupPath)
+		backupPath := vault.BackupPath(fileSet.Tool, profile, spec.VaultFileName())
+		backupData, err := vaultcrypt.ReadFile(backupPath)
 		if err != nil {
 			return false
 		}
@@ -0,0 +1,75 @@
+// Package risk classifies profiles by how costly it would be to lose them.
+//
+// A primary personal account that would be painful to get flagged or locked
+// is "high" risk; a disposable pool account that exists to absorb unattended
+// agent work is "expendable". Automation uses the tier to decide how
+// aggressive it may be: high-risk profiles are never picked by unattended
+// rotation, and expendable ones are preferred for it.
+package risk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tier is a profile's risk classification.
+type Tier string
+
+const (
+	// High marks a primary or otherwise precious account. Automation must
+	// not rotate onto it or log into it unattended.
+	High Tier = "high"
+
+	// Normal is the default tier for unclassified profiles.
+	Normal Tier = "normal"
+
+	// Expendable marks a disposable pool account, preferred for unattended
+	// agent work.
+	Expendable Tier = "expendable"
+)
+
+// Tiers lists all tiers from most to least protected.
+var Tiers = []Tier{High, Normal, Expendable}
+
+// Parse converts user input to a Tier. It accepts the tier names plus a few
+// descriptive aliases ("primary", "personal", "pool", "disposable").
+// An empty string is Normal.
+func Parse(s string) (Tier, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "normal", "default":
+		return Normal, nil
+	case "high", "primary", "personal", "precious":
+		return High, nil
+	case "expendable", "pool", "disposable", "low":
+		return Expendable, nil
+	default:
+		return "", fmt.Errorf("invalid risk tier %q (valid: high, normal, expendable)", s)
+	}
+}
+
+// AllowsUnattended reports whether unattended automation (rotation and
+// login injection) may act on a profile of this tier.
+func (t Tier) AllowsUnattended() bool {
+	return t != High
+}
+
+// SelectionBonus returns how much to adjust a profile's selection score for
+// unattended work: expendable profiles float up, high-risk ones sink.
+func (t Tier) SelectionBonus() float64 {
+	switch t {
+	case High:
+		return -150
+	case Expendable:
+		return 40
+	default:
+		return 0
+	}
+}
+
+// String returns the tier name, treating the zero value as Normal.
+func (t Tier) String() string {
+	if t == "" {
+		return string(Normal)
+	}
+	return string(t)
+}
@@ -0,0 +1,46 @@
+package risk
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Tier
+		wantErr bool
+	}{
+		{"", Normal, false},
+		{"normal", Normal, false},
+		{"HIGH", High, false},
+		{"primary", High, false},
+		{" pool ", Expendable, false},
+		{"disposable", Expendable, false},
+		{"expendable", Expendable, false},
+		{"medium", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTierPolicy(t *testing.T) {
+	if High.AllowsUnattended() {
+		t.Error("high-risk profiles must not allow unattended automation")
+	}
+	if !Normal.AllowsUnattended() || !Expendable.AllowsUnattended() {
+		t.Error("normal and expendable profiles should allow unattended automation")
+	}
+	if !(Expendable.SelectionBonus() > Normal.SelectionBonus() && Normal.SelectionBonus() > High.SelectionBonus()) {
+		t.Error("selection bonus should order expendable > normal > high")
+	}
+	if Tier("").String() != "normal" {
+		t.Errorf("zero Tier String() = %q, want normal", Tier("").String())
+	}
+}
@@ -0,0 +1,62 @@
+package rotation
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+)
+
+// PolicyState describes a provider's active profile when a rotation policy
+// is evaluated.
+type PolicyState struct {
+	// Active is the active profile; empty if none matches the vault.
+	Active string
+
+	// ActiveSince is when Active was last activated; zero if unknown.
+	ActiveSince time.Time
+
+	// Metrics holds the known config.PolicyMetrics values for Active.
+	// Missing metrics never satisfy a condition.
+	Metrics map[string]float64
+}
+
+// PolicyDue reports whether a policy calls for rotating away from the
+// active profile, with a human-readable reason either way. A scheduled
+// policy whose activation time is unknown is due, so applying policies
+// for the first time starts the schedule.
+func PolicyDue(p config.RotationPolicy, state PolicyState, now time.Time) (bool, string) {
+	if state.Active == "" {
+		return false, "no active profile"
+	}
+
+	if p.When != "" {
+		cond, err := config.ParsePolicyCondition(p.When)
+		if err != nil {
+			return false, err.Error()
+		}
+		v, ok := state.Metrics[cond.Metric]
+		if !ok {
+			return false, fmt.Sprintf("%s unknown", cond.Metric)
+		}
+		value := strconv.FormatFloat(v, 'f', -1, 64)
+		if !cond.Holds(v) {
+			return false, fmt.Sprintf("%s is %s (rotates when %s)", cond.Metric, value, cond)
+		}
+		return true, fmt.Sprintf("%s is %s (%s)", cond.Metric, value, cond)
+	}
+
+	every := p.Every.Duration()
+	if every <= 0 {
+		return false, "policy has no trigger"
+	}
+	if state.ActiveSince.IsZero() {
+		return true, fmt.Sprintf("activation time unknown (every %s)", formatDuration(every))
+	}
+	elapsed := now.Sub(state.ActiveSince)
+	if elapsed < every {
+		return false, fmt.Sprintf("active for %s, rotates in %s", formatDuration(elapsed), formatDuration(every-elapsed))
+	}
+	return true, fmt.Sprintf("active for %s (every %s)", formatDuration(elapsed), formatDuration(every))
+}
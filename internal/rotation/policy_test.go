@@ -0,0 +1,40 @@
+package rotation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+)
+
+func TestPolicyDue(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	every := config.RotationPolicy{Provider: "claude", Every: config.Duration(4 * time.Hour)}
+	when := config.RotationPolicy{Provider: "codex", When: "error_count_1h >= 3"}
+
+	tests := []struct {
+		name   string
+		policy config.RotationPolicy
+		state  PolicyState
+		want   bool
+	}{
+		{"no active profile", every, PolicyState{}, false},
+		{"schedule not reached", every, PolicyState{Active: "a", ActiveSince: now.Add(-3 * time.Hour)}, false},
+		{"schedule reached", every, PolicyState{Active: "a", ActiveSince: now.Add(-4 * time.Hour)}, true},
+		{"activation unknown", every, PolicyState{Active: "a"}, true},
+		{"condition holds", when, PolicyState{Active: "a", Metrics: map[string]float64{"error_count_1h": 3}}, true},
+		{"condition fails", when, PolicyState{Active: "a", Metrics: map[string]float64{"error_count_1h": 2}}, false},
+		{"metric unknown", when, PolicyState{Active: "a"}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, reason := PolicyDue(tc.policy, tc.state, now)
+			if got != tc.want {
+				t.Errorf("PolicyDue() = %v (%s), want %v", got, reason, tc.want)
+			}
+			if reason == "" {
+				t.Error("PolicyDue() returned no reason")
+			}
+		})
+	}
+}
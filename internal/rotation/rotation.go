@@ -19,6 +19,7 @@ import (
 
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
 )
 
 // Algorithm identifies a rotation algorithm.
@@ -73,6 +74,8 @@ type Selector struct {
 	rng         *rand.Rand
 	avoidRecent time.Duration // Don't select profiles used within this duration
 	usageData   map[string]*UsageInfo // Real-time usage data by profile name
+	riskTiers   map[string]risk.Tier  // Risk tier by profile name (missing = normal)
+	unattended  bool                  // Selecting for unattended work; keep off high-risk profiles
 }
 
 // NewSelector creates a new profile selector.
@@ -107,6 +110,22 @@ func (s *Selector) SetUsageData(usage map[string]*UsageInfo) {
 	s.usageData = usage
 }
 
+// SetRiskTiers sets the risk tier of each profile, keyed by profile name.
+func (s *Selector) SetRiskTiers(tiers map[string]risk.Tier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.riskTiers = tiers
+}
+
+// SetUnattended marks selections as being for unattended automation.
+// Unattended selection never picks high-risk profiles and prefers
+// expendable ones.
+func (s *Selector) SetUnattended(unattended bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unattended = unattended
+}
+
 // Select chooses a profile from the given list using the configured algorithm.
 // Returns an error if no profiles are available or all are in cooldown.
 func (s *Selector) Select(tool string, profiles []string, currentProfile string) (*Result, error) {
@@ -129,6 +148,35 @@ func (s *Selector) Select(tool string, profiles []string, currentProfile string)
 		return nil, fmt.Errorf("no user profiles available for %s (only system profiles found)", tool)
 	}
 
+	// A revoked token fails every request until someone logs in again, so
+	// quarantined profiles are never rotated back in.
+	if s.db != nil {
+		var usable []string
+		for _, p := range available {
+			if rev, err := s.db.ActiveRevocation(tool, p); err != nil || rev == nil {
+				usable = append(usable, p)
+			}
+		}
+		if len(usable) == 0 {
+			return nil, fmt.Errorf("all profiles for %s have revoked tokens; log in again and run caam backup", tool)
+		}
+		available = usable
+	}
+
+	// Unattended automation must never land on a high-risk account.
+	if s.unattended {
+		var allowed []string
+		for _, p := range available {
+			if s.riskTier(p).AllowsUnattended() {
+				allowed = append(allowed, p)
+			}
+		}
+		if len(allowed) == 0 {
+			return nil, fmt.Errorf("all profiles for %s are high-risk; unattended rotation will not use them", tool)
+		}
+		available = allowed
+	}
+
 	// If only one profile, return it
 	if len(available) == 1 {
 		return &Result{
@@ -434,7 +482,16 @@ func (s *Selector) selectSmart(tool string, profiles []string) (*Result, error)
 			}
 		}
 
-		// Factor 5: Small random jitter to break ties
+		// Factor 5: Risk tier (unattended work prefers expendable accounts)
+		if s.unattended && s.riskTier(p) == risk.Expendable {
+			score.Score += risk.Expendable.SelectionBonus()
+			score.Reasons = append(score.Reasons, Reason{
+				Text:     "Expendable account (preferred for unattended work)",
+				Positive: true,
+			})
+		}
+
+		// Factor 6: Small random jitter to break ties
 		jitter := s.rng.Float64() * 5
 		score.Score += jitter
 
@@ -462,6 +519,14 @@ func (s *Selector) selectSmart(tool string, profiles []string) (*Result, error)
 	}, nil
 }
 
+// riskTier returns a profile's risk tier, defaulting to normal.
+func (s *Selector) riskTier(profile string) risk.Tier {
+	if tier, ok := s.riskTiers[profile]; ok && tier != "" {
+		return tier
+	}
+	return risk.Normal
+}
+
 // isInCooldown checks if a profile is currently in cooldown.
 func (s *Selector) isInCooldown(tool, profile string, now time.Time) bool {
 	if s.db == nil {
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/risk"
 )
 
 func TestNewSelector(t *testing.T) {
@@ -348,3 +350,53 @@ func TestSetAvoidRecent(t *testing.T) {
 		t.Errorf("avoidRecent = %v, expected 2h", s.avoidRecent)
 	}
 }
+
+func TestSelectUnattendedRiskTiers(t *testing.T) {
+	tiers := map[string]risk.Tier{
+		"personal": risk.High,
+		"pool-1":   risk.Expendable,
+	}
+	profiles := []string{"personal", "work", "pool-1"}
+
+	for _, algo := range []Algorithm{AlgorithmSmart, AlgorithmRoundRobin, AlgorithmRandom} {
+		s := NewSelector(algo, nil, nil)
+		s.SetRNG(rand.New(rand.NewSource(7)))
+		s.SetRiskTiers(tiers)
+		s.SetUnattended(true)
+
+		for i := 0; i < 10; i++ {
+			result, err := s.Select("claude", profiles, "")
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", algo, err)
+			}
+			if result.Selected == "personal" {
+				t.Fatalf("%s: unattended selection picked a high-risk profile", algo)
+			}
+		}
+	}
+
+	// Smart selection prefers the expendable account.
+	s := NewSelector(AlgorithmSmart, nil, nil)
+	s.SetRiskTiers(tiers)
+	s.SetUnattended(true)
+	result, err := s.Select("claude", profiles, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Selected != "pool-1" {
+		t.Errorf("expected expendable profile pool-1, got %q", result.Selected)
+	}
+
+	// Attended selection still considers high-risk profiles.
+	s.SetUnattended(false)
+	result, err = s.Select("claude", []string{"personal"}, "")
+	if err != nil || result.Selected != "personal" {
+		t.Errorf("attended selection = %v, %v; want personal", result, err)
+	}
+
+	// Nothing left once high-risk profiles are excluded.
+	s.SetUnattended(true)
+	if _, err := s.Select("claude", []string{"personal"}, ""); err == nil {
+		t.Error("expected error when only high-risk profiles are available")
+	}
+}
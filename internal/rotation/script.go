@@ -0,0 +1,111 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ScriptInput is what a scoring script reads on stdin.
+type ScriptInput struct {
+	// Command is the robot command scoring the profiles: "next" or
+	// "precheck".
+	Command    string            `json:"command"`
+	Provider   string            `json:"provider"`
+	Now        string            `json:"now"`
+	Candidates []ScriptCandidate `json:"candidates"`
+}
+
+// ScriptCandidate is a profile with its built-in score.
+type ScriptCandidate struct {
+	Profile string         `json:"profile"`
+	Score   float64        `json:"score"`
+	Factors []ScriptFactor `json:"factors,omitempty"`
+
+	// Info is the profile's robot status, as caam robot status reports it.
+	Info interface{} `json:"info,omitempty"`
+}
+
+// ScriptFactor is one part of a candidate's built-in score.
+type ScriptFactor struct {
+	Factor string  `json:"factor"`
+	Points float64 `json:"points"`
+	Detail string  `json:"detail,omitempty"`
+}
+
+// ScriptOutput is what a scoring script prints on stdout.
+type ScriptOutput struct {
+	Adjustments []ScriptAdjustment `json:"adjustments"`
+}
+
+// ScriptAdjustment adds points to a candidate's score.
+type ScriptAdjustment struct {
+	Profile string  `json:"profile"`
+	Points  float64 `json:"points"`
+	Reason  string  `json:"reason,omitempty"`
+}
+
+// RunScoringScript runs script with in as JSON on stdin and returns the
+// adjustments it prints. script is split on whitespace into a command and
+// its arguments; a leading ~/ is expanded. Adjustments for profiles that
+// are not candidates are dropped.
+func RunScoringScript(ctx context.Context, script string, timeout time.Duration, in ScriptInput) ([]ScriptAdjustment, error) {
+	args := strings.Fields(script)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("scoring script is empty")
+	}
+	if strings.HasPrefix(args[0], "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			args[0] = filepath.Join(home, args[0][2:])
+		}
+	}
+
+	input, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("marshal scoring input: %w", err)
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait on children that outlive a killed script.
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("scoring script timed out after %s", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("scoring script: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("scoring script: %w", err)
+	}
+
+	var out ScriptOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("parse scoring script output: %w", err)
+	}
+	known := make(map[string]bool, len(in.Candidates))
+	for _, c := range in.Candidates {
+		known[c.Profile] = true
+	}
+	adjustments := make([]ScriptAdjustment, 0, len(out.Adjustments))
+	for _, a := range out.Adjustments {
+		if known[a.Profile] {
+			adjustments = append(adjustments, a)
+		}
+	}
+	return adjustments, nil
+}
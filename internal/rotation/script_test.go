@@ -0,0 +1,67 @@
+package rotation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "score.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunScoringScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test scripts are shell scripts")
+	}
+	in := ScriptInput{
+		Command:  "next",
+		Provider: "claude",
+		Candidates: []ScriptCandidate{
+			{Profile: "work", Score: 120},
+			{Profile: "spare", Score: 100},
+		},
+	}
+
+	// The script sees the candidates and its adjustments for unknown
+	// profiles are dropped.
+	script := writeScript(t, `grep -q '"profile":"spare"' || exit 3
+echo '{"adjustments":[{"profile":"work","points":-50,"reason":"used recently"},{"profile":"ghost","points":10}]}'`)
+	got, err := RunScoringScript(context.Background(), script+" --flag", time.Second, in)
+	if err != nil {
+		t.Fatalf("RunScoringScript: %v", err)
+	}
+	if len(got) != 1 || got[0].Profile != "work" || got[0].Points != -50 || got[0].Reason != "used recently" {
+		t.Errorf("adjustments = %+v", got)
+	}
+
+	tests := []struct {
+		name, body, wantErr string
+		timeout             time.Duration
+	}{
+		{"exit status", "echo broken >&2; exit 1", "broken", time.Second},
+		{"bad output", "echo not json", "parse scoring script output", time.Second},
+		{"timeout", "sleep 5", "timed out", 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RunScoringScript(context.Background(), writeScript(t, tt.body), tt.timeout, in)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := RunScoringScript(context.Background(), "  ", time.Second, in); err == nil {
+		t.Error("empty script: want error")
+	}
+}
@@ -0,0 +1,87 @@
+package rotation
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+)
+
+// ShapingState describes a profile when shaping rules are checked.
+type ShapingState struct {
+	// Active is true for the provider's active profile.
+	Active bool
+
+	// ActiveSince is when the profile was last activated; zero if unknown.
+	ActiveSince time.Time
+
+	// ActivationsToday is how often the profile was activated since the
+	// start of the local day.
+	ActivationsToday int
+}
+
+// DwellRemaining returns how much longer the active profile must stay
+// active before rotating away from it is allowed. It is zero once the
+// minimum dwell has passed, or if the activation time is unknown.
+func DwellRemaining(rules config.ShapingRules, state ShapingState, now time.Time) time.Duration {
+	if !state.Active || state.ActiveSince.IsZero() {
+		return 0
+	}
+	remaining := rules.MinDwell.Duration() - now.Sub(state.ActiveSince)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// DwellReason describes a dwell that has not passed yet.
+func DwellReason(remaining time.Duration) string {
+	return fmt.Sprintf("minimum dwell not reached, rotates in %s", formatDuration(remaining))
+}
+
+// ShapingBlock returns why a profile may not be activated now, or "" if it
+// may. A profile is blocked outside its active hours and, unless it is
+// already active, once it has reached its daily activation cap.
+func ShapingBlock(rules config.ShapingRules, state ShapingState, now time.Time) string {
+	windows, err := config.ParseActiveHours(rules.ActiveHours)
+	if err != nil {
+		return err.Error()
+	}
+	if len(windows) > 0 {
+		inside := false
+		names := make([]string, 0, len(windows))
+		for _, w := range windows {
+			inside = inside || w.Contains(now)
+			names = append(names, w.String())
+		}
+		if !inside {
+			return fmt.Sprintf("outside active hours (%s)", strings.Join(names, ", "))
+		}
+	}
+	if !state.Active && rules.MaxActivationsPerDay > 0 && state.ActivationsToday >= rules.MaxActivationsPerDay {
+		return fmt.Sprintf("daily activation cap reached (%d/%d)", state.ActivationsToday, rules.MaxActivationsPerDay)
+	}
+	return ""
+}
+
+// StartOfDay returns local midnight on t's day, where daily activation
+// caps reset.
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// JitterDuration lengthens d by a random fraction of it, up to jitter.
+// A nil rng uses the global source.
+func JitterDuration(d time.Duration, jitter float64, rng *rand.Rand) time.Duration {
+	if d <= 0 || jitter <= 0 {
+		return d
+	}
+	f := rand.Float64
+	if rng != nil {
+		f = rng.Float64
+	}
+	return d + time.Duration(float64(d)*jitter*f())
+}
@@ -0,0 +1,83 @@
+package rotation
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+)
+
+func TestDwellRemaining(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	rules := config.ShapingRules{MinDwell: config.Duration(30 * time.Minute)}
+
+	if got := DwellRemaining(rules, ShapingState{Active: true, ActiveSince: now.Add(-10 * time.Minute)}, now); got != 20*time.Minute {
+		t.Errorf("DwellRemaining(10m in) = %s, want 20m", got)
+	}
+	if got := DwellRemaining(rules, ShapingState{Active: true, ActiveSince: now.Add(-time.Hour)}, now); got != 0 {
+		t.Errorf("DwellRemaining(1h in) = %s, want 0", got)
+	}
+	if got := DwellRemaining(rules, ShapingState{Active: true}, now); got != 0 {
+		t.Errorf("DwellRemaining(unknown) = %s, want 0", got)
+	}
+	if got := DwellRemaining(rules, ShapingState{ActiveSince: now}, now); got != 0 {
+		t.Errorf("DwellRemaining(inactive) = %s, want 0", got)
+	}
+}
+
+func TestShapingBlock(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 1, h, m, 0, 0, time.UTC) }
+	day := config.ShapingRules{ActiveHours: "08:30-12:00,13:00-19:00", MaxActivationsPerDay: 3}
+	night := config.ShapingRules{ActiveHours: "22:00-02:00"}
+
+	tests := []struct {
+		name  string
+		rules config.ShapingRules
+		state ShapingState
+		now   time.Time
+		want  string
+	}{
+		{"inside window", day, ShapingState{}, at(9, 0), ""},
+		{"lunch break", day, ShapingState{}, at(12, 30), "outside active hours"},
+		{"window end is exclusive", day, ShapingState{}, at(19, 0), "outside active hours"},
+		{"overnight before midnight", night, ShapingState{}, at(23, 0), ""},
+		{"overnight after midnight", night, ShapingState{}, at(1, 59), ""},
+		{"overnight outside", night, ShapingState{}, at(2, 0), "outside active hours"},
+		{"under cap", day, ShapingState{ActivationsToday: 2}, at(9, 0), ""},
+		{"cap reached", day, ShapingState{ActivationsToday: 3}, at(9, 0), "daily activation cap reached (3/3)"},
+		{"active profile ignores cap", day, ShapingState{Active: true, ActivationsToday: 5}, at(9, 0), ""},
+		{"no rules", config.ShapingRules{}, ShapingState{ActivationsToday: 100}, at(3, 0), ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ShapingBlock(tc.rules, tc.state, tc.now)
+			if tc.want == "" && got != "" || !strings.HasPrefix(got, tc.want) {
+				t.Errorf("ShapingBlock() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJitterDuration(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	d := time.Hour
+	for i := 0; i < 100; i++ {
+		got := JitterDuration(d, 0.2, rng)
+		if got < d || got > d+12*time.Minute {
+			t.Fatalf("JitterDuration(1h, 0.2) = %s, want within [1h, 1h12m]", got)
+		}
+	}
+	if got := JitterDuration(d, 0, rng); got != d {
+		t.Errorf("JitterDuration(1h, 0) = %s, want 1h", got)
+	}
+}
+
+func TestStartOfDay(t *testing.T) {
+	loc := time.FixedZone("X", 5*3600)
+	got := StartOfDay(time.Date(2026, 3, 4, 17, 45, 12, 0, loc))
+	if want := time.Date(2026, 3, 4, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("StartOfDay() = %s, want %s", got, want)
+	}
+}
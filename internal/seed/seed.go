@@ -0,0 +1,213 @@
+// Package seed generates realistic fake vault profiles and activity history.
+//
+// It exists so performance work on status, next, and sync can be measured
+// against a large, reproducible data set instead of whatever happens to be
+// in a developer's vault. The same options and seed always produce the same
+// profiles, expiries, cooldowns, and events (relative to Options.Now).
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+// Providers lists the providers seed knows how to fake, in the order they
+// are assigned by Options.Providers.
+var Providers = []string{"claude", "codex", "gemini"}
+
+// ProfilePrefix prefixes every generated profile name so seeded data is easy
+// to spot and remove.
+const ProfilePrefix = "seed-"
+
+// Options controls what Generate produces.
+type Options struct {
+	// VaultDir is the vault root to write profiles into (required).
+	VaultDir string
+
+	// DB receives activity events and cooldowns. Optional.
+	DB *caamdb.DB
+
+	// Profiles is the total number of profiles, spread across providers.
+	Profiles int
+
+	// Providers is how many providers to use, from the front of Providers.
+	Providers int
+
+	// Seed makes generation reproducible. Zero uses 1.
+	Seed int64
+
+	// Now anchors generated timestamps. Zero uses time.Now().
+	Now time.Time
+}
+
+// Result summarizes what Generate wrote.
+type Result struct {
+	Profiles   int            `json:"profiles"`
+	ByProvider map[string]int `json:"by_provider"`
+	Events     int            `json:"events"`
+	Cooldowns  int            `json:"cooldowns"`
+	Expired    int            `json:"expired"`
+}
+
+// Generate writes fake profiles into the vault and, if a DB is given,
+// activity history and cooldowns for them.
+func Generate(opts Options) (*Result, error) {
+	if opts.VaultDir == "" {
+		return nil, fmt.Errorf("vault dir is required")
+	}
+	if opts.Profiles <= 0 {
+		return nil, fmt.Errorf("profiles must be > 0")
+	}
+	if opts.Providers <= 0 || opts.Providers > len(Providers) {
+		return nil, fmt.Errorf("providers must be between 1 and %d", len(Providers))
+	}
+	if opts.Seed == 0 {
+		opts.Seed = 1
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	providers := Providers[:opts.Providers]
+	result := &Result{ByProvider: make(map[string]int)}
+
+	for i := 0; i < opts.Profiles; i++ {
+		provider := providers[i%len(providers)]
+		name := fmt.Sprintf("%s%04d", ProfilePrefix, i/len(providers)+1)
+
+		expiresAt := opts.Now.Add(randomTTL(rng))
+		if expiresAt.Before(opts.Now) {
+			result.Expired++
+		}
+
+		profileDir := filepath.Join(opts.VaultDir, provider, name)
+		if err := writeAuthFiles(profileDir, provider, name, expiresAt, rng); err != nil {
+			return nil, fmt.Errorf("write %s/%s: %w", provider, name, err)
+		}
+		result.Profiles++
+		result.ByProvider[provider]++
+
+		if opts.DB == nil {
+			continue
+		}
+
+		events, err := seedEvents(opts.DB, provider, name, opts.Now, rng)
+		if err != nil {
+			return nil, fmt.Errorf("seed events for %s/%s: %w", provider, name, err)
+		}
+		result.Events += events
+
+		// Roughly 1 in 10 profiles is cooling down after a limit hit.
+		if rng.Intn(10) == 0 {
+			hitAt := opts.Now.Add(-time.Duration(rng.Intn(60)) * time.Minute)
+			duration := time.Duration(30+rng.Intn(270)) * time.Minute
+			if _, err := opts.DB.SetCooldown(provider, name, hitAt, duration, "seeded rate limit"); err != nil {
+				return nil, fmt.Errorf("seed cooldown for %s/%s: %w", provider, name, err)
+			}
+			result.Cooldowns++
+		}
+	}
+
+	return result, nil
+}
+
+// randomTTL returns a token lifetime with a realistic spread: most tokens
+// are comfortably valid, some are expiring soon, and a few have expired.
+func randomTTL(rng *rand.Rand) time.Duration {
+	switch n := rng.Intn(100); {
+	case n < 70:
+		return time.Duration(7*24+rng.Intn(23*24)) * time.Hour
+	case n < 85:
+		return time.Duration(24+rng.Intn(6*24)) * time.Hour
+	case n < 95:
+		return time.Duration(5+rng.Intn(24*60-5)) * time.Minute
+	default:
+		return -time.Duration(1+rng.Intn(72)) * time.Hour
+	}
+}
+
+// writeAuthFiles writes auth files in each provider's on-disk format.
+func writeAuthFiles(dir, provider, name string, expiresAt time.Time, rng *rand.Rand) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	accessToken := fmt.Sprintf("seed-access-%s-%s-%08x", provider, name, rng.Uint32())
+	refreshToken := fmt.Sprintf("seed-refresh-%s-%s-%08x", provider, name, rng.Uint32())
+
+	files := make(map[string]interface{})
+	switch provider {
+	case "claude":
+		files[".credentials.json"] = map[string]interface{}{
+			"claudeAiOauth": map[string]interface{}{
+				"accessToken":      accessToken,
+				"refreshToken":     refreshToken,
+				"expiresAt":        expiresAt.UnixMilli(),
+				"subscriptionType": "max",
+				"scopes":           []string{"user:inference", "user:profile"},
+			},
+		}
+		files[".claude.json"] = map[string]interface{}{
+			"oauthAccount": map[string]interface{}{
+				"emailAddress": name + "@seed.example.com",
+			},
+		}
+	case "codex":
+		files["auth.json"] = map[string]interface{}{
+			"access_token":  accessToken,
+			"refresh_token": refreshToken,
+			"expires_at":    expiresAt.Unix(),
+			"token_type":    "Bearer",
+		}
+	case "gemini":
+		files["settings.json"] = map[string]interface{}{
+			"access_token":  accessToken,
+			"refresh_token": refreshToken,
+			"expiry":        expiresAt.UTC().Format(time.RFC3339),
+			"token_type":    "Bearer",
+		}
+	default:
+		return fmt.Errorf("unsupported provider: %s", provider)
+	}
+
+	for filename, content := range files {
+		data, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, filename), data, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedEvents logs a month of activations, with the occasional error.
+func seedEvents(db *caamdb.DB, provider, name string, now time.Time, rng *rand.Rand) (int, error) {
+	count := 5 + rng.Intn(16)
+	for i := 0; i < count; i++ {
+		event := caamdb.Event{
+			Timestamp:   now.Add(-time.Duration(rng.Intn(30*24*60)) * time.Minute),
+			Type:        caamdb.EventActivate,
+			Provider:    provider,
+			ProfileName: name,
+			Duration:    time.Duration(5+rng.Intn(240)) * time.Minute,
+		}
+		if rng.Intn(8) == 0 {
+			event.Type = caamdb.EventError
+			event.Duration = 0
+			event.Details = map[string]any{"error": "seeded 429 rate limit"}
+		}
+		if err := db.LogEvent(event); err != nil {
+			return i, err
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,90 @@
+package seed
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := caamdb.OpenAt(filepath.Join(tmpDir, "caam.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	result, err := Generate(Options{
+		VaultDir:  filepath.Join(tmpDir, "vault"),
+		DB:        db,
+		Profiles:  30,
+		Providers: 3,
+		Seed:      42,
+		Now:       now,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 30, result.Profiles)
+	assert.Equal(t, map[string]int{"claude": 10, "codex": 10, "gemini": 10}, result.ByProvider)
+	assert.Greater(t, result.Events, 0)
+
+	// Generated auth files parse with the real expiry parsers.
+	claudeInfo, err := health.ParseClaudeExpiry(filepath.Join(tmpDir, "vault", "claude", "seed-0001"))
+	require.NoError(t, err)
+	assert.False(t, claudeInfo.ExpiresAt.IsZero())
+
+	codexInfo, err := health.ParseCodexExpiry(filepath.Join(tmpDir, "vault", "codex", "seed-0001", "auth.json"))
+	require.NoError(t, err)
+	assert.False(t, codexInfo.ExpiresAt.IsZero())
+
+	geminiInfo, err := health.ParseGeminiExpiry(filepath.Join(tmpDir, "vault", "gemini", "seed-0001"))
+	require.NoError(t, err)
+	assert.False(t, geminiInfo.ExpiresAt.IsZero())
+
+	cooldowns, err := db.ListActiveCooldowns(now)
+	require.NoError(t, err)
+	assert.Len(t, cooldowns, result.Cooldowns)
+}
+
+func TestGenerate_Reproducible(t *testing.T) {
+	now := time.Now()
+	opts := Options{Profiles: 50, Providers: 2, Seed: 7, Now: now}
+
+	opts.VaultDir = t.TempDir()
+	first, err := Generate(opts)
+	require.NoError(t, err)
+
+	opts.VaultDir = t.TempDir()
+	second, err := Generate(opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.NotContains(t, first.ByProvider, "gemini")
+}
+
+func TestGenerate_Validation(t *testing.T) {
+	_, err := Generate(Options{Profiles: 1, Providers: 1})
+	assert.Error(t, err, "missing vault dir")
+
+	_, err = Generate(Options{VaultDir: t.TempDir(), Providers: 1})
+	assert.Error(t, err, "zero profiles")
+
+	_, err = Generate(Options{VaultDir: t.TempDir(), Profiles: 1, Providers: 4})
+	assert.Error(t, err, "too many providers")
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := Generate(Options{
+			VaultDir:  b.TempDir(),
+			Profiles:  200,
+			Providers: 3,
+		}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
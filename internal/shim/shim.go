@@ -0,0 +1,373 @@
+// Package shim installs wrapper scripts named after a provider's CLI
+// (claude, codex, gemini, ...) that run the tool through 'caam run'.
+//
+// With the shim directory ahead of the real tool on PATH, scripts and agents
+// that call the tool by name get profile selection, rate limit failover, and
+// usage recording without any changes. 'caam run' drops every shim
+// directory from PATH before starting the tool, so a shim never ends up
+// running itself.
+//
+// Installed shims are recorded in shims.json in the caam data directory so
+// they can be listed, checked, and removed wherever they were written.
+package shim
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirEnv is set by every shim to the directory holding it.
+const DirEnv = "CAAM_SHIM_DIR"
+
+// marker identifies a file as a caam shim; it is followed by the provider.
+const marker = "caam-shim: "
+
+var (
+	// ErrNotShim is returned when installing over, or removing, a file that
+	// caam did not write.
+	ErrNotShim = errors.New("file exists and is not a caam shim")
+
+	// ErrNotInstalled is returned when removing a shim that is not recorded.
+	ErrNotInstalled = errors.New("shim not installed")
+)
+
+// Shim is one installed wrapper script.
+type Shim struct {
+	Provider string `json:"provider"`
+	Path     string `json:"path"`
+
+	// CaamPath is the caam binary the script runs.
+	CaamPath string `json:"caam_path"`
+
+	// RunArgs are extra 'caam run' flags, such as --precheck.
+	RunArgs []string `json:"run_args,omitempty"`
+
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// Dir returns the directory holding the shim.
+func (s Shim) Dir() string {
+	return filepath.Dir(s.Path)
+}
+
+// DefaultDir returns the shim directory used when none is given.
+func DefaultDir(dataPath string) string {
+	return filepath.Join(dataPath, "shims")
+}
+
+// ScriptName returns the shim file name for provider on this platform.
+func ScriptName(provider string) string {
+	if runtime.GOOS == "windows" {
+		return provider + ".cmd"
+	}
+	return provider
+}
+
+// Script returns the contents of the shim script for s.
+func Script(s Shim) []byte {
+	var b bytes.Buffer
+	if runtime.GOOS == "windows" {
+		fmt.Fprintf(&b, "@echo off\r\nrem %s%s\r\n", marker, s.Provider)
+		fmt.Fprintf(&b, "rem Generated by 'caam shim install %s'. Remove with 'caam shim uninstall %s'.\r\n", s.Provider, s.Provider)
+		b.WriteString("set \"" + DirEnv + "=%~dp0\"\r\n")
+		fmt.Fprintf(&b, "\"%s\" run %s", s.CaamPath, s.Provider)
+		for _, arg := range s.RunArgs {
+			b.WriteString(" " + arg)
+		}
+		b.WriteString(" -- %*\r\n")
+		return b.Bytes()
+	}
+
+	fmt.Fprintf(&b, "#!/bin/sh\n# %s%s\n", marker, s.Provider)
+	fmt.Fprintf(&b, "# Generated by 'caam shim install %s'. Remove with 'caam shim uninstall %s'.\n", s.Provider, s.Provider)
+	fmt.Fprintf(&b, "%s=%s\nexport %s\n", DirEnv, shQuote(s.Dir()), DirEnv)
+	fmt.Fprintf(&b, "exec %s run %s", shQuote(s.CaamPath), s.Provider)
+	for _, arg := range s.RunArgs {
+		b.WriteString(" " + shQuote(arg))
+	}
+	b.WriteString(" -- \"$@\"\n")
+	return b.Bytes()
+}
+
+// IsShim reports whether the file at path is a caam shim for provider.
+func IsShim(path, provider string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	if len(data) > 1024 {
+		data = data[:1024]
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(strings.TrimPrefix(line, "#"), "rem")
+		if strings.TrimSpace(line) == marker+provider {
+			return true
+		}
+	}
+	return false
+}
+
+// Manager installs and tracks shims.
+type Manager struct {
+	dataPath string
+}
+
+// NewManager returns a manager recording shims under dataPath.
+func NewManager(dataPath string) *Manager {
+	return &Manager{dataPath: dataPath}
+}
+
+func (m *Manager) statePath() string {
+	return filepath.Join(m.dataPath, "shims.json")
+}
+
+// List returns the recorded shims, sorted by provider.
+func (m *Manager) List() ([]Shim, error) {
+	data, err := os.ReadFile(m.statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read shim state: %w", err)
+	}
+	var shims []Shim
+	if err := json.Unmarshal(data, &shims); err != nil {
+		return nil, fmt.Errorf("parse shim state: %w", err)
+	}
+	return shims, nil
+}
+
+func (m *Manager) save(shims []Shim) error {
+	path := m.statePath()
+	if len(shims) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove shim state: %w", err)
+		}
+		return nil
+	}
+	sort.Slice(shims, func(i, j int) bool { return shims[i].Provider < shims[j].Provider })
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create data dir: %w", err)
+	}
+	data, err := json.MarshalIndent(shims, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal shim state: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("write shim state: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("save shim state: %w", err)
+	}
+	return nil
+}
+
+// Install writes the shim script at s.Path and records it, replacing any
+// earlier shim for the same provider. An existing file that is not a caam
+// shim is only overwritten with force.
+func (m *Manager) Install(s Shim, force bool) error {
+	if _, err := os.Stat(s.Path); err == nil && !force && !IsShim(s.Path, s.Provider) {
+		return fmt.Errorf("%s: %w", s.Path, ErrNotShim)
+	}
+	if err := os.MkdirAll(s.Dir(), 0755); err != nil {
+		return fmt.Errorf("create shim dir: %w", err)
+	}
+	tmpPath := s.Path + ".tmp"
+	if err := os.WriteFile(tmpPath, Script(s), 0755); err != nil {
+		return fmt.Errorf("write shim: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.Path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("install shim: %w", err)
+	}
+
+	shims, err := m.List()
+	if err != nil {
+		return err
+	}
+	kept := shims[:0]
+	for _, old := range shims {
+		if old.Provider == s.Provider {
+			// A shim moved to a new directory leaves no copy behind.
+			if old.Path != s.Path && IsShim(old.Path, old.Provider) {
+				_ = os.Remove(old.Path)
+			}
+			continue
+		}
+		kept = append(kept, old)
+	}
+	if s.InstalledAt.IsZero() {
+		s.InstalledAt = time.Now().UTC()
+	}
+	return m.save(append(kept, s))
+}
+
+// Uninstall removes the shim for provider and its record. A file that is no
+// longer a caam shim is left in place, and ErrNotShim is returned after the
+// record is dropped.
+func (m *Manager) Uninstall(provider string) (*Shim, error) {
+	shims, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	var removed *Shim
+	kept := shims[:0]
+	for i := range shims {
+		if shims[i].Provider == provider && removed == nil {
+			s := shims[i]
+			removed = &s
+			continue
+		}
+		kept = append(kept, shims[i])
+	}
+	if removed == nil {
+		return nil, fmt.Errorf("%s: %w", provider, ErrNotInstalled)
+	}
+	if err := m.save(kept); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(removed.Path); os.IsNotExist(err) {
+		return removed, nil
+	}
+	if !IsShim(removed.Path, provider) {
+		return removed, fmt.Errorf("%s: %w", removed.Path, ErrNotShim)
+	}
+	if err := os.Remove(removed.Path); err != nil {
+		return removed, fmt.Errorf("remove shim: %w", err)
+	}
+	return removed, nil
+}
+
+// Dirs returns every directory holding a recorded shim, plus the one named
+// by DirEnv when caam was started from a shim.
+func (m *Manager) Dirs() []string {
+	var dirs []string
+	if dir := os.Getenv(DirEnv); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	shims, _ := m.List()
+	for _, s := range shims {
+		dirs = append(dirs, s.Dir())
+	}
+	return dirs
+}
+
+// StripPath returns pathList (a PATH value) without the given directories.
+func StripPath(pathList string, dirs []string) string {
+	drop := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		drop[filepath.Clean(dir)] = true
+	}
+	var kept []string
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir != "" && drop[filepath.Clean(dir)] {
+			continue
+		}
+		kept = append(kept, dir)
+	}
+	return strings.Join(kept, string(os.PathListSeparator))
+}
+
+// Status is the health of an installed shim.
+type Status struct {
+	Shim
+
+	// Present is true when the shim file exists and is still a caam shim.
+	Present bool `json:"present"`
+
+	// CaamFound is true when the caam binary the shim runs still exists.
+	CaamFound bool `json:"caam_found"`
+
+	// OnPath is true when the shim's directory is on PATH.
+	OnPath bool `json:"on_path"`
+
+	// Resolved is what running the provider by name finds first on PATH.
+	Resolved string `json:"resolved,omitempty"`
+
+	// Active is true when Resolved is the shim.
+	Active bool `json:"active"`
+
+	// RealBinary is the tool the shim ends up running, found on PATH
+	// without the shim directories.
+	RealBinary string `json:"real_binary,omitempty"`
+}
+
+// Healthy reports whether the shim is in place and wraps a real tool.
+func (s Status) Healthy() bool {
+	return s.Present && s.CaamFound && s.Active && s.RealBinary != ""
+}
+
+// Check inspects s against pathList (a PATH value).
+func (m *Manager) Check(s Shim, pathList string) Status {
+	st := Status{Shim: s}
+	st.Present = IsShim(s.Path, s.Provider)
+	if info, err := os.Stat(s.CaamPath); err == nil && !info.IsDir() {
+		st.CaamFound = true
+	}
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir != "" && filepath.Clean(dir) == filepath.Clean(s.Dir()) {
+			st.OnPath = true
+		}
+	}
+	st.Resolved = LookPath(s.Provider, pathList)
+	if st.Resolved != "" {
+		a, errA := os.Stat(st.Resolved)
+		b, errB := os.Stat(s.Path)
+		st.Active = errA == nil && errB == nil && os.SameFile(a, b)
+	}
+	st.RealBinary = LookPath(s.Provider, StripPath(pathList, m.Dirs()))
+	return st
+}
+
+// LookPath finds the executable name in the directories of pathList, like
+// exec.LookPath but against a PATH value other than the process's own.
+func LookPath(name, pathList string) string {
+	names := []string{name}
+	if runtime.GOOS == "windows" {
+		names = nil
+		exts := os.Getenv("PATHEXT")
+		if exts == "" {
+			exts = ".com;.exe;.bat;.cmd"
+		}
+		for _, ext := range strings.Split(strings.ToLower(exts), ";") {
+			if ext != "" {
+				names = append(names, name+ext)
+			}
+		}
+	}
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		for _, n := range names {
+			path := filepath.Join(dir, n)
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+			return path
+		}
+	}
+	return ""
+}
+
+// shQuote quotes s for a POSIX shell.
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,136 @@
+package shim
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestInstallUninstall(t *testing.T) {
+	dataDir := t.TempDir()
+	m := NewManager(dataDir)
+	s := Shim{
+		Provider: "claude",
+		Path:     filepath.Join(DefaultDir(dataDir), ScriptName("claude")),
+		CaamPath: "/opt/caam bin/caam",
+		RunArgs:  []string{"--precheck"},
+	}
+	if err := m.Install(s, false); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if !IsShim(s.Path, "claude") {
+		t.Fatal("installed file is not recognized as a shim")
+	}
+	if IsShim(s.Path, "codex") {
+		t.Error("claude shim recognized as a codex shim")
+	}
+	if runtime.GOOS != "windows" {
+		data, _ := os.ReadFile(s.Path)
+		if !strings.Contains(string(data), `exec '/opt/caam bin/caam' run claude '--precheck' -- "$@"`) {
+			t.Errorf("script does not run caam:\n%s", data)
+		}
+	}
+
+	shims, err := m.List()
+	if err != nil || len(shims) != 1 || shims[0].InstalledAt.IsZero() {
+		t.Fatalf("List() = %+v, %v", shims, err)
+	}
+
+	removed, err := m.Uninstall("claude")
+	if err != nil || removed.Path != s.Path {
+		t.Fatalf("Uninstall() = %+v, %v", removed, err)
+	}
+	if _, err := os.Stat(s.Path); !os.IsNotExist(err) {
+		t.Error("shim file still exists")
+	}
+	if _, err := m.Uninstall("claude"); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("second Uninstall() error = %v, want ErrNotInstalled", err)
+	}
+}
+
+func TestInstallRefusesForeignFile(t *testing.T) {
+	dataDir := t.TempDir()
+	m := NewManager(dataDir)
+	path := filepath.Join(dataDir, "bin", "codex")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho real\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	s := Shim{Provider: "codex", Path: path, CaamPath: "/usr/bin/caam"}
+
+	if err := m.Install(s, false); !errors.Is(err, ErrNotShim) {
+		t.Fatalf("Install() error = %v, want ErrNotShim", err)
+	}
+	if err := m.Install(s, true); err != nil {
+		t.Fatalf("Install(force) error = %v", err)
+	}
+
+	// A shim replaced by something else is forgotten but not deleted.
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho mine\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Uninstall("codex"); !errors.Is(err, ErrNotShim) {
+		t.Fatalf("Uninstall() error = %v, want ErrNotShim", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error("foreign file was removed")
+	}
+}
+
+func TestStripPath(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	got := StripPath(strings.Join([]string{"/a/shims/", "/usr/bin", "/a/shims", "/bin"}, sep), []string{"/a/shims"})
+	if want := "/usr/bin" + sep + "/bin"; got != want {
+		t.Errorf("StripPath() = %q, want %q", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX executables")
+	}
+	t.Setenv(DirEnv, "")
+	dataDir := t.TempDir()
+	realDir := filepath.Join(dataDir, "real")
+	if err := os.MkdirAll(realDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	caam := filepath.Join(realDir, "caam")
+	if err := os.WriteFile(caam, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(dataDir)
+	s := Shim{Provider: "gemini", Path: filepath.Join(DefaultDir(dataDir), "gemini"), CaamPath: caam}
+	if err := m.Install(s, false); err != nil {
+		t.Fatal(err)
+	}
+	pathList := DefaultDir(dataDir) + string(os.PathListSeparator) + realDir
+
+	st := m.Check(s, pathList)
+	if !st.Present || !st.CaamFound || !st.OnPath || !st.Active {
+		t.Fatalf("Check() = %+v, want present, found, on PATH, and active", st)
+	}
+	if st.RealBinary != "" || st.Healthy() {
+		t.Errorf("Check() found real binary %q with none installed", st.RealBinary)
+	}
+
+	realBin := filepath.Join(realDir, "gemini")
+	if err := os.WriteFile(realBin, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if st := m.Check(s, pathList); st.RealBinary != realBin || !st.Healthy() {
+		t.Errorf("Check() = %+v, want healthy wrapping %s", st, realBin)
+	}
+
+	// With the real tool first on PATH the shim is shadowed.
+	reversed := realDir + string(os.PathListSeparator) + DefaultDir(dataDir)
+	if st := m.Check(s, reversed); st.Active || st.Resolved != realBin {
+		t.Errorf("Check(reversed) = %+v, want shadowed by %s", st, realBin)
+	}
+}
@@ -7,3 +7,13 @@ import "syscall"
 func SendHUP(pid int) error {
 	return syscall.Kill(pid, syscall.SIGHUP)
 }
+
+// SendPause asks the process to suspend automatic actions.
+func SendPause(pid int) error {
+	return syscall.Kill(pid, syscall.SIGUSR1)
+}
+
+// SendResume asks the process to resume automatic actions.
+func SendResume(pid int) error {
+	return syscall.Kill(pid, syscall.SIGUSR2)
+}
@@ -7,3 +7,11 @@ import "fmt"
 func SendHUP(pid int) error {
 	return fmt.Errorf("SIGHUP not supported on Windows (pid=%d)", pid)
 }
+
+func SendPause(pid int) error {
+	return fmt.Errorf("SIGUSR1 not supported on Windows (pid=%d)", pid)
+}
+
+func SendResume(pid int) error {
+	return fmt.Errorf("SIGUSR2 not supported on Windows (pid=%d)", pid)
+}
@@ -15,6 +15,24 @@ type Handler struct {
 	stop func()
 }
 
+// PauseSignal and ResumeSignal ask a long-running caam process (daemon,
+// coordinator) to suspend or resume automatic actions without exiting.
+// They are SIGUSR1 and SIGUSR2 on Unix and nil on Windows, where there is
+// no equivalent.
+var (
+	PauseSignal  os.Signal = pauseSignal
+	ResumeSignal os.Signal = resumeSignal
+)
+
+// PauseResumeSignals returns the pause/resume signals supported on this
+// platform, for passing to signal.Notify.
+func PauseResumeSignals() []os.Signal {
+	if PauseSignal == nil || ResumeSignal == nil {
+		return nil
+	}
+	return []os.Signal{PauseSignal, ResumeSignal}
+}
+
 // New starts a signal handler for the current process.
 func New() (*Handler, error) {
 	return newHandler()
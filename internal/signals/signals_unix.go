@@ -9,6 +9,11 @@ import (
 	"syscall"
 )
 
+const (
+	pauseSignal  = syscall.SIGUSR1
+	resumeSignal = syscall.SIGUSR2
+)
+
 func newHandler() (*Handler, error) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
@@ -4,6 +4,7 @@ package signals
 
 import (
 	"os"
+	"os/signal"
 	"syscall"
 	"testing"
 	"time"
@@ -94,3 +95,33 @@ func TestHandler_CloseWithNilStop(t *testing.T) {
 		t.Errorf("Close with nil stop should not error: %v", err)
 	}
 }
+
+func TestSendPauseResume(t *testing.T) {
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, PauseResumeSignals()...)
+	defer signal.Stop(sigCh)
+
+	if err := SendPause(os.Getpid()); err != nil {
+		t.Fatalf("SendPause: %v", err)
+	}
+	select {
+	case sig := <-sigCh:
+		if sig != PauseSignal {
+			t.Fatalf("got %v, want pause signal", sig)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for pause signal")
+	}
+
+	if err := SendResume(os.Getpid()); err != nil {
+		t.Fatalf("SendResume: %v", err)
+	}
+	select {
+	case sig := <-sigCh:
+		if sig != ResumeSignal {
+			t.Fatalf("got %v, want resume signal", sig)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for resume signal")
+	}
+}
@@ -8,6 +8,11 @@ import (
 	"sync"
 )
 
+var (
+	pauseSignal  os.Signal
+	resumeSignal os.Signal
+)
+
 func newHandler() (*Handler, error) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt)
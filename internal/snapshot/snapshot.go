@@ -0,0 +1,494 @@
+// Package snapshot keeps point-in-time copies of the whole vault so a
+// profile, or every profile, can be rolled back to an earlier state.
+//
+// A snapshot is a directory holding a copy of every provider/profile
+// directory under vault/, a bundle manifest with per-file checksums, and a
+// small snapshot.json with its ID and note:
+//
+//	snapshots/<id>/manifest.json
+//	snapshots/<id>/snapshot.json
+//	snapshots/<id>/vault/<provider>/<profile>/...
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/bundle"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultfs"
+)
+
+const (
+	// metaFileName holds the snapshot's ID, time, and note.
+	metaFileName = "snapshot.json"
+
+	// vaultDirName is the copy of the vault inside a snapshot.
+	vaultDirName = "vault"
+
+	// idFormat names snapshots by creation time (UTC), so they sort
+	// chronologically.
+	idFormat = "20060102-150405"
+
+	// Latest selects the newest snapshot.
+	Latest = "latest"
+)
+
+// Snapshot describes one saved copy of the vault.
+type Snapshot struct {
+	ID        string              `json:"id"`
+	CreatedAt time.Time           `json:"created_at"`
+	Note      string              `json:"note,omitempty"`
+	Profiles  map[string][]string `json:"profiles"`
+	Files     int                 `json:"files"`
+	Path      string              `json:"path"`
+}
+
+// ProfileCount returns the number of profiles in the snapshot.
+func (s *Snapshot) ProfileCount() int {
+	n := 0
+	for _, profiles := range s.Profiles {
+		n += len(profiles)
+	}
+	return n
+}
+
+// Has reports whether the snapshot holds provider/profile.
+func (s *Snapshot) Has(provider, profile string) bool {
+	for _, p := range s.Profiles[provider] {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// Store creates and restores snapshots of a vault.
+type Store struct {
+	dir   string
+	vault *authfile.Vault
+}
+
+// NewStore returns a store keeping snapshots of vault in dir.
+func NewStore(dir string, vault *authfile.Vault) *Store {
+	return &Store{dir: dir, vault: vault}
+}
+
+// DefaultDir returns the snapshot directory for a vault: a "snapshots"
+// directory next to it.
+func DefaultDir(vaultPath string) string {
+	return filepath.Join(filepath.Dir(vaultPath), "snapshots")
+}
+
+// Dir returns where the store keeps snapshots.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) lock() (*vaultfs.Lock, error) {
+	lock, err := vaultfs.Acquire(s.vault.BasePath(), s.vault.Filesystem().Locking(), vaultfs.DefaultLockTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("lock vault: %w", err)
+	}
+	return lock, nil
+}
+
+// Create snapshots every profile in the vault. When keep is positive, the
+// oldest snapshots beyond keep are removed afterwards.
+func (s *Store) Create(note string, keep int) (*Snapshot, error) {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("create snapshot dir: %w", err)
+	}
+
+	now := time.Now().UTC()
+	id := now.Format(idFormat)
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(s.dir, id)); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", now.Format(idFormat), n)
+	}
+
+	tmpDir, err := os.MkdirTemp(s.dir, "."+id+".tmp-")
+	if err != nil {
+		return nil, fmt.Errorf("create snapshot: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	lock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := vaultProfiles(s.vault.BasePath())
+	if err == nil {
+		for provider, names := range profiles {
+			for _, profile := range names {
+				src := filepath.Join(s.vault.BasePath(), provider, profile)
+				dst := filepath.Join(tmpDir, vaultDirName, provider, profile)
+				if err = copyTree(src, dst); err != nil {
+					err = fmt.Errorf("copy %s/%s: %w", provider, profile, err)
+					break
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+	lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	checksums, err := bundle.ComputeDirectoryChecksums(tmpDir, bundle.DefaultAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("checksum snapshot: %w", err)
+	}
+	manifest := bundle.NewManifest()
+	manifest.ExportTimestamp = now
+	manifest.Source = sourceInfo(s.vault.BasePath())
+	for provider, names := range profiles {
+		for _, profile := range names {
+			manifest.AddProfile(provider, profile)
+		}
+	}
+	for path, sum := range checksums {
+		manifest.AddChecksum(path, sum)
+	}
+	if err := writeJSON(filepath.Join(tmpDir, bundle.ManifestFileName), manifest); err != nil {
+		return nil, err
+	}
+
+	snap := &Snapshot{
+		ID:        id,
+		CreatedAt: now,
+		Note:      strings.TrimSpace(note),
+		Profiles:  profiles,
+		Files:     len(checksums),
+	}
+	if err := writeJSON(filepath.Join(tmpDir, metaFileName), snap); err != nil {
+		return nil, err
+	}
+
+	final := filepath.Join(s.dir, id)
+	if err := os.Rename(tmpDir, final); err != nil {
+		return nil, fmt.Errorf("save snapshot: %w", err)
+	}
+	snap.Path = final
+
+	if keep > 0 {
+		if _, err := s.Prune(keep); err != nil {
+			return snap, fmt.Errorf("prune snapshots: %w", err)
+		}
+	}
+	return snap, nil
+}
+
+// List returns the snapshots, newest first.
+func (s *Store) List() ([]*Snapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot dir: %w", err)
+	}
+
+	var snaps []*Snapshot
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		snap, err := s.load(e.Name())
+		if err != nil {
+			continue
+		}
+		snaps = append(snaps, snap)
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		if !snaps[i].CreatedAt.Equal(snaps[j].CreatedAt) {
+			return snaps[i].CreatedAt.After(snaps[j].CreatedAt)
+		}
+		return snaps[i].ID > snaps[j].ID
+	})
+	return snaps, nil
+}
+
+// Get returns the snapshot with id, or the newest one for Latest.
+func (s *Store) Get(id string) (*Snapshot, error) {
+	id = strings.TrimSpace(id)
+	if id == Latest {
+		snaps, err := s.List()
+		if err != nil {
+			return nil, err
+		}
+		if len(snaps) == 0 {
+			return nil, fmt.Errorf("no snapshots in %s", s.dir)
+		}
+		return snaps[0], nil
+	}
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return nil, fmt.Errorf("invalid snapshot ID %q", id)
+	}
+	snap, err := s.load(id)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot %s not found", id)
+	}
+	return snap, err
+}
+
+func (s *Store) load(id string) (*Snapshot, error) {
+	dir := filepath.Join(s.dir, id)
+	data, err := os.ReadFile(filepath.Join(dir, metaFileName))
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("parse snapshot %s: %w", id, err)
+	}
+	snap.ID = id
+	snap.Path = dir
+	return &snap, nil
+}
+
+// Verify checks the snapshot's files against its manifest checksums.
+func (s *Store) Verify(snap *Snapshot) (*bundle.VerificationResult, error) {
+	data, err := os.ReadFile(filepath.Join(snap.Path, bundle.ManifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var manifest bundle.ManifestV1
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	result, err := bundle.VerifyChecksums(snap.Path, &manifest)
+	if err != nil {
+		return nil, err
+	}
+	// snapshot.json is written after the checksums.
+	extra := result.Extra[:0]
+	for _, path := range result.Extra {
+		if path != metaFileName {
+			extra = append(extra, path)
+		}
+	}
+	result.Extra = extra
+	return result, nil
+}
+
+// RestoreResult lists what a restore changed.
+type RestoreResult struct {
+	Restored []string `json:"restored"`
+	Removed  []string `json:"removed,omitempty"`
+}
+
+// Restore copies provider/profile back from the snapshot, replacing the
+// profile in the vault. With an empty provider the whole vault is
+// restored: every profile in the snapshot is replaced and profiles created
+// since are removed. The snapshot is verified first and nothing is changed
+// if any file fails its checksum.
+//
+// Restore only touches the vault; restored profiles that are active must
+// be activated again to update the live auth files.
+func (s *Store) Restore(snap *Snapshot, provider, profile string) (*RestoreResult, error) {
+	if provider != "" && !snap.Has(provider, profile) {
+		return nil, fmt.Errorf("snapshot %s does not contain %s/%s", snap.ID, provider, profile)
+	}
+
+	verify, err := s.Verify(snap)
+	if err != nil {
+		return nil, err
+	}
+	if !verify.Valid {
+		return nil, fmt.Errorf("snapshot %s failed verification: %d changed, %d missing file(s)",
+			snap.ID, len(verify.Mismatch), len(verify.Missing))
+	}
+
+	lock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	result := &RestoreResult{}
+	restore := func(provider, profile string) error {
+		src := filepath.Join(snap.Path, vaultDirName, provider, profile)
+		dst := filepath.Join(s.vault.BasePath(), provider, profile)
+		if err := replaceTree(src, dst); err != nil {
+			return fmt.Errorf("restore %s/%s: %w", provider, profile, err)
+		}
+		result.Restored = append(result.Restored, provider+"/"+profile)
+		return nil
+	}
+
+	if provider != "" {
+		if err := restore(provider, profile); err != nil {
+			return result, err
+		}
+		return result, nil
+	}
+
+	current, err := vaultProfiles(s.vault.BasePath())
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range sortedKeys(current) {
+		for _, name := range current[p] {
+			if snap.Has(p, name) {
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(s.vault.BasePath(), p, name)); err != nil {
+				return result, fmt.Errorf("remove %s/%s: %w", p, name, err)
+			}
+			result.Removed = append(result.Removed, p+"/"+name)
+		}
+	}
+	for _, p := range sortedKeys(snap.Profiles) {
+		for _, name := range snap.Profiles[p] {
+			if err := restore(p, name); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// Prune removes the oldest snapshots beyond keep and returns their IDs.
+func (s *Store) Prune(keep int) ([]string, error) {
+	snaps, err := s.List()
+	if err != nil || keep <= 0 || len(snaps) <= keep {
+		return nil, err
+	}
+	var removed []string
+	for _, snap := range snaps[keep:] {
+		if err := os.RemoveAll(snap.Path); err != nil {
+			return removed, fmt.Errorf("remove snapshot %s: %w", snap.ID, err)
+		}
+		removed = append(removed, snap.ID)
+	}
+	return removed, nil
+}
+
+// vaultProfiles lists the provider/profile directories in the vault,
+// skipping hidden entries such as the lock and key files.
+func vaultProfiles(vaultPath string) (map[string][]string, error) {
+	profiles := make(map[string][]string)
+	providers, err := os.ReadDir(vaultPath)
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read vault: %w", err)
+	}
+	for _, p := range providers {
+		if !p.IsDir() || strings.HasPrefix(p.Name(), ".") {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(vaultPath, p.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read vault: %w", err)
+		}
+		for _, e := range entries {
+			if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+				profiles[p.Name()] = append(profiles[p.Name()], e.Name())
+			}
+		}
+	}
+	return profiles, nil
+}
+
+// replaceTree swaps dst for a copy of src. The copy is made next to dst
+// first so a failed copy leaves dst untouched.
+func replaceTree(src, dst string) error {
+	staging := dst + ".restore-tmp"
+	os.RemoveAll(staging)
+	if err := copyTree(src, staging); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	old := dst + ".restore-old"
+	os.RemoveAll(old)
+	if err := os.Rename(dst, old); err != nil && !os.IsNotExist(err) {
+		os.RemoveAll(staging)
+		return err
+	}
+	if err := os.Rename(staging, dst); err != nil {
+		os.Rename(old, dst)
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// copyTree copies the files under src to dst with owner-only permissions.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0700)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func sourceInfo(vaultPath string) bundle.SourceInfo {
+	hostname, _ := os.Hostname()
+	return bundle.SourceInfo{
+		Hostname:     hostname,
+		Platform:     runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		Username:     os.Getenv("USER"),
+		CAAMDataPath: filepath.Dir(vaultPath),
+	}
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
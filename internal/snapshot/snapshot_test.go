@@ -0,0 +1,177 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+func writeProfile(t *testing.T, vaultPath, provider, profile, content string) {
+	t.Helper()
+	dir := filepath.Join(vaultPath, provider, profile)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "auth.json"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readProfile(t *testing.T, vaultPath, provider, profile string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(vaultPath, provider, profile, "auth.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func newTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	root := t.TempDir()
+	vaultPath := filepath.Join(root, "vault")
+	return NewStore(DefaultDir(vaultPath), authfile.NewVault(vaultPath)), vaultPath
+}
+
+func TestCreateAndList(t *testing.T) {
+	store, vaultPath := newTestStore(t)
+	writeProfile(t, vaultPath, "claude", "work", `{"v":1}`)
+	writeProfile(t, vaultPath, "codex", "home", `{"v":1}`)
+
+	snap, err := store.Create("first", 0)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if snap.ProfileCount() != 2 || snap.Files != 2 || snap.Note != "first" {
+		t.Errorf("snapshot = %+v, want 2 profiles, 2 files, note", snap)
+	}
+	if !snap.Has("claude", "work") || snap.Has("claude", "home") {
+		t.Errorf("Has reports wrong profiles: %+v", snap.Profiles)
+	}
+
+	second, err := store.Create("", 0)
+	if err != nil {
+		t.Fatalf("Create second: %v", err)
+	}
+	if second.ID == snap.ID {
+		t.Fatalf("snapshots share ID %s", snap.ID)
+	}
+
+	snaps, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(snaps) != 2 || snaps[0].ID != second.ID {
+		t.Fatalf("List = %v, want newest (%s) first", snaps, second.ID)
+	}
+	latest, err := store.Get(Latest)
+	if err != nil || latest.ID != second.ID {
+		t.Errorf("Get(latest) = %v, %v; want %s", latest, err, second.ID)
+	}
+}
+
+func TestRestoreProfile(t *testing.T) {
+	store, vaultPath := newTestStore(t)
+	writeProfile(t, vaultPath, "claude", "work", `{"v":1}`)
+	writeProfile(t, vaultPath, "claude", "home", `{"v":1}`)
+
+	snap, err := store.Create("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeProfile(t, vaultPath, "claude", "work", `{"v":2}`)
+	writeProfile(t, vaultPath, "claude", "home", `{"v":2}`)
+
+	result, err := store.Restore(snap, "claude", "work")
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(result.Restored) != 1 || result.Restored[0] != "claude/work" {
+		t.Errorf("Restored = %v", result.Restored)
+	}
+	if got := readProfile(t, vaultPath, "claude", "work"); got != `{"v":1}` {
+		t.Errorf("work = %s, want restored v1", got)
+	}
+	if got := readProfile(t, vaultPath, "claude", "home"); got != `{"v":2}` {
+		t.Errorf("home = %s, want untouched v2", got)
+	}
+
+	if _, err := store.Restore(snap, "codex", "missing"); err == nil {
+		t.Error("Restore of a profile not in the snapshot succeeded")
+	}
+}
+
+func TestRestoreVault(t *testing.T) {
+	store, vaultPath := newTestStore(t)
+	writeProfile(t, vaultPath, "claude", "work", `{"v":1}`)
+
+	snap, err := store.Create("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeProfile(t, vaultPath, "claude", "work", `{"v":2}`)
+	writeProfile(t, vaultPath, "gemini", "new", `{"v":2}`)
+
+	result, err := store.Restore(snap, "", "")
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(result.Removed) != 1 || result.Removed[0] != "gemini/new" {
+		t.Errorf("Removed = %v, want [gemini/new]", result.Removed)
+	}
+	if got := readProfile(t, vaultPath, "claude", "work"); got != `{"v":1}` {
+		t.Errorf("work = %s, want restored v1", got)
+	}
+	if _, err := os.Stat(filepath.Join(vaultPath, "gemini", "new")); !os.IsNotExist(err) {
+		t.Errorf("gemini/new still exists: %v", err)
+	}
+}
+
+func TestRestoreRejectsTamperedSnapshot(t *testing.T) {
+	store, vaultPath := newTestStore(t)
+	writeProfile(t, vaultPath, "claude", "work", `{"v":1}`)
+
+	snap, err := store.Create("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := filepath.Join(snap.Path, vaultDirName, "claude", "work", "auth.json")
+	if err := os.WriteFile(tampered, []byte(`{"v":"evil"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	writeProfile(t, vaultPath, "claude", "work", `{"v":2}`)
+
+	if _, err := store.Restore(snap, "claude", "work"); err == nil {
+		t.Fatal("Restore accepted a snapshot that fails verification")
+	}
+	if got := readProfile(t, vaultPath, "claude", "work"); got != `{"v":2}` {
+		t.Errorf("work = %s, want unchanged v2", got)
+	}
+}
+
+func TestCreatePrunesOldest(t *testing.T) {
+	store, vaultPath := newTestStore(t)
+	writeProfile(t, vaultPath, "claude", "work", `{"v":1}`)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		snap, err := store.Create("", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, snap.ID)
+	}
+
+	snaps, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 {
+		t.Fatalf("kept %d snapshots, want 2", len(snaps))
+	}
+	if _, err := store.Get(ids[0]); err == nil {
+		t.Errorf("oldest snapshot %s was not pruned", ids[0])
+	}
+}
@@ -0,0 +1,366 @@
+// Package statusengine loads the state robot commands report on once per
+// invocation: the vault's profiles, stored health, config, every active
+// cooldown, revocation, lease and tag, and the profiles whose history looks
+// flagged. Commands then look profiles up in
+// memory instead of reopening the database and rereading files for each
+// one, which dominates latency on vaults with many profiles.
+package statusengine
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/anomaly"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/tracing"
+)
+
+// Key identifies a profile.
+type Key struct {
+	Provider string
+	Profile  string
+}
+
+// Sources are where a snapshot is loaded from. Any of them may be nil; the
+// state it would provide is then empty.
+type Sources struct {
+	Vault  *authfile.Vault
+	DB     *caamdb.DB
+	Health *health.Storage
+	// SPM is used as is when set; otherwise config.yaml is loaded.
+	SPM *config.SPMConfig
+	// UnprobedSince, when set, also loads cooldowns that ended after it but
+	// have not been through the daemon's end-of-cooldown probe yet.
+	UnprobedSince time.Time
+}
+
+// Snapshot is the state of the given providers' profiles at one instant.
+type Snapshot struct {
+	// Now is when the snapshot was taken. Cooldowns and leases are those
+	// active at Now.
+	Now time.Time
+	// SPM is the loaded config.yaml, or the defaults if it could not be
+	// loaded.
+	SPM *config.SPMConfig
+
+	profiles    map[string][]string
+	health      map[string]*health.ProfileHealth
+	cooldowns   map[Key]caamdb.CooldownEvent
+	unprobed    map[Key]caamdb.CooldownEvent
+	revocations map[Key]caamdb.Revocation
+	leases      map[Key]caamdb.Lease
+	tags        map[Key]map[string]string
+	suspects    map[Key][]anomaly.Evidence
+}
+
+// Load takes a snapshot of providers. Errors from individual sources leave
+// their part of the snapshot empty, the same as the per-profile lookups
+// commands made before. Each source read is traced as a child of ctx.
+func Load(ctx context.Context, src Sources, providers []string, now time.Time) *Snapshot {
+	ctx, span := tracing.Start(ctx, "statusengine.load")
+	defer span.End()
+
+	s := &Snapshot{
+		Now:         now,
+		SPM:         src.SPM,
+		profiles:    make(map[string][]string, len(providers)),
+		cooldowns:   make(map[Key]caamdb.CooldownEvent),
+		unprobed:    make(map[Key]caamdb.CooldownEvent),
+		revocations: make(map[Key]caamdb.Revocation),
+		leases:      make(map[Key]caamdb.Lease),
+		tags:        make(map[Key]map[string]string),
+		suspects:    make(map[Key][]anomaly.Evidence),
+	}
+	if s.SPM == nil {
+		cfg, err := config.LoadSPMConfig()
+		if err != nil || cfg == nil {
+			cfg = config.DefaultSPMConfig()
+		}
+		s.SPM = cfg
+	}
+
+	if src.Vault != nil {
+		for _, p := range providers {
+			traced(ctx, "vault.list", func() error {
+				names, err := src.Vault.List(p)
+				if err == nil {
+					s.profiles[p] = names
+				}
+				return err
+			}, tracing.Provider(p))
+		}
+	}
+
+	if src.Health != nil {
+		traced(ctx, "health.load", func() error {
+			store, err := src.Health.Load()
+			if err == nil && store != nil {
+				s.health = store.Profiles
+			}
+			return err
+		})
+	}
+
+	if src.DB != nil {
+		traced(ctx, "db.list_active_cooldowns", func() error {
+			evs, err := src.DB.ListActiveCooldowns(now)
+			if err != nil {
+				return err
+			}
+			for _, ev := range evs {
+				s.cooldowns[Key{ev.Provider, ev.ProfileName}] = ev
+			}
+			return nil
+		})
+		if !src.UnprobedSince.IsZero() {
+			traced(ctx, "db.expired_unprobed_cooldowns", func() error {
+				evs, err := src.DB.ExpiredUnprobedCooldowns(src.UnprobedSince, now)
+				if err != nil {
+					return err
+				}
+				for _, ev := range evs {
+					s.unprobed[Key{ev.Provider, ev.ProfileName}] = ev
+				}
+				return nil
+			})
+		}
+		traced(ctx, "db.list_active_revocations", func() error {
+			revs, err := src.DB.ListActiveRevocations()
+			if err != nil {
+				return err
+			}
+			for _, r := range revs {
+				k := Key{r.Provider, r.ProfileName}
+				// The newest open revocation wins, as in ActiveRevocation.
+				if cur, ok := s.revocations[k]; !ok || r.ID > cur.ID {
+					s.revocations[k] = r
+				}
+			}
+			return nil
+		})
+		traced(ctx, "db.active_leases", func() error {
+			leases, err := src.DB.ActiveLeases("", now)
+			if err != nil {
+				return err
+			}
+			for _, l := range leases {
+				k := Key{l.Provider, l.ProfileName}
+				if _, ok := s.leases[k]; !ok {
+					s.leases[k] = l
+				}
+			}
+			return nil
+		})
+		traced(ctx, "db.list_profile_tags", func() error {
+			tags, err := src.DB.ListProfileTags("")
+			if err != nil {
+				return err
+			}
+			for _, t := range tags {
+				k := Key{t.Provider, t.ProfileName}
+				if s.tags[k] == nil {
+					s.tags[k] = make(map[string]string)
+				}
+				s.tags[k][t.Key] = t.Value
+			}
+			return nil
+		})
+		traced(ctx, "anomaly.analyze", func() error {
+			return s.loadSuspects(src.DB)
+		})
+	}
+	return s
+}
+
+// loadSuspects runs the anomaly analyzer over every profile with recent
+// probes, rate limits or revocations.
+func (s *Snapshot) loadSuspects(db *caamdb.DB) error {
+	since := s.Now.Add(-anomaly.Lookback)
+	probes, err := db.ProbesSince(since)
+	if err != nil {
+		return err
+	}
+	cooldowns, err := db.CooldownsSince(since)
+	if err != nil {
+		return err
+	}
+	revs, err := db.RevocationsSince(since)
+	if err != nil {
+		return err
+	}
+
+	histories := make(map[Key]*anomaly.History)
+	history := func(k Key) *anomaly.History {
+		if histories[k] == nil {
+			histories[k] = &anomaly.History{}
+		}
+		return histories[k]
+	}
+	for _, p := range probes {
+		h := history(Key{p.Provider, p.ProfileName})
+		h.Probes = append(h.Probes, p)
+	}
+	for _, c := range cooldowns {
+		h := history(Key{c.Provider, c.ProfileName})
+		h.Cooldowns = append(h.Cooldowns, c)
+	}
+	for _, r := range revs {
+		h := history(Key{r.Provider, r.ProfileName})
+		h.Revocations = append(h.Revocations, r)
+	}
+	for k, h := range histories {
+		if evidence := anomaly.Analyze(*h, s.Now, anomaly.DefaultConfig()); len(evidence) > 0 {
+			s.suspects[k] = evidence
+		}
+	}
+	return nil
+}
+
+// traced runs fn in a span named name.
+func traced(ctx context.Context, name string, fn func() error, attrs ...attribute.KeyValue) {
+	_, span := tracing.Start(ctx, name, attrs...)
+	tracing.End(span, fn())
+}
+
+// Profiles returns a provider's vault profiles, sorted by name.
+func (s *Snapshot) Profiles(provider string) []string {
+	return s.profiles[provider]
+}
+
+// Health returns a copy of a profile's stored health, or an empty one.
+func (s *Snapshot) Health(provider, profile string) *health.ProfileHealth {
+	if h := s.health[provider+"/"+profile]; h != nil {
+		c := *h
+		return &c
+	}
+	return &health.ProfileHealth{}
+}
+
+// Cooldown returns a profile's active cooldown, or nil.
+func (s *Snapshot) Cooldown(provider, profile string) *caamdb.CooldownEvent {
+	if ev, ok := s.cooldowns[Key{provider, profile}]; ok {
+		return &ev
+	}
+	return nil
+}
+
+// AwaitingProbe returns a profile's cooldown that has ended but not been
+// probed yet, or nil. Only loaded when Sources.UnprobedSince is set.
+func (s *Snapshot) AwaitingProbe(provider, profile string) *caamdb.CooldownEvent {
+	if ev, ok := s.unprobed[Key{provider, profile}]; ok {
+		return &ev
+	}
+	return nil
+}
+
+// Revocation returns a profile's open revocation, or nil.
+func (s *Snapshot) Revocation(provider, profile string) *caamdb.Revocation {
+	if r, ok := s.revocations[Key{provider, profile}]; ok {
+		return &r
+	}
+	return nil
+}
+
+// Lease returns the local lease on a profile, or nil.
+func (s *Snapshot) Lease(provider, profile string) *caamdb.Lease {
+	if l, ok := s.leases[Key{provider, profile}]; ok {
+		return &l
+	}
+	return nil
+}
+
+// Tags returns a profile's tags, or nil. The map must not be modified.
+func (s *Snapshot) Tags(provider, profile string) map[string]string {
+	return s.tags[Key{provider, profile}]
+}
+
+// Suspect returns the evidence that a profile's account is flagged, or nil
+// if its history looks normal.
+func (s *Snapshot) Suspect(provider, profile string) []anomaly.Evidence {
+	return s.suspects[Key{provider, profile}]
+}
+
+// Rank sorts items best first by score, keeping equal scores in their
+// original order.
+func Rank[T any](items []T, score func(T) float64) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return score(items[i]) > score(items[j])
+	})
+}
+
+// ForEach calls fn for each index below n, on up to workers goroutines at a
+// time, and waits for the calls to return. Once ctx is done no more calls
+// start and ForEach returns ctx.Err(); calls already running are left to
+// finish, so fn should itself give up when ctx is done. fn must be safe to
+// call concurrently.
+func ForEach(ctx context.Context, n, workers int, fn func(ctx context.Context, i int)) error {
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case sem <- struct{}{}:
+		}
+		// Both cases can be ready at once; don't start work past the deadline.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(ctx, i)
+		}(i)
+	}
+	return nil
+}
+
+// Cache keeps a snapshot for reuse by later invocations in the same
+// process, such as requests to caam serve. A zero TTL disables it, so every
+// Get loads afresh.
+type Cache struct {
+	TTL time.Duration
+
+	mu       sync.Mutex
+	snap     *Snapshot
+	key      string
+	loadedAt time.Time
+}
+
+// Get returns the cached snapshot for key if it is younger than the TTL,
+// and otherwise loads a new one with load and caches it.
+func (c *Cache) Get(key string, now time.Time, load func() *Snapshot) *Snapshot {
+	if c == nil || c.TTL <= 0 {
+		return load()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.snap != nil && c.key == key && now.Sub(c.loadedAt) < c.TTL {
+		return c.snap
+	}
+	c.snap, c.key, c.loadedAt = load(), key, now
+	return c.snap
+}
+
+// Invalidate drops the cached snapshot, for after a change such as an
+// activation or cooldown.
+func (c *Cache) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.snap = nil
+	c.mu.Unlock()
+}
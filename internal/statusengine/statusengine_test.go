@@ -0,0 +1,279 @@
+package statusengine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/anomaly"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+)
+
+func TestLoad(t *testing.T) {
+	vault := authfile.NewVault(t.TempDir())
+	for _, name := range []string{"b", "a"} {
+		if err := os.MkdirAll(vault.ProfilePath("codex", name), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	now := time.Now()
+	if _, err := db.SetCooldown("codex", "a", now.Add(-time.Minute), time.Hour, "limit"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.SetCooldown("codex", "b", now.Add(-2*time.Hour), time.Hour, "expired"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.MarkRevoked("codex", "b", now, "invalid_grant", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AcquireLease("codex", "a", "alice", time.Hour, "", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetProfileTags("codex", "a", map[string]string{"team": "infra"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ago := range []time.Duration{time.Hour, 2 * time.Hour} {
+		if _, err := db.RecordProbe(caamdb.Probe{Provider: "codex", ProfileName: "a", ProbedAt: now.Add(-ago), HTTPStatus: 403}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := health.NewStorage(filepath.Join(t.TempDir(), "health.json"))
+	if err := store.UpdateProfile("codex", "a", &health.ProfileHealth{ErrorCount1h: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	spm := config.DefaultSPMConfig()
+	s := Load(context.Background(), Sources{Vault: vault, DB: db, Health: store, SPM: spm}, []string{"codex"}, now)
+
+	if got := s.Profiles("codex"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Profiles = %v, want [a b]", got)
+	}
+	if s.SPM != spm {
+		t.Error("SPM was reloaded instead of used as given")
+	}
+	if h := s.Health("codex", "a"); h.ErrorCount1h != 3 {
+		t.Errorf("Health(a).ErrorCount1h = %d, want 3", h.ErrorCount1h)
+	}
+	if h := s.Health("codex", "b"); h == nil || h.ErrorCount1h != 0 {
+		t.Errorf("Health(b) = %+v, want empty", h)
+	}
+	if c := s.Cooldown("codex", "a"); c == nil || c.Notes != "limit" {
+		t.Errorf("Cooldown(a) = %+v, want the active cooldown", c)
+	}
+	if c := s.Cooldown("codex", "b"); c != nil {
+		t.Errorf("Cooldown(b) = %+v, want nil for an expired cooldown", c)
+	}
+	if r := s.Revocation("codex", "b"); r == nil || r.Reason != "invalid_grant" {
+		t.Errorf("Revocation(b) = %+v", r)
+	}
+	if l := s.Lease("codex", "a"); l == nil || l.Holder != "alice" {
+		t.Errorf("Lease(a) = %+v", l)
+	}
+	if tags := s.Tags("codex", "a"); tags["team"] != "infra" {
+		t.Errorf("Tags(a) = %v", tags)
+	}
+	// Two 403s in a day against one rate limit.
+	if ev := s.Suspect("codex", "a"); len(ev) != 1 || ev[0].Kind != anomaly.ForbiddenSpike {
+		t.Errorf("Suspect(a) = %+v, want a forbidden spike", ev)
+	}
+	if s.Lease("codex", "b") != nil || s.Tags("codex", "b") != nil || s.Revocation("codex", "a") != nil || s.Suspect("codex", "b") != nil {
+		t.Error("state leaked between profiles")
+	}
+}
+
+func TestLoadAwaitingProbe(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	now := time.Now()
+	ended, err := db.SetCooldown("codex", "a", now.Add(-2*time.Hour), time.Hour, "limit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := Sources{DB: db, SPM: config.DefaultSPMConfig()}
+	if ev := Load(context.Background(), src, []string{"codex"}, now).AwaitingProbe("codex", "a"); ev != nil {
+		t.Errorf("AwaitingProbe without UnprobedSince = %+v, want nil", ev)
+	}
+
+	src.UnprobedSince = now.Add(-24 * time.Hour)
+	s := Load(context.Background(), src, []string{"codex"}, now)
+	if ev := s.AwaitingProbe("codex", "a"); ev == nil || ev.ID != ended.ID {
+		t.Errorf("AwaitingProbe = %+v, want the ended cooldown", ev)
+	}
+	if s.Cooldown("codex", "a") != nil {
+		t.Error("an ended cooldown is reported as active")
+	}
+
+	if err := db.MarkCooldownProbed(ended.ID, "passed", now); err != nil {
+		t.Fatal(err)
+	}
+	if ev := Load(context.Background(), src, []string{"codex"}, now).AwaitingProbe("codex", "a"); ev != nil {
+		t.Errorf("AwaitingProbe after the probe passed = %+v, want nil", ev)
+	}
+}
+
+func TestLoadWithoutSources(t *testing.T) {
+	s := Load(context.Background(), Sources{SPM: config.DefaultSPMConfig()}, []string{"codex"}, time.Now())
+	if s.Profiles("codex") != nil || s.Cooldown("codex", "a") != nil || s.Health("codex", "a") == nil {
+		t.Errorf("snapshot without sources = %+v, want empty", s)
+	}
+}
+
+func TestLoadTraces(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	src := Sources{Vault: authfile.NewVault(t.TempDir()), DB: db, SPM: config.DefaultSPMConfig()}
+	Load(context.Background(), src, []string{"claude", "codex"}, time.Now())
+
+	counts := make(map[string]int)
+	var root trace.SpanContext
+	for _, span := range exporter.GetSpans() {
+		counts[span.Name]++
+		if span.Name == "statusengine.load" {
+			root = span.SpanContext
+		}
+	}
+	for _, span := range exporter.GetSpans() {
+		if span.Name != "statusengine.load" && span.Parent.SpanID() != root.SpanID() {
+			t.Errorf("span %s is not a child of statusengine.load", span.Name)
+		}
+	}
+	want := map[string]int{"statusengine.load": 1, "vault.list": 2, "db.list_active_cooldowns": 1, "db.list_active_revocations": 1, "db.active_leases": 1, "db.list_profile_tags": 1, "anomaly.analyze": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("spans = %v, want %v", counts, want)
+	}
+}
+
+func TestRank(t *testing.T) {
+	type item struct {
+		name  string
+		score float64
+	}
+	items := []item{{"a", 1}, {"b", 3}, {"c", 1}, {"d", 2}}
+	Rank(items, func(it item) float64 { return it.score })
+	var got string
+	for _, it := range items {
+		got += it.name
+	}
+	if got != "bdac" {
+		t.Errorf("Rank order = %s, want bdac", got)
+	}
+}
+
+func TestCache(t *testing.T) {
+	loads := 0
+	load := func() *Snapshot {
+		loads++
+		return &Snapshot{}
+	}
+	now := time.Now()
+
+	var off Cache
+	off.Get("k", now, load)
+	off.Get("k", now, load)
+	if loads != 2 {
+		t.Fatalf("loads with zero TTL = %d, want 2", loads)
+	}
+
+	c := Cache{TTL: time.Second}
+	loads = 0
+	first := c.Get("k", now, load)
+	if c.Get("k", now.Add(500*time.Millisecond), load) != first || loads != 1 {
+		t.Errorf("Get within TTL reloaded (loads = %d)", loads)
+	}
+	c.Get("other", now, load)
+	if loads != 2 {
+		t.Errorf("Get with a new key did not reload (loads = %d)", loads)
+	}
+	c.Get("other", now.Add(2*time.Second), load)
+	if loads != 3 {
+		t.Errorf("Get after TTL did not reload (loads = %d)", loads)
+	}
+	c.Invalidate()
+	c.Get("other", now.Add(2*time.Second), load)
+	if loads != 4 {
+		t.Errorf("Get after Invalidate did not reload (loads = %d)", loads)
+	}
+}
+
+func TestForEach(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	seen := make([]bool, 20)
+	err := ForEach(context.Background(), len(seen), 3, func(_ context.Context, i int) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		seen[i] = true
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatalf("ForEach error = %v", err)
+	}
+	if peak > 3 {
+		t.Errorf("peak concurrency = %d, want at most 3", peak)
+	}
+	for i, ok := range seen {
+		if !ok {
+			t.Errorf("index %d not visited", i)
+		}
+	}
+}
+
+func TestForEachStopsAtDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var mu sync.Mutex
+	calls := 0
+	err := ForEach(ctx, 100, 1, func(ctx context.Context, _ int) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		<-ctx.Done()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ForEach error = %v, want DeadlineExceeded", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 before the deadline", calls)
+	}
+}
@@ -8,7 +8,12 @@ import (
 	"path/filepath"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/tracing"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // SyncDirection indicates the direction of a sync operation.
@@ -21,6 +26,9 @@ const (
 	SyncPull SyncDirection = "pull"
 	// SyncSkip indicates no sync is needed (already in sync).
 	SyncSkip SyncDirection = "skip"
+	// SyncConflict indicates both sides changed since the last sync; the
+	// profile is left alone until the conflict is resolved.
+	SyncConflict SyncDirection = "conflict"
 )
 
 // SyncOperation represents a planned sync operation.
@@ -78,6 +86,10 @@ type Syncer struct {
 
 	// remoteVaultPath is the remote vault directory path pattern.
 	remoteVaultPath string
+	onProfile       func(m *Machine, p ProfileRef, done, total int, result *SyncResult)
+
+	// keyring holds the pool encryption keys.
+	keyring *PoolKeyring
 }
 
 // SyncerConfig configures a Syncer instance.
@@ -91,6 +103,10 @@ type SyncerConfig struct {
 
 	// ConnectOptions configures SSH connections.
 	ConnectOptions ConnectOptions
+
+	// OnProfile, if set, is called after each profile is compared with a
+	// machine. result is nil when the profile was already in sync.
+	OnProfile func(m *Machine, p ProfileRef, done, total int, result *SyncResult)
 }
 
 // DefaultSyncerConfig returns a default configuration.
@@ -116,11 +132,21 @@ func NewSyncer(config SyncerConfig) (*Syncer, error) {
 		config.RemoteVaultPath = DefaultSyncerConfig().RemoteVaultPath
 	}
 
+	keyring, err := LoadPoolKeyring()
+	if err != nil {
+		return nil, fmt.Errorf("load pool keys: %w", err)
+	}
+	// Peers wrap pool keys for this machine's exchange key, so make sure
+	// it exists before anyone asks for it.
+	_, _ = LoadOrCreateExchangeKey()
+
 	return &Syncer{
 		pool:            NewConnectionPool(config.ConnectOptions),
 		state:           state,
 		vaultPath:       config.VaultPath,
 		remoteVaultPath: config.RemoteVaultPath,
+		keyring:         keyring,
+		onProfile:       config.OnProfile,
 	}, nil
 }
 
@@ -131,16 +157,35 @@ func (s *Syncer) Close() error {
 }
 
 // SyncWithMachine synchronizes all profiles with a single machine.
-func (s *Syncer) SyncWithMachine(ctx context.Context, m *Machine) ([]*SyncResult, error) {
-	results := []*SyncResult{}
+func (s *Syncer) SyncWithMachine(ctx context.Context, m *Machine) (results []*SyncResult, err error) {
+	ctx, span := tracing.Start(ctx, "sync.machine", tracing.Machine(m.Name))
+	defer func() { tracing.End(span, err) }()
+	results = []*SyncResult{}
+
+	if s.refuseWipedMachine(m) {
+		return nil, ErrWipePending
+	}
 
 	// 1. Connect to remote
-	client, err := s.pool.Get(m)
+	client, err := s.connect(ctx, m)
 	if err != nil {
 		m.SetError(err.Error())
+		events.Publish(events.Event{
+			Type:   events.SyncCompleted,
+			Source: "sync",
+			Data: map[string]any{
+				"machine": m.Name,
+				"error":   err.Error(),
+			},
+		})
 		return nil, fmt.Errorf("connection failed: %w", err)
 	}
 
+	s.learnIdentity(client)
+	s.shareWipeOrders(m)
+	s.sharePoolKey(client)
+	_ = s.shareLeases(client)
+
 	// 2. Get local profiles
 	localProfiles, err := s.listLocalProfiles()
 	if err != nil {
@@ -157,7 +202,7 @@ func (s *Syncer) SyncWithMachine(ctx context.Context, m *Machine) ([]*SyncResult
 	allProfiles := mergeProfileLists(localProfiles, remoteProfiles)
 
 	// 5. For each profile, compare and sync
-	for _, p := range allProfiles {
+	for i, p := range allProfiles {
 		select {
 		case <-ctx.Done():
 			return results, ctx.Err()
@@ -167,7 +212,7 @@ func (s *Syncer) SyncWithMachine(ctx context.Context, m *Machine) ([]*SyncResult
 		op, err := s.determineSyncOperation(client, m, p)
 		if err != nil {
 			// Log error but continue with other profiles
-			results = append(results, &SyncResult{
+			result := &SyncResult{
 				Operation: &SyncOperation{
 					Provider:  p.Provider,
 					Profile:   p.Profile,
@@ -176,16 +221,23 @@ func (s *Syncer) SyncWithMachine(ctx context.Context, m *Machine) ([]*SyncResult
 				},
 				Success: false,
 				Error:   err,
-			})
+			}
+			results = append(results, result)
+			s.reportProfile(m, p, i+1, len(allProfiles), result)
 			continue
 		}
 
 		if op == nil || op.Direction == SyncSkip {
+			if op != nil {
+				s.recordSynced(op)
+			}
+			s.reportProfile(m, p, i+1, len(allProfiles), nil)
 			continue // Already in sync
 		}
 
-		result := s.executeOperation(client, op)
+		result := s.executeOperation(ctx, client, op)
 		results = append(results, result)
+		s.reportProfile(m, p, i+1, len(allProfiles), result)
 
 		// Record in history
 		action := string(op.Direction)
@@ -201,17 +253,45 @@ func (s *Syncer) SyncWithMachine(ctx context.Context, m *Machine) ([]*SyncResult
 			Duration:  result.Duration,
 		})
 
-		// Update queue
+		// Update queue. Conflicts wait for resolution rather than retrying.
 		if result.Success {
+			s.recordSynced(op)
 			s.state.RemoveFromQueue(op.Provider, op.Profile, m.ID)
-		} else {
+		} else if op.Direction != SyncConflict {
 			s.state.AddToQueue(op.Provider, op.Profile, m.ID, errorToString(result.Error))
 		}
 	}
 
+	stats := AggregateResults(results)
+	events.Publish(events.Event{
+		Type:   events.SyncCompleted,
+		Source: "sync",
+		Data: map[string]any{
+			"machine": m.Name,
+			"pushed":  stats.Pushed,
+			"pulled":  stats.Pulled,
+			"failed":  stats.Failed,
+		},
+	})
+
 	return results, nil
 }
 
+// connect gets a connection to m from the pool, traced as ssh.connect.
+func (s *Syncer) connect(ctx context.Context, m *Machine) (RemoteFS, error) {
+	_, span := tracing.Start(ctx, "ssh.connect", tracing.Machine(m.Name))
+	client, err := s.pool.Get(m)
+	tracing.End(span, err)
+	return client, err
+}
+
+// reportProfile invokes the OnProfile callback, if any.
+func (s *Syncer) reportProfile(m *Machine, p ProfileRef, done, total int, result *SyncResult) {
+	if s.onProfile != nil {
+		s.onProfile(m, p, done, total, result)
+	}
+}
+
 // SyncProfileWithMachine syncs a specific profile with a specific machine.
 // This is useful for queue processing where we only want to retry the failed machine.
 func (s *Syncer) SyncProfileWithMachine(ctx context.Context, provider, profile string, m *Machine) (*SyncResult, error) {
@@ -225,7 +305,20 @@ func (s *Syncer) SyncProfileWithMachine(ctx context.Context, provider, profile s
 	default:
 	}
 
-	client, err := s.pool.Get(m)
+	if s.refuseWipedMachine(m) {
+		return &SyncResult{
+			Operation: &SyncOperation{
+				Provider:  provider,
+				Profile:   profile,
+				Direction: SyncSkip,
+				Machine:   m,
+			},
+			Success: false,
+			Error:   ErrWipePending,
+		}, nil
+	}
+
+	client, err := s.connect(ctx, m)
 	if err != nil {
 		m.SetError(err.Error())
 		return &SyncResult{
@@ -256,6 +349,9 @@ func (s *Syncer) SyncProfileWithMachine(ctx context.Context, provider, profile s
 	}
 
 	if op == nil || op.Direction == SyncSkip {
+		if op != nil {
+			s.recordSynced(op)
+		}
 		return &SyncResult{
 			Operation: &SyncOperation{
 				Provider:  provider,
@@ -267,7 +363,7 @@ func (s *Syncer) SyncProfileWithMachine(ctx context.Context, provider, profile s
 		}, nil
 	}
 
-	result := s.executeOperation(client, op)
+	result := s.executeOperation(ctx, client, op)
 
 	// Record in history
 	s.state.AddToHistory(HistoryEntry{
@@ -281,6 +377,9 @@ func (s *Syncer) SyncProfileWithMachine(ctx context.Context, provider, profile s
 		Error:     errorToString(result.Error),
 		Duration:  result.Duration,
 	})
+	if result.Success {
+		s.recordSynced(op)
+	}
 
 	return result, nil
 }
@@ -300,7 +399,11 @@ func (s *Syncer) SyncProfile(ctx context.Context, provider, profile string) ([]*
 		default:
 		}
 
-		client, err := s.pool.Get(m)
+		if s.refuseWipedMachine(m) {
+			continue
+		}
+
+		client, err := s.connect(ctx, m)
 		if err != nil {
 			m.SetError(err.Error())
 			allResults = append(allResults, &SyncResult{
@@ -334,10 +437,13 @@ func (s *Syncer) SyncProfile(ctx context.Context, provider, profile string) ([]*
 		}
 
 		if op == nil || op.Direction == SyncSkip {
+			if op != nil {
+				s.recordSynced(op)
+			}
 			continue
 		}
 
-		result := s.executeOperation(client, op)
+		result := s.executeOperation(ctx, client, op)
 		allResults = append(allResults, result)
 
 		// Record in history
@@ -354,8 +460,9 @@ func (s *Syncer) SyncProfile(ctx context.Context, provider, profile string) ([]*
 		})
 
 		if result.Success {
+			s.recordSynced(op)
 			s.state.RemoveFromQueue(provider, profile, m.ID)
-		} else {
+		} else if op.Direction != SyncConflict {
 			s.state.AddToQueue(provider, profile, m.ID, errorToString(result.Error))
 		}
 	}
@@ -390,7 +497,7 @@ func (s *Syncer) SyncAll(ctx context.Context) ([]*SyncResult, error) {
 }
 
 // determineSyncOperation determines what sync operation is needed for a profile.
-func (s *Syncer) determineSyncOperation(client *SSHClient, m *Machine, p ProfileRef) (*SyncOperation, error) {
+func (s *Syncer) determineSyncOperation(client RemoteFS, m *Machine, p ProfileRef) (*SyncOperation, error) {
 	localFresh, localErr := s.getLocalFreshness(p)
 	remoteFresh, remoteErr := s.getRemoteFreshness(client, p)
 
@@ -436,6 +543,11 @@ func (s *Syncer) determineSyncOperation(client *SSHClient, m *Machine, p Profile
 		op.Direction = SyncPush
 		return op, nil
 
+	case s.recordConflict(m, localFresh, remoteFresh):
+		// Both sides changed since the last sync: leave both alone
+		op.Direction = SyncConflict
+		return op, nil
+
 	case CompareFreshness(localFresh, remoteFresh):
 		// Local is fresher: push
 		op.Direction = SyncPush
@@ -453,9 +565,24 @@ func (s *Syncer) determineSyncOperation(client *SSHClient, m *Machine, p Profile
 	}
 }
 
+// recordConflict records a conflict if both copies of a profile changed
+// since they were last in sync with m.
+func (s *Syncer) recordConflict(m *Machine, local, remote *TokenFreshness) bool {
+	c, ok := s.state.DetectConflict(m, local, remote)
+	if ok {
+		s.state.AddConflict(*c)
+	}
+	return ok
+}
+
 // executeOperation executes a sync operation.
-func (s *Syncer) executeOperation(client *SSHClient, op *SyncOperation) *SyncResult {
+func (s *Syncer) executeOperation(ctx context.Context, client RemoteFS, op *SyncOperation) *SyncResult {
 	start := time.Now()
+	_, span := tracing.Start(ctx, "sync.transfer",
+		tracing.Provider(op.Provider),
+		tracing.Profile(op.Profile),
+		attribute.String("caam.sync.direction", string(op.Direction)),
+	)
 
 	result := &SyncResult{
 		Operation: op,
@@ -463,64 +590,79 @@ func (s *Syncer) executeOperation(client *SSHClient, op *SyncOperation) *SyncRes
 
 	switch op.Direction {
 	case SyncPush:
-		err := s.pushProfile(client, op.Provider, op.Profile)
+		n, err := s.pushProfile(client, op.Provider, op.Profile)
+		result.BytesSent = n
 		result.Error = err
 		result.Success = err == nil
 
 	case SyncPull:
-		err := s.pullProfile(client, op.Provider, op.Profile)
+		n, err := s.pullProfile(client, op.Provider, op.Profile)
+		result.BytesReceived = n
 		result.Error = err
 		result.Success = err == nil
 
 	case SyncSkip:
 		result.Success = true
+
+	case SyncConflict:
+		result.Error = ErrSyncConflict
 	}
 
 	result.Duration = time.Since(start)
+	tracing.End(span, result.Error)
 	return result
 }
 
-// pushProfile pushes a local profile to the remote machine.
-func (s *Syncer) pushProfile(client *SSHClient, provider, profile string) error {
+// pushProfile pushes a local profile to the remote machine and returns the
+// bytes written.
+func (s *Syncer) pushProfile(client RemoteFS, provider, profile string) (int64, error) {
 	localPath := filepath.Join(s.vaultPath, provider, profile)
 	// Use posixJoin for remote paths since SFTP always uses forward slashes
-	remotePath := posixJoin(s.remoteVaultPath, provider, profile)
+	remotePath := posixJoin(s.remoteVaultDir(client), provider, profile)
 
 	// Read local files
 	files, err := s.readLocalProfileFiles(localPath)
 	if err != nil {
-		return fmt.Errorf("read local files: %w", err)
+		return 0, fmt.Errorf("read local files: %w", err)
 	}
 
 	// Write to remote
+	var n int64
 	for filename, data := range files {
+		data, err := s.sealForPush(client, provider, profile, filename, filepath.Join(localPath, filename), data)
+		if err != nil {
+			return n, fmt.Errorf("seal %s: %w", filename, err)
+		}
 		remoteFilePath := posixJoin(remotePath, filename)
 		if err := client.WriteFile(remoteFilePath, data, 0600); err != nil {
-			return fmt.Errorf("write remote file %s: %w", filename, err)
+			return n, fmt.Errorf("write remote file %s: %w", filename, err)
 		}
+		n += int64(len(data))
 	}
 
-	return nil
+	return n, nil
 }
 
-// pullProfile pulls a remote profile to the local machine.
-func (s *Syncer) pullProfile(client *SSHClient, provider, profile string) error {
+// pullProfile pulls a remote profile to the local machine and returns the
+// bytes read.
+func (s *Syncer) pullProfile(client RemoteFS, provider, profile string) (int64, error) {
 	localPath := filepath.Join(s.vaultPath, provider, profile)
 	// Use posixJoin for remote paths since SFTP always uses forward slashes
-	remotePath := posixJoin(s.remoteVaultPath, provider, profile)
+	remotePath := posixJoin(s.remoteVaultDir(client), provider, profile)
 
 	// List remote files
 	remoteFiles, err := client.ListDir(remotePath)
 	if err != nil {
-		return fmt.Errorf("list remote files: %w", err)
+		return 0, fmt.Errorf("list remote files: %w", err)
 	}
 
 	// Ensure local directory exists
 	if err := os.MkdirAll(localPath, 0700); err != nil {
-		return fmt.Errorf("create local directory: %w", err)
+		return 0, fmt.Errorf("create local directory: %w", err)
 	}
 
 	// Read remote files and write locally using atomic writes
+	var n int64
 	for _, fi := range remoteFiles {
 		if fi.IsDir() {
 			continue
@@ -529,16 +671,30 @@ func (s *Syncer) pullProfile(client *SSHClient, provider, profile string) error
 		remoteFilePath := posixJoin(remotePath, fi.Name())
 		data, err := client.ReadFile(remoteFilePath)
 		if err != nil {
-			return fmt.Errorf("read remote file %s: %w", fi.Name(), err)
+			return n, fmt.Errorf("read remote file %s: %w", fi.Name(), err)
 		}
+		n += int64(len(data))
 
 		localFilePath := filepath.Join(localPath, fi.Name())
+		data, err = s.openPulled(provider, profile, fi.Name(), data)
+		if err != nil {
+			return n, fmt.Errorf("decrypt remote file %s: %w", fi.Name(), err)
+		}
+		// Auth files land encrypted in an encrypted vault. Files sealed with
+		// a key this machine can't open are kept as they are, and meta.json
+		// and other extras stay plaintext.
+		plain := !vaultcrypt.IsEncrypted(data) && !vaultcrypt.IsPoolSealed(data)
+		if plain && authfile.IsVaultAuthFile(provider, fi.Name()) {
+			if data, err = vaultcrypt.SealFor(localFilePath, data); err != nil {
+				return n, fmt.Errorf("encrypt local file %s: %w", fi.Name(), err)
+			}
+		}
 		if err := atomicWriteFile(localFilePath, data, 0600); err != nil {
-			return fmt.Errorf("write local file %s: %w", fi.Name(), err)
+			return n, fmt.Errorf("write local file %s: %w", fi.Name(), err)
 		}
 	}
 
-	return nil
+	return n, nil
 }
 
 // atomicWriteFile writes data to a file atomically using temp file + fsync + rename.
@@ -649,9 +805,9 @@ func (s *Syncer) getLocalFreshness(p ProfileRef) (*TokenFreshness, error) {
 }
 
 // getRemoteFreshness gets the freshness of a remote profile.
-func (s *Syncer) getRemoteFreshness(client *SSHClient, p ProfileRef) (*TokenFreshness, error) {
+func (s *Syncer) getRemoteFreshness(client RemoteFS, p ProfileRef) (*TokenFreshness, error) {
 	// Use posixJoin for remote paths since SFTP always uses forward slashes
-	remotePath := posixJoin(s.remoteVaultPath, p.Provider, p.Profile)
+	remotePath := posixJoin(s.remoteVaultDir(client), p.Provider, p.Profile)
 
 	// Check if remote directory exists
 	exists, err := client.FileExists(remotePath)
@@ -670,16 +826,23 @@ func (s *Syncer) getRemoteFreshness(client *SSHClient, p ProfileRef) (*TokenFres
 
 	// Read remote auth files
 	authFiles := make(map[string][]byte)
+	var modTime time.Time
 	for _, fi := range files {
 		if fi.IsDir() {
 			continue
 		}
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
 
 		filePath := posixJoin(remotePath, fi.Name())
 		data, err := client.ReadFile(filePath)
 		if err != nil {
 			continue // Skip files we can't read
 		}
+		if data, err = s.openPulled(p.Provider, p.Profile, fi.Name(), data); err != nil {
+			continue
+		}
 
 		authFiles[filePath] = data
 	}
@@ -693,17 +856,29 @@ func (s *Syncer) getRemoteFreshness(client *SSHClient, p ProfileRef) (*TokenFres
 		return nil, err
 	}
 
-	freshness.Source = client.machine.Name
+	// Extractors stat paths on the local filesystem, so take the remote
+	// modification time from the directory listing instead.
+	if freshness.ModifiedAt.IsZero() {
+		freshness.ModifiedAt = modTime
+	}
+	freshness.Source = client.Machine().Name
 	return freshness, nil
 }
 
+// remoteVaultDir returns the vault directory on the other end of client:
+// the remote vault path for SSH peers, or the vault below an HTTPS endpoint.
+func (s *Syncer) remoteVaultDir(client RemoteFS) string {
+	if client.Machine().TransportName() == TransportHTTPS {
+		return httpsVaultDir
+	}
+	return s.remoteVaultPath
+}
+
 // listLocalProfiles lists all profiles in the local vault.
 func (s *Syncer) listLocalProfiles() ([]ProfileRef, error) {
 	var profiles []ProfileRef
 
-	providers := []string{"claude", "codex", "gemini"}
-
-	for _, provider := range providers {
+	for _, provider := range Providers() {
 		providerPath := filepath.Join(s.vaultPath, provider)
 
 		entries, err := os.ReadDir(providerPath)
@@ -728,14 +903,12 @@ func (s *Syncer) listLocalProfiles() ([]ProfileRef, error) {
 }
 
 // listRemoteProfiles lists all profiles in the remote vault.
-func (s *Syncer) listRemoteProfiles(client *SSHClient) ([]ProfileRef, error) {
+func (s *Syncer) listRemoteProfiles(client RemoteFS) ([]ProfileRef, error) {
 	var profiles []ProfileRef
 
-	providers := []string{"claude", "codex", "gemini"}
-
-	for _, provider := range providers {
+	for _, provider := range Providers() {
 		// Use posixJoin for remote paths since SFTP always uses forward slashes
-		providerPath := posixJoin(s.remoteVaultPath, provider)
+		providerPath := posixJoin(s.remoteVaultDir(client), provider)
 
 		entries, err := client.ListDir(providerPath)
 		if err != nil {
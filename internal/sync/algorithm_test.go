@@ -6,6 +6,10 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // TestSyncDirection tests the SyncDirection constants.
@@ -442,10 +446,6 @@ func TestSyncerContextCancellation(t *testing.T) {
 				Entries: []QueueEntry{},
 				MaxSize: 100,
 			},
-			History: &SyncHistory{
-				Entries: []HistoryEntry{},
-				MaxSize: 1000,
-			},
 		},
 	}
 
@@ -550,3 +550,39 @@ func TestLocalRandomString(t *testing.T) {
 		}
 	})
 }
+
+// TestSyncWithMachineTraces tests that a sync records connect and transfer
+// spans under one span for the machine.
+func TestSyncWithMachineTraces(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	t.Setenv("CAAM_HOME", t.TempDir())
+	srv, _ := newWebDAVServer(t)
+	vault := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(vault, "claude", "work"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(vault, "claude", "work", ".credentials.json"), []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s := &Syncer{pool: NewConnectionPool(httpsTestOptions(srv, "shared")), state: NewSyncState(t.TempDir()), vaultPath: vault}
+	defer s.pool.CloseAll()
+	if _, err := s.SyncWithMachine(context.Background(), newHTTPSMachine("hub", srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+
+	parents := make(map[string]string)
+	ids := make(map[string]string)
+	for _, span := range exporter.GetSpans() {
+		ids[span.Name] = span.SpanContext.SpanID().String()
+		parents[span.Name] = span.Parent.SpanID().String()
+	}
+	for _, name := range []string{"ssh.connect", "sync.transfer"} {
+		if parents[name] != ids["sync.machine"] || ids["sync.machine"] == "" {
+			t.Errorf("%s parent = %q, want sync.machine %q", name, parents[name], ids["sync.machine"])
+		}
+	}
+}
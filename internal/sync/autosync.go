@@ -2,6 +2,7 @@ package sync
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -161,6 +162,35 @@ func runBackgroundSync(provider, profile string, state *SyncState, config AutoSy
 	ctx, cancel := context.WithTimeout(context.Background(), config.SyncTimeout)
 	defer cancel()
 
+	results, err := syncProfileAndQueue(ctx, provider, profile, state, config)
+	if err != nil {
+		logSyncError("sync profile", err, config.Verbose)
+	}
+	// Record throttle even on failure to prevent sync storms
+	globalThrottler.RecordSync(provider, profile)
+	logSyncResults(results, config.Verbose)
+}
+
+// PushProfile syncs one profile with every machine in the pool now,
+// queueing failures for retry like auto-sync does. It does nothing if the
+// pool has no machines.
+func PushProfile(ctx context.Context, provider, profile string) ([]*SyncResult, error) {
+	state, err := LoadSyncState()
+	if err != nil {
+		return nil, err
+	}
+	if state.Pool == nil || len(state.Pool.Machines) == 0 {
+		return nil, nil
+	}
+	config := DefaultAutoSyncConfig()
+	ctx, cancel := context.WithTimeout(ctx, config.SyncTimeout)
+	defer cancel()
+	return syncProfileAndQueue(ctx, provider, profile, state, config)
+}
+
+// syncProfileAndQueue syncs one profile with the pool and queues the
+// machines that failed for retry.
+func syncProfileAndQueue(ctx context.Context, provider, profile string, state *SyncState, config AutoSyncConfig) ([]*SyncResult, error) {
 	syncerConfig := SyncerConfig{
 		VaultPath:       config.VaultPath,
 		RemoteVaultPath: config.RemoteVaultPath,
@@ -169,8 +199,7 @@ func runBackgroundSync(provider, profile string, state *SyncState, config AutoSy
 
 	syncer, err := NewSyncer(syncerConfig)
 	if err != nil {
-		logSyncError("create syncer", err, config.Verbose)
-		return
+		return nil, fmt.Errorf("create syncer: %w", err)
 	}
 	defer syncer.Close()
 
@@ -179,20 +208,11 @@ func runBackgroundSync(provider, profile string, state *SyncState, config AutoSy
 
 	results, err := syncer.SyncProfile(ctx, provider, profile)
 	if err != nil {
-		logSyncError("sync profile", err, config.Verbose)
-		// Record throttle even on failure to prevent sync storms
-		globalThrottler.RecordSync(provider, profile)
 		// Queue for retry
 		queueFailedSync(state, provider, profile, err.Error())
-		return
+		return results, err
 	}
 
-	// Log results and queue failures
-	logSyncResults(results, config.Verbose)
-
-	// Update throttle timestamp
-	globalThrottler.RecordSync(provider, profile)
-
 	// Queue any failed machines for retry
 	failedMachines := getFailedMachines(results)
 	if len(failedMachines) > 0 {
@@ -202,6 +222,7 @@ func runBackgroundSync(provider, profile string, state *SyncState, config AutoSy
 		}
 		state.Save()
 	}
+	return results, nil
 }
 
 // logSyncResults logs the results of sync operations.
@@ -0,0 +1,347 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrSyncConflict is returned for profiles that changed both locally and on
+// the remote machine since they were last in sync.
+var ErrSyncConflict = errors.New("sync conflict: profile changed on both machines since last sync (see 'caam sync conflicts')")
+
+// ConflictResolution picks which side of a conflict wins.
+type ConflictResolution string
+
+const (
+	// KeepLocal pushes the local profile over the remote one.
+	KeepLocal ConflictResolution = "keep-local"
+	// KeepRemote pulls the remote profile over the local one.
+	KeepRemote ConflictResolution = "keep-remote"
+	// KeepFreshest keeps whichever side has the later token expiry.
+	KeepFreshest ConflictResolution = "keep-freshest"
+)
+
+// ParseConflictResolution parses a resolution name. The "keep-" prefix is
+// optional, so "local", "remote" and "freshest" are accepted too.
+func ParseConflictResolution(s string) (ConflictResolution, error) {
+	switch s {
+	case "keep-local", "local":
+		return KeepLocal, nil
+	case "keep-remote", "remote":
+		return KeepRemote, nil
+	case "keep-freshest", "freshest":
+		return KeepFreshest, nil
+	}
+	return "", fmt.Errorf("invalid resolution %q (valid: keep-local, keep-remote, keep-freshest)", s)
+}
+
+// conflictClockSlack absorbs clock skew between machines when modification
+// times are the only signal (tokens without a known expiry).
+const conflictClockSlack = time.Minute
+
+// SyncBase is what a profile looked like the last time it was in sync with a
+// machine: the common ancestor for three-way conflict detection.
+type SyncBase struct {
+	Provider string `json:"provider"`
+	Profile  string `json:"profile"`
+	// Machine is the machine ID.
+	Machine string `json:"machine"`
+
+	// ExpiresAt is the token expiry both sides agreed on.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+	// SyncedAt is when the profile was last in sync.
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// Conflict is a profile that changed on both sides since the last sync.
+type Conflict struct {
+	Provider string `json:"provider"`
+	Profile  string `json:"profile"`
+	// Machine is the machine ID.
+	Machine string `json:"machine"`
+	// MachineName is the machine's display name when the conflict was found.
+	MachineName string `json:"machine_name"`
+
+	DetectedAt time.Time `json:"detected_at"`
+
+	// Base is the last agreed state, Local and Remote the diverged copies.
+	Base   SyncBase       `json:"base"`
+	Local  TokenFreshness `json:"local"`
+	Remote TokenFreshness `json:"remote"`
+}
+
+// Key identifies the conflict as provider/profile@machine.
+func (c Conflict) Key() string {
+	return conflictKey(c.Provider, c.Profile, c.Machine)
+}
+
+// Freshest returns which side would win under KeepFreshest.
+func (c Conflict) Freshest() ConflictResolution {
+	if CompareFreshness(&c.Remote, &c.Local) {
+		return KeepRemote
+	}
+	return KeepLocal
+}
+
+func conflictKey(provider, profile, machine string) string {
+	return provider + "/" + profile + "@" + machine
+}
+
+// conflictsFile is the on-disk format of conflicts.json.
+type conflictsFile struct {
+	Bases     []SyncBase `json:"bases"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+const conflictsFileName = "conflicts.json"
+
+// loadConflicts reads bases and conflicts. Callers must hold s.mu.
+func (s *SyncState) loadConflicts() error {
+	s.bases = make(map[string]SyncBase)
+	s.Conflicts = nil
+
+	data, err := os.ReadFile(filepath.Join(s.basePath, conflictsFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var f conflictsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parse %s: %w", conflictsFileName, err)
+	}
+	for _, b := range f.Bases {
+		s.bases[conflictKey(b.Provider, b.Profile, b.Machine)] = b
+	}
+	s.Conflicts = f.Conflicts
+	return nil
+}
+
+// saveConflicts writes bases and conflicts. Callers must hold s.mu.
+func (s *SyncState) saveConflicts() error {
+	// Only write when this state changed them, so a stale copy loaded
+	// alongside a Syncer can't overwrite what the Syncer recorded.
+	if !s.conflictsDirty {
+		return nil
+	}
+	f := conflictsFile{Conflicts: s.Conflicts}
+	for _, b := range s.bases {
+		f.Bases = append(f.Bases, b)
+	}
+	if err := saveJSONFile(s.basePath, conflictsFileName, f); err != nil {
+		return err
+	}
+	s.conflictsDirty = false
+	return nil
+}
+
+// RecordSyncBase notes that a profile is in sync with a machine, with the
+// given freshness on both sides, and clears any conflict for it.
+func (s *SyncState) RecordSyncBase(provider, profile, machineID string, fresh *TokenFreshness, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bases == nil {
+		s.bases = make(map[string]SyncBase)
+	}
+	base := SyncBase{Provider: provider, Profile: profile, Machine: machineID, SyncedAt: at}
+	if fresh != nil {
+		base.ExpiresAt = fresh.ExpiresAt
+	}
+	key := conflictKey(provider, profile, machineID)
+	s.bases[key] = base
+	s.conflictsDirty = true
+	s.removeConflictLocked(key)
+}
+
+// SyncBaseFor returns the last in-sync state of a profile with a machine.
+func (s *SyncState) SyncBaseFor(provider, profile, machineID string) (SyncBase, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.bases[conflictKey(provider, profile, machineID)]
+	return b, ok
+}
+
+// DetectConflict reports whether both copies of a profile changed since its
+// sync base with a machine and now disagree. A side has changed when its
+// token expiry differs from the base; when either expiry is unknown, when its
+// file was modified after the last sync. Profiles with no base (never synced
+// with this machine) never conflict.
+func (s *SyncState) DetectConflict(m *Machine, local, remote *TokenFreshness) (*Conflict, bool) {
+	if m == nil || local == nil || remote == nil {
+		return nil, false
+	}
+	base, ok := s.SyncBaseFor(local.Provider, local.Profile, m.ID)
+	if !ok {
+		return nil, false
+	}
+	if !local.ExpiresAt.IsZero() && local.ExpiresAt.Equal(remote.ExpiresAt) {
+		// Both sides converged on the same token.
+		return nil, false
+	}
+	if !changedSinceBase(local, base) || !changedSinceBase(remote, base) {
+		return nil, false
+	}
+	return &Conflict{
+		Provider:    local.Provider,
+		Profile:     local.Profile,
+		Machine:     m.ID,
+		MachineName: m.Name,
+		DetectedAt:  time.Now(),
+		Base:        base,
+		Local:       *local,
+		Remote:      *remote,
+	}, true
+}
+
+func changedSinceBase(f *TokenFreshness, base SyncBase) bool {
+	if !f.ExpiresAt.IsZero() && !base.ExpiresAt.IsZero() {
+		return !f.ExpiresAt.Equal(base.ExpiresAt)
+	}
+	return f.ModifiedAt.After(base.SyncedAt.Add(conflictClockSlack))
+}
+
+// AddConflict records a conflict, replacing any earlier one for the same
+// profile and machine.
+func (s *SyncState) AddConflict(c Conflict) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.Conflicts {
+		if existing.Key() == c.Key() {
+			c.DetectedAt = existing.DetectedAt
+			s.Conflicts[i] = c
+			s.conflictsDirty = true
+			return
+		}
+	}
+	s.Conflicts = append(s.Conflicts, c)
+	s.conflictsDirty = true
+}
+
+// RemoveConflict drops a recorded conflict.
+func (s *SyncState) RemoveConflict(provider, profile, machineID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeConflictLocked(conflictKey(provider, profile, machineID))
+}
+
+func (s *SyncState) removeConflictLocked(key string) {
+	for i, c := range s.Conflicts {
+		if c.Key() == key {
+			s.Conflicts = append(s.Conflicts[:i], s.Conflicts[i+1:]...)
+			s.conflictsDirty = true
+			return
+		}
+	}
+}
+
+// ListConflicts returns a copy of the recorded conflicts, oldest first.
+func (s *SyncState) ListConflicts() []Conflict {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Conflict(nil), s.Conflicts...)
+}
+
+// Conflicts returns the unresolved sync conflicts.
+func (s *Syncer) Conflicts() []Conflict {
+	return s.state.ListConflicts()
+}
+
+// ResolveConflict settles a conflict by pushing or pulling the profile. Both
+// sides are re-read first, so KeepFreshest compares the copies as they are
+// now rather than when the conflict was recorded.
+func (s *Syncer) ResolveConflict(ctx context.Context, c Conflict, resolution ConflictResolution) (*SyncResult, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	m := s.state.Pool.GetMachine(c.Machine)
+	if m == nil {
+		return nil, fmt.Errorf("machine %q is no longer in the sync pool", c.MachineName)
+	}
+	if s.refuseWipedMachine(m) {
+		return nil, ErrWipePending
+	}
+
+	client, err := s.connect(ctx, m)
+	if err != nil {
+		m.SetError(err.Error())
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+
+	p := ProfileRef{Provider: c.Provider, Profile: c.Profile}
+	local, err := s.getLocalFreshness(p)
+	if err != nil {
+		return nil, fmt.Errorf("local profile: %w", err)
+	}
+	remote, err := s.getRemoteFreshness(client, p)
+	if err != nil {
+		return nil, fmt.Errorf("remote profile: %w", err)
+	}
+
+	op := &SyncOperation{
+		Provider:        c.Provider,
+		Profile:         c.Profile,
+		Machine:         m,
+		LocalFreshness:  local,
+		RemoteFreshness: remote,
+	}
+	switch resolution {
+	case KeepLocal:
+		op.Direction = SyncPush
+	case KeepRemote:
+		op.Direction = SyncPull
+	case KeepFreshest:
+		switch {
+		case CompareFreshness(local, remote):
+			op.Direction = SyncPush
+		case CompareFreshness(remote, local):
+			op.Direction = SyncPull
+		default:
+			op.Direction = SyncSkip
+		}
+	default:
+		return nil, fmt.Errorf("invalid resolution %q", resolution)
+	}
+
+	result := s.executeOperation(ctx, client, op)
+	if op.Direction != SyncSkip {
+		s.state.AddToHistory(HistoryEntry{
+			Timestamp: time.Now(),
+			Trigger:   "resolve",
+			Provider:  c.Provider,
+			Profile:   c.Profile,
+			Machine:   m.Name,
+			Action:    string(op.Direction),
+			Success:   result.Success,
+			Error:     errorToString(result.Error),
+			Duration:  result.Duration,
+		})
+	}
+	if result.Success {
+		s.recordSynced(op)
+	}
+	return result, nil
+}
+
+// recordSynced records the sync base after a successful operation.
+func (s *Syncer) recordSynced(op *SyncOperation) {
+	fresh := op.LocalFreshness
+	if op.Direction == SyncPull {
+		fresh = op.RemoteFreshness
+	}
+	if fresh == nil || op.Machine == nil {
+		return
+	}
+	s.state.RecordSyncBase(op.Provider, op.Profile, op.Machine.ID, fresh, time.Now())
+}
@@ -0,0 +1,112 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectConflict(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMachine("peer", "192.168.1.50")
+
+	fresh := func(expires time.Time, modified time.Time) *TokenFreshness {
+		return &TokenFreshness{Provider: "claude", Profile: "work", ExpiresAt: expires, ModifiedAt: modified}
+	}
+
+	state := NewSyncState(t.TempDir())
+	if _, ok := state.DetectConflict(m, fresh(base.Add(time.Hour), base), fresh(base.Add(2*time.Hour), base)); ok {
+		t.Fatal("profile with no sync base should not conflict")
+	}
+
+	state.RecordSyncBase("claude", "work", m.ID, fresh(base, base), base)
+
+	tests := []struct {
+		name          string
+		local, remote *TokenFreshness
+		want          bool
+	}{
+		{"only local refreshed", fresh(base.Add(time.Hour), base.Add(time.Hour)), fresh(base, base), false},
+		{"only remote refreshed", fresh(base, base), fresh(base.Add(time.Hour), base.Add(time.Hour)), false},
+		{"both refreshed", fresh(base.Add(time.Hour), base.Add(time.Hour)), fresh(base.Add(2*time.Hour), base.Add(time.Hour)), true},
+		{"both refreshed to same token", fresh(base.Add(time.Hour), base.Add(time.Hour)), fresh(base.Add(time.Hour), base.Add(time.Hour)), false},
+		{"unknown expiry, both modified", fresh(time.Time{}, base.Add(time.Hour)), fresh(time.Time{}, base.Add(2*time.Hour)), true},
+		{"unknown expiry, remote within clock slack", fresh(time.Time{}, base.Add(time.Hour)), fresh(time.Time{}, base.Add(30*time.Second)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := state.DetectConflict(m, tt.local, tt.remote)
+			if ok != tt.want {
+				t.Fatalf("DetectConflict() = %v, want %v", ok, tt.want)
+			}
+			if ok && (c.Machine != m.ID || c.MachineName != "peer" || !c.Base.ExpiresAt.Equal(base)) {
+				t.Errorf("conflict = %+v", c)
+			}
+		})
+	}
+}
+
+func TestConflictLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMachine("peer", "192.168.1.50")
+
+	state := NewSyncState(dir)
+	state.RecordSyncBase("codex", "main", m.ID, &TokenFreshness{ExpiresAt: base}, base)
+	c, ok := state.DetectConflict(m,
+		&TokenFreshness{Provider: "codex", Profile: "main", ExpiresAt: base.Add(time.Hour)},
+		&TokenFreshness{Provider: "codex", Profile: "main", ExpiresAt: base.Add(2 * time.Hour)},
+	)
+	if !ok {
+		t.Fatal("expected conflict")
+	}
+	if got := c.Freshest(); got != KeepRemote {
+		t.Errorf("Freshest() = %q, want %q", got, KeepRemote)
+	}
+	state.AddConflict(*c)
+	state.AddConflict(*c)
+	if n := len(state.ListConflicts()); n != 1 {
+		t.Fatalf("conflicts after duplicate add = %d, want 1", n)
+	}
+
+	state.mu.Lock()
+	if err := state.saveConflicts(); err != nil {
+		t.Fatalf("saveConflicts: %v", err)
+	}
+	state.mu.Unlock()
+
+	reloaded := NewSyncState(dir)
+	reloaded.mu.Lock()
+	if err := reloaded.loadConflicts(); err != nil {
+		t.Fatalf("loadConflicts: %v", err)
+	}
+	reloaded.mu.Unlock()
+	conflicts := reloaded.ListConflicts()
+	if len(conflicts) != 1 || conflicts[0].Key() != c.Key() {
+		t.Fatalf("reloaded conflicts = %+v", conflicts)
+	}
+	if _, ok := reloaded.SyncBaseFor("codex", "main", m.ID); !ok {
+		t.Error("sync base not persisted")
+	}
+
+	// Syncing the profile again resolves the conflict.
+	reloaded.RecordSyncBase("codex", "main", m.ID, &TokenFreshness{ExpiresAt: base.Add(2 * time.Hour)}, base.Add(3*time.Hour))
+	if n := len(reloaded.ListConflicts()); n != 0 {
+		t.Errorf("conflicts after sync = %d, want 0", n)
+	}
+}
+
+func TestParseConflictResolution(t *testing.T) {
+	for in, want := range map[string]ConflictResolution{
+		"keep-local":    KeepLocal,
+		"remote":        KeepRemote,
+		"keep-freshest": KeepFreshest,
+	} {
+		got, err := ParseConflictResolution(in)
+		if err != nil || got != want {
+			t.Errorf("ParseConflictResolution(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseConflictResolution("newest"); err == nil {
+		t.Error("expected error for unknown resolution")
+	}
+}
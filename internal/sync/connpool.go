@@ -4,9 +4,9 @@ import (
 	"sync"
 )
 
-// ConnectionPool manages a pool of SSH connections.
+// ConnectionPool manages a pool of remote connections.
 type ConnectionPool struct {
-	clients map[string]*SSHClient
+	clients map[string]RemoteFS
 	mu      sync.RWMutex
 	opts    ConnectOptions
 }
@@ -14,14 +14,14 @@ type ConnectionPool struct {
 // NewConnectionPool creates a new connection pool with the given options.
 func NewConnectionPool(opts ConnectOptions) *ConnectionPool {
 	return &ConnectionPool{
-		clients: make(map[string]*SSHClient),
+		clients: make(map[string]RemoteFS),
 		opts:    opts,
 	}
 }
 
-// Get returns a connected SSH client for the given machine.
-// If a connection already exists, it is reused.
-func (p *ConnectionPool) Get(machine *Machine) (*SSHClient, error) {
+// Get returns a connected client for the given machine, over SSH or HTTPS
+// depending on its transport. If a connection already exists, it is reused.
+func (p *ConnectionPool) Get(machine *Machine) (RemoteFS, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -36,7 +36,7 @@ func (p *ConnectionPool) Get(machine *Machine) (*SSHClient, error) {
 	}
 
 	// Create new connection
-	client := NewSSHClient(machine)
+	client := NewRemoteFS(machine)
 	if err := client.Connect(p.opts); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,152 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/caamerr"
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultFleetTimeout bounds one machine's run of a fleet command,
+// including the SSH connection.
+const DefaultFleetTimeout = 60 * time.Second
+
+// FleetOptions configures RunFleet.
+type FleetOptions struct {
+	// Connect is used for each machine's SSH connection.
+	Connect ConnectOptions
+
+	// Timeout bounds each machine. Default: DefaultFleetTimeout.
+	Timeout time.Duration
+
+	// Parallel is how many machines run at once. Default: 8.
+	Parallel int
+
+	// Exec runs a shell command on a machine. Default: over SSH.
+	Exec func(ctx context.Context, m *Machine, command string, opts ConnectOptions) (stdout, stderr []byte, err error)
+}
+
+// FleetResult is one machine's reply to a fleet command. Output is the
+// robot command's JSON envelope as the machine printed it.
+type FleetResult struct {
+	Machine    string          `json:"machine"`
+	Success    bool            `json:"success"`
+	Code       string          `json:"code,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	ExitCode   int             `json:"exit_code"`
+	DurationMs int64           `json:"duration_ms"`
+	Output     json.RawMessage `json:"output,omitempty"`
+}
+
+// fleetUnreachable is the code of a machine whose command never ran or
+// printed no robot output.
+const fleetUnreachable = string(caamerr.SyncFailed)
+
+// RunFleet runs the shell command on each machine and returns their
+// replies in the order of machines. command should run a caam robot
+// command, whose JSON envelope each reply carries. Machines reached over
+// HTTPS and machines with a pending wipe order are not contacted.
+func RunFleet(ctx context.Context, machines []*Machine, command string, opts FleetOptions) []FleetResult {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultFleetTimeout
+	}
+	if opts.Parallel <= 0 {
+		opts.Parallel = 8
+	}
+	if opts.Exec == nil {
+		opts.Exec = sshFleetExec
+	}
+	results := make([]FleetResult, len(machines))
+	sem := make(chan struct{}, opts.Parallel)
+	var wg sync.WaitGroup
+	for i, m := range machines {
+		results[i] = FleetResult{Machine: m.Name}
+		switch {
+		case m.TransportName() != TransportSSH:
+			results[i].Code, results[i].Error = fleetUnreachable, "machine is reached over "+m.TransportName()+" and cannot run commands"
+			continue
+		case !m.WipeRequestedAt.IsZero():
+			results[i].Code, results[i].Error = fleetUnreachable, "machine has a pending wipe order"
+			continue
+		}
+
+		wg.Add(1)
+		go func(r *FleetResult, m *Machine) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			runCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+			defer cancel()
+			start := time.Now()
+			stdout, stderr, err := opts.Exec(runCtx, m, command, opts.Connect)
+			r.DurationMs = time.Since(start).Milliseconds()
+			fillFleetResult(r, stdout, stderr, err)
+		}(&results[i], m)
+	}
+	wg.Wait()
+	return results
+}
+
+// fillFleetResult sets r from a remote robot command's output. A robot
+// command that fails still prints its envelope, so the envelope decides
+// success whenever there is one.
+func fillFleetResult(r *FleetResult, stdout, stderr []byte, err error) {
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		r.ExitCode = exitErr.ExitStatus()
+	} else if err != nil {
+		r.ExitCode = -1
+	}
+
+	var envelope struct {
+		Success bool `json:"success"`
+		Error   *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	out := bytes.TrimSpace(stdout)
+	if len(out) == 0 || json.Unmarshal(out, &envelope) != nil {
+		r.Code = fleetUnreachable
+		if errors.Is(err, context.DeadlineExceeded) {
+			r.Code = string(caamerr.Timeout)
+		}
+		switch msg := strings.TrimSpace(string(stderr)); {
+		case err != nil && msg != "":
+			r.Error = fmt.Sprintf("%v: %s", err, msg)
+		case err != nil:
+			r.Error = err.Error()
+		case msg != "":
+			r.Error = "no robot output: " + msg
+		default:
+			r.Error = "no robot output"
+		}
+		return
+	}
+
+	r.Output = json.RawMessage(out)
+	r.Success = envelope.Success && r.ExitCode == 0
+	if envelope.Error != nil {
+		r.Code, r.Error = envelope.Error.Code, envelope.Error.Message
+	} else if !r.Success && err != nil {
+		r.Code, r.Error = fleetUnreachable, err.Error()
+	}
+}
+
+// sshFleetExec runs command on m over a new SSH connection.
+func sshFleetExec(ctx context.Context, m *Machine, command string, opts ConnectOptions) ([]byte, []byte, error) {
+	client := NewSSHClient(m)
+	if err := client.Connect(opts); err != nil {
+		return nil, nil, err
+	}
+	defer client.Disconnect()
+	return client.Run(ctx, command)
+}
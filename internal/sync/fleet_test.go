@@ -0,0 +1,75 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunFleet(t *testing.T) {
+	machines := []*Machine{
+		{Name: "ok", Address: "10.0.0.1"},
+		{Name: "refused", Address: "10.0.0.2"},
+		{Name: "down", Address: "10.0.0.3"},
+		{Name: "slow", Address: "10.0.0.4"},
+		{Name: "bucket", Address: "https://dav.example.com", Transport: TransportHTTPS},
+		{Name: "lost", Address: "10.0.0.5", WipeRequestedAt: time.Now()},
+	}
+
+	var commands []string
+	exec := func(ctx context.Context, m *Machine, command string, _ ConnectOptions) ([]byte, []byte, error) {
+		commands = append(commands, command)
+		switch m.Name {
+		case "ok":
+			return []byte(`{"success":true,"command":"status","data":{"providers":[]}}` + "\n"), nil, nil
+		case "refused":
+			return []byte(`{"success":false,"command":"act","error":{"code":"PROFILE_LEASED","message":"leased"}}`), nil, errors.New("Process exited with status 11")
+		case "down":
+			return nil, nil, errors.New("dial tcp 10.0.0.3:22: connection refused")
+		default:
+			<-ctx.Done()
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	results := RunFleet(context.Background(), machines, "caam robot status", FleetOptions{
+		Exec:     exec,
+		Timeout:  50 * time.Millisecond,
+		Parallel: 1,
+	})
+	if len(commands) != 4 || commands[0] != "caam robot status" {
+		t.Errorf("commands = %q", commands)
+	}
+
+	want := map[string]struct {
+		success bool
+		code    string
+	}{
+		"ok":      {true, ""},
+		"refused": {false, "PROFILE_LEASED"},
+		"down":    {false, "SYNC_FAILED"},
+		"slow":    {false, "TIMEOUT"},
+		"bucket":  {false, "SYNC_FAILED"},
+		"lost":    {false, "SYNC_FAILED"},
+	}
+	for i, r := range results {
+		if r.Machine != machines[i].Name {
+			t.Fatalf("results[%d] = %s, want %s", i, r.Machine, machines[i].Name)
+		}
+		w := want[r.Machine]
+		if r.Success != w.success || r.Code != w.code {
+			t.Errorf("%s: success=%v code=%q error=%q, want success=%v code=%q", r.Machine, r.Success, r.Code, r.Error, w.success, w.code)
+		}
+	}
+
+	var envelope map[string]any
+	if err := json.Unmarshal(results[0].Output, &envelope); err != nil || envelope["command"] != "status" {
+		t.Errorf("ok output = %s (%v)", results[0].Output, err)
+	}
+	if !strings.Contains(results[2].Error, "connection refused") {
+		t.Errorf("down error = %q", results[2].Error)
+	}
+}
@@ -5,7 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // TokenFreshness represents the freshness of authentication tokens for a profile.
@@ -76,9 +81,42 @@ func GetExtractor(provider string) FreshnessExtractor {
 		return &CodexFreshnessExtractor{}
 	case "gemini":
 		return &GeminiFreshnessExtractor{}
+	case "cursor":
+		return &CursorFreshnessExtractor{}
+	case "copilot":
+		return &CopilotFreshnessExtractor{}
 	default:
-		return nil
+		extractorsMu.RLock()
+		defer extractorsMu.RUnlock()
+		return extractors[provider]
+	}
+}
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   = make(map[string]FreshnessExtractor)
+)
+
+// RegisterExtractor adds a freshness extractor for a provider that has no
+// built-in one, such as one loaded from a provider plugin. Registered
+// providers are synced like the built-in ones.
+func RegisterExtractor(provider string, e FreshnessExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors[provider] = e
+}
+
+// Providers returns the built-in providers followed by registered ones.
+func Providers() []string {
+	providers := []string{"claude", "codex", "gemini", "cursor", "copilot"}
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	var extra []string
+	for name := range extractors {
+		extra = append(extra, name)
 	}
+	sort.Strings(extra)
+	return append(providers, extra...)
 }
 
 // ClaudeFreshnessExtractor extracts freshness from Claude auth files.
@@ -349,6 +387,96 @@ func (e *GeminiFreshnessExtractor) Extract(provider, profile string, authFiles m
 	}, nil
 }
 
+// CursorFreshnessExtractor extracts freshness from Cursor CLI auth files.
+type CursorFreshnessExtractor struct{}
+
+// cursorToken represents the structure of auth.json for Cursor CLI.
+type cursorToken struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Extract implements FreshnessExtractor for Cursor. The expiry comes from
+// the access token's exp claim.
+func (e *CursorFreshnessExtractor) Extract(provider, profile string, authFiles map[string][]byte) (*TokenFreshness, error) {
+	var authData []byte
+	var modTime time.Time
+
+	for path, data := range authFiles {
+		if containsPath(path, "auth.json") {
+			authData = data
+			if info, err := os.Stat(path); err == nil {
+				modTime = info.ModTime()
+			}
+			break
+		}
+	}
+
+	if authData == nil {
+		return nil, fmt.Errorf("no auth.json found in auth files")
+	}
+
+	var token cursorToken
+	if err := json.Unmarshal(authData, &token); err != nil {
+		return nil, fmt.Errorf("parse auth.json: %w", err)
+	}
+
+	freshness := &TokenFreshness{
+		Provider:   provider,
+		Profile:    profile,
+		ModifiedAt: modTime,
+		Source:     "local",
+	}
+	if id, err := identity.ExtractFromJWT(token.AccessToken); err == nil && !id.ExpiresAt.IsZero() {
+		freshness.ExpiresAt = id.ExpiresAt
+		freshness.IsExpired = time.Now().After(id.ExpiresAt)
+	}
+	return freshness, nil
+}
+
+// CopilotFreshnessExtractor extracts freshness from GitHub Copilot auth files.
+type CopilotFreshnessExtractor struct{}
+
+// Extract implements FreshnessExtractor for Copilot. Tokens without an
+// expires_at (classic gho_ tokens) compare by modification time only.
+func (e *CopilotFreshnessExtractor) Extract(provider, profile string, authFiles map[string][]byte) (*TokenFreshness, error) {
+	var hostsData []byte
+	var modTime time.Time
+
+	for _, name := range []string{"hosts.json", "apps.json"} {
+		for path, data := range authFiles {
+			if containsPath(path, name) {
+				hostsData = data
+				if info, err := os.Stat(path); err == nil {
+					modTime = info.ModTime()
+				}
+				break
+			}
+		}
+		if hostsData != nil {
+			break
+		}
+	}
+
+	if hostsData == nil {
+		return nil, fmt.Errorf("no hosts.json or apps.json found in auth files")
+	}
+
+	id, err := identity.ParseCopilotHosts(hostsData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenFreshness{
+		Provider:   provider,
+		Profile:    profile,
+		ExpiresAt:  id.ExpiresAt,
+		ModifiedAt: modTime,
+		IsExpired:  !id.ExpiresAt.IsZero() && time.Now().After(id.ExpiresAt),
+		Source:     "local",
+	}, nil
+}
+
 // containsPath checks if the path ends with the given filename.
 // It properly handles path separators to avoid false positives like
 // matching "auth.json.backup" when looking for "auth.json".
@@ -374,7 +502,7 @@ func ExtractFreshnessFromFiles(provider, profile string, filePaths []string) (*T
 
 	authFiles := make(map[string][]byte)
 	for _, path := range filePaths {
-		data, err := os.ReadFile(path)
+		data, err := vaultcrypt.ReadFile(path)
 		if err != nil {
 			if os.IsNotExist(err) {
 				continue // Skip missing files
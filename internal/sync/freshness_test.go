@@ -101,6 +101,8 @@ func TestGetExtractor(t *testing.T) {
 		{"claude", false},
 		{"codex", false},
 		{"gemini", false},
+		{"cursor", false},
+		{"copilot", false},
 		{"unknown", true},
 		{"", true},
 	}
@@ -762,3 +764,60 @@ func TestProfileRef(t *testing.T) {
 		t.Errorf("Profile = %q, want %q", ref.Profile, "test@example.com")
 	}
 }
+
+func TestCopilotFreshnessExtractor(t *testing.T) {
+	expiry := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		authFiles map[string][]byte
+		wantErr   bool
+		want      time.Time
+	}{
+		{
+			name: "hosts.json with expiry",
+			authFiles: map[string][]byte{
+				"/home/user/.config/github-copilot/hosts.json": []byte(`{"github.com":{"user":"octocat","oauth_token":"ghu_x","expires_at":"2026-03-01T12:00:00Z"}}`),
+			},
+			want: expiry,
+		},
+		{
+			name: "apps.json without expiry",
+			authFiles: map[string][]byte{
+				"apps.json": []byte(`{"github.com:Iv1.abc":{"user":"octocat","oauth_token":"gho_x"}}`),
+			},
+		},
+		{
+			name:      "no auth files",
+			authFiles: map[string][]byte{},
+			wantErr:   true,
+		},
+		{
+			name: "no signed-in host",
+			authFiles: map[string][]byte{
+				"hosts.json": []byte(`{}`),
+			},
+			wantErr: true,
+		},
+	}
+
+	extractor := &CopilotFreshnessExtractor{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractor.Extract("copilot", "test-profile", tt.authFiles)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !got.ExpiresAt.Equal(tt.want) {
+				t.Errorf("ExpiresAt = %v, want %v", got.ExpiresAt, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,504 @@
+package sync
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// HTTPSClient syncs with a WebDAV endpoint (a self-hosted server, Nextcloud,
+// rclone serve webdav, ...) for machines that can't accept SSH. The endpoint
+// is a shared, dumb store: every file is encrypted with a key derived from
+// the sync passphrase before it leaves the machine, so the server never sees
+// tokens.
+//
+// Machine.Address is the endpoint URL. The server is sent basic auth when a
+// user is set (in the URL or Machine.SSHUser) with the password from
+// CAAM_SYNC_HTTP_PASSWORD, or else a bearer token from CAAM_SYNC_HTTP_TOKEN.
+type HTTPSClient struct {
+	machine   *Machine
+	base      *url.URL
+	http      *http.Client
+	user      string
+	password  string
+	token     string
+	aead      cipher.AEAD
+	connected bool
+}
+
+// Credentials for HTTPS sync endpoints. They are read from the environment
+// so they never land in pool.json.
+const (
+	httpPasswordEnv = "CAAM_SYNC_HTTP_PASSWORD"
+	httpTokenEnv    = "CAAM_SYNC_HTTP_TOKEN"
+)
+
+// saltFileName is stored at the endpoint root. Every machine derives the same
+// key from the shared passphrase and this salt.
+const saltFileName = ".caam-sync-salt"
+
+// encryptedMagic prefixes every file written to an endpoint.
+var encryptedMagic = []byte("CAAMSYNC1")
+
+// NewHTTPSClient creates a new HTTPS client for the given machine.
+func NewHTTPSClient(m *Machine) *HTTPSClient {
+	return &HTTPSClient{machine: m}
+}
+
+// ParseEndpoint validates an HTTPS sync endpoint URL. Plain http is only
+// accepted for loopback addresses, e.g. a local tunnel.
+func ParseEndpoint(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, fmt.Errorf("endpoint %s must use https", u.Redacted())
+		}
+	default:
+		return nil, fmt.Errorf("endpoint URL must start with https:// (got %q)", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("endpoint URL %q has no host", raw)
+	}
+	if _, ok := u.User.Password(); ok {
+		return nil, fmt.Errorf("endpoint URL must not contain a password; set %s instead", httpPasswordEnv)
+	}
+	return u, nil
+}
+
+// Machine returns the machine this client connects to.
+func (c *HTTPSClient) Machine() *Machine {
+	return c.machine
+}
+
+// Connect checks the endpoint and derives the encryption key, creating the
+// endpoint's salt on first use.
+func (c *HTTPSClient) Connect(opts ConnectOptions) error {
+	base, err := ParseEndpoint(c.machine.Address)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	c.base = base
+	c.user = base.User.Username()
+	if c.user == "" {
+		c.user = c.machine.SSHUser
+	}
+	c.password = os.Getenv(httpPasswordEnv)
+	c.token = os.Getenv(httpTokenEnv)
+
+	c.http = opts.HTTPClient
+	if c.http == nil {
+		timeout := opts.Timeout
+		if timeout == 0 {
+			timeout = DefaultConnectOptions().Timeout
+		}
+		c.http = &http.Client{Timeout: timeout}
+	}
+
+	passphrase := opts.Passphrase
+	if passphrase == "" {
+		if passphrase, err = SyncPassphrase(); err != nil {
+			return err
+		}
+	}
+
+	salt, err := c.endpointSalt()
+	if err != nil {
+		return err
+	}
+	key := argon2.IDKey([]byte(passphrase), salt, 1, 64*1024, 4, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("create cipher: %w", err)
+	}
+	if c.aead, err = cipher.NewGCM(block); err != nil {
+		return fmt.Errorf("create GCM: %w", err)
+	}
+
+	c.connected = true
+	c.machine.SetOnline()
+	return nil
+}
+
+// endpointSalt fetches the endpoint's key salt, creating it if missing.
+func (c *HTTPSClient) endpointSalt() ([]byte, error) {
+	resp, err := c.do(http.MethodGet, c.resolve(saltFileName), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		salt, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if err != nil {
+			return nil, fmt.Errorf("read salt: %w", err)
+		}
+		if len(salt) < 16 {
+			return nil, fmt.Errorf("endpoint salt is corrupt (%d bytes)", len(salt))
+		}
+		return salt, nil
+	case http.StatusNotFound:
+		// A new endpoint: make sure its root collection exists.
+		resp, err := c.do("MKCOL", c.resolve(""), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return nil, httpStatusError("MKCOL", "/", resp)
+		}
+
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("generate salt: %w", err)
+		}
+		if err := c.put(saltFileName, salt); err != nil {
+			return nil, fmt.Errorf("create salt: %w", err)
+		}
+		return salt, nil
+	default:
+		return nil, httpStatusError("GET", saltFileName, resp)
+	}
+}
+
+// Disconnect releases the client.
+func (c *HTTPSClient) Disconnect() error {
+	c.connected = false
+	if c.http != nil {
+		c.http.CloseIdleConnections()
+	}
+	return nil
+}
+
+// IsConnected reports whether Connect succeeded.
+func (c *HTTPSClient) IsConnected() bool {
+	return c.connected
+}
+
+// ReadFile downloads and decrypts a file.
+func (c *HTTPSClient) ReadFile(remotePath string) ([]byte, error) {
+	if !c.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	resp, err := c.do(http.MethodGet, c.resolve(remotePath), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, &os.PathError{Op: "read", Path: remotePath, Err: os.ErrNotExist}
+	default:
+		return nil, httpStatusError("GET", remotePath, resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", remotePath, err)
+	}
+	return c.decrypt(remotePath, data)
+}
+
+// WriteFile encrypts and uploads a file. The mode is ignored; the server
+// decides permissions.
+func (c *HTTPSClient) WriteFile(remotePath string, data []byte, mode os.FileMode) error {
+	if !c.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := c.mkdirAll(posixDir(remotePath)); err != nil {
+		return err
+	}
+	sealed, err := c.encrypt(remotePath, data)
+	if err != nil {
+		return err
+	}
+	return c.put(remotePath, sealed)
+}
+
+// FileExists reports whether a file or collection exists.
+func (c *HTTPSClient) FileExists(remotePath string) (bool, error) {
+	if !c.connected {
+		return false, fmt.Errorf("not connected")
+	}
+	_, err := c.propfind(remotePath, "0")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ListDir lists a collection.
+func (c *HTTPSClient) ListDir(remotePath string) ([]os.FileInfo, error) {
+	if !c.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	entries, err := c.propfind(remotePath, "1")
+	if err != nil {
+		return nil, err
+	}
+
+	self := strings.Trim(c.resolve(remotePath).Path, "/")
+	var infos []os.FileInfo
+	for _, e := range entries {
+		if strings.Trim(e.path, "/") == self {
+			continue
+		}
+		infos = append(infos, e.info)
+	}
+	return infos, nil
+}
+
+// mkdirAll creates a collection and its parents. Existing collections
+// answer 405, which is fine.
+func (c *HTTPSClient) mkdirAll(dir string) error {
+	dir = strings.Trim(dir, "/")
+	if dir == "" || dir == "." {
+		return nil
+	}
+	var current string
+	for _, part := range strings.Split(dir, "/") {
+		current = posixJoin(current, part)
+		u := c.resolve(current)
+		u.Path += "/"
+		resp, err := c.do("MKCOL", u, nil, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusCreated, http.StatusOK, http.StatusMethodNotAllowed:
+		default:
+			return httpStatusError("MKCOL", current, resp)
+		}
+	}
+	return nil
+}
+
+func (c *HTTPSClient) put(remotePath string, data []byte) error {
+	resp, err := c.do(http.MethodPut, c.resolve(remotePath), bytes.NewReader(data), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	}
+	return httpStatusError("PUT", remotePath, resp)
+}
+
+// davEntry is one resource in a PROPFIND response.
+type davEntry struct {
+	path string
+	info *remoteFileInfo
+}
+
+type davMultistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ContentLength int64  `xml:"DAV: getcontentlength"`
+				LastModified  string `xml:"DAV: getlastmodified"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/></d:prop></d:propfind>`
+
+func (c *HTTPSClient) propfind(remotePath, depth string) ([]davEntry, error) {
+	header := http.Header{"Depth": {depth}, "Content-Type": {"application/xml"}}
+	resp, err := c.do("PROPFIND", c.resolve(remotePath), strings.NewReader(propfindBody), header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusMultiStatus:
+	case http.StatusNotFound:
+		return nil, &os.PathError{Op: "stat", Path: remotePath, Err: os.ErrNotExist}
+	default:
+		return nil, httpStatusError("PROPFIND", remotePath, resp)
+	}
+
+	var ms davMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("parse PROPFIND %s: %w", remotePath, err)
+	}
+
+	var entries []davEntry
+	for _, r := range ms.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			continue
+		}
+		info := &remoteFileInfo{name: path.Base(strings.TrimSuffix(href.Path, "/"))}
+		for _, ps := range r.Propstats {
+			if ps.Status != "" && !strings.Contains(ps.Status, " 200") {
+				continue
+			}
+			info.dir = info.dir || ps.Prop.ResourceType.Collection != nil
+			if ps.Prop.ContentLength > 0 {
+				info.size = ps.Prop.ContentLength
+			}
+			if t, err := http.ParseTime(ps.Prop.LastModified); err == nil {
+				info.modTime = t
+			}
+		}
+		entries = append(entries, davEntry{path: href.Path, info: info})
+	}
+	return entries, nil
+}
+
+// resolve returns the URL for a path below the endpoint.
+func (c *HTTPSClient) resolve(remotePath string) *url.URL {
+	rel := strings.TrimPrefix(path.Clean("/"+remotePath), "/")
+	u := *c.base
+	u.Path = c.base.Path + rel
+	u.RawPath = ""
+	u.User = nil
+	return &u
+}
+
+func (c *HTTPSClient) do(method string, u *url.URL, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.password)
+	} else if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, u.Redacted(), err)
+	}
+	return resp, nil
+}
+
+// encrypt seals data, binding it to its path so the server can't swap files
+// between profiles.
+func (c *HTTPSClient) encrypt(remotePath string, data []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	out := append([]byte{}, encryptedMagic...)
+	out = append(out, nonce...)
+	return c.aead.Seal(out, nonce, data, []byte(path.Clean(remotePath))), nil
+}
+
+func (c *HTTPSClient) decrypt(remotePath string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, encryptedMagic) {
+		return nil, fmt.Errorf("%s is not an encrypted caam sync file", remotePath)
+	}
+	data = data[len(encryptedMagic):]
+	n := c.aead.NonceSize()
+	if len(data) < n {
+		return nil, fmt.Errorf("%s is truncated", remotePath)
+	}
+	plain, err := c.aead.Open(nil, data[:n], data[n:], []byte(path.Clean(remotePath)))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: wrong sync passphrase?", remotePath)
+	}
+	return plain, nil
+}
+
+// HTTPStatusError is an unexpected response from a sync endpoint.
+type HTTPStatusError struct {
+	Method string
+	Path   string
+	Status int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.Status, http.StatusText(e.Status))
+}
+
+// IsAuthFailure reports whether the endpoint rejected the credentials.
+func (e *HTTPStatusError) IsAuthFailure() bool {
+	return e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden
+}
+
+func httpStatusError(method, remotePath string, resp *http.Response) error {
+	return &HTTPStatusError{Method: method, Path: remotePath, Status: resp.StatusCode}
+}
+
+// errWipeNeedsSSH is returned for wipe orders to HTTPS machines: the
+// endpoint is shared storage, not a machine that could act on the order.
+var errWipeNeedsSSH = errors.New("wipe orders can only be delivered over SSH")
+
+// testHTTPSConnectivity checks an HTTPS machine for TestMachineConnectivity.
+func testHTTPSConnectivity(m *Machine, opts ConnectOptions) *ConnectivityResult {
+	result := &ConnectivityResult{Machine: m}
+
+	client := NewHTTPSClient(m)
+	start := time.Now()
+	if err := client.Connect(opts); err != nil {
+		result.Error = err
+		result.ErrorType = "network"
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) && statusErr.IsAuthFailure() {
+			result.ErrorType = "auth"
+		}
+		return result
+	}
+	defer client.Disconnect()
+
+	result.Latency = time.Since(start)
+	result.Success = true
+
+	if providers, err := client.ListDir(httpsVaultDir); err == nil {
+		result.CAAMFound = true
+		for _, p := range providers {
+			if !p.IsDir() {
+				continue
+			}
+			if profiles, err := client.ListDir(posixJoin(httpsVaultDir, p.Name())); err == nil {
+				result.ProfileCount += len(profiles)
+			}
+		}
+	}
+	return result
+}
+
+// httpsVaultDir is the vault directory below an HTTPS endpoint.
+const httpsVaultDir = "vault"
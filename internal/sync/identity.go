@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 )
 
 // LocalIdentity represents this machine's unique identity in the sync network.
@@ -26,8 +28,12 @@ type LocalIdentity struct {
 const identityFileName = "identity.json"
 
 // SyncDataDir returns the path to the sync data directory.
-// Uses CAAM_HOME/data if set, otherwise XDG_DATA_HOME/caam/sync.
+// Uses the active config environment's sync directory if there is one,
+// CAAM_HOME/data if set, otherwise XDG_DATA_HOME/caam/sync.
 func SyncDataDir() string {
+	if env := config.ActiveEnv(); env != "" {
+		return filepath.Join(config.EnvDir(env), "sync")
+	}
 	if caamHome := os.Getenv("CAAM_HOME"); caamHome != "" {
 		return filepath.Join(caamHome, "data", "sync")
 	}
@@ -0,0 +1,211 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// Import sources and actions reported in ImportResult.
+const (
+	ImportSourceVault = "vault"
+	ImportSourceLive  = "live"
+
+	ImportPulled  = "pulled"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// ImportOptions configures ImportFromMachine.
+type ImportOptions struct {
+	// Providers limits the import to these providers. Empty means all.
+	Providers []string
+
+	// LiveProfile is the profile a provider's live auth files on the
+	// remote machine are imported as, when its vault has no profiles for
+	// that provider. Empty skips live auth files.
+	LiveProfile string
+
+	// DryRun reports what would be imported without writing anything.
+	DryRun bool
+}
+
+// ImportResult is what happened to one remote profile.
+type ImportResult struct {
+	Provider string `json:"provider"`
+	Profile  string `json:"profile"`
+	Source   string `json:"source"`
+	Action   string `json:"action"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ImportFromMachine pulls profiles from a machine that need not be in the
+// sync pool. Each remote vault profile is pulled if the local vault lacks it
+// or holds an older token; nothing is ever pushed. For providers with no
+// remote vault profiles, the machine's live auth files are imported as
+// opts.LiveProfile.
+func (s *Syncer) ImportFromMachine(ctx context.Context, m *Machine, opts ImportOptions) ([]ImportResult, error) {
+	client, err := s.connect(ctx, m)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	return s.importFrom(ctx, client, opts)
+}
+
+func (s *Syncer) importFrom(ctx context.Context, client RemoteFS, opts ImportOptions) ([]ImportResult, error) {
+	wanted := func(provider string) bool {
+		if len(opts.Providers) == 0 {
+			return true
+		}
+		for _, p := range opts.Providers {
+			if strings.EqualFold(p, provider) {
+				return true
+			}
+		}
+		return false
+	}
+
+	remoteProfiles, err := s.listRemoteProfiles(client)
+	if err != nil {
+		return nil, fmt.Errorf("list remote profiles: %w", err)
+	}
+
+	var results []ImportResult
+	inVault := make(map[string]bool)
+	for _, p := range remoteProfiles {
+		if !wanted(p.Provider) || authfile.IsSystemProfile(p.Profile) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		inVault[p.Provider] = true
+		results = append(results, s.importVaultProfile(client, p, opts.DryRun))
+	}
+
+	if opts.LiveProfile == "" {
+		return results, nil
+	}
+	for _, provider := range Providers() {
+		if !wanted(provider) || inVault[provider] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if r := s.importLiveAuth(client, provider, opts.LiveProfile, opts.DryRun); r != nil {
+			results = append(results, *r)
+		}
+	}
+	return results, nil
+}
+
+// importVaultProfile pulls one remote vault profile if it is fresher.
+func (s *Syncer) importVaultProfile(client RemoteFS, p ProfileRef, dryRun bool) ImportResult {
+	result := ImportResult{Provider: p.Provider, Profile: p.Profile, Source: ImportSourceVault}
+
+	remote, err := s.getRemoteFreshness(client, p)
+	if err != nil {
+		result.Action, result.Reason = ImportFailed, "remote: "+err.Error()
+		return result
+	}
+	result.Action, result.Reason = s.importAction(p, remote)
+	if result.Action != ImportPulled || dryRun {
+		return result
+	}
+	if _, err := s.pullProfile(client, p.Provider, p.Profile); err != nil {
+		result.Action, result.Reason = ImportFailed, err.Error()
+	}
+	return result
+}
+
+// importAction decides whether a remote copy of p with freshness remote
+// should replace the local one.
+func (s *Syncer) importAction(p ProfileRef, remote *TokenFreshness) (action, reason string) {
+	local, err := s.getLocalFreshness(p)
+	switch {
+	case err != nil && os.IsNotExist(err):
+		return ImportPulled, "not in the local vault"
+	case err != nil:
+		return ImportFailed, "local: " + err.Error()
+	case CompareFreshness(remote, local):
+		return ImportPulled, "remote token is fresher"
+	default:
+		return ImportSkipped, "local token is as fresh or fresher"
+	}
+}
+
+// importLiveAuth imports the auth files a provider's CLI is currently
+// logged in with on the remote machine. The files are looked up at the
+// same paths relative to the remote home directory as they have locally.
+// It returns nil if the provider isn't logged in there.
+func (s *Syncer) importLiveAuth(client RemoteFS, provider, profile string, dryRun bool) *ImportResult {
+	set, ok := authfile.GetAuthFileSet(provider)
+	if !ok {
+		return nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	files := make(map[string][]byte)  // vault file name -> contents
+	byPath := make(map[string][]byte) // remote path -> contents, for freshness
+	needRequired, haveRequired := false, false
+	for _, spec := range set.Files {
+		rel, err := filepath.Rel(home, spec.Path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		needRequired = needRequired || spec.Required
+		remotePath := filepath.ToSlash(rel)
+		data, err := client.ReadFile(remotePath)
+		if err != nil {
+			continue
+		}
+		files[spec.VaultFileName()] = data
+		byPath[remotePath] = data
+		if spec.Required {
+			haveRequired = true
+		}
+	}
+	// Settings files alone don't make a login.
+	if len(files) == 0 || (needRequired && !haveRequired) {
+		return nil
+	}
+
+	result := &ImportResult{Provider: provider, Profile: profile, Source: ImportSourceLive}
+	p := ProfileRef{Provider: provider, Profile: profile}
+	remote, err := ExtractFreshnessFromBytes(provider, profile, byPath)
+	if err != nil {
+		result.Action, result.Reason = ImportFailed, "remote: "+err.Error()
+		return result
+	}
+	result.Action, result.Reason = s.importAction(p, remote)
+	if result.Action != ImportPulled || dryRun {
+		return result
+	}
+	localPath := filepath.Join(s.vaultPath, provider, profile)
+	if err := os.MkdirAll(localPath, 0700); err != nil {
+		result.Action, result.Reason = ImportFailed, err.Error()
+		return result
+	}
+	for name, data := range files {
+		path := filepath.Join(localPath, name)
+		data, err := vaultcrypt.SealFor(path, data)
+		if err != nil {
+			result.Action, result.Reason = ImportFailed, fmt.Sprintf("encrypt %s: %v", name, err)
+			return result
+		}
+		if err := atomicWriteFile(path, data, 0600); err != nil {
+			result.Action, result.Reason = ImportFailed, fmt.Sprintf("write %s: %v", name, err)
+			return result
+		}
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// machineFS is a dirFS that reports a machine.
+type machineFS struct {
+	dirFS
+	m *Machine
+}
+
+func (f machineFS) Machine() *Machine { return f.m }
+
+func writeCodexAuth(t *testing.T, dir string, expires time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	data := fmt.Sprintf(`{"access_token":"tok","expires_at":%d}`, expires.Unix())
+	if err := os.WriteFile(filepath.Join(dir, "auth.json"), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportFromMachine(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CODEX_HOME", "")
+	remoteRoot := t.TempDir()
+	vaultPath := t.TempDir()
+	s := &Syncer{vaultPath: vaultPath, remoteVaultPath: ".local/share/caam/vault"}
+	client := machineFS{dirFS{remoteRoot}, NewMachine("build-1", "build-1")}
+
+	now := time.Now()
+	remoteVault := filepath.Join(remoteRoot, ".local/share/caam/vault")
+	writeCodexAuth(t, filepath.Join(remoteVault, "codex", "new"), now.Add(48*time.Hour))
+	writeCodexAuth(t, filepath.Join(remoteVault, "codex", "stale"), now.Add(time.Hour))
+	writeCodexAuth(t, filepath.Join(remoteVault, "codex", "fresh"), now.Add(72*time.Hour))
+	writeCodexAuth(t, filepath.Join(remoteVault, "codex", "_original"), now.Add(72*time.Hour))
+	writeCodexAuth(t, filepath.Join(vaultPath, "codex", "stale"), now.Add(24*time.Hour))
+	writeCodexAuth(t, filepath.Join(vaultPath, "codex", "fresh"), now.Add(time.Hour))
+	// Claude is logged in on the remote machine but has no vault there.
+	if err := os.MkdirAll(filepath.Join(remoteRoot, ".claude"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	creds := fmt.Sprintf(`{"claudeAiOauth":{"accessToken":"a","expiresAt":%d}}`, now.Add(8*time.Hour).UnixMilli())
+	if err := os.WriteFile(filepath.Join(remoteRoot, ".claude", ".credentials.json"), []byte(creds), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ImportOptions{LiveProfile: "build-1", DryRun: true}
+	results, err := s.importFrom(context.Background(), client, opts)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	got := make(map[string]ImportResult)
+	for _, r := range results {
+		got[r.Provider+"/"+r.Profile] = r
+	}
+	want := map[string]string{
+		"codex/new":      ImportPulled,
+		"codex/stale":    ImportSkipped,
+		"codex/fresh":    ImportPulled,
+		"claude/build-1": ImportPulled,
+	}
+	if len(got) != len(want) {
+		t.Errorf("results = %+v", results)
+	}
+	for key, action := range want {
+		if got[key].Action != action {
+			t.Errorf("%s = %+v, want %s", key, got[key], action)
+		}
+	}
+	if got["claude/build-1"].Source != ImportSourceLive {
+		t.Errorf("claude source = %q, want live", got["claude/build-1"].Source)
+	}
+	if _, err := os.Stat(filepath.Join(vaultPath, "codex", "new")); !os.IsNotExist(err) {
+		t.Error("dry run wrote to the vault")
+	}
+
+	opts.DryRun = false
+	if _, err := s.importFrom(context.Background(), client, opts); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	for _, path := range []string{"codex/new/auth.json", "codex/fresh/auth.json", "claude/build-1/.credentials.json"} {
+		if _, err := os.Stat(filepath.Join(vaultPath, path)); err != nil {
+			t.Errorf("%s not imported: %v", path, err)
+		}
+	}
+	local, err := s.getLocalFreshness(ProfileRef{Provider: "codex", Profile: "fresh"})
+	if err != nil || local.ExpiresAt.Unix() != now.Add(72*time.Hour).Unix() {
+		t.Errorf("codex/fresh after import = %+v, %v", local, err)
+	}
+
+	// A second import finds everything up to date.
+	results, _ = s.importFrom(context.Background(), client, opts)
+	for _, r := range results {
+		if r.Action != ImportSkipped {
+			t.Errorf("second import: %+v", r)
+		}
+	}
+
+	// Providers filters.
+	results, _ = s.importFrom(context.Background(), client, ImportOptions{Providers: []string{"claude"}, LiveProfile: "build-1"})
+	if len(results) != 1 || results[0].Provider != "claude" {
+		t.Errorf("claude-only import = %+v", results)
+	}
+}
+
+func TestImportLiveIntoEncryptedVault(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CAAM_HOME", t.TempDir())
+	t.Setenv(vaultcrypt.PassphraseEnv, "test-passphrase")
+	remoteRoot := t.TempDir()
+	vaultPath := t.TempDir()
+	if _, err := vaultcrypt.Init(vaultPath, vaultcrypt.ModePassphrase); err != nil {
+		t.Fatal(err)
+	}
+	s := &Syncer{vaultPath: vaultPath, remoteVaultPath: ".local/share/caam/vault"}
+	client := machineFS{dirFS{remoteRoot}, NewMachine("build-1", "build-1")}
+
+	if err := os.MkdirAll(filepath.Join(remoteRoot, ".claude"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	creds := fmt.Sprintf(`{"claudeAiOauth":{"accessToken":"a","expiresAt":%d}}`, time.Now().Add(8*time.Hour).UnixMilli())
+	if err := os.WriteFile(filepath.Join(remoteRoot, ".claude", ".credentials.json"), []byte(creds), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := s.importFrom(context.Background(), client, ImportOptions{Providers: []string{"claude"}, LiveProfile: "build-1"})
+	if err != nil || len(results) != 1 || results[0].Action != ImportPulled {
+		t.Fatalf("import = %+v, %v", results, err)
+	}
+	path := filepath.Join(vaultPath, "claude", "build-1", ".credentials.json")
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vaultcrypt.IsEncrypted(raw) {
+		t.Error("live login was written to the encrypted vault in plaintext")
+	}
+	plain, err := vaultcrypt.ReadFile(path)
+	if err != nil || string(plain) != creds {
+		t.Errorf("ReadFile() = %q, %v", plain, err)
+	}
+}
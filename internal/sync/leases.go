@@ -0,0 +1,212 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Profile leases.
+//
+// A lease reserves a profile for one holder so agents on different machines
+// don't use the same account at once. Leases are recorded in each machine's
+// database; to let the pool see them, every machine publishes the leases it
+// holds as leases/<machine-id>.json in its sync directory. Syncing with a
+// peer copies each machine's file whichever way is newer, so lease sets
+// travel through the pool the same way wipe orders do.
+
+const leasesDirName = "leases"
+
+// LeaseRecord is one published lease.
+type LeaseRecord struct {
+	Provider   string    `json:"provider"`
+	Profile    string    `json:"profile"`
+	Holder     string    `json:"holder"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Note       string    `json:"note,omitempty"`
+
+	// Machine is the hostname of the machine that published the lease. It
+	// is filled in when peer leases are read.
+	Machine string `json:"-"`
+}
+
+// LeaseSet is the leases one machine holds.
+type LeaseSet struct {
+	MachineID string        `json:"machine_id"`
+	Hostname  string        `json:"hostname"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Leases    []LeaseRecord `json:"leases"`
+}
+
+func leasesDir() string {
+	return filepath.Join(SyncDataDir(), leasesDirName)
+}
+
+func leaseSetName(machineID string) string {
+	return machineID + ".json"
+}
+
+// validLeaseSet rejects sets whose machine ID can't be used as a file name,
+// since peers choose it.
+func validLeaseSet(set *LeaseSet) bool {
+	id := set.MachineID
+	return id != "" && id != "." && id != ".." && !strings.ContainsAny(id, `/\:`)
+}
+
+// PublishLeases records leases as this machine's current lease set, for
+// peers to pick up on the next sync.
+func PublishLeases(leases []LeaseRecord) error {
+	self, err := GetOrCreateLocalIdentity()
+	if err != nil {
+		return err
+	}
+	set := LeaseSet{
+		MachineID: self.ID,
+		Hostname:  self.Hostname,
+		UpdatedAt: time.Now().UTC(),
+		Leases:    leases,
+	}
+	data, err := json.MarshalIndent(&set, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal leases: %w", err)
+	}
+	if err := os.MkdirAll(leasesDir(), 0700); err != nil {
+		return fmt.Errorf("create leases dir: %w", err)
+	}
+	return atomicWriteFile(filepath.Join(leasesDir(), leaseSetName(self.ID)), data, 0600)
+}
+
+// PeerLeases returns the unexpired leases published by other machines.
+func PeerLeases(now time.Time) ([]LeaseRecord, error) {
+	sets, err := loadLeaseSets()
+	if err != nil {
+		return nil, err
+	}
+	selfID := ""
+	if self, err := GetOrCreateLocalIdentity(); err == nil {
+		selfID = self.ID
+	}
+
+	var out []LeaseRecord
+	for _, set := range sets {
+		if set.MachineID == selfID {
+			continue
+		}
+		for _, l := range set.Leases {
+			if l.ExpiresAt.After(now) {
+				l.Machine = set.Hostname
+				out = append(out, l)
+			}
+		}
+	}
+	return out, nil
+}
+
+func loadLeaseSets() ([]*LeaseSet, error) {
+	entries, err := os.ReadDir(leasesDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read leases: %w", err)
+	}
+	var sets []*LeaseSet
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(leasesDir(), e.Name()))
+		if err != nil {
+			continue
+		}
+		var set LeaseSet
+		if err := json.Unmarshal(data, &set); err != nil || !validLeaseSet(&set) {
+			continue
+		}
+		sets = append(sets, &set)
+	}
+	return sets, nil
+}
+
+// shareLeases exchanges lease sets with the machine on the other end of
+// client. An HTTPS endpoint is shared storage, so its sync directory sits
+// next to its vault.
+func (s *Syncer) shareLeases(client RemoteFS) error {
+	dir := s.remoteSyncDir()
+	if client.Machine().TransportName() == TransportHTTPS {
+		dir = "sync"
+	}
+	return exchangeLeases(client, dir)
+}
+
+// exchangeLeases merges lease sets with a peer's sync directory at
+// remoteDir: each machine's set is copied to whichever side has an older
+// copy, or none.
+func exchangeLeases(client RemoteFS, remoteDir string) error {
+	local, err := loadLeaseSets()
+	if err != nil {
+		return err
+	}
+	localByID := make(map[string]*LeaseSet, len(local))
+	for _, set := range local {
+		localByID[set.MachineID] = set
+	}
+
+	dir := posixJoin(remoteDir, leasesDirName)
+	remoteByID := make(map[string]*LeaseSet)
+	if exists, err := client.FileExists(dir); err != nil {
+		return fmt.Errorf("check remote leases: %w", err)
+	} else if exists {
+		infos, err := client.ListDir(dir)
+		if err != nil {
+			return fmt.Errorf("list remote leases: %w", err)
+		}
+		for _, info := range infos {
+			if info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+				continue
+			}
+			data, err := client.ReadFile(posixJoin(dir, info.Name()))
+			if err != nil {
+				continue
+			}
+			var set LeaseSet
+			if err := json.Unmarshal(data, &set); err != nil || !validLeaseSet(&set) {
+				continue
+			}
+			remoteByID[set.MachineID] = &set
+		}
+	}
+
+	for id, set := range remoteByID {
+		if mine := localByID[id]; mine != nil && !set.UpdatedAt.After(mine.UpdatedAt) {
+			continue
+		}
+		data, err := json.MarshalIndent(set, "", "  ")
+		if err != nil {
+			continue
+		}
+		if err := os.MkdirAll(leasesDir(), 0700); err != nil {
+			return fmt.Errorf("create leases dir: %w", err)
+		}
+		if err := atomicWriteFile(filepath.Join(leasesDir(), leaseSetName(id)), data, 0600); err != nil {
+			return fmt.Errorf("save leases from peer: %w", err)
+		}
+	}
+	for id, set := range localByID {
+		if theirs := remoteByID[id]; theirs != nil && !set.UpdatedAt.After(theirs.UpdatedAt) {
+			continue
+		}
+		data, err := json.MarshalIndent(set, "", "  ")
+		if err != nil {
+			continue
+		}
+		if err := client.WriteFile(posixJoin(dir, leaseSetName(id)), data, 0600); err != nil {
+			return fmt.Errorf("share leases: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// dirFS is a RemoteFS backed by a local directory.
+type dirFS struct{ root string }
+
+func (d dirFS) Connect(ConnectOptions) error { return nil }
+func (d dirFS) Disconnect() error            { return nil }
+func (d dirFS) IsConnected() bool            { return true }
+func (d dirFS) Machine() *Machine            { return nil }
+
+func (d dirFS) ReadFile(p string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(d.root, p))
+}
+
+func (d dirFS) WriteFile(p string, data []byte, mode os.FileMode) error {
+	path := filepath.Join(d.root, p)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, mode)
+}
+
+func (d dirFS) FileExists(p string) (bool, error) {
+	_, err := os.Stat(filepath.Join(d.root, p))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (d dirFS) ListDir(p string) ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(filepath.Join(d.root, p))
+	if err != nil {
+		return nil, err
+	}
+	var infos []os.FileInfo
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func writeRemoteLeaseSet(t *testing.T, remote dirFS, set LeaseSet) {
+	t.Helper()
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.WriteFile("sync/leases/"+set.MachineID+".json", data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExchangeLeases(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	remote := dirFS{root: t.TempDir()}
+	now := time.Now().UTC()
+
+	if err := PublishLeases([]LeaseRecord{{
+		Provider: "claude", Profile: "work1", Holder: "alice@laptop",
+		AcquiredAt: now, ExpiresAt: now.Add(2 * time.Hour),
+	}}); err != nil {
+		t.Fatalf("PublishLeases() error = %v", err)
+	}
+	writeRemoteLeaseSet(t, remote, LeaseSet{
+		MachineID: "peer-1",
+		Hostname:  "buildbox",
+		UpdatedAt: now,
+		Leases: []LeaseRecord{
+			{Provider: "codex", Profile: "ci", Holder: "bot@buildbox", AcquiredAt: now, ExpiresAt: now.Add(time.Hour)},
+			{Provider: "codex", Profile: "old", Holder: "bot@buildbox", AcquiredAt: now.Add(-3 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+		},
+	})
+	writeRemoteLeaseSet(t, remote, LeaseSet{MachineID: "../escape", UpdatedAt: now})
+
+	if err := exchangeLeases(remote, "sync"); err != nil {
+		t.Fatalf("exchangeLeases() error = %v", err)
+	}
+
+	peers, err := PeerLeases(now)
+	if err != nil {
+		t.Fatalf("PeerLeases() error = %v", err)
+	}
+	if len(peers) != 1 || peers[0].Profile != "ci" || peers[0].Machine != "buildbox" {
+		t.Errorf("PeerLeases() = %+v, want codex/ci from buildbox", peers)
+	}
+
+	self, err := GetOrCreateLocalIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := remote.ReadFile("sync/leases/" + self.ID + ".json")
+	if err != nil {
+		t.Fatalf("local leases not shared: %v", err)
+	}
+	var shared LeaseSet
+	if err := json.Unmarshal(data, &shared); err != nil || len(shared.Leases) != 1 || shared.Leases[0].Profile != "work1" {
+		t.Errorf("shared set = %+v, %v", shared, err)
+	}
+
+	// An older copy of the peer's set does not replace the newer one.
+	writeRemoteLeaseSet(t, remote, LeaseSet{MachineID: "peer-1", Hostname: "buildbox", UpdatedAt: now.Add(-time.Minute)})
+	if err := exchangeLeases(remote, "sync"); err != nil {
+		t.Fatalf("second exchangeLeases() error = %v", err)
+	}
+	if peers, _ := PeerLeases(now); len(peers) != 1 {
+		t.Errorf("PeerLeases() after stale exchange = %+v", peers)
+	}
+}
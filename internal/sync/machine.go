@@ -1,7 +1,8 @@
 // Package sync provides multi-machine vault synchronization capabilities.
 //
 // This package implements the infrastructure for syncing authentication tokens
-// across multiple machines using SSH transport. It provides:
+// across multiple machines over SSH, or over HTTPS/WebDAV for machines that
+// can't accept SSH. It provides:
 //   - Machine identity and discovery (SSH config, CSV file)
 //   - Sync pool management
 //   - State persistence
@@ -53,6 +54,10 @@ type Machine struct {
 	// SSHUser is the SSH username (default: current user).
 	SSHUser string `json:"ssh_user,omitempty"`
 
+	// Transport is how the machine is reached: "ssh" (default) or "https".
+	// For HTTPS machines, Address is the endpoint URL.
+	Transport string `json:"transport,omitempty"`
+
 	// SSHKeyPath is the path to the SSH private key.
 	SSHKeyPath string `json:"ssh_key_path,omitempty"`
 
@@ -77,6 +82,20 @@ type Machine struct {
 
 	// Source indicates where this machine definition came from.
 	Source string `json:"source"`
+
+	// WipeRequestedAt is set when `caam fleet wipe` ordered this machine to
+	// delete its vault. Sync with the machine is refused from then on.
+	WipeRequestedAt time.Time `json:"wipe_requested_at,omitempty"`
+
+	// WipeDeliveredAt is when the wipe order was written to the machine.
+	WipeDeliveredAt time.Time `json:"wipe_delivered_at,omitempty"`
+
+	// IdentityID is the machine's own identity ID (its LocalIdentity.ID),
+	// learned on sync. Wipe orders are bound to it.
+	IdentityID string `json:"identity_id,omitempty"`
+
+	// PoolKeyID is the pool encryption key last delivered to this machine.
+	PoolKeyID string `json:"pool_key_id,omitempty"`
 }
 
 // NewMachine creates a new Machine with a generated UUID.
@@ -101,6 +120,14 @@ func (m *Machine) HostPort() string {
 	return net.JoinHostPort(m.Address, strconv.Itoa(port))
 }
 
+// TransportName returns the machine's transport, defaulting to SSH.
+func (m *Machine) TransportName() string {
+	if m.Transport == "" {
+		return TransportSSH
+	}
+	return m.Transport
+}
+
 // SetError updates the machine status to error with the given message.
 func (m *Machine) SetError(msg string) {
 	m.Status = StatusError
@@ -134,6 +161,15 @@ func (m *Machine) Validate() error {
 	if m.Address == "" {
 		return &ValidationError{Field: "address", Message: "machine address is required"}
 	}
+	switch m.TransportName() {
+	case TransportSSH:
+	case TransportHTTPS:
+		if _, err := ParseEndpoint(m.Address); err != nil {
+			return &ValidationError{Field: "address", Message: err.Error()}
+		}
+	default:
+		return &ValidationError{Field: "transport", Message: "unknown transport " + m.Transport + " (valid: ssh, https)"}
+	}
 	return nil
 }
 
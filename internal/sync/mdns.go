@@ -0,0 +1,338 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// mDNS is enough of multicast DNS service discovery (RFC 6762/6763) for caam
+// instances to find each other on a LAN: a responder that answers queries
+// for BeaconService, and a one-shot browser.
+
+// mdnsGroup is the IPv4 mDNS multicast address.
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// mdnsDomain is the mDNS top-level domain.
+const mdnsDomain = "local."
+
+// mdnsTTL is the TTL of records the responder sends.
+const mdnsTTL = 120
+
+// mdnsCacheFlush is the cache-flush bit of the class field in responses.
+const mdnsCacheFlush = 1 << 15
+
+// MDNSEntry is a service instance found by BrowseMDNS.
+type MDNSEntry struct {
+	// Instance is the instance label, e.g. "workstation".
+	Instance string
+	// Host is the host name the service runs on, without ".local.".
+	Host string
+	// IP is the host's address, from its A record or else the address the
+	// response came from.
+	IP net.IP
+	// Port is the service port.
+	Port int
+	// Text holds the TXT record's key=value pairs.
+	Text map[string]string
+}
+
+// mdnsService describes a service instance the responder announces.
+type mdnsService struct {
+	instance string // instance label
+	host     string // host label
+	port     int
+	text     map[string]string
+	ips      func() []net.IP // host addresses, looked up per response
+}
+
+// mdnsServiceName is the fully qualified service type, e.g. "_caam._tcp.local.".
+func mdnsServiceName(service string) string {
+	return strings.TrimSuffix(service, ".") + "." + mdnsDomain
+}
+
+// mdnsLabel makes s usable as a single DNS label.
+func mdnsLabel(s string) string {
+	s = strings.TrimSuffix(strings.TrimSpace(s), ".local")
+	s = strings.NewReplacer(".", "-", " ", "-").Replace(s)
+	if len(s) > 63 {
+		s = s[:63]
+	}
+	if s == "" {
+		s = "caam"
+	}
+	return s
+}
+
+// ServeMDNS answers mDNS queries for service on the LAN until ctx is done,
+// announcing an instance named after host that listens on port. text is
+// published as the instance's TXT record.
+func ServeMDNS(ctx context.Context, service, host string, port int, text map[string]string) error {
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return fmt.Errorf("listen for mDNS: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	svc := &mdnsService{
+		instance: mdnsLabel(host),
+		host:     mdnsLabel(host),
+		port:     port,
+		text:     text,
+		ips:      localIPv4s,
+	}
+	typ := mdnsServiceName(service)
+
+	// Announce once so browsers that are already listening see us.
+	if resp, err := svc.response(0, typ); err == nil {
+		_, _ = conn.WriteToUDP(resp, mdnsGroup)
+	}
+
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			continue
+		}
+		id, ok := svc.matches(buf[:n], typ)
+		if !ok {
+			continue
+		}
+		// One-shot queries come from a port other than 5353 and get a
+		// unicast reply with the query's ID (RFC 6762 section 6.7).
+		if from.Port != mdnsGroup.Port {
+			if resp, err := svc.response(id, typ); err == nil {
+				_, _ = conn.WriteToUDP(resp, from)
+			}
+			continue
+		}
+		if resp, err := svc.response(0, typ); err == nil {
+			_, _ = conn.WriteToUDP(resp, mdnsGroup)
+		}
+	}
+}
+
+// matches reports whether a packet is a query for the service or this
+// instance, and returns its ID.
+func (s *mdnsService) matches(packet []byte, typ string) (uint16, bool) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(packet); err != nil || msg.Header.Response {
+		return 0, false
+	}
+	instance := strings.ToLower(s.instance + "." + typ)
+	host := strings.ToLower(s.host + "." + mdnsDomain)
+	for _, q := range msg.Questions {
+		switch strings.ToLower(q.Name.String()) {
+		case strings.ToLower(typ), instance, host:
+			return msg.Header.ID, true
+		}
+	}
+	return 0, false
+}
+
+// response builds the PTR, SRV, TXT, and A records for the instance.
+func (s *mdnsService) response(id uint16, typ string) ([]byte, error) {
+	typName, err := dnsmessage.NewName(typ)
+	if err != nil {
+		return nil, err
+	}
+	instName, err := dnsmessage.NewName(s.instance + "." + typ)
+	if err != nil {
+		return nil, err
+	}
+	hostName, err := dnsmessage.NewName(s.host + "." + mdnsDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	var txt []string
+	for k, v := range s.text {
+		txt = append(txt, k+"="+v)
+	}
+	if len(txt) == 0 {
+		txt = []string{""}
+	}
+
+	header := func(name dnsmessage.Name, typ dnsmessage.Type, flush bool) dnsmessage.ResourceHeader {
+		class := dnsmessage.ClassINET
+		if flush {
+			class |= mdnsCacheFlush
+		}
+		return dnsmessage.ResourceHeader{Name: name, Type: typ, Class: class, TTL: mdnsTTL}
+	}
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, Response: true, Authoritative: true},
+		Answers: []dnsmessage.Resource{
+			{Header: header(typName, dnsmessage.TypePTR, false), Body: &dnsmessage.PTRResource{PTR: instName}},
+			{Header: header(instName, dnsmessage.TypeSRV, true), Body: &dnsmessage.SRVResource{Target: hostName, Port: uint16(s.port)}},
+			{Header: header(instName, dnsmessage.TypeTXT, true), Body: &dnsmessage.TXTResource{TXT: txt}},
+		},
+	}
+	for _, ip := range s.ips() {
+		var a [4]byte
+		copy(a[:], ip.To4())
+		msg.Additionals = append(msg.Additionals, dnsmessage.Resource{
+			Header: header(hostName, dnsmessage.TypeA, true),
+			Body:   &dnsmessage.AResource{A: a},
+		})
+	}
+	return msg.Pack()
+}
+
+// BrowseMDNS asks the LAN for instances of service and collects the answers
+// that arrive within timeout.
+func BrowseMDNS(ctx context.Context, service string, timeout time.Duration) ([]MDNSEntry, error) {
+	typ := mdnsServiceName(service)
+	typName, err := dnsmessage.NewName(typ)
+	if err != nil {
+		return nil, err
+	}
+	query, err := (&dnsmessage.Message{
+		Questions: []dnsmessage.Question{{Name: typName, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("open mDNS socket: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+	if _, err := conn.WriteToUDP(query, mdnsGroup); err != nil {
+		return nil, fmt.Errorf("send mDNS query: %w", err)
+	}
+
+	found := make(map[string]*MDNSEntry)
+	var order []string
+	buf := make([]byte, 9000)
+	for ctx.Err() == nil {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break // Deadline reached.
+		}
+		for _, e := range parseMDNSResponse(buf[:n], typ, from.IP) {
+			key := strings.ToLower(e.Instance)
+			if _, seen := found[key]; !seen {
+				order = append(order, key)
+			}
+			entry := e
+			found[key] = &entry
+		}
+	}
+
+	entries := make([]MDNSEntry, 0, len(order))
+	for _, key := range order {
+		entries = append(entries, *found[key])
+	}
+	return entries, nil
+}
+
+// parseMDNSResponse extracts the instances of a service type from a
+// response. from is used as the address of hosts without an A record.
+func parseMDNSResponse(packet []byte, typ string, from net.IP) []MDNSEntry {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(packet); err != nil || !msg.Header.Response {
+		return nil
+	}
+
+	typ = strings.ToLower(typ)
+	type srv struct {
+		target string
+		port   int
+	}
+	var instances []string
+	srvs := make(map[string]srv)
+	texts := make(map[string]map[string]string)
+	addrs := make(map[string]net.IP)
+
+	records := append(append([]dnsmessage.Resource{}, msg.Answers...), msg.Additionals...)
+	for _, r := range records {
+		name := strings.ToLower(r.Header.Name.String())
+		switch body := r.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if name == typ {
+				instances = append(instances, strings.ToLower(body.PTR.String()))
+			}
+		case *dnsmessage.SRVResource:
+			srvs[name] = srv{target: strings.ToLower(body.Target.String()), port: int(body.Port)}
+		case *dnsmessage.TXTResource:
+			text := make(map[string]string)
+			for _, kv := range body.TXT {
+				if k, v, ok := strings.Cut(kv, "="); ok && k != "" {
+					text[k] = v
+				}
+			}
+			texts[name] = text
+		case *dnsmessage.AResource:
+			if _, ok := addrs[name]; !ok {
+				addrs[name] = net.IP(body.A[:])
+			}
+		}
+	}
+
+	var entries []MDNSEntry
+	for _, inst := range instances {
+		s, ok := srvs[inst]
+		if !ok {
+			continue
+		}
+		ip := addrs[s.target]
+		if ip == nil {
+			ip = from
+		}
+		entries = append(entries, MDNSEntry{
+			Instance: strings.TrimSuffix(inst, "."+typ),
+			Host:     strings.TrimSuffix(s.target, "."+mdnsDomain),
+			IP:       ip,
+			Port:     s.port,
+			Text:     texts[inst],
+		})
+	}
+	return entries
+}
+
+// localIPv4s returns the IPv4 addresses of the up, non-loopback interfaces
+// that support multicast.
+func localIPv4s() []net.IP {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+				ips = append(ips, ipnet.IP.To4())
+			}
+		}
+	}
+	return ips
+}
@@ -0,0 +1,325 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/tailscale"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/version"
+)
+
+// Machine sources for network discovery.
+const (
+	SourceMDNS      = "mdns"
+	SourceTailscale = "tailscale"
+)
+
+// A caam daemon with announcing enabled serves a small description of
+// itself, the beacon, over HTTP and advertises it on the LAN over mDNS, so
+// other machines can find it with 'caam sync discover --network'.
+const (
+	// BeaconService is the mDNS service type of the beacon.
+	BeaconService = "_caam._tcp"
+	// BeaconPath is the HTTP path the beacon is served on.
+	BeaconPath = "/caam/v1/beacon"
+	// DefaultBeaconPort is the port the beacon listens on by default, and
+	// the port probed on Tailscale peers.
+	DefaultBeaconPort = 7899
+)
+
+// BeaconInfo describes a caam instance to the machines that discover it.
+// It holds nothing secret: profile counts, not names or tokens.
+type BeaconInfo struct {
+	// MachineID is the instance's sync identity.
+	MachineID string `json:"machine_id"`
+	Hostname  string `json:"hostname"`
+	Version   string `json:"version"`
+	// SSHUser and SSHPort are how sync should log in to the machine.
+	SSHUser string `json:"ssh_user,omitempty"`
+	SSHPort int    `json:"ssh_port,omitempty"`
+	// Profiles counts vault profiles per provider.
+	Profiles map[string]int `json:"profiles"`
+}
+
+// TotalProfiles returns the number of vault profiles across providers.
+func (b BeaconInfo) TotalProfiles() int {
+	total := 0
+	for _, n := range b.Profiles {
+		total += n
+	}
+	return total
+}
+
+// LocalBeaconInfo describes this machine and its vault.
+func LocalBeaconInfo(vault *authfile.Vault) BeaconInfo {
+	info := BeaconInfo{
+		Version:  version.Short(),
+		SSHPort:  DefaultSSHPort,
+		Profiles: make(map[string]int),
+	}
+	if id, err := GetOrCreateLocalIdentity(); err == nil {
+		info.MachineID = id.ID
+	}
+	info.Hostname, _ = os.Hostname()
+	if u, err := user.Current(); err == nil {
+		info.SSHUser = u.Username
+	}
+	if vault != nil {
+		all, _ := vault.ListAll()
+		for provider, profiles := range all {
+			for _, profile := range profiles {
+				if !authfile.IsSystemProfile(profile) {
+					info.Profiles[provider]++
+				}
+			}
+		}
+	}
+	return info
+}
+
+// BeaconHandler serves the beacon returned by info.
+func BeaconHandler(info func() BeaconInfo) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info())
+	})
+}
+
+// AnnounceBeacon advertises a beacon listening on port over mDNS until ctx
+// is done.
+func AnnounceBeacon(ctx context.Context, port int, info BeaconInfo) error {
+	text := map[string]string{
+		"path":    BeaconPath,
+		"version": info.Version,
+		"id":      info.MachineID,
+	}
+	return ServeMDNS(ctx, BeaconService, info.Hostname, port, text)
+}
+
+// FetchBeacon reads the beacon of the caam instance at host:port.
+func FetchBeacon(ctx context.Context, client *http.Client, host string, port int) (*BeaconInfo, error) {
+	url := "http://" + net.JoinHostPort(host, strconv.Itoa(port)) + BeaconPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("beacon %s: %s", url, resp.Status)
+	}
+	var info BeaconInfo
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&info); err != nil {
+		return nil, fmt.Errorf("beacon %s: %w", url, err)
+	}
+	return &info, nil
+}
+
+// NetworkPeer is a caam instance found on the network.
+type NetworkPeer struct {
+	BeaconInfo
+	// Address is the IP sync should connect to.
+	Address string `json:"address"`
+	// Source is where the peer was found: SourceMDNS or SourceTailscale.
+	Source string `json:"source"`
+}
+
+// Machine returns a pool machine for the peer.
+func (p *NetworkPeer) Machine() *Machine {
+	name := p.Hostname
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		name = p.Address
+	}
+	m := NewMachine(name, p.Address)
+	if p.SSHPort > 0 {
+		m.Port = p.SSHPort
+	}
+	m.SSHUser = p.SSHUser
+	m.Source = p.Source
+	return m
+}
+
+// NetworkDiscoveryOptions configures DiscoverNetwork.
+type NetworkDiscoveryOptions struct {
+	// MDNS browses the LAN. Default when neither is set: both.
+	MDNS bool
+	// Tailscale probes online tailnet peers.
+	Tailscale bool
+
+	// Timeout bounds the mDNS browse and each beacon request.
+	// Default: 3s
+	Timeout time.Duration
+
+	// BeaconPort is probed on Tailscale peers. Default: DefaultBeaconPort.
+	BeaconPort int
+
+	// Tailnet returns the tailnet status. Default: the Tailscale client.
+	Tailnet func(ctx context.Context) (*tailscale.Status, error)
+
+	// Browse browses mDNS. Default: BrowseMDNS.
+	Browse func(ctx context.Context, service string, timeout time.Duration) ([]MDNSEntry, error)
+
+	// HTTPClient fetches beacons. Default: a client with Timeout.
+	HTTPClient *http.Client
+}
+
+// NetworkDiscoveryResult is what DiscoverNetwork found.
+type NetworkDiscoveryResult struct {
+	Peers []*NetworkPeer
+	// Warnings are non-fatal problems, e.g. Tailscale not running.
+	Warnings []string
+}
+
+// DiscoverNetwork finds other caam instances on the LAN over mDNS and on the
+// tailnet by probing each online peer's beacon port. Instances without
+// announcing enabled aren't found. This machine is left out, and a machine
+// found both ways is listed once, by its Tailscale address.
+func DiscoverNetwork(ctx context.Context, opts NetworkDiscoveryOptions) *NetworkDiscoveryResult {
+	if !opts.MDNS && !opts.Tailscale {
+		opts.MDNS, opts.Tailscale = true, true
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 3 * time.Second
+	}
+	if opts.BeaconPort <= 0 {
+		opts.BeaconPort = DefaultBeaconPort
+	}
+	if opts.Tailnet == nil {
+		opts.Tailnet = tailscale.NewClient().GetStatus
+	}
+	if opts.Browse == nil {
+		opts.Browse = BrowseMDNS
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: opts.Timeout}
+	}
+
+	result := &NetworkDiscoveryResult{}
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		peers []*NetworkPeer
+	)
+	add := func(p *NetworkPeer) {
+		mu.Lock()
+		peers = append(peers, p)
+		mu.Unlock()
+	}
+	warn := func(format string, args ...interface{}) {
+		mu.Lock()
+		result.Warnings = append(result.Warnings, fmt.Sprintf(format, args...))
+		mu.Unlock()
+	}
+	probe := func(host string, port int, source string) {
+		defer wg.Done()
+		info, err := FetchBeacon(ctx, opts.HTTPClient, host, port)
+		if err != nil {
+			if source == SourceMDNS {
+				warn("%s: %v", host, err)
+			}
+			return // Most tailnet peers don't run caam.
+		}
+		add(&NetworkPeer{BeaconInfo: *info, Address: host, Source: source})
+	}
+
+	if opts.MDNS {
+		// Browsing takes the whole timeout; probe the tailnet meanwhile.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entries, err := opts.Browse(ctx, BeaconService, opts.Timeout)
+			if err != nil {
+				warn("mDNS: %v", err)
+			}
+			for _, e := range entries {
+				if e.IP == nil || e.Port == 0 {
+					continue
+				}
+				wg.Add(1)
+				go probe(e.IP.String(), e.Port, SourceMDNS)
+			}
+		}()
+	}
+
+	if opts.Tailscale {
+		status, err := opts.Tailnet(ctx)
+		switch {
+		case err != nil:
+			warn("Tailscale: %v", err)
+		case !status.IsRunning():
+			warn("Tailscale: not running (state %q)", status.BackendState)
+		default:
+			for _, peer := range status.Peer {
+				ip := peer.GetIPv4()
+				if !peer.Online || ip == "" {
+					continue
+				}
+				wg.Add(1)
+				go probe(ip, opts.BeaconPort, SourceTailscale)
+			}
+		}
+	}
+	wg.Wait()
+
+	result.Peers = dedupePeers(peers)
+	sort.Strings(result.Warnings)
+	return result
+}
+
+// dedupePeers drops this machine and lists each other machine once,
+// preferring its Tailscale address, sorted by hostname.
+func dedupePeers(peers []*NetworkPeer) []*NetworkPeer {
+	selfID := ""
+	if id, err := LoadLocalIdentity(); err == nil && id != nil {
+		selfID = id.ID
+	}
+
+	byKey := make(map[string]*NetworkPeer)
+	for _, p := range peers {
+		if selfID != "" && p.MachineID == selfID {
+			continue
+		}
+		key := p.MachineID
+		if key == "" {
+			key = p.Address
+		}
+		if existing, ok := byKey[key]; ok && existing.Source == SourceTailscale {
+			continue
+		}
+		byKey[key] = p
+	}
+
+	result := make([]*NetworkPeer, 0, len(byKey))
+	for _, p := range byKey {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Hostname != result[j].Hostname {
+			return result[i].Hostname < result[j].Hostname
+		}
+		return result[i].Address < result[j].Address
+	})
+	return result
+}
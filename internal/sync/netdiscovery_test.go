@@ -0,0 +1,177 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/tailscale"
+)
+
+func TestMDNSResponseRoundTrip(t *testing.T) {
+	svc := &mdnsService{
+		instance: mdnsLabel("work.example.com"),
+		host:     mdnsLabel("work.example.com"),
+		port:     7899,
+		text:     map[string]string{"version": "1.2.3", "id": "abc"},
+		ips:      func() []net.IP { return []net.IP{net.IPv4(192, 168, 1, 20)} },
+	}
+	typ := mdnsServiceName(BeaconService)
+
+	resp, err := svc.response(42, typ)
+	if err != nil {
+		t.Fatalf("response: %v", err)
+	}
+	entries := parseMDNSResponse(resp, typ, net.IPv4(10, 0, 0, 1))
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Instance != "work-example-com" || e.Host != "work-example-com" {
+		t.Errorf("instance/host = %q/%q", e.Instance, e.Host)
+	}
+	if !e.IP.Equal(net.IPv4(192, 168, 1, 20)) {
+		t.Errorf("IP = %v, want A record address", e.IP)
+	}
+	if e.Port != 7899 || e.Text["version"] != "1.2.3" || e.Text["id"] != "abc" {
+		t.Errorf("entry = %+v", e)
+	}
+
+	// Other services' responses are ignored.
+	if got := parseMDNSResponse(resp, mdnsServiceName("_other._tcp"), nil); len(got) != 0 {
+		t.Errorf("parsed %d entries for another service", len(got))
+	}
+}
+
+func TestMDNSServiceMatchesQueries(t *testing.T) {
+	svc := &mdnsService{instance: "work", host: "work", port: 7899, ips: func() []net.IP { return nil }}
+	typ := mdnsServiceName(BeaconService)
+
+	resp, err := svc.response(0, typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := svc.matches(resp, typ); ok {
+		t.Error("a response was treated as a query")
+	}
+
+	// Without an A record, the sender's address is used.
+	entries := parseMDNSResponse(resp, typ, net.IPv4(10, 0, 0, 7))
+	if len(entries) != 1 || !entries[0].IP.Equal(net.IPv4(10, 0, 0, 7)) {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+// beaconServer serves info as a beacon and returns its host and port.
+func beaconServer(t *testing.T, info BeaconInfo) (string, int) {
+	t.Helper()
+	srv := httptest.NewServer(BeaconHandler(func() BeaconInfo { return info }))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	return u.Hostname(), port
+}
+
+func TestDiscoverNetwork(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+
+	lanHost, lanPort := beaconServer(t, BeaconInfo{
+		MachineID: "lan-1", Hostname: "desk", Version: "1.0.0",
+		Profiles: map[string]int{"claude": 2},
+	})
+	_, tsPort := beaconServer(t, BeaconInfo{
+		MachineID: "ts-1", Hostname: "cloud.example.com", Version: "1.1.0", SSHUser: "ubuntu", SSHPort: 22,
+		Profiles: map[string]int{"codex": 1},
+	})
+
+	result := DiscoverNetwork(context.Background(), NetworkDiscoveryOptions{
+		Timeout:    time.Second,
+		BeaconPort: tsPort,
+		Browse: func(ctx context.Context, service string, timeout time.Duration) ([]MDNSEntry, error) {
+			if service != BeaconService {
+				t.Errorf("browsed %q", service)
+			}
+			return []MDNSEntry{{Instance: "desk", IP: net.ParseIP(lanHost), Port: lanPort}}, nil
+		},
+		Tailnet: func(ctx context.Context) (*tailscale.Status, error) {
+			return &tailscale.Status{
+				BackendState: "Running",
+				Peer: map[string]*tailscale.Peer{
+					"a": {HostName: "cloud", TailscaleIPs: []string{"127.0.0.1"}, Online: true},
+					"b": {HostName: "asleep", TailscaleIPs: []string{"127.0.0.2"}, Online: false},
+				},
+			}, nil
+		},
+	})
+
+	if len(result.Peers) != 2 {
+		t.Fatalf("got %d peers (%+v), want 2", len(result.Peers), result.Peers)
+	}
+	cloud, desk := result.Peers[0], result.Peers[1]
+	if cloud.Source != SourceTailscale || cloud.Version != "1.1.0" || cloud.Profiles["codex"] != 1 {
+		t.Errorf("tailscale peer = %+v", cloud)
+	}
+	if desk.Source != SourceMDNS || desk.TotalProfiles() != 2 {
+		t.Errorf("mDNS peer = %+v", desk)
+	}
+
+	m := cloud.Machine()
+	if m.Name != "cloud" || m.Address != "127.0.0.1" || m.SSHUser != "ubuntu" || m.Source != SourceTailscale {
+		t.Errorf("machine = %+v", m)
+	}
+}
+
+func TestDiscoverNetworkWarnsWhenTailscaleIsDown(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+
+	result := DiscoverNetwork(context.Background(), NetworkDiscoveryOptions{
+		Tailscale: true,
+		Tailnet: func(ctx context.Context) (*tailscale.Status, error) {
+			return nil, fmt.Errorf("tailscale status: not installed")
+		},
+	})
+	if len(result.Peers) != 0 || len(result.Warnings) != 1 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestDedupePeersPrefersTailscaleAndSkipsSelf(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	self, err := GetOrCreateLocalIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peers := dedupePeers([]*NetworkPeer{
+		{BeaconInfo: BeaconInfo{MachineID: "m1", Hostname: "a"}, Address: "100.64.0.1", Source: SourceTailscale},
+		{BeaconInfo: BeaconInfo{MachineID: "m1", Hostname: "a"}, Address: "192.168.1.5", Source: SourceMDNS},
+		{BeaconInfo: BeaconInfo{MachineID: self.ID, Hostname: "me"}, Address: "192.168.1.2", Source: SourceMDNS},
+	})
+	if len(peers) != 1 || peers[0].Address != "100.64.0.1" {
+		t.Errorf("peers = %+v", peers)
+	}
+}
+
+func TestBeaconHandlerRejectsWrites(t *testing.T) {
+	h := BeaconHandler(func() BeaconInfo { return BeaconInfo{Hostname: "x"} })
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, BeaconPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, BeaconPath, nil))
+	var info BeaconInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil || info.Hostname != "x" {
+		t.Errorf("GET body = %s (%v)", rec.Body.String(), err)
+	}
+}
@@ -0,0 +1,778 @@
+package sync
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+	"golang.org/x/crypto/ssh"
+)
+
+// Pool encryption.
+//
+// In an encrypted pool, profiles are sealed with a pool-shared key before
+// they're written to another machine, so SSH peers, hubs, and backup hosts
+// store only ciphertext. Members decrypt transparently when they read their
+// vault (see vaultcrypt.PoolOpener); machines without the key never can.
+//
+// Every machine has an X25519 exchange key. The pool key is sent to a peer
+// wrapped for the peer's exchange public key: an ephemeral X25519 key pair
+// is combined with the peer's public key, and the shared secret, through
+// HKDF, seals the pool key. The issuer signs the wrapped key with its SSH
+// key, like a wipe order, and drops it into the peer's incoming_pool_keys/
+// directory. The peer accepts it the next time it needs the keyring, but
+// only if the signature verifies against its trusted keys, so a hub that
+// can write to the directory can't plant a key of its own. 'caam sync
+// rekey' rotates the key the same way.
+
+const (
+	poolKeysFileName        = "pool_keys.json"
+	exchangeKeyFileName     = "exchange_key"
+	exchangePubKeyFileName  = "exchange_key.pub"
+	incomingPoolKeysDirName = "incoming_pool_keys"
+)
+
+// poolKeyIDSize is the length of the key ID in sealed files.
+const poolKeyIDSize = 8
+
+// poolKeyInfo is the HKDF info for wrapping pool keys.
+const poolKeyInfo = "caam pool key v1"
+
+// PoolKey is one pool encryption key.
+type PoolKey struct {
+	// ID is the hex SHA-256 prefix of the key; sealed files name it.
+	ID        string    `json:"id"`
+	Key       []byte    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+	// From names the machine the key was received from, if any.
+	From string `json:"from,omitempty"`
+}
+
+// PoolKeyring holds the current pool key and the keys it replaced, which
+// are kept to read files sealed before a rekey.
+type PoolKeyring struct {
+	// Current is the ID of the key new files are sealed with. Empty means
+	// encryption is off.
+	Current string     `json:"current,omitempty"`
+	Keys    []*PoolKey `json:"keys"`
+	// Disabled is set by 'caam sync encrypt --disable'. Keys delivered
+	// afterwards are kept but don't turn sealing back on.
+	Disabled bool `json:"disabled,omitempty"`
+
+	path string
+}
+
+// ErrNoPoolKey is returned when a file is sealed with a key this machine
+// doesn't have.
+type ErrNoPoolKey struct {
+	KeyID string
+}
+
+func (e *ErrNoPoolKey) Error() string {
+	return fmt.Sprintf("sealed with sync pool key %s, which this machine doesn't have; run 'caam sync rekey' on a machine that does", e.KeyID)
+}
+
+var poolKeyMu sync.Mutex
+
+func init() {
+	vaultcrypt.PoolOpener = openPoolSealedFile
+}
+
+// PoolKeysPath is where this machine's pool keyring is stored.
+func PoolKeysPath() string {
+	return filepath.Join(SyncDataDir(), poolKeysFileName)
+}
+
+func incomingPoolKeysDir() string {
+	return filepath.Join(SyncDataDir(), incomingPoolKeysDirName)
+}
+
+// LoadPoolKeyring loads the keyring, first accepting any pool keys peers
+// delivered since it was last loaded.
+func LoadPoolKeyring() (*PoolKeyring, error) {
+	poolKeyMu.Lock()
+	defer poolKeyMu.Unlock()
+
+	kr := &PoolKeyring{path: PoolKeysPath()}
+	data, err := os.ReadFile(kr.path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, kr); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", kr.path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("read pool keys: %w", err)
+	}
+
+	if accepted, err := kr.acceptIncoming(); err != nil {
+		return nil, err
+	} else if accepted {
+		if err := kr.save(); err != nil {
+			return nil, err
+		}
+	}
+	return kr, nil
+}
+
+// Enabled reports whether new files are sealed.
+func (kr *PoolKeyring) Enabled() bool {
+	return kr.CurrentKey() != nil
+}
+
+// CurrentKey returns the key new files are sealed with, or nil.
+func (kr *PoolKeyring) CurrentKey() *PoolKey {
+	if kr.Current == "" {
+		return nil
+	}
+	return kr.key(kr.Current)
+}
+
+func (kr *PoolKeyring) key(id string) *PoolKey {
+	for _, k := range kr.Keys {
+		if k.ID == id {
+			return k
+		}
+	}
+	return nil
+}
+
+// add stores a key, making it current if it is newer than the current one
+// and encryption hasn't been disabled. It reports whether the keyring
+// changed.
+func (kr *PoolKeyring) add(k *PoolKey) bool {
+	if kr.key(k.ID) != nil {
+		return false
+	}
+	kr.Keys = append(kr.Keys, k)
+	sort.Slice(kr.Keys, func(i, j int) bool { return kr.Keys[i].CreatedAt.Before(kr.Keys[j].CreatedAt) })
+	if kr.Disabled {
+		return true
+	}
+	if cur := kr.CurrentKey(); cur == nil || k.CreatedAt.After(cur.CreatedAt) {
+		kr.Current = k.ID
+	}
+	return true
+}
+
+// Generate creates a new pool key and makes it current.
+func (kr *PoolKeyring) Generate() (*PoolKey, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate pool key: %w", err)
+	}
+	k := &PoolKey{ID: poolKeyID(key), Key: key, CreatedAt: time.Now().UTC()}
+	kr.Keys = append(kr.Keys, k)
+	kr.Current = k.ID
+	kr.Disabled = false
+	return k, nil
+}
+
+// Disable stops sealing new files on this machine until encryption is
+// enabled here again. Old keys are kept so sealed files stay readable.
+func (kr *PoolKeyring) Disable() {
+	kr.Current = ""
+	kr.Disabled = true
+}
+
+// Save writes the keyring.
+func (kr *PoolKeyring) Save() error {
+	poolKeyMu.Lock()
+	defer poolKeyMu.Unlock()
+	return kr.save()
+}
+
+func (kr *PoolKeyring) save() error {
+	if kr.path == "" {
+		kr.path = PoolKeysPath()
+	}
+	data, err := json.MarshalIndent(kr, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(kr.path), 0700); err != nil {
+		return fmt.Errorf("create sync dir: %w", err)
+	}
+	return atomicWriteFile(kr.path, data, 0600)
+}
+
+func poolKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:poolKeyIDSize])
+}
+
+// ============================================================================
+// Sealing
+// ============================================================================
+
+// poolFileAD binds a sealed file to its place in the vault, so a peer can't
+// swap files between profiles.
+func poolFileAD(keyID []byte, provider, profile, name string) []byte {
+	ad := append(append([]byte{}, vaultcrypt.PoolMagic...), keyID...)
+	return append(ad, path.Join(provider, profile, name)...)
+}
+
+// Seal encrypts a vault file with the current key.
+func (kr *PoolKeyring) Seal(provider, profile, name string, plaintext []byte) ([]byte, error) {
+	k := kr.CurrentKey()
+	if k == nil {
+		return nil, fmt.Errorf("sync pool encryption is off")
+	}
+	id, _ := hex.DecodeString(k.ID)
+	aead, err := newPoolAEAD(k.Key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	out := append(append([]byte{}, vaultcrypt.PoolMagic...), id...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, poolFileAD(id, provider, profile, name)), nil
+}
+
+// Open decrypts a vault file sealed with any key in the keyring.
+func (kr *PoolKeyring) Open(provider, profile, name string, data []byte) ([]byte, error) {
+	if !vaultcrypt.IsPoolSealed(data) {
+		return nil, fmt.Errorf("%s/%s/%s is not sealed with a pool key", provider, profile, name)
+	}
+	rest := data[len(vaultcrypt.PoolMagic):]
+	if len(rest) < poolKeyIDSize {
+		return nil, fmt.Errorf("%s/%s/%s is truncated", provider, profile, name)
+	}
+	id := rest[:poolKeyIDSize]
+	k := kr.key(hex.EncodeToString(id))
+	if k == nil {
+		return nil, &ErrNoPoolKey{KeyID: hex.EncodeToString(id)}
+	}
+	aead, err := newPoolAEAD(k.Key)
+	if err != nil {
+		return nil, err
+	}
+	rest = rest[poolKeyIDSize:]
+	if len(rest) < aead.NonceSize() {
+		return nil, fmt.Errorf("%s/%s/%s is truncated", provider, profile, name)
+	}
+	n := aead.NonceSize()
+	plain, err := aead.Open(nil, rest[:n], rest[n:], poolFileAD(id, provider, profile, name))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s/%s/%s: %w", provider, profile, name, err)
+	}
+	return plain, nil
+}
+
+// openPoolSealedFile is vaultcrypt.PoolOpener: vault files live at
+// <vault>/<provider>/<profile>/<name>.
+func openPoolSealedFile(filePath string, data []byte) ([]byte, error) {
+	kr, err := LoadPoolKeyring()
+	if err != nil {
+		return nil, err
+	}
+	profileDir := filepath.Dir(filePath)
+	plain, err := kr.Open(filepath.Base(filepath.Dir(profileDir)), filepath.Base(profileDir), filepath.Base(filePath), data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return plain, nil
+}
+
+func newPoolAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// ============================================================================
+// Key exchange
+// ============================================================================
+
+// ExchangeKeyPath is this machine's X25519 private key, used to receive
+// pool keys.
+func ExchangeKeyPath() string {
+	return filepath.Join(SyncDataDir(), exchangeKeyFileName)
+}
+
+// exchangePubKeyPath is the public half, read by peers over SSH.
+func exchangePubKeyPath() string {
+	return filepath.Join(SyncDataDir(), exchangePubKeyFileName)
+}
+
+// LoadOrCreateExchangeKey returns this machine's X25519 exchange key,
+// creating it and its public key file on first use.
+func LoadOrCreateExchangeKey() (*ecdh.PrivateKey, error) {
+	data, err := os.ReadFile(ExchangeKeyPath())
+	if err == nil {
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("parse exchange key: %w", err)
+		}
+		priv, err := ecdh.X25519().NewPrivateKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parse exchange key: %w", err)
+		}
+		if _, err := os.Stat(exchangePubKeyPath()); os.IsNotExist(err) {
+			_ = writeExchangePubKey(priv.PublicKey())
+		}
+		return priv, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read exchange key: %w", err)
+	}
+
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate exchange key: %w", err)
+	}
+	if err := os.MkdirAll(SyncDataDir(), 0700); err != nil {
+		return nil, fmt.Errorf("create sync dir: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(priv.Bytes()) + "\n"
+	if err := atomicWriteFile(ExchangeKeyPath(), []byte(encoded), 0600); err != nil {
+		return nil, fmt.Errorf("write exchange key: %w", err)
+	}
+	if err := writeExchangePubKey(priv.PublicKey()); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+func writeExchangePubKey(pub *ecdh.PublicKey) error {
+	encoded := base64.StdEncoding.EncodeToString(pub.Bytes()) + "\n"
+	if err := atomicWriteFile(exchangePubKeyPath(), []byte(encoded), 0644); err != nil {
+		return fmt.Errorf("write exchange public key: %w", err)
+	}
+	return nil
+}
+
+// parseExchangePubKey parses the contents of an exchange_key.pub file.
+func parseExchangePubKey(data []byte) (*ecdh.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("parse exchange public key: %w", err)
+	}
+	return ecdh.X25519().NewPublicKey(raw)
+}
+
+// wrappedPoolKey is a pool key sealed for one recipient.
+type wrappedPoolKey struct {
+	KeyID      string    `json:"key_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	IssuerID   string    `json:"issuer_id"`
+	IssuerHost string    `json:"issuer_host"`
+	// Recipient is the exchange public key the key is wrapped for.
+	Recipient string `json:"recipient"`
+	Ephemeral string `json:"ephemeral"`
+	Nonce     string `json:"nonce"`
+	Sealed    string `json:"sealed"`
+
+	// SigningKey is the issuer's SSH public key (authorized_keys format).
+	SigningKey string `json:"signing_key"`
+	// Signature is the base64 SSH signature over the key without it.
+	Signature string `json:"signature,omitempty"`
+}
+
+// signedPayload returns the bytes covered by the signature.
+func (w *wrappedPoolKey) signedPayload() ([]byte, error) {
+	unsigned := *w
+	unsigned.Signature = ""
+	return json.Marshal(&unsigned)
+}
+
+// sign signs the wrapped key with signer, recording its public key.
+func (w *wrappedPoolKey) sign(signer ssh.Signer) error {
+	w.SigningKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	payload, err := w.signedPayload()
+	if err != nil {
+		return err
+	}
+	if w.Signature, err = signPayload(signer, payload); err != nil {
+		return fmt.Errorf("sign pool key: %w", err)
+	}
+	return nil
+}
+
+// verify checks that the wrapped key is signed by one of the trusted keys.
+func (w *wrappedPoolKey) verify(trusted []ssh.PublicKey) error {
+	payload, err := w.signedPayload()
+	if err != nil {
+		return err
+	}
+	return verifySignature("pool key "+w.KeyID, w.SigningKey, w.Signature, payload, trusted)
+}
+
+// poolKeySigner loads the SSH key pool keys are signed with; tests replace
+// it.
+var poolKeySigner = func() (ssh.Signer, error) { return LoadSigningKey("") }
+
+// fileName is the name the wrapped key is delivered under.
+func (w *wrappedPoolKey) fileName() string {
+	issuer := w.IssuerID
+	if issuer == "" {
+		issuer = "unknown"
+	}
+	return issuer + "-" + w.KeyID + ".json"
+}
+
+// wrapKEK derives the key that wraps a pool key from an X25519 shared
+// secret.
+func wrapKEK(shared, ephemeral, recipient []byte) ([]byte, error) {
+	salt := append(append([]byte{}, ephemeral...), recipient...)
+	return hkdf.Key(sha256.New, shared, salt, poolKeyInfo, 32)
+}
+
+// wrapPoolKey seals k for the holder of recipient's private key.
+func wrapPoolKey(k *PoolKey, recipient *ecdh.PublicKey, issuer *LocalIdentity) (*wrappedPoolKey, error) {
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate ephemeral key: %w", err)
+	}
+	shared, err := eph.ECDH(recipient)
+	if err != nil {
+		return nil, fmt.Errorf("key exchange: %w", err)
+	}
+	kek, err := wrapKEK(shared, eph.PublicKey().Bytes(), recipient.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newPoolAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	w := &wrappedPoolKey{
+		KeyID:     k.ID,
+		CreatedAt: k.CreatedAt,
+		Recipient: base64.StdEncoding.EncodeToString(recipient.Bytes()),
+		Ephemeral: base64.StdEncoding.EncodeToString(eph.PublicKey().Bytes()),
+		Nonce:     base64.StdEncoding.EncodeToString(nonce),
+	}
+	if issuer != nil {
+		w.IssuerID = issuer.ID
+		w.IssuerHost = issuer.Hostname
+	}
+	w.Sealed = base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, k.Key, []byte(w.KeyID)))
+	return w, nil
+}
+
+// unwrap opens a wrapped key with this machine's exchange key.
+func (w *wrappedPoolKey) unwrap(priv *ecdh.PrivateKey) (*PoolKey, error) {
+	if w.Recipient != base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes()) {
+		return nil, fmt.Errorf("pool key %s is wrapped for another exchange key", w.KeyID)
+	}
+	ephRaw, err := base64.StdEncoding.DecodeString(w.Ephemeral)
+	if err != nil {
+		return nil, fmt.Errorf("parse ephemeral key: %w", err)
+	}
+	eph, err := ecdh.X25519().NewPublicKey(ephRaw)
+	if err != nil {
+		return nil, fmt.Errorf("parse ephemeral key: %w", err)
+	}
+	shared, err := priv.ECDH(eph)
+	if err != nil {
+		return nil, fmt.Errorf("key exchange: %w", err)
+	}
+	kek, err := wrapKEK(shared, ephRaw, priv.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newPoolAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(w.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("pool key %s has a bad nonce", w.KeyID)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(w.Sealed)
+	if err != nil {
+		return nil, fmt.Errorf("parse wrapped key: %w", err)
+	}
+	key, err := aead.Open(nil, nonce, sealed, []byte(w.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap pool key %s: %w", w.KeyID, err)
+	}
+	if poolKeyID(key) != w.KeyID {
+		return nil, fmt.Errorf("pool key %s doesn't match its ID", w.KeyID)
+	}
+	return &PoolKey{ID: w.KeyID, Key: key, CreatedAt: w.CreatedAt, From: w.IssuerHost}, nil
+}
+
+// acceptIncoming unwraps the pool keys peers delivered and removes the
+// delivered files. Keys that aren't signed by a trusted key or can't be
+// unwrapped are left for inspection.
+func (kr *PoolKeyring) acceptIncoming() (bool, error) {
+	entries, err := os.ReadDir(incomingPoolKeysDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("read incoming pool keys: %w", err)
+	}
+	if len(entries) == 0 {
+		return false, nil
+	}
+	priv, err := LoadOrCreateExchangeKey()
+	if err != nil {
+		return false, err
+	}
+	trusted, err := TrustedKeys()
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		p := filepath.Join(incomingPoolKeysDir(), e.Name())
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		var w wrappedPoolKey
+		if err := json.Unmarshal(data, &w); err != nil {
+			continue
+		}
+		if err := w.verify(trusted); err != nil {
+			continue
+		}
+		k, err := w.unwrap(priv)
+		if err != nil {
+			continue
+		}
+		if kr.add(k) {
+			changed = true
+		}
+		_ = os.Remove(p)
+	}
+	return changed, nil
+}
+
+// ============================================================================
+// Distribution
+// ============================================================================
+
+// PoolKeyDelivery reports how the current pool key reached one machine.
+type PoolKeyDelivery struct {
+	Machine string `json:"machine"`
+	KeyID   string `json:"key_id"`
+	Error   string `json:"error,omitempty"`
+}
+
+// deliverPoolKey wraps k for m's exchange key and drops it into m's
+// incoming_pool_keys directory.
+func (s *Syncer) deliverPoolKey(client RemoteFS, k *PoolKey, issuer *LocalIdentity) error {
+	m := client.Machine()
+	if m.TransportName() != TransportSSH {
+		return nil // HTTPS endpoints are sealed with the sync passphrase.
+	}
+	data, err := client.ReadFile(posixJoin(s.remoteSyncDir(), exchangePubKeyFileName))
+	if err != nil {
+		return fmt.Errorf("%s has no exchange key yet; run any caam sync command there once, then 'caam sync rekey'", m.Name)
+	}
+	pub, err := parseExchangePubKey(data)
+	if err != nil {
+		return err
+	}
+	w, err := wrapPoolKey(k, pub, issuer)
+	if err != nil {
+		return err
+	}
+	signer, err := poolKeySigner()
+	if err != nil {
+		return err
+	}
+	if err := w.sign(signer); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := client.WriteFile(posixJoin(s.remoteSyncDir(), incomingPoolKeysDirName, w.fileName()), out, 0600); err != nil {
+		return fmt.Errorf("deliver pool key: %w", err)
+	}
+	m.PoolKeyID = k.ID
+	return nil
+}
+
+// sharePoolKey delivers the current pool key to a peer that doesn't have
+// it yet, so machines added after encryption was enabled receive it on
+// their first sync.
+func (s *Syncer) sharePoolKey(client RemoteFS) {
+	if s.keyring == nil || !s.keyring.Enabled() {
+		return
+	}
+	k := s.keyring.CurrentKey()
+	if client.Machine().PoolKeyID == k.ID {
+		return
+	}
+	issuer, _ := GetOrCreateLocalIdentity()
+	_ = s.deliverPoolKey(client, k, issuer)
+}
+
+// EnablePoolEncryption creates a pool key if there is none and delivers the
+// current key to every SSH machine in the pool.
+func (s *Syncer) EnablePoolEncryption() ([]PoolKeyDelivery, error) {
+	kr, err := s.poolKeyring()
+	if err != nil {
+		return nil, err
+	}
+	if !kr.Enabled() {
+		kr.Disabled = false
+		if cur := newestKey(kr); cur != nil {
+			kr.Current = cur.ID
+		} else if _, err := kr.Generate(); err != nil {
+			return nil, err
+		}
+		if err := kr.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return s.distributePoolKey(kr.CurrentKey(), false), nil
+}
+
+// Rekey replaces the pool key with a new one, delivers it to every SSH
+// machine in the pool, and pushes every local profile to each machine it
+// reached so their copies are sealed with the new key. Old keys are kept to
+// read files that weren't rewritten. Machines removed from the pool never
+// receive the new key.
+func (s *Syncer) Rekey() ([]PoolKeyDelivery, error) {
+	kr, err := s.poolKeyring()
+	if err != nil {
+		return nil, err
+	}
+	k, err := kr.Generate()
+	if err != nil {
+		return nil, err
+	}
+	if err := kr.Save(); err != nil {
+		return nil, err
+	}
+	return s.distributePoolKey(k, true), nil
+}
+
+func newestKey(kr *PoolKeyring) *PoolKey {
+	if len(kr.Keys) == 0 {
+		return nil
+	}
+	return kr.Keys[len(kr.Keys)-1]
+}
+
+// poolKeyring returns the Syncer's keyring, loading it if needed.
+func (s *Syncer) poolKeyring() (*PoolKeyring, error) {
+	if s.keyring == nil {
+		kr, err := LoadPoolKeyring()
+		if err != nil {
+			return nil, err
+		}
+		s.keyring = kr
+	}
+	return s.keyring, nil
+}
+
+// distributePoolKey delivers k to every SSH machine in the pool, and with
+// repush rewrites each machine's copy of every local profile.
+func (s *Syncer) distributePoolKey(k *PoolKey, repush bool) []PoolKeyDelivery {
+	if s.state.Pool == nil {
+		return nil
+	}
+	issuer, _ := GetOrCreateLocalIdentity()
+	profiles, _ := s.listLocalProfiles()
+
+	var deliveries []PoolKeyDelivery
+	for _, m := range s.state.Pool.ListMachines() {
+		if m.TransportName() != TransportSSH || PendingWipeOrder(m) != nil {
+			continue
+		}
+		d := PoolKeyDelivery{Machine: m.Name, KeyID: k.ID}
+		client, err := s.pool.Get(m)
+		if err == nil {
+			err = s.deliverPoolKey(client, k, issuer)
+		}
+		if err == nil && repush {
+			for _, p := range profiles {
+				if _, err = s.pushProfile(client, p.Provider, p.Profile); err != nil {
+					err = fmt.Errorf("reseal %s/%s: %w", p.Provider, p.Profile, err)
+					break
+				}
+			}
+		}
+		if err != nil {
+			d.Error = err.Error()
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries
+}
+
+// sealForPush returns a local vault file as it should be written to a
+// peer: sealed with the current pool key when encryption is on and the peer
+// is reached over SSH. Otherwise files encrypted with this machine's vault
+// key, which no other machine can open, are decrypted and the rest sent
+// unchanged. Files this machine can't open are relayed as they are.
+func (s *Syncer) sealForPush(client RemoteFS, provider, profile, name, localPath string, raw []byte) ([]byte, error) {
+	if s.keyring == nil || !s.keyring.Enabled() || client.Machine().TransportName() != TransportSSH {
+		if !vaultcrypt.IsEncrypted(raw) {
+			return raw, nil
+		}
+		return vaultcrypt.ReadFile(localPath)
+	}
+	plain := raw
+	if vaultcrypt.IsEncrypted(raw) || vaultcrypt.IsPoolSealed(raw) {
+		var err error
+		if plain, err = vaultcrypt.ReadFile(localPath); err != nil {
+			var noKey *ErrNoPoolKey
+			if errors.As(err, &noKey) {
+				return raw, nil
+			}
+			return nil, err
+		}
+	}
+	return s.keyring.Seal(provider, profile, name, plain)
+}
+
+// openPulled decrypts a file read from a peer if it is pool-sealed. Files
+// sealed with a key this machine doesn't have are returned unchanged, so a
+// hub without the key relays ciphertext.
+func (s *Syncer) openPulled(provider, profile, name string, data []byte) ([]byte, error) {
+	if !vaultcrypt.IsPoolSealed(data) {
+		return data, nil
+	}
+	kr, err := s.poolKeyring()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := kr.Open(provider, profile, name, data)
+	if err != nil {
+		var noKey *ErrNoPoolKey
+		if errors.As(err, &noKey) {
+			return data, nil
+		}
+		return nil, err
+	}
+	return plain, nil
+}
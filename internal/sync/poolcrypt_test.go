@@ -0,0 +1,359 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshDirFS is a dirFS that reports an SSH machine.
+type sshDirFS struct {
+	dirFS
+	m *Machine
+}
+
+func (d sshDirFS) Machine() *Machine { return d.m }
+
+func TestPoolSealOpen(t *testing.T) {
+	kr := &PoolKeyring{}
+	if _, err := kr.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	plain := []byte(`{"accessToken":"secret"}`)
+
+	sealed, err := kr.Seal("claude", "work", ".credentials.json", plain)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if !vaultcrypt.IsPoolSealed(sealed) || bytes.Contains(sealed, []byte("secret")) {
+		t.Fatalf("sealed data isn't opaque: %q", sealed)
+	}
+
+	got, err := kr.Open("claude", "work", ".credentials.json", sealed)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("Open() = %q, %v", got, err)
+	}
+	if _, err := kr.Open("claude", "personal", ".credentials.json", sealed); err == nil {
+		t.Error("Open() accepted a file moved to another profile")
+	}
+
+	var noKey *ErrNoPoolKey
+	if _, err := (&PoolKeyring{}).Open("claude", "work", ".credentials.json", sealed); !errors.As(err, &noKey) {
+		t.Errorf("Open() without the key error = %v, want ErrNoPoolKey", err)
+	}
+}
+
+func TestPoolKeyExchange(t *testing.T) {
+	alice, bob := t.TempDir(), t.TempDir()
+
+	t.Setenv("CAAM_HOME", bob)
+	bobKey, err := LoadOrCreateExchangeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubData, err := os.ReadFile(exchangePubKeyPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := parseExchangePubKey(pubData)
+	if err != nil || !pub.Equal(bobKey.PublicKey()) {
+		t.Fatalf("exchange public key = %v, %v", pub, err)
+	}
+
+	t.Setenv("CAAM_HOME", alice)
+	kr, err := LoadPoolKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := kr.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := wrapPoolKey(k, pub, &LocalIdentity{ID: "alice-id", Hostname: "alice"})
+	if err != nil {
+		t.Fatalf("wrapPoolKey() error = %v", err)
+	}
+	aliceSigner := newTestSigner(t)
+	if err := w.sign(aliceSigner); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(w)
+
+	// Another machine's exchange key can't unwrap it.
+	aliceKey, err := LoadOrCreateExchangeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.unwrap(aliceKey); err == nil {
+		t.Error("unwrap() with the wrong exchange key succeeded")
+	}
+
+	t.Setenv("CAAM_HOME", bob)
+	if err := os.MkdirAll(incomingPoolKeysDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	delivered := filepath.Join(incomingPoolKeysDir(), w.fileName())
+	if err := os.WriteFile(delivered, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Until alice's key is trusted, the delivered key is left alone.
+	got, err := LoadPoolKeyring()
+	if err != nil {
+		t.Fatalf("LoadPoolKeyring() error = %v", err)
+	}
+	if got.CurrentKey() != nil {
+		t.Fatal("LoadPoolKeyring() accepted a key from an untrusted issuer")
+	}
+	if err := os.WriteFile(TrustedKeysPath(), ssh.MarshalAuthorizedKey(aliceSigner.PublicKey()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = LoadPoolKeyring()
+	if err != nil {
+		t.Fatalf("LoadPoolKeyring() error = %v", err)
+	}
+	cur := got.CurrentKey()
+	if cur == nil || cur.ID != k.ID || !bytes.Equal(cur.Key, k.Key) || cur.From != "alice" {
+		t.Fatalf("current key = %+v, want %s", cur, k.ID)
+	}
+	if _, err := os.Stat(delivered); !os.IsNotExist(err) {
+		t.Error("delivered key file was not removed")
+	}
+}
+
+func TestPoolKeyUnsignedOrTampered(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	priv, err := LoadOrCreateExchangeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := newTestSigner(t)
+	if err := os.WriteFile(TrustedKeysPath(), ssh.MarshalAuthorizedKey(signer.PublicKey()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(incomingPoolKeysDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	deliver := func(name string, w *wrappedPoolKey) {
+		t.Helper()
+		data, _ := json.Marshal(w)
+		if err := os.WriteFile(filepath.Join(incomingPoolKeysDir(), name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newKey := func(createdAt time.Time) *PoolKey {
+		kr := &PoolKeyring{}
+		k, err := kr.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.CreatedAt = createdAt
+		return k
+	}
+
+	// A hub plants an unsigned key dated in the future, and one signed
+	// by its own key.
+	planted, err := wrapPoolKey(newKey(time.Now().Add(24*time.Hour)), priv.PublicKey(), &LocalIdentity{ID: "hub"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deliver("unsigned.json", planted)
+	if err := planted.sign(newTestSigner(t)); err != nil {
+		t.Fatal(err)
+	}
+	deliver("untrusted.json", planted)
+
+	// A trusted key whose date was changed after signing.
+	tampered, err := wrapPoolKey(newKey(time.Now()), priv.PublicKey(), &LocalIdentity{ID: "peer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tampered.sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	tampered.CreatedAt = tampered.CreatedAt.Add(48 * time.Hour)
+	deliver("tampered.json", tampered)
+
+	kr, err := LoadPoolKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kr.Keys) != 0 || kr.Enabled() {
+		t.Fatalf("LoadPoolKeyring() accepted %d unverified keys", len(kr.Keys))
+	}
+}
+
+func TestPoolKeyDeliveredAfterDisable(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	priv, err := LoadOrCreateExchangeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := newTestSigner(t)
+	if err := os.WriteFile(TrustedKeysPath(), ssh.MarshalAuthorizedKey(signer.PublicKey()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	kr, err := LoadPoolKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kr.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	kr.Disable()
+	if err := kr.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := (&PoolKeyring{}).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := wrapPoolKey(k, priv.PublicKey(), &LocalIdentity{ID: "peer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(w)
+	if err := os.MkdirAll(incomingPoolKeysDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(incomingPoolKeysDir(), w.fileName()), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	kr, err = LoadPoolKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.key(k.ID) == nil {
+		t.Error("delivered key was not kept")
+	}
+	if kr.Enabled() {
+		t.Error("a delivered key re-enabled sealing after --disable")
+	}
+}
+
+func TestPushPullPoolEncrypted(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	kr, err := LoadPoolKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kr.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	token := []byte(`{"accessToken":"secret"}`)
+	localVault := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(localVault, "claude", "work"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(localVault, "claude", "work", ".credentials.json"), token, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := sshDirFS{dirFS: dirFS{root: t.TempDir()}, m: NewMachine("backup", "10.0.0.9")}
+	s := &Syncer{vaultPath: localVault, remoteVaultPath: "vault", keyring: kr}
+	if _, err := s.pushProfile(remote, "claude", "work"); err != nil {
+		t.Fatalf("pushProfile() error = %v", err)
+	}
+	stored, err := remote.ReadFile("vault/claude/work/.credentials.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vaultcrypt.IsPoolSealed(stored) || bytes.Contains(stored, []byte("secret")) {
+		t.Fatalf("remote copy isn't sealed: %q", stored)
+	}
+
+	// A member decrypts what it pulls.
+	member := &Syncer{vaultPath: t.TempDir(), remoteVaultPath: "vault", keyring: kr}
+	if _, err := member.pullProfile(remote, "claude", "work"); err != nil {
+		t.Fatalf("pullProfile() error = %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(member.vaultPath, "claude", "work", ".credentials.json"))
+	if !bytes.Equal(got, token) {
+		t.Errorf("member pulled %q, want plaintext", got)
+	}
+
+	// A hub without the key keeps the ciphertext.
+	hub := &Syncer{vaultPath: t.TempDir(), remoteVaultPath: "vault", keyring: &PoolKeyring{}}
+	if _, err := hub.pullProfile(remote, "claude", "work"); err != nil {
+		t.Fatalf("pullProfile() without key error = %v", err)
+	}
+	relayed := filepath.Join(hub.vaultPath, "claude", "work", ".credentials.json")
+	got, _ = os.ReadFile(relayed)
+	if !bytes.Equal(got, stored) {
+		t.Errorf("hub stored %q, want the sealed copy", got)
+	}
+
+	// Reading a sealed vault file uses the keyring on disk.
+	got, err = vaultcrypt.ReadFile(relayed)
+	if err != nil || !bytes.Equal(got, token) {
+		t.Errorf("vaultcrypt.ReadFile() = %q, %v", got, err)
+	}
+}
+
+func TestPushPullEncryptedVault(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	t.Setenv(vaultcrypt.PassphraseEnv, "test-passphrase")
+	localVault, memberVault := t.TempDir(), t.TempDir()
+	for _, dir := range []string{localVault, memberVault} {
+		if _, err := vaultcrypt.Init(dir, vaultcrypt.ModePassphrase); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	token := []byte(`{"accessToken":"secret"}`)
+	path := filepath.Join(localVault, "claude", "work", ".credentials.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := vaultcrypt.SealFor(path, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, sealed, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without pool encryption, the peer gets the file decrypted: it could
+	// never open this vault's key.
+	remote := sshDirFS{dirFS: dirFS{root: t.TempDir()}, m: NewMachine("peer", "10.0.0.9")}
+	s := &Syncer{vaultPath: localVault, remoteVaultPath: "vault", keyring: &PoolKeyring{}}
+	if _, err := s.pushProfile(remote, "claude", "work"); err != nil {
+		t.Fatalf("pushProfile() error = %v", err)
+	}
+	stored, err := remote.ReadFile("vault/claude/work/.credentials.json")
+	if err != nil || !bytes.Equal(stored, token) {
+		t.Fatalf("remote copy = %q, %v; want the decrypted token", stored, err)
+	}
+
+	// A member with its own encrypted vault stores it under its own key.
+	member := &Syncer{vaultPath: memberVault, remoteVaultPath: "vault", keyring: &PoolKeyring{}}
+	if _, err := member.pullProfile(remote, "claude", "work"); err != nil {
+		t.Fatalf("pullProfile() error = %v", err)
+	}
+	pulled := filepath.Join(memberVault, "claude", "work", ".credentials.json")
+	raw, _ := os.ReadFile(pulled)
+	if !vaultcrypt.IsEncrypted(raw) {
+		t.Errorf("pulled file is plaintext in an encrypted vault")
+	}
+	if got, err := vaultcrypt.ReadFile(pulled); err != nil || !bytes.Equal(got, token) {
+		t.Errorf("vaultcrypt.ReadFile() = %q, %v", got, err)
+	}
+}
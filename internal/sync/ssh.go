@@ -1,16 +1,20 @@
 package sync
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/chaos"
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
@@ -27,6 +31,12 @@ type ConnectOptions struct {
 
 	// SkipHostKeyCheck disables host key verification (insecure, for testing only).
 	SkipHostKeyCheck bool
+
+	// HTTPClient is used by the HTTPS transport. Default: a client with Timeout.
+	HTTPClient *http.Client
+
+	// Passphrase encrypts files on HTTPS endpoints. Default: SyncPassphrase().
+	Passphrase string
 }
 
 // DefaultConnectOptions returns the default connection options.
@@ -56,6 +66,11 @@ func NewSSHClient(m *Machine) *SSHClient {
 	}
 }
 
+// Machine returns the machine this client connects to.
+func (c *SSHClient) Machine() *Machine {
+	return c.machine
+}
+
 // Connect establishes an SSH connection to the machine.
 func (c *SSHClient) Connect(opts ConnectOptions) error {
 	if c.connected {
@@ -67,6 +82,14 @@ func (c *SSHClient) Connect(opts ConnectOptions) error {
 		c.opts.Timeout = 10 * time.Second
 	}
 
+	if err := chaos.Err(chaos.SyncUnreachable); err != nil {
+		return &SSHError{
+			Machine:    c.machine,
+			Operation:  "connect",
+			Underlying: err,
+		}
+	}
+
 	// Get authentication methods
 	authMethods, err := c.getAuthMethods()
 	if err != nil {
@@ -130,6 +153,44 @@ func (c *SSHClient) Connect(opts ConnectOptions) error {
 	return nil
 }
 
+// Dial opens a connection from the remote machine to addr over the SSH
+// connection, e.g. to reach a service bound to the remote loopback.
+func (c *SSHClient) Dial(network, addr string) (net.Conn, error) {
+	if !c.connected || c.client == nil {
+		return nil, errors.New("not connected")
+	}
+	return c.client.Dial(network, addr)
+}
+
+// Run runs command through the remote user's shell and returns what it
+// wrote to stdout and stderr. A command that exits non-zero returns its
+// output with an *ssh.ExitError. If ctx ends first the command is sent
+// SIGTERM and ctx's error is returned.
+func (c *SSHClient) Run(ctx context.Context, command string) (stdout, stderr []byte, err error) {
+	if !c.connected || c.client == nil {
+		return nil, nil, errors.New("not connected")
+	}
+	session, err := c.client.NewSession()
+	if err != nil {
+		return nil, nil, &SSHError{Machine: c.machine, Operation: "session", Underlying: err}
+	}
+	defer session.Close()
+
+	var outBuf, errBuf bytes.Buffer
+	session.Stdout = &outBuf
+	session.Stderr = &errBuf
+
+	done := make(chan error, 1)
+	go func() { done <- session.Run(command) }()
+	select {
+	case <-ctx.Done():
+		_ = session.Signal(ssh.SIGTERM)
+		return outBuf.Bytes(), errBuf.Bytes(), ctx.Err()
+	case err := <-done:
+		return outBuf.Bytes(), errBuf.Bytes(), err
+	}
+}
+
 // Disconnect closes the SSH connection.
 func (c *SSHClient) Disconnect() error {
 	if c.sftp != nil {
@@ -608,8 +669,12 @@ type ConnectivityResult struct {
 	ErrorType    string
 }
 
-// TestMachineConnectivity tests SSH connectivity to a machine.
+// TestMachineConnectivity tests connectivity to a machine over its transport.
 func TestMachineConnectivity(m *Machine, opts ConnectOptions) *ConnectivityResult {
+	if m.TransportName() == TransportHTTPS {
+		return testHTTPSConnectivity(m, opts)
+	}
+
 	result := &ConnectivityResult{
 		Machine: m,
 	}
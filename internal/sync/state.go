@@ -1,15 +1,16 @@
 package sync
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 	"sync"
 	"time"
 )
 
 // SyncState manages the complete sync state including identity, pool, queue, and history.
+//
+// History is written through to the Store as it is recorded and queried from
+// it on demand; the retry queue is small and is held in memory between Load
+// and Save.
 type SyncState struct {
 	// Identity is the local machine's identity.
 	Identity *LocalIdentity
@@ -20,9 +21,15 @@ type SyncState struct {
 	// Queue holds pending sync operations for retry.
 	Queue *SyncQueue
 
-	// History records recent sync operations.
-	History *SyncHistory
+	// Conflicts are profiles that changed on both sides since their last
+	// sync, awaiting resolution.
+	Conflicts []Conflict
 
+	// bases records each profile's last in-sync state per machine.
+	bases          map[string]SyncBase
+	conflictsDirty bool
+
+	store    Store
 	basePath string
 	mu       sync.RWMutex
 }
@@ -60,7 +67,7 @@ type QueueEntry struct {
 	LastError string `json:"last_error,omitempty"`
 }
 
-// SyncHistory records recent sync operations.
+// SyncHistory is the legacy on-disk format of history.json.
 type SyncHistory struct {
 	// Entries are recent sync events.
 	Entries []HistoryEntry `json:"entries"`
@@ -127,14 +134,27 @@ func NewSyncState(basePath string) *SyncState {
 			Entries: make([]QueueEntry, 0),
 			MaxSize: DefaultQueueMaxSize,
 		},
-		History: &SyncHistory{
-			Entries: make([]HistoryEntry, 0),
-			MaxSize: DefaultHistoryMaxSize,
-		},
+		bases:    make(map[string]SyncBase),
 		basePath: basePath,
 	}
 }
 
+// SetStore replaces the store used for history and the retry queue.
+func (s *SyncState) SetStore(store Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = store
+}
+
+// historyStore returns the state's store, opening it on first use.
+// Callers must hold s.mu.
+func (s *SyncState) historyStore() Store {
+	if s.store == nil {
+		s.store = openStore(s.basePath)
+	}
+	return s.store
+}
+
 // Load loads all sync state from disk.
 func (s *SyncState) Load() error {
 	s.mu.Lock()
@@ -161,21 +181,20 @@ func (s *SyncState) Load() error {
 	}
 
 	// Load queue
-	if err := s.loadQueue(); err != nil {
+	entries, err := s.historyStore().LoadQueue()
+	if err != nil || entries == nil {
 		// Non-fatal - start with empty queue
-		s.Queue = &SyncQueue{
-			Entries: make([]QueueEntry, 0),
-			MaxSize: DefaultQueueMaxSize,
-		}
+		entries = make([]QueueEntry, 0)
+	}
+	s.Queue = &SyncQueue{
+		Entries: entries,
+		MaxSize: DefaultQueueMaxSize,
 	}
 
-	// Load history
-	if err := s.loadHistory(); err != nil {
-		// Non-fatal - start with empty history
-		s.History = &SyncHistory{
-			Entries: make([]HistoryEntry, 0),
-			MaxSize: DefaultHistoryMaxSize,
-		}
+	// Load conflicts and sync bases
+	if err := s.loadConflicts(); err != nil {
+		// Non-fatal - without bases, conflicts simply aren't detected
+		s.bases = make(map[string]SyncBase)
 	}
 
 	return nil
@@ -198,127 +217,26 @@ func (s *SyncState) Save() error {
 		return fmt.Errorf("save queue: %w", err)
 	}
 
-	// Save history
-	if err := s.saveHistory(); err != nil {
-		return fmt.Errorf("save history: %w", err)
-	}
-
-	return nil
-}
-
-// loadQueue loads the queue from disk.
-func (s *SyncState) loadQueue() error {
-	path := filepath.Join(s.basePath, queueFileName)
-
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return err
-	}
-
-	var queue SyncQueue
-	if err := json.Unmarshal(data, &queue); err != nil {
-		return err
+	// Save conflicts and sync bases
+	if err := s.saveConflicts(); err != nil {
+		return fmt.Errorf("save conflicts: %w", err)
 	}
 
-	if queue.MaxSize == 0 {
-		queue.MaxSize = DefaultQueueMaxSize
-	}
-	s.Queue = &queue
 	return nil
 }
 
-// saveQueue saves the queue to disk.
+// saveQueue writes the queue to the store.
 func (s *SyncState) saveQueue() error {
 	if s.Queue == nil {
 		return nil
 	}
 
 	// Trim to max size
-	if len(s.Queue.Entries) > s.Queue.MaxSize {
+	if s.Queue.MaxSize > 0 && len(s.Queue.Entries) > s.Queue.MaxSize {
 		s.Queue.Entries = s.Queue.Entries[len(s.Queue.Entries)-s.Queue.MaxSize:]
 	}
 
-	return s.saveJSON(queueFileName, s.Queue)
-}
-
-// loadHistory loads the history from disk.
-func (s *SyncState) loadHistory() error {
-	path := filepath.Join(s.basePath, historyFileName)
-
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return err
-	}
-
-	var history SyncHistory
-	if err := json.Unmarshal(data, &history); err != nil {
-		return err
-	}
-
-	if history.MaxSize == 0 {
-		history.MaxSize = DefaultHistoryMaxSize
-	}
-	s.History = &history
-	return nil
-}
-
-// saveHistory saves the history to disk.
-func (s *SyncState) saveHistory() error {
-	if s.History == nil {
-		return nil
-	}
-
-	// Trim to max size
-	if len(s.History.Entries) > s.History.MaxSize {
-		s.History.Entries = s.History.Entries[len(s.History.Entries)-s.History.MaxSize:]
-	}
-
-	return s.saveJSON(historyFileName, s.History)
-}
-
-// saveJSON saves a value to a JSON file atomically.
-func (s *SyncState) saveJSON(filename string, v interface{}) error {
-	// Ensure directory exists
-	if err := os.MkdirAll(s.basePath, 0700); err != nil {
-		return fmt.Errorf("create directory: %w", err)
-	}
-
-	path := filepath.Join(s.basePath, filename)
-	data, err := json.MarshalIndent(v, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshal: %w", err)
-	}
-
-	// Atomic write
-	tmpPath := path + ".tmp"
-	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
-	if err != nil {
-		return fmt.Errorf("create temp file: %w", err)
-	}
-
-	if _, err := f.Write(data); err != nil {
-		f.Close()
-		os.Remove(tmpPath)
-		return fmt.Errorf("write temp file: %w", err)
-	}
-
-	if err := f.Sync(); err != nil {
-		f.Close()
-		os.Remove(tmpPath)
-		return fmt.Errorf("sync temp file: %w", err)
-	}
-
-	if err := f.Close(); err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("close temp file: %w", err)
-	}
-
-	if err := os.Rename(tmpPath, path); err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("rename temp file: %w", err)
-	}
-
-	return nil
+	return s.historyStore().SaveQueue(s.Queue.Entries)
 }
 
 // AddToQueue adds a sync operation to the queue.
@@ -392,46 +310,45 @@ func (s *SyncState) ClearOldQueueEntries(maxAge time.Duration) {
 	s.Queue.Entries = filtered
 }
 
-// AddToHistory adds a sync event to the history.
+// AddToHistory records a sync event. History is written straight to the
+// store; failures to record history never fail the sync itself.
 func (s *SyncState) AddToHistory(entry HistoryEntry) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.History == nil {
-		s.History = &SyncHistory{
-			Entries: make([]HistoryEntry, 0),
-			MaxSize: DefaultHistoryMaxSize,
-		}
-	}
+	_ = s.historyStore().AppendHistory(entry)
+}
 
-	s.History.Entries = append(s.History.Entries, entry)
+// QueryHistory returns history entries matching filter, most recent first.
+func (s *SyncState) QueryHistory(filter HistoryFilter) ([]HistoryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Trim if over max size
-	if len(s.History.Entries) > s.History.MaxSize {
-		s.History.Entries = s.History.Entries[len(s.History.Entries)-s.History.MaxSize:]
-	}
+	return s.historyStore().QueryHistory(filter)
 }
 
-// RecentHistory returns the most recent history entries.
+// RecentHistory returns the most recent history entries, most recent first.
 func (s *SyncState) RecentHistory(limit int) []HistoryEntry {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if s.History == nil || len(s.History.Entries) == 0 {
+	if limit <= 0 {
 		return nil
 	}
-
-	entries := s.History.Entries
-	if len(entries) > limit {
-		entries = entries[len(entries)-limit:]
+	entries, err := s.QueryHistory(HistoryFilter{Limit: limit})
+	if err != nil {
+		return nil
 	}
+	return entries
+}
 
-	// Return in reverse order (most recent first)
-	result := make([]HistoryEntry, len(entries))
-	for i, e := range entries {
-		result[len(entries)-1-i] = e
+// HistoryCount returns the number of recorded history entries.
+func (s *SyncState) HistoryCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.historyStore().CountHistory()
+	if err != nil {
+		return 0
 	}
-	return result
+	return n
 }
 
 // LoadSyncState loads or creates the sync state.
@@ -442,3 +359,41 @@ func LoadSyncState() (*SyncState, error) {
 	}
 	return state, nil
 }
+
+// DefaultPoolStaleThreshold is how long a profile can go without a successful
+// sync before it is considered stale relative to the pool. Past this point a
+// peer may have refreshed the token and invalidated the local copy.
+const DefaultPoolStaleThreshold = 72 * time.Hour
+
+// LastSynced returns when a profile was last known to be in sync with the
+// pool: the most recent successful push or pull for it, or the last full sync
+// if that is newer. Returns the zero time if it has never synced.
+func (s *SyncState) LastSynced(provider, profile string) time.Time {
+	var last time.Time
+	entries, err := s.QueryHistory(HistoryFilter{Provider: provider, Profile: profile, SuccessOnly: true, Limit: 1})
+	if err == nil && len(entries) > 0 {
+		last = entries[0].Timestamp
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.Pool != nil && s.Pool.LastFullSync.After(last) {
+		last = s.Pool.LastFullSync
+	}
+	return last
+}
+
+// StaleVsPool reports whether a profile has gone longer than threshold
+// without syncing with an enabled, non-empty pool. It also returns the last
+// sync time. Profiles that have never synced are not reported as stale,
+// since there is no pool copy for them to be stale against.
+func (s *SyncState) StaleVsPool(provider, profile string, threshold time.Duration, now time.Time) (bool, time.Time) {
+	if s.Pool == nil || !s.Pool.Enabled || s.Pool.IsEmpty() {
+		return false, time.Time{}
+	}
+	last := s.LastSynced(provider, profile)
+	if last.IsZero() {
+		return false, last
+	}
+	return now.Sub(last) > threshold, last
+}
@@ -0,0 +1,416 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+// HistoryFilter narrows a sync history query. Zero values match everything.
+type HistoryFilter struct {
+	Provider   string
+	Profile    string
+	Machine    string
+	ErrorsOnly bool
+	// SuccessOnly restricts results to successful operations.
+	SuccessOnly bool
+	// Since excludes entries older than this time.
+	Since time.Time
+	// Limit caps the number of entries returned (0 = no limit).
+	Limit int
+}
+
+func (f HistoryFilter) matches(e HistoryEntry) bool {
+	if f.Provider != "" && e.Provider != f.Provider {
+		return false
+	}
+	if f.Profile != "" && e.Profile != f.Profile {
+		return false
+	}
+	if f.Machine != "" && e.Machine != f.Machine {
+		return false
+	}
+	if f.ErrorsOnly && e.Success {
+		return false
+	}
+	if f.SuccessOnly && !e.Success {
+		return false
+	}
+	if !f.Since.IsZero() && e.Timestamp.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// Store persists sync history and the retry queue.
+type Store interface {
+	// AppendHistory records sync operations.
+	AppendHistory(entries ...HistoryEntry) error
+
+	// QueryHistory returns matching history entries, most recent first.
+	QueryHistory(filter HistoryFilter) ([]HistoryEntry, error)
+
+	// CountHistory returns the number of recorded history entries.
+	CountHistory() (int, error)
+
+	// LoadQueue returns the pending retry queue, oldest first.
+	LoadQueue() ([]QueueEntry, error)
+
+	// SaveQueue replaces the retry queue.
+	SaveQueue(entries []QueueEntry) error
+}
+
+// fileStore keeps history and queue in JSON files under the sync data dir.
+// It is the legacy format, still used for explicit state directories and
+// whenever the database is unavailable.
+type fileStore struct {
+	basePath   string
+	maxHistory int
+	mu         sync.Mutex
+}
+
+// NewFileStore returns a Store backed by queue.json and history.json in
+// basePath.
+func NewFileStore(basePath string) Store {
+	return &fileStore{basePath: basePath, maxHistory: DefaultHistoryMaxSize}
+}
+
+func (f *fileStore) loadHistory() (*SyncHistory, error) {
+	data, err := os.ReadFile(filepath.Join(f.basePath, historyFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &SyncHistory{MaxSize: f.maxHistory}, nil
+		}
+		return nil, err
+	}
+	var history SyncHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", historyFileName, err)
+	}
+	history.MaxSize = f.maxHistory
+	return &history, nil
+}
+
+func (f *fileStore) AppendHistory(entries ...HistoryEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	history, err := f.loadHistory()
+	if err != nil {
+		// A corrupt history file is not worth failing a sync over.
+		history = &SyncHistory{MaxSize: f.maxHistory}
+	}
+	history.Entries = append(history.Entries, entries...)
+	if len(history.Entries) > history.MaxSize {
+		history.Entries = history.Entries[len(history.Entries)-history.MaxSize:]
+	}
+	return saveJSONFile(f.basePath, historyFileName, history)
+}
+
+func (f *fileStore) QueryHistory(filter HistoryFilter) ([]HistoryEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	history, err := f.loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	var out []HistoryEntry
+	for i := len(history.Entries) - 1; i >= 0; i-- {
+		e := history.Entries[i]
+		if !filter.matches(e) {
+			continue
+		}
+		out = append(out, e)
+		if filter.Limit > 0 && len(out) >= filter.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (f *fileStore) CountHistory() (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	history, err := f.loadHistory()
+	if err != nil {
+		return 0, err
+	}
+	return len(history.Entries), nil
+}
+
+func (f *fileStore) LoadQueue() ([]QueueEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(f.basePath, queueFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var queue SyncQueue
+	if err := json.Unmarshal(data, &queue); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", queueFileName, err)
+	}
+	return queue.Entries, nil
+}
+
+func (f *fileStore) SaveQueue(entries []QueueEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if entries == nil {
+		entries = make([]QueueEntry, 0)
+	}
+	return saveJSONFile(f.basePath, queueFileName, &SyncQueue{Entries: entries, MaxSize: DefaultQueueMaxSize})
+}
+
+// dbStore keeps history and queue in the caam SQLite database, so history
+// can be filtered and limited by indexed queries instead of loading it all.
+type dbStore struct {
+	db *caamdb.DB
+}
+
+// NewDBStore returns a Store backed by the caam database.
+func NewDBStore(db *caamdb.DB) Store {
+	return &dbStore{db: db}
+}
+
+func (d *dbStore) AppendHistory(entries ...HistoryEntry) error {
+	records := make([]caamdb.SyncHistoryRecord, len(entries))
+	for i, e := range entries {
+		records[i] = caamdb.SyncHistoryRecord{
+			Timestamp:   e.Timestamp,
+			Trigger:     e.Trigger,
+			Provider:    e.Provider,
+			ProfileName: e.Profile,
+			Machine:     e.Machine,
+			Action:      e.Action,
+			Success:     e.Success,
+			Error:       e.Error,
+			Duration:    e.Duration,
+		}
+	}
+	return d.db.InsertSyncHistory(records)
+}
+
+func (d *dbStore) QueryHistory(filter HistoryFilter) ([]HistoryEntry, error) {
+	records, err := d.db.QuerySyncHistory(caamdb.SyncHistoryQuery{
+		Provider:    filter.Provider,
+		ProfileName: filter.Profile,
+		Machine:     filter.Machine,
+		ErrorsOnly:  filter.ErrorsOnly,
+		SuccessOnly: filter.SuccessOnly,
+		Since:       filter.Since,
+		Limit:       filter.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]HistoryEntry, len(records))
+	for i, r := range records {
+		out[i] = HistoryEntry{
+			Timestamp: r.Timestamp,
+			Trigger:   r.Trigger,
+			Provider:  r.Provider,
+			Profile:   r.ProfileName,
+			Machine:   r.Machine,
+			Action:    r.Action,
+			Success:   r.Success,
+			Error:     r.Error,
+			Duration:  r.Duration,
+		}
+	}
+	return out, nil
+}
+
+func (d *dbStore) CountHistory() (int, error) {
+	return d.db.CountSyncHistory()
+}
+
+func (d *dbStore) LoadQueue() ([]QueueEntry, error) {
+	records, err := d.db.ListSyncQueue()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]QueueEntry, len(records))
+	for i, r := range records {
+		out[i] = QueueEntry{
+			Provider:    r.Provider,
+			Profile:     r.ProfileName,
+			Machine:     r.Machine,
+			AddedAt:     r.AddedAt,
+			Attempts:    r.Attempts,
+			LastAttempt: r.LastAttempt,
+			LastError:   r.LastError,
+		}
+	}
+	return out, nil
+}
+
+func (d *dbStore) SaveQueue(entries []QueueEntry) error {
+	records := make([]caamdb.SyncQueueRecord, len(entries))
+	for i, e := range entries {
+		records[i] = caamdb.SyncQueueRecord{
+			Provider:    e.Provider,
+			ProfileName: e.Profile,
+			Machine:     e.Machine,
+			AddedAt:     e.AddedAt,
+			Attempts:    e.Attempts,
+			LastAttempt: e.LastAttempt,
+			LastError:   e.LastError,
+		}
+	}
+	return d.db.ReplaceSyncQueue(records)
+}
+
+// defaultStore holds the process-wide store for the default sync data dir.
+var defaultStore struct {
+	mu    sync.Mutex
+	open  func() (Store, error)
+	store Store
+}
+
+// SetDefaultStoreOpener sets how the store for the default sync data dir is
+// opened. The CLI points it at the SQLite database; without an opener, or if
+// it fails, the legacy JSON files are used. The opened store is shared by
+// every SyncState in the process.
+func SetDefaultStoreOpener(open func() (Store, error)) {
+	defaultStore.mu.Lock()
+	defer defaultStore.mu.Unlock()
+	defaultStore.open = open
+	defaultStore.store = nil
+}
+
+// openStore returns the store for basePath. Only the default sync data dir
+// uses the shared default store; legacy files there are migrated into it.
+func openStore(basePath string) Store {
+	if basePath == "" {
+		basePath = SyncDataDir()
+	}
+	if filepath.Clean(basePath) != filepath.Clean(SyncDataDir()) {
+		return NewFileStore(basePath)
+	}
+
+	defaultStore.mu.Lock()
+	defer defaultStore.mu.Unlock()
+	if defaultStore.store == nil {
+		if defaultStore.open == nil {
+			return NewFileStore(basePath)
+		}
+		store, err := defaultStore.open()
+		if err != nil {
+			return NewFileStore(basePath)
+		}
+		defaultStore.store = store
+	}
+	// Best effort: if migration fails the files stay put and are retried.
+	_ = migrateLegacyFiles(basePath, defaultStore.store)
+	return defaultStore.store
+}
+
+// migrateLegacyFiles moves history.json and queue.json from basePath into
+// store, renaming each file with a .migrated suffix once imported.
+func migrateLegacyFiles(basePath string, store Store) error {
+	legacy := &fileStore{basePath: basePath, maxHistory: DefaultHistoryMaxSize}
+
+	historyPath := filepath.Join(basePath, historyFileName)
+	if _, err := os.Stat(historyPath); err == nil {
+		history, err := legacy.loadHistory()
+		if err != nil {
+			return err
+		}
+		if err := store.AppendHistory(history.Entries...); err != nil {
+			return fmt.Errorf("migrate sync history: %w", err)
+		}
+		if err := os.Rename(historyPath, historyPath+".migrated"); err != nil {
+			return err
+		}
+	}
+
+	queuePath := filepath.Join(basePath, queueFileName)
+	if _, err := os.Stat(queuePath); err == nil {
+		legacyQueue, err := legacy.LoadQueue()
+		if err != nil {
+			return err
+		}
+		current, err := store.LoadQueue()
+		if err != nil {
+			return err
+		}
+		if err := store.SaveQueue(mergeQueues(current, legacyQueue)); err != nil {
+			return fmt.Errorf("migrate sync queue: %w", err)
+		}
+		if err := os.Rename(queuePath, queuePath+".migrated"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeQueues returns a's entries followed by b's entries for targets a
+// doesn't already have.
+func mergeQueues(a, b []QueueEntry) []QueueEntry {
+	seen := make(map[string]bool, len(a))
+	out := append([]QueueEntry(nil), a...)
+	for _, e := range a {
+		seen[e.Provider+"\x00"+e.Profile+"\x00"+e.Machine] = true
+	}
+	for _, e := range b {
+		if !seen[e.Provider+"\x00"+e.Profile+"\x00"+e.Machine] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// saveJSONFile writes v as indented JSON to basePath/filename atomically.
+func saveJSONFile(basePath, filename string, v interface{}) error {
+	if err := os.MkdirAll(basePath, 0700); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	path := filepath.Join(basePath, filename)
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("write temp file: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+
+	return nil
+}
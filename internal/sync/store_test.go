@@ -0,0 +1,127 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func TestHistoryFilterFileStore(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	now := time.Now()
+	entries := []HistoryEntry{
+		{Timestamp: now.Add(-3 * time.Minute), Provider: "claude", Profile: "a", Machine: "m1", Success: true},
+		{Timestamp: now.Add(-2 * time.Minute), Provider: "codex", Profile: "b", Machine: "m2", Success: false, Error: "boom"},
+		{Timestamp: now.Add(-1 * time.Minute), Provider: "claude", Profile: "a", Machine: "m2", Success: true},
+	}
+	if err := store.AppendHistory(entries...); err != nil {
+		t.Fatalf("AppendHistory failed: %v", err)
+	}
+
+	got, err := store.QueryHistory(HistoryFilter{Provider: "claude"})
+	if err != nil {
+		t.Fatalf("QueryHistory failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Machine != "m2" {
+		t.Errorf("claude history = %+v, want 2 entries newest first", got)
+	}
+
+	got, _ = store.QueryHistory(HistoryFilter{ErrorsOnly: true})
+	if len(got) != 1 || got[0].Error != "boom" {
+		t.Errorf("errors-only history = %+v", got)
+	}
+
+	got, _ = store.QueryHistory(HistoryFilter{Limit: 1})
+	if len(got) != 1 || got[0].Machine != "m2" || got[0].Provider != "claude" {
+		t.Errorf("limit 1 history = %+v", got)
+	}
+}
+
+func TestDBStoreMigratesLegacyFiles(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	store := NewDBStore(db)
+
+	// Seed legacy JSON files the way older versions wrote them.
+	basePath := t.TempDir()
+	legacy := NewFileStore(basePath)
+	if err := legacy.AppendHistory(
+		HistoryEntry{Timestamp: time.Now().Add(-time.Hour), Provider: "claude", Profile: "a", Machine: "m1", Success: true},
+		HistoryEntry{Timestamp: time.Now().Add(-time.Minute), Provider: "codex", Profile: "b", Machine: "m1", Error: "refused"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := legacy.SaveQueue([]QueueEntry{{Provider: "codex", Profile: "b", Machine: "m1", AddedAt: time.Now(), Attempts: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrateLegacyFiles(basePath, store); err != nil {
+		t.Fatalf("migrateLegacyFiles failed: %v", err)
+	}
+
+	if n, _ := store.CountHistory(); n != 2 {
+		t.Errorf("migrated history count = %d, want 2", n)
+	}
+	queue, err := store.LoadQueue()
+	if err != nil || len(queue) != 1 || queue[0].Profile != "b" {
+		t.Errorf("migrated queue = %+v, %v", queue, err)
+	}
+	for _, name := range []string{historyFileName, queueFileName} {
+		if _, err := os.Stat(filepath.Join(basePath, name)); !os.IsNotExist(err) {
+			t.Errorf("%s still present after migration", name)
+		}
+		if _, err := os.Stat(filepath.Join(basePath, name+".migrated")); err != nil {
+			t.Errorf("%s.migrated missing: %v", name, err)
+		}
+	}
+
+	// A second run finds nothing to import.
+	if err := migrateLegacyFiles(basePath, store); err != nil {
+		t.Fatalf("second migrateLegacyFiles failed: %v", err)
+	}
+	if n, _ := store.CountHistory(); n != 2 {
+		t.Errorf("history count after second migration = %d, want 2", n)
+	}
+
+	failed, err := store.QueryHistory(HistoryFilter{ErrorsOnly: true})
+	if err != nil || len(failed) != 1 || failed[0].Provider != "codex" {
+		t.Errorf("errors-only query = %+v, %v", failed, err)
+	}
+}
+
+func TestSyncStateUsesInjectedStore(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	tmpDir := t.TempDir()
+	state := NewSyncState(tmpDir)
+	state.SetStore(NewDBStore(db))
+
+	state.AddToHistory(HistoryEntry{Timestamp: time.Now(), Provider: "claude", Profile: "a", Machine: "m1", Success: true})
+	state.AddToQueue("claude", "a", "m2", "refused")
+	if err := state.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if n := state.HistoryCount(); n != 1 {
+		t.Errorf("HistoryCount() = %d, want 1", n)
+	}
+	if last := state.LastSynced("claude", "a"); last.IsZero() {
+		t.Error("LastSynced() is zero, want the recorded sync")
+	}
+	if queue, _ := db.ListSyncQueue(); len(queue) != 1 {
+		t.Errorf("db queue = %+v, want 1 entry", queue)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, historyFileName)); !os.IsNotExist(err) {
+		t.Error("history.json written despite injected store")
+	}
+}
@@ -750,8 +750,8 @@ func TestSyncHistory(t *testing.T) {
 		})
 	}
 
-	if len(state.History.Entries) != 5 {
-		t.Errorf("History len = %d, want 5", len(state.History.Entries))
+	if n := state.HistoryCount(); n != 5 {
+		t.Errorf("HistoryCount() = %d, want 5", n)
 	}
 
 	// Recent should return in reverse order
@@ -766,6 +766,61 @@ func TestSyncHistory(t *testing.T) {
 	}
 }
 
+// TestSyncStateStaleVsPool tests pool staleness detection.
+func TestSyncStateStaleVsPool(t *testing.T) {
+	state := NewSyncState(t.TempDir())
+	now := time.Now()
+
+	// Disabled pool is never stale.
+	state.AddToHistory(HistoryEntry{
+		Timestamp: now.Add(-5 * 24 * time.Hour),
+		Provider:  "claude",
+		Profile:   "work",
+		Action:    "push",
+		Success:   true,
+	})
+	if stale, _ := state.StaleVsPool("claude", "work", DefaultPoolStaleThreshold, now); stale {
+		t.Error("disabled pool should not report stale")
+	}
+
+	if err := state.Pool.AddMachine(NewMachine("peer", "192.168.1.50")); err != nil {
+		t.Fatalf("AddMachine failed: %v", err)
+	}
+	state.Pool.Enable()
+
+	stale, last := state.StaleVsPool("claude", "work", DefaultPoolStaleThreshold, now)
+	if !stale {
+		t.Error("profile synced 5 days ago should be stale")
+	}
+	if !last.Equal(now.Add(-5 * 24 * time.Hour)) {
+		t.Errorf("last synced = %v, want 5 days ago", last)
+	}
+
+	// Failed syncs don't count.
+	state.AddToHistory(HistoryEntry{
+		Timestamp: now.Add(-time.Hour),
+		Provider:  "claude",
+		Profile:   "work",
+		Action:    "push",
+		Success:   false,
+	})
+	if stale, _ := state.StaleVsPool("claude", "work", DefaultPoolStaleThreshold, now); !stale {
+		t.Error("failed sync should not refresh staleness")
+	}
+
+	// A recent full sync covers every profile.
+	state.Pool.LastFullSync = now.Add(-time.Hour)
+	if stale, _ := state.StaleVsPool("claude", "work", DefaultPoolStaleThreshold, now); stale {
+		t.Error("recent full sync should clear staleness")
+	}
+
+	// Profiles that never synced are not stale.
+	state.Pool.LastFullSync = time.Time{}
+	if stale, _ := state.StaleVsPool("codex", "other", DefaultPoolStaleThreshold, now); stale {
+		t.Error("never-synced profile should not be stale")
+	}
+}
+
 // TestSyncStatePersistence tests full state save/load cycle.
 func TestSyncStatePersistence(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -819,8 +874,8 @@ func TestSyncStatePersistence(t *testing.T) {
 	if len(loaded.Queue.Entries) != 1 {
 		t.Errorf("Loaded queue len = %d, want 1", len(loaded.Queue.Entries))
 	}
-	if len(loaded.History.Entries) != 1 {
-		t.Errorf("Loaded history len = %d, want 1", len(loaded.History.Entries))
+	if n := loaded.HistoryCount(); n != 1 {
+		t.Errorf("Loaded history count = %d, want 1", n)
 	}
 }
 
@@ -1400,30 +1455,24 @@ func TestSyncStateSaveQueueTrimming(t *testing.T) {
 	}
 }
 
-// TestSyncStateSaveHistoryTrimming tests that Save trims history to max size.
-func TestSyncStateSaveHistoryTrimming(t *testing.T) {
-	tmpDir := t.TempDir()
-	state := NewSyncState(tmpDir)
-
-	// Set a small max size for testing
-	state.History.MaxSize = 5
+// TestFileStoreHistoryTrimming tests that the JSON store trims history to max size.
+func TestFileStoreHistoryTrimming(t *testing.T) {
+	store := &fileStore{basePath: t.TempDir(), maxHistory: 5}
 
 	// Add more entries than max
 	for i := 0; i < 10; i++ {
-		state.History.Entries = append(state.History.Entries, HistoryEntry{
+		if err := store.AppendHistory(HistoryEntry{
 			Timestamp: time.Now(),
 			Provider:  "claude",
 			Profile:   "test",
-		})
-	}
-
-	if err := state.Save(); err != nil {
-		t.Fatalf("Save failed: %v", err)
+		}); err != nil {
+			t.Fatalf("AppendHistory failed: %v", err)
+		}
 	}
 
 	// History should be trimmed
-	if len(state.History.Entries) != 5 {
-		t.Errorf("History len after save = %d, want 5", len(state.History.Entries))
+	if n, _ := store.CountHistory(); n != 5 {
+		t.Errorf("History count = %d, want 5", n)
 	}
 }
 
@@ -1469,34 +1518,33 @@ func TestSyncStateRemoveFromQueueNotFound(t *testing.T) {
 	}
 }
 
-// TestSyncStateAddToHistoryNilHistory tests AddToHistory with nil history.
-func TestSyncStateAddToHistoryNilHistory(t *testing.T) {
+// TestSyncStateAddToHistoryOpensStore tests AddToHistory before Load.
+func TestSyncStateAddToHistoryOpensStore(t *testing.T) {
 	tmpDir := t.TempDir()
 	state := NewSyncState(tmpDir)
-	state.History = nil
 
 	state.AddToHistory(HistoryEntry{
 		Timestamp: time.Now(),
 		Provider:  "claude",
 	})
 
-	if state.History == nil {
-		t.Error("AddToHistory should create history when nil")
+	if n := state.HistoryCount(); n != 1 {
+		t.Errorf("HistoryCount() = %d, want 1", n)
 	}
-	if len(state.History.Entries) != 1 {
-		t.Errorf("History len = %d, want 1", len(state.History.Entries))
+	if _, err := os.Stat(filepath.Join(tmpDir, "history.json")); err != nil {
+		t.Errorf("history.json not written: %v", err)
 	}
 }
 
-// TestSyncStateRecentHistoryNilHistory tests RecentHistory with nil history.
-func TestSyncStateRecentHistoryNilHistory(t *testing.T) {
+// TestSyncStateRecentHistoryZeroLimit tests RecentHistory with a zero limit.
+func TestSyncStateRecentHistoryZeroLimit(t *testing.T) {
 	tmpDir := t.TempDir()
 	state := NewSyncState(tmpDir)
-	state.History = nil
+	state.AddToHistory(HistoryEntry{Timestamp: time.Now(), Provider: "claude"})
 
-	recent := state.RecentHistory(10)
+	recent := state.RecentHistory(0)
 	if recent != nil {
-		t.Errorf("RecentHistory should return nil when history is nil")
+		t.Errorf("RecentHistory(0) should return nil")
 	}
 }
 
@@ -1512,35 +1560,39 @@ func TestSyncStateRecentHistoryEmpty(t *testing.T) {
 	}
 }
 
-// TestSyncStateLoadQueueMaxSizeDefault tests loadQueue sets default max size.
-func TestSyncStateLoadQueueMaxSizeDefault(t *testing.T) {
+// TestFileStoreLoadQueueMaxSizeZero tests loading a queue file with max_size 0.
+func TestFileStoreLoadQueueMaxSizeZero(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	// Write queue file with MaxSize = 0
-	queueData := `{"entries": [], "max_size": 0}`
+	queueData := `{"entries": [{"provider": "claude", "profile": "a", "machine": "m1"}], "max_size": 0}`
 	os.WriteFile(filepath.Join(tmpDir, "queue.json"), []byte(queueData), 0600)
 
-	state := NewSyncState(tmpDir)
-	state.loadQueue()
-
-	if state.Queue.MaxSize != DefaultQueueMaxSize {
-		t.Errorf("Queue.MaxSize = %d, want %d", state.Queue.MaxSize, DefaultQueueMaxSize)
+	entries, err := NewFileStore(tmpDir).LoadQueue()
+	if err != nil {
+		t.Fatalf("LoadQueue failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("LoadQueue len = %d, want 1", len(entries))
 	}
 }
 
-// TestSyncStateLoadHistoryMaxSizeDefault tests loadHistory sets default max size.
-func TestSyncStateLoadHistoryMaxSizeDefault(t *testing.T) {
+// TestFileStoreLoadHistoryMaxSizeDefault tests loadHistory sets default max size.
+func TestFileStoreLoadHistoryMaxSizeDefault(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	// Write history file with MaxSize = 0
 	historyData := `{"entries": [], "max_size": 0}`
 	os.WriteFile(filepath.Join(tmpDir, "history.json"), []byte(historyData), 0600)
 
-	state := NewSyncState(tmpDir)
-	state.loadHistory()
+	store := NewFileStore(tmpDir).(*fileStore)
+	history, err := store.loadHistory()
+	if err != nil {
+		t.Fatalf("loadHistory failed: %v", err)
+	}
 
-	if state.History.MaxSize != DefaultHistoryMaxSize {
-		t.Errorf("History.MaxSize = %d, want %d", state.History.MaxSize, DefaultHistoryMaxSize)
+	if history.MaxSize != DefaultHistoryMaxSize {
+		t.Errorf("History.MaxSize = %d, want %d", history.MaxSize, DefaultHistoryMaxSize)
 	}
 }
 
@@ -0,0 +1,112 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sync transports. A machine without one uses SSH.
+const (
+	TransportSSH   = "ssh"
+	TransportHTTPS = "https"
+)
+
+// RemoteFS is a connection to a machine's caam data. Paths are slash
+// separated. SSHClient reaches a peer's home directory over SFTP;
+// HTTPSClient reaches an encrypted copy of the vault on a WebDAV server.
+type RemoteFS interface {
+	// Connect establishes the connection.
+	Connect(opts ConnectOptions) error
+
+	// Disconnect closes the connection.
+	Disconnect() error
+
+	// IsConnected reports whether the connection is usable.
+	IsConnected() bool
+
+	// Machine returns the machine this connection is for.
+	Machine() *Machine
+
+	// ReadFile reads a remote file.
+	ReadFile(remotePath string) ([]byte, error)
+
+	// WriteFile writes a remote file, creating parent directories.
+	WriteFile(remotePath string, data []byte, mode os.FileMode) error
+
+	// FileExists reports whether a remote file or directory exists.
+	FileExists(remotePath string) (bool, error)
+
+	// ListDir lists a remote directory.
+	ListDir(remotePath string) ([]os.FileInfo, error)
+}
+
+// NewRemoteFS returns an unconnected client for the machine's transport.
+func NewRemoteFS(m *Machine) RemoteFS {
+	if m.TransportName() == TransportHTTPS {
+		return NewHTTPSClient(m)
+	}
+	return NewSSHClient(m)
+}
+
+// ValidTransport reports whether name is a known transport.
+func ValidTransport(name string) bool {
+	return name == TransportSSH || name == TransportHTTPS
+}
+
+// passphraseEnv and passphraseFileName locate the passphrase that encrypts
+// profiles stored on HTTPS endpoints. Every machine sharing an endpoint must
+// use the same passphrase.
+const (
+	passphraseEnv      = "CAAM_SYNC_PASSPHRASE"
+	passphraseFileName = "passphrase"
+)
+
+// PassphrasePath returns the file the sync passphrase is read from when
+// CAAM_SYNC_PASSPHRASE is unset.
+func PassphrasePath() string {
+	return filepath.Join(SyncDataDir(), passphraseFileName)
+}
+
+// SyncPassphrase returns the passphrase for HTTPS sync endpoints and the
+// object storage hub.
+func SyncPassphrase() (string, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return p, nil
+	}
+	data, err := os.ReadFile(PassphrasePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("encrypted sync needs a passphrase: set %s or write it to %s", passphraseEnv, PassphrasePath())
+		}
+		return "", fmt.Errorf("read sync passphrase: %w", err)
+	}
+	p := strings.TrimRight(string(data), "\r\n")
+	if p == "" {
+		return "", fmt.Errorf("sync passphrase file %s is empty", PassphrasePath())
+	}
+	return p, nil
+}
+
+// remoteFileInfo is an os.FileInfo for an entry in a remote listing.
+type remoteFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (fi *remoteFileInfo) Name() string       { return fi.name }
+func (fi *remoteFileInfo) Size() int64        { return fi.size }
+func (fi *remoteFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *remoteFileInfo) IsDir() bool        { return fi.dir }
+func (fi *remoteFileInfo) Sys() interface{}   { return nil }
+
+func (fi *remoteFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0700
+	}
+	return 0600
+}
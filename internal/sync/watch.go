@@ -0,0 +1,370 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/discovery"
+)
+
+// DefaultWatchDebounce is how long Watch waits after the last write to a
+// file before acting, so a CLI rewriting its credentials in several steps
+// is handled once.
+const DefaultWatchDebounce = 2 * time.Second
+
+// Actions reported in WatchEvent.Action.
+const (
+	// WatchBackedUp means a CLI refreshed its live token and the fresh
+	// token was saved to the profile it came from.
+	WatchBackedUp = "backed_up"
+	// WatchPushed means a changed vault profile was synced with the pool.
+	WatchPushed = "pushed"
+	// WatchSkipped means a live change was not saved, e.g. because it
+	// belongs to no vault profile.
+	WatchSkipped = "skipped"
+	// WatchFailed means a backup or push failed.
+	WatchFailed = "failed"
+)
+
+// WatchConfig configures Watch.
+type WatchConfig struct {
+	// Vault holds the profiles refreshed tokens are saved to.
+	Vault *authfile.Vault
+
+	// Providers to watch. Default: claude, codex, gemini.
+	Providers []string
+
+	// Debounce is how long to wait after the last change. Default:
+	// DefaultWatchDebounce.
+	Debounce time.Duration
+
+	// Push syncs one profile with the pool. Default: PushProfile.
+	Push func(ctx context.Context, provider, profile string) ([]*SyncResult, error)
+
+	// OnEvent, if set, is called for every backup, push, and skip.
+	OnEvent func(WatchEvent)
+
+	// Paused, if set, is asked before acting on changes. Changes that
+	// settle while it returns true are dropped, not saved or pushed.
+	Paused func() bool
+}
+
+// WatchEvent reports something Watch did.
+type WatchEvent struct {
+	Time     time.Time
+	Provider string
+	Profile  string
+	Action   string
+	Message  string
+	// Results are the per-machine results of a push.
+	Results []*SyncResult
+	Err     error
+}
+
+// watchState is the state of one Watch call.
+type watchState struct {
+	cfg       WatchConfig
+	fsw       *fsnotify.Watcher
+	vaultDir  string
+	live      map[string]string // live auth file path -> provider
+	fileSets  map[string]authfile.AuthFileSet
+	active    map[string]string          // provider -> profile the live login came from
+	vaultFile map[string]map[string]bool // provider -> vault file names of auth files
+	pending   map[string]time.Time       // "live:<provider>" or "vault:<provider>/<profile>" -> last change
+}
+
+// Watch pushes auth changes to the sync pool as they happen, until ctx is
+// done. It watches the providers' live auth files and the vault:
+//
+//   - When a CLI refreshes its token in place, the live files stop matching
+//     any vault profile. Watch saves them to the profile that was active
+//     before, unless they belong to a different account.
+//   - When a profile's auth files change in the vault, by that backup or by
+//     any caam command, Watch syncs the profile with every pool machine.
+func Watch(ctx context.Context, cfg WatchConfig) error {
+	if cfg.Vault == nil {
+		return fmt.Errorf("watch: vault is required")
+	}
+	if len(cfg.Providers) == 0 {
+		cfg.Providers = []string{"claude", "codex", "gemini"}
+	}
+	if cfg.Debounce <= 0 {
+		cfg.Debounce = DefaultWatchDebounce
+	}
+	if cfg.Push == nil {
+		cfg.Push = PushProfile
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create fsnotify watcher: %w", err)
+	}
+	defer fsw.Close()
+
+	w := &watchState{
+		cfg:       cfg,
+		fsw:       fsw,
+		vaultDir:  filepath.Clean(cfg.Vault.BasePath()),
+		live:      make(map[string]string),
+		fileSets:  make(map[string]authfile.AuthFileSet),
+		active:    make(map[string]string),
+		vaultFile: make(map[string]map[string]bool),
+		pending:   make(map[string]time.Time),
+	}
+	if err := w.addWatches(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.cfg.Debounce / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			w.handle(event)
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			w.emit(WatchEvent{Action: WatchFailed, Message: "file watch error", Err: err})
+		case <-ticker.C:
+			w.processDue(ctx)
+		}
+	}
+}
+
+// addWatches watches the directories of the live auth files and every
+// provider and profile directory in the vault, and records which profile
+// each provider's live login belongs to.
+func (w *watchState) addWatches() error {
+	liveDirs := make(map[string]bool)
+	for _, provider := range w.cfg.Providers {
+		fileSet, ok := authfile.GetAuthFileSet(provider)
+		if !ok {
+			return fmt.Errorf("watch: unknown provider %q", provider)
+		}
+		w.fileSets[provider] = fileSet
+		w.vaultFile[provider] = make(map[string]bool)
+		for _, spec := range fileSet.Files {
+			w.live[filepath.Clean(spec.Path)] = provider
+			w.vaultFile[provider][spec.VaultFileName()] = true
+			liveDirs[filepath.Dir(spec.Path)] = true
+		}
+		if active, err := w.cfg.Vault.ActiveProfile(fileSet); err == nil {
+			w.active[provider] = active
+		}
+	}
+	for dir := range liveDirs {
+		// A CLI that has never logged in may not have its directory yet.
+		if _, err := os.Stat(dir); err == nil {
+			if err := w.fsw.Add(dir); err != nil {
+				return fmt.Errorf("watch %s: %w", dir, err)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(w.vaultDir, 0700); err != nil {
+		return fmt.Errorf("create vault dir: %w", err)
+	}
+	if err := w.fsw.Add(w.vaultDir); err != nil {
+		return fmt.Errorf("watch %s: %w", w.vaultDir, err)
+	}
+	for _, provider := range w.cfg.Providers {
+		w.addVaultDir(filepath.Join(w.vaultDir, provider), false)
+	}
+	return nil
+}
+
+// addVaultDir watches a vault provider directory and its profile
+// directories, or a single profile directory. Profiles that appear after
+// Watch started are pushed, since their files may have been written before
+// the watch was added.
+func (w *watchState) addVaultDir(dir string, isNew bool) {
+	if err := w.fsw.Add(dir); err != nil {
+		return
+	}
+	provider, profile, ok := w.vaultProfile(dir)
+	if ok {
+		if profile != "" && isNew {
+			w.pending["vault:"+provider+"/"+profile] = time.Now()
+		}
+		if profile != "" {
+			return
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			w.addVaultDir(filepath.Join(dir, e.Name()), isNew)
+		}
+	}
+}
+
+// vaultProfile splits a path in the vault into the provider and profile
+// directories it is in. profile is "" for a provider directory.
+func (w *watchState) vaultProfile(path string) (provider, profile string, ok bool) {
+	rel, err := filepath.Rel(w.vaultDir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", "", false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if _, watched := w.fileSets[parts[0]]; !watched {
+		return "", "", false
+	}
+	if len(parts) == 1 {
+		return parts[0], "", true
+	}
+	return parts[0], parts[1], true
+}
+
+// handle records a file change to act on once the debounce expires.
+func (w *watchState) handle(event fsnotify.Event) {
+	if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+		return
+	}
+	name := filepath.Clean(event.Name)
+	if provider, ok := w.live[name]; ok {
+		w.pending["live:"+provider] = time.Now()
+		return
+	}
+
+	provider, profile, ok := w.vaultProfile(name)
+	if !ok {
+		return
+	}
+	rel, _ := filepath.Rel(filepath.Join(w.vaultDir, provider, profile), name)
+	if rel == "." || profile == "" {
+		// A new provider or profile directory.
+		if info, err := os.Stat(name); err == nil && info.IsDir() && event.Op&fsnotify.Create != 0 {
+			w.addVaultDir(name, true)
+		}
+		return
+	}
+	if w.vaultFile[provider][filepath.Base(name)] && filepath.Dir(rel) == "." {
+		w.pending["vault:"+provider+"/"+profile] = time.Now()
+	}
+}
+
+// processDue acts on the changes whose debounce has expired. Live changes
+// go first, so a refreshed token is saved before its profile is pushed.
+func (w *watchState) processDue(ctx context.Context) {
+	now := time.Now()
+	var live, vault []string
+	for key, at := range w.pending {
+		if now.Sub(at) < w.cfg.Debounce {
+			continue
+		}
+		delete(w.pending, key)
+		if w.cfg.Paused != nil && w.cfg.Paused() {
+			continue
+		}
+		if provider, ok := strings.CutPrefix(key, "live:"); ok {
+			live = append(live, provider)
+		} else {
+			vault = append(vault, strings.TrimPrefix(key, "vault:"))
+		}
+	}
+	for _, provider := range live {
+		w.processLive(provider)
+	}
+	for _, key := range vault {
+		if _, pending := w.pending["vault:"+key]; pending {
+			continue // Just backed up; push once the backup settles.
+		}
+		provider, profile, _ := strings.Cut(key, "/")
+		w.push(ctx, provider, profile)
+	}
+}
+
+// processLive saves a provider's live auth files to the profile they were
+// refreshed from.
+func (w *watchState) processLive(provider string) {
+	fileSet := w.fileSets[provider]
+	if !authfile.HasAuthFiles(fileSet) {
+		return
+	}
+	if active, err := w.cfg.Vault.ActiveProfile(fileSet); err == nil && active != "" {
+		// An activation, or a token already saved.
+		w.active[provider] = active
+		return
+	}
+
+	profile := w.active[provider]
+	if profile == "" {
+		w.emit(WatchEvent{Provider: provider, Action: WatchSkipped,
+			Message: fmt.Sprintf("live login matches no vault profile; save it with 'caam backup %s <profile>'", provider)})
+		return
+	}
+	if !w.sameAccount(fileSet, profile) {
+		w.active[provider] = ""
+		w.emit(WatchEvent{Provider: provider, Profile: profile, Action: WatchSkipped,
+			Message: "live login belongs to a different account than the profile it replaced"})
+		return
+	}
+
+	if err := w.cfg.Vault.Backup(fileSet, profile); err != nil {
+		w.emit(WatchEvent{Provider: provider, Profile: profile, Action: WatchFailed, Message: "backup refreshed token", Err: err})
+		return
+	}
+	w.emit(WatchEvent{Provider: provider, Profile: profile, Action: WatchBackedUp, Message: "saved refreshed token"})
+	w.pending["vault:"+provider+"/"+profile] = time.Now()
+}
+
+// sameAccount reports whether the live auth files and a vault profile
+// belong to the same account, as far as their files tell.
+func (w *watchState) sameAccount(fileSet authfile.AuthFileSet, profile string) bool {
+	tool := discovery.Tool(fileSet.Tool)
+	for _, spec := range fileSet.Files {
+		liveID, _ := discovery.ExtractIdentity(tool, spec.Path)
+		if liveID == "" {
+			continue
+		}
+		vaultID, _ := discovery.ExtractIdentity(tool, w.cfg.Vault.BackupPath(fileSet.Tool, profile, spec.VaultFileName()))
+		if vaultID != "" && !strings.EqualFold(liveID, vaultID) {
+			return false
+		}
+	}
+	return true
+}
+
+// push syncs a changed vault profile with the pool.
+func (w *watchState) push(ctx context.Context, provider, profile string) {
+	if authfile.IsSystemProfile(profile) {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(w.vaultDir, provider, profile)); err != nil {
+		return // Deleted
+	}
+	results, err := w.cfg.Push(ctx, provider, profile)
+	event := WatchEvent{Provider: provider, Profile: profile, Action: WatchPushed, Results: results, Err: err}
+	stats := AggregateResults(results)
+	event.Message = fmt.Sprintf("%d pushed, %d pulled, %d up to date, %d failed", stats.Pushed, stats.Pulled, stats.Skipped, stats.Failed)
+	if err != nil || stats.Failed > 0 {
+		event.Action = WatchFailed
+	}
+	w.emit(event)
+}
+
+func (w *watchState) emit(event WatchEvent) {
+	if w.cfg.OnEvent == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	w.cfg.OnEvent(event)
+}
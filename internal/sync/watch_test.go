@@ -0,0 +1,159 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+func TestWatchBacksUpRefreshedTokenAndPushes(t *testing.T) {
+	codexHome := t.TempDir()
+	t.Setenv("CODEX_HOME", codexHome)
+	livePath := filepath.Join(codexHome, "auth.json")
+	vault := authfile.NewVault(filepath.Join(t.TempDir(), "vault"))
+
+	if err := os.WriteFile(livePath, []byte(`{"email":"me@example.com","token":"old"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fileSet, _ := authfile.GetAuthFileSet("codex")
+	if err := vault.Backup(fileSet, "work"); err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan WatchEvent, 16)
+	pushed := make(chan string, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, WatchConfig{
+			Vault:     vault,
+			Providers: []string{"codex"},
+			Debounce:  50 * time.Millisecond,
+			Push: func(ctx context.Context, provider, profile string) ([]*SyncResult, error) {
+				pushed <- provider + "/" + profile
+				return nil, nil
+			},
+			OnEvent: func(e WatchEvent) { events <- e },
+		})
+	}()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	}()
+	time.Sleep(100 * time.Millisecond) // Let the watches be added
+
+	// The CLI refreshes its token in place.
+	refreshed := `{"email":"me@example.com","token":"new"}`
+	if err := os.WriteFile(livePath, []byte(refreshed), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if e := nextWatchEvent(t, events); e.Action != WatchBackedUp || e.Profile != "work" {
+		t.Fatalf("event = %+v, want backed_up codex/work", e)
+	}
+	if data, _ := vault.ReadProfileFile("codex", "work", "auth.json"); string(data) != refreshed {
+		t.Errorf("vault auth.json = %s, want the refreshed token", data)
+	}
+	select {
+	case p := <-pushed:
+		if p != "codex/work" {
+			t.Errorf("pushed %s, want codex/work", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("refreshed profile was not pushed")
+	}
+	if e := nextWatchEvent(t, events); e.Action != WatchPushed {
+		t.Fatalf("event = %+v, want pushed", e)
+	}
+
+	// Logging in to another account directly must not overwrite work.
+	if err := os.WriteFile(livePath, []byte(`{"email":"other@example.com","token":"x"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if e := nextWatchEvent(t, events); e.Action != WatchSkipped {
+		t.Fatalf("event = %+v, want skipped", e)
+	}
+	if data, _ := vault.ReadProfileFile("codex", "work", "auth.json"); string(data) != refreshed {
+		t.Errorf("vault auth.json = %s, overwritten by another account", data)
+	}
+}
+
+func TestWatchPausedDropsChanges(t *testing.T) {
+	codexHome := t.TempDir()
+	t.Setenv("CODEX_HOME", codexHome)
+	livePath := filepath.Join(codexHome, "auth.json")
+	vault := authfile.NewVault(filepath.Join(t.TempDir(), "vault"))
+
+	original := `{"email":"me@example.com","token":"old"}`
+	if err := os.WriteFile(livePath, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fileSet, _ := authfile.GetAuthFileSet("codex")
+	if err := vault.Backup(fileSet, "work"); err != nil {
+		t.Fatal(err)
+	}
+
+	var paused atomic.Bool
+	paused.Store(true)
+	events := make(chan WatchEvent, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, WatchConfig{
+			Vault:     vault,
+			Providers: []string{"codex"},
+			Debounce:  50 * time.Millisecond,
+			Push: func(ctx context.Context, provider, profile string) ([]*SyncResult, error) {
+				return nil, nil
+			},
+			OnEvent: func(e WatchEvent) { events <- e },
+			Paused:  paused.Load,
+		})
+	}()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	}()
+	time.Sleep(100 * time.Millisecond) // Let the watches be added
+
+	if err := os.WriteFile(livePath, []byte(`{"email":"me@example.com","token":"paused"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("event while paused: %+v", e)
+	case <-time.After(300 * time.Millisecond):
+	}
+	if data, _ := vault.ReadProfileFile("codex", "work", "auth.json"); string(data) != original {
+		t.Errorf("vault auth.json = %s, saved while paused", data)
+	}
+
+	// Once resumed, the next change is saved.
+	paused.Store(false)
+	refreshed := `{"email":"me@example.com","token":"new"}`
+	if err := os.WriteFile(livePath, []byte(refreshed), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if e := nextWatchEvent(t, events); e.Action != WatchBackedUp || e.Profile != "work" {
+		t.Fatalf("event = %+v, want backed_up codex/work", e)
+	}
+}
+
+func nextWatchEvent(t *testing.T, events <-chan WatchEvent) WatchEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no watch event")
+		return WatchEvent{}
+	}
+}
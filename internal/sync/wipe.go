@@ -0,0 +1,780 @@
+package sync
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+)
+
+// Remote wipe.
+//
+// A wipe order is a signed instruction for a lost or retired machine to
+// delete its vault. The issuing machine keeps the order in wipe_orders/ and
+// copies it to every other pool member, so the whole pool stops syncing with
+// the target. Whichever member reaches the target first drops the order into
+// the target's sync directory as wipe_order.json, where the target's daemon
+// verifies the signature against its trusted keys and wipes itself.
+//
+// An order names the target's identity ID, learned when the issuer last
+// synced with it, so a copy planted on another pool member is refused there.
+// Each order has an ID; executed and cancelled IDs are recorded in
+// wipe_orders_done.json so an old order can't be replayed, and orders older
+// than WipeOrderMaxAge are refused.
+
+const (
+	wipeOrdersDirName     = "wipe_orders"
+	incomingWipeOrderName = "wipe_order.json"
+	wipeRecordName        = "wiped.json"
+	wipeOrdersDoneName    = "wipe_orders_done.json"
+	trustedKeysFileName   = "trusted_keys"
+)
+
+// WipeOrderMaxAge is how long a wipe order stays valid. A target that stays
+// offline longer needs a new order.
+const WipeOrderMaxAge = 30 * 24 * time.Hour
+
+// wipeClockSkew is how far in the future an order's IssuedAt may be.
+const wipeClockSkew = time.Hour
+
+// ErrWipePending is returned when syncing with a machine that has a pending
+// wipe order.
+var ErrWipePending = errors.New("machine has a pending remote wipe; sync refused")
+
+// WipeOrder instructs a machine to delete its vault.
+type WipeOrder struct {
+	// ID identifies the order, so it can be executed or cancelled only once.
+	ID string `json:"id"`
+
+	// TargetID is the target's LocalIdentity.ID. The target refuses orders
+	// for any other ID.
+	TargetID string `json:"target_id"`
+
+	// TargetName and TargetAddress identify the machine to wipe within the
+	// issuer's pool.
+	TargetName    string `json:"target_name"`
+	TargetAddress string `json:"target_address"`
+
+	// IssuedAt is when the order was created.
+	IssuedAt time.Time `json:"issued_at"`
+
+	// IssuerID and IssuerHost identify the machine that issued the order.
+	IssuerID   string `json:"issuer_id"`
+	IssuerHost string `json:"issuer_host"`
+
+	// SigningKey is the issuer's SSH public key (authorized_keys format).
+	SigningKey string `json:"signing_key"`
+
+	// Signature is the base64 SSH signature over the order without it.
+	Signature string `json:"signature,omitempty"`
+}
+
+// WipeRecord is left behind on a machine after it wipes itself.
+type WipeRecord struct {
+	Order   *WipeOrder `json:"order"`
+	WipedAt time.Time  `json:"wiped_at"`
+	Removed []string   `json:"removed"`
+	Errors  []string   `json:"errors,omitempty"`
+}
+
+// NewWipeOrder creates an unsigned wipe order for m.
+func NewWipeOrder(m *Machine, issuer *LocalIdentity) *WipeOrder {
+	o := &WipeOrder{
+		ID:            uuid.New().String(),
+		TargetID:      m.IdentityID,
+		TargetName:    m.Name,
+		TargetAddress: m.Address,
+		IssuedAt:      time.Now().UTC(),
+	}
+	if issuer != nil {
+		o.IssuerID = issuer.ID
+		o.IssuerHost = issuer.Hostname
+	}
+	return o
+}
+
+// signedPayload returns the bytes covered by the signature.
+func (o *WipeOrder) signedPayload() ([]byte, error) {
+	unsigned := *o
+	unsigned.Signature = ""
+	return json.Marshal(&unsigned)
+}
+
+// Sign signs the order with signer, recording its public key.
+func (o *WipeOrder) Sign(signer ssh.Signer) error {
+	o.SigningKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	payload, err := o.signedPayload()
+	if err != nil {
+		return err
+	}
+	o.Signature, err = signPayload(signer, payload)
+	if err != nil {
+		return fmt.Errorf("sign wipe order: %w", err)
+	}
+	return nil
+}
+
+// Verify checks that the order is signed by one of the trusted keys.
+func (o *WipeOrder) Verify(trusted []ssh.PublicKey) error {
+	payload, err := o.signedPayload()
+	if err != nil {
+		return err
+	}
+	return verifySignature("wipe order", o.SigningKey, o.Signature, payload, trusted)
+}
+
+// signPayload signs payload with signer, returning the base64 SSH
+// signature.
+func signPayload(signer ssh.Signer, payload []byte) (string, error) {
+	sig, err := signer.Sign(rand.Reader, payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ssh.Marshal(sig)), nil
+}
+
+// verifySignature checks that signature is a valid signature of payload by
+// signingKey (authorized_keys format), and that signingKey is trusted. what
+// names the signed item in errors.
+func verifySignature(what, signingKey, signature string, payload []byte, trusted []ssh.PublicKey) error {
+	if signature == "" {
+		return fmt.Errorf("%s is not signed", what)
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(signingKey))
+	if err != nil {
+		return fmt.Errorf("parse signing key: %w", err)
+	}
+
+	isTrusted := false
+	for _, t := range trusted {
+		if bytes.Equal(t.Marshal(), key.Marshal()) {
+			isTrusted = true
+			break
+		}
+	}
+	if !isTrusted {
+		return fmt.Errorf("%s signed by untrusted key %s", what, ssh.FingerprintSHA256(key))
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+	var sig ssh.Signature
+	if err := ssh.Unmarshal(raw, &sig); err != nil {
+		return fmt.Errorf("parse signature: %w", err)
+	}
+	if err := key.Verify(payload, &sig); err != nil {
+		return fmt.Errorf("%s signature invalid: %w", what, err)
+	}
+	return nil
+}
+
+// Matches reports whether the order targets m. Machines are matched by
+// address as well as name, since each pool member names peers itself.
+func (o *WipeOrder) Matches(m *Machine) bool {
+	if m == nil {
+		return false
+	}
+	if o.TargetAddress != "" && strings.EqualFold(o.TargetAddress, m.Address) {
+		return true
+	}
+	return o.TargetName != "" && strings.EqualFold(o.TargetName, m.Name)
+}
+
+// fileName returns the name the order is stored under in wipe_orders/.
+func (o *WipeOrder) fileName() string {
+	name := o.TargetAddress
+	if name == "" {
+		name = o.TargetName
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	return name + ".json"
+}
+
+// LoadSigningKey loads an SSH private key for signing wipe orders. An empty
+// path tries the default key locations (~/.ssh/id_ed25519, id_rsa, ...).
+func LoadSigningKey(path string) (ssh.Signer, error) {
+	if path != "" {
+		return loadSSHKey(path)
+	}
+	for _, p := range defaultKeyPaths() {
+		if signer, err := loadSSHKey(p); err == nil {
+			return signer, nil
+		}
+	}
+	return nil, fmt.Errorf("no usable SSH key found in ~/.ssh (use --key)")
+}
+
+// TrustedKeysPath returns the file listing keys allowed to wipe this
+// machine and hand it pool keys, in authorized_keys format.
+func TrustedKeysPath() string {
+	return filepath.Join(SyncDataDir(), trustedKeysFileName)
+}
+
+// TrustedKeys returns the keys allowed to wipe this machine and hand it pool
+// keys: TrustedKeysPath
+// if it exists, otherwise ~/.ssh/authorized_keys (keys that can already sync
+// into this machine).
+func TrustedKeys() ([]ssh.PublicKey, error) {
+	data, err := os.ReadFile(TrustedKeysPath())
+	if os.IsNotExist(err) {
+		home, herr := os.UserHomeDir()
+		if herr != nil {
+			return nil, herr
+		}
+		data, err = os.ReadFile(filepath.Join(home, ".ssh", "authorized_keys"))
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read trusted keys: %w", err)
+	}
+
+	var keys []ssh.PublicKey
+	for len(bytes.TrimSpace(data)) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			break
+		}
+		keys = append(keys, key)
+		data = rest
+	}
+	return keys, nil
+}
+
+// ============================================================================
+// Issuer side
+// ============================================================================
+
+func wipeOrdersDir() string {
+	return filepath.Join(SyncDataDir(), wipeOrdersDirName)
+}
+
+// SaveWipeOrder records a pending wipe order locally.
+func SaveWipeOrder(o *WipeOrder) error {
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal wipe order: %w", err)
+	}
+	dir := wipeOrdersDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("create wipe orders dir: %w", err)
+	}
+	return atomicWriteFile(filepath.Join(dir, o.fileName()), data, 0600)
+}
+
+// LoadWipeOrders returns the pending wipe orders known to this machine.
+func LoadWipeOrders() ([]*WipeOrder, error) {
+	entries, err := os.ReadDir(wipeOrdersDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read wipe orders: %w", err)
+	}
+
+	done := loadDoneWipeOrders()
+	var orders []*WipeOrder
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(wipeOrdersDir(), e.Name()))
+		if err != nil {
+			continue
+		}
+		var o WipeOrder
+		if err := json.Unmarshal(data, &o); err != nil {
+			continue
+		}
+		if _, ok := done[o.ID]; ok && o.ID != "" {
+			// Cancelled elsewhere in the pool.
+			_ = os.Remove(filepath.Join(wipeOrdersDir(), e.Name()))
+			continue
+		}
+		orders = append(orders, &o)
+	}
+	return orders, nil
+}
+
+// CancelWipeOrder removes the local pending wipe order for m and records
+// its ID as cancelled. It reports whether one existed. The cancellation
+// reaches other machines, including the target, on their next sync.
+func CancelWipeOrder(m *Machine) (bool, error) {
+	orders, err := LoadWipeOrders()
+	if err != nil {
+		return false, err
+	}
+	found := false
+	for _, o := range orders {
+		if o.Matches(m) {
+			if err := recordDoneWipeOrder(o.ID, wipeCancelled); err != nil {
+				return found, err
+			}
+			if err := os.Remove(filepath.Join(wipeOrdersDir(), o.fileName())); err != nil && !os.IsNotExist(err) {
+				return found, fmt.Errorf("remove wipe order: %w", err)
+			}
+			found = true
+		}
+	}
+	return found, nil
+}
+
+// PendingWipeOrder returns the wipe order targeting m, if any.
+func PendingWipeOrder(m *Machine) *WipeOrder {
+	orders, err := LoadWipeOrders()
+	if err != nil {
+		return nil
+	}
+	for _, o := range orders {
+		if o.Matches(m) {
+			return o
+		}
+	}
+	return nil
+}
+
+// WipeDelivery reports how a wipe order reached one machine.
+type WipeDelivery struct {
+	Machine string `json:"machine"`
+	Target  bool   `json:"target"`
+	Error   string `json:"error,omitempty"`
+}
+
+// remoteSyncDir returns the remote sync data directory, a sibling of the
+// remote vault.
+func (s *Syncer) remoteSyncDir() string {
+	return posixJoin(posixDir(s.remoteVaultPath), "sync")
+}
+
+// deliverWipeOrder writes the order into the target's sync directory.
+func (s *Syncer) deliverWipeOrder(m *Machine, o *WipeOrder) error {
+	if m.TransportName() != TransportSSH {
+		return errWipeNeedsSSH
+	}
+	client, err := s.pool.Get(m)
+	if err != nil {
+		m.SetError(err.Error())
+		return err
+	}
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := client.WriteFile(posixJoin(s.remoteSyncDir(), incomingWipeOrderName), data, 0600); err != nil {
+		return fmt.Errorf("write wipe order: %w", err)
+	}
+	if m.WipeDeliveredAt.IsZero() {
+		m.WipeDeliveredAt = time.Now()
+	}
+	return nil
+}
+
+// refuseWipedMachine delivers any pending wipe order for m and reports
+// whether syncing with m must be refused.
+func (s *Syncer) refuseWipedMachine(m *Machine) bool {
+	o := PendingWipeOrder(m)
+	if o == nil && m.WipeRequestedAt.IsZero() {
+		return false
+	}
+	if o != nil {
+		_ = s.deliverWipeOrder(m, o)
+	}
+	return true
+}
+
+// IssueWipe orders the named pool machine to wipe itself. The order is
+// signed with signer, recorded locally, sent to the target, and copied to
+// every other machine in the pool so they refuse to sync with the target
+// too. Unreachable targets get the order on the next sync attempt.
+func (s *Syncer) IssueWipe(name string, signer ssh.Signer) (*WipeOrder, []WipeDelivery, error) {
+	if s.state.Pool == nil {
+		return nil, nil, fmt.Errorf("sync pool is empty")
+	}
+	m := s.state.Pool.GetMachineByName(name)
+	if m == nil {
+		return nil, nil, fmt.Errorf("machine %q not found in pool", name)
+	}
+
+	if m.IdentityID == "" {
+		if client, err := s.pool.Get(m); err == nil {
+			s.learnIdentity(client)
+		}
+	}
+	if m.IdentityID == "" {
+		return nil, nil, fmt.Errorf("identity of %q is unknown; sync with it once before ordering a wipe", name)
+	}
+
+	issuer, err := GetOrCreateLocalIdentity()
+	if err != nil {
+		return nil, nil, err
+	}
+	o := NewWipeOrder(m, issuer)
+	if err := o.Sign(signer); err != nil {
+		return nil, nil, err
+	}
+	if err := SaveWipeOrder(o); err != nil {
+		return nil, nil, err
+	}
+	m.WipeRequestedAt = o.IssuedAt
+
+	return o, s.distributeWipeOrder(o), nil
+}
+
+// CancelWipe withdraws the local wipe order for the named machine so sync
+// with it resumes. The cancellation is shared on the next sync with each
+// machine, and an order already delivered to the target is then refused.
+func (s *Syncer) CancelWipe(name string) (bool, error) {
+	if s.state.Pool == nil {
+		return false, fmt.Errorf("sync pool is empty")
+	}
+	m := s.state.Pool.GetMachineByName(name)
+	if m == nil {
+		return false, fmt.Errorf("machine %q not found in pool", name)
+	}
+	found, err := CancelWipeOrder(m)
+	if err != nil {
+		return found, err
+	}
+	pending := !m.WipeRequestedAt.IsZero()
+	m.WipeRequestedAt = time.Time{}
+	m.WipeDeliveredAt = time.Time{}
+	return found || pending, nil
+}
+
+// distributeWipeOrder sends o to its target and shares it with every other
+// machine in the pool.
+func (s *Syncer) distributeWipeOrder(o *WipeOrder) []WipeDelivery {
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return nil
+	}
+
+	var deliveries []WipeDelivery
+	for _, m := range s.state.Pool.ListMachines() {
+		d := WipeDelivery{Machine: m.Name, Target: o.Matches(m)}
+		if d.Target {
+			err = s.deliverWipeOrder(m, o)
+		} else {
+			err = s.shareWipeOrder(m, o.fileName(), data)
+		}
+		if err != nil {
+			d.Error = err.Error()
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries
+}
+
+// shareWipeOrder copies a wipe order into a peer's wipe_orders directory.
+func (s *Syncer) shareWipeOrder(m *Machine, name string, data []byte) error {
+	if m.TransportName() != TransportSSH {
+		return errWipeNeedsSSH
+	}
+	client, err := s.pool.Get(m)
+	if err != nil {
+		m.SetError(err.Error())
+		return err
+	}
+	if err := client.WriteFile(posixJoin(s.remoteSyncDir(), wipeOrdersDirName, name), data, 0600); err != nil {
+		return fmt.Errorf("share wipe order: %w", err)
+	}
+	return nil
+}
+
+// shareWipeOrders copies every pending wipe order and every cancellation to
+// a peer, so orders that couldn't be shared when issued reach it on a later
+// sync.
+func (s *Syncer) shareWipeOrders(m *Machine) {
+	s.shareWipeCancellations(m)
+	orders, err := LoadWipeOrders()
+	if err != nil {
+		return
+	}
+	for _, o := range orders {
+		if o.Matches(m) {
+			continue
+		}
+		data, err := json.MarshalIndent(o, "", "  ")
+		if err != nil {
+			continue
+		}
+		_ = s.shareWipeOrder(m, o.fileName(), data)
+	}
+}
+
+// shareWipeCancellations merges this machine's cancelled order IDs into a
+// peer's done record.
+func (s *Syncer) shareWipeCancellations(m *Machine) {
+	var cancelled []string
+	for id, d := range loadDoneWipeOrders() {
+		if d.State == wipeCancelled {
+			cancelled = append(cancelled, id)
+		}
+	}
+	if len(cancelled) == 0 || m.TransportName() != TransportSSH {
+		return
+	}
+	client, err := s.pool.Get(m)
+	if err != nil {
+		return
+	}
+	path := posixJoin(s.remoteSyncDir(), wipeOrdersDoneName)
+	remote := make(map[string]doneWipeOrder)
+	if data, err := client.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &remote)
+	}
+	changed := false
+	for _, id := range cancelled {
+		if _, ok := remote[id]; !ok {
+			remote[id] = doneWipeOrder{State: wipeCancelled, At: time.Now().UTC()}
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	if data, err := json.MarshalIndent(remote, "", "  "); err == nil {
+		_ = client.WriteFile(path, data, 0600)
+	}
+}
+
+// learnIdentity records the identity ID of the machine client is connected
+// to, which wipe orders for it must name.
+func (s *Syncer) learnIdentity(client RemoteFS) {
+	m := client.Machine()
+	if m.TransportName() != TransportSSH {
+		return
+	}
+	data, err := client.ReadFile(posixJoin(s.remoteSyncDir(), identityFileName))
+	if err != nil {
+		return
+	}
+	var id LocalIdentity
+	if err := json.Unmarshal(data, &id); err == nil && id.ID != "" {
+		m.IdentityID = id.ID
+	}
+}
+
+// Done wipe order states.
+const (
+	wipeExecuted  = "executed"
+	wipeCancelled = "cancelled"
+)
+
+// doneWipeOrder records that an order was executed or cancelled.
+type doneWipeOrder struct {
+	State string    `json:"state"`
+	At    time.Time `json:"at"`
+}
+
+func doneWipeOrdersPath() string {
+	return filepath.Join(SyncDataDir(), wipeOrdersDoneName)
+}
+
+// loadDoneWipeOrders returns the executed and cancelled orders by ID.
+func loadDoneWipeOrders() map[string]doneWipeOrder {
+	done := make(map[string]doneWipeOrder)
+	if data, err := os.ReadFile(doneWipeOrdersPath()); err == nil {
+		_ = json.Unmarshal(data, &done)
+	}
+	return done
+}
+
+// recordDoneWipeOrder records that the order id was executed or cancelled.
+func recordDoneWipeOrder(id, state string) error {
+	if id == "" {
+		return nil
+	}
+	done := loadDoneWipeOrders()
+	if _, ok := done[id]; ok {
+		return nil
+	}
+	done[id] = doneWipeOrder{State: state, At: time.Now().UTC()}
+	data, err := json.MarshalIndent(done, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(SyncDataDir(), 0700); err != nil {
+		return fmt.Errorf("create sync dir: %w", err)
+	}
+	if err := atomicWriteFile(doneWipeOrdersPath(), data, 0600); err != nil {
+		return fmt.Errorf("record wipe order: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// Target side
+// ============================================================================
+
+// IncomingWipeOrderPath is where pool members deliver a wipe order for this
+// machine.
+func IncomingWipeOrderPath() string {
+	return filepath.Join(SyncDataDir(), incomingWipeOrderName)
+}
+
+// WipeRecordPath is where a machine records that it wiped itself.
+func WipeRecordPath() string {
+	return filepath.Join(SyncDataDir(), wipeRecordName)
+}
+
+// CheckIncomingWipe executes a delivered wipe order, deleting paths, if its
+// signature verifies against TrustedKeys, it names this machine's identity,
+// it is not older than WipeOrderMaxAge, and it has not been executed or
+// cancelled before. It returns nil if no order is waiting. Orders that fail
+// these checks are left in place and reported.
+func CheckIncomingWipe(paths []string) (*WipeRecord, error) {
+	data, err := os.ReadFile(IncomingWipeOrderPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read wipe order: %w", err)
+	}
+	var o WipeOrder
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("parse wipe order: %w", err)
+	}
+
+	trusted, err := TrustedKeys()
+	if err != nil {
+		return nil, err
+	}
+	if err := o.Verify(trusted); err != nil {
+		return nil, err
+	}
+	local, err := GetOrCreateLocalIdentity()
+	if err != nil {
+		return nil, err
+	}
+	if o.ID == "" || o.TargetID == "" {
+		return nil, fmt.Errorf("wipe order does not name its target's identity; reissue it")
+	}
+	if o.TargetID != local.ID {
+		return nil, fmt.Errorf("wipe order targets machine %s, not this one (%s)", o.TargetID, local.ID)
+	}
+	now := time.Now()
+	if now.Sub(o.IssuedAt) > WipeOrderMaxAge {
+		return nil, fmt.Errorf("wipe order %s was issued %s and has expired", o.ID, o.IssuedAt.Format(time.RFC3339))
+	}
+	if o.IssuedAt.Sub(now) > wipeClockSkew {
+		return nil, fmt.Errorf("wipe order %s is dated in the future (%s)", o.ID, o.IssuedAt.Format(time.RFC3339))
+	}
+	if d, ok := loadDoneWipeOrders()[o.ID]; ok {
+		return nil, fmt.Errorf("wipe order %s was already %s", o.ID, d.State)
+	}
+	// Record the order before wiping, so it can't run twice even if the
+	// wipe is interrupted.
+	if err := recordDoneWipeOrder(o.ID, wipeExecuted); err != nil {
+		return nil, err
+	}
+	return ExecuteWipe(&o, paths)
+}
+
+// ExecuteWipe overwrites and deletes every file under paths, disables the
+// sync pool, and records the wipe. It keeps going past errors so as much as
+// possible is removed.
+func ExecuteWipe(o *WipeOrder, paths []string) (*WipeRecord, error) {
+	record := &WipeRecord{Order: o, WipedAt: time.Now().UTC()}
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if err := shredPath(p); err != nil {
+			record.Errors = append(record.Errors, err.Error())
+			continue
+		}
+		record.Removed = append(record.Removed, p)
+	}
+
+	if pool, err := LoadSyncPool(); err == nil {
+		pool.Disable()
+		pool.DisableAutoSync()
+		if err := pool.Save(); err != nil {
+			record.Errors = append(record.Errors, err.Error())
+		}
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return record, err
+	}
+	if err := os.MkdirAll(SyncDataDir(), 0700); err != nil {
+		return record, fmt.Errorf("create sync dir: %w", err)
+	}
+	if err := atomicWriteFile(WipeRecordPath(), data, 0600); err != nil {
+		return record, fmt.Errorf("write wipe record: %w", err)
+	}
+	_ = os.Remove(IncomingWipeOrderPath())
+	return record, nil
+}
+
+// LoadWipeRecord returns the record of a completed wipe, or nil.
+func LoadWipeRecord() (*WipeRecord, error) {
+	data, err := os.ReadFile(WipeRecordPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var r WipeRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// shredPath zeroes every regular file under path before removing the tree.
+// On SSDs and copy-on-write filesystems overwriting is best-effort.
+func shredPath(path string) error {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return nil
+	}
+	_ = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.OpenFile(p, os.O_WRONLY, 0)
+		if err != nil {
+			return nil
+		}
+		zeros := make([]byte, 32*1024)
+		for remaining := info.Size(); remaining > 0; {
+			n := int64(len(zeros))
+			if remaining < n {
+				n = remaining
+			}
+			if _, err := f.Write(zeros[:n]); err != nil {
+				break
+			}
+			remaining -= n
+		}
+		_ = f.Sync()
+		f.Close()
+		return nil
+	})
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("remove %s: %w", path, err)
+	}
+	return nil
+}
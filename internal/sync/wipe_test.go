@@ -0,0 +1,233 @@
+package sync
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestWipeOrderSignVerify(t *testing.T) {
+	signer := newTestSigner(t)
+	other := newTestSigner(t)
+
+	o := NewWipeOrder(NewMachine("laptop", "10.0.0.5"), &LocalIdentity{ID: "id-1", Hostname: "desk"})
+	if err := o.Sign(signer); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	if err := o.Verify([]ssh.PublicKey{signer.PublicKey()}); err != nil {
+		t.Errorf("Verify() with signing key error = %v", err)
+	}
+	if err := o.Verify([]ssh.PublicKey{other.PublicKey()}); err == nil {
+		t.Error("Verify() should reject an untrusted key")
+	}
+
+	tampered := *o
+	tampered.TargetAddress = "10.0.0.6"
+	if err := tampered.Verify([]ssh.PublicKey{signer.PublicKey()}); err == nil {
+		t.Error("Verify() should reject a modified order")
+	}
+}
+
+func TestWipeOrderMatches(t *testing.T) {
+	o := &WipeOrder{TargetName: "laptop", TargetAddress: "10.0.0.5"}
+
+	tests := []struct {
+		name    string
+		machine *Machine
+		want    bool
+	}{
+		{"same name", NewMachine("laptop", "laptop.lan"), true},
+		{"same address", NewMachine("my-laptop", "10.0.0.5"), true},
+		{"different", NewMachine("desktop", "10.0.0.9"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := o.Matches(tt.machine); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPendingWipeOrder(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+
+	target := NewMachine("laptop", "10.0.0.5")
+	if PendingWipeOrder(target) != nil {
+		t.Fatal("no order should be pending yet")
+	}
+
+	o := NewWipeOrder(target, nil)
+	if err := o.Sign(newTestSigner(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveWipeOrder(o); err != nil {
+		t.Fatalf("SaveWipeOrder() error = %v", err)
+	}
+
+	if PendingWipeOrder(NewMachine("renamed", "10.0.0.5")) == nil {
+		t.Error("order should match the target by address")
+	}
+	if PendingWipeOrder(NewMachine("desktop", "10.0.0.9")) != nil {
+		t.Error("order should not match other machines")
+	}
+
+	found, err := CancelWipeOrder(target)
+	if err != nil || !found {
+		t.Fatalf("CancelWipeOrder() = %v, %v", found, err)
+	}
+	if PendingWipeOrder(target) != nil {
+		t.Error("order should be gone after cancel")
+	}
+}
+
+func TestCheckIncomingWipe(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CAAM_HOME", home)
+
+	vaultDir := filepath.Join(home, "data", "vault")
+	profileDir := filepath.Join(vaultDir, "claude", "work")
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, ".credentials.json"), []byte(`{"token":"x"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// No order waiting
+	if record, err := CheckIncomingWipe([]string{vaultDir}); record != nil || err != nil {
+		t.Fatalf("CheckIncomingWipe() without order = %v, %v", record, err)
+	}
+
+	local, err := GetOrCreateLocalIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := newTestSigner(t)
+	deliver := func(o *WipeOrder) {
+		t.Helper()
+		if err := o.Sign(signer); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(o)
+		if err := os.MkdirAll(SyncDataDir(), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(IncomingWipeOrderPath(), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	target := NewMachine("laptop", "10.0.0.5")
+	target.IdentityID = local.ID
+	o := NewWipeOrder(target, &LocalIdentity{Hostname: "desk"})
+	deliver(o)
+
+	// Untrusted signer: the order is rejected and the vault kept
+	if err := os.WriteFile(TrustedKeysPath(), ssh.MarshalAuthorizedKey(newTestSigner(t).PublicKey()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CheckIncomingWipe([]string{vaultDir}); err == nil {
+		t.Fatal("CheckIncomingWipe() should reject an untrusted order")
+	}
+	if _, err := os.Stat(profileDir); err != nil {
+		t.Fatal("vault should survive a rejected order")
+	}
+
+	if err := os.WriteFile(TrustedKeysPath(), ssh.MarshalAuthorizedKey(signer.PublicKey()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Trusted, but meant for another machine, expired or cancelled: refused
+	other := NewMachine("desktop", "10.0.0.9")
+	other.IdentityID = "some-other-machine"
+	expired := NewWipeOrder(target, nil)
+	expired.IssuedAt = time.Now().Add(-WipeOrderMaxAge - time.Hour)
+	cancelled := NewWipeOrder(target, nil)
+	if err := recordDoneWipeOrder(cancelled.ID, wipeCancelled); err != nil {
+		t.Fatal(err)
+	}
+	for name, bad := range map[string]*WipeOrder{
+		"other target": NewWipeOrder(other, nil),
+		"no target":    NewWipeOrder(NewMachine("x", "10.0.0.7"), nil),
+		"expired":      expired,
+		"cancelled":    cancelled,
+	} {
+		deliver(bad)
+		if _, err := CheckIncomingWipe([]string{vaultDir}); err == nil {
+			t.Fatalf("CheckIncomingWipe() accepted an order with %s", name)
+		}
+		if _, err := os.Stat(profileDir); err != nil {
+			t.Fatalf("vault should survive an order with %s", name)
+		}
+	}
+
+	// Trusted signer: the vault is wiped and the wipe recorded
+	deliver(o)
+	record, err := CheckIncomingWipe([]string{vaultDir})
+	if err != nil {
+		t.Fatalf("CheckIncomingWipe() error = %v", err)
+	}
+	if record == nil || len(record.Removed) != 1 {
+		t.Fatalf("record = %+v, want vault removed", record)
+	}
+	if _, err := os.Stat(vaultDir); !os.IsNotExist(err) {
+		t.Error("vault should be deleted")
+	}
+	if _, err := os.Stat(IncomingWipeOrderPath()); !os.IsNotExist(err) {
+		t.Error("incoming order should be consumed")
+	}
+	saved, err := LoadWipeRecord()
+	if err != nil || saved == nil || saved.Order.IssuerHost != "desk" {
+		t.Errorf("LoadWipeRecord() = %+v, %v", saved, err)
+	}
+
+	// The same order delivered again is not replayed
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	deliver(o)
+	if _, err := CheckIncomingWipe([]string{vaultDir}); err == nil {
+		t.Fatal("CheckIncomingWipe() replayed an executed order")
+	}
+	if _, err := os.Stat(profileDir); err != nil {
+		t.Error("vault should survive a replayed order")
+	}
+}
+
+func TestCancelledWipeOrderDropped(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+
+	target := NewMachine("laptop", "10.0.0.5")
+	o := NewWipeOrder(target, nil)
+	if err := SaveWipeOrder(o); err != nil {
+		t.Fatal(err)
+	}
+	// A cancellation shared by another pool member drops this copy.
+	if err := recordDoneWipeOrder(o.ID, wipeCancelled); err != nil {
+		t.Fatal(err)
+	}
+	if PendingWipeOrder(target) != nil {
+		t.Error("cancelled order should not be pending")
+	}
+}
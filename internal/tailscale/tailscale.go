@@ -5,7 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 )
 
@@ -63,15 +68,31 @@ type VersionInfo struct {
 // Client provides access to Tailscale status information.
 type Client struct {
 	binaryPath string
+	socketPath string // tailscaled local API socket; empty uses the CLI only
 }
 
 // NewClient creates a new Tailscale client.
 func NewClient() *Client {
 	return &Client{
 		binaryPath: "tailscale",
+		socketPath: DefaultSocketPath(),
 	}
 }
 
+// DefaultSocketPath returns the tailscaled local API socket if one exists.
+// macOS and Windows don't expose a Unix socket, so they use the CLI.
+func DefaultSocketPath() string {
+	if runtime.GOOS != "linux" && runtime.GOOS != "freebsd" {
+		return ""
+	}
+	for _, path := range []string{"/var/run/tailscale/tailscaled.sock", "/run/tailscale/tailscaled.sock"} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
 // IsAvailable checks if tailscaled is running and accessible.
 func (c *Client) IsAvailable(ctx context.Context) bool {
 	cmd := exec.CommandContext(ctx, c.binaryPath, "status", "--json")
@@ -119,9 +140,17 @@ func (c *Client) GetVersionString(ctx context.Context) string {
 	return info.Short
 }
 
-// GetStatus returns the current Tailscale status.
+// GetStatus returns the current Tailscale status, from the tailscaled local
+// API when its socket is available and from 'tailscale status --json'
+// otherwise.
 // Uses lenient JSON parsing to tolerate schema drift between tailscale versions.
 func (c *Client) GetStatus(ctx context.Context) (*Status, error) {
+	if c.socketPath != "" {
+		if output, err := c.localAPIStatus(ctx); err == nil {
+			return ParseStatus(output)
+		}
+	}
+
 	cmd := exec.CommandContext(ctx, c.binaryPath, "status", "--json")
 	output, err := cmd.Output()
 	if err != nil {
@@ -131,6 +160,32 @@ func (c *Client) GetStatus(ctx context.Context) (*Status, error) {
 	return ParseStatus(output)
 }
 
+// localAPIStatus fetches the status JSON from tailscaled's local API.
+func (c *Client) localAPIStatus(ctx context.Context) ([]byte, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", c.socketPath)
+			},
+		},
+	}
+	// The host is ignored by the dialer; tailscaled checks it matches.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://local-tailscaled.sock/localapi/v0/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("tailscale local API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tailscale local API: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+}
+
 // ParseStatus parses tailscale status JSON with lenient handling.
 // This is exported for testing with fixture data.
 func ParseStatus(data []byte) (*Status, error) {
@@ -1,8 +1,12 @@
 package tailscale
 
 import (
+	"context"
 	"encoding/json"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -541,3 +545,32 @@ func TestFixture_NoPeers(t *testing.T) {
 		t.Errorf("expected 0 peers, got %d", len(status.Peer))
 	}
 }
+
+// TestGetStatusFromLocalAPI tests reading status from tailscaled's socket
+// instead of the CLI.
+func TestGetStatusFromLocalAPI(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "tailscaled.sock")
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/localapi/v0/status" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(sampleStatusJSON))
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	// A missing binary proves the CLI isn't used.
+	c := &Client{binaryPath: filepath.Join(t.TempDir(), "no-tailscale"), socketPath: socketPath}
+	status, err := c.GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatus: %v", err)
+	}
+	if !status.IsRunning() || status.Self == nil || status.Self.HostName != "threadripperje" {
+		t.Errorf("status = %+v", status)
+	}
+}
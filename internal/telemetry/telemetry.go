@@ -0,0 +1,241 @@
+// Package telemetry implements caam's opt-in, anonymous feature-usage counters.
+//
+// Telemetry is off unless the user runs `caam telemetry enable`, and
+// CAAM_TELEMETRY=off or DO_NOT_TRACK=1 force it off regardless of that
+// setting. Counters are aggregated locally: only command names and counts are
+// kept, never arguments, profile names, paths, or tokens. Nothing leaves the
+// machine until the user runs `caam telemetry send`, and
+// `caam telemetry preview` prints the exact payload that would be sent.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/version"
+)
+
+// EnvVar forces telemetry off when set to "off", "0", or "false".
+const EnvVar = "CAAM_TELEMETRY"
+
+// EndpointEnvVar overrides the upload endpoint.
+const EndpointEnvVar = "CAAM_TELEMETRY_ENDPOINT"
+
+// PayloadSchema is the version of the Payload format.
+const PayloadSchema = 1
+
+const stateFileName = "telemetry.json"
+
+// State is the locally stored telemetry setting and aggregated counters.
+type State struct {
+	// Enabled is the user's opt-in. It defaults to false.
+	Enabled bool `json:"enabled"`
+
+	// InstallID is a random identifier, created on opt-in and discarded on
+	// opt-out, that lets uploads from one install be de-duplicated.
+	InstallID string `json:"install_id,omitempty"`
+
+	// Endpoint is where `caam telemetry send` uploads the payload.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// PeriodStart is when the current counters started accumulating.
+	PeriodStart time.Time `json:"period_start,omitempty"`
+
+	// Counters maps a feature (e.g. "caam activate") to its use count.
+	Counters map[string]int64 `json:"counters,omitempty"`
+
+	// LastSent is when counters were last uploaded.
+	LastSent time.Time `json:"last_sent,omitempty"`
+}
+
+// Payload is exactly what `caam telemetry send` uploads.
+type Payload struct {
+	Schema      int              `json:"schema"`
+	InstallID   string           `json:"install_id"`
+	Version     string           `json:"caam_version"`
+	OS          string           `json:"os"`
+	Arch        string           `json:"arch"`
+	PeriodStart time.Time        `json:"period_start"`
+	PeriodEnd   time.Time        `json:"period_end"`
+	Counters    map[string]int64 `json:"counters"`
+}
+
+// Path returns the telemetry state file path.
+func Path() string {
+	return filepath.Join(config.DefaultDataPath(), stateFileName)
+}
+
+// ForcedOff reports whether the environment disables telemetry outright.
+func ForcedOff() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(EnvVar))) {
+	case "off", "0", "false", "no":
+		return true
+	}
+	dnt := strings.TrimSpace(os.Getenv("DO_NOT_TRACK"))
+	return dnt != "" && dnt != "0"
+}
+
+// Load reads the telemetry state. A missing file means telemetry was never
+// enabled.
+func Load() (*State, error) {
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, fmt.Errorf("read telemetry state: %w", err)
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse telemetry state: %w", err)
+	}
+	return &s, nil
+}
+
+// Save writes the telemetry state atomically.
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal telemetry state: %w", err)
+	}
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create telemetry dir: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("write telemetry state: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("rename telemetry state: %w", err)
+	}
+	return nil
+}
+
+// Active reports whether counters are being collected.
+func (s *State) Active() bool {
+	return s.Enabled && !ForcedOff()
+}
+
+// Enable opts in, creating an install ID and starting a new period.
+func (s *State) Enable(endpoint string) error {
+	if !s.Enabled || s.InstallID == "" {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return fmt.Errorf("generate install id: %w", err)
+		}
+		s.InstallID = hex.EncodeToString(id)
+		s.PeriodStart = time.Now().UTC()
+		s.Counters = nil
+	}
+	s.Enabled = true
+	if endpoint != "" {
+		s.Endpoint = endpoint
+	}
+	return nil
+}
+
+// Disable opts out and discards the install ID and any unsent counters.
+func (s *State) Disable() {
+	*s = State{Endpoint: s.Endpoint}
+}
+
+// Record increments feature's counter if telemetry is active. It is a no-op,
+// and never touches disk, when telemetry is off.
+func Record(feature string) {
+	if ForcedOff() {
+		return
+	}
+	s, err := Load()
+	if err != nil || !s.Enabled {
+		return
+	}
+	if s.Counters == nil {
+		s.Counters = make(map[string]int64)
+	}
+	s.Counters[feature]++
+	_ = s.Save()
+}
+
+// Payload builds the upload payload from the current counters.
+func (s *State) Payload(now time.Time) *Payload {
+	counters := make(map[string]int64, len(s.Counters))
+	for k, v := range s.Counters {
+		counters[k] = v
+	}
+	return &Payload{
+		Schema:      PayloadSchema,
+		InstallID:   s.InstallID,
+		Version:     version.Short(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		PeriodStart: s.PeriodStart,
+		PeriodEnd:   now.UTC(),
+		Counters:    counters,
+	}
+}
+
+// UploadEndpoint returns the endpoint uploads go to, preferring
+// CAAM_TELEMETRY_ENDPOINT over the stored setting.
+func (s *State) UploadEndpoint() string {
+	if ep := strings.TrimSpace(os.Getenv(EndpointEnvVar)); ep != "" {
+		return ep
+	}
+	return s.Endpoint
+}
+
+// Send uploads the payload and, on success, resets the counters for a new
+// period.
+func (s *State) Send(ctx context.Context, client *http.Client) (*Payload, error) {
+	if !s.Active() {
+		return nil, fmt.Errorf("telemetry is disabled")
+	}
+	endpoint := s.UploadEndpoint()
+	if endpoint == "" {
+		return nil, fmt.Errorf("no telemetry endpoint configured (use --endpoint or %s)", EndpointEnvVar)
+	}
+	if !strings.HasPrefix(endpoint, "https://") && !strings.HasPrefix(endpoint, "http://localhost") && !strings.HasPrefix(endpoint, "http://127.0.0.1") {
+		return nil, fmt.Errorf("telemetry endpoint must use https")
+	}
+
+	now := time.Now()
+	payload := s.Payload(now)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("upload telemetry: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("upload telemetry: server returned %s", resp.Status)
+	}
+
+	s.Counters = nil
+	s.PeriodStart = now.UTC()
+	s.LastSent = now.UTC()
+	return payload, nil
+}
@@ -0,0 +1,142 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func setupHome(t *testing.T) {
+	t.Helper()
+	t.Setenv("CAAM_HOME", t.TempDir())
+	t.Setenv(EnvVar, "")
+	t.Setenv(EndpointEnvVar, "")
+	t.Setenv("DO_NOT_TRACK", "")
+}
+
+func TestRecordOffByDefault(t *testing.T) {
+	setupHome(t)
+
+	Record("caam activate")
+
+	if _, err := os.Stat(Path()); !os.IsNotExist(err) {
+		t.Fatal("Record() must not write anything before opt-in")
+	}
+	s, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Enabled || s.Active() {
+		t.Error("telemetry should be disabled by default")
+	}
+}
+
+func TestRecordAggregatesWhenEnabled(t *testing.T) {
+	setupHome(t)
+
+	s, _ := Load()
+	if err := s.Enable(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	Record("caam activate")
+	Record("caam activate")
+	Record("caam ls")
+
+	s, _ = Load()
+	if s.Counters["caam activate"] != 2 || s.Counters["caam ls"] != 1 {
+		t.Errorf("counters = %v", s.Counters)
+	}
+	if len(s.InstallID) != 32 {
+		t.Errorf("install id = %q, want 32 hex chars", s.InstallID)
+	}
+
+	p := s.Payload(time.Now())
+	if p.Schema != PayloadSchema || p.InstallID != s.InstallID || p.Counters["caam ls"] != 1 {
+		t.Errorf("payload = %+v", p)
+	}
+}
+
+func TestForcedOff(t *testing.T) {
+	setupHome(t)
+
+	s, _ := Load()
+	_ = s.Enable("")
+	_ = s.Save()
+
+	for _, env := range []struct{ key, val string }{{EnvVar, "off"}, {"DO_NOT_TRACK", "1"}} {
+		t.Run(env.key, func(t *testing.T) {
+			t.Setenv(env.key, env.val)
+			Record("caam ls")
+			s, _ := Load()
+			if s.Active() {
+				t.Error("Active() should be false")
+			}
+			if s.Counters["caam ls"] != 0 {
+				t.Error("Record() should not count while forced off")
+			}
+		})
+	}
+}
+
+func TestDisableDiscardsCounters(t *testing.T) {
+	setupHome(t)
+
+	s, _ := Load()
+	_ = s.Enable("https://example.com/t")
+	s.Counters = map[string]int64{"caam ls": 3}
+	s.Disable()
+
+	if s.Enabled || s.InstallID != "" || len(s.Counters) != 0 {
+		t.Errorf("state after Disable() = %+v", s)
+	}
+	if s.Endpoint != "https://example.com/t" {
+		t.Error("Disable() should keep the configured endpoint")
+	}
+}
+
+func TestSend(t *testing.T) {
+	setupHome(t)
+
+	var got Payload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	s, _ := Load()
+	if _, err := s.Send(context.Background(), srv.Client()); err == nil {
+		t.Error("Send() should fail while disabled")
+	}
+
+	_ = s.Enable("")
+	if _, err := s.Send(context.Background(), srv.Client()); err == nil {
+		t.Error("Send() should fail without an endpoint")
+	}
+
+	s.Endpoint = "http://example.com/t"
+	if _, err := s.Send(context.Background(), srv.Client()); err == nil {
+		t.Error("Send() should refuse plain http")
+	}
+
+	t.Setenv(EndpointEnvVar, srv.URL) // httptest uses 127.0.0.1
+	s.Counters = map[string]int64{"caam run": 5}
+	if _, err := s.Send(context.Background(), srv.Client()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got.Counters["caam run"] != 5 {
+		t.Errorf("server received %+v", got)
+	}
+	if len(s.Counters) != 0 || s.LastSent.IsZero() {
+		t.Error("Send() should reset counters and record LastSent")
+	}
+}
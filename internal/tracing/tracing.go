@@ -0,0 +1,118 @@
+// Package tracing records OpenTelemetry spans around caam's slow paths
+// (vault listing, health computation, database queries, provider API calls,
+// SSH sync transfers) and exports them over OTLP when enabled.
+//
+// Until Setup installs an exporter, the global tracer provider is OpenTelemetry's
+// no-op one, so Start costs next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/Dicklesworthstone/coding_agent_account_manager"
+
+// Options configure trace export.
+type Options struct {
+	// Endpoint is the OTLP/HTTP collector as host:port or a URL. Empty
+	// uses OTEL_EXPORTER_OTLP_ENDPOINT, or localhost:4318.
+	Endpoint string
+	// Insecure sends spans over plain HTTP.
+	Insecure bool
+	// Headers are sent with every export, e.g. an API key.
+	Headers map[string]string
+	// SampleRatio is the fraction of commands traced, from 0 to 1.
+	SampleRatio float64
+	// ServiceVersion is reported as service.version.
+	ServiceVersion string
+}
+
+// Setup starts exporting spans and returns a func that flushes and stops
+// the exporter; call it before the process exits.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	var exporterOpts []otlptracehttp.Option
+	if opts.Endpoint != "" {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithEndpointURL(endpointURL(opts.Endpoint, opts.Insecure)))
+	} else if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+	}
+	if len(opts.Headers) > 0 {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithHeaders(opts.Headers))
+	}
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName("caam"),
+		semconv.ServiceVersion(opts.ServiceVersion),
+	))
+	if err != nil {
+		res = resource.Default()
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return tp.Shutdown, nil
+}
+
+// endpointURL turns host:port into a URL; a URL is returned as given.
+func endpointURL(endpoint string, insecure bool) string {
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		return endpoint
+	}
+	if insecure {
+		return "http://" + endpoint
+	}
+	return "https://" + endpoint
+}
+
+// Start starts a span as a child of any span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, marking it failed if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the ID of the trace ctx belongs to, or "" if it is not
+// being recorded.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsSampled() || !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// Provider, Profile and Machine are the attributes spans are tagged with.
+func Provider(name string) attribute.KeyValue { return attribute.String("caam.provider", name) }
+func Profile(name string) attribute.KeyValue  { return attribute.String("caam.profile", name) }
+func Machine(name string) attribute.KeyValue  { return attribute.String("caam.machine", name) }
+
+// ShutdownTimeout bounds how long flushing spans may delay exit.
+const ShutdownTimeout = 5 * time.Second
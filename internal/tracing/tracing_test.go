@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestEndpointURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		insecure bool
+		want     string
+	}{
+		{"localhost:4318", true, "http://localhost:4318"},
+		{"collector:4318", false, "https://collector:4318"},
+		{"http://collector:4318/v1/traces", false, "http://collector:4318/v1/traces"},
+		{"https://collector", true, "https://collector"},
+	}
+	for _, tt := range tests {
+		if got := endpointURL(tt.endpoint, tt.insecure); got != tt.want {
+			t.Errorf("endpointURL(%q, %v) = %q, want %q", tt.endpoint, tt.insecure, got, tt.want)
+		}
+	}
+}
+
+func TestStartEnd(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	if id := TraceID(context.Background()); id != "" {
+		t.Errorf("TraceID without a span = %q, want empty", id)
+	}
+	ctx, root := Start(context.Background(), "root", Provider("claude"))
+	if TraceID(ctx) == "" {
+		t.Error("TraceID inside a span is empty")
+	}
+	_, child := Start(ctx, "child", Profile("work"))
+	End(child, errors.New("boom"))
+	End(root, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(spans))
+	}
+	c, r := spans[0], spans[1]
+	if c.Name != "child" || c.Parent.SpanID() != r.SpanContext.SpanID() {
+		t.Errorf("child %q not parented to root", c.Name)
+	}
+	if c.Status.Code != codes.Error || c.Status.Description != "boom" {
+		t.Errorf("child status = %v %q, want error boom", c.Status.Code, c.Status.Description)
+	}
+	if r.Status.Code == codes.Error {
+		t.Error("root marked failed")
+	}
+	if len(r.Attributes) != 1 || r.Attributes[0] != Provider("claude") {
+		t.Errorf("root attributes = %v", r.Attributes)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/browser"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
@@ -1530,13 +1531,14 @@ func (m Model) doActivateProfile(provider, profile string) tea.Cmd {
 		}
 
 		vault := authfile.NewVault(m.vaultPath)
-		if err := vault.Restore(fileSet, profile); err != nil {
+		if err := vault.Activate(fileSet, profile, "tui"); err != nil {
 			return activateResultMsg{
 				provider: provider,
 				profile:  profile,
 				err:      err,
 			}
 		}
+		events.PublishActivated(provider, profile, "tui")
 
 		return activateResultMsg{
 			provider: provider,
@@ -2057,6 +2059,13 @@ func vaultIdentityEmail(provider, profileDir string) string {
 		if id == nil {
 			id, _ = identity.ExtractFromGeminiConfig(filepath.Join(profileDir, "oauth_credentials.json"))
 		}
+	case "cursor":
+		id, _ = identity.ExtractFromCursorAuth(filepath.Join(profileDir, "auth.json"))
+	case "copilot":
+		id, _ = identity.ExtractFromCopilotHosts(filepath.Join(profileDir, "hosts.json"))
+		if id == nil {
+			id, _ = identity.ExtractFromCopilotHosts(filepath.Join(profileDir, "apps.json"))
+		}
 	}
 	if id == nil {
 		return ""
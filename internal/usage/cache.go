@@ -0,0 +1,49 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+// SaveToCache stores fetched readings in the database so later calls can
+// skip the provider APIs. Failed readings are not stored, so the last good
+// one survives an outage.
+func SaveToCache(db *caamdb.DB, results []ProfileUsage) error {
+	for _, r := range results {
+		if r.Usage == nil || r.Usage.Error != "" {
+			continue
+		}
+		data, err := json.Marshal(r.Usage)
+		if err != nil {
+			return fmt.Errorf("encode usage for %s/%s: %w", r.Provider, r.ProfileName, err)
+		}
+		if err := db.SaveRateLimitSnapshot(caamdb.RateLimitSnapshot{
+			Provider:    r.Provider,
+			ProfileName: r.ProfileName,
+			FetchedAt:   r.Usage.FetchedAt,
+			Data:        string(data),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFromCache returns a profile's cached reading, or nil if none was
+// stored.
+func LoadFromCache(db *caamdb.DB, provider, profile string) (*UsageInfo, error) {
+	snap, err := db.GetRateLimitSnapshot(provider, profile)
+	if err != nil || snap == nil {
+		return nil, err
+	}
+	var info UsageInfo
+	if err := json.Unmarshal([]byte(snap.Data), &info); err != nil {
+		return nil, fmt.Errorf("decode cached usage for %s/%s: %w", provider, profile, err)
+	}
+	if info.FetchedAt.IsZero() {
+		info.FetchedAt = snap.FetchedAt
+	}
+	return &info, nil
+}
@@ -11,6 +11,7 @@ import (
 // Claude API constants.
 const (
 	ClaudeUsageURL   = "https://api.anthropic.com/api/oauth/usage"
+	ClaudeProfileURL = "https://api.anthropic.com/api/oauth/profile"
 	ClaudeAPIBeta    = "oauth-2025-04-20"
 	ClaudeUserAgent  = "caam/1.0"
 	claudeTimeout    = 30 * time.Second
@@ -18,8 +19,9 @@ const (
 
 // ClaudeFetcher fetches usage data from Claude's OAuth API.
 type ClaudeFetcher struct {
-	client  *http.Client
-	baseURL string // For testing
+	client     *http.Client
+	baseURL    string // For testing
+	profileURL string // For testing
 }
 
 // NewClaudeFetcher creates a new Claude usage fetcher.
@@ -62,7 +64,7 @@ func (f *ClaudeFetcher) Fetch(ctx context.Context, accessToken string) (*UsageIn
 	req.Header.Set("User-Agent", ClaudeUserAgent)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := f.client.Do(req)
+	resp, err := doTraced(f.client, req, "provider.fetch_usage", "claude")
 	if err != nil {
 		return &UsageInfo{
 			Provider:  "claude",
@@ -83,6 +85,12 @@ func (f *ClaudeFetcher) Fetch(ctx context.Context, accessToken string) (*UsageIn
 	case http.StatusUnauthorized, http.StatusForbidden:
 		info.Error = "unauthorized: token expired or invalid"
 		return info, fmt.Errorf("unauthorized: status %d", resp.StatusCode)
+	case http.StatusTooManyRequests:
+		if t, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), info.FetchedAt); ok {
+			info.RetryAfter = t
+		}
+		info.Error = "rate limited: status 429"
+		return info, fmt.Errorf("rate limited: status %d", resp.StatusCode)
 	default:
 		info.Error = fmt.Sprintf("API error: status %d", resp.StatusCode)
 		return info, fmt.Errorf("API error: status %d", resp.StatusCode)
@@ -140,6 +148,87 @@ func (f *ClaudeFetcher) Fetch(ctx context.Context, accessToken string) (*UsageIn
 	return info, nil
 }
 
+// ClaudeProfile is the account behind a Claude OAuth token.
+type ClaudeProfile struct {
+	Email            string
+	AccountUUID      string
+	OrganizationName string
+	OrganizationUUID string
+	// PlanType is "max", "pro", or "" when neither is reported.
+	PlanType string
+}
+
+type claudeProfileResponse struct {
+	Account struct {
+		UUID         string `json:"uuid"`
+		Email        string `json:"email"`
+		EmailAddress string `json:"email_address"`
+		HasClaudeMax bool   `json:"has_claude_max"`
+		HasClaudePro bool   `json:"has_claude_pro"`
+	} `json:"account"`
+	Organization struct {
+		UUID string `json:"uuid"`
+		Name string `json:"name"`
+	} `json:"organization"`
+}
+
+// FetchProfile retrieves the account and organization for an access token.
+func (f *ClaudeFetcher) FetchProfile(ctx context.Context, accessToken string) (*ClaudeProfile, error) {
+	if accessToken == "" {
+		return nil, fmt.Errorf("access token is empty")
+	}
+
+	url := ClaudeProfileURL
+	if f.profileURL != "" {
+		url = f.profileURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("anthropic-beta", ClaudeAPIBeta)
+	req.Header.Set("User-Agent", ClaudeUserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doTraced(f.client, req, "provider.fetch_profile", "claude")
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("unauthorized: status %d", resp.StatusCode)
+	default:
+		return nil, fmt.Errorf("API error: status %d", resp.StatusCode)
+	}
+
+	var raw claudeProfileResponse
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	profile := &ClaudeProfile{
+		Email:            raw.Account.Email,
+		AccountUUID:      raw.Account.UUID,
+		OrganizationName: raw.Organization.Name,
+		OrganizationUUID: raw.Organization.UUID,
+	}
+	if profile.Email == "" {
+		profile.Email = raw.Account.EmailAddress
+	}
+	switch {
+	case raw.Account.HasClaudeMax:
+		profile.PlanType = "max"
+	case raw.Account.HasClaudePro:
+		profile.PlanType = "pro"
+	}
+	return profile, nil
+}
+
 // parseISO8601 parses an ISO8601 timestamp string.
 func parseISO8601(s string) time.Time {
 	if s == "" {
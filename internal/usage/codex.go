@@ -128,7 +128,7 @@ func (f *CodexFetcher) FetchWithOptions(ctx context.Context, accessToken string,
 		req.Header.Set("ChatGPT-Account-Id", opts.AccountID)
 	}
 
-	resp, err := f.client.Do(req)
+	resp, err := doTraced(f.client, req, "provider.fetch_usage", "codex")
 	if err != nil {
 		return &UsageInfo{
 			Provider:  "codex",
@@ -149,6 +149,12 @@ func (f *CodexFetcher) FetchWithOptions(ctx context.Context, accessToken string,
 	case http.StatusUnauthorized, http.StatusForbidden:
 		info.Error = "unauthorized: token expired or invalid"
 		return info, fmt.Errorf("unauthorized: status %d", resp.StatusCode)
+	case http.StatusTooManyRequests:
+		if t, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), info.FetchedAt); ok {
+			info.RetryAfter = t
+		}
+		info.Error = "rate limited: status 429"
+		return info, fmt.Errorf("rate limited: status %d", resp.StatusCode)
 	default:
 		info.Error = fmt.Sprintf("API error: status %d", resp.StatusCode)
 		return info, fmt.Errorf("API error: status %d", resp.StatusCode)
@@ -185,6 +191,12 @@ func (f *CodexFetcher) FetchWithOptions(ctx context.Context, accessToken string,
 		}
 	}
 
+	// The backend also reports the windows as x-codex-* response headers;
+	// use them when the body omits the rate_limit block.
+	if info.PrimaryWindow == nil && info.SecondaryWindow == nil {
+		info.PrimaryWindow, info.SecondaryWindow = ParseCodexRateLimitHeaders(resp.Header, info.FetchedAt)
+	}
+
 	if usage.Credits != nil {
 		info.Credits = &CreditInfo{
 			HasCredits: usage.Credits.HasCredits,
@@ -198,6 +210,36 @@ func (f *CodexFetcher) FetchWithOptions(ctx context.Context, accessToken string,
 	return info, nil
 }
 
+// ParseCodexRateLimitHeaders reads the primary and secondary windows from
+// the x-codex-{primary,secondary}-* headers Codex responses carry:
+// used-percent, window-minutes, and reset-after-seconds (or reset-at, Unix
+// seconds). A window is nil when its used-percent header is absent.
+func ParseCodexRateLimitHeaders(h http.Header, now time.Time) (primary, secondary *UsageWindow) {
+	return codexHeaderWindow(h, "primary", now), codexHeaderWindow(h, "secondary", now)
+}
+
+func codexHeaderWindow(h http.Header, name string, now time.Time) *UsageWindow {
+	prefix := "X-Codex-" + name + "-"
+	used, err := strconv.ParseFloat(strings.TrimSpace(h.Get(prefix+"Used-Percent")), 64)
+	if err != nil {
+		return nil
+	}
+
+	w := &UsageWindow{
+		Utilization: used / 100.0,
+		UsedPercent: int(used),
+	}
+	if mins, err := strconv.Atoi(strings.TrimSpace(h.Get(prefix + "Window-Minutes"))); err == nil {
+		w.WindowDuration = time.Duration(mins) * time.Minute
+	}
+	if secs, err := strconv.ParseInt(strings.TrimSpace(h.Get(prefix+"Reset-After-Seconds")), 10, 64); err == nil {
+		w.ResetsAt = now.Add(time.Duration(secs) * time.Second)
+	} else if at, err := strconv.ParseInt(strings.TrimSpace(h.Get(prefix+"Reset-At")), 10, 64); err == nil {
+		w.ResetsAt = time.Unix(at, 0)
+	}
+	return w
+}
+
 // resolveUsageURL determines the correct usage API URL.
 // Checks for custom config in ~/.codex/config.toml.
 func (f *CodexFetcher) resolveUsageURL() string {
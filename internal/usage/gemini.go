@@ -0,0 +1,203 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
+)
+
+// Gemini Code Assist API constants. The Gemini CLI signs in through Code
+// Assist, whose per-model request quotas are reported by retrieveUserQuota.
+const (
+	GeminiCodeAssistURL = "https://cloudcode-pa.googleapis.com/v1internal"
+	GeminiUserAgent     = "caam/1.0"
+	geminiTimeout       = 30 * time.Second
+)
+
+// GeminiFetcher fetches quota data from the Gemini Code Assist API.
+type GeminiFetcher struct {
+	client  *http.Client
+	baseURL string // For testing
+
+	// Project is the Google Cloud project to query. When empty,
+	// GOOGLE_CLOUD_PROJECT is used, then the project Code Assist assigned
+	// to the account.
+	Project string
+}
+
+// NewGeminiFetcher creates a new Gemini quota fetcher.
+func NewGeminiFetcher() *GeminiFetcher {
+	return &GeminiFetcher{
+		client: &http.Client{Timeout: geminiTimeout},
+	}
+}
+
+// geminiQuotaResponse represents the retrieveUserQuota response.
+type geminiQuotaResponse struct {
+	Buckets []geminiBucket `json:"buckets"`
+}
+
+type geminiBucket struct {
+	RemainingAmount   string   `json:"remainingAmount"`
+	RemainingFraction *float64 `json:"remainingFraction"`
+	ResetTime         string   `json:"resetTime"`
+	TokenType         string   `json:"tokenType"`
+	ModelID           string   `json:"modelId"`
+}
+
+// Fetch retrieves quota data from the Code Assist API. Each model's bucket
+// becomes a ModelWindows entry; the most used one is also the primary
+// window.
+func (f *GeminiFetcher) Fetch(ctx context.Context, accessToken string) (*UsageInfo, error) {
+	if accessToken == "" {
+		return nil, fmt.Errorf("access token is empty")
+	}
+
+	info := &UsageInfo{
+		Provider:  "gemini",
+		FetchedAt: time.Now(),
+	}
+
+	project := f.Project
+	if project == "" {
+		project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	if project == "" {
+		var load struct {
+			Project     string `json:"cloudaicompanionProject"`
+			CurrentTier *struct {
+				ID string `json:"id"`
+			} `json:"currentTier"`
+		}
+		body := map[string]interface{}{
+			"metadata": map[string]string{
+				"ideType":    "IDE_UNSPECIFIED",
+				"platform":   "PLATFORM_UNSPECIFIED",
+				"pluginType": "GEMINI",
+			},
+		}
+		if err := f.post(ctx, accessToken, "loadCodeAssist", body, &load); err != nil {
+			info.Error = err.Error()
+			return info, err
+		}
+		project = load.Project
+		if load.CurrentTier != nil {
+			info.PlanType = load.CurrentTier.ID
+		}
+	}
+
+	var quota geminiQuotaResponse
+	if err := f.post(ctx, accessToken, "retrieveUserQuota", map[string]string{"project": project}, &quota); err != nil {
+		info.Error = err.Error()
+		return info, err
+	}
+
+	applyGeminiBuckets(info, quota.Buckets)
+	return info, nil
+}
+
+// applyGeminiBuckets converts quota buckets to usage windows.
+func applyGeminiBuckets(info *UsageInfo, buckets []geminiBucket) {
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].ModelID < buckets[j].ModelID })
+
+	for _, b := range buckets {
+		if b.RemainingFraction == nil {
+			continue
+		}
+		util := 1 - *b.RemainingFraction
+		if util < 0 {
+			util = 0
+		}
+		w := &UsageWindow{
+			Utilization: util,
+			UsedPercent: int(math.Round(util * 100)),
+			ResetsAt:    parseISO8601(b.ResetTime),
+		}
+
+		key := b.ModelID
+		if key == "" {
+			key = strings.ToLower(b.TokenType)
+		}
+		if key != "" {
+			if info.ModelWindows == nil {
+				info.ModelWindows = make(map[string]*UsageWindow)
+			}
+			info.ModelWindows[key] = w
+		}
+		if info.PrimaryWindow == nil || w.Utilization > info.PrimaryWindow.Utilization {
+			info.PrimaryWindow = w
+		}
+	}
+}
+
+// post calls a Code Assist method and decodes the JSON response into out.
+func (f *GeminiFetcher) post(ctx context.Context, accessToken, method string, body, out interface{}) error {
+	baseURL := GeminiCodeAssistURL
+	if f.baseURL != "" {
+		baseURL = f.baseURL
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+":"+method, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", GeminiUserAgent)
+
+	resp, err := doTraced(f.client, req, "provider.fetch_usage", "gemini")
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("unauthorized: token expired or invalid")
+	default:
+		return fmt.Errorf("API error: status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// ReadGeminiCredentials reads the access token from a Gemini CLI OAuth file
+// (oauth_creds.json or oauth_credentials.json).
+func ReadGeminiCredentials(path string) (accessToken string, err error) {
+	data, err := vaultcrypt.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var creds struct {
+		AccessToken string `json:"access_token"`
+		AccessCamel string `json:"accessToken"`
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return "", err
+	}
+	if creds.AccessToken != "" {
+		return creds.AccessToken, nil
+	}
+	if creds.AccessCamel != "" {
+		return creds.AccessCamel, nil
+	}
+	return "", fmt.Errorf("no access token found in credentials")
+}
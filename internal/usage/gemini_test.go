@@ -0,0 +1,132 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func TestGeminiFetcher_Fetch(t *testing.T) {
+	var quotaProject string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1internal:loadCodeAssist":
+			_, _ = w.Write([]byte(`{"cloudaicompanionProject":"proj-123","currentTier":{"id":"standard-tier"}}`))
+		case "/v1internal:retrieveUserQuota":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			quotaProject = body["project"]
+			_, _ = w.Write([]byte(`{"buckets":[
+				{"modelId":"gemini-2.5-flash","remainingFraction":0.9,"resetTime":"2026-01-02T00:00:00Z","tokenType":"REQUESTS"},
+				{"modelId":"gemini-2.5-pro","remainingFraction":0.25,"resetTime":"2026-01-02T00:00:00Z","tokenType":"REQUESTS"}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	f := NewGeminiFetcher()
+	f.baseURL = server.URL + "/v1internal"
+
+	info, err := f.Fetch(context.Background(), "tok")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if quotaProject != "proj-123" || info.PlanType != "standard-tier" {
+		t.Errorf("project/plan = %q/%q", quotaProject, info.PlanType)
+	}
+	if info.PrimaryWindow == nil || info.PrimaryWindow.UsedPercent != 75 {
+		t.Fatalf("PrimaryWindow = %+v, want the pro bucket at 75%%", info.PrimaryWindow)
+	}
+	if len(info.ModelWindows) != 2 || info.ModelWindows["gemini-2.5-flash"].UsedPercent != 10 {
+		t.Errorf("ModelWindows = %+v", info.ModelWindows)
+	}
+	if !info.PrimaryWindow.ResetsAt.Equal(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ResetsAt = %v", info.PrimaryWindow.ResetsAt)
+	}
+
+	info, err = f.Fetch(context.Background(), "bad")
+	if err == nil || info.Error == "" {
+		t.Errorf("Fetch(bad token) = %+v, %v; want an error", info, err)
+	}
+}
+
+func TestReadGeminiCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oauth_creds.json")
+	if err := os.WriteFile(path, []byte(`{"access_token":"ya29.x","refresh_token":"1//r"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if token, err := ReadGeminiCredentials(path); err != nil || token != "ya29.x" {
+		t.Errorf("ReadGeminiCredentials() = %q, %v", token, err)
+	}
+	if err := os.WriteFile(path, []byte(`{"refresh_token":"1//r"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadGeminiCredentials(path); err == nil {
+		t.Error("expected error without an access token")
+	}
+}
+
+func TestParseCodexRateLimitHeaders(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	h := http.Header{}
+	h.Set("x-codex-primary-used-percent", "42.5")
+	h.Set("x-codex-primary-window-minutes", "300")
+	h.Set("x-codex-primary-reset-after-seconds", "600")
+	h.Set("x-codex-secondary-used-percent", "10")
+	h.Set("x-codex-secondary-reset-at", "1767312000")
+
+	primary, secondary := ParseCodexRateLimitHeaders(h, now)
+	if primary == nil || primary.UsedPercent != 42 || primary.WindowDuration != 5*time.Hour || !primary.ResetsAt.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("primary = %+v", primary)
+	}
+	if secondary == nil || secondary.UsedPercent != 10 || secondary.ResetsAt.Unix() != 1767312000 {
+		t.Errorf("secondary = %+v", secondary)
+	}
+	if p, s := ParseCodexRateLimitHeaders(http.Header{}, now); p != nil || s != nil {
+		t.Errorf("empty headers = %+v, %+v", p, s)
+	}
+}
+
+func TestUsageCache(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	fetched := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	good := &UsageInfo{Provider: "claude", FetchedAt: fetched, PrimaryWindow: &UsageWindow{UsedPercent: 40, Utilization: 0.4}}
+	if err := SaveToCache(db, []ProfileUsage{{Provider: "claude", ProfileName: "work", Usage: good}}); err != nil {
+		t.Fatalf("SaveToCache() error = %v", err)
+	}
+	// A failed fetch must not replace the last good reading.
+	failed := &UsageInfo{Provider: "claude", FetchedAt: time.Now(), Error: "unauthorized"}
+	if err := SaveToCache(db, []ProfileUsage{{Provider: "claude", ProfileName: "work", Usage: failed}}); err != nil {
+		t.Fatalf("SaveToCache() error = %v", err)
+	}
+
+	info, err := LoadFromCache(db, "claude", "work")
+	if err != nil || info == nil {
+		t.Fatalf("LoadFromCache() = %+v, %v", info, err)
+	}
+	if info.PrimaryWindow == nil || info.PrimaryWindow.UsedPercent != 40 || !info.FetchedAt.Equal(fetched) {
+		t.Errorf("cached = %+v", info)
+	}
+	if info, err := LoadFromCache(db, "claude", "other"); err != nil || info != nil {
+		t.Errorf("LoadFromCache(missing) = %+v, %v", info, err)
+	}
+}
@@ -0,0 +1,105 @@
+package usage
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+// maxRecordBytes caps ingestion request bodies.
+const maxRecordBytes = 64 << 10
+
+// RecordHandler accepts token usage over HTTP so agents and wrappers can
+// report each request as it completes:
+//
+//	POST /usage/record   {"provider": "claude", "profile": "work",
+//	                      "model": "...", "input_tokens": 1200,
+//	                      "output_tokens": 300, "source": "my-agent"}
+//
+// The profile may be omitted to charge the provider's active profile. The
+// stored record is echoed back. Requests carry the shared secret the same
+// way usage alerts do: "Authorization: Bearer <secret>", an X-Caam-Secret
+// header, or a ?secret= query parameter.
+type RecordHandler struct {
+	Secret string
+
+	// DB is the activity database. When nil, each request opens the
+	// default one.
+	DB *caamdb.DB
+
+	// ActiveProfile returns the provider's active profile, or "".
+	ActiveProfile func(provider string) string
+
+	Logf func(format string, args ...interface{})
+}
+
+// ServeHTTP implements http.Handler.
+func (h *RecordHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+	if !h.authorized(r) {
+		writeJSONError(w, http.StatusUnauthorized, "invalid or missing secret")
+		return
+	}
+
+	var rec caamdb.UsageRecord
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRecordBytes)).Decode(&rec); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "parse usage record: "+err.Error())
+		return
+	}
+	if rec.Source == "" {
+		rec.Source = "http"
+	}
+	if strings.TrimSpace(rec.ProfileName) == "" && h.ActiveProfile != nil {
+		rec.ProfileName = h.ActiveProfile(strings.ToLower(strings.TrimSpace(rec.Provider)))
+	}
+
+	db := h.DB
+	if db == nil {
+		opened, err := caamdb.Open()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer opened.Close()
+		db = opened
+	}
+
+	stored, err := db.RecordUsage(rec)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if h.Logf != nil {
+		h.Logf("Usage: %s/%s +%d tokens (%s)", stored.Provider, stored.ProfileName, stored.TotalTokens, stored.Source)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stored)
+}
+
+func (h *RecordHandler) authorized(r *http.Request) bool {
+	if h.Secret == "" {
+		return false
+	}
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if given == "" {
+		given = r.Header.Get("X-Caam-Secret")
+	}
+	if given == "" {
+		given = r.URL.Query().Get("secret")
+	}
+	return subtle.ConstantTimeCompare([]byte(given), []byte(h.Secret)) == 1
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
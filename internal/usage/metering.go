@@ -0,0 +1,98 @@
+package usage
+
+import (
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+)
+
+// DefaultForecastWindow is how far back metered usage is considered when no
+// limit window is configured.
+const DefaultForecastWindow = 5 * time.Hour
+
+// Forecast projects a profile's recorded usage against its token limit.
+type Forecast struct {
+	BurnRate *BurnRateInfo `json:"burn_rate,omitempty"`
+
+	// TokenLimit and LimitWindow are the plan allowance, when configured.
+	TokenLimit  int64         `json:"token_limit,omitempty"`
+	LimitWindow time.Duration `json:"limit_window,omitempty"`
+
+	// UsedTokens is what was recorded within the (rolling) limit window.
+	UsedTokens  int64   `json:"used_tokens"`
+	UsedPercent float64 `json:"used_percent,omitempty"`
+	Remaining   int64   `json:"remaining,omitempty"`
+
+	// DepletesIn is zero when there is no limit or no measurable burn rate.
+	DepletesIn time.Duration `json:"depletes_in,omitempty"`
+	DepletesAt time.Time     `json:"depletes_at,omitempty"`
+}
+
+// Depleted reports whether the recorded usage already meets the limit.
+func (f *Forecast) Depleted() bool {
+	return f != nil && f.TokenLimit > 0 && f.Remaining <= 0
+}
+
+// EntriesFromRecords converts metered usage into log entries so the
+// log-based burn-rate helpers can be applied to it.
+func EntriesFromRecords(records []caamdb.UsageRecord) []*logs.LogEntry {
+	entries := make([]*logs.LogEntry, 0, len(records))
+	for _, rec := range records {
+		entries = append(entries, &logs.LogEntry{
+			Timestamp:         rec.Timestamp,
+			Type:              "usage",
+			Model:             rec.Model,
+			InputTokens:       rec.InputTokens,
+			OutputTokens:      rec.OutputTokens,
+			CacheReadTokens:   rec.CacheReadTokens,
+			CacheCreateTokens: rec.CacheCreateTokens,
+			TotalTokens:       rec.TotalTokens,
+		})
+	}
+	return entries
+}
+
+// ForecastFromRecords computes the burn rate of metered usage and, when a
+// token limit is known, how long the rest of the rolling limit window's
+// allowance lasts at that rate. Records older than the limit window (or
+// DefaultForecastWindow without one) are ignored, and a depletion further
+// out than the window is not reported since older usage rolls off first.
+func ForecastFromRecords(records []caamdb.UsageRecord, tokenLimit int64, limitWindow time.Duration) *Forecast {
+	window := limitWindow
+	if window <= 0 {
+		window = DefaultForecastWindow
+	}
+
+	opts := DefaultBurnRateOptions()
+	if tokenLimit > 0 && limitWindow > 0 {
+		opts.TokenLimit = tokenLimit
+		opts.LimitWindow = limitWindow
+	}
+
+	now := time.Now()
+	f := &Forecast{
+		BurnRate: CalculateBurnRate(EntriesFromRecords(records), window, opts),
+	}
+	for _, rec := range records {
+		if !rec.Timestamp.Before(now.Add(-window)) {
+			f.UsedTokens += rec.TotalTokens
+		}
+	}
+
+	if opts.TokenLimit <= 0 {
+		return f
+	}
+	f.TokenLimit = tokenLimit
+	f.LimitWindow = limitWindow
+	f.UsedPercent = float64(f.UsedTokens) / float64(tokenLimit) * 100
+	f.Remaining = tokenLimit - f.UsedTokens
+	if f.Remaining < 0 {
+		f.Remaining = 0
+	}
+	if d := f.BurnRate.ProjectDepletion(f.Remaining); d > 0 && d < window {
+		f.DepletesIn = d
+		f.DepletesAt = now.Add(d)
+	}
+	return f
+}
@@ -0,0 +1,86 @@
+package usage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func TestForecastFromRecords(t *testing.T) {
+	now := time.Now()
+	var records []caamdb.UsageRecord
+	for i := 4; i >= 0; i-- {
+		records = append(records, caamdb.UsageRecord{
+			Timestamp:   now.Add(-time.Duration(i) * 15 * time.Minute),
+			TotalTokens: 10_000,
+		})
+	}
+	// Outside the 5h limit window; must not count.
+	records = append([]caamdb.UsageRecord{{Timestamp: now.Add(-6 * time.Hour), TotalTokens: 1_000_000}}, records...)
+
+	f := ForecastFromRecords(records, 100_000, 5*time.Hour)
+	if f.BurnRate == nil {
+		t.Fatal("expected a burn rate")
+	}
+	if f.UsedTokens != 50_000 || f.Remaining != 50_000 || f.UsedPercent != 50 {
+		t.Errorf("used/remaining/percent = %d/%d/%.0f", f.UsedTokens, f.Remaining, f.UsedPercent)
+	}
+	// 50K tokens over an hour leaves an hour of the remaining 50K.
+	if f.DepletesIn < 55*time.Minute || f.DepletesIn > 65*time.Minute {
+		t.Errorf("DepletesIn = %v, want about 1h", f.DepletesIn)
+	}
+	if f.Depleted() {
+		t.Error("Depleted() = true with allowance left")
+	}
+
+	if f := ForecastFromRecords(records, 0, 0); f.TokenLimit != 0 || f.DepletesIn != 0 || f.BurnRate == nil {
+		t.Errorf("forecast without limit = %+v", f)
+	}
+	if f := ForecastFromRecords(records, 40_000, 5*time.Hour); !f.Depleted() || f.Remaining != 0 {
+		t.Errorf("forecast over limit = %+v", f)
+	}
+	if f := ForecastFromRecords(records[:2], 100_000, 5*time.Hour); f.BurnRate != nil || f.DepletesIn != 0 {
+		t.Errorf("forecast from too few records = %+v", f)
+	}
+}
+
+func TestRecordHandler(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	h := &RecordHandler{
+		Secret:        "s3cret",
+		DB:            db,
+		ActiveProfile: func(provider string) string { return "active-" + provider },
+	}
+	post := func(target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("/usage/record", `{"provider":"claude","input_tokens":5}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("no secret: status %d", rec.Code)
+	}
+	if rec := post("/usage/record?secret=s3cret", `{"provider":"claude"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("no tokens: status %d", rec.Code)
+	}
+	rec := post("/usage/record?secret=s3cret", `{"provider":"claude","input_tokens":100,"output_tokens":20}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"total_tokens":120`) {
+		t.Fatalf("record: status %d body %s", rec.Code, rec.Body)
+	}
+
+	records, err := db.UsageSince("claude", "active-claude", time.Time{})
+	if err != nil || len(records) != 1 || records[0].Source != "http" {
+		t.Errorf("stored records = %+v, %v", records, err)
+	}
+}
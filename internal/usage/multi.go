@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/vaultcrypt"
 )
 
 // ProfileUsage combines usage info with profile metadata.
@@ -25,6 +26,7 @@ type ProfileUsage struct {
 type MultiProfileFetcher struct {
 	claudeFetcher *ClaudeFetcher
 	codexFetcher  *CodexFetcher
+	geminiFetcher *GeminiFetcher
 	logScanner    logs.Scanner // Optional scanner for burn rate calculation
 }
 
@@ -43,6 +45,7 @@ func NewMultiProfileFetcher(opts ...FetcherOption) *MultiProfileFetcher {
 	m := &MultiProfileFetcher{
 		claudeFetcher: NewClaudeFetcher(),
 		codexFetcher:  NewCodexFetcher(),
+		geminiFetcher: NewGeminiFetcher(),
 	}
 	for _, opt := range opts {
 		opt(m)
@@ -90,6 +93,16 @@ func (m *MultiProfileFetcher) FetchAllProfiles(ctx context.Context, provider str
 				} else {
 					info, err = m.codexFetcher.Fetch(ctx, token)
 				}
+			case "gemini":
+				if m.geminiFetcher == nil {
+					info = &UsageInfo{
+						Provider:  provider,
+						FetchedAt: time.Now(),
+						Error:     "gemini fetcher unavailable",
+					}
+				} else {
+					info, err = m.geminiFetcher.Fetch(ctx, token)
+				}
 			default:
 				info = &UsageInfo{
 					Provider:  provider,
@@ -226,7 +239,7 @@ func (m *MultiProfileFetcher) GetProfilesAboveThreshold(ctx context.Context, pro
 
 // ReadClaudeCredentials reads the access token from Claude credentials file.
 func ReadClaudeCredentials(path string) (accessToken string, accountID string, err error) {
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return "", "", err
 	}
@@ -280,7 +293,7 @@ func ReadClaudeCredentials(path string) (accessToken string, accountID string, e
 
 // ReadCodexCredentials reads the access token from Codex auth file.
 func ReadCodexCredentials(path string) (accessToken string, accountID string, err error) {
-	data, err := os.ReadFile(path)
+	data, err := vaultcrypt.ReadFile(path)
 	if err != nil {
 		return "", "", err
 	}
@@ -339,31 +352,7 @@ func LoadProfileCredentials(vaultDir, provider string) (map[string]string, error
 		}
 
 		profileName := entry.Name()
-		profileDir := filepath.Join(providerDir, profileName)
-
-		var token string
-		var readErr error
-
-		switch provider {
-		case "claude":
-			// Try new location first
-			credPath := filepath.Join(profileDir, ".credentials.json")
-			token, _, readErr = ReadClaudeCredentials(credPath)
-			if readErr != nil {
-				// Fall back to old location
-				oldPath := filepath.Join(profileDir, ".claude.json")
-				token, _, readErr = ReadClaudeCredentials(oldPath)
-				if readErr != nil {
-					// Fall back to claude-code auth.json (optional file)
-					authPath := filepath.Join(profileDir, "auth.json")
-					token, _, readErr = ReadClaudeCredentials(authPath)
-				}
-			}
-		case "codex":
-			authPath := filepath.Join(profileDir, "auth.json")
-			token, _, readErr = ReadCodexCredentials(authPath)
-		}
-
+		token, _, readErr := ReadProfileCredentials(filepath.Join(providerDir, profileName), provider)
 		if readErr != nil {
 			continue // Skip profiles with invalid credentials
 		}
@@ -375,3 +364,31 @@ func LoadProfileCredentials(vaultDir, provider string) (map[string]string, error
 
 	return credentials, nil
 }
+
+// ReadProfileCredentials reads the access token, and the account ID where
+// the provider records one, from a vault profile directory.
+func ReadProfileCredentials(profileDir, provider string) (accessToken string, accountID string, err error) {
+	switch provider {
+	case "claude":
+		// Try new location first
+		accessToken, accountID, err = ReadClaudeCredentials(filepath.Join(profileDir, ".credentials.json"))
+		if err != nil {
+			// Fall back to old location
+			accessToken, accountID, err = ReadClaudeCredentials(filepath.Join(profileDir, ".claude.json"))
+			if err != nil {
+				// Fall back to claude-code auth.json (optional file)
+				accessToken, accountID, err = ReadClaudeCredentials(filepath.Join(profileDir, "auth.json"))
+			}
+		}
+	case "codex":
+		accessToken, accountID, err = ReadCodexCredentials(filepath.Join(profileDir, "auth.json"))
+	case "gemini":
+		accessToken, err = ReadGeminiCredentials(filepath.Join(profileDir, "oauth_creds.json"))
+		if err != nil {
+			accessToken, err = ReadGeminiCredentials(filepath.Join(profileDir, "oauth_credentials.json"))
+		}
+	default:
+		err = fmt.Errorf("reading %s credentials is not supported", provider)
+	}
+	return accessToken, accountID, err
+}
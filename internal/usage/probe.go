@@ -0,0 +1,287 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Probe endpoints.
+const (
+	ClaudeMessagesURL = "https://api.anthropic.com/v1/messages"
+	ClaudeAPIVersion  = "2023-06-01"
+	CodexModelsPath   = "/codex/models"
+)
+
+// Models a probe uses when none is given. They are the cheapest each
+// provider serves to subscription accounts.
+var DefaultProbeModels = map[string]string{
+	"claude": "claude-haiku-4-5",
+	"gemini": "gemini-2.5-flash",
+}
+
+// maxProbeBody caps how much of a response a probe reads.
+const maxProbeBody = 64 << 10
+
+// ProbeResult is the outcome of one synthetic request sent on a profile's
+// behalf.
+type ProbeResult struct {
+	Provider string
+	Endpoint string
+	Model    string
+	// HTTPStatus is the response status, or 0 if there was no response.
+	HTTPStatus int
+	OK         bool
+	// AccountError is set when the provider answered and refused the
+	// request for a reason tied to the account (not a rate limit or a
+	// server error), such as a ban or a suspended subscription.
+	AccountError bool
+	Latency      time.Duration
+	Error        string
+}
+
+// Prober sends the smallest real request each provider serves: a
+// one-token Claude completion, the Codex model list, and a one-token
+// Gemini completion through Code Assist. Unlike TokenChecker, a probe
+// goes through the same path the CLI uses for real work, so it catches
+// accounts whose token is accepted but whose requests are refused.
+type Prober struct {
+	client    *http.Client
+	claudeURL string // For testing
+	codex     *CodexFetcher
+	gemini    *GeminiFetcher
+}
+
+// NewProber creates a prober.
+func NewProber() *Prober {
+	return &Prober{
+		client: &http.Client{Timeout: claudeTimeout},
+		codex:  NewCodexFetcher(),
+		gemini: NewGeminiFetcher(),
+	}
+}
+
+// SupportsProbe reports whether Probe can test provider's accounts.
+func SupportsProbe(provider string) bool {
+	switch provider {
+	case "claude", "codex", "gemini":
+		return true
+	}
+	return false
+}
+
+// Probe sends one synthetic request with accessToken. accountID is sent
+// where the provider needs it; model overrides DefaultProbeModels. The
+// outcome is in the result; Probe itself never fails.
+func (p *Prober) Probe(ctx context.Context, provider, accessToken, accountID, model string) ProbeResult {
+	if p == nil {
+		p = NewProber()
+	}
+	if model == "" {
+		model = DefaultProbeModels[provider]
+	}
+	result := ProbeResult{Provider: provider, Model: model}
+	if accessToken == "" {
+		result.Error = "access token is empty"
+		return result
+	}
+
+	var (
+		req    *http.Request
+		client = p.client
+		err    error
+	)
+	start := time.Now()
+	switch provider {
+	case "claude":
+		result.Endpoint = ClaudeMessagesURL
+		if p.claudeURL != "" {
+			result.Endpoint = p.claudeURL
+		}
+		req, err = newProbeRequest(ctx, result.Endpoint, map[string]interface{}{
+			"model":      model,
+			"max_tokens": 1,
+			"messages":   []map[string]string{{"role": "user", "content": "ping"}},
+		})
+		if err == nil {
+			req.Header.Set("anthropic-version", ClaudeAPIVersion)
+			req.Header.Set("anthropic-beta", ClaudeAPIBeta)
+			req.Header.Set("User-Agent", ClaudeUserAgent)
+		}
+	case "codex":
+		base := p.codex.baseURL
+		if base == "" {
+			base = p.codex.resolveChatGPTBaseURL()
+		}
+		result.Endpoint = strings.TrimRight(base, "/") + CodexModelsPath
+		req, err = http.NewRequestWithContext(ctx, "GET", result.Endpoint+"?client_version=1.0.0", nil)
+		if err == nil {
+			req.Header.Set("User-Agent", CodexUserAgent)
+			if accountID != "" {
+				req.Header.Set("ChatGPT-Account-Id", accountID)
+			}
+		}
+		client = p.codex.client
+	case "gemini":
+		// Code Assist needs the account's project; loadCodeAssist is free.
+		project := p.gemini.Project
+		if project == "" {
+			project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+		}
+		if project == "" {
+			var load struct {
+				Project string `json:"cloudaicompanionProject"`
+			}
+			body := map[string]interface{}{
+				"metadata": map[string]string{
+					"ideType":    "IDE_UNSPECIFIED",
+					"platform":   "PLATFORM_UNSPECIFIED",
+					"pluginType": "GEMINI",
+				},
+			}
+			if err := p.gemini.post(ctx, accessToken, "loadCodeAssist", body, &load); err != nil {
+				result.Latency = time.Since(start)
+				result.Error = fmt.Sprintf("load project: %v", err)
+				result.AccountError = strings.Contains(err.Error(), "unauthorized")
+				return result
+			}
+			project = load.Project
+		}
+		result.Endpoint = GeminiCodeAssistURL
+		if p.gemini.baseURL != "" {
+			result.Endpoint = p.gemini.baseURL
+		}
+		result.Endpoint += ":generateContent"
+		req, err = newProbeRequest(ctx, result.Endpoint, map[string]interface{}{
+			"model":   model,
+			"project": project,
+			"request": map[string]interface{}{
+				"contents":         []map[string]interface{}{{"role": "user", "parts": []map[string]string{{"text": "ping"}}}},
+				"generationConfig": map[string]int{"maxOutputTokens": 1},
+			},
+		})
+		if err == nil {
+			req.Header.Set("User-Agent", GeminiUserAgent)
+		}
+		client = p.gemini.client
+	default:
+		result.Error = fmt.Sprintf("no probe for provider %s", provider)
+		return result
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("create request: %v", err)
+		return result
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doTraced(client, req, "provider.probe", provider)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Error = fmt.Sprintf("request failed: %v", err)
+		return result
+	}
+	defer resp.Body.Close()
+	result.HTTPStatus = resp.StatusCode
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+
+	msg := probeErrorMessage(body)
+	switch code := resp.StatusCode; {
+	case code >= 200 && code < 300:
+		result.OK = true
+	case code == http.StatusTooManyRequests:
+		result.Error = fmt.Sprintf("rate limited: status %d", code)
+	case code >= 500:
+		result.Error = fmt.Sprintf("API error: status %d", code)
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		result.AccountError = true
+		result.Error = fmt.Sprintf("unauthorized: status %d", code)
+	case isAccountRefusal(msg):
+		result.AccountError = true
+		result.Error = fmt.Sprintf("account refused: status %d", code)
+	default:
+		// Most likely the probe itself is wrong, such as an unknown model,
+		// which says nothing about the account.
+		result.Error = fmt.Sprintf("request rejected: status %d", code)
+	}
+	if msg != "" && !result.OK {
+		result.Error += ": " + msg
+	}
+	return result
+}
+
+// accountRefusals are phrases providers use when they refuse an account
+// rather than a request, whatever the status code.
+var accountRefusals = []string{
+	"banned",
+	"suspended",
+	"deactivated",
+	"disabled",
+	"terminated",
+}
+
+// isAccountRefusal reports whether an error message says the account
+// itself was banned or suspended.
+func isAccountRefusal(msg string) bool {
+	msg = strings.ToLower(msg)
+	for _, phrase := range accountRefusals {
+		if strings.Contains(msg, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// newProbeRequest creates a JSON POST request.
+func newProbeRequest(ctx context.Context, url string, body interface{}) (*http.Request, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// probeErrorMessage pulls the provider's explanation out of an error body,
+// which is where bans and suspensions are spelled out.
+func probeErrorMessage(body []byte) string {
+	var raw struct {
+		Error   json.RawMessage `json:"error"`
+		Detail  json.RawMessage `json:"detail"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(body, &raw) != nil {
+		return ""
+	}
+	for _, field := range []json.RawMessage{raw.Error, raw.Detail} {
+		var s string
+		if json.Unmarshal(field, &s) == nil && s != "" {
+			return truncateProbeMessage(s)
+		}
+		var obj struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(field, &obj) == nil && obj.Message != "" {
+			return truncateProbeMessage(obj.Message)
+		}
+	}
+	return truncateProbeMessage(raw.Message)
+}
+
+func truncateProbeMessage(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProbe(t *testing.T) {
+	var status int
+	var body string
+	var gotPath, gotBeta string
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotBeta = r.Header.Get("anthropic-beta")
+		gotBody = nil
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		if strings.HasSuffix(r.URL.Path, ":loadCodeAssist") {
+			_, _ = w.Write([]byte(`{"cloudaicompanionProject":"proj-1"}`))
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	p := &Prober{
+		client:    server.Client(),
+		claudeURL: server.URL + "/v1/messages",
+		codex:     &CodexFetcher{client: server.Client(), baseURL: server.URL},
+		gemini:    &GeminiFetcher{client: server.Client(), baseURL: server.URL + "/v1internal"},
+	}
+	ctx := context.Background()
+
+	status, body = http.StatusOK, `{"content":[{"type":"text","text":"p"}]}`
+	got := p.Probe(ctx, "claude", "tok", "", "")
+	if !got.OK || got.Model != DefaultProbeModels["claude"] || gotPath != "/v1/messages" || gotBeta != ClaudeAPIBeta {
+		t.Errorf("claude 200 = %+v (path %q, beta %q)", got, gotPath, gotBeta)
+	}
+	if gotBody["max_tokens"] != float64(1) {
+		t.Errorf("claude request max_tokens = %v, want 1", gotBody["max_tokens"])
+	}
+
+	status, body = http.StatusOK, `{"models":[]}`
+	got = p.Probe(ctx, "codex", "tok", "acct-1", "")
+	if !got.OK || gotPath != CodexModelsPath {
+		t.Errorf("codex 200 = %+v (path %q)", got, gotPath)
+	}
+
+	status, body = http.StatusOK, `{}`
+	got = p.Probe(ctx, "gemini", "tok", "", "gemini-test")
+	if !got.OK || gotPath != "/v1internal:generateContent" || gotBody["project"] != "proj-1" || gotBody["model"] != "gemini-test" {
+		t.Errorf("gemini 200 = %+v (path %q, body %v)", got, gotPath, gotBody)
+	}
+
+	tests := []struct {
+		status       int
+		body         string
+		accountError bool
+		wantError    string
+	}{
+		{http.StatusForbidden, `{"error":{"type":"permission_error","message":"This organization has been disabled."}}`, true, "organization has been disabled"},
+		{http.StatusBadRequest, `{"detail":"Account deactivated"}`, true, "Account deactivated"},
+		{http.StatusBadRequest, `{"error":{"type":"not_found_error","message":"model: claude-haiku-typo"}}`, false, "request rejected: status 400"},
+		{http.StatusNotFound, `{}`, false, "status 404"},
+		{http.StatusTooManyRequests, `{}`, false, "rate limited"},
+		{http.StatusBadGateway, `not json`, false, "status 502"},
+	}
+	for _, tt := range tests {
+		status, body = tt.status, tt.body
+		got := p.Probe(ctx, "claude", "tok", "", "")
+		if got.OK || got.HTTPStatus != tt.status || got.AccountError != tt.accountError || !strings.Contains(got.Error, tt.wantError) {
+			t.Errorf("status %d = %+v, want account error %v and error containing %q", tt.status, got, tt.accountError, tt.wantError)
+		}
+	}
+
+	if got := p.Probe(ctx, "cursor", "tok", "", ""); got.OK || got.Error == "" {
+		t.Errorf("unsupported provider = %+v, want error", got)
+	}
+	if got := p.Probe(ctx, "claude", "", "", ""); got.OK || got.Error != "access token is empty" {
+		t.Errorf("empty token = %+v", got)
+	}
+}
@@ -0,0 +1,205 @@
+package usage
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reset sources, reported alongside an inferred reset time.
+const (
+	ResetSourceRetryAfter  = "retry_after"
+	ResetSourceUsageWindow = "usage_window"
+	ResetSourceResetText   = "reset_text"
+	ResetSourceWindow      = "window_fallback"
+)
+
+// exhaustedUtilization is the utilization at which a window counts as used up.
+const exhaustedUtilization = 0.99
+
+var (
+	resetClockRe = regexp.MustCompile(`(?i)(?:\b(mon|tue|wed|thu|fri|sat|sun)[a-z]*\.?,?\s+(?:at\s+)?)?\b(\d{1,2})(?::(\d{2}))?\s*(?:([ap])\.?m\b\.?)?(?:\s*\(([^)]+)\))?`)
+	resetSplitRe = regexp.MustCompile(`(?i)([a-z])(\d)`)
+	resetPartRe  = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(days?|d|hours?|hrs?|hr|h|minutes?|mins?|min|m|seconds?|secs?|sec|s)\b`)
+)
+
+var resetWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseRetryAfter parses a Retry-After header value, either a number of
+// seconds or an HTTP date, into the time it names.
+func ParseRetryAfter(value string, now time.Time) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return time.Time{}, false
+		}
+		return now.Add(time.Duration(secs) * time.Second), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// ParseResetText parses the reset time out of a provider's rate limit
+// message, such as "resets 3pm", "3:30 PM (America/Los_Angeles)",
+// "Mon 9am", or "try again in 2 hours 15 minutes". Clock times are taken
+// as the next time that clock reading comes round after now.
+func ParseResetText(text string, now time.Time) (time.Time, bool) {
+	if t, ok := parseResetClock(text, now); ok {
+		return t, true
+	}
+
+	// Split compact forms like "1h30m" so each unit ends at a word boundary.
+	text = resetSplitRe.ReplaceAllString(text, "$1 $2")
+	var total time.Duration
+	for _, m := range resetPartRe.FindAllStringSubmatch(text, -1) {
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			continue
+		}
+		var unit time.Duration
+		switch strings.ToLower(m[2])[0] {
+		case 'd':
+			unit = 24 * time.Hour
+		case 'h':
+			unit = time.Hour
+		case 'm':
+			unit = time.Minute
+		case 's':
+			unit = time.Second
+		}
+		total += time.Duration(n * float64(unit))
+	}
+	if total <= 0 {
+		return time.Time{}, false
+	}
+	return now.Add(total), true
+}
+
+func parseResetClock(text string, now time.Time) (time.Time, bool) {
+	for _, m := range resetClockRe.FindAllStringSubmatch(text, -1) {
+		day, hourStr, minStr, meridiem, zone := m[1], m[2], m[3], strings.ToLower(m[4]), m[5]
+		// A bare number is a count ("2 hours"), not a clock reading.
+		if minStr == "" && meridiem == "" {
+			continue
+		}
+		hour, _ := strconv.Atoi(hourStr)
+		minute := 0
+		if minStr != "" {
+			minute, _ = strconv.Atoi(minStr)
+		}
+		if minute > 59 {
+			continue
+		}
+		if meridiem != "" {
+			if hour < 1 || hour > 12 {
+				continue
+			}
+			hour %= 12
+			if meridiem == "p" {
+				hour += 12
+			}
+		} else if hour > 23 {
+			continue
+		}
+
+		loc := now.Location()
+		if zone = strings.TrimSpace(zone); zone != "" {
+			if l, err := time.LoadLocation(zone); err == nil {
+				loc = l
+			}
+		}
+		local := now.In(loc)
+		t := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+		if day != "" {
+			want := resetWeekdays[strings.ToLower(day)]
+			t = t.AddDate(0, 0, (int(want)-int(t.Weekday())+7)%7)
+			if !t.After(now) {
+				t = t.AddDate(0, 0, 7)
+			}
+		} else if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// InferReset works out when a rate limited account can be used again from
+// a usage reading: a Retry-After from the provider first, then the latest
+// reset among exhausted windows, then the reset of the busiest window.
+func InferReset(info *UsageInfo, now time.Time) (time.Time, string, bool) {
+	if info == nil {
+		return time.Time{}, "", false
+	}
+	if info.RetryAfter.After(now) {
+		return info.RetryAfter, ResetSourceRetryAfter, true
+	}
+
+	windows := []*UsageWindow{info.PrimaryWindow, info.SecondaryWindow, info.TertiaryWindow}
+	for _, w := range info.ModelWindows {
+		windows = append(windows, w)
+	}
+
+	var exhausted, busiest time.Time
+	busiestUtil := -1.0
+	for _, w := range windows {
+		if w == nil || !w.ResetsAt.After(now) {
+			continue
+		}
+		util := windowUtilization(w)
+		if util >= exhaustedUtilization && w.ResetsAt.After(exhausted) {
+			exhausted = w.ResetsAt
+		}
+		if util > busiestUtil {
+			busiest, busiestUtil = w.ResetsAt, util
+		}
+	}
+	switch {
+	case !exhausted.IsZero():
+		return exhausted, ResetSourceUsageWindow, true
+	case !busiest.IsZero():
+		return busiest, ResetSourceUsageWindow, true
+	}
+	return time.Time{}, "", false
+}
+
+// WindowFallback returns when a provider's rate limit window would reset
+// if it was hit at now, for when nothing better is known: Claude and Codex
+// limits roll over five hours after they start, and Gemini's daily quota
+// resets at midnight Pacific time.
+func WindowFallback(provider string, now time.Time) (time.Time, bool) {
+	switch provider {
+	case "claude", "codex":
+		return now.Add(5 * time.Hour), true
+	case "gemini":
+		loc, err := time.LoadLocation("America/Los_Angeles")
+		if err != nil {
+			loc = time.FixedZone("PST", -8*60*60)
+		}
+		local := now.In(loc)
+		return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc), true
+	}
+	return time.Time{}, false
+}
+
+func windowUtilization(w *UsageWindow) float64 {
+	if w.Utilization == 0 && w.UsedPercent > 0 {
+		return float64(w.UsedPercent) / 100.0
+	}
+	return w.Utilization
+}
@@ -0,0 +1,182 @@
+package usage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{name: "seconds", value: "120", want: now.Add(2 * time.Minute), ok: true},
+		{name: "http date", value: "Tue, 10 Mar 2026 15:30:00 GMT", want: time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC), ok: true},
+		{name: "empty", value: "", ok: false},
+		{name: "negative", value: "-5", ok: false},
+		{name: "garbage", value: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseRetryAfter(tt.value, now)
+			if ok != tt.ok {
+				t.Fatalf("ParseRetryAfter(%q) ok = %v, want %v", tt.value, ok, tt.ok)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResetText(t *testing.T) {
+	// Tuesday, 10:00 local (UTC).
+	now := time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC)
+
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want time.Time
+		ok   bool
+	}{
+		{name: "bare pm", text: "3pm", want: time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC), ok: true},
+		{name: "claude sentence", text: "5-hour limit reached ∙ resets 3pm", want: time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC), ok: true},
+		{name: "minutes and meridiem", text: "3:45 PM", want: time.Date(2026, 3, 10, 15, 45, 0, 0, time.UTC), ok: true},
+		{name: "24 hour", text: "15:00", want: time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC), ok: true},
+		{name: "passed today rolls to tomorrow", text: "9am", want: time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC), ok: true},
+		{name: "midnight", text: "12am", want: time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC), ok: true},
+		{name: "with zone", text: "resets 3pm (America/Los_Angeles)", want: time.Date(2026, 3, 10, 15, 0, 0, 0, la), ok: true},
+		{name: "weekday", text: "resets Mon 9am", want: time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC), ok: true},
+		{name: "relative", text: "2 hours 15 minutes", want: now.Add(2*time.Hour + 15*time.Minute), ok: true},
+		{name: "relative sentence", text: "Try again in 4 days 2 hours.", want: now.Add(98 * time.Hour), ok: true},
+		{name: "compact", text: "1h30m", want: now.Add(90 * time.Minute), ok: true},
+		{name: "fractional seconds", text: "retry in 12.5s", want: now.Add(12500 * time.Millisecond), ok: true},
+		{name: "nothing", text: "usage limit reached", ok: false},
+		{name: "bad hour", text: "13pm", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseResetText(tt.text, now)
+			if ok != tt.ok {
+				t.Fatalf("ParseResetText(%q) ok = %v (%v), want %v", tt.text, ok, got, tt.ok)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("ParseResetText(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferReset(t *testing.T) {
+	now := time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC)
+	soon := now.Add(2 * time.Hour)
+	later := now.Add(72 * time.Hour)
+
+	tests := []struct {
+		name   string
+		info   *UsageInfo
+		want   time.Time
+		source string
+		ok     bool
+	}{
+		{name: "nil", info: nil, ok: false},
+		{
+			name:   "retry after wins",
+			info:   &UsageInfo{RetryAfter: now.Add(time.Minute), PrimaryWindow: &UsageWindow{Utilization: 1, ResetsAt: soon}},
+			want:   now.Add(time.Minute),
+			source: ResetSourceRetryAfter,
+			ok:     true,
+		},
+		{
+			name: "latest exhausted window",
+			info: &UsageInfo{
+				PrimaryWindow:   &UsageWindow{Utilization: 1, ResetsAt: soon},
+				SecondaryWindow: &UsageWindow{UsedPercent: 100, ResetsAt: later},
+			},
+			want:   later,
+			source: ResetSourceUsageWindow,
+			ok:     true,
+		},
+		{
+			name: "busiest window when none exhausted",
+			info: &UsageInfo{
+				PrimaryWindow:   &UsageWindow{Utilization: 0.9, ResetsAt: soon},
+				SecondaryWindow: &UsageWindow{Utilization: 0.4, ResetsAt: later},
+			},
+			want:   soon,
+			source: ResetSourceUsageWindow,
+			ok:     true,
+		},
+		{
+			name: "past resets ignored",
+			info: &UsageInfo{PrimaryWindow: &UsageWindow{Utilization: 1, ResetsAt: now.Add(-time.Hour)}},
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, source, ok := InferReset(tt.info, now)
+			if ok != tt.ok {
+				t.Fatalf("InferReset() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if !got.Equal(tt.want) || source != tt.source {
+				t.Errorf("InferReset() = %v (%s), want %v (%s)", got, source, tt.want, tt.source)
+			}
+		})
+	}
+}
+
+func TestWindowFallback(t *testing.T) {
+	now := time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC)
+
+	if got, ok := WindowFallback("claude", now); !ok || !got.Equal(now.Add(5*time.Hour)) {
+		t.Errorf("claude fallback = %v, %v", got, ok)
+	}
+	got, ok := WindowFallback("gemini", now)
+	if !ok || !got.After(now) || got.Sub(now) > 24*time.Hour {
+		t.Errorf("gemini fallback = %v, %v", got, ok)
+	}
+	if _, ok := WindowFallback("unknown", now); ok {
+		t.Error("unknown provider should have no fallback")
+	}
+}
+
+func TestClaudeFetcher_RetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	fetcher := NewClaudeFetcher()
+	fetcher.baseURL = server.URL
+
+	info, err := fetcher.Fetch(context.Background(), "token")
+	if err == nil {
+		t.Fatal("expected error for 429")
+	}
+	if info == nil || info.RetryAfter.IsZero() {
+		t.Fatalf("RetryAfter not set: %+v", info)
+	}
+	if d := info.RetryAfter.Sub(info.FetchedAt); d != 10*time.Minute {
+		t.Errorf("RetryAfter - FetchedAt = %v, want 10m", d)
+	}
+}
@@ -0,0 +1,158 @@
+package usage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/pricing"
+)
+
+// minProjectionElapsed keeps a month's first hours from projecting a few
+// requests into a huge monthly figure.
+const minProjectionElapsed = 24 * time.Hour
+
+// ProfileSpend is one profile's priced usage over a month.
+type ProfileSpend struct {
+	Provider          string  `json:"provider"`
+	ProfileName       string  `json:"profile"`
+	Requests          int     `json:"requests"`
+	InputTokens       int64   `json:"input_tokens"`
+	OutputTokens      int64   `json:"output_tokens"`
+	CacheReadTokens   int64   `json:"cache_read_tokens,omitempty"`
+	CacheCreateTokens int64   `json:"cache_create_tokens,omitempty"`
+	TotalTokens       int64   `json:"total_tokens"`
+	CostUSD           float64 `json:"cost_usd"`
+	ProjectedUSD      float64 `json:"projected_usd"`
+
+	// UnpricedTokens were used by models with no known price and are not
+	// included in CostUSD.
+	UnpricedTokens int64 `json:"unpriced_tokens,omitempty"`
+}
+
+// BudgetStatus is a budget's spend for a month.
+type BudgetStatus struct {
+	Name         string  `json:"name"`
+	Provider     string  `json:"provider,omitempty"`
+	Profile      string  `json:"profile,omitempty"`
+	BudgetUSD    float64 `json:"budget_usd"`
+	SpentUSD     float64 `json:"spent_usd"`
+	ProjectedUSD float64 `json:"projected_usd"`
+	UsedPercent  float64 `json:"used_percent"`
+
+	// Crossed is the highest configured threshold (a percentage) the
+	// spend has reached, or zero.
+	Crossed int `json:"crossed_threshold,omitempty"`
+}
+
+// MonthReport is the priced usage of one calendar month.
+type MonthReport struct {
+	Month        string         `json:"month"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	TotalUSD     float64        `json:"total_usd"`
+	ProjectedUSD float64        `json:"projected_usd"`
+	Profiles     []ProfileSpend `json:"profiles"`
+	Budgets      []BudgetStatus `json:"budgets,omitempty"`
+}
+
+// MonthBounds returns the first instant of t's month and of the next one,
+// in t's location.
+func MonthBounds(t time.Time) (start, end time.Time) {
+	start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// ProjectMonth extrapolates spend so far in [start, end) to the whole
+// month at the same rate. Finished months project what was spent, and
+// months that haven't started project nothing.
+func ProjectMonth(spent float64, start, end, now time.Time) float64 {
+	switch {
+	case !now.Before(end):
+		return spent
+	case now.Before(start):
+		return 0
+	}
+	elapsed := now.Sub(start)
+	if elapsed < minProjectionElapsed {
+		elapsed = minProjectionElapsed
+	}
+	total := end.Sub(start)
+	if elapsed >= total {
+		return spent
+	}
+	return spent * float64(total) / float64(elapsed)
+}
+
+// BuildMonthReport prices the usage records of the month starting at start
+// and checks it against budgets. now places the projection within the month.
+func BuildMonthReport(records []caamdb.UsageRecord, prices *pricing.Table, budgets []config.Budget, start, now time.Time) *MonthReport {
+	start, end := MonthBounds(start)
+	report := &MonthReport{
+		Month:    start.Format("2006-01"),
+		Start:    start,
+		End:      end,
+		Profiles: []ProfileSpend{},
+	}
+
+	byProfile := make(map[string]*ProfileSpend)
+	var order []string
+	for _, rec := range records {
+		if rec.Timestamp.Before(start) || !rec.Timestamp.Before(end) {
+			continue
+		}
+		key := rec.Provider + "/" + rec.ProfileName
+		ps := byProfile[key]
+		if ps == nil {
+			ps = &ProfileSpend{Provider: rec.Provider, ProfileName: rec.ProfileName}
+			byProfile[key] = ps
+			order = append(order, key)
+		}
+		ps.Requests++
+		ps.InputTokens += rec.InputTokens
+		ps.OutputTokens += rec.OutputTokens
+		ps.CacheReadTokens += rec.CacheReadTokens
+		ps.CacheCreateTokens += rec.CacheCreateTokens
+		ps.TotalTokens += rec.TotalTokens
+		if cost, ok := prices.TokenCost(rec.Provider, rec.Model, rec.InputTokens, rec.OutputTokens, rec.CacheReadTokens, rec.CacheCreateTokens); ok {
+			ps.CostUSD += cost
+		} else {
+			ps.UnpricedTokens += rec.TotalTokens
+		}
+	}
+
+	sort.Strings(order)
+	for _, key := range order {
+		ps := byProfile[key]
+		ps.ProjectedUSD = ProjectMonth(ps.CostUSD, start, end, now)
+		report.Profiles = append(report.Profiles, *ps)
+		report.TotalUSD += ps.CostUSD
+	}
+	report.ProjectedUSD = ProjectMonth(report.TotalUSD, start, end, now)
+
+	for _, b := range budgets {
+		status := BudgetStatus{
+			Name:      b.Name(),
+			Provider:  b.Provider,
+			Profile:   b.Profile,
+			BudgetUSD: b.MonthlyUSD,
+		}
+		for _, ps := range report.Profiles {
+			if b.Covers(ps.Provider, ps.ProfileName) {
+				status.SpentUSD += ps.CostUSD
+			}
+		}
+		status.ProjectedUSD = ProjectMonth(status.SpentUSD, start, end, now)
+		if b.MonthlyUSD > 0 {
+			status.UsedPercent = status.SpentUSD / b.MonthlyUSD * 100
+		}
+		for _, pct := range b.ThresholdList() {
+			if status.UsedPercent >= float64(pct) && pct > status.Crossed {
+				status.Crossed = pct
+			}
+		}
+		report.Budgets = append(report.Budgets, status)
+	}
+	return report
+}
@@ -0,0 +1,75 @@
+package usage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func TestProjectMonth(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want float64
+	}{
+		{"past month", end.Add(time.Hour), 10},
+		{"future month", start.Add(-time.Hour), 0},
+		{"a third in", start.Add(end.Sub(start) / 3), 30},
+		{"first hours use a day", start.Add(time.Hour), 310},
+	}
+	for _, tt := range tests {
+		if got := ProjectMonth(10, start, end, tt.now); math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("%s: ProjectMonth = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildMonthReport(t *testing.T) {
+	costs := config.CostsConfig{
+		Prices: []config.ModelPrice{{Provider: "claude", Model: "m1", Input: 10, Output: 20}},
+		Budgets: []config.Budget{
+			{Provider: "claude", MonthlyUSD: 20},
+			{Provider: "claude", Profile: "home", MonthlyUSD: 100, Thresholds: []int{50}},
+		},
+	}
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	at := func(day int) time.Time { return start.AddDate(0, 0, day-1).Add(time.Hour) }
+	records := []caamdb.UsageRecord{
+		{Timestamp: at(2), Provider: "claude", ProfileName: "work", Model: "m1", InputTokens: 1_000_000, OutputTokens: 250_000, TotalTokens: 1_250_000},
+		{Timestamp: at(3), Provider: "claude", ProfileName: "work", Model: "unknown", InputTokens: 5, TotalTokens: 5},
+		{Timestamp: at(4), Provider: "claude", ProfileName: "home", Model: "m1", InputTokens: 500_000, TotalTokens: 500_000},
+		{Timestamp: start.AddDate(0, 1, 1), Provider: "claude", ProfileName: "home", Model: "m1", InputTokens: 9_000_000, TotalTokens: 9_000_000},
+	}
+
+	report := BuildMonthReport(records, costs.PriceTable(), costs.Budgets, start, start.AddDate(0, 2, 0))
+	if report.Month != "2024-07" || len(report.Profiles) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	home, work := report.Profiles[0], report.Profiles[1]
+	if home.ProfileName != "home" || math.Abs(home.CostUSD-5) > 1e-9 || home.Requests != 1 {
+		t.Errorf("home = %+v, want $5 from one request (next month's usage excluded)", home)
+	}
+	if work.ProfileName != "work" || math.Abs(work.CostUSD-15) > 1e-9 || work.UnpricedTokens != 5 {
+		t.Errorf("work = %+v, want $15 and 5 unpriced tokens", work)
+	}
+	if math.Abs(report.TotalUSD-20) > 1e-9 || report.ProjectedUSD != report.TotalUSD {
+		t.Errorf("total = %v projected %v, want 20 for a finished month", report.TotalUSD, report.ProjectedUSD)
+	}
+
+	if len(report.Budgets) != 2 {
+		t.Fatalf("budgets = %+v", report.Budgets)
+	}
+	provider, profile := report.Budgets[0], report.Budgets[1]
+	if provider.Name != "claude" || math.Abs(provider.UsedPercent-100) > 1e-9 || provider.Crossed != 100 {
+		t.Errorf("provider budget = %+v, want 100%% used", provider)
+	}
+	if profile.Name != "claude/home" || profile.Crossed != 0 {
+		t.Errorf("profile budget = %+v, want no threshold crossed at 5%%", profile)
+	}
+}
@@ -0,0 +1,211 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Confidence levels of a TokenCheck.
+const (
+	// ConfidenceHigh means the provider accepted or rejected the token.
+	ConfidenceHigh = "high"
+	// ConfidenceMedium means the provider answered without settling it,
+	// such as a rate limit that only authenticated callers hit.
+	ConfidenceMedium = "medium"
+	// ConfidenceLow means the call told nothing about the token, such as a
+	// network or server error.
+	ConfidenceLow = "low"
+)
+
+// maxTokenCheckBody caps how much of a response CheckToken decodes.
+const maxTokenCheckBody = 1 << 20
+
+// TokenCheck is the result of one authenticated call made to test a token.
+type TokenCheck struct {
+	Provider string
+	Endpoint string
+	// HTTPStatus is the response status, or 0 if there was no response.
+	HTTPStatus int
+	Valid      bool
+	Confidence string
+
+	// What the response said about the account, when it said anything.
+	Email        string
+	Plan         string
+	Organization string // For Gemini, the Code Assist project
+
+	Latency time.Duration
+	Error   string
+}
+
+// TokenChecker tests tokens with the cheapest authenticated call each
+// provider has: Claude's OAuth profile, the Codex usage endpoint, and
+// Gemini's loadCodeAssist. None of them uses model quota.
+type TokenChecker struct {
+	claude *ClaudeFetcher
+	codex  *CodexFetcher
+	gemini *GeminiFetcher
+}
+
+// NewTokenChecker creates a token checker.
+func NewTokenChecker() *TokenChecker {
+	return &TokenChecker{
+		claude: NewClaudeFetcher(),
+		codex:  NewCodexFetcher(),
+		gemini: NewGeminiFetcher(),
+	}
+}
+
+// SupportsTokenCheck reports whether CheckToken can test provider's tokens.
+func SupportsTokenCheck(provider string) bool {
+	switch provider {
+	case "claude", "codex", "gemini":
+		return true
+	}
+	return false
+}
+
+// CheckToken makes one authenticated call with accessToken. accountID is
+// sent where the provider needs it (the ChatGPT account for Codex). The
+// outcome is in the result; CheckToken itself never fails.
+func (c *TokenChecker) CheckToken(ctx context.Context, provider, accessToken, accountID string) TokenCheck {
+	if c == nil {
+		c = NewTokenChecker()
+	}
+	check := TokenCheck{Provider: provider, Confidence: ConfidenceLow}
+	if accessToken == "" {
+		check.Error = "access token is empty"
+		return check
+	}
+
+	var (
+		req    *http.Request
+		client *http.Client
+		decode func(io.Reader, *TokenCheck) error
+		err    error
+	)
+	switch provider {
+	case "claude":
+		check.Endpoint = ClaudeProfileURL
+		if c.claude.profileURL != "" {
+			check.Endpoint = c.claude.profileURL
+		}
+		req, err = http.NewRequestWithContext(ctx, "GET", check.Endpoint, nil)
+		if err == nil {
+			req.Header.Set("anthropic-beta", ClaudeAPIBeta)
+			req.Header.Set("User-Agent", ClaudeUserAgent)
+		}
+		client, decode = c.claude.client, decodeClaudeTokenCheck
+	case "codex":
+		check.Endpoint = c.codex.resolveUsageURL()
+		req, err = http.NewRequestWithContext(ctx, "GET", check.Endpoint, nil)
+		if err == nil {
+			req.Header.Set("User-Agent", CodexUserAgent)
+			if accountID != "" {
+				req.Header.Set("ChatGPT-Account-Id", accountID)
+			}
+		}
+		client, decode = c.codex.client, decodeCodexTokenCheck
+	case "gemini":
+		check.Endpoint = GeminiCodeAssistURL
+		if c.gemini.baseURL != "" {
+			check.Endpoint = c.gemini.baseURL
+		}
+		check.Endpoint += ":loadCodeAssist"
+		body := []byte(`{"metadata":{"ideType":"IDE_UNSPECIFIED","platform":"PLATFORM_UNSPECIFIED","pluginType":"GEMINI"}}`)
+		req, err = http.NewRequestWithContext(ctx, "POST", check.Endpoint, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", GeminiUserAgent)
+		}
+		client, decode = c.gemini.client, decodeGeminiTokenCheck
+	default:
+		check.Error = fmt.Sprintf("no token check for provider %s", provider)
+		return check
+	}
+	if err != nil {
+		check.Error = fmt.Sprintf("create request: %v", err)
+		return check
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	start := time.Now()
+	resp, err := doTraced(client, req, "provider.check_token", provider)
+	check.Latency = time.Since(start)
+	if err != nil {
+		check.Error = fmt.Sprintf("request failed: %v", err)
+		return check
+	}
+	defer resp.Body.Close()
+	check.HTTPStatus = resp.StatusCode
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		check.Valid = true
+		check.Confidence = ConfidenceHigh
+		// The token was accepted; a body we can't read only costs the
+		// account details.
+		_ = decode(io.LimitReader(resp.Body, maxTokenCheckBody), &check)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		check.Confidence = ConfidenceHigh
+		check.Error = fmt.Sprintf("unauthorized: status %d", resp.StatusCode)
+	case http.StatusTooManyRequests:
+		check.Valid = true
+		check.Confidence = ConfidenceMedium
+		check.Error = fmt.Sprintf("rate limited: status %d", resp.StatusCode)
+	default:
+		check.Error = fmt.Sprintf("API error: status %d", resp.StatusCode)
+	}
+	return check
+}
+
+func decodeClaudeTokenCheck(r io.Reader, check *TokenCheck) error {
+	var raw claudeProfileResponse
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return err
+	}
+	check.Email = raw.Account.Email
+	if check.Email == "" {
+		check.Email = raw.Account.EmailAddress
+	}
+	check.Organization = raw.Organization.Name
+	switch {
+	case raw.Account.HasClaudeMax:
+		check.Plan = "max"
+	case raw.Account.HasClaudePro:
+		check.Plan = "pro"
+	}
+	return nil
+}
+
+func decodeCodexTokenCheck(r io.Reader, check *TokenCheck) error {
+	var raw codexUsageResponse
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return err
+	}
+	check.Plan = raw.PlanType
+	return nil
+}
+
+func decodeGeminiTokenCheck(r io.Reader, check *TokenCheck) error {
+	var raw struct {
+		Project     string `json:"cloudaicompanionProject"`
+		CurrentTier *struct {
+			ID string `json:"id"`
+		} `json:"currentTier"`
+	}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return err
+	}
+	check.Organization = raw.Project
+	if raw.CurrentTier != nil {
+		check.Plan = raw.CurrentTier.ID
+	}
+	return nil
+}
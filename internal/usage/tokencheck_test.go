@@ -0,0 +1,75 @@
+package usage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckToken(t *testing.T) {
+	var status int
+	var body string
+	var gotAuth, gotAccount, gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotAccount = r.Header.Get("ChatGPT-Account-Id")
+		gotPath = r.URL.Path
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	c := &TokenChecker{
+		claude: &ClaudeFetcher{client: server.Client(), profileURL: server.URL + "/api/oauth/profile"},
+		codex:  &CodexFetcher{client: server.Client(), baseURL: server.URL},
+		gemini: &GeminiFetcher{client: server.Client(), baseURL: server.URL + "/v1internal"},
+	}
+	ctx := context.Background()
+
+	status, body = http.StatusOK, `{"account":{"email":"a@example.com","has_claude_max":true},"organization":{"name":"Acme"}}`
+	got := c.CheckToken(ctx, "claude", "tok", "")
+	if !got.Valid || got.Confidence != ConfidenceHigh || got.HTTPStatus != 200 || got.Email != "a@example.com" || got.Plan != "max" || got.Organization != "Acme" {
+		t.Errorf("claude 200 = %+v", got)
+	}
+	if gotAuth != "Bearer tok" || gotPath != "/api/oauth/profile" {
+		t.Errorf("claude request auth = %q, path = %q", gotAuth, gotPath)
+	}
+
+	status, body = http.StatusOK, `{"plan_type":"pro"}`
+	got = c.CheckToken(ctx, "codex", "tok", "acct-1")
+	if !got.Valid || got.Plan != "pro" || gotAccount != "acct-1" || gotPath != CodexUsagePath {
+		t.Errorf("codex 200 = %+v (account %q, path %q)", got, gotAccount, gotPath)
+	}
+
+	status, body = http.StatusOK, `{"cloudaicompanionProject":"proj-1","currentTier":{"id":"standard-tier"}}`
+	got = c.CheckToken(ctx, "gemini", "tok", "")
+	if !got.Valid || got.Plan != "standard-tier" || got.Organization != "proj-1" || gotPath != "/v1internal:loadCodeAssist" {
+		t.Errorf("gemini 200 = %+v (path %q)", got, gotPath)
+	}
+
+	tests := []struct {
+		status     int
+		valid      bool
+		confidence string
+	}{
+		{http.StatusUnauthorized, false, ConfidenceHigh},
+		{http.StatusForbidden, false, ConfidenceHigh},
+		{http.StatusTooManyRequests, true, ConfidenceMedium},
+		{http.StatusBadGateway, false, ConfidenceLow},
+	}
+	for _, tt := range tests {
+		status, body = tt.status, ""
+		got := c.CheckToken(ctx, "claude", "tok", "")
+		if got.Valid != tt.valid || got.Confidence != tt.confidence || got.HTTPStatus != tt.status || got.Error == "" {
+			t.Errorf("status %d = %+v, want valid=%v confidence=%s", tt.status, got, tt.valid, tt.confidence)
+		}
+	}
+
+	if got := c.CheckToken(ctx, "claude", "", ""); got.Confidence != ConfidenceLow || got.Valid || got.HTTPStatus != 0 {
+		t.Errorf("empty token = %+v", got)
+	}
+	if got := c.CheckToken(ctx, "cursor", "tok", ""); got.Error == "" || SupportsTokenCheck("cursor") {
+		t.Errorf("unsupported provider = %+v", got)
+	}
+}
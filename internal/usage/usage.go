@@ -5,7 +5,12 @@ package usage
 
 import (
 	"context"
+	"net/http"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/tracing"
 )
 
 // UsageWindow represents a rate limit window with utilization data.
@@ -25,7 +30,7 @@ type UsageWindow struct {
 
 // UsageInfo contains rate limit and usage information for a provider account.
 type UsageInfo struct {
-	// Provider is "claude" or "codex".
+	// Provider is "claude", "codex", or "gemini".
 	Provider string `json:"provider"`
 
 	// ProfileName is the CAAM profile name (if known).
@@ -63,6 +68,10 @@ type UsageInfo struct {
 	// Error contains any error message from fetching.
 	Error string `json:"error,omitempty"`
 
+	// RetryAfter is when the provider said to try again, from the
+	// Retry-After header of a rate limited (429) response.
+	RetryAfter time.Time `json:"retry_after,omitempty"`
+
 	// BurnRate contains token consumption rate from log/session data.
 	BurnRate *BurnRateInfo `json:"burn_rate,omitempty"`
 
@@ -386,3 +395,20 @@ func (u *UsageInfo) DepletionWarningLevel() int {
 	}
 	return 0 // None
 }
+
+// doTraced sends req in a span named name, tagged with the provider, the
+// API host, and the response status. Trace headers are not forwarded to the
+// provider.
+func doTraced(client *http.Client, req *http.Request, name, provider string) (*http.Response, error) {
+	_, span := tracing.Start(req.Context(), name,
+		tracing.Provider(provider),
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Host),
+	)
+	resp, err := client.Do(req)
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	tracing.End(span, err)
+	return resp, err
+}
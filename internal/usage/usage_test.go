@@ -848,3 +848,34 @@ func TestUsageInfo_DepletionWarningLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestClaudeFetcher_FetchProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Fatalf("Authorization = %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"account":{"uuid":"acct-1","email":"dev@example.com","has_claude_max":true},"organization":{"uuid":"org-1","name":"Acme"}}`)
+	}))
+	defer server.Close()
+
+	fetcher := NewClaudeFetcher()
+	fetcher.profileURL = server.URL
+
+	profile, err := fetcher.FetchProfile(context.Background(), "token")
+	if err != nil {
+		t.Fatalf("FetchProfile() error = %v", err)
+	}
+	if profile.Email != "dev@example.com" || profile.PlanType != "max" || profile.AccountUUID != "acct-1" || profile.OrganizationName != "Acme" {
+		t.Fatalf("FetchProfile() = %+v", profile)
+	}
+
+	unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer unauthorized.Close()
+	fetcher.profileURL = unauthorized.URL
+	if _, err := fetcher.FetchProfile(context.Background(), "token"); err == nil {
+		t.Fatal("FetchProfile() expected error for 401")
+	}
+}
@@ -0,0 +1,128 @@
+package usagealert
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+// EventUsageAlert is the activity event type logged for each alert.
+const EventUsageAlert = "usage_alert"
+
+// DefaultCooldown is how long a pre-emptive cooldown lasts when the alert
+// carries no reset time.
+const DefaultCooldown = time.Hour
+
+// ErrNoProfile is returned when an alert's account matches no vault profile.
+var ErrNoProfile = errors.New("no profile matches the alert's account")
+
+// Applier records alerts and schedules pre-emptive cooldowns.
+type Applier struct {
+	// DB is the activity database. When nil, Apply opens the default one.
+	DB *caamdb.DB
+
+	// Resolve maps a provider account (usually an email) to a vault profile
+	// name, returning "" if none matches.
+	Resolve func(provider, account string) string
+
+	// CriticalPercent is the usage at which a cooldown is scheduled.
+	CriticalPercent float64
+
+	// FallbackCooldown is used when the alert has no future reset time.
+	FallbackCooldown time.Duration
+
+	now func() time.Time // for tests
+}
+
+// Result describes what Apply did with an alert.
+type Result struct {
+	Alert    *Alert                `json:"alert"`
+	Profile  string                `json:"profile"`
+	Cooldown *caamdb.CooldownEvent `json:"cooldown,omitempty"`
+	// Action is "logged", "cooldown", or "cooldown_kept" when an existing
+	// cooldown already lasts at least as long.
+	Action string `json:"action"`
+}
+
+// Apply logs the alert and, at or above CriticalPercent, puts the profile in
+// cooldown until the alert's reset time.
+func (a *Applier) Apply(alert *Alert) (*Result, error) {
+	now := time.Now().UTC()
+	if a.now != nil {
+		now = a.now()
+	}
+
+	profile := alert.Profile
+	if profile == "" && a.Resolve != nil {
+		profile = a.Resolve(alert.Provider, alert.Account)
+	}
+	if profile == "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoProfile, alert.Provider, alert.Account)
+	}
+	alert.Profile = profile
+
+	db := a.DB
+	if db == nil {
+		opened, err := caamdb.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open database: %w", err)
+		}
+		defer opened.Close()
+		db = opened
+	}
+
+	details := map[string]any{
+		"percent": alert.Percent,
+		"source":  alert.Source,
+	}
+	if alert.Window != "" {
+		details["window"] = alert.Window
+	}
+	if !alert.ResetAt.IsZero() {
+		details["reset_at"] = alert.ResetAt.Format(time.RFC3339)
+	}
+	if err := db.LogEvent(caamdb.Event{
+		Timestamp:   now,
+		Type:        EventUsageAlert,
+		Provider:    alert.Provider,
+		ProfileName: profile,
+		Details:     details,
+	}); err != nil {
+		return nil, fmt.Errorf("log usage alert: %w", err)
+	}
+
+	result := &Result{Alert: alert, Profile: profile, Action: "logged"}
+	if a.CriticalPercent <= 0 || alert.Percent < a.CriticalPercent {
+		return result, nil
+	}
+
+	until := alert.ResetAt
+	if !until.After(now) {
+		fallback := a.FallbackCooldown
+		if fallback <= 0 {
+			fallback = DefaultCooldown
+		}
+		until = now.Add(fallback)
+	}
+
+	if existing, err := db.ActiveCooldown(alert.Provider, profile, now); err == nil && existing != nil && !existing.CooldownUntil.Before(until) {
+		result.Cooldown = existing
+		result.Action = "cooldown_kept"
+		return result, nil
+	}
+
+	notes := fmt.Sprintf("usage alert: %.0f%%", alert.Percent)
+	if alert.Window != "" {
+		notes += " of " + alert.Window + " limit"
+	}
+	notes += " (" + alert.Source + ")"
+	ev, err := db.SetCooldown(alert.Provider, profile, now, until.Sub(now), notes)
+	if err != nil {
+		return nil, fmt.Errorf("schedule cooldown: %w", err)
+	}
+	result.Cooldown = ev
+	result.Action = "cooldown"
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package usagealert
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxBodyBytes caps webhook request bodies.
+const maxBodyBytes = 1 << 20
+
+// Handler receives usage alerts over HTTP:
+//
+//	POST /usage/email        raw RFC 822 message (mail-to-webhook relays)
+//	POST /usage/<provider>   JSON payload, see ParseWebhook
+//
+// Requests must carry the shared secret as "Authorization: Bearer <secret>",
+// an X-Caam-Secret header, or a ?secret= query parameter, since most
+// relays can only be configured with a URL.
+type Handler struct {
+	Secret  string
+	Applier *Applier
+	Logf    func(format string, args ...interface{})
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+	if !h.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid or missing secret")
+		return
+	}
+
+	target := strings.Trim(strings.TrimPrefix(r.URL.Path, "/usage"), "/")
+	if target == "" || strings.Contains(target, "/") {
+		writeError(w, http.StatusNotFound, "use /usage/email or /usage/<provider>")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var alert *Alert
+	if target == "email" {
+		alert, err = ParseEmail(strings.NewReader(string(body)))
+	} else {
+		alert, err = ParseWebhook(target, body)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.Applier.Apply(alert)
+	if err != nil {
+		h.logf("Usage alert for %s %s not applied: %v", alert.Provider, alert.Account, err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrNoProfile) {
+			status = http.StatusUnprocessableEntity
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+
+	h.logf("Usage alert: %s/%s at %.0f%% (%s) -> %s", result.Alert.Provider, result.Profile, result.Alert.Percent, result.Alert.Source, result.Action)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	if h.Secret == "" {
+		return false
+	}
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if given == "" {
+		given = r.Header.Get("X-Caam-Secret")
+	}
+	if given == "" {
+		given = r.URL.Query().Get("secret")
+	}
+	return subtle.ConstantTimeCompare([]byte(given), []byte(h.Secret)) == 1
+}
+
+func (h *Handler) logf(format string, args ...interface{}) {
+	if h.Logf != nil {
+		h.Logf(format, args...)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,325 @@
+// Package usagealert turns provider usage alerts - webhook payloads and
+// "you're approaching your limit" emails - into caam events.
+//
+// An alert at or above the critical threshold (alerts.critical_threshold)
+// puts the matching profile into cooldown until the provider's reset time,
+// so rotation moves off the account before it hits the hard limit instead of
+// after. Every alert is also logged to the activity database.
+//
+// Providers don't share a webhook format, so the receiver accepts a small
+// generic JSON shape (see ParseWebhook) that relays such as mail-to-webhook
+// services can be mapped into, plus raw RFC 822 messages (see ParseEmail).
+package usagealert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alert sources.
+const (
+	SourceWebhook = "webhook"
+	SourceEmail   = "email"
+)
+
+// Alert is one provider usage alert.
+type Alert struct {
+	Provider   string    `json:"provider"`
+	Account    string    `json:"account,omitempty"`
+	Profile    string    `json:"profile,omitempty"`
+	Percent    float64   `json:"percent"`
+	Window     string    `json:"window,omitempty"`
+	ResetAt    time.Time `json:"reset_at,omitempty"`
+	Source     string    `json:"source"`
+	Subject    string    `json:"subject,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// senderProviders maps alert sender domains to providers.
+var senderProviders = map[string]string{
+	"anthropic.com": "claude",
+	"claude.ai":     "claude",
+	"claude.com":    "claude",
+	"openai.com":    "codex",
+	"chatgpt.com":   "codex",
+	"google.com":    "gemini",
+	"gemini.google": "gemini",
+}
+
+// ParseWebhook parses a webhook payload. The provider comes from the URL
+// unless the payload names one. Recognised fields (first match wins):
+//
+//	provider
+//	account | email | user_email | user
+//	profile
+//	percent | usage_percent | percentage | used_percent | utilization (0-1 or 0-100)
+//	window | period | limit
+//	reset_at | resets_at | reset | reset_time (RFC 3339 or Unix seconds)
+func ParseWebhook(provider string, body []byte) (*Alert, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("parse webhook payload: %w", err)
+	}
+
+	a := &Alert{
+		Provider:   strings.ToLower(firstString(raw, "provider")),
+		Account:    firstString(raw, "account", "email", "user_email", "user"),
+		Profile:    firstString(raw, "profile"),
+		Window:     firstString(raw, "window", "period", "limit"),
+		Source:     SourceWebhook,
+		ReceivedAt: time.Now().UTC(),
+	}
+	if a.Provider == "" {
+		a.Provider = strings.ToLower(provider)
+	}
+
+	percent, ok := firstNumber(raw, "percent", "usage_percent", "percentage", "used_percent")
+	if !ok {
+		if u, uok := firstNumber(raw, "utilization"); uok {
+			percent, ok = u, true
+			if u <= 1 {
+				percent = u * 100
+			}
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("webhook payload has no usage percentage")
+	}
+	a.Percent = percent
+
+	for _, key := range []string{"reset_at", "resets_at", "reset", "reset_time"} {
+		if t, ok := parseTimeValue(raw[key]); ok {
+			a.ResetAt = t
+			break
+		}
+	}
+
+	if a.Provider == "" {
+		return nil, fmt.Errorf("webhook payload has no provider")
+	}
+	if a.Account == "" && a.Profile == "" {
+		return nil, fmt.Errorf("webhook payload names no account or profile")
+	}
+	return a, nil
+}
+
+var (
+	percentPattern = regexp.MustCompile(`(\d{1,3}(?:\.\d+)?)\s?%`)
+	limitReached   = regexp.MustCompile(`(?i)(reached|hit|exceeded|used all of) (your|the) (usage |rate )?limit`)
+	resetPattern   = regexp.MustCompile(`(?i)resets?\s+(?:on|at|in)?\s*([^\n.]+)`)
+	htmlTag        = regexp.MustCompile(`<[^>]*>`)
+)
+
+// resetLayouts are the date formats recognised after "resets on/at".
+var resetLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"January 2, 2006 at 3:04 PM MST",
+	"January 2, 2006 3:04 PM MST",
+	"January 2, 2006 at 3:04 PM",
+	"January 2, 2006",
+	"Jan 2, 2006 3:04 PM MST",
+	"Jan 2, 2006",
+	"2006-01-02 15:04 MST",
+	"2006-01-02",
+}
+
+// ParseEmail parses a provider usage-alert email. The provider is taken
+// from the sender's domain and the account from the recipient; the usage
+// percentage, limit window, and reset time are read from the subject and
+// text body.
+func ParseEmail(r io.Reader) (*Alert, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("parse email: %w", err)
+	}
+
+	a := &Alert{Source: SourceEmail, ReceivedAt: time.Now().UTC()}
+	dec := new(mime.WordDecoder)
+	a.Subject = msg.Header.Get("Subject")
+	if s, err := dec.DecodeHeader(a.Subject); err == nil {
+		a.Subject = s
+	}
+	if t, err := msg.Header.Date(); err == nil {
+		a.ReceivedAt = t.UTC()
+	}
+
+	if from, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		a.Provider = providerForAddress(from.Address)
+	}
+	if a.Provider == "" {
+		return nil, fmt.Errorf("email sender %q is not a known provider", msg.Header.Get("From"))
+	}
+	for _, h := range []string{"Delivered-To", "X-Original-To", "To"} {
+		if list, err := mail.ParseAddressList(msg.Header.Get(h)); err == nil && len(list) > 0 {
+			a.Account = list[0].Address
+			break
+		}
+	}
+
+	body, err := emailText(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	text := a.Subject + "\n" + body
+
+	switch {
+	case percentPattern.MatchString(text):
+		m := percentPattern.FindStringSubmatch(text)
+		a.Percent, _ = strconv.ParseFloat(m[1], 64)
+	case limitReached.MatchString(text):
+		a.Percent = 100
+	default:
+		return nil, fmt.Errorf("email does not look like a usage alert (no percentage found)")
+	}
+
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(lower, "5-hour") || strings.Contains(lower, "5 hour"):
+		a.Window = "5h"
+	case strings.Contains(lower, "weekly") || strings.Contains(lower, "week"):
+		a.Window = "weekly"
+	case strings.Contains(lower, "monthly") || strings.Contains(lower, "month"):
+		a.Window = "monthly"
+	case strings.Contains(lower, "daily"):
+		a.Window = "daily"
+	}
+
+	if m := resetPattern.FindStringSubmatch(text); m != nil {
+		if t, ok := parseResetPhrase(strings.TrimSpace(m[1]), a.ReceivedAt); ok {
+			a.ResetAt = t
+		}
+	}
+	return a, nil
+}
+
+// providerForAddress maps a sender address to a provider by domain.
+func providerForAddress(addr string) string {
+	at := strings.LastIndex(addr, "@")
+	if at < 0 {
+		return ""
+	}
+	domain := strings.ToLower(addr[at+1:])
+	for suffix, provider := range senderProviders {
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return provider
+		}
+	}
+	return ""
+}
+
+// emailText returns the plain-text content of a message body, preferring
+// text/plain parts and falling back to tag-stripped HTML.
+func emailText(contentType, encoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		var htmlText string
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", fmt.Errorf("read email part: %w", err)
+			}
+			text, err := emailText(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				continue
+			}
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			if partType == "text/plain" || strings.HasPrefix(partType, "multipart/") {
+				return text, nil
+			}
+			if htmlText == "" {
+				htmlText = text
+			}
+		}
+		return htmlText, nil
+	}
+
+	if strings.EqualFold(strings.TrimSpace(encoding), "quoted-printable") {
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("read email body: %w", err)
+	}
+	if mediaType == "text/html" {
+		data = htmlTag.ReplaceAll(data, []byte(" "))
+	}
+	return string(bytes.TrimSpace(data)), nil
+}
+
+// parseResetPhrase parses the text after "resets on/at/in".
+func parseResetPhrase(s string, now time.Time) (time.Time, bool) {
+	s = strings.TrimRight(s, " ,;:")
+	if d, err := time.ParseDuration(strings.ReplaceAll(s, " ", "")); err == nil && d > 0 {
+		return now.Add(d), true
+	}
+	for _, layout := range resetLayouts {
+		// Try progressively shorter prefixes so trailing words don't
+		// defeat the match.
+		for end := len(s); end > 0; end = strings.LastIndex(s[:end], " ") {
+			if t, err := time.Parse(layout, s[:end]); err == nil {
+				return t.UTC(), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func firstString(raw map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := raw[k].(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+func firstNumber(raw map[string]interface{}, keys ...string) (float64, bool) {
+	for _, k := range keys {
+		switch v := raw[k].(type) {
+		case float64:
+			return v, true
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "%"), 64); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func parseTimeValue(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case string:
+		if parsed, err := time.Parse(time.RFC3339, t); err == nil {
+			return parsed.UTC(), true
+		}
+		if secs, err := strconv.ParseInt(t, 10, 64); err == nil && secs > 0 {
+			return time.Unix(secs, 0).UTC(), true
+		}
+	case float64:
+		if t > 0 {
+			return time.Unix(int64(t), 0).UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,198 @@
+package usagealert
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func TestParseWebhook(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		body     string
+		want     Alert
+		wantErr  bool
+	}{
+		{
+			name:     "percent and RFC 3339 reset",
+			provider: "claude",
+			body:     `{"email":"alice@example.com","percent":92,"window":"weekly","reset_at":"2026-01-05T00:00:00Z"}`,
+			want:     Alert{Provider: "claude", Account: "alice@example.com", Percent: 92, Window: "weekly", ResetAt: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:     "fractional utilization and unix reset",
+			provider: "codex",
+			body:     `{"account":"bob@example.com","utilization":0.9,"resets_at":1767571200}`,
+			want:     Alert{Provider: "codex", Account: "bob@example.com", Percent: 90, ResetAt: time.Unix(1767571200, 0).UTC()},
+		},
+		{
+			name:     "provider in payload wins",
+			provider: "",
+			body:     `{"provider":"Gemini","profile":"work","usage_percent":"75%"}`,
+			want:     Alert{Provider: "gemini", Profile: "work", Percent: 75},
+		},
+		{name: "no percent", provider: "claude", body: `{"email":"a@b.c"}`, wantErr: true},
+		{name: "no account", provider: "claude", body: `{"percent":90}`, wantErr: true},
+		{name: "not json", provider: "claude", body: `percent=90`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWebhook(tt.provider, []byte(tt.body))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseWebhook() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWebhook() error = %v", err)
+			}
+			if got.Provider != tt.want.Provider || got.Account != tt.want.Account || got.Profile != tt.want.Profile ||
+				got.Percent != tt.want.Percent || got.Window != tt.want.Window || !got.ResetAt.Equal(tt.want.ResetAt) {
+				t.Errorf("ParseWebhook() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+const multipartEmail = "From: Anthropic <no-reply@mail.anthropic.com>\r\n" +
+	"To: alice@example.com\r\n" +
+	"Subject: You've used 90% of your weekly limit\r\n" +
+	"Date: Mon, 05 Jan 2026 10:00:00 +0000\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"b1\"\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>ignored</p>\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Your usage resets on January 8, 2026 at 5:00 PM UTC. Upgrade for more=\r\n" +
+	" capacity.\r\n" +
+	"--b1--\r\n"
+
+func TestParseEmail(t *testing.T) {
+	a, err := ParseEmail(strings.NewReader(multipartEmail))
+	if err != nil {
+		t.Fatalf("ParseEmail() error = %v", err)
+	}
+	if a.Provider != "claude" || a.Account != "alice@example.com" || a.Percent != 90 || a.Window != "weekly" {
+		t.Errorf("ParseEmail() = %+v", a)
+	}
+	wantReset := time.Date(2026, 1, 8, 17, 0, 0, 0, time.UTC)
+	if !a.ResetAt.Equal(wantReset) {
+		t.Errorf("ResetAt = %v, want %v", a.ResetAt, wantReset)
+	}
+
+	limitHit := "From: OpenAI <noreply@openai.com>\r\nTo: bob@example.com\r\nSubject: Usage update\r\n\r\nYou have reached your usage limit. It resets in 3h.\r\n"
+	a, err = ParseEmail(strings.NewReader(limitHit))
+	if err != nil {
+		t.Fatalf("ParseEmail(limit hit) error = %v", err)
+	}
+	if a.Provider != "codex" || a.Percent != 100 || a.ResetAt.IsZero() {
+		t.Errorf("ParseEmail(limit hit) = %+v", a)
+	}
+
+	unknown := "From: someone@example.org\r\nTo: a@b.c\r\nSubject: 95% done\r\n\r\nhi\r\n"
+	if _, err := ParseEmail(strings.NewReader(unknown)); err == nil {
+		t.Error("ParseEmail() should reject unknown senders")
+	}
+}
+
+func newTestApplier(t *testing.T, now time.Time) (*Applier, *caamdb.DB) {
+	t.Helper()
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &Applier{
+		DB: db,
+		Resolve: func(provider, account string) string {
+			if account == "alice@example.com" {
+				return "alice"
+			}
+			return ""
+		},
+		CriticalPercent:  85,
+		FallbackCooldown: 30 * time.Minute,
+		now:              func() time.Time { return now },
+	}, db
+}
+
+func TestApplierSchedulesCooldown(t *testing.T) {
+	now := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	a, db := newTestApplier(t, now)
+
+	// Below the threshold: logged only.
+	res, err := a.Apply(&Alert{Provider: "claude", Account: "alice@example.com", Percent: 70, Source: SourceEmail})
+	if err != nil || res.Action != "logged" || res.Profile != "alice" {
+		t.Fatalf("Apply(70%%) = %+v, %v", res, err)
+	}
+
+	// Critical with a reset time: cooldown until the reset.
+	reset := now.Add(6 * time.Hour)
+	res, err = a.Apply(&Alert{Provider: "claude", Account: "alice@example.com", Percent: 92, ResetAt: reset, Source: SourceWebhook})
+	if err != nil || res.Action != "cooldown" || !res.Cooldown.CooldownUntil.Equal(reset) {
+		t.Fatalf("Apply(92%%) = %+v, %v", res, err)
+	}
+
+	// A shorter follow-up keeps the longer cooldown.
+	res, err = a.Apply(&Alert{Provider: "claude", Account: "alice@example.com", Percent: 95, Source: SourceWebhook})
+	if err != nil || res.Action != "cooldown_kept" {
+		t.Fatalf("Apply(95%%, no reset) = %+v, %v", res, err)
+	}
+
+	events, err := db.GetEvents("claude", "alice", time.Time{}, 10)
+	if err != nil || len(events) != 3 || events[0].Type != EventUsageAlert {
+		t.Errorf("events = %+v, %v", events, err)
+	}
+
+	if _, err := a.Apply(&Alert{Provider: "claude", Account: "nobody@example.com", Percent: 99}); err == nil {
+		t.Error("Apply() should fail for an unknown account")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	a, _ := newTestApplier(t, time.Now().UTC())
+	h := &Handler{Secret: "s3cret", Applier: a}
+
+	post := func(path, auth, body string) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", "Bearer "+auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	body := `{"email":"alice@example.com","percent":90}`
+	if code := post("/usage/claude", "", body); code != http.StatusUnauthorized {
+		t.Errorf("no secret: status %d", code)
+	}
+	if code := post("/usage/claude", "wrong", body); code != http.StatusUnauthorized {
+		t.Errorf("wrong secret: status %d", code)
+	}
+	if code := post("/usage/claude", "s3cret", body); code != http.StatusOK {
+		t.Errorf("valid webhook: status %d", code)
+	}
+	if code := post("/usage/claude?secret=s3cret", "", body); code != http.StatusOK {
+		t.Errorf("query secret: status %d", code)
+	}
+	if code := post("/usage/claude", "s3cret", `{"email":"x@y.z","percent":90}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("unknown account: status %d", code)
+	}
+	if code := post("/usage/email", "s3cret", multipartEmail); code != http.StatusOK {
+		t.Errorf("email relay: status %d", code)
+	}
+}
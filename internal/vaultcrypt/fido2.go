@@ -0,0 +1,388 @@
+package vaultcrypt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNoSecurityKey means no FIDO2 security key is plugged in, or the
+// libfido2 tools caam drives it with are not installed.
+var ErrNoSecurityKey = errors.New("no FIDO2 security key available")
+
+// SecurityKey is a FIDO2 authenticator with the hmac-secret extension. The
+// vault key is wrapped with the secret the authenticator derives from a
+// per-enrollment salt, so it can only be unwrapped with the same device.
+type SecurityKey interface {
+	// Name describes the device, e.g. "Yubico YubiKey OTP+FIDO+CCID".
+	Name() string
+
+	// Enroll creates a credential with hmac-secret enabled and returns its
+	// ID.
+	Enroll(rpID, user string) ([]byte, error)
+
+	// HMACSecret returns the hmac-secret output for salt under the
+	// credential credID.
+	HMACSecret(rpID string, credID, salt []byte) ([]byte, error)
+}
+
+// securityKeyRPID is the relying party security key credentials are
+// created for.
+const securityKeyRPID = "caam"
+
+// systemSecurityKey returns the first plugged-in security key; tests
+// replace it.
+var systemSecurityKey = fido2Device
+
+// Notice tells the user to touch their security key. The CLI installs one
+// that writes to the terminal; nil stays silent.
+var Notice func(msg string)
+
+func notice(format string, args ...any) {
+	if Notice != nil {
+		Notice(fmt.Sprintf(format, args...))
+	}
+}
+
+// fido2Tool drives a security key with the libfido2 command-line tools
+// (fido2-token, fido2-cred and fido2-assert), which are available on
+// macOS, Linux and Windows.
+type fido2Tool struct {
+	path string
+	name string
+}
+
+func fido2Device() (SecurityKey, error) {
+	if _, err := exec.LookPath("fido2-token"); err != nil {
+		return nil, fmt.Errorf("%w: install the libfido2 tools (fido2-token, fido2-cred, fido2-assert)", ErrNoSecurityKey)
+	}
+	out, err := runTool("", "fido2-token", "-L")
+	if err != nil {
+		return nil, fmt.Errorf("list security keys: %w", err)
+	}
+	// Each line is "<path>: vendor=..., product=... (<name>)".
+	for _, line := range strings.Split(out, "\n") {
+		path, desc, ok := strings.Cut(strings.TrimSpace(line), ": ")
+		if !ok {
+			continue
+		}
+		name := "security key"
+		if i := strings.LastIndex(desc, "("); i >= 0 && strings.HasSuffix(desc, ")") {
+			name = desc[i+1 : len(desc)-1]
+		}
+		return &fido2Tool{path: path, name: name}, nil
+	}
+	return nil, ErrNoSecurityKey
+}
+
+func (f *fido2Tool) Name() string { return f.name }
+
+func (f *fido2Tool) Enroll(rpID, user string) ([]byte, error) {
+	cdh, err := randomBase64(32)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := randomBase64(16)
+	if err != nil {
+		return nil, err
+	}
+	// Input: client data hash, relying party, user name, user ID. The
+	// credential ID is the fifth line of the output.
+	in := strings.Join([]string{cdh, rpID, user, userID}, "\n") + "\n"
+	out, err := runTool(in, "fido2-cred", "-M", "-h", f.path)
+	if err != nil {
+		return nil, fmt.Errorf("create credential on %s: %w", f.name, err)
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) < 5 {
+		return nil, fmt.Errorf("create credential on %s: unexpected fido2-cred output", f.name)
+	}
+	return decodeBase64(lines[4], "credential ID")
+}
+
+func (f *fido2Tool) HMACSecret(rpID string, credID, salt []byte) ([]byte, error) {
+	cdh, err := randomBase64(32)
+	if err != nil {
+		return nil, err
+	}
+	// Input: client data hash, relying party, credential ID, hmac salt. The
+	// hmac-secret is the last line of the output.
+	in := strings.Join([]string{
+		cdh,
+		rpID,
+		base64.StdEncoding.EncodeToString(credID),
+		base64.StdEncoding.EncodeToString(salt),
+	}, "\n") + "\n"
+	out, err := runTool(in, "fido2-assert", "-G", "-h", "-p", f.path)
+	if err != nil {
+		return nil, fmt.Errorf("get assertion from %s: %w", f.name, err)
+	}
+	lines := strings.Split(out, "\n")
+	return decodeBase64(lines[len(lines)-1], "hmac-secret")
+}
+
+// wrappedKey is the vault key wrapped for one security key or recovery
+// code.
+type wrappedKey struct {
+	Name         string `json:"name,omitempty"`
+	CredentialID string `json:"credential_id,omitempty"`
+	Salt         string `json:"salt"`
+	Nonce        string `json:"nonce"`
+	WrappedKey   string `json:"wrapped_key"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// RecoveryCodeEnv supplies a recovery code without prompting, for when no
+// enrolled security key is at hand.
+const RecoveryCodeEnv = "CAAM_VAULT_RECOVERY_CODE"
+
+// recoveryCodeCount is how many recovery codes an enrollment creates.
+const recoveryCodeCount = 8
+
+// recoveryAlphabet is Crockford's base32, which leaves out letters easily
+// mistaken for digits.
+const recoveryAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// EnrollSecurityKey lets the security key that is plugged in unlock the
+// vault at vaultDir. Unless only is set, the keychain or passphrase keeps
+// working too; with only, it is removed and the vault can then be unlocked
+// only with an enrolled security key or a recovery code.
+//
+// The first enrollment, or one with newCodes set, creates a set of
+// single-use recovery codes and returns them; they are not stored in the
+// clear and cannot be shown again. Otherwise the returned slice is nil.
+func EnrollSecurityKey(vaultDir, name string, only, newCodes bool) ([]string, error) {
+	dir := filepath.Clean(vaultDir)
+	key, err := Key(dir)
+	if err != nil {
+		return nil, err
+	}
+	kf, err := readKeyFile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sk, err := systemSecurityKey()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = sk.Name()
+	}
+	notice("Touch %s to register it...", sk.Name())
+	credID, err := sk.Enroll(securityKeyRPID, "vault")
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+	notice("Touch %s again to confirm...", sk.Name())
+	secret, err := sk.HMACSecret(securityKeyRPID, credID, salt)
+	if err != nil {
+		return nil, err
+	}
+	wk, err := wrapFor(secret, key)
+	if err != nil {
+		return nil, err
+	}
+	wk.Name = name
+	wk.CredentialID = base64.StdEncoding.EncodeToString(credID)
+	wk.Salt = base64.StdEncoding.EncodeToString(salt)
+	kf.SecurityKeys = append(kf.SecurityKeys, wk)
+
+	var codes []string
+	if newCodes || len(kf.RecoveryCodes) == 0 {
+		if codes, err = newRecoveryCodes(kf, key); err != nil {
+			return nil, err
+		}
+	}
+
+	oldMode, oldKeyID := kf.Mode, kf.KeyID
+	if only {
+		kf.Mode = ModeSecurityKey
+		kf.Keychain, kf.KeyID, kf.KeyRef = "", "", ""
+		kf.Salt, kf.Nonce, kf.WrappedKey = "", "", ""
+	}
+	if err := writeKeyFile(dir, kf); err != nil {
+		return nil, err
+	}
+	// The key file no longer points at the keychain entry, so drop it only
+	// now that the security key can unlock the vault.
+	if only && oldMode == ModeKeychain {
+		kc, err := systemKeychain()
+		if err == nil {
+			err = kc.Delete(oldKeyID)
+		}
+		if err != nil {
+			return codes, fmt.Errorf("security key enrolled, but the old vault key could not be deleted from the keychain: %w", err)
+		}
+	}
+	return codes, nil
+}
+
+// newRecoveryCodes replaces kf's recovery codes with a new set wrapping
+// key and returns the codes.
+func newRecoveryCodes(kf *keyFile, key []byte) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	wrapped := make([]wrappedKey, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := io.ReadFull(rand.Reader, b); err != nil {
+			return nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		for j := range b {
+			b[j] = recoveryAlphabet[b[j]&31]
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+
+		salt := make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, fmt.Errorf("generate salt: %w", err)
+		}
+		wk, err := wrapFor(passphraseKey(normalizeRecoveryCode(codes[i]), salt), key)
+		if err != nil {
+			return nil, err
+		}
+		wk.Salt = base64.StdEncoding.EncodeToString(salt)
+		wrapped[i] = wk
+	}
+	kf.RecoveryCodes = wrapped
+	return codes, nil
+}
+
+// unlockWithSecurityKey unwraps the vault key with the plugged-in security
+// key, if it is one of those enrolled.
+func unlockWithSecurityKey(kf *keyFile) ([]byte, error) {
+	sk, err := systemSecurityKey()
+	if err != nil {
+		return nil, err
+	}
+	lastErr := fmt.Errorf("%s is not enrolled for this vault", sk.Name())
+	for _, wk := range kf.SecurityKeys {
+		credID, err := decodeBase64(wk.CredentialID, "credential ID")
+		if err != nil {
+			return nil, err
+		}
+		salt, err := decodeBase64(wk.Salt, "salt")
+		if err != nil {
+			return nil, err
+		}
+		notice("Touch %s to unlock the vault...", sk.Name())
+		secret, err := sk.HMACSecret(securityKeyRPID, credID, salt)
+		if err != nil {
+			// The device may not hold this credential; try the next one.
+			lastErr = err
+			continue
+		}
+		if key, err := unwrapFrom(secret, wk); err == nil {
+			return key, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// unlockWithRecoveryCode unwraps the vault key with a recovery code and
+// removes the code from the key file, so it cannot be used again.
+func unlockWithRecoveryCode(dir string, kf *keyFile) ([]byte, error) {
+	code := os.Getenv(RecoveryCodeEnv)
+	if code == "" {
+		if Prompt == nil {
+			return nil, fmt.Errorf("set %s to unlock the vault with a recovery code", RecoveryCodeEnv)
+		}
+		var err error
+		if code, err = Prompt("Vault recovery code: "); err != nil {
+			return nil, fmt.Errorf("read recovery code: %w", err)
+		}
+	}
+	code = normalizeRecoveryCode(code)
+	for i, wk := range kf.RecoveryCodes {
+		salt, err := decodeBase64(wk.Salt, "salt")
+		if err != nil {
+			return nil, err
+		}
+		key, err := unwrapFrom(passphraseKey(code, salt), wk)
+		if err != nil {
+			continue
+		}
+		kf.RecoveryCodes = append(kf.RecoveryCodes[:i:i], kf.RecoveryCodes[i+1:]...)
+		if err := writeKeyFile(dir, kf); err != nil {
+			return nil, fmt.Errorf("use recovery code: %w", err)
+		}
+		notice("Recovery code used; %d left. Run 'caam vault enroll-key --new-recovery-codes' for a new set.", len(kf.RecoveryCodes))
+		return key, nil
+	}
+	return nil, fmt.Errorf("unlock vault key: wrong recovery code?")
+}
+
+// normalizeRecoveryCode drops the dash and spacing from a typed code.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
+
+// wrapFor seals key under kek. The caller fills in the salt and, for a
+// security key, its name and credential.
+func wrapFor(kek, key []byte) (wrappedKey, error) {
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return wrappedKey{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return wrappedKey{}, fmt.Errorf("generate nonce: %w", err)
+	}
+	return wrappedKey{
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		WrappedKey: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, key, nil)),
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+func unwrapFrom(kek []byte, wk wrappedKey) ([]byte, error) {
+	nonce, err := decodeBase64(wk.Nonce, "nonce")
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := decodeBase64(wk.WrappedKey, "wrapped key")
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce in key file")
+	}
+	return gcm.Open(nil, nonce, wrapped, nil)
+}
+
+func randomBase64(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", fmt.Errorf("generate random data: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func decodeBase64(s, what string) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", what, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,79 @@
+package vaultcrypt
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoKeychain means the platform has no usable OS keychain.
+var ErrNoKeychain = errors.New("no OS keychain available")
+
+// Keychain keeps the vault key in an OS credential store.
+type Keychain interface {
+	// Name describes the store, e.g. "macOS Keychain".
+	Name() string
+
+	// Store saves key under id. It returns data the key file must keep to
+	// load the key again; stores that hold the key themselves return "".
+	Store(id string, key []byte) (string, error)
+
+	// Load returns the key saved under id.
+	Load(id, ref string) ([]byte, error)
+
+	// Delete removes the key saved under id.
+	Delete(id string) error
+}
+
+// keychainService is the service name vault keys are stored under.
+const keychainService = "caam-vault"
+
+// systemKeychain returns the platform keychain; tests replace it.
+var systemKeychain = platformKeychain
+
+// runTool runs a keychain helper, returning its trimmed stdout.
+func runTool(stdin string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// StoreSecret saves a secret other than the vault key, such as a provider
+// API key, in the OS keychain under id. Like Keychain.Store it returns data
+// the caller must keep to load the secret again.
+func StoreSecret(id string, secret []byte) (string, error) {
+	kc, err := systemKeychain()
+	if err != nil {
+		return "", err
+	}
+	return kc.Store(id, secret)
+}
+
+// LoadSecret returns a secret saved by StoreSecret.
+func LoadSecret(id, ref string) ([]byte, error) {
+	kc, err := systemKeychain()
+	if err != nil {
+		return nil, err
+	}
+	return kc.Load(id, ref)
+}
+
+// DeleteSecret removes a secret saved by StoreSecret.
+func DeleteSecret(id string) error {
+	kc, err := systemKeychain()
+	if err != nil {
+		return err
+	}
+	return kc.Delete(id)
+}
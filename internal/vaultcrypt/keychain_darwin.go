@@ -0,0 +1,62 @@
+//go:build darwin
+
+package vaultcrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// macKeychain stores the key in the login keychain via security(1).
+type macKeychain struct{}
+
+func platformKeychain() (Keychain, error) {
+	if _, err := exec.LookPath("security"); err != nil {
+		return nil, ErrNoKeychain
+	}
+	return macKeychain{}, nil
+}
+
+func (macKeychain) Name() string { return "macOS Keychain" }
+
+// Store runs the command through "security -i", which reads it from stdin,
+// so the key never shows up in the process list the way a -w argument does.
+// Interactive mode doesn't fail on a failed command, so the key is read
+// back to check it was stored.
+func (k macKeychain) Store(id string, key []byte) (string, error) {
+	command := strings.Join([]string{"add-generic-password", "-U",
+		"-s", securityQuote(keychainService), "-a", securityQuote(id),
+		"-l", securityQuote("caam vault key"), "-w", hex.EncodeToString(key)}, " ")
+	if _, err := runTool(command+"\n", "security", "-i"); err != nil {
+		return "", err
+	}
+	if stored, err := k.Load(id, ""); err != nil || !bytes.Equal(stored, key) {
+		return "", fmt.Errorf("store vault key in keychain: not found after adding")
+	}
+	return "", nil
+}
+
+// securityQuote quotes an argument for security(1)'s interactive mode.
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (macKeychain) Load(id, _ string) ([]byte, error) {
+	out, err := runTool("", "security", "find-generic-password", "-s", keychainService, "-a", id, "-w")
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(out)
+	if err != nil {
+		return nil, fmt.Errorf("decode vault key: %w", err)
+	}
+	return key, nil
+}
+
+func (macKeychain) Delete(id string) error {
+	_, err := runTool("", "security", "delete-generic-password", "-s", keychainService, "-a", id)
+	return err
+}
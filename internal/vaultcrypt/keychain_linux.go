@@ -0,0 +1,52 @@
+//go:build linux
+
+package vaultcrypt
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// secretService stores the key through the freedesktop secret service
+// (GNOME Keyring, KWallet) using secret-tool(1).
+type secretService struct{}
+
+func platformKeychain() (Keychain, error) {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return nil, ErrNoKeychain
+	}
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil, ErrNoKeychain
+	}
+	return secretService{}, nil
+}
+
+func (secretService) Name() string { return "secret service" }
+
+func (secretService) Store(id string, key []byte) (string, error) {
+	_, err := runTool(hex.EncodeToString(key), "secret-tool", "store",
+		"--label=caam vault key", "service", keychainService, "account", id)
+	return "", err
+}
+
+func (secretService) Load(id, _ string) ([]byte, error) {
+	out, err := runTool("", "secret-tool", "lookup", "service", keychainService, "account", id)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, fmt.Errorf("no vault key stored for %s", id)
+	}
+	key, err := hex.DecodeString(out)
+	if err != nil {
+		return nil, fmt.Errorf("decode vault key: %w", err)
+	}
+	return key, nil
+}
+
+func (secretService) Delete(id string) error {
+	_, err := runTool("", "secret-tool", "clear", "service", keychainService, "account", id)
+	return err
+}
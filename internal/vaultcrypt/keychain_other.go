@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !windows
+
+package vaultcrypt
+
+func platformKeychain() (Keychain, error) {
+	return nil, ErrNoKeychain
+}
@@ -0,0 +1,61 @@
+//go:build windows
+
+package vaultcrypt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// dpapi protects the key with the user's Windows credentials. The protected
+// blob is kept in the key file; only the same user can unprotect it.
+type dpapi struct{}
+
+func platformKeychain() (Keychain, error) {
+	return dpapi{}, nil
+}
+
+func (dpapi) Name() string { return "Windows DPAPI" }
+
+func (dpapi) Store(_ string, key []byte) (string, error) {
+	in := newBlob(key)
+	var out windows.DataBlob
+	if err := windows.CryptProtectData(in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return "", fmt.Errorf("CryptProtectData: %w", err)
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	return base64.StdEncoding.EncodeToString(blobBytes(&out)), nil
+}
+
+func (dpapi) Load(_, ref string) ([]byte, error) {
+	protected, err := base64.StdEncoding.DecodeString(ref)
+	if err != nil {
+		return nil, fmt.Errorf("decode protected key: %w", err)
+	}
+	in := newBlob(protected)
+	var out windows.DataBlob
+	if err := windows.CryptUnprotectData(in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return nil, fmt.Errorf("CryptUnprotectData: %w", err)
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	return blobBytes(&out), nil
+}
+
+// Delete is a no-op: the protected key lives in the key file.
+func (dpapi) Delete(string) error { return nil }
+
+func newBlob(data []byte) *windows.DataBlob {
+	if len(data) == 0 {
+		return &windows.DataBlob{}
+	}
+	return &windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+}
+
+func blobBytes(b *windows.DataBlob) []byte {
+	out := make([]byte, b.Size)
+	copy(out, unsafe.Slice(b.Data, b.Size))
+	return out
+}
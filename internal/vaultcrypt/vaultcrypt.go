@@ -0,0 +1,569 @@
+// Package vaultcrypt encrypts vault auth files at rest.
+//
+// An encrypted vault has a key file (.caam_vault_key) at its root. The key
+// file records where the 32-byte vault key lives: in the OS keychain (macOS
+// Keychain, Linux secret service, Windows DPAPI) or wrapped with a passphrase
+// when no keychain is available. FIDO2 security keys and single-use recovery
+// codes can be enrolled to unlock it too, or instead. Encrypted files start with a magic header,
+// so plaintext and encrypted files can coexist while a vault is migrated and
+// readers can decrypt transparently.
+package vaultcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// KeyFileName is the key file at the root of an encrypted vault.
+const KeyFileName = ".caam_vault_key"
+
+// PassphraseEnv supplies the passphrase for passphrase-mode vaults without
+// prompting.
+const PassphraseEnv = "CAAM_VAULT_PASSPHRASE"
+
+// KeySize is the size of the vault key in bytes (AES-256).
+const KeySize = 32
+
+// magic prefixes every encrypted vault file.
+var magic = []byte("CAAMVLT1")
+
+// PoolMagic prefixes vault files sealed with a sync pool key. Peers in an
+// encrypted sync pool write profiles in this form, so a machine without the
+// pool key only ever holds ciphertext.
+var PoolMagic = []byte("CAAMPOOL1")
+
+// PoolOpener decrypts a pool-sealed file read from path. The sync package
+// installs it; without it, pool-sealed files can't be read.
+var PoolOpener func(path string, data []byte) ([]byte, error)
+
+// IsPoolSealed reports whether data is sealed with a sync pool key.
+func IsPoolSealed(data []byte) bool {
+	return bytes.HasPrefix(data, PoolMagic)
+}
+
+// Mode is where the vault key is kept.
+type Mode string
+
+const (
+	// ModeKeychain keeps the key in the OS keychain.
+	ModeKeychain Mode = "keychain"
+	// ModePassphrase keeps the key in the key file, wrapped with a
+	// passphrase-derived key.
+	ModePassphrase Mode = "passphrase"
+	// ModeSecurityKey keeps the key only wrapped for enrolled FIDO2
+	// security keys and recovery codes.
+	ModeSecurityKey Mode = "security-key"
+)
+
+// Argon2id parameters for wrapping the vault key with a passphrase.
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+)
+
+// Prompt asks for the vault passphrase when CAAM_VAULT_PASSPHRASE is unset.
+// The CLI installs a terminal prompt; nil disables prompting.
+var Prompt func(prompt string) (string, error)
+
+// keyFile is the JSON stored in KeyFileName.
+type keyFile struct {
+	Version   int    `json:"version"`
+	Mode      Mode   `json:"mode"`
+	CreatedAt string `json:"created_at"`
+
+	// Keychain mode.
+	Keychain string `json:"keychain,omitempty"`
+	KeyID    string `json:"key_id,omitempty"`
+	KeyRef   string `json:"key_ref,omitempty"`
+
+	// Passphrase mode.
+	Salt       string `json:"salt,omitempty"`
+	Nonce      string `json:"nonce,omitempty"`
+	WrappedKey string `json:"wrapped_key,omitempty"`
+
+	// Enrolled security keys and unused recovery codes, in any mode.
+	SecurityKeys  []wrappedKey `json:"security_keys,omitempty"`
+	RecoveryCodes []wrappedKey `json:"recovery_codes,omitempty"`
+}
+
+var (
+	keyMu    sync.Mutex
+	keyCache = make(map[string][]byte)
+)
+
+// Enabled reports whether the vault at vaultDir is encrypted.
+func Enabled(vaultDir string) bool {
+	_, err := os.Stat(filepath.Join(vaultDir, KeyFileName))
+	return err == nil
+}
+
+// Describe returns where the vault key is kept, e.g. "macOS Keychain" or
+// "passphrase, 1 security key, 8 recovery codes".
+func Describe(vaultDir string) (string, error) {
+	kf, err := readKeyFile(vaultDir)
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	switch kf.Mode {
+	case ModeKeychain:
+		parts = append(parts, kf.Keychain)
+	case ModePassphrase:
+		parts = append(parts, string(kf.Mode))
+	}
+	if n := len(kf.SecurityKeys); n > 0 {
+		parts = append(parts, plural(n, "security key"))
+	}
+	if n := len(kf.RecoveryCodes); n > 0 || kf.Mode == ModeSecurityKey {
+		parts = append(parts, plural(n, "recovery code"))
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+func plural(n int, what string) string {
+	if n == 1 {
+		return "1 " + what
+	}
+	return fmt.Sprintf("%d %ss", n, what)
+}
+
+// Init creates a vault key for vaultDir and records it in the key file. An
+// empty mode uses the OS keychain when one is available and falls back to a
+// passphrase otherwise. It returns the description of where the key is kept.
+func Init(vaultDir string, mode Mode) (string, error) {
+	if Enabled(vaultDir) {
+		return "", fmt.Errorf("vault %s is already encrypted", vaultDir)
+	}
+	if mode != "" && mode != ModeKeychain && mode != ModePassphrase {
+		return "", fmt.Errorf("unknown key mode %q", mode)
+	}
+
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", fmt.Errorf("generate vault key: %w", err)
+	}
+
+	kf := &keyFile{Version: 1, CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+	if mode != ModePassphrase {
+		if err := storeInKeychain(kf, key); err != nil {
+			if mode == ModeKeychain {
+				return "", err
+			}
+			if !errors.Is(err, ErrNoKeychain) {
+				return "", err
+			}
+		}
+	}
+	if kf.Mode == "" {
+		passphrase, err := newPassphrase()
+		if err != nil {
+			return "", err
+		}
+		if err := wrapWithPassphrase(kf, key, passphrase); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(vaultDir, 0700); err != nil {
+		return "", fmt.Errorf("create vault dir: %w", err)
+	}
+	if err := writeKeyFile(vaultDir, kf); err != nil {
+		return "", err
+	}
+
+	keyMu.Lock()
+	keyCache[filepath.Clean(vaultDir)] = key
+	keyMu.Unlock()
+
+	if kf.Mode == ModeKeychain {
+		return kf.Keychain, nil
+	}
+	return string(kf.Mode), nil
+}
+
+// Key returns the vault key for vaultDir, asking the keychain or for the
+// passphrase on first use. When that fails, or the vault is in
+// security-key mode, an enrolled security key and then a recovery code are
+// tried.
+func Key(vaultDir string) ([]byte, error) {
+	dir := filepath.Clean(vaultDir)
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if key, ok := keyCache[dir]; ok {
+		return key, nil
+	}
+
+	kf, err := readKeyFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	key, err := unlock(dir, kf)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("vault key has %d bytes, want %d", len(key), KeySize)
+	}
+
+	keyCache[dir] = key
+	return key, nil
+}
+
+// unlock unwraps the vault key with the first method that works: the
+// keychain or passphrase, an enrolled security key, then a recovery code.
+// It returns the first error if none does.
+func unlock(dir string, kf *keyFile) ([]byte, error) {
+	var firstErr error
+	switch kf.Mode {
+	case ModeKeychain:
+		kc, err := systemKeychain()
+		if err != nil {
+			firstErr = fmt.Errorf("vault key is in %s: %w", kf.Keychain, err)
+			break
+		}
+		key, err := kc.Load(kf.KeyID, kf.KeyRef)
+		if err == nil {
+			return key, nil
+		}
+		firstErr = fmt.Errorf("load vault key from %s: %w", kc.Name(), err)
+	case ModePassphrase:
+		passphrase, err := existingPassphrase()
+		if err == nil {
+			var key []byte
+			if key, err = unwrapWithPassphrase(kf, passphrase); err == nil {
+				return key, nil
+			}
+		}
+		firstErr = err
+	case ModeSecurityKey:
+	default:
+		return nil, fmt.Errorf("unknown vault key mode %q", kf.Mode)
+	}
+
+	if len(kf.SecurityKeys) > 0 {
+		key, err := unlockWithSecurityKey(kf)
+		if err == nil {
+			return key, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(kf.RecoveryCodes) > 0 {
+		key, err := unlockWithRecoveryCode(dir, kf)
+		if err == nil {
+			return key, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("no security key or recovery code can unlock the vault key")
+	}
+	return nil, firstErr
+}
+
+// Remove deletes the vault key from the keychain and removes the key file.
+// Call it only after every file has been decrypted.
+func Remove(vaultDir string) error {
+	dir := filepath.Clean(vaultDir)
+	kf, err := readKeyFile(dir)
+	if err != nil {
+		return err
+	}
+	if kf.Mode == ModeKeychain {
+		kc, err := systemKeychain()
+		if err != nil {
+			return fmt.Errorf("vault key is in %s: %w", kf.Keychain, err)
+		}
+		if err := kc.Delete(kf.KeyID); err != nil {
+			return fmt.Errorf("delete vault key from %s: %w", kc.Name(), err)
+		}
+	}
+	if err := os.Remove(filepath.Join(dir, KeyFileName)); err != nil {
+		return fmt.Errorf("remove key file: %w", err)
+	}
+
+	keyMu.Lock()
+	delete(keyCache, dir)
+	keyMu.Unlock()
+	return nil
+}
+
+// IsEncrypted reports whether data is an encrypted vault file.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Seal encrypts plaintext with AES-256-GCM under key.
+func Seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	out := append([]byte{}, magic...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, magic), nil
+}
+
+// Open decrypts data produced by Seal.
+func Open(key, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, fmt.Errorf("not an encrypted vault file")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	data = data[len(magic):]
+	n := gcm.NonceSize()
+	if len(data) < n {
+		return nil, fmt.Errorf("encrypted vault file is truncated")
+	}
+	plain, err := gcm.Open(nil, data[:n], data[n:], magic)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt vault file: %w", err)
+	}
+	return plain, nil
+}
+
+// VaultFor returns the encrypted vault that path lies in, or "" if none.
+// Vault files live at <vault>/<tool>/<profile>/<file>, so the key file is
+// looked for at most three directories up.
+func VaultFor(path string) string {
+	dir := filepath.Dir(filepath.Clean(path))
+	for i := 0; i < 3; i++ {
+		if Enabled(dir) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ""
+}
+
+// ReadFile reads path, decrypting it if it is an encrypted vault file or
+// sealed with a sync pool key. Plaintext files are returned unchanged, so
+// callers can use it for live auth files and vault copies alike.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if IsEncrypted(data) {
+		vaultDir := VaultFor(path)
+		if vaultDir == "" {
+			return nil, fmt.Errorf("%s is encrypted but no vault key file was found", path)
+		}
+		key, err := Key(vaultDir)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = Open(key, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if IsPoolSealed(data) {
+		if PoolOpener == nil {
+			return nil, fmt.Errorf("%s is sealed with a sync pool key", path)
+		}
+		return PoolOpener(path, data)
+	}
+	return data, nil
+}
+
+// SealFor encrypts data for writing to path when path lies in an encrypted
+// vault, and returns it unchanged otherwise.
+func SealFor(path string, data []byte) ([]byte, error) {
+	vaultDir := VaultFor(path)
+	if vaultDir == "" {
+		return data, nil
+	}
+	key, err := Key(vaultDir)
+	if err != nil {
+		return nil, err
+	}
+	return Seal(key, data)
+}
+
+func storeInKeychain(kf *keyFile, key []byte) error {
+	kc, err := systemKeychain()
+	if err != nil {
+		return err
+	}
+	id := make([]byte, 8)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return fmt.Errorf("generate key id: %w", err)
+	}
+	kf.KeyID = "vault-" + hex.EncodeToString(id)
+	ref, err := kc.Store(kf.KeyID, key)
+	if err != nil {
+		return fmt.Errorf("store vault key in %s: %w", kc.Name(), err)
+	}
+	kf.Mode = ModeKeychain
+	kf.Keychain = kc.Name()
+	kf.KeyRef = ref
+	return nil
+}
+
+func wrapWithPassphrase(kf *keyFile, key []byte, passphrase string) error {
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return fmt.Errorf("generate salt: %w", err)
+	}
+	gcm, err := newGCM(passphraseKey(passphrase, salt))
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("generate nonce: %w", err)
+	}
+	kf.Mode = ModePassphrase
+	kf.Salt = base64.StdEncoding.EncodeToString(salt)
+	kf.Nonce = base64.StdEncoding.EncodeToString(nonce)
+	kf.WrappedKey = base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, key, nil))
+	return nil
+}
+
+func unwrapWithPassphrase(kf *keyFile, passphrase string) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(kf.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("decode salt: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(kf.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("decode nonce: %w", err)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(kf.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode wrapped key: %w", err)
+	}
+	gcm, err := newGCM(passphraseKey(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce in key file")
+	}
+	key, err := gcm.Open(nil, nonce, wrapped, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unlock vault key: wrong passphrase?")
+	}
+	return key, nil
+}
+
+func passphraseKey(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, argon2Time, argon2Memory, argon2Threads, KeySize)
+}
+
+// newPassphrase gets the passphrase for a new vault key, asking twice when
+// prompting.
+func newPassphrase() (string, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return p, nil
+	}
+	if Prompt == nil {
+		return "", fmt.Errorf("no OS keychain available: set %s to encrypt the vault with a passphrase", PassphraseEnv)
+	}
+	p, err := Prompt("No OS keychain available. New vault passphrase: ")
+	if err != nil {
+		return "", fmt.Errorf("read passphrase: %w", err)
+	}
+	if p == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+	confirm, err := Prompt("Confirm vault passphrase: ")
+	if err != nil {
+		return "", fmt.Errorf("read passphrase: %w", err)
+	}
+	if confirm != p {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return p, nil
+}
+
+func existingPassphrase() (string, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return p, nil
+	}
+	if Prompt == nil {
+		return "", fmt.Errorf("vault is encrypted with a passphrase: set %s", PassphraseEnv)
+	}
+	p, err := Prompt("Vault passphrase: ")
+	if err != nil {
+		return "", fmt.Errorf("read passphrase: %w", err)
+	}
+	return p, nil
+}
+
+func readKeyFile(vaultDir string) (*keyFile, error) {
+	data, err := os.ReadFile(filepath.Join(vaultDir, KeyFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("vault %s is not encrypted", vaultDir)
+		}
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("parse key file: %w", err)
+	}
+	if kf.Version != 1 {
+		return nil, fmt.Errorf("unsupported key file version %d", kf.Version)
+	}
+	return &kf, nil
+}
+
+func writeKeyFile(vaultDir string, kf *keyFile) error {
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal key file: %w", err)
+	}
+	path := filepath.Join(vaultDir, KeyFileName)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("write key file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("rename key file: %w", err)
+	}
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create GCM: %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,297 @@
+package vaultcrypt
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// memKeychain is an in-memory Keychain for tests.
+type memKeychain map[string][]byte
+
+func (memKeychain) Name() string { return "test keychain" }
+
+func (m memKeychain) Store(id string, key []byte) (string, error) {
+	m[id] = append([]byte{}, key...)
+	return "", nil
+}
+
+func (m memKeychain) Load(id, _ string) ([]byte, error) {
+	key, ok := m[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return key, nil
+}
+
+func (m memKeychain) Delete(id string) error {
+	delete(m, id)
+	return nil
+}
+
+func useKeychain(t *testing.T, kc Keychain, err error) {
+	t.Helper()
+	old := systemKeychain
+	systemKeychain = func() (Keychain, error) { return kc, err }
+	t.Cleanup(func() { systemKeychain = old })
+}
+
+func forgetKeys(t *testing.T) {
+	t.Helper()
+	keyMu.Lock()
+	keyCache = make(map[string][]byte)
+	keyMu.Unlock()
+}
+
+func TestSealOpen(t *testing.T) {
+	key := make([]byte, KeySize)
+	sealed, err := Seal(key, []byte("token"))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if !IsEncrypted(sealed) || strings.Contains(string(sealed), "token") {
+		t.Fatalf("Seal() output not encrypted: %q", sealed)
+	}
+	plain, err := Open(key, sealed)
+	if err != nil || string(plain) != "token" {
+		t.Fatalf("Open() = %q, %v", plain, err)
+	}
+
+	other := make([]byte, KeySize)
+	other[0] = 1
+	if _, err := Open(other, sealed); err == nil {
+		t.Error("Open() with the wrong key succeeded")
+	}
+}
+
+func TestInit_Keychain(t *testing.T) {
+	kc := memKeychain{}
+	useKeychain(t, kc, nil)
+	dir := t.TempDir()
+
+	where, err := Init(dir, "")
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if where != "test keychain" || len(kc) != 1 {
+		t.Fatalf("Init() = %q with %d stored keys", where, len(kc))
+	}
+
+	// Files in the vault are sealed and read back transparently.
+	path := filepath.Join(dir, "codex", "work", "auth.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := SealFor(path, []byte(`{"a":1}`))
+	if err != nil || !IsEncrypted(sealed) {
+		t.Fatalf("SealFor() = %q, %v", sealed, err)
+	}
+	if err := os.WriteFile(path, sealed, 0600); err != nil {
+		t.Fatal(err)
+	}
+	forgetKeys(t)
+	if got, err := ReadFile(path); err != nil || string(got) != `{"a":1}` {
+		t.Fatalf("ReadFile() = %q, %v", got, err)
+	}
+
+	if err := Remove(dir); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if Enabled(dir) || len(kc) != 0 {
+		t.Errorf("Remove() left the key behind")
+	}
+}
+
+func TestInit_PassphraseFallback(t *testing.T) {
+	useKeychain(t, nil, ErrNoKeychain)
+	dir := t.TempDir()
+
+	t.Setenv(PassphraseEnv, "")
+	if _, err := Init(dir, ""); err == nil || !strings.Contains(err.Error(), PassphraseEnv) {
+		t.Fatalf("Init() without keychain or passphrase error = %v", err)
+	}
+
+	t.Setenv(PassphraseEnv, "hunter2")
+	where, err := Init(dir, "")
+	if err != nil || where != "passphrase" {
+		t.Fatalf("Init() = %q, %v; want passphrase", where, err)
+	}
+	key, _ := Key(dir)
+
+	forgetKeys(t)
+	t.Setenv(PassphraseEnv, "wrong")
+	if _, err := Key(dir); err == nil {
+		t.Fatal("Key() with the wrong passphrase succeeded")
+	}
+
+	t.Setenv(PassphraseEnv, "hunter2")
+	got, err := Key(dir)
+	if err != nil || string(got) != string(key) {
+		t.Fatalf("Key() = %x, %v; want %x", got, err, key)
+	}
+}
+
+func TestSealFor_PlainVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "codex", "work", "auth.json")
+	got, err := SealFor(path, []byte("x"))
+	if err != nil || string(got) != "x" {
+		t.Errorf("SealFor() outside an encrypted vault = %q, %v", got, err)
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	kc := memKeychain{}
+	useKeychain(t, kc, nil)
+
+	if _, err := StoreSecret("api-key:claude/work", []byte("sk-ant-test")); err != nil {
+		t.Fatalf("StoreSecret() error = %v", err)
+	}
+	got, err := LoadSecret("api-key:claude/work", "")
+	if err != nil || string(got) != "sk-ant-test" {
+		t.Fatalf("LoadSecret() = %q, %v", got, err)
+	}
+	if err := DeleteSecret("api-key:claude/work"); err != nil {
+		t.Fatalf("DeleteSecret() error = %v", err)
+	}
+	if _, err := LoadSecret("api-key:claude/work", ""); err == nil {
+		t.Error("LoadSecret() after delete: want error")
+	}
+
+	useKeychain(t, nil, ErrNoKeychain)
+	if _, err := StoreSecret("x", []byte("y")); !errors.Is(err, ErrNoKeychain) {
+		t.Errorf("StoreSecret() without keychain: error = %v", err)
+	}
+}
+
+// fakeSecurityKey derives hmac-secret output from a per-device secret, as a
+// FIDO2 authenticator does.
+type fakeSecurityKey struct {
+	secret []byte
+	creds  [][]byte
+}
+
+func (*fakeSecurityKey) Name() string { return "test security key" }
+
+func (f *fakeSecurityKey) Enroll(rpID, user string) ([]byte, error) {
+	id := []byte(fmt.Sprintf("cred-%d", len(f.creds)))
+	f.creds = append(f.creds, id)
+	return id, nil
+}
+
+func (f *fakeSecurityKey) HMACSecret(rpID string, credID, salt []byte) ([]byte, error) {
+	if !slices.ContainsFunc(f.creds, func(c []byte) bool { return bytes.Equal(c, credID) }) {
+		return nil, errors.New("credential not found")
+	}
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(credID)
+	mac.Write(salt)
+	return mac.Sum(nil), nil
+}
+
+func useSecurityKey(t *testing.T, sk SecurityKey, err error) {
+	t.Helper()
+	old := systemSecurityKey
+	systemSecurityKey = func() (SecurityKey, error) { return sk, err }
+	t.Cleanup(func() { systemSecurityKey = old })
+}
+
+func TestEnrollSecurityKey(t *testing.T) {
+	kc := memKeychain{}
+	useKeychain(t, kc, nil)
+	sk := &fakeSecurityKey{secret: []byte("device")}
+	useSecurityKey(t, sk, nil)
+	t.Setenv(RecoveryCodeEnv, "")
+	dir := t.TempDir()
+
+	if _, err := Init(dir, ""); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	key, _ := Key(dir)
+
+	codes, err := EnrollSecurityKey(dir, "", false, false)
+	if err != nil {
+		t.Fatalf("EnrollSecurityKey() error = %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("EnrollSecurityKey() returned %d recovery codes", len(codes))
+	}
+	if where, _ := Describe(dir); where != "test keychain, 1 security key, 8 recovery codes" {
+		t.Errorf("Describe() = %q", where)
+	}
+
+	// A second key keeps the recovery codes unless asked for new ones.
+	if again, err := EnrollSecurityKey(dir, "spare", false, false); err != nil || again != nil {
+		t.Fatalf("second EnrollSecurityKey() = %v, %v", again, err)
+	}
+
+	// With only, the keychain entry goes and the security key unlocks.
+	if _, err := EnrollSecurityKey(dir, "", true, false); err != nil {
+		t.Fatalf("EnrollSecurityKey(only) error = %v", err)
+	}
+	if len(kc) != 0 {
+		t.Error("EnrollSecurityKey(only) left the key in the keychain")
+	}
+	forgetKeys(t)
+	if got, err := Key(dir); err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("Key() with security key = %x, %v; want %x", got, err, key)
+	}
+
+	// Another device is not enrolled, so it takes a recovery code, which
+	// works only once.
+	useSecurityKey(t, &fakeSecurityKey{secret: []byte("other")}, nil)
+	forgetKeys(t)
+	if _, err := Key(dir); err == nil {
+		t.Fatal("Key() with an unenrolled security key succeeded")
+	}
+	t.Setenv(RecoveryCodeEnv, strings.ToUpper(codes[3]))
+	if got, err := Key(dir); err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("Key() with recovery code = %x, %v; want %x", got, err, key)
+	}
+	forgetKeys(t)
+	if _, err := Key(dir); err == nil {
+		t.Fatal("Key() reused a recovery code")
+	}
+	if where, _ := Describe(dir); where != "3 security keys, 7 recovery codes" {
+		t.Errorf("Describe() = %q", where)
+	}
+
+	useSecurityKey(t, nil, ErrNoSecurityKey)
+	t.Setenv(RecoveryCodeEnv, codes[0])
+	forgetKeys(t)
+	if got, err := Key(dir); err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("Key() without a security key = %x, %v; want %x", got, err, key)
+	}
+}
+
+func TestEnrollSecurityKey_PassphraseStillWorks(t *testing.T) {
+	useKeychain(t, nil, ErrNoKeychain)
+	useSecurityKey(t, &fakeSecurityKey{secret: []byte("device")}, nil)
+	t.Setenv(PassphraseEnv, "hunter2")
+	dir := t.TempDir()
+
+	if _, err := Init(dir, ModePassphrase); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	key, _ := Key(dir)
+	if _, err := EnrollSecurityKey(dir, "yubikey", false, false); err != nil {
+		t.Fatalf("EnrollSecurityKey() error = %v", err)
+	}
+
+	forgetKeys(t)
+	if got, err := Key(dir); err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("Key() with passphrase = %x, %v", got, err)
+	}
+	// Without the passphrase, the security key unlocks.
+	t.Setenv(PassphraseEnv, "")
+	forgetKeys(t)
+	if got, err := Key(dir); err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("Key() with security key = %x, %v", got, err)
+	}
+}
@@ -0,0 +1,75 @@
+package vaultfs
+
+import (
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// Conflict is a conflict copy a sync tool left next to a vault file.
+type Conflict struct {
+	// Path is the conflict copy.
+	Path string `json:"path"`
+
+	// Original is the file the copy conflicts with. It may not exist if the
+	// sync tool renamed the only copy.
+	Original string `json:"original"`
+
+	// SyncTool names the tool whose naming scheme matched.
+	SyncTool string `json:"sync_tool"`
+}
+
+var conflictPatterns = []struct {
+	re   *regexp.Regexp
+	tool string
+}{
+	// Dropbox and Nextcloud: "auth (conflicted copy).json",
+	// "auth (laptop's conflicted copy 2024-01-02).json".
+	{regexp.MustCompile(`^(.+?) \([^()]*conflicted copy[^()]*\)(\.[^.]+)?$`), "Dropbox"},
+	// Syncthing: "auth.sync-conflict-20240102-150405-ABCDEF1.json".
+	{regexp.MustCompile(`^(.+?)\.sync-conflict-\d{8}-\d{6}(?:-[A-Z0-9]+)?(\.[^.]+)?$`), "Syncthing"},
+}
+
+// ParseConflictName reports whether name is a sync-tool conflict copy and,
+// if so, the name of the file it conflicts with.
+func ParseConflictName(name string) (original, syncTool string, ok bool) {
+	for _, p := range conflictPatterns {
+		if m := p.re.FindStringSubmatch(name); m != nil {
+			return m[1] + m[2], p.tool, true
+		}
+	}
+	return "", "", false
+}
+
+// FindConflicts walks root and returns every conflict copy under it, sorted
+// by path.
+func FindConflicts(root string) ([]Conflict, error) {
+	var conflicts []Conflict
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		original, tool, ok := ParseConflictName(d.Name())
+		if !ok {
+			return nil
+		}
+		conflicts = append(conflicts, Conflict{
+			Path:     path,
+			Original: filepath.Join(filepath.Dir(path), original),
+			SyncTool: tool,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Path < conflicts[j].Path })
+	return conflicts, nil
+}
@@ -0,0 +1,33 @@
+//go:build darwin
+
+package vaultfs
+
+import (
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+func fsTypeOf(path string) (string, Kind, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return "", KindLocal, err
+	}
+	name := unix.ByteSliceToString(st.Fstypename[:])
+	switch {
+	case name == "nfs" || name == "smbfs" || name == "afpfs" || name == "webdav" || name == "cifs":
+		return name, KindNetwork, nil
+	case strings.Contains(name, "fuse"):
+		return name, KindFUSE, nil
+	default:
+		return name, KindLocal, nil
+	}
+}
+
+func freeSpaceOf(path string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return st.Bavail * uint64(st.Bsize), nil
+}
@@ -0,0 +1,39 @@
+//go:build linux
+
+package vaultfs
+
+import "golang.org/x/sys/unix"
+
+// Filesystem magic numbers from statfs(2).
+var linuxFSTypes = map[uint32]struct {
+	name string
+	kind Kind
+}{
+	0x6969:     {"nfs", KindNetwork},
+	0x517b:     {"smb", KindNetwork},
+	0xff534d42: {"cifs", KindNetwork},
+	0xfe534d42: {"smb2", KindNetwork},
+	0x01021997: {"9p", KindNetwork},
+	0x00c36400: {"ceph", KindNetwork},
+	0x5346414f: {"afs", KindNetwork},
+	0x65735546: {"fuse", KindFUSE},
+}
+
+func fsTypeOf(path string) (string, Kind, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return "", KindLocal, err
+	}
+	if t, ok := linuxFSTypes[uint32(st.Type)]; ok {
+		return t.name, t.kind, nil
+	}
+	return "local", KindLocal, nil
+}
+
+func freeSpaceOf(path string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return st.Bavail * uint64(st.Bsize), nil
+}
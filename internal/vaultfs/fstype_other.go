@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !windows
+
+package vaultfs
+
+import "errors"
+
+// fsTypeOf can't identify filesystems on this platform; assume local.
+func fsTypeOf(path string) (string, Kind, error) {
+	return "unknown", KindLocal, nil
+}
+
+// freeSpaceOf can't measure free space on this platform.
+func freeSpaceOf(path string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package vaultfs
+
+import (
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+func fsTypeOf(path string) (string, Kind, error) {
+	volume := filepath.VolumeName(path)
+	if strings.HasPrefix(volume, `\\`) {
+		return "smb", KindNetwork, nil
+	}
+	root, err := windows.UTF16PtrFromString(volume + `\`)
+	if err != nil {
+		return "", KindLocal, err
+	}
+	if windows.GetDriveType(root) == windows.DRIVE_REMOTE {
+		return "smb", KindNetwork, nil
+	}
+	return "local", KindLocal, nil
+}
+
+func freeSpaceOf(path string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &free, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
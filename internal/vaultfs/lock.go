@@ -0,0 +1,186 @@
+package vaultfs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LockFileName is the lock file kept at the vault root.
+const LockFileName = ".caam-vault.lock"
+
+const (
+	// DefaultLockTimeout bounds how long a writer waits for the vault lock.
+	DefaultLockTimeout = 10 * time.Second
+
+	// StaleLockAge is how old an exclusive lock file must be before another
+	// writer may break it. Vault writes take milliseconds, so a lock this
+	// old belongs to a crashed process or a host that went away.
+	StaleLockAge = 2 * time.Minute
+
+	lockPollInterval = 100 * time.Millisecond
+)
+
+// LockOwner identifies the process holding an exclusive vault lock.
+type LockOwner struct {
+	Host       string    `json:"host"`
+	PID        int       `json:"pid"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// LockHeldError is returned when the vault lock could not be acquired in
+// time because another writer holds it.
+type LockHeldError struct {
+	Path  string
+	Owner *LockOwner
+}
+
+func (e *LockHeldError) Error() string {
+	if e.Owner == nil {
+		return fmt.Sprintf("vault is locked by another writer (%s)", e.Path)
+	}
+	return fmt.Sprintf("vault is locked by %s (pid %d) since %s (%s)",
+		e.Owner.Host, e.Owner.PID, e.Owner.AcquiredAt.Format(time.RFC3339), e.Path)
+}
+
+// Lock is a held vault write lock.
+type Lock struct {
+	release func() error
+}
+
+// Unlock releases the lock. It is safe to call more than once.
+func (l *Lock) Unlock() error {
+	if l == nil || l.release == nil {
+		return nil
+	}
+	release := l.release
+	l.release = nil
+	return release()
+}
+
+// Acquire takes the vault write lock in dir using strategy, waiting up to
+// timeout for a competing writer to finish.
+func Acquire(dir string, strategy LockStrategy, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create vault dir: %w", err)
+	}
+	path := filepath.Join(dir, LockFileName)
+	if strategy == LockExclusiveFile {
+		return acquireExclusive(path, timeout)
+	}
+	return acquireFlock(path, timeout)
+}
+
+func acquireFlock(path string, timeout time.Duration) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open vault lock: %w", err)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryFlock(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errWouldBlock) || time.Now().After(deadline) {
+			f.Close()
+			if errors.Is(err, errWouldBlock) {
+				return nil, &LockHeldError{Path: path}
+			}
+			return nil, fmt.Errorf("lock vault: %w", err)
+		}
+		time.Sleep(lockPollInterval)
+	}
+	return &Lock{release: func() error {
+		unlockFlock(f)
+		return f.Close()
+	}}, nil
+}
+
+// acquireExclusive creates the lock file with O_EXCL, which is atomic on
+// NFSv3+ and SMB, and records the owner so a stale lock can be identified
+// and broken.
+func acquireExclusive(path string, timeout time.Duration) (*Lock, error) {
+	host, _ := os.Hostname()
+	deadline := time.Now().Add(timeout)
+
+	for {
+		owner := LockOwner{Host: host, PID: os.Getpid(), AcquiredAt: time.Now().UTC()}
+		err := createLockFile(path, owner)
+		if err == nil {
+			return &Lock{release: func() error { return releaseExclusive(path, owner) }}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("create vault lock: %w", err)
+		}
+
+		current, age := readLockFile(path)
+		if age > StaleLockAge {
+			// Break the stale lock and retry immediately.
+			if rmErr := os.Remove(path); rmErr != nil && !os.IsNotExist(rmErr) {
+				return nil, fmt.Errorf("remove stale vault lock: %w", rmErr)
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, &LockHeldError{Path: path, Owner: current}
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+func createLockFile(path string, owner LockOwner) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(owner)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return SyncDir(filepath.Dir(path))
+}
+
+// readLockFile returns the recorded owner (nil if unreadable) and the lock's
+// age. An unreadable lock is aged by its modification time.
+func readLockFile(path string) (*LockOwner, time.Duration) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var owner LockOwner
+		if json.Unmarshal(data, &owner) == nil && !owner.AcquiredAt.IsZero() {
+			return &owner, time.Since(owner.AcquiredAt)
+		}
+	}
+	if fi, statErr := os.Stat(path); statErr == nil {
+		return nil, time.Since(fi.ModTime())
+	}
+	return nil, 0
+}
+
+// releaseExclusive removes the lock file only if it still belongs to owner,
+// so a writer whose lock was broken as stale can't delete its successor's.
+func releaseExclusive(path string, owner LockOwner) error {
+	current, _ := readLockFile(path)
+	if current == nil || current.Host != owner.Host || current.PID != owner.PID ||
+		!current.AcquiredAt.Equal(owner.AcquiredAt) {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("release vault lock: %w", err)
+	}
+	return nil
+}
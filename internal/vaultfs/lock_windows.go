@@ -0,0 +1,23 @@
+//go:build windows
+
+package vaultfs
+
+import (
+	"errors"
+	"os"
+)
+
+var errWouldBlock = errors.New("lock would block")
+
+// tryFlock is a no-op on Windows; vaults there use the exclusive lock file
+// on network shares and rely on sharing modes locally.
+func tryFlock(f *os.File) error {
+	return nil
+}
+
+func unlockFlock(f *os.File) {}
+
+// SyncDir is a no-op on Windows, where directories can't be fsynced.
+func SyncDir(dir string) error {
+	return nil
+}
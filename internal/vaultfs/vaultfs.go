@@ -0,0 +1,183 @@
+// Package vaultfs adapts vault writes to the filesystem the vault lives on.
+//
+// A vault on a local disk can rely on flock and plain atomic renames. Vaults
+// on NFS or SMB shares need a lock that works across hosts and an explicit
+// directory fsync so renames reach the server. Vaults inside a
+// Syncthing/Dropbox-style synced folder are replicated without any locking
+// at all, so two machines writing the same vault will eventually conflict;
+// those setups are detected and reported rather than silently allowed.
+package vaultfs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kind classifies the filesystem holding a vault.
+type Kind string
+
+const (
+	// KindLocal is a local disk filesystem.
+	KindLocal Kind = "local"
+
+	// KindNetwork is a network filesystem with server-side consistency
+	// (NFS, SMB/CIFS, AFP, 9p, Ceph).
+	KindNetwork Kind = "network"
+
+	// KindFUSE is a userspace filesystem (sshfs, rclone mount, ...) whose
+	// locking and durability guarantees are unknown.
+	KindFUSE Kind = "fuse"
+
+	// KindSynced is a folder replicated by a file sync tool (Syncthing,
+	// Dropbox, OneDrive, iCloud Drive, Google Drive).
+	KindSynced Kind = "synced"
+)
+
+// LockStrategy is how vault writers exclude each other.
+type LockStrategy string
+
+const (
+	// LockFlock uses an advisory flock on a lock file. Reliable on local
+	// disks only.
+	LockFlock LockStrategy = "flock"
+
+	// LockExclusiveFile creates the lock file with O_EXCL and records the
+	// owning host and pid, so it also works across NFS/SMB clients.
+	LockExclusiveFile LockStrategy = "lockfile"
+)
+
+// Info describes the filesystem a vault lives on.
+type Info struct {
+	Path     string `json:"path"`
+	FSType   string `json:"fs_type"`
+	Kind     Kind   `json:"kind"`
+	SyncTool string `json:"sync_tool,omitempty"`
+}
+
+// Detect inspects the filesystem holding path. The path need not exist yet;
+// the nearest existing ancestor is examined.
+func Detect(path string) Info {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	info := Info{Path: abs, FSType: "unknown", Kind: KindLocal}
+
+	existing := nearestExisting(abs)
+	if fsType, kind, err := fsTypeOf(existing); err == nil {
+		info.FSType = fsType
+		info.Kind = kind
+	}
+
+	// A synced folder is the bigger hazard, whatever the filesystem under it.
+	if tool := detectSyncTool(abs); tool != "" {
+		info.Kind = KindSynced
+		info.SyncTool = tool
+	}
+	return info
+}
+
+// FreeSpace returns the bytes available to unprivileged users on the
+// filesystem holding path. Like Detect, it examines the nearest existing
+// ancestor when path does not exist yet.
+func FreeSpace(path string) (uint64, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return freeSpaceOf(nearestExisting(abs))
+}
+
+// Locking returns the lock strategy for vault writes.
+func (i Info) Locking() LockStrategy {
+	if i.Kind == KindLocal {
+		return LockFlock
+	}
+	return LockExclusiveFile
+}
+
+// NeedsDirSync reports whether the parent directory must be fsynced after a
+// rename for the rename to be durable and visible to other clients.
+func (i Info) NeedsDirSync() bool {
+	return i.Kind != KindLocal
+}
+
+// Supported reports whether caam can safely share this vault between
+// concurrent writers.
+func (i Info) Supported() bool {
+	return i.Kind == KindLocal || i.Kind == KindNetwork
+}
+
+// Warning explains the risk of an unsupported or degraded setup, or returns
+// "" when there is nothing to warn about.
+func (i Info) Warning() string {
+	switch i.Kind {
+	case KindSynced:
+		return "vault is inside a " + i.SyncTool + " folder; the sync tool replicates files without locks, so running caam on more than one machine against it can corrupt profiles. Keep the vault on a local disk and use 'caam sync' instead"
+	case KindFUSE:
+		return "vault is on a FUSE filesystem (" + i.FSType + "); locking and fsync guarantees are unknown. Avoid concurrent caam writers on this vault"
+	default:
+		return ""
+	}
+}
+
+// nearestExisting returns path or its closest existing ancestor.
+func nearestExisting(path string) string {
+	for p := path; ; p = filepath.Dir(p) {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+		if parent := filepath.Dir(p); parent == p {
+			return p
+		}
+	}
+}
+
+// syncMarkers are files or directories a sync tool keeps at the root of a
+// synced folder.
+var syncMarkers = []struct {
+	name string
+	tool string
+}{
+	{".stfolder", "Syncthing"},
+	{".dropbox", "Dropbox"},
+	{".dropbox.cache", "Dropbox"},
+}
+
+// syncPathHints are path fragments that identify well-known sync roots
+// that don't leave marker files.
+var syncPathHints = []struct {
+	fragment string
+	tool     string
+}{
+	{"/Library/Mobile Documents/", "iCloud Drive"},
+	{"/Library/CloudStorage/OneDrive", "OneDrive"},
+	{"/Library/CloudStorage/GoogleDrive", "Google Drive"},
+	{"/Library/CloudStorage/Dropbox", "Dropbox"},
+	{"/OneDrive/", "OneDrive"},
+	{"/OneDrive - ", "OneDrive"},
+	{"/Google Drive/", "Google Drive"},
+	{"/Dropbox/", "Dropbox"},
+}
+
+// detectSyncTool returns the sync tool managing path, if any.
+func detectSyncTool(path string) string {
+	slashed := filepath.ToSlash(path) + "/"
+	for _, hint := range syncPathHints {
+		if strings.Contains(slashed, hint.fragment) {
+			return hint.tool
+		}
+	}
+
+	for dir := path; ; dir = filepath.Dir(dir) {
+		for _, m := range syncMarkers {
+			if _, err := os.Lstat(filepath.Join(dir, m.name)); err == nil {
+				return m.tool
+			}
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return ""
+		}
+	}
+}
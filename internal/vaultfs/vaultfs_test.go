@@ -0,0 +1,206 @@
+package vaultfs
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDetectSyncMarker(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".stfolder"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	info := Detect(filepath.Join(root, "caam", "vault"))
+	if info.Kind != KindSynced || info.SyncTool != "Syncthing" {
+		t.Fatalf("Detect() = %+v, want synced/Syncthing", info)
+	}
+	if info.Supported() {
+		t.Error("synced vault should not be supported")
+	}
+	if info.Locking() != LockExclusiveFile {
+		t.Errorf("Locking() = %s, want %s", info.Locking(), LockExclusiveFile)
+	}
+	if info.Warning() == "" {
+		t.Error("expected a warning for a synced vault")
+	}
+}
+
+func TestDetectSyncPathHint(t *testing.T) {
+	tests := []struct {
+		path string
+		tool string
+	}{
+		{"/Users/me/Library/Mobile Documents/com~apple~CloudDocs/caam", "iCloud Drive"},
+		{"/home/me/Dropbox/caam/vault", "Dropbox"},
+		{"/home/me/OneDrive - Contoso/caam", "OneDrive"},
+		{"/home/me/.local/share/caam/vault", ""},
+	}
+	for _, tt := range tests {
+		if got := detectSyncTool(tt.path); got != tt.tool {
+			t.Errorf("detectSyncTool(%q) = %q, want %q", tt.path, got, tt.tool)
+		}
+	}
+}
+
+func TestDetectLocal(t *testing.T) {
+	info := Detect(t.TempDir())
+	if info.Kind == KindSynced {
+		t.Fatalf("temp dir detected as synced: %+v", info)
+	}
+	if info.Kind == KindLocal && (info.Locking() != LockFlock || info.NeedsDirSync()) {
+		t.Errorf("local vault should use flock without dir sync: %+v", info)
+	}
+}
+
+func TestFreeSpace(t *testing.T) {
+	free, err := FreeSpace(filepath.Join(t.TempDir(), "not", "yet", "created"))
+	if err != nil {
+		t.Skipf("FreeSpace() unsupported here: %v", err)
+	}
+	if free == 0 {
+		t.Error("FreeSpace() = 0 for the temp dir")
+	}
+}
+
+func TestExclusiveLock(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := Acquire(dir, LockExclusiveFile, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	_, err = Acquire(dir, LockExclusiveFile, 200*time.Millisecond)
+	var held *LockHeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("second Acquire() error = %v, want LockHeldError", err)
+	}
+	if held.Owner == nil || held.Owner.PID != os.Getpid() {
+		t.Errorf("LockHeldError owner = %+v, want this process", held.Owner)
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, LockFileName)); !os.IsNotExist(err) {
+		t.Errorf("lock file still present after Unlock: %v", err)
+	}
+
+	lock, err = Acquire(dir, LockExclusiveFile, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() after Unlock error = %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestExclusiveLockBreaksStale(t *testing.T) {
+	dir := t.TempDir()
+	stale := LockOwner{Host: "other-host", PID: 1, AcquiredAt: time.Now().Add(-2 * StaleLockAge)}
+	data, _ := json.Marshal(stale)
+	if err := os.WriteFile(filepath.Join(dir, LockFileName), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := Acquire(dir, LockExclusiveFile, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire() over stale lock error = %v", err)
+	}
+	defer lock.Unlock()
+
+	owner, _ := readLockFile(filepath.Join(dir, LockFileName))
+	if owner == nil || owner.PID != os.Getpid() {
+		t.Errorf("lock owner = %+v, want this process", owner)
+	}
+}
+
+func TestExclusiveLockReleaseKeepsSuccessor(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, LockFileName)
+
+	lock, err := Acquire(dir, LockExclusiveFile, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate another writer breaking our lock as stale and taking over.
+	successor := LockOwner{Host: "other-host", PID: 42, AcquiredAt: time.Now().UTC()}
+	data, _ := json.Marshal(successor)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("successor's lock was removed: %v", err)
+	}
+}
+
+func TestFlockLock(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := Acquire(dir, LockFlock, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("second Unlock() error = %v", err)
+	}
+}
+
+func TestParseConflictName(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		tool     string
+		ok       bool
+	}{
+		{"auth (conflicted copy).json", "auth.json", "Dropbox", true},
+		{"auth (laptop's conflicted copy 2024-01-02).json", "auth.json", "Dropbox", true},
+		{".claude (conflicted copy 2024-01-02 101010).json", ".claude.json", "Dropbox", true},
+		{"auth.sync-conflict-20240102-150405-ABCDEF1.json", "auth.json", "Syncthing", true},
+		{".credentials.sync-conflict-20240102-150405-ABCDEF1.json", ".credentials.json", "Syncthing", true},
+		{"meta.sync-conflict-20240102-150405.json", "meta.json", "Syncthing", true},
+		{"auth.json", "", "", false},
+		{"auth (1).json", "", "", false},
+	}
+	for _, tt := range tests {
+		original, tool, ok := ParseConflictName(tt.name)
+		if ok != tt.ok || original != tt.original || tool != tt.tool {
+			t.Errorf("ParseConflictName(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				tt.name, original, tool, ok, tt.original, tt.tool, tt.ok)
+		}
+	}
+}
+
+func TestFindConflicts(t *testing.T) {
+	root := t.TempDir()
+	profileDir := filepath.Join(root, "codex", "work")
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"auth.json", "auth (conflicted copy).json", "meta.json"} {
+		if err := os.WriteFile(filepath.Join(profileDir, name), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conflicts, err := FindConflicts(root)
+	if err != nil {
+		t.Fatalf("FindConflicts() error = %v", err)
+	}
+	if len(conflicts) != 1 {
+		t.Fatalf("FindConflicts() = %+v, want 1 conflict", conflicts)
+	}
+	if conflicts[0].Original != filepath.Join(profileDir, "auth.json") {
+		t.Errorf("Original = %q", conflicts[0].Original)
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/i18n"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 )
@@ -159,7 +160,7 @@ func (c *Checker) checkVaultProfile(ctx context.Context, tool, profileName strin
 			Level:   LevelCritical,
 			Tool:    tool,
 			Profile: profileName,
-			Message: "Token EXPIRED",
+			Message: i18n.T("warning.token_expired"),
 			Action:  fmt.Sprintf("caam login %s %s", tool, profileName),
 		})
 	} else if remaining <= c.CriticalThreshold {
@@ -168,7 +169,7 @@ func (c *Checker) checkVaultProfile(ctx context.Context, tool, profileName strin
 			Level:   LevelCritical,
 			Tool:    tool,
 			Profile: profileName,
-			Message: fmt.Sprintf("Token expires in %s", formatDuration(remaining)),
+			Message: i18n.T("reason.token_expires_in", formatDuration(remaining)),
 			Action:  fmt.Sprintf("caam refresh %s %s", tool, profileName),
 		})
 	} else if remaining <= c.WarningThreshold {
@@ -177,7 +178,7 @@ func (c *Checker) checkVaultProfile(ctx context.Context, tool, profileName strin
 			Level:   LevelWarning,
 			Tool:    tool,
 			Profile: profileName,
-			Message: fmt.Sprintf("Token expires in %s", formatDuration(remaining)),
+			Message: i18n.T("reason.token_expires_in", formatDuration(remaining)),
 			Action:  fmt.Sprintf("caam refresh %s %s", tool, profileName),
 		})
 	}
@@ -187,28 +188,7 @@ func (c *Checker) checkVaultProfile(ctx context.Context, tool, profileName strin
 
 // formatDuration formats a duration in a human-friendly way.
 func formatDuration(d time.Duration) string {
-	if d < time.Minute {
-		return "less than a minute"
-	}
-	if d < time.Hour {
-		mins := int(d.Minutes())
-		if mins == 1 {
-			return "1 minute"
-		}
-		return fmt.Sprintf("%d minutes", mins)
-	}
-	if d < 24*time.Hour {
-		hours := int(d.Hours())
-		if hours == 1 {
-			return "1 hour"
-		}
-		return fmt.Sprintf("%d hours", hours)
-	}
-	days := int(d.Hours() / 24)
-	if days == 1 {
-		return "1 day"
-	}
-	return fmt.Sprintf("%d days", days)
+	return i18n.Duration(d)
 }
 
 // Print prints warnings to the given writer.
@@ -21,6 +21,7 @@ import (
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/events"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/ratelimit"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
@@ -163,6 +164,9 @@ type Result struct {
 	// RateLimitHit is true if a rate limit was detected.
 	RateLimitHit bool
 
+	// Revoked lists profiles quarantined because their token was revoked.
+	Revoked []string
+
 	// RetryCount is how many retries were attempted.
 	RetryCount int
 
@@ -253,28 +257,39 @@ func (w *Wrapper) Run(ctx context.Context) *Result {
 		}
 
 		// Run the command
-		exitCode, rateLimitHit, runErr := w.runOnce(ctx, currentProfile)
+		exitCode, rateLimitHit, revokedReason, runErr := w.runOnce(ctx, currentProfile)
 		result.ExitCode = exitCode
 
-		if runErr != nil && !rateLimitHit {
+		if runErr != nil && !rateLimitHit && revokedReason == "" {
 			result.Err = runErr
 			return result
 		}
 
-		// Check if rate limit was hit
-		if rateLimitHit {
-			result.RateLimitHit = true
+		// Check if rate limit was hit or the token is dead
+		if rateLimitHit || revokedReason != "" {
 			result.RetryCount++
 
-			// Record cooldown
-			if w.db != nil {
-				w.db.SetCooldown(
-					w.config.Provider,
-					currentProfile,
-					time.Now(),
-					w.config.CooldownDuration,
-					"auto-detected via caam wrap",
-				)
+			if revokedReason != "" {
+				// A revoked token needs a fresh login, not a cooldown.
+				result.Revoked = append(result.Revoked, currentProfile)
+				if w.db != nil {
+					w.db.MarkRevoked(w.config.Provider, currentProfile, time.Now(), revokedReason, "wrap")
+				}
+				fmt.Fprintf(w.config.Stderr, "⚠️  Token for '%s' was revoked (%s); quarantined. Log in again, then run: caam backup %s %s\n",
+					currentProfile, revokedReason, w.config.Provider, currentProfile)
+			} else {
+				result.RateLimitHit = true
+
+				// Record cooldown
+				if w.db != nil {
+					w.db.SetCooldown(
+						w.config.Provider,
+						currentProfile,
+						time.Now(),
+						w.config.CooldownDuration,
+						"auto-detected via caam wrap",
+					)
+				}
 			}
 
 			// Check if we can retry
@@ -321,18 +336,20 @@ func (w *Wrapper) Run(ctx context.Context) *Result {
 }
 
 // runOnce executes the command once with the given profile.
-// Returns exit code, whether rate limit was hit, and any error.
-func (w *Wrapper) runOnce(ctx context.Context, profile string) (int, bool, error) {
+// Returns exit code, whether rate limit was hit, the revoked-token fingerprint
+// that matched (if any), and any error.
+func (w *Wrapper) runOnce(ctx context.Context, profile string) (int, bool, string, error) {
 	// Get auth file set for this provider
 	fileSet, ok := AuthFileSetForProvider(w.config.Provider)
 	if !ok {
-		return 1, false, fmt.Errorf("unknown provider: %s", w.config.Provider)
+		return 1, false, "", fmt.Errorf("unknown provider: %s", w.config.Provider)
 	}
 
 	// Activate the profile (restore auth files)
-	if err := w.vault.Restore(fileSet, profile); err != nil {
-		return 1, false, fmt.Errorf("activate profile %s: %w", profile, err)
+	if err := w.vault.Activate(fileSet, profile, "wrap"); err != nil {
+		return 1, false, "", fmt.Errorf("activate profile %s: %w", profile, err)
 	}
+	events.PublishActivated(w.config.Provider, profile, "wrap")
 
 	// Create rate limit detector
 	detector, err := ratelimit.NewDetector(
@@ -340,7 +357,7 @@ func (w *Wrapper) runOnce(ctx context.Context, profile string) (int, bool, error
 		w.config.CustomPatterns,
 	)
 	if err != nil {
-		return 1, false, fmt.Errorf("create detector: %w", err)
+		return 1, false, "", fmt.Errorf("create detector: %w", err)
 	}
 
 	// Build command
@@ -407,7 +424,7 @@ func (w *Wrapper) runOnce(ctx context.Context, profile string) (int, bool, error
 		}
 	}
 
-	return exitCode, rateLimitHit, nil
+	return exitCode, rateLimitHit, detector.RevokedReason(), nil
 }
 
 // maxBufferSize is the maximum buffer size before forcing a flush (64KB).
@@ -561,7 +561,7 @@ func TestWrapper_RunOnce_UnknownProvider(t *testing.T) {
 
 	w := NewWrapper(vault, nil, nil, cfg)
 
-	exitCode, rateLimitHit, err := w.runOnce(context.Background(), "test")
+	exitCode, rateLimitHit, _, err := w.runOnce(context.Background(), "test")
 
 	if err == nil {
 		t.Error("Expected error for unknown provider")